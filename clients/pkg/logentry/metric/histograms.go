@@ -4,16 +4,27 @@ import (
 	"time"
 
 	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 )
 
+const (
+	ErrHistogramInvalidBuckets = "histogram buckets must be in strictly increasing order"
+)
+
 type HistogramConfig struct {
 	Value   *string   `mapstructure:"value"`
 	Buckets []float64 `mapstructure:"buckets"`
 }
 
 func validateHistogramConfig(config *HistogramConfig) error {
+	// prometheus.NewHistogram panics on buckets which are not strictly increasing.
+	for i := 1; i < len(config.Buckets); i++ {
+		if config.Buckets[i] <= config.Buckets[i-1] {
+			return errors.New(ErrHistogramInvalidBuckets)
+		}
+	}
 	return nil
 }
 
@@ -4,10 +4,54 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 )
 
+func Test_validateHistogramConfig(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		config HistogramConfig
+		err    error
+	}{
+		{"default buckets",
+			HistogramConfig{},
+			nil,
+		},
+		{"valid buckets",
+			HistogramConfig{
+				Buckets: []float64{0.1, 0.5, 1, 5},
+			},
+			nil,
+		},
+		{"unsorted buckets",
+			HistogramConfig{
+				Buckets: []float64{1, 0.5},
+			},
+			errors.New(ErrHistogramInvalidBuckets),
+		},
+		{"duplicate buckets",
+			HistogramConfig{
+				Buckets: []float64{0.5, 1, 1},
+			},
+			errors.New(ErrHistogramInvalidBuckets),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateHistogramConfig(&tt.config)
+			if ((err != nil) && (err.Error() != tt.err.Error())) || (err == nil && tt.err != nil) {
+				t.Errorf("Metrics stage validation error, expected error = %v, actual error = %v", tt.err, err)
+				return
+			}
+		})
+	}
+}
+
 func TestHistogramExpiration(t *testing.T) {
 	t.Parallel()
 	cfg := HistogramConfig{}
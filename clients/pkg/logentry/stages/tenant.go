@@ -14,8 +14,8 @@ import (
 )
 
 const (
-	ErrTenantStageEmptySourceOrValue        = "source or value config are required"
-	ErrTenantStageConflictingSourceAndValue = "source and value are mutually exclusive: you should set source or value but not both"
+	ErrTenantStageEmptyLabelSourceOrValue        = "label, source or value config are required"
+	ErrTenantStageConflictingLabelSourceAndValue = "label, source and value are mutually exclusive: you should set only one of them"
 )
 
 type tenantStage struct {
@@ -24,24 +24,32 @@ type tenantStage struct {
 }
 
 type TenantConfig struct {
+	Label  string `mapstructure:"label"`
 	Source string `mapstructure:"source"`
 	Value  string `mapstructure:"value"`
 }
 
 // validateTenantConfig validates the tenant stage configuration
 func validateTenantConfig(c TenantConfig) error {
-	if c.Source == "" && c.Value == "" {
-		return errors.New(ErrTenantStageEmptySourceOrValue)
+	set := 0
+	for _, option := range []string{c.Label, c.Source, c.Value} {
+		if option != "" {
+			set++
+		}
+	}
+
+	if set == 0 {
+		return errors.New(ErrTenantStageEmptyLabelSourceOrValue)
 	}
 
-	if c.Source != "" && c.Value != "" {
-		return errors.New(ErrTenantStageConflictingSourceAndValue)
+	if set > 1 {
+		return errors.New(ErrTenantStageConflictingLabelSourceAndValue)
 	}
 
 	return nil
 }
 
-// newTenantStage creates a new tenant stage to override the tenant ID from extracted data
+// newTenantStage creates a new tenant stage to override the tenant ID from a label or extracted data
 func newTenantStage(logger log.Logger, configs interface{}) (Stage, error) {
 	cfg := TenantConfig{}
 	err := mapstructure.Decode(configs, &cfg)
@@ -64,14 +72,17 @@ func newTenantStage(logger log.Logger, configs interface{}) (Stage, error) {
 func (s *tenantStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
 	var tenantID string
 
-	// Get tenant ID from source or configured value
-	if s.cfg.Source != "" {
+	// Get tenant ID from label, source or configured value
+	switch {
+	case s.cfg.Label != "":
+		tenantID = string(labels[model.LabelName(s.cfg.Label)])
+	case s.cfg.Source != "":
 		tenantID = s.getTenantFromSourceField(extracted)
-	} else {
+	default:
 		tenantID = s.cfg.Value
 	}
 
-	// Skip an empty tenant ID (ie. failed to get the tenant from the source)
+	// Skip an empty tenant ID (ie. failed to get the tenant from the label or source)
 	if tenantID == "" {
 		return
 	}
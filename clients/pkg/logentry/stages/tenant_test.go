@@ -60,6 +60,12 @@ func TestTenantStage_Validation(t *testing.T) {
 		config      *TenantConfig
 		expectedErr *string
 	}{
+		"should pass on label config option set": {
+			config: &TenantConfig{
+				Label: "namespace",
+			},
+			expectedErr: nil,
+		},
 		"should pass on source config option set": {
 			config: &TenantConfig{
 				Source: "tenant",
@@ -74,26 +80,33 @@ func TestTenantStage_Validation(t *testing.T) {
 		},
 		"should fail on missing source and value": {
 			config:      &TenantConfig{},
-			expectedErr: lokiutil.StringRef(ErrTenantStageEmptySourceOrValue),
+			expectedErr: lokiutil.StringRef(ErrTenantStageEmptyLabelSourceOrValue),
 		},
 		"should fail on empty source": {
 			config: &TenantConfig{
 				Source: "",
 			},
-			expectedErr: lokiutil.StringRef(ErrTenantStageEmptySourceOrValue),
+			expectedErr: lokiutil.StringRef(ErrTenantStageEmptyLabelSourceOrValue),
 		},
 		"should fail on empty value": {
 			config: &TenantConfig{
 				Value: "",
 			},
-			expectedErr: lokiutil.StringRef(ErrTenantStageEmptySourceOrValue),
+			expectedErr: lokiutil.StringRef(ErrTenantStageEmptyLabelSourceOrValue),
 		},
 		"should fail on both source and value set": {
 			config: &TenantConfig{
 				Source: "tenant",
 				Value:  "team-a",
 			},
-			expectedErr: lokiutil.StringRef(ErrTenantStageConflictingSourceAndValue),
+			expectedErr: lokiutil.StringRef(ErrTenantStageConflictingLabelSourceAndValue),
+		},
+		"should fail on both label and source set": {
+			config: &TenantConfig{
+				Label:  "namespace",
+				Source: "tenant",
+			},
+			expectedErr: lokiutil.StringRef(ErrTenantStageConflictingLabelSourceAndValue),
 		},
 	}
 
@@ -153,6 +166,18 @@ func TestTenantStage_Process(t *testing.T) {
 			inputExtracted: map[string]interface{}{"tenant_id": []string{"bar"}},
 			expectedTenant: nil,
 		},
+		"should set the tenant from the label": {
+			config:         &TenantConfig{Label: "namespace"},
+			inputLabels:    model.LabelSet{"namespace": "team-a", client.ReservedLabelTenantID: "foo"},
+			inputExtracted: map[string]interface{}{},
+			expectedTenant: lokiutil.StringRef("team-a"),
+		},
+		"should not override the tenant if the label is missing": {
+			config:         &TenantConfig{Label: "namespace"},
+			inputLabels:    model.LabelSet{client.ReservedLabelTenantID: "foo"},
+			inputExtracted: map[string]interface{}{},
+			expectedTenant: lokiutil.StringRef("foo"),
+		},
 		"should set the tenant with the configured static value": {
 			config:         &TenantConfig{Value: "bar"},
 			inputLabels:    model.LabelSet{},
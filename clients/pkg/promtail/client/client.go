@@ -18,6 +18,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 	"github.com/prometheus/prometheus/promql/parser"
 
 	"github.com/grafana/loki/clients/pkg/logentry/metric"
@@ -185,12 +186,22 @@ func newClient(reg prometheus.Registerer, cfg Config, logger log.Logger) (*clien
 	if err != nil {
 		return nil, err
 	}
+	if cfg.SigV4Config != nil && (cfg.Client.BasicAuth != nil || cfg.Client.Authorization != nil || cfg.Client.OAuth2 != nil) {
+		return nil, errors.New("at most one of basic_auth, authorization, oauth2 & sigv4 must be configured")
+	}
 
 	c.client, err = config.NewClientFromConfig(cfg.Client, "promtail", config.WithHTTP2Disabled())
 	if err != nil {
 		return nil, err
 	}
 
+	if cfg.SigV4Config != nil {
+		c.client.Transport, err = sigv4.NewSigV4RoundTripper(cfg.SigV4Config, c.client.Transport)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c.client.Timeout = cfg.Timeout
 
 	// Initialize counters to 0 so the metrics are exported before the first
@@ -18,6 +18,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -493,3 +494,43 @@ func Test_Tripperware(t *testing.T) {
 	c.Stop()
 	require.True(t, called)
 }
+
+func Test_SigV4(t *testing.T) {
+	authorization := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cfg := Config{
+		URL:       flagext.URLValue{URL: serverURL},
+		BatchWait: 10 * time.Millisecond,
+		BatchSize: 10,
+		Timeout:   time.Second,
+		SigV4Config: &sigv4.SigV4Config{
+			Region:    "us-east-1",
+			AccessKey: "access-key",
+			SecretKey: config.Secret("secret-key"),
+		},
+	}
+	c, err := New(nil, cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	c.Chan() <- api.Entry{
+		Labels: model.LabelSet{"foo": "bar"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: "foo"},
+	}
+	select {
+	case auth := <-authorization:
+		require.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access-key/"), auth)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the push request")
+	}
+
+	cfg.Client.BasicAuth = &config.BasicAuth{Username: "foo", Password: "bar"}
+	_, err = New(nil, cfg, log.NewNopLogger())
+	require.Error(t, err)
+}
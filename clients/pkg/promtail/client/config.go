@@ -7,6 +7,7 @@ import (
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/sigv4"
 
 	lokiflag "github.com/grafana/loki/pkg/util/flagext"
 )
@@ -28,6 +29,8 @@ type Config struct {
 	BatchSize int
 
 	Client config.HTTPClientConfig `yaml:",inline"`
+	// Signs the push requests with AWS Signature Version 4, e.g. to push through an AWS API Gateway.
+	SigV4Config *sigv4.SigV4Config `yaml:"sigv4,omitempty"`
 
 	BackoffConfig backoff.Config `yaml:"backoff_config"`
 	// The labels to add to any time series or alerts when communicating with loki
@@ -43,6 +43,7 @@ type Config struct {
 	KafkaConfig            *KafkaTargetConfig         `yaml:"kafka,omitempty"`
 	GelfConfig             *GelfTargetConfig          `yaml:"gelf,omitempty"`
 	CloudflareConfig       *CloudflareConfig          `yaml:"cloudflare,omitempty"`
+	DockerSDConfigs        []*moby.DockerSDConfig     `yaml:"docker_sd_configs,omitempty"`
 	RelabelConfigs         []*relabel.Config          `yaml:"relabel_configs,omitempty"`
 	ServiceDiscoveryConfig ServiceDiscoveryConfig     `yaml:",inline"`
 }
@@ -163,6 +164,14 @@ type SyslogTargetConfig struct {
 	// ListenAddress is the address to listen on for syslog messages.
 	ListenAddress string `yaml:"listen_address"`
 
+	// ListenProtocol is the protocol to listen on for syslog messages, tcp or udp.
+	// Defaults to tcp.
+	ListenProtocol string `yaml:"listen_protocol"`
+
+	// SyslogFormat is the format of the syslog messages, rfc5424 or rfc3164.
+	// Defaults to rfc5424.
+	SyslogFormat string `yaml:"syslog_format"`
+
 	// IdleTimeout is the idle timeout for tcp connections.
 	IdleTimeout time.Duration `yaml:"idle_timeout"`
 
@@ -0,0 +1,45 @@
+package docker
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Docker target metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	dockerEntries prometheus.Counter
+	dockerErrors  prometheus.Counter
+	targetsActive prometheus.Gauge
+}
+
+// NewMetrics creates a new set of Docker target metrics. If reg is non-nil, the
+// metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.dockerEntries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_target_entries_total",
+		Help:      "Total number of successful entries sent by the Docker targets.",
+	})
+	m.dockerErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "docker_target_errors_total",
+		Help:      "Total number of errors reading the logs of the Docker containers.",
+	})
+	m.targetsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "docker_targets_active_total",
+		Help:      "Number of active Docker targets.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.dockerEntries,
+			m.dockerErrors,
+			m.targetsActive,
+		)
+	}
+
+	return &m
+}
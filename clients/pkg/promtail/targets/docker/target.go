@@ -0,0 +1,408 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	dockerLabel            = model.MetaLabelPrefix + "docker_"
+	dockerLabelContainerID = dockerLabel + "container_id"
+	dockerLabelLogStream   = dockerLabel + "container_log_stream"
+
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// followBackoff is the backoff between the attempts to follow the logs of a container, while it is stopped or the
+// Docker daemon is unreachable.
+var followBackoff = backoff.Config{
+	MinBackoff: 1 * time.Second,
+	MaxBackoff: 10 * time.Second,
+}
+
+// Target follows the stdout and stderr of a Docker container through the logs API of the Docker daemon. The timestamp
+// of the last entry sent of each stream is saved in the positions, and the logs are followed again from the earliest
+// of them when the logs end, e.g. when the container restarts.
+type Target struct {
+	logger           log.Logger
+	handler          api.EntryHandler
+	positions        positions.Positions
+	metrics          *Metrics
+	client           *client.Client
+	containerID      string
+	discoveredLabels model.LabelSet
+	// labels are the labels of the entries of each stream, nil for the streams dropped by relabeling.
+	labels map[string]model.LabelSet
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running *atomic.Bool
+
+	mtx   sync.Mutex
+	since map[string]time.Time // the timestamp of the last entry sent of each stream
+	err   error
+}
+
+// NewTarget starts a Target following the logs of a container with the labels of its streams.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	position positions.Positions,
+	containerID string,
+	discoveredLabels model.LabelSet,
+	streamLabels map[string]model.LabelSet,
+	client *client.Client,
+) (*Target, error) {
+	since := make(map[string]time.Time, 2)
+	for _, stream := range []string{streamStdout, streamStderr} {
+		pos, err := position.Get(positionKey(containerID, stream))
+		if err != nil {
+			return nil, err
+		}
+		if pos != 0 {
+			since[stream] = time.Unix(0, pos)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Target{
+		logger:           logger,
+		handler:          handler,
+		positions:        position,
+		metrics:          metrics,
+		client:           client,
+		containerID:      containerID,
+		discoveredLabels: discoveredLabels,
+		labels:           streamLabels,
+		ctx:              ctx,
+		cancel:           cancel,
+		running:          atomic.NewBool(false),
+		since:            since,
+	}
+	t.start()
+	return t, nil
+}
+
+// positionKey returns the key of the position of a stream of a container.
+func positionKey(containerID, stream string) string {
+	return positions.CursorKey("docker/" + containerID + "/" + stream)
+}
+
+// relabelStream returns the labels of the entries of a stream of a container, or nil if the stream is dropped.
+func relabelStream(discoveredLabels model.LabelSet, stream string, relabelConfig []*relabel.Config) model.LabelSet {
+	lbls := make(map[string]string, len(discoveredLabels)+1)
+	for k, v := range discoveredLabels {
+		lbls[string(k)] = string(v)
+	}
+	lbls[dockerLabelLogStream] = stream
+
+	processed := relabel.Process(labels.FromMap(lbls), relabelConfig...)
+	result := make(model.LabelSet, len(processed))
+	for _, l := range processed {
+		if strings.HasPrefix(l.Name, "__") {
+			continue
+		}
+		result[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func (t *Target) start() {
+	t.wg.Add(1)
+	t.running.Store(true)
+	go func() {
+		defer func() {
+			t.wg.Done()
+			t.running.Store(false)
+		}()
+
+		retries := backoff.New(t.ctx, followBackoff)
+		for t.ctx.Err() == nil {
+			n, err := t.follow()
+			if t.ctx.Err() != nil {
+				return
+			}
+			if client.IsErrNotFound(err) {
+				// The container was removed, its logs will not be followed again.
+				level.Info(t.logger).Log("msg", "container removed, stopping to follow its logs", "container", t.containerID)
+				t.positions.Remove(positionKey(t.containerID, streamStdout))
+				t.positions.Remove(positionKey(t.containerID, streamStderr))
+				return
+			}
+			if err != nil {
+				t.metrics.dockerErrors.Inc()
+				level.Warn(t.logger).Log("msg", "could not follow the container logs", "container", t.containerID, "err", err)
+			}
+			t.setErr(err)
+			if n > 0 {
+				retries.Reset()
+			}
+			retries.Wait()
+		}
+	}()
+}
+
+// follow follows the logs of the container from the earliest last entry sent of its streams until the logs end, and
+// returns the number of entries sent.
+func (t *Target) follow() (int, error) {
+	info, err := t.client.ContainerInspect(t.ctx, t.containerID)
+	if err != nil {
+		return 0, err
+	}
+
+	opts := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+	}
+	since := t.followSince()
+	if !since.IsZero() {
+		opts.Since = since.Format(time.RFC3339Nano)
+	}
+	logs, err := t.client.ContainerLogs(t.ctx, t.containerID, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer logs.Close()
+
+	// The stdout and stderr are multiplexed in the same stream, unless the container has a TTY.
+	var read func() (string, []byte, error)
+	if info.Config != nil && info.Config.Tty {
+		read = rawMessages(bufio.NewReader(logs))
+	} else {
+		read = multiplexedMessages(logs)
+	}
+
+	var (
+		n       int
+		pending = map[string]*partialLine{}
+	)
+	for {
+		stream, msg, err := read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		ts, payload, err := parseMessage(msg)
+		if err != nil {
+			t.metrics.dockerErrors.Inc()
+			level.Debug(t.logger).Log("msg", "could not parse the container log message", "container", t.containerID, "err", err)
+			continue
+		}
+
+		// A message without a trailing newline is a partial message, continued by the next one of its stream.
+		p, ok := pending[stream]
+		if !ok {
+			p = &partialLine{}
+			pending[stream] = p
+		}
+		if len(p.line) == 0 {
+			p.ts = ts
+		}
+		p.line = append(p.line, payload...)
+		if !bytes.HasSuffix(p.line, []byte("\n")) {
+			continue
+		}
+		line := strings.TrimRight(string(p.line), "\r\n")
+		p.line = p.line[:0]
+
+		if t.send(stream, p.ts, line) {
+			n++
+		}
+	}
+}
+
+// partialLine is a line of a stream not received completely yet.
+type partialLine struct {
+	ts   time.Time
+	line []byte
+}
+
+// send sends an entry of a stream, and returns false if it was already sent or its stream is dropped. The position of
+// the stream is updated once the entry is accepted by the handler.
+func (t *Target) send(stream string, ts time.Time, line string) bool {
+	lbls := t.labels[stream]
+	if lbls == nil {
+		return false
+	}
+	// The logs are followed again from the timestamp of the last entry sent of a stream, included.
+	if !ts.After(t.position(stream)) {
+		return false
+	}
+
+	select {
+	case t.handler.Chan() <- api.Entry{
+		Labels: lbls.Clone(),
+		Entry: logproto.Entry{
+			Timestamp: ts,
+			Line:      line,
+		},
+	}:
+	case <-t.ctx.Done():
+		return false
+	}
+	t.metrics.dockerEntries.Inc()
+	t.mtx.Lock()
+	t.since[stream] = ts
+	t.mtx.Unlock()
+	t.positions.Put(positionKey(t.containerID, stream), ts.UnixNano())
+	return true
+}
+
+// rawMessages returns the lines of the logs of a container with a TTY, which are all on stdout.
+func rawMessages(r *bufio.Reader) func() (string, []byte, error) {
+	return func() (string, []byte, error) {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF && len(line) > 0 {
+			return streamStdout, line, nil
+		}
+		return streamStdout, line, err
+	}
+}
+
+// multiplexedMessages returns the messages of the logs of a container without a TTY. Each message is prefixed with a
+// header of 8 bytes, the stream in the first byte and the size of the message in the last 4 bytes.
+func multiplexedMessages(r io.Reader) func() (string, []byte, error) {
+	header := make([]byte, 8)
+	return func() (string, []byte, error) {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return "", nil, err
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(r, msg); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", nil, err
+		}
+		switch header[0] {
+		case 1:
+			return streamStdout, msg, nil
+		case 2:
+			return streamStderr, msg, nil
+		default:
+			return "", nil, fmt.Errorf("unexpected stream %d in the container logs", header[0])
+		}
+	}
+}
+
+// parseMessage splits a message into its timestamp and its payload.
+func parseMessage(msg []byte) (time.Time, []byte, error) {
+	i := bytes.IndexByte(msg, ' ')
+	if i < 0 {
+		return time.Time{}, nil, errors.New("missing timestamp")
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(msg[:i]))
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return ts, msg[i+1:], nil
+}
+
+func (t *Target) position(stream string) time.Time {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.since[stream]
+}
+
+// followSince returns the earliest timestamp of the last entry sent of the streams not dropped, ignoring the streams
+// without any entry sent yet, zero if none has.
+func (t *Target) followSince() time.Time {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var since time.Time
+	for stream, ts := range t.since {
+		if t.labels[stream] == nil {
+			continue
+		}
+		if since.IsZero() || ts.Before(since) {
+			since = ts
+		}
+	}
+	return since
+}
+
+func (t *Target) setErr(err error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.err = err
+}
+
+// Stop stops following the logs of the container.
+func (t *Target) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Type implements target.Target.
+func (t *Target) Type() target.TargetType {
+	return target.DockerTargetType
+}
+
+// DiscoveredLabels implements target.Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return t.discoveredLabels
+}
+
+// Labels implements target.Target.
+func (t *Target) Labels() model.LabelSet {
+	if lbls := t.labels[streamStdout]; lbls != nil {
+		return lbls
+	}
+	return t.labels[streamStderr]
+}
+
+// Ready implements target.Target.
+func (t *Target) Ready() bool {
+	return t.running.Load()
+}
+
+// Details implements target.Target.
+func (t *Target) Details() interface{} {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	var errMsg string
+	if t.err != nil {
+		errMsg = t.err.Error()
+	}
+	return map[string]string{
+		"container_id":          t.containerID,
+		"error":                 errMsg,
+		"last_stdout_timestamp": t.since[streamStdout].String(),
+		"last_stderr_timestamp": t.since[streamStderr].String(),
+	}
+}
@@ -0,0 +1,184 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+)
+
+// frame returns a message of the multiplexed logs of a container.
+func frame(stream byte, msg string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(msg)))
+	return append(header, msg...)
+}
+
+// fakeDaemon serves the logs of a container, a batch of logs for each request, and records their since parameter.
+type fakeDaemon struct {
+	mtx    sync.Mutex
+	logs   [][]byte
+	sinces []string
+}
+
+func (d *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/_ping"):
+		w.Header().Set("API-Version", "1.41")
+	case strings.HasSuffix(r.URL.Path, "/containers/abc/json"):
+		_, _ = w.Write([]byte(`{"Id": "abc", "Config": {"Tty": false}}`))
+	case strings.HasSuffix(r.URL.Path, "/containers/abc/logs"):
+		d.mtx.Lock()
+		d.sinces = append(d.sinces, r.URL.Query().Get("since"))
+		var logs []byte
+		if len(d.logs) > 0 {
+			logs, d.logs = d.logs[0], d.logs[1:]
+		}
+		d.mtx.Unlock()
+		_, _ = w.Write(logs)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDockerTarget(t *testing.T) {
+	defer func(b time.Duration) { followBackoff.MinBackoff = b }(followBackoff.MinBackoff)
+	followBackoff.MinBackoff = time.Millisecond
+
+	daemon := &fakeDaemon{
+		logs: [][]byte{
+			bytes.Join([][]byte{
+				frame(1, "2021-11-18T10:00:00.000000001Z line 1\n"),
+				frame(2, "2021-11-18T10:00:00.000000002Z error 1\n"),
+				// A partial message is continued by the next one of its stream.
+				frame(1, "2021-11-18T10:00:00.000000003Z line "),
+				frame(1, "2021-11-18T10:00:00.000000004Z 2\n"),
+				frame(1, "2021-11-18T10:00:00.000000006Z line 3\n"),
+				// An entry of a stream older than the last entry of the other stream is still sent.
+				frame(2, "2021-11-18T10:00:00.000000005Z error 2\n"),
+			}, nil),
+			// After a restart, the logs are followed again from the earliest last entry sent of the streams.
+			bytes.Join([][]byte{
+				frame(2, "2021-11-18T10:00:00.000000005Z error 2\n"),
+				frame(1, "2021-11-18T10:00:00.000000006Z line 3\n"),
+				frame(1, "2021-11-18T10:00:00.000000007Z line 4\n"),
+			}, nil),
+		},
+	}
+	server := httptest.NewServer(daemon)
+	defer server.Close()
+
+	dirName := t.TempDir()
+	ps, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: dirName + "/positions.yml",
+	})
+	require.NoError(t, err)
+	defer ps.Stop()
+
+	c, err := newDockerClient(server.URL, config.DefaultHTTPClientConfig)
+	require.NoError(t, err)
+
+	client := fake.New(func() {})
+	defer client.Stop()
+
+	discovered := model.LabelSet{
+		dockerLabelContainerID:                         "abc",
+		"__meta_docker_container_name":                 "/app",
+		"__meta_docker_container_label_com_example_id": "42",
+	}
+	relabelConfig := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__meta_docker_container_name"},
+			Regex:        relabel.MustNewRegexp("/(.*)"),
+			TargetLabel:  "container",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{dockerLabelLogStream},
+			Regex:        relabel.MustNewRegexp("(.*)"),
+			TargetLabel:  "stream",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+		{
+			Regex:       relabel.MustNewRegexp("__meta_docker_container_label_(.+)"),
+			Replacement: "$1",
+			Action:      relabel.LabelMap,
+		},
+	}
+	streamLabels := map[string]model.LabelSet{
+		streamStdout: relabelStream(discovered, streamStdout, relabelConfig),
+		streamStderr: relabelStream(discovered, streamStderr, relabelConfig),
+	}
+	require.Equal(t, model.LabelSet{"container": "app", "stream": "stdout", "com_example_id": "42"}, streamLabels[streamStdout])
+
+	target, err := NewTarget(NewMetrics(nil), log.NewNopLogger(), client, ps, "abc", discovered, streamLabels, c)
+	require.NoError(t, err)
+	defer target.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 6
+	}, 5*time.Second, 10*time.Millisecond)
+
+	received := client.Received()
+	sort.Slice(received, func(i, j int) bool {
+		return received[i].Timestamp.Before(received[j].Timestamp)
+	})
+	var lines []string
+	for _, e := range received {
+		lines = append(lines, string(e.Labels["stream"])+" "+e.Line)
+	}
+	require.Equal(t, []string{"stdout line 1", "stderr error 1", "stdout line 2", "stderr error 2", "stdout line 3", "stdout line 4"}, lines)
+
+	daemon.mtx.Lock()
+	require.Equal(t, "", daemon.sinces[0])
+	require.Equal(t, "1637229600.000000005", daemon.sinces[1])
+	daemon.mtx.Unlock()
+
+	pos, err := ps.Get(positionKey("abc", streamStdout))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 11, 18, 10, 0, 0, 7, time.UTC).UnixNano(), pos)
+	pos, err = ps.Get(positionKey("abc", streamStderr))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 11, 18, 10, 0, 0, 5, time.UTC).UnixNano(), pos)
+}
+
+func TestDockerTarget_TTY(t *testing.T) {
+	lines := [][]byte{}
+	read := rawMessages(bufio.NewReader(strings.NewReader("2021-11-18T10:00:00Z line 1\r\n2021-11-18T10:00:01Z line 2")))
+	for {
+		stream, msg, err := read()
+		if err != nil {
+			break
+		}
+		require.Equal(t, streamStdout, stream)
+		lines = append(lines, msg)
+	}
+	require.Equal(t, [][]byte{[]byte("2021-11-18T10:00:00Z line 1\r\n"), []byte("2021-11-18T10:00:01Z line 2")}, lines)
+
+	ts, payload, err := parseMessage(lines[0])
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 11, 18, 10, 0, 0, 0, time.UTC), ts)
+	require.Equal(t, "line 1\r\n", string(payload))
+
+	_, _, err = parseMessage([]byte("line"))
+	require.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package docker
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/docker/docker/client"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+)
+
+// targetGroup manages the targets of the containers discovered on a Docker daemon for a job.
+type targetGroup struct {
+	jobName       string
+	metrics       *Metrics
+	logger        log.Logger
+	positions     positions.Positions
+	entryHandler  api.EntryHandler
+	relabelConfig []*relabel.Config
+	client        *client.Client
+
+	mtx            sync.Mutex
+	targets        map[string]*Target
+	droppedTargets []target.Target
+}
+
+// newDockerClient returns a client of the Docker daemon at host.
+func newDockerClient(host string, httpClientConfig config.HTTPClientConfig) (*client.Client, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.Opt{
+		client.WithHost(host),
+		client.WithAPIVersionNegotiation(),
+	}
+	// The HTTP client options only apply to the daemons listening on HTTP, not on a unix socket. There is no timeout,
+	// the logs of the containers are followed as long as they run.
+	if hostURL.Scheme == "http" || hostURL.Scheme == "https" {
+		rt, err := config.NewRoundTripperFromConfig(httpClientConfig, "docker_target")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts,
+			client.WithHTTPClient(&http.Client{Transport: rt}),
+			client.WithScheme(hostURL.Scheme),
+		)
+	}
+
+	c, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up docker client: %w", err)
+	}
+	return c, nil
+}
+
+// sync synchronizes the targets with the containers of the target groups received from the service discovery.
+func (tg *targetGroup) sync(groups []*targetgroup.Group) {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+
+	containers := map[string]struct{}{}
+	dropped := []target.Target{}
+
+	for _, group := range groups {
+		for _, t := range group.Targets {
+			discoveredLabels := group.Labels.Merge(t)
+
+			containerID := string(discoveredLabels[dockerLabelContainerID])
+			if containerID == "" {
+				dropped = append(dropped, target.NewDroppedTarget("no container ID for target", discoveredLabels))
+				level.Debug(tg.logger).Log("msg", "no container ID for target", "labels", discoveredLabels.String())
+				continue
+			}
+			// A container has a target for each of its networks and ports, its logs are followed once.
+			if _, ok := containers[containerID]; ok {
+				continue
+			}
+			containers[containerID] = struct{}{}
+			if _, ok := tg.targets[containerID]; ok {
+				continue
+			}
+
+			streamLabels := map[string]model.LabelSet{
+				streamStdout: relabelStream(discoveredLabels, streamStdout, tg.relabelConfig),
+				streamStderr: relabelStream(discoveredLabels, streamStderr, tg.relabelConfig),
+			}
+			if streamLabels[streamStdout] == nil && streamLabels[streamStderr] == nil {
+				dropped = append(dropped, target.NewDroppedTarget("dropping target, no labels", discoveredLabels))
+				level.Debug(tg.logger).Log("msg", "dropping target, no labels", "container", containerID)
+				continue
+			}
+
+			level.Info(tg.logger).Log("msg", "Adding target", "container", containerID)
+			t, err := NewTarget(
+				tg.metrics,
+				log.With(tg.logger, "target", "docker"),
+				tg.entryHandler,
+				tg.positions,
+				containerID,
+				discoveredLabels,
+				streamLabels,
+				tg.client,
+			)
+			if err != nil {
+				dropped = append(dropped, target.NewDroppedTarget(fmt.Sprintf("Failed to create target: %s", err.Error()), discoveredLabels))
+				level.Error(tg.logger).Log("msg", "Failed to create target", "container", containerID, "error", err)
+				continue
+			}
+			tg.metrics.targetsActive.Inc()
+			tg.targets[containerID] = t
+		}
+	}
+
+	for containerID, t := range tg.targets {
+		if _, ok := containers[containerID]; !ok {
+			level.Info(tg.logger).Log("msg", "Removing target", "container", containerID)
+			t.Stop()
+			tg.metrics.targetsActive.Dec()
+			delete(tg.targets, containerID)
+		}
+	}
+	tg.droppedTargets = dropped
+}
+
+func (tg *targetGroup) ready() bool {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	for _, t := range tg.targets {
+		if t.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+func (tg *targetGroup) activeTargets() []target.Target {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	result := make([]target.Target, 0, len(tg.targets))
+	for _, t := range tg.targets {
+		result = append(result, t)
+	}
+	return result
+}
+
+func (tg *targetGroup) allTargets() []target.Target {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	result := make([]target.Target, 0, len(tg.targets)+len(tg.droppedTargets))
+	for _, t := range tg.targets {
+		result = append(result, t)
+	}
+	return append(result, tg.droppedTargets...)
+}
+
+func (tg *targetGroup) stop() {
+	tg.mtx.Lock()
+	defer tg.mtx.Unlock()
+	for containerID, t := range tg.targets {
+		t.Stop()
+		tg.metrics.targetsActive.Dec()
+		delete(tg.targets, containerID)
+	}
+	tg.entryHandler.Stop()
+}
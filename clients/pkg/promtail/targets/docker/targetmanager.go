@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/discovery"
+
+	"github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/pkg/util"
+)
+
+// TargetManager manages the Docker targets of the containers discovered with the docker_sd_configs.
+type TargetManager struct {
+	logger  log.Logger
+	quit    context.CancelFunc
+	manager *discovery.Manager
+	groups  map[string]*targetGroup
+}
+
+// NewTargetManager creates a new Docker target manager.
+func NewTargetManager(
+	metrics *Metrics,
+	logger log.Logger,
+	positions positions.Positions,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+) (*TargetManager, error) {
+	ctx, quit := context.WithCancel(context.Background())
+	tm := &TargetManager{
+		logger:  logger,
+		quit:    quit,
+		manager: discovery.NewManager(ctx, log.With(logger, "component", "docker_discovery")),
+		groups:  map[string]*targetGroup{},
+	}
+
+	configs := map[string]discovery.Configs{}
+	for _, cfg := range scrapeConfigs {
+		if cfg.DockerSDConfigs == nil {
+			continue
+		}
+
+		pipeline, err := stages.NewPipeline(log.With(logger, "component", "docker_pipeline"), cfg.PipelineStages, &cfg.JobName, metrics.reg)
+		if err != nil {
+			quit()
+			return nil, err
+		}
+
+		// The containers of each Docker daemon are synchronized separately, to follow their logs on their daemon.
+		for i, sdConfig := range cfg.DockerSDConfigs {
+			client, err := newDockerClient(sdConfig.Host, sdConfig.HTTPClientConfig)
+			if err != nil {
+				quit()
+				return nil, err
+			}
+			key := fmt.Sprintf("%s/%d", cfg.JobName, i)
+			tm.groups[key] = &targetGroup{
+				jobName:       cfg.JobName,
+				metrics:       metrics,
+				logger:        logger,
+				positions:     positions,
+				entryHandler:  pipeline.Wrap(pushClient),
+				relabelConfig: cfg.RelabelConfigs,
+				client:        client,
+				targets:       map[string]*Target{},
+			}
+			configs[key] = discovery.Configs{sdConfig}
+		}
+	}
+
+	go tm.run()
+	go util.LogError("running docker target manager", tm.manager.Run)
+
+	return tm, tm.manager.ApplyConfig(configs)
+}
+
+func (tm *TargetManager) run() {
+	for targetGroups := range tm.manager.SyncCh() {
+		for key, groups := range targetGroups {
+			if tg, ok := tm.groups[key]; ok {
+				tg.sync(groups)
+			}
+		}
+	}
+}
+
+// Ready returns true if at least one Docker target is active.
+func (tm *TargetManager) Ready() bool {
+	for _, tg := range tm.groups {
+		if tg.ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the discovery and all the Docker targets.
+func (tm *TargetManager) Stop() {
+	tm.quit()
+	for _, tg := range tm.groups {
+		tg.stop()
+	}
+}
+
+// ActiveTargets returns the active targets currently being scraped.
+func (tm *TargetManager) ActiveTargets() map[string][]target.Target {
+	result := map[string][]target.Target{}
+	for _, tg := range tm.groups {
+		result[tg.jobName] = append(result[tg.jobName], tg.activeTargets()...)
+	}
+	return result
+}
+
+// AllTargets returns all targets, active and dropped.
+func (tm *TargetManager) AllTargets() map[string][]target.Target {
+	result := map[string][]target.Target{}
+	for _, tg := range tm.groups {
+		result[tg.jobName] = append(result[tg.jobName], tg.allTargets()...)
+	}
+	return result
+}
@@ -2,6 +2,7 @@ package lokipush
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"io"
 	"net/http"
@@ -96,6 +97,10 @@ func (t *PushTarget) run() error {
 
 	t.server = srv
 	t.server.HTTP.Path("/loki/api/v1/push").Methods("POST").Handler(http.HandlerFunc(t.handleLoki))
+	// Promtail push endpoint, for the promtails forwarding to this one.
+	t.server.HTTP.Path("/api/promtail").Methods("POST").Handler(http.HandlerFunc(t.handleLoki))
+	// Legacy push endpoint, still used by older Loki clients.
+	t.server.HTTP.Path("/api/prom/push").Methods("POST").Handler(http.HandlerFunc(t.handleLoki))
 	t.server.HTTP.Path("/promtail/api/v1/raw").Methods("POST").Handler(http.HandlerFunc(t.handlePlaintext))
 
 	go func() {
@@ -111,9 +116,15 @@ func (t *PushTarget) run() error {
 func (t *PushTarget) handleLoki(w http.ResponseWriter, r *http.Request) {
 	logger := util_log.WithContext(r.Context(), util_log.Logger)
 	userID, _ := tenant.TenantID(r.Context())
-	req, err := push.ParseRequest(logger, userID, r, nil)
+	req, err := push.ParseRequest(logger, userID, r, nil, push.DefaultMaxRecvMsgSize)
 	if err != nil {
 		level.Warn(t.logger).Log("msg", "failed to parse incoming push request", "err", err.Error())
+		var encodingErr push.UnsupportedContentEncodingError
+		if errors.As(err, &encodingErr) {
+			w.Header().Set("Accept-Encoding", strings.Join(push.SupportedContentEncodings, ", "))
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
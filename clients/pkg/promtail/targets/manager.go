@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/positions"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/cloudflare"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/docker"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/file"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/gcplog"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/gelf"
@@ -35,6 +36,7 @@ const (
 	KafkaConfigs         = "kafkaConfigs"
 	GelfConfigs          = "gelfConfigs"
 	CloudflareConfigs    = "cloudflareConfigs"
+	DockerSDConfigs      = "dockerSDConfigs"
 )
 
 type targetManager interface {
@@ -94,6 +96,8 @@ func NewTargetManagers(
 			targetScrapeConfigs[GelfConfigs] = append(targetScrapeConfigs[GelfConfigs], cfg)
 		case cfg.CloudflareConfig != nil:
 			targetScrapeConfigs[CloudflareConfigs] = append(targetScrapeConfigs[CloudflareConfigs], cfg)
+		case cfg.DockerSDConfigs != nil:
+			targetScrapeConfigs[DockerSDConfigs] = append(targetScrapeConfigs[DockerSDConfigs], cfg)
 		default:
 			return nil, fmt.Errorf("no valid target scrape config defined for %q", cfg.JobName)
 		}
@@ -119,6 +123,7 @@ func NewTargetManagers(
 		gcplogMetrics     *gcplog.Metrics
 		gelfMetrics       *gelf.Metrics
 		cloudflareMetrics *cloudflare.Metrics
+		dockerMetrics     *docker.Metrics
 	)
 	if len(targetScrapeConfigs[FileScrapeConfigs]) > 0 {
 		fileMetrics = file.NewMetrics(reg)
@@ -135,6 +140,9 @@ func NewTargetManagers(
 	if len(targetScrapeConfigs[CloudflareConfigs]) > 0 {
 		cloudflareMetrics = cloudflare.NewMetrics(reg)
 	}
+	if len(targetScrapeConfigs[DockerSDConfigs]) > 0 {
+		dockerMetrics = docker.NewMetrics(reg)
+	}
 
 	for target, scrapeConfigs := range targetScrapeConfigs {
 		switch target {
@@ -232,6 +240,16 @@ func NewTargetManagers(
 				return nil, errors.Wrap(err, "failed to make cloudflare target manager")
 			}
 			targetManagers = append(targetManagers, cfTargetManager)
+		case DockerSDConfigs:
+			pos, err := getPositionFile()
+			if err != nil {
+				return nil, err
+			}
+			dockerTargetManager, err := docker.NewTargetManager(dockerMetrics, logger, pos, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make docker target manager")
+			}
+			targetManagers = append(targetManagers, dockerTargetManager)
 		default:
 			return nil, errors.New("unknown scrape config")
 		}
@@ -0,0 +1,193 @@
+package syslogparser
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/go-syslog/v3"
+)
+
+// RFC3164Message is a BSD syslog message, as described in RFC3164.
+type RFC3164Message struct {
+	syslog.Base
+}
+
+// ParseRFC3164Stream parses a rfc3164 syslog stream from the given Reader,
+// calling the callback function with the parsed messages. The messages are
+// either separated by newlines or framed with octet counting, which is detected
+// automatically.
+// The function returns on EOF or unrecoverable errors.
+func ParseRFC3164Stream(r io.Reader, callback func(res *syslog.Result), maxMessageLength int) error {
+	buf := bufio.NewReader(r)
+
+	firstByte, err := buf.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	b := firstByte[0]
+	if b == '<' {
+		parseNewlineSeparated(buf, callback, maxMessageLength)
+	} else if b >= '0' && b <= '9' {
+		parseOctetCounting(buf, callback, maxMessageLength)
+	} else {
+		return fmt.Errorf("invalid or unsupported framing. first byte: '%s'", firstByte)
+	}
+
+	return nil
+}
+
+func parseNewlineSeparated(r *bufio.Reader, callback func(res *syslog.Result), maxMessageLength int) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageLength+1)
+	for scanner.Scan() {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(line) == 0 {
+			continue
+		}
+		callback(parseRFC3164Result(line, time.Now()))
+	}
+	if err := scanner.Err(); err != nil {
+		callback(&syslog.Result{Error: err})
+	}
+}
+
+func parseOctetCounting(r *bufio.Reader, callback func(res *syslog.Result), maxMessageLength int) {
+	for {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			if err != io.EOF || length != "" {
+				callback(&syslog.Result{Error: err})
+			}
+			return
+		}
+		n, err := strconv.Atoi(length[:len(length)-1])
+		if err != nil || n <= 0 {
+			callback(&syslog.Result{Error: fmt.Errorf("invalid message length %q", length[:len(length)-1])})
+			return
+		}
+		if n > maxMessageLength {
+			callback(&syslog.Result{Error: fmt.Errorf("message too long to parse. was size %d, max length %d", n, maxMessageLength)})
+			if _, err := r.Discard(n); err != nil {
+				return
+			}
+			continue
+		}
+
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			callback(&syslog.Result{Error: err})
+			return
+		}
+		callback(parseRFC3164Result(bytes.TrimRight(msg, "\r\n"), time.Now()))
+	}
+}
+
+func parseRFC3164Result(msg []byte, now time.Time) *syslog.Result {
+	m, err := ParseRFC3164(msg, now)
+	if err != nil {
+		return &syslog.Result{Error: err}
+	}
+	return &syslog.Result{Message: m}
+}
+
+// ParseRFC3164 parses a rfc3164 syslog message: <PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG.
+// The parsing is lenient, as the devices sending BSD syslog messages often
+// don't follow the RFC: only the priority is required, and the timestamp can
+// also be a RFC3339 timestamp. The timestamps without a year, in the local time
+// of now, are in the last year before now.
+func ParseRFC3164(msg []byte, now time.Time) (*RFC3164Message, error) {
+	if len(msg) == 0 || msg[0] != '<' {
+		return nil, errors.New("expecting a priority value within angle brackets")
+	}
+	end := bytes.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return nil, errors.New("expecting a priority value within angle brackets")
+	}
+	priority, err := strconv.ParseUint(string(msg[1:end]), 10, 8)
+	if err != nil || priority > 191 {
+		return nil, fmt.Errorf("expecting a priority value in the range 0-191, got %q", msg[1:end])
+	}
+
+	m := &RFC3164Message{}
+	m.ComputeFromPriority(uint8(priority))
+	rest := msg[end+1:]
+
+	ts, rest, ok := parseRFC3164Timestamp(rest, now)
+	if ok {
+		m.Timestamp = &ts
+		rest = bytes.TrimLeft(rest, " ")
+		// The hostname follows the timestamp, unless the device omitted it and the tag follows.
+		if i := bytes.IndexByte(rest, ' '); i > 0 && !isTag(rest[:i]) {
+			hostname := string(rest[:i])
+			m.Hostname = &hostname
+			rest = rest[i+1:]
+		}
+	}
+
+	if appname, procID, remaining, ok := parseTag(rest); ok {
+		m.Appname = &appname
+		if procID != "" {
+			m.ProcID = &procID
+		}
+		rest = remaining
+	}
+
+	if len(rest) > 0 {
+		message := string(rest)
+		m.Message = &message
+	}
+	return m, nil
+}
+
+// parseRFC3164Timestamp parses the timestamp at the start of msg, either "Mmm dd hh:mm:ss" or RFC3339.
+func parseRFC3164Timestamp(msg []byte, now time.Time) (time.Time, []byte, bool) {
+	if len(msg) >= len(time.Stamp) {
+		if ts, err := time.ParseInLocation(time.Stamp, string(msg[:len(time.Stamp)]), now.Location()); err == nil {
+			ts = ts.AddDate(now.Year(), 0, 0)
+			// Allow for the clocks of the devices to be a bit ahead.
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			return ts, msg[len(time.Stamp):], true
+		}
+	}
+	if i := bytes.IndexByte(msg, ' '); i > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, string(msg[:i])); err == nil {
+			return ts, msg[i:], true
+		}
+	}
+	return time.Time{}, msg, false
+}
+
+// isTag returns true if the word is a tag, ending with a colon.
+func isTag(word []byte) bool {
+	return bytes.HasSuffix(word, []byte(":"))
+}
+
+// parseTag parses the tag at the start of msg, the name of the program with an optional pid
+// within square brackets, followed by a colon.
+func parseTag(msg []byte) (appname, procID string, rest []byte, ok bool) {
+	i := bytes.IndexAny(msg, ":[ ")
+	if i <= 0 || msg[i] == ' ' {
+		return "", "", msg, false
+	}
+	appname = string(msg[:i])
+	if msg[i] == '[' {
+		j := bytes.IndexByte(msg[i:], ']')
+		if j < 0 {
+			return "", "", msg, false
+		}
+		procID = string(msg[i+1 : i+j])
+		i += j + 1
+		if i >= len(msg) || msg[i] != ':' {
+			return "", "", msg, false
+		}
+	}
+	return appname, procID, bytes.TrimPrefix(msg[i+1:], []byte(" ")), true
+}
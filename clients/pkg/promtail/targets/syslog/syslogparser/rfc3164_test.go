@@ -0,0 +1,155 @@
+package syslogparser_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/go-syslog/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog/syslogparser"
+)
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name                               string
+		msg                                string
+		ts                                 time.Time
+		hostname, appname, procID, message string
+		expectedFacility, expectedSeverity string
+		expectedErr                        string
+	}{
+		{
+			name:             "full message",
+			msg:              "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8",
+			ts:               time.Date(2020, 10, 11, 22, 14, 15, 0, time.UTC),
+			hostname:         "mymachine",
+			appname:          "su",
+			procID:           "123",
+			message:          "'su root' failed for lonvick on /dev/pts/8",
+			expectedFacility: "auth",
+			expectedSeverity: "critical",
+		},
+		{
+			name:             "padded day without pid",
+			msg:              "<13>Jan  1 11:59:00 router01 kernel: link down",
+			ts:               time.Date(2021, 1, 1, 11, 59, 0, 0, time.UTC),
+			hostname:         "router01",
+			appname:          "kernel",
+			message:          "link down",
+			expectedFacility: "user",
+			expectedSeverity: "notice",
+		},
+		{
+			name:             "rfc3339 timestamp without hostname",
+			msg:              "<13>2021-01-01T10:00:00.5+01:00 app: started",
+			ts:               time.Date(2021, 1, 1, 9, 0, 0, 500000000, time.UTC),
+			appname:          "app",
+			message:          "started",
+			expectedFacility: "user",
+			expectedSeverity: "notice",
+		},
+		{
+			name:             "no header",
+			msg:              "<13>just a message",
+			message:          "just a message",
+			expectedFacility: "user",
+			expectedSeverity: "notice",
+		},
+		{
+			name:        "no priority",
+			msg:         "Oct 11 22:14:15 mymachine su: message",
+			expectedErr: "expecting a priority value within angle brackets",
+		},
+		{
+			name:        "invalid priority",
+			msg:         "<192>Oct 11 22:14:15 mymachine su: message",
+			expectedErr: `expecting a priority value in the range 0-191, got "192"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := syslogparser.ParseRFC3164([]byte(tc.msg), now)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedFacility, *m.FacilityLevel())
+			require.Equal(t, tc.expectedSeverity, *m.SeverityLevel())
+			if tc.ts.IsZero() {
+				require.Nil(t, m.Timestamp)
+			} else {
+				require.True(t, tc.ts.Equal(*m.Timestamp), "expected %s, got %s", tc.ts, *m.Timestamp)
+			}
+			requireOptionalString(t, tc.hostname, m.Hostname)
+			requireOptionalString(t, tc.appname, m.Appname)
+			requireOptionalString(t, tc.procID, m.ProcID)
+			requireOptionalString(t, tc.message, m.Message)
+		})
+	}
+}
+
+func requireOptionalString(t *testing.T, expected string, actual *string) {
+	if expected == "" {
+		require.Nil(t, actual)
+		return
+	}
+	require.NotNil(t, actual)
+	require.Equal(t, expected, *actual)
+}
+
+func TestParseRFC3164Stream(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "newline separated",
+			input: "<13>Jan  1 11:59:00 host app: First\r\n<13>Jan  1 11:59:00 host app: Second\n",
+		},
+		{
+			name:  "octet counting",
+			input: "35 <13>Jan  1 11:59:00 host app: First36 <13>Jan  1 11:59:00 host app: Second",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results := make([]*syslog.Result, 0)
+			cb := func(res *syslog.Result) {
+				results = append(results, res)
+			}
+
+			err := syslogparser.ParseRFC3164Stream(strings.NewReader(tc.input), cb, defaultMaxMessageLength)
+			require.NoError(t, err)
+
+			require.Equal(t, 2, len(results))
+			require.NoError(t, results[0].Error)
+			require.Equal(t, "First", *results[0].Message.(*syslogparser.RFC3164Message).Message)
+			require.NoError(t, results[1].Error)
+			require.Equal(t, "Second", *results[1].Message.(*syslogparser.RFC3164Message).Message)
+		})
+	}
+}
+
+func TestParseRFC3164Stream_LongMessage(t *testing.T) {
+	results := make([]*syslog.Result, 0)
+	cb := func(res *syslog.Result) {
+		results = append(results, res)
+	}
+
+	err := syslogparser.ParseRFC3164Stream(strings.NewReader("19 <13>host app: First10 <13>Second"), cb, 15)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(results))
+	require.EqualError(t, results[0].Error, "message too long to parse. was size 19, max length 15")
+	require.NoError(t, results[1].Error)
+	require.Equal(t, "Second", *results[1].Message.(*syslogparser.RFC3164Message).Message)
+}
+
+func TestParseRFC3164Stream_InvalidStream(t *testing.T) {
+	err := syslogparser.ParseRFC3164Stream(strings.NewReader("invalid"), func(res *syslog.Result) {}, defaultMaxMessageLength)
+	require.EqualError(t, err, "invalid or unsupported framing. first byte: 'i'")
+}
@@ -1,6 +1,7 @@
 package syslog
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -33,6 +34,16 @@ import (
 var (
 	defaultIdleTimeout      = 120 * time.Second
 	defaultMaxMessageLength = 8192
+	defaultProtocol         = protocolTCP
+	defaultSyslogFormat     = syslogFormatRFC5424
+)
+
+const (
+	protocolTCP = "tcp"
+	protocolUDP = "udp"
+
+	syslogFormatRFC5424 = "rfc5424"
+	syslogFormatRFC3164 = "rfc3164"
 )
 
 // SyslogTarget listens to syslog messages.
@@ -44,8 +55,9 @@ type SyslogTarget struct {
 	config        *scrapeconfig.SyslogTargetConfig
 	relabelConfig []*relabel.Config
 
-	listener net.Listener
-	messages chan message
+	listener   net.Listener
+	packetConn net.PacketConn
+	messages   chan message
 
 	ctx             context.Context
 	ctxCancel       context.CancelFunc
@@ -89,13 +101,30 @@ func NewSyslogTarget(
 }
 
 func (t *SyslogTarget) run() error {
+	switch t.syslogFormat() {
+	case syslogFormatRFC5424, syslogFormatRFC3164:
+	default:
+		return fmt.Errorf("error setting up syslog target: unsupported syslog format %q", t.config.SyslogFormat)
+	}
+
+	tlsEnabled := t.config.TLSConfig.CertFile != "" || t.config.TLSConfig.KeyFile != "" || t.config.TLSConfig.CAFile != ""
+	switch t.protocol() {
+	case protocolTCP:
+	case protocolUDP:
+		if tlsEnabled {
+			return fmt.Errorf("error setting up syslog target: TLS is not supported over udp")
+		}
+		return t.runUDP()
+	default:
+		return fmt.Errorf("error setting up syslog target: unsupported protocol %q", t.config.ListenProtocol)
+	}
+
 	l, err := net.Listen("tcp", t.config.ListenAddress)
 	l = conntrack.NewListener(l, conntrack.TrackWithName("syslog_target/"+t.config.ListenAddress))
 	if err != nil {
 		return fmt.Errorf("error setting up syslog target: %w", err)
 	}
 
-	tlsEnabled := t.config.TLSConfig.CertFile != "" || t.config.TLSConfig.KeyFile != "" || t.config.TLSConfig.CAFile != ""
 	if tlsEnabled {
 		tlsConfig, err := newTLSConfig(t.config.TLSConfig.CertFile, t.config.TLSConfig.KeyFile, t.config.TLSConfig.CAFile)
 		if err != nil {
@@ -113,6 +142,63 @@ func (t *SyslogTarget) run() error {
 	return nil
 }
 
+func (t *SyslogTarget) runUDP() error {
+	c, err := net.ListenPacket("udp", t.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("error setting up syslog target: %w", err)
+	}
+
+	t.packetConn = c
+	level.Info(t.logger).Log("msg", "syslog listening on address", "address", t.ListenAddress().String(), "protocol", protocolUDP)
+
+	t.openConnections.Add(1)
+	go t.readPackets()
+
+	return nil
+}
+
+// readPackets reads the syslog messages received over udp, one message per datagram.
+func (t *SyslogTarget) readPackets() {
+	defer t.openConnections.Done()
+
+	maxMessageLength := t.maxMessageLength()
+	buf := make([]byte, maxMessageLength+1)
+	for {
+		n, addr, err := t.packetConn.ReadFrom(buf)
+		if err != nil {
+			if t.ctx.Err() != nil {
+				level.Info(t.logger).Log("msg", "syslog server shutting down")
+				return
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				level.Warn(t.logger).Log("msg", "failed to read syslog datagram", "err", err)
+				continue
+			}
+			level.Error(t.logger).Log("msg", "failed to read syslog datagram. quiting", "err", err)
+			return
+		}
+		if n > maxMessageLength {
+			t.handleMessageError(fmt.Errorf("message too long to parse. max length %d", maxMessageLength))
+			continue
+		}
+
+		datagram := bytes.TrimRight(buf[:n], "\r\n\x00")
+		var result *syslog.Result
+		if t.syslogFormat() == syslogFormatRFC3164 {
+			m, err := syslogparser.ParseRFC3164(datagram, time.Now())
+			result = &syslog.Result{Message: m, Error: err}
+		} else {
+			m, err := rfc5424.NewParser().Parse(datagram)
+			result = &syslog.Result{Message: m, Error: err}
+		}
+		if result.Error != nil {
+			t.handleMessageError(result.Error)
+			continue
+		}
+		t.handleMessage(t.connectionLabels(addr), result.Message)
+	}
+}
+
 func newTLSConfig(certFile string, keyFile string, caFile string) (*tls.Config, error) {
 	if certFile == "" || keyFile == "" {
 		return nil, fmt.Errorf("certificate and key files are required")
@@ -192,9 +278,13 @@ func (t *SyslogTarget) handleConnection(cn net.Conn) {
 		_ = c.Close()
 	}()
 
-	connLabels := t.connectionLabels(c)
+	connLabels := t.connectionLabels(c.RemoteAddr())
 
-	err := syslogparser.ParseStream(c, func(msg *syslog.Result) {
+	parseStream := syslogparser.ParseStream
+	if t.syslogFormat() == syslogFormatRFC3164 {
+		parseStream = syslogparser.ParseRFC3164Stream
+	}
+	err := parseStream(c, func(msg *syslog.Result) {
 		if err := msg.Error; err != nil {
 			t.handleMessageError(err)
 			return
@@ -218,35 +308,44 @@ func (t *SyslogTarget) handleMessageError(err error) {
 }
 
 func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Message) {
-	rfc5424Msg := msg.(*rfc5424.SyslogMessage)
+	var (
+		base           *syslog.Base
+		structuredData *map[string]map[string]string
+	)
+	switch m := msg.(type) {
+	case *rfc5424.SyslogMessage:
+		base, structuredData = &m.Base, m.StructuredData
+	case *syslogparser.RFC3164Message:
+		base = &m.Base
+	}
 
-	if rfc5424Msg.Message == nil {
+	if base.Message == nil {
 		t.metrics.syslogEmptyMessages.Inc()
 		return
 	}
 
 	lb := labels.NewBuilder(connLabels)
-	if v := rfc5424Msg.SeverityLevel(); v != nil {
+	if v := base.SeverityLevel(); v != nil {
 		lb.Set("__syslog_message_severity", *v)
 	}
-	if v := rfc5424Msg.FacilityLevel(); v != nil {
+	if v := base.FacilityLevel(); v != nil {
 		lb.Set("__syslog_message_facility", *v)
 	}
-	if v := rfc5424Msg.Hostname; v != nil {
+	if v := base.Hostname; v != nil {
 		lb.Set("__syslog_message_hostname", *v)
 	}
-	if v := rfc5424Msg.Appname; v != nil {
+	if v := base.Appname; v != nil {
 		lb.Set("__syslog_message_app_name", *v)
 	}
-	if v := rfc5424Msg.ProcID; v != nil {
+	if v := base.ProcID; v != nil {
 		lb.Set("__syslog_message_proc_id", *v)
 	}
-	if v := rfc5424Msg.MsgID; v != nil {
+	if v := base.MsgID; v != nil {
 		lb.Set("__syslog_message_msg_id", *v)
 	}
 
-	if t.config.LabelStructuredData && rfc5424Msg.StructuredData != nil {
-		for id, params := range *rfc5424Msg.StructuredData {
+	if t.config.LabelStructuredData && structuredData != nil {
+		for id, params := range *structuredData {
 			id = strings.Replace(id, "@", "_", -1)
 			for name, value := range params {
 				key := "__syslog_message_sd_" + id + "_" + name
@@ -266,12 +365,12 @@ func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Messag
 	}
 
 	var timestamp time.Time
-	if t.config.UseIncomingTimestamp && rfc5424Msg.Timestamp != nil {
-		timestamp = *rfc5424Msg.Timestamp
+	if t.config.UseIncomingTimestamp && base.Timestamp != nil {
+		timestamp = *base.Timestamp
 	} else {
 		timestamp = time.Now()
 	}
-	t.messages <- message{filtered, *rfc5424Msg.Message, timestamp}
+	t.messages <- message{filtered, *base.Message, timestamp}
 }
 
 func (t *SyslogTarget) messageSender(entries chan<- api.Entry) {
@@ -287,23 +386,25 @@ func (t *SyslogTarget) messageSender(entries chan<- api.Entry) {
 	}
 }
 
-func (t *SyslogTarget) connectionLabels(c net.Conn) labels.Labels {
+func (t *SyslogTarget) connectionLabels(addr net.Addr) labels.Labels {
 	lb := labels.NewBuilder(nil)
 	for k, v := range t.config.Labels {
 		lb.Set(string(k), string(v))
 	}
 
-	ip := ipFromConn(c).String()
+	ip := ipFromAddr(addr).String()
 	lb.Set("__syslog_connection_ip_address", ip)
 	lb.Set("__syslog_connection_hostname", lookupAddr(ip))
 
 	return lb.Labels()
 }
 
-func ipFromConn(c net.Conn) net.IP {
-	switch addr := c.RemoteAddr().(type) {
+func ipFromAddr(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
 	case *net.TCPAddr:
 		return addr.IP
+	case *net.UDPAddr:
+		return addr.IP
 	}
 
 	return nil
@@ -344,7 +445,12 @@ func (t *SyslogTarget) Details() interface{} {
 // Stop shuts down the SyslogTarget.
 func (t *SyslogTarget) Stop() error {
 	t.ctxCancel()
-	err := t.listener.Close()
+	var err error
+	if t.packetConn != nil {
+		err = t.packetConn.Close()
+	} else {
+		err = t.listener.Close()
+	}
 	t.openConnections.Wait()
 	close(t.messages)
 	t.handler.Stop()
@@ -353,6 +459,9 @@ func (t *SyslogTarget) Stop() error {
 
 // ListenAddress returns the address SyslogTarget is listening on.
 func (t *SyslogTarget) ListenAddress() net.Addr {
+	if t.packetConn != nil {
+		return t.packetConn.LocalAddr()
+	}
 	return t.listener.Addr()
 }
 
@@ -363,6 +472,20 @@ func (t *SyslogTarget) idleTimeout() time.Duration {
 	return defaultIdleTimeout
 }
 
+func (t *SyslogTarget) protocol() string {
+	if t.config.ListenProtocol != "" {
+		return t.config.ListenProtocol
+	}
+	return defaultProtocol
+}
+
+func (t *SyslogTarget) syslogFormat() string {
+	if t.config.SyslogFormat != "" {
+		return t.config.SyslogFormat
+	}
+	return defaultSyslogFormat
+}
+
 func (t *SyslogTarget) maxMessageLength() int {
 	if t.config.MaxMessageLength != 0 {
 		return t.config.MaxMessageLength
@@ -291,6 +291,145 @@ func testSyslogTarget(t *testing.T, octetCounting bool) {
 	require.NotZero(t, client.Received()[0].Timestamp)
 }
 
+func TestSyslogTarget_RFC3164(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		protocol      string
+		octetCounting bool
+	}{
+		{name: "tcp newline separated", protocol: "tcp"},
+		{name: "tcp octet counting", protocol: "tcp", octetCounting: true},
+		{name: "udp", protocol: "udp"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.New(func() {})
+
+			metrics := NewMetrics(nil)
+			tgt, err := NewSyslogTarget(metrics, log.NewNopLogger(), client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+				ListenAddress:  "127.0.0.1:0",
+				ListenProtocol: tc.protocol,
+				SyslogFormat:   "rfc3164",
+				Labels: model.LabelSet{
+					"test": "syslog_target",
+				},
+			})
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, tgt.Stop())
+			}()
+
+			c, err := net.Dial(tc.protocol, tgt.ListenAddress().String())
+			require.NoError(t, err)
+
+			messages := []string{
+				`<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`,
+				`<13>Oct 11 22:14:16 mymachine kernel: link down`,
+			}
+			if tc.protocol == "udp" {
+				// Each datagram is a message.
+				for _, m := range messages {
+					_, err = c.Write([]byte(m))
+					require.NoError(t, err)
+				}
+			} else {
+				require.NoError(t, writeMessagesToStream(c, messages, tc.octetCounting))
+			}
+			require.NoError(t, c.Close())
+
+			require.Eventuallyf(t, func() bool {
+				return len(client.Received()) == len(messages)
+			}, time.Second, time.Millisecond, "Expected to receive %d messages, got %d.", len(messages), len(client.Received()))
+
+			require.Equal(t, model.LabelSet{
+				"test": "syslog_target",
+
+				"severity": "critical",
+				"facility": "auth",
+				"hostname": "mymachine",
+				"app_name": "su",
+				"proc_id":  "123",
+			}, client.Received()[0].Labels)
+			require.Equal(t, "'su root' failed for lonvick on /dev/pts/8", client.Received()[0].Line)
+			require.Equal(t, time.October, client.Received()[0].Timestamp.Month())
+			require.Equal(t, "link down", client.Received()[1].Line)
+		})
+	}
+}
+
+func TestSyslogTarget_UDP(t *testing.T) {
+	client := fake.New(func() {})
+
+	metrics := NewMetrics(nil)
+	tgt, err := NewSyslogTarget(metrics, log.NewNopLogger(), client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+		ListenAddress:       "127.0.0.1:0",
+		ListenProtocol:      "udp",
+		LabelStructuredData: true,
+		Labels: model.LabelSet{
+			"test": "syslog_target",
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tgt.Stop())
+	}()
+
+	c, err := net.Dial("udp", tgt.ListenAddress().String())
+	require.NoError(t, err)
+	_, err = c.Write([]byte(`<165>1 2018-10-11T22:14:15.003Z host5 e - id1 [custom@32473 exkey="1"] An application event log entry...` + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 1
+	}, time.Second, time.Millisecond)
+
+	require.Equal(t, model.LabelSet{
+		"test": "syslog_target",
+
+		"severity": "notice",
+		"facility": "local4",
+		"hostname": "host5",
+		"app_name": "e",
+		"msg_id":   "id1",
+
+		"sd_custom_exkey": "1",
+	}, client.Received()[0].Labels)
+	require.Equal(t, "An application event log entry...", client.Received()[0].Line)
+}
+
+func TestSyslogTarget_InvalidConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		config      scrapeconfig.SyslogTargetConfig
+		expectedErr string
+	}{
+		{
+			name:        "unsupported protocol",
+			config:      scrapeconfig.SyslogTargetConfig{ListenProtocol: "sctp"},
+			expectedErr: `error setting up syslog target: unsupported protocol "sctp"`,
+		},
+		{
+			name:        "unsupported format",
+			config:      scrapeconfig.SyslogTargetConfig{SyslogFormat: "rfc3339"},
+			expectedErr: `error setting up syslog target: unsupported syslog format "rfc3339"`,
+		},
+		{
+			name: "tls over udp",
+			config: scrapeconfig.SyslogTargetConfig{
+				ListenProtocol: "udp",
+				TLSConfig:      promconfig.TLSConfig{CertFile: "cert", KeyFile: "key"},
+			},
+			expectedErr: "error setting up syslog target: TLS is not supported over udp",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.ListenAddress = "127.0.0.1:0"
+			_, err := NewSyslogTarget(NewMetrics(nil), log.NewNopLogger(), fake.New(func() {}), relabelConfig(t), &tc.config)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
 func relabelConfig(t *testing.T) []*relabel.Config {
 	relabelCfg := `
 - source_labels: ['__syslog_message_severity']
@@ -22,14 +22,16 @@ import (
 )
 
 var (
-	app        = kingpin.New("logcli", "A command-line for loki.").Version(version.Print("logcli"))
-	quiet      = app.Flag("quiet", "Suppress query metadata").Default("false").Short('q').Bool()
-	statistics = app.Flag("stats", "Show query statistics").Default("false").Bool()
-	outputMode = app.Flag("output", "Specify output mode [default, raw, jsonl]. raw suppresses log labels and timestamp.").Default("default").Short('o').Enum("default", "raw", "jsonl")
-	timezone   = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").Default("Local").Short('z').Enum("Local", "UTC")
-	cpuProfile = app.Flag("cpuprofile", "Specify the location for writing a CPU profile.").Default("").String()
-	memProfile = app.Flag("memprofile", "Specify the location for writing a memory profile.").Default("").String()
-	stdin      = app.Flag("stdin", "Take input logs from stdin").Bool()
+	app            = kingpin.New("logcli", "A command-line for loki.").Version(version.Print("logcli"))
+	quiet          = app.Flag("quiet", "Suppress query metadata").Default("false").Short('q').Bool()
+	statistics     = app.Flag("stats", "Show query statistics").Default("false").Bool()
+	outputMode     = app.Flag("output", "Specify output mode [default, raw, jsonl, csv, parquet, template]. raw suppresses log labels and timestamp.").Default("default").Short('o').Enum("default", "raw", "jsonl", "csv", "parquet", "template")
+	outputColumns  = app.Flag("columns", "Comma separated list of the columns of the csv and parquet output modes: timestamp, labels, line, or the name of a label. Defaults to timestamp, labels and line.").Default("").String()
+	outputTemplate = app.Flag("template", "Go template formatting each entry in the template output mode, for example '{{.Timestamp}} {{.Labels.level}} {{.Line}}'.").Default("").String()
+	timezone       = app.Flag("timezone", "Specify the timezone to use when formatting output timestamps [Local, UTC]").Default("Local").Short('z').Enum("Local", "UTC")
+	cpuProfile     = app.Flag("cpuprofile", "Specify the location for writing a CPU profile.").Default("").String()
+	memProfile     = app.Flag("memprofile", "Specify the location for writing a memory profile.").Default("").String()
+	stdin          = app.Flag("stdin", "Take input logs from stdin").Bool()
 
 	queryClient = newQueryClient(app)
 
@@ -41,6 +43,9 @@ returned in a few output modes:
 	raw: log line
 	default: log timestamp + log labels + log line
 	jsonl: JSON response from Loki API of log line
+	csv: CSV records of the columns selected with --columns
+	parquet: Parquet file of the columns selected with --columns
+	template: log line formatted with the Go template of --template
 
 The output of the log can be specified with the "-o" flag, for
 example, "-o raw" for the raw output format.
@@ -74,10 +79,12 @@ build graphs, similar to what is seen in the Grafana Explore graph view.
 If you are querying metrics and just want the most recent data point
 (like what is seen in the Grafana Explore table view), then you should use
 the "instant-query" command instead.`)
-	rangeQuery = newQuery(false, queryCmd)
-	tail       = queryCmd.Flag("tail", "Tail the logs").Short('t').Default("false").Bool()
-	follow     = queryCmd.Flag("follow", "Alias for --tail").Short('f').Default("false").Bool()
-	delayFor   = queryCmd.Flag("delay-for", "Delay in tailing by number of seconds to accumulate logs for re-ordering").Default("0").Int()
+	rangeQuery     = newQuery(false, queryCmd)
+	tail           = queryCmd.Flag("tail", "Tail the logs").Short('t').Default("false").Bool()
+	follow         = queryCmd.Flag("follow", "Alias for --tail").Short('f').Default("false").Bool()
+	delayFor       = queryCmd.Flag("delay-for", "Delay in tailing by number of seconds to accumulate logs for re-ordering").Default("0").Int()
+	maxReconnects  = queryCmd.Flag("max-reconnects", "Maximum number of consecutive reconnections when the connection of a tail is lost, resuming from the timestamp of the last entry received. 0 disables reconnections.").Default("10").Int()
+	clientPipeline = queryCmd.Flag("client-pipeline", "Tail only the stream selector of the query and apply its pipeline to the tailed entries, for the servers which can't tail queries with a pipeline.").Default("false").Bool()
 
 	instantQueryCmd = app.Command("instant-query", `Run an instant LogQL query.
 
@@ -179,6 +186,8 @@ func main() {
 			Timezone:      location,
 			NoLabels:      rangeQuery.NoLabels,
 			ColoredOutput: rangeQuery.ColoredOutput,
+			Columns:       splitColumns(*outputColumns),
+			Template:      *outputTemplate,
 		}
 
 		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
@@ -187,10 +196,19 @@ func main() {
 		}
 
 		if *tail || *follow {
+			if *outputMode == "parquet" {
+				log.Fatalf("The parquet output mode cannot be used to tail logs")
+			}
+			if rangeQuery.LocalConfig != "" {
+				log.Fatalf("Logs cannot be tailed from the storage, only Loki servers can be tailed")
+			}
+			rangeQuery.TailMaxReconnects = *maxReconnects
+			rangeQuery.TailClientPipeline = *clientPipeline
 			rangeQuery.TailQuery(time.Duration(*delayFor)*time.Second, queryClient, out)
 		} else {
 			rangeQuery.DoQuery(queryClient, out, *statistics)
 		}
+		closeOutput(out)
 	case instantQueryCmd.FullCommand():
 		location, err := time.LoadLocation(*timezone)
 		if err != nil {
@@ -201,6 +219,8 @@ func main() {
 			Timezone:      location,
 			NoLabels:      instantQuery.NoLabels,
 			ColoredOutput: instantQuery.ColoredOutput,
+			Columns:       splitColumns(*outputColumns),
+			Template:      *outputTemplate,
 		}
 
 		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
@@ -209,6 +229,7 @@ func main() {
 		}
 
 		instantQuery.DoQuery(queryClient, out, *statistics)
+		closeOutput(out)
 	case labelsCmd.FullCommand():
 		labelsQuery.DoLabels(queryClient)
 	case seriesCmd.FullCommand():
@@ -216,6 +237,22 @@ func main() {
 	}
 }
 
+func splitColumns(columns string) []string {
+	if columns == "" {
+		return nil
+	}
+	return strings.Split(columns, ",")
+}
+
+// closeOutput completes the export of the entries by the output modes which require it.
+func closeOutput(out output.LogOutput) {
+	if exportOutput, ok := out.(output.ExportOutput); ok {
+		if err := exportOutput.Close(); err != nil {
+			log.Fatalf("Unable to write log output: %s", err)
+		}
+	}
+}
+
 func newQueryClient(app *kingpin.Application) client.Client {
 
 	client := &client.DefaultClient{
@@ -270,6 +307,7 @@ func newLabelQuery(cmd *kingpin.CmdClause) *labelquery.LabelQuery {
 	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
 	cmd.Flag("from", "Start looking for labels at this absolute time (inclusive)").StringVar(&from)
 	cmd.Flag("to", "Stop looking for labels at this absolute time (exclusive)").StringVar(&to)
+	cmd.Flag("store-config", "Find the labels in the storage configured in a given Loki configuration file, instead of a Loki server.").Default("").StringVar(&q.LocalConfig)
 
 	return q
 }
@@ -298,6 +336,7 @@ func newSeriesQuery(cmd *kingpin.CmdClause) *seriesquery.SeriesQuery {
 	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
 	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
 	cmd.Flag("analyze-labels", "Printout a summary of labels including count of label value combinations, useful for debugging high cardinality series").BoolVar(&q.AnalyzeLabels)
+	cmd.Flag("store-config", "Find the series in the storage configured in a given Loki configuration file, instead of a Loki server.").Default("").StringVar(&q.LocalConfig)
 
 	return q
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/backup"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	"github.com/grafana/loki/pkg/util/cfg"
+)
+
+// backupConfig is the Loki config along with the flags of the backup subcommands.
+type backupConfig struct {
+	loki.ConfigWrapper `yaml:",inline"`
+
+	Store              string `yaml:"-"`
+	StoreConfigFile    string `yaml:"-"`
+	Prefix             string `yaml:"-"`
+	Incremental        bool   `yaml:"-"`
+	ID                 string `yaml:"-"`
+	ChunksFromConfFile string `yaml:"-"`
+}
+
+func (c *backupConfig) RegisterFlags(f *flag.FlagSet) {
+	c.ConfigWrapper.RegisterFlags(f)
+	f.StringVar(&c.Store, "backup.store", "", "Object store of the backup bucket. Supported types: gcs, s3, azure, swift, filesystem.")
+	f.StringVar(&c.StoreConfigFile, "backup.store.config.file", "", "Loki config file whose storage_config block configures the backup bucket.")
+	f.StringVar(&c.Prefix, "backup.prefix", "backups/", "Prefix of Object Keys in the backup bucket under which the backups are stored.")
+	f.BoolVar(&c.Incremental, "backup.incremental", false, "Only copy the index files which changed since the latest backup.")
+	f.StringVar(&c.ID, "backup.id", "", "ID of the backup to restore. Empty restores the latest backup.")
+	f.StringVar(&c.ChunksFromConfFile, "restore.chunks-from.config.file", "", "Loki config file of the backed up cluster, whose chunks under the prefixes of the backup are copied to the chunk stores of the restored cluster. The backups don't contain the chunks, the ones removed from the backed up cluster since the backup, e.g. by retention, can't be restored. Empty only restores the index.")
+}
+
+func (c *backupConfig) Clone() flagext.Registerer {
+	return func(c backupConfig) *backupConfig {
+		return &c
+	}(*c)
+}
+
+func (c *backupConfig) ApplyDynamicConfig() cfg.Source {
+	apply := c.ConfigWrapper.ApplyDynamicConfig()
+	return func(dst cfg.Cloneable) error {
+		r, ok := dst.(*backupConfig)
+		if !ok {
+			return errors.New("dst is not a backup config")
+		}
+		return apply(&r.ConfigWrapper)
+	}
+}
+
+// runBackup runs the backup subcommand, either create, restore or list.
+func runBackup(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: loki backup create|restore|list [flags]")
+	}
+
+	var c backupConfig
+	if err := cfg.DynamicUnmarshal(&c, args[1:], flag.NewFlagSet("backup", flag.ExitOnError)); err != nil {
+		return fmt.Errorf("failed parsing config: %w", err)
+	}
+	util_log.InitLogger(&c.Server)
+	if err := c.SchemaConfig.Load(); err != nil {
+		return err
+	}
+	if c.Store == "" {
+		return errors.New("the object store of the backup bucket must be set with -backup.store")
+	}
+
+	backupStorage := c.StorageConfig
+	if c.StoreConfigFile != "" {
+		storeConfig, err := loadLokiConfig(c.StoreConfigFile)
+		if err != nil {
+			return err
+		}
+		backupStorage = storeConfig.StorageConfig
+	}
+	backupClient, err := storage.NewObjectClient(c.Store, backupStorage.Config)
+	if err != nil {
+		return err
+	}
+	backups := backup.New(backupClient, c.Prefix, util_log.Logger)
+
+	ctx := context.Background()
+	switch args[0] {
+	case "create":
+		return createBackup(ctx, &c, backups)
+	case "restore":
+		return restoreBackup(ctx, &c, backups)
+	case "list":
+		ids, err := backups.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown backup subcommand %q, expected create, restore or list", args[0])
+	}
+}
+
+func createBackup(ctx context.Context, c *backupConfig, backups *backup.Backups) error {
+	index, err := indexClient(c.Config)
+	if err != nil {
+		return err
+	}
+	chunkStores, err := chunkStores(c.Config)
+	if err != nil {
+		return err
+	}
+
+	var base *backup.Manifest
+	if c.Incremental {
+		ids, err := backups.List(ctx)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			if base, err = backups.Manifest(ctx, ids[len(ids)-1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Leave out the prefixes of the objects which are not chunks.
+	exclude := []string{
+		c.StorageConfig.BoltDBShipperConfig.SharedStoreKeyPrefix,
+		c.CompactorConfig.SharedStoreKeyPrefix,
+		c.CompactorConfig.ReportsKeyPrefix,
+		c.CompactorConfig.VolumeKeyPrefix,
+		c.CompactorConfig.UsageKeyPrefix,
+		c.Prefix,
+	}
+	m, err := backups.Create(ctx, index, chunkStores, exclude, base, time.Now())
+	if err != nil {
+		return err
+	}
+	level.Info(util_log.Logger).Log("msg", "backup created", "id", m.ID, "base", m.Base, "tables", len(m.Tables))
+	return nil
+}
+
+func restoreBackup(ctx context.Context, c *backupConfig, backups *backup.Backups) error {
+	id := c.ID
+	if id == "" {
+		ids, err := backups.List(ctx)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return errors.New("no backup to restore")
+		}
+		id = ids[len(ids)-1]
+	}
+	m, err := backups.Manifest(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	index, err := indexClient(c.Config)
+	if err != nil {
+		return err
+	}
+	files, err := backups.RestoreIndex(ctx, m, index)
+	if err != nil {
+		return err
+	}
+	level.Info(util_log.Logger).Log("msg", "index restored", "id", m.ID, "files", files)
+
+	if c.ChunksFromConfFile == "" {
+		return nil
+	}
+	from, err := loadLokiConfig(c.ChunksFromConfFile)
+	if err != nil {
+		return err
+	}
+	fromStores, err := chunkStores(from)
+	if err != nil {
+		return err
+	}
+	toStores, err := chunkStores(c.Config)
+	if err != nil {
+		return err
+	}
+	chunks, err := backup.RestoreChunks(ctx, m, fromStores, toStores)
+	if err != nil {
+		return err
+	}
+	level.Info(util_log.Logger).Log("msg", "chunks restored", "id", m.ID, "chunks", chunks)
+	return nil
+}
+
+// indexClient returns the client of the boltdb-shipper index in the shared store.
+func indexClient(c loki.Config) (shipper_storage.Client, error) {
+	objectClient, err := storage.NewObjectClient(c.StorageConfig.BoltDBShipperConfig.SharedStoreType, c.StorageConfig.BoltDBShipperConfig.SharedStoreConfig.Apply(c.StorageConfig.Config))
+	if err != nil {
+		return nil, err
+	}
+	return shipper_storage.NewIndexStorageClient(objectClient, c.StorageConfig.BoltDBShipperConfig.SharedStoreKeyPrefix), nil
+}
+
+// chunkStores returns the object clients of the chunk stores of the boltdb-shipper periods, keyed by object type.
+func chunkStores(c loki.Config) (map[string]chunk.ObjectClient, error) {
+	stores := map[string]chunk.ObjectClient{}
+	for _, p := range c.SchemaConfig.Configs {
+		if p.IndexType != shipper.BoltDBShipperType || p.ObjectType == "" {
+			continue
+		}
+		if _, ok := stores[p.ObjectType]; ok {
+			continue
+		}
+		objectClient, err := storage.NewObjectClient(p.ObjectType, c.StorageConfig.Config)
+		if err != nil {
+			return nil, err
+		}
+		stores[p.ObjectType] = objectClient
+	}
+	return stores, nil
+}
+
+// loadLokiConfig loads a Loki config file on top of the defaults.
+func loadLokiConfig(path string) (loki.Config, error) {
+	var c loki.ConfigWrapper
+	if err := cfg.Unmarshal(&c, cfg.Defaults(flag.NewFlagSet("", flag.ContinueOnError)), cfg.YAML(path, true), c.ApplyDynamicConfig(), cfg.YAML(path, true)); err != nil {
+		return loki.Config{}, fmt.Errorf("failed parsing config file %s: %w", path, err)
+	}
+	if err := c.SchemaConfig.Load(); err != nil {
+		return loki.Config{}, err
+	}
+	return c.Config, nil
+}
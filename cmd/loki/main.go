@@ -21,6 +21,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackup(os.Args[2:]); err != nil {
+			level.Error(util_log.Logger).Log("msg", "backup failed", "err", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	var config loki.ConfigWrapper
 
 	if err := cfg.DynamicUnmarshal(&config, os.Args[1:], flag.CommandLine); err != nil {
@@ -31,6 +39,13 @@ func main() {
 		fmt.Println(version.Print("loki"))
 		os.Exit(0)
 	}
+	if config.PrintSchema {
+		if err := cfg.PrintSchema(os.Stdout, &config.Config, flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "failed printing config schema: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
 	// This global is set to the config passed into the last call to `NewOverrides`. If we don't
 	// call it atleast once, the defaults are set to an empty struct.
@@ -87,6 +102,8 @@ func main() {
 		}()
 	}
 
+	config.Profiling.Apply()
+
 	// Start Loki
 	t, err := loki.New(config.Config)
 	util_log.CheckFatal("initialising loki", err)
@@ -50,6 +50,11 @@ func main() {
 	batch := flag.Int("batchLen", 500, "Specify how many chunks to read/write in one batch")
 	shardBy := flag.Duration("shardBy", 6*time.Hour, "Break down the total interval into shards of this size, making this too small can lead to syncing a lot of duplicate chunks")
 	parallel := flag.Int("parallel", 8, "How many parallel threads to process each shard")
+	stateFile := flag.String("state.file", "", "Optional file recording the shards migrated successfully, a later run with the same from, to and shardBy only migrates the remaining shards")
+	skipExisting := flag.Bool("skip-existing", false, "Skip the chunks already indexed for the dest tenant, useful to incrementally migrate a tenant still receiving data")
+	verify := flag.Bool("verify", false, "Verify that all the chunks of the source tenant are indexed for the dest tenant once migrated")
+	verifyOnly := flag.Bool("verify-only", false, "Only verify that all the chunks of the source tenant are indexed for the dest tenant, without migrating anything")
+	yes := flag.Bool("yes", false, "Do not ask for confirmation and exit once done instead of sleeping, to run as a job. The exit code is non-zero on failure")
 	flag.Parse()
 
 	// Create a set of defaults
@@ -154,16 +159,18 @@ func main() {
 	for i := range schemaGroups {
 		totalChunks += len(schemaGroups[i])
 	}
-	rdr := bufio.NewReader(os.Stdin)
 	fmt.Printf("Timespan will sync %v chunks spanning %v schemas.\n", totalChunks, len(fetchers))
-	fmt.Print("Proceed? (Y/n):")
-	in, err := rdr.ReadString('\n')
-	if err != nil {
-		log.Fatalf("Error reading input: %v", err)
-	}
-	if strings.ToLower(strings.TrimSpace(in)) == "n" {
-		log.Println("Exiting")
-		os.Exit(0)
+	if !*yes && !*verifyOnly {
+		rdr := bufio.NewReader(os.Stdin)
+		fmt.Print("Proceed? (Y/n):")
+		in, err := rdr.ReadString('\n')
+		if err != nil {
+			log.Fatalf("Error reading input: %v", err)
+		}
+		if strings.ToLower(strings.TrimSpace(in)) == "n" {
+			log.Println("Exiting")
+			os.Exit(0)
+		}
 	}
 	start := time.Now()
 
@@ -172,8 +179,26 @@ func main() {
 	log.Printf("With a shard duration of %v, %v ranges have been calculated.\n", shardByNs, len(syncRanges))
 
 	cm := newChunkMover(ctx, s, d, *source, *dest, matchers, *batch)
+	cm.skipExisting = *skipExisting
+
+	if *verifyOnly {
+		if !verifyRanges(ctx, cm, syncRanges) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *stateFile != "" {
+		cm.state, err = loadSyncState(*stateFile)
+		if err != nil {
+			log.Println("Failed to load state file:", err)
+			os.Exit(1)
+		}
+	}
+
 	syncChan := make(chan *syncRange)
-	errorChan := make(chan error)
+	// Buffered so that the processing threads never block on reporting an error.
+	errorChan := make(chan error, *parallel)
 	statsChan := make(chan stats)
 
 	// Start the parallel processors
@@ -192,8 +217,17 @@ func main() {
 		i := 0
 		length := len(syncRanges)
 		for i < length {
+			if cm.state != nil && cm.state.isDone(syncRanges[i]) {
+				log.Printf("Skipping sync range %v of %v, already migrated\n", i+1, length)
+				i++
+				continue
+			}
 			log.Printf("Dispatching sync range %v of %v\n", i+1, length)
-			syncChan <- syncRanges[i]
+			select {
+			case syncChan <- syncRanges[i]:
+			case <-cancelContext.Done():
+				return
+			}
 			i++
 		}
 		// Everything processed, exit
@@ -214,17 +248,35 @@ func main() {
 	}()
 
 	// Wait for an error or the context to be canceled
+	failed := false
 	select {
 	case <-cancelContext.Done():
 		log.Println("Received done call")
 	case err := <-errorChan:
 		log.Println("Received an error from processing thread, shutting down: ", err)
+		failed = true
 		cancelFunc()
 	}
 	log.Println("Waiting for threads to exit")
 	wg.Wait()
 	close(statsChan)
 	log.Println("All threads finished")
+	// The last sync ranges may have failed after all of them were dispatched.
+	if len(errorChan) > 0 {
+		log.Println("Received an error from processing thread: ", <-errorChan)
+		failed = true
+	}
+
+	if *verify && !failed {
+		failed = !verifyRanges(ctx, cm, syncRanges)
+	}
+
+	if *yes {
+		if failed {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
 	log.Println("Going to sleep....")
 	for {
@@ -270,6 +322,11 @@ type chunkMover struct {
 	destUser   string
 	matchers   []*labels.Matcher
 	batch      int
+
+	// skipExisting skips the chunks already indexed for the dest tenant.
+	skipExisting bool
+	// state records the sync ranges migrated successfully, it is nil when not tracked.
+	state *syncState
 }
 
 func newChunkMover(ctx context.Context, source, dest storage.Store, sourceUser, destUser string, matchers []*labels.Matcher, batch int) *chunkMover {
@@ -302,6 +359,17 @@ func (m *chunkMover) moveChunks(ctx context.Context, threadID int, syncRangeCh <
 				errCh <- err
 				return
 			}
+			if m.skipExisting {
+				existing, err := chunkIDs(m.ctx, m.dest, m.destUser, sr, m.matchers)
+				if err != nil {
+					log.Println(threadID, "Error querying dest index for chunk refs:", err)
+					errCh <- err
+					return
+				}
+				for i := range schemaGroups {
+					schemaGroups[i] = filterChunks(schemaGroups[i], existing)
+				}
+			}
 			for i, f := range fetchers {
 				log.Printf("%v Processing Schema %v which contains %v chunks\n", threadID, i, len(schemaGroups[i]))
 
@@ -383,6 +451,13 @@ func (m *chunkMover) moveChunks(ctx context.Context, threadID int, syncRangeCh <
 					log.Println(threadID, "Batch sent successfully")
 				}
 			}
+			if m.state != nil {
+				if err := m.state.markDone(sr); err != nil {
+					log.Println(threadID, "Error recording the sync range in the state file:", err)
+					errCh <- err
+					return
+				}
+			}
 			log.Printf("%v Finished processing sync range, %v chunks, %v bytes in %v seconds\n", threadID, totalChunks, totalBytes, time.Since(start).Seconds())
 			statsCh <- stats{
 				totalChunks: totalChunks,
@@ -392,6 +467,28 @@ func (m *chunkMover) moveChunks(ctx context.Context, threadID int, syncRangeCh <
 	}
 }
 
+// verifyRanges logs the number of chunks missing from the dest tenant for each sync range, and returns false if any
+// chunk is missing or if the verification failed.
+func verifyRanges(ctx context.Context, m *chunkMover, syncRanges []*syncRange) bool {
+	ok := true
+	var totalMissing, totalChunks int
+	for i, sr := range syncRanges {
+		missing, total, err := m.verifyRange(ctx, sr)
+		if err != nil {
+			log.Printf("Error verifying sync range %v of %v: %v\n", i+1, len(syncRanges), err)
+			return false
+		}
+		if missing > 0 {
+			log.Printf("Sync range %v of %v (%v to %v) is missing %v of %v chunks\n", i+1, len(syncRanges), time.Unix(0, sr.from).UTC(), time.Unix(0, sr.to).UTC(), missing, total)
+			ok = false
+		}
+		totalMissing += missing
+		totalChunks += total
+	}
+	log.Printf("Verification done, %v of %v chunks are missing from the dest tenant\n", totalMissing, totalChunks)
+	return ok
+}
+
 func mustParse(t string) time.Time {
 	ret, err := time.Parse(time.RFC3339Nano, t)
 	if err != nil {
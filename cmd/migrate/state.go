@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// syncState records the sync ranges which were migrated successfully in a file, so that a later run with the same
+// time range and shard duration only migrates the remaining ones.
+type syncState struct {
+	mtx  sync.Mutex
+	file *os.File
+	done map[syncRange]struct{}
+}
+
+func loadSyncState(path string) (*syncState, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &syncState{file: f, done: map[syncRange]struct{}{}}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sr syncRange
+		if _, err := fmt.Sscanf(scanner.Text(), "%d %d", &sr.from, &sr.to); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("invalid line %q in state file %s: %w", scanner.Text(), path, err)
+		}
+		s.done[sr] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *syncState) isDone(sr *syncRange) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.done[*sr]
+	return ok
+}
+
+// markDone records that the sync range was migrated, the record is synced to disk to survive a crash.
+func (s *syncState) markDone(sr *syncRange) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, err := fmt.Fprintf(s.file, "%d %d\n", sr.from, sr.to); err != nil {
+		return err
+	}
+	s.done[*sr] = struct{}{}
+	return s.file.Sync()
+}
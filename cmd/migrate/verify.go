@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// chunkID identifies a chunk in both stores. The tenant and the checksum of a chunk are left out since they change
+// when the chunk is migrated to another tenant.
+type chunkID struct {
+	fingerprint   model.Fingerprint
+	from, through model.Time
+}
+
+func idOf(c chunk.Chunk) chunkID {
+	return chunkID{fingerprint: c.Fingerprint, from: c.From, through: c.Through}
+}
+
+// chunkIDs returns the chunks of the tenant indexed in the store over the sync range.
+func chunkIDs(ctx context.Context, s storage.Store, userID string, sr *syncRange, matchers []*labels.Matcher) (map[chunkID]struct{}, error) {
+	schemaGroups, _, err := s.GetChunkRefs(ctx, userID, model.TimeFromUnixNano(sr.from), model.TimeFromUnixNano(sr.to), matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[chunkID]struct{}{}
+	for _, chunks := range schemaGroups {
+		for _, c := range chunks {
+			ids[idOf(c)] = struct{}{}
+		}
+	}
+	return ids, nil
+}
+
+// filterChunks returns the chunks which are not in ids.
+func filterChunks(chunks []chunk.Chunk, ids map[chunkID]struct{}) []chunk.Chunk {
+	filtered := make([]chunk.Chunk, 0, len(chunks))
+	for _, c := range chunks {
+		if _, ok := ids[idOf(c)]; !ok {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// verifyRange returns the number of chunks of the source tenant over the sync range which are missing from the
+// destination tenant, along with the number of chunks of the source tenant.
+func (m *chunkMover) verifyRange(ctx context.Context, sr *syncRange) (missing, total int, err error) {
+	sourceIDs, err := chunkIDs(ctx, m.source, m.sourceUser, sr, m.matchers)
+	if err != nil {
+		return 0, 0, err
+	}
+	destIDs, err := chunkIDs(ctx, m.dest, m.destUser, sr, m.matchers)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for id := range sourceIDs {
+		if _, ok := destIDs[id]; !ok {
+			missing++
+		}
+	}
+	return missing, len(sourceIDs), nil
+}
@@ -0,0 +1,225 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	logql_log "github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// Results of the check of a chunk.
+const (
+	resultOK        = "ok"
+	resultNotFound  = "not_found"
+	resultCorrupted = "corrupted"
+	resultError     = "error"
+)
+
+// Config configures the storage consistency checker.
+type Config struct {
+	Tenants        flagext.StringSliceCSV `yaml:"tenants"`
+	Interval       time.Duration          `yaml:"interval"`
+	Lookback       time.Duration          `yaml:"lookback"`
+	ChunksPerCheck int                    `yaml:"chunks_per_check"`
+}
+
+// RegisterFlags registers the flags of the checker.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.Var(&cfg.Tenants, "checker.tenants", "Comma-separated list of the tenants whose recently stored data is checked.")
+	f.DurationVar(&cfg.Interval, "checker.interval", time.Minute, "How often the data of each tenant is checked.")
+	f.DurationVar(&cfg.Lookback, "checker.lookback", time.Hour, "Period before now the index entries are sampled from.")
+	f.IntVar(&cfg.ChunksPerCheck, "checker.chunks-per-check", 10, "Maximum number of chunks fetched and decoded per tenant and check.")
+}
+
+// Validate validates the checker config.
+func (cfg *Config) Validate() error {
+	if cfg.Interval <= 0 {
+		return errors.New("checker interval must be > 0")
+	}
+	if cfg.Lookback <= 0 {
+		return errors.New("checker lookback must be > 0")
+	}
+	if cfg.ChunksPerCheck <= 0 {
+		return errors.New("checker chunks per check must be > 0")
+	}
+	return nil
+}
+
+// Store is the part of the chunk store used by the checker.
+type Store interface {
+	LabelNamesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string) ([]string, error)
+	LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error)
+	GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]chunk.SeriesChunkRefs, error)
+	GetChunkFetcher(tm model.Time) *chunk.Fetcher
+}
+
+// Checker continuously samples the recent index entries of tenants and verifies that the chunks they reference can be
+// fetched and decoded. The ratio of the chunks checked successfully is an end-to-end durability SLI of the storage.
+type Checker struct {
+	services.Service
+
+	cfg    Config
+	store  Store
+	logger log.Logger
+	now    func() time.Time
+	rand   *rand.Rand
+
+	chunksChecked *prometheus.CounterVec
+	indexFailures *prometheus.CounterVec
+}
+
+// New returns a checker of the data of the configured tenants in store.
+func New(cfg Config, store Store, logger log.Logger, registerer prometheus.Registerer) (*Checker, error) {
+	if len(cfg.Tenants) == 0 {
+		return nil, errors.New("no tenants configured to check")
+	}
+	c := &Checker{
+		cfg:    cfg,
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		chunksChecked: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "checker_chunks_checked_total",
+			Help:      "The total number of chunks referenced by the index checked by the storage consistency checker, by result: ok, not_found, corrupted or error when the storage failed to respond.",
+		}, []string{"tenant", "result"}),
+		indexFailures: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "checker_index_lookup_failures_total",
+			Help:      "The total number of checks which failed to sample the index entries of a tenant.",
+		}, []string{"tenant"}),
+	}
+	c.Service = services.NewTimerService(cfg.Interval, nil, c.iteration, nil)
+	return c, nil
+}
+
+func (c *Checker) iteration(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Interval)
+	defer cancel()
+	for _, tenant := range c.cfg.Tenants {
+		if err := c.check(user.InjectOrgID(ctx, tenant), tenant); err != nil {
+			c.indexFailures.WithLabelValues(tenant).Inc()
+			level.Warn(c.logger).Log("msg", "failed to sample index entries", "tenant", tenant, "err", err)
+		}
+	}
+	// The failures are exported as metrics, they must not stop the checker.
+	return nil
+}
+
+// check samples the chunks of a series of a label name and value picked at random in the index and checks them.
+func (c *Checker) check(ctx context.Context, tenant string) error {
+	through := model.TimeFromUnixNano(c.now().UnixNano())
+	from := through.Add(-c.cfg.Lookback)
+
+	names, err := c.store.LabelNamesForMetricName(ctx, tenant, from, through, "logs")
+	if err != nil {
+		return fmt.Errorf("label names: %w", err)
+	}
+	names = withoutMetricName(names)
+	if len(names) == 0 {
+		return nil
+	}
+	name := names[c.rand.Intn(len(names))]
+	values, err := c.store.LabelValuesForMetricName(ctx, tenant, from, through, "logs", name)
+	if err != nil {
+		return fmt.Errorf("label values: %w", err)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	value := values[c.rand.Intn(len(values))]
+
+	series, err := c.store.GetSeriesChunkRefs(ctx, tenant, from, through,
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "logs"),
+		labels.MustNewMatcher(labels.MatchEqual, name, value),
+	)
+	if err != nil {
+		return fmt.Errorf("series chunk refs: %w", err)
+	}
+	var chunks []chunk.Chunk
+	for _, s := range series {
+		chunks = append(chunks, s.Chunks...)
+	}
+	c.rand.Shuffle(len(chunks), func(i, j int) { chunks[i], chunks[j] = chunks[j], chunks[i] })
+	if len(chunks) > c.cfg.ChunksPerCheck {
+		chunks = chunks[:c.cfg.ChunksPerCheck]
+	}
+	for _, chk := range chunks {
+		result, err := c.checkChunk(ctx, chk)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "chunk check failed", "tenant", tenant, "chunk", chk.ExternalKey(), "result", result, "err", err)
+		}
+		c.chunksChecked.WithLabelValues(tenant, result).Inc()
+	}
+	return nil
+}
+
+// checkChunk fetches a chunk and iterates over its entries.
+func (c *Checker) checkChunk(ctx context.Context, chk chunk.Chunk) (string, error) {
+	fetcher := c.store.GetChunkFetcher(chk.Through)
+	fetched, err := fetcher.FetchChunks(ctx, []chunk.Chunk{chk}, []string{chk.ExternalKey()})
+	cause := err
+	if storageErr, ok := err.(promql.ErrStorage); ok {
+		cause = storageErr.Err
+	}
+	switch {
+	case err != nil && fetcher.IsChunkNotFoundErr(err):
+		return resultNotFound, err
+	case chunk.IsCorruptChunkErr(cause):
+		return resultCorrupted, err
+	case err != nil:
+		return resultError, err
+	case len(fetched) != 1:
+		return resultNotFound, fmt.Errorf("fetched %d chunks", len(fetched))
+	}
+
+	facade, ok := fetched[0].Data.(*chunkenc.Facade)
+	if !ok {
+		return resultCorrupted, fmt.Errorf("unexpected chunk encoding %s", fetched[0].Data.Encoding())
+	}
+	it, err := facade.LokiChunk().Iterator(ctx, chk.From.Time(), chk.Through.Time().Add(time.Millisecond), logproto.FORWARD, logql_log.NewNoopPipeline().ForStream(chk.Metric))
+	if err != nil {
+		return resultCorrupted, err
+	}
+	defer it.Close()
+	entries := 0
+	for it.Next() {
+		entries++
+	}
+	if err := it.Error(); err != nil {
+		return resultCorrupted, err
+	}
+	if entries == 0 {
+		return resultCorrupted, errors.New("no entries within the chunk time range")
+	}
+	return resultOK, nil
+}
+
+func withoutMetricName(names []string) []string {
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != labels.MetricName {
+			res = append(res, n)
+		}
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package checker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+type fakeStore struct {
+	series  []chunk.SeriesChunkRefs
+	fetcher *chunk.Fetcher
+}
+
+func (s *fakeStore) LabelNamesForMetricName(_ context.Context, _ string, _, _ model.Time, _ string) ([]string, error) {
+	return []string{labels.MetricName, "app"}, nil
+}
+
+func (s *fakeStore) LabelValuesForMetricName(_ context.Context, _ string, _, _ model.Time, _, _ string, _ ...*labels.Matcher) ([]string, error) {
+	return []string{"foo"}, nil
+}
+
+func (s *fakeStore) GetSeriesChunkRefs(_ context.Context, _ string, _, _ model.Time, matchers ...*labels.Matcher) ([]chunk.SeriesChunkRefs, error) {
+	var res []chunk.SeriesChunkRefs
+	for _, series := range s.series {
+		matches := true
+		for _, m := range matchers {
+			matches = matches && m.Matches(series.Labels.Get(m.Name))
+		}
+		if matches {
+			res = append(res, series)
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeStore) GetChunkFetcher(_ model.Time) *chunk.Fetcher {
+	return s.fetcher
+}
+
+func newChunk(t *testing.T, lbs labels.Labels, now time.Time) chunk.Chunk {
+	chk := chunkenc.NewMemChunk(chunkenc.EncGZIP, chunkenc.UnorderedHeadBlockFmt, 256*1024, 0)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, chk.Append(&logproto.Entry{Timestamp: now.Add(time.Duration(i) * time.Second), Line: "line"}))
+	}
+	require.NoError(t, chk.Close())
+	from, through := model.TimeFromUnixNano(now.UnixNano()), model.TimeFromUnixNano(now.Add(9*time.Second).UnixNano())
+	c := chunk.NewChunk("fake", client.Fingerprint(lbs), lbs, chunkenc.NewFacade(chk, 0, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func TestChecker(t *testing.T) {
+	storage := chunk.NewMockStorage()
+	fetcher, err := chunk.NewChunkFetcher(cache.NewNoopCache(), false, 0, storage)
+	require.NoError(t, err)
+	defer fetcher.Stop()
+
+	now := time.Now().Add(-time.Minute)
+	lbs := labels.FromStrings(labels.MetricName, "logs", "app", "foo")
+	stored, missing, corrupted := newChunk(t, lbs, now), newChunk(t, lbs, now.Add(-time.Minute)), newChunk(t, lbs, now.Add(-2*time.Minute))
+	require.NoError(t, storage.PutChunks(context.Background(), []chunk.Chunk{stored, corrupted}))
+	// The stored buffer is the encoded one of the chunk, break its checksum.
+	buf, err := corrupted.Encoded()
+	require.NoError(t, err)
+	buf[len(buf)-1] ^= 0xff
+
+	// The checker only gets the references of the chunks from the index.
+	refs := []chunk.Chunk{}
+	for _, c := range []chunk.Chunk{stored, missing, corrupted} {
+		ref, err := chunk.ParseExternalKey(c.UserID, c.ExternalKey())
+		require.NoError(t, err)
+		ref.Metric = c.Metric
+		refs = append(refs, ref)
+	}
+	store := &fakeStore{
+		series:  []chunk.SeriesChunkRefs{{Labels: lbs, Chunks: refs}},
+		fetcher: fetcher,
+	}
+
+	cfg := Config{Tenants: []string{"fake"}, Interval: time.Minute, Lookback: time.Hour, ChunksPerCheck: 10}
+	require.NoError(t, cfg.Validate())
+	c, err := New(cfg, store, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	require.NoError(t, c.check(user.InjectOrgID(context.Background(), "fake"), "fake"))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.chunksChecked.WithLabelValues("fake", resultOK)))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.chunksChecked.WithLabelValues("fake", resultNotFound)))
+	require.Equal(t, float64(1), testutil.ToFloat64(c.chunksChecked.WithLabelValues("fake", resultCorrupted)))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, (&Config{Interval: time.Minute, Lookback: time.Hour, ChunksPerCheck: 1}).Validate())
+	require.EqualError(t, (&Config{Lookback: time.Hour, ChunksPerCheck: 1}).Validate(), "checker interval must be > 0")
+	require.EqualError(t, (&Config{Interval: time.Minute, ChunksPerCheck: 1}).Validate(), "checker lookback must be > 0")
+	require.EqualError(t, (&Config{Interval: time.Minute, Lookback: time.Hour}).Validate(), "checker chunks per check must be > 0")
+
+	_, err := New(Config{Interval: time.Minute, Lookback: time.Hour, ChunksPerCheck: 1}, &fakeStore{}, log.NewNopLogger(), prometheus.NewRegistry())
+	require.EqualError(t, err, "no tenants configured to check")
+}
@@ -0,0 +1,106 @@
+package chunkenc
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+)
+
+const (
+	// bloomShingleSize is the length in bytes of the line tokens added to the block bloom filters.
+	// Line filters shorter than it can't skip blocks.
+	bloomShingleSize = 4
+	// bloomBitsPerToken and bloomHashes give a false positive rate of about 1%.
+	bloomBitsPerToken = 10
+	bloomHashes       = 7
+)
+
+// blockBloom is a bloom filter over the shingles of the lines of a block, used to skip the blocks which can't
+// contain the substrings line filters look for without decompressing them.
+// It is stored as its bits, an empty filter matches everything.
+type blockBloom []byte
+
+// newBlockBloom builds the bloom filter of the lines of a head block.
+func newBlockBloom(head HeadBlock) blockBloom {
+	if head.IsEmpty() {
+		return nil
+	}
+	mint, maxt := head.Bounds()
+	it := head.Iterator(context.Background(), logproto.FORWARD, mint, maxt+1, noopStreamPipeline)
+	defer it.Close()
+
+	var hashes []uint64
+	for it.Next() {
+		line := it.Entry().Line
+		for i := 0; i+bloomShingleSize <= len(line); i++ {
+			hashes = append(hashes, xxhash.Sum64String(line[i:i+bloomShingleSize]))
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	// Size the filter after the distinct shingles, logs are very repetitive.
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	distinct := 1
+	for i := 1; i < len(hashes); i++ {
+		if hashes[i] != hashes[i-1] {
+			distinct++
+		}
+	}
+
+	b := make(blockBloom, (distinct*bloomBitsPerToken+7)/8)
+	for _, h := range hashes {
+		b.add(h)
+	}
+	return b
+}
+
+// add adds the hash of a shingle, derivating the positions of the bits by double hashing.
+func (b blockBloom) add(h uint64) {
+	m := uint64(len(b)) * 8
+	h1, h2 := h&0xffffffff, h>>32
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % m
+		b[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (b blockBloom) test(h uint64) bool {
+	m := uint64(len(b)) * 8
+	h1, h2 := h&0xffffffff, h>>32
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) % m
+		if b[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mayContainAll returns false when no line of the block can contain all the substrings.
+func (b blockBloom) mayContainAll(substrings [][]byte) bool {
+	if len(b) == 0 {
+		return true
+	}
+	for _, s := range substrings {
+		for i := 0; i+bloomShingleSize <= len(s); i++ {
+			if !b.test(xxhash.Sum64(s[i : i+bloomShingleSize])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// requiredLineSubstrings returns the substrings that every line kept by a pipeline or an extractor contains.
+func requiredLineSubstrings(p interface{}) [][]byte {
+	if h, ok := p.(log.LineFilterHints); ok {
+		return h.RequiredLineSubstrings()
+	}
+	return nil
+}
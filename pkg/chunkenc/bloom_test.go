@@ -0,0 +1,138 @@
+package chunkenc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
+)
+
+func TestBlockBloom(t *testing.T) {
+	hb := &headBlock{}
+	require.Nil(t, newBlockBloom(hb))
+
+	require.NoError(t, hb.Append(1, "level=info msg=hello"))
+	require.NoError(t, hb.Append(2, "level=warn msg=world"))
+	b := newBlockBloom(hb)
+	require.NotEmpty(t, b)
+
+	for _, s := range []string{"hello", "world", "level=warn", "msg=hello"} {
+		require.True(t, b.mayContainAll([][]byte{[]byte(s)}), s)
+	}
+	require.True(t, b.mayContainAll(nil))
+	// Substrings shorter than a shingle can't be looked up.
+	require.True(t, b.mayContainAll([][]byte{[]byte("xyz")}))
+
+	require.False(t, b.mayContainAll([][]byte{[]byte("goodbye")}))
+	require.False(t, b.mayContainAll([][]byte{[]byte("hello"), []byte("level=error")}))
+
+	// Blocks without filter match everything.
+	require.True(t, blockBloom(nil).mayContainAll([][]byte{[]byte("goodbye")}))
+}
+
+func TestMemChunk_BlockBloomFilters(t *testing.T) {
+	fill := func(c *MemChunk) {
+		// The lines of the first half of the blocks are all from the frontend, the others from the backend.
+		for i := 0; i < 1000; i++ {
+			app := "frontend"
+			if i >= 500 {
+				app = "backend"
+			}
+			require.NoError(t, c.Append(logprotoEntry(int64(i), fmt.Sprintf("ts=%d app=%s msg=\"request %d\"", i, app, i))))
+		}
+		require.NoError(t, c.Close())
+	}
+
+	withoutBlooms := NewMemChunk(EncSnappy, DefaultHeadBlockFmt, 2048, 0)
+	fill(withoutBlooms)
+	withBlooms := NewMemChunk(EncSnappy, DefaultHeadBlockFmt, 2048, 0)
+	withBlooms.EnableBlockBloomFilters()
+	fill(withBlooms)
+	require.Greater(t, withBlooms.BlockCount(), 4)
+
+	b, err := withBlooms.Bytes()
+	require.NoError(t, err)
+	loaded, err := NewByteChunk(b, 2048, 0)
+	require.NoError(t, err)
+	require.Equal(t, chunkFormatV4, loaded.format)
+	require.Equal(t, withBlooms.blocks, loaded.blocks)
+
+	for _, tc := range []struct {
+		query         string
+		skippedBlocks bool
+	}{
+		{query: `{app="foo"} |= "backend"`, skippedBlocks: true},
+		{query: `{app="foo"} |= "frontend" |= "request 42"`, skippedBlocks: true},
+		{query: `{app="foo"} |~ "backend.*"`, skippedBlocks: true},
+		{query: `{app="foo"} |= "missing"`, skippedBlocks: true},
+		{query: `{app="foo"} != "backend"`},
+		{query: `{app="foo"} |~ "(?i)BACKEND"`},
+		{query: `{app="foo"} | logfmt | app="backend"`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := logql.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			p, err := expr.Pipeline()
+			require.NoError(t, err)
+
+			expected := chunkLines(t, withoutBlooms, p.ForStream(labels.Labels{}))
+			for _, c := range []*MemChunk{withBlooms, loaded} {
+				require.Equal(t, expected, chunkLines(t, c, p.ForStream(labels.Labels{})))
+			}
+
+			var skipped int
+			for _, blk := range loaded.blocks {
+				if !blk.bloom.mayContainAll(requiredLineSubstrings(p.ForStream(labels.Labels{}))) {
+					skipped++
+				}
+			}
+			if tc.skippedBlocks {
+				require.Greater(t, skipped, 0)
+			} else {
+				require.Equal(t, 0, skipped)
+			}
+		})
+	}
+
+	t.Run("samples", func(t *testing.T) {
+		expr, err := logql.ParseSampleExpr(`count_over_time({app="foo"} |= "backend" [1m])`)
+		require.NoError(t, err)
+		ex, err := expr.Extractor()
+		require.NoError(t, err)
+
+		it := loaded.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, 1000), ex.ForStream(labels.Labels{}))
+		var count int
+		for it.Next() {
+			count++
+		}
+		require.NoError(t, it.Close())
+		require.Equal(t, 500, count)
+	})
+
+	t.Run("rebound", func(t *testing.T) {
+		rebound, err := withBlooms.Rebound(time.Unix(0, 100), time.Unix(0, 900))
+		require.NoError(t, err)
+		for _, blk := range rebound.(*MemChunk).blocks {
+			require.NotEmpty(t, blk.bloom)
+		}
+	})
+}
+
+func chunkLines(t *testing.T, c *MemChunk, pipeline log.StreamPipeline) []string {
+	t.Helper()
+	it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 1000), logproto.FORWARD, pipeline)
+	require.NoError(t, err)
+	var lines []string
+	for it.Next() {
+		lines = append(lines, it.Entry().Line)
+	}
+	require.NoError(t, it.Close())
+	return lines
+}
@@ -17,6 +17,8 @@ import (
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/loki/pkg/storage/chunk/encoding"
@@ -32,6 +34,7 @@ const (
 	chunkFormatV1
 	chunkFormatV2
 	chunkFormatV3
+	chunkFormatV4 // adds a bloom filter per block
 
 	DefaultChunkFormat = chunkFormatV3 // the currently used chunk format
 
@@ -82,6 +85,12 @@ const (
 
 var magicNumber = uint32(0x12EE56A)
 
+var corruptBlocks = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "loki",
+	Name:      "chunk_corrupt_blocks_total",
+	Help:      "Total number of blocks of the chunks skipped as their checksum does not match.",
+})
+
 // The table gets initialized with sync.Once but may still cause a race
 // with any other use of the crc32 package anywhere. Thus we initialize it
 // before.
@@ -116,6 +125,9 @@ type MemChunk struct {
 	format   byte
 	encoding Encoding
 	headFmt  HeadBlockFmt
+
+	// The dictionary the blocks are compressed with, for the zstd chunks.
+	zstdDict *ZstdDictionary
 }
 
 type block struct {
@@ -127,6 +139,8 @@ type block struct {
 
 	offset           int // The offset of the block in the chunk.
 	uncompressedSize int // Total uncompressed size in bytes when the chunk is cut.
+
+	bloom blockBloom // The filter of the shingles of the lines, for chunk format v4+.
 }
 
 // This block holds the un-compressed entries. Once it has enough data, this is
@@ -346,6 +360,21 @@ func NewMemChunk(enc Encoding, head HeadBlockFmt, blockSize, targetSize int) *Me
 	}
 }
 
+// EnableBlockBloomFilters switches the chunk to the format v4, which stores a bloom filter over the shingles of
+// the lines of each block so that line filter queries can skip blocks without decompressing them.
+// It must be called before any block is cut.
+func (c *MemChunk) EnableBlockBloomFilters() {
+	c.format = chunkFormatV4
+}
+
+// SetZstdDictionary compresses the blocks cut afterwards with a dictionary, if the chunk is zstd encoded. The chunk
+// is decoded by the readers which registered the dictionary.
+func (c *MemChunk) SetZstdDictionary(d *ZstdDictionary) {
+	if c.encoding == EncZstd {
+		c.zstdDict = d
+	}
+}
+
 // NewByteChunk returns a MemChunk on the passed bytes.
 func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 	bc := &MemChunk{
@@ -367,7 +396,7 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 	switch version {
 	case chunkFormatV1:
 		bc.encoding = EncGZIP
-	case chunkFormatV2, chunkFormatV3:
+	case chunkFormatV2, chunkFormatV3, chunkFormatV4:
 		// format v2+ has a byte for block encoding.
 		enc := Encoding(db.byte())
 		if db.err() != nil {
@@ -402,9 +431,12 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 
 		// Read offset and length.
 		blk.offset = db.uvarint()
-		if version == chunkFormatV3 {
+		if version >= chunkFormatV3 {
 			blk.uncompressedSize = db.uvarint()
 		}
+		if version >= chunkFormatV4 {
+			blk.bloom = db.bytes(db.uvarint())
+		}
 		l := db.uvarint()
 		blk.b = b[blk.offset : blk.offset+l]
 
@@ -412,6 +444,7 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 		expCRC := binary.BigEndian.Uint32(b[blk.offset+l:])
 		if expCRC != crc32.Checksum(blk.b, castagnoliTable) {
 			_ = level.Error(util_log.Logger).Log("msg", "Checksum does not match for a block in chunk, this block will be skipped", "err", ErrInvalidChecksum)
+			corruptBlocks.Inc()
 			continue
 		}
 
@@ -461,9 +494,12 @@ func (c *MemChunk) BytesSize() int {
 		size += binary.MaxVarintLen64 // mint
 		size += binary.MaxVarintLen64 // maxt
 		size += binary.MaxVarintLen32 // offset
-		if c.format == chunkFormatV3 {
+		if c.format >= chunkFormatV3 {
 			size += binary.MaxVarintLen32 // uncompressed size
 		}
+		if c.format >= chunkFormatV4 {
+			size += binary.MaxVarintLen32 + len(b.bloom) // bloom filter
+		}
 		size += binary.MaxVarintLen32 // len(b)
 	}
 
@@ -535,9 +571,13 @@ func (c *MemChunk) WriteTo(w io.Writer) (int64, error) {
 		eb.putVarint64(b.mint)
 		eb.putVarint64(b.maxt)
 		eb.putUvarint(b.offset)
-		if c.format == chunkFormatV3 {
+		if c.format >= chunkFormatV3 {
 			eb.putUvarint(b.uncompressedSize)
 		}
+		if c.format >= chunkFormatV4 {
+			eb.putUvarint(len(b.bloom))
+			eb.putBytes(b.bloom)
+		}
 		eb.putUvarint(len(b.b))
 	}
 	eb.putHash(crc32Hash)
@@ -741,11 +781,20 @@ func (c *MemChunk) cut() error {
 		return nil
 	}
 
-	b, err := c.head.Serialise(getWriterPool(c.encoding))
+	pool := getWriterPool(c.encoding)
+	if c.zstdDict != nil {
+		pool = c.zstdDict
+	}
+	b, err := c.head.Serialise(pool)
 	if err != nil {
 		return err
 	}
 
+	var bloom blockBloom
+	if c.format >= chunkFormatV4 {
+		bloom = newBlockBloom(c.head)
+	}
+
 	mint, maxt := c.head.Bounds()
 	c.blocks = append(c.blocks, block{
 		b:                b,
@@ -753,6 +802,7 @@ func (c *MemChunk) cut() error {
 		mint:             mint,
 		maxt:             maxt,
 		uncompressedSize: c.head.UncompressedSize(),
+		bloom:            bloom,
 	})
 
 	c.cutBlockSize += len(b)
@@ -929,6 +979,9 @@ func (c *MemChunk) Rebound(start, end time.Time) (Chunk, error) {
 		// For target chunk size I am using compressed size of original chunk since the newChunk should anyways be lower in size than that.
 		newChunk = NewMemChunk(c.Encoding(), c.headFmt, defaultBlockSize, c.CompressedSize())
 	}
+	if c.format >= chunkFormatV4 {
+		newChunk.EnableBlockBloomFilters()
+	}
 
 	for itr.Next() {
 		entry := itr.Entry()
@@ -958,14 +1011,14 @@ type encBlock struct {
 }
 
 func (b encBlock) Iterator(ctx context.Context, pipeline log.StreamPipeline) iter.EntryIterator {
-	if len(b.b) == 0 {
+	if len(b.b) == 0 || !b.bloom.mayContainAll(requiredLineSubstrings(pipeline)) {
 		return iter.NoopIterator
 	}
 	return newEntryIterator(ctx, getReaderPool(b.enc), b.b, pipeline)
 }
 
 func (b encBlock) SampleIterator(ctx context.Context, extractor log.StreamSampleExtractor) iter.SampleIterator {
-	if len(b.b) == 0 {
+	if len(b.b) == 0 || !b.bloom.mayContainAll(requiredLineSubstrings(extractor)) {
 		return iter.NoopIterator
 	}
 	return newSampleIterator(ctx, getReaderPool(b.enc), b.b, extractor)
@@ -1007,7 +1060,7 @@ func (hb *headBlock) Iterator(ctx context.Context, direction logproto.Direction,
 			return
 		}
 		stats.AddHeadChunkBytes(int64(len(e.s)))
-		newLine, parsedLbs, ok := pipeline.ProcessString(e.s)
+		newLine, parsedLbs, ok := pipeline.ProcessString(e.t, e.s)
 		if !ok {
 			return
 		}
@@ -1054,7 +1107,7 @@ func (hb *headBlock) SampleIterator(ctx context.Context, mint, maxt int64, extra
 	series := map[uint64]*logproto.Series{}
 	for _, e := range hb.entries {
 		stats.AddHeadChunkBytes(int64(len(e.s)))
-		value, parsedLabels, ok := extractor.ProcessString(e.s)
+		value, parsedLabels, ok := extractor.ProcessString(e.t, e.s)
 		if !ok {
 			continue
 		}
@@ -1255,7 +1308,7 @@ func (e *entryBufferedIterator) Labels() string { return e.currLabels.String() }
 
 func (e *entryBufferedIterator) Next() bool {
 	for e.bufferedIterator.Next() {
-		newLine, lbs, ok := e.pipeline.Process(e.currLine)
+		newLine, lbs, ok := e.pipeline.Process(e.currTs, e.currLine)
 		if !ok {
 			continue
 		}
@@ -1286,7 +1339,7 @@ type sampleBufferedIterator struct {
 
 func (e *sampleBufferedIterator) Next() bool {
 	for e.bufferedIterator.Next() {
-		val, labels, ok := e.extractor.Process(e.currLine)
+		val, labels, ok := e.extractor.Process(e.currTs, e.currLine)
 		if !ok {
 			continue
 		}
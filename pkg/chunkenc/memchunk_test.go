@@ -277,22 +277,25 @@ func TestRoundtripV2(t *testing.T) {
 func TestRoundtripV3(t *testing.T) {
 	for _, f := range HeadBlockFmts {
 		for _, enc := range testEncoding {
-			t.Run(fmt.Sprintf("%v-%v", f, enc), func(t *testing.T) {
-				t.Parallel()
+			for _, version := range []byte{chunkFormatV3, chunkFormatV4} {
+				f, enc, version := f, enc, version
+				t.Run(fmt.Sprintf("%v-%v-v%d", f, enc, version), func(t *testing.T) {
+					t.Parallel()
 
-				c := NewMemChunk(enc, f, testBlockSize, testTargetSize)
-				c.format = chunkFormatV3
-				_ = fillChunk(c)
+					c := NewMemChunk(enc, f, testBlockSize, testTargetSize)
+					c.format = version
+					_ = fillChunk(c)
 
-				b, err := c.Bytes()
-				require.Nil(t, err)
-				r, err := NewByteChunk(b, testBlockSize, testTargetSize)
-				require.Nil(t, err)
+					b, err := c.Bytes()
+					require.Nil(t, err)
+					r, err := NewByteChunk(b, testBlockSize, testTargetSize)
+					require.Nil(t, err)
 
-				b2, err := r.Bytes()
-				require.Nil(t, err)
-				require.Equal(t, b, b2)
-			})
+					b2, err := r.Bytes()
+					require.Nil(t, err)
+					require.Equal(t, b, b2)
+				})
+			}
 		}
 	}
 }
@@ -677,8 +680,8 @@ func BenchmarkWrite(b *testing.B) {
 
 type nomatchPipeline struct{}
 
-func (nomatchPipeline) Process(line []byte) ([]byte, log.LabelsResult, bool) { return line, nil, false }
-func (nomatchPipeline) ProcessString(line string) (string, log.LabelsResult, bool) {
+func (nomatchPipeline) Process(_ int64, line []byte) ([]byte, log.LabelsResult, bool) { return line, nil, false }
+func (nomatchPipeline) ProcessString(_ int64, line string) (string, log.LabelsResult, bool) {
 	return line, nil, false
 }
 
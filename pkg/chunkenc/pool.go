@@ -3,8 +3,8 @@ package chunkenc
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
-	"runtime"
 	"sync"
 
 	"github.com/golang/snappy"
@@ -212,33 +212,72 @@ func (pool *FlatePool) PutWriter(writer io.WriteCloser) {
 	pool.writers.Put(writer)
 }
 
-// GzipPool is a gun zip compression pool
+// ZstdPool is a zstd compression pool
 type ZstdPool struct {
 	readers sync.Pool
-	writers sync.Pool
+	// dictReaders are the pools of the readers of the frames compressed with a dictionary, by dictionary ID.
+	dictReaders sync.Map
+	writers     sync.Pool
+	level       zstd.EncoderLevel
+}
+
+// ParseZstdLevel parses a zstd compression level by its name: fastest, default, better or best.
+func ParseZstdLevel(name string) (zstd.EncoderLevel, error) {
+	ok, level := zstd.EncoderLevelFromString(name)
+	if !ok {
+		return 0, fmt.Errorf("invalid zstd level: %s, supported: fastest, default, better, best", name)
+	}
+	return level, nil
+}
+
+// SetZstdLevel sets the level of the chunks compressed with zstd, it must be called before any chunk is encoded.
+func SetZstdLevel(level zstd.EncoderLevel) {
+	Zstd.level = level
 }
 
 // GetReader gets or creates a new CompressionReader and reset it to read from src
+// The readers are pooled by the dictionary of the frames they decode, which is loaded the first time one is decoded.
 func (pool *ZstdPool) GetReader(src io.Reader) io.Reader {
-	if r := pool.readers.Get(); r != nil {
-		reader := r.(*zstd.Decoder)
+	dictID, src, err := zstdFrameDictionaryID(src)
+	if err != nil {
+		return errorReader{err: err}
+	}
+	readers := &pool.readers
+	if dictID != 0 {
+		p, _ := pool.dictReaders.LoadOrStore(dictID, &sync.Pool{})
+		readers = p.(*sync.Pool)
+	}
+	if r := readers.Get(); r != nil {
+		reader := r.(*zstdDecoder)
 		err := reader.Reset(src)
 		if err != nil {
 			panic(err)
 		}
 		return reader
 	}
-	reader, err := zstd.NewReader(src)
-	if err != nil {
-		panic(err)
+	var dict []byte
+	if dictID != 0 {
+		if dict, err = zstdDictionary(dictID); err != nil {
+			return errorReader{err: err}
+		}
 	}
-	runtime.SetFinalizer(reader, (*zstd.Decoder).Close)
-	return reader
+	return newZstdDecoder(src, dictID, dict)
 }
 
 // PutReader places back in the pool a CompressionReader
 func (pool *ZstdPool) PutReader(reader io.Reader) {
-	pool.readers.Put(reader)
+	r, ok := reader.(*zstdDecoder)
+	if !ok {
+		return
+	}
+	if r.dictID == 0 {
+		pool.readers.Put(r)
+		return
+	}
+	// The readers of the unregistered dictionaries are released.
+	if p, ok := pool.dictReaders.Load(r.dictID); ok && IsZstdDictionaryRegistered(r.dictID) {
+		p.(*sync.Pool).Put(r)
+	}
 }
 
 // GetWriter gets or creates a new CompressionWriter and reset it to write to dst
@@ -249,7 +288,11 @@ func (pool *ZstdPool) GetWriter(dst io.Writer) io.WriteCloser {
 		return writer
 	}
 
-	w, err := zstd.NewWriter(dst)
+	level := pool.level
+	if level == 0 {
+		level = zstd.SpeedDefault
+	}
+	w, err := zstd.NewWriter(dst, zstd.WithEncoderLevel(level))
 	if err != nil {
 		panic(err) // never happens, error is only returned on wrong compression level.
 	}
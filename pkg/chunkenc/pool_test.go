@@ -2,6 +2,7 @@ package chunkenc
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"runtime"
@@ -57,3 +58,35 @@ func TestPool(t *testing.T) {
 		_ = pprof.Lookup("goroutine").WriteTo(os.Stdout, 1)
 	}
 }
+
+func TestZstdLevels(t *testing.T) {
+	var data []byte
+	for i := 0; i < 1000; i++ {
+		data = append(data, []byte(fmt.Sprintf("level=info ts=%d caller=pool_test.go msg=\"compressing line %d\"\n", i, i%17))...)
+	}
+
+	sizes := map[string]int{}
+	for _, name := range []string{"fastest", "default", "better", "best"} {
+		level, err := ParseZstdLevel(name)
+		require.NoError(t, err)
+		pool := ZstdPool{level: level}
+
+		buf := bytes.NewBuffer(nil)
+		w := pool.GetWriter(buf)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		pool.PutWriter(w)
+		sizes[name] = buf.Len()
+
+		r := pool.GetReader(buf)
+		res, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, res, name)
+		pool.PutReader(r)
+	}
+	require.LessOrEqual(t, sizes["best"], sizes["fastest"])
+
+	_, err := ParseZstdLevel("ultra")
+	require.Error(t, err)
+}
@@ -0,0 +1,437 @@
+package chunkenc
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/klauspost/compress/huff0"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The zstd frames compressed with a dictionary carry the ID of the dictionary in their header, so the chunks
+// compressed with a dictionary keep the EncZstd encoding and are decoded by the zstd decoders as long as the
+// dictionary was registered with RegisterZstdDictionary or can be loaded by the ZstdDictionaryLoader.
+
+var zstdDictMagic = []byte{0x37, 0xa4, 0x30, 0xec}
+
+const (
+	// The dictionary IDs below 32768 are reserved by the zstd format, the ones from 2^31 too.
+	minZstdDictID = 1 << 15
+	maxZstdDictID = 1 << 31
+
+	// dictDmerSize is the size of the substrings of the samples whose frequency scores the segments.
+	dictDmerSize = 8
+	// dictSegmentSize is the size of the segments of the samples the dictionary content is made of.
+	dictSegmentSize = 64
+)
+
+// ErrZstdDictionaryIDCollision is returned when registering a dictionary whose ID is the one of another registered
+// dictionary.
+var ErrZstdDictionaryIDCollision = errors.New("zstd dictionary ID collision")
+
+// ZstdDictionaryLoader loads the dictionary of an ID which isn't registered, for decoding the chunks compressed with it.
+type ZstdDictionaryLoader func(id uint32) ([]byte, error)
+
+// zstdDictionaries are the dictionaries the zstd chunks are decoded with, by ID, and the loader of the other ones.
+var zstdDictionaries = struct {
+	sync.RWMutex
+	dicts  map[uint32][]byte
+	loader ZstdDictionaryLoader
+}{dicts: map[uint32][]byte{}}
+
+// SetZstdDictionaryLoader sets the loader of the dictionaries the zstd chunks are compressed with, which are loaded the
+// first time a chunk compressed with them is decoded.
+func SetZstdDictionaryLoader(loader ZstdDictionaryLoader) {
+	zstdDictionaries.Lock()
+	defer zstdDictionaries.Unlock()
+	zstdDictionaries.loader = loader
+}
+
+// RegisterZstdDictionary registers a dictionary built by TrainZstdDictionary for decoding the zstd chunks compressed
+// with it. Registering a dictionary again is a no-op, registering another dictionary with the same ID fails with
+// ErrZstdDictionaryIDCollision.
+func RegisterZstdDictionary(dict []byte) error {
+	id, err := ZstdDictionaryID(dict)
+	if err != nil {
+		return err
+	}
+	zstdDictionaries.Lock()
+	defer zstdDictionaries.Unlock()
+	if registered, ok := zstdDictionaries.dicts[id]; ok {
+		if !bytes.Equal(registered, dict) {
+			return fmt.Errorf("%w: %d", ErrZstdDictionaryIDCollision, id)
+		}
+		return nil
+	}
+	// Validate the dictionary before the decoders fail to be created with it.
+	if _, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict)); err != nil {
+		return fmt.Errorf("invalid zstd dictionary %d: %w", id, err)
+	}
+	zstdDictionaries.dicts[id] = dict
+	return nil
+}
+
+// UnregisterZstdDictionary releases a dictionary and its decoders, the chunks compressed with it are decoded with the
+// dictionary loaded again by the loader.
+func UnregisterZstdDictionary(id uint32) {
+	zstdDictionaries.Lock()
+	defer zstdDictionaries.Unlock()
+	delete(zstdDictionaries.dicts, id)
+	Zstd.dictReaders.Delete(id)
+}
+
+// IsZstdDictionaryRegistered returns whether the dictionary of an ID is registered.
+func IsZstdDictionaryRegistered(id uint32) bool {
+	zstdDictionaries.RLock()
+	defer zstdDictionaries.RUnlock()
+	_, ok := zstdDictionaries.dicts[id]
+	return ok
+}
+
+// zstdDictionary returns the dictionary of an ID, loading it if it isn't registered yet.
+func zstdDictionary(id uint32) ([]byte, error) {
+	zstdDictionaries.RLock()
+	dict, ok := zstdDictionaries.dicts[id]
+	loader := zstdDictionaries.loader
+	zstdDictionaries.RUnlock()
+	if ok {
+		return dict, nil
+	}
+	if loader == nil {
+		return nil, fmt.Errorf("unknown zstd dictionary %d", id)
+	}
+	dict, err := loader(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load zstd dictionary %d: %w", id, err)
+	}
+	if loaded, err := ZstdDictionaryID(dict); err != nil || loaded != id {
+		return nil, fmt.Errorf("failed to load zstd dictionary %d: got dictionary %d: %v", id, loaded, err)
+	}
+	if err := RegisterZstdDictionary(dict); err != nil {
+		return nil, err
+	}
+	return dict, nil
+}
+
+// zstdDecoder is a decoder created with the dictionary of an ID, none for the ID 0.
+type zstdDecoder struct {
+	*zstd.Decoder
+	dictID uint32
+}
+
+// ZstdDictionaryID returns the ID of a zstd dictionary.
+func ZstdDictionaryID(dict []byte) (uint32, error) {
+	if len(dict) < 8 || string(dict[:4]) != string(zstdDictMagic) {
+		return 0, errors.New("not a zstd dictionary")
+	}
+	return binary.LittleEndian.Uint32(dict[4:8]), nil
+}
+
+// ReassignZstdDictionaryID returns a copy of a dictionary with the first ID following its own for which taken is false,
+// for a dictionary whose ID collides with the one of another dictionary.
+func ReassignZstdDictionaryID(dict []byte, taken func(id uint32) bool) ([]byte, error) {
+	id, err := ZstdDictionaryID(dict)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; taken(id); i++ {
+		if i == maxZstdDictID-minZstdDictID {
+			return nil, errors.New("no zstd dictionary ID left")
+		}
+		if id++; id >= maxZstdDictID {
+			id = minZstdDictID
+		}
+	}
+	reassigned := append([]byte{}, dict...)
+	binary.LittleEndian.PutUint32(reassigned[4:8], id)
+	return reassigned, nil
+}
+
+// ZstdDictionary compresses the blocks of the chunks with a dictionary, see MemChunk.SetZstdDictionary.
+type ZstdDictionary struct {
+	id      uint32
+	dict    []byte
+	writers sync.Pool
+}
+
+// NewZstdDictionary returns the compressor of a dictionary built by TrainZstdDictionary, registering it for decoding.
+func NewZstdDictionary(dict []byte) (*ZstdDictionary, error) {
+	if err := RegisterZstdDictionary(dict); err != nil {
+		return nil, err
+	}
+	id, _ := ZstdDictionaryID(dict)
+	return &ZstdDictionary{id: id, dict: dict}, nil
+}
+
+// ID returns the ID of the dictionary.
+func (d *ZstdDictionary) ID() uint32 {
+	return d.id
+}
+
+// GetWriter gets or creates a new CompressionWriter and reset it to write to dst
+func (d *ZstdDictionary) GetWriter(dst io.Writer) io.WriteCloser {
+	if w := d.writers.Get(); w != nil {
+		writer := w.(*zstd.Encoder)
+		writer.Reset(dst)
+		return writer
+	}
+
+	level := Zstd.level
+	if level == 0 {
+		level = zstd.SpeedDefault
+	}
+	w, err := zstd.NewWriter(dst, zstd.WithEncoderLevel(level), zstd.WithEncoderDict(d.dict))
+	if err != nil {
+		panic(err) // never happens, the dictionary was validated when registered.
+	}
+	return w
+}
+
+// PutWriter places back in the pool a CompressionWriter
+func (d *ZstdDictionary) PutWriter(writer io.WriteCloser) {
+	d.writers.Put(writer)
+}
+
+// TrainZstdDictionary builds a zstd dictionary of at most maxSize bytes from sample log lines. Its content is made
+// of the segments of the samples whose substrings are the most frequent across the samples, the best ones last
+// since they are closest to the compressed data.
+func TrainZstdDictionary(samples [][]byte, maxSize int) ([]byte, error) {
+	content := dictionaryContent(samples, maxSize-zstdDictHeaderMaxSize)
+	if len(content) < dictDmerSize {
+		return nil, errors.New("not enough repeated content in the samples to train a dictionary")
+	}
+
+	// The literals of the blocks compressed with the dictionary can reuse its Huffman table, built from the content
+	// plus every byte so that any literal can be encoded with it.
+	literals := make([]byte, 0, len(content)+256)
+	literals = append(literals, content...)
+	for b := 0; b < 256; b++ {
+		literals = append(literals, byte(b))
+	}
+	var scratch huff0.Scratch
+	if _, _, err := huff0.Compress1X(literals, &scratch); err != nil {
+		return nil, fmt.Errorf("building the literals table: %w", err)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write(content)
+	id := minZstdDictID + h.Sum32()%(maxZstdDictID-minZstdDictID)
+
+	dict := make([]byte, 0, zstdDictHeaderMaxSize+len(content))
+	dict = append(dict, zstdDictMagic...)
+	dict = appendUint32(dict, id)
+	dict = append(dict, scratch.OutTable...)
+	// The sequences of the blocks compressed by the zstd encoder never reuse the tables of the dictionary, which
+	// only need to be valid. They are written in the order of the format: offsets, match and literal lengths.
+	for _, symbols := range []int{maxOffsetCode + 1, maxMatchLengthCode + 1, maxLiteralLengthCode + 1} {
+		dict = appendFlatNormalizedCounts(dict, symbols)
+	}
+	// The initial repeat offsets, the defaults of the format.
+	for _, offset := range []uint32{1, 4, 8} {
+		dict = appendUint32(dict, offset)
+	}
+	return append(dict, content...), nil
+}
+
+const (
+	maxLiteralLengthCode = 35
+	maxOffsetCode        = 30
+	maxMatchLengthCode   = 52
+	// flatTableLog is the log of the size of the FSE tables of the dictionaries.
+	flatTableLog = 6
+	// zstdDictHeaderMaxSize bounds the size of the dictionary before its content: magic, ID, Huffman and FSE tables
+	// and repeat offsets.
+	zstdDictHeaderMaxSize = 8 + 256 + 3*64 + 12
+)
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// appendFlatNormalizedCounts appends the description of an FSE table giving every symbol the same probability, as
+// written by the reference implementation for normalized counts without zeros.
+func appendFlatNormalizedCounts(b []byte, symbols int) []byte {
+	const tableSize = 1 << flatTableLog
+	var (
+		bitStream = uint32(flatTableLog - 5)
+		bitCount  = uint(4)
+		remaining = tableSize + 1
+		threshold = tableSize
+		nbBits    = uint(flatTableLog + 1)
+	)
+	for s := 0; s < symbols && remaining > 1; s++ {
+		count := tableSize / symbols
+		if s < tableSize%symbols {
+			count++
+		}
+		max := (2*threshold - 1) - remaining
+		remaining -= count
+		count++
+		if count >= threshold {
+			count += max
+		}
+		bitStream += uint32(count) << bitCount
+		bitCount += nbBits
+		if count < max {
+			bitCount--
+		}
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+		if bitCount > 16 {
+			b = append(b, byte(bitStream), byte(bitStream>>8))
+			bitStream >>= 16
+			bitCount -= 16
+		}
+	}
+	b = append(b, byte(bitStream), byte(bitStream>>8))
+	return b[:len(b)-2+int(bitCount+7)/8]
+}
+
+// dictionaryContent selects the segments of the samples covering the most frequent substrings, greedily: the score
+// of a segment is the number of samples containing each of its substrings not covered yet by a selected segment.
+func dictionaryContent(samples [][]byte, maxSize int) []byte {
+	frequencies := map[uint64]int{}
+	seen := map[uint64]struct{}{}
+	for _, sample := range samples {
+		for k := range seen {
+			delete(seen, k)
+		}
+		for i := 0; i+dictDmerSize <= len(sample); i++ {
+			dmer := binary.LittleEndian.Uint64(sample[i:])
+			if _, ok := seen[dmer]; !ok {
+				seen[dmer] = struct{}{}
+				frequencies[dmer]++
+			}
+		}
+	}
+
+	score := func(segment []byte) int {
+		total := 0
+		for i := 0; i+dictDmerSize <= len(segment); i++ {
+			// The substrings of a single sample don't repeat across samples.
+			if f := frequencies[binary.LittleEndian.Uint64(segment[i:])]; f > 1 {
+				total += f
+			}
+		}
+		return total
+	}
+
+	candidates := &segmentHeap{}
+	for _, sample := range samples {
+		for start := 0; start+dictDmerSize <= len(sample); start += dictSegmentSize / 2 {
+			end := start + dictSegmentSize
+			if end > len(sample) {
+				end = len(sample)
+			}
+			if s := score(sample[start:end]); s > 0 {
+				*candidates = append(*candidates, scoredSegment{segment: sample[start:end], score: s})
+			}
+		}
+	}
+	heap.Init(candidates)
+
+	var selected [][]byte
+	size := 0
+	for candidates.Len() > 0 && size < maxSize {
+		best := heap.Pop(candidates).(scoredSegment)
+		// The scores only decrease as segments are selected, a candidate whose updated score is still the best is
+		// the best one.
+		if s := score(best.segment); s != best.score {
+			if s > 0 {
+				best.score = s
+				heap.Push(candidates, best)
+			}
+			continue
+		}
+		segment := best.segment
+		if size+len(segment) > maxSize {
+			segment = segment[:maxSize-size]
+		}
+		selected = append(selected, segment)
+		size += len(segment)
+		for i := 0; i+dictDmerSize <= len(segment); i++ {
+			delete(frequencies, binary.LittleEndian.Uint64(segment[i:]))
+		}
+	}
+
+	content := make([]byte, 0, size)
+	for i := len(selected) - 1; i >= 0; i-- {
+		content = append(content, selected[i]...)
+	}
+	return content
+}
+
+type scoredSegment struct {
+	segment []byte
+	score   int
+}
+
+// segmentHeap is a max-heap of segments by score.
+type segmentHeap []scoredSegment
+
+func (h segmentHeap) Len() int            { return len(h) }
+func (h segmentHeap) Less(i, j int) bool  { return h[i].score > h[j].score }
+func (h segmentHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *segmentHeap) Push(x interface{}) { *h = append(*h, x.(scoredSegment)) }
+func (h *segmentHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func newZstdDecoder(src io.Reader, dictID uint32, dict []byte) *zstdDecoder {
+	var opts []zstd.DOption
+	if dict != nil {
+		opts = append(opts, zstd.WithDecoderDicts(dict))
+	}
+	reader, err := zstd.NewReader(src, opts...)
+	if err != nil {
+		panic(err) // never happens, the dictionaries were validated when registered.
+	}
+	d := &zstdDecoder{Decoder: reader, dictID: dictID}
+	runtime.SetFinalizer(d, func(d *zstdDecoder) { d.Close() })
+	return d
+}
+
+// zstdFrameDictionaryID returns the ID of the dictionary the frame read by src was compressed with, 0 for none, and the
+// reader of the frame.
+func zstdFrameDictionaryID(src io.Reader) (uint32, io.Reader, error) {
+	var header []byte
+	if b, ok := src.(interface{ Bytes() []byte }); ok {
+		// Peek the header of the buffers without consuming it.
+		header = b.Bytes()
+	} else {
+		header = make([]byte, zstd.HeaderMaxSize)
+		n, err := io.ReadFull(src, header)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return 0, nil, err
+		}
+		header = header[:n]
+		src = io.MultiReader(bytes.NewReader(header), src)
+	}
+	var h zstd.Header
+	if err := h.Decode(header); err != nil {
+		// The decoder reports the invalid frames.
+		return 0, src, nil
+	}
+	return h.DictionaryID, src, nil
+}
+
+// errorReader is the reader of the frames which can't be decoded.
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,167 @@
+package chunkenc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+)
+
+func sampleLine(r *rand.Rand, i int) string {
+	return fmt.Sprintf(`level=info ts=2021-11-%02dT10:%02d:%02d.%03dZ caller=handler.go:%d msg="request served" method=%s path=/api/v1/users/%d status=%d duration=%dms`,
+		1+r.Intn(28), r.Intn(60), r.Intn(60), r.Intn(1000), 100+r.Intn(50), []string{"GET", "POST", "PUT"}[r.Intn(3)], r.Intn(100000), []int{200, 404, 500}[r.Intn(3)], r.Intn(2000))
+}
+
+func TestZstdDictionary(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	samples := make([][]byte, 0, 2000)
+	for i := 0; i < cap(samples); i++ {
+		samples = append(samples, []byte(sampleLine(r, i)))
+	}
+	raw, err := TrainZstdDictionary(samples, 16<<10)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(raw), 16<<10)
+	dict, err := NewZstdDictionary(raw)
+	require.NoError(t, err)
+	id, err := ZstdDictionaryID(raw)
+	require.NoError(t, err)
+	require.Equal(t, id, dict.ID())
+
+	// Small blocks of short lines benefit the most from a dictionary.
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = sampleLine(r, i)
+	}
+	newChunk := func(d *ZstdDictionary) *MemChunk {
+		c := NewMemChunk(EncZstd, DefaultHeadBlockFmt, 4<<10, 0)
+		c.SetZstdDictionary(d)
+		for i, line := range lines {
+			require.NoError(t, c.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: line}))
+		}
+		require.NoError(t, c.Close())
+		return c
+	}
+	plain, withDict := newChunk(nil), newChunk(dict)
+	require.Less(t, withDict.CompressedSize(), plain.CompressedSize())
+
+	b, err := withDict.Bytes()
+	require.NoError(t, err)
+	decoded, err := NewByteChunk(b, 4<<10, 0)
+	require.NoError(t, err)
+	it, err := decoded.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 1000), logproto.FORWARD, log.NewNoopPipeline().ForStream(nil))
+	require.NoError(t, err)
+	n := 0
+	for ; it.Next(); n++ {
+		require.Equal(t, int64(n), it.Entry().Timestamp.UnixNano())
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, 1000, n)
+}
+
+func TestTrainZstdDictionary_NotEnoughSamples(t *testing.T) {
+	_, err := TrainZstdDictionary([][]byte{[]byte("foo")}, 16<<10)
+	require.Error(t, err)
+}
+
+func TestRegisterZstdDictionary_Invalid(t *testing.T) {
+	require.Error(t, RegisterZstdDictionary([]byte("not a dictionary")))
+	require.Error(t, RegisterZstdDictionary(append(append([]byte{}, zstdDictMagic...), 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14)))
+}
+
+// The dictionaries of testdata/zstd are reference.dict, trained by the reference implementation with
+// `zstd --train --maxdict=4096` from lines like the ones of sampleLine, and trained.dict, built by TrainZstdDictionary
+// from the same lines. The .zst files are lines.txt compressed with them by `zstd -D <dictionary>`.
+func readZstdTestdata(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile("testdata/zstd/" + name)
+	require.NoError(t, err)
+	return b
+}
+
+func TestZstdDictionary_Reference(t *testing.T) {
+	lines := readZstdTestdata(t, "lines.txt")
+	for _, name := range []string{"reference", "trained"} {
+		t.Run(name, func(t *testing.T) {
+			raw := readZstdTestdata(t, name+".dict")
+			dict, err := NewZstdDictionary(raw)
+			require.NoError(t, err)
+
+			// The frames compressed by the reference implementation are decoded.
+			r := Zstd.GetReader(bytes.NewBuffer(readZstdTestdata(t, name+".zst")))
+			decoded, err := ioutil.ReadAll(r)
+			Zstd.PutReader(r)
+			require.NoError(t, err)
+			require.Equal(t, lines, decoded)
+
+			// So are the frames compressed with the dictionary.
+			var compressed bytes.Buffer
+			w := dict.GetWriter(&compressed)
+			_, err = w.Write(lines)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			dict.PutWriter(w)
+			r = Zstd.GetReader(&compressed)
+			decoded, err = ioutil.ReadAll(r)
+			Zstd.PutReader(r)
+			require.NoError(t, err)
+			require.Equal(t, lines, decoded)
+		})
+	}
+}
+
+func TestRegisterZstdDictionary_Collision(t *testing.T) {
+	raw := readZstdTestdata(t, "trained.dict")
+	require.NoError(t, RegisterZstdDictionary(raw))
+	require.NoError(t, RegisterZstdDictionary(raw))
+
+	other := append([]byte{}, raw...)
+	other[len(other)-1]++
+	require.True(t, errors.Is(RegisterZstdDictionary(other), ErrZstdDictionaryIDCollision))
+
+	other, err := ReassignZstdDictionaryID(other, IsZstdDictionaryRegistered)
+	require.NoError(t, err)
+	id, _ := ZstdDictionaryID(raw)
+	otherID, _ := ZstdDictionaryID(other)
+	require.NotEqual(t, id, otherID)
+	require.NoError(t, RegisterZstdDictionary(other))
+	UnregisterZstdDictionary(otherID)
+}
+
+func TestZstdDictionaryLoader(t *testing.T) {
+	raw := readZstdTestdata(t, "reference.dict")
+	id, err := ZstdDictionaryID(raw)
+	require.NoError(t, err)
+	UnregisterZstdDictionary(id)
+	t.Cleanup(func() { SetZstdDictionaryLoader(nil) })
+
+	decode := func() error {
+		r := Zstd.GetReader(bytes.NewBuffer(readZstdTestdata(t, "reference.zst")))
+		defer Zstd.PutReader(r)
+		_, err := ioutil.ReadAll(r)
+		return err
+	}
+	require.Error(t, decode())
+
+	loads := 0
+	SetZstdDictionaryLoader(func(loaded uint32) ([]byte, error) {
+		require.Equal(t, id, loaded)
+		loads++
+		return raw, nil
+	})
+	require.NoError(t, decode())
+	require.NoError(t, decode())
+	require.Equal(t, 1, loads)
+
+	// The dictionary is loaded again once unregistered.
+	UnregisterZstdDictionary(id)
+	require.NoError(t, decode())
+	require.Equal(t, 2, loads)
+}
@@ -0,0 +1,408 @@
+// Package dictionaries trains the per-tenant zstd dictionaries the ingesters compress the chunks with, stores them in
+// the object store and loads them when the chunks compressed with them are decoded.
+package dictionaries
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/util/flagext"
+)
+
+const dictionaryExtension = ".dict"
+
+// Config configures the zstd dictionaries.
+type Config struct {
+	Store            string           `yaml:"store"`
+	KeyPrefix        string           `yaml:"key_prefix"`
+	RefreshPeriod    time.Duration    `yaml:"refresh_period"`
+	TrainPeriod      time.Duration    `yaml:"train_period"`
+	SamplesPerTenant int              `yaml:"samples_per_tenant"`
+	MaxSize          flagext.ByteSize `yaml:"max_size"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Store, "zstd-dictionaries.store", "", "Object store in which the zstd dictionaries of the tenants are kept. Supported types: gcs, s3, azure, swift, filesystem. Empty to disable the dictionaries.")
+	f.StringVar(&cfg.KeyPrefix, "zstd-dictionaries.key-prefix", "zstd-dictionaries/", "Prefix of the keys of the dictionaries in the object store, which must end with a '/'.")
+	f.DurationVar(&cfg.RefreshPeriod, "zstd-dictionaries.refresh-period", 5*time.Minute, "How often the dictionaries are reloaded from the object store.")
+	f.DurationVar(&cfg.TrainPeriod, "zstd-dictionaries.train-period", 24*time.Hour, "How often the ingesters train a new dictionary for a tenant, from the lines sampled since the previous one.")
+	f.IntVar(&cfg.SamplesPerTenant, "zstd-dictionaries.samples-per-tenant", 10000, "Number of lines sampled per tenant by the ingesters to train the dictionaries.")
+	cfg.MaxSize = 64 << 10
+	f.Var(&cfg.MaxSize, "zstd-dictionaries.max-size", "Maximum size of the dictionaries.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if cfg.Store == "" {
+		return nil
+	}
+	if cfg.KeyPrefix == "" || !strings.HasSuffix(cfg.KeyPrefix, "/") || strings.HasPrefix(cfg.KeyPrefix, "/") {
+		return fmt.Errorf("zstd dictionaries key prefix must end with a '/' and not start with one")
+	}
+	if cfg.RefreshPeriod <= 0 || cfg.TrainPeriod <= 0 {
+		return fmt.Errorf("zstd dictionaries refresh and train periods must be > 0")
+	}
+	if cfg.SamplesPerTenant <= 0 {
+		return fmt.Errorf("zstd dictionaries samples per tenant must be > 0")
+	}
+	if cfg.MaxSize < 1<<10 {
+		return fmt.Errorf("zstd dictionaries max size must be at least 1KiB")
+	}
+	return nil
+}
+
+// tenantSamples is a uniform sample of the lines of a tenant since its last dictionary.
+type tenantSamples struct {
+	mtx   sync.Mutex
+	lines [][]byte
+	seen  int64
+	rand  *rand.Rand
+	// trained is the time of the last dictionary of the tenant.
+	trained time.Time
+}
+
+// dictionaryObject is the object of a dictionary in the object store.
+type dictionaryObject struct {
+	key        string
+	tenant     string
+	modifiedAt time.Time
+}
+
+// Dictionaries lists the dictionaries of all the tenants in the object store and loads them when the chunks compressed
+// with them are decoded, and trains the dictionaries of the tenants whose lines are sampled.
+type Dictionaries struct {
+	services.Service
+
+	cfg          Config
+	objectClient chunk.ObjectClient
+	logger       log.Logger
+
+	mtx sync.RWMutex
+	// objects are the dictionaries in the object store by ID, as of the last listing.
+	objects map[uint32]dictionaryObject
+	listed  time.Time
+	active  map[string]activeDictionary
+	samples map[string]*tenantSamples
+
+	// loadMtx serializes the loads of the dictionaries by the decoders.
+	loadMtx sync.Mutex
+
+	loadedTotal prometheus.Counter
+	trainings   *prometheus.CounterVec
+}
+
+// activeDictionary is the latest dictionary of a tenant, the one the new chunks are compressed with.
+type activeDictionary struct {
+	dict       *chunkenc.ZstdDictionary
+	modifiedAt time.Time
+}
+
+const (
+	// minListInterval bounds how often the dictionaries are listed to find the one a chunk is compressed with.
+	minListInterval = 10 * time.Second
+	// loadTimeout bounds the time to load a dictionary when decoding a chunk.
+	loadTimeout = 30 * time.Second
+)
+
+// New creates the dictionaries, which are listed when the service starts.
+func New(cfg Config, objectClient chunk.ObjectClient, logger log.Logger, registerer prometheus.Registerer) *Dictionaries {
+	d := &Dictionaries{
+		cfg:          cfg,
+		objectClient: objectClient,
+		logger:       logger,
+		objects:      map[uint32]dictionaryObject{},
+		active:       map[string]activeDictionary{},
+		samples:      map[string]*tenantSamples{},
+		loadedTotal: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "zstd_dictionaries_loaded_total",
+			Help:      "Total number of zstd dictionaries loaded from the object store.",
+		}),
+		trainings: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "zstd_dictionary_trainings_total",
+			Help:      "Total number of trainings of zstd dictionaries, by status.",
+		}, []string{"status"}),
+	}
+	d.Service = services.NewTimerService(cfg.RefreshPeriod, d.starting, d.iteration, nil)
+	return d
+}
+
+// Active returns the dictionary the new chunks of a tenant are compressed with, nil if the tenant has none yet. The
+// dictionary of a tenant is loaded by the refresh following the first sampled line of the tenant.
+func (d *Dictionaries) Active(tenant string) *chunkenc.ZstdDictionary {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	return d.active[tenant].dict
+}
+
+// Sample samples a line of a tenant for training its next dictionary.
+func (d *Dictionaries) Sample(tenant, line string) {
+	d.mtx.RLock()
+	s, ok := d.samples[tenant]
+	d.mtx.RUnlock()
+	if !ok {
+		d.mtx.Lock()
+		if s, ok = d.samples[tenant]; !ok {
+			s = &tenantSamples{
+				rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+				trained: d.active[tenant].modifiedAt,
+			}
+			d.samples[tenant] = s
+		}
+		d.mtx.Unlock()
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.seen++
+	if len(s.lines) < d.cfg.SamplesPerTenant {
+		s.lines = append(s.lines, []byte(line))
+		return
+	}
+	// Reservoir sampling: the line replaces a sampled one with the probability of a line to be sampled.
+	if i := s.rand.Int63n(s.seen); i < int64(len(s.lines)) {
+		s.lines[i] = []byte(line)
+	}
+}
+
+// Load loads the dictionary of an ID from the object store, for decoding the chunks compressed with it. It is the
+// loader of the dictionaries of the chunkenc package.
+func (d *Dictionaries) Load(id uint32) ([]byte, error) {
+	d.loadMtx.Lock()
+	defer d.loadMtx.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	d.mtx.RLock()
+	object, ok := d.objects[id]
+	listed := d.listed
+	d.mtx.RUnlock()
+	if !ok && time.Since(listed) >= minListInterval {
+		// The dictionary may have been trained since the last listing.
+		if err := d.list(ctx); err != nil {
+			return nil, err
+		}
+		d.mtx.RLock()
+		object, ok = d.objects[id]
+		d.mtx.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("zstd dictionary %d not found", id)
+	}
+	raw, err := d.read(ctx, object.key)
+	if err != nil {
+		return nil, err
+	}
+	d.loadedTotal.Inc()
+	return raw, nil
+}
+
+// starting lists the dictionaries, which fails if the object store can't be listed.
+func (d *Dictionaries) starting(ctx context.Context) error {
+	if err := d.refresh(ctx); err != nil {
+		return fmt.Errorf("failed to list the zstd dictionaries: %w", err)
+	}
+	return nil
+}
+
+func (d *Dictionaries) iteration(ctx context.Context) error {
+	if err := d.refresh(ctx); err != nil {
+		level.Warn(d.logger).Log("msg", "failed to list the zstd dictionaries", "err", err)
+	}
+	d.train(ctx, time.Now())
+	return nil
+}
+
+// refresh lists the dictionaries and loads the active ones of the tenants whose lines are sampled.
+func (d *Dictionaries) refresh(ctx context.Context) error {
+	if err := d.list(ctx); err != nil {
+		return err
+	}
+
+	d.mtx.RLock()
+	latest := map[string]dictionaryObject{}
+	for _, object := range d.objects {
+		if _, ok := d.samples[object.tenant]; !ok {
+			continue
+		}
+		if object.modifiedAt.After(latest[object.tenant].modifiedAt) && object.modifiedAt.After(d.active[object.tenant].modifiedAt) {
+			latest[object.tenant] = object
+		}
+	}
+	d.mtx.RUnlock()
+
+	for tenant, object := range latest {
+		raw, err := d.read(ctx, object.key)
+		var dict *chunkenc.ZstdDictionary
+		if err == nil {
+			dict, err = chunkenc.NewZstdDictionary(raw)
+		}
+		if err != nil {
+			level.Warn(d.logger).Log("msg", "failed to load zstd dictionary", "key", object.key, "err", err)
+			continue
+		}
+		d.loadedTotal.Inc()
+		d.activate(tenant, dict, object.modifiedAt)
+	}
+	return nil
+}
+
+// list lists the dictionaries of all the tenants. The objects of the dictionaries of a tenant are
+// <key prefix><tenant>/<ID>.dict, the latest one being the active one. The dictionaries which were deleted are
+// unregistered.
+func (d *Dictionaries) list(ctx context.Context) error {
+	listed := time.Now()
+	objects, _, err := d.objectClient.List(ctx, d.cfg.KeyPrefix, "")
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint32]dictionaryObject, len(objects))
+	for _, object := range objects {
+		tenant, id, ok := parseKey(d.cfg.KeyPrefix, object.Key)
+		if !ok {
+			continue
+		}
+		if other, ok := byID[id]; ok {
+			// Only the first dictionary with an ID decodes the chunks compressed with it.
+			level.Warn(d.logger).Log("msg", "zstd dictionary ID collision", "key", object.Key, "other", other.key)
+			if other.modifiedAt.Before(object.ModifiedAt) {
+				continue
+			}
+		}
+		byID[id] = dictionaryObject{key: object.Key, tenant: tenant, modifiedAt: object.ModifiedAt}
+	}
+
+	d.mtx.Lock()
+	previous := d.objects
+	d.objects, d.listed = byID, listed
+	d.mtx.Unlock()
+	for id := range previous {
+		if _, ok := byID[id]; !ok {
+			chunkenc.UnregisterZstdDictionary(id)
+		}
+	}
+	return nil
+}
+
+func (d *Dictionaries) read(ctx context.Context, key string) ([]byte, error) {
+	reader, err := d.objectClient.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+func (d *Dictionaries) activate(tenant string, dict *chunkenc.ZstdDictionary, modifiedAt time.Time) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if !modifiedAt.After(d.active[tenant].modifiedAt) {
+		return
+	}
+	d.active[tenant] = activeDictionary{dict: dict, modifiedAt: modifiedAt}
+	// Another ingester trained a dictionary for the tenant, which doesn't need a new one before the train period.
+	if s, ok := d.samples[tenant]; ok {
+		s.mtx.Lock()
+		if modifiedAt.After(s.trained) {
+			s.trained = modifiedAt
+		}
+		s.mtx.Unlock()
+	}
+}
+
+// train trains the dictionaries of the tenants whose sample is complete and whose last dictionary is older than the
+// train period, uploading them to the object store.
+func (d *Dictionaries) train(ctx context.Context, now time.Time) {
+	d.mtx.RLock()
+	due := map[string][][]byte{}
+	for tenant, s := range d.samples {
+		s.mtx.Lock()
+		if len(s.lines) >= d.cfg.SamplesPerTenant && now.Sub(s.trained) >= d.cfg.TrainPeriod {
+			due[tenant] = s.lines
+			// The lines sampled from now on are for the next dictionary.
+			s.lines, s.seen, s.trained = nil, 0, now
+		}
+		s.mtx.Unlock()
+	}
+	d.mtx.RUnlock()
+
+	for tenant, lines := range due {
+		if err := d.trainTenant(ctx, tenant, lines, now); err != nil {
+			d.trainings.WithLabelValues("failure").Inc()
+			level.Warn(d.logger).Log("msg", "failed to train zstd dictionary", "tenant", tenant, "err", err)
+			continue
+		}
+		d.trainings.WithLabelValues("success").Inc()
+	}
+}
+
+func (d *Dictionaries) trainTenant(ctx context.Context, tenant string, lines [][]byte, now time.Time) error {
+	raw, err := chunkenc.TrainZstdDictionary(lines, d.cfg.MaxSize.Val())
+	if err != nil {
+		return err
+	}
+	// The ID of the dictionary must not be the one of another dictionary, of any tenant.
+	if err := d.list(ctx); err != nil {
+		return err
+	}
+	raw, err = chunkenc.ReassignZstdDictionaryID(raw, d.idTaken)
+	if err != nil {
+		return err
+	}
+	dict, err := chunkenc.NewZstdDictionary(raw)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s%s/%d%s", d.cfg.KeyPrefix, tenant, dict.ID(), dictionaryExtension)
+	// The dictionary must be in the object store before any chunk is compressed with it, so that the queriers can
+	// decode the chunk.
+	if err := d.objectClient.PutObject(ctx, key, bytes.NewReader(raw)); err != nil {
+		return err
+	}
+	level.Info(d.logger).Log("msg", "trained zstd dictionary", "tenant", tenant, "key", key, "size", len(raw), "samples", len(lines))
+	d.mtx.Lock()
+	d.objects[dict.ID()] = dictionaryObject{key: key, tenant: tenant, modifiedAt: now}
+	d.mtx.Unlock()
+	d.activate(tenant, dict, now)
+	return nil
+}
+
+// idTaken returns whether a dictionary ID is the one of a listed or registered dictionary.
+func (d *Dictionaries) idTaken(id uint32) bool {
+	d.mtx.RLock()
+	_, listed := d.objects[id]
+	d.mtx.RUnlock()
+	return listed || chunkenc.IsZstdDictionaryRegistered(id)
+}
+
+// parseKey returns the tenant and the ID of the dictionary whose key is key.
+func parseKey(prefix, key string) (string, uint32, bool) {
+	rest := strings.TrimPrefix(key, prefix)
+	i := strings.Index(rest, "/")
+	if i <= 0 || !strings.HasSuffix(rest, dictionaryExtension) {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimSuffix(rest[i+1:], dictionaryExtension), 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return rest[:i], uint32(id), true
+}
@@ -0,0 +1,129 @@
+package dictionaries
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{Store: "filesystem", KeyPrefix: "zstd-dictionaries/", RefreshPeriod: time.Minute, TrainPeriod: time.Hour, SamplesPerTenant: 100, MaxSize: 16 << 10}
+	require.NoError(t, valid.Validate())
+	require.NoError(t, (&Config{}).Validate())
+
+	for name, update := range map[string]func(*Config){
+		"no key prefix separator": func(cfg *Config) { cfg.KeyPrefix = "zstd-dictionaries" },
+		"no refresh period":       func(cfg *Config) { cfg.RefreshPeriod = 0 },
+		"no samples":              func(cfg *Config) { cfg.SamplesPerTenant = 0 },
+		"max size too small":      func(cfg *Config) { cfg.MaxSize = 100 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := valid
+			update(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestDictionaries(t *testing.T) {
+	ctx := context.Background()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	cfg := Config{Store: "filesystem", KeyPrefix: "zstd-dictionaries/", RefreshPeriod: time.Minute, TrainPeriod: time.Hour, SamplesPerTenant: 500, MaxSize: 16 << 10}
+
+	ingester := New(cfg, objectClient, log.NewNopLogger(), nil)
+	require.NoError(t, ingester.starting(ctx))
+	require.Nil(t, ingester.Active("fake"))
+
+	// No dictionary is trained before the sample of the tenant is complete.
+	for i := 0; i < 2*cfg.SamplesPerTenant; i++ {
+		if i == cfg.SamplesPerTenant/2 {
+			ingester.train(ctx, time.Now())
+			require.Nil(t, ingester.Active("fake"))
+		}
+		ingester.Sample("fake", fmt.Sprintf(`level=info msg="request served" path=/api/v1/users/%d status=200 duration=%dms`, i, i%1000))
+	}
+	now := time.Now()
+	ingester.train(ctx, now)
+	dict := ingester.Active("fake")
+	require.NotNil(t, dict)
+
+	// The tenant isn't trained again before the train period.
+	for i := 0; i < cfg.SamplesPerTenant; i++ {
+		ingester.Sample("fake", fmt.Sprintf(`level=warn msg="slow request" path=/api/v1/orders/%d`, i))
+	}
+	ingester.train(ctx, now.Add(time.Minute))
+	require.Equal(t, dict, ingester.Active("fake"))
+
+	// The other ingesters load the active dictionary of the tenants they sample.
+	other := New(cfg, objectClient, log.NewNopLogger(), nil)
+	require.NoError(t, other.starting(ctx))
+	require.Nil(t, other.Active("fake"))
+	other.Sample("fake", "foo")
+	require.NoError(t, other.refresh(ctx))
+	require.NotNil(t, other.Active("fake"))
+	require.Equal(t, dict.ID(), other.Active("fake").ID())
+	require.Nil(t, other.Active("other"))
+
+	// The queriers load the dictionaries when decoding the chunks compressed with them.
+	querier := New(cfg, objectClient, log.NewNopLogger(), nil)
+	require.NoError(t, querier.starting(ctx))
+	require.Nil(t, querier.Active("fake"))
+	raw, err := querier.Load(dict.ID())
+	require.NoError(t, err)
+	id, err := chunkenc.ZstdDictionaryID(raw)
+	require.NoError(t, err)
+	require.Equal(t, dict.ID(), id)
+	_, err = querier.Load(dict.ID() + 1)
+	require.Error(t, err)
+}
+
+func TestDictionaries_IDCollision(t *testing.T) {
+	ctx := context.Background()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	cfg := Config{Store: "filesystem", KeyPrefix: "zstd-dictionaries/", RefreshPeriod: time.Minute, TrainPeriod: time.Hour, SamplesPerTenant: 100, MaxSize: 16 << 10}
+
+	// The same lines train the same dictionary, whose ID is taken by the first tenant.
+	lines := make([][]byte, 0, cfg.SamplesPerTenant)
+	for i := 0; i < cfg.SamplesPerTenant; i++ {
+		lines = append(lines, []byte(fmt.Sprintf(`level=debug msg="cache hit" key=%d`, i)))
+	}
+	first := New(cfg, objectClient, log.NewNopLogger(), nil)
+	require.NoError(t, first.starting(ctx))
+	require.NoError(t, first.trainTenant(ctx, "first", lines, time.Now()))
+	// The ID is found by listing the dictionaries.
+	chunkenc.UnregisterZstdDictionary(first.Active("first").ID())
+	second := New(cfg, objectClient, log.NewNopLogger(), nil)
+	require.NoError(t, second.starting(ctx))
+	require.NoError(t, second.trainTenant(ctx, "second", lines, time.Now()))
+	require.NotEqual(t, first.Active("first").ID(), second.Active("second").ID())
+
+	objects, _, err := objectClient.List(ctx, cfg.KeyPrefix, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+}
+
+func Test_parseKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"zstd-dictionaries/fake/123.dict":     "fake",
+		"zstd-dictionaries/fake/123.txt":      "",
+		"zstd-dictionaries/123.dict":          "",
+		"zstd-dictionaries/fake/sub/123.dict": "",
+		"zstd-dictionaries/fake/abc.dict":     "",
+	} {
+		tenant, id, ok := parseKey("zstd-dictionaries/", key)
+		require.Equal(t, expected != "", ok, key)
+		require.Equal(t, expected, tenant, key)
+		if ok {
+			require.Equal(t, uint32(123), id)
+		}
+	}
+}
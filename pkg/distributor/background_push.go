@@ -0,0 +1,57 @@
+package distributor
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/dskit/services"
+)
+
+// backgroundPusher runs the pushes made in the background by a fixed number of workers, through a bounded queue so
+// that a burst of pushes can't pile up goroutines and memory in the distributor.
+type backgroundPusher struct {
+	services.Service
+
+	queue   chan func(context.Context)
+	workers int
+}
+
+func newBackgroundPusher(queueSize, workers int) *backgroundPusher {
+	p := &backgroundPusher{
+		queue:   make(chan func(context.Context), queueSize),
+		workers: workers,
+	}
+	p.Service = services.NewBasicService(nil, p.running, nil)
+	return p
+}
+
+// enqueue queues a push, it returns false without queueing it when the queue is full.
+func (p *backgroundPusher) enqueue(push func(context.Context)) bool {
+	select {
+	case p.queue <- push:
+		return true
+	default:
+		return false
+	}
+}
+
+// running runs the queued pushes until the context is cancelled, the pushes left in the queue being dropped.
+func (p *backgroundPusher) running(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case push := <-p.queue:
+					push(ctx)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
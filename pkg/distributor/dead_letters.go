@@ -0,0 +1,138 @@
+package distributor
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/user"
+	"golang.org/x/time/rate"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// deadLettersKey marks the context of pushes of rejected entries to a dead letter tenant,
+// whose own rejected entries are dropped.
+const deadLettersKey contextKey = 1
+
+// Labels added to the streams pushed to a dead letter tenant.
+const (
+	deadLetterTenantLabel = "rejected_tenant"
+	deadLetterReasonLabel = "rejected_reason"
+)
+
+// Reasons of the rejected entries dropped instead of being forwarded to the dead letter tenant.
+const (
+	deadLetterQueueFull   = "queue_full"
+	deadLetterRateLimited = "rate_limited"
+)
+
+// DeadLettersConfig configures the forwarding of the rejected entries to the dead letter tenants.
+type DeadLettersConfig struct {
+	QueueSize int     `yaml:"queue_size"`
+	Workers   int     `yaml:"workers"`
+	RateLimit float64 `yaml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst"`
+}
+
+// RegisterFlags registers the flags of the forwarding of the rejected entries.
+func (cfg *DeadLettersConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&cfg.QueueSize, "distributor.dead-letters.queue-size", 100, "Maximum number of pushes of rejected entries waiting to be forwarded to the dead letter tenants. The rejected entries of a push are dropped when the queue is full.")
+	f.IntVar(&cfg.Workers, "distributor.dead-letters.workers", 4, "Number of workers forwarding the rejected entries to the dead letter tenants.")
+	f.Float64Var(&cfg.RateLimit, "distributor.dead-letters.rate-limit", 1000, "Maximum number of rejected lines per second forwarded to the dead letter tenant of each tenant, the rejected entries exceeding it are dropped.")
+	f.IntVar(&cfg.RateBurst, "distributor.dead-letters.rate-burst", 10000, "Maximum number of rejected lines forwarded at once to the dead letter tenant of each tenant.")
+}
+
+// deadLetters collects the entries of a push rejected by the distributor, by stream and reason, to forward them to
+// the dead letter tenant of the tenant. A nil *deadLetters drops them.
+type deadLetters struct {
+	userID   string
+	tenantID string
+	now      time.Time
+
+	streams map[string]*logproto.Stream
+	lines   int
+}
+
+// newDeadLetters returns the collector of the rejected entries of a push, nil if the tenant has no dead letter tenant
+// or if the push is already one of rejected entries.
+func newDeadLetters(ctx context.Context, vContext validationContext, now time.Time) *deadLetters {
+	if vContext.deadLetterTenantID == "" || vContext.deadLetterTenantID == vContext.userID || ctx.Value(deadLettersKey) != nil {
+		return nil
+	}
+	return &deadLetters{
+		userID:   vContext.userID,
+		tenantID: vContext.deadLetterTenantID,
+		now:      now,
+		streams:  map[string]*logproto.Stream{},
+	}
+}
+
+// add collects entries of a stream rejected for the given reason. They are timestamped with the time of the push,
+// so that the dead letter tenant doesn't reject them again for their timestamp.
+func (dl *deadLetters) add(reason, lbs string, entries ...logproto.Entry) {
+	if dl == nil || len(entries) == 0 {
+		return
+	}
+	key := reason + "\xff" + lbs
+	stream, ok := dl.streams[key]
+	if !ok {
+		ls, err := logql.ParseLabels(lbs)
+		if err != nil {
+			// The labels of the rejected entries were already parsed.
+			return
+		}
+		ls = labels.NewBuilder(ls).Set(deadLetterTenantLabel, dl.userID).Set(deadLetterReasonLabel, reason).Labels()
+		stream = &logproto.Stream{Labels: ls.String()}
+		dl.streams[key] = stream
+	}
+	for _, e := range entries {
+		stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: dl.now, Line: e.Line})
+	}
+	dl.lines += len(entries)
+}
+
+// addStreams collects all the entries of the streams of a push rejected for the given reason.
+func (dl *deadLetters) addStreams(reason string, streams []streamTracker) {
+	for _, s := range streams {
+		dl.add(reason, s.stream.Labels, s.stream.Entries...)
+	}
+}
+
+// sendDeadLetters queues the push of the rejected entries to the dead letter tenant, failing to forward them must not
+// change the response of the push. The entries exceeding the rate limit of the tenant, or the capacity of the queue,
+// are dropped.
+func (d *Distributor) sendDeadLetters(dl *deadLetters) {
+	if dl == nil || dl.lines == 0 {
+		return
+	}
+	limit := validation.RateLimit{Limit: rate.Limit(d.cfg.DeadLetters.RateLimit), Burst: d.cfg.DeadLetters.RateBurst}
+	if !d.deadLetterRateLimiter.limiter(dl.now, dl.userID, "", limit).AllowN(dl.now, dl.lines) {
+		d.deadLetterDroppedLines.WithLabelValues(dl.userID, deadLetterRateLimited).Add(float64(dl.lines))
+		return
+	}
+
+	streams := make([]logproto.Stream, 0, len(dl.streams))
+	for _, s := range dl.streams {
+		streams = append(streams, *s)
+	}
+	queued := d.deadLettersPusher.enqueue(func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, d.clientCfg.RemoteTimeout)
+		defer cancel()
+		ctx = user.InjectOrgID(context.WithValue(ctx, deadLettersKey, dl.userID), dl.tenantID)
+		if _, err := d.Push(ctx, &logproto.PushRequest{Streams: streams}); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to forward rejected entries to dead letter tenant", "tenant", dl.userID, "dead_letter_tenant", dl.tenantID, "err", err)
+			return
+		}
+		d.deadLetterLines.WithLabelValues(dl.userID, dl.tenantID).Add(float64(dl.lines))
+	})
+	if !queued {
+		d.deadLetterDroppedLines.WithLabelValues(dl.userID, deadLetterQueueFull).Add(float64(dl.lines))
+	}
+}
@@ -0,0 +1,62 @@
+package distributor
+
+import (
+	"context"
+	"flag"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log/level"
+	"github.com/weaveworks/common/user"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// debugSamplesKey marks the context of pushes of sampled streams to a debug tenant,
+// which are never sampled again.
+const debugSamplesKey contextKey = 0
+
+// DebugSamplesConfig configures the copy of the sampled streams to the debug tenants.
+type DebugSamplesConfig struct {
+	QueueSize int `yaml:"queue_size"`
+	Workers   int `yaml:"workers"`
+}
+
+// RegisterFlags registers the flags of the copy of the sampled streams.
+func (cfg *DebugSamplesConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&cfg.QueueSize, "distributor.debug-samples.queue-size", 100, "Maximum number of pushes of sampled streams waiting to be copied to the debug tenants. The sampled streams of a push are dropped when the queue is full.")
+	f.IntVar(&cfg.Workers, "distributor.debug-samples.workers", 2, "Number of workers copying the sampled streams to the debug tenants.")
+}
+
+// sampleStream tells if a stream is sampled, the decision only depends on its labels
+// so that sampled streams are copied entirely.
+func sampleStream(ratio float64, lbs string) bool {
+	return float64(xxhash.Sum64String(lbs)) < ratio*math.MaxUint64
+}
+
+// sendDebugSamples queues the push of the sampled streams to the debug tenant, failing to copy them must not fail
+// the push of the tenant. The sampled streams are dropped when the queue is full.
+func (d *Distributor) sendDebugSamples(userID, debugTenantID string, streams []logproto.Stream) {
+	if len(streams) == 0 {
+		return
+	}
+	lines := 0
+	for _, s := range streams {
+		lines += len(s.Entries)
+	}
+	queued := d.debugSamplesPusher.enqueue(func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, d.clientCfg.RemoteTimeout)
+		defer cancel()
+		ctx = user.InjectOrgID(context.WithValue(ctx, debugSamplesKey, userID), debugTenantID)
+		if _, err := d.Push(ctx, &logproto.PushRequest{Streams: streams}); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to copy sampled streams to debug tenant", "tenant", userID, "debug_tenant", debugTenantID, "err", err)
+			return
+		}
+		d.debugSampledLines.WithLabelValues(userID, debugTenantID).Add(float64(lines))
+	})
+	if !queued {
+		d.debugDroppedLines.WithLabelValues(userID).Add(float64(lines))
+	}
+}
@@ -3,9 +3,12 @@ package distributor
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net/http"
 	"time"
+	"unicode/utf8"
 
+	"github.com/cespare/xxhash/v2"
 	cortex_distributor "github.com/cortexproject/cortex/pkg/distributor"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/grafana/dskit/limiter"
@@ -17,29 +20,54 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/loki/pkg/tenant"
 
 	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/loghttp/push"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/runtime"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
+	"github.com/grafana/loki/pkg/usage"
 	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/flagext"
 	"github.com/grafana/loki/pkg/validation"
 )
 
 var maxLabelCacheSize = 100000
 
+// relabelDropRule is the rule reported for the lines of the streams dropped by the tenant relabel configs.
+const relabelDropRule = "relabel"
+
+// errStreamDropped is returned when parsing the labels of a stream dropped by the tenant relabel configs.
+var errStreamDropped = errors.New("stream dropped by relabel configs")
+
 // Config for a Distributor.
 type Config struct {
 	// Distributors ring
 	DistributorRing cortex_distributor.RingConfig `yaml:"ring,omitempty"`
 
+	UsageTracker usage.TrackerConfig `yaml:"usage_tracker"`
+
+	PushTracingMaxDuration time.Duration `yaml:"push_tracing_max_duration"`
+
+	MaxRecvMsgSize int `yaml:"max_recv_msg_size"`
+
+	OTLP      OTLPConfig      `yaml:"otlp"`
+	GELF      GELFConfig      `yaml:"gelf"`
+	SplunkHEC SplunkHECConfig `yaml:"splunk_hec"`
+
+	DeadLetters  DeadLettersConfig  `yaml:"dead_letters"`
+	DebugSamples DebugSamplesConfig `yaml:"debug_samples"`
+
 	// For testing.
 	factory ring_client.PoolFactory `yaml:"-"`
 }
@@ -47,6 +75,14 @@ type Config struct {
 // RegisterFlags registers distributor-related flags.
 func (cfg *Config) RegisterFlags(fs *flag.FlagSet) {
 	cfg.DistributorRing.RegisterFlags(fs)
+	cfg.UsageTracker.RegisterFlagsWithPrefix("distributor.usage-tracker.", "usage/", "Account the bytes and lines ingested by each tenant per calendar month in the KV store, required to enforce the monthly ingestion caps.", fs)
+	fs.DurationVar(&cfg.PushTracingMaxDuration, "distributor.push-tracing-max-duration", time.Hour, "Maximum duration of the push tracing sessions started with /distributor/push_tracing. 0 to disable the endpoint.")
+	fs.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", push.DefaultMaxRecvMsgSize, "Maximum size in bytes of the body of the push requests once decompressed, larger requests are rejected.")
+	cfg.OTLP.RegisterFlags(fs)
+	cfg.GELF.RegisterFlags(fs)
+	cfg.SplunkHEC.RegisterFlags(fs)
+	cfg.DeadLetters.RegisterFlags(fs)
+	cfg.DebugSamples.RegisterFlags(fs)
 }
 
 // Distributor coordinates replicates and distribution of log streams.
@@ -72,12 +108,36 @@ type Distributor struct {
 	ingestionRateLimiter *limiter.RateLimiter
 	labelCache           *lru.Cache
 
+	// Per-stream rate limiter.
+	streamRateLimiter *streamRateLimiter
+
+	// Monthly usage of the tenants, nil if the usage tracker is disabled.
+	usageTracker *usage.Tracker
+
+	// Tenants whose push requests are logged for debugging.
+	pushTracer *pushTracer
+
+	// Forwarding of the rejected entries to the dead letter tenants, rate limited per tenant.
+	deadLettersPusher     *backgroundPusher
+	deadLetterRateLimiter *streamRateLimiter
+
+	// Copy of the sampled streams to the debug tenants.
+	debugSamplesPusher *backgroundPusher
+
 	// metrics
 	ingesterAppends        *prometheus.CounterVec
 	ingesterAppendFailures *prometheus.CounterVec
 	replicationFactor      prometheus.Gauge
+	droppedLines           *prometheus.CounterVec
+	droppedBytes           *prometheus.CounterVec
+	debugSampledLines      *prometheus.CounterVec
+	debugDroppedLines      *prometheus.CounterVec
+	deadLetterLines        *prometheus.CounterVec
+	deadLetterDroppedLines *prometheus.CounterVec
 }
 
+type contextKey int
+
 // New a distributor creates.
 func New(cfg Config, clientCfg client.Config, configs *runtime.TenantConfigs, ingestersRing ring.ReadRing, overrides *validation.Overrides, registerer prometheus.Registerer) (*Distributor, error) {
 	factory := cfg.factory
@@ -126,6 +186,8 @@ func New(cfg Config, clientCfg client.Config, configs *runtime.TenantConfigs, in
 		pool:                 cortex_distributor.NewPool(clientCfg.PoolConfig, ingestersRing, factory, util_log.Logger),
 		ingestionRateLimiter: limiter.NewRateLimiter(ingestionRateStrategy, 10*time.Second),
 		labelCache:           labelCache,
+		streamRateLimiter:    newStreamRateLimiter(),
+		pushTracer:           newPushTracer(),
 		ingesterAppends: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 			Namespace: "loki",
 			Name:      "distributor_ingester_appends_total",
@@ -141,10 +203,55 @@ func New(cfg Config, clientCfg client.Config, configs *runtime.TenantConfigs, in
 			Name:      "distributor_replication_factor",
 			Help:      "The configured replication factor.",
 		}),
+		droppedLines: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_dropped_lines_total",
+			Help:      "The total number of lines dropped by tenant drop rules and relabel configs.",
+		}, []string{"tenant", "rule"}),
+		droppedBytes: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_dropped_bytes_total",
+			Help:      "The total number of bytes dropped by tenant drop rules and relabel configs.",
+		}, []string{"tenant", "rule"}),
+		debugSampledLines: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_debug_sampled_lines_total",
+			Help:      "The total number of lines copied to the debug tenant.",
+		}, []string{"tenant", "debug_tenant"}),
+		debugDroppedLines: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_debug_sampled_dropped_lines_total",
+			Help:      "The total number of sampled lines dropped instead of being copied to the debug tenant, because the queue was full.",
+		}, []string{"tenant"}),
+		deadLetterLines: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_dead_letter_lines_total",
+			Help:      "The total number of rejected lines forwarded to the dead letter tenant.",
+		}, []string{"tenant", "dead_letter_tenant"}),
+		deadLetterDroppedLines: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_dead_letter_dropped_lines_total",
+			Help:      "The total number of rejected lines dropped instead of being forwarded to the dead letter tenant.",
+		}, []string{"tenant", "reason"}),
 	}
 	d.replicationFactor.Set(float64(ingestersRing.ReplicationFactor()))
+	d.deadLettersPusher = newBackgroundPusher(cfg.DeadLetters.QueueSize, cfg.DeadLetters.Workers)
+	d.deadLetterRateLimiter = newStreamRateLimiter()
+	d.debugSamplesPusher = newBackgroundPusher(cfg.DebugSamples.QueueSize, cfg.DebugSamples.Workers)
+
+	if cfg.UsageTracker.Enabled {
+		d.usageTracker, err = usage.NewTracker("distributor", cfg.UsageTracker, util_log.Logger, registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "usage tracker")
+		}
+		servs = append(servs, d.usageTracker)
+	}
+
+	if cfg.GELF.enabled() {
+		servs = append(servs, newGELFListener(cfg.GELF, cfg.MaxRecvMsgSize, d.pushFromListener, registerer))
+	}
 
-	servs = append(servs, d.pool)
+	servs = append(servs, d.pool, d.deadLettersPusher, d.debugSamplesPusher)
 	d.subservices, err = services.NewManager(servs...)
 	if err != nil {
 		return nil, errors.Wrap(err, "services manager")
@@ -191,12 +298,17 @@ type pushTracker struct {
 }
 
 // Push a set of streams.
-func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*logproto.PushResponse, error) {
+func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (_ *logproto.PushResponse, err error) {
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if d.pushTracer.sample(time.Now(), userID) {
+		traced := newTracedPush(req)
+		defer func() { traced.log(ctx, userID, err) }()
+	}
+
 	// First we flatten out the request into a list of samples.
 	// We use the heuristic of 1 sample per TS to size the array.
 	// We also work out the hash value at the same time.
@@ -207,12 +319,30 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	validatedSamplesCount := 0
 
 	validationContext := d.validator.getValidationContextFor(userID)
+	sampling := validationContext.debugSampleRatio > 0 && validationContext.debugTenantID != "" &&
+		validationContext.debugTenantID != userID && ctx.Value(debugSamplesKey) == nil
+	var debugStreams []logproto.Stream
+	var streamRateLimitErr error
+	var reservations streamRateReservations
+
+	now := time.Now()
+	deadLetters := newDeadLetters(ctx, validationContext, now)
+	defer d.sendDeadLetters(deadLetters)
 
 	for _, stream := range req.Streams {
 		// Truncate first so subsequent steps have consistent line lengths
 		d.truncateLines(validationContext, &stream)
 
 		stream.Labels, err = d.parseStreamLabels(validationContext, stream.Labels, &stream)
+		if err == errStreamDropped {
+			bytes := 0
+			for _, e := range stream.Entries {
+				bytes += len(e.Line)
+			}
+			d.droppedLines.WithLabelValues(userID, relabelDropRule).Add(float64(len(stream.Entries)))
+			d.droppedBytes.WithLabelValues(userID, relabelDropRule).Add(float64(bytes))
+			continue
+		}
 		if err != nil {
 			validationErr = err
 			validation.DiscardedSamples.WithLabelValues(validation.InvalidLabels, userID).Add(float64(len(stream.Entries)))
@@ -224,10 +354,18 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 			continue
 		}
 
+		dropRules := d.streamDropRules(validationContext, stream.Labels)
+
 		n := 0
 		for _, entry := range stream.Entries {
-			if err := d.validator.ValidateEntry(validationContext, stream.Labels, entry); err != nil {
+			if rule := dropRuleFor(dropRules, entry); rule != nil {
+				d.droppedLines.WithLabelValues(userID, rule.Name).Inc()
+				d.droppedBytes.WithLabelValues(userID, rule.Name).Add(float64(len(entry.Line)))
+				continue
+			}
+			if reason, err := d.validator.validateEntry(validationContext, stream.Labels, entry); err != nil {
 				validationErr = err
+				deadLetters.add(reason, stream.Labels, entry)
 				continue
 			}
 			stream.Entries[n] = entry
@@ -237,6 +375,13 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 		}
 		stream.Entries = stream.Entries[:n]
 
+		droppedLines, droppedBytes, err := d.limitStreamRate(now, validationContext, &stream, deadLetters, &reservations)
+		if err != nil && streamRateLimitErr == nil {
+			streamRateLimitErr = err
+		}
+		validatedSamplesCount -= droppedLines
+		validatedSamplesSize -= droppedBytes
+
 		if len(stream.Entries) == 0 {
 			continue
 		}
@@ -245,20 +390,43 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 		streams = append(streams, streamTracker{
 			stream: stream,
 		})
+
+		if sampling && sampleStream(validationContext.debugSampleRatio, stream.Labels) {
+			debugStreams = append(debugStreams, logproto.Stream{
+				Labels:  stream.Labels,
+				Entries: append([]logproto.Entry(nil), stream.Entries...),
+			})
+		}
+	}
+
+	if streamRateLimitErr != nil {
+		reservations.cancel(now)
+		// Return a 429 to indicate to the client they are being rate limited
+		validation.DiscardedSamples.WithLabelValues(validation.StreamRateLimit, userID).Add(float64(validatedSamplesCount))
+		validation.DiscardedBytes.WithLabelValues(validation.StreamRateLimit, userID).Add(float64(validatedSamplesSize))
+		deadLetters.addStreams(validation.StreamRateLimit, streams)
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, "%s", streamRateLimitErr.Error())
 	}
 
 	if len(streams) == 0 {
 		return &logproto.PushResponse{}, validationErr
 	}
 
-	now := time.Now()
 	if !d.ingestionRateLimiter.AllowN(now, userID, validatedSamplesSize) {
+		reservations.cancel(now)
 		// Return a 429 to indicate to the client they are being rate limited
 		validation.DiscardedSamples.WithLabelValues(validation.RateLimited, userID).Add(float64(validatedSamplesCount))
 		validation.DiscardedBytes.WithLabelValues(validation.RateLimited, userID).Add(float64(validatedSamplesSize))
+		deadLetters.addStreams(validation.RateLimited, streams)
 		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, validation.RateLimitedErrorMsg, userID, int(d.ingestionRateLimiter.Limit(now, userID)), validatedSamplesCount, validatedSamplesSize)
 	}
 
+	if err := d.checkMonthlyCaps(validationContext, validatedSamplesCount, validatedSamplesSize); err != nil {
+		reservations.cancel(now)
+		deadLetters.addStreams(validation.MonthlyCapExceeded, streams)
+		return nil, err
+	}
+
 	const maxExpectedReplicationSet = 5 // typical replication factor 3 plus one for inactive plus one for luck
 	var descs [maxExpectedReplicationSet]ring.InstanceDesc
 
@@ -299,12 +467,82 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	case err := <-tracker.err:
 		return nil, err
 	case <-tracker.done:
+		if d.usageTracker != nil {
+			d.usageTracker.Add(userID, validatedSamplesSize, validatedSamplesCount)
+		}
+		d.sendDebugSamples(userID, validationContext.debugTenantID, debugStreams)
 		return &logproto.PushResponse{}, validationErr
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
 }
 
+// limitStreamRate enforces the distributor rate limit of a stream. With the drop policy the entries beyond the
+// limit are removed from the stream and accounted as discarded, the removed entries being collected into the dead
+// letters. With the reject policy an error is returned when the stream exceeds its limit, without taking any token,
+// and the tokens taken otherwise are added to the reservations to be given back if the push is rejected. It returns
+// the number of lines and bytes removed from the stream.
+func (d *Distributor) limitStreamRate(now time.Time, vContext validationContext, stream *logproto.Stream, deadLetters *deadLetters, reservations *streamRateReservations) (int, int, error) {
+	limit := vContext.streamRateLimit
+	if limit.Limit <= 0 || len(stream.Entries) == 0 {
+		return 0, 0, nil
+	}
+	// Like the ingestion rate limit, the global strategy shares the stream rate limit across the distributors.
+	if d.distributorsRing != nil {
+		if n := d.distributorsRing.HealthyInstancesCount(); n > 0 {
+			limit.Limit /= rate.Limit(n)
+		}
+	}
+	streamLimiter := d.streamRateLimiter.limiter(now, vContext.userID, stream.Labels, limit)
+
+	if vContext.streamRateLimitPolicy == validation.RejectStreamRateLimitPolicy {
+		bytes := 0
+		for _, entry := range stream.Entries {
+			bytes += len(entry.Line)
+		}
+		reservation := streamLimiter.ReserveN(now, bytes)
+		if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
+			return 0, 0, &validation.ErrStreamRateLimit{RateLimit: flagext.ByteSize(limit.Limit), Labels: stream.Labels, Bytes: flagext.ByteSize(bytes)}
+		}
+		*reservations = append(*reservations, reservation)
+		return 0, 0, nil
+	}
+
+	n, droppedBytes := 0, 0
+	for _, entry := range stream.Entries {
+		if !streamLimiter.AllowN(now, len(entry.Line)) {
+			droppedBytes += len(entry.Line)
+			deadLetters.add(validation.StreamRateLimit, stream.Labels, entry)
+			continue
+		}
+		stream.Entries[n] = entry
+		n++
+	}
+	droppedLines := len(stream.Entries) - n
+	stream.Entries = stream.Entries[:n]
+	if droppedLines > 0 {
+		validation.DiscardedSamples.WithLabelValues(validation.StreamRateLimit, vContext.userID).Add(float64(droppedLines))
+		validation.DiscardedBytes.WithLabelValues(validation.StreamRateLimit, vContext.userID).Add(float64(droppedBytes))
+	}
+	return droppedLines, droppedBytes, nil
+}
+
+// checkMonthlyCaps rejects the push once the tenant exceeded one of its monthly ingestion caps.
+func (d *Distributor) checkMonthlyCaps(vContext validationContext, lines, bytes int) error {
+	if d.usageTracker == nil || (vContext.monthlyBytesCap <= 0 && vContext.monthlyLinesCap <= 0) {
+		return nil
+	}
+	u := d.usageTracker.Usage(vContext.userID)
+	if (vContext.monthlyBytesCap <= 0 || u.Bytes < int64(vContext.monthlyBytesCap)) &&
+		(vContext.monthlyLinesCap <= 0 || u.Lines < int64(vContext.monthlyLinesCap)) {
+		return nil
+	}
+	validation.DiscardedSamples.WithLabelValues(validation.MonthlyCapExceeded, vContext.userID).Add(float64(lines))
+	validation.DiscardedBytes.WithLabelValues(validation.MonthlyCapExceeded, vContext.userID).Add(float64(bytes))
+	return httpgrpc.Errorf(http.StatusTooManyRequests, validation.MonthlyCapExceededErrorMsg, vContext.userID, u.Bytes, u.Lines, vContext.monthlyBytesCap, vContext.monthlyLinesCap, lines, bytes)
+}
+
 func (d *Distributor) truncateLines(vContext validationContext, stream *logproto.Stream) {
 	if !vContext.maxLineSizeTruncate {
 		return
@@ -383,20 +621,97 @@ func (*Distributor) Check(_ context.Context, _ *grpc_health_v1.HealthCheckReques
 	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
 }
 
+// streamDropRules returns the tenant drop rules whose selector matches the stream labels.
+func (d *Distributor) streamDropRules(vContext validationContext, lbs string) []*validation.DropRule {
+	if len(vContext.dropRules) == 0 {
+		return nil
+	}
+	// labels have already been validated.
+	ls, err := logql.ParseLabels(lbs)
+	if err != nil {
+		return nil
+	}
+	var rules []*validation.DropRule
+Outer:
+	for i := range vContext.dropRules {
+		for _, m := range vContext.dropRules[i].Matchers {
+			if !m.Matches(ls.Get(m.Name)) {
+				continue Outer
+			}
+		}
+		rules = append(rules, &vContext.dropRules[i])
+	}
+	return rules
+}
+
+// dropRuleFor returns the first rule dropping the entry, nil if the entry must be kept.
+func dropRuleFor(rules []*validation.DropRule, entry logproto.Entry) *validation.DropRule {
+	for _, rule := range rules {
+		if rule.Regex == nil || rule.Regex.MatchString(entry.Line) {
+			return rule
+		}
+	}
+	return nil
+}
+
 func (d *Distributor) parseStreamLabels(vContext validationContext, key string, stream *logproto.Stream) (string, error) {
-	labelVal, ok := d.labelCache.Get(key)
+	cacheKey := key
+	if vContext.hashLabelValuesLongerThan > 0 || len(vContext.relabelConfigs) > 0 {
+		// the parsed labels now depend on the tenant limits.
+		cacheKey = vContext.userID + "/" + key
+	}
+	labelVal, ok := d.labelCache.Get(cacheKey)
 	if ok {
+		if labelVal.(string) == "" {
+			return "", errStreamDropped
+		}
 		return labelVal.(string), nil
 	}
 	ls, err := logql.ParseLabels(key)
 	if err != nil {
 		return "", httpgrpc.Errorf(http.StatusBadRequest, validation.InvalidLabelsErrorMsg, key, err)
 	}
+	if len(vContext.relabelConfigs) > 0 {
+		ls = relabel.Process(ls, vContext.relabelConfigs...)
+		if ls == nil {
+			// An empty value is cached for the streams dropped by the relabel configs.
+			d.labelCache.Add(cacheKey, "")
+			return "", errStreamDropped
+		}
+	}
+	hashLongLabelValues(vContext, ls)
 	// ensure labels are correctly sorted.
 	if err := d.validator.ValidateLabels(vContext, ls, *stream); err != nil {
 		return "", err
 	}
 	lsVal := ls.String()
-	d.labelCache.Add(key, lsVal)
+	d.labelCache.Add(cacheKey, lsVal)
 	return lsVal, nil
 }
+
+// hashLongLabelValues replaces label values longer than the tenant threshold by
+// a prefix of the value followed by the hash of the whole value, so that agents putting
+// unique identifiers into labels don't bloat the index.
+// The hashed values are the prefix followed by 17 bytes, a dash and the 16 hex digits of the hash.
+func hashLongLabelValues(vContext validationContext, ls labels.Labels) {
+	if vContext.hashLabelValuesLongerThan <= 0 {
+		return
+	}
+	for i, l := range ls {
+		if len(l.Value) <= vContext.hashLabelValuesLongerThan {
+			continue
+		}
+		prefix := truncateRunes(l.Value, vContext.hashedLabelValuePrefixLength)
+		ls[i].Value = fmt.Sprintf("%s-%016x", prefix, xxhash.Sum64String(l.Value))
+	}
+}
+
+// truncateRunes returns the first n runes of s, so that multi-byte characters are not split.
+func truncateRunes(s string, n int) string {
+	end := 0
+	for i := 0; i < n && end < len(s); i++ {
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+	return s[:end]
+}
@@ -2,12 +2,14 @@ package distributor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,6 +33,7 @@ import (
 
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/logproto"
+	rutil "github.com/grafana/loki/pkg/ruler/util"
 	"github.com/grafana/loki/pkg/runtime"
 	fe "github.com/grafana/loki/pkg/util/flagext"
 	loki_net "github.com/grafana/loki/pkg/util/net"
@@ -138,6 +142,150 @@ func Test_TruncateLogLines(t *testing.T) {
 	})
 }
 
+func Test_HashLongLabelValues(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.EnforceMetricName = false
+	limits.HashLabelValuesLongerThan = 21
+	limits.HashedLabelValuePrefixLength = 4
+	ingester := &mockIngester{}
+
+	d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	request := makeWriteRequest(1, 10)
+	request.Streams[0].Labels = `{app="foo", request_id="0f8fad5b-d9cb-469f-a165-70867728950e"}`
+	_, err := d.Push(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, `{app="foo", request_id="0f8f-e842fcac2395a4f3"}`, ingester.pushedFor("test")[0].Streams[0].Labels)
+
+	// multi-byte characters should not be split.
+	request = makeWriteRequest(1, 10)
+	request.Streams[0].Labels = `{app="foo", path="/éééééééééééééééééééééé"}`
+	_, err = d.Push(ctx, request)
+	require.NoError(t, err)
+	pushed := ingester.pushedFor("test")
+	require.Equal(t, `{app="foo", path="/ééé-6e23b358cd2df0c5"}`, pushed[len(pushed)-1].Streams[0].Labels)
+}
+
+func Test_DebugSampling(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.EnforceMetricName = false
+	limits.DebugTenantID = "debug"
+	limits.DebugSampleRatio = 1
+	ingester := &mockIngester{}
+
+	d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	request := makeWriteRequest(10, 10)
+	_, err := d.Push(ctx, request)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(ingester.pushedFor("debug")) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	debug := ingester.pushedFor("debug")[0]
+	require.Len(t, debug.Streams, 1)
+	require.Equal(t, request.Streams[0].Labels, debug.Streams[0].Labels)
+	require.Len(t, debug.Streams[0].Entries, 10)
+}
+
+func Test_DebugSamplesDropped(t *testing.T) {
+	d := &Distributor{
+		// The pusher is not running, so that its queue fills up.
+		debugSamplesPusher: newBackgroundPusher(1, 1),
+		debugDroppedLines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "debug_sampled_dropped_lines_total",
+		}, []string{"tenant"}),
+	}
+
+	d.sendDebugSamples("test", "debug", makeWriteRequest(4, 10).Streams)
+	require.Len(t, d.debugSamplesPusher.queue, 1)
+	d.sendDebugSamples("test", "debug", makeWriteRequest(4, 10).Streams)
+	require.Equal(t, float64(4), testutil.ToFloat64(d.debugDroppedLines.WithLabelValues("test")))
+}
+
+func Test_DeadLetters(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.EnforceMetricName = false
+	limits.DeadLetterTenantID = "dead"
+	ingester := &mockIngester{}
+
+	d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	request := makeWriteRequest(10, 10)
+	for i := range request.Streams[0].Entries[:4] {
+		request.Streams[0].Entries[i].Timestamp = time.Now().Add(-30 * 24 * time.Hour)
+	}
+	_, err := d.Push(ctx, request)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusBadRequest), resp.Code)
+	require.Eventually(t, func() bool {
+		return len(ingester.pushedFor("dead")) == 3 // one push per replica
+	}, time.Second, 10*time.Millisecond)
+
+	// Only the rejected entries are forwarded, with the tenant and the reason of the rejection.
+	require.Len(t, ingester.pushedFor("test")[0].Streams[0].Entries, 6)
+	dead := ingester.pushedFor("dead")[0]
+	require.Len(t, dead.Streams, 1)
+	require.Equal(t, `{foo="bar", rejected_reason="greater_than_max_sample_age", rejected_tenant="test"}`, dead.Streams[0].Labels)
+	require.Len(t, dead.Streams[0].Entries, 4)
+	require.Equal(t, "0         ", dead.Streams[0].Entries[0].Line)
+
+	// The entries rejected for the dead letter tenant itself are dropped.
+	request = makeWriteRequest(1, 10)
+	request.Streams[0].Entries[0].Timestamp = time.Now().Add(-30 * 24 * time.Hour)
+	_, err = d.Push(user.InjectOrgID(context.Background(), "dead"), request)
+	require.Error(t, err)
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, ingester.pushedFor("dead"), 3)
+}
+
+func Test_DeadLettersDropped(t *testing.T) {
+	d := &Distributor{
+		cfg: Config{DeadLetters: DeadLettersConfig{RateLimit: 1, RateBurst: 10}},
+		// The pusher is not running, so that its queue fills up.
+		deadLettersPusher:     newBackgroundPusher(1, 1),
+		deadLetterRateLimiter: newStreamRateLimiter(),
+		deadLetterDroppedLines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dead_letter_dropped_lines_total",
+		}, []string{"tenant", "reason"}),
+	}
+	send := func() {
+		dl := newDeadLetters(ctx, validationContext{userID: "test", deadLetterTenantID: "dead"}, time.Now())
+		dl.add(validation.RateLimited, `{foo="bar"}`, makeWriteRequest(4, 10).Streams[0].Entries...)
+		d.sendDeadLetters(dl)
+	}
+
+	send()
+	require.Len(t, d.deadLettersPusher.queue, 1)
+	send()
+	require.Equal(t, float64(4), testutil.ToFloat64(d.deadLetterDroppedLines.WithLabelValues("test", deadLetterQueueFull)))
+	// The burst of the tenant is exhausted.
+	send()
+	require.Equal(t, float64(4), testutil.ToFloat64(d.deadLetterDroppedLines.WithLabelValues("test", deadLetterRateLimited)))
+}
+
+func Test_SampleStream(t *testing.T) {
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		lbs := fmt.Sprintf(`{app="foo", id="%d"}`, i)
+		if sampleStream(0.1, lbs) {
+			sampled++
+		}
+		// the decision only depends on the labels.
+		require.Equal(t, sampleStream(0.1, lbs), sampleStream(0.1, lbs))
+		require.False(t, sampleStream(0, lbs))
+		require.True(t, sampleStream(1, lbs))
+	}
+	require.InDelta(t, 1000, sampled, 100)
+}
+
 func Benchmark_SortLabelsOnPush(b *testing.B) {
 	limits := &validation.Limits{}
 	flagext.DefaultValues(limits)
@@ -354,6 +502,283 @@ func prepare(t *testing.T, limits *validation.Limits, kvStore kv.Client, factory
 	return d
 }
 
+func Test_ZoneAwareReplication(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		failingZones []string
+		expectedErr  bool
+	}{
+		{name: "all zones available"},
+		{name: "a zone outage", failingZones: []string{"zone-c"}},
+		{name: "the outage of 2 zones", failingZones: []string{"zone-b", "zone-c"}, expectedErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.RejectOldSamples = false
+
+			// 3 zones of 3 ingesters.
+			kvStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+			desc := ring.NewDesc()
+			ingesters := map[string]*mockIngester{}
+			zones := map[string]string{}
+			for _, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+				for i := 0; i < 3; i++ {
+					addr := fmt.Sprintf("%s-ingester%d", zone, i)
+					desc.AddIngester(addr, addr, zone, ring.GenerateTokens(128, nil), ring.ACTIVE, time.Now())
+					ingesters[addr] = &mockIngester{}
+					zones[addr] = zone
+				}
+			}
+			for _, zone := range tc.failingZones {
+				for addr, ingester := range ingesters {
+					if zones[addr] == zone {
+						ingester.err = errors.New("zone unavailable")
+					}
+				}
+			}
+			require.NoError(t, kvStore.CAS(context.Background(), ring.IngesterRingKey, func(_ interface{}) (interface{}, bool, error) {
+				return desc, true, nil
+			}))
+
+			ingestersRing, err := ring.NewWithStoreClientAndStrategy(ring.Config{
+				HeartbeatTimeout:     time.Minute,
+				ReplicationFactor:    3,
+				ZoneAwarenessEnabled: true,
+			}, "ingester", ring.IngesterRingKey, kvStore, ring.NewDefaultReplicationStrategy(), nil, log.NewNopLogger())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), ingestersRing))
+			defer services.StopAndAwaitTerminated(context.Background(), ingestersRing) //nolint:errcheck
+			test.Poll(t, time.Second, 9, func() interface{} {
+				return ingestersRing.InstancesCount()
+			})
+
+			d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) {
+				return ingesters[addr], nil
+			})
+			defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+			d.ingestersRing = ingestersRing
+
+			request := &logproto.PushRequest{}
+			for _, app := range []string{"a", "b", "c", "d", "e"} {
+				request.Streams = append(request.Streams, logproto.Stream{
+					Labels:  fmt.Sprintf(`{app="%s"}`, app),
+					Entries: []logproto.Entry{{Timestamp: time.Now(), Line: "line"}},
+				})
+			}
+			_, err = d.Push(ctx, request)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// Every zone available holds a single replica of every stream.
+			for _, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+				expected := len(request.Streams)
+				for _, failing := range tc.failingZones {
+					if zone == failing {
+						expected = 0
+					}
+				}
+				require.Eventually(t, func() bool {
+					var streams int
+					for addr, ingester := range ingesters {
+						if zones[addr] != zone {
+							continue
+						}
+						for _, pushed := range ingester.pushedFor("test") {
+							streams += len(pushed.Streams)
+						}
+					}
+					return streams == expected
+				}, time.Second, 10*time.Millisecond, zone)
+			}
+		})
+	}
+}
+
+func Test_DropRules(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		rules         []validation.DropRule
+		expectedLines int
+		dropped       map[string]float64
+	}{
+		{
+			name:          "no rules",
+			expectedLines: 10,
+		},
+		{
+			name: "selector not matching",
+			rules: []validation.DropRule{
+				{Name: "health", Selector: `{foo="baz"}`},
+			},
+			expectedLines: 10,
+			dropped:       map[string]float64{"health": 0},
+		},
+		{
+			name: "drop lines matching the regex",
+			rules: []validation.DropRule{
+				{Name: "ones", Selector: `{foo="bar"}`, LineRegex: `^1`},
+				{Name: "twos", Selector: `{foo=~"b.*"}`, LineRegex: `^2`},
+			},
+			expectedLines: 8,
+			dropped:       map[string]float64{"ones": 1, "twos": 1},
+		},
+		{
+			name: "drop the whole stream",
+			rules: []validation.DropRule{
+				{Name: "ones", Selector: `{foo="bar"}`, LineRegex: `^1`},
+				{Name: "all", Selector: `{foo="bar"}`},
+			},
+			expectedLines: 0,
+			dropped:       map[string]float64{"ones": 1, "all": 9},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.EnforceMetricName = false
+			limits.DropRules = tc.rules
+			require.NoError(t, limits.Validate())
+
+			ingester := &mockIngester{}
+			d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+			defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+			_, err := d.Push(ctx, makeWriteRequest(10, 10))
+			require.NoError(t, err)
+
+			if tc.expectedLines == 0 {
+				require.Len(t, ingester.pushed, 0)
+			} else {
+				require.Len(t, ingester.pushed[0].Streams[0].Entries, tc.expectedLines)
+			}
+			for rule, expected := range tc.dropped {
+				require.Equal(t, expected, testutil.ToFloat64(d.droppedLines.WithLabelValues("test", rule)), rule)
+				require.Equal(t, expected*10, testutil.ToFloat64(d.droppedBytes.WithLabelValues("test", rule)), rule)
+			}
+		})
+	}
+}
+
+func Test_StreamRelabeling(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		configs        []*rutil.RelabelConfig
+		expectedLabels string
+	}{
+		{
+			name:           "no relabel configs",
+			expectedLabels: `{foo="bar"}`,
+		},
+		{
+			name: "rename a label",
+			configs: []*rutil.RelabelConfig{
+				{SourceLabels: []string{"foo"}, TargetLabel: "app"},
+				{Regex: "foo", Action: "labeldrop"},
+			},
+			expectedLabels: `{app="bar"}`,
+		},
+		{
+			name: "drop the stream",
+			configs: []*rutil.RelabelConfig{
+				{SourceLabels: []string{"foo"}, Regex: "bar", Action: "drop"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.EnforceMetricName = false
+			limits.StreamRelabelConfigs = tc.configs
+			require.NoError(t, limits.Validate())
+
+			ingester := &mockIngester{}
+			d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+			defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+			_, err := d.Push(ctx, makeWriteRequest(10, 10))
+			require.NoError(t, err)
+
+			if tc.expectedLabels == "" {
+				require.Len(t, ingester.pushed, 0)
+				require.Equal(t, float64(10), testutil.ToFloat64(d.droppedLines.WithLabelValues("test", relabelDropRule)))
+				return
+			}
+			require.Equal(t, tc.expectedLabels, ingester.pushed[0].Streams[0].Labels)
+		})
+	}
+}
+
+func Test_StreamRateLimit(t *testing.T) {
+	for _, tc := range []struct {
+		policy    string
+		discarded float64
+		rejection bool
+	}{
+		{policy: validation.DropStreamRateLimitPolicy, discarded: 5},
+		{policy: validation.RejectStreamRateLimitPolicy, discarded: 10, rejection: true},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.EnforceMetricName = false
+			limits.DistributorStreamRateLimit = fe.ByteSize(10)
+			limits.DistributorStreamRateLimitBurst = fe.ByteSize(150)
+			limits.DistributorStreamRateLimitPolicy = tc.policy
+			require.NoError(t, limits.Validate())
+
+			d := prepare(t, limits, nil, nil)
+			defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+			tenant := "stream-rate-limit-" + tc.policy
+			ctx := user.InjectOrgID(context.Background(), tenant)
+			discarded := validation.DiscardedSamples.WithLabelValues(validation.StreamRateLimit, tenant)
+			before := testutil.ToFloat64(discarded)
+
+			// The first push is within the burst of the stream.
+			_, err := d.Push(ctx, makeWriteRequest(10, 10))
+			require.NoError(t, err)
+
+			// Another stream of the tenant has its own limit.
+			other := makeWriteRequest(10, 10)
+			other.Streams[0].Labels = `{foo="baz"}`
+			_, err = d.Push(ctx, other)
+			require.NoError(t, err)
+			require.Equal(t, before, testutil.ToFloat64(discarded))
+
+			_, err = d.Push(ctx, makeWriteRequest(10, 10))
+			if tc.rejection {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, before+tc.discarded, testutil.ToFloat64(discarded))
+			if !tc.rejection {
+				return
+			}
+
+			// The rejected push took no token, nor did the other stream of a rejected push.
+			both := makeWriteRequest(5, 10)
+			both.Streams = append(both.Streams, makeWriteRequest(10, 10).Streams[0])
+			both.Streams[1].Labels = `{foo="baz"}`
+			_, err = d.Push(ctx, both)
+			require.Error(t, err)
+			_, err = d.Push(ctx, makeWriteRequest(5, 10))
+			require.NoError(t, err)
+			other = makeWriteRequest(5, 10)
+			other.Streams[0].Labels = `{foo="baz"}`
+			_, err = d.Push(ctx, other)
+			require.NoError(t, err)
+		})
+	}
+}
+
 func makeWriteRequest(lines int, size int) *logproto.PushRequest {
 	req := logproto.PushRequest{
 		Streams: []logproto.Stream{
@@ -380,14 +805,37 @@ type mockIngester struct {
 	grpc_health_v1.HealthClient
 	logproto.PusherClient
 
-	pushed []*logproto.PushRequest
+	mtx     sync.Mutex
+	pushed  []*logproto.PushRequest
+	tenants []string
+	err     error
 }
 
 func (i *mockIngester) Push(ctx context.Context, in *logproto.PushRequest, opts ...grpc.CallOption) (*logproto.PushResponse, error) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	if i.err != nil {
+		return nil, i.err
+	}
+	orgID, _ := user.ExtractOrgID(ctx)
 	i.pushed = append(i.pushed, in)
+	i.tenants = append(i.tenants, orgID)
 	return nil, nil
 }
 
+// pushedFor returns the requests pushed for the given tenant.
+func (i *mockIngester) pushedFor(tenant string) []*logproto.PushRequest {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	var res []*logproto.PushRequest
+	for j, t := range i.tenants {
+		if t == tenant {
+			res = append(res, i.pushed[j])
+		}
+	}
+	return res
+}
+
 func (i *mockIngester) Close() error {
 	return nil
 }
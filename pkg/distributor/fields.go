@@ -0,0 +1,52 @@
+package distributor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/go-logfmt/logfmt"
+)
+
+// appendLogfmt appends key-value pairs to a line in logfmt.
+func appendLogfmt(line string, keyvals []interface{}) string {
+	if len(keyvals) == 0 {
+		return line
+	}
+	var buf bytes.Buffer
+	buf.WriteString(line)
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	enc := logfmt.NewEncoder(&buf)
+	for i := 0; i < len(keyvals); i += 2 {
+		// The keys are never empty, logfmt only fails on invalid keys.
+		_ = enc.EncodeKeyval(keyvals[i], keyvals[i+1])
+	}
+	return buf.String()
+}
+
+// valueString formats the value of a field received by the push endpoints, the arrays and objects in JSON.
+func valueString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			// Only the NaN and infinite floats can't be marshalled.
+			return ""
+		}
+		return string(b)
+	}
+}
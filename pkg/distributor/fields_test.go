@@ -0,0 +1,35 @@
+package distributor
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueString(t *testing.T) {
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{value: nil, expected: ""},
+		{value: "foo", expected: "foo"},
+		{value: true, expected: "true"},
+		{value: int64(-42), expected: "-42"},
+		{value: 1234567.5, expected: "1234567.5"},
+		{value: json.Number("42"), expected: "42"},
+		{value: []interface{}{"a", 1.5}, expected: `["a",1.5]`},
+		{value: map[string]interface{}{"a": int64(1)}, expected: `{"a":1}`},
+		{value: math.NaN(), expected: "NaN"},
+		{value: []interface{}{math.Inf(1)}, expected: ""},
+	} {
+		require.Equal(t, tc.expected, valueString(tc.value))
+	}
+}
+
+func TestAppendLogfmt(t *testing.T) {
+	require.Equal(t, "line", appendLogfmt("line", nil))
+	require.Equal(t, `line a=1 b="with space"`, appendLogfmt("line", []interface{}{"a", "1", "b", "with space"}))
+	require.Equal(t, "a=1", appendLogfmt("", []interface{}{"a", "1"}))
+}
@@ -0,0 +1,364 @@
+package distributor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/util/strutil"
+	"github.com/weaveworks/common/user"
+	"gopkg.in/Graylog2/go-gelf.v2/gelf"
+
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	// gelfBatchSize is the number of entries received by the GELF listeners pushed at once.
+	gelfBatchSize = 1000
+	// gelfBatchWait is how long the entries received by the GELF listeners wait for a full batch before being pushed.
+	gelfBatchWait = time.Second
+)
+
+// gelfSeverities are the names of the syslog severities of the GELF levels.
+var gelfSeverities = []string{"emergency", "alert", "critical", "error", "warning", "notice", "informational", "debug"}
+
+// GELFConfig configures the listeners of the logs sent with the Graylog Extended Log Format (GELF).
+type GELFConfig struct {
+	UDPListenAddress string                 `yaml:"udp_listen_address"`
+	TCPListenAddress string                 `yaml:"tcp_listen_address"`
+	Tenant           string                 `yaml:"tenant"`
+	FieldsAsLabels   flagext.StringSliceCSV `yaml:"fields_as_labels"`
+}
+
+// RegisterFlags registers the flags of the GELF listeners.
+func (cfg *GELFConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.UDPListenAddress, "distributor.gelf.udp-listen-address", "", "Address the distributor listens to for GELF messages over UDP, compressed or not and chunked or not, e.g. :12201. Empty to disable the listener.")
+	f.StringVar(&cfg.TCPListenAddress, "distributor.gelf.tcp-listen-address", "", "Address the distributor listens to for GELF messages over TCP, delimited by null bytes, e.g. :12201. Empty to disable the listener.")
+	f.StringVar(&cfg.Tenant, "distributor.gelf.tenant", "fake", "Tenant the GELF messages are pushed for, the listeners being unauthenticated.")
+	cfg.FieldsAsLabels = []string{"host"}
+	f.Var(&cfg.FieldsAsLabels, "distributor.gelf.fields-as-labels", "Comma-separated list of the fields of the GELF messages added as stream labels, e.g. host,facility,_container_name. The leading underscore of the additional fields is removed from the label names and the characters invalid in label names are replaced by underscores. The other fields are added to the lines in logfmt.")
+}
+
+func (cfg *GELFConfig) enabled() bool {
+	return cfg.UDPListenAddress != "" || cfg.TCPListenAddress != ""
+}
+
+// gelfListener receives the GELF messages and pushes them in batches.
+type gelfListener struct {
+	services.Service
+
+	cfg            GELFConfig
+	maxMessageSize int
+	push           func(tenant string, req *logproto.PushRequest) error
+	logger         log.Logger
+
+	udpReader   *gelf.Reader
+	tcpListener net.Listener
+	wg          sync.WaitGroup
+	closing     chan struct{}
+
+	mtx     sync.Mutex
+	streams map[string]*logproto.Stream
+	entries int
+
+	messages *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+}
+
+func newGELFListener(cfg GELFConfig, maxMessageSize int, push func(tenant string, req *logproto.PushRequest) error, registerer prometheus.Registerer) *gelfListener {
+	l := &gelfListener{
+		cfg:            cfg,
+		maxMessageSize: maxMessageSize,
+		push:           push,
+		logger:         log.With(util_log.Logger, "component", "gelf"),
+		closing:        make(chan struct{}),
+		streams:        map[string]*logproto.Stream{},
+		messages: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_gelf_messages_total",
+			Help:      "The total number of GELF messages received, by protocol.",
+		}, []string{"protocol"}),
+		errors: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "distributor_gelf_errors_total",
+			Help:      "The total number of GELF messages which could not be read or pushed, by reason.",
+		}, []string{"reason"}),
+	}
+	l.Service = services.NewBasicService(l.starting, l.running, l.stopping)
+	return l
+}
+
+func (l *gelfListener) starting(_ context.Context) error {
+	var err error
+	if l.cfg.UDPListenAddress != "" {
+		if l.udpReader, err = gelf.NewReader(l.cfg.UDPListenAddress); err != nil {
+			return err
+		}
+		level.Info(l.logger).Log("msg", "listening for GELF messages over UDP", "address", l.udpReader.Addr())
+		l.wg.Add(1)
+		go l.readUDP()
+	}
+	if l.cfg.TCPListenAddress != "" {
+		if l.tcpListener, err = net.Listen("tcp", l.cfg.TCPListenAddress); err != nil {
+			if l.udpReader != nil {
+				_ = l.udpReader.Close()
+			}
+			return err
+		}
+		level.Info(l.logger).Log("msg", "listening for GELF messages over TCP", "address", l.tcpListener.Addr())
+		l.wg.Add(1)
+		go l.acceptTCP()
+	}
+	return nil
+}
+
+func (l *gelfListener) running(ctx context.Context) error {
+	ticker := time.NewTicker(gelfBatchWait)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			l.flush(l.takeBatch())
+		}
+	}
+}
+
+func (l *gelfListener) stopping(_ error) error {
+	close(l.closing)
+	if l.udpReader != nil {
+		_ = l.udpReader.Close()
+	}
+	if l.tcpListener != nil {
+		_ = l.tcpListener.Close()
+	}
+	l.wg.Wait()
+	l.flush(l.takeBatch())
+	return nil
+}
+
+func (l *gelfListener) isClosing() bool {
+	select {
+	case <-l.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *gelfListener) readUDP() {
+	defer l.wg.Done()
+	for {
+		msg, err := l.udpReader.ReadMessage()
+		if l.isClosing() {
+			return
+		}
+		if err != nil {
+			l.errors.WithLabelValues("invalid_message").Inc()
+			level.Debug(l.logger).Log("msg", "failed to read GELF message", "protocol", "udp", "err", err)
+			continue
+		}
+		l.messages.WithLabelValues("udp").Inc()
+		l.add(msg)
+	}
+}
+
+func (l *gelfListener) acceptTCP() {
+	defer l.wg.Done()
+	var conns sync.WaitGroup
+	defer conns.Wait()
+	for {
+		conn, err := l.tcpListener.Accept()
+		if err != nil {
+			if l.isClosing() {
+				return
+			}
+			level.Warn(l.logger).Log("msg", "failed to accept GELF connection", "err", err)
+			continue
+		}
+		conns.Add(2)
+		done := make(chan struct{})
+		go func() {
+			defer conns.Done()
+			defer close(done)
+			l.readTCP(conn)
+		}()
+		// The connections are closed when the listener stops, interrupting their reads.
+		go func() {
+			defer conns.Done()
+			select {
+			case <-l.closing:
+			case <-done:
+			}
+			_ = conn.Close()
+		}()
+	}
+}
+
+// readTCP reads the messages of a connection, which are delimited by null bytes.
+func (l *gelfListener) readTCP(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64<<10), l.maxMessageSize)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var msg gelf.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			l.errors.WithLabelValues("invalid_message").Inc()
+			level.Debug(l.logger).Log("msg", "failed to read GELF message", "protocol", "tcp", "remote", conn.RemoteAddr(), "err", err)
+			continue
+		}
+		l.messages.WithLabelValues("tcp").Inc()
+		l.add(&msg)
+	}
+	if err := scanner.Err(); err != nil && !l.isClosing() {
+		l.errors.WithLabelValues("invalid_message").Inc()
+		level.Debug(l.logger).Log("msg", "closing GELF connection", "remote", conn.RemoteAddr(), "err", err)
+	}
+}
+
+// add adds a message to the batch, pushing the batch when it is full.
+func (l *gelfListener) add(msg *gelf.Message) {
+	ls, entry := l.cfg.entry(msg, time.Now())
+	key := ls.String()
+
+	l.mtx.Lock()
+	stream, ok := l.streams[key]
+	if !ok {
+		stream = &logproto.Stream{Labels: key}
+		l.streams[key] = stream
+	}
+	stream.Entries = append(stream.Entries, entry)
+	l.entries++
+	var batch *logproto.PushRequest
+	if l.entries >= gelfBatchSize {
+		batch = l.takeBatchLocked()
+	}
+	l.mtx.Unlock()
+
+	l.flush(batch)
+}
+
+func (l *gelfListener) takeBatch() *logproto.PushRequest {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.takeBatchLocked()
+}
+
+func (l *gelfListener) takeBatchLocked() *logproto.PushRequest {
+	if l.entries == 0 {
+		return nil
+	}
+	req := &logproto.PushRequest{Streams: make([]logproto.Stream, 0, len(l.streams))}
+	for _, s := range l.streams {
+		req.Streams = append(req.Streams, *s)
+	}
+	l.streams = map[string]*logproto.Stream{}
+	l.entries = 0
+	return req
+}
+
+func (l *gelfListener) flush(req *logproto.PushRequest) {
+	if req == nil {
+		return
+	}
+	if err := l.push(l.cfg.Tenant, req); err != nil {
+		var entries int
+		for _, s := range req.Streams {
+			entries += len(s.Entries)
+		}
+		l.errors.WithLabelValues("push").Add(float64(entries))
+		level.Warn(l.logger).Log("msg", "failed to push GELF messages", "tenant", l.cfg.Tenant, "entries", entries, "err", err)
+	}
+}
+
+// entry converts a GELF message to an entry and the labels of its stream. The line is the full message, else the
+// short message, followed by the level and the fields which aren't labels in logfmt. The streams without any label
+// get the job="gelf" label.
+func (cfg *GELFConfig) entry(msg *gelf.Message, now time.Time) (labels.Labels, logproto.Entry) {
+	fields := map[string]string{"host": msg.Host}
+	if msg.Facility != "" {
+		fields["facility"] = msg.Facility
+	}
+	// The level of the messages without level can't be told from the emergency level, it is omitted.
+	if msg.Level > 0 && int(msg.Level) < len(gelfSeverities) {
+		fields["level"] = gelfSeverities[msg.Level]
+	}
+	for k, v := range msg.Extra {
+		// The _id field is reserved by Graylog.
+		if k != "_id" {
+			fields[k] = valueString(v)
+		}
+	}
+
+	lb := labels.NewBuilder(nil)
+	for _, name := range cfg.FieldsAsLabels {
+		if v := fields[name]; v != "" {
+			lb.Set(strutil.SanitizeLabelName(strings.TrimPrefix(name, "_")), v)
+			delete(fields, name)
+		}
+	}
+	ls := lb.Labels()
+	if len(ls) == 0 {
+		ls = labels.Labels{{Name: "job", Value: "gelf"}}
+	}
+
+	line := msg.Full
+	if line == "" {
+		line = msg.Short
+	}
+	names := make([]string, 0, len(fields))
+	for name, v := range fields {
+		if v != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.TrimPrefix(names[i], "_") < strings.TrimPrefix(names[j], "_") })
+	keyvals := make([]interface{}, 0, 2*len(names))
+	for _, name := range names {
+		keyvals = append(keyvals, strings.TrimPrefix(name, "_"), fields[name])
+	}
+
+	ts := now
+	if msg.TimeUnix > 0 {
+		// The timestamp is in seconds with decimals for the fractional seconds.
+		ts = time.Unix(0, int64(msg.TimeUnix*float64(time.Second)))
+	}
+	return ls, logproto.Entry{Timestamp: ts, Line: appendLogfmt(line, keyvals)}
+}
+
+// pushFromListener pushes the streams received by a listener, which has no request context, for a tenant.
+func (d *Distributor) pushFromListener(tenant string, req *logproto.PushRequest) error {
+	if err := push.RecordReceived(tenant, req, d.tenantsRetention); err != nil {
+		return err
+	}
+	_, err := d.Push(user.InjectOrgID(context.Background(), tenant), req)
+	return err
+}
@@ -0,0 +1,111 @@
+package distributor
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/Graylog2/go-gelf.v2/gelf"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func Test_GELFEntry(t *testing.T) {
+	cfg := GELFConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.FieldsAsLabels = []string{"host", "_container_name"}
+	now := time.Unix(20, 0)
+
+	for _, tc := range []struct {
+		name   string
+		msg    gelf.Message
+		labels labels.Labels
+		entry  logproto.Entry
+	}{
+		{
+			name: "full",
+			msg: gelf.Message{
+				Host:     "host-1",
+				Short:    "short",
+				Full:     "full message",
+				TimeUnix: 10.5,
+				Level:    3,
+				Facility: "app",
+				Extra:    map[string]interface{}{"_container_name": "web", "_request_id": "abc", "_status": float64(500), "_id": "reserved"},
+			},
+			labels: labels.Labels{{Name: "container_name", Value: "web"}, {Name: "host", Value: "host-1"}},
+			entry:  logproto.Entry{Timestamp: time.Unix(10, 5e8), Line: "full message facility=app level=error request_id=abc status=500"},
+		},
+		{
+			name:   "minimal",
+			msg:    gelf.Message{Short: "short"},
+			labels: labels.Labels{{Name: "job", Value: "gelf"}},
+			entry:  logproto.Entry{Timestamp: now, Line: "short"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ls, entry := cfg.entry(&tc.msg, now)
+			require.Equal(t, tc.labels, ls)
+			require.Equal(t, tc.entry, entry)
+		})
+	}
+}
+
+func Test_GELFListener(t *testing.T) {
+	cfg := GELFConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.UDPListenAddress = "127.0.0.1:0"
+	cfg.TCPListenAddress = "127.0.0.1:0"
+	cfg.Tenant = "gelf"
+
+	var (
+		mtx    sync.Mutex
+		pushed = map[string][]string{}
+	)
+	l := newGELFListener(cfg, 1<<20, func(tenant string, req *logproto.PushRequest) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		for _, s := range req.Streams {
+			for _, e := range s.Entries {
+				pushed[tenant] = append(pushed[tenant], s.Labels+" "+e.Line)
+			}
+		}
+		return nil
+	}, nil)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), l))
+
+	// A compressed message and an uncompressed message chunked over UDP.
+	w, err := gelf.NewUDPWriter(l.udpReader.Addr())
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, w.WriteMessage(&gelf.Message{Version: "1.1", Host: "host-1", Short: "compressed", Level: 6}))
+	w.CompressionType = gelf.CompressNone
+	long := strings.Repeat("a", 3*gelf.ChunkSize)
+	require.NoError(t, w.WriteMessage(&gelf.Message{Version: "1.1", Host: "host-1", Short: long}))
+
+	// Messages delimited by null bytes over TCP, the invalid ones being skipped.
+	conn, err := net.Dial("tcp", l.tcpListener.Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(`{"version":"1.1","host":"host-2","short_message":"first"}` + "\x00" + `not json` + "\x00" + `{"version":"1.1","host":"host-2","short_message":"second","_user":"bob"}` + "\x00"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(pushed["gelf"]) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), l))
+
+	require.Contains(t, pushed["gelf"], `{host="host-1"} compressed level=informational`)
+	require.Contains(t, pushed["gelf"], `{host="host-1"} `+long)
+	require.Contains(t, pushed["gelf"], `{host="host-2"} first`)
+	require.Contains(t, pushed["gelf"], `{host="host-2"} second user=bob`)
+}
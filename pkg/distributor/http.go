@@ -1,6 +1,7 @@
 package distributor
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -18,16 +19,22 @@ import (
 func (d *Distributor) PushHandler(w http.ResponseWriter, r *http.Request) {
 	logger := util_log.WithContext(r.Context(), util_log.Logger)
 	userID, _ := tenant.TenantID(r.Context())
-	req, err := push.ParseRequest(logger, userID, r, d.tenantsRetention)
+	req, err := push.ParseRequest(logger, userID, r, d.tenantsRetention, d.cfg.MaxRecvMsgSize)
 	if err != nil {
+		code := http.StatusBadRequest
+		var encodingErr push.UnsupportedContentEncodingError
+		if errors.As(err, &encodingErr) {
+			code = http.StatusUnsupportedMediaType
+			w.Header().Set("Accept-Encoding", strings.Join(push.SupportedContentEncodings, ", "))
+		}
 		if d.tenantConfigs.LogPushRequest(userID) {
 			level.Debug(logger).Log(
 				"msg", "push request failed",
-				"code", http.StatusBadRequest,
+				"code", code,
 				"err", err,
 			)
 		}
-		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		serverutil.JSONError(w, code, err.Error())
 		return
 	}
 
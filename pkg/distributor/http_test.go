@@ -1 +1,34 @@
 package distributor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestPushHandler_UnsupportedContentEncoding(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return &mockIngester{}, nil })
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(`{"streams": []}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "br")
+	req = req.WithContext(user.InjectOrgID(req.Context(), "test"))
+	w := httptest.NewRecorder()
+	d.PushHandler(w, req)
+
+	require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	require.Equal(t, "gzip, snappy, zstd", w.Header().Get("Accept-Encoding"))
+}
@@ -1,6 +1,12 @@
 package distributor
 
-import "time"
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/pkg/validation"
+)
 
 // Limits is an interface for distributor limits/related configs
 type Limits interface {
@@ -10,8 +16,22 @@ type Limits interface {
 	MaxLabelNamesPerSeries(userID string) int
 	MaxLabelNameLength(userID string) int
 	MaxLabelValueLength(userID string) int
+	HashLabelValuesLongerThan(userID string) int
+	HashedLabelValuePrefixLength(userID string) int
+	DebugTenantID(userID string) string
+	DebugSampleRatio(userID string) float64
+	DeadLetterTenantID(userID string) string
+	IngestionMonthlyBytesCap(userID string) int
+	IngestionMonthlyLinesCap(userID string) int
+	DistributorStreamRateLimit(userID string) validation.RateLimit
+	DistributorStreamRateLimitPolicy(userID string) string
 
 	CreationGracePeriod(userID string) time.Duration
 	RejectOldSamples(userID string) bool
 	RejectOldSamplesMaxAge(userID string) time.Duration
+
+	DropRules(userID string) []validation.DropRule
+	StreamRelabelConfigs(userID string) []*relabel.Config
+
+	FeatureEnabled(userID string, feature validation.FeatureFlag) bool
 }
@@ -0,0 +1,227 @@
+package distributor
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/util/strutil"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logproto/otlp"
+	"github.com/grafana/loki/pkg/tenant"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+const applicationProtobuf = "application/x-protobuf"
+
+// OTLPConfig configures the ingestion of the logs sent with the OpenTelemetry Logs protocol (OTLP).
+type OTLPConfig struct {
+	ResourceAttributesAsLabels flagext.StringSliceCSV `yaml:"resource_attributes_as_labels"`
+	ScopeAttributesAsLabels    flagext.StringSliceCSV `yaml:"scope_attributes_as_labels"`
+}
+
+// RegisterFlags registers the flags of the OTLP ingestion.
+func (cfg *OTLPConfig) RegisterFlags(f *flag.FlagSet) {
+	cfg.ResourceAttributesAsLabels = []string{"service.name", "service.namespace", "deployment.environment", "k8s.namespace.name", "k8s.container.name"}
+	f.Var(&cfg.ResourceAttributesAsLabels, "distributor.otlp.resource-attributes-as-labels", "Comma-separated list of the resource attributes of the OTLP logs added as stream labels, with the characters invalid in label names replaced by underscores, e.g. service.name becomes service_name. The other resource attributes are dropped.")
+	f.Var(&cfg.ScopeAttributesAsLabels, "distributor.otlp.scope-attributes-as-labels", "Comma-separated list of the instrumentation scope attributes of the OTLP logs added as stream labels, like the resource attributes.")
+}
+
+// OTLPHandler ingests the logs of an OTLP/HTTP export request, protobuf encoded and optionally gzip compressed.
+func (d *Distributor) OTLPHandler(w http.ResponseWriter, r *http.Request) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || contentType != applicationProtobuf {
+		serverutil.JSONError(w, http.StatusUnsupportedMediaType, "unsupported content type, OTLP logs must be sent as "+applicationProtobuf)
+		return
+	}
+
+	var body io.Reader = r.Body
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "":
+	case "gzip":
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer gzipReader.Close()
+		body = push.NewMaxSizeReader(gzipReader, d.cfg.MaxRecvMsgSize)
+	default:
+		serverutil.JSONError(w, http.StatusUnsupportedMediaType, "Content-Encoding "+strconv.Quote(encoding)+" not supported")
+		return
+	}
+
+	var req otlp.ExportLogsServiceRequest
+	if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), d.cfg.MaxRecvMsgSize, &req, util.NoCompression); err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := d.Export(r.Context(), &req); err != nil {
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			serverutil.JSONError(w, int(resp.Code), string(resp.Body))
+		} else {
+			serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	// The empty body is an empty ExportLogsServiceResponse.
+	w.Header().Set("Content-Type", applicationProtobuf)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Export implements otlp.LogsServiceServer, ingesting the logs of an OTLP/gRPC export request.
+func (d *Distributor) Export(ctx context.Context, req *otlp.ExportLogsServiceRequest) (*otlp.ExportLogsServiceResponse, error) {
+	pushReq := d.cfg.OTLP.pushRequest(req, time.Now())
+	userID, _ := tenant.TenantID(ctx)
+	if err := push.RecordReceived(userID, pushReq, d.tenantsRetention); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	if _, err := d.Push(ctx, pushReq); err != nil {
+		level.Debug(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "OTLP push request failed", "err", err)
+		return nil, err
+	}
+	return &otlp.ExportLogsServiceResponse{}, nil
+}
+
+// pushRequest converts the logs of an OTLP export request to streams, whose labels are the allowed attributes of the
+// resources and scopes of the logs. The streams without any of them get the service_name="unknown_service" label,
+// the default service name of OpenTelemetry.
+func (cfg *OTLPConfig) pushRequest(req *otlp.ExportLogsServiceRequest, now time.Time) *logproto.PushRequest {
+	streams := map[string]*logproto.Stream{}
+	for _, rl := range req.ResourceLogs {
+		var resourceAttributes []*otlp.KeyValue
+		if rl.Resource != nil {
+			resourceAttributes = rl.Resource.Attributes
+		}
+		scopeLogs := append(rl.ScopeLogs[:len(rl.ScopeLogs):len(rl.ScopeLogs)], rl.InstrumentationLibraryLogs...)
+		for _, sl := range scopeLogs {
+			if len(sl.LogRecords) == 0 {
+				continue
+			}
+			lb := labels.NewBuilder(nil)
+			addAttributesAsLabels(lb, resourceAttributes, cfg.ResourceAttributesAsLabels)
+			if sl.Scope != nil {
+				addAttributesAsLabels(lb, sl.Scope.Attributes, cfg.ScopeAttributesAsLabels)
+			}
+			ls := lb.Labels()
+			if len(ls) == 0 {
+				ls = labels.Labels{{Name: "service_name", Value: "unknown_service"}}
+			}
+
+			key := ls.String()
+			stream, ok := streams[key]
+			if !ok {
+				stream = &logproto.Stream{Labels: key}
+				streams[key] = stream
+			}
+			for _, lr := range sl.LogRecords {
+				stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: logRecordTimestamp(lr, now), Line: logRecordLine(lr)})
+			}
+		}
+	}
+
+	res := &logproto.PushRequest{Streams: make([]logproto.Stream, 0, len(streams))}
+	for _, s := range streams {
+		res.Streams = append(res.Streams, *s)
+	}
+	sort.Slice(res.Streams, func(i, j int) bool { return res.Streams[i].Labels < res.Streams[j].Labels })
+	return res
+}
+
+func addAttributesAsLabels(lb *labels.Builder, attributes []*otlp.KeyValue, allowed []string) {
+	for _, name := range allowed {
+		for _, attr := range attributes {
+			if attr.Key != name {
+				continue
+			}
+			if v := anyValueString(attr.Value); v != "" {
+				lb.Set(strutil.SanitizeLabelName(name), v)
+			}
+			break
+		}
+	}
+}
+
+// logRecordTimestamp returns the time of the event of a log record, else the time it was observed at.
+func logRecordTimestamp(lr *otlp.LogRecord, now time.Time) time.Time {
+	switch {
+	case lr.TimeUnixNano != 0:
+		return time.Unix(0, int64(lr.TimeUnixNano))
+	case lr.ObservedTimeUnixNano != 0:
+		return time.Unix(0, int64(lr.ObservedTimeUnixNano))
+	default:
+		return now
+	}
+}
+
+// logRecordLine returns the body of a log record followed by its severity, trace and span IDs and attributes
+// in logfmt.
+func logRecordLine(lr *otlp.LogRecord) string {
+	keyvals := make([]interface{}, 0, 6+2*len(lr.Attributes))
+	if lr.SeverityText != "" {
+		keyvals = append(keyvals, "severity", lr.SeverityText)
+	}
+	if len(lr.TraceId) > 0 {
+		keyvals = append(keyvals, "trace_id", hex.EncodeToString(lr.TraceId))
+	}
+	if len(lr.SpanId) > 0 {
+		keyvals = append(keyvals, "span_id", hex.EncodeToString(lr.SpanId))
+	}
+	for _, attr := range lr.Attributes {
+		keyvals = append(keyvals, attr.Key, anyValueString(attr.Value))
+	}
+	return appendLogfmt(anyValueString(lr.Body), keyvals)
+}
+
+// anyValueString formats a value, the arrays and maps in JSON.
+func anyValueString(v *otlp.AnyValue) string {
+	return valueString(anyValueJSON(v))
+}
+
+func anyValueJSON(v *otlp.AnyValue) interface{} {
+	switch {
+	case v == nil:
+		return nil
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return *v.IntValue
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.BytesValue != nil:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case v.ArrayValue != nil:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, anyValueJSON(value))
+		}
+		return values
+	case v.KvlistValue != nil:
+		values := make(map[string]interface{}, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[kv.Key] = anyValueJSON(kv.Value)
+		}
+		return values
+	default:
+		return nil
+	}
+}
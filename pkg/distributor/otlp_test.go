@@ -0,0 +1,154 @@
+package distributor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logproto/otlp"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func stringValue(s string) *otlp.AnyValue {
+	return &otlp.AnyValue{StringValue: &s}
+}
+
+func intValue(i int64) *otlp.AnyValue {
+	return &otlp.AnyValue{IntValue: &i}
+}
+
+func otlpRequest() *otlp.ExportLogsServiceRequest {
+	return &otlp.ExportLogsServiceRequest{
+		ResourceLogs: []*otlp.ResourceLogs{
+			{
+				Resource: &otlp.Resource{Attributes: []*otlp.KeyValue{
+					{Key: "service.name", Value: stringValue("checkout")},
+					{Key: "host.name", Value: stringValue("host-1")},
+				}},
+				ScopeLogs: []*otlp.ScopeLogs{{
+					Scope: &otlp.InstrumentationScope{Name: "logger", Attributes: []*otlp.KeyValue{{Key: "team", Value: stringValue("payments")}}},
+					LogRecords: []*otlp.LogRecord{
+						{
+							TimeUnixNano: uint64(time.Unix(10, 0).UnixNano()),
+							SeverityText: "ERROR",
+							Body:         stringValue("payment failed"),
+							Attributes:   []*otlp.KeyValue{{Key: "order.id", Value: intValue(42)}, {Key: "reason", Value: stringValue("card declined")}},
+							TraceId:      []byte{0xab, 0xcd},
+						},
+						{
+							ObservedTimeUnixNano: uint64(time.Unix(11, 0).UnixNano()),
+							Body: &otlp.AnyValue{KvlistValue: &otlp.KeyValueList{Values: []*otlp.KeyValue{
+								{Key: "msg", Value: stringValue("retrying")},
+								{Key: "attempt", Value: intValue(2)},
+							}}},
+						},
+					},
+				}},
+			},
+			{
+				InstrumentationLibraryLogs: []*otlp.ScopeLogs{{
+					LogRecords: []*otlp.LogRecord{{Body: stringValue("no resource")}},
+				}},
+			},
+		},
+	}
+}
+
+func Test_OTLPPushRequest(t *testing.T) {
+	cfg := OTLPConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.ScopeAttributesAsLabels = []string{"team"}
+	now := time.Unix(20, 0)
+
+	require.Equal(t, &logproto.PushRequest{Streams: []logproto.Stream{
+		{
+			Labels: `{service_name="checkout", team="payments"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(10, 0), Line: `payment failed severity=ERROR trace_id=abcd order.id=42 reason="card declined"`},
+				{Timestamp: time.Unix(11, 0), Line: `{"attempt":2,"msg":"retrying"}`},
+			},
+		},
+		{
+			Labels:  `{service_name="unknown_service"}`,
+			Entries: []logproto.Entry{{Timestamp: now, Line: "no resource"}},
+		},
+	}}, cfg.pushRequest(otlpRequest(), now))
+}
+
+func Test_OTLPHandler(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RejectOldSamples = false
+	ingester := &mockIngester{}
+	d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	body, err := otlpRequest().Marshal()
+	require.NoError(t, err)
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, err = gw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		encoding    string
+		body        []byte
+		code        int
+	}{
+		{"protobuf", "application/x-protobuf", "", body, http.StatusOK},
+		{"gzipped protobuf", "application/x-protobuf", "gzip", gzipped.Bytes(), http.StatusOK},
+		{"json", "application/json", "", []byte(`{}`), http.StatusUnsupportedMediaType},
+		{"invalid protobuf", "application/x-protobuf", "", []byte("foo"), http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/otlp/v1/logs", bytes.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req.Header.Set("Content-Encoding", tc.encoding)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "otlp"))
+			w := httptest.NewRecorder()
+			d.OTLPHandler(w, req)
+			require.Equal(t, tc.code, w.Code, w.Body.String())
+		})
+	}
+
+	// The 2 streams of each successful request are pushed to the 3 replicas.
+	require.Eventually(t, func() bool {
+		var streams int
+		for _, pushed := range ingester.pushedFor("otlp") {
+			streams += len(pushed.Streams)
+		}
+		return streams == 12
+	}, time.Second, 10*time.Millisecond)
+
+	// The bodies larger than the maximum size once decompressed are rejected.
+	d.cfg.MaxRecvMsgSize = len(body) - 1
+	for name, encoding := range map[string]string{"too large protobuf": "", "too large gzipped protobuf": "gzip"} {
+		t.Run(name, func(t *testing.T) {
+			b := body
+			if encoding == "gzip" {
+				b = gzipped.Bytes()
+			}
+			req := httptest.NewRequest(http.MethodPost, "/otlp/v1/logs", bytes.NewReader(b))
+			req.Header.Set("Content-Type", "application/x-protobuf")
+			req.Header.Set("Content-Encoding", encoding)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "otlp"))
+			w := httptest.NewRecorder()
+			d.OTLPHandler(w, req)
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
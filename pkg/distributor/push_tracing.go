@@ -0,0 +1,224 @@
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	// defaultPushTracingDuration is how long the push requests of a tenant are traced when no duration is given.
+	defaultPushTracingDuration = 15 * time.Minute
+	// maxTracedStreams is the number of streams logged per traced push request, the others are only counted.
+	maxTracedStreams = 100
+)
+
+// pushTracingSession samples the push requests of a tenant until it expires.
+type pushTracingSession struct {
+	Tenant string    `json:"tenant"`
+	Ratio  float64   `json:"ratio"`
+	Until  time.Time `json:"until"`
+}
+
+// pushTracer holds the push tracing sessions of a distributor, toggled with the push tracing endpoint.
+type pushTracer struct {
+	mtx      sync.RWMutex
+	sessions map[string]pushTracingSession
+}
+
+func newPushTracer() *pushTracer {
+	return &pushTracer{sessions: map[string]pushTracingSession{}}
+}
+
+// enable starts tracing a tenant, replacing its current session if any.
+func (t *pushTracer) enable(s pushTracingSession) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.sessions[s.Tenant] = s
+}
+
+// disable stops tracing a tenant.
+func (t *pushTracer) disable(tenant string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.sessions, tenant)
+}
+
+// active returns the sessions which didn't expire yet sorted by tenant, forgetting the expired ones.
+func (t *pushTracer) active(now time.Time) []pushTracingSession {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	sessions := make([]pushTracingSession, 0, len(t.sessions))
+	for tenant, s := range t.sessions {
+		if now.After(s.Until) {
+			delete(t.sessions, tenant)
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Tenant < sessions[j].Tenant })
+	return sessions
+}
+
+// sample tells if a push request of the tenant is traced.
+func (t *pushTracer) sample(now time.Time, tenant string) bool {
+	t.mtx.RLock()
+	s, ok := t.sessions[tenant]
+	t.mtx.RUnlock()
+	if !ok || now.After(s.Until) {
+		return false
+	}
+	return s.Ratio >= 1 || rand.Float64() < s.Ratio
+}
+
+// tracedStream is the metadata of a stream of a traced push request.
+type tracedStream struct {
+	labels  string
+	entries int
+	bytes   int
+	first   time.Time
+	last    time.Time
+}
+
+// tracedPush is the metadata of a traced push request, taken as received before it is validated.
+type tracedPush struct {
+	streams []tracedStream
+	total   int
+	entries int
+	bytes   int
+}
+
+func newTracedPush(req *logproto.PushRequest) *tracedPush {
+	p := &tracedPush{total: len(req.Streams)}
+	for i, s := range req.Streams {
+		ts := tracedStream{labels: s.Labels, entries: len(s.Entries)}
+		for _, e := range s.Entries {
+			ts.bytes += len(e.Line)
+		}
+		if len(s.Entries) > 0 {
+			ts.first, ts.last = s.Entries[0].Timestamp, s.Entries[len(s.Entries)-1].Timestamp
+		}
+		p.entries += ts.entries
+		p.bytes += ts.bytes
+		if i < maxTracedStreams {
+			p.streams = append(p.streams, ts)
+		}
+	}
+	return p
+}
+
+// log logs the metadata of the request and its outcome.
+func (p *tracedPush) log(ctx context.Context, userID string, err error) {
+	logger := util_log.WithContext(ctx, util_log.Logger)
+	status := "success"
+	if err != nil {
+		status = err.Error()
+	}
+	level.Info(logger).Log(
+		"msg", "traced push request",
+		"tenant", userID,
+		"streams", p.total,
+		"entries", p.entries,
+		"bytes", p.bytes,
+		"status", status,
+	)
+	for _, s := range p.streams {
+		level.Info(logger).Log(
+			"msg", "traced push request stream",
+			"tenant", userID,
+			"labels", s.labels,
+			"entries", s.entries,
+			"bytes", s.bytes,
+			"first", s.first.Format(time.RFC3339Nano),
+			"last", s.last.Format(time.RFC3339Nano),
+		)
+	}
+	if p.total > len(p.streams) {
+		level.Info(logger).Log("msg", "traced push request streams truncated", "tenant", userID, "logged", len(p.streams), "streams", p.total)
+	}
+}
+
+// PushTracingHandler lists, starts or stops the tracing of the push requests of tenants by this distributor,
+// logging the labels, entry counts and first and last timestamps of the streams of a sample of their requests.
+//   - GET returns the active sessions.
+//   - POST starts tracing the tenant of the "tenant" parameter, for the "duration" parameter (default 15m) and
+//     sampling the "ratio" parameter of the requests (default 1).
+//   - DELETE stops tracing the tenant of the "tenant" parameter.
+func (d *Distributor) PushTracingHandler(w http.ResponseWriter, r *http.Request) {
+	if d.cfg.PushTracingMaxDuration <= 0 {
+		http.Error(w, "push tracing is disabled", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(d.pushTracer.active(time.Now())); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	case http.MethodPost:
+		s, err := d.parsePushTracingSession(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d.pushTracer.enable(s)
+		level.Info(util_log.Logger).Log("msg", "push tracing started", "tenant", s.Tenant, "ratio", s.Ratio, "until", s.Until)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		tenant := r.FormValue("tenant")
+		if tenant == "" {
+			http.Error(w, "missing tenant parameter", http.StatusBadRequest)
+			return
+		}
+		d.pushTracer.disable(tenant)
+		level.Info(util_log.Logger).Log("msg", "push tracing stopped", "tenant", tenant)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (d *Distributor) parsePushTracingSession(r *http.Request) (pushTracingSession, error) {
+	s := pushTracingSession{Tenant: r.FormValue("tenant"), Ratio: 1}
+	if s.Tenant == "" {
+		return s, fmt.Errorf("missing tenant parameter")
+	}
+
+	duration := defaultPushTracingDuration
+	if v := r.FormValue("duration"); v != "" {
+		var err error
+		if duration, err = time.ParseDuration(v); err != nil {
+			return s, fmt.Errorf("invalid duration: %w", err)
+		}
+	}
+	if duration <= 0 || duration > d.cfg.PushTracingMaxDuration {
+		return s, fmt.Errorf("duration must be > 0 and <= %s", d.cfg.PushTracingMaxDuration)
+	}
+	s.Until = time.Now().Add(duration)
+
+	if v := r.FormValue("ratio"); v != "" {
+		var err error
+		if s.Ratio, err = strconv.ParseFloat(v, 64); err != nil {
+			return s, fmt.Errorf("invalid ratio: %w", err)
+		}
+	}
+	if s.Ratio <= 0 || s.Ratio > 1 {
+		return s, fmt.Errorf("ratio must be > 0 and <= 1")
+	}
+	return s, nil
+}
@@ -0,0 +1,114 @@
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func Test_PushTracer(t *testing.T) {
+	now := time.Now()
+	tracer := newPushTracer()
+	require.False(t, tracer.sample(now, "a"))
+
+	tracer.enable(pushTracingSession{Tenant: "a", Ratio: 1, Until: now.Add(time.Minute)})
+	tracer.enable(pushTracingSession{Tenant: "b", Ratio: 1, Until: now.Add(-time.Minute)})
+	require.True(t, tracer.sample(now, "a"))
+	require.False(t, tracer.sample(now, "b"))
+	require.False(t, tracer.sample(now.Add(2*time.Minute), "a"))
+
+	require.Equal(t, []pushTracingSession{{Tenant: "a", Ratio: 1, Until: now.Add(time.Minute)}}, tracer.active(now))
+
+	tracer.disable("a")
+	require.False(t, tracer.sample(now, "a"))
+	require.Empty(t, tracer.active(now))
+}
+
+func Test_NewTracedPush(t *testing.T) {
+	req := &logproto.PushRequest{}
+	for i := 0; i <= maxTracedStreams; i++ {
+		req.Streams = append(req.Streams, logproto.Stream{
+			Labels: fmt.Sprintf(`{i="%d"}`, i),
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "first"},
+				{Timestamp: time.Unix(2, 0), Line: "last"},
+			},
+		})
+	}
+
+	p := newTracedPush(req)
+	require.Equal(t, maxTracedStreams+1, p.total)
+	require.Len(t, p.streams, maxTracedStreams)
+	require.Equal(t, 2*(maxTracedStreams+1), p.entries)
+	require.Equal(t, 9*(maxTracedStreams+1), p.bytes)
+	require.Equal(t, tracedStream{labels: `{i="0"}`, entries: 2, bytes: 9, first: time.Unix(1, 0), last: time.Unix(2, 0)}, p.streams[0])
+}
+
+func Test_PushTracingHandler(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	d := prepare(t, limits, nil, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	do := func(method, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		d.PushTracingHandler(w, httptest.NewRequest(method, "/distributor/push_tracing?"+query, nil))
+		return w
+	}
+	sessions := func() []pushTracingSession {
+		w := do(http.MethodGet, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var s []pushTracingSession
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+		return s
+	}
+
+	for _, query := range []string{
+		"",
+		"tenant=a&duration=foo",
+		"tenant=a&duration=2h",
+		"tenant=a&duration=-1m",
+		"tenant=a&ratio=0",
+		"tenant=a&ratio=1.5",
+	} {
+		require.Equal(t, http.StatusBadRequest, do(http.MethodPost, query).Code, query)
+	}
+	require.Empty(t, sessions())
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodPost, "tenant=a&duration=10m&ratio=0.5").Code)
+	s := sessions()
+	require.Len(t, s, 1)
+	require.Equal(t, "a", s[0].Tenant)
+	require.Equal(t, 0.5, s[0].Ratio)
+	require.WithinDuration(t, time.Now().Add(10*time.Minute), s[0].Until, time.Minute)
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "tenant=a").Code)
+	require.Empty(t, sessions())
+
+	d.cfg.PushTracingMaxDuration = 0
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "tenant=a").Code)
+}
+
+func Test_PushTracedTenant(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.EnforceMetricName = false
+	d := prepare(t, limits, nil, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	d.pushTracer.enable(pushTracingSession{Tenant: "test", Ratio: 1, Until: time.Now().Add(time.Minute)})
+	_, err := d.Push(ctx, makeWriteRequest(10, 10))
+	require.NoError(t, err)
+}
@@ -0,0 +1,255 @@
+package distributor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/util/strutil"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+// The codes of the responses of the Splunk HTTP Event Collector (HEC), which the forwarders check.
+const (
+	hecCodeSuccess      = 0
+	hecCodeServerBusy   = 9
+	hecCodeInvalidData  = 6
+	hecCodeNoData       = 5
+	hecCodeEventMissing = 12
+	hecCodeEventBlank   = 13
+	hecCodeHealthy      = 17
+	hecCodeInternal     = 8
+)
+
+// SplunkHECConfig configures the ingestion of the events sent with the Splunk HTTP Event Collector (HEC) protocol.
+type SplunkHECConfig struct {
+	Enabled        bool                   `yaml:"enabled"`
+	FieldsAsLabels flagext.StringSliceCSV `yaml:"fields_as_labels"`
+}
+
+// RegisterFlags registers the flags of the Splunk HEC ingestion.
+func (cfg *SplunkHECConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.splunk-hec.enabled", false, "Accept the events sent with the Splunk HTTP Event Collector protocol to /services/collector/event.")
+	cfg.FieldsAsLabels = []string{"index", "sourcetype", "host"}
+	f.Var(&cfg.FieldsAsLabels, "distributor.splunk-hec.fields-as-labels", "Comma-separated list of the metadata (host, source, sourcetype, index) and indexed fields of the Splunk HEC events added as stream labels, with the characters invalid in label names replaced by underscores. The other metadata and fields are added to the lines in logfmt.")
+}
+
+// hecEvent is an event of a Splunk HEC request.
+type hecEvent struct {
+	Time       json.RawMessage        `json:"time"`
+	Host       string                 `json:"host"`
+	Source     string                 `json:"source"`
+	SourceType string                 `json:"sourcetype"`
+	Index      string                 `json:"index"`
+	Event      json.RawMessage        `json:"event"`
+	Fields     map[string]interface{} `json:"fields"`
+}
+
+type hecResponse struct {
+	Text               string `json:"text"`
+	Code               int    `json:"code"`
+	InvalidEventNumber *int   `json:"invalid-event-number,omitempty"`
+}
+
+func writeHECResponse(w http.ResponseWriter, status int, resp hecResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// SplunkHECHealthHandler answers the health checks of the Splunk forwarders.
+func (d *Distributor) SplunkHECHealthHandler(w http.ResponseWriter, _ *http.Request) {
+	writeHECResponse(w, http.StatusOK, hecResponse{Text: "HEC is healthy", Code: hecCodeHealthy})
+}
+
+// SplunkHECHandler ingests the events of a Splunk HEC request, a sequence of JSON objects optionally gzip compressed.
+// The tenant is the one of the request, as for the Loki push API.
+func (d *Distributor) SplunkHECHandler(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = r.Body
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "":
+	case "gzip":
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeHECResponse(w, http.StatusBadRequest, hecResponse{Text: "Invalid data format", Code: hecCodeInvalidData})
+			return
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	default:
+		writeHECResponse(w, http.StatusUnsupportedMediaType, hecResponse{Text: "Content-Encoding " + strconv.Quote(encoding) + " not supported", Code: hecCodeInvalidData})
+		return
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(body, int64(d.cfg.MaxRecvMsgSize)+1))
+	if err != nil {
+		writeHECResponse(w, http.StatusBadRequest, hecResponse{Text: "Invalid data format", Code: hecCodeInvalidData})
+		return
+	}
+	if len(b) > d.cfg.MaxRecvMsgSize {
+		writeHECResponse(w, http.StatusRequestEntityTooLarge, hecResponse{Text: "Request entity too large", Code: hecCodeInvalidData})
+		return
+	}
+
+	req, status, resp := d.cfg.SplunkHEC.pushRequest(b, time.Now())
+	if resp != nil {
+		writeHECResponse(w, status, *resp)
+		return
+	}
+
+	userID, _ := tenant.TenantID(r.Context())
+	if err := push.RecordReceived(userID, req, d.tenantsRetention); err != nil {
+		writeHECResponse(w, http.StatusBadRequest, hecResponse{Text: err.Error(), Code: hecCodeInvalidData})
+		return
+	}
+	if _, err := d.Push(r.Context(), req); err != nil {
+		level.Debug(util_log.WithContext(r.Context(), util_log.Logger)).Log("msg", "Splunk HEC push request failed", "err", err)
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+			code := hecCodeInvalidData
+			if resp.Code == http.StatusTooManyRequests {
+				code = hecCodeServerBusy
+			}
+			writeHECResponse(w, int(resp.Code), hecResponse{Text: string(resp.Body), Code: code})
+			return
+		}
+		writeHECResponse(w, http.StatusInternalServerError, hecResponse{Text: err.Error(), Code: hecCodeInternal})
+		return
+	}
+	writeHECResponse(w, http.StatusOK, hecResponse{Text: "Success", Code: hecCodeSuccess})
+}
+
+// pushRequest converts the events of a Splunk HEC request to streams, whose labels are the allowed metadata and fields
+// of the events. The streams without any of them get the job="splunk_hec" label. An invalid request is answered with
+// a status and a response in the format of Splunk.
+func (cfg *SplunkHECConfig) pushRequest(body []byte, now time.Time) (*logproto.PushRequest, int, *hecResponse) {
+	streams := map[string]*logproto.Stream{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	n := 0
+	for ; ; n++ {
+		var event hecEvent
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, http.StatusBadRequest, &hecResponse{Text: "Invalid data format", Code: hecCodeInvalidData, InvalidEventNumber: &n}
+		}
+		line, ok := hecEventLine(event.Event)
+		if !ok {
+			return nil, http.StatusBadRequest, &hecResponse{Text: "Event field is required", Code: hecCodeEventMissing, InvalidEventNumber: &n}
+		}
+		if line == "" {
+			return nil, http.StatusBadRequest, &hecResponse{Text: "Event field cannot be blank", Code: hecCodeEventBlank, InvalidEventNumber: &n}
+		}
+		ts, ok := hecEventTime(event.Time, now)
+		if !ok {
+			return nil, http.StatusBadRequest, &hecResponse{Text: "Invalid data format", Code: hecCodeInvalidData, InvalidEventNumber: &n}
+		}
+
+		ls, line := cfg.eventLabelsAndLine(event, line)
+		key := ls.String()
+		stream, ok := streams[key]
+		if !ok {
+			stream = &logproto.Stream{Labels: key}
+			streams[key] = stream
+		}
+		stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: ts, Line: line})
+	}
+	if n == 0 {
+		return nil, http.StatusBadRequest, &hecResponse{Text: "No data", Code: hecCodeNoData}
+	}
+
+	res := &logproto.PushRequest{Streams: make([]logproto.Stream, 0, len(streams))}
+	for _, s := range streams {
+		res.Streams = append(res.Streams, *s)
+	}
+	sort.Slice(res.Streams, func(i, j int) bool { return res.Streams[i].Labels < res.Streams[j].Labels })
+	return res, http.StatusOK, nil
+}
+
+// eventLabelsAndLine returns the labels of the stream of an event and its line followed by the metadata and fields
+// which aren't labels in logfmt.
+func (cfg *SplunkHECConfig) eventLabelsAndLine(event hecEvent, line string) (labels.Labels, string) {
+	fields := make(map[string]string, 4+len(event.Fields))
+	for k, v := range event.Fields {
+		fields[k] = valueString(v)
+	}
+	for k, v := range map[string]string{"host": event.Host, "source": event.Source, "sourcetype": event.SourceType, "index": event.Index} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+
+	lb := labels.NewBuilder(nil)
+	for _, name := range cfg.FieldsAsLabels {
+		if v := fields[name]; v != "" {
+			lb.Set(strutil.SanitizeLabelName(name), v)
+			delete(fields, name)
+		}
+	}
+	ls := lb.Labels()
+	if len(ls) == 0 {
+		ls = labels.Labels{{Name: "job", Value: "splunk_hec"}}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name, v := range fields {
+		if v != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	keyvals := make([]interface{}, 0, 2*len(names))
+	for _, name := range names {
+		keyvals = append(keyvals, name, fields[name])
+	}
+	return ls, appendLogfmt(line, keyvals)
+}
+
+// hecEventLine returns the line of the event field, which is either a string or any JSON value kept as is.
+func hecEventLine(event json.RawMessage) (string, bool) {
+	event = bytes.TrimSpace(event)
+	if len(event) == 0 || string(event) == "null" {
+		return "", false
+	}
+	if event[0] == '"' {
+		var s string
+		if err := json.Unmarshal(event, &s); err != nil {
+			return "", false
+		}
+		return s, true
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, event); err != nil {
+		return "", false
+	}
+	return compacted.String(), true
+}
+
+// hecEventTime returns the time of an event, in seconds since the epoch with decimals for the fractional seconds,
+// either as a number or a string. The events without time are received now.
+func hecEventTime(t json.RawMessage, now time.Time) (time.Time, bool) {
+	s := strings.Trim(string(bytes.TrimSpace(t)), `"`)
+	if s == "" || s == "null" {
+		return now, true
+	}
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
@@ -0,0 +1,108 @@
+package distributor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func Test_SplunkHECPushRequest(t *testing.T) {
+	cfg := SplunkHECConfig{}
+	flagext.DefaultValues(&cfg)
+	now := time.Unix(20, 0)
+
+	req, status, resp := cfg.pushRequest([]byte(`
+{"time": 10.5, "host": "web-1", "source": "/var/log/app.log", "sourcetype": "app", "index": "main", "event": "payment failed", "fields": {"order_id": 42}}
+{"time": "11", "host": "web-1", "sourcetype": "app", "index": "main", "event": {"msg": "retrying", "attempt": 2}}
+{"event": "no metadata"}`), now)
+	require.Nil(t, resp)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, &logproto.PushRequest{Streams: []logproto.Stream{
+		{
+			Labels: `{host="web-1", index="main", sourcetype="app"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(10, 5e8), Line: "payment failed order_id=42 source=/var/log/app.log"},
+				{Timestamp: time.Unix(11, 0), Line: `{"msg":"retrying","attempt":2}`},
+			},
+		},
+		{
+			Labels:  `{job="splunk_hec"}`,
+			Entries: []logproto.Entry{{Timestamp: now, Line: "no metadata"}},
+		},
+	}}, req)
+
+	for _, tc := range []struct {
+		name  string
+		body  string
+		code  int
+		index int
+	}{
+		{"no data", ``, hecCodeNoData, -1},
+		{"invalid json", `{"event": "ok"} {"event": `, hecCodeInvalidData, 1},
+		{"missing event", `{"event": "ok"} {"host": "web-1"}`, hecCodeEventMissing, 1},
+		{"blank event", `{"event": ""}`, hecCodeEventBlank, 0},
+		{"invalid time", `{"event": "ok", "time": "yesterday"}`, hecCodeInvalidData, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, status, resp := cfg.pushRequest([]byte(tc.body), now)
+			require.Equal(t, http.StatusBadRequest, status)
+			require.NotNil(t, resp)
+			require.Equal(t, tc.code, resp.Code)
+			if tc.index >= 0 {
+				require.Equal(t, tc.index, *resp.InvalidEventNumber)
+			}
+		})
+	}
+}
+
+func Test_SplunkHECHandler(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RejectOldSamples = false
+	ingester := &mockIngester{}
+	d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+	defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+	post := func(body string) (int, hecResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/services/collector/event", bytes.NewReader([]byte(body)))
+		req = req.WithContext(user.InjectOrgID(req.Context(), "splunk"))
+		w := httptest.NewRecorder()
+		d.SplunkHECHandler(w, req)
+		var resp hecResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+
+	code, resp := post(`{"host": "web-1", "event": "hello"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, hecResponse{Text: "Success", Code: hecCodeSuccess}, resp)
+	code, resp = post(`{"host": "web-1"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, hecCodeEventMissing, resp.Code)
+
+	// The stream of the successful request is pushed to the 3 replicas.
+	require.Eventually(t, func() bool {
+		var streams int
+		for _, pushed := range ingester.pushedFor("splunk") {
+			streams += len(pushed.Streams)
+		}
+		return streams == 3
+	}, time.Second, 10*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	d.SplunkHECHealthHandler(w, httptest.NewRequest(http.MethodGet, "/services/collector/health", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,83 @@
+package distributor
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// streamLimiterIdleTimeout is how long the limiter of a stream which stopped receiving entries is kept.
+const streamLimiterIdleTimeout = 5 * time.Minute
+
+type streamKey struct {
+	tenant string
+	labels string
+}
+
+type streamLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// streamRateLimiter holds a token bucket per stream so that a single stream can't consume the whole ingestion
+// rate limit of its tenant.
+type streamRateLimiter struct {
+	mtx         sync.Mutex
+	limiters    map[streamKey]*streamLimiter
+	lastCleanup time.Time
+}
+
+func newStreamRateLimiter() *streamRateLimiter {
+	return &streamRateLimiter{
+		limiters: map[streamKey]*streamLimiter{},
+	}
+}
+
+// limiter returns the limiter of a stream, updated to the current limit of its tenant.
+func (l *streamRateLimiter) limiter(now time.Time, tenant, labels string, limit validation.RateLimit) *rate.Limiter {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if now.Sub(l.lastCleanup) > streamLimiterIdleTimeout {
+		l.removeIdle(now.Add(-streamLimiterIdleTimeout))
+		l.lastCleanup = now
+	}
+
+	key := streamKey{tenant: tenant, labels: labels}
+	s, ok := l.limiters[key]
+	if !ok {
+		s = &streamLimiter{limiter: rate.NewLimiter(limit.Limit, limit.Burst)}
+		l.limiters[key] = s
+	}
+	s.lastUsed = now
+	if s.limiter.Limit() != limit.Limit {
+		s.limiter.SetLimitAt(now, limit.Limit)
+	}
+	if s.limiter.Burst() != limit.Burst {
+		s.limiter.SetBurstAt(now, limit.Burst)
+	}
+	return s.limiter
+}
+
+// removeIdle removes the limiters of the streams which did not receive entries since before.
+func (l *streamRateLimiter) removeIdle(before time.Time) {
+	for key, s := range l.limiters {
+		if s.lastUsed.Before(before) {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// streamRateReservations are the tokens taken from the limiters of the streams of a push with the reject policy,
+// given back when the push is rejected.
+type streamRateReservations []*rate.Reservation
+
+// cancel gives back the reserved tokens to the limiters.
+func (r streamRateReservations) cancel(now time.Time) {
+	for _, reservation := range r {
+		reservation.CancelAt(now)
+	}
+}
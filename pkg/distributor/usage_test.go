@@ -0,0 +1,91 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/kv/consul"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/usage"
+	fe "github.com/grafana/loki/pkg/util/flagext"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func newTestUsageTracker(t *testing.T, kvStore *consul.Client) *usage.Tracker {
+	cfg := usage.TrackerConfig{Enabled: true, FlushPeriod: time.Minute}
+	cfg.KVStore.Mock = kvStore
+
+	tracker, err := usage.NewTracker("distributor", cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	return tracker
+}
+
+func Test_MonthlyCaps(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		bytesCap  int
+		linesCap  int
+		usage     usage.Usage
+		rejection bool
+	}{
+		{
+			name:  "no caps",
+			usage: usage.Usage{Bytes: 1000, Lines: 1000},
+		},
+		{
+			name:     "below the caps",
+			bytesCap: 100,
+			linesCap: 100,
+			usage:    usage.Usage{Bytes: 99, Lines: 99},
+		},
+		{
+			name:      "bytes cap exceeded",
+			bytesCap:  100,
+			usage:     usage.Usage{Bytes: 100, Lines: 1},
+			rejection: true,
+		},
+		{
+			name:      "lines cap exceeded",
+			bytesCap:  100,
+			linesCap:  10,
+			usage:     usage.Usage{Bytes: 1, Lines: 10},
+			rejection: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.EnforceMetricName = false
+			limits.IngestionMonthlyBytesCap = fe.ByteSize(tc.bytesCap)
+			limits.IngestionMonthlyLinesCap = tc.linesCap
+
+			ingester := &mockIngester{}
+			d := prepare(t, limits, nil, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+			defer services.StopAndAwaitTerminated(context.Background(), d) //nolint:errcheck
+
+			kvStore, closer := consul.NewInMemoryClient(usage.Codec{}, log.NewNopLogger(), nil)
+			defer closer.Close()
+			d.usageTracker = newTestUsageTracker(t, kvStore)
+			d.usageTracker.Add("test", int(tc.usage.Bytes), int(tc.usage.Lines))
+
+			_, err := d.Push(ctx, makeWriteRequest(10, 10))
+			if !tc.rejection {
+				require.NoError(t, err)
+				require.Equal(t, usage.Usage{Bytes: tc.usage.Bytes + 100, Lines: tc.usage.Lines + 10}, d.usageTracker.Usage("test"))
+				return
+			}
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+			require.Len(t, ingester.pushed, 0)
+		})
+	}
+}
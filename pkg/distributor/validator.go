@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/loki/pkg/logproto"
@@ -36,6 +37,25 @@ type validationContext struct {
 	maxLabelNameLength     int
 	maxLabelValueLength    int
 
+	hashLabelValuesLongerThan    int
+	hashedLabelValuePrefixLength int
+
+	debugTenantID    string
+	debugSampleRatio float64
+
+	deadLetterTenantID string
+
+	monthlyBytesCap int
+	monthlyLinesCap int
+
+	streamRateLimit       validation.RateLimit
+	streamRateLimitPolicy string
+
+	dropRules      []validation.DropRule
+	relabelConfigs []*relabel.Config
+
+	entrySequence bool
+
 	userID string
 }
 
@@ -51,22 +71,46 @@ func (v Validator) getValidationContextFor(userID string) validationContext {
 		maxLabelNamesPerSeries: v.MaxLabelNamesPerSeries(userID),
 		maxLabelNameLength:     v.MaxLabelNameLength(userID),
 		maxLabelValueLength:    v.MaxLabelValueLength(userID),
+		dropRules:              v.DropRules(userID),
+		relabelConfigs:         v.StreamRelabelConfigs(userID),
+
+		hashLabelValuesLongerThan:    v.HashLabelValuesLongerThan(userID),
+		hashedLabelValuePrefixLength: v.HashedLabelValuePrefixLength(userID),
+
+		debugTenantID:    v.DebugTenantID(userID),
+		debugSampleRatio: v.DebugSampleRatio(userID),
+
+		deadLetterTenantID: v.DeadLetterTenantID(userID),
+
+		monthlyBytesCap: v.IngestionMonthlyBytesCap(userID),
+		monthlyLinesCap: v.IngestionMonthlyLinesCap(userID),
+
+		streamRateLimit:       v.DistributorStreamRateLimit(userID),
+		streamRateLimitPolicy: v.DistributorStreamRateLimitPolicy(userID),
+
+		entrySequence: v.FeatureEnabled(userID, validation.FeatureEntrySequence),
 	}
 }
 
 // ValidateEntry returns an error if the entry is invalid
 func (v Validator) ValidateEntry(ctx validationContext, labels string, entry logproto.Entry) error {
+	_, err := v.validateEntry(ctx, labels, entry)
+	return err
+}
+
+// validateEntry returns the reason and the error if the entry is invalid.
+func (v Validator) validateEntry(ctx validationContext, labels string, entry logproto.Entry) (string, error) {
 	ts := entry.Timestamp.UnixNano()
 	if ctx.rejectOldSample && ts < ctx.rejectOldSampleMaxAge {
 		validation.DiscardedSamples.WithLabelValues(validation.GreaterThanMaxSampleAge, ctx.userID).Inc()
 		validation.DiscardedBytes.WithLabelValues(validation.GreaterThanMaxSampleAge, ctx.userID).Add(float64(len(entry.Line)))
-		return httpgrpc.Errorf(http.StatusBadRequest, validation.GreaterThanMaxSampleAgeErrorMsg, labels, entry.Timestamp)
+		return validation.GreaterThanMaxSampleAge, httpgrpc.Errorf(http.StatusBadRequest, validation.GreaterThanMaxSampleAgeErrorMsg, labels, entry.Timestamp)
 	}
 
 	if ts > ctx.creationGracePeriod {
 		validation.DiscardedSamples.WithLabelValues(validation.TooFarInFuture, ctx.userID).Inc()
 		validation.DiscardedBytes.WithLabelValues(validation.TooFarInFuture, ctx.userID).Add(float64(len(entry.Line)))
-		return httpgrpc.Errorf(http.StatusBadRequest, validation.TooFarInFutureErrorMsg, labels, entry.Timestamp)
+		return validation.TooFarInFuture, httpgrpc.Errorf(http.StatusBadRequest, validation.TooFarInFutureErrorMsg, labels, entry.Timestamp)
 	}
 
 	if maxSize := ctx.maxLineSize; maxSize != 0 && len(entry.Line) > maxSize {
@@ -76,10 +120,16 @@ func (v Validator) ValidateEntry(ctx validationContext, labels string, entry log
 		// for parity.
 		validation.DiscardedSamples.WithLabelValues(validation.LineTooLong, ctx.userID).Inc()
 		validation.DiscardedBytes.WithLabelValues(validation.LineTooLong, ctx.userID).Add(float64(len(entry.Line)))
-		return httpgrpc.Errorf(http.StatusBadRequest, validation.LineTooLongErrorMsg, maxSize, labels, len(entry.Line))
+		return validation.LineTooLong, httpgrpc.Errorf(http.StatusBadRequest, validation.LineTooLongErrorMsg, maxSize, labels, len(entry.Line))
 	}
 
-	return nil
+	if ctx.entrySequence && entry.Sequence == 0 {
+		validation.DiscardedSamples.WithLabelValues(validation.MissingSequence, ctx.userID).Inc()
+		validation.DiscardedBytes.WithLabelValues(validation.MissingSequence, ctx.userID).Add(float64(len(entry.Line)))
+		return validation.MissingSequence, httpgrpc.Errorf(http.StatusBadRequest, validation.MissingSequenceErrorMsg, labels)
+	}
+
+	return "", nil
 }
 
 // Validate labels returns an error if the labels are invalid
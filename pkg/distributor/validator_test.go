@@ -79,6 +79,28 @@ func TestValidator_ValidateEntry(t *testing.T) {
 			logproto.Entry{Timestamp: testTime, Line: "12345678901"},
 			httpgrpc.Errorf(http.StatusBadRequest, validation.LineTooLongErrorMsg, 10, testStreamLabels, 11),
 		},
+		{
+			"missing sequence",
+			"test",
+			fakeLimits{
+				&validation.Limits{
+					FeatureFlags: map[string]bool{string(validation.FeatureEntrySequence): true},
+				},
+			},
+			logproto.Entry{Timestamp: testTime, Line: "test"},
+			httpgrpc.Errorf(http.StatusBadRequest, validation.MissingSequenceErrorMsg, testStreamLabels),
+		},
+		{
+			"with sequence",
+			"test",
+			fakeLimits{
+				&validation.Limits{
+					FeatureFlags: map[string]bool{string(validation.FeatureEntrySequence): true},
+				},
+			},
+			logproto.Entry{Timestamp: testTime, Line: "test", Sequence: 1},
+			nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -278,6 +278,7 @@ func (s *streamIterator) Next() bool {
 	s.current.LastLine = stream.lastLine.content
 	s.current.EntryCt = stream.entryCt
 	s.current.HighestTs = stream.highestTs
+	s.current.LastSequence = stream.lastSequence
 
 	return true
 }
@@ -311,6 +312,8 @@ type walLogger interface {
 type WALCheckpointWriter struct {
 	metrics    *ingesterMetrics
 	segmentWAL *wal.WAL
+	// usage is released once the segments covered by the checkpoint are truncated, nil to not account it.
+	usage *walUsage
 
 	checkpointWAL walLogger
 	lastSegment   int    // name of the last segment guaranteed to be covered by the checkpoint
@@ -335,6 +338,9 @@ func (w *WALCheckpointWriter) Advance() (bool, error) {
 	if err := w.segmentWAL.NextSegment(); err != nil {
 		return false, err
 	}
+	if w.usage != nil {
+		w.usage.cut()
+	}
 
 	// Checkpoint is named after the last WAL segment present so that when replaying the WAL
 	// we can start from that particular WAL segment.
@@ -509,6 +515,8 @@ func (w *WALCheckpointWriter) Close(abort bool) error {
 		// It is fine to have old WAL segments hanging around if deletion failed.
 		// We can try again next time.
 		level.Error(util_log.Logger).Log("msg", "error deleting old WAL segments", "err", err, "lastSegment", w.lastSegment)
+	} else if w.usage != nil {
+		w.usage.release()
 	}
 
 	if w.lastSegment >= 0 {
@@ -528,6 +536,10 @@ type Checkpointer struct {
 	writer  CheckpointWriter
 	metrics *ingesterMetrics
 
+	// Checkpoints of dir are compacted every compactionInterval if it is > 0.
+	dir                string
+	compactionInterval time.Duration
+
 	quit <-chan struct{}
 }
 
@@ -611,6 +623,14 @@ func (c *Checkpointer) Run() {
 	defer ticker.Stop()
 	defer c.iter.Stop()
 
+	// Compactions run in the checkpointing goroutine so that they never race with checkpoint deletions.
+	var compactions <-chan time.Time
+	if c.compactionInterval > 0 {
+		compactionTicker := time.NewTicker(c.compactionInterval)
+		defer compactionTicker.Stop()
+		compactions = compactionTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
@@ -619,12 +639,105 @@ func (c *Checkpointer) Run() {
 				level.Error(util_log.Logger).Log("msg", "error checkpointing series", "err", err)
 				continue
 			}
+		case <-compactions:
+			if err := c.compactLastCheckpoint(); err != nil {
+				level.Error(util_log.Logger).Log("msg", "error compacting checkpoint", "err", err)
+			}
 		case <-c.quit:
 			return
 		}
 	}
 }
 
+// compactLastCheckpoint merges the segments of the last checkpoint into as few segments as possible,
+// reducing the number of files read by the replay. It is a no-op if they can't be merged.
+// The merged segments are appended to the checkpoint before the old ones are deleted: replaying a
+// checkpoint interrupted in between replays some series twice, which is harmless.
+func (c *Checkpointer) compactLastCheckpoint() (err error) {
+	checkpointDir, idx, err := lastCheckpoint(c.dir)
+	if err != nil || idx < 0 {
+		return err
+	}
+
+	first, last, err := wal.Segments(checkpointDir)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for i := first; i <= last; i++ {
+		fi, err := os.Stat(wal.SegmentName(checkpointDir, i))
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+	}
+	segments := int64(last - first + 1)
+	if segments <= 1 || (size+walSegmentSize-1)/walSegmentSize >= segments {
+		return nil
+	}
+
+	c.metrics.checkpointCompactionTotal.Inc()
+	defer func() {
+		if err != nil {
+			c.metrics.checkpointCompactionFail.Inc()
+		}
+	}()
+	start := time.Now()
+
+	// Opening the checkpoint WAL starts a new segment after the last one.
+	compacted, err := wal.NewSize(log.With(util_log.Logger, "component", "checkpoint_wal"), nil, checkpointDir, walSegmentSize, false)
+	if err != nil {
+		return errors.Wrap(err, "open checkpoint")
+	}
+	if err := copyRecords(wal.SegmentRange{Dir: checkpointDir, First: first, Last: last}, compacted); err != nil {
+		_ = compacted.Close()
+		return err
+	}
+	if err := compacted.Close(); err != nil {
+		return err
+	}
+	for i := first; i <= last; i++ {
+		if err := os.Remove(wal.SegmentName(checkpointDir, i)); err != nil {
+			return err
+		}
+	}
+	level.Info(util_log.Logger).Log("msg", "checkpoint compacted", "dir", checkpointDir, "segments", segments, "time", time.Since(start).String())
+	return nil
+}
+
+// copyRecords logs all the records of the segments r to w, in batches of about 1MB.
+func copyRecords(r wal.SegmentRange, w *wal.WAL) error {
+	segments, err := wal.NewSegmentsRangeReader(r)
+	if err != nil {
+		return err
+	}
+	defer segments.Close()
+
+	var (
+		reader = wal.NewReader(segments)
+		recs   [][]byte
+		size   int
+	)
+	for reader.Next() {
+		rec := append([]byte(nil), reader.Record()...)
+		recs = append(recs, rec)
+		size += len(rec)
+		if size > 1<<20 {
+			if err := w.Log(recs...); err != nil {
+				return err
+			}
+			recs, size = recs[:0], 0
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+	if len(recs) > 0 {
+		return w.Log(recs...)
+	}
+	return nil
+}
+
 func unflushedChunks(descs []chunkDesc) []chunkDesc {
 	filtered := make([]chunkDesc, 0, len(descs))
 
@@ -151,6 +151,8 @@ type Series struct {
 	EntryCt int64 `protobuf:"varint,7,opt,name=entryCt,proto3" json:"entryCt,omitempty"`
 	// highest timestamp pushed to this stream.
 	HighestTs time.Time `protobuf:"bytes,8,opt,name=highestTs,proto3,stdtime" json:"highestTs"`
+	// highest sequence number of the entries pushed to this stream.
+	LastSequence uint64 `protobuf:"varint,9,opt,name=lastSequence,proto3" json:"lastSequence,omitempty"`
 }
 
 func (m *Series) Reset()      { *m = Series{} }
@@ -234,6 +236,13 @@ func (m *Series) GetHighestTs() time.Time {
 	return time.Time{}
 }
 
+func (m *Series) GetLastSequence() uint64 {
+	if m != nil {
+		return m.LastSequence
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Chunk)(nil), "loki_ingester.Chunk")
 	proto.RegisterType((*Series)(nil), "loki_ingester.Series")
@@ -242,40 +251,41 @@ func init() {
 func init() { proto.RegisterFile("pkg/ingester/checkpoint.proto", fileDescriptor_00f4b7152db9bdb5) }
 
 var fileDescriptor_00f4b7152db9bdb5 = []byte{
-	// 520 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x52, 0xb1, 0x8e, 0xd3, 0x40,
-	0x10, 0xf5, 0x26, 0x8e, 0x2f, 0xd9, 0x40, 0xb3, 0x20, 0xb4, 0x8a, 0xc4, 0x26, 0xba, 0x2a, 0x0d,
-	0xb6, 0x14, 0x28, 0xa0, 0x41, 0x4a, 0x0e, 0x21, 0x21, 0x5d, 0x81, 0xcc, 0xd1, 0xd0, 0x20, 0xc7,
-	0x9e, 0xd8, 0x26, 0x8e, 0xd7, 0xda, 0xdd, 0x48, 0x5c, 0xc7, 0x27, 0x5c, 0xc5, 0x37, 0xf0, 0x29,
-	0x57, 0xa6, 0x3c, 0x81, 0x74, 0x10, 0xa7, 0xa1, 0xbc, 0x4f, 0x40, 0xbb, 0xb6, 0xef, 0x42, 0x77,
-	0xe9, 0xe6, 0xbd, 0x99, 0xe7, 0x19, 0xbf, 0x7d, 0xf8, 0x69, 0xb1, 0x8c, 0xbd, 0x34, 0x8f, 0x41,
-	0x2a, 0x10, 0x5e, 0x98, 0x40, 0xb8, 0x2c, 0x78, 0x9a, 0x2b, 0xb7, 0x10, 0x5c, 0x71, 0xf2, 0x30,
-	0xe3, 0xcb, 0xf4, 0x73, 0xd3, 0x1f, 0x0c, 0x63, 0xce, 0xe3, 0x0c, 0x3c, 0xd3, 0x9c, 0xaf, 0x17,
-	0x9e, 0x4a, 0x57, 0x20, 0x55, 0xb0, 0x2a, 0xaa, 0xf9, 0xc1, 0xb3, 0x38, 0x55, 0xc9, 0x7a, 0xee,
-	0x86, 0x7c, 0xe5, 0xc5, 0x3c, 0xe6, 0x77, 0x93, 0x1a, 0x19, 0x60, 0xaa, 0x7a, 0xfc, 0xd5, 0xde,
-	0x78, 0xc8, 0x85, 0x82, 0xaf, 0x85, 0xe0, 0x5f, 0x20, 0x54, 0x35, 0xf2, 0xf4, 0x75, 0x75, 0x63,
-	0x5e, 0x17, 0x95, 0xf4, 0xf8, 0x57, 0x0b, 0x77, 0x4e, 0x92, 0x75, 0xbe, 0x24, 0x2f, 0xb1, 0xbd,
-	0x10, 0x7c, 0x45, 0xd1, 0x08, 0x8d, 0xfb, 0x93, 0x81, 0x5b, 0xdd, 0xe8, 0x36, 0x9b, 0xdd, 0xb3,
-	0xe6, 0xc6, 0x59, 0xf7, 0xf2, 0x7a, 0x68, 0x5d, 0xfc, 0x1e, 0x22, 0xdf, 0x28, 0xc8, 0x0b, 0xdc,
-	0x52, 0x9c, 0xb6, 0x0e, 0xd0, 0xb5, 0x14, 0x27, 0x33, 0xdc, 0x5b, 0x64, 0x6b, 0x99, 0x40, 0x34,
-	0x55, 0xb4, 0x7d, 0x80, 0xf8, 0x4e, 0x46, 0xde, 0xe2, 0x7e, 0x16, 0x48, 0xf5, 0xb1, 0x88, 0x02,
-	0x05, 0x11, 0xb5, 0x0f, 0xf8, 0xca, 0xbe, 0x90, 0x3c, 0xc1, 0x4e, 0x98, 0x71, 0x09, 0x11, 0xed,
-	0x8c, 0xd0, 0xb8, 0xeb, 0xd7, 0x48, 0xf3, 0xf2, 0x3c, 0x0f, 0x21, 0xa2, 0x4e, 0xc5, 0x57, 0x88,
-	0x10, 0x6c, 0x47, 0x81, 0x0a, 0xe8, 0xd1, 0x08, 0x8d, 0x1f, 0xf8, 0xa6, 0xd6, 0x5c, 0x02, 0x41,
-	0x44, 0xbb, 0x15, 0xa7, 0xeb, 0xe3, 0xef, 0x6d, 0xec, 0x7c, 0x00, 0x91, 0x82, 0xd4, 0x9f, 0x5a,
-	0x4b, 0x10, 0xef, 0xde, 0x18, 0x83, 0x7b, 0x7e, 0x8d, 0xc8, 0x08, 0xf7, 0x17, 0x3a, 0x18, 0xa2,
-	0x10, 0x69, 0xae, 0x8c, 0x8b, 0xb6, 0xbf, 0x4f, 0x91, 0x1c, 0x3b, 0x59, 0x30, 0x87, 0x4c, 0xd2,
-	0xf6, 0xa8, 0x3d, 0xee, 0x4f, 0x1e, 0xb9, 0xcd, 0x53, 0xba, 0xa7, 0x9a, 0x7f, 0x1f, 0xa4, 0x62,
-	0x36, 0xd5, 0x3f, 0xf6, 0xf3, 0x7a, 0x78, 0x50, 0x14, 0x2a, 0xfd, 0x34, 0x0a, 0x0a, 0x05, 0xc2,
-	0xaf, 0xb7, 0x90, 0x09, 0x76, 0x42, 0x9d, 0x08, 0x49, 0x6d, 0xb3, 0xef, 0xb1, 0xfb, 0x5f, 0x7a,
-	0x5d, 0x13, 0x97, 0x99, 0xad, 0x17, 0xfa, 0xf5, 0x64, 0x1d, 0x81, 0xce, 0x81, 0x11, 0x18, 0xe0,
-	0xae, 0x7e, 0x85, 0xd3, 0x34, 0x07, 0x63, 0x70, 0xcf, 0xbf, 0xc5, 0x84, 0xe2, 0x23, 0xc8, 0x95,
-	0x38, 0x3f, 0x51, 0xc6, 0xe5, 0xb6, 0xdf, 0x40, 0x1d, 0x9c, 0x24, 0x8d, 0x13, 0x90, 0xea, 0x4c,
-	0x1a, 0xb7, 0xef, 0x1d, 0x9c, 0x5b, 0xd9, 0xec, 0xf5, 0x66, 0xcb, 0xac, 0xab, 0x2d, 0xb3, 0x6e,
-	0xb6, 0x0c, 0x7d, 0x2b, 0x19, 0xfa, 0x51, 0x32, 0x74, 0x59, 0x32, 0xb4, 0x29, 0x19, 0xfa, 0x53,
-	0x32, 0xf4, 0xb7, 0x64, 0xd6, 0x4d, 0xc9, 0xd0, 0xc5, 0x8e, 0x59, 0x9b, 0x1d, 0xb3, 0xae, 0x76,
-	0xcc, 0xfa, 0xd4, 0x6d, 0x3c, 0x98, 0x3b, 0x66, 0xd1, 0xf3, 0x7f, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x38, 0x4d, 0x9b, 0xd7, 0xf8, 0x03, 0x00, 0x00,
+	// 534 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x52, 0xb1, 0x8e, 0xd3, 0x4a,
+	0x14, 0xf5, 0x24, 0x8e, 0xd7, 0x99, 0xec, 0x6b, 0xe6, 0x21, 0x34, 0x8a, 0xc4, 0x24, 0x4a, 0x95,
+	0x06, 0x5b, 0x0a, 0x14, 0xd0, 0x20, 0x25, 0x8b, 0x90, 0x90, 0xb6, 0x40, 0xde, 0xa5, 0xa1, 0x41,
+	0x8e, 0x3d, 0xb1, 0x4d, 0x1c, 0x8f, 0x99, 0x19, 0x4b, 0x6c, 0xc7, 0x27, 0xec, 0x67, 0xf0, 0x0d,
+	0x7c, 0xc1, 0x96, 0x29, 0x57, 0x20, 0x2d, 0xc4, 0x69, 0x28, 0xf7, 0x13, 0xd0, 0x8c, 0x6d, 0x92,
+	0x2d, 0xdd, 0xdd, 0x73, 0xee, 0x3d, 0xbe, 0xd7, 0x67, 0x0e, 0x7c, 0x92, 0xaf, 0x23, 0x37, 0xc9,
+	0x22, 0x2a, 0x24, 0xe5, 0x6e, 0x10, 0xd3, 0x60, 0x9d, 0xb3, 0x24, 0x93, 0x4e, 0xce, 0x99, 0x64,
+	0xe8, 0xbf, 0x94, 0xad, 0x93, 0x8f, 0x4d, 0x7f, 0x38, 0x8a, 0x18, 0x8b, 0x52, 0xea, 0xea, 0xe6,
+	0xb2, 0x58, 0xb9, 0x32, 0xd9, 0x50, 0x21, 0xfd, 0x4d, 0x5e, 0xcd, 0x0f, 0x9f, 0x46, 0x89, 0x8c,
+	0x8b, 0xa5, 0x13, 0xb0, 0x8d, 0x1b, 0xb1, 0x88, 0x1d, 0x26, 0x15, 0xd2, 0x40, 0x57, 0xf5, 0xf8,
+	0xcb, 0xa3, 0xf1, 0x80, 0x71, 0x49, 0xbf, 0xe4, 0x9c, 0x7d, 0xa2, 0x81, 0xac, 0x91, 0xab, 0xae,
+	0xab, 0x1b, 0xcb, 0xba, 0xa8, 0xa4, 0x93, 0x9f, 0x1d, 0xd8, 0x3b, 0x8b, 0x8b, 0x6c, 0x8d, 0x5e,
+	0x40, 0x73, 0xc5, 0xd9, 0x06, 0x83, 0x31, 0x98, 0x0e, 0x66, 0x43, 0xa7, 0xba, 0xd1, 0x69, 0x36,
+	0x3b, 0x97, 0xcd, 0x8d, 0x0b, 0xfb, 0xe6, 0x6e, 0x64, 0x5c, 0xff, 0x1a, 0x01, 0x4f, 0x2b, 0xd0,
+	0x73, 0xd8, 0x91, 0x0c, 0x77, 0x5a, 0xe8, 0x3a, 0x92, 0xa1, 0x05, 0xec, 0xaf, 0xd2, 0x42, 0xc4,
+	0x34, 0x9c, 0x4b, 0xdc, 0x6d, 0x21, 0x3e, 0xc8, 0xd0, 0x1b, 0x38, 0x48, 0x7d, 0x21, 0xdf, 0xe7,
+	0xa1, 0x2f, 0x69, 0x88, 0xcd, 0x16, 0x5f, 0x39, 0x16, 0xa2, 0xc7, 0xd0, 0x0a, 0x52, 0x26, 0x68,
+	0x88, 0x7b, 0x63, 0x30, 0xb5, 0xbd, 0x1a, 0x29, 0x5e, 0x5c, 0x65, 0x01, 0x0d, 0xb1, 0x55, 0xf1,
+	0x15, 0x42, 0x08, 0x9a, 0xa1, 0x2f, 0x7d, 0x7c, 0x32, 0x06, 0xd3, 0x53, 0x4f, 0xd7, 0x8a, 0x8b,
+	0xa9, 0x1f, 0x62, 0xbb, 0xe2, 0x54, 0x3d, 0xf9, 0xde, 0x85, 0xd6, 0x05, 0xe5, 0x09, 0x15, 0xea,
+	0x53, 0x85, 0xa0, 0xfc, 0xed, 0x6b, 0x6d, 0x70, 0xdf, 0xab, 0x11, 0x1a, 0xc3, 0xc1, 0x4a, 0x05,
+	0x83, 0xe7, 0x3c, 0xc9, 0xa4, 0x76, 0xd1, 0xf4, 0x8e, 0x29, 0x94, 0x41, 0x2b, 0xf5, 0x97, 0x34,
+	0x15, 0xb8, 0x3b, 0xee, 0x4e, 0x07, 0xb3, 0xff, 0x9d, 0xe6, 0x29, 0x9d, 0x73, 0xc5, 0xbf, 0xf3,
+	0x13, 0xbe, 0x98, 0xab, 0x1f, 0xfb, 0x71, 0x37, 0x6a, 0x15, 0x85, 0x4a, 0x3f, 0x0f, 0xfd, 0x5c,
+	0x52, 0xee, 0xd5, 0x5b, 0xd0, 0x0c, 0x5a, 0x81, 0x4a, 0x84, 0xc0, 0xa6, 0xde, 0xf7, 0xc8, 0x79,
+	0x90, 0x5e, 0x47, 0xc7, 0x65, 0x61, 0xaa, 0x85, 0x5e, 0x3d, 0x59, 0x47, 0xa0, 0xd7, 0x32, 0x02,
+	0x43, 0x68, 0xab, 0x57, 0x38, 0x4f, 0x32, 0xaa, 0x0d, 0xee, 0x7b, 0xff, 0x30, 0xc2, 0xf0, 0x84,
+	0x66, 0x92, 0x5f, 0x9d, 0x49, 0xed, 0x72, 0xd7, 0x6b, 0xa0, 0x0a, 0x4e, 0x9c, 0x44, 0x31, 0x15,
+	0xf2, 0x52, 0x60, 0xbb, 0xc5, 0xca, 0x83, 0x0c, 0x4d, 0xe0, 0xa9, 0xda, 0x74, 0x41, 0x3f, 0x17,
+	0x34, 0x0b, 0x28, 0xee, 0x6b, 0xdb, 0x1f, 0x70, 0x8b, 0x57, 0xdb, 0x1d, 0x31, 0x6e, 0x77, 0xc4,
+	0xb8, 0xdf, 0x11, 0xf0, 0xb5, 0x24, 0xe0, 0x5b, 0x49, 0xc0, 0x4d, 0x49, 0xc0, 0xb6, 0x24, 0xe0,
+	0x77, 0x49, 0xc0, 0x9f, 0x92, 0x18, 0xf7, 0x25, 0x01, 0xd7, 0x7b, 0x62, 0x6c, 0xf7, 0xc4, 0xb8,
+	0xdd, 0x13, 0xe3, 0x83, 0xdd, 0xf8, 0xb4, 0xb4, 0xf4, 0x31, 0xcf, 0xfe, 0x0e, 0x00, 0xa1, 0xf0,
+	0x81, 0x28, 0x1c, 0x04, 0x00, 0x00,
 }
 
 func (this *Chunk) Equal(that interface{}) bool {
@@ -376,6 +386,9 @@ func (this *Series) Equal(that interface{}) bool {
 	if !this.HighestTs.Equal(that1.HighestTs) {
 		return false
 	}
+	if this.LastSequence != that1.LastSequence {
+		return false
+	}
 	return true
 }
 func (this *Chunk) GoString() string {
@@ -399,7 +412,7 @@ func (this *Series) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 12)
+	s := make([]string, 0, 13)
 	s = append(s, "&ingester.Series{")
 	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
 	s = append(s, "Fingerprint: "+fmt.Sprintf("%#v", this.Fingerprint)+",\n")
@@ -415,6 +428,7 @@ func (this *Series) GoString() string {
 	s = append(s, "LastLine: "+fmt.Sprintf("%#v", this.LastLine)+",\n")
 	s = append(s, "EntryCt: "+fmt.Sprintf("%#v", this.EntryCt)+",\n")
 	s = append(s, "HighestTs: "+fmt.Sprintf("%#v", this.HighestTs)+",\n")
+	s = append(s, "LastSequence: "+fmt.Sprintf("%#v", this.LastSequence)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -535,6 +549,11 @@ func (m *Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.LastSequence != 0 {
+		i = encodeVarintCheckpoint(dAtA, i, uint64(m.LastSequence))
+		i--
+		dAtA[i] = 0x48
+	}
 	n5, err5 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.HighestTs, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.HighestTs):])
 	if err5 != nil {
 		return 0, err5
@@ -684,6 +703,9 @@ func (m *Series) Size() (n int) {
 	}
 	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.HighestTs)
 	n += 1 + l + sovCheckpoint(uint64(l))
+	if m.LastSequence != 0 {
+		n += 1 + sovCheckpoint(uint64(m.LastSequence))
+	}
 	return n
 }
 
@@ -728,6 +750,7 @@ func (this *Series) String() string {
 		`LastLine:` + fmt.Sprintf("%v", this.LastLine) + `,`,
 		`EntryCt:` + fmt.Sprintf("%v", this.EntryCt) + `,`,
 		`HighestTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.HighestTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`LastSequence:` + fmt.Sprintf("%v", this.LastSequence) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1298,6 +1321,25 @@ func (m *Series) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastSequence", wireType)
+			}
+			m.LastSequence = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCheckpoint
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LastSequence |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCheckpoint(dAtA[iNdEx:])
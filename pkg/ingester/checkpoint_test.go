@@ -11,7 +11,9 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/wal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
@@ -681,3 +683,57 @@ func TestIngesterWALReplaysUnorderedToOrdered(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckpointCompaction(t *testing.T) {
+	walDir, err := ioutil.TempDir(os.TempDir(), "loki-wal")
+	require.Nil(t, err)
+	defer os.RemoveAll(walDir)
+
+	// A checkpoint made of small segments.
+	checkpointDir := walDir + "/" + checkpointPrefix + "000003"
+	w, err := wal.NewSize(nil, nil, checkpointDir, walSegmentSize, false)
+	require.NoError(t, err)
+	var expected []uint64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 10; j++ {
+			fp := uint64(i*10 + j)
+			b, err := encodeWithTypeHeader(&Series{UserID: "foo", Fingerprint: fp}, CheckpointRecord, nil)
+			require.NoError(t, err)
+			require.NoError(t, w.Log(b))
+			expected = append(expected, fp)
+		}
+		require.NoError(t, w.NextSegment())
+	}
+	require.NoError(t, w.Close())
+	first, last, err := wal.Segments(checkpointDir)
+	require.NoError(t, err)
+	require.Equal(t, 3, last-first)
+
+	c := NewCheckpointer(time.Minute, nil, nil, newIngesterMetrics(prometheus.NewRegistry()), nil)
+	c.dir = walDir
+	require.NoError(t, c.compactLastCheckpoint())
+
+	first, last, err = wal.Segments(checkpointDir)
+	require.NoError(t, err)
+	require.Equal(t, first, last)
+
+	readers, closer, err := newCheckpointReaders(walDir)
+	require.NoError(t, err)
+	defer closer.Close()
+	require.Len(t, readers, 1)
+	var fps []uint64
+	for readers[0].Next() {
+		var s Series
+		require.NoError(t, decodeCheckpointRecord(readers[0].Record(), &s))
+		fps = append(fps, s.Fingerprint)
+	}
+	require.NoError(t, readers[0].Err())
+	require.Equal(t, expected, fps)
+
+	// Compacting a single segment is a no-op.
+	require.NoError(t, c.compactLastCheckpoint())
+	first2, last2, err := wal.Segments(checkpointDir)
+	require.NoError(t, err)
+	require.Equal(t, first, first2)
+	require.Equal(t, last, last2)
+}
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/distributor"
-	"github.com/grafana/dskit/grpcclient"
 	dsmiddleware "github.com/grafana/dskit/middleware"
 	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	"github.com/opentracing/opentracing-go"
@@ -17,6 +16,7 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util/grpcclient"
 )
 
 var ingesterClientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -34,6 +34,7 @@ type HealthAndIngesterClient interface {
 type ClosableHealthAndIngesterClient struct {
 	logproto.PusherClient
 	logproto.QuerierClient
+	logproto.RecentDataClient
 	logproto.IngesterClient
 	grpc_health_v1.HealthClient
 	io.Closer
@@ -74,11 +75,12 @@ func New(cfg Config, addr string) (HealthAndIngesterClient, error) {
 		return nil, err
 	}
 	return ClosableHealthAndIngesterClient{
-		PusherClient:   logproto.NewPusherClient(conn),
-		QuerierClient:  logproto.NewQuerierClient(conn),
-		IngesterClient: logproto.NewIngesterClient(conn),
-		HealthClient:   grpc_health_v1.NewHealthClient(conn),
-		Closer:         conn,
+		PusherClient:     logproto.NewPusherClient(conn),
+		QuerierClient:    logproto.NewQuerierClient(conn),
+		RecentDataClient: logproto.NewRecentDataClient(conn),
+		IngesterClient:   logproto.NewIngesterClient(conn),
+		HealthClient:     grpc_health_v1.NewHealthClient(conn),
+		Closer:           conn,
 	}, nil
 }
 
@@ -25,11 +25,15 @@ const (
 	// WALRecordEntriesV2 is the type for the WAL record for samples with an
 	// additional counter value for use in replaying without the ordering constraint.
 	WALRecordEntriesV2
+	// WALRecordEntriesV3 is the type for the WAL record for samples with the
+	// sequence numbers of the entries.
+	WALRecordEntriesV3
 )
 
 // The current type of Entries that this distribution writes.
-// Loki can read in a backwards compatible manner, but will write the newest variant.
-const CurrentEntriesRec RecordType = WALRecordEntriesV2
+// Loki can read in a backwards compatible manner, but will write the newest variant, except for the sequence numbers:
+// see WALRecord.entriesRecordType.
+const CurrentEntriesRec RecordType = WALRecordEntriesV3
 
 // WALRecord is a struct combining the series and samples record.
 type WALRecord struct {
@@ -43,6 +47,19 @@ type WALRecord struct {
 	RefEntries    []RefEntries
 }
 
+// entriesRecordType returns the type used to write the entries of the record: the sequence numbers are only written
+// when some entries have one, so that the WAL written without them can still be replayed by older ingesters.
+func (r *WALRecord) entriesRecordType() RecordType {
+	for _, ref := range r.RefEntries {
+		for _, e := range ref.Entries {
+			if e.Sequence != 0 {
+				return WALRecordEntriesV3
+			}
+		}
+	}
+	return WALRecordEntriesV2
+}
+
 func (r *WALRecord) IsEmpty() bool {
 	return len(r.Series) == 0 && len(r.RefEntries) == 0
 }
@@ -131,6 +148,9 @@ outer:
 			buf.PutVarint64(s.Timestamp.UnixNano() - first)
 			buf.PutUvarint(len(s.Line))
 			buf.PutString(s.Line)
+			if version >= WALRecordEntriesV3 {
+				buf.PutUvarint64(s.Sequence)
+			}
 		}
 	}
 	return buf.Get()
@@ -160,10 +180,15 @@ func decodeEntries(b []byte, version RecordType, rec *WALRecord) error {
 			timeOffset := dec.Varint64()
 			lineLength := dec.Uvarint()
 			line := dec.Bytes(lineLength)
+			var sequence uint64
+			if version >= WALRecordEntriesV3 {
+				sequence = dec.Uvarint64()
+			}
 
 			refEntries.Entries = append(refEntries.Entries, logproto.Entry{
 				Timestamp: time.Unix(0, baseTime+timeOffset),
 				Line:      string(line),
+				Sequence:  sequence,
 			})
 		}
 
@@ -198,7 +223,7 @@ func decodeWALRecord(b []byte, walRec *WALRecord) (err error) {
 	case WALRecordSeries:
 		userID = decbuf.UvarintStr()
 		rSeries, err = dec.Series(decbuf.B, walRec.Series)
-	case WALRecordEntriesV1, WALRecordEntriesV2:
+	case WALRecordEntriesV1, WALRecordEntriesV2, WALRecordEntriesV3:
 		userID = decbuf.UvarintStr()
 		err = decodeEntries(decbuf.B, t, walRec)
 	default:
@@ -130,6 +130,42 @@ func Test_Encoding_Entries(t *testing.T) {
 			},
 			version: WALRecordEntriesV2,
 		},
+		{
+			desc: "v3",
+			rec: &WALRecord{
+				entryIndexMap: make(map[uint64]int),
+				UserID:        "123",
+				RefEntries: []RefEntries{
+					{
+						Ref:     456,
+						Counter: 1,
+						Entries: []logproto.Entry{
+							{
+								Timestamp: time.Unix(1000, 0),
+								Line:      "first",
+								Sequence:  1, // v3 encodes the sequence numbers
+							},
+							{
+								Timestamp: time.Unix(2000, 0),
+								Line:      "second",
+								Sequence:  2,
+							},
+						},
+					},
+					{
+						Ref:     789,
+						Counter: 2,
+						Entries: []logproto.Entry{
+							{
+								Timestamp: time.Unix(3000, 0),
+								Line:      "third",
+							},
+						},
+					},
+				},
+			},
+			version: WALRecordEntriesV3,
+		},
 	} {
 		decoded := recordPool.GetRecord()
 		buf := tc.rec.encodeEntries(tc.version, nil)
@@ -140,6 +176,25 @@ func Test_Encoding_Entries(t *testing.T) {
 	}
 }
 
+func Test_EntriesRecordType(t *testing.T) {
+	record := &WALRecord{
+		RefEntries: []RefEntries{
+			{
+				Ref:     456,
+				Entries: []logproto.Entry{{Timestamp: time.Unix(1000, 0), Line: "first"}},
+			},
+		},
+	}
+	// The records without sequence numbers stay readable by the ingesters not knowing v3.
+	require.Equal(t, WALRecordEntriesV2, record.entriesRecordType())
+
+	record.RefEntries = append(record.RefEntries, RefEntries{
+		Ref:     789,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(2000, 0), Line: "second", Sequence: 1}},
+	})
+	require.Equal(t, WALRecordEntriesV3, record.entriesRecordType())
+}
+
 func Benchmark_EncodeEntries(b *testing.B) {
 	var entries []logproto.Entry
 	for i := int64(0); i < 10000; i++ {
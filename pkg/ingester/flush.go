@@ -391,6 +391,7 @@ func (i *Ingester) flushChunks(ctx context.Context, fp model.Fingerprint, labelP
 
 		// flush successful, write while we have lock
 		cs[i].flushed = time.Now()
+		cs[i].checksum = wc.Checksum
 
 		numEntries := cs[i].chunk.Size()
 		byt, err := wc.Encoded()
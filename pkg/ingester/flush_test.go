@@ -64,9 +64,10 @@ func TestChunkFlushingShutdown(t *testing.T) {
 
 type fullWAL struct{}
 
-func (fullWAL) Log(_ *WALRecord) error { return &os.PathError{Err: syscall.ENOSPC} }
-func (fullWAL) Start()                 {}
-func (fullWAL) Stop() error            { return nil }
+func (fullWAL) Log(_ *WALRecord) error   { return &os.PathError{Err: syscall.ENOSPC} }
+func (fullWAL) TenantBytes(string) int64 { return 0 }
+func (fullWAL) Start()                   {}
+func (fullWAL) Stop() error              { return nil }
 
 func Benchmark_FlushLoop(b *testing.B) {
 	var (
@@ -347,6 +348,8 @@ func (s *testStore) Stop() {}
 
 func (s *testStore) SetChunkFilterer(_ storage.RequestChunkFilterer) {}
 
+func (s *testStore) SetLookupTables(log.LookupTables) {}
+
 func pushTestSamples(t *testing.T, ing logproto.PusherServer) map[string][]logproto.Stream {
 	userIDs := []string{"1", "2", "3"}
 
@@ -96,6 +96,12 @@ var (
 	}
 )
 
+// ShardOf returns the shard of the series with the labels out of the given number of shards, as sharded by the
+// index for the sharded queries.
+func ShardOf(ls labels.Labels, of int) int {
+	return int(labelsSeriesIDHash(ls) % uint32(of))
+}
+
 func labelsSeriesIDHash(ls labels.Labels) uint32 {
 	b64 := base64Pool.Get().(*bytes.Buffer)
 	defer func() {
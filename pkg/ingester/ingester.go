@@ -15,6 +15,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -30,6 +31,7 @@ import (
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/runtime"
 	"github.com/grafana/loki/pkg/storage"
@@ -44,6 +46,8 @@ import (
 // attempted.
 var ErrReadOnly = errors.New("Ingester is shutting down")
 
+var errMissingQueryRequest = errors.New("missing query request")
+
 var flushQueueLength = promauto.NewGauge(prometheus.GaugeOpts{
 	Name: "cortex_ingester_flush_queue_length",
 	Help: "The total number of series pending in the flush queue.",
@@ -65,6 +69,9 @@ type Config struct {
 	TargetChunkSize     int               `yaml:"chunk_target_size"`
 	ChunkEncoding       string            `yaml:"chunk_encoding"`
 	parsedEncoding      chunkenc.Encoding `yaml:"-"` // placeholder for validated encoding
+	ChunkZstdLevel      string            `yaml:"chunk_zstd_level"`
+	parsedZstdLevel     zstd.EncoderLevel `yaml:"-"` // placeholder for validated zstd level
+	ChunkBloomFilters   bool              `yaml:"chunk_bloom_filters"`
 	MaxChunkAge         time.Duration     `yaml:"max_chunk_age"`
 	AutoForgetUnhealthy bool              `yaml:"autoforget_unhealthy"`
 
@@ -82,10 +89,16 @@ type Config struct {
 
 	WAL WALConfig `yaml:"wal,omitempty"`
 
-	ChunkFilterer storage.RequestChunkFilterer `yaml:"-"`
-	LabelFilterer LabelValueFilterer           `yaml:"-"`
+	ChunkFilterer    storage.RequestChunkFilterer `yaml:"-"`
+	LabelFilterer    LabelValueFilterer           `yaml:"-"`
+	ZstdDictionaries ZstdDictionaries             `yaml:"-"`
+	LookupTables     log.LookupTables             `yaml:"-"`
 
 	IndexShards int `yaml:"index_shards"`
+
+	LockContentionLogPeriod time.Duration `yaml:"lock_contention_log_period"`
+
+	ShutdownMarkerDir string `yaml:"shutdown_marker_dir"`
 }
 
 // RegisterFlags registers the flags.
@@ -102,6 +115,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.BlockSize, "ingester.chunks-block-size", 256*1024, "")
 	f.IntVar(&cfg.TargetChunkSize, "ingester.chunk-target-size", 1572864, "") // 1.5 MB
 	f.StringVar(&cfg.ChunkEncoding, "ingester.chunk-encoding", chunkenc.EncGZIP.String(), fmt.Sprintf("The algorithm to use for compressing chunk. (%s)", chunkenc.SupportedEncoding()))
+	f.StringVar(&cfg.ChunkZstdLevel, "ingester.chunk-zstd-level", "default", "The level of the chunks compressed with zstd, trading CPU for a better compression ratio. (fastest, default, better, best)")
+	f.BoolVar(&cfg.ChunkBloomFilters, "ingester.chunk-bloom-filters", false, "Write a bloom filter of the line tokens of each block into the chunks, allowing line filter queries to skip blocks without decompressing them. Requires all the queriers to support the chunk format v4.")
 	f.DurationVar(&cfg.SyncPeriod, "ingester.sync-period", 0, "How often to cut chunks to synchronize ingesters.")
 	f.Float64Var(&cfg.SyncMinUtilization, "ingester.sync-min-utilization", 0, "Minimum utilization of chunk when doing synchronization.")
 	f.IntVar(&cfg.MaxReturnedErrors, "ingester.max-ignored-stream-errors", 10, "Maximum number of ignored stream errors to return. 0 to return all errors.")
@@ -109,6 +124,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.QueryStoreMaxLookBackPeriod, "ingester.query-store-max-look-back-period", 0, "How far back should an ingester be allowed to query the store for data, for use only with boltdb-shipper index and filesystem object store. -1 for infinite.")
 	f.BoolVar(&cfg.AutoForgetUnhealthy, "ingester.autoforget-unhealthy", false, "Enable to remove unhealthy ingesters from the ring after `ring.kvstore.heartbeat_timeout`")
 	f.IntVar(&cfg.IndexShards, "ingester.index-shards", index.DefaultIndexShards, "Shard factor used in the ingesters for the in process reverse index. This MUST be evenly divisible by ALL schema shard factors or Loki will not start.")
+	f.DurationVar(&cfg.LockContentionLogPeriod, "ingester.lock-contention-log-period", 0, "How often to log the most contended locks since the previous period, read from the mutex profile. Requires -profiling.mutex-profile-fraction. 0 to disable.")
+	f.StringVar(&cfg.ShutdownMarkerDir, "ingester.shutdown-marker-dir", "", "Directory persisting the prepare shutdown requests made to /ingester/prepare_shutdown across restarts. Empty to use the WAL directory.")
 }
 
 func (cfg *Config) Validate() error {
@@ -118,6 +135,12 @@ func (cfg *Config) Validate() error {
 	}
 	cfg.parsedEncoding = enc
 
+	if cfg.ChunkZstdLevel != "" {
+		if cfg.parsedZstdLevel, err = chunkenc.ParseZstdLevel(cfg.ChunkZstdLevel); err != nil {
+			return err
+		}
+	}
+
 	if err = cfg.WAL.Validate(); err != nil {
 		return err
 	}
@@ -138,6 +161,14 @@ type LabelValueFilterer interface {
 	Filter(ctx context.Context, labelName string, labelValues []string) ([]string, error)
 }
 
+// ZstdDictionaries are the dictionaries the zstd chunks of the tenants are compressed with.
+type ZstdDictionaries interface {
+	// Active returns the dictionary of the new chunks of a tenant, nil if it has none yet.
+	Active(tenant string) *chunkenc.ZstdDictionary
+	// Sample samples a line of a tenant for training its next dictionary.
+	Sample(tenant, line string)
+}
+
 // Ingester builds chunks for incoming log streams.
 type Ingester struct {
 	services.Service
@@ -198,8 +229,16 @@ func New(cfg Config, clientConfig client.Config, store ChunkStore, limits *valid
 		cfg.ingesterClientFactory = client.New
 	}
 
+	if cfg.LifecyclerConfig.RingConfig.ZoneAwarenessEnabled && cfg.LifecyclerConfig.Zone == "" {
+		level.Warn(util_log.Logger).Log("msg", "the availability zone of the ingester should be set with zone-aware replication, the distributors place the replicas of a stream in distinct zones")
+	}
+
 	metrics := newIngesterMetrics(registerer)
 
+	if cfg.parsedZstdLevel != 0 {
+		chunkenc.SetZstdLevel(cfg.parsedZstdLevel)
+	}
+
 	i := &Ingester{
 		cfg:                   cfg,
 		clientConfig:          clientConfig,
@@ -241,6 +280,16 @@ func New(cfg Config, clientConfig client.Config, store ChunkStore, limits *valid
 	i.lifecyclerWatcher = services.NewFailureWatcher()
 	i.lifecyclerWatcher.WatchService(i.lifecycler)
 
+	// A prepare shutdown request survives restarts until the ingester is terminated.
+	exists, err := shutdownMarkerExists(cfg.shutdownMarkerPath())
+	if err != nil {
+		return nil, fmt.Errorf("checking the shutdown marker: %w", err)
+	}
+	if exists {
+		level.Info(util_log.Logger).Log("msg", "shutdown marker found, the ingester will flush and leave the ring on shutdown", "path", cfg.shutdownMarkerPath())
+		i.setPrepareShutdown(true)
+	}
+
 	// Now that the lifecycler has been created, we can create the limiter
 	// which depends on it.
 	i.limiter = NewLimiter(limits, metrics, i.lifecycler, cfg.LifecyclerConfig.RingConfig.ReplicationFactor)
@@ -374,13 +423,17 @@ func (i *Ingester) starting(ctx context.Context) error {
 		defer endReplay()
 
 		level.Info(util_log.Logger).Log("msg", "recovering from checkpoint")
-		checkpointReader, checkpointCloser, err := newCheckpointReader(i.cfg.WAL.Dir)
+		checkpointReaders, checkpointCloser, err := newCheckpointReaders(i.cfg.WAL.Dir)
 		if err != nil {
 			return err
 		}
 		defer checkpointCloser.Close()
+		for j := range checkpointReaders {
+			checkpointReaders[j] = countingWALReader{WALReader: checkpointReaders[j], bytes: i.metrics.walReplayBytes.WithLabelValues(walTypeCheckpoint)}
+		}
 
-		checkpointRecoveryErr := RecoverCheckpoint(checkpointReader, recoverer)
+		checkpointRecoveryErr := RecoverCheckpoint(checkpointReaders, recoverer)
+		i.metrics.walReplayPhaseDuration.WithLabelValues(walTypeCheckpoint).Set(time.Since(start).Seconds())
 		if checkpointRecoveryErr != nil {
 			i.metrics.walCorruptionsTotal.WithLabelValues(walTypeCheckpoint).Inc()
 			level.Error(util_log.Logger).Log(
@@ -402,7 +455,9 @@ func (i *Ingester) starting(ctx context.Context) error {
 		}
 		defer segmentCloser.Close()
 
-		segmentRecoveryErr := RecoverWAL(segmentReader, recoverer)
+		segmentStart := time.Now()
+		segmentRecoveryErr := RecoverWAL(countingWALReader{WALReader: segmentReader, bytes: i.metrics.walReplayBytes.WithLabelValues(walTypeSegment)}, recoverer)
+		i.metrics.walReplayPhaseDuration.WithLabelValues(walTypeSegment).Set(time.Since(segmentStart).Seconds())
 		if segmentRecoveryErr != nil {
 			i.metrics.walCorruptionsTotal.WithLabelValues(walTypeSegment).Inc()
 			level.Error(util_log.Logger).Log(
@@ -472,7 +527,16 @@ func (i *Ingester) stopping(_ error) error {
 	if i.flushOnShutdownSwitch.Get() {
 		i.lifecycler.SetFlushOnShutdown(true)
 	}
-	errs.Add(services.StopAndAwaitTerminated(context.Background(), i.lifecycler))
+	lifecyclerErr := services.StopAndAwaitTerminated(context.Background(), i.lifecycler)
+	errs.Add(lifecyclerErr)
+
+	// The prepare shutdown request has been honored once the ingester flushed and left the ring.
+	if lifecyclerErr == nil {
+		if exists, _ := shutdownMarkerExists(i.cfg.shutdownMarkerPath()); exists {
+			errs.Add(removeShutdownMarker(i.cfg.shutdownMarkerPath()))
+			i.metrics.shutdownMarker.Set(0)
+		}
+	}
 
 	// Normally, flushers are stopped via lifecycler (in transferOut), but if lifecycler fails,
 	// we better stop them.
@@ -490,11 +554,25 @@ func (i *Ingester) loop() {
 	flushTicker := time.NewTicker(i.cfg.FlushCheckPeriod)
 	defer flushTicker.Stop()
 
+	var (
+		contentionLogger *lockContentionLogger
+		contentionTicker <-chan time.Time
+	)
+	if i.cfg.LockContentionLogPeriod > 0 {
+		contentionLogger = newLockContentionLogger(util_log.Logger)
+		t := time.NewTicker(i.cfg.LockContentionLogPeriod)
+		defer t.Stop()
+		contentionTicker = t.C
+	}
+
 	for {
 		select {
 		case <-flushTicker.C:
 			i.sweepUsers(false, true)
 
+		case <-contentionTicker:
+			contentionLogger.logTopContentions()
+
 		case <-i.loopQuit:
 			return
 		}
@@ -513,6 +591,68 @@ func (i *Ingester) ShutdownHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// PrepareShutdownHandler gets, sets or clears a prepare shutdown request, which makes the next shutdown of the
+// ingester flush all the chunks and leave the ring whatever the config, so that scale-downs can be automated
+// by an external operator. The request is persisted until the ingester is terminated or it is cleared.
+//     * GET returns "set" or "unset".
+//     * POST sets the request.
+//     * DELETE clears the request.
+func (i *Ingester) PrepareShutdownHandler(w http.ResponseWriter, r *http.Request) {
+	path := i.cfg.shutdownMarkerPath()
+
+	switch r.Method {
+	case http.MethodGet:
+		exists, err := shutdownMarkerExists(path)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to check the shutdown marker", "path", path, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		if exists {
+			_, _ = w.Write([]byte("set"))
+		} else {
+			_, _ = w.Write([]byte("unset"))
+		}
+
+	case http.MethodPost:
+		if err := createShutdownMarker(path); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to create the shutdown marker", "path", path, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		i.setPrepareShutdown(true)
+		level.Info(util_log.Logger).Log("msg", "prepare shutdown requested, the ingester will flush and leave the ring on shutdown", "path", path)
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if err := removeShutdownMarker(path); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove the shutdown marker", "path", path, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		i.setPrepareShutdown(false)
+		level.Info(util_log.Logger).Log("msg", "prepare shutdown request cleared", "path", path)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// setPrepareShutdown makes the ingester flush and leave the ring on shutdown, or restores the configured behaviour.
+func (i *Ingester) setPrepareShutdown(prepare bool) {
+	if prepare {
+		i.lifecycler.SetFlushOnShutdown(true)
+		i.lifecycler.SetUnregisterOnShutdown(true)
+		i.metrics.shutdownMarker.Set(1)
+		return
+	}
+	i.lifecycler.SetFlushOnShutdown(!i.cfg.WAL.Enabled || i.cfg.WAL.FlushOnShutdown)
+	i.lifecycler.SetUnregisterOnShutdown(i.cfg.LifecyclerConfig.UnregisterOnShutdown)
+	i.metrics.shutdownMarker.Set(0)
+}
+
 // Push implements logproto.Pusher.
 func (i *Ingester) Push(ctx context.Context, req *logproto.PushRequest) (*logproto.PushResponse, error) {
 	instanceID, err := tenant.TenantID(ctx)
@@ -621,6 +761,60 @@ func (i *Ingester) QuerySample(req *logproto.SampleQueryRequest, queryServer log
 	return sendSampleBatches(ctx, heapItr, queryServer)
 }
 
+// QueryRecent the ingesters for log streams matching a set of matchers, considering only the data which is not available in the store yet.
+func (i *Ingester) QueryRecent(req *logproto.RecentQueryRequest, queryServer logproto.RecentData_QueryRecentServer) error {
+	if req.Request == nil {
+		return errMissingQueryRequest
+	}
+
+	// initialize stats collection for ingester queries.
+	_, ctx := stats.NewContext(queryServer.Context())
+
+	instanceID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	instance := i.getOrCreateInstance(instanceID)
+	itrs, err := instance.QueryRecent(ctx, logql.SelectLogParams{QueryRequest: req.Request}, req.StoreChunks)
+	if err != nil {
+		return err
+	}
+
+	heapItr := iter.NewHeapIterator(ctx, itrs, req.Request.Direction)
+
+	defer listutil.LogErrorWithContext(ctx, "closing iterator", heapItr.Close)
+
+	return sendBatches(ctx, heapItr, queryServer, req.Request.Limit)
+}
+
+// QuerySampleRecent the ingesters for series from logs matching a set of matchers, considering only the data which is not available in the store yet.
+func (i *Ingester) QuerySampleRecent(req *logproto.RecentSampleQueryRequest, queryServer logproto.RecentData_QuerySampleRecentServer) error {
+	if req.Request == nil {
+		return errMissingQueryRequest
+	}
+
+	// initialize stats collection for ingester queries.
+	_, ctx := stats.NewContext(queryServer.Context())
+
+	instanceID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	instance := i.getOrCreateInstance(instanceID)
+	itrs, err := instance.QuerySampleRecent(ctx, logql.SelectSampleParams{SampleQueryRequest: req.Request}, req.StoreChunks)
+	if err != nil {
+		return err
+	}
+
+	heapItr := iter.NewHeapSampleIterator(ctx, itrs)
+
+	defer listutil.LogErrorWithContext(ctx, "closing iterator", heapItr.Close)
+
+	return sendSampleBatches(ctx, heapItr, queryServer)
+}
+
 // boltdbShipperMaxLookBack returns a max look back period only if active index type is boltdb-shipper.
 // max look back is limited to from time of boltdb-shipper config.
 // It considers previous periodic config's from time if that also has index type set to boltdb-shipper.
@@ -679,6 +873,22 @@ func (i *Ingester) GetChunkIDs(ctx context.Context, req *logproto.GetChunkIDsReq
 	return &resp, nil
 }
 
+// GetIndexStats returns the chunks, bytes and entries of the streams matching the selector which are not flushed yet.
+func (i *Ingester) GetIndexStats(ctx context.Context, req *logproto.IndexStatsRequest) (*logproto.IndexStatsResponse, error) {
+	instanceID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matchers, err := logql.ParseMatchers(req.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := i.getOrCreateInstance(instanceID)
+	return instance.IndexStats(ctx, req.Start, req.End, matchers...)
+}
+
 // Label returns the set of labels for the stream this ingester knows about.
 func (i *Ingester) Label(ctx context.Context, req *logproto.LabelRequest) (*logproto.LabelResponse, error) {
 	userID, err := tenant.TenantID(ctx)
@@ -686,8 +896,16 @@ func (i *Ingester) Label(ctx context.Context, req *logproto.LabelRequest) (*logp
 		return nil, err
 	}
 
+	var matchers []*labels.Matcher
+	if req.Values && req.Query != "" {
+		matchers, err = logql.ParseMatchers(req.Query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	instance := i.getOrCreateInstance(userID)
-	resp, err := instance.Label(ctx, req)
+	resp, err := instance.Label(ctx, req, matchers...)
 	if err != nil {
 		return nil, err
 	}
@@ -722,7 +940,7 @@ func (i *Ingester) Label(ctx context.Context, req *logproto.LabelRequest) (*logp
 	from, through := model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(req.End.UnixNano())
 	var storeValues []string
 	if req.Values {
-		storeValues, err = cs.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name)
+		storeValues, err = cs.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name, matchers...)
 		if err != nil {
 			return nil, err
 		}
@@ -817,7 +1035,7 @@ func (i *Ingester) Tail(req *logproto.TailRequest, queryServer logproto.Querier_
 	}
 
 	instance := i.getOrCreateInstance(instanceID)
-	tailer, err := newTailer(instanceID, req.Query, queryServer)
+	tailer, err := newTailer(instanceID, req.Query, queryServer, i.cfg.LookupTables)
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,8 @@ package ingester
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -303,7 +305,11 @@ func (s *mockStore) GetChunkRefs(ctx context.Context, userID string, from, throu
 	return nil, nil, nil
 }
 
-func (s *mockStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error) {
+func (s *mockStore) GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]chunk.SeriesChunkRefs, error) {
+	return nil, nil
+}
+
+func (s *mockStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
 	return []string{"val1", "val2"}, nil
 }
 
@@ -686,3 +692,65 @@ func Test_InMemoryLabels_WithLabelFilter(t *testing.T) {
 
 	InMemoryLabels(t, &labelFilter, expectedValues)
 }
+
+func TestIngester_PrepareShutdownHandler(t *testing.T) {
+	ingesterConfig := defaultIngesterTestConfig(t)
+	ingesterConfig.ShutdownMarkerDir = t.TempDir()
+	ingesterConfig.LifecyclerConfig.UnregisterOnShutdown = false
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	store := &mockStore{
+		chunks: map[string][]chunk.Chunk{},
+	}
+
+	prepareShutdown := func(i *Ingester, method string) string {
+		rec := httptest.NewRecorder()
+		i.PrepareShutdownHandler(rec, httptest.NewRequest(method, "/ingester/prepare_shutdown", nil))
+		require.Less(t, rec.Code, 300, method)
+		return rec.Body.String()
+	}
+
+	i, err := New(ingesterConfig, client.Config{}, store, limits, runtime.DefaultTenantConfigs(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "unset", prepareShutdown(i, http.MethodGet))
+
+	prepareShutdown(i, http.MethodPost)
+	require.Equal(t, "set", prepareShutdown(i, http.MethodGet))
+	require.True(t, i.lifecycler.ShouldUnregisterOnShutdown())
+
+	prepareShutdown(i, http.MethodDelete)
+	require.Equal(t, "unset", prepareShutdown(i, http.MethodGet))
+	require.False(t, i.lifecycler.ShouldUnregisterOnShutdown())
+
+	// The request is persisted across restarts, until the ingester is terminated.
+	prepareShutdown(i, http.MethodPost)
+	i, err = New(ingesterConfig, client.Config{}, store, limits, runtime.DefaultTenantConfigs(), nil)
+	require.NoError(t, err)
+	require.True(t, i.lifecycler.ShouldUnregisterOnShutdown())
+	require.True(t, i.lifecycler.FlushOnShutdown())
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	require.NoFileExists(t, filepath.Join(ingesterConfig.ShutdownMarkerDir, shutdownMarkerFilename))
+}
+
+func TestIngester_ZoneAwareness(t *testing.T) {
+	ingesterConfig := defaultIngesterTestConfig(t)
+	ingesterConfig.LifecyclerConfig.RingConfig.ZoneAwarenessEnabled = true
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	store := &mockStore{
+		chunks: map[string][]chunk.Chunk{},
+	}
+
+	// A missing availability zone is only warned about.
+	_, err = New(ingesterConfig, client.Config{}, store, limits, runtime.DefaultTenantConfigs(), nil)
+	require.NoError(t, err)
+
+	ingesterConfig.LifecyclerConfig.Zone = "zone-a"
+	i, err := New(ingesterConfig, client.Config{}, store, limits, runtime.DefaultTenantConfigs(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+	require.Equal(t, "zone-a", i.lifecycler.Zone)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"net/http"
 	"os"
+	"sort"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/querier/astmapper"
 	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -37,6 +40,9 @@ import (
 const (
 	queryBatchSize       = 128
 	queryBatchSampleSize = 512
+
+	// maxRemovedSequences bounds the number of removed streams per tenant whose last sequence number is kept.
+	maxRemovedSequences = 10000
 )
 
 // Errors returned on Query.
@@ -78,6 +84,10 @@ type instance struct {
 	streamsCreatedTotal prometheus.Counter
 	streamsRemovedTotal prometheus.Counter
 
+	// removedSequences keeps the last sequence number of the removed streams, so that an idle stream removed after
+	// being flushed still rejects the entries already pushed once it is created again.
+	removedSequences *lru.Cache
+
 	tailers   map[uint32]*tailer
 	tailerMtx sync.RWMutex
 
@@ -118,6 +128,8 @@ func newInstance(cfg *Config, instanceID string, limiter *Limiter, configs *runt
 		chunkFilter: chunkFilter,
 	}
 	i.mapper = newFPMapper(i.getLabelsFromFingerprint)
+	// lru.New only fails with a non-positive size.
+	i.removedSequences, _ = lru.New(maxRemovedSequences)
 	return i
 }
 
@@ -134,6 +146,7 @@ func (i *instance) consumeChunk(ctx context.Context, ls labels.Labels, chunk *lo
 
 		sortedLabels := i.index.Add(cortexpb.FromLabelsToLabelAdapters(ls), fp)
 		stream = newStream(i.cfg, i.limiter, i.instanceID, fp, sortedLabels, i.limiter.UnorderedWrites(i.instanceID), i.metrics)
+		i.restoreLastSequence(stream)
 		i.streamsByFP[fp] = stream
 		i.streams[stream.labelsString] = stream
 		i.streamsCreatedTotal.Inc()
@@ -150,6 +163,19 @@ func (i *instance) consumeChunk(ctx context.Context, ls labels.Labels, chunk *lo
 }
 
 func (i *instance) Push(ctx context.Context, req *logproto.PushRequest) error {
+	if err := i.limiter.AssertMaxWALBytesPerUser(i.instanceID, i.wal.TenantBytes(i.instanceID)); err != nil {
+		lines, bytes := 0, 0
+		for _, s := range req.Streams {
+			lines += len(s.Entries)
+			for _, e := range s.Entries {
+				bytes += len(e.Line)
+			}
+		}
+		validation.DiscardedSamples.WithLabelValues(validation.WALLimit, i.instanceID).Add(float64(lines))
+		validation.DiscardedBytes.WithLabelValues(validation.WALLimit, i.instanceID).Add(float64(bytes))
+		return err
+	}
+
 	record := recordPool.GetRecord()
 	record.UserID = i.instanceID
 	defer recordPool.PutRecord(record)
@@ -245,6 +271,7 @@ func (i *instance) getOrCreateStream(pushReqStream logproto.Stream, lock bool, r
 
 	sortedLabels := i.index.Add(cortexpb.FromLabelsToLabelAdapters(labels), fp)
 	stream = newStream(i.cfg, i.limiter, i.instanceID, fp, sortedLabels, i.limiter.UnorderedWrites(i.instanceID), i.metrics)
+	i.restoreLastSequence(stream)
 	i.streams[pushReqStream.Labels] = stream
 	i.streamsByFP[fp] = stream
 
@@ -276,6 +303,9 @@ func (i *instance) getOrCreateStream(pushReqStream logproto.Stream, lock bool, r
 
 // removeStream removes a stream from the instance. The streamsMtx must be held.
 func (i *instance) removeStream(s *stream) {
+	if s.lastSequence != 0 {
+		i.removedSequences.Add(s.labelsString, s.lastSequence)
+	}
 	delete(i.streamsByFP, s.fp)
 	delete(i.streams, s.labelsString)
 	i.index.Delete(s.labels, s.fp)
@@ -283,6 +313,15 @@ func (i *instance) removeStream(s *stream) {
 	memoryStreams.WithLabelValues(i.instanceID).Dec()
 }
 
+// restoreLastSequence sets the last sequence number of a created stream to the one it had when it was removed, if
+// any. The streamsMtx must be held.
+func (i *instance) restoreLastSequence(s *stream) {
+	if seq, ok := i.removedSequences.Get(s.labelsString); ok {
+		s.lastSequence = seq.(uint64)
+		i.removedSequences.Remove(s.labelsString)
+	}
+}
+
 func (i *instance) getHashForLabels(ls labels.Labels) model.Fingerprint {
 	var fp uint64
 	fp, i.buf = ls.HashWithoutLabels(i.buf, []string(nil)...)
@@ -299,10 +338,20 @@ func (i *instance) getLabelsFromFingerprint(fp model.Fingerprint) labels.Labels
 }
 
 func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) ([]iter.EntryIterator, error) {
+	return i.query(ctx, req, nil)
+}
+
+// QueryRecent queries only the chunks which are not flushed yet or are flushed but not returned by the store to the querier.
+func (i *instance) QueryRecent(ctx context.Context, req logql.SelectLogParams, storeChunks []logproto.StoreChunk) ([]iter.EntryIterator, error) {
+	return i.query(ctx, req, recentChunksFilter(storeChunks))
+}
+
+func (i *instance) query(ctx context.Context, req logql.SelectLogParams, filter chunkFilter) ([]iter.EntryIterator, error) {
 	expr, err := req.LogSelector()
 	if err != nil {
 		return nil, err
 	}
+	logql.BindLookupTables(expr, i.cfg.LookupTables)
 	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
@@ -321,7 +370,7 @@ func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) ([]iter
 		expr.Matchers(),
 		shard,
 		func(stream *stream) error {
-			iter, err := stream.Iterator(ctx, stats, req.Start, req.End, req.Direction, pipeline.ForStream(stream.labels))
+			iter, err := stream.iterator(ctx, stats, req.Start, req.End, req.Direction, pipeline.ForStream(stream.labels), filter)
 			if err != nil {
 				return err
 			}
@@ -337,10 +386,20 @@ func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) ([]iter
 }
 
 func (i *instance) QuerySample(ctx context.Context, req logql.SelectSampleParams) ([]iter.SampleIterator, error) {
+	return i.querySample(ctx, req, nil)
+}
+
+// QuerySampleRecent is the QueryRecent counterpart for sample queries.
+func (i *instance) QuerySampleRecent(ctx context.Context, req logql.SelectSampleParams, storeChunks []logproto.StoreChunk) ([]iter.SampleIterator, error) {
+	return i.querySample(ctx, req, recentChunksFilter(storeChunks))
+}
+
+func (i *instance) querySample(ctx context.Context, req logql.SelectSampleParams, filter chunkFilter) ([]iter.SampleIterator, error) {
 	expr, err := req.Expr()
 	if err != nil {
 		return nil, err
 	}
+	logql.BindLookupTables(expr, i.cfg.LookupTables)
 	extractor, err := expr.Extractor()
 	if err != nil {
 		return nil, err
@@ -366,7 +425,7 @@ func (i *instance) QuerySample(ctx context.Context, req logql.SelectSampleParams
 		expr.Selector().Matchers(),
 		shard,
 		func(stream *stream) error {
-			iter, err := stream.SampleIterator(ctx, stats, req.Start, req.End, extractor.ForStream(stream.labels))
+			iter, err := stream.sampleIterator(ctx, stats, req.Start, req.End, extractor.ForStream(stream.labels), filter)
 			if err != nil {
 				return err
 			}
@@ -381,7 +440,13 @@ func (i *instance) QuerySample(ctx context.Context, req logql.SelectSampleParams
 	return iters, nil
 }
 
-func (i *instance) Label(_ context.Context, req *logproto.LabelRequest) (*logproto.LabelResponse, error) {
+// Label returns the label names or the values of a label name. Values are restricted to the streams
+// matching the matchers when some are given.
+func (i *instance) Label(ctx context.Context, req *logproto.LabelRequest, matchers ...*labels.Matcher) (*logproto.LabelResponse, error) {
+	if req.Values && len(matchers) > 0 {
+		return i.labelValuesForMatchers(ctx, req.Name, matchers)
+	}
+
 	var labels []string
 	if req.Values {
 		values, err := i.index.LabelValues(req.Name, nil)
@@ -407,6 +472,26 @@ func (i *instance) Label(_ context.Context, req *logproto.LabelRequest) (*logpro
 	}, nil
 }
 
+func (i *instance) labelValuesForMatchers(ctx context.Context, name string, matchers []*labels.Matcher) (*logproto.LabelResponse, error) {
+	values := map[string]struct{}{}
+	err := i.forMatchingStreams(ctx, matchers, nil, func(s *stream) error {
+		if value := s.labels.Get(name); value != "" {
+			values[value] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(values))
+	for value := range values {
+		result = append(result, value)
+	}
+	sort.Strings(result)
+	return &logproto.LabelResponse{Values: result}, nil
+}
+
 func (i *instance) Series(ctx context.Context, req *logproto.SeriesRequest) (*logproto.SeriesResponse, error) {
 	groups, err := logql.Match(req.GetGroups())
 	if err != nil {
@@ -462,9 +547,44 @@ func (i *instance) Series(ctx context.Context, req *logproto.SeriesRequest) (*lo
 		}
 	}
 
+	if req.Limit > 0 && len(series) > int(req.Limit) {
+		series = series[:req.Limit]
+	}
+
 	return &logproto.SeriesResponse{Series: series}, nil
 }
 
+// IndexStats returns the chunks, bytes and entries held in memory for each stream matching the selector.
+// Chunks already flushed are skipped since they are accounted for by the store.
+func (i *instance) IndexStats(ctx context.Context, from, through time.Time, matchers ...*labels.Matcher) (*logproto.IndexStatsResponse, error) {
+	resp := &logproto.IndexStatsResponse{}
+	err := i.forMatchingStreams(ctx, matchers, nil, func(s *stream) error {
+		stats := logproto.StreamIndexStats{Labels: s.labelsString}
+		s.chunkMtx.RLock()
+		for _, c := range s.chunks {
+			if !c.flushed.IsZero() {
+				continue
+			}
+			chkFrom, chkThrough := c.chunk.Bounds()
+			if chkFrom.After(through) || chkThrough.Before(from) {
+				continue
+			}
+			stats.Chunks++
+			stats.Bytes += uint64(c.chunk.UncompressedSize())
+			stats.Entries += uint64(c.chunk.Size())
+		}
+		s.chunkMtx.RUnlock()
+		if stats.Chunks > 0 {
+			resp.Streams = append(resp.Streams, stats)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (i *instance) numStreams() int {
 	i.streamsMtx.RLock()
 	defer i.streamsMtx.RUnlock()
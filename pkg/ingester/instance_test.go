@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"runtime"
 	"sort"
 	"sync"
@@ -15,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
@@ -272,6 +274,69 @@ func Test_SeriesQuery(t *testing.T) {
 	}
 }
 
+func Test_LabelValuesWithMatchers(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	instance := newInstance(defaultConfig(), "test", limiter, loki_runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil)
+	for _, ls := range []string{
+		`{namespace="prod", pod="a"}`,
+		`{namespace="prod", pod="b"}`,
+		`{namespace="dev", pod="c"}`,
+		`{namespace="prod"}`,
+	} {
+		_, err := instance.getOrCreateStream(logproto.Stream{Labels: ls}, false, recordPool.GetRecord())
+		require.NoError(t, err)
+	}
+
+	matchers, err := logql.ParseMatchers(`{namespace="prod"}`)
+	require.NoError(t, err)
+	resp, err := instance.Label(context.Background(), &logproto.LabelRequest{Name: "pod", Values: true}, matchers...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, resp.Values)
+
+	resp, err = instance.Label(context.Background(), &logproto.LabelRequest{Name: "pod", Values: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, resp.Values)
+}
+
+func Test_IndexStats(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	cfg := defaultConfig()
+	instance := newInstance(cfg, "test", limiter, loki_runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil)
+
+	currentTime := time.Now()
+	testStreams := []logproto.Stream{
+		{Labels: "{app=\"test\",job=\"varlogs\"}", Entries: entries(5, currentTime)},
+		{Labels: "{app=\"test2\",job=\"varlogs\"}", Entries: entries(5, currentTime.Add(time.Hour))},
+	}
+	var chunkSize int
+	for _, testStream := range testStreams {
+		stream, err := instance.getOrCreateStream(testStream, false, recordPool.GetRecord())
+		require.NoError(t, err)
+		chunk := newStream(cfg, limiter, "fake", 0, nil, true, NilMetrics).NewChunk()
+		for _, entry := range testStream.Entries {
+			require.NoError(t, chunk.Append(&entry))
+		}
+		chunkSize = chunk.UncompressedSize()
+		// flushed chunks are accounted for by the store.
+		stream.chunks = append(stream.chunks, chunkDesc{chunk: chunk, flushed: currentTime}, chunkDesc{chunk: chunk})
+	}
+
+	matchers, err := logql.ParseMatchers(`{job="varlogs"}`)
+	require.NoError(t, err)
+
+	resp, err := instance.IndexStats(context.Background(), currentTime.Add(-time.Minute), currentTime.Add(time.Minute), matchers...)
+	require.NoError(t, err)
+	require.Equal(t, []logproto.StreamIndexStats{
+		{Labels: `{app="test", job="varlogs"}`, Chunks: 1, Bytes: uint64(chunkSize), Entries: 5},
+	}, resp.Streams)
+}
+
 func entries(n int, t time.Time) []logproto.Entry {
 	result := make([]logproto.Entry, 0, n)
 	for i := 0; i < n; i++ {
@@ -341,7 +406,7 @@ func Benchmark_instance_addNewTailer(b *testing.B) {
 	ctx := context.Background()
 
 	inst := newInstance(&Config{}, "test", limiter, loki_runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil)
-	t, err := newTailer("foo", `{namespace="foo",pod="bar",instance=~"10.*"}`, nil)
+	t, err := newTailer("foo", `{namespace="foo",pod="bar",instance=~"10.*"}`, nil, nil)
 	require.NoError(b, err)
 	for i := 0; i < 10000; i++ {
 		require.NoError(b, inst.Push(ctx, &logproto.PushRequest{
@@ -527,3 +592,66 @@ func (f fakeQueryServer) Send(res *logproto.QueryResponse) error {
 	return f(res)
 }
 func (f fakeQueryServer) Context() context.Context { return context.TODO() }
+
+type tenantBytesWAL struct {
+	noopWAL
+	bytes int64
+}
+
+func (w tenantBytesWAL) TenantBytes(string) int64 { return w.bytes }
+
+func TestInstance_MaxWALBytesPerUser(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	require.NoError(t, limits.MaxWALBytesPerUser.Set("1KB"))
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(overrides, NilMetrics, &ringCountMock{count: 1}, 1)
+	req := &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="l"}`, Entries: entries(5, time.Now().Add(-time.Minute))},
+	}}
+
+	i := newInstance(defaultConfig(), "test", limiter, loki_runtime.DefaultTenantConfigs(), tenantBytesWAL{bytes: 1023}, NilMetrics, &OnceSwitch{}, nil)
+	require.NoError(t, i.Push(context.Background(), req))
+
+	i = newInstance(defaultConfig(), "test", limiter, loki_runtime.DefaultTenantConfigs(), tenantBytesWAL{bytes: 1024}, NilMetrics, &OnceSwitch{}, nil)
+	err = i.Push(context.Background(), req)
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+	require.Empty(t, i.streams)
+}
+
+func TestInstance_RemovedStreamKeepsLastSequence(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.FeatureFlags = map[string]bool{string(validation.FeatureEntrySequence): true}
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(overrides, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	i := newInstance(defaultConfig(), "test", limiter, loki_runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil)
+	ts := time.Now().Add(-time.Minute)
+	require.NoError(t, i.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="l"}`, Entries: []logproto.Entry{
+			{Timestamp: ts, Line: "1", Sequence: 1},
+			{Timestamp: ts.Add(time.Second), Line: "2", Sequence: 2},
+		}},
+	}}))
+
+	// The stream is removed once idle and flushed, and created again by a retried push.
+	i.streamsMtx.Lock()
+	i.removeStream(i.streams[`{app="l"}`])
+	i.streamsMtx.Unlock()
+
+	require.NoError(t, i.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{
+		{Labels: `{app="l"}`, Entries: []logproto.Entry{
+			{Timestamp: ts.Add(time.Second), Line: "2", Sequence: 2},
+			{Timestamp: ts.Add(2 * time.Second), Line: "3", Sequence: 3},
+		}},
+	}}))
+
+	s := i.streams[`{app="l"}`]
+	require.Equal(t, uint64(3), s.lastSequence)
+	require.Len(t, s.chunks, 1)
+	require.Equal(t, 1, s.chunks[0].chunk.Size())
+}
@@ -3,9 +3,11 @@ package ingester
 import (
 	"fmt"
 	"math"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/weaveworks/common/httpgrpc"
 	"golang.org/x/time/rate"
 
 	"github.com/grafana/loki/pkg/validation"
@@ -67,6 +69,17 @@ func (l *Limiter) UnorderedWrites(userID string) bool {
 	return l.limits.UnorderedWrites(userID)
 }
 
+// FeatureEnabled returns whether a feature is enabled for a tenant.
+func (l *Limiter) FeatureEnabled(userID string, feature validation.FeatureFlag) bool {
+	return l.limits.FeatureEnabled(userID, feature)
+}
+
+// MaxOutOfOrderTimeWindow returns how far behind the most recent entry of a stream out of order entries of userID
+// are accepted, 0 if the default window must be used.
+func (l *Limiter) MaxOutOfOrderTimeWindow(userID string) time.Duration {
+	return l.limits.MaxOutOfOrderTimeWindow(userID)
+}
+
 // AssertMaxStreamsPerUser ensures limit has not been reached compared to the current
 // number of streams in input and returns an error if so.
 func (l *Limiter) AssertMaxStreamsPerUser(userID string, streams int) error {
@@ -102,6 +115,21 @@ func (l *Limiter) AssertMaxStreamsPerUser(userID string, streams int) error {
 	return fmt.Errorf(errMaxStreamsPerUserLimitExceeded, userID, streams, calculatedLimit, localLimit, globalLimit, adjustedGlobalLimit)
 }
 
+// AssertMaxWALBytesPerUser returns an error once a user has as many bytes in the WAL segments as allowed.
+func (l *Limiter) AssertMaxWALBytesPerUser(userID string, bytes int64) error {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+	if l.disabled {
+		return nil
+	}
+
+	limit := l.limits.MaxWALBytesPerUser(userID)
+	if limit <= 0 || bytes < int64(limit) {
+		return nil
+	}
+	return httpgrpc.Errorf(http.StatusTooManyRequests, validation.WALLimitErrorMsg, userID, limit, bytes)
+}
+
 func (l *Limiter) convertGlobalToLocalLimit(globalLimit int) int {
 	if globalLimit == 0 {
 		return 0
@@ -134,6 +162,13 @@ type RateLimiterStrategy interface {
 	RateLimit(tenant string) validation.RateLimit
 }
 
+// StreamLimits are the limits enforced on the pushes of a stream.
+type StreamLimits interface {
+	RateLimiterStrategy
+	MaxOutOfOrderTimeWindow(tenant string) time.Duration
+	FeatureEnabled(tenant string, feature validation.FeatureFlag) bool
+}
+
 func (l *Limiter) RateLimit(tenant string) validation.RateLimit {
 	if l.disabled {
 		return validation.Unlimited
@@ -0,0 +1,110 @@
+package ingester
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/pprof/profile"
+)
+
+const topLockContentions = 10
+
+// lockContention is the number of contentions and the time spent waiting on the locks released by a call site.
+type lockContention struct {
+	caller string
+	count  int64
+	delay  time.Duration
+}
+
+// lockContentionLogger logs the call sites whose locks were waited on the most since the previous call,
+// according to the mutex profile of the runtime.
+type lockContentionLogger struct {
+	logger   log.Logger
+	previous map[string]lockContention
+}
+
+func newLockContentionLogger(logger log.Logger) *lockContentionLogger {
+	if runtime.SetMutexProfileFraction(-1) == 0 {
+		level.Warn(logger).Log("msg", "lock contentions won't be logged as mutex profiling is disabled, set -profiling.mutex-profile-fraction to enable it")
+	}
+	return &lockContentionLogger{
+		logger:   logger,
+		previous: map[string]lockContention{},
+	}
+}
+
+func (l *lockContentionLogger) logTopContentions() {
+	current, err := readLockContentions()
+	if err != nil {
+		level.Error(l.logger).Log("msg", "failed to read mutex profile", "err", err)
+		return
+	}
+
+	top := make([]lockContention, 0, len(current))
+	for caller, c := range current {
+		prev := l.previous[caller]
+		if c.delay > prev.delay {
+			top = append(top, lockContention{caller: caller, count: c.count - prev.count, delay: c.delay - prev.delay})
+		}
+	}
+	l.previous = current
+
+	sort.Slice(top, func(i, j int) bool { return top[i].delay > top[j].delay })
+	if len(top) > topLockContentions {
+		top = top[:topLockContentions]
+	}
+	for i, c := range top {
+		level.Info(l.logger).Log("msg", "lock contention", "rank", i+1, "caller", c.caller, "contentions", c.count, "delay", c.delay)
+	}
+}
+
+// readLockContentions returns the contentions accumulated by the mutex profile since startup, by call site.
+func readLockContentions() (map[string]lockContention, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("mutex").WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]lockContention{}
+	for _, s := range p.Sample {
+		// samples of the mutex profile hold the contentions count and the delay in nanoseconds.
+		if len(s.Value) < 2 {
+			continue
+		}
+		caller := lockContentionCaller(s)
+		c := result[caller]
+		c.count += s.Value[0]
+		c.delay += time.Duration(s.Value[1])
+		result[caller] = c
+	}
+	return result, nil
+}
+
+// lockContentionCaller returns the first frame of the sample out of the runtime and sync packages,
+// which is the one releasing the contended lock.
+func lockContentionCaller(s *profile.Sample) string {
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			name := line.Function.Name
+			if strings.HasPrefix(name, "runtime.") || strings.HasPrefix(name, "sync.") {
+				continue
+			}
+			return fmt.Sprintf("%s %s:%d", name, line.Function.Filename, line.Line)
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,48 @@
+package ingester
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func contendLock() {
+	var (
+		mtx sync.Mutex
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mtx.Lock()
+			time.Sleep(time.Millisecond)
+			mtx.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLockContentionLogger(t *testing.T) {
+	prev := runtime.SetMutexProfileFraction(1)
+	defer runtime.SetMutexProfileFraction(prev)
+
+	var buf bytes.Buffer
+	l := newLockContentionLogger(log.NewLogfmtLogger(&buf))
+	require.Empty(t, buf.String())
+
+	contendLock()
+	l.logTopContentions()
+	require.Contains(t, buf.String(), "contendLock")
+
+	// only the contentions since the previous call are logged.
+	buf.Reset()
+	l.logTopContentions()
+	require.False(t, strings.Contains(buf.String(), "contendLock"), buf.String())
+}
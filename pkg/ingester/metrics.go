@@ -14,15 +14,20 @@ type ingesterMetrics struct {
 	checkpointCreationTotal    prometheus.Counter
 	checkpointDuration         prometheus.Summary
 	checkpointLoggedBytesTotal prometheus.Counter
+	checkpointCompactionFail   prometheus.Counter
+	checkpointCompactionTotal  prometheus.Counter
 
 	walDiskFullFailures     prometheus.Counter
 	walReplayActive         prometheus.Gauge
 	walReplayDuration       prometheus.Gauge
+	walReplayPhaseDuration  *prometheus.GaugeVec
+	walReplayBytes          *prometheus.CounterVec
 	walReplaySamplesDropped *prometheus.CounterVec
 	walReplayBytesDropped   *prometheus.CounterVec
 	walCorruptionsTotal     *prometheus.CounterVec
 	walLoggedBytesTotal     prometheus.Counter
 	walRecordsLogged        prometheus.Counter
+	walTenantBytes          *prometheus.GaugeVec
 
 	recoveredStreamsTotal prometheus.Counter
 	recoveredChunksTotal  prometheus.Counter
@@ -35,6 +40,8 @@ type ingesterMetrics struct {
 	limiterEnabled prometheus.Gauge
 
 	autoForgetUnhealthyIngestersTotal prometheus.Counter
+
+	shutdownMarker prometheus.Gauge
 }
 
 // setRecoveryBytesInUse bounds the bytes reports to >= 0.
@@ -67,6 +74,14 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 			Name: "loki_ingester_wal_replay_duration_seconds",
 			Help: "Time taken to replay the checkpoint and the WAL.",
 		}),
+		walReplayPhaseDuration: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "loki_ingester_wal_replay_phase_duration_seconds",
+			Help: "Time taken to replay the checkpoint or the WAL segments, by type.",
+		}, []string{"type"}),
+		walReplayBytes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_ingester_wal_replay_bytes_total",
+			Help: "Total number of bytes of records read from the checkpoint or the WAL segments during replay, by type.",
+		}, []string{"type"}),
 		walReplaySamplesDropped: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_discarded_samples_total",
 			Help: "WAL segment entries discarded during replay",
@@ -95,6 +110,14 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 			Name: "loki_ingester_checkpoint_creations_total",
 			Help: "Total number of checkpoint creations attempted.",
 		}),
+		checkpointCompactionFail: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "loki_ingester_checkpoint_compactions_failed_total",
+			Help: "Total number of checkpoint compactions that failed.",
+		}),
+		checkpointCompactionTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "loki_ingester_checkpoint_compactions_total",
+			Help: "Total number of checkpoint compactions attempted.",
+		}),
 		checkpointDuration: promauto.With(r).NewSummary(prometheus.SummaryOpts{
 			Name:       "loki_ingester_checkpoint_duration_seconds",
 			Help:       "Time taken to create a checkpoint.",
@@ -112,6 +135,10 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 			Name: "loki_ingester_wal_logged_bytes_total",
 			Help: "Total number of bytes written to disk for WAL records.",
 		}),
+		walTenantBytes: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "loki_ingester_wal_tenant_bytes",
+			Help: "The bytes of the WAL records of a tenant in the WAL segments not yet truncated by a checkpoint.",
+		}, []string{"tenant"}),
 		recoveredStreamsTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "loki_ingester_wal_recovered_streams_total",
 			Help: "Total number of streams recovered from the WAL.",
@@ -148,5 +175,9 @@ func newIngesterMetrics(r prometheus.Registerer) *ingesterMetrics {
 			Name: "loki_ingester_autoforget_unhealthy_ingesters_total",
 			Help: "Total number of ingesters automatically forgotten",
 		}),
+		shutdownMarker: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "loki_ingester_prepare_shutdown_requested",
+			Help: "1 if the ingester has been requested to flush and leave the ring on its next shutdown, 0 otherwise.",
+		}),
 	}
 }
@@ -9,6 +9,7 @@ import (
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/record"
 	"github.com/prometheus/prometheus/tsdb/wal"
@@ -65,7 +66,9 @@ func newWalReader(dir string, startSegment int) (*wal.Reader, io.Closer, error)
 	return wal.NewReader(segmentReader), segmentReader, nil
 }
 
-func newCheckpointReader(dir string) (WALReader, io.Closer, error) {
+// newCheckpointReaders returns a reader per segment of the last checkpoint, checkpoint records are
+// independent series which can be replayed concurrently.
+func newCheckpointReaders(dir string) ([]WALReader, io.Closer, error) {
 	lastCheckpointDir, idx, err := lastCheckpoint(dir)
 	if err != nil {
 		return nil, nil, err
@@ -73,14 +76,53 @@ func newCheckpointReader(dir string) (WALReader, io.Closer, error) {
 	if idx < 0 {
 		level.Info(util_log.Logger).Log("msg", "no checkpoint found, treating as no-op")
 		var reader NoopWALReader
-		return reader, reader, nil
+		return []WALReader{reader}, reader, nil
 	}
 
-	r, err := wal.NewSegmentsReader(lastCheckpointDir)
+	first, last, err := wal.Segments(lastCheckpointDir)
 	if err != nil {
 		return nil, nil, err
 	}
-	return wal.NewReader(r), r, nil
+	var (
+		readers []WALReader
+		closers multiCloser
+	)
+	for i := first; i <= last; i++ {
+		r, err := wal.NewSegmentsRangeReader(wal.SegmentRange{Dir: lastCheckpointDir, First: i, Last: i})
+		if err != nil {
+			_ = closers.Close()
+			return nil, nil, err
+		}
+		readers = append(readers, wal.NewReader(r))
+		closers = append(closers, r)
+	}
+	return readers, closers, nil
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var firstErr error
+	for _, c := range m {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// countingWALReader counts the bytes of the records read.
+type countingWALReader struct {
+	WALReader
+	bytes prometheus.Counter
+}
+
+func (r countingWALReader) Next() bool {
+	if !r.WALReader.Next() {
+		return false
+	}
+	r.bytes.Add(float64(len(r.WALReader.Record())))
+	return true
 }
 
 type Recoverer interface {
@@ -107,8 +149,13 @@ func newIngesterRecoverer(i *Ingester) *ingesterRecoverer {
 	}
 }
 
-// Use all available cores
-func (r *ingesterRecoverer) NumWorkers() int { return runtime.GOMAXPROCS(0) }
+// Use all available cores unless configured otherwise.
+func (r *ingesterRecoverer) NumWorkers() int {
+	if n := r.ing.cfg.WAL.ReplayConcurrency; n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
 
 func (r *ingesterRecoverer) Series(series *Series) error {
 	return r.ing.replayController.WithBackPressure(func() error {
@@ -129,6 +176,7 @@ func (r *ingesterRecoverer) Series(series *Series) error {
 		stream.lastLine.content = series.LastLine
 		stream.entryCt = series.EntryCt
 		stream.highestTs = series.HighestTs
+		stream.lastSequence = series.LastSequence
 
 		if err != nil {
 			return err
@@ -255,11 +303,16 @@ func (r *ingesterRecoverer) Done() <-chan struct{} {
 }
 
 func RecoverWAL(reader WALReader, recoverer Recoverer) error {
-	dispatch := func(recoverer Recoverer, b []byte, inputs []chan recoveryInput) error {
+	decode := func(b []byte) (interface{}, error) {
 		rec := recordPool.GetRecord()
 		if err := decodeWALRecord(b, rec); err != nil {
-			return err
+			return nil, err
 		}
+		return rec, nil
+	}
+
+	dispatch := func(recoverer Recoverer, decoded interface{}, inputs []chan recoveryInput) error {
+		rec := decoded.(*WALRecord)
 
 		// First process all series to ensure we don't write entries to nonexistant series.
 		var firstErr error
@@ -310,21 +363,27 @@ func RecoverWAL(reader WALReader, recoverer Recoverer) error {
 	}
 
 	return recoverGeneric(
-		reader,
+		[]WALReader{reader},
 		recoverer,
+		decode,
 		dispatch,
 		process,
 	)
 
 }
 
-func RecoverCheckpoint(reader WALReader, recoverer Recoverer) error {
-	dispatch := func(recoverer Recoverer, b []byte, inputs []chan recoveryInput) error {
+// RecoverCheckpoint recovers the series of a checkpoint, reading its readers concurrently.
+func RecoverCheckpoint(readers []WALReader, recoverer Recoverer) error {
+	decode := func(b []byte) (interface{}, error) {
 		s := &Series{}
 		if err := decodeCheckpointRecord(b, s); err != nil {
-			return err
+			return nil, err
 		}
+		return s, nil
+	}
 
+	dispatch := func(recoverer Recoverer, decoded interface{}, inputs []chan recoveryInput) error {
+		s := decoded.(*Series)
 		worker := int(s.Fingerprint % uint64(len(inputs)))
 		inputs[worker] <- recoveryInput{
 			userID: s.UserID,
@@ -359,8 +418,9 @@ func RecoverCheckpoint(reader WALReader, recoverer Recoverer) error {
 	}
 
 	return recoverGeneric(
-		reader,
+		readers,
 		recoverer,
+		decode,
 		dispatch,
 		process,
 	)
@@ -372,14 +432,17 @@ type recoveryInput struct {
 }
 
 // recoverGeneric enables reusing the ability to recover from WALs of different types
-// by exposing the dispatch and process functions.
+// by exposing the decode, dispatch and process functions.
+// Readers are read concurrently, and the records of each reader are decoded by as many goroutines
+// as workers but dispatched in the order of the reader.
 // Note: it explicitly does not call the Recoverer.Close function as it's possible to layer
 // multiple recoveries on top of each other, as in the case of recovering from Checkpoints
 // then the WAL.
 func recoverGeneric(
-	reader WALReader,
+	readers []WALReader,
 	recoverer Recoverer,
-	dispatch func(Recoverer, []byte, []chan recoveryInput) error,
+	decode func([]byte) (interface{}, error),
+	dispatch func(Recoverer, interface{}, []chan recoveryInput) error,
 	process func(Recoverer, <-chan recoveryInput, chan<- error),
 ) error {
 	var wg sync.WaitGroup
@@ -403,20 +466,24 @@ func recoverGeneric(
 
 	}
 
-	go func() {
-		for reader.Next() {
-			b := reader.Record()
-			if err := reader.Err(); err != nil {
-				errCh <- err
-				continue
-			}
-
-			if err := dispatch(recoverer, b, inputs); err != nil {
-				errCh <- err
-				continue
-			}
-		}
+	var readersWg sync.WaitGroup
+	readersWg.Add(len(readers))
+	for _, reader := range readers {
+		go func(reader WALReader) {
+			defer readersWg.Done()
+			decodeInOrder(reader, nWorkers, decode, func(decoded interface{}, err error) {
+				if err == nil {
+					err = dispatch(recoverer, decoded, inputs)
+				}
+				if err != nil {
+					errCh <- err
+				}
+			})
+		}(reader)
+	}
 
+	go func() {
+		readersWg.Wait()
 		for _, w := range inputs {
 			close(w)
 		}
@@ -439,3 +506,50 @@ func recoverGeneric(
 		}
 	}
 }
+
+type decodeResult struct {
+	decoded interface{}
+	err     error
+}
+
+type decodeJob struct {
+	record []byte
+	result chan<- decodeResult
+}
+
+// decodeInOrder decodes the records of reader with nDecoders goroutines and calls fn with the decoded records,
+// or the errors reading or decoding them, in the order of the reader.
+func decodeInOrder(reader WALReader, nDecoders int, decode func([]byte) (interface{}, error), fn func(interface{}, error)) {
+	jobs := make(chan decodeJob)
+	for i := 0; i < nDecoders; i++ {
+		go func() {
+			for job := range jobs {
+				decoded, err := decode(job.record)
+				job.result <- decodeResult{decoded: decoded, err: err}
+			}
+		}()
+	}
+
+	// Bounds the number of records read ahead of the one being dispatched.
+	results := make(chan chan decodeResult, nDecoders)
+	go func() {
+		defer close(results)
+		defer close(jobs)
+		for reader.Next() {
+			result := make(chan decodeResult, 1)
+			if err := reader.Err(); err != nil {
+				result <- decodeResult{err: err}
+				results <- result
+				continue
+			}
+			results <- result
+			// The record is only valid until the next call to Next.
+			jobs <- decodeJob{record: append([]byte(nil), reader.Record()...), result: result}
+		}
+	}()
+
+	for result := range results {
+		r := <-result
+		fn(r.decoded, r.err)
+	}
+}
@@ -275,3 +275,27 @@ func TestSeriesRecoveryNoDuplicates(t *testing.T) {
 	}
 	require.Equal(t, expected, result.resps[0].Streams)
 }
+
+func Test_DecodeInOrder(t *testing.T) {
+	reader := &MemoryWALReader{}
+	for i := 0; i < 1000; i++ {
+		reader.xs = append(reader.xs, []byte(fmt.Sprint(i)))
+	}
+
+	var decoded []interface{}
+	decodeInOrder(reader, 8, func(b []byte) (interface{}, error) {
+		// Decoding the first records slower must not reorder them.
+		if len(b) == 1 {
+			time.Sleep(time.Millisecond)
+		}
+		return string(b), nil
+	}, func(v interface{}, err error) {
+		require.NoError(t, err)
+		decoded = append(decoded, v)
+	})
+
+	require.Len(t, decoded, 1000)
+	for i, v := range decoded {
+		require.Equal(t, fmt.Sprint(i), v)
+	}
+}
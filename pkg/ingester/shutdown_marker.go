@@ -0,0 +1,60 @@
+package ingester
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// shutdownMarkerFilename is the name of the file persisting a prepare shutdown request across restarts.
+const shutdownMarkerFilename = "shutdown-requested.txt"
+
+// shutdownMarkerPath returns the path of the shutdown marker of the ingester, in the WAL directory unless
+// another directory is configured.
+func (cfg *Config) shutdownMarkerPath() string {
+	dir := cfg.ShutdownMarkerDir
+	if dir == "" {
+		dir = cfg.WAL.Dir
+	}
+	return filepath.Join(dir, shutdownMarkerFilename)
+}
+
+// createShutdownMarker writes the shutdown marker, containing the time of the request, and syncs it to disk.
+func createShutdownMarker(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(time.Now().UTC().Format(time.RFC3339)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeShutdownMarker removes the shutdown marker, it is not an error if it doesn't exist.
+func removeShutdownMarker(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// shutdownMarkerExists returns whether a prepare shutdown request is persisted.
+func shutdownMarkerExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
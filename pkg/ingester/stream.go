@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
@@ -96,7 +97,13 @@ type stream struct {
 	// introduced to facilitate removing the ordering constraint.
 	entryCt int64
 
+	// highest sequence number of the entries accepted by the stream.
+	// This is used to drop the entries pushed again by retries, when the
+	// tenant enforces sequence numbers.
+	lastSequence uint64
+
 	unorderedWrites bool
+	limits          StreamLimits
 }
 
 type chunkDesc struct {
@@ -104,16 +111,40 @@ type chunkDesc struct {
 	closed  bool
 	synced  bool
 	flushed time.Time
+	// checksum of the encoded chunk, only set once the chunk is flushed.
+	checksum uint32
 
 	lastUpdated time.Time
 }
 
+// chunkFilter returns true for the chunks of the stream with the fingerprint fp which should be included in the query
+// results.
+type chunkFilter func(fp model.Fingerprint, c *chunkDesc) bool
+
+// recentChunksFilter accepts the chunks which are not flushed yet and the flushed chunks which are not among the chunks
+// returned by the store to the querier, identified by the fingerprint of their stream and their checksum.
+func recentChunksFilter(storeChunks []logproto.StoreChunk) chunkFilter {
+	inStore := make(map[logproto.StoreChunk]struct{}, len(storeChunks))
+	for _, c := range storeChunks {
+		inStore[c] = struct{}{}
+	}
+
+	return func(fp model.Fingerprint, c *chunkDesc) bool {
+		if c.flushed.IsZero() {
+			return true
+		}
+
+		_, ok := inStore[logproto.StoreChunk{Fingerprint: uint64(fp), Checksum: c.checksum}]
+		return !ok
+	}
+}
+
 type entryWithError struct {
 	entry *logproto.Entry
 	e     error
 }
 
-func newStream(cfg *Config, limits RateLimiterStrategy, tenant string, fp model.Fingerprint, labels labels.Labels, unorderedWrites bool, metrics *ingesterMetrics) *stream {
+func newStream(cfg *Config, limits StreamLimits, tenant string, fp model.Fingerprint, labels labels.Labels, unorderedWrites bool, metrics *ingesterMetrics) *stream {
 	return &stream{
 		limiter:         NewStreamRateLimiter(limits, tenant, 10*time.Second),
 		cfg:             cfg,
@@ -124,6 +155,7 @@ func newStream(cfg *Config, limits RateLimiterStrategy, tenant string, fp model.
 		metrics:         metrics,
 		tenant:          tenant,
 		unorderedWrites: unorderedWrites,
+		limits:          limits,
 	}
 }
 
@@ -162,7 +194,20 @@ func (s *stream) setChunks(chunks []Chunk) (bytesAdded, entriesAdded int, err er
 }
 
 func (s *stream) NewChunk() *chunkenc.MemChunk {
-	return chunkenc.NewMemChunk(s.cfg.parsedEncoding, headBlockType(s.unorderedWrites), s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	chunk := chunkenc.NewMemChunk(s.cfg.parsedEncoding, headBlockType(s.unorderedWrites), s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	if s.cfg.ChunkBloomFilters || s.limits.FeatureEnabled(s.tenant, validation.FeatureChunkBloomFilters) {
+		chunk.EnableBlockBloomFilters()
+	}
+	if s.zstdDictionariesEnabled() {
+		chunk.SetZstdDictionary(s.cfg.ZstdDictionaries.Active(s.tenant))
+	}
+	return chunk
+}
+
+// zstdDictionariesEnabled returns whether the chunks of the stream are compressed with the dictionaries of the tenant.
+func (s *stream) zstdDictionariesEnabled() bool {
+	return s.cfg.ZstdDictionaries != nil && s.cfg.parsedEncoding == chunkenc.EncZstd &&
+		s.limits.FeatureEnabled(s.tenant, validation.FeatureZstdDictionaries)
 }
 
 func (s *stream) Push(
@@ -206,6 +251,7 @@ func (s *stream) Push(
 
 	var outOfOrderSamples, outOfOrderBytes int
 	var rateLimitedSamples, rateLimitedBytes int
+	var duplicateSamples, duplicateBytes int
 	defer func() {
 		if outOfOrderSamples > 0 {
 			name := validation.OutOfOrder
@@ -219,12 +265,24 @@ func (s *stream) Push(
 			validation.DiscardedSamples.WithLabelValues(validation.StreamRateLimit, s.tenant).Add(float64(rateLimitedSamples))
 			validation.DiscardedBytes.WithLabelValues(validation.StreamRateLimit, s.tenant).Add(float64(rateLimitedBytes))
 		}
+		if duplicateSamples > 0 {
+			validation.DiscardedSamples.WithLabelValues(validation.DuplicateSequence, s.tenant).Add(float64(duplicateSamples))
+			validation.DiscardedBytes.WithLabelValues(validation.DuplicateSequence, s.tenant).Add(float64(duplicateBytes))
+		}
 	}()
 
 	// This call uses a mutex under the hood, cache the result since we're checking the limit
 	// on each entry in the push (hot path) and we only use this value when logging entries
 	// over the rate limit.
 	limit := s.limiter.lim.Limit()
+	outOfOrderWindow := s.outOfOrderWindow()
+
+	// When the tenant enforces sequence numbers, the entries are appended in the order
+	// of their sequence numbers, whatever the order they were pushed in.
+	enforceSequence := s.limits.FeatureEnabled(s.tenant, validation.FeatureEntrySequence)
+	if enforceSequence && !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Sequence < entries[j].Sequence }) {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Sequence < entries[j].Sequence })
+	}
 
 	// Don't fail on the first append error - if samples are sent out of order,
 	// we still want to append the later ones.
@@ -241,6 +299,14 @@ func (s *stream) Push(
 			continue
 		}
 
+		// An entry whose sequence number was already accepted is pushed again by a
+		// retry, it is dropped without failing the push.
+		if enforceSequence && entries[i].Sequence != 0 && entries[i].Sequence <= s.lastSequence {
+			duplicateSamples++
+			duplicateBytes += len(entries[i].Line)
+			continue
+		}
+
 		chunk := &s.chunks[len(s.chunks)-1]
 		if chunk.closed || !chunk.chunk.SpaceFor(&entries[i]) || s.cutChunkForSynchronization(entries[i].Timestamp, s.highestTs, chunk, s.cfg.SyncPeriod, s.cfg.SyncMinUtilization) {
 			chunk = s.cutChunk(ctx)
@@ -254,8 +320,8 @@ func (s *stream) Push(
 			continue
 		}
 
-		// The validity window for unordered writes is the highest timestamp present minus 1/2 * max-chunk-age.
-		if !isReplay && s.unorderedWrites && !s.highestTs.IsZero() && s.highestTs.Add(-s.cfg.MaxChunkAge/2).After(entries[i].Timestamp) {
+		// The validity window for unordered writes is the highest timestamp present minus the out of order window.
+		if !isReplay && s.unorderedWrites && !s.highestTs.IsZero() && s.highestTs.Add(-outOfOrderWindow).After(entries[i].Timestamp) {
 			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], chunkenc.ErrTooFarBehind})
 			outOfOrderSamples++
 			outOfOrderBytes += len(entries[i].Line)
@@ -272,6 +338,9 @@ func (s *stream) Push(
 			if s.highestTs.Before(entries[i].Timestamp) {
 				s.highestTs = entries[i].Timestamp
 			}
+			if s.lastSequence < entries[i].Sequence {
+				s.lastSequence = entries[i].Sequence
+			}
 			s.entryCt++
 
 			// length of string plus
@@ -281,6 +350,13 @@ func (s *stream) Push(
 	}
 
 	if len(storedEntries) != 0 {
+		// The replayed entries were already sampled.
+		if record != nil && s.zstdDictionariesEnabled() {
+			for _, e := range storedEntries {
+				s.cfg.ZstdDictionaries.Sample(s.tenant, e.Line)
+			}
+		}
+
 		// record will be nil when replaying the wal (we don't want to rewrite wal entries as we replay them).
 		if record != nil {
 			record.AddEntries(uint64(s.fp), s.entryCt, storedEntries...)
@@ -424,6 +500,11 @@ func (s *stream) Bounds() (from, to time.Time) {
 
 // Returns an iterator.
 func (s *stream) Iterator(ctx context.Context, statsCtx *stats.Context, from, through time.Time, direction logproto.Direction, pipeline log.StreamPipeline) (iter.EntryIterator, error) {
+	return s.iterator(ctx, statsCtx, from, through, direction, pipeline, nil)
+}
+
+// iterator returns an iterator over the chunks accepted by the filter, or all the chunks if the filter is nil.
+func (s *stream) iterator(ctx context.Context, statsCtx *stats.Context, from, through time.Time, direction logproto.Direction, pipeline log.StreamPipeline, filter chunkFilter) (iter.EntryIterator, error) {
 	s.chunkMtx.RLock()
 	defer s.chunkMtx.RUnlock()
 	iterators := make([]iter.EntryIterator, 0, len(s.chunks))
@@ -439,6 +520,10 @@ func (s *stream) Iterator(ctx context.Context, statsCtx *stats.Context, from, th
 			continue
 		}
 
+		if filter != nil && !filter(s.fp, &c) {
+			continue
+		}
+
 		if mint.Before(lastMax) {
 			ordered = false
 		}
@@ -471,6 +556,11 @@ func (s *stream) Iterator(ctx context.Context, statsCtx *stats.Context, from, th
 
 // Returns an SampleIterator.
 func (s *stream) SampleIterator(ctx context.Context, statsCtx *stats.Context, from, through time.Time, extractor log.StreamSampleExtractor) (iter.SampleIterator, error) {
+	return s.sampleIterator(ctx, statsCtx, from, through, extractor, nil)
+}
+
+// sampleIterator returns a sample iterator over the chunks accepted by the filter, or all the chunks if the filter is nil.
+func (s *stream) sampleIterator(ctx context.Context, statsCtx *stats.Context, from, through time.Time, extractor log.StreamSampleExtractor, filter chunkFilter) (iter.SampleIterator, error) {
 	s.chunkMtx.RLock()
 	defer s.chunkMtx.RUnlock()
 	iterators := make([]iter.SampleIterator, 0, len(s.chunks))
@@ -486,6 +576,10 @@ func (s *stream) SampleIterator(ctx context.Context, statsCtx *stats.Context, fr
 			continue
 		}
 
+		if filter != nil && !filter(s.fp, &c) {
+			continue
+		}
+
 		if mint.Before(lastMax) {
 			ordered = false
 		}
@@ -517,6 +611,15 @@ func (s *stream) resetCounter() {
 	s.entryCt = 0
 }
 
+// outOfOrderWindow returns how far behind the highest timestamp of the stream unordered writes are accepted,
+// which defaults to 1/2 * max-chunk-age.
+func (s *stream) outOfOrderWindow() time.Duration {
+	if w := s.limits.MaxOutOfOrderTimeWindow(s.tenant); w > 0 {
+		return w
+	}
+	return s.cfg.MaxChunkAge / 2
+}
+
 func headBlockType(unorderedWrites bool) chunkenc.HeadBlockFmt {
 	if unorderedWrites {
 		return chunkenc.UnorderedHeadBlockFmt
@@ -213,6 +213,42 @@ func TestStreamIterator(t *testing.T) {
 	}
 }
 
+func TestStreamIterator_RecentChunks(t *testing.T) {
+	var s stream
+	// 3 chunks with 10 entries each. First 2 chunks are flushed but only the first one is returned by the store, the
+	// second one having the checksum of a chunk of another stream.
+	for i := int64(0); i < 3; i++ {
+		chunk := chunkenc.NewMemChunk(chunkenc.EncGZIP, chunkenc.UnorderedHeadBlockFmt, 256*1024, 0)
+		for j := int64(0); j < 10; j++ {
+			require.NoError(t, chunk.Append(&logproto.Entry{
+				Timestamp: time.Unix(i*10+j, 0),
+				Line:      fmt.Sprintf("line %d", i*10+j),
+			}))
+		}
+
+		desc := chunkDesc{chunk: chunk}
+		if i < 2 {
+			desc.flushed = time.Now()
+			desc.checksum = uint32(i + 1)
+		}
+		s.chunks = append(s.chunks, desc)
+	}
+
+	it, err := s.iterator(context.TODO(), nil, time.Unix(0, 0), time.Unix(30, 0), logproto.FORWARD, log.NewNoopPipeline().ForStream(s.labels), recentChunksFilter([]logproto.StoreChunk{{Checksum: 1}, {Fingerprint: 1, Checksum: 2}}))
+	require.NoError(t, err)
+	testIteratorForward(t, it, 10, 30)
+	require.NoError(t, it.Close())
+
+	sampleIt, err := s.sampleIterator(context.TODO(), nil, time.Unix(0, 0), time.Unix(30, 0), countExtractor(), recentChunksFilter([]logproto.StoreChunk{{Checksum: 1}, {Checksum: 2}}))
+	require.NoError(t, err)
+	for i := int64(20); i < 30; i++ {
+		require.True(t, sampleIt.Next())
+		require.Equal(t, time.Unix(i, 0).UnixNano(), sampleIt.Sample().Timestamp)
+	}
+	require.False(t, sampleIt.Next())
+	require.NoError(t, sampleIt.Close())
+}
+
 func TestUnorderedPush(t *testing.T) {
 	cfg := defaultIngesterTestConfig(t)
 	cfg.MaxChunkAge = 10 * time.Second
@@ -384,6 +420,141 @@ func TestReplayAppendIgnoresValidityWindow(t *testing.T) {
 
 }
 
+func TestPushOutOfOrderWindow(t *testing.T) {
+	l := defaultLimitsTestConfig()
+	l.MaxOutOfOrderTimeWindow = model.Duration(2 * time.Hour)
+	limits, err := validation.NewOverrides(l, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	cfg := defaultConfig()
+	cfg.MaxChunkAge = time.Minute
+
+	s := newStream(
+		cfg,
+		limiter,
+		"fake",
+		model.Fingerprint(0),
+		labels.Labels{
+			{Name: "foo", Value: "bar"},
+		},
+		true,
+		NilMetrics,
+	)
+
+	base := time.Now()
+	_, err = s.Push(context.Background(), []logproto.Entry{{Timestamp: base, Line: "1"}}, recordPool.GetRecord(), 0)
+	require.NoError(t, err)
+
+	// Within the tenant's window, even though it is older than 1/2 * max-chunk-age.
+	_, err = s.Push(context.Background(), []logproto.Entry{{Timestamp: base.Add(-time.Hour), Line: "2"}}, recordPool.GetRecord(), 0)
+	require.NoError(t, err)
+
+	// Outside of the tenant's window.
+	_, err = s.Push(context.Background(), []logproto.Entry{{Timestamp: base.Add(-3 * time.Hour), Line: "3"}}, recordPool.GetRecord(), 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), chunkenc.ErrTooFarBehind.Error())
+}
+
+func TestPushSequence(t *testing.T) {
+	l := defaultLimitsTestConfig()
+	l.FeatureFlags = map[string]bool{string(validation.FeatureEntrySequence): true}
+	limits, err := validation.NewOverrides(l, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	s := newStream(
+		defaultConfig(),
+		limiter,
+		"fake",
+		model.Fingerprint(0),
+		labels.Labels{
+			{Name: "foo", Value: "bar"},
+		},
+		false,
+		NilMetrics,
+	)
+
+	// The entries are appended in the order of their sequence numbers, so
+	// they are not out of order.
+	written, err := s.Push(context.Background(), []logproto.Entry{
+		{Timestamp: time.Unix(2, 0), Line: "2", Sequence: 2},
+		{Timestamp: time.Unix(1, 0), Line: "1", Sequence: 1},
+	}, recordPool.GetRecord(), 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+	require.Equal(t, uint64(2), s.lastSequence)
+
+	// The entries already accepted are dropped when a push is retried.
+	written, err = s.Push(context.Background(), []logproto.Entry{
+		{Timestamp: time.Unix(1, 0), Line: "1", Sequence: 1},
+		{Timestamp: time.Unix(2, 0), Line: "2", Sequence: 2},
+		{Timestamp: time.Unix(3, 0), Line: "3", Sequence: 3},
+	}, recordPool.GetRecord(), 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, written)
+	require.Equal(t, uint64(3), s.lastSequence)
+
+	it, err := s.Iterator(context.Background(), nil, time.Unix(0, 0), time.Unix(10, 0), logproto.FORWARD, log.NewNoopPipeline().ForStream(s.labels))
+	require.NoError(t, err)
+	iterEq(t, []logproto.Entry{
+		{Timestamp: time.Unix(1, 0), Line: "1"},
+		{Timestamp: time.Unix(2, 0), Line: "2"},
+		{Timestamp: time.Unix(3, 0), Line: "3"},
+	}, it)
+}
+
+// zstdDictionariesMock records the sampled lines and has no dictionary.
+type zstdDictionariesMock struct {
+	active  int
+	sampled []string
+}
+
+func (m *zstdDictionariesMock) Active(string) *chunkenc.ZstdDictionary {
+	m.active++
+	return nil
+}
+
+func (m *zstdDictionariesMock) Sample(_, line string) {
+	m.sampled = append(m.sampled, line)
+}
+
+func TestStreamZstdDictionaries(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			l := defaultLimitsTestConfig()
+			l.FeatureFlags = map[string]bool{string(validation.FeatureZstdDictionaries): enabled}
+			limits, err := validation.NewOverrides(l, nil)
+			require.NoError(t, err)
+			limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+			cfg := defaultConfig()
+			cfg.ChunkEncoding = chunkenc.EncZstd.String()
+			require.NoError(t, cfg.Validate())
+			dicts := &zstdDictionariesMock{}
+			cfg.ZstdDictionaries = dicts
+			s := newStream(cfg, limiter, "fake", model.Fingerprint(0), labels.Labels{{Name: "foo", Value: "bar"}}, false, NilMetrics)
+
+			_, err = s.Push(context.Background(), []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "1"},
+				{Timestamp: time.Unix(2, 0), Line: "2"},
+			}, recordPool.GetRecord(), 0)
+			require.NoError(t, err)
+			// The replayed entries are not sampled again.
+			_, err = s.Push(context.Background(), []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "3"}}, nil, 0)
+			require.NoError(t, err)
+
+			if !enabled {
+				require.Zero(t, dicts.active)
+				require.Empty(t, dicts.sampled)
+				return
+			}
+			require.Equal(t, 1, dicts.active)
+			require.Equal(t, []string{"1", "2"}, dicts.sampled)
+		})
+	}
+}
+
 func iterEq(t *testing.T, exp []logproto.Entry, got iter.EntryIterator) {
 	var i int
 	for got.Next() {
@@ -407,7 +578,7 @@ func Benchmark_PushStream(b *testing.B) {
 	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
 
 	s := newStream(&Config{MaxChunkAge: 24 * time.Hour}, limiter, "fake", model.Fingerprint(0), ls, true, NilMetrics)
-	t, err := newTailer("foo", `{namespace="loki-dev"}`, &fakeTailServer{})
+	t, err := newTailer("foo", `{namespace="loki-dev"}`, &fakeTailServer{}, nil)
 	require.NoError(b, err)
 
 	go t.loop()
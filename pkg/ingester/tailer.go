@@ -46,11 +46,12 @@ type tailer struct {
 	conn TailServer
 }
 
-func newTailer(orgID, query string, conn TailServer) (*tailer, error) {
+func newTailer(orgID, query string, conn TailServer, lookupTables log.LookupTables) (*tailer, error) {
 	expr, err := logql.ParseLogSelector(query, true)
 	if err != nil {
 		return nil, err
 	}
+	logql.BindLookupTables(expr, lookupTables)
 	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
@@ -151,7 +152,7 @@ func (t *tailer) processStream(stream logproto.Stream, lbs labels.Labels) []*log
 
 	sp := t.pipeline.ForStream(lbs)
 	for _, e := range stream.Entries {
-		newLine, parsedLbs, ok := sp.ProcessString(e.Line)
+		newLine, parsedLbs, ok := sp.ProcessString(e.Timestamp.UnixNano(), e.Line)
 		if !ok {
 			continue
 		}
@@ -26,7 +26,7 @@ func TestTailer_sendRaceConditionOnSendWhileClosing(t *testing.T) {
 	}
 
 	for run := 0; run < runs; run++ {
-		tailer, err := newTailer("org-id", stream.Labels, nil)
+		tailer, err := newTailer("org-id", stream.Labels, nil, nil)
 		require.NoError(t, err)
 		require.NotNil(t, tailer)
 
@@ -55,7 +55,7 @@ func (f *fakeTailServer) Send(*logproto.TailResponse) error { return nil }
 func (f *fakeTailServer) Context() context.Context          { return context.Background() }
 
 func Test_TailerSendRace(t *testing.T) {
-	tail, err := newTailer("foo", `{app="foo"} |= "foo"`, &fakeTailServer{})
+	tail, err := newTailer("foo", `{app="foo"} |= "foo"`, &fakeTailServer{}, nil)
 	require.NoError(t, err)
 
 	var wg sync.WaitGroup
@@ -93,3 +93,26 @@ func Test_IsMatching(t *testing.T) {
 		})
 	}
 }
+
+func Test_TailerPipeline(t *testing.T) {
+	tail, err := newTailer("foo", `{app="foo"} | json level="level" | level="error" | line_format "{{.level}}: {{__line__}}"`, &fakeTailServer{}, nil)
+	require.NoError(t, err)
+
+	lbs := labels.Labels{{Name: "app", Value: "foo"}}
+	streams := tail.processStream(logproto.Stream{
+		Labels: lbs.String(),
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(0, 1), Line: `{"level":"info","msg":"1"}`},
+			{Timestamp: time.Unix(0, 2), Line: `{"level":"error","msg":"2"}`},
+			{Timestamp: time.Unix(0, 3), Line: `{"level":"error","msg":"3"}`},
+			{Timestamp: time.Unix(0, 4), Line: `not json`},
+		},
+	}, lbs)
+
+	require.Len(t, streams, 1)
+	require.Equal(t, `{app="foo", level="error"}`, streams[0].Labels)
+	require.Equal(t, []logproto.Entry{
+		{Timestamp: time.Unix(0, 2), Line: `error: {"level":"error","msg":"2"}`},
+		{Timestamp: time.Unix(0, 3), Line: `error: {"level":"error","msg":"3"}`},
+	}, streams[0].Entries)
+}
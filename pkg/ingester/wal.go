@@ -29,12 +29,18 @@ type WALConfig struct {
 	CheckpointDuration  time.Duration    `yaml:"checkpoint_duration"`
 	FlushOnShutdown     bool             `yaml:"flush_on_shutdown"`
 	ReplayMemoryCeiling flagext.ByteSize `yaml:"replay_memory_ceiling"`
+	ReplayConcurrency   int              `yaml:"replay_concurrency"`
+
+	CheckpointCompactionInterval time.Duration `yaml:"checkpoint_compaction_interval"`
 }
 
 func (cfg *WALConfig) Validate() error {
 	if cfg.Enabled && cfg.CheckpointDuration < 1 {
 		return errors.Errorf("invalid checkpoint duration: %v", cfg.CheckpointDuration)
 	}
+	if cfg.ReplayConcurrency < 0 {
+		return errors.Errorf("invalid replay concurrency: %d", cfg.ReplayConcurrency)
+	}
 	return nil
 }
 
@@ -48,6 +54,8 @@ func (cfg *WALConfig) RegisterFlags(f *flag.FlagSet) {
 	// Need to set default here
 	cfg.ReplayMemoryCeiling = flagext.ByteSize(defaultCeiling)
 	f.Var(&cfg.ReplayMemoryCeiling, "ingester.wal-replay-memory-ceiling", "How much memory the WAL may use during replay before it needs to flush chunks to storage, i.e. 10GB. We suggest setting this to a high percentage (~75%) of available memory.")
+	f.IntVar(&cfg.ReplayConcurrency, "ingester.wal-replay-concurrency", 0, "Number of goroutines decoding and replaying WAL records, and of checkpoint segments replayed concurrently. 0 to use all available cores.")
+	f.DurationVar(&cfg.CheckpointCompactionInterval, "ingester.checkpoint-compaction-interval", 0, "Interval at which the segments of the last checkpoint are merged into as few segments as possible, in between checkpoints. 0 to disable.")
 }
 
 // WAL interface allows us to have a no-op WAL when the WAL is disabled.
@@ -55,21 +63,25 @@ type WAL interface {
 	Start()
 	// Log marshalls the records and writes it into the WAL.
 	Log(*WALRecord) error
+	// TenantBytes returns the bytes a tenant logged to the WAL segments not yet truncated by a checkpoint.
+	TenantBytes(userID string) int64
 	// Stop stops all the WAL operations.
 	Stop() error
 }
 
 type noopWAL struct{}
 
-func (noopWAL) Start()               {}
-func (noopWAL) Log(*WALRecord) error { return nil }
-func (noopWAL) Stop() error          { return nil }
+func (noopWAL) Start()                   {}
+func (noopWAL) Log(*WALRecord) error     { return nil }
+func (noopWAL) TenantBytes(string) int64 { return 0 }
+func (noopWAL) Stop() error              { return nil }
 
 type walWrapper struct {
 	cfg        WALConfig
 	wal        *wal.WAL
 	metrics    *ingesterMetrics
 	seriesIter SeriesIter
+	usage      *walUsage
 
 	wait sync.WaitGroup
 	quit chan struct{}
@@ -92,6 +104,7 @@ func newWAL(cfg WALConfig, registerer prometheus.Registerer, metrics *ingesterMe
 		wal:        tsdbWAL,
 		metrics:    metrics,
 		seriesIter: seriesIter,
+		usage:      newWALUsage(metrics.walTenantBytes),
 	}
 
 	return w, nil
@@ -123,20 +136,26 @@ func (w *walWrapper) Log(record *WALRecord) error {
 			}
 			w.metrics.walRecordsLogged.Inc()
 			w.metrics.walLoggedBytesTotal.Add(float64(len(buf)))
+			w.usage.add(record.UserID, len(buf))
 			buf = buf[:0]
 		}
 		if len(record.RefEntries) > 0 {
-			buf = record.encodeEntries(CurrentEntriesRec, buf)
+			buf = record.encodeEntries(record.entriesRecordType(), buf)
 			if err := w.wal.Log(buf); err != nil {
 				return err
 			}
 			w.metrics.walRecordsLogged.Inc()
 			w.metrics.walLoggedBytesTotal.Add(float64(len(buf)))
+			w.usage.add(record.UserID, len(buf))
 		}
 		return nil
 	}
 }
 
+func (w *walWrapper) TenantBytes(userID string) int64 {
+	return w.usage.bytes(userID)
+}
+
 func (w *walWrapper) Stop() error {
 	close(w.quit)
 	w.wait.Wait()
@@ -149,6 +168,7 @@ func (w *walWrapper) checkpointWriter() *WALCheckpointWriter {
 	return &WALCheckpointWriter{
 		metrics:    w.metrics,
 		segmentWAL: w.wal,
+		usage:      w.usage,
 	}
 }
 
@@ -163,6 +183,8 @@ func (w *walWrapper) run() {
 		w.metrics,
 		w.quit,
 	)
+	checkpointer.compactionInterval = w.cfg.CheckpointCompactionInterval
+	checkpointer.dir = w.wal.Dir()
 	checkpointer.Run()
 
 }
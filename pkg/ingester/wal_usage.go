@@ -0,0 +1,71 @@
+package ingester
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// walUsage accounts the bytes each tenant logged to the WAL segments which were not truncated yet.
+// The bytes logged before a checkpoint started are released once the checkpoint truncated their segments.
+// The segments replayed on startup are not accounted, they are truncated by the first checkpoint.
+type walUsage struct {
+	mtx sync.Mutex
+	// current are the bytes logged to the segments after the last checkpoint started.
+	current map[string]int64
+	// checkpointed are the bytes logged before, in segments waiting to be truncated by a checkpoint.
+	checkpointed map[string]int64
+
+	tenantBytes *prometheus.GaugeVec
+}
+
+func newWALUsage(tenantBytes *prometheus.GaugeVec) *walUsage {
+	return &walUsage{
+		current:      map[string]int64{},
+		checkpointed: map[string]int64{},
+		tenantBytes:  tenantBytes,
+	}
+}
+
+func (u *walUsage) add(userID string, bytes int) {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	u.current[userID] += int64(bytes)
+	if u.tenantBytes != nil {
+		u.tenantBytes.WithLabelValues(userID).Add(float64(bytes))
+	}
+}
+
+// bytes returns the bytes of the tenant in the WAL segments.
+func (u *walUsage) bytes(userID string) int64 {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	return u.current[userID] + u.checkpointed[userID]
+}
+
+// cut is called when a checkpoint starts a new segment, the bytes logged so far are truncated by the checkpoint.
+// The bytes of a previous checkpoint which failed to truncate its segments are kept until one succeeds.
+func (u *walUsage) cut() {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	for userID, bytes := range u.current {
+		u.checkpointed[userID] += bytes
+	}
+	u.current = map[string]int64{}
+}
+
+// release is called once a checkpoint truncated the segments it covers.
+func (u *walUsage) release() {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	if u.tenantBytes != nil {
+		for userID := range u.checkpointed {
+			if bytes, ok := u.current[userID]; ok {
+				u.tenantBytes.WithLabelValues(userID).Set(float64(bytes))
+			} else {
+				u.tenantBytes.DeleteLabelValues(userID)
+			}
+		}
+	}
+	u.checkpointed = map[string]int64{}
+}
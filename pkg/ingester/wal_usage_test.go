@@ -0,0 +1,53 @@
+package ingester
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func TestWALUsage(t *testing.T) {
+	metrics := newIngesterMetrics(nil)
+	u := newWALUsage(metrics.walTenantBytes)
+
+	u.add("a", 10)
+	u.add("b", 5)
+	u.cut()
+	u.add("a", 3)
+	require.Equal(t, int64(13), u.bytes("a"))
+	require.Equal(t, int64(5), u.bytes("b"))
+
+	// A checkpoint failing to truncate its segments keeps their bytes until the next one.
+	u.cut()
+	u.add("a", 1)
+	require.Equal(t, int64(14), u.bytes("a"))
+
+	u.release()
+	require.Equal(t, int64(1), u.bytes("a"))
+	require.Equal(t, int64(0), u.bytes("b"))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.walTenantBytes.WithLabelValues("a")))
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.walTenantBytes))
+}
+
+func TestWALUsage_ReleasedByCheckpoints(t *testing.T) {
+	cfg := WALConfig{Enabled: true, Dir: t.TempDir(), CheckpointDuration: 1}
+	w, err := newWAL(cfg, nil, newIngesterMetrics(nil), nil)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, w.Stop()) }()
+
+	record := &WALRecord{UserID: "a", RefEntries: []RefEntries{{Ref: chunks.HeadSeriesRef(1), Entries: []logproto.Entry{{Line: "line"}}}}}
+	require.NoError(t, w.Log(record))
+	require.Greater(t, w.TenantBytes("a"), int64(0))
+	require.Equal(t, int64(0), w.TenantBytes("b"))
+
+	writer := w.(*walWrapper).checkpointWriter()
+	_, err = writer.Advance()
+	require.NoError(t, err)
+	require.Greater(t, w.TenantBytes("a"), int64(0))
+	require.NoError(t, writer.Close(false))
+	require.Equal(t, int64(0), w.TenantBytes("a"))
+}
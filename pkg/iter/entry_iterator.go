@@ -491,6 +491,64 @@ func (i *timeRangedIterator) Next() bool {
 	return ok
 }
 
+type dedupIterator struct {
+	EntryIterator
+	tolerance time.Duration
+
+	// last timestamp returned for each labels and line, and the returned entries
+	// in iteration order to forget them once out of the tolerance window.
+	seen     map[string]time.Time
+	returned []entryWithLabels
+}
+
+// NewDedupIterator returns an iterator which skips entries having the same labels and line as an
+// entry returned less than tolerance apart. It works with iterators going in either direction.
+func NewDedupIterator(it EntryIterator, tolerance time.Duration) EntryIterator {
+	return &dedupIterator{
+		EntryIterator: it,
+		tolerance:     tolerance,
+		seen:          map[string]time.Time{},
+	}
+}
+
+func (i *dedupIterator) Next() bool {
+	for i.EntryIterator.Next() {
+		entry, labels := i.EntryIterator.Entry(), i.EntryIterator.Labels()
+		i.forget(entry.Timestamp)
+		key := labels + "\xff" + entry.Line
+		if _, ok := i.seen[key]; ok {
+			continue
+		}
+		i.seen[key] = entry.Timestamp
+		i.returned = append(i.returned, entryWithLabels{entry: entry, labels: labels})
+		return true
+	}
+	return false
+}
+
+// forget removes the returned entries which are out of the tolerance window of ts.
+func (i *dedupIterator) forget(ts time.Time) {
+	n := 0
+	for _, e := range i.returned {
+		if absDuration(ts.Sub(e.entry.Timestamp)) <= i.tolerance {
+			break
+		}
+		key := e.labels + "\xff" + e.entry.Line
+		if i.seen[key].Equal(e.entry.Timestamp) {
+			delete(i.seen, key)
+		}
+		n++
+	}
+	i.returned = i.returned[n:]
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
 type entryWithLabels struct {
 	entry  logproto.Entry
 	labels string
@@ -607,6 +607,75 @@ func Test_timeRangedIterator_Next(t *testing.T) {
 	}
 }
 
+func Test_DedupIterator(t *testing.T) {
+	streams := []logproto.Stream{
+		{
+			Labels: `{app="foo"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "a"},
+				{Timestamp: time.Unix(2, 0), Line: "b"},
+				{Timestamp: time.Unix(10, 0), Line: "a"},
+			},
+		},
+		{
+			// the same lines shipped twice, a bit later.
+			Labels: `{app="foo"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(3, 0), Line: "a"},
+				{Timestamp: time.Unix(4, 0), Line: "b"},
+				{Timestamp: time.Unix(20, 0), Line: "a"},
+			},
+		},
+		{
+			Labels: `{app="bar"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(3, 0), Line: "a"},
+			},
+		},
+	}
+
+	for _, direction := range []logproto.Direction{logproto.FORWARD, logproto.BACKWARD} {
+		t.Run(direction.String(), func(t *testing.T) {
+			input := streams
+			if direction == logproto.BACKWARD {
+				input = make([]logproto.Stream, 0, len(streams))
+				for _, s := range streams {
+					entries := make([]logproto.Entry, 0, len(s.Entries))
+					for i := len(s.Entries) - 1; i >= 0; i-- {
+						entries = append(entries, s.Entries[i])
+					}
+					input = append(input, logproto.Stream{Labels: s.Labels, Entries: entries})
+				}
+			}
+			it := NewDedupIterator(NewStreamsIterator(context.Background(), input, direction), 5*time.Second)
+			var got []string
+			for it.Next() {
+				got = append(got, fmt.Sprintf("%s %d %s", it.Labels(), it.Entry().Timestamp.Unix(), it.Entry().Line))
+			}
+			require.NoError(t, it.Error())
+			require.NoError(t, it.Close())
+
+			expected := []string{
+				`{app="foo"} 1 a`,
+				`{app="foo"} 2 b`,
+				`{app="bar"} 3 a`,
+				`{app="foo"} 10 a`,
+				`{app="foo"} 20 a`,
+			}
+			if direction == logproto.BACKWARD {
+				expected = []string{
+					`{app="foo"} 20 a`,
+					`{app="foo"} 10 a`,
+					`{app="foo"} 4 b`,
+					`{app="bar"} 3 a`,
+					`{app="foo"} 3 a`,
+				}
+			}
+			require.Equal(t, expected, got)
+		})
+	}
+}
+
 type CloseTestingIterator struct {
 	closed atomic.Bool
 	e      logproto.Entry
@@ -302,6 +302,8 @@ func (c *DefaultClient) wsConnect(path, query string, quiet bool) (*websocket.Co
 
 	ws := websocket.Dialer{
 		TLSClientConfig: tlsConfig,
+		// Compression is only used when the server accepts it.
+		EnableCompression: true,
 	}
 
 	conn, resp, err := ws.Dial(us, h)
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/loki"
+	"github.com/grafana/loki/pkg/storage"
+	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/util/cfg"
+	"github.com/grafana/loki/pkg/util/marshal"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// storeOrgID is the tenant of the logs when authentication is disabled in the Loki configuration.
+const storeOrgID = "fake"
+
+// StoreClient is a type of LogCLI client that runs queries in-process against the storage configured in a Loki
+// configuration file, instead of sending them to Loki servers. Only the logs flushed to the storage are queried,
+// the ingesters are not.
+type StoreClient struct {
+	orgID  string
+	store  storage.Store
+	engine *logql.Engine
+}
+
+// NewStoreClient returns a StoreClient for the storage configured in the given Loki configuration file. The
+// tenant is required when authentication is enabled in the configuration.
+func NewStoreClient(configFile string, orgID string) (*StoreClient, error) {
+	if configFile == "" {
+		return nil, errors.New("no supplied config file")
+	}
+	var conf loki.Config
+	// The flags are only registered for their defaults.
+	conf.RegisterFlags(flag.NewFlagSet("store", flag.ContinueOnError))
+	if err := cfg.YAML(configFile, false)(&conf); err != nil {
+		return nil, err
+	}
+	if orgID == "" {
+		if conf.AuthEnabled {
+			return nil, errors.New("the org ID is required when authentication is enabled")
+		}
+		orgID = storeOrgID
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	storage.RegisterCustomIndexClients(&conf.StorageConfig, prometheus.DefaultRegisterer)
+	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
+	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, prometheus.DefaultRegisterer, nil, util_log.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, prometheus.DefaultRegisterer)
+	if err != nil {
+		chunkStore.Stop()
+		return nil, err
+	}
+
+	return &StoreClient{
+		orgID:  orgID,
+		store:  store,
+		engine: logql.NewEngine(conf.Querier.Engine, store, limits),
+	}, nil
+}
+
+func (s *StoreClient) context() context.Context {
+	return user.InjectOrgID(context.Background(), s.orgID)
+}
+
+func (s *StoreClient) Query(queryStr string, limit int, t time.Time, direction logproto.Direction, quiet bool) (*loghttp.QueryResponse, error) {
+	return s.QueryRange(queryStr, limit, t, t, direction, 0, 0, quiet)
+}
+
+func (s *StoreClient) QueryRange(queryStr string, limit int, start, end time.Time, direction logproto.Direction, step, interval time.Duration, quiet bool) (*loghttp.QueryResponse, error) {
+	params := logql.NewLiteralParams(
+		queryStr,
+		start,
+		end,
+		step,
+		interval,
+		direction,
+		uint32(limit),
+		nil,
+	)
+
+	result, err := s.engine.Query(params).Exec(s.context())
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := marshal.NewResultValue(result.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loghttp.QueryResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data: loghttp.QueryResponseData{
+			ResultType: value.Type(),
+			Result:     value,
+			Statistics: result.Statistics,
+		},
+	}, nil
+}
+
+func (s *StoreClient) ListLabelNames(quiet bool, start, end time.Time) (*loghttp.LabelResponse, error) {
+	names, err := s.store.LabelNamesForMetricName(s.context(), s.orgID, model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano()), "logs")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return &loghttp.LabelResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   names,
+	}, nil
+}
+
+func (s *StoreClient) ListLabelValues(name string, quiet bool, start, end time.Time) (*loghttp.LabelResponse, error) {
+	values, err := s.store.LabelValuesForMetricName(s.context(), s.orgID, model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano()), "logs", name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(values)
+	return &loghttp.LabelResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   values,
+	}, nil
+}
+
+func (s *StoreClient) Series(matchers []string, start, end time.Time, quiet bool) (*loghttp.SeriesResponse, error) {
+	// Without matchers, all the series are returned.
+	if len(matchers) == 0 {
+		matchers = []string{""}
+	}
+
+	seen := map[string]struct{}{}
+	var series []loghttp.LabelSet
+	for _, matcher := range matchers {
+		ids, err := s.store.GetSeries(s.context(), logql.SelectLogParams{
+			QueryRequest: &logproto.QueryRequest{
+				Selector:  matcher,
+				Start:     start,
+				End:       end,
+				Direction: logproto.FORWARD,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			lbls := loghttp.LabelSet(id.Labels)
+			// Series of different matchers can overlap.
+			if _, ok := seen[lbls.String()]; ok {
+				continue
+			}
+			seen[lbls.String()] = struct{}{}
+			series = append(series, lbls)
+		}
+	}
+
+	return &loghttp.SeriesResponse{
+		Status: loghttp.QueryStatusSuccess,
+		Data:   series,
+	}, nil
+}
+
+func (s *StoreClient) LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error) {
+	return nil, fmt.Errorf("LiveTailQuery: %w", ErrNotSupported)
+}
+
+func (s *StoreClient) GetOrgID() string {
+	return s.orgID
+}
+
+// Stop stops the store.
+func (s *StoreClient) Stop() {
+	s.store.Stop()
+}
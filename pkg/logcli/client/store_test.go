@@ -0,0 +1,19 @@
+package client
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStoreClient_OrgID(t *testing.T) {
+	_, err := NewStoreClient("", "")
+	require.EqualError(t, err, "no supplied config file")
+
+	configFile := filepath.Join(t.TempDir(), "loki.yaml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte("auth_enabled: true\n"), 0o600))
+	_, err = NewStoreClient(configFile, "")
+	require.EqualError(t, err, "the org ID is required when authentication is enabled")
+}
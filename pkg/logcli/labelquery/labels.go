@@ -11,10 +11,11 @@ import (
 
 // LabelQuery contains all necessary fields to execute label queries and print out the results
 type LabelQuery struct {
-	LabelName string
-	Quiet     bool
-	Start     time.Time
-	End       time.Time
+	LabelName   string
+	Quiet       bool
+	Start       time.Time
+	End         time.Time
+	LocalConfig string
 }
 
 // DoLabels prints out label results
@@ -28,6 +29,15 @@ func (q *LabelQuery) DoLabels(c client.Client) {
 
 // ListLabels returns an array of label strings
 func (q *LabelQuery) ListLabels(c client.Client) []string {
+	if q.LocalConfig != "" {
+		storeClient, err := client.NewStoreClient(q.LocalConfig, c.GetOrgID())
+		if err != nil {
+			log.Fatalf("Unable to load the store: %+v", err)
+		}
+		defer storeClient.Stop()
+		c = storeClient
+	}
+
 	var labelResponse *loghttp.LabelResponse
 	var err error
 	if len(q.LabelName) > 0 {
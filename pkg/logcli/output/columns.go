@@ -0,0 +1,42 @@
+package output
+
+import (
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// Columns with a special meaning in the csv and parquet output modes. Any other column is the value of the label of
+// the same name.
+const (
+	ColumnTimestamp = "timestamp"
+	ColumnLabels    = "labels"
+	ColumnLine      = "line"
+)
+
+// columns returns the columns of the options, defaulting to the timestamp, the labels when they are not disabled,
+// and the line.
+func columns(options *LogOutputOptions) []string {
+	if len(options.Columns) > 0 {
+		return options.Columns
+	}
+	if options.NoLabels {
+		return []string{ColumnTimestamp, ColumnLine}
+	}
+	return []string{ColumnTimestamp, ColumnLabels, ColumnLine}
+}
+
+// columnValue returns the value of a column of an entry, and false if the column is a label the entry doesn't have.
+func columnValue(column string, ts time.Time, lbls loghttp.LabelSet, line string, timezone *time.Location) (string, bool) {
+	switch column {
+	case ColumnTimestamp:
+		return ts.In(timezone).Format(time.RFC3339Nano), true
+	case ColumnLabels:
+		return lbls.String(), true
+	case ColumnLine:
+		return line, true
+	default:
+		value, ok := lbls[column]
+		return value, ok
+	}
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// CSVOutput prints logs as CSV records of the selected columns, preceded by a header
+type CSVOutput struct {
+	w       *csv.Writer
+	options *LogOutputOptions
+	columns []string
+	record  []string
+	header  bool
+}
+
+func NewCSV(writer io.Writer, options *LogOutputOptions) ExportOutput {
+	columns := columns(options)
+	return &CSVOutput{
+		w:       csv.NewWriter(writer),
+		options: options,
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+}
+
+// Format a log entry as a CSV record. Labels missing from the entry are empty
+func (o *CSVOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	o.writeHeader()
+	for i, column := range o.columns {
+		o.record[i], _ = columnValue(column, ts, lbls, line, o.options.Timezone)
+	}
+	if err := o.w.Write(o.record); err != nil {
+		log.Fatalf("error writing record: %s", err)
+	}
+	// Records are flushed one by one so that tailed entries are printed as they are received.
+	o.w.Flush()
+}
+
+func (o *CSVOutput) writeHeader() {
+	if o.header {
+		return
+	}
+	o.header = true
+	if err := o.w.Write(o.columns); err != nil {
+		log.Fatalf("error writing header: %s", err)
+	}
+}
+
+// Close prints the header if no entries were printed
+func (o *CSVOutput) Close() error {
+	o.writeHeader()
+	o.w.Flush()
+	return o.w.Error()
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestCSVOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type":  "test",
+		"level": "info",
+	})
+
+	tests := map[string]struct {
+		options  *LogOutputOptions
+		entries  int
+		expected string
+	}{
+		"default columns": {
+			&LogOutputOptions{Timezone: time.UTC},
+			2,
+			"timestamp,labels,line\n" +
+				`2006-01-02T08:04:05Z,"{level=""info"", type=""test""}","Hello, ""world"""` + "\n" +
+				`2006-01-02T08:04:05Z,"{level=""info"", type=""test""}","Hello, ""world"""` + "\n",
+		},
+		"default columns without labels": {
+			&LogOutputOptions{Timezone: time.UTC, NoLabels: true},
+			1,
+			"timestamp,line\n" +
+				`2006-01-02T08:04:05Z,"Hello, ""world"""` + "\n",
+		},
+		"selected columns": {
+			&LogOutputOptions{Timezone: time.FixedZone("test", 2*60*60), Columns: []string{"level", "missing", "timestamp"}},
+			1,
+			"level,missing,timestamp\n" +
+				"info,,2006-01-02T10:04:05+02:00\n",
+		},
+		"header without entries": {
+			&LogOutputOptions{Timezone: time.UTC},
+			0,
+			"timestamp,labels,line\n",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			writer := &bytes.Buffer{}
+			out := NewCSV(writer, testData.options)
+			for i := 0; i < testData.entries; i++ {
+				out.FormatAndPrintln(timestamp, someLabels, 0, `Hello, "world"`)
+			}
+			assert.NoError(t, out.Close())
+
+			assert.Equal(t, testData.expected, writer.String())
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"io"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
@@ -31,11 +32,23 @@ type LogOutput interface {
 	FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string)
 }
 
+// ExportOutput is implemented by the output modes exporting the entries to other tools. They are given all the
+// labels of the entries, including the labels common to all the streams, and must be closed once all the entries
+// are printed.
+type ExportOutput interface {
+	LogOutput
+	Close() error
+}
+
 // LogOutputOptions defines options supported by LogOutput
 type LogOutputOptions struct {
 	Timezone      *time.Location
 	NoLabels      bool
 	ColoredOutput bool
+	// Columns are the columns of the csv and parquet output modes.
+	Columns []string
+	// Template is the Go template of the template output mode.
+	Template string
 }
 
 // NewLogOutput creates a log output based on the input mode and options
@@ -60,6 +73,21 @@ func NewLogOutput(w io.Writer, mode string, options *LogOutputOptions) (LogOutpu
 			w:       w,
 			options: options,
 		}, nil
+	case "csv":
+		return NewCSV(w, options), nil
+	case "parquet":
+		return NewParquet(w, options), nil
+	case "template":
+		// Labels missing from an entry are empty.
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(options.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %w", err)
+		}
+		return &TemplateOutput{
+			w:        w,
+			options:  options,
+			template: tmpl,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unknown log output mode '%s'", mode)
 	}
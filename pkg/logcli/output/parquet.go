@@ -0,0 +1,402 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// The subset of the Parquet format written by the parquet output mode: all the values are PLAIN encoded and
+// uncompressed, in a single data page per column chunk. See https://github.com/apache/parquet-format.
+const (
+	parquetMagic = "PAR1"
+
+	// defaultParquetRowGroupSize is the size of the values buffered before they are written as a row group.
+	defaultParquetRowGroupSize = 8 << 20
+
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetRepetitionRequired = 0
+	parquetRepetitionOptional = 1
+
+	parquetConvertedTypeUTF8 = 0
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetPageTypeData = 0
+)
+
+type parquetColumn struct {
+	name     string
+	optional bool
+	// values are the PLAIN encoded values of the current row group.
+	values bytes.Buffer
+	// defined are the definition levels of the values of an optional column.
+	defined []bool
+}
+
+// ParquetOutput writes logs as a Parquet file whose columns are the selected columns. The timestamp column is a
+// timestamp in nanoseconds, the other columns are strings, and the columns of the labels are null for the entries
+// without the label. Entries are written as row groups as they are printed, and the file is complete once closed.
+type ParquetOutput struct {
+	w            io.Writer
+	options      *LogOutputOptions
+	columns      []*parquetColumn
+	rowGroupSize int
+	offset       int64
+	rows         int64
+	size         int
+	rowGroups    [][]parquetColumnChunk
+	groupRows    []int64
+}
+
+// parquetColumnChunk is the location of a column chunk written in the file.
+type parquetColumnChunk struct {
+	numValues int64
+	offset    int64
+	size      int64
+}
+
+func NewParquet(writer io.Writer, options *LogOutputOptions) ExportOutput {
+	o := &ParquetOutput{
+		w:            writer,
+		options:      options,
+		rowGroupSize: defaultParquetRowGroupSize,
+	}
+	for _, name := range columns(options) {
+		o.columns = append(o.columns, &parquetColumn{
+			name:     name,
+			optional: name != ColumnTimestamp && name != ColumnLabels && name != ColumnLine,
+		})
+	}
+	return o
+}
+
+// Format a log entry as a row of the current row group
+func (o *ParquetOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	for _, c := range o.columns {
+		if c.name == ColumnTimestamp {
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], uint64(ts.UnixNano()))
+			c.values.Write(b[:])
+			o.size += len(b)
+			continue
+		}
+		value, ok := columnValue(c.name, ts, lbls, line, o.options.Timezone)
+		if c.optional {
+			c.defined = append(c.defined, ok)
+		}
+		if !ok {
+			continue
+		}
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(len(value)))
+		c.values.Write(b[:])
+		c.values.WriteString(value)
+		o.size += len(b) + len(value)
+	}
+	o.rows++
+
+	if o.size >= o.rowGroupSize {
+		if err := o.flush(); err != nil {
+			log.Fatalf("error writing row group: %s", err)
+		}
+	}
+}
+
+func (o *ParquetOutput) write(b []byte) error {
+	n, err := o.w.Write(b)
+	o.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as a row group.
+func (o *ParquetOutput) flush() error {
+	if o.offset == 0 {
+		if err := o.write([]byte(parquetMagic)); err != nil {
+			return err
+		}
+	}
+	if o.rows == 0 {
+		return nil
+	}
+
+	chunks := make([]parquetColumnChunk, 0, len(o.columns))
+	for _, c := range o.columns {
+		var page bytes.Buffer
+		if c.optional {
+			levels := encodeDefinitionLevels(c.defined)
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], uint32(len(levels)))
+			page.Write(b[:])
+			page.Write(levels)
+		}
+		page.Write(c.values.Bytes())
+
+		header := thriftCompactWriter{}
+		header.beginStruct()
+		header.i32Field(1, parquetPageTypeData)
+		header.i32Field(2, int32(page.Len()))
+		header.i32Field(3, int32(page.Len()))
+		header.structField(5, func() {
+			header.i32Field(1, int32(o.rows))
+			header.i32Field(2, parquetEncodingPlain)
+			header.i32Field(3, parquetEncodingRLE)
+			header.i32Field(4, parquetEncodingRLE)
+		})
+		header.endStruct()
+
+		chunk := parquetColumnChunk{numValues: o.rows, offset: o.offset, size: int64(header.buf.Len() + page.Len())}
+		if err := o.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := o.write(page.Bytes()); err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		c.values.Reset()
+		c.defined = c.defined[:0]
+	}
+
+	o.rowGroups = append(o.rowGroups, chunks)
+	o.groupRows = append(o.groupRows, o.rows)
+	o.rows = 0
+	o.size = 0
+	return nil
+}
+
+// Close writes the buffered rows and the footer of the file
+func (o *ParquetOutput) Close() error {
+	if err := o.flush(); err != nil {
+		return err
+	}
+
+	var numRows int64
+	for _, rows := range o.groupRows {
+		numRows += rows
+	}
+
+	footer := thriftCompactWriter{}
+	footer.beginStruct()
+	footer.i32Field(1, 1)
+	footer.listField(2, thriftTypeStruct, len(o.columns)+1)
+	footer.listStruct(func() {
+		footer.stringField(4, "schema")
+		footer.i32Field(5, int32(len(o.columns)))
+	})
+	for _, c := range o.columns {
+		c := c
+		footer.listStruct(func() {
+			if c.name == ColumnTimestamp {
+				footer.i32Field(1, parquetTypeInt64)
+				footer.i32Field(3, parquetRepetitionRequired)
+				footer.stringField(4, c.name)
+				// TIMESTAMP logical type in nanoseconds, adjusted to UTC.
+				footer.structField(10, func() {
+					footer.structField(8, func() {
+						footer.boolField(1, true)
+						footer.structField(2, func() {
+							footer.structField(3, func() {})
+						})
+					})
+				})
+				return
+			}
+			footer.i32Field(1, parquetTypeByteArray)
+			if c.optional {
+				footer.i32Field(3, parquetRepetitionOptional)
+			} else {
+				footer.i32Field(3, parquetRepetitionRequired)
+			}
+			footer.stringField(4, c.name)
+			footer.i32Field(6, parquetConvertedTypeUTF8)
+			// STRING logical type.
+			footer.structField(10, func() {
+				footer.structField(1, func() {})
+			})
+		})
+	}
+	footer.i64Field(3, numRows)
+	footer.listField(4, thriftTypeStruct, len(o.rowGroups))
+	for i, chunks := range o.rowGroups {
+		var totalSize int64
+		for _, chunk := range chunks {
+			totalSize += chunk.size
+		}
+		footer.listStruct(func() {
+			footer.listField(1, thriftTypeStruct, len(chunks))
+			for j, chunk := range chunks {
+				c, chunk := o.columns[j], chunk
+				footer.listStruct(func() {
+					footer.i64Field(2, chunk.offset)
+					footer.structField(3, func() {
+						if c.name == ColumnTimestamp {
+							footer.i32Field(1, parquetTypeInt64)
+						} else {
+							footer.i32Field(1, parquetTypeByteArray)
+						}
+						footer.listField(2, thriftTypeI32, 2)
+						footer.i32(parquetEncodingPlain)
+						footer.i32(parquetEncodingRLE)
+						footer.listField(3, thriftTypeBinary, 1)
+						footer.string(c.name)
+						footer.i32Field(4, 0) // UNCOMPRESSED
+						footer.i64Field(5, chunk.numValues)
+						footer.i64Field(6, chunk.size)
+						footer.i64Field(7, chunk.size)
+						footer.i64Field(9, chunk.offset)
+					})
+				})
+			}
+			footer.i64Field(2, totalSize)
+			footer.i64Field(3, o.groupRows[i])
+		})
+	}
+	footer.stringField(6, "logcli")
+	footer.endStruct()
+
+	if err := o.write(footer.buf.Bytes()); err != nil {
+		return err
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(footer.buf.Len()))
+	if err := o.write(b[:]); err != nil {
+		return err
+	}
+	return o.write([]byte(parquetMagic))
+}
+
+// encodeDefinitionLevels encodes definition levels of bit width 1 as runs of the RLE/bit-packing hybrid encoding.
+func encodeDefinitionLevels(defined []bool) []byte {
+	var (
+		buf    []byte
+		header [binary.MaxVarintLen64]byte
+	)
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		buf = append(buf, header[:binary.PutUvarint(header[:], uint64(j-i)<<1)]...)
+		if defined[i] {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+		i = j
+	}
+	return buf
+}
+
+// Types of the Thrift compact protocol.
+const (
+	thriftTypeStop      = 0
+	thriftTypeBoolTrue  = 1
+	thriftTypeBoolFalse = 2
+	thriftTypeI32       = 5
+	thriftTypeI64       = 6
+	thriftTypeBinary    = 8
+	thriftTypeList      = 9
+	thriftTypeStruct    = 12
+)
+
+// thriftCompactWriter encodes the Thrift structures of the Parquet metadata with the Thrift compact protocol.
+type thriftCompactWriter struct {
+	buf bytes.Buffer
+	// lastField is the ID of the last field of the current struct, and fields the IDs of the enclosing structs.
+	lastField int16
+	fields    []int16
+}
+
+func (w *thriftCompactWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (w *thriftCompactWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftCompactWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.lastField; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	w.lastField = id
+}
+
+func (w *thriftCompactWriter) beginStruct() {
+	w.fields = append(w.fields, w.lastField)
+	w.lastField = 0
+}
+
+func (w *thriftCompactWriter) endStruct() {
+	w.buf.WriteByte(thriftTypeStop)
+	w.lastField = w.fields[len(w.fields)-1]
+	w.fields = w.fields[:len(w.fields)-1]
+}
+
+func (w *thriftCompactWriter) i32(v int32) {
+	w.zigzag(int64(v))
+}
+
+func (w *thriftCompactWriter) string(s string) {
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *thriftCompactWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftTypeI32)
+	w.i32(v)
+}
+
+func (w *thriftCompactWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftTypeI64)
+	w.zigzag(v)
+}
+
+func (w *thriftCompactWriter) boolField(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, thriftTypeBoolTrue)
+	} else {
+		w.fieldHeader(id, thriftTypeBoolFalse)
+	}
+}
+
+func (w *thriftCompactWriter) stringField(id int16, s string) {
+	w.fieldHeader(id, thriftTypeBinary)
+	w.string(s)
+}
+
+func (w *thriftCompactWriter) structField(id int16, fields func()) {
+	w.fieldHeader(id, thriftTypeStruct)
+	w.beginStruct()
+	fields()
+	w.endStruct()
+}
+
+// listField writes the header of a list field, whose elements must be written next.
+func (w *thriftCompactWriter) listField(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elemType)
+	w.varint(uint64(size))
+}
+
+// listStruct writes a struct element of a list.
+func (w *thriftCompactWriter) listStruct(fields func()) {
+	w.beginStruct()
+	fields()
+	w.endStruct()
+}
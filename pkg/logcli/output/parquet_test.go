@@ -0,0 +1,309 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// readThriftStruct decodes a struct of the Thrift compact protocol as a map of its fields by ID.
+func readThriftStruct(t *testing.T, r *bytes.Reader) map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		b, err := r.ReadByte()
+		require.NoError(t, err)
+		if b == thriftTypeStop {
+			return fields
+		}
+		typ := b & 0x0f
+		if delta := int16(b >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(readZigzag(t, r))
+		}
+		fields[last] = readThriftValue(t, r, typ)
+	}
+}
+
+func readThriftValue(t *testing.T, r *bytes.Reader, typ byte) interface{} {
+	switch typ {
+	case thriftTypeBoolTrue:
+		return true
+	case thriftTypeBoolFalse:
+		return false
+	case thriftTypeI32, thriftTypeI64:
+		return readZigzag(t, r)
+	case thriftTypeBinary:
+		n, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		b := make([]byte, n)
+		_, err = r.Read(b)
+		require.NoError(t, err)
+		return string(b)
+	case thriftTypeList:
+		header, err := r.ReadByte()
+		require.NoError(t, err)
+		size := uint64(header >> 4)
+		if size == 15 {
+			size, err = binary.ReadUvarint(r)
+			require.NoError(t, err)
+		}
+		list := make([]interface{}, 0, size)
+		for i := uint64(0); i < size; i++ {
+			list = append(list, readThriftValue(t, r, header&0x0f))
+		}
+		return list
+	case thriftTypeStruct:
+		return readThriftStruct(t, r)
+	}
+	t.Fatalf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func readZigzag(t *testing.T, r *bytes.Reader) int64 {
+	v, err := binary.ReadUvarint(r)
+	require.NoError(t, err)
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func TestParquetOutput_Format(t *testing.T) {
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+
+	writer := &bytes.Buffer{}
+	out := NewParquet(writer, &LogOutputOptions{Timezone: time.UTC, Columns: []string{"timestamp", "level", "line"}})
+	out.FormatAndPrintln(timestamp, loghttp.LabelSet{"level": "info"}, 0, "Hello")
+	out.FormatAndPrintln(timestamp.Add(time.Second), loghttp.LabelSet{}, 0, "world")
+	require.NoError(t, out.Close())
+
+	file := writer.Bytes()
+	require.Equal(t, parquetMagic, string(file[:4]))
+	require.Equal(t, parquetMagic, string(file[len(file)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := readThriftStruct(t, bytes.NewReader(file[len(file)-8-footerLen:len(file)-8]))
+
+	require.Equal(t, int64(2), footer[3])
+	schema := footer[2].([]interface{})
+	require.Len(t, schema, 4)
+	require.Equal(t, int64(3), schema[0].(map[int16]interface{})[5])
+	for i, expected := range []struct {
+		name       string
+		typ        int64
+		repetition int64
+	}{
+		{"timestamp", parquetTypeInt64, parquetRepetitionRequired},
+		{"level", parquetTypeByteArray, parquetRepetitionOptional},
+		{"line", parquetTypeByteArray, parquetRepetitionRequired},
+	} {
+		element := schema[i+1].(map[int16]interface{})
+		require.Equal(t, expected.name, element[4])
+		require.Equal(t, expected.typ, element[1])
+		require.Equal(t, expected.repetition, element[3])
+	}
+
+	rowGroups := footer[4].([]interface{})
+	require.Len(t, rowGroups, 1)
+	rowGroup := rowGroups[0].(map[int16]interface{})
+	require.Equal(t, int64(2), rowGroup[3])
+	chunks := rowGroup[1].([]interface{})
+	require.Len(t, chunks, 3)
+
+	readPage := func(i int) []byte {
+		metadata := chunks[i].(map[int16]interface{})[3].(map[int16]interface{})
+		require.Equal(t, int64(2), metadata[5])
+		offset := metadata[9].(int64)
+		r := bytes.NewReader(file[offset : offset+metadata[7].(int64)])
+		header := readThriftStruct(t, r)
+		require.Equal(t, int64(2), header[5].(map[int16]interface{})[1])
+		page := make([]byte, header[3].(int64))
+		_, err := r.Read(page)
+		require.NoError(t, err)
+		require.Zero(t, r.Len())
+		return page
+	}
+
+	timestamps := readPage(0)
+	require.Len(t, timestamps, 16)
+	require.Equal(t, timestamp.UnixNano(), int64(binary.LittleEndian.Uint64(timestamps)))
+	require.Equal(t, timestamp.Add(time.Second).UnixNano(), int64(binary.LittleEndian.Uint64(timestamps[8:])))
+
+	// The definition levels of the level column are a run of one defined value followed by a run of one null.
+	require.Equal(t, []byte{4, 0, 0, 0, 2, 1, 2, 0, 4, 0, 0, 0, 'i', 'n', 'f', 'o'}, readPage(1))
+	require.Equal(t, []byte{5, 0, 0, 0, 'H', 'e', 'l', 'l', 'o', 5, 0, 0, 0, 'w', 'o', 'r', 'l', 'd'}, readPage(2))
+}
+
+// readParquet decodes a Parquet file from its metadata alone, following the format specification rather than the
+// writer, and returns the names of its columns and its rows, the null values being nil.
+func readParquet(t *testing.T, file []byte) ([]string, [][]interface{}) {
+	require.Equal(t, "PAR1", string(file[:4]))
+	require.Equal(t, "PAR1", string(file[len(file)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := readThriftStruct(t, bytes.NewReader(file[len(file)-8-footerLen:len(file)-8]))
+
+	// FileMetaData: 2 schema, 3 num_rows, 4 row_groups.
+	schema := footer[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	require.Equal(t, int64(len(schema)-1), root[5])
+	type column struct {
+		name     string
+		typ      int64
+		optional bool
+	}
+	var (
+		columns []column
+		names   []string
+	)
+	for _, e := range schema[1:] {
+		// SchemaElement: 1 type, 3 repetition_type, 4 name.
+		element := e.(map[int16]interface{})
+		columns = append(columns, column{name: element[4].(string), typ: element[1].(int64), optional: element[3] == int64(1)})
+		names = append(names, element[4].(string))
+	}
+
+	var rows [][]interface{}
+	for _, rg := range footer[4].([]interface{}) {
+		// RowGroup: 1 columns, 3 num_rows.
+		rowGroup := rg.(map[int16]interface{})
+		numRows := int(rowGroup[3].(int64))
+		groupRows := make([][]interface{}, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(columns))
+		}
+		chunks := rowGroup[1].([]interface{})
+		require.Len(t, chunks, len(columns))
+		for i, c := range chunks {
+			// ColumnMetaData: 1 type, 3 path_in_schema, 4 codec, 5 num_values, 7 total_compressed_size,
+			// 9 data_page_offset.
+			metadata := c.(map[int16]interface{})[3].(map[int16]interface{})
+			require.Equal(t, columns[i].typ, metadata[1])
+			require.Equal(t, []interface{}{columns[i].name}, metadata[3])
+			require.Equal(t, int64(0), metadata[4], "compressed column chunk")
+			require.Equal(t, int64(numRows), metadata[5])
+			offset := metadata[9].(int64)
+			r := bytes.NewReader(file[offset : offset+metadata[7].(int64)])
+
+			// PageHeader: 1 type, 3 compressed_page_size, 5 data_page_header, whose fields are 1 num_values,
+			// 2 encoding and 3 definition_level_encoding.
+			header := readThriftStruct(t, r)
+			require.Equal(t, int64(0), header[1], "not a data page")
+			dataPageHeader := header[5].(map[int16]interface{})
+			require.Equal(t, int64(numRows), dataPageHeader[1])
+			require.Equal(t, int64(0), dataPageHeader[2], "values not PLAIN encoded")
+			page := make([]byte, header[3].(int64))
+			_, err := r.Read(page)
+			require.NoError(t, err)
+			require.Zero(t, r.Len())
+
+			defined := make([]bool, numRows)
+			for j := range defined {
+				defined[j] = true
+			}
+			if columns[i].optional {
+				require.Equal(t, int64(3), dataPageHeader[3], "definition levels not RLE encoded")
+				n := binary.LittleEndian.Uint32(page)
+				defined = readDefinitionLevels(t, page[4:4+n], numRows)
+				page = page[4+n:]
+			}
+
+			values := bytes.NewReader(page)
+			for j := range groupRows {
+				if !defined[j] {
+					continue
+				}
+				switch columns[i].typ {
+				case 2: // INT64
+					var v int64
+					require.NoError(t, binary.Read(values, binary.LittleEndian, &v))
+					groupRows[j][i] = v
+				case 6: // BYTE_ARRAY
+					var n uint32
+					require.NoError(t, binary.Read(values, binary.LittleEndian, &n))
+					b := make([]byte, n)
+					_, err := values.Read(b)
+					require.NoError(t, err)
+					groupRows[j][i] = string(b)
+				default:
+					t.Fatalf("unexpected physical type %d", columns[i].typ)
+				}
+			}
+			require.Zero(t, values.Len())
+		}
+		rows = append(rows, groupRows...)
+	}
+	require.Equal(t, int64(len(rows)), footer[3])
+	return names, rows
+}
+
+// readDefinitionLevels decodes n definition levels of bit width 1 encoded with the RLE/bit-packing hybrid encoding.
+func readDefinitionLevels(t *testing.T, b []byte, n int) []bool {
+	r := bytes.NewReader(b)
+	var levels []bool
+	for len(levels) < n {
+		header, err := binary.ReadUvarint(r)
+		require.NoError(t, err)
+		if header&1 == 0 {
+			value, err := r.ReadByte()
+			require.NoError(t, err)
+			for i := uint64(0); i < header>>1; i++ {
+				levels = append(levels, value == 1)
+			}
+			continue
+		}
+		// Bit-packed run of groups of 8 values, each group taking one byte with a bit width of 1.
+		for i := uint64(0); i < header>>1; i++ {
+			packed, err := r.ReadByte()
+			require.NoError(t, err)
+			for bit := 0; bit < 8; bit++ {
+				levels = append(levels, packed&(1<<bit) != 0)
+			}
+		}
+	}
+	require.Zero(t, r.Len())
+	return levels[:n]
+}
+
+func TestParquetOutput_Read(t *testing.T) {
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+
+	for _, tc := range []struct {
+		name         string
+		entries      int
+		rowGroupSize int
+	}{
+		{"empty", 0, defaultParquetRowGroupSize},
+		{"single row group", 20, defaultParquetRowGroupSize},
+		// More than 15 row groups, whose list has a long header in the footer.
+		{"row group per entry", 20, 1},
+		{"several row groups", 20, 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			writer := &bytes.Buffer{}
+			out := NewParquet(writer, &LogOutputOptions{Timezone: time.UTC, Columns: []string{"timestamp", "level", "app", "line"}})
+			out.(*ParquetOutput).rowGroupSize = tc.rowGroupSize
+
+			var expected [][]interface{}
+			for i := 0; i < tc.entries; i++ {
+				ts := timestamp.Add(time.Duration(i) * time.Second)
+				lbls := loghttp.LabelSet{"app": "foo"}
+				row := []interface{}{ts.UnixNano(), nil, "foo", fmt.Sprintf("line %d", i)}
+				if i%3 == 0 {
+					lbls["level"] = "info"
+					row[1] = "info"
+				}
+				out.FormatAndPrintln(ts, lbls, 0, fmt.Sprintf("line %d", i))
+				expected = append(expected, row)
+			}
+			require.NoError(t, out.Close())
+
+			names, rows := readParquet(t, writer.Bytes())
+			require.Equal(t, []string{"timestamp", "level", "app", "line"}, names)
+			require.Equal(t, expected, rows)
+		})
+	}
+}
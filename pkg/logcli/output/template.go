@@ -0,0 +1,44 @@
+package output
+
+import (
+	"io"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// TemplateEntry is the data of the template of the template output mode
+type TemplateEntry struct {
+	Timestamp time.Time
+	Labels    loghttp.LabelSet
+	Line      string
+}
+
+// TemplateOutput prints logs formatted by a Go template, followed by a new line
+type TemplateOutput struct {
+	w        io.Writer
+	options  *LogOutputOptions
+	template *template.Template
+}
+
+// Format a log entry with the template
+func (o *TemplateOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	entry := TemplateEntry{
+		Timestamp: ts.In(o.options.Timezone),
+		Labels:    lbls,
+		Line:      line,
+	}
+	if err := o.template.Execute(o.w, entry); err != nil {
+		log.Fatalf("error executing output template: %s", err)
+	}
+	if _, err := io.WriteString(o.w, "\n"); err != nil {
+		log.Fatalf("error writing entry: %s", err)
+	}
+}
+
+// Close does nothing, entries are printed as they are formatted
+func (o *TemplateOutput) Close() error {
+	return nil
+}
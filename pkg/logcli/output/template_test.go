@@ -0,0 +1,33 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func TestTemplateOutput_Format(t *testing.T) {
+	t.Parallel()
+
+	timestamp, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05+07:00")
+	someLabels := loghttp.LabelSet(map[string]string{
+		"type":  "test",
+		"level": "info",
+	})
+
+	writer := &bytes.Buffer{}
+	out, err := NewLogOutput(writer, "template", &LogOutputOptions{
+		Timezone: time.UTC,
+		Template: `{{.Timestamp.Format "15:04:05"}} [{{.Labels.level}}] {{.Line}}{{with .Labels.missing}} {{.}}{{end}}`,
+	})
+	require.NoError(t, err)
+
+	out.FormatAndPrintln(timestamp, someLabels, 0, "Hello")
+	out.FormatAndPrintln(timestamp, loghttp.LabelSet{}, 0, "world")
+	assert.Equal(t, "08:04:05 [info] Hello\n08:04:05 [] world\n", writer.String())
+}
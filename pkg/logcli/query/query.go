@@ -1,9 +1,6 @@
 package query
 
 import (
-	"context"
-	"errors"
-	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -12,26 +9,15 @@ import (
 	"text/tabwriter"
 	"time"
 
-	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/fatih/color"
 	json "github.com/json-iterator/go"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
-	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
-	"github.com/grafana/loki/pkg/loki"
-	"github.com/grafana/loki/pkg/storage"
-	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
-	"github.com/grafana/loki/pkg/storage/stores/shipper"
-	"github.com/grafana/loki/pkg/util/cfg"
-	"github.com/grafana/loki/pkg/util/marshal"
-	"github.com/grafana/loki/pkg/validation"
 )
 
 type streamEntryPair struct {
@@ -56,15 +42,22 @@ type Query struct {
 	FixedLabelsLen  int
 	ColoredOutput   bool
 	LocalConfig     string
+
+	// TailMaxReconnects is the maximum number of consecutive reconnections of a tail.
+	TailMaxReconnects int
+	// TailClientPipeline applies the pipeline of the query to the tailed entries instead of the server.
+	TailClientPipeline bool
 }
 
 // DoQuery executes the query and prints out the results
 func (q *Query) DoQuery(c client.Client, out output.LogOutput, statistics bool) {
 	if q.LocalConfig != "" {
-		if err := q.DoLocalQuery(out, statistics, c.GetOrgID()); err != nil {
-			log.Fatalf("Query failed: %+v", err)
+		storeClient, err := client.NewStoreClient(q.LocalConfig, c.GetOrgID())
+		if err != nil {
+			log.Fatalf("Unable to load the store: %+v", err)
 		}
-		return
+		defer storeClient.Stop()
+		c = storeClient
 	}
 
 	d := q.resultsDirection()
@@ -170,84 +163,6 @@ func (q *Query) printResult(value loghttp.ResultValue, out output.LogOutput, las
 	return length, entry
 }
 
-// DoLocalQuery executes the query against the local store using a Loki configuration file.
-func (q *Query) DoLocalQuery(out output.LogOutput, statistics bool, orgID string) error {
-	var conf loki.Config
-	conf.RegisterFlags(flag.CommandLine)
-	if q.LocalConfig == "" {
-		return errors.New("no supplied config file")
-	}
-	if err := cfg.YAML(q.LocalConfig, false)(&conf); err != nil {
-		return err
-	}
-
-	if err := conf.Validate(); err != nil {
-		return err
-	}
-
-	limits, err := validation.NewOverrides(conf.LimitsConfig, nil)
-	if err != nil {
-		return err
-	}
-	storage.RegisterCustomIndexClients(&conf.StorageConfig, prometheus.DefaultRegisterer)
-	conf.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
-	chunkStore, err := chunk_storage.NewStore(conf.StorageConfig.Config, conf.ChunkStoreConfig.StoreConfig, conf.SchemaConfig.SchemaConfig, limits, prometheus.DefaultRegisterer, nil, util_log.Logger)
-	if err != nil {
-		return err
-	}
-
-	querier, err := storage.NewStore(conf.StorageConfig, conf.SchemaConfig, chunkStore, prometheus.DefaultRegisterer)
-	if err != nil {
-		return err
-	}
-
-	eng := logql.NewEngine(conf.Querier.Engine, querier, limits)
-	var query logql.Query
-
-	if q.isInstant() {
-		query = eng.Query(logql.NewLiteralParams(
-			q.QueryString,
-			q.Start,
-			q.Start,
-			0,
-			0,
-			q.resultsDirection(),
-			uint32(q.Limit),
-			nil,
-		))
-	} else {
-		query = eng.Query(logql.NewLiteralParams(
-			q.QueryString,
-			q.Start,
-			q.End,
-			q.Step,
-			q.Interval,
-			q.resultsDirection(),
-			uint32(q.Limit),
-			nil,
-		))
-	}
-
-	// execute the query
-	ctx := user.InjectOrgID(context.Background(), orgID)
-	result, err := query.Exec(ctx)
-	if err != nil {
-		return err
-	}
-
-	if statistics {
-		q.printStats(result.Statistics)
-	}
-
-	value, err := marshal.NewResultValue(result.Data)
-	if err != nil {
-		return err
-	}
-
-	q.printResult(value, out, nil)
-	return nil
-}
-
 // SetInstant makes the Query an instant type
 func (q *Query) SetInstant(time time.Time) {
 	q.Start = time
@@ -259,7 +174,11 @@ func (q *Query) isInstant() bool {
 }
 
 func (q *Query) printStream(streams loghttp.Streams, out output.LogOutput, lastEntry []*loghttp.Entry) (int, []*loghttp.Entry) {
-	common := commonLabels(streams)
+	// Exported entries keep all their labels.
+	var common loghttp.LabelSet
+	if _, ok := out.(output.ExportOutput); !ok {
+		common = commonLabels(streams)
+	}
 
 	// Remove the labels we want to show from common
 	if len(q.ShowLabelsKey) > 0 {
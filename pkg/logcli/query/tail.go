@@ -1,41 +1,65 @@
 package query
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/gorilla/websocket"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	logqllog "github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/util/unmarshal"
 )
 
-// TailQuery connects to the Loki websocket endpoint and tails logs
+// tailBackoff is the backoff between the reconnections of a tail.
+var tailBackoff = backoff.Config{
+	MinBackoff: time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// TailQuery connects to the Loki websocket endpoint and tails logs. When the connection is lost, it reconnects up to
+// TailMaxReconnects times in a row, resuming from the timestamp of the last entry received.
 func (q *Query) TailQuery(delayFor time.Duration, c client.Client, out output.LogOutput) {
-	conn, err := c.LiveTailQueryConn(q.QueryString, delayFor, q.Limit, q.Start, q.Quiet)
-	if err != nil {
-		log.Fatalf("Tailing logs failed: %+v", err)
+	queryString := q.QueryString
+	var pipeline logqllog.Pipeline
+	if q.TailClientPipeline {
+		var err error
+		queryString, pipeline, err = splitPipeline(q.QueryString)
+		if err != nil {
+			log.Fatalf("Unable to parse the query: %s", err)
+		}
 	}
 
+	var (
+		connMtx sync.Mutex
+		conn    *websocket.Conn
+	)
 	go func() {
 		stopChan := make(chan os.Signal, 1)
 		signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM)
 		<-stopChan
-		if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
-			log.Println("Error closing websocket:", err)
+		connMtx.Lock()
+		if conn != nil {
+			if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+				log.Println("Error closing websocket:", err)
+			}
 		}
 		os.Exit(0)
 	}()
 
-	tailResponse := new(loghttp.TailResponse)
-
 	if len(q.IgnoreLabelsKey) > 0 {
 		log.Println("Ignoring labels key:", color.RedString(strings.Join(q.IgnoreLabelsKey, ",")))
 	}
@@ -44,45 +68,143 @@ func (q *Query) TailQuery(delayFor time.Duration, c client.Client, out output.Lo
 		log.Println("Print only labels key:", color.RedString(strings.Join(q.ShowLabelsKey, ",")))
 	}
 
+	var (
+		position     tailPosition
+		tailResponse = new(loghttp.TailResponse)
+		reconnects   = backoff.New(context.Background(), tailBackoff)
+		start        = q.Start
+	)
 	for {
-		err := unmarshal.ReadTailResponseJSON(tailResponse, conn)
+		newConn, err := c.LiveTailQueryConn(queryString, delayFor, q.Limit, start, q.Quiet)
 		if err != nil {
-			log.Println("Error reading stream:", err)
+			if !q.retryTail(reconnects) {
+				log.Fatalf("Tailing logs failed: %+v", err)
+			}
+			log.Println("Tailing logs failed:", err)
+			continue
+		}
+		connMtx.Lock()
+		conn = newConn
+		connMtx.Unlock()
+
+		for {
+			err := unmarshal.ReadTailResponseJSON(tailResponse, conn)
+			if err != nil {
+				log.Println("Error reading stream:", err)
+				break
+			}
+			reconnects.Reset()
+			q.printTailResponse(tailResponse, pipeline, &position, out)
+		}
+
+		connMtx.Lock()
+		_ = conn.Close()
+		conn = nil
+		connMtx.Unlock()
+		if !q.retryTail(reconnects) {
 			return
 		}
+		if !position.last.IsZero() {
+			start = position.last
+		}
+	}
+}
 
-		labels := loghttp.LabelSet{}
-		for _, stream := range tailResponse.Streams {
-			if !q.NoLabels {
-				if len(q.IgnoreLabelsKey) > 0 || len(q.ShowLabelsKey) > 0 {
+// retryTail waits before the next reconnection of a tail, and returns false when the tail must not reconnect.
+func (q *Query) retryTail(reconnects *backoff.Backoff) bool {
+	if reconnects.NumRetries() >= q.TailMaxReconnects {
+		return false
+	}
+	reconnects.Wait()
+	log.Printf("Reconnecting (attempt %d of %d)", reconnects.NumRetries(), q.TailMaxReconnects)
+	return true
+}
 
-					ls := stream.Labels
+func (q *Query) printTailResponse(tailResponse *loghttp.TailResponse, pipeline logqllog.Pipeline, position *tailPosition, out output.LogOutput) {
+	for _, stream := range tailResponse.Streams {
+		for _, entry := range stream.Entries {
+			if !position.add(entry.Timestamp, stream.Labels, entry.Line) {
+				continue
+			}
 
-					if len(q.ShowLabelsKey) > 0 {
-						ls = matchLabels(true, ls, q.ShowLabelsKey)
-					}
+			line, lbls := entry.Line, stream.Labels
+			if pipeline != nil {
+				var (
+					result logqllog.LabelsResult
+					ok     bool
+				)
+				line, result, ok = pipeline.ForStream(labels.FromMap(stream.Labels.Map())).ProcessString(entry.Timestamp.UnixNano(), entry.Line)
+				if !ok {
+					continue
+				}
+				lbls = loghttp.LabelSet(result.Labels().Map())
+			}
 
-					if len(q.IgnoreLabelsKey) > 0 {
-						ls = matchLabels(false, ls, q.ShowLabelsKey)
-					}
+			out.FormatAndPrintln(entry.Timestamp, q.tailLabels(lbls), 0, line)
+		}
+	}
+	if len(tailResponse.DroppedStreams) != 0 {
+		log.Println("Server dropped following entries due to slow client")
+		for _, d := range tailResponse.DroppedStreams {
+			log.Println(d.Timestamp, d.Labels)
+		}
+	}
+}
 
-					labels = ls
+// tailLabels returns the labels printed with an entry.
+func (q *Query) tailLabels(ls loghttp.LabelSet) loghttp.LabelSet {
+	if q.NoLabels {
+		return loghttp.LabelSet{}
+	}
+	if len(q.ShowLabelsKey) > 0 {
+		ls = matchLabels(true, ls, q.ShowLabelsKey)
+	}
+	if len(q.IgnoreLabelsKey) > 0 {
+		ls = matchLabels(false, ls, q.IgnoreLabelsKey)
+	}
+	return ls
+}
 
-				} else {
-					labels = stream.Labels
-				}
-			}
+// splitPipeline splits a log query into the stream selector tailed from the server and the pipeline applied to the
+// tailed entries.
+func splitPipeline(query string) (string, logqllog.Pipeline, error) {
+	expr, err := logql.ParseLogSelector(query, true)
+	if err != nil {
+		return "", nil, err
+	}
+	pipeline, err := expr.Pipeline()
+	if err != nil {
+		return "", nil, err
+	}
 
-			for _, entry := range stream.Entries {
-				out.FormatAndPrintln(entry.Timestamp, labels, 0, entry.Line)
-			}
+	matchers := make([]string, 0, len(expr.Matchers()))
+	for _, m := range expr.Matchers() {
+		matchers = append(matchers, m.String())
+	}
+	return fmt.Sprintf("{%s}", strings.Join(matchers, ", ")), pipeline, nil
+}
 
+// tailPosition is the position of a tail, the timestamp of the last entry received. The entries at this timestamp are
+// kept to drop them when they are received again after a reconnection resuming from the position.
+type tailPosition struct {
+	last time.Time
+	seen map[string]struct{}
+}
+
+// add moves the position to the timestamp of an entry, and returns false if the entry was already received.
+func (p *tailPosition) add(ts time.Time, lbls loghttp.LabelSet, line string) bool {
+	if ts.Before(p.last) {
+		return true
+	}
+	key := lbls.String() + "\n" + line
+	if ts.Equal(p.last) && p.seen != nil {
+		if _, ok := p.seen[key]; ok {
+			return false
 		}
-		if len(tailResponse.DroppedStreams) != 0 {
-			log.Println("Server dropped following entries due to slow client")
-			for _, d := range tailResponse.DroppedStreams {
-				log.Println(d.Timestamp, d.Labels)
-			}
-		}
+		p.seen[key] = struct{}{}
+		return true
 	}
+	p.last = ts
+	p.seen = map[string]struct{}{key: {}}
+	return true
 }
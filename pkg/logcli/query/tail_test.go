@@ -0,0 +1,152 @@
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+type printedEntry struct {
+	ts     time.Time
+	labels loghttp.LabelSet
+	line   string
+}
+
+// testOutput records the printed entries.
+type testOutput struct {
+	entries []printedEntry
+}
+
+func (o *testOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
+	o.entries = append(o.entries, printedEntry{ts: ts, labels: lbls, line: line})
+}
+
+// testTailClient tails a websocket server, recording the start times of the tails.
+type testTailClient struct {
+	testQueryClient
+	addr   string
+	starts []time.Time
+}
+
+func (c *testTailClient) LiveTailQueryConn(queryStr string, delayFor time.Duration, limit int, start time.Time, quiet bool) (*websocket.Conn, error) {
+	c.starts = append(c.starts, start)
+	conn, _, err := websocket.DefaultDialer.Dial(c.addr, nil)
+	return conn, err
+}
+
+func tailMessage(entries ...string) string {
+	values := make([]string, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, fmt.Sprintf(`["%s", "line %s"]`, e, e))
+	}
+	return fmt.Sprintf(`{"streams": [{"stream": {"app": "foo"}, "values": [%s]}]}`, strings.Join(values, ", "))
+}
+
+func TestTailQuery_Reconnects(t *testing.T) {
+	defer func(b time.Duration) { tailBackoff.MinBackoff, tailBackoff.MaxBackoff = b, b }(tailBackoff.MinBackoff)
+	tailBackoff.MinBackoff, tailBackoff.MaxBackoff = time.Millisecond, time.Millisecond
+
+	// Each connection sends its messages then closes, the last one without sending anything.
+	connections := [][]string{
+		{tailMessage("1", "2"), tailMessage("2")},
+		{tailMessage("2", "3")},
+		{},
+	}
+	// The handler runs in the goroutines of the server, where the test can't fail: its errors are checked once the
+	// tail ended, the connections being closed after the errors are sent.
+	errs := make(chan error, len(connections))
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+		if len(connections) == 0 {
+			errs <- fmt.Errorf("unexpected connection")
+			return
+		}
+		messages := connections[0]
+		connections = connections[1:]
+		for _, m := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	c := &testTailClient{addr: "ws" + strings.TrimPrefix(server.URL, "http")}
+	out := &testOutput{}
+	q := &Query{QueryString: `{app="foo"}`, Start: time.Unix(0, 0), TailMaxReconnects: 1}
+	q.TailQuery(0, c, out)
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// The second connection resumes from the last entry received, which is not printed twice.
+	require.Equal(t, []time.Time{time.Unix(0, 0), time.Unix(0, 2), time.Unix(0, 3)}, c.starts)
+	var lines []string
+	for _, e := range out.entries {
+		lines = append(lines, e.line)
+	}
+	require.Equal(t, []string{"line 1", "line 2", "line 3"}, lines)
+}
+
+func TestTailQuery_ClientPipeline(t *testing.T) {
+	selector, pipeline, err := splitPipeline(`{app="foo", env=~"prod|dev"} |= "error" | logfmt | level="error"`)
+	require.NoError(t, err)
+	require.Equal(t, `{app="foo", env=~"prod|dev"}`, selector)
+
+	out := &testOutput{}
+	q := &Query{}
+	q.printTailResponse(&loghttp.TailResponse{
+		Streams: []loghttp.Stream{
+			{
+				Labels: loghttp.LabelSet{"app": "foo", "env": "prod"},
+				Entries: []loghttp.Entry{
+					{Timestamp: time.Unix(0, 1), Line: "level=error msg=error"},
+					{Timestamp: time.Unix(0, 2), Line: "level=info msg=error"},
+					{Timestamp: time.Unix(0, 3), Line: "level=warn msg=ok"},
+				},
+			},
+		},
+	}, pipeline, &tailPosition{}, out)
+
+	require.Equal(t, []printedEntry{
+		{
+			ts:     time.Unix(0, 1),
+			labels: loghttp.LabelSet{"app": "foo", "env": "prod", "level": "error", "msg": "error"},
+			line:   "level=error msg=error",
+		},
+	}, out.entries)
+}
+
+func TestTailPosition(t *testing.T) {
+	var p tailPosition
+	lbls := loghttp.LabelSet{"app": "foo"}
+
+	require.True(t, p.add(time.Unix(0, 2), lbls, "a"))
+	require.True(t, p.add(time.Unix(0, 2), lbls, "b"))
+	require.False(t, p.add(time.Unix(0, 2), lbls, "a"))
+	require.True(t, p.add(time.Unix(0, 2), loghttp.LabelSet{"app": "bar"}, "a"))
+	// Entries older than the position are not deduplicated.
+	require.True(t, p.add(time.Unix(0, 1), lbls, "a"))
+	require.True(t, p.add(time.Unix(0, 1), lbls, "a"))
+	require.Equal(t, time.Unix(0, 2), p.last)
+
+	require.True(t, p.add(time.Unix(0, 3), lbls, "a"))
+	require.Equal(t, time.Unix(0, 3), p.last)
+	require.False(t, p.add(time.Unix(0, 3), lbls, "a"))
+}
@@ -19,6 +19,7 @@ type SeriesQuery struct {
 	End           time.Time
 	AnalyzeLabels bool
 	Quiet         bool
+	LocalConfig   string
 }
 
 type labelDetails struct {
@@ -78,6 +79,15 @@ func (q *SeriesQuery) DoSeries(c client.Client) {
 
 // GetSeries returns an array of label sets
 func (q *SeriesQuery) GetSeries(c client.Client) []loghttp.LabelSet {
+	if q.LocalConfig != "" {
+		storeClient, err := client.NewStoreClient(q.LocalConfig, c.GetOrgID())
+		if err != nil {
+			log.Fatalf("Unable to load the store: %+v", err)
+		}
+		defer storeClient.Stop()
+		c = storeClient
+	}
+
 	seriesResponse, err := c.Series([]string{q.Matcher}, q.Start, q.End, q.Quiet)
 	if err != nil {
 		log.Fatalf("Error doing request: %+v", err)
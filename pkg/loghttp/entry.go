@@ -15,9 +15,13 @@ func init() {
 }
 
 // Entry represents a log entry.  It includes a log message and the time it occurred at.
+// Its layout must match the one of logproto.Entry, as slices of entries are converted
+// from one type to the other without copy.
 type Entry struct {
 	Timestamp time.Time
 	Line      string
+	// Sequence is the sequence number of the entry, which is not part of the JSON format.
+	Sequence uint64
 }
 
 func (e *Entry) UnmarshalJSON(data []byte) error {
@@ -0,0 +1,40 @@
+package loghttp
+
+import (
+	"github.com/prometheus/common/model"
+)
+
+// ExplainResponse represents the http json response to an explain query.
+type ExplainResponse struct {
+	Status string              `json:"status"`
+	Data   ExplainResponseData `json:"data"`
+}
+
+// ExplainResponseData describes how a query would be executed.
+type ExplainResponseData struct {
+	AST  *ExplainNode `json:"ast"`
+	Plan ExplainPlan  `json:"plan"`
+	Cost ExplainCost  `json:"cost"`
+}
+
+// ExplainNode is a node of the tree describing a parsed LogQL expression.
+type ExplainNode struct {
+	Type     string         `json:"type"`
+	Expr     string         `json:"expr"`
+	Children []*ExplainNode `json:"children,omitempty"`
+}
+
+// ExplainPlan is the split and shard plan the query frontend would use for a query.
+type ExplainPlan struct {
+	SplitInterval model.Duration `json:"splitInterval"`
+	Splits        int            `json:"splits"`
+	Shards        int            `json:"shards"`
+	ShardedQuery  string         `json:"shardedQuery,omitempty"`
+}
+
+// ExplainCost is the estimated cost of a query, based on the chunks referenced by the index.
+// Data not yet flushed by the ingesters is not accounted for.
+type ExplainCost struct {
+	Streams int `json:"streams"`
+	Chunks  int `json:"chunks"`
+}
@@ -0,0 +1,61 @@
+package loghttp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// IndexStatsResponse represents the http json response to an index stats query.
+type IndexStatsResponse struct {
+	Status string                 `json:"status"`
+	Data   IndexStatsResponseData `json:"data"`
+}
+
+// IndexStatsResponseData holds the index stats of the streams matching a selector,
+// in total and broken down by label name and value.
+type IndexStatsResponseData struct {
+	IndexStats
+	Labels []LabelIndexStats `json:"labels"`
+}
+
+// IndexStats counts the streams, chunks, bytes and entries referenced by the index.
+type IndexStats struct {
+	Streams uint64 `json:"streams"`
+	Chunks  uint64 `json:"chunks"`
+	Bytes   uint64 `json:"bytes"`
+	Entries uint64 `json:"entries"`
+}
+
+// LabelIndexStats holds the index stats of each value of a label name.
+type LabelIndexStats struct {
+	Name   string                 `json:"name"`
+	Values []LabelValueIndexStats `json:"values"`
+}
+
+// LabelValueIndexStats holds the index stats of the streams having a label value.
+type LabelValueIndexStats struct {
+	Value string `json:"value"`
+	IndexStats
+}
+
+// ParseIndexStatsQuery parses an IndexStatsRequest from an http request.
+func ParseIndexStatsQuery(r *http.Request) (*logproto.IndexStatsRequest, error) {
+	start, end, err := bounds(r)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, errEndBeforeStart
+	}
+	q := query(r)
+	if q == "" {
+		return nil, errors.New("query must not be empty")
+	}
+	return &logproto.IndexStatsRequest{
+		Matchers: q,
+		Start:    start,
+		End:      end,
+	}, nil
+}
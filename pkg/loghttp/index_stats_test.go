@@ -0,0 +1,34 @@
+package loghttp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func TestParseIndexStatsQuery(t *testing.T) {
+	req, err := ParseIndexStatsQuery(withForm(url.Values{
+		"query": []string{`{app="foo"}`},
+		"start": []string{"1000"},
+		"end":   []string{"2000"},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, &logproto.IndexStatsRequest{
+		Matchers: `{app="foo"}`,
+		Start:    time.Unix(1000, 0),
+		End:      time.Unix(2000, 0),
+	}, req)
+
+	for _, form := range []url.Values{
+		{"start": []string{"1000"}, "end": []string{"2000"}},
+		{"query": []string{`{app="foo"}`}, "start": []string{"2000"}, "end": []string{"1000"}},
+		{"query": []string{`{app="foo"}`}, "start": []string{"foo"}},
+	} {
+		_, err := ParseIndexStatsQuery(withForm(form))
+		require.Error(t, err)
+	}
+}
@@ -74,6 +74,7 @@ func ParseLabelQuery(r *http.Request) (*logproto.LabelRequest, error) {
 	req := &logproto.LabelRequest{
 		Values: ok,
 		Name:   name,
+		Query:  query(r),
 	}
 
 	start, end, err := bounds(r)
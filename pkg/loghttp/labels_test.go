@@ -32,6 +32,16 @@ func TestParseLabelQuery(t *testing.T) {
 				Start:  timePtr(time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC)),
 				End:    timePtr(time.Date(2017, 07, 10, 21, 42, 24, 760738998, time.UTC)),
 			}, false},
+		{"good with query",
+			requestWithVar(&http.Request{
+				URL: mustParseURL(`?start=2017-06-10T21:42:24.760738998Z&end=2017-07-10T21:42:24.760738998Z&query={namespace="prod"}`),
+			}, "name", "pod"), &logproto.LabelRequest{
+				Name:   "pod",
+				Values: true,
+				Start:  timePtr(time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC)),
+				End:    timePtr(time.Date(2017, 07, 10, 21, 42, 24, 760738998, time.UTC)),
+				Query:  `{namespace="prod"}`,
+			}, false},
 		{"good with name",
 			&http.Request{
 				URL: mustParseURL(`?start=2017-06-10T21:42:24.760738998Z&end=2017-07-10T21:42:24.760738998Z`),
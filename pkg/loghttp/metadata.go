@@ -0,0 +1,66 @@
+package loghttp
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Types of the label metadata.
+const (
+	// MetadataTypeStream is the type of the stream labels, stored in the index.
+	MetadataTypeStream = "stream"
+	// MetadataTypeParsed is the type of the labels extracted by parsers at query time.
+	MetadataTypeParsed = "parsed"
+)
+
+// MetadataResponse represents the http json response to a metadata query. Like the Prometheus
+// metadata API, it maps each name to its metadata.
+type MetadataResponse struct {
+	Status string                `json:"status"`
+	Data   map[string][]Metadata `json:"data"`
+}
+
+// Metadata describes a label which can be used in queries.
+type Metadata struct {
+	Type string `json:"type"`
+	Help string `json:"help"`
+	Unit string `json:"unit"`
+	// Parsers are the parsers which extracted a parsed label in the recent queries.
+	Parsers []string `json:"parsers,omitempty"`
+	// LastUsed is when a parsed label was last used by a query.
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// MetadataQuery is a metadata query.
+type MetadataQuery struct {
+	Start, End time.Time
+	// Metric restricts the response to a single name, named after the Prometheus parameter.
+	Metric string
+	// Limit is the maximum number of names returned, 0 meaning no limit.
+	Limit int
+}
+
+// ParseMetadataQuery parses a MetadataQuery from an http request.
+func ParseMetadataQuery(r *http.Request) (*MetadataQuery, error) {
+	start, end, err := bounds(r)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, errEndBeforeStart
+	}
+	l, err := parseInt(r.Form.Get("limit"), 0)
+	if err != nil {
+		return nil, err
+	}
+	if l < 0 {
+		return nil, errors.New("limit must not be negative")
+	}
+	return &MetadataQuery{
+		Start:  start,
+		End:    end,
+		Metric: r.Form.Get("metric"),
+		Limit:  l,
+	}, nil
+}
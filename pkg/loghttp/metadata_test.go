@@ -0,0 +1,35 @@
+package loghttp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadataQuery(t *testing.T) {
+	req, err := ParseMetadataQuery(withForm(url.Values{
+		"start":  []string{"1000"},
+		"end":    []string{"2000"},
+		"metric": []string{"status"},
+		"limit":  []string{"10"},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, &MetadataQuery{
+		Start:  time.Unix(1000, 0),
+		End:    time.Unix(2000, 0),
+		Metric: "status",
+		Limit:  10,
+	}, req)
+
+	for _, form := range []url.Values{
+		{"start": []string{"2000"}, "end": []string{"1000"}},
+		{"start": []string{"foo"}},
+		{"limit": []string{"foo"}},
+		{"limit": []string{"-1"}},
+	} {
+		_, err := ParseMetadataQuery(withForm(form))
+		require.Error(t, err)
+	}
+}
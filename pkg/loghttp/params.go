@@ -30,6 +30,18 @@ func limit(r *http.Request) (uint32, error) {
 	return uint32(l), nil
 }
 
+// seriesLimit parses the limit of a series request, 0 meaning no limit.
+func seriesLimit(r *http.Request) (uint32, error) {
+	l, err := parseInt(r.Form.Get("limit"), 0)
+	if err != nil {
+		return 0, err
+	}
+	if l < 0 {
+		return 0, errors.New("limit must not be negative")
+	}
+	return uint32(l), nil
+}
+
 func query(r *http.Request) string {
 	return r.Form.Get("query")
 }
@@ -2,17 +2,20 @@ package push
 
 import (
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"mime"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/dustin/go-humanize"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
@@ -40,17 +43,38 @@ var (
 	}, []string{"tenant"})
 )
 
-const applicationJSON = "application/json"
+const (
+	applicationJSON = "application/json"
+
+	// DefaultMaxRecvMsgSize is the default maximum size of the decompressed body of a push request.
+	DefaultMaxRecvMsgSize = 100 << 20
+)
+
+// SupportedContentEncodings are the Content-Encodings of the push requests, advertised in the Accept-Encoding header
+// of the responses to the requests with other encodings.
+var SupportedContentEncodings = []string{"gzip", "snappy", "zstd"}
+
+// UnsupportedContentEncodingError is returned by ParseRequest for the requests with an unsupported Content-Encoding.
+type UnsupportedContentEncodingError struct {
+	ContentEncoding string
+}
+
+func (e UnsupportedContentEncodingError) Error() string {
+	return fmt.Sprintf("Content-Encoding %q not supported, supported encodings are %s", e.ContentEncoding, strings.Join(SupportedContentEncodings, ", "))
+}
 
 type TenantsRetention interface {
 	RetentionPeriodFor(userID string, lbs labels.Labels) time.Duration
 }
 
-func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRetention TenantsRetention) (*logproto.PushRequest, error) {
+// ParseRequest decodes the body of a push request, failing if it is larger than maxRecvMsgSize once decompressed.
+// The protobuf bodies are snappy compressed, except the zstd-encoded ones.
+func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRetention TenantsRetention, maxRecvMsgSize int) (*logproto.PushRequest, error) {
 	// Body
 	var body io.Reader
 	// bodySize should always reflect the compressed size of the request body
 	bodySize := loki_util.NewSizeReader(r.Body)
+	protoCompression := util.RawSnappy
 	contentEncoding := r.Header.Get(contentEnc)
 	switch contentEncoding {
 	case "":
@@ -67,17 +91,21 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 		}
 		defer gzipReader.Close()
 		body = gzipReader
+	case "zstd":
+		// The window of the frames is bounded too, so that a small body can't allocate a large decoding buffer.
+		zstdReader, err := zstd.NewReader(bodySize, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxRecvMsgSize)))
+		if err != nil {
+			return nil, err
+		}
+		defer zstdReader.Close()
+		body = zstdBodyReader{Decoder: zstdReader, max: maxRecvMsgSize}
+		protoCompression = util.NoCompression
 	default:
-		return nil, fmt.Errorf("Content-Encoding %q not supported", contentEncoding)
+		return nil, UnsupportedContentEncodingError{ContentEncoding: contentEncoding}
 	}
 
 	contentType := r.Header.Get(contentType)
-	var (
-		entriesSize      int64
-		streamLabelsSize int64
-		totalEntries     int64
-		req              logproto.PushRequest
-	)
+	var req logproto.PushRequest
 
 	contentType, _ /* params */, err := mime.ParseMediaType(contentType)
 	if err != nil {
@@ -91,6 +119,7 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 
 		// todo once https://github.com/weaveworks/common/commit/73225442af7da93ec8f6a6e2f7c8aafaee3f8840 is in Loki.
 		// We can try to pass the body as bytes.buffer instead to avoid reading into another buffer.
+		body = NewMaxSizeReader(body, maxRecvMsgSize)
 		if loghttp.GetVersion(r.RequestURI) == loghttp.VersionV1 {
 			err = unmarshal.DecodePushRequest(body, &req)
 		} else {
@@ -103,51 +132,118 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 
 	default:
 		// When no content-type header is set or when it is set to
-		// `application/x-protobuf`: expect snappy compression.
-		if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), math.MaxInt32, &req, util.RawSnappy); err != nil {
+		// `application/x-protobuf`: expect snappy compression, unless zstd-encoded.
+		// The decompressed size is bounded by maxRecvMsgSize.
+		if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), maxRecvMsgSize, &req, protoCompression); err != nil {
 			return nil, err
 		}
 	}
 
-	mostRecentEntry := time.Unix(0, 0)
+	received, err := recordReceived(userID, &req, tenantsRetention)
+	if err != nil {
+		return nil, err
+	}
+
+	level.Debug(logger).Log(
+		"msg", "push request parsed",
+		"path", r.URL.Path,
+		"contentType", contentType,
+		"contentEncoding", contentEncoding,
+		"bodySize", humanize.Bytes(uint64(bodySize.Size())),
+		"streams", len(req.Streams),
+		"entries", received.totalEntries,
+		"streamLabelsSize", humanize.Bytes(uint64(received.streamLabelsSize)),
+		"entriesSize", humanize.Bytes(uint64(received.entriesSize)),
+		"totalSize", humanize.Bytes(uint64(received.entriesSize+received.streamLabelsSize)),
+		"mostRecentLagMs", time.Since(received.mostRecentEntry).Milliseconds(),
+	)
+	return &req, nil
+}
+
+// NewMaxSizeReader returns a reader failing the reads of the decompressed bodies beyond max bytes, unlike
+// io.LimitReader which ends them silently.
+func NewMaxSizeReader(r io.Reader, max int) io.Reader {
+	return &maxSizeReader{r: r, max: int64(max)}
+}
+
+type maxSizeReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell a body of exactly the maximum size from a larger one.
+	if int64(len(p)) > m.max-m.read+1 {
+		p = p[:m.max-m.read+1]
+	}
+	n, err := m.r.Read(p)
+	m.read += int64(n)
+	if m.read > m.max {
+		return n, bodyTooLargeError(int(m.max))
+	}
+	return n, err
+}
+
+// zstdBodyReader reports the frames exceeding the memory limit of the decoder as too large bodies.
+type zstdBodyReader struct {
+	*zstd.Decoder
+	max int
+}
+
+func (z zstdBodyReader) Read(p []byte) (int, error) {
+	n, err := z.Decoder.Read(p)
+	if errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		err = bodyTooLargeError(z.max)
+	}
+	return n, err
+}
+
+func bodyTooLargeError(max int) error {
+	return fmt.Errorf("decompressed push request body larger than the maximum allowed size of %d bytes", max)
+}
+
+// receivedSizes are the sizes of the streams of a push request.
+type receivedSizes struct {
+	entriesSize      int64
+	streamLabelsSize int64
+	totalEntries     int64
+	mostRecentEntry  time.Time
+}
+
+// RecordReceived accounts the bytes and lines of a push request of the tenant in the received metrics,
+// for the requests not parsed by ParseRequest.
+func RecordReceived(userID string, req *logproto.PushRequest, tenantsRetention TenantsRetention) error {
+	_, err := recordReceived(userID, req, tenantsRetention)
+	return err
+}
+
+func recordReceived(userID string, req *logproto.PushRequest, tenantsRetention TenantsRetention) (receivedSizes, error) {
+	received := receivedSizes{mostRecentEntry: time.Unix(0, 0)}
 
 	for _, s := range req.Streams {
-		streamLabelsSize += int64(len(s.Labels))
+		received.streamLabelsSize += int64(len(s.Labels))
 		var retentionHours string
 		if tenantsRetention != nil {
 			lbs, err := logql.ParseLabels(s.Labels)
 			if err != nil {
-				return nil, err
+				return received, err
 			}
 			retentionHours = fmt.Sprintf("%d", int64(math.Floor(tenantsRetention.RetentionPeriodFor(userID, lbs).Hours())))
 		}
 		for _, e := range s.Entries {
-			totalEntries++
-			entriesSize += int64(len(e.Line))
+			received.totalEntries++
+			received.entriesSize += int64(len(e.Line))
 			bytesIngested.WithLabelValues(userID, retentionHours).Add(float64(int64(len(e.Line))))
-			if e.Timestamp.After(mostRecentEntry) {
-				mostRecentEntry = e.Timestamp
+			if e.Timestamp.After(received.mostRecentEntry) {
+				received.mostRecentEntry = e.Timestamp
 			}
 		}
 	}
 
 	// incrementing tenant metrics if we have a tenant.
-	if totalEntries != 0 && userID != "" {
-		linesIngested.WithLabelValues(userID).Add(float64(totalEntries))
+	if received.totalEntries != 0 && userID != "" {
+		linesIngested.WithLabelValues(userID).Add(float64(received.totalEntries))
 	}
-
-	level.Debug(logger).Log(
-		"msg", "push request parsed",
-		"path", r.URL.Path,
-		"contentType", contentType,
-		"contentEncoding", contentEncoding,
-		"bodySize", humanize.Bytes(uint64(bodySize.Size())),
-		"streams", len(req.Streams),
-		"entries", totalEntries,
-		"streamLabelsSize", humanize.Bytes(uint64(streamLabelsSize)),
-		"entriesSize", humanize.Bytes(uint64(entriesSize)),
-		"totalSize", humanize.Bytes(uint64(entriesSize+streamLabelsSize)),
-		"mostRecentLagMs", time.Since(mostRecentEntry).Milliseconds(),
-	)
-	return &req, nil
+	return received, nil
 }
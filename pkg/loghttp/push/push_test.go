@@ -3,13 +3,20 @@ package push
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"log"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
 )
 
 // GZip source string and return compressed string
@@ -104,7 +111,7 @@ func TestParseRequest(t *testing.T) {
 		if len(test.contentEncoding) > 0 {
 			request.Header.Add("Content-Encoding", test.contentEncoding)
 		}
-		data, err := ParseRequest(util_log.Logger, "", request, nil)
+		data, err := ParseRequest(util_log.Logger, "", request, nil, DefaultMaxRecvMsgSize)
 		if test.valid {
 			assert.Nil(t, err, "Should not give error for %d", index)
 			assert.NotNil(t, data, "Should give data for %d", index)
@@ -114,3 +121,59 @@ func TestParseRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRequest_ZstdAndMaxSize(t *testing.T) {
+	pushReq := logproto.PushRequest{Streams: []logproto.Stream{{
+		Labels:  `{foo="bar"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: strings.Repeat("a", 1000)}},
+	}}}
+	proto, err := pushReq.Marshal()
+	require.NoError(t, err)
+	jsonBody := `{"streams": [{ "stream": { "foo": "bar" }, "values": [ [ "1570818238000000000", "` + strings.Repeat("a", 1000) + `" ] ] }]}`
+
+	zstdEncoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer zstdEncoder.Close()
+
+	for _, tc := range []struct {
+		name            string
+		body            []byte
+		contentType     string
+		contentEncoding string
+		maxSize         int
+		err             string
+	}{
+		{"snappy protobuf", snappy.Encode(nil, proto), "application/x-protobuf", "", DefaultMaxRecvMsgSize, ""},
+		{"zstd protobuf", zstdEncoder.EncodeAll(proto, nil), "application/x-protobuf", "zstd", DefaultMaxRecvMsgSize, ""},
+		{"zstd json", zstdEncoder.EncodeAll([]byte(jsonBody), nil), "application/json", "zstd", DefaultMaxRecvMsgSize, ""},
+		{"exact size json", []byte(jsonBody), "application/json", "", len(jsonBody), ""},
+		{"too large snappy protobuf", snappy.Encode(nil, proto), "application/x-protobuf", "", 500, "message larger than max"},
+		{"too large zstd protobuf", zstdEncoder.EncodeAll(proto, nil), "application/x-protobuf", "zstd", 500, "larger than the maximum allowed size of 500 bytes"},
+		{"too large gzip json", []byte(gzipString(jsonBody)), "application/json", "gzip", 500, "larger than the maximum allowed size of 500 bytes"},
+		{"unsupported encoding", []byte(jsonBody), "application/json", "br", DefaultMaxRecvMsgSize, `Content-Encoding "br" not supported`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/loki/api/v1/push", bytes.NewReader(tc.body))
+			request.Header.Set("Content-Type", tc.contentType)
+			request.Header.Set("Content-Encoding", tc.contentEncoding)
+			data, err := ParseRequest(util_log.Logger, "", request, nil, tc.maxSize)
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, data.Streams, 1)
+			require.Equal(t, strings.Repeat("a", 1000), data.Streams[0].Entries[0].Line)
+		})
+	}
+}
+
+func TestParseRequest_UnsupportedContentEncoding(t *testing.T) {
+	request := httptest.NewRequest("POST", "/loki/api/v1/push", strings.NewReader(""))
+	request.Header.Set("Content-Encoding", "br")
+	_, err := ParseRequest(util_log.Logger, "", request, nil, DefaultMaxRecvMsgSize)
+	var encodingErr UnsupportedContentEncodingError
+	require.True(t, errors.As(err, &encodingErr))
+	require.Equal(t, "br", encodingErr.ContentEncoding)
+}
@@ -159,8 +159,8 @@ func TestStreams_ToProto(t *testing.T) {
 				{
 					Labels: map[string]string{"foo": "bar", "lvl": "error"},
 					Entries: []Entry{
-						{Timestamp: time.Unix(0, 3), Line: "3"},
-						{Timestamp: time.Unix(0, 4), Line: "4"},
+						{Timestamp: time.Unix(0, 3), Line: "3", Sequence: 3},
+						{Timestamp: time.Unix(0, 4), Line: "4", Sequence: 4},
 					},
 				},
 			},
@@ -175,8 +175,8 @@ func TestStreams_ToProto(t *testing.T) {
 				{
 					Labels: `{foo="bar", lvl="error"}`,
 					Entries: []logproto.Entry{
-						{Timestamp: time.Unix(0, 3), Line: "3"},
-						{Timestamp: time.Unix(0, 4), Line: "4"},
+						{Timestamp: time.Unix(0, 3), Line: "3", Sequence: 3},
+						{Timestamp: time.Unix(0, 4), Line: "4", Sequence: 4},
 					},
 				},
 			},
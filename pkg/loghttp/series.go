@@ -19,6 +19,11 @@ func ParseSeriesQuery(r *http.Request) (*logproto.SeriesRequest, error) {
 		return nil, err
 	}
 
+	limit, err := seriesLimit(r)
+	if err != nil {
+		return nil, err
+	}
+
 	xs := r.Form["match"]
 	// Prometheus encodes with `match[]`; we use both for compatibility.
 	ys := r.Form["match[]"]
@@ -42,6 +47,7 @@ func ParseSeriesQuery(r *http.Request) (*logproto.SeriesRequest, error) {
 		End:    end,
 		Groups: deduped,
 		Shards: shards(r),
+		Limit:  limit,
 	}, nil
 }
 
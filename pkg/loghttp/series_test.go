@@ -78,6 +78,31 @@ func TestParseSeriesQuery(t *testing.T) {
 			false,
 			mkSeriesRequest(t, "1000", "2000", []string{`{a="1"}`, `{b="2"}`, `{c="3"}`}),
 		},
+		{
+			"limit",
+			withForm(url.Values{
+				"start": []string{"1000"},
+				"end":   []string{"2000"},
+				"match": []string{`{a=~"1|2"}`},
+				"limit": []string{"50"},
+			}),
+			false,
+			func() *logproto.SeriesRequest {
+				req := mkSeriesRequest(t, "1000", "2000", []string{`{a=~"1|2"}`})
+				req.Limit = 50
+				return req
+			}(),
+		},
+		{
+			"negative limit",
+			withForm(url.Values{
+				"start": []string{"1000"},
+				"end":   []string{"2000"},
+				"limit": []string{"-1"},
+			}),
+			true,
+			nil,
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			out, err := ParseSeriesQuery(tc.input)
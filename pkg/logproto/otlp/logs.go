@@ -0,0 +1,208 @@
+// Package otlp contains the messages and the gRPC service of the OpenTelemetry Logs protocol (OTLP) received by the
+// distributor, as defined by the opentelemetry/proto/collector/logs/v1/logs_service.proto and
+// opentelemetry/proto/logs/v1/logs.proto files of https://github.com/open-telemetry/opentelemetry-proto.
+//
+// Only the fields used to ingest the logs are declared, the others are skipped when decoding. The oneof of AnyValue
+// is declared as optional fields, which have the same encoding. The messages are decoded by the reflection-based
+// table unmarshaler of gogo/protobuf from their struct tags.
+package otlp
+
+import (
+	context "context"
+
+	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+)
+
+// ExportLogsServiceRequest is the request of the Export RPC of the logs service.
+type ExportLogsServiceRequest struct {
+	ResourceLogs []*ResourceLogs `protobuf:"bytes,1,rep,name=resource_logs,json=resourceLogs,proto3" json:"resource_logs,omitempty"`
+}
+
+func (m *ExportLogsServiceRequest) Reset()         { *m = ExportLogsServiceRequest{} }
+func (m *ExportLogsServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportLogsServiceRequest) ProtoMessage()    {}
+
+// Unmarshal decodes the request, it is used by both the HTTP handler and the gRPC codec.
+func (m *ExportLogsServiceRequest) Unmarshal(b []byte) error {
+	return proto.Unmarshal(b, (*rawExportLogsServiceRequest)(m))
+}
+
+// Marshal encodes the request.
+func (m *ExportLogsServiceRequest) Marshal() ([]byte, error) {
+	return proto.Marshal((*rawExportLogsServiceRequest)(m))
+}
+
+// rawExportLogsServiceRequest has no Marshal and Unmarshal methods, which proto.Marshal and proto.Unmarshal would call back.
+type rawExportLogsServiceRequest ExportLogsServiceRequest
+
+func (m *rawExportLogsServiceRequest) Reset()         { *m = rawExportLogsServiceRequest{} }
+func (m *rawExportLogsServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*rawExportLogsServiceRequest) ProtoMessage()    {}
+
+// ExportLogsServiceResponse is the response of the Export RPC of the logs service. A successful export has no
+// partial success, the rejected requests fail as a whole.
+type ExportLogsServiceResponse struct{}
+
+func (m *ExportLogsServiceResponse) Reset()         { *m = ExportLogsServiceResponse{} }
+func (m *ExportLogsServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportLogsServiceResponse) ProtoMessage()    {}
+
+// Unmarshal decodes the response.
+func (m *ExportLogsServiceResponse) Unmarshal(b []byte) error {
+	return proto.Unmarshal(b, (*rawExportLogsServiceResponse)(m))
+}
+
+// Marshal encodes the response.
+func (m *ExportLogsServiceResponse) Marshal() ([]byte, error) {
+	return proto.Marshal((*rawExportLogsServiceResponse)(m))
+}
+
+// rawExportLogsServiceResponse has no Marshal and Unmarshal methods, which proto.Marshal and proto.Unmarshal would call back.
+type rawExportLogsServiceResponse ExportLogsServiceResponse
+
+func (m *rawExportLogsServiceResponse) Reset()         { *m = rawExportLogsServiceResponse{} }
+func (m *rawExportLogsServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*rawExportLogsServiceResponse) ProtoMessage()    {}
+
+// ResourceLogs is a collection of the logs of a resource.
+type ResourceLogs struct {
+	Resource  *Resource    `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	ScopeLogs []*ScopeLogs `protobuf:"bytes,2,rep,name=scope_logs,json=scopeLogs,proto3" json:"scope_logs,omitempty"`
+	// InstrumentationLibraryLogs is the deprecated name of the scope logs, still sent by older SDKs.
+	InstrumentationLibraryLogs []*ScopeLogs `protobuf:"bytes,1000,rep,name=instrumentation_library_logs,json=instrumentationLibraryLogs,proto3" json:"instrumentation_library_logs,omitempty"`
+}
+
+func (m *ResourceLogs) Reset()         { *m = ResourceLogs{} }
+func (m *ResourceLogs) String() string { return proto.CompactTextString(m) }
+func (*ResourceLogs) ProtoMessage()    {}
+
+// Resource is the entity producing the logs, e.g. a service.
+type Resource struct {
+	Attributes []*KeyValue `protobuf:"bytes,1,rep,name=attributes,proto3" json:"attributes,omitempty"`
+}
+
+func (m *Resource) Reset()         { *m = Resource{} }
+func (m *Resource) String() string { return proto.CompactTextString(m) }
+func (*Resource) ProtoMessage()    {}
+
+// ScopeLogs is a collection of the logs produced by an instrumentation scope, e.g. a library.
+type ScopeLogs struct {
+	Scope      *InstrumentationScope `protobuf:"bytes,1,opt,name=scope,proto3" json:"scope,omitempty"`
+	LogRecords []*LogRecord          `protobuf:"bytes,2,rep,name=log_records,json=logRecords,proto3" json:"log_records,omitempty"`
+}
+
+func (m *ScopeLogs) Reset()         { *m = ScopeLogs{} }
+func (m *ScopeLogs) String() string { return proto.CompactTextString(m) }
+func (*ScopeLogs) ProtoMessage()    {}
+
+// InstrumentationScope is the scope producing the logs.
+type InstrumentationScope struct {
+	Name       string      `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version    string      `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Attributes []*KeyValue `protobuf:"bytes,3,rep,name=attributes,proto3" json:"attributes,omitempty"`
+}
+
+func (m *InstrumentationScope) Reset()         { *m = InstrumentationScope{} }
+func (m *InstrumentationScope) String() string { return proto.CompactTextString(m) }
+func (*InstrumentationScope) ProtoMessage()    {}
+
+// LogRecord is a log entry.
+type LogRecord struct {
+	TimeUnixNano         uint64      `protobuf:"fixed64,1,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	ObservedTimeUnixNano uint64      `protobuf:"fixed64,11,opt,name=observed_time_unix_nano,json=observedTimeUnixNano,proto3" json:"observed_time_unix_nano,omitempty"`
+	SeverityNumber       int32       `protobuf:"varint,2,opt,name=severity_number,json=severityNumber,proto3" json:"severity_number,omitempty"`
+	SeverityText         string      `protobuf:"bytes,3,opt,name=severity_text,json=severityText,proto3" json:"severity_text,omitempty"`
+	Body                 *AnyValue   `protobuf:"bytes,5,opt,name=body,proto3" json:"body,omitempty"`
+	Attributes           []*KeyValue `protobuf:"bytes,6,rep,name=attributes,proto3" json:"attributes,omitempty"`
+	TraceId              []byte      `protobuf:"bytes,9,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"` //nolint:golint
+	SpanId               []byte      `protobuf:"bytes,10,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`   //nolint:golint
+}
+
+func (m *LogRecord) Reset()         { *m = LogRecord{} }
+func (m *LogRecord) String() string { return proto.CompactTextString(m) }
+func (*LogRecord) ProtoMessage()    {}
+
+// KeyValue is an attribute.
+type KeyValue struct {
+	Key   string    `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value *AnyValue `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *KeyValue) Reset()         { *m = KeyValue{} }
+func (m *KeyValue) String() string { return proto.CompactTextString(m) }
+func (*KeyValue) ProtoMessage()    {}
+
+// AnyValue is the value of an attribute or the body of a log record, at most one of its fields is set.
+type AnyValue struct {
+	StringValue *string       `protobuf:"bytes,1,opt,name=string_value,json=stringValue" json:"string_value,omitempty"`
+	BoolValue   *bool         `protobuf:"varint,2,opt,name=bool_value,json=boolValue" json:"bool_value,omitempty"`
+	IntValue    *int64        `protobuf:"varint,3,opt,name=int_value,json=intValue" json:"int_value,omitempty"`
+	DoubleValue *float64      `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue" json:"double_value,omitempty"`
+	ArrayValue  *ArrayValue   `protobuf:"bytes,5,opt,name=array_value,json=arrayValue" json:"array_value,omitempty"`
+	KvlistValue *KeyValueList `protobuf:"bytes,6,opt,name=kvlist_value,json=kvlistValue" json:"kvlist_value,omitempty"`
+	BytesValue  []byte        `protobuf:"bytes,7,opt,name=bytes_value,json=bytesValue" json:"bytes_value,omitempty"`
+}
+
+func (m *AnyValue) Reset()         { *m = AnyValue{} }
+func (m *AnyValue) String() string { return proto.CompactTextString(m) }
+func (*AnyValue) ProtoMessage()    {}
+
+// ArrayValue is a list of values.
+type ArrayValue struct {
+	Values []*AnyValue `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *ArrayValue) Reset()         { *m = ArrayValue{} }
+func (m *ArrayValue) String() string { return proto.CompactTextString(m) }
+func (*ArrayValue) ProtoMessage()    {}
+
+// KeyValueList is a list of attributes, used as a map.
+type KeyValueList struct {
+	Values []*KeyValue `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *KeyValueList) Reset()         { *m = KeyValueList{} }
+func (m *KeyValueList) String() string { return proto.CompactTextString(m) }
+func (*KeyValueList) ProtoMessage()    {}
+
+// LogsServiceServer is the server API for the logs service.
+type LogsServiceServer interface {
+	Export(context.Context, *ExportLogsServiceRequest) (*ExportLogsServiceResponse, error)
+}
+
+// RegisterLogsServiceServer registers the logs service on a gRPC server.
+func RegisterLogsServiceServer(s *grpc.Server, srv LogsServiceServer) {
+	s.RegisterService(&_LogsService_serviceDesc, srv)
+}
+
+func _LogsService_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportLogsServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogsServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/opentelemetry.proto.collector.logs.v1.LogsService/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogsServiceServer).Export(ctx, req.(*ExportLogsServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _LogsService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.logs.v1.LogsService",
+	HandlerType: (*LogsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    _LogsService_Export_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "opentelemetry/proto/collector/logs/v1/logs_service.proto",
+}
@@ -0,0 +1,110 @@
+package otlp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestExportLogsServiceRequest_MarshalUnmarshal(t *testing.T) {
+	body, i := "line", int64(42)
+	req := &ExportLogsServiceRequest{ResourceLogs: []*ResourceLogs{{
+		Resource: &Resource{Attributes: []*KeyValue{{Key: "service.name", Value: &AnyValue{StringValue: &body}}}},
+		ScopeLogs: []*ScopeLogs{{
+			Scope: &InstrumentationScope{Name: "scope"},
+			LogRecords: []*LogRecord{{
+				TimeUnixNano: 1,
+				SeverityText: "INFO",
+				Body:         &AnyValue{KvlistValue: &KeyValueList{Values: []*KeyValue{{Key: "n", Value: &AnyValue{IntValue: &i}}}}},
+				TraceId:      []byte{1, 2},
+			}},
+		}},
+	}}}
+
+	b, err := req.Marshal()
+	require.NoError(t, err)
+	var decoded ExportLogsServiceRequest
+	require.NoError(t, decoded.Unmarshal(b))
+	require.Equal(t, req, &decoded)
+}
+
+// TestExportLogsServiceRequest_Unmarshal decodes a request encoded field by field with the numbers and types of the
+// opentelemetry-proto files, including fields the messages don't declare, independently of the struct tags.
+func TestExportLogsServiceRequest_Unmarshal(t *testing.T) {
+	message := func(fields ...func([]byte) []byte) []byte {
+		var b []byte
+		for _, f := range fields {
+			b = f(b)
+		}
+		return b
+	}
+	bytesField := func(num protowire.Number, v []byte) func([]byte) []byte {
+		return func(b []byte) []byte {
+			return protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), v)
+		}
+	}
+	varintField := func(num protowire.Number, v uint64) func([]byte) []byte {
+		return func(b []byte) []byte {
+			return protowire.AppendVarint(protowire.AppendTag(b, num, protowire.VarintType), v)
+		}
+	}
+	fixed64Field := func(num protowire.Number, v uint64) func([]byte) []byte {
+		return func(b []byte) []byte {
+			return protowire.AppendFixed64(protowire.AppendTag(b, num, protowire.Fixed64Type), v)
+		}
+	}
+	fixed32Field := func(num protowire.Number, v uint32) func([]byte) []byte {
+		return func(b []byte) []byte {
+			return protowire.AppendFixed32(protowire.AppendTag(b, num, protowire.Fixed32Type), v)
+		}
+	}
+	stringAttribute := func(key, value string) []byte {
+		// KeyValue{key = 1, value = 2}, AnyValue{string_value = 1}
+		return message(bytesField(1, []byte(key)), bytesField(2, message(bytesField(1, []byte(value)))))
+	}
+
+	logRecord := message(
+		fixed64Field(1, 1000),                               // time_unix_nano
+		varintField(2, 17),                                  // severity_number: SEVERITY_NUMBER_ERROR
+		bytesField(3, []byte("ERROR")),                      // severity_text
+		bytesField(5, message(varintField(3, 42))),          // body: int_value
+		bytesField(6, stringAttribute("reason", "timeout")), // attributes
+		varintField(7, 3),                                   // dropped_attributes_count
+		fixed32Field(8, 1),                                  // flags
+		bytesField(9, []byte{1, 2}),                         // trace_id
+		bytesField(10, []byte{3, 4}),                        // span_id
+		fixed64Field(11, 2000),                              // observed_time_unix_nano
+	)
+	scopeLogs := message(
+		bytesField(1, message(bytesField(1, []byte("scope")), bytesField(2, []byte("v1")), varintField(4, 0))), // scope
+		bytesField(2, logRecord),                // log_records
+		bytesField(3, []byte("https://schema")), // schema_url
+	)
+	resourceLogs := message(
+		bytesField(1, message(bytesField(1, stringAttribute("service.name", "api")), varintField(2, 0))), // resource
+		bytesField(2, scopeLogs),                // scope_logs
+		bytesField(3, []byte("https://schema")), // schema_url
+	)
+	b := message(bytesField(1, resourceLogs)) // resource_logs
+
+	var decoded ExportLogsServiceRequest
+	require.NoError(t, decoded.Unmarshal(b))
+	service, reason, severity, body := "api", "timeout", "ERROR", int64(42)
+	require.Equal(t, &ExportLogsServiceRequest{ResourceLogs: []*ResourceLogs{{
+		Resource: &Resource{Attributes: []*KeyValue{{Key: "service.name", Value: &AnyValue{StringValue: &service}}}},
+		ScopeLogs: []*ScopeLogs{{
+			Scope: &InstrumentationScope{Name: "scope", Version: "v1"},
+			LogRecords: []*LogRecord{{
+				TimeUnixNano:         1000,
+				ObservedTimeUnixNano: 2000,
+				SeverityNumber:       17,
+				SeverityText:         severity,
+				Body:                 &AnyValue{IntValue: &body},
+				Attributes:           []*KeyValue{{Key: "reason", Value: &AnyValue{StringValue: &reason}}},
+				TraceId:              []byte{1, 2},
+				SpanId:               []byte{3, 4},
+			}},
+		}},
+	}}}, &decoded)
+}
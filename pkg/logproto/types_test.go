@@ -13,19 +13,19 @@ var (
 	stream = Stream{
 		Labels: `{job="foobar", cluster="foo-central1", namespace="bar", container_name="buzz"}`,
 		Entries: []Entry{
-			{now, line},
-			{now.Add(1 * time.Second), line},
-			{now.Add(2 * time.Second), line},
-			{now.Add(3 * time.Second), line},
+			{now, line, 1},
+			{now.Add(1 * time.Second), line, 2},
+			{now.Add(2 * time.Second), line, 0},
+			{now.Add(3 * time.Second), line, 4},
 		},
 	}
 	streamAdapter = StreamAdapter{
 		Labels: `{job="foobar", cluster="foo-central1", namespace="bar", container_name="buzz"}`,
 		Entries: []EntryAdapter{
-			{now, line},
-			{now.Add(1 * time.Second), line},
-			{now.Add(2 * time.Second), line},
-			{now.Add(3 * time.Second), line},
+			{now, line, 1},
+			{now.Add(1 * time.Second), line, 2},
+			{now.Add(2 * time.Second), line, 0},
+			{now.Add(3 * time.Second), line, 4},
 		},
 	}
 )
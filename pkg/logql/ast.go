@@ -84,6 +84,28 @@ type Querier interface {
 	SelectSamples(context.Context, SelectSampleParams) (iter.SampleIterator, error)
 }
 
+// RangeAggregationQuerier is implemented by the Queriers which can have some range aggregations evaluated elsewhere,
+// i.e. by the remote clusters of a federation, rather than returning all their samples.
+type RangeAggregationQuerier interface {
+	// SelectSamplesAggregated selects the samples of the summable range aggregation expr like SelectSamples, except
+	// the samples aggregated elsewhere over the steps of the query, whose results are returned in the matrix and get
+	// summed with the results of the samples by series and by step. The matrix is nil when nothing was aggregated.
+	SelectSamplesAggregated(ctx context.Context, params SelectSampleParams, expr *RangeAggregationExpr, q Params) (iter.SampleIterator, promql.Matrix, error)
+}
+
+// SummableRangeAggregation tells if the results of a range aggregation over disjoint sets of samples can be summed,
+// by series and by step, into the result of the range aggregation over all the samples.
+func SummableRangeAggregation(expr *RangeAggregationExpr) bool {
+	if expr.Left.Unwrap != nil || expr.Grouping != nil {
+		return false
+	}
+	switch expr.Operation {
+	case OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeBytes, OpRangeTypeBytesRate:
+		return true
+	}
+	return false
+}
+
 // LogSelectorExpr is a LogQL expression filtering and returning logs.
 type LogSelectorExpr interface {
 	Matchers() []*labels.Matcher
@@ -511,6 +533,141 @@ func (e *LabelFmtExpr) String() string {
 	return sb.String()
 }
 
+type DropLabelsExpr struct {
+	Names []string
+
+	implicit
+}
+
+func newDropLabelsExpr(names []string) *DropLabelsExpr {
+	return &DropLabelsExpr{
+		Names: names,
+	}
+}
+
+func (e *DropLabelsExpr) Shardable() bool { return false }
+
+func (e *DropLabelsExpr) Walk(f WalkFn) { f(e) }
+
+func (e *DropLabelsExpr) Stage() (log.Stage, error) {
+	return log.NewDropLabels(e.Names), nil
+}
+
+func (e *DropLabelsExpr) String() string {
+	return fmt.Sprintf("%s %s %s", OpPipe, OpDropLabels, strings.Join(e.Names, ","))
+}
+
+type KeepLabelsExpr struct {
+	Names []string
+
+	implicit
+}
+
+func newKeepLabelsExpr(names []string) *KeepLabelsExpr {
+	return &KeepLabelsExpr{
+		Names: names,
+	}
+}
+
+func (e *KeepLabelsExpr) Shardable() bool { return false }
+
+func (e *KeepLabelsExpr) Walk(f WalkFn) { f(e) }
+
+func (e *KeepLabelsExpr) Stage() (log.Stage, error) {
+	return log.NewKeepLabels(e.Names), nil
+}
+
+func (e *KeepLabelsExpr) String() string {
+	return fmt.Sprintf("%s %s %s", OpPipe, OpKeepLabels, strings.Join(e.Names, ","))
+}
+
+type DedupExpr struct {
+	Tolerance time.Duration
+
+	implicit
+}
+
+func newDedupExpr(tolerance time.Duration) *DedupExpr {
+	return &DedupExpr{
+		Tolerance: tolerance,
+	}
+}
+
+// Shardable returns false since duplicates can come from streams belonging to different shards.
+func (e *DedupExpr) Shardable() bool { return false }
+
+func (e *DedupExpr) Walk(f WalkFn) { f(e) }
+
+// Stage returns a noop stage, duplicates are removed by the engine once all streams are merged.
+func (e *DedupExpr) Stage() (log.Stage, error) { return log.NoopStage, nil }
+
+func (e *DedupExpr) String() string {
+	return fmt.Sprintf("%s %s %s", OpPipe, OpDedup, model.Duration(e.Tolerance))
+}
+
+// HasDedup returns true if the expression has a dedup stage. Such queries can't be tailed, the
+// entries being sent as soon as they are received from the ingesters.
+func HasDedup(expr Expr) bool {
+	_, ok := dedupTolerance(expr)
+	return ok
+}
+
+// dedupTolerance returns the tolerance of the dedup stage of the expression, if any.
+func dedupTolerance(expr Expr) (time.Duration, bool) {
+	var (
+		tolerance time.Duration
+		found     bool
+	)
+	expr.Walk(func(e interface{}) {
+		if d, ok := e.(*DedupExpr); ok {
+			found = true
+			if d.Tolerance > tolerance {
+				tolerance = d.Tolerance
+			}
+		}
+	})
+	return tolerance, found
+}
+
+type LookupExpr struct {
+	Table string
+	Label string
+
+	// tables resolves the table, they are bound to the expression by the component evaluating its pipeline.
+	tables log.LookupTables
+
+	implicit
+}
+
+func newLookupExpr(table, label string) *LookupExpr {
+	return &LookupExpr{
+		Table: table,
+		Label: label,
+	}
+}
+
+func (e *LookupExpr) Shardable() bool { return true }
+
+func (e *LookupExpr) Walk(f WalkFn) { f(e) }
+
+func (e *LookupExpr) Stage() (log.Stage, error) {
+	return log.NewLookup(e.tables, e.Table, e.Label)
+}
+
+func (e *LookupExpr) String() string {
+	return fmt.Sprintf("%s %s %s %s %s", OpPipe, OpLookup, e.Table, OpOn, e.Label)
+}
+
+// BindLookupTables sets the tables resolving the tables of the lookup stages of the expression. It must be called
+// before building the pipeline or the sample extractor of an expression with lookup stages.
+func BindLookupTables(expr Expr, tables log.LookupTables) {
+	expr.Walk(func(e interface{}) {
+		if l, ok := e.(*LookupExpr); ok {
+			l.tables = tables
+		}
+	})
+}
+
 type JSONExpressionParser struct {
 	Expressions []log.JSONExpression
 
@@ -683,6 +840,12 @@ const (
 	OpRangeTypeLast      = "last_over_time"
 	OpRangeTypeAbsent    = "absent_over_time"
 
+	// internal range vector ops
+
+	// OpRangeTypeQuantileSketch builds a quantile sketch per series instead of
+	// a single value, it's used to shard quantile_over_time.
+	OpRangeTypeQuantileSketch = "__quantile_sketch_over_time__"
+
 	// binops - logical/set
 	OpTypeOr     = "or"
 	OpTypeAnd    = "and"
@@ -714,6 +877,12 @@ const (
 	OpFmtLine  = "line_format"
 	OpFmtLabel = "label_format"
 
+	OpDropLabels = "drop"
+	OpKeepLabels = "keep"
+
+	OpDedup  = "dedup"
+	OpLookup = "lookup"
+
 	OpPipe   = "|"
 	OpUnwrap = "unwrap"
 	OpOffset = "offset"
@@ -808,14 +977,14 @@ func (e *RangeAggregationExpr) Selector() LogSelectorExpr {
 func (e RangeAggregationExpr) validate() error {
 	if e.Grouping != nil {
 		switch e.Operation {
-		case OpRangeTypeAvg, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeFirst, OpRangeTypeLast:
+		case OpRangeTypeAvg, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeQuantileSketch, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeFirst, OpRangeTypeLast:
 		default:
 			return fmt.Errorf("grouping not allowed for %s aggregation", e.Operation)
 		}
 	}
 	if e.Left.Unwrap != nil {
 		switch e.Operation {
-		case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeRate, OpRangeTypeAbsent, OpRangeTypeFirst, OpRangeTypeLast:
+		case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeQuantileSketch, OpRangeTypeRate, OpRangeTypeAbsent, OpRangeTypeFirst, OpRangeTypeLast:
 			return nil
 		default:
 			return fmt.Errorf("invalid aggregation %s with unwrap", e.Operation)
@@ -848,6 +1017,10 @@ func (e *RangeAggregationExpr) String() string {
 
 // impl SampleExpr
 func (e *RangeAggregationExpr) Shardable() bool {
+	// first and last of a group of series can't be merged once spread across shards.
+	if (e.Operation == OpRangeTypeFirst || e.Operation == OpRangeTypeLast) && e.Grouping != nil {
+		return false
+	}
 	return shardableOps[e.Operation] && e.Left.Shardable()
 }
 
@@ -37,6 +37,9 @@ func Test_logSelectorExpr_String(t *testing.T) {
 		{`{foo="bar"} |= "baz" |~ "blip" != "flip" !~ "flap" | logfmt | b=ip("127.0.0.1") | level="error" | c=ip("::1")`, true}, // chain inside label filters.
 		{`{foo="bar"} |= "baz" |~ "blip" != "flip" !~ "flap" | regexp "(?P<foo>foo|bar)"`, true},
 		{`{foo="bar"} |= "baz" |~ "blip" != "flip" !~ "flap" | regexp "(?P<foo>foo|bar)" | ( ( foo<5.01 , bar>20ms ) or foo="bar" ) | line_format "blip{{.boop}}bap" | label_format foo=bar,bar="blip{{.blop}}"`, true},
+		{`{foo="bar"} |= "baz" | logfmt | drop instance,pod`, true},
+		{`{foo="bar"} |= "baz" | logfmt | keep job,level`, true},
+		{`{foo="bar"} |= "baz" | logfmt | drop agent | dedup 5s`, true},
 	}
 
 	for _, tt := range tests {
@@ -190,7 +193,7 @@ func Test_NilFilterDoesntPanic(t *testing.T) {
 
 			p, err := expr.Pipeline()
 			require.Nil(t, err)
-			_, _, ok := p.ForStream(labelBar).Process([]byte("bleepbloop"))
+			_, _, ok := p.ForStream(labelBar).Process(0, []byte("bleepbloop"))
 
 			require.True(t, ok)
 		})
@@ -284,7 +287,7 @@ func Test_FilterMatcher(t *testing.T) {
 			} else {
 				sp := p.ForStream(labelBar)
 				for _, lc := range tt.lines {
-					_, _, ok := sp.Process([]byte(lc.l))
+					_, _, ok := sp.Process(0, []byte(lc.l))
 					assert.Equalf(t, lc.e, ok, "query for line '%s' was %v and not %v", lc.l, ok, lc.e)
 				}
 			}
@@ -389,7 +392,7 @@ func BenchmarkContainsFilter(b *testing.B) {
 			sp := p.ForStream(labelBar)
 			for i := 0; i < b.N; i++ {
 				for _, line := range lines {
-					sp.Process(line)
+					sp.Process(0, line)
 				}
 			}
 		})
@@ -484,3 +487,49 @@ func Test_canInjectVectorGrouping(t *testing.T) {
 		})
 	}
 }
+
+type fakeLookupTables map[string]fakeLookupTable
+
+func (f fakeLookupTables) Table(name string) (log.LookupTable, bool) {
+	t, ok := f[name]
+	return t, ok
+}
+
+type fakeLookupTable map[string]labels.Labels
+
+func (f fakeLookupTable) Lookup(key string) (labels.Labels, bool) {
+	row, ok := f[key]
+	return row, ok
+}
+
+func Test_BindLookupTables(t *testing.T) {
+	tables := fakeLookupTables{"teams": {"i-1": labels.Labels{{Name: "team", Value: "payments"}}}}
+
+	for _, query := range []string{
+		`{app="foo"} | logfmt | lookup teams on instance_id`,
+		`sum by (team) (count_over_time({app="foo"} | logfmt | lookup teams on instance_id [5m]))`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			expr, err := ParseExpr(query)
+			require.NoError(t, err)
+			var sel LogSelectorExpr
+			switch e := expr.(type) {
+			case LogSelectorExpr:
+				sel = e
+			case SampleExpr:
+				sel = e.Selector()
+			}
+
+			// The tables are bound by the components evaluating the pipelines.
+			_, err = sel.Pipeline()
+			require.Error(t, err)
+
+			BindLookupTables(expr, tables)
+			p, err := sel.Pipeline()
+			require.NoError(t, err)
+			_, lbs, ok := p.ForStream(labels.Labels{{Name: "app", Value: "foo"}}).Process(0, []byte("instance_id=i-1"))
+			require.True(t, ok)
+			require.Equal(t, "payments", lbs.Labels().Get("team"))
+		})
+	}
+}
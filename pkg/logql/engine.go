@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -42,6 +43,9 @@ type EngineOpts struct {
 	// MaxLookBackPeriod is the maximum amount of time to look back for log lines.
 	// only used for instant log queries.
 	MaxLookBackPeriod time.Duration `yaml:"max_look_back_period"`
+
+	// Inflight tracks the queries executed by the engine if not nil.
+	Inflight *InflightQueries `yaml:"-"`
 }
 
 func (opts *EngineOpts) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
@@ -63,6 +67,7 @@ type Engine struct {
 	timeout   time.Duration
 	evaluator Evaluator
 	limits    Limits
+	inflight  *InflightQueries
 }
 
 // NewEngine creates a new LogQL Engine.
@@ -72,6 +77,7 @@ func NewEngine(opts EngineOpts, q Querier, l Limits) *Engine {
 		timeout:   opts.Timeout,
 		evaluator: NewDefaultEvaluator(q, opts.MaxLookBackPeriod),
 		limits:    l,
+		inflight:  opts.Inflight,
 	}
 }
 
@@ -82,10 +88,16 @@ func (ng *Engine) Query(params Params) Query {
 		params:    params,
 		evaluator: ng.evaluator,
 		parse: func(_ context.Context, query string) (Expr, error) {
-			return ParseExpr(query)
+			expr, err := ParseExpr(query)
+			if err != nil || len(params.Shards()) > 0 {
+				return expr, err
+			}
+			// only the sharded queries sent by the query frontend may use the internal operations.
+			return expr, ValidateUserExpr(expr)
 		},
-		record: true,
-		limits: ng.limits,
+		record:   true,
+		limits:   ng.limits,
+		inflight: ng.inflight,
 	}
 }
 
@@ -102,6 +114,7 @@ type query struct {
 	limits    Limits
 	evaluator Evaluator
 	record    bool
+	inflight  *InflightQueries
 }
 
 // Exec Implements `Query`. It handles instrumentation & defers to Eval.
@@ -116,6 +129,8 @@ func (q *query) Exec(ctx context.Context) (logqlmodel.Result, error) {
 	// records query statistics
 	start := time.Now()
 	statsCtx, ctx := stats.NewContext(ctx)
+	ctx, done := q.inflight.Track(ctx, q.params.Query(), statsCtx.BytesProcessed)
+	defer done()
 
 	data, err := q.Eval(ctx)
 
@@ -158,13 +173,16 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 		return value, err
 
 	case LogSelectorExpr:
-		iter, err := q.evaluator.Iterator(ctx, e, q.params)
+		it, err := q.evaluator.Iterator(ctx, e, q.params)
 		if err != nil {
 			return nil, err
 		}
 
-		defer util.LogErrorWithContext(ctx, "closing iterator", iter.Close)
-		streams, err := readStreams(iter, q.params.Limit(), q.params.Direction(), q.params.Interval())
+		defer util.LogErrorWithContext(ctx, "closing iterator", it.Close)
+		if tolerance, ok := dedupTolerance(e); ok {
+			it = iter.NewDedupIterator(it, tolerance)
+		}
+		streams, err := readStreams(it, q.params.Limit(), q.params.Direction(), q.params.Interval())
 		return streams, err
 	default:
 		return nil, errors.New("Unexpected type (%T): cannot evaluate")
@@ -177,7 +195,7 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (promql_parser.
 		return q.evalLiteral(ctx, lit)
 	}
 
-	userID, err := tenant.TenantID(ctx)
+	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -194,13 +212,21 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (promql_parser.
 	defer util.LogErrorWithContext(ctx, "closing SampleExpr", stepEvaluator.Close)
 
 	seriesIndex := map[uint64]*promql.Series{}
-	maxSeries := q.limits.MaxQuerySeries(userID)
+	maxSeries := validation.SmallestPositiveIntPerTenant(tenantIDs, q.limits.MaxQuerySeries)
 
 	next, ts, vec := stepEvaluator.Next()
 	if stepEvaluator.Error() != nil {
 		return nil, stepEvaluator.Error()
 	}
 
+	// the label carrying the quantile sketches changes at every step, only the sketched series count against the limit.
+	var isSketch bool
+	if r, ok := expr.(*RangeAggregationExpr); ok && r.Operation == OpRangeTypeQuantileSketch {
+		isSketch = true
+	}
+	sketchSeries := map[uint64]struct{}{}
+	buf := make([]byte, 0, 1024)
+
 	// fail fast for the first step or instant query
 	if len(vec) > maxSeries {
 		return nil, logqlmodel.NewSeriesLimitError(maxSeries)
@@ -231,6 +257,11 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (promql_parser.
 					Points: make([]promql.Point, 0, stepCount),
 				}
 				seriesIndex[hash] = series
+				if isSketch {
+					var sketchHash uint64
+					sketchHash, buf = p.Metric.HashWithoutLabels(buf, QuantileSketchLabel)
+					sketchSeries[sketchHash] = struct{}{}
+				}
 			}
 			series.Points = append(series.Points, promql.Point{
 				T: ts,
@@ -238,7 +269,7 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (promql_parser.
 			})
 		}
 		// as we slowly build the full query for each steps, make sure we don't go over the limit of unique series.
-		if len(seriesIndex) > maxSeries {
+		if (!isSketch && len(seriesIndex) > maxSeries) || len(sketchSeries) > maxSeries {
 			return nil, logqlmodel.NewSeriesLimitError(maxSeries)
 		}
 		next, ts, vec = stepEvaluator.Next()
@@ -55,6 +55,24 @@ func TestEngine_LogsInstantQuery(t *testing.T) {
 			},
 			logqlmodel.Streams([]logproto.Stream{newStream(10, identity, `{app="foo"}`)}),
 		},
+		{
+			`{app="foo"} | dedup 5s`, time.Unix(30, 0), logproto.FORWARD, 10,
+			[][]logproto.Stream{
+				{
+					newStream(testSize, identity, `{app="foo"}`),
+					// the same lines shipped twice, a second later.
+					newStream(testSize, func(i int64) logData {
+						d := identity(i)
+						d.Entry.Timestamp = d.Entry.Timestamp.Add(time.Second)
+						return d
+					}, `{app="foo"}`),
+				},
+			},
+			[]SelectLogParams{
+				{&logproto.QueryRequest{Direction: logproto.FORWARD, Start: time.Unix(0, 0), End: time.Unix(30, 0), Limit: 10, Selector: `{app="foo"} | dedup 5s`}},
+			},
+			logqlmodel.Streams([]logproto.Stream{newStream(10, identity, `{app="foo"}`)}),
+		},
 		{
 			`{app="bar"} |= "foo" |~ ".+bar"`, time.Unix(30, 0), logproto.BACKWARD, 30,
 			[][]logproto.Stream{
@@ -173,34 +173,26 @@ func (ev *DefaultEvaluator) StepEvaluator(
 			// if range expression is wrapped with a vector expression
 			// we should send the vector expression for allowing reducing labels at the source.
 			nextEv = SampleEvaluatorFunc(func(ctx context.Context, nextEvaluator SampleEvaluator, expr SampleExpr, p Params) (StepEvaluator, error) {
-				it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
+				return ev.rangeAggregationStepEvaluator(ctx, SelectSampleParams{
 					&logproto.SampleQueryRequest{
 						Start:    q.Start().Add(-rangExpr.Left.Interval).Add(-rangExpr.Left.Offset),
 						End:      q.End().Add(-rangExpr.Left.Offset),
 						Selector: e.String(), // intentionally send the the vector for reducing labels.
 						Shards:   q.Shards(),
 					},
-				})
-				if err != nil {
-					return nil, err
-				}
-				return rangeAggEvaluator(iter.NewPeekingSampleIterator(it), rangExpr, q, rangExpr.Left.Offset)
+				}, rangExpr, q)
 			})
 		}
 		return vectorAggEvaluator(ctx, nextEv, e, q)
 	case *RangeAggregationExpr:
-		it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
+		return ev.rangeAggregationStepEvaluator(ctx, SelectSampleParams{
 			&logproto.SampleQueryRequest{
 				Start:    q.Start().Add(-e.Left.Interval).Add(-e.Left.Offset),
 				End:      q.End().Add(-e.Left.Offset),
 				Selector: expr.String(),
 				Shards:   q.Shards(),
 			},
-		})
-		if err != nil {
-			return nil, err
-		}
-		return rangeAggEvaluator(iter.NewPeekingSampleIterator(it), e, q, e.Left.Offset)
+		}, e, q)
 	case *BinOpExpr:
 		return binOpStepEvaluator(ctx, nextEv, e, q)
 	case *LabelReplaceExpr:
@@ -210,6 +202,65 @@ func (ev *DefaultEvaluator) StepEvaluator(
 	}
 }
 
+// rangeAggregationStepEvaluator evaluates the range aggregation of the samples selected by params, summed with the
+// results of the range aggregations evaluated elsewhere when the querier is a RangeAggregationQuerier.
+func (ev *DefaultEvaluator) rangeAggregationStepEvaluator(ctx context.Context, params SelectSampleParams, expr *RangeAggregationExpr, q Params) (StepEvaluator, error) {
+	querier, ok := ev.querier.(RangeAggregationQuerier)
+	if !ok || !SummableRangeAggregation(expr) {
+		it, err := ev.querier.SelectSamples(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return rangeAggEvaluator(iter.NewPeekingSampleIterator(it), expr, q, expr.Left.Offset)
+	}
+
+	it, aggregated, err := querier.SelectSamplesAggregated(ctx, params, expr, q)
+	if err != nil {
+		return nil, err
+	}
+	local, err := rangeAggEvaluator(iter.NewPeekingSampleIterator(it), expr, q, expr.Left.Offset)
+	if err != nil || len(aggregated) == 0 {
+		return local, err
+	}
+	return newSummedStepEvaluator(local, aggregated), nil
+}
+
+// summedStepEvaluator sums the results of a matrix with the ones of a step evaluator, by series and by step.
+type summedStepEvaluator struct {
+	StepEvaluator
+	byStep map[int64]promql.Vector
+}
+
+func newSummedStepEvaluator(ev StepEvaluator, m promql.Matrix) *summedStepEvaluator {
+	byStep := map[int64]promql.Vector{}
+	for _, series := range m {
+		for _, p := range series.Points {
+			byStep[p.T] = append(byStep[p.T], promql.Sample{Point: p, Metric: series.Metric})
+		}
+	}
+	return &summedStepEvaluator{StepEvaluator: ev, byStep: byStep}
+}
+
+func (e *summedStepEvaluator) Next() (bool, int64, promql.Vector) {
+	ok, ts, vec := e.StepEvaluator.Next()
+	if !ok || len(e.byStep[ts]) == 0 {
+		return ok, ts, vec
+	}
+
+	bySeries := make(map[uint64]int, len(vec))
+	for i, s := range vec {
+		bySeries[s.Metric.Hash()] = i
+	}
+	for _, s := range e.byStep[ts] {
+		if i, found := bySeries[s.Metric.Hash()]; found {
+			vec[i].V += s.V
+			continue
+		}
+		vec = append(vec, s)
+	}
+	return ok, ts, vec
+}
+
 func vectorAggEvaluator(
 	ctx context.Context,
 	ev SampleEvaluator,
@@ -409,21 +460,26 @@ func rangeAggEvaluator(
 	q Params,
 	o time.Duration,
 ) (StepEvaluator, error) {
-	agg, err := expr.aggregator()
-	if err != nil {
-		return nil, err
-	}
 	iter := newRangeVectorIterator(
 		it,
 		expr.Left.Interval.Nanoseconds(),
 		q.Step().Nanoseconds(),
 		q.Start().UnixNano(), q.End().UnixNano(), o.Nanoseconds(),
 	)
-	if expr.Operation == OpRangeTypeAbsent {
+	switch expr.Operation {
+	case OpRangeTypeAbsent:
 		return &absentRangeVectorEvaluator{
 			iter: iter,
 			lbs:  absentLabels(expr),
 		}, nil
+	case OpRangeTypeQuantileSketch:
+		return &quantileSketchEvaluator{
+			iter: iter,
+		}, nil
+	}
+	agg, err := expr.aggregator()
+	if err != nil {
+		return nil, err
 	}
 	return &rangeVectorEvaluator{
 		iter: iter,
@@ -1,11 +1,18 @@
 package logql
 
 import (
+	"context"
 	"math"
 	"testing"
+	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
 )
 
 func TestDefaultEvaluator_DivideByZero(t *testing.T) {
@@ -240,3 +247,51 @@ func Test_MergeBinOpVectors_Filter(t *testing.T) {
 		Point: promql.Point{V: 2},
 	}, res)
 }
+
+// aggregatingQuerier returns the same aggregated results for all the summable range aggregations.
+type aggregatingQuerier struct {
+	Querier
+	aggregated promql.Matrix
+}
+
+func (q aggregatingQuerier) SelectSamplesAggregated(ctx context.Context, params SelectSampleParams, _ *RangeAggregationExpr, _ Params) (iter.SampleIterator, promql.Matrix, error) {
+	it, err := q.SelectSamples(ctx, params)
+	return it, q.aggregated, err
+}
+
+func TestDefaultEvaluator_RangeAggregationQuerier(t *testing.T) {
+	streams := []logproto.Stream{{
+		Labels:  `{app="foo"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "a"}, {Timestamp: time.Unix(2, 0), Line: "b"}},
+	}}
+	q := aggregatingQuerier{
+		Querier: NewMockQuerier(0, streams),
+		aggregated: promql.Matrix{
+			{Metric: labels.Labels{{Name: "app", Value: "foo"}}, Points: []promql.Point{{T: 10000, V: 3}}},
+			{Metric: labels.Labels{{Name: "app", Value: "bar"}}, Points: []promql.Point{{T: 10000, V: 1}}},
+		},
+	}
+	engine := NewEngine(EngineOpts{}, q, NoLimits)
+	ctx := user.InjectOrgID(context.Background(), "fake")
+
+	for _, tc := range []struct {
+		query    string
+		expected map[string]float64
+	}{
+		{`count_over_time({app="foo"}[10s])`, map[string]float64{`{app="foo"}`: 5, `{app="bar"}`: 1}},
+		// the aggregated results are summed before the vector aggregation reducing the labels of the samples.
+		{`sum(count_over_time({app="foo"}[10s]))`, map[string]float64{`{}`: 6}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			params := NewLiteralParams(tc.query, time.Unix(10, 0), time.Unix(10, 0), 0, 0, logproto.FORWARD, 100, nil)
+			res, err := engine.Query(params).Exec(ctx)
+			require.NoError(t, err)
+
+			actual := map[string]float64{}
+			for _, s := range res.Data.(promql.Vector) {
+				actual[s.Metric.String()] = s.V
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package logql
+
+import (
+	"fmt"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// ExplainExpr returns the tree of nodes making up the given expression.
+func ExplainExpr(expr Expr) *loghttp.ExplainNode {
+	n := &loghttp.ExplainNode{Expr: expr.String()}
+	switch e := expr.(type) {
+	case *MatchersExpr:
+		n.Type = "selector"
+	case *PipelineExpr:
+		n.Type = "pipeline"
+		n.Children = append(n.Children, ExplainExpr(e.Left))
+		for _, s := range e.MultiStages {
+			n.Children = append(n.Children, ExplainExpr(s))
+		}
+	case *LineFilterExpr:
+		n.Type = "line_filter"
+	case *LabelParserExpr:
+		n.Type = "parser"
+	case *JSONExpressionParser:
+		n.Type = "parser"
+	case *LabelFilterExpr:
+		n.Type = "label_filter"
+	case *LineFmtExpr:
+		n.Type = "line_format"
+	case *LabelFmtExpr:
+		n.Type = "label_format"
+	case *DropLabelsExpr:
+		n.Type = "drop_labels"
+	case *KeepLabelsExpr:
+		n.Type = "keep_labels"
+	case *DedupExpr:
+		n.Type = "dedup"
+	case *LookupExpr:
+		n.Type = "lookup"
+	case *LogRange:
+		n.Type = "log_range"
+		n.Children = append(n.Children, ExplainExpr(e.Left))
+	case *RangeAggregationExpr:
+		n.Type = fmt.Sprintf("range_aggregation(%s)", e.Operation)
+		n.Children = append(n.Children, ExplainExpr(e.Left))
+	case *VectorAggregationExpr:
+		n.Type = fmt.Sprintf("vector_aggregation(%s)", e.Operation)
+		n.Children = append(n.Children, ExplainExpr(e.Left))
+	case *BinOpExpr:
+		n.Type = fmt.Sprintf("binary_operation(%s)", e.Op)
+		n.Children = append(n.Children, ExplainExpr(e.SampleExpr), ExplainExpr(e.RHS))
+	case *LiteralExpr:
+		n.Type = "literal"
+	case *LabelReplaceExpr:
+		n.Type = "label_replace"
+		n.Children = append(n.Children, ExplainExpr(e.Left))
+	default:
+		n.Type = fmt.Sprintf("%T", expr)
+	}
+	return n
+}
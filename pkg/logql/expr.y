@@ -12,7 +12,7 @@ import (
 %union{
   Expr                    Expr
   Filter                  labels.MatchType
-  Grouping                *grouping
+  Grouping                *Grouping
   Labels                  []string
   LogExpr                 LogSelectorExpr
   LogRangeExpr            *LogRange
@@ -51,6 +51,10 @@ import (
   LabelFormatExpr         *LabelFmtExpr
   LabelFormat             log.LabelFmt
   LabelsFormat            []log.LabelFmt
+  DropLabelsExpr          *DropLabelsExpr
+  KeepLabelsExpr          *KeepLabelsExpr
+  DedupExpr               *DedupExpr
+  LookupExpr              *LookupExpr
   JSONExpressionParser    *JSONExpressionParser
   JSONExpression          log.JSONExpression
   JSONExpressionList      []log.JSONExpression
@@ -95,6 +99,10 @@ import (
 %type <LabelFormatExpr>       labelFormatExpr
 %type <LabelFormat>           labelFormat
 %type <LabelsFormat>          labelsFormat
+%type <DropLabelsExpr>        dropLabelsExpr
+%type <KeepLabelsExpr>        keepLabelsExpr
+%type <DedupExpr>             dedupExpr
+%type <LookupExpr>            lookupExpr
 %type <JSONExpressionParser>  jsonExpressionParser
 %type <JSONExpression>        jsonExpression
 %type <JSONExpressionList>    jsonExpressionList
@@ -108,9 +116,9 @@ import (
 %token <duration> DURATION RANGE
 %token <val>      MATCHERS LABELS EQ RE NRE OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT
                   OPEN_PARENTHESIS CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE SUM AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
-                  BYTES_OVER_TIME BYTES_RATE BOOL JSON REGEXP LOGFMT PIPE LINE_FMT LABEL_FMT UNWRAP AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME
+                  BYTES_OVER_TIME BYTES_RATE BOOL JSON REGEXP LOGFMT PIPE LINE_FMT LABEL_FMT DROP KEEP DEDUP LOOKUP UNWRAP AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME
                   MAX_OVER_TIME STDVAR_OVER_TIME STDDEV_OVER_TIME QUANTILE_OVER_TIME BYTES_CONV DURATION_CONV DURATION_SECONDS_CONV
-                  FIRST_OVER_TIME LAST_OVER_TIME ABSENT_OVER_TIME LABEL_REPLACE UNPACK OFFSET PATTERN IP ON IGNORING GROUP_LEFT GROUP_RIGHT
+                  FIRST_OVER_TIME LAST_OVER_TIME ABSENT_OVER_TIME QUANTILE_SKETCH_OVER_TIME LABEL_REPLACE UNPACK OFFSET PATTERN IP ON IGNORING GROUP_LEFT GROUP_RIGHT
 
 // Operators are listed with increasing precedence.
 %left <binOp> OR
@@ -245,6 +253,10 @@ pipelineStage:
   | PIPE labelFilter             { $$ = &LabelFilterExpr{LabelFilterer: $2 }}
   | PIPE lineFormatExpr          { $$ = $2 }
   | PIPE labelFormatExpr         { $$ = $2 }
+  | PIPE dropLabelsExpr          { $$ = $2 }
+  | PIPE keepLabelsExpr          { $$ = $2 }
+  | PIPE dedupExpr               { $$ = $2 }
+  | PIPE lookupExpr              { $$ = $2 }
   ;
 
 filterOp:
@@ -287,6 +299,14 @@ labelsFormat:
 
 labelFormatExpr: LABEL_FMT labelsFormat { $$ = newLabelFmtExpr($2) };
 
+dropLabelsExpr: DROP labels { $$ = newDropLabelsExpr($2) };
+
+keepLabelsExpr: KEEP labels { $$ = newKeepLabelsExpr($2) };
+
+dedupExpr: DEDUP DURATION { $$ = newDedupExpr($2) };
+
+lookupExpr: LOOKUP IDENTIFIER ON IDENTIFIER { $$ = newLookupExpr($2, $4) };
+
 labelFilter:
       matcher                                        { $$ = log.NewStringLabelFilter($1) }
     | ipLabelFilter                                       { $$ = $1 }
@@ -468,6 +488,7 @@ rangeOp:
     | FIRST_OVER_TIME    { $$ = OpRangeTypeFirst }
     | LAST_OVER_TIME     { $$ = OpRangeTypeLast }
     | ABSENT_OVER_TIME   { $$ = OpRangeTypeAbsent }
+    | QUANTILE_SKETCH_OVER_TIME { $$ = OpRangeTypeQuantileSketch }
     ;
 
 offsetExpr:
@@ -479,9 +500,9 @@ labels:
     ;
 
 grouping:
-      BY OPEN_PARENTHESIS labels CLOSE_PARENTHESIS        { $$ = &grouping{ without: false , groups: $3 } }
-    | WITHOUT OPEN_PARENTHESIS labels CLOSE_PARENTHESIS   { $$ = &grouping{ without: true , groups: $3 } }
-    | BY OPEN_PARENTHESIS CLOSE_PARENTHESIS               { $$ = &grouping{ without: false , groups: nil } }
-    | WITHOUT OPEN_PARENTHESIS CLOSE_PARENTHESIS          { $$ = &grouping{ without: true , groups: nil } }
+      BY OPEN_PARENTHESIS labels CLOSE_PARENTHESIS        { $$ = &Grouping{ Without: false , Groups: $3 } }
+    | WITHOUT OPEN_PARENTHESIS labels CLOSE_PARENTHESIS   { $$ = &Grouping{ Without: true , Groups: $3 } }
+    | BY OPEN_PARENTHESIS CLOSE_PARENTHESIS               { $$ = &Grouping{ Without: false , Groups: nil } }
+    | WITHOUT OPEN_PARENTHESIS CLOSE_PARENTHESIS          { $$ = &Grouping{ Without: true , Groups: nil } }
     ;
 %%
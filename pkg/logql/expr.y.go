@@ -4,7 +4,6 @@ package logql
 
 import __yyfmt__ "fmt"
 
-
 import (
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/prometheus/prometheus/model/labels"
@@ -54,6 +53,10 @@ type exprSymType struct {
 	LabelFormatExpr       *LabelFmtExpr
 	LabelFormat           log.LabelFmt
 	LabelsFormat          []log.LabelFmt
+	DropLabelsExpr        *DropLabelsExpr
+	KeepLabelsExpr        *KeepLabelsExpr
+	DedupExpr             *DedupExpr
+	LookupExpr            *LookupExpr
 	JSONExpressionParser  *JSONExpressionParser
 	JSONExpression        log.JSONExpression
 	JSONExpressionList    []log.JSONExpression
@@ -104,44 +107,49 @@ const LOGFMT = 57385
 const PIPE = 57386
 const LINE_FMT = 57387
 const LABEL_FMT = 57388
-const UNWRAP = 57389
-const AVG_OVER_TIME = 57390
-const SUM_OVER_TIME = 57391
-const MIN_OVER_TIME = 57392
-const MAX_OVER_TIME = 57393
-const STDVAR_OVER_TIME = 57394
-const STDDEV_OVER_TIME = 57395
-const QUANTILE_OVER_TIME = 57396
-const BYTES_CONV = 57397
-const DURATION_CONV = 57398
-const DURATION_SECONDS_CONV = 57399
-const FIRST_OVER_TIME = 57400
-const LAST_OVER_TIME = 57401
-const ABSENT_OVER_TIME = 57402
-const LABEL_REPLACE = 57403
-const UNPACK = 57404
-const OFFSET = 57405
-const PATTERN = 57406
-const IP = 57407
-const ON = 57408
-const IGNORING = 57409
-const GROUP_LEFT = 57410
-const GROUP_RIGHT = 57411
-const OR = 57412
-const AND = 57413
-const UNLESS = 57414
-const CMP_EQ = 57415
-const NEQ = 57416
-const LT = 57417
-const LTE = 57418
-const GT = 57419
-const GTE = 57420
-const ADD = 57421
-const SUB = 57422
-const MUL = 57423
-const DIV = 57424
-const MOD = 57425
-const POW = 57426
+const DROP = 57389
+const KEEP = 57390
+const DEDUP = 57391
+const LOOKUP = 57392
+const UNWRAP = 57393
+const AVG_OVER_TIME = 57394
+const SUM_OVER_TIME = 57395
+const MIN_OVER_TIME = 57396
+const MAX_OVER_TIME = 57397
+const STDVAR_OVER_TIME = 57398
+const STDDEV_OVER_TIME = 57399
+const QUANTILE_OVER_TIME = 57400
+const BYTES_CONV = 57401
+const DURATION_CONV = 57402
+const DURATION_SECONDS_CONV = 57403
+const FIRST_OVER_TIME = 57404
+const LAST_OVER_TIME = 57405
+const ABSENT_OVER_TIME = 57406
+const QUANTILE_SKETCH_OVER_TIME = 57407
+const LABEL_REPLACE = 57408
+const UNPACK = 57409
+const OFFSET = 57410
+const PATTERN = 57411
+const IP = 57412
+const ON = 57413
+const IGNORING = 57414
+const GROUP_LEFT = 57415
+const GROUP_RIGHT = 57416
+const OR = 57417
+const AND = 57418
+const UNLESS = 57419
+const CMP_EQ = 57420
+const NEQ = 57421
+const LT = 57422
+const LTE = 57423
+const GT = 57424
+const GTE = 57425
+const ADD = 57426
+const SUB = 57427
+const MUL = 57428
+const DIV = 57429
+const MOD = 57430
+const POW = 57431
 
 var exprToknames = [...]string{
 	"$end",
@@ -190,6 +198,10 @@ var exprToknames = [...]string{
 	"PIPE",
 	"LINE_FMT",
 	"LABEL_FMT",
+	"DROP",
+	"KEEP",
+	"DEDUP",
+	"LOOKUP",
 	"UNWRAP",
 	"AVG_OVER_TIME",
 	"SUM_OVER_TIME",
@@ -204,6 +216,7 @@ var exprToknames = [...]string{
 	"FIRST_OVER_TIME",
 	"LAST_OVER_TIME",
 	"ABSENT_OVER_TIME",
+	"QUANTILE_SKETCH_OVER_TIME",
 	"LABEL_REPLACE",
 	"UNPACK",
 	"OFFSET",
@@ -235,7 +248,6 @@ const exprEofCode = 1
 const exprErrCode = 2
 const exprInitialStackSize = 16
 
-
 var exprExca = [...]int{
 	-1, 1,
 	1, -1,
@@ -244,133 +256,137 @@ var exprExca = [...]int{
 
 const exprPrivate = 57344
 
-const exprLast = 532
+const exprLast = 555
 
 var exprAct = [...]int{
 
-	248, 195, 76, 4, 176, 58, 164, 5, 169, 204,
-	67, 112, 50, 57, 122, 135, 69, 2, 45, 46,
-	47, 48, 49, 50, 72, 42, 43, 44, 51, 52,
-	55, 56, 53, 54, 45, 46, 47, 48, 49, 50,
-	43, 44, 51, 52, 55, 56, 53, 54, 45, 46,
-	47, 48, 49, 50, 47, 48, 49, 50, 131, 133,
-	134, 65, 320, 100, 178, 133, 134, 104, 63, 64,
-	148, 149, 228, 124, 188, 229, 227, 146, 147, 139,
-	251, 251, 137, 61, 256, 144, 51, 52, 55, 56,
-	53, 54, 45, 46, 47, 48, 49, 50, 253, 145,
-	294, 320, 294, 150, 151, 152, 153, 154, 155, 156,
-	157, 158, 159, 160, 161, 162, 163, 323, 119, 85,
-	132, 66, 340, 173, 335, 184, 179, 182, 183, 180,
-	181, 328, 166, 226, 191, 253, 116, 253, 317, 77,
-	78, 186, 265, 101, 265, 202, 198, 311, 191, 310,
-	254, 196, 206, 207, 199, 65, 286, 252, 75, 65,
-	77, 78, 63, 64, 194, 302, 63, 64, 327, 65,
-	260, 275, 214, 215, 216, 254, 63, 64, 325, 257,
-	65, 194, 252, 65, 165, 197, 65, 63, 64, 197,
-	63, 64, 253, 63, 64, 246, 249, 301, 255, 197,
-	258, 137, 100, 261, 104, 262, 265, 304, 250, 247,
-	197, 309, 259, 197, 285, 66, 197, 253, 263, 66,
-	269, 271, 274, 276, 119, 279, 277, 65, 82, 66,
-	206, 265, 251, 119, 63, 64, 308, 284, 166, 206,
-	66, 295, 116, 66, 191, 119, 66, 166, 200, 273,
-	287, 116, 289, 291, 126, 293, 100, 60, 272, 166,
-	292, 303, 288, 116, 219, 100, 192, 224, 305, 187,
-	225, 223, 86, 87, 88, 89, 90, 91, 92, 93,
-	94, 95, 96, 97, 98, 99, 206, 66, 125, 314,
-	315, 297, 298, 299, 100, 316, 119, 283, 167, 165,
-	206, 318, 319, 265, 265, 270, 206, 324, 267, 266,
-	167, 165, 213, 136, 116, 119, 15, 212, 12, 208,
-	330, 12, 331, 332, 12, 205, 138, 211, 222, 138,
-	210, 185, 6, 116, 336, 143, 19, 20, 33, 34,
-	36, 37, 35, 38, 39, 40, 41, 21, 22, 142,
-	141, 107, 109, 108, 81, 117, 118, 23, 24, 25,
-	26, 27, 28, 29, 74, 338, 334, 30, 31, 32,
-	18, 203, 110, 307, 111, 264, 220, 217, 209, 12,
-	201, 193, 130, 221, 218, 333, 322, 6, 16, 17,
-	128, 19, 20, 33, 34, 36, 37, 35, 38, 39,
-	40, 41, 21, 22, 127, 243, 321, 129, 244, 242,
-	80, 300, 23, 24, 25, 26, 27, 28, 29, 290,
-	281, 282, 30, 31, 32, 18, 140, 240, 79, 237,
-	241, 239, 238, 236, 12, 234, 339, 231, 235, 233,
-	232, 230, 6, 16, 17, 119, 19, 20, 33, 34,
-	36, 37, 35, 38, 39, 40, 41, 21, 22, 3,
-	337, 326, 313, 116, 312, 278, 68, 23, 24, 25,
-	26, 27, 28, 29, 268, 245, 190, 30, 31, 32,
-	18, 107, 109, 108, 189, 117, 118, 256, 280, 188,
-	187, 177, 113, 174, 172, 171, 329, 71, 16, 17,
-	73, 306, 110, 170, 111, 73, 177, 114, 168, 103,
-	175, 106, 105, 59, 120, 115, 121, 102, 84, 83,
-	11, 10, 9, 123, 14, 8, 296, 13, 7, 70,
-	62, 1,
+	263, 209, 77, 4, 185, 59, 173, 5, 178, 187,
+	68, 117, 51, 58, 236, 144, 70, 2, 46, 47,
+	48, 49, 50, 51, 73, 43, 44, 45, 52, 53,
+	56, 57, 54, 55, 46, 47, 48, 49, 50, 51,
+	44, 45, 52, 53, 56, 57, 54, 55, 46, 47,
+	48, 49, 50, 51, 48, 49, 50, 51, 140, 142,
+	143, 157, 158, 266, 101, 155, 156, 66, 105, 66,
+	192, 142, 143, 271, 64, 65, 64, 65, 131, 335,
+	148, 268, 335, 146, 62, 310, 153, 52, 53, 56,
+	57, 54, 55, 46, 47, 48, 49, 50, 51, 211,
+	154, 86, 311, 266, 159, 160, 161, 162, 163, 164,
+	165, 166, 167, 168, 169, 170, 171, 172, 269, 66,
+	268, 78, 79, 66, 355, 141, 64, 65, 182, 128,
+	64, 65, 67, 318, 67, 189, 198, 193, 196, 197,
+	194, 195, 133, 175, 267, 102, 235, 121, 232, 61,
+	200, 326, 128, 211, 216, 212, 313, 314, 315, 208,
+	210, 218, 220, 213, 66, 243, 175, 202, 244, 242,
+	121, 64, 65, 269, 272, 128, 350, 128, 66, 268,
+	343, 227, 228, 229, 67, 64, 65, 342, 67, 175,
+	66, 175, 310, 121, 211, 121, 332, 64, 65, 176,
+	174, 76, 205, 78, 79, 340, 267, 338, 211, 261,
+	264, 320, 270, 188, 273, 146, 101, 276, 105, 277,
+	211, 317, 265, 262, 302, 235, 274, 268, 188, 67,
+	325, 241, 289, 283, 285, 288, 290, 208, 293, 291,
+	188, 268, 66, 67, 266, 176, 174, 287, 174, 64,
+	65, 128, 235, 235, 188, 67, 235, 324, 323, 286,
+	205, 281, 188, 301, 205, 303, 188, 305, 307, 121,
+	309, 101, 211, 284, 235, 308, 319, 304, 278, 280,
+	101, 221, 275, 321, 128, 219, 206, 112, 114, 113,
+	12, 122, 123, 124, 125, 126, 127, 271, 147, 300,
+	145, 128, 121, 353, 214, 329, 330, 67, 12, 135,
+	101, 331, 134, 115, 299, 116, 147, 333, 334, 121,
+	112, 114, 113, 339, 122, 123, 124, 125, 126, 127,
+	226, 15, 225, 224, 223, 345, 199, 346, 347, 12,
+	152, 151, 150, 82, 75, 349, 115, 6, 116, 351,
+	137, 19, 20, 34, 35, 37, 38, 36, 39, 40,
+	41, 42, 21, 22, 136, 322, 279, 138, 235, 233,
+	239, 230, 201, 240, 238, 217, 23, 24, 25, 26,
+	27, 28, 29, 12, 222, 139, 30, 31, 32, 33,
+	18, 6, 215, 207, 234, 19, 20, 34, 35, 37,
+	38, 36, 39, 40, 41, 42, 21, 22, 16, 17,
+	231, 258, 348, 255, 259, 257, 256, 254, 306, 149,
+	23, 24, 25, 26, 27, 28, 29, 12, 337, 336,
+	30, 31, 32, 33, 18, 6, 237, 83, 316, 19,
+	20, 34, 35, 37, 38, 36, 39, 40, 41, 42,
+	21, 22, 16, 17, 252, 190, 249, 253, 251, 250,
+	248, 295, 296, 354, 23, 24, 25, 26, 27, 28,
+	29, 81, 80, 3, 30, 31, 32, 33, 18, 352,
+	69, 341, 87, 88, 89, 90, 91, 92, 93, 94,
+	95, 96, 97, 98, 99, 100, 16, 17, 246, 328,
+	344, 247, 245, 327, 294, 292, 282, 186, 118, 260,
+	204, 203, 202, 201, 183, 181, 180, 72, 298, 297,
+	74, 179, 74, 191, 188, 186, 119, 177, 104, 111,
+	110, 109, 108, 184, 107, 106, 60, 129, 120, 130,
+	103, 85, 84, 11, 10, 9, 132, 14, 8, 312,
+	13, 7, 71, 63, 1,
 }
 var exprPact = [...]int{
 
-	309, -1000, -45, -1000, -1000, 213, 309, -1000, -1000, -1000,
-	-1000, -1000, 495, 341, 135, -1000, 421, 403, 331, -1000,
+	324, -1000, -50, -1000, -1000, 105, 324, -1000, -1000, -1000,
+	-1000, -1000, 515, 321, 178, -1000, 465, 464, 320, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, 79, 79, 79, 79, 79, 79, 79, 79,
-	79, 79, 79, 79, 79, 79, 79, 213, -1000, 47,
-	310, -1000, 8, -1000, -1000, -1000, -1000, 264, 230, -45,
-	388, 366, -1000, 46, 306, 419, 327, 326, 312, -1000,
-	-1000, 309, 309, 11, 2, -1000, 309, 309, 309, 309,
-	309, 309, 309, 309, 309, 309, 309, 309, 309, 309,
-	-1000, -1000, -1000, -1000, 228, -1000, -1000, 498, -1000, 489,
-	-1000, 488, -1000, -1000, -1000, -1000, 291, 487, 501, 52,
-	-1000, -1000, -1000, 308, -1000, -1000, -1000, -1000, -1000, 500,
-	-1000, 484, 483, 478, 470, 242, 362, 172, 303, 224,
-	361, 364, 301, 295, 359, -31, 307, 304, 294, 289,
-	13, 13, -27, -27, -72, -72, -72, -72, -61, -61,
-	-61, -61, -61, -61, 228, 291, 291, 291, 358, -1000,
-	372, -1000, -1000, 240, -1000, 357, -1000, 371, 263, 68,
-	433, 431, 425, 423, 401, 469, -1000, -1000, -1000, -1000,
-	-1000, -1000, 114, 303, 169, 148, 166, 440, 155, 146,
-	114, 309, 194, 356, 285, -1000, -1000, 284, -1000, 468,
-	281, 234, 225, 147, 219, 228, 113, 498, 459, -1000,
-	486, 415, 274, -1000, -1000, -1000, 214, -1000, -1000, -1000,
+	-1000, -1000, -1000, 61, 61, 61, 61, 61, 61, 61,
+	61, 61, 61, 61, 61, 61, 61, 61, 105, -1000,
+	53, 279, -1000, 72, -1000, -1000, -1000, -1000, 288, 285,
+	-50, 348, 369, -1000, 46, 293, 412, 319, 318, 317,
+	-1000, -1000, 324, 324, -6, -12, -1000, 324, 324, 324,
+	324, 324, 324, 324, 324, 324, 324, 324, 324, 324,
+	324, -1000, -1000, -1000, -1000, 170, -1000, -1000, -1000, -1000,
+	-1000, -1000, 516, -1000, 510, -1000, 509, -1000, -1000, -1000,
+	-1000, 296, 508, 520, 519, 519, 447, 518, 58, -1000,
+	-1000, -1000, 313, -1000, -1000, -1000, -1000, -1000, 517, -1000,
+	507, 506, 505, 504, 262, 374, 228, 275, 280, 373,
+	368, 261, 257, 365, -36, 311, 310, 309, 307, 9,
+	9, -32, -32, -77, -77, -77, -77, -66, -66, -66,
+	-66, -66, -66, 170, 296, 296, 296, 352, -1000, 398,
+	-1000, -1000, 124, -1000, 350, -1000, 382, 349, -1000, 349,
+	-1000, -57, 366, 161, 494, 452, 450, 409, 407, 503,
+	-1000, -1000, -1000, -1000, -1000, -1000, 96, 275, 176, 135,
+	164, 246, 150, 258, 96, 324, 254, 347, 255, -1000,
+	237, -1000, 500, 249, 235, 223, 208, 147, 170, 172,
+	516, 499, -1000, 502, 456, 514, 513, 291, -1000, -1000,
+	-1000, 276, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, 190, -1000, 132, 145, 54,
-	145, 411, 17, 291, 17, 91, 236, 402, 173, 141,
-	-1000, -1000, 183, -1000, 309, 496, -1000, -1000, 354, 212,
-	-1000, 187, -1000, -1000, 125, -1000, 123, -1000, -1000, -1000,
-	-1000, -1000, -1000, 458, 456, -1000, 114, 54, 145, 54,
-	-1000, -1000, 228, -1000, 17, -1000, 115, -1000, -1000, -1000,
-	18, 397, 377, 93, 114, 154, -1000, 455, -1000, -1000,
-	-1000, -1000, 144, 107, -1000, 54, -1000, 491, 57, 54,
-	37, 17, 17, 376, -1000, -1000, 347, -1000, -1000, 100,
-	54, -1000, -1000, 17, 454, -1000, -1000, 346, 430, 98,
-	-1000,
+	239, -1000, 200, 55, 37, 55, 410, -5, 296, -5,
+	76, 97, 429, 197, 109, -1000, -1000, 187, -1000, 324,
+	-1000, -1000, 346, 234, -1000, 233, -1000, -1000, 206, -1000,
+	127, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 497,
+	493, -1000, 96, 37, 55, 37, -1000, -1000, 170, -1000,
+	-5, -1000, 173, -1000, -1000, -1000, 35, 420, 419, 183,
+	96, 181, 475, -1000, -1000, -1000, -1000, 163, 156, -1000,
+	37, -1000, 495, 38, 37, 22, -5, -5, 403, -1000,
+	-1000, 326, -1000, -1000, 152, 37, -1000, -1000, -5, 473,
+	-1000, -1000, 284, 457, 100, -1000,
 }
 var exprPgo = [...]int{
 
-	0, 531, 16, 530, 2, 9, 459, 3, 15, 11,
-	529, 528, 527, 526, 7, 525, 524, 523, 522, 521,
-	520, 228, 519, 518, 517, 13, 5, 516, 515, 514,
-	6, 513, 83, 512, 511, 4, 510, 509, 8, 508,
-	1, 507, 492, 0,
+	0, 554, 16, 553, 2, 9, 473, 3, 15, 11,
+	552, 551, 550, 549, 7, 548, 547, 546, 545, 544,
+	543, 437, 542, 541, 540, 13, 5, 539, 538, 537,
+	6, 536, 84, 535, 534, 4, 533, 532, 531, 530,
+	529, 528, 8, 527, 1, 526, 508, 0,
 }
 var exprR1 = [...]int{
 
 	0, 1, 2, 2, 7, 7, 7, 7, 7, 7,
 	6, 6, 6, 8, 8, 8, 8, 8, 8, 8,
 	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
-	8, 8, 8, 8, 8, 8, 8, 8, 8, 40,
-	40, 40, 13, 13, 13, 11, 11, 11, 11, 15,
+	8, 8, 8, 8, 8, 8, 8, 8, 8, 44,
+	44, 44, 13, 13, 13, 11, 11, 11, 11, 15,
 	15, 15, 15, 15, 15, 20, 3, 3, 3, 3,
 	14, 14, 14, 10, 10, 9, 9, 9, 9, 25,
-	25, 26, 26, 26, 26, 26, 26, 17, 32, 32,
-	31, 31, 24, 24, 24, 24, 24, 37, 33, 35,
-	35, 36, 36, 36, 34, 30, 30, 30, 30, 30,
-	30, 30, 30, 30, 38, 39, 39, 42, 42, 41,
-	41, 29, 29, 29, 29, 29, 29, 29, 27, 27,
-	27, 27, 27, 27, 27, 28, 28, 28, 28, 28,
-	28, 28, 18, 18, 18, 18, 18, 18, 18, 18,
-	18, 18, 18, 18, 18, 18, 18, 22, 22, 23,
-	23, 23, 23, 21, 21, 21, 21, 21, 21, 21,
-	21, 19, 19, 19, 16, 16, 16, 16, 16, 16,
-	16, 16, 16, 12, 12, 12, 12, 12, 12, 12,
-	12, 12, 12, 12, 12, 12, 12, 43, 5, 5,
-	4, 4, 4, 4,
+	25, 26, 26, 26, 26, 26, 26, 26, 26, 26,
+	26, 17, 32, 32, 31, 31, 24, 24, 24, 24,
+	24, 41, 33, 35, 35, 36, 36, 36, 34, 37,
+	38, 39, 40, 30, 30, 30, 30, 30, 30, 30,
+	30, 30, 42, 43, 43, 46, 46, 45, 45, 29,
+	29, 29, 29, 29, 29, 29, 27, 27, 27, 27,
+	27, 27, 27, 28, 28, 28, 28, 28, 28, 28,
+	18, 18, 18, 18, 18, 18, 18, 18, 18, 18,
+	18, 18, 18, 18, 18, 22, 22, 23, 23, 23,
+	23, 21, 21, 21, 21, 21, 21, 21, 21, 19,
+	19, 19, 16, 16, 16, 16, 16, 16, 16, 16,
+	16, 12, 12, 12, 12, 12, 12, 12, 12, 12,
+	12, 12, 12, 12, 12, 12, 47, 5, 5, 4,
+	4, 4, 4,
 }
 var exprR2 = [...]int{
 
@@ -381,95 +397,98 @@ var exprR2 = [...]int{
 	6, 3, 1, 1, 1, 4, 6, 5, 7, 4,
 	5, 5, 6, 7, 7, 12, 1, 1, 1, 1,
 	3, 3, 3, 1, 3, 3, 3, 3, 3, 1,
-	2, 1, 2, 2, 2, 2, 2, 1, 2, 5,
-	1, 2, 1, 1, 2, 1, 2, 2, 2, 3,
-	3, 1, 3, 3, 2, 1, 1, 1, 1, 3,
-	2, 3, 3, 3, 3, 1, 3, 6, 6, 1,
-	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	2, 1, 2, 2, 2, 2, 2, 2, 2, 2,
+	2, 1, 2, 5, 1, 2, 1, 1, 2, 1,
+	2, 2, 2, 3, 3, 1, 3, 3, 2, 2,
+	2, 2, 4, 1, 1, 1, 1, 3, 2, 3,
+	3, 3, 3, 1, 3, 6, 6, 1, 1, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 4, 4, 4, 4, 4, 4, 4, 4,
-	4, 4, 4, 4, 4, 4, 4, 0, 1, 5,
-	4, 5, 4, 1, 1, 2, 4, 5, 2, 4,
-	5, 1, 2, 2, 1, 1, 1, 1, 1, 1,
+	4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
+	4, 4, 4, 4, 4, 0, 1, 5, 4, 5,
+	4, 1, 1, 2, 4, 5, 2, 4, 5, 1,
+	2, 2, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 1, 1, 1, 2, 1, 3,
-	4, 4, 3, 3,
+	1, 1, 1, 1, 1, 1, 2, 1, 3, 4,
+	4, 3, 3,
 }
 var exprChk = [...]int{
 
 	-1000, -1, -2, -6, -7, -14, 23, -11, -15, -18,
-	-19, -20, 15, -12, -16, 7, 79, 80, 61, 27,
-	28, 38, 39, 48, 49, 50, 51, 52, 53, 54,
-	58, 59, 60, 29, 30, 33, 31, 32, 34, 35,
-	36, 37, 70, 71, 72, 79, 80, 81, 82, 83,
-	84, 73, 74, 77, 78, 75, 76, -25, -26, -31,
-	44, -32, -3, 21, 22, 14, 74, -7, -6, -2,
-	-10, 2, -9, 5, 23, 23, -4, 25, 26, 7,
-	7, 23, -21, -22, -23, 40, -21, -21, -21, -21,
+	-19, -20, 15, -12, -16, 7, 84, 85, 66, 27,
+	28, 38, 39, 52, 53, 54, 55, 56, 57, 58,
+	62, 63, 64, 65, 29, 30, 33, 31, 32, 34,
+	35, 36, 37, 75, 76, 77, 84, 85, 86, 87,
+	88, 89, 78, 79, 82, 83, 80, 81, -25, -26,
+	-31, 44, -32, -3, 21, 22, 14, 79, -7, -6,
+	-2, -10, 2, -9, 5, 23, 23, -4, 25, 26,
+	7, 7, 23, -21, -22, -23, 40, -21, -21, -21,
 	-21, -21, -21, -21, -21, -21, -21, -21, -21, -21,
-	-26, -32, -24, -37, -30, -33, -34, 41, 43, 42,
-	62, 64, -9, -42, -41, -28, 23, 45, 46, 5,
-	-29, -27, 6, -17, 65, 24, 24, 16, 2, 19,
-	16, 12, 74, 13, 14, -8, 7, -14, 23, -7,
-	7, 23, 23, 23, -7, -2, 66, 67, 68, 69,
+	-21, -26, -32, -24, -41, -30, -33, -34, -37, -38,
+	-39, -40, 41, 43, 42, 67, 69, -9, -46, -45,
+	-28, 23, 45, 46, 47, 48, 49, 50, 5, -29,
+	-27, 6, -17, 70, 24, 24, 16, 2, 19, 16,
+	12, 79, 13, 14, -8, 7, -14, 23, -7, 7,
+	23, 23, 23, -7, -2, 71, 72, 73, 74, -2,
 	-2, -2, -2, -2, -2, -2, -2, -2, -2, -2,
-	-2, -2, -2, -2, -30, 71, 19, 70, -39, -38,
-	5, 6, 6, -30, 6, -36, -35, 5, 12, 74,
-	77, 78, 75, 76, 73, 23, -9, 6, 6, 6,
-	6, 2, 24, 19, 9, -40, -25, 44, -14, -8,
-	24, 19, -7, 7, -5, 24, 5, -5, 24, 19,
-	23, 23, 23, 23, -30, -30, -30, 19, 12, 24,
-	19, 12, 65, 8, 4, 7, 65, 8, 4, 7,
-	8, 4, 7, 8, 4, 7, 8, 4, 7, 8,
-	4, 7, 8, 4, 7, 6, -4, -8, -43, -40,
-	-25, 63, 9, 44, 9, -40, 47, 24, -40, -25,
-	24, -4, -7, 24, 19, 19, 24, 24, 6, -5,
-	24, -5, 24, 24, -5, 24, -5, -38, 6, -35,
-	2, 5, 6, 23, 23, 24, 24, -40, -25, -40,
-	8, -43, -30, -43, 9, 5, -13, 55, 56, 57,
-	9, 24, 24, -40, 24, -7, 5, 19, 24, 24,
-	24, 24, 6, 6, -4, -40, -43, 23, -43, -40,
-	44, 9, 9, 24, -4, 24, 6, 24, 24, 5,
-	-40, -43, -43, 9, 19, 24, -43, 6, 19, 6,
-	24,
+	-2, -2, -2, -30, 76, 19, 75, -43, -42, 5,
+	6, 6, -30, 6, -36, -35, 5, -5, 5, -5,
+	8, 5, 12, 79, 82, 83, 80, 81, 78, 23,
+	-9, 6, 6, 6, 6, 2, 24, 19, 9, -44,
+	-25, 44, -14, -8, 24, 19, -7, 7, -5, 24,
+	-5, 24, 19, 23, 23, 23, 23, -30, -30, -30,
+	19, 12, 24, 19, 12, 19, 71, 70, 8, 4,
+	7, 70, 8, 4, 7, 8, 4, 7, 8, 4,
+	7, 8, 4, 7, 8, 4, 7, 8, 4, 7,
+	6, -4, -8, -47, -44, -25, 68, 9, 44, 9,
+	-44, 51, 24, -44, -25, 24, -4, -7, 24, 19,
+	24, 24, 6, -5, 24, -5, 24, 24, -5, 24,
+	-5, -42, 6, -35, 2, 5, 6, 5, 5, 23,
+	23, 24, 24, -44, -25, -44, 8, -47, -30, -47,
+	9, 5, -13, 59, 60, 61, 9, 24, 24, -44,
+	24, -7, 19, 24, 24, 24, 24, 6, 6, -4,
+	-44, -47, 23, -47, -44, 44, 9, 9, 24, -4,
+	24, 6, 24, 24, 5, -44, -47, -47, 9, 19,
+	24, -47, 6, 19, 6, 24,
 }
 var exprDef = [...]int{
 
 	0, -2, 1, 2, 3, 10, 0, 4, 5, 6,
-	7, 8, 0, 0, 0, 161, 0, 0, 0, 173,
-	174, 175, 176, 177, 178, 179, 180, 181, 182, 183,
-	184, 185, 186, 164, 165, 166, 167, 168, 169, 170,
-	171, 172, 147, 147, 147, 147, 147, 147, 147, 147,
-	147, 147, 147, 147, 147, 147, 147, 11, 69, 71,
-	0, 80, 0, 56, 57, 58, 59, 3, 2, 0,
-	0, 0, 63, 0, 0, 0, 0, 0, 0, 162,
-	163, 0, 0, 153, 154, 148, 0, 0, 0, 0,
+	7, 8, 0, 0, 0, 169, 0, 0, 0, 181,
+	182, 183, 184, 185, 186, 187, 188, 189, 190, 191,
+	192, 193, 194, 195, 172, 173, 174, 175, 176, 177,
+	178, 179, 180, 155, 155, 155, 155, 155, 155, 155,
+	155, 155, 155, 155, 155, 155, 155, 155, 11, 69,
+	71, 0, 84, 0, 56, 57, 58, 59, 3, 2,
+	0, 0, 0, 63, 0, 0, 0, 0, 0, 0,
+	170, 171, 0, 0, 161, 162, 156, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	70, 81, 72, 73, 74, 75, 76, 82, 83, 0,
-	85, 0, 95, 96, 97, 98, 0, 0, 0, 0,
-	109, 110, 78, 0, 77, 9, 12, 60, 61, 0,
-	62, 0, 0, 0, 0, 0, 0, 0, 0, 3,
-	161, 0, 0, 0, 3, 132, 0, 0, 155, 158,
-	133, 134, 135, 136, 137, 138, 139, 140, 141, 142,
-	143, 144, 145, 146, 100, 0, 0, 0, 87, 105,
-	0, 84, 86, 0, 88, 94, 91, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 64, 65, 66, 67,
-	68, 38, 45, 0, 13, 0, 0, 0, 0, 0,
-	49, 0, 3, 161, 0, 192, 188, 0, 193, 0,
-	0, 0, 0, 0, 101, 102, 103, 0, 0, 99,
-	0, 0, 0, 116, 123, 130, 0, 115, 122, 129,
-	111, 118, 125, 112, 119, 126, 113, 120, 127, 114,
-	121, 128, 117, 124, 131, 0, 47, 0, 14, 17,
-	33, 0, 21, 0, 25, 0, 0, 0, 0, 0,
-	37, 51, 3, 50, 0, 0, 190, 191, 0, 0,
-	150, 0, 152, 156, 0, 159, 0, 106, 104, 92,
-	93, 89, 90, 0, 0, 79, 46, 18, 34, 35,
-	187, 22, 41, 26, 29, 39, 0, 42, 43, 44,
-	15, 0, 0, 0, 52, 3, 189, 0, 149, 151,
-	157, 160, 0, 0, 48, 36, 30, 0, 16, 19,
-	0, 23, 27, 0, 53, 54, 0, 107, 108, 0,
-	20, 24, 28, 31, 0, 40, 32, 0, 0, 0,
-	55,
+	0, 70, 85, 72, 73, 74, 75, 76, 77, 78,
+	79, 80, 86, 87, 0, 89, 0, 103, 104, 105,
+	106, 0, 0, 0, 0, 0, 0, 0, 0, 117,
+	118, 82, 0, 81, 9, 12, 60, 61, 0, 62,
+	0, 0, 0, 0, 0, 0, 0, 0, 3, 169,
+	0, 0, 0, 3, 140, 0, 0, 163, 166, 141,
+	142, 143, 144, 145, 146, 147, 148, 149, 150, 151,
+	152, 153, 154, 108, 0, 0, 0, 91, 113, 0,
+	88, 90, 0, 92, 98, 95, 0, 99, 197, 100,
+	101, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	64, 65, 66, 67, 68, 38, 45, 0, 13, 0,
+	0, 0, 0, 0, 49, 0, 3, 169, 0, 201,
+	0, 202, 0, 0, 0, 0, 0, 109, 110, 111,
+	0, 0, 107, 0, 0, 0, 0, 0, 124, 131,
+	138, 0, 123, 130, 137, 119, 126, 133, 120, 127,
+	134, 121, 128, 135, 122, 129, 136, 125, 132, 139,
+	0, 47, 0, 14, 17, 33, 0, 21, 0, 25,
+	0, 0, 0, 0, 0, 37, 51, 3, 50, 0,
+	199, 200, 0, 0, 158, 0, 160, 164, 0, 167,
+	0, 114, 112, 96, 97, 93, 94, 198, 102, 0,
+	0, 83, 46, 18, 34, 35, 196, 22, 41, 26,
+	29, 39, 0, 42, 43, 44, 15, 0, 0, 0,
+	52, 3, 0, 157, 159, 165, 168, 0, 0, 48,
+	36, 30, 0, 16, 19, 0, 23, 27, 0, 53,
+	54, 0, 115, 116, 0, 20, 24, 28, 31, 0,
+	40, 32, 0, 0, 0, 55,
 }
 var exprTok1 = [...]int{
 
@@ -485,7 +504,7 @@ var exprTok2 = [...]int{
 	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
 	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
 	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
-	82, 83, 84,
+	82, 83, 84, 85, 86, 87, 88, 89,
 }
 var exprTok3 = [...]int{
 	0,
@@ -497,7 +516,6 @@ var exprErrorMessages = [...]struct {
 	msg   string
 }{}
 
-
 /*	parser for yacc output	*/
 
 var (
@@ -1202,593 +1220,638 @@ exprdefault:
 			exprVAL.PipelineStage = exprDollar[2].LabelFormatExpr
 		}
 	case 77:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].DropLabelsExpr
+		}
+	case 78:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].KeepLabelsExpr
+		}
+	case 79:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].DedupExpr
+		}
+	case 80:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.PipelineStage = exprDollar[2].LookupExpr
+		}
+	case 81:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.FilterOp = OpFilterIP
 		}
-	case 78:
+	case 82:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, "", exprDollar[2].str)
 		}
-	case 79:
+	case 83:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, exprDollar[2].FilterOp, exprDollar[4].str)
 		}
-	case 80:
+	case 84:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LineFilters = exprDollar[1].LineFilter
 		}
-	case 81:
+	case 85:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LineFilters = newNestedLineFilterExpr(exprDollar[1].LineFilters, exprDollar[2].LineFilter)
 		}
-	case 82:
+	case 86:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeJSON, "")
 		}
-	case 83:
+	case 87:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeLogfmt, "")
 		}
-	case 84:
+	case 88:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeRegexp, exprDollar[2].str)
 		}
-	case 85:
+	case 89:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeUnpack, "")
 		}
-	case 86:
+	case 90:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypePattern, exprDollar[2].str)
 		}
-	case 87:
+	case 91:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.JSONExpressionParser = newJSONExpressionParser(exprDollar[2].JSONExpressionList)
 		}
-	case 88:
+	case 92:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LineFormatExpr = newLineFmtExpr(exprDollar[2].str)
 		}
-	case 89:
+	case 93:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFormat = log.NewRenameLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 90:
+	case 94:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFormat = log.NewTemplateLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 91:
+	case 95:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelsFormat = []log.LabelFmt{exprDollar[1].LabelFormat}
 		}
-	case 92:
+	case 96:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelsFormat = append(exprDollar[1].LabelsFormat, exprDollar[3].LabelFormat)
 		}
-	case 94:
+	case 98:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelFormatExpr = newLabelFmtExpr(exprDollar[2].LabelsFormat)
 		}
-	case 95:
+	case 99:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.DropLabelsExpr = newDropLabelsExpr(exprDollar[2].Labels)
+		}
+	case 100:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.KeepLabelsExpr = newKeepLabelsExpr(exprDollar[2].Labels)
+		}
+	case 101:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.DedupExpr = newDedupExpr(exprDollar[2].duration)
+		}
+	case 102:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.LookupExpr = newLookupExpr(exprDollar[2].str, exprDollar[4].str)
+		}
+	case 103:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewStringLabelFilter(exprDollar[1].Matcher)
 		}
-	case 96:
+	case 104:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[1].IPLabelFilter
 		}
-	case 97:
+	case 105:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[1].UnitFilter
 		}
-	case 98:
+	case 106:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[1].NumberFilter
 		}
-	case 99:
+	case 107:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = exprDollar[2].LabelFilter
 		}
-	case 100:
+	case 108:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[2].LabelFilter)
 		}
-	case 101:
+	case 109:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 102:
+	case 110:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 103:
+	case 111:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LabelFilter = log.NewOrLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 104:
+	case 112:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.JSONExpression = log.NewJSONExpr(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 105:
+	case 113:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.JSONExpressionList = []log.JSONExpression{exprDollar[1].JSONExpression}
 		}
-	case 106:
+	case 114:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.JSONExpressionList = append(exprDollar[1].JSONExpressionList, exprDollar[3].JSONExpression)
 		}
-	case 107:
+	case 115:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterEqual)
 		}
-	case 108:
+	case 116:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterNotEqual)
 		}
-	case 109:
+	case 117:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.UnitFilter = exprDollar[1].DurationFilter
 		}
-	case 110:
+	case 118:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.UnitFilter = exprDollar[1].BytesFilter
 		}
-	case 111:
+	case 119:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 112:
+	case 120:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 113:
+	case 121:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 114:
+	case 122:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 115:
+	case 123:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 116:
+	case 124:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 117:
+	case 125:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 118:
+	case 126:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 119:
+	case 127:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 120:
+	case 128:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 121:
+	case 129:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 122:
+	case 130:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 123:
+	case 131:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 124:
+	case 132:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 125:
+	case 133:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 126:
+	case 134:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 127:
+	case 135:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 128:
+	case 136:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 129:
+	case 137:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 130:
+	case 138:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 131:
+	case 139:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
 		}
-	case 132:
+	case 140:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("or", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 133:
+	case 141:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("and", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 134:
+	case 142:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("unless", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 135:
+	case 143:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("+", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 136:
+	case 144:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("-", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 137:
+	case 145:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("*", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 138:
+	case 146:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("/", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 139:
+	case 147:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("%", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 140:
+	case 148:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("^", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 141:
+	case 149:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("==", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 142:
+	case 150:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("!=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 143:
+	case 151:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 144:
+	case 152:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 145:
+	case 153:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 146:
+	case 154:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 147:
+	case 155:
 		exprDollar = exprS[exprpt-0 : exprpt+1]
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}}
 		}
-	case 148:
+	case 156:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}, ReturnBool: true}
 		}
-	case 149:
+	case 157:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 150:
+	case 158:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 		}
-	case 151:
+	case 159:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 152:
+	case 160:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 		}
-	case 153:
+	case 161:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].BoolModifier
 		}
-	case 154:
+	case 162:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 		}
-	case 155:
+	case 163:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 156:
+	case 164:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 157:
+	case 165:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 158:
+	case 166:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 159:
+	case 167:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 160:
+	case 168:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 161:
+	case 169:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[1].str, false)
 		}
-	case 162:
+	case 170:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, false)
 		}
-	case 163:
+	case 171:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, true)
 		}
-	case 164:
+	case 172:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeSum
 		}
-	case 165:
+	case 173:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeAvg
 		}
-	case 166:
+	case 174:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeCount
 		}
-	case 167:
+	case 175:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeMax
 		}
-	case 168:
+	case 176:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeMin
 		}
-	case 169:
+	case 177:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeStddev
 		}
-	case 170:
+	case 178:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeStdvar
 		}
-	case 171:
+	case 179:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeBottomK
 		}
-	case 172:
+	case 180:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeTopK
 		}
-	case 173:
+	case 181:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeCount
 		}
-	case 174:
+	case 182:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeRate
 		}
-	case 175:
+	case 183:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeBytes
 		}
-	case 176:
+	case 184:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeBytesRate
 		}
-	case 177:
+	case 185:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeAvg
 		}
-	case 178:
+	case 186:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeSum
 		}
-	case 179:
+	case 187:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeMin
 		}
-	case 180:
+	case 188:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeMax
 		}
-	case 181:
+	case 189:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeStdvar
 		}
-	case 182:
+	case 190:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeStddev
 		}
-	case 183:
+	case 191:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeQuantile
 		}
-	case 184:
+	case 192:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeFirst
 		}
-	case 185:
+	case 193:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeLast
 		}
-	case 186:
+	case 194:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeAbsent
 		}
-	case 187:
+	case 195:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeQuantileSketch
+		}
+	case 196:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.OffsetExpr = newOffsetExpr(exprDollar[2].duration)
 		}
-	case 188:
+	case 197:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Labels = []string{exprDollar[1].str}
 		}
-	case 189:
+	case 198:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
 		}
-	case 190:
+	case 199:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: exprDollar[3].Labels}
 		}
-	case 191:
+	case 200:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: exprDollar[3].Labels}
 		}
-	case 192:
+	case 201:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: nil}
 		}
-	case 193:
+	case 202:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: nil}
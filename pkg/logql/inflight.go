@@ -0,0 +1,150 @@
+package logql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/weaveworks/common/user"
+)
+
+// InflightQuery describes a query being executed.
+type InflightQuery struct {
+	ID             string    `json:"id"`
+	Tenant         string    `json:"tenant"`
+	Query          string    `json:"query"`
+	Start          time.Time `json:"start"`
+	Elapsed        string    `json:"elapsed"`
+	BytesProcessed int64     `json:"bytes_processed"`
+}
+
+type inflightQuery struct {
+	id     string
+	tenant string
+	query  string
+	start  time.Time
+	bytes  func() int64
+	cancel context.CancelFunc
+}
+
+// InflightQueries tracks the queries being executed by a component, to list and cancel them during an incident.
+// A nil *InflightQueries tracks nothing.
+type InflightQueries struct {
+	mtx     sync.Mutex
+	queries map[string]*inflightQuery
+	nextID  uint64
+	now     func() time.Time
+}
+
+// NewInflightQueries returns an empty tracker of queries.
+func NewInflightQueries() *InflightQueries {
+	return &InflightQueries{
+		queries: map[string]*inflightQuery{},
+		now:     time.Now,
+	}
+}
+
+// Track registers a query of the tenant of the context until the returned function is called. The returned context is
+// canceled when the query is canceled with Cancel. bytes returns the bytes processed by the query so far.
+func (q *InflightQueries) Track(ctx context.Context, query string, bytes func() int64) (context.Context, func()) {
+	if q == nil {
+		return ctx, func() {}
+	}
+	// The tenant is informative, a missing one is listed as empty.
+	tenant, _ := user.ExtractOrgID(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+
+	q.mtx.Lock()
+	q.nextID++
+	iq := &inflightQuery{
+		id:     strconv.FormatUint(q.nextID, 10),
+		tenant: tenant,
+		query:  query,
+		start:  q.now(),
+		bytes:  bytes,
+		cancel: cancel,
+	}
+	q.queries[iq.id] = iq
+	q.mtx.Unlock()
+
+	return ctx, func() {
+		q.mtx.Lock()
+		delete(q.queries, iq.id)
+		q.mtx.Unlock()
+		cancel()
+	}
+}
+
+// List returns the queries being executed, the oldest first, only the ones of the tenant if not empty.
+func (q *InflightQueries) List(tenant string) []InflightQuery {
+	q.mtx.Lock()
+	queries := make([]*inflightQuery, 0, len(q.queries))
+	for _, iq := range q.queries {
+		if tenant == "" || iq.tenant == tenant {
+			queries = append(queries, iq)
+		}
+	}
+	now := q.now()
+	q.mtx.Unlock()
+
+	res := make([]InflightQuery, 0, len(queries))
+	for _, iq := range queries {
+		res = append(res, InflightQuery{
+			ID:             iq.id,
+			Tenant:         iq.tenant,
+			Query:          iq.query,
+			Start:          iq.start,
+			Elapsed:        now.Sub(iq.start).String(),
+			BytesProcessed: iq.bytes(),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Start.Before(res[j].Start) })
+	return res
+}
+
+// Cancel cancels the context of the query with the given ID, returning false if no such query is being executed.
+func (q *InflightQueries) Cancel(id string) bool {
+	q.mtx.Lock()
+	iq, ok := q.queries[id]
+	q.mtx.Unlock()
+	if !ok {
+		return false
+	}
+	iq.cancel()
+	level.Info(util_log.Logger).Log("msg", "inflight query canceled", "id", iq.id, "tenant", iq.tenant, "query", iq.query)
+	return true
+}
+
+// Handler lists or cancels the queries being executed.
+//   - GET returns the queries, only the ones of the tenant of the "tenant" parameter if given.
+//   - DELETE cancels the query of the "id" parameter.
+func (q *InflightQueries) Handler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(q.List(r.FormValue("tenant"))); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+	case http.MethodDelete:
+		id := r.FormValue("id")
+		if id == "" {
+			http.Error(w, "missing id parameter", http.StatusBadRequest)
+			return
+		}
+		if !q.Cancel(id) {
+			http.Error(w, "no inflight query with id "+id, http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
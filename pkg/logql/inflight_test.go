@@ -0,0 +1,86 @@
+package logql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+// blockingQuerier processes some bytes then blocks until the query is canceled.
+type blockingQuerier struct {
+	started chan struct{}
+}
+
+func (q *blockingQuerier) SelectLogs(ctx context.Context, _ SelectLogParams) (iter.EntryIterator, error) {
+	stats.FromContext(ctx).AddDecompressedBytes(10)
+	close(q.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (q *blockingQuerier) SelectSamples(ctx context.Context, _ SelectSampleParams) (iter.SampleIterator, error) {
+	return nil, nil
+}
+
+func TestInflightQueries(t *testing.T) {
+	inflight := NewInflightQueries()
+	querier := &blockingQuerier{started: make(chan struct{})}
+	eng := NewEngine(EngineOpts{Inflight: inflight}, querier, NoLimits)
+
+	errs := make(chan error)
+	go func() {
+		_, err := eng.Query(LiteralParams{
+			qs:        `{app="foo"}`,
+			start:     time.Now(),
+			end:       time.Now(),
+			direction: logproto.BACKWARD,
+			limit:     1000,
+		}).Exec(user.InjectOrgID(context.Background(), "fake"))
+		errs <- err
+	}()
+	<-querier.started
+
+	do := func(method, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		inflight.Handler(w, httptest.NewRequest(method, "/querier/inflight?"+query, nil))
+		return w
+	}
+	list := func(query string) []InflightQuery {
+		w := do(http.MethodGet, query)
+		require.Equal(t, http.StatusOK, w.Code)
+		var queries []InflightQuery
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &queries))
+		return queries
+	}
+
+	queries := list("")
+	require.Len(t, queries, 1)
+	require.Equal(t, "fake", queries[0].Tenant)
+	require.Equal(t, `{app="foo"}`, queries[0].Query)
+	require.Equal(t, int64(10), queries[0].BytesProcessed)
+	require.Len(t, list("tenant=fake"), 1)
+	require.Empty(t, list("tenant=other"))
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "").Code)
+	require.Equal(t, http.StatusNotFound, do(http.MethodDelete, "id=unknown").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "id="+queries[0].ID).Code)
+	require.ErrorIs(t, <-errs, context.Canceled)
+	require.Empty(t, list(""))
+}
+
+func TestInflightQueries_Nil(t *testing.T) {
+	var inflight *InflightQueries
+	ctx, done := inflight.Track(context.Background(), `{app="foo"}`, func() int64 { return 0 })
+	done()
+	require.NoError(t, ctx.Err())
+}
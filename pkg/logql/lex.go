@@ -73,6 +73,17 @@ var tokens = map[string]int{
 	OpFilterIP: IP,
 }
 
+// stageTokens are tokens of pipeline stages which are only keywords right after a pipe and
+// when not compared like in a label filter, so that they remain valid label names.
+var stageTokens = map[string]int{
+	// labels
+	OpDropLabels: DROP,
+	OpKeepLabels: KEEP,
+
+	OpDedup:  DEDUP,
+	OpLookup: LOOKUP,
+}
+
 // functionTokens are tokens that needs to be suffixes with parenthesis
 var functionTokens = map[string]int{
 	// range vec ops
@@ -91,6 +102,9 @@ var functionTokens = map[string]int{
 	OpRangeTypeLast:      LAST_OVER_TIME,
 	OpRangeTypeAbsent:    ABSENT_OVER_TIME,
 
+	// internal range vec ops used by sharding
+	OpRangeTypeQuantileSketch: QUANTILE_SKETCH_OVER_TIME,
+
 	// vec ops
 	OpTypeSum:      SUM,
 	OpTypeAvg:      AVG,
@@ -116,9 +130,16 @@ type lexer struct {
 	scanner.Scanner
 	errs    []logqlmodel.ParseError
 	builder strings.Builder
+	last    int
 }
 
 func (l *lexer) Lex(lval *exprSymType) int {
+	tok := l.lex(lval)
+	l.last = tok
+	return tok
+}
+
+func (l *lexer) lex(lval *exprSymType) int {
 	r := l.Scan()
 
 	switch r {
@@ -127,7 +148,7 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		for next := l.Peek(); !(next == '\n' || next == scanner.EOF); next = l.Next() {
 		}
 
-		return l.Lex(lval)
+		return l.lex(lval)
 
 	case scanner.EOF:
 		return 0
@@ -213,10 +234,25 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return tok
 	}
 
+	if tok, ok := stageTokens[tokenText]; ok && l.last == PIPE && !isComparison(l.Scanner) {
+		return tok
+	}
+
 	lval.str = tokenText
 	return IDENTIFIER
 }
 
+// isComparison returns true if the next token is a comparison operator.
+func isComparison(sc scanner.Scanner) bool {
+	var r rune
+	for r = sc.Next(); r != scanner.EOF; r = sc.Next() {
+		if !unicode.IsSpace(r) {
+			break
+		}
+	}
+	return strings.ContainsRune("=!<>", r)
+}
+
 func (l *lexer) Error(msg string) {
 	l.errs = append(l.errs, logqlmodel.NewParseError(msg, l.Line, l.Column))
 }
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"github.com/grafana/loki/pkg/logqlmodel"
+)
+
+// DropLabels is a stage removing the given labels.
+type DropLabels struct {
+	names []string
+}
+
+// NewDropLabels creates a stage removing the given labels.
+// Dropping the error label clears the error.
+func NewDropLabels(names []string) *DropLabels {
+	return &DropLabels{names: names}
+}
+
+func (d *DropLabels) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	for _, name := range d.names {
+		if name == logqlmodel.ErrorLabel {
+			lbs.SetErr("")
+			continue
+		}
+		lbs.Del(name)
+	}
+	return line, true
+}
+
+func (d *DropLabels) RequiredLabelNames() []string { return []string{} }
+
+// KeepLabels is a stage removing all labels but the given ones.
+type KeepLabels struct {
+	names []string
+}
+
+// NewKeepLabels creates a stage removing all labels but the given ones.
+// The error label is always kept.
+func NewKeepLabels(names []string) *KeepLabels {
+	return &KeepLabels{names: names}
+}
+
+func (k *KeepLabels) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	for _, l := range lbs.Labels() {
+		if l.Name == logqlmodel.ErrorLabel || k.keep(l.Name) {
+			continue
+		}
+		lbs.Del(l.Name)
+	}
+	return line, true
+}
+
+func (k *KeepLabels) keep(name string) bool {
+	for _, n := range k.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *KeepLabels) RequiredLabelNames() []string { return uniqueString(k.names) }
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logqlmodel"
+)
+
+func Test_DropKeepLabels(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage Stage
+		lbs   labels.Labels
+		err   string
+
+		want labels.Labels
+	}{
+		{
+			"drop",
+			NewDropLabels([]string{"instance", "pod"}),
+			labels.Labels{{Name: "app", Value: "foo"}, {Name: "instance", Value: "bar"}, {Name: "pod", Value: "buzz"}},
+			"",
+			labels.Labels{{Name: "app", Value: "foo"}},
+		},
+		{
+			"drop missing",
+			NewDropLabels([]string{"pod"}),
+			labels.Labels{{Name: "app", Value: "foo"}},
+			"",
+			labels.Labels{{Name: "app", Value: "foo"}},
+		},
+		{
+			"drop error",
+			NewDropLabels([]string{logqlmodel.ErrorLabel}),
+			labels.Labels{{Name: "app", Value: "foo"}},
+			errJSON,
+			labels.Labels{{Name: "app", Value: "foo"}},
+		},
+		{
+			"keep",
+			NewKeepLabels([]string{"job", "level"}),
+			labels.Labels{{Name: "job", Value: "foo"}, {Name: "level", Value: "error"}, {Name: "pod", Value: "buzz"}},
+			"",
+			labels.Labels{{Name: "job", Value: "foo"}, {Name: "level", Value: "error"}},
+		},
+		{
+			"keep with error",
+			NewKeepLabels([]string{"job"}),
+			labels.Labels{{Name: "job", Value: "foo"}, {Name: "pod", Value: "buzz"}},
+			errJSON,
+			labels.Labels{{Name: logqlmodel.ErrorLabel, Value: errJSON}, {Name: "job", Value: "foo"}},
+		},
+		{
+			"keep none",
+			NewKeepLabels([]string{"level"}),
+			labels.Labels{{Name: "job", Value: "foo"}},
+			"",
+			labels.Labels{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			b.SetErr(tt.err)
+			_, ok := tt.stage.Process(0, []byte("line"), b)
+			require.True(t, ok)
+			sort.Sort(tt.want)
+			require.Equal(t, tt.want, b.Labels())
+		})
+	}
+}
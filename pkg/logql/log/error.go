@@ -4,6 +4,7 @@ var (
 	// Possible errors thrown by a log pipeline.
 	errJSON             = "JSONParserErr"
 	errLogfmt           = "LogfmtParserErr"
+	errPattern          = "PatternParserErr"
 	errSampleExtraction = "SampleExtractionErr"
 	errLabelFilter      = "LabelFilterErr"
 	errTemplateFormat   = "TemplateFormatErr"
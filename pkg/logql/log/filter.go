@@ -24,6 +24,47 @@ func (f FiltererFunc) Filter(line []byte) bool {
 	return f(line)
 }
 
+// lineFilterStage is the stage of a line filter, it never changes the lines.
+type lineFilterStage struct {
+	Filterer
+}
+
+func (s lineFilterStage) Process(_ int64, line []byte, _ *LabelsBuilder) ([]byte, bool) {
+	return line, s.Filter(line)
+}
+
+func (lineFilterStage) RequiredLabelNames() []string { return []string{} }
+
+func (s lineFilterStage) requiredLineSubstrings() ([][]byte, bool) {
+	return requiredSubstrings(s.Filterer), true
+}
+
+// requiredSubstrings returns substrings that every line matching the filter contains.
+// Case insensitive matches are left out since they don't tell the exact bytes of the lines.
+func requiredSubstrings(f Filterer) [][]byte {
+	var res [][]byte
+	switch f := f.(type) {
+	case *containsFilter:
+		if !f.caseInsensitive {
+			res = append(res, f.match)
+		}
+	case *containsAllFilter:
+		return requiredSubstrings(*f)
+	case containsAllFilter:
+		for i := range f.matches {
+			res = append(res, requiredSubstrings(&f.matches[i])...)
+		}
+	case andFilter:
+		res = append(res, requiredSubstrings(f.left)...)
+		res = append(res, requiredSubstrings(f.right)...)
+	case andFilters:
+		for _, filter := range f.filters {
+			res = append(res, requiredSubstrings(filter)...)
+		}
+	}
+	return res
+}
+
 type trueFilter struct{}
 
 func (trueFilter) Filter(_ []byte) bool { return true }
@@ -41,11 +82,7 @@ func (n notFilter) Filter(line []byte) bool {
 }
 
 func (n notFilter) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, n.Filter(line)
-		},
-	}
+	return lineFilterStage{n}
 }
 
 // newNotFilter creates a new filter which matches only if the base filter doesn't match.
@@ -85,11 +122,7 @@ func (a andFilter) Filter(line []byte) bool {
 }
 
 func (a andFilter) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, a.Filter(line)
-		},
-	}
+	return lineFilterStage{a}
 }
 
 type andFilters struct {
@@ -162,11 +195,7 @@ func (a andFilters) Filter(line []byte) bool {
 }
 
 func (a andFilters) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, a.Filter(line)
-		},
-	}
+	return lineFilterStage{a}
 }
 
 type orFilter struct {
@@ -203,11 +232,7 @@ func (a orFilter) Filter(line []byte) bool {
 }
 
 func (a orFilter) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, a.Filter(line)
-		},
-	}
+	return lineFilterStage{a}
 }
 
 type regexpFilter struct {
@@ -233,11 +258,7 @@ func (r regexpFilter) Filter(line []byte) bool {
 }
 
 func (r regexpFilter) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, r.Filter(line)
-		},
-	}
+	return lineFilterStage{r}
 }
 
 type containsFilter struct {
@@ -297,11 +318,7 @@ func containsLower(line, substr []byte) bool {
 }
 
 func (l containsFilter) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, l.Filter(line)
-		},
-	}
+	return lineFilterStage{&l}
 }
 
 func (l containsFilter) String() string {
@@ -344,11 +361,7 @@ func (f containsAllFilter) Filter(line []byte) bool {
 }
 
 func (f containsAllFilter) ToStage() Stage {
-	return StageFunc{
-		process: func(line []byte, _ *LabelsBuilder) ([]byte, bool) {
-			return line, f.Filter(line)
-		},
-	}
+	return lineFilterStage{f}
 }
 
 // NewFilter creates a new line filter from a match string and type.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
@@ -234,13 +235,46 @@ func Test_lineFormatter_Format(t *testing.T) {
 			sort.Sort(tt.wantLbs)
 			builder := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
 			builder.Reset()
-			outLine, _ := tt.fmter.Process(nil, builder)
+			outLine, _ := tt.fmter.Process(0, nil, builder)
 			require.Equal(t, tt.want, outLine)
 			require.Equal(t, tt.wantLbs, builder.Labels())
 		})
 	}
 }
 
+func Test_formatters_LineAndTimestamp(t *testing.T) {
+	ts := time.Date(2021, 11, 2, 10, 30, 0, 0, time.UTC).UnixNano()
+	line := []byte("GET /healthz")
+	lbs := labels.Labels{{Name: "app", Value: "nginx"}}
+
+	for _, tc := range []struct {
+		tmpl string
+		want string
+	}{
+		{`{{ __line__ }}`, "GET /healthz"},
+		{`{{ .app }}: {{ __line__ | lower }}`, "nginx: get /healthz"},
+		{`{{ __timestamp__ | date "2006-01-02" }}`, "2021-11-02"},
+		{`{{ __timestamp__ | unixEpoch }}`, "1635849000"},
+		{`{{ .missing | default "none" }}`, "none"},
+		{`{{ .app | default "none" }}`, "nginx"},
+	} {
+		t.Run(tc.tmpl, func(t *testing.T) {
+			builder := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
+			builder.Reset()
+			outLine, ok := newMustLineFormatter(tc.tmpl).Process(ts, line, builder)
+			require.True(t, ok)
+			require.Equal(t, tc.want, string(outLine))
+
+			builder.Reset()
+			outLine, ok = mustNewLabelsFormatter([]LabelFmt{NewTemplateLabelFmt("out", tc.tmpl)}).Process(ts, line, builder)
+			require.True(t, ok)
+			require.Equal(t, line, outLine)
+			out, _ := builder.Get("out")
+			require.Equal(t, tc.want, out)
+		})
+	}
+}
+
 func newMustLineFormatter(tmpl string) *LineFormatter {
 	l, err := NewFormatter(tmpl)
 	if err != nil {
@@ -293,7 +327,7 @@ func Test_labelsFormatter_Format(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			builder := NewBaseLabelsBuilder().ForLabels(tt.in, tt.in.Hash())
 			builder.Reset()
-			_, _ = tt.fmter.Process(nil, builder)
+			_, _ = tt.fmter.Process(0, nil, builder)
 			sort.Sort(tt.want)
 			require.Equal(t, tt.want, builder.Labels())
 		})
@@ -21,6 +21,11 @@ const (
 	IPv6Charset = "0123456789abcdefABCDEF:."
 )
 
+var (
+	ipv4Charset = newCharset(IPv4Charset)
+	ipv6Charset = newCharset(IPv6Charset)
+)
+
 // Should be one of the netaddr.IP, netaddr.IPRange, netadd.IPPrefix.
 type IPMatcher interface{}
 
@@ -59,7 +64,7 @@ func (f *IPLineFilter) ToStage() Stage {
 }
 
 // `Process` implements `Stage` interface
-func (f *IPLineFilter) Process(line []byte, _ *LabelsBuilder) ([]byte, bool) {
+func (f *IPLineFilter) Process(_ int64, line []byte, _ *LabelsBuilder) ([]byte, bool) {
 	return line, f.filterTy(line, f.ty)
 }
 
@@ -68,6 +73,10 @@ func (f *IPLineFilter) RequiredLabelNames() []string {
 	return []string{} // empty for line filter
 }
 
+func (f *IPLineFilter) requiredLineSubstrings() ([][]byte, bool) {
+	return nil, true // lines are kept unchanged
+}
+
 func (f *IPLineFilter) filterTy(line []byte, ty labels.MatchType) bool {
 	if ty == labels.MatchNotEqual {
 		return !f.ip.filter(line)
@@ -103,7 +112,7 @@ func NewIPLabelFilter(pattern string, label string, ty LabelFilterType) *IPLabel
 }
 
 // `Process` implements `Stage` interface
-func (f *IPLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+func (f *IPLabelFilter) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
 	return line, f.filterTy(line, f.ty, lbs)
 }
 
@@ -185,8 +194,8 @@ func (f *ipFilter) filter(line []byte) bool {
 
 	n := len(line)
 
-	filterFn := func(line []byte, start int, charset string) (bool, int) {
-		iplen := bytesSpan(line[start:], []byte(charset))
+	filterFn := func(line []byte, start int, charset *charset) (bool, int) {
+		iplen := bytesSpan(line[start:], charset)
 		if iplen < 0 {
 			return false, 0
 		}
@@ -203,7 +212,7 @@ func (f *ipFilter) filter(line []byte) bool {
 	// It uses IPv4 and IPv6 prefix hints to find the IP addresses faster without using regexp.
 	for i := 0; i < n; i++ {
 		if i+3 < n && ipv4Hint([4]byte{line[i], line[i+1], line[i+2], line[i+3]}) {
-			ok, iplen := filterFn(line, i, IPv4Charset)
+			ok, iplen := filterFn(line, i, ipv4Charset)
 			if ok {
 				return true
 			}
@@ -212,7 +221,7 @@ func (f *ipFilter) filter(line []byte) bool {
 		}
 
 		if i+4 < n && ipv6Hint([5]byte{line[i], line[i+1], line[i+2], line[i+3], line[i+4]}) {
-			ok, iplen := filterFn(line, i, IPv6Charset)
+			ok, iplen := filterFn(line, i, ipv6Charset)
 			if ok {
 				return true
 			}
@@ -276,21 +285,26 @@ func isHexDigit(r byte) bool {
 	return unicode.IsDigit(rune(r)) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
 }
 
-// bytesSpan is same as C's `strcspan()` function.
+// bytesSpan is same as C's `strspn()` function.
 // It returns the number of chars in the initial segment of `s`
 // which consist only of chars from `accept`.
-func bytesSpan(s, accept []byte) int {
-	m := make(map[byte]bool)
-
-	for _, r := range accept {
-		m[r] = true
-	}
-
+func bytesSpan(s []byte, accept *charset) int {
 	for i, r := range s {
-		if !m[r] {
+		if !accept[r] {
 			return i
 		}
 	}
 
 	return len(s)
 }
+
+// charset is a lookup table of the bytes accepted by `bytesSpan`.
+type charset [256]bool
+
+func newCharset(chars string) *charset {
+	var c charset
+	for i := 0; i < len(chars); i++ {
+		c[chars[i]] = true
+	}
+	return &c
+}
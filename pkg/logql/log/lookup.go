@@ -0,0 +1,53 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// LookupTable maps keys to the labels of their row.
+type LookupTable interface {
+	Lookup(key string) (labels.Labels, bool)
+}
+
+// LookupTables resolves the tables used by the lookup stage.
+type LookupTables interface {
+	Table(name string) (LookupTable, bool)
+}
+
+// Lookup is a stage adding the labels of the row of a lookup table whose key is the value of a label.
+type Lookup struct {
+	table LookupTable
+	label string
+}
+
+// NewLookup creates a stage adding the labels of the row of the table of tables whose key is the value of the
+// label. Lines whose label is missing or has no row in the table are left untouched.
+func NewLookup(tables LookupTables, table, label string) (*Lookup, error) {
+	if tables == nil {
+		return nil, fmt.Errorf("lookup table %s not found: no lookup tables are configured", table)
+	}
+	t, ok := tables.Table(table)
+	if !ok {
+		return nil, fmt.Errorf("lookup table %s not found", table)
+	}
+	return &Lookup{table: t, label: label}, nil
+}
+
+func (l *Lookup) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	key, ok := lbs.Get(l.label)
+	if !ok {
+		return line, true
+	}
+	row, ok := l.table.Lookup(key)
+	if !ok {
+		return line, true
+	}
+	for _, lbl := range row {
+		lbs.Set(lbl.Name, lbl.Value)
+	}
+	return line, true
+}
+
+func (l *Lookup) RequiredLabelNames() []string { return []string{l.label} }
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLookupTables map[string]fakeLookupTable
+
+func (f fakeLookupTables) Table(name string) (LookupTable, bool) {
+	t, ok := f[name]
+	return t, ok
+}
+
+type fakeLookupTable map[string]labels.Labels
+
+func (f fakeLookupTable) Lookup(key string) (labels.Labels, bool) {
+	row, ok := f[key]
+	return row, ok
+}
+
+func Test_Lookup(t *testing.T) {
+	_, err := NewLookup(nil, "teams", "instance_id")
+	require.Error(t, err)
+
+	tables := fakeLookupTables{
+		"teams": {
+			"i-1": labels.Labels{{Name: "owner", Value: "alice"}, {Name: "team", Value: "payments"}},
+		},
+	}
+
+	_, err = NewLookup(tables, "owners", "instance_id")
+	require.Error(t, err)
+
+	stage, err := NewLookup(tables, "teams", "instance_id")
+	require.NoError(t, err)
+	require.Equal(t, []string{"instance_id"}, stage.RequiredLabelNames())
+
+	tests := []struct {
+		name string
+		lbs  labels.Labels
+		want labels.Labels
+	}{
+		{
+			"found",
+			labels.Labels{{Name: "app", Value: "foo"}, {Name: "instance_id", Value: "i-1"}},
+			labels.Labels{{Name: "app", Value: "foo"}, {Name: "instance_id", Value: "i-1"}, {Name: "owner", Value: "alice"}, {Name: "team", Value: "payments"}},
+		},
+		{
+			"overrides existing labels",
+			labels.Labels{{Name: "instance_id", Value: "i-1"}, {Name: "team", Value: "unknown"}},
+			labels.Labels{{Name: "instance_id", Value: "i-1"}, {Name: "owner", Value: "alice"}, {Name: "team", Value: "payments"}},
+		},
+		{
+			"not found",
+			labels.Labels{{Name: "app", Value: "foo"}, {Name: "instance_id", Value: "i-2"}},
+			labels.Labels{{Name: "app", Value: "foo"}, {Name: "instance_id", Value: "i-2"}},
+		},
+		{
+			"missing label",
+			labels.Labels{{Name: "app", Value: "foo"}},
+			labels.Labels{{Name: "app", Value: "foo"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lbs := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
+			lbs.Reset()
+			line, ok := stage.Process(0, []byte("line"), lbs)
+			require.True(t, ok)
+			require.Equal(t, "line", string(line))
+			got := lbs.Labels()
+			sort.Sort(got)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
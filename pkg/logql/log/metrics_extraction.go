@@ -33,13 +33,14 @@ type SampleExtractor interface {
 // StreamSampleExtractor extracts sample for a log line.
 // A StreamSampleExtractor never mutate the received line.
 type StreamSampleExtractor interface {
-	Process(line []byte) (float64, LabelsResult, bool)
-	ProcessString(line string) (float64, LabelsResult, bool)
+	Process(ts int64, line []byte) (float64, LabelsResult, bool)
+	ProcessString(ts int64, line string) (float64, LabelsResult, bool)
 }
 
 type lineSampleExtractor struct {
 	Stage
 	LineExtractor
+	lineSubstrings [][]byte
 
 	baseBuilder      *BaseLabelsBuilder
 	streamExtractors map[uint64]StreamSampleExtractor
@@ -53,6 +54,7 @@ func NewLineSampleExtractor(ex LineExtractor, stages []Stage, groups []string, w
 	return &lineSampleExtractor{
 		Stage:            s,
 		LineExtractor:    ex,
+		lineSubstrings:   requiredLineSubstrings([]Stage{s}),
 		baseBuilder:      NewBaseLabelsBuilderWithGrouping(groups, hints, without, noLabels),
 		streamExtractors: make(map[uint64]StreamSampleExtractor),
 	}, nil
@@ -65,9 +67,10 @@ func (l *lineSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtrac
 	}
 
 	res := &streamLineSampleExtractor{
-		Stage:         l.Stage,
-		LineExtractor: l.LineExtractor,
-		builder:       l.baseBuilder.ForLabels(labels, hash),
+		Stage:          l.Stage,
+		LineExtractor:  l.LineExtractor,
+		lineSubstrings: l.lineSubstrings,
+		builder:        l.baseBuilder.ForLabels(labels, hash),
 	}
 	l.streamExtractors[hash] = res
 	return res
@@ -76,34 +79,41 @@ func (l *lineSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtrac
 type streamLineSampleExtractor struct {
 	Stage
 	LineExtractor
-	builder *LabelsBuilder
+	lineSubstrings [][]byte
+	builder        *LabelsBuilder
 }
 
-func (l *streamLineSampleExtractor) Process(line []byte) (float64, LabelsResult, bool) {
+func (l *streamLineSampleExtractor) Process(ts int64, line []byte) (float64, LabelsResult, bool) {
 	// short circuit.
 	if l.Stage == NoopStage {
 		return l.LineExtractor(line), l.builder.GroupedLabels(), true
 	}
 	l.builder.Reset()
-	line, ok := l.Stage.Process(line, l.builder)
+	line, ok := l.Stage.Process(ts, line, l.builder)
 	if !ok {
 		return 0, nil, false
 	}
 	return l.LineExtractor(line), l.builder.GroupedLabels(), true
 }
 
-func (l *streamLineSampleExtractor) ProcessString(line string) (float64, LabelsResult, bool) {
+// RequiredLineSubstrings implements LineFilterHints.
+func (l *streamLineSampleExtractor) RequiredLineSubstrings() [][]byte {
+	return l.lineSubstrings
+}
+
+func (l *streamLineSampleExtractor) ProcessString(ts int64, line string) (float64, LabelsResult, bool) {
 	// unsafe get bytes since we have the guarantee that the line won't be mutated.
-	return l.Process(unsafeGetBytes(line))
+	return l.Process(ts, unsafeGetBytes(line))
 }
 
 type convertionFn func(value string) (float64, error)
 
 type labelSampleExtractor struct {
-	preStage     Stage
-	postFilter   Stage
-	labelName    string
-	conversionFn convertionFn
+	preStage       Stage
+	postFilter     Stage
+	labelName      string
+	conversionFn   convertionFn
+	lineSubstrings [][]byte
 
 	baseBuilder      *BaseLabelsBuilder
 	streamExtractors map[uint64]StreamSampleExtractor
@@ -141,6 +151,7 @@ func LabelExtractorWithStages(
 		conversionFn:     convFn,
 		labelName:        labelName,
 		postFilter:       postFilter,
+		lineSubstrings:   requiredLineSubstrings([]Stage{preStage}),
 		baseBuilder:      NewBaseLabelsBuilderWithGrouping(groups, hints, without, noLabels),
 		streamExtractors: make(map[uint64]StreamSampleExtractor),
 	}, nil
@@ -165,10 +176,10 @@ func (l *labelSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtra
 	return res
 }
 
-func (l *streamLabelSampleExtractor) Process(line []byte) (float64, LabelsResult, bool) {
+func (l *streamLabelSampleExtractor) Process(ts int64, line []byte) (float64, LabelsResult, bool) {
 	// Apply the pipeline first.
 	l.builder.Reset()
-	line, ok := l.preStage.Process(line, l.builder)
+	line, ok := l.preStage.Process(ts, line, l.builder)
 	if !ok {
 		return 0, nil, false
 	}
@@ -185,15 +196,20 @@ func (l *streamLabelSampleExtractor) Process(line []byte) (float64, LabelsResult
 		}
 	}
 	// post filters
-	if _, ok = l.postFilter.Process(line, l.builder); !ok {
+	if _, ok = l.postFilter.Process(ts, line, l.builder); !ok {
 		return 0, nil, false
 	}
 	return v, l.builder.GroupedLabels(), true
 }
 
-func (l *streamLabelSampleExtractor) ProcessString(line string) (float64, LabelsResult, bool) {
+// RequiredLineSubstrings implements LineFilterHints.
+func (l *streamLabelSampleExtractor) RequiredLineSubstrings() [][]byte {
+	return l.lineSubstrings
+}
+
+func (l *streamLabelSampleExtractor) ProcessString(ts int64, line string) (float64, LabelsResult, bool) {
 	// unsafe get bytes since we have the guarantee that the line won't be mutated.
-	return l.Process(unsafeGetBytes(line))
+	return l.Process(ts, unsafeGetBytes(line))
 }
 
 func convertFloat(v string) (float64, error) {
@@ -221,7 +221,7 @@ func Test_ParserHints(t *testing.T) {
 
 			ex, err := expr.Extractor()
 			require.NoError(t, err)
-			v, lbsRes, ok := ex.ForStream(lbs).Process(append([]byte{}, tt.line...))
+			v, lbsRes, ok := ex.ForStream(lbs).Process(0, append([]byte{}, tt.line...))
 			var lbsResString string
 			if lbsRes != nil {
 				lbsResString = lbsRes.String()
@@ -103,7 +103,7 @@ func Test_jsonParser_Parse(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
 			b.Reset()
-			_, _ = j.Process(tt.line, b)
+			_, _ = j.Process(0, tt.line, b)
 			sort.Sort(tt.want)
 			require.Equal(t, tt.want, b.Labels())
 		})
@@ -353,7 +353,7 @@ func TestJSONExpressionParser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
 			b.Reset()
-			_, _ = j.Process(tt.line, b)
+			_, _ = j.Process(0, tt.line, b)
 			sort.Sort(tt.want)
 			require.Equal(t, tt.want, b.Labels())
 		})
@@ -438,7 +438,7 @@ func Benchmark_Parser(b *testing.B) {
 				builder := NewBaseLabelsBuilder().ForLabels(lbs, lbs.Hash())
 				for n := 0; n < b.N; n++ {
 					builder.Reset()
-					_, _ = tt.s.Process(line, builder)
+					_, _ = tt.s.Process(0, line, builder)
 				}
 			})
 
@@ -447,7 +447,7 @@ func Benchmark_Parser(b *testing.B) {
 				builder.parserKeyHints = newParserHint(tt.LabelParseHints, tt.LabelParseHints, false, false, "")
 				for n := 0; n < b.N; n++ {
 					builder.Reset()
-					_, _ = tt.s.Process(line, builder)
+					_, _ = tt.s.Process(0, line, builder)
 				}
 			})
 		})
@@ -546,7 +546,7 @@ func Test_regexpParser_Parse(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
 			b.Reset()
-			_, _ = tt.parser.Process(tt.line, b)
+			_, _ = tt.parser.Process(0, tt.line, b)
 			sort.Sort(tt.want)
 			require.Equal(t, tt.want, b.Labels())
 		})
@@ -711,7 +711,7 @@ func Test_logfmtParser_Parse(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
 			b.Reset()
-			_, _ = p.Process(tt.line, b)
+			_, _ = p.Process(0, tt.line, b)
 			sort.Sort(tt.want)
 			require.Equal(t, tt.want, b.Labels())
 		})
@@ -805,7 +805,7 @@ func Test_unpackParser_Parse(t *testing.T) {
 			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
 			b.Reset()
 			copy := string(tt.line)
-			l, _ := j.Process(tt.line, b)
+			l, _ := j.Process(0, tt.line, b)
 			sort.Sort(tt.wantLbs)
 			require.Equal(t, tt.wantLbs, b.Labels())
 			require.Equal(t, tt.wantLine, l)
@@ -861,6 +861,18 @@ func Test_PatternParser(t *testing.T) {
 			},
 			labels.Labels{
 				{Name: "method", Value: "bar"},
+				{Name: logqlmodel.ErrorLabel, Value: errPattern},
+			},
+		},
+		{
+			`<_> "<method> <path> <_>"`,
+			[]byte(`partial "GET`),
+			labels.Labels{
+				{Name: "foo", Value: "bar"},
+			},
+			labels.Labels{
+				{Name: "foo", Value: "bar"},
+				{Name: "method", Value: "GET"},
 			},
 		},
 	}
@@ -873,7 +885,7 @@ func Test_PatternParser(t *testing.T) {
 			b.Reset()
 			pp, err := NewPatternParser(tt.pattern)
 			require.NoError(t, err)
-			_, _ = pp.Process(tt.line, b)
+			_, _ = pp.Process(0, tt.line, b)
 			sort.Sort(tt.want)
 			require.Equal(t, tt.want, b.Labels())
 		})
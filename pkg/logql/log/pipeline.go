@@ -18,15 +18,45 @@ type Pipeline interface {
 // StreamPipeline transform and filter log lines and labels.
 // A StreamPipeline never mutate the received line.
 type StreamPipeline interface {
-	Process(line []byte) (resultLine []byte, resultLabels LabelsResult, skip bool)
-	ProcessString(line string) (resultLine string, resultLabels LabelsResult, skip bool)
+	Process(ts int64, line []byte) (resultLine []byte, resultLabels LabelsResult, skip bool)
+	ProcessString(ts int64, line string) (resultLine string, resultLabels LabelsResult, skip bool)
+}
+
+// LineFilterHints is implemented by the stream pipelines and sample extractors which can tell substrings that every
+// line they keep contains. This allows skipping the data which can't contain them without processing it.
+type LineFilterHints interface {
+	RequiredLineSubstrings() [][]byte
+}
+
+// substringsStage is implemented by the stages which can tell substrings that every line they keep contains.
+type substringsStage interface {
+	// requiredLineSubstrings returns the substrings and whether the stage keeps the lines unchanged.
+	requiredLineSubstrings() (substrings [][]byte, unchanged bool)
+}
+
+// requiredLineSubstrings returns substrings that every line kept by the stages contains. Only the stages before
+// the first one changing the lines are taken into account.
+func requiredLineSubstrings(stages []Stage) [][]byte {
+	var res [][]byte
+	for _, s := range stages {
+		ss, ok := s.(substringsStage)
+		if !ok {
+			break
+		}
+		substrings, unchanged := ss.requiredLineSubstrings()
+		res = append(res, substrings...)
+		if !unchanged {
+			break
+		}
+	}
+	return res
 }
 
 // Stage is a single step of a Pipeline.
 // A Stage implementation should never mutate the line passed, but instead either
 // return the line unchanged or allocate a new line.
 type Stage interface {
-	Process(line []byte, lbs *LabelsBuilder) ([]byte, bool)
+	Process(ts int64, line []byte, lbs *LabelsBuilder) ([]byte, bool)
 	RequiredLabelNames() []string
 }
 
@@ -51,11 +81,11 @@ type noopStreamPipeline struct {
 	LabelsResult
 }
 
-func (n noopStreamPipeline) Process(line []byte) ([]byte, LabelsResult, bool) {
+func (n noopStreamPipeline) Process(_ int64, line []byte) ([]byte, LabelsResult, bool) {
 	return line, n.LabelsResult, true
 }
 
-func (n noopStreamPipeline) ProcessString(line string) (string, LabelsResult, bool) {
+func (n noopStreamPipeline) ProcessString(_ int64, line string) (string, LabelsResult, bool) {
 	return line, n.LabelsResult, true
 }
 
@@ -71,18 +101,19 @@ func (n *noopPipeline) ForStream(labels labels.Labels) StreamPipeline {
 
 type noopStage struct{}
 
-func (noopStage) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+func (noopStage) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
 	return line, true
 }
 func (noopStage) RequiredLabelNames() []string { return []string{} }
 
 type StageFunc struct {
-	process        func(line []byte, lbs *LabelsBuilder) ([]byte, bool)
+	process        func(ts int64, line []byte, lbs *LabelsBuilder) ([]byte, bool)
 	requiredLabels []string
+	lineSubstrings [][]byte
 }
 
-func (fn StageFunc) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
-	return fn.process(line, lbs)
+func (fn StageFunc) Process(ts int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	return fn.process(ts, line, lbs)
 }
 
 func (fn StageFunc) RequiredLabelNames() []string {
@@ -92,11 +123,16 @@ func (fn StageFunc) RequiredLabelNames() []string {
 	return fn.requiredLabels
 }
 
+func (fn StageFunc) requiredLineSubstrings() ([][]byte, bool) {
+	return fn.lineSubstrings, false
+}
+
 // pipeline is a combinations of multiple stages.
 // It can also be reduced into a single stage for convenience.
 type pipeline struct {
-	stages      []Stage
-	baseBuilder *BaseLabelsBuilder
+	stages         []Stage
+	lineSubstrings [][]byte
+	baseBuilder    *BaseLabelsBuilder
 
 	streamPipelines map[uint64]StreamPipeline
 }
@@ -108,14 +144,16 @@ func NewPipeline(stages []Stage) Pipeline {
 	}
 	return &pipeline{
 		stages:          stages,
+		lineSubstrings:  requiredLineSubstrings(stages),
 		baseBuilder:     NewBaseLabelsBuilder(),
 		streamPipelines: make(map[uint64]StreamPipeline),
 	}
 }
 
 type streamPipeline struct {
-	stages  []Stage
-	builder *LabelsBuilder
+	stages         []Stage
+	lineSubstrings [][]byte
+	builder        *LabelsBuilder
 }
 
 func (p *pipeline) ForStream(labels labels.Labels) StreamPipeline {
@@ -125,18 +163,19 @@ func (p *pipeline) ForStream(labels labels.Labels) StreamPipeline {
 	}
 
 	res := &streamPipeline{
-		stages:  p.stages,
-		builder: p.baseBuilder.ForLabels(labels, hash),
+		stages:         p.stages,
+		lineSubstrings: p.lineSubstrings,
+		builder:        p.baseBuilder.ForLabels(labels, hash),
 	}
 	p.streamPipelines[hash] = res
 	return res
 }
 
-func (p *streamPipeline) Process(line []byte) ([]byte, LabelsResult, bool) {
+func (p *streamPipeline) Process(ts int64, line []byte) ([]byte, LabelsResult, bool) {
 	var ok bool
 	p.builder.Reset()
 	for _, s := range p.stages {
-		line, ok = s.Process(line, p.builder)
+		line, ok = s.Process(ts, line, p.builder)
 		if !ok {
 			return nil, nil, false
 		}
@@ -144,10 +183,15 @@ func (p *streamPipeline) Process(line []byte) ([]byte, LabelsResult, bool) {
 	return line, p.builder.LabelsResult(), true
 }
 
-func (p *streamPipeline) ProcessString(line string) (string, LabelsResult, bool) {
+// RequiredLineSubstrings implements LineFilterHints.
+func (p *streamPipeline) RequiredLineSubstrings() [][]byte {
+	return p.lineSubstrings
+}
+
+func (p *streamPipeline) ProcessString(ts int64, line string) (string, LabelsResult, bool) {
 	// Stages only read from the line.
 	lb := unsafeGetBytes(line)
-	lb, lr, ok := p.Process(lb)
+	lb, lr, ok := p.Process(ts, lb)
 	// either the line is unchanged and we can just send back the same string.
 	// or we created a new buffer for it in which case it is still safe to avoid the string(byte) copy.
 	return unsafeGetString(lb), lr, ok
@@ -163,10 +207,10 @@ func ReduceStages(stages []Stage) Stage {
 		requiredLabelNames = append(requiredLabelNames, s.RequiredLabelNames()...)
 	}
 	return StageFunc{
-		process: func(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+		process: func(ts int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
 			var ok bool
 			for _, p := range stages {
-				line, ok = p.Process(line, lbs)
+				line, ok = p.Process(ts, line, lbs)
 				if !ok {
 					return nil, false
 				}
@@ -174,6 +218,7 @@ func ReduceStages(stages []Stage) Stage {
 			return line, true
 		},
 		requiredLabels: requiredLabelNames,
+		lineSubstrings: requiredLineSubstrings(stages),
 	}
 }
 
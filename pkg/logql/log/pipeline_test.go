@@ -12,12 +12,12 @@ import (
 
 func TestNoopPipeline(t *testing.T) {
 	lbs := labels.Labels{{Name: "foo", Value: "bar"}}
-	l, lbr, ok := NewNoopPipeline().ForStream(lbs).Process([]byte(""))
+	l, lbr, ok := NewNoopPipeline().ForStream(lbs).Process(0, []byte(""))
 	require.Equal(t, []byte(""), l)
 	require.Equal(t, NewLabelsResult(lbs, lbs.Hash()), lbr)
 	require.Equal(t, true, ok)
 
-	ls, lbr, ok := NewNoopPipeline().ForStream(lbs).ProcessString("")
+	ls, lbr, ok := NewNoopPipeline().ForStream(lbs).ProcessString(0, "")
 	require.Equal(t, "", ls)
 	require.Equal(t, NewLabelsResult(lbs, lbs.Hash()), lbr)
 	require.Equal(t, true, ok)
@@ -29,27 +29,104 @@ func TestPipeline(t *testing.T) {
 		NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")),
 		newMustLineFormatter("lbs {{.foo}}"),
 	})
-	l, lbr, ok := p.ForStream(lbs).Process([]byte("line"))
+	l, lbr, ok := p.ForStream(lbs).Process(0, []byte("line"))
 	require.Equal(t, []byte("lbs bar"), l)
 	require.Equal(t, NewLabelsResult(lbs, lbs.Hash()), lbr)
 	require.Equal(t, true, ok)
 
-	ls, lbr, ok := p.ForStream(lbs).ProcessString("line")
+	ls, lbr, ok := p.ForStream(lbs).ProcessString(0, "line")
 	require.Equal(t, "lbs bar", ls)
 	require.Equal(t, NewLabelsResult(lbs, lbs.Hash()), lbr)
 	require.Equal(t, true, ok)
 
-	l, lbr, ok = p.ForStream(labels.Labels{}).Process([]byte("line"))
+	l, lbr, ok = p.ForStream(labels.Labels{}).Process(0, []byte("line"))
 	require.Equal(t, []byte(nil), l)
 	require.Equal(t, nil, lbr)
 	require.Equal(t, false, ok)
 
-	ls, lbr, ok = p.ForStream(labels.Labels{}).ProcessString("line")
+	ls, lbr, ok = p.ForStream(labels.Labels{}).ProcessString(0, "line")
 	require.Equal(t, "", ls)
 	require.Equal(t, nil, lbr)
 	require.Equal(t, false, ok)
 }
 
+func TestPipeline_RequiredLineSubstrings(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		stages   []Stage
+		expected [][]byte
+	}{
+		{
+			name:     "no stages",
+			expected: nil,
+		},
+		{
+			name: "contains filters",
+			stages: []Stage{
+				mustFilter(NewFilter("foo", labels.MatchEqual)).ToStage(),
+				mustFilter(NewFilter("bar.*", labels.MatchRegexp)).ToStage(),
+			},
+			expected: [][]byte{[]byte("foo"), []byte("bar")},
+		},
+		{
+			name: "and filters",
+			stages: []Stage{
+				NewAndFilters([]Filterer{
+					mustFilter(NewFilter("foo", labels.MatchEqual)),
+					mustFilter(NewFilter("bar", labels.MatchNotEqual)),
+					mustFilter(NewFilter("baz", labels.MatchEqual)),
+				}).ToStage(),
+			},
+			expected: [][]byte{[]byte("foo"), []byte("baz")},
+		},
+		{
+			name: "filters not requiring substrings",
+			stages: []Stage{
+				mustFilter(NewFilter("foo", labels.MatchNotEqual)).ToStage(),
+				mustFilter(NewFilter("(?i)foo", labels.MatchRegexp)).ToStage(),
+				mustFilter(NewFilter("foo|bar", labels.MatchRegexp)).ToStage(),
+				mustFilter(NewFilter("baz", labels.MatchEqual)).ToStage(),
+			},
+			expected: [][]byte{[]byte("baz")},
+		},
+		{
+			name: "filters after a parser",
+			stages: []Stage{
+				mustFilter(NewFilter("foo", labels.MatchEqual)).ToStage(),
+				NewLogfmtParser(),
+				mustFilter(NewFilter("bar", labels.MatchEqual)).ToStage(),
+			},
+			expected: [][]byte{[]byte("foo")},
+		},
+		{
+			name: "filters after a line format",
+			stages: []Stage{
+				newMustLineFormatter("{{.foo}}"),
+				mustFilter(NewFilter("foo", labels.MatchEqual)).ToStage(),
+			},
+			expected: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := NewPipeline(tc.stages).ForStream(labels.Labels{}).(LineFilterHints)
+			if len(tc.stages) == 0 {
+				require.False(t, ok)
+			} else {
+				require.True(t, ok)
+				require.Equal(t, tc.expected, p.RequiredLineSubstrings())
+			}
+
+			ex, err := NewLineSampleExtractor(CountExtractor, tc.stages, nil, false, false)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ex.ForStream(labels.Labels{}).(LineFilterHints).RequiredLineSubstrings())
+
+			ex, err = LabelExtractorWithStages("foo", ConvertFloat, nil, false, false, tc.stages, NoopStage)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ex.ForStream(labels.Labels{}).(LineFilterHints).RequiredLineSubstrings())
+		})
+	}
+}
+
 var (
 	resOK         bool
 	resLine       []byte
@@ -92,13 +169,13 @@ func Benchmark_Pipeline(b *testing.B) {
 	b.Run("pipeline bytes", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resLine, resLbs, resOK = sp.Process(line)
+			resLine, resLbs, resOK = sp.Process(0, line)
 		}
 	})
 	b.Run("pipeline string", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resLineString, resLbs, resOK = sp.ProcessString(lineString)
+			resLineString, resLbs, resOK = sp.ProcessString(0, lineString)
 		}
 	})
 
@@ -108,13 +185,13 @@ func Benchmark_Pipeline(b *testing.B) {
 	b.Run("line extractor bytes", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resOK = ex.Process(line)
+			resSample, resLbs, resOK = ex.Process(0, line)
 		}
 	})
 	b.Run("line extractor string", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resOK = ex.ProcessString(lineString)
+			resSample, resLbs, resOK = ex.ProcessString(0, lineString)
 		}
 	})
 
@@ -125,13 +202,13 @@ func Benchmark_Pipeline(b *testing.B) {
 	b.Run("label extractor bytes", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resOK = ex.Process(line)
+			resSample, resLbs, resOK = ex.Process(0, line)
 		}
 	})
 	b.Run("label extractor string", func(b *testing.B) {
 		b.ResetTimer()
 		for n := 0; n < b.N; n++ {
-			resSample, resLbs, resOK = ex.ProcessString(lineString)
+			resSample, resLbs, resOK = ex.ProcessString(0, lineString)
 		}
 	})
 }
@@ -164,7 +241,7 @@ func jsonBenchmark(b *testing.B, parser Stage) {
 	b.ResetTimer()
 	sp := p.ForStream(lbs)
 	for n := 0; n < b.N; n++ {
-		resLine, resLbs, resOK = sp.Process(line)
+		resLine, resLbs, resOK = sp.Process(0, line)
 
 		if !resOK {
 			b.Fatalf("resulting line not ok: %s\n", line)
@@ -187,7 +264,7 @@ func invalidJSONBenchmark(b *testing.B, parser Stage) {
 	b.ResetTimer()
 	sp := p.ForStream(labels.Labels{})
 	for n := 0; n < b.N; n++ {
-		resLine, resLbs, resOK = sp.Process(line)
+		resLine, resLbs, resOK = sp.Process(0, line)
 
 		if !resOK {
 			b.Fatalf("resulting line not ok: %s\n", line)
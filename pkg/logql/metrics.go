@@ -91,7 +91,7 @@ func RecordMetrics(ctx context.Context, p Params, status string, stats stats.Res
 
 	queryTags, _ := ctx.Value(httpreq.QueryTagsHTTPHeader).(string) // it's ok to be empty.
 
-	logValues := make([]interface{}, 0, 20)
+	logValues := make([]interface{}, 0, 40)
 
 	logValues = append(logValues, []interface{}{
 		"latency", latencyType, // this can be used to filter log lines.
@@ -106,6 +106,14 @@ func RecordMetrics(ctx context.Context, p Params, status string, stats stats.Res
 		"returned_lines", returnedLines,
 		"throughput", strings.Replace(humanize.Bytes(uint64(stats.Summary.BytesProcessedPerSecond)), " ", "", 1),
 		"total_bytes", strings.Replace(humanize.Bytes(uint64(stats.Summary.TotalBytesProcessed)), " ", "", 1),
+		"ingester_chunks", stats.Ingester.TotalChunksMatched,
+		"store_chunks", stats.Querier.Store.TotalChunksDownloaded,
+		"index_pages", stats.Querier.Store.TotalIndexPagesRead,
+		"compressed_bytes", strings.Replace(humanize.Bytes(uint64(stats.TotalCompressedBytes())), " ", "", 1),
+		"decompressed_bytes", strings.Replace(humanize.Bytes(uint64(stats.TotalDecompressedBytes())), " ", "", 1),
+		"chunk_cache_hit_ratio", stats.Summary.ChunkCacheHitRatio,
+		"index_cache_hit_ratio", stats.Summary.IndexCacheHitRatio,
+		"duplicate_ratio", stats.Summary.DuplicateRatio,
 	}...)
 
 	logValues = append(logValues, tagsToKeyValues(queryTags)...)
@@ -78,11 +78,19 @@ func TestLogSlowQuery(t *testing.T) {
 			BytesProcessedPerSecond: 100000,
 			ExecTime:                25.25,
 			TotalBytesProcessed:     100000,
+			ChunkCacheHitRatio:      0.5,
+			DuplicateRatio:          0.25,
 		},
+		Ingester: stats.Ingester{TotalChunksMatched: 3},
+		Querier: stats.Querier{Store: stats.Store{
+			TotalChunksDownloaded: 4,
+			TotalIndexPagesRead:   2,
+			Chunk:                 stats.Chunk{CompressedBytes: 20000, DecompressedBytes: 80000},
+		}},
 	}, logqlmodel.Streams{logproto.Stream{Entries: make([]logproto.Entry, 10)}})
 	require.Equal(t,
 		fmt.Sprintf(
-			"level=info org_id=foo traceID=%s latency=slow query=\"{foo=\\\"bar\\\"} |= \\\"buzz\\\"\" query_type=filter range_type=range length=1h0m0s step=1m0s duration=25.25s status=200 limit=1000 returned_lines=10 throughput=100kB total_bytes=100kB source=logvolhist feature=beta\n",
+			"level=info org_id=foo traceID=%s latency=slow query=\"{foo=\\\"bar\\\"} |= \\\"buzz\\\"\" query_type=filter range_type=range length=1h0m0s step=1m0s duration=25.25s status=200 limit=1000 returned_lines=10 throughput=100kB total_bytes=100kB ingester_chunks=3 store_chunks=4 index_pages=2 compressed_bytes=20kB decompressed_bytes=80kB chunk_cache_hit_ratio=0.5 index_cache_hit_ratio=0 duplicate_ratio=0.25 source=logvolhist feature=beta\n",
 			sp.Context().(jaeger.SpanContext).SpanID().String(),
 		),
 		buf.String())
@@ -6,7 +6,7 @@ func optimizeSampleExpr(expr SampleExpr) (SampleExpr, error) {
 	// we skip sharding AST for now, it's not easy to clone them since they are not part of the language.
 	expr.Walk(func(e interface{}) {
 		switch e.(type) {
-		case *ConcatSampleExpr, *DownstreamSampleExpr:
+		case *ConcatSampleExpr, *DownstreamSampleExpr, *QuantileSketchEvalExpr:
 			skip = true
 			return
 		}
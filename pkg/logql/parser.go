@@ -32,6 +32,8 @@ var parserPool = sync.Pool{
 	},
 }
 
+const errDedupInMetricQuery = "the dedup stage is only supported in log queries"
+
 const maxInputSize = 5120
 
 func init() {
@@ -170,6 +172,9 @@ func validateSampleExpr(expr SampleExpr) error {
 	case *LiteralExpr:
 		return nil
 	default:
+		if _, ok := dedupTolerance(expr); ok {
+			return logqlmodel.NewParseError(errDedupInMetricQuery, 0, 0)
+		}
 		return validateMatchers(expr.Selector().Matchers())
 	}
 }
@@ -1548,6 +1548,126 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			in: `{app="foo"} | json | drop instance, pod | keep job, level`,
+			exp: &PipelineExpr{
+				Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+				MultiStages: MultiStageExpr{
+					newLabelParserExpr(OpParserTypeJSON, ""),
+					newDropLabelsExpr([]string{"instance", "pod"}),
+					newKeepLabelsExpr([]string{"job", "level"}),
+				},
+			},
+		},
+		{
+			in: `sum by (job) (count_over_time({app="foo"} | logfmt | keep job [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					newLogRange(&PipelineExpr{
+						Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+						MultiStages: MultiStageExpr{
+							newLabelParserExpr(OpParserTypeLogfmt, ""),
+							newKeepLabelsExpr([]string{"job"}),
+						},
+					},
+						5*time.Minute,
+						nil, nil),
+					OpRangeTypeCount,
+					nil,
+					nil,
+				),
+				OpTypeSum,
+				&Grouping{Groups: []string{"job"}},
+				nil,
+			),
+		},
+		{
+			in: `{app="foo"} | logfmt | drop agent | dedup 5s`,
+			exp: &PipelineExpr{
+				Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+				MultiStages: MultiStageExpr{
+					newLabelParserExpr(OpParserTypeLogfmt, ""),
+					newDropLabelsExpr([]string{"agent"}),
+					newDedupExpr(5 * time.Second),
+				},
+			},
+		},
+		{
+			in: `{app="foo"} | logfmt | lookup teams on instance_id | team="payments"`,
+			exp: &PipelineExpr{
+				Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+				MultiStages: MultiStageExpr{
+					newLabelParserExpr(OpParserTypeLogfmt, ""),
+					newLookupExpr("teams", "instance_id"),
+					&LabelFilterExpr{
+						LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "team", "payments")),
+					},
+				},
+			},
+		},
+		{
+			in:  `{app="foo"} | lookup teams`,
+			err: logqlmodel.NewParseError("syntax error: unexpected $end, expecting on", 1, 27),
+		},
+		{
+			in:  `count_over_time({app="foo"} | dedup 5s [5m])`,
+			err: logqlmodel.NewParseError(errDedupInMetricQuery, 0, 0),
+		},
+		{
+			in:  `{app="foo"} | drop`,
+			err: logqlmodel.NewParseError("syntax error: unexpected $end, expecting IDENTIFIER", 1, 19),
+		},
+		{
+			in: `{dedup="a"} | logfmt | dedup="b" | dedup 5s`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "dedup", "a")}),
+				MultiStageExpr{
+					newLabelParserExpr(OpParserTypeLogfmt, ""),
+					newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "dedup", "b"))),
+					newDedupExpr(5 * time.Second),
+				},
+			),
+		},
+		{
+			in: `{lookup="a"} | lookup="b" | lookup teams on lookup`,
+			exp: newPipelineExpr(
+				newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "lookup", "a")}),
+				MultiStageExpr{
+					newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "lookup", "b"))),
+					newLookupExpr("teams", "lookup"),
+				},
+			),
+		},
+		{
+			in:  `{keep="a", drop="b"}`,
+			exp: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "keep", "a"), mustNewMatcher(labels.MatchEqual, "drop", "b")}),
+		},
+		{
+			in: `sum by (keep, drop) (count_over_time({app="foo"}[5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					newLogRange(newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}), 5*time.Minute, nil, nil),
+					OpRangeTypeCount,
+					nil,
+					nil,
+				),
+				OpTypeSum,
+				&Grouping{Groups: []string{"keep", "drop"}},
+				nil,
+			),
+		},
+		{
+			in: `{app="foo"} | logfmt | drop="1" | keep != "2" | drop keep`,
+			exp: &PipelineExpr{
+				Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+				MultiStages: MultiStageExpr{
+					newLabelParserExpr(OpParserTypeLogfmt, ""),
+					newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "drop", "1"))),
+					newLabelFilterExpr(log.NewStringLabelFilter(mustNewMatcher(labels.MatchNotEqual, "keep", "2"))),
+					newDropLabelsExpr([]string{"keep"}),
+				},
+			},
+		},
 		{
 			in: `count_over_time({app="foo"} |= "bar" | json | latency >= 250ms or ( status_code < 500 and status_code > 200)
 			| line_format "blip{{ .foo }}blop {{.status_code}}" | label_format foo=bar,status_code="buzz{{.bar}}"[5m])`,
@@ -1596,6 +1716,26 @@ func TestParse(t *testing.T) {
 			exp: nil,
 			err: logqlmodel.NewParseError("invalid aggregation count_over_time with unwrap", 0, 0),
 		},
+		{
+			in: `__quantile_sketch_over_time__({app="foo"} | json | unwrap foo [5m]) by (namespace)`,
+			exp: newRangeAggregationExpr(
+				newLogRange(&PipelineExpr{
+					Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+					MultiStages: MultiStageExpr{
+						newLabelParserExpr(OpParserTypeJSON, ""),
+					},
+				},
+					5*time.Minute,
+					newUnwrapExpr("foo", ""),
+					nil),
+				OpRangeTypeQuantileSketch, &Grouping{Groups: []string{"namespace"}}, nil,
+			),
+		},
+		{
+			in:  `__quantile_sketch_over_time__({app="foo"} |= "foo" [5m])`,
+			exp: nil,
+			err: logqlmodel.NewParseError("invalid aggregation __quantile_sketch_over_time__ without unwrap", 0, 0),
+		},
 		{
 			in: `{app="foo"} |= "bar" | json |  status_code < 500 or status_code > 200 and size >= 2.5KiB `,
 			exp: &PipelineExpr{
@@ -3039,7 +3179,7 @@ func Test_PipelineCombined(t *testing.T) {
 	p, err := expr.Pipeline()
 	require.Nil(t, err)
 	sp := p.ForStream(labels.Labels{})
-	line, lbs, ok := sp.Process([]byte(`level=debug ts=2020-10-02T10:10:42.092268913Z caller=logging.go:66 traceID=a9d4d8a928d8db1 msg="POST /api/prom/api/v1/query_range (200) 1.5s"`))
+	line, lbs, ok := sp.Process(0, []byte(`level=debug ts=2020-10-02T10:10:42.092268913Z caller=logging.go:66 traceID=a9d4d8a928d8db1 msg="POST /api/prom/api/v1/query_range (200) 1.5s"`))
 	require.True(t, ok)
 	require.Equal(
 		t,
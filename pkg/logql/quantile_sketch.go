@@ -0,0 +1,156 @@
+package logql
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/grafana/loki/pkg/logql/sketch"
+	"github.com/grafana/loki/pkg/logqlmodel"
+)
+
+// QuantileSketchLabel is the label carrying the encoded quantile sketch of a series
+// when sending quantile sketches from the queriers to the frontend.
+const QuantileSketchLabel = "__quantile_sketch__"
+
+// ValidateUserExpr rejects the expressions using an internal operation, which are only
+// valid in the sharded queries built by the shard mapper.
+func ValidateUserExpr(expr Expr) error {
+	var err error
+	expr.Walk(func(e interface{}) {
+		if r, ok := e.(*RangeAggregationExpr); ok && r.Operation == OpRangeTypeQuantileSketch {
+			err = logqlmodel.NewParseError(fmt.Sprintf("%s is reserved to sharded queries", r.Operation), 0, 0)
+		}
+	})
+	return err
+}
+
+// QuantileSketchEvalExpr merges the quantile sketches returned by each shard
+// and computes the requested quantile from them.
+// quantile_over_time(q, x) by (y) -> quantile_sketch_eval(q, __quantile_sketch_over_time__(x, shard=1) by (y) ++ ...)
+type QuantileSketchEvalExpr struct {
+	SampleExpr
+	quantile float64
+}
+
+func (e QuantileSketchEvalExpr) String() string {
+	return fmt.Sprintf("quantileSketchEval<%s, quantile=%v>", e.SampleExpr.String(), e.quantile)
+}
+
+func (e *QuantileSketchEvalExpr) Walk(f WalkFn) {
+	f(e)
+	e.SampleExpr.Walk(f)
+}
+
+// quantileSketchEvaluator computes a quantile sketch for each series of the range vector
+// and returns one sample per series, with the encoded sketch in the QuantileSketchLabel and its count as value.
+type quantileSketchEvaluator struct {
+	iter RangeVectorIterator
+
+	err error
+}
+
+func (r *quantileSketchEvaluator) Next() (bool, int64, promql.Vector) {
+	next := r.iter.Next()
+	if !next {
+		return false, 0, promql.Vector{}
+	}
+	// the aggregator is called for each series in the same order as the returned vector.
+	var sketches []*sketch.DDSketch
+	ts, vec := r.iter.At(func(points []promql.Point) float64 {
+		s := sketch.NewDefault()
+		for _, p := range points {
+			s.Add(p.V)
+		}
+		sketches = append(sketches, s)
+		return s.Count()
+	})
+	result := make(promql.Vector, 0, len(vec))
+	for i, s := range vec {
+		// Errors are not allowed in metrics.
+		if s.Metric.Has(logqlmodel.ErrorLabel) {
+			r.err = logqlmodel.NewPipelineErr(s.Metric)
+			return false, 0, promql.Vector{}
+		}
+		data, err := sketches[i].MarshalBinary()
+		if err != nil {
+			r.err = err
+			return false, 0, promql.Vector{}
+		}
+		result = append(result, promql.Sample{
+			Point:  promql.Point{T: ts, V: s.V},
+			Metric: labels.NewBuilder(s.Metric).Set(QuantileSketchLabel, base64.StdEncoding.EncodeToString(data)).Labels(),
+		})
+	}
+	return true, ts, result
+}
+
+func (r quantileSketchEvaluator) Close() error { return r.iter.Close() }
+
+func (r quantileSketchEvaluator) Error() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.iter.Error()
+}
+
+// quantileSketchMergeEvaluator decodes the sketches of each series, merging the sketches
+// coming from different shards, and computes the quantile.
+func quantileSketchMergeEvaluator(next StepEvaluator, q float64) (StepEvaluator, error) {
+	buf := make([]byte, 0, 1024)
+	var err error
+	return newStepEvaluator(func() (bool, int64, promql.Vector) {
+		ok, ts, vec := next.Next()
+		if !ok {
+			return false, 0, promql.Vector{}
+		}
+		type group struct {
+			metric labels.Labels
+			sketch *sketch.DDSketch
+		}
+		groups := map[uint64]*group{}
+		order := make([]uint64, 0, len(vec))
+		for _, s := range vec {
+			// the sketches of the other steps are returned with a zero count by the steppers of the downstream results.
+			if s.V == 0 {
+				continue
+			}
+			data, decodeErr := base64.StdEncoding.DecodeString(s.Metric.Get(QuantileSketchLabel))
+			if decodeErr != nil {
+				err = fmt.Errorf("invalid quantile sketch: %w", decodeErr)
+				return false, 0, promql.Vector{}
+			}
+			var hash uint64
+			hash, buf = s.Metric.HashWithoutLabels(buf, QuantileSketchLabel)
+			g, found := groups[hash]
+			if !found {
+				g = &group{
+					metric: labels.NewBuilder(s.Metric).Del(QuantileSketchLabel).Labels(),
+					sketch: sketch.NewDefault(),
+				}
+				groups[hash] = g
+				order = append(order, hash)
+			}
+			if decodeErr := g.sketch.UnmarshalBinary(data); decodeErr != nil {
+				err = decodeErr
+				return false, 0, promql.Vector{}
+			}
+		}
+		result := make(promql.Vector, 0, len(groups))
+		for _, hash := range order {
+			g := groups[hash]
+			result = append(result, promql.Sample{
+				Point:  promql.Point{T: ts, V: g.sketch.Quantile(q)},
+				Metric: g.metric,
+			})
+		}
+		return true, ts, result
+	}, next.Close, func() error {
+		if err != nil {
+			return err
+		}
+		return next.Error()
+	})
+}
@@ -0,0 +1,176 @@
+package logql
+
+import (
+	"fmt"
+	"time"
+)
+
+// RangeMapper maps instant metric queries whose range is longer than the split interval
+// into the same queries over consecutive sub-ranges, which are downstreamed and then recombined.
+// For example with a 1d interval:
+//
+//		sum(count_over_time({app="foo"}[2d]))
+//
+// is mapped into:
+//
+//		sum(downstream<sum(count_over_time({app="foo"}[1d]))> ++ downstream<sum(count_over_time({app="foo"}[1d] offset 1d))>)
+//
+// Only range aggregations whose results can be recombined from the results of sub-ranges are split,
+// others such as avg_over_time or quantile_over_time are kept as is.
+type RangeMapper struct {
+	splitByInterval time.Duration
+}
+
+// NewRangeMapper creates a RangeMapper splitting ranges by the given interval.
+func NewRangeMapper(interval time.Duration) (RangeMapper, error) {
+	if interval <= 0 {
+		return RangeMapper{}, fmt.Errorf("cannot create RangeMapper with split interval <= 0; got %s", interval)
+	}
+	return RangeMapper{splitByInterval: interval}, nil
+}
+
+// Parse parses and maps the query, noop tells if the query can't be split.
+func (m RangeMapper) Parse(query string) (noop bool, expr Expr, err error) {
+	parsed, err := ParseExpr(query)
+	if err != nil {
+		return false, nil, err
+	}
+	sampleExpr, ok := parsed.(SampleExpr)
+	if !ok {
+		// log queries are not split.
+		return true, parsed, nil
+	}
+	mapped := m.Map(sampleExpr)
+	return mapped.String() == parsed.String(), mapped, nil
+}
+
+// Map maps the sample expression, splitting the range aggregations it contains when possible.
+func (m RangeMapper) Map(expr SampleExpr) SampleExpr {
+	switch e := expr.(type) {
+	case *VectorAggregationExpr:
+		return m.mapVectorAggregationExpr(e)
+	case *RangeAggregationExpr:
+		return m.mapRangeAggregationExpr(e, nil)
+	case *BinOpExpr:
+		return &BinOpExpr{
+			SampleExpr: m.Map(e.SampleExpr),
+			RHS:        m.Map(e.RHS),
+			Op:         e.Op,
+			Opts:       e.Opts,
+		}
+	case *LabelReplaceExpr:
+		mapped := *e
+		mapped.Left = m.Map(e.Left)
+		return &mapped
+	default:
+		return expr
+	}
+}
+
+func (m RangeMapper) mapVectorAggregationExpr(expr *VectorAggregationExpr) SampleExpr {
+	if ra, ok := expr.Left.(*RangeAggregationExpr); ok && m.splittable(ra) && combinedBy(ra.Operation) == expr.Operation {
+		// the vector aggregation combines results the same way sub-ranges are, it can be pushed down.
+		// sum(count_over_time(x[2d])) -> sum(sum(count_over_time(x[1d])) ++ sum(count_over_time(x[1d] offset 1d)))
+		return m.mapRangeAggregationExpr(ra, func(partial SampleExpr) SampleExpr {
+			return &VectorAggregationExpr{
+				Left:      partial,
+				Grouping:  expr.Grouping,
+				Params:    expr.Params,
+				Operation: expr.Operation,
+			}
+		})
+	}
+	return &VectorAggregationExpr{
+		Left:      m.Map(expr.Left),
+		Grouping:  expr.Grouping,
+		Params:    expr.Params,
+		Operation: expr.Operation,
+	}
+}
+
+// mapRangeAggregationExpr splits the range aggregation, and applies aggregate to each sub-range and to their
+// concatenation when not nil. Otherwise sub-ranges results are combined by series.
+func (m RangeMapper) mapRangeAggregationExpr(expr *RangeAggregationExpr, aggregate func(SampleExpr) SampleExpr) SampleExpr {
+	if !m.splittable(expr) {
+		return expr
+	}
+
+	// rates are computed from the sum of the sub-ranges divided by the whole range.
+	op := expr.Operation
+	switch op {
+	case OpRangeTypeRate:
+		op = OpRangeTypeCount
+		if expr.Left.Unwrap != nil {
+			op = OpRangeTypeSum
+		}
+	case OpRangeTypeBytesRate:
+		op = OpRangeTypeBytes
+	}
+
+	var head *ConcatSampleExpr
+	for offset := time.Duration(0); offset < expr.Left.Interval; offset += m.splitByInterval {
+		interval := m.splitByInterval
+		if rest := expr.Left.Interval - offset; rest < interval {
+			interval = rest
+		}
+		logRange := *expr.Left
+		logRange.Interval = interval
+		logRange.Offset = expr.Left.Offset + offset
+
+		var partial SampleExpr = &RangeAggregationExpr{
+			Left:      &logRange,
+			Operation: op,
+			Params:    expr.Params,
+			Grouping:  expr.Grouping,
+		}
+		if aggregate != nil {
+			partial = aggregate(partial)
+		}
+		head = &ConcatSampleExpr{
+			DownstreamSampleExpr: DownstreamSampleExpr{SampleExpr: partial},
+			next:                 head,
+		}
+	}
+
+	var combined SampleExpr
+	if aggregate != nil {
+		combined = aggregate(head)
+	} else {
+		combined = &VectorAggregationExpr{
+			Left:      head,
+			Grouping:  &Grouping{Without: true},
+			Operation: combinedBy(expr.Operation),
+		}
+	}
+
+	if op != expr.Operation {
+		return &BinOpExpr{
+			SampleExpr: combined,
+			RHS:        &LiteralExpr{value: expr.Left.Interval.Seconds()},
+			Op:         OpTypeDiv,
+			Opts:       &BinOpOptions{},
+		}
+	}
+	return combined
+}
+
+// splittable tells if the range aggregation has a range longer than the split interval
+// and can be recombined from its sub-ranges.
+func (m RangeMapper) splittable(expr *RangeAggregationExpr) bool {
+	return expr.Left.Interval > m.splitByInterval && combinedBy(expr.Operation) != ""
+}
+
+// combinedBy returns the vector aggregation combining the results of a range aggregation over sub-ranges,
+// or an empty string if they can't be combined.
+func combinedBy(op string) string {
+	switch op {
+	case OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeBytes, OpRangeTypeBytesRate, OpRangeTypeSum:
+		return OpTypeSum
+	case OpRangeTypeMax:
+		return OpTypeMax
+	case OpRangeTypeMin:
+		return OpTypeMin
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,134 @@
+package logql
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func TestRangeMappingStrings(t *testing.T) {
+	m, err := NewRangeMapper(time.Minute)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		in   string
+		out  string
+		noop bool
+	}{
+		{
+			in:   `count_over_time({app="foo"}[1m])`,
+			out:  `count_over_time({app="foo"}[1m])`,
+			noop: true,
+		},
+		{
+			in:   `{app="foo"}`,
+			out:  `{app="foo"}`,
+			noop: true,
+		},
+		{
+			in:   `avg_over_time({app="foo"} | unwrap bar [3m])`,
+			out:  `avg_over_time({app="foo"} | unwrap bar[3m])`,
+			noop: true,
+		},
+		{
+			in:  `count_over_time({app="foo"}[3m])`,
+			out: `sum without(downstream<count_over_time({app="foo"}[1m] offset 2m0s)> ++ downstream<count_over_time({app="foo"}[1m] offset 1m0s)> ++ downstream<count_over_time({app="foo"}[1m])>)`,
+		},
+		{
+			in:  `sum by (level) (count_over_time({app="foo"}[150s] offset 1h))`,
+			out: `sum by(level)(downstream<sum by(level)(count_over_time({app="foo"}[30s] offset 1h2m0s))> ++ downstream<sum by(level)(count_over_time({app="foo"}[1m] offset 1h1m0s))> ++ downstream<sum by(level)(count_over_time({app="foo"}[1m] offset 1h0m0s))>)`,
+		},
+		{
+			in:  `sum(rate({app="foo"}[2m]))`,
+			out: `(sum(downstream<sum(count_over_time({app="foo"}[1m] offset 1m0s))> ++ downstream<sum(count_over_time({app="foo"}[1m]))>) / 120)`,
+		},
+		{
+			in:  `topk(10, bytes_rate({app="foo"}[2m]))`,
+			out: `topk(10,(sum without(downstream<bytes_over_time({app="foo"}[1m] offset 1m0s)> ++ downstream<bytes_over_time({app="foo"}[1m])>) / 120))`,
+		},
+		{
+			in:  `max(max_over_time({app="foo"} | unwrap bar [2m]) by (level))`,
+			out: `max(downstream<max(max_over_time({app="foo"} | unwrap bar[1m] offset 1m0s) by(level))> ++ downstream<max(max_over_time({app="foo"} | unwrap bar[1m]) by(level))>)`,
+		},
+		{
+			// the vector aggregation can't be pushed down.
+			in:  `sum(max_over_time({app="foo"} | unwrap bar [2m]))`,
+			out: `sum(max without(downstream<max_over_time({app="foo"} | unwrap bar[1m] offset 1m0s)> ++ downstream<max_over_time({app="foo"} | unwrap bar[1m])>))`,
+		},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			noop, mapped, err := m.Parse(tc.in)
+			require.NoError(t, err)
+			require.Equal(t, tc.noop, noop)
+			require.Equal(t, tc.out, mapped.String())
+		})
+	}
+}
+
+func TestRangeMappingEquivalence(t *testing.T) {
+	var (
+		shards   = 3
+		nStreams = 60
+		rounds   = 20
+		streams  = randomStreams(nStreams, rounds+1, shards, []string{"a", "b", "c", "d"})
+		ts       = time.Unix(0, int64(time.Second*time.Duration(rounds)))
+		limit    = 100
+	)
+
+	for _, query := range []string{
+		`count_over_time({a=~".+"}[10s])`,
+		`sum by (a) (count_over_time({a=~".+"}[10s]))`,
+		`sum(rate({a=~".+"}[10s] offset 5s))`,
+		`bytes_rate({a=~".+"}[10s])`,
+		`topk(3, sum by (a) (bytes_over_time({a=~".+"}[10s])))`,
+		`max(max_over_time({a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [10s]) by (a))`,
+		`min_over_time({a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [10s])`,
+		`sum(rate({a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [10s])) / sum(count_over_time({a=~".+"}[10s]))`,
+	} {
+		q := NewMockQuerier(
+			shards,
+			streams,
+		)
+
+		opts := EngineOpts{}
+		regular := NewEngine(opts, q, NoLimits)
+		split := NewShardedEngine(opts, MockDownstreamer{regular}, nilMetrics, NoLimits)
+
+		t.Run(query, func(t *testing.T) {
+			params := NewLiteralParams(query, ts, ts, 0, 0, logproto.FORWARD, uint32(limit), nil)
+			ctx := user.InjectOrgID(context.Background(), "fake")
+
+			mapper, err := NewRangeMapper(3 * time.Second)
+			require.NoError(t, err)
+			noop, mapped, err := mapper.Parse(query)
+			require.NoError(t, err)
+			require.False(t, noop)
+
+			res, err := regular.Query(params).Exec(ctx)
+			require.NoError(t, err)
+			splitRes, err := split.Query(params, mapped).Exec(ctx)
+			require.NoError(t, err)
+
+			require.Equal(t, roundVector(res.Data.(promql.Vector)), roundVector(splitRes.Data.(promql.Vector)))
+		})
+	}
+}
+
+func roundVector(v promql.Vector) promql.Vector {
+	for i := range v {
+		v[i].V = math.Round(v[i].V*1e6) / 1e6
+	}
+	sort.Slice(v, func(i, j int) bool {
+		return labels.Compare(v[i].Metric, v[j].Metric) < 0
+	})
+	return v
+}
@@ -68,6 +68,9 @@ type DownstreamSampleExpr struct {
 }
 
 func (d DownstreamSampleExpr) String() string {
+	if d.shard == nil {
+		return fmt.Sprintf("downstream<%s>", d.SampleExpr.String())
+	}
 	return fmt.Sprintf("downstream<%s, shard=%s>", d.SampleExpr.String(), d.shard)
 }
 
@@ -259,6 +262,13 @@ func (ev *DownstreamEvaluator) StepEvaluator(
 
 		return ConcatEvaluator(xs)
 
+	case *QuantileSketchEvalExpr:
+		sketches, err := ev.StepEvaluator(ctx, nextEv, e.SampleExpr, params)
+		if err != nil {
+			return nil, err
+		}
+		return quantileSketchMergeEvaluator(sketches, e.quantile)
+
 	default:
 		return ev.defaultEvaluator.StepEvaluator(ctx, nextEv, e, params)
 	}
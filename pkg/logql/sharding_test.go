@@ -2,6 +2,7 @@ package logql
 
 import (
 	"context"
+	"errors"
 	"math"
 	"testing"
 	"time"
@@ -11,6 +12,8 @@ import (
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/sketch"
+	"github.com/grafana/loki/pkg/logqlmodel"
 )
 
 var nilMetrics = NewShardingMetrics(nil)
@@ -47,6 +50,8 @@ func TestMappingEquivalence(t *testing.T) {
 		{`sum(max(rate({a=~".+"}[1s])))`, false},
 		{`max(count(rate({a=~".+"}[1s])))`, false},
 		{`max(sum by (cluster) (rate({a=~".+"}[1s]))) / count(rate({a=~".+"}[1s]))`, false},
+		{`first_over_time({a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [2s])`, false},
+		{`sum by (a) (last_over_time({a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [2s]))`, false},
 		// topk prefers already-seen values in tiebreakers. Since the test data generates
 		// the same log lines for each series & the resulting promql.Vectors aren't deterministically
 		// sorted by labels, we don't expect this to pass.
@@ -98,6 +103,97 @@ func TestMappingEquivalence(t *testing.T) {
 	}
 }
 
+func TestQuantileSketchMappingEquivalence(t *testing.T) {
+	var (
+		shards   = 3
+		nStreams = 60
+		rounds   = 20
+		streams  = randomStreams(nStreams, rounds+1, shards, []string{"a", "b", "c", "d"})
+		start    = time.Unix(0, 0)
+		end      = time.Unix(0, int64(time.Second*time.Duration(rounds)))
+		step     = time.Second
+		interval = time.Duration(0)
+		limit    = 100
+	)
+
+	for _, query := range []string{
+		`quantile_over_time(0.99, {a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [5s]) by (a)`,
+		`quantile_over_time(0.5, {a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [5s]) by (a, b)`,
+		`sum(quantile_over_time(0.9, {a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [5s]) by (a))`,
+	} {
+		q := NewMockQuerier(
+			shards,
+			streams,
+		)
+
+		opts := EngineOpts{}
+		regular := NewEngine(opts, q, NoLimits)
+		sharded := NewShardedEngine(opts, MockDownstreamer{regular}, nilMetrics, NoLimits)
+
+		t.Run(query, func(t *testing.T) {
+			params := NewLiteralParams(
+				query,
+				start,
+				end,
+				step,
+				interval,
+				logproto.FORWARD,
+				uint32(limit),
+				nil,
+			)
+			ctx := user.InjectOrgID(context.Background(), "fake")
+
+			mapper, err := NewShardMapper(shards, nilMetrics)
+			require.Nil(t, err)
+			_, mapped, err := mapper.Parse(query)
+			require.Nil(t, err)
+
+			res, err := regular.Query(params).Exec(ctx)
+			require.Nil(t, err)
+
+			shardedRes, err := sharded.Query(params, mapped).Exec(ctx)
+			require.Nil(t, err)
+
+			// sketches are only accurate up to their relative accuracy.
+			as, bs := res.Data.(promql.Matrix), shardedRes.Data.(promql.Matrix)
+			require.Equal(t, len(as), len(bs))
+			for i := range as {
+				require.Equal(t, as[i].Metric, bs[i].Metric)
+				require.Equal(t, len(as[i].Points), len(bs[i].Points))
+				for j := range as[i].Points {
+					require.Equal(t, as[i].Points[j].T, bs[i].Points[j].T)
+					expected := as[i].Points[j].V
+					require.InDelta(t, expected, bs[i].Points[j].V, math.Abs(expected)*2*sketch.DefaultRelativeAccuracy)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantileSketchInternalOperation(t *testing.T) {
+	streams := randomStreams(10, 10, 2, []string{"a", "b"})
+	engine := NewEngine(EngineOpts{}, NewMockQuerier(2, streams), NoLimits)
+	ctx := user.InjectOrgID(context.Background(), "fake")
+	query := `__quantile_sketch_over_time__({a=~".+"} | regexp "line number: (?P<n>\\d+)" | unwrap n [5s]) by (a)`
+
+	// only the sharded queries sent by the query frontend may use the internal operations.
+	_, err := engine.Query(NewLiteralParams(query, time.Unix(10, 0), time.Unix(10, 0), 0, 0, logproto.FORWARD, 100, nil)).Exec(ctx)
+	require.True(t, errors.Is(err, logqlmodel.ErrParse))
+
+	res, err := engine.Query(NewLiteralParams(query, time.Unix(10, 0), time.Unix(10, 0), 0, 0, logproto.FORWARD, 100, []string{"0_of_2"})).Exec(ctx)
+	require.NoError(t, err)
+
+	// each series carries a single encoded sketch.
+	vec := res.Data.(promql.Vector)
+	require.NotEmpty(t, vec)
+	seen := map[string]struct{}{}
+	for _, s := range vec {
+		require.True(t, s.Metric.Has(QuantileSketchLabel))
+		seen[s.Metric.Get("a")] = struct{}{}
+	}
+	require.Len(t, seen, len(vec))
+}
+
 // approximatelyEquals ensures two responses are approximately equal, up to 6 decimals precision per sample
 func approximatelyEquals(t *testing.T, as, bs promql.Matrix) {
 	require.Equal(t, len(as), len(bs))
@@ -129,6 +129,10 @@ func (m ShardMapper) Map(expr Expr, r *shardRecorder) (Expr, error) {
 	case *LiteralExpr:
 		return e, nil
 	case *MatchersExpr, *PipelineExpr:
+		if _, ok := dedupTolerance(e); ok {
+			// duplicates must be removed from the results of all shards.
+			return e, nil
+		}
 		return m.mapLogSelectorExpr(e.(LogSelectorExpr), r), nil
 	case *VectorAggregationExpr:
 		return m.mapVectorAggregationExpr(e, r)
@@ -301,6 +305,28 @@ func (m ShardMapper) mapRangeAggregationExpr(expr *RangeAggregationExpr, r *shar
 		// rate(x) -> rate(x, shard=1) ++ rate(x, shard=2)...
 		// same goes for bytes_rate and bytes_over_time
 		return m.mapSampleExpr(expr, r)
+	case OpRangeTypeFirst, OpRangeTypeLast:
+		// first_over_time(x) -> first_over_time(x, shard=1) ++ first_over_time(x, shard=2)...
+		// series are never split across shards, but groups of series are.
+		if expr.Grouping == nil {
+			return m.mapSampleExpr(expr, r)
+		}
+		return expr
+	case OpRangeTypeQuantile:
+		if expr.Grouping == nil {
+			// without grouping, series are never split across shards so the quantile is exact.
+			return m.mapSampleExpr(expr, r)
+		}
+		// quantile_over_time(q, x) by (y) -> quantile_sketch_eval(q, __quantile_sketch_over_time__(x, shard=1) by (y) ++ ...)
+		// sketches from all shards are merged before computing the quantile.
+		return &QuantileSketchEvalExpr{
+			SampleExpr: m.mapSampleExpr(&RangeAggregationExpr{
+				Left:      expr.Left,
+				Operation: OpRangeTypeQuantileSketch,
+				Grouping:  expr.Grouping,
+			}, r),
+			quantile: *expr.Params,
+		}
 	default:
 		return expr
 	}
@@ -314,7 +340,8 @@ func hasLabelModifier(expr *RangeAggregationExpr) bool {
 		return false
 	case *PipelineExpr:
 		for _, p := range ex.MultiStages {
-			if _, ok := p.(*LabelFmtExpr); ok {
+			switch p.(type) {
+			case *LabelFmtExpr, *DropLabelsExpr, *KeepLabelsExpr:
 				return true
 			}
 		}
@@ -354,6 +381,8 @@ var shardableOps = map[string]bool{
 	OpRangeTypeSum:       true,
 	OpRangeTypeMax:       true,
 	OpRangeTypeMin:       true,
+	OpRangeTypeFirst:     true,
+	OpRangeTypeLast:      true,
 
 	// binops - arith
 	OpTypeAdd: true,
@@ -158,6 +158,22 @@ func TestMappingStrings(t *testing.T) {
 			in:  `rate({foo="bar"} | json | label_format foo=bar [5m])`,
 			out: `rate({foo="bar"} | json | label_format foo=bar [5m])`,
 		},
+		{
+			in:  `{foo="bar"} | json | dedup 5s`,
+			out: `{foo="bar"} | json | dedup 5s`,
+		},
+		{
+			in:  `sum(rate({foo="bar"} | json | drop instance [5m]))`,
+			out: `sum(rate({foo="bar"} | json | drop instance [5m]))`,
+		},
+		{
+			in:  `sum by (level) (rate({foo="bar"} | json | keep level [5m]))`,
+			out: `sum by (level) (rate({foo="bar"} | json | keep level [5m]))`,
+		},
+		{
+			in:  `sum by (team) (rate({foo="bar"} | lookup teams on instance [5m]))`,
+			out: `sum by(team)(downstream<sum by(team)(rate({foo="bar"}|lookup teams on instance[5m])), shard=0_of_2> ++ downstream<sum by(team)(rate({foo="bar"}|lookup teams on instance[5m])), shard=1_of_2>)`,
+		},
 		{
 			in:  `{foo="bar"} |= "id=123"`,
 			out: `downstream<{foo="bar"}|="id=123", shard=0_of_2> ++ downstream<{foo="bar"}|="id=123", shard=1_of_2>`,
@@ -174,6 +190,26 @@ func TestMappingStrings(t *testing.T) {
 			in:  `sum by (cluster) (stddev_over_time({foo="bar"} |= "id=123" | logfmt | unwrap latency [5m]))`,
 			out: `sum by (cluster) (stddev_over_time({foo="bar"} |= "id=123" | logfmt | unwrap latency [5m]))`,
 		},
+		{
+			in:  `first_over_time({foo="bar"} | logfmt | unwrap latency [5m])`,
+			out: `downstream<first_over_time({foo="bar"}| logfmt | unwrap latency[5m]), shard=0_of_2> ++ downstream<first_over_time({foo="bar"}| logfmt | unwrap latency[5m]), shard=1_of_2>`,
+		},
+		{
+			in:  `sum by (cluster) (last_over_time({foo="bar"} | logfmt | unwrap latency [5m]))`,
+			out: `sum by(cluster)(downstream<sum by(cluster)(last_over_time({foo="bar"}| logfmt | unwrap latency[5m])), shard=0_of_2> ++ downstream<sum by(cluster)(last_over_time({foo="bar"}| logfmt | unwrap latency[5m])), shard=1_of_2>)`,
+		},
+		{
+			in:  `sum(last_over_time({foo="bar"} | logfmt | unwrap latency [5m]) by (cluster))`,
+			out: `sum(last_over_time({foo="bar"} | logfmt | unwrap latency [5m]) by (cluster))`,
+		},
+		{
+			in:  `quantile_over_time(0.99, {foo="bar"} | logfmt | unwrap latency [5m])`,
+			out: `downstream<quantile_over_time(0.99,{foo="bar"}| logfmt | unwrap latency[5m]), shard=0_of_2> ++ downstream<quantile_over_time(0.99,{foo="bar"}| logfmt | unwrap latency[5m]), shard=1_of_2>`,
+		},
+		{
+			in:  `quantile_over_time(0.99, {foo="bar"} | logfmt | unwrap latency [5m]) by (cluster)`,
+			out: `quantileSketchEval<downstream<__quantile_sketch_over_time__({foo="bar"}| logfmt | unwrap latency[5m]) by (cluster), shard=0_of_2> ++ downstream<__quantile_sketch_over_time__({foo="bar"}| logfmt | unwrap latency[5m]) by (cluster), shard=1_of_2>, quantile=0.99>`,
+		},
 		{
 			in: `
 		sum without (a) (
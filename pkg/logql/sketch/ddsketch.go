@@ -0,0 +1,245 @@
+// Package sketch provides mergeable quantile sketches. They allow quantiles
+// to be computed from the partial results of sharded queries without having
+// to pull every sample to a single place.
+package sketch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultRelativeAccuracy is the relative accuracy used for quantile sketches
+// unless specified otherwise. A 1% accuracy keeps the number of buckets
+// needed for the usual latency ranges in the low hundreds.
+const DefaultRelativeAccuracy = 0.01
+
+// minIndexableValue is the smallest absolute value tracked in its own bucket.
+// Anything closer to zero is counted in the zero bucket.
+const minIndexableValue = 1e-9
+
+// DDSketch is a quantile sketch with relative error guarantees as described in
+// https://arxiv.org/abs/1908.10693.
+//
+// Values are mapped to logarithmically sized buckets so that any quantile
+// returned is within the relative accuracy of the exact value. Two sketches
+// using the same accuracy are merged by summing the counts of their buckets,
+// which makes them suitable for combining results across shards.
+type DDSketch struct {
+	gamma      float64
+	multiplier float64
+
+	positive map[int32]float64
+	negative map[int32]float64
+	zero     float64
+	count    float64
+}
+
+// New creates a DDSketch with the given relative accuracy, which must be in (0, 1).
+func New(relativeAccuracy float64) (*DDSketch, error) {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		return nil, fmt.Errorf("relative accuracy must be between 0 and 1, got %v", relativeAccuracy)
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &DDSketch{
+		gamma:      gamma,
+		multiplier: 1 / math.Log(gamma),
+		positive:   map[int32]float64{},
+		negative:   map[int32]float64{},
+	}, nil
+}
+
+// NewDefault creates a DDSketch using DefaultRelativeAccuracy.
+func NewDefault() *DDSketch {
+	s, _ := New(DefaultRelativeAccuracy)
+	return s
+}
+
+// Add records a value. NaN values are ignored.
+func (s *DDSketch) Add(v float64) {
+	s.AddCount(v, 1)
+}
+
+// AddCount records a value count times. NaN values are ignored.
+func (s *DDSketch) AddCount(v, count float64) {
+	switch {
+	case math.IsNaN(v):
+		return
+	case v >= minIndexableValue:
+		s.positive[s.index(v)] += count
+	case v <= -minIndexableValue:
+		s.negative[s.index(-v)] += count
+	default:
+		s.zero += count
+	}
+	s.count += count
+}
+
+// AddBucket adds count values to the given bucket.
+func (s *DDSketch) AddBucket(b Bucket) {
+	switch {
+	case b.Sign > 0:
+		s.positive[b.Index] += b.Count
+	case b.Sign < 0:
+		s.negative[b.Index] += b.Count
+	default:
+		s.zero += b.Count
+	}
+	s.count += b.Count
+}
+
+// Merge adds all values of o to s. Both sketches must have the same relative accuracy.
+func (s *DDSketch) Merge(o *DDSketch) error {
+	if s.gamma != o.gamma {
+		return fmt.Errorf("cannot merge sketches with different relative accuracy")
+	}
+	for _, b := range o.Buckets() {
+		s.AddBucket(b)
+	}
+	return nil
+}
+
+// Count returns the number of values recorded.
+func (s *DDSketch) Count() float64 {
+	return s.count
+}
+
+// Quantile returns the estimated q-quantile of the recorded values.
+// It follows the same conventions as the exact quantile_over_time:
+// NaN is returned for an empty sketch, -Inf for q<0 and +Inf for q>1, and
+// a weighted average of the two closest values is used when the quantile lies
+// between them.
+func (s *DDSketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	if q < 0 {
+		return math.Inf(-1)
+	}
+	if q > 1 {
+		return math.Inf(+1)
+	}
+	buckets := s.Buckets()
+	rank := q * (s.count - 1)
+	lowerRank := math.Floor(rank)
+	upperRank := math.Min(s.count-1, lowerRank+1)
+
+	weight := rank - lowerRank
+	return s.valueAt(buckets, lowerRank)*(1-weight) + s.valueAt(buckets, upperRank)*weight
+}
+
+// valueAt returns the value of the bucket holding the value at the given rank.
+func (s *DDSketch) valueAt(buckets []Bucket, rank float64) float64 {
+	var seen float64
+	for _, b := range buckets {
+		seen += b.Count
+		if seen > rank {
+			return s.value(b)
+		}
+	}
+	return s.value(buckets[len(buckets)-1])
+}
+
+// Buckets returns the non empty buckets of the sketch ordered by value.
+func (s *DDSketch) Buckets() []Bucket {
+	res := make([]Bucket, 0, len(s.negative)+len(s.positive)+1)
+	for i, c := range s.negative {
+		res = append(res, Bucket{Sign: -1, Index: i, Count: c})
+	}
+	if s.zero > 0 {
+		res = append(res, Bucket{Count: s.zero})
+	}
+	for i, c := range s.positive {
+		res = append(res, Bucket{Sign: 1, Index: i, Count: c})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].less(res[j])
+	})
+	return res
+}
+
+func (s *DDSketch) index(v float64) int32 {
+	idx := math.Ceil(math.Log(v) * s.multiplier)
+	if idx >= math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(idx)
+}
+
+// value returns the representative value of a bucket. Bucket i holds values
+// in (gamma^(i-1), gamma^i], the value returned is within the relative accuracy
+// of all of them.
+func (s *DDSketch) value(b Bucket) float64 {
+	if b.Sign == 0 {
+		return 0
+	}
+	v := 2 * math.Pow(s.gamma, float64(b.Index)) / (1 + s.gamma)
+	if b.Sign < 0 {
+		return -v
+	}
+	return v
+}
+
+// Bucket is a single bucket of a sketch.
+type Bucket struct {
+	// Sign is 1 for positive values, -1 for negative values and 0 for the zero bucket.
+	Sign  int8
+	Index int32
+	Count float64
+}
+
+func (b Bucket) less(o Bucket) bool {
+	if b.Sign != o.Sign {
+		return b.Sign < o.Sign
+	}
+	if b.Sign < 0 {
+		// larger indexes hold more negative values.
+		return b.Index > o.Index
+	}
+	return b.Index < o.Index
+}
+
+// MarshalBinary encodes the buckets of the sketch, ordered by value, to carry it in query results.
+// Each bucket is encoded as its sign, the varint delta of its index from the previous bucket and its count.
+func (s *DDSketch) MarshalBinary() ([]byte, error) {
+	buckets := s.Buckets()
+	buf := make([]byte, 0, len(buckets)*(2+binary.MaxVarintLen32+8))
+	tmp := make([]byte, binary.MaxVarintLen64)
+	var prev int32
+	for _, b := range buckets {
+		buf = append(buf, byte(b.Sign))
+		n := binary.PutVarint(tmp, int64(b.Index)-int64(prev))
+		buf = append(buf, tmp[:n]...)
+		binary.LittleEndian.PutUint64(tmp, math.Float64bits(b.Count))
+		buf = append(buf, tmp[:8]...)
+		prev = b.Index
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary adds the buckets encoded by MarshalBinary to the sketch.
+func (s *DDSketch) UnmarshalBinary(data []byte) error {
+	var prev int64
+	for len(data) > 0 {
+		b := Bucket{Sign: int8(data[0])}
+		if b.Sign < -1 || b.Sign > 1 {
+			return fmt.Errorf("invalid sketch bucket sign %d", b.Sign)
+		}
+		delta, n := binary.Varint(data[1:])
+		if n <= 0 || len(data) < 1+n+8 {
+			return fmt.Errorf("invalid sketch encoding")
+		}
+		idx := prev + delta
+		if idx < math.MinInt32 || idx > math.MaxInt32 {
+			return fmt.Errorf("invalid sketch bucket index %d", idx)
+		}
+		b.Index = int32(idx)
+		b.Count = math.Float64frombits(binary.LittleEndian.Uint64(data[1+n:]))
+		s.AddBucket(b)
+
+		prev = idx
+		data = data[1+n+8:]
+	}
+	return nil
+}
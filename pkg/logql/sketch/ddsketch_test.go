@@ -0,0 +1,115 @@
+package sketch
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDDSketch_Quantile(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		values func(r *rand.Rand) float64
+	}{
+		{"uniform", func(r *rand.Rand) float64 { return r.Float64() * 1000 }},
+		{"exponential", func(r *rand.Rand) float64 { return r.ExpFloat64() * 100 }},
+		{"negative", func(r *rand.Rand) float64 { return r.Float64()*2000 - 1000 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(42))
+			s := NewDefault()
+			values := make([]float64, 0, 10000)
+			for i := 0; i < 10000; i++ {
+				v := tc.values(r)
+				values = append(values, v)
+				s.Add(v)
+			}
+			sort.Float64s(values)
+			require.Equal(t, float64(len(values)), s.Count())
+
+			for _, q := range []float64{0, 0.25, 0.5, 0.9, 0.99, 1} {
+				expected := values[int(q*float64(len(values)-1))]
+				require.InEpsilon(t, expected, s.Quantile(q), DefaultRelativeAccuracy, "quantile %v", q)
+			}
+		})
+	}
+}
+
+func TestDDSketch_EdgeCases(t *testing.T) {
+	s := NewDefault()
+	require.True(t, math.IsNaN(s.Quantile(0.5)))
+
+	s.Add(math.NaN())
+	require.Equal(t, 0.0, s.Count())
+
+	s.Add(0)
+	s.Add(10)
+	require.Equal(t, math.Inf(-1), s.Quantile(-1))
+	require.Equal(t, math.Inf(1), s.Quantile(2))
+	require.Equal(t, 0.0, s.Quantile(0))
+	require.InEpsilon(t, 10, s.Quantile(1), DefaultRelativeAccuracy)
+
+	_, err := New(0)
+	require.Error(t, err)
+	_, err = New(1)
+	require.Error(t, err)
+}
+
+func TestDDSketch_Merge(t *testing.T) {
+	var (
+		r     = rand.New(rand.NewSource(42))
+		all   = NewDefault()
+		left  = NewDefault()
+		right = NewDefault()
+	)
+	for i := 0; i < 1000; i++ {
+		v := r.Float64() * 100
+		all.Add(v)
+		if i%2 == 0 {
+			left.Add(v)
+		} else {
+			right.Add(-v)
+			right.Add(v)
+			all.Add(-v)
+		}
+	}
+	require.NoError(t, left.Merge(right))
+	require.Equal(t, all.Buckets(), left.Buckets())
+
+	other, err := New(0.05)
+	require.NoError(t, err)
+	require.Error(t, left.Merge(other))
+}
+
+func TestDDSketch_MarshalBinary(t *testing.T) {
+	s := NewDefault()
+	s.Add(-42)
+	s.Add(-0.5)
+	s.Add(0)
+	s.Add(0.5)
+	s.Add(0.5)
+	s.Add(1e6)
+
+	data, err := s.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := NewDefault()
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, s.Buckets(), decoded.Buckets())
+	require.Equal(t, s.Count(), decoded.Count())
+
+	// decoding adds to the buckets of the sketch.
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, 2*s.Count(), decoded.Count())
+
+	empty, err := NewDefault().MarshalBinary()
+	require.NoError(t, err)
+	require.Empty(t, empty)
+
+	for _, data := range [][]byte{{1}, {1, 2}, {3, 2, 0, 0, 0, 0, 0, 0, 0, 0}, data[:len(data)-1]} {
+		require.Error(t, NewDefault().UnmarshalBinary(data), data)
+	}
+}
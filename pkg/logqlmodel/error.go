@@ -86,6 +86,13 @@ func NewSeriesLimitError(limit int) *LimitError {
 	}
 }
 
+// NewQueryLimitError returns the error of a query exceeding one of its per-query resource limits.
+func NewQueryLimitError(err error) *LimitError {
+	return &LimitError{
+		error: err,
+	}
+}
+
 // Is allows to use errors.Is(err,ErrLimit) on this error.
 func (e LimitError) Is(target error) bool {
 	return target == ErrLimit
@@ -16,7 +16,6 @@ To get the  statistic from the current context you can use:
 Finally to get a snapshot of the current query statistic use
 
 	statsCtx.Result(time.Since(start))
-
 */
 package stats
 
@@ -46,6 +45,8 @@ type Context struct {
 
 	// store is the store statistics collected across the query path
 	store Store
+	// caches is the cache statistics collected across the query path
+	caches Caches
 	// result accumulates results for JoinResult.
 	result Result
 
@@ -87,6 +88,7 @@ func (c *Context) Reset() {
 	c.store.Reset()
 	c.querier.Reset()
 	c.ingester.Reset()
+	c.caches.Reset()
 	c.result.Reset()
 }
 
@@ -99,6 +101,7 @@ func (c *Context) Result(execTime time.Duration) Result {
 			Store: c.store,
 		},
 		Ingester: c.ingester,
+		Caches:   c.caches,
 	})
 
 	r.ComputeSummary(execTime)
@@ -106,6 +109,18 @@ func (c *Context) Result(execTime time.Duration) Result {
 	return r
 }
 
+// BytesProcessed returns the total bytes processed so far, as computed by the summary of the result,
+// while the query is still being executed.
+func (c *Context) BytesProcessed() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return atomic.LoadInt64(&c.store.Chunk.DecompressedBytes) + atomic.LoadInt64(&c.store.Chunk.HeadChunkBytes) +
+		c.ingester.Store.Chunk.DecompressedBytes + c.ingester.Store.Chunk.HeadChunkBytes +
+		c.result.Querier.Store.Chunk.DecompressedBytes + c.result.Querier.Store.Chunk.HeadChunkBytes +
+		c.result.Ingester.Store.Chunk.DecompressedBytes + c.result.Ingester.Store.Chunk.HeadChunkBytes
+}
+
 // JoinResults merges a Result with the embedded Result in a context in a concurrency-safe manner.
 func JoinResults(ctx context.Context, res Result) {
 	stats := FromContext(ctx)
@@ -131,6 +146,12 @@ func (r *Result) ComputeSummary(execTime time.Duration) {
 	r.Summary.TotalLinesProcessed = r.Querier.Store.Chunk.DecompressedLines + r.Querier.Store.Chunk.HeadChunkLines +
 		r.Ingester.Store.Chunk.DecompressedLines + r.Ingester.Store.Chunk.HeadChunkLines
 	r.Summary.ExecTime = execTime.Seconds()
+	r.Summary.ChunkCacheHitRatio = r.Caches.Chunk.HitRatio()
+	r.Summary.IndexCacheHitRatio = r.Caches.Index.HitRatio()
+	r.Summary.DuplicateRatio = 0
+	if r.Summary.TotalLinesProcessed != 0 {
+		r.Summary.DuplicateRatio = float64(r.TotalDuplicates()) / float64(r.Summary.TotalLinesProcessed)
+	}
 	if execTime != 0 {
 		r.Summary.BytesProcessedPerSecond =
 			int64(float64(r.Summary.TotalBytesProcessed) /
@@ -151,6 +172,25 @@ func (s *Store) Merge(m Store) {
 	s.Chunk.DecompressedLines += m.Chunk.DecompressedLines
 	s.Chunk.CompressedBytes += m.Chunk.CompressedBytes
 	s.Chunk.TotalDuplicates += m.Chunk.TotalDuplicates
+	s.TotalIndexPagesRead += m.TotalIndexPagesRead
+}
+
+func (c *Cache) Merge(m Cache) {
+	c.EntriesRequested += m.EntriesRequested
+	c.EntriesFound += m.EntriesFound
+}
+
+// HitRatio returns the ratio of the entries requested found in the cache.
+func (c Cache) HitRatio() float64 {
+	if c.EntriesRequested == 0 {
+		return 0
+	}
+	return float64(c.EntriesFound) / float64(c.EntriesRequested)
+}
+
+func (c *Caches) Merge(m Caches) {
+	c.Chunk.Merge(m.Chunk)
+	c.Index.Merge(m.Index)
 }
 
 func (q *Querier) Merge(m Querier) {
@@ -168,6 +208,7 @@ func (i *Ingester) Merge(m Ingester) {
 func (r *Result) Merge(m Result) {
 	r.Querier.Merge(m.Querier)
 	r.Ingester.Merge(m.Ingester)
+	r.Caches.Merge(m.Caches)
 	r.ComputeSummary(time.Duration(int64((r.Summary.ExecTime + m.Summary.ExecTime) * float64(time.Second))))
 }
 
@@ -191,6 +232,10 @@ func (r Result) TotalDecompressedBytes() int64 {
 	return r.Querier.Store.Chunk.DecompressedBytes + r.Ingester.Store.Chunk.DecompressedBytes
 }
 
+func (r Result) TotalCompressedBytes() int64 {
+	return r.Querier.Store.Chunk.CompressedBytes + r.Ingester.Store.Chunk.CompressedBytes
+}
+
 func (r Result) TotalDecompressedLines() int64 {
 	return r.Querier.Store.Chunk.DecompressedLines + r.Ingester.Store.Chunk.DecompressedLines
 }
@@ -244,6 +289,22 @@ func (c *Context) AddChunksRef(i int64) {
 	atomic.AddInt64(&c.store.TotalChunksRef, i)
 }
 
+func (c *Context) AddIndexPagesRead(i int64) {
+	atomic.AddInt64(&c.store.TotalIndexPagesRead, i)
+}
+
+// AddChunkCacheEntries records a lookup of requested chunks in the chunk cache, out of which found were hits.
+func (c *Context) AddChunkCacheEntries(requested, found int64) {
+	atomic.AddInt64(&c.caches.Chunk.EntriesRequested, requested)
+	atomic.AddInt64(&c.caches.Chunk.EntriesFound, found)
+}
+
+// AddIndexCacheEntries records a lookup of requested index queries in the index cache, out of which found were hits.
+func (c *Context) AddIndexCacheEntries(requested, found int64) {
+	atomic.AddInt64(&c.caches.Index.EntriesRequested, requested)
+	atomic.AddInt64(&c.caches.Index.EntriesFound, found)
+}
+
 // Log logs a query statistics result.
 func (r Result) Log(log log.Logger) {
 	_ = log.Log(
@@ -270,6 +331,12 @@ func (r Result) Log(log log.Logger) {
 		"Querier.DecompressedLines", r.Querier.Store.Chunk.DecompressedLines,
 		"Querier.CompressedBytes", humanize.Bytes(uint64(r.Querier.Store.Chunk.CompressedBytes)),
 		"Querier.TotalDuplicates", r.Querier.Store.Chunk.TotalDuplicates,
+		"Querier.TotalIndexPagesRead", r.Querier.Store.TotalIndexPagesRead,
+
+		"Cache.Chunk.EntriesRequested", r.Caches.Chunk.EntriesRequested,
+		"Cache.Chunk.EntriesFound", r.Caches.Chunk.EntriesFound,
+		"Cache.Index.EntriesRequested", r.Caches.Index.EntriesRequested,
+		"Cache.Index.EntriesFound", r.Caches.Index.EntriesFound,
 	)
 	r.Summary.Log(log)
 }
@@ -281,5 +348,8 @@ func (s Summary) Log(log log.Logger) {
 		"Summary.TotalBytesProcessed", humanize.Bytes(uint64(s.TotalBytesProcessed)),
 		"Summary.TotalLinesProcessed", s.TotalLinesProcessed,
 		"Summary.ExecTime", time.Duration(int64(s.ExecTime*float64(time.Second))),
+		"Summary.ChunkCacheHitRatio", s.ChunkCacheHitRatio,
+		"Summary.IndexCacheHitRatio", s.IndexCacheHitRatio,
+		"Summary.DuplicateRatio", s.DuplicateRatio,
 	)
 }
@@ -21,12 +21,16 @@ func TestResult(t *testing.T) {
 	stats.AddChunksRef(50)
 	stats.AddChunksDownloaded(60)
 	stats.AddChunksDownloadTime(time.Second)
+	stats.AddIndexPagesRead(3)
+	stats.AddChunkCacheEntries(10, 4)
+	stats.AddIndexCacheEntries(8, 6)
 
 	fakeIngesterQuery(ctx)
 	fakeIngesterQuery(ctx)
 
 	res := stats.Result(2 * time.Second)
 	res.Log(util_log.Logger)
+	require.Equal(t, res.Summary.TotalBytesProcessed, stats.BytesProcessed())
 	expected := Result{
 		Ingester: Ingester{
 			TotalChunksMatched: 200,
@@ -49,6 +53,7 @@ func TestResult(t *testing.T) {
 				TotalChunksRef:        50,
 				TotalChunksDownloaded: 60,
 				ChunksDownloadTime:    time.Second.Nanoseconds(),
+				TotalIndexPagesRead:   3,
 				Chunk: Chunk{
 					HeadChunkBytes:    10,
 					HeadChunkLines:    20,
@@ -65,6 +70,13 @@ func TestResult(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     int64(84),
 			TotalLinesProcessed:     int64(100),
+			DuplicateRatio:          0.12,
+			ChunkCacheHitRatio:      0.4,
+			IndexCacheHitRatio:      0.75,
+		},
+		Caches: Caches{
+			Chunk: Cache{EntriesRequested: 10, EntriesFound: 4},
+			Index: Cache{EntriesRequested: 8, EntriesFound: 6},
 		},
 	}
 	require.Equal(t, expected, res)
@@ -110,10 +122,12 @@ func TestSnapshot_JoinResults(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     int64(84),
 			TotalLinesProcessed:     int64(100),
+			DuplicateRatio:          0.12,
 		},
 	}
 
 	JoinResults(ctx, expected)
+	require.Equal(t, expected.Summary.TotalBytesProcessed, statsCtx.BytesProcessed())
 	res := statsCtx.Result(2 * time.Second)
 	require.Equal(t, expected, res)
 }
@@ -181,6 +195,11 @@ func TestResult_Merge(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     int64(84),
 			TotalLinesProcessed:     int64(100),
+			DuplicateRatio:          0.12,
+			ChunkCacheHitRatio:      0.5,
+		},
+		Caches: Caches{
+			Chunk: Cache{EntriesRequested: 4, EntriesFound: 2},
 		},
 	}
 
@@ -227,6 +246,11 @@ func TestResult_Merge(t *testing.T) {
 			LinesProcessedPerSecond: int64(50),
 			TotalBytesProcessed:     2 * int64(84),
 			TotalLinesProcessed:     2 * int64(100),
+			DuplicateRatio:          0.12,
+			ChunkCacheHitRatio:      0.5,
+		},
+		Caches: Caches{
+			Chunk: Cache{EntriesRequested: 2 * 4, EntriesFound: 2 * 2},
 		},
 	}, res)
 }
@@ -31,6 +31,7 @@ type Result struct {
 	Summary  Summary  `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary"`
 	Querier  Querier  `protobuf:"bytes,2,opt,name=querier,proto3" json:"querier"`
 	Ingester Ingester `protobuf:"bytes,3,opt,name=ingester,proto3" json:"ingester"`
+	Caches   Caches   `protobuf:"bytes,4,opt,name=caches,proto3" json:"cache"`
 }
 
 func (m *Result) Reset()      { *m = Result{} }
@@ -86,6 +87,118 @@ func (m *Result) GetIngester() Ingester {
 	return Ingester{}
 }
 
+func (m *Result) GetCaches() Caches {
+	if m != nil {
+		return m.Caches
+	}
+	return Caches{}
+}
+
+// Caches contains the statistics of the caches used by the query.
+type Caches struct {
+	Chunk Cache `protobuf:"bytes,1,opt,name=chunk,proto3" json:"chunk"`
+	Index Cache `protobuf:"bytes,2,opt,name=index,proto3" json:"index"`
+}
+
+func (m *Caches) Reset()      { *m = Caches{} }
+func (*Caches) ProtoMessage() {}
+func (*Caches) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{1}
+}
+func (m *Caches) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Caches) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Caches.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Caches) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Caches.Merge(m, src)
+}
+func (m *Caches) XXX_Size() int {
+	return m.Size()
+}
+func (m *Caches) XXX_DiscardUnknown() {
+	xxx_messageInfo_Caches.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Caches proto.InternalMessageInfo
+
+func (m *Caches) GetChunk() Cache {
+	if m != nil {
+		return m.Chunk
+	}
+	return Cache{}
+}
+
+func (m *Caches) GetIndex() Cache {
+	if m != nil {
+		return m.Index
+	}
+	return Cache{}
+}
+
+type Cache struct {
+	// Total entries looked up in the cache.
+	EntriesRequested int64 `protobuf:"varint,1,opt,name=entriesRequested,proto3" json:"entriesRequested"`
+	// Total entries found in the cache.
+	EntriesFound int64 `protobuf:"varint,2,opt,name=entriesFound,proto3" json:"entriesFound"`
+}
+
+func (m *Cache) Reset()      { *m = Cache{} }
+func (*Cache) ProtoMessage() {}
+func (*Cache) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{2}
+}
+func (m *Cache) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Cache) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Cache.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Cache) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Cache.Merge(m, src)
+}
+func (m *Cache) XXX_Size() int {
+	return m.Size()
+}
+func (m *Cache) XXX_DiscardUnknown() {
+	xxx_messageInfo_Cache.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Cache proto.InternalMessageInfo
+
+func (m *Cache) GetEntriesRequested() int64 {
+	if m != nil {
+		return m.EntriesRequested
+	}
+	return 0
+}
+
+func (m *Cache) GetEntriesFound() int64 {
+	if m != nil {
+		return m.EntriesFound
+	}
+	return 0
+}
+
 // Summary is the summary of a query statistics.
 type Summary struct {
 	// Total bytes processed per second.
@@ -98,12 +211,18 @@ type Summary struct {
 	TotalLinesProcessed int64 `protobuf:"varint,4,opt,name=totalLinesProcessed,proto3" json:"totalLinesProcessed"`
 	// Execution time in seconds.
 	ExecTime float64 `protobuf:"fixed64,5,opt,name=execTime,proto3" json:"execTime"`
+	// Ratio of the chunks found in the chunk cache.
+	ChunkCacheHitRatio float64 `protobuf:"fixed64,6,opt,name=chunkCacheHitRatio,proto3" json:"chunkCacheHitRatio"`
+	// Ratio of the index queries found in the index cache.
+	IndexCacheHitRatio float64 `protobuf:"fixed64,7,opt,name=indexCacheHitRatio,proto3" json:"indexCacheHitRatio"`
+	// Ratio of the processed lines that were duplicates.
+	DuplicateRatio float64 `protobuf:"fixed64,8,opt,name=duplicateRatio,proto3" json:"duplicateRatio"`
 }
 
 func (m *Summary) Reset()      { *m = Summary{} }
 func (*Summary) ProtoMessage() {}
 func (*Summary) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{1}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{3}
 }
 func (m *Summary) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -167,6 +286,27 @@ func (m *Summary) GetExecTime() float64 {
 	return 0
 }
 
+func (m *Summary) GetChunkCacheHitRatio() float64 {
+	if m != nil {
+		return m.ChunkCacheHitRatio
+	}
+	return 0
+}
+
+func (m *Summary) GetIndexCacheHitRatio() float64 {
+	if m != nil {
+		return m.IndexCacheHitRatio
+	}
+	return 0
+}
+
+func (m *Summary) GetDuplicateRatio() float64 {
+	if m != nil {
+		return m.DuplicateRatio
+	}
+	return 0
+}
+
 type Querier struct {
 	Store Store `protobuf:"bytes,1,opt,name=store,proto3" json:"store"`
 }
@@ -174,7 +314,7 @@ type Querier struct {
 func (m *Querier) Reset()      { *m = Querier{} }
 func (*Querier) ProtoMessage() {}
 func (*Querier) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{2}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{4}
 }
 func (m *Querier) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -225,7 +365,7 @@ type Ingester struct {
 func (m *Ingester) Reset()      { *m = Ingester{} }
 func (*Ingester) ProtoMessage() {}
 func (*Ingester) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{3}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{5}
 }
 func (m *Ingester) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -297,12 +437,14 @@ type Store struct {
 	// Time spent fetching chunks in nanoseconds.
 	ChunksDownloadTime int64 `protobuf:"varint,3,opt,name=chunksDownloadTime,proto3" json:"chunksDownloadTime"`
 	Chunk              Chunk `protobuf:"bytes,4,opt,name=chunk,proto3" json:"chunk"`
+	// Total number of index pages read.
+	TotalIndexPagesRead int64 `protobuf:"varint,5,opt,name=totalIndexPagesRead,proto3" json:"totalIndexPagesRead"`
 }
 
 func (m *Store) Reset()      { *m = Store{} }
 func (*Store) ProtoMessage() {}
 func (*Store) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{4}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{6}
 }
 func (m *Store) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -359,6 +501,13 @@ func (m *Store) GetChunk() Chunk {
 	return Chunk{}
 }
 
+func (m *Store) GetTotalIndexPagesRead() int64 {
+	if m != nil {
+		return m.TotalIndexPagesRead
+	}
+	return 0
+}
+
 type Chunk struct {
 	// Total bytes processed but was already in memory. (found in the headchunk)
 	HeadChunkBytes int64 `protobuf:"varint,4,opt,name=headChunkBytes,proto3" json:"headChunkBytes"`
@@ -377,7 +526,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6cdfe5d2aea33ebb, []int{5}
+	return fileDescriptor_6cdfe5d2aea33ebb, []int{7}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -450,6 +599,8 @@ func (m *Chunk) GetTotalDuplicates() int64 {
 
 func init() {
 	proto.RegisterType((*Result)(nil), "stats.Result")
+	proto.RegisterType((*Caches)(nil), "stats.Caches")
+	proto.RegisterType((*Cache)(nil), "stats.Cache")
 	proto.RegisterType((*Summary)(nil), "stats.Summary")
 	proto.RegisterType((*Querier)(nil), "stats.Querier")
 	proto.RegisterType((*Ingester)(nil), "stats.Ingester")
@@ -460,51 +611,62 @@ func init() {
 func init() { proto.RegisterFile("pkg/logqlmodel/stats/stats.proto", fileDescriptor_6cdfe5d2aea33ebb) }
 
 var fileDescriptor_6cdfe5d2aea33ebb = []byte{
-	// 693 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xbf, 0x6f, 0xd3, 0x40,
-	0x14, 0xb6, 0x13, 0xdc, 0x84, 0xa3, 0xb4, 0xe5, 0xaa, 0xd2, 0x00, 0x92, 0x5d, 0x65, 0xea, 0x00,
-	0x8d, 0xf8, 0xb1, 0x80, 0xe8, 0xe2, 0x56, 0x48, 0x95, 0x40, 0x94, 0x57, 0x58, 0xd8, 0x1c, 0xe7,
-	0x9a, 0x58, 0x75, 0x7c, 0xa9, 0x7f, 0x08, 0xba, 0xb1, 0x31, 0xc2, 0x9f, 0xc1, 0xc2, 0x9f, 0xc0,
-	0xde, 0xb1, 0x63, 0x27, 0x8b, 0xba, 0x0b, 0xf2, 0xd4, 0x8d, 0x15, 0xf9, 0x9d, 0x63, 0xd7, 0x17,
-	0x47, 0x62, 0xb1, 0xef, 0x7d, 0xdf, 0xfb, 0xde, 0x3b, 0xbf, 0xef, 0xac, 0x23, 0x1b, 0x93, 0xa3,
-	0x61, 0xcf, 0xe5, 0xc3, 0x63, 0x77, 0xcc, 0x07, 0xcc, 0xed, 0x05, 0xa1, 0x15, 0x06, 0xe2, 0xb9,
-	0x35, 0xf1, 0x79, 0xc8, 0xa9, 0x86, 0xc1, 0xfd, 0x47, 0x43, 0x27, 0x1c, 0x45, 0xfd, 0x2d, 0x9b,
-	0x8f, 0x7b, 0x43, 0x3e, 0xe4, 0x3d, 0x64, 0xfb, 0xd1, 0x21, 0x46, 0x18, 0xe0, 0x4a, 0xa8, 0xba,
-	0xbf, 0x54, 0xb2, 0x00, 0x2c, 0x88, 0xdc, 0x90, 0x3e, 0x27, 0xad, 0x20, 0x1a, 0x8f, 0x2d, 0xff,
-	0xa4, 0xa3, 0x6e, 0xa8, 0x9b, 0xb7, 0x9e, 0x2c, 0x6d, 0x89, 0xfa, 0x07, 0x02, 0x35, 0x97, 0x4f,
-	0x63, 0x43, 0x49, 0x63, 0x63, 0x9a, 0x06, 0xd3, 0x45, 0x26, 0x3d, 0x8e, 0x98, 0xef, 0x30, 0xbf,
-	0xd3, 0xa8, 0x48, 0xdf, 0x09, 0xb4, 0x94, 0xe6, 0x69, 0x30, 0x5d, 0xd0, 0x6d, 0xd2, 0x76, 0xbc,
-	0x21, 0x0b, 0x42, 0xe6, 0x77, 0x9a, 0xa8, 0x5d, 0xce, 0xb5, 0x7b, 0x39, 0x6c, 0xae, 0xe4, 0xe2,
-	0x22, 0x11, 0x8a, 0x55, 0xf7, 0x6f, 0x83, 0xb4, 0xf2, 0xfd, 0xd1, 0x0f, 0x64, 0xbd, 0x7f, 0x12,
-	0xb2, 0x60, 0xdf, 0xe7, 0x36, 0x0b, 0x02, 0x36, 0xd8, 0x67, 0xfe, 0x01, 0xb3, 0xb9, 0x37, 0xc0,
-	0x0f, 0x6a, 0x9a, 0x0f, 0xd2, 0xd8, 0x98, 0x97, 0x02, 0xf3, 0x88, 0xac, 0xac, 0xeb, 0x78, 0xb5,
-	0x65, 0x1b, 0x65, 0xd9, 0x39, 0x29, 0x30, 0x8f, 0xa0, 0x7b, 0x64, 0x35, 0xe4, 0xa1, 0xe5, 0x9a,
-	0x95, 0xb6, 0x38, 0x83, 0xa6, 0xb9, 0x9e, 0xc6, 0x46, 0x1d, 0x0d, 0x75, 0x60, 0x51, 0xea, 0x75,
-	0xa5, 0x55, 0xe7, 0x86, 0x54, 0xaa, 0x4a, 0x43, 0x1d, 0x48, 0x37, 0x49, 0x9b, 0x7d, 0x66, 0xf6,
-	0x7b, 0x67, 0xcc, 0x3a, 0xda, 0x86, 0xba, 0xa9, 0x9a, 0x8b, 0xd9, 0xe4, 0xa7, 0x18, 0x14, 0xab,
-	0xee, 0x4b, 0xd2, 0xca, 0xdd, 0xa5, 0x8f, 0x89, 0x16, 0x84, 0xdc, 0x67, 0xf9, 0xb9, 0x59, 0x9c,
-	0x9e, 0x9b, 0x0c, 0x33, 0x6f, 0xe7, 0xee, 0x89, 0x14, 0x10, 0xaf, 0xee, 0xcf, 0x06, 0x69, 0x4f,
-	0x0d, 0xa6, 0xcf, 0xc8, 0x22, 0xee, 0x05, 0x98, 0x65, 0x8f, 0x98, 0x70, 0x4b, 0x33, 0x57, 0xd2,
-	0xd8, 0xa8, 0xe0, 0x50, 0x89, 0xe8, 0x2b, 0x42, 0x31, 0xde, 0x19, 0x45, 0xde, 0x51, 0xf0, 0xc6,
-	0x0a, 0x51, 0x2b, 0x2c, 0xb9, 0x9b, 0xc6, 0x46, 0x0d, 0x0b, 0x35, 0x58, 0xd1, 0xdd, 0xc4, 0x38,
-	0xc8, 0x1d, 0x28, 0xbb, 0xe7, 0x38, 0x54, 0x22, 0xfa, 0x82, 0x2c, 0x95, 0xf3, 0x3b, 0x60, 0x5e,
-	0x98, 0x8f, 0x9b, 0xa6, 0xb1, 0x21, 0x31, 0x20, 0xc5, 0xe5, 0xbc, 0xb4, 0xff, 0x9e, 0xd7, 0xb7,
-	0x06, 0xd1, 0x90, 0x2f, 0x1a, 0x8b, 0x8f, 0x00, 0x76, 0x98, 0x1f, 0xee, 0xb2, 0x71, 0xc1, 0x80,
-	0x14, 0xd3, 0xb7, 0x64, 0xed, 0x1a, 0xb2, 0xcb, 0x3f, 0x79, 0x2e, 0xb7, 0x06, 0xc5, 0xd4, 0xee,
-	0xa5, 0xb1, 0x51, 0x9f, 0x00, 0xf5, 0x70, 0xe6, 0x81, 0x5d, 0xc1, 0xf0, 0xe0, 0x34, 0x4b, 0x0f,
-	0x66, 0x59, 0xa8, 0xc1, 0xb2, 0x89, 0x20, 0x8a, 0x43, 0x2c, 0x27, 0x82, 0xfd, 0xca, 0x89, 0x60,
-	0x0a, 0x88, 0x57, 0xf7, 0x6b, 0x93, 0x68, 0xc8, 0x67, 0x13, 0x19, 0x31, 0x6b, 0x20, 0x92, 0xb3,
-	0x3f, 0xe3, 0xba, 0x15, 0x55, 0x06, 0xa4, 0xb8, 0xa2, 0x45, 0x83, 0xd0, 0x13, 0x59, 0x8b, 0x0c,
-	0x48, 0x31, 0xdd, 0x21, 0x77, 0x06, 0xcc, 0xe6, 0xe3, 0x89, 0x8f, 0xff, 0x8e, 0x68, 0xbd, 0x80,
-	0xf2, 0xb5, 0x34, 0x36, 0x66, 0x49, 0x98, 0x85, 0xe4, 0x22, 0x62, 0x0f, 0xad, 0xfa, 0x22, 0x62,
-	0x1b, 0xb3, 0x10, 0xdd, 0x26, 0xcb, 0xf2, 0x3e, 0xda, 0x58, 0x62, 0x35, 0x8d, 0x0d, 0x99, 0x02,
-	0x19, 0xc8, 0xe4, 0x68, 0xef, 0x6e, 0x34, 0x71, 0x1d, 0xdb, 0xca, 0xe4, 0x37, 0x4b, 0xb9, 0x44,
-	0x81, 0x0c, 0x98, 0xfd, 0xb3, 0x0b, 0x5d, 0x39, 0xbf, 0xd0, 0x95, 0xab, 0x0b, 0x5d, 0xfd, 0x92,
-	0xe8, 0xea, 0x8f, 0x44, 0x57, 0x4f, 0x13, 0x5d, 0x3d, 0x4b, 0x74, 0xf5, 0x77, 0xa2, 0xab, 0x7f,
-	0x12, 0x5d, 0xb9, 0x4a, 0x74, 0xf5, 0xfb, 0xa5, 0xae, 0x9c, 0x5d, 0xea, 0xca, 0xf9, 0xa5, 0xae,
-	0x7c, 0x7c, 0x78, 0xfd, 0xa2, 0xf2, 0xad, 0x43, 0xcb, 0xb3, 0x7a, 0x2e, 0x3f, 0x72, 0x7a, 0x75,
-	0x37, 0x5d, 0x7f, 0x01, 0xaf, 0xab, 0xa7, 0xff, 0x02, 0x00, 0x00, 0xff, 0xff, 0x2e, 0xbd, 0xac,
-	0xdd, 0x08, 0x07, 0x00, 0x00,
+	// 865 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0x41, 0x6f, 0xe3, 0x44,
+	0x14, 0x8e, 0x93, 0x3a, 0x09, 0x43, 0x77, 0x5b, 0x66, 0x59, 0xd6, 0x80, 0x64, 0x57, 0x39, 0xf5,
+	0x00, 0x8d, 0x80, 0xbd, 0x80, 0x58, 0x09, 0xb9, 0xab, 0x15, 0x95, 0x40, 0x94, 0x57, 0xb8, 0x70,
+	0x73, 0xec, 0x69, 0x62, 0xd5, 0xf1, 0xa4, 0xf6, 0x58, 0xec, 0x9e, 0xe0, 0xc6, 0x95, 0x9f, 0xc1,
+	0x85, 0x5f, 0xc1, 0x65, 0x8f, 0x3d, 0xae, 0x38, 0x58, 0x34, 0xbd, 0x20, 0x9f, 0x56, 0xe2, 0x0f,
+	0xa0, 0x79, 0xe3, 0xd8, 0xf1, 0xd8, 0x41, 0x5c, 0xec, 0x79, 0xdf, 0xf7, 0xbe, 0xf7, 0xc6, 0x6f,
+	0xde, 0x1b, 0x99, 0x1c, 0xad, 0xae, 0xe6, 0xd3, 0x88, 0xcf, 0xaf, 0xa3, 0x25, 0x0f, 0x58, 0x34,
+	0x4d, 0x85, 0x27, 0x52, 0xf5, 0x3c, 0x59, 0x25, 0x5c, 0x70, 0x6a, 0xa2, 0xf1, 0xde, 0x87, 0xf3,
+	0x50, 0x2c, 0xb2, 0xd9, 0x89, 0xcf, 0x97, 0xd3, 0x39, 0x9f, 0xf3, 0x29, 0xb2, 0xb3, 0xec, 0x12,
+	0x2d, 0x34, 0x70, 0xa5, 0x54, 0x93, 0x7f, 0x0c, 0x32, 0x04, 0x96, 0x66, 0x91, 0xa0, 0x9f, 0x92,
+	0x51, 0x9a, 0x2d, 0x97, 0x5e, 0xf2, 0xc2, 0x32, 0x8e, 0x8c, 0xe3, 0x37, 0x3f, 0xbe, 0x7f, 0xa2,
+	0xe2, 0x5f, 0x28, 0xd4, 0x3d, 0x78, 0x99, 0x3b, 0xbd, 0x22, 0x77, 0x36, 0x6e, 0xb0, 0x59, 0x48,
+	0xe9, 0x75, 0xc6, 0x92, 0x90, 0x25, 0x56, 0xbf, 0x21, 0xfd, 0x56, 0xa1, 0xb5, 0xb4, 0x74, 0x83,
+	0xcd, 0x82, 0x3e, 0x21, 0xe3, 0x30, 0x9e, 0xb3, 0x54, 0xb0, 0xc4, 0x1a, 0xa0, 0xf6, 0xa0, 0xd4,
+	0x9e, 0x95, 0xb0, 0x7b, 0x58, 0x8a, 0x2b, 0x47, 0xa8, 0x56, 0xf4, 0x31, 0x19, 0xfa, 0x9e, 0xbf,
+	0x60, 0xa9, 0xb5, 0x87, 0xe2, 0x7b, 0xa5, 0xf8, 0x14, 0x41, 0xf7, 0x5e, 0x29, 0x35, 0xd1, 0x09,
+	0x4a, 0xdf, 0x49, 0x4c, 0x86, 0xca, 0x81, 0x7e, 0x44, 0x4c, 0x7f, 0x91, 0xc5, 0x57, 0xe5, 0x27,
+	0xef, 0x6f, 0xcb, 0xb7, 0xd4, 0xd2, 0x05, 0xd4, 0x4b, 0x4a, 0xc2, 0x38, 0x60, 0xcf, 0xad, 0xfe,
+	0x7f, 0x49, 0xd0, 0x05, 0xd4, 0x6b, 0xf2, 0x13, 0x31, 0x91, 0xa6, 0x5f, 0x90, 0x43, 0x16, 0x8b,
+	0x24, 0x64, 0x29, 0xb0, 0xeb, 0x4c, 0x7e, 0x42, 0x80, 0x99, 0x07, 0xee, 0xdb, 0x45, 0xee, 0xb4,
+	0x38, 0x68, 0x21, 0xf4, 0x31, 0xd9, 0x2f, 0xb1, 0x67, 0x3c, 0x8b, 0x03, 0xdc, 0xc4, 0xc0, 0x3d,
+	0x2c, 0x72, 0xa7, 0x81, 0x43, 0xc3, 0x9a, 0xfc, 0xb1, 0x47, 0x46, 0xe5, 0x31, 0xd2, 0xef, 0xc9,
+	0xa3, 0xd9, 0x0b, 0xc1, 0xd2, 0xf3, 0x84, 0xfb, 0x2c, 0x4d, 0x59, 0x70, 0xce, 0x92, 0x0b, 0xe6,
+	0xf3, 0x78, 0xb3, 0x95, 0xf7, 0x8b, 0xdc, 0xd9, 0xe5, 0x02, 0xbb, 0x08, 0x19, 0x36, 0x0a, 0xe3,
+	0xce, 0xb0, 0xfd, 0x3a, 0xec, 0x0e, 0x17, 0xd8, 0x45, 0xd0, 0x33, 0xf2, 0x40, 0x70, 0xe1, 0x45,
+	0x6e, 0x23, 0x2d, 0xb6, 0xca, 0xc0, 0x7d, 0x54, 0xe4, 0x4e, 0x17, 0x0d, 0x5d, 0x60, 0x15, 0xea,
+	0xab, 0x46, 0x2a, 0x6b, 0x4f, 0x0b, 0xd5, 0xa4, 0xa1, 0x0b, 0xa4, 0xc7, 0x64, 0xcc, 0x9e, 0x33,
+	0xff, 0xbb, 0x70, 0xc9, 0x2c, 0xf3, 0xc8, 0x38, 0x36, 0xdc, 0x7d, 0xd9, 0xa0, 0x1b, 0x0c, 0xaa,
+	0x15, 0x7d, 0x46, 0x28, 0xb6, 0x0d, 0x9e, 0xff, 0x97, 0xa1, 0x00, 0x4f, 0x84, 0xdc, 0x1a, 0xa2,
+	0xe6, 0x9d, 0x22, 0x77, 0x3a, 0x58, 0xe8, 0xc0, 0x64, 0x1c, 0xec, 0xa5, 0x66, 0x9c, 0x51, 0x1d,
+	0xa7, 0xcd, 0x42, 0x07, 0x46, 0x3f, 0x23, 0xf7, 0x83, 0x6c, 0x15, 0x85, 0xbe, 0x27, 0x98, 0x8a,
+	0x31, 0xc6, 0x18, 0xb4, 0xc8, 0x1d, 0x8d, 0x01, 0xcd, 0x9e, 0x7c, 0x4e, 0x46, 0xe5, 0x40, 0xcb,
+	0x21, 0x48, 0x05, 0x4f, 0x98, 0x36, 0x37, 0x17, 0x12, 0xab, 0x87, 0x00, 0x5d, 0x40, 0xbd, 0x26,
+	0xbf, 0xf7, 0xc9, 0xf8, 0xac, 0x9e, 0xdb, 0x7d, 0xac, 0x2b, 0x30, 0xb9, 0x3b, 0xd5, 0x79, 0xa6,
+	0x6a, 0xe3, 0x6d, 0x1c, 0x1a, 0x96, 0x2c, 0x02, 0xda, 0xa7, 0xb2, 0x3e, 0xe9, 0xd7, 0x9e, 0x40,
+	0xad, 0x6a, 0x2f, 0x2c, 0x42, 0x9b, 0x85, 0x0e, 0xac, 0xca, 0xee, 0xa2, 0x9d, 0x96, 0xdd, 0x54,
+	0x67, 0x2f, 0x71, 0x68, 0x58, 0xb2, 0x74, 0x75, 0x2f, 0x5c, 0xb0, 0x58, 0x94, 0xad, 0x83, 0xa5,
+	0x6b, 0x32, 0xa0, 0xd9, 0x75, 0xbd, 0xcc, 0xff, 0x5d, 0xaf, 0x3f, 0xfb, 0xc4, 0x44, 0xbe, 0x4a,
+	0xac, 0x3e, 0x02, 0xd8, 0xa5, 0x65, 0x68, 0x89, 0x2b, 0x06, 0x34, 0x9b, 0x7e, 0x43, 0x1e, 0x6e,
+	0x21, 0x4f, 0xf9, 0x8f, 0x71, 0xc4, 0xbd, 0xa0, 0xaa, 0xda, 0xbb, 0x45, 0xee, 0x74, 0x3b, 0x40,
+	0x37, 0x5c, 0x35, 0x74, 0x85, 0xe1, 0x10, 0x0c, 0xea, 0x33, 0x68, 0xb3, 0xd0, 0x81, 0xd5, 0x37,
+	0xef, 0x5e, 0xf3, 0x1a, 0x95, 0xd8, 0x8e, 0x9b, 0x77, 0x33, 0xc0, 0x67, 0xb2, 0xad, 0xcf, 0xbd,
+	0x39, 0x4b, 0x81, 0x79, 0x81, 0x65, 0x6a, 0x03, 0xdc, 0xa4, 0xa1, 0x0b, 0x9c, 0xfc, 0x32, 0x20,
+	0x26, 0xa6, 0x92, 0xc5, 0x5d, 0x30, 0x2f, 0x50, 0x79, 0xe5, 0x85, 0xb1, 0x7d, 0xaa, 0x4d, 0x06,
+	0x34, 0xbb, 0xa1, 0xc5, 0xb3, 0xb6, 0xcc, 0x0e, 0x2d, 0x32, 0xa0, 0xd9, 0xf4, 0x94, 0xbc, 0x15,
+	0x30, 0x9f, 0x2f, 0x57, 0x09, 0x5e, 0x29, 0x2a, 0xf5, 0x10, 0xe5, 0x0f, 0x8b, 0xdc, 0x69, 0x93,
+	0xd0, 0x86, 0xf4, 0x20, 0x6a, 0x0f, 0xa3, 0xee, 0x20, 0x6a, 0x1b, 0x6d, 0x88, 0x3e, 0x21, 0x07,
+	0xfa, 0x3e, 0xc6, 0x18, 0xe2, 0x41, 0x91, 0x3b, 0x3a, 0x05, 0x3a, 0x20, 0xe5, 0x58, 0xe1, 0xa7,
+	0x9b, 0xcb, 0x22, 0xb5, 0xde, 0xa8, 0xe5, 0x1a, 0x05, 0x3a, 0xe0, 0xce, 0x6e, 0x6e, 0xed, 0xde,
+	0xab, 0x5b, 0xbb, 0xf7, 0xfa, 0xd6, 0x36, 0x7e, 0x5e, 0xdb, 0xc6, 0x6f, 0x6b, 0xdb, 0x78, 0xb9,
+	0xb6, 0x8d, 0x9b, 0xb5, 0x6d, 0xfc, 0xb5, 0xb6, 0x8d, 0xbf, 0xd7, 0x76, 0xef, 0xf5, 0xda, 0x36,
+	0x7e, 0xbd, 0xb3, 0x7b, 0x37, 0x77, 0x76, 0xef, 0xd5, 0x9d, 0xdd, 0xfb, 0xe1, 0x83, 0xed, 0xdf,
+	0x9c, 0xc4, 0xbb, 0xf4, 0x62, 0x6f, 0x1a, 0xf1, 0xab, 0x70, 0xda, 0xf5, 0x9f, 0x34, 0x1b, 0xe2,
+	0xcf, 0xce, 0x27, 0xff, 0x06, 0x00, 0x00, 0xff, 0xff, 0x0d, 0x55, 0x9f, 0x90, 0x46, 0x09, 0x00,
+	0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -535,6 +697,63 @@ func (this *Result) Equal(that interface{}) bool {
 	if !this.Ingester.Equal(&that1.Ingester) {
 		return false
 	}
+	if !this.Caches.Equal(&that1.Caches) {
+		return false
+	}
+	return true
+}
+func (this *Caches) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Caches)
+	if !ok {
+		that2, ok := that.(Caches)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Chunk.Equal(&that1.Chunk) {
+		return false
+	}
+	if !this.Index.Equal(&that1.Index) {
+		return false
+	}
+	return true
+}
+func (this *Cache) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Cache)
+	if !ok {
+		that2, ok := that.(Cache)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.EntriesRequested != that1.EntriesRequested {
+		return false
+	}
+	if this.EntriesFound != that1.EntriesFound {
+		return false
+	}
 	return true
 }
 func (this *Summary) Equal(that interface{}) bool {
@@ -571,6 +790,15 @@ func (this *Summary) Equal(that interface{}) bool {
 	if this.ExecTime != that1.ExecTime {
 		return false
 	}
+	if this.ChunkCacheHitRatio != that1.ChunkCacheHitRatio {
+		return false
+	}
+	if this.IndexCacheHitRatio != that1.IndexCacheHitRatio {
+		return false
+	}
+	if this.DuplicateRatio != that1.DuplicateRatio {
+		return false
+	}
 	return true
 }
 func (this *Querier) Equal(that interface{}) bool {
@@ -664,6 +892,9 @@ func (this *Store) Equal(that interface{}) bool {
 	if !this.Chunk.Equal(&that1.Chunk) {
 		return false
 	}
+	if this.TotalIndexPagesRead != that1.TotalIndexPagesRead {
+		return false
+	}
 	return true
 }
 func (this *Chunk) Equal(that interface{}) bool {
@@ -709,11 +940,34 @@ func (this *Result) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&stats.Result{")
 	s = append(s, "Summary: "+strings.Replace(this.Summary.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Querier: "+strings.Replace(this.Querier.GoString(), `&`, ``, 1)+",\n")
 	s = append(s, "Ingester: "+strings.Replace(this.Ingester.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Caches: "+strings.Replace(this.Caches.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Caches) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.Caches{")
+	s = append(s, "Chunk: "+strings.Replace(this.Chunk.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "Index: "+strings.Replace(this.Index.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Cache) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.Cache{")
+	s = append(s, "EntriesRequested: "+fmt.Sprintf("%#v", this.EntriesRequested)+",\n")
+	s = append(s, "EntriesFound: "+fmt.Sprintf("%#v", this.EntriesFound)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -721,13 +975,16 @@ func (this *Summary) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 12)
 	s = append(s, "&stats.Summary{")
 	s = append(s, "BytesProcessedPerSecond: "+fmt.Sprintf("%#v", this.BytesProcessedPerSecond)+",\n")
 	s = append(s, "LinesProcessedPerSecond: "+fmt.Sprintf("%#v", this.LinesProcessedPerSecond)+",\n")
 	s = append(s, "TotalBytesProcessed: "+fmt.Sprintf("%#v", this.TotalBytesProcessed)+",\n")
 	s = append(s, "TotalLinesProcessed: "+fmt.Sprintf("%#v", this.TotalLinesProcessed)+",\n")
 	s = append(s, "ExecTime: "+fmt.Sprintf("%#v", this.ExecTime)+",\n")
+	s = append(s, "ChunkCacheHitRatio: "+fmt.Sprintf("%#v", this.ChunkCacheHitRatio)+",\n")
+	s = append(s, "IndexCacheHitRatio: "+fmt.Sprintf("%#v", this.IndexCacheHitRatio)+",\n")
+	s = append(s, "DuplicateRatio: "+fmt.Sprintf("%#v", this.DuplicateRatio)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -759,12 +1016,13 @@ func (this *Store) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&stats.Store{")
 	s = append(s, "TotalChunksRef: "+fmt.Sprintf("%#v", this.TotalChunksRef)+",\n")
 	s = append(s, "TotalChunksDownloaded: "+fmt.Sprintf("%#v", this.TotalChunksDownloaded)+",\n")
 	s = append(s, "ChunksDownloadTime: "+fmt.Sprintf("%#v", this.ChunksDownloadTime)+",\n")
 	s = append(s, "Chunk: "+strings.Replace(this.Chunk.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "TotalIndexPagesRead: "+fmt.Sprintf("%#v", this.TotalIndexPagesRead)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -811,6 +1069,16 @@ func (m *Result) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	{
+		size, err := m.Caches.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x22
 	{
 		size, err := m.Ingester.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -844,6 +1112,82 @@ func (m *Result) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Caches) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Caches) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Caches) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	{
+		size, err := m.Index.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x12
+	{
+		size, err := m.Chunk.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintStats(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
+func (m *Cache) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Cache) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Cache) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.EntriesFound != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EntriesFound))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.EntriesRequested != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.EntriesRequested))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *Summary) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -864,6 +1208,24 @@ func (m *Summary) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.DuplicateRatio != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.DuplicateRatio))))
+		i--
+		dAtA[i] = 0x41
+	}
+	if m.IndexCacheHitRatio != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.IndexCacheHitRatio))))
+		i--
+		dAtA[i] = 0x39
+	}
+	if m.ChunkCacheHitRatio != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ChunkCacheHitRatio))))
+		i--
+		dAtA[i] = 0x31
+	}
 	if m.ExecTime != 0 {
 		i -= 8
 		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ExecTime))))
@@ -999,6 +1361,11 @@ func (m *Store) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.TotalIndexPagesRead != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalIndexPagesRead))
+		i--
+		dAtA[i] = 0x28
+	}
 	{
 		size, err := m.Chunk.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -1103,16 +1470,46 @@ func (m *Result) Size() (n int) {
 	n += 1 + l + sovStats(uint64(l))
 	l = m.Ingester.Size()
 	n += 1 + l + sovStats(uint64(l))
+	l = m.Caches.Size()
+	n += 1 + l + sovStats(uint64(l))
 	return n
 }
 
-func (m *Summary) Size() (n int) {
+func (m *Caches) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.BytesProcessedPerSecond != 0 {
+	l = m.Chunk.Size()
+	n += 1 + l + sovStats(uint64(l))
+	l = m.Index.Size()
+	n += 1 + l + sovStats(uint64(l))
+	return n
+}
+
+func (m *Cache) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EntriesRequested != 0 {
+		n += 1 + sovStats(uint64(m.EntriesRequested))
+	}
+	if m.EntriesFound != 0 {
+		n += 1 + sovStats(uint64(m.EntriesFound))
+	}
+	return n
+}
+
+func (m *Summary) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.BytesProcessedPerSecond != 0 {
 		n += 1 + sovStats(uint64(m.BytesProcessedPerSecond))
 	}
 	if m.LinesProcessedPerSecond != 0 {
@@ -1127,6 +1524,15 @@ func (m *Summary) Size() (n int) {
 	if m.ExecTime != 0 {
 		n += 9
 	}
+	if m.ChunkCacheHitRatio != 0 {
+		n += 9
+	}
+	if m.IndexCacheHitRatio != 0 {
+		n += 9
+	}
+	if m.DuplicateRatio != 0 {
+		n += 9
+	}
 	return n
 }
 
@@ -1181,6 +1587,9 @@ func (m *Store) Size() (n int) {
 	}
 	l = m.Chunk.Size()
 	n += 1 + l + sovStats(uint64(l))
+	if m.TotalIndexPagesRead != 0 {
+		n += 1 + sovStats(uint64(m.TotalIndexPagesRead))
+	}
 	return n
 }
 
@@ -1225,6 +1634,29 @@ func (this *Result) String() string {
 		`Summary:` + strings.Replace(strings.Replace(this.Summary.String(), "Summary", "Summary", 1), `&`, ``, 1) + `,`,
 		`Querier:` + strings.Replace(strings.Replace(this.Querier.String(), "Querier", "Querier", 1), `&`, ``, 1) + `,`,
 		`Ingester:` + strings.Replace(strings.Replace(this.Ingester.String(), "Ingester", "Ingester", 1), `&`, ``, 1) + `,`,
+		`Caches:` + strings.Replace(strings.Replace(this.Caches.String(), "Caches", "Caches", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Caches) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Caches{`,
+		`Chunk:` + strings.Replace(strings.Replace(this.Chunk.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`Index:` + strings.Replace(strings.Replace(this.Index.String(), "Cache", "Cache", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Cache) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Cache{`,
+		`EntriesRequested:` + fmt.Sprintf("%v", this.EntriesRequested) + `,`,
+		`EntriesFound:` + fmt.Sprintf("%v", this.EntriesFound) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1239,6 +1671,9 @@ func (this *Summary) String() string {
 		`TotalBytesProcessed:` + fmt.Sprintf("%v", this.TotalBytesProcessed) + `,`,
 		`TotalLinesProcessed:` + fmt.Sprintf("%v", this.TotalLinesProcessed) + `,`,
 		`ExecTime:` + fmt.Sprintf("%v", this.ExecTime) + `,`,
+		`ChunkCacheHitRatio:` + fmt.Sprintf("%v", this.ChunkCacheHitRatio) + `,`,
+		`IndexCacheHitRatio:` + fmt.Sprintf("%v", this.IndexCacheHitRatio) + `,`,
+		`DuplicateRatio:` + fmt.Sprintf("%v", this.DuplicateRatio) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1276,6 +1711,7 @@ func (this *Store) String() string {
 		`TotalChunksDownloaded:` + fmt.Sprintf("%v", this.TotalChunksDownloaded) + `,`,
 		`ChunksDownloadTime:` + fmt.Sprintf("%v", this.ChunksDownloadTime) + `,`,
 		`Chunk:` + strings.Replace(strings.Replace(this.Chunk.String(), "Chunk", "Chunk", 1), `&`, ``, 1) + `,`,
+		`TotalIndexPagesRead:` + fmt.Sprintf("%v", this.TotalIndexPagesRead) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1431,6 +1867,249 @@ func (m *Result) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Caches", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Caches.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Caches) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Caches: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Caches: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Chunk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Index.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Cache) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Cache: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Cache: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntriesRequested", wireType)
+			}
+			m.EntriesRequested = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntriesRequested |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EntriesFound", wireType)
+			}
+			m.EntriesFound = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EntriesFound |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1571,6 +2250,39 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
 			iNdEx += 8
 			m.ExecTime = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkCacheHitRatio", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ChunkCacheHitRatio = float64(math.Float64frombits(v))
+		case 7:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IndexCacheHitRatio", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.IndexCacheHitRatio = float64(math.Float64frombits(v))
+		case 8:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DuplicateRatio", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.DuplicateRatio = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1962,6 +2674,25 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalIndexPagesRead", wireType)
+			}
+			m.TotalIndexPagesRead = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalIndexPagesRead |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
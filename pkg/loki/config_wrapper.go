@@ -31,6 +31,7 @@ type ConfigWrapper struct {
 	PrintVersion    bool
 	VerifyConfig    bool
 	PrintConfig     bool
+	PrintSchema     bool
 	ListTargets     bool
 	LogConfig       bool
 	ConfigFile      string
@@ -41,6 +42,7 @@ func (c *ConfigWrapper) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&c.PrintVersion, "version", false, "Print this builds version information")
 	f.BoolVar(&c.VerifyConfig, "verify-config", false, "Verify config file and exits")
 	f.BoolVar(&c.PrintConfig, "print-config-stderr", false, "Dump the entire Loki config object to stderr")
+	f.BoolVar(&c.PrintSchema, "print-config-schema", false, "Print the schema of the config file as JSON, with the type, flag, default value and description of each field, and exit.")
 	f.BoolVar(&c.ListTargets, "list-targets", false, "List available targets")
 	f.BoolVar(&c.LogConfig, "log-config-reverse-order", false, "Dump the entire Loki config object at Info log "+
 		"level with the order reversed, reversing the order makes viewing the entries easier in Grafana.")
@@ -86,6 +88,7 @@ func (c *ConfigWrapper) ApplyDynamicConfig() cfg.Source {
 		applyPathPrefixDefaults(r, &defaults)
 
 		applyDynamicRingConfigs(r, &defaults)
+		applyCompactorTableLockTTL(r, &defaults)
 
 		appendLoopbackInterface(r, &defaults)
 
@@ -215,6 +218,28 @@ func applyConfigToRings(r, defaults *ConfigWrapper, rc util.RingConfig, mergeWit
 		r.CompactorConfig.CompactorRing.ZoneAwarenessEnabled = rc.ZoneAwarenessEnabled
 		r.CompactorConfig.CompactorRing.KVStore = rc.KVStore
 	}
+
+	// Index Gateway
+	if mergeWithExisting || reflect.DeepEqual(r.IndexGateway.Ring, defaults.IndexGateway.Ring) {
+		r.IndexGateway.Ring.HeartbeatTimeout = rc.HeartbeatTimeout
+		r.IndexGateway.Ring.HeartbeatPeriod = rc.HeartbeatPeriod
+		r.IndexGateway.Ring.InstancePort = rc.InstancePort
+		r.IndexGateway.Ring.InstanceAddr = rc.InstanceAddr
+		r.IndexGateway.Ring.InstanceID = rc.InstanceID
+		r.IndexGateway.Ring.InstanceInterfaceNames = rc.InstanceInterfaceNames
+		r.IndexGateway.Ring.InstanceZone = rc.InstanceZone
+		r.IndexGateway.Ring.ZoneAwarenessEnabled = rc.ZoneAwarenessEnabled
+		r.IndexGateway.Ring.KVStore = rc.KVStore
+	}
+}
+
+// applyCompactorTableLockTTL enables the table locks of the compactor when its ring uses memberlist and they were
+// not configured. A gossiped ring only converges eventually, so two compactors can briefly both consider they
+// should run the compaction, the locks taken in the shared store keeping them from compacting the same table.
+func applyCompactorTableLockTTL(r, defaults *ConfigWrapper) {
+	if r.CompactorConfig.CompactorRing.KVStore.Store == memberlistStr && r.CompactorConfig.TableLockTTL == defaults.CompactorConfig.TableLockTTL {
+		r.CompactorConfig.TableLockTTL = 10 * time.Minute
+	}
 }
 
 func applyTokensFilePath(cfg *ConfigWrapper) error {
@@ -239,6 +264,13 @@ func applyTokensFilePath(cfg *ConfigWrapper) error {
 	}
 	cfg.QueryScheduler.SchedulerRing.TokensFilePath = f
 
+	// Index Gateway
+	f, err = tokensFile(cfg, "indexgateway.tokens")
+	if err != nil {
+		return err
+	}
+	cfg.IndexGateway.Ring.TokensFilePath = f
+
 	return nil
 }
 
@@ -310,9 +342,13 @@ func appendLoopbackInterface(cfg, defaults *ConfigWrapper) {
 	if reflect.DeepEqual(cfg.Ruler.Ring.InstanceInterfaceNames, defaults.Ruler.Ring.InstanceInterfaceNames) {
 		cfg.Ruler.Ring.InstanceInterfaceNames = append(cfg.Ruler.Ring.InstanceInterfaceNames, loopbackIface)
 	}
+
+	if reflect.DeepEqual(cfg.IndexGateway.Ring.InstanceInterfaceNames, defaults.IndexGateway.Ring.InstanceInterfaceNames) {
+		cfg.IndexGateway.Ring.InstanceInterfaceNames = append(cfg.IndexGateway.Ring.InstanceInterfaceNames, loopbackIface)
+	}
 }
 
-// applyMemberlistConfig will change the default ingester, distributor, ruler, and query scheduler ring configurations to use memberlist.
+// applyMemberlistConfig will change the default ingester, distributor, ruler, query scheduler, compactor and index gateway ring configurations to use memberlist.
 // The idea here is that if a user explicitly configured the memberlist configuration section, they probably want to be using memberlist
 // for all their ring configurations. Since a user can still explicitly override a specific ring configuration
 // (for example, use consul for the distributor), it seems harmless to take a guess at better defaults here.
@@ -322,6 +358,7 @@ func applyMemberlistConfig(r *ConfigWrapper) {
 	r.Ruler.Ring.KVStore.Store = memberlistStr
 	r.QueryScheduler.SchedulerRing.KVStore.Store = memberlistStr
 	r.CompactorConfig.CompactorRing.KVStore.Store = memberlistStr
+	r.IndexGateway.Ring.KVStore.Store = memberlistStr
 }
 
 var ErrTooManyStorageConfigs = errors.New("too many storage configs provided in the common config, please only define one storage backend")
@@ -32,11 +32,16 @@ import (
 	"github.com/weaveworks/common/signals"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/grafana/loki/pkg/checker"
+	"github.com/grafana/loki/pkg/dictionaries"
 	"github.com/grafana/loki/pkg/distributor"
 	"github.com/grafana/loki/pkg/ingester"
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/loki/common"
 	"github.com/grafana/loki/pkg/lokifrontend"
+	"github.com/grafana/loki/pkg/lookup"
+	"github.com/grafana/loki/pkg/overrides"
+	"github.com/grafana/loki/pkg/profiling"
 	"github.com/grafana/loki/pkg/querier"
 	"github.com/grafana/loki/pkg/querier/queryrange"
 	"github.com/grafana/loki/pkg/querier/worker"
@@ -46,6 +51,7 @@ import (
 	"github.com/grafana/loki/pkg/storage"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway"
 	"github.com/grafana/loki/pkg/tracing"
 	serverutil "github.com/grafana/loki/pkg/util/server"
 	"github.com/grafana/loki/pkg/validation"
@@ -73,10 +79,16 @@ type Config struct {
 	Ruler            ruler.Config             `yaml:"ruler,omitempty"`
 	QueryRange       queryrange.Config        `yaml:"query_range,omitempty"`
 	RuntimeConfig    runtimeconfig.Config     `yaml:"runtime_config,omitempty"`
+	OverridesAPI     overrides.Config         `yaml:"overrides_api,omitempty"`
+	LookupTables     lookup.Config            `yaml:"lookup_tables,omitempty"`
+	ZstdDictionaries dictionaries.Config      `yaml:"zstd_dictionaries,omitempty"`
 	MemberlistKV     memberlist.KVConfig      `yaml:"memberlist"`
 	Tracing          tracing.Config           `yaml:"tracing"`
+	Profiling        profiling.Config         `yaml:"profiling"`
 	CompactorConfig  compactor.Config         `yaml:"compactor,omitempty"`
+	IndexGateway     indexgateway.Config      `yaml:"index_gateway,omitempty"`
 	QueryScheduler   scheduler.Config         `yaml:"query_scheduler"`
+	Checker          checker.Config           `yaml:"checker,omitempty"`
 }
 
 // RegisterFlags registers flag.
@@ -107,10 +119,16 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.Worker.RegisterFlags(f)
 	c.QueryRange.RegisterFlags(f)
 	c.RuntimeConfig.RegisterFlags(f)
+	c.OverridesAPI.RegisterFlags(f)
+	c.LookupTables.RegisterFlags(f)
+	c.ZstdDictionaries.RegisterFlags(f)
 	c.MemberlistKV.RegisterFlags(f)
 	c.Tracing.RegisterFlags(f)
+	c.Profiling.RegisterFlags(f)
 	c.CompactorConfig.RegisterFlags(f)
+	c.IndexGateway.RegisterFlags(f)
 	c.QueryScheduler.RegisterFlags(f)
+	c.Checker.RegisterFlags(f)
 }
 
 func (c *Config) registerServerFlagsWithChangedDefaultValues(fs *flag.FlagSet) {
@@ -157,6 +175,9 @@ func (c *Config) Validate() error {
 	if err := c.TableManager.Validate(); err != nil {
 		return errors.Wrap(err, "invalid tablemanager config")
 	}
+	if err := c.Querier.Validate(); err != nil {
+		return errors.Wrap(err, "invalid querier config")
+	}
 	if err := c.Ruler.Validate(); err != nil {
 		return errors.Wrap(err, "invalid ruler config")
 	}
@@ -166,6 +187,18 @@ func (c *Config) Validate() error {
 	if err := c.LimitsConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid limits config")
 	}
+	if err := c.OverridesAPI.Validate(); err != nil {
+		return errors.Wrap(err, "invalid overrides api config")
+	}
+	if err := c.LookupTables.Validate(); err != nil {
+		return errors.Wrap(err, "invalid lookup tables config")
+	}
+	if err := c.ZstdDictionaries.Validate(); err != nil {
+		return errors.Wrap(err, "invalid zstd dictionaries config")
+	}
+	if err := c.Frontend.Validate(); err != nil {
+		return errors.Wrap(err, "invalid frontend config")
+	}
 	if err := c.Worker.Validate(util_log.Logger); err != nil {
 		return errors.Wrap(err, "invalid storage config")
 	}
@@ -175,9 +208,21 @@ func (c *Config) Validate() error {
 	if err := c.CompactorConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid compactor config")
 	}
+	if err := c.IndexGateway.Validate(); err != nil {
+		return errors.Wrap(err, "invalid index gateway config")
+	}
+	if err := c.Profiling.Validate(); err != nil {
+		return errors.Wrap(err, "invalid profiling config")
+	}
 	if err := c.ChunkStoreConfig.Validate(util_log.Logger); err != nil {
 		return errors.Wrap(err, "invalid chunk store config")
 	}
+	if err := c.Distributor.UsageTracker.Validate(); err != nil {
+		return errors.Wrap(err, "invalid distributor usage tracker config")
+	}
+	if err := c.Checker.Validate(); err != nil {
+		return errors.Wrap(err, "invalid checker config")
+	}
 	// TODO(cyriltovena): remove when MaxLookBackPeriod in the storage will be fully deprecated.
 	if c.ChunkStoreConfig.MaxLookBackPeriod > 0 {
 		c.LimitsConfig.MaxQueryLookback = c.ChunkStoreConfig.MaxLookBackPeriod
@@ -218,12 +263,15 @@ type Loki struct {
 	ring                     *ring.Ring
 	overrides                *validation.Overrides
 	tenantConfigs            *runtime.TenantConfigs
+	zstdDictionaries         *dictionaries.Dictionaries
+	lookupTables             *lookup.Tables
 	TenantLimits             validation.TenantLimits
 	distributor              *distributor.Distributor
 	Ingester                 *ingester.Ingester
 	Querier                  *querier.Querier
 	ingesterQuerier          *querier.IngesterQuerier
 	Store                    storage.Store
+	archiveStore             storage.Store
 	tableManager             *chunk.TableManager
 	frontend                 Frontend
 	ruler                    *cortex_ruler.Ruler
@@ -233,6 +281,7 @@ type Loki struct {
 	runtimeConfig            *runtimeconfig.Manager
 	MemberlistKV             *memberlist.KVInitService
 	compactor                *compactor.Compactor
+	indexGatewayRing         *ring.Ring
 	QueryFrontEndTripperware cortex_tripper.Tripperware
 	queryScheduler           *scheduler.Scheduler
 
@@ -455,6 +504,8 @@ func (t *Loki) setupModuleManager() error {
 	mm.RegisterModule(Overrides, t.initOverrides, modules.UserInvisibleModule)
 	mm.RegisterModule(OverridesExporter, t.initOverridesExporter)
 	mm.RegisterModule(TenantConfigs, t.initTenantConfigs, modules.UserInvisibleModule)
+	mm.RegisterModule(LookupTables, t.initLookupTables, modules.UserInvisibleModule)
+	mm.RegisterModule(ZstdDictionaries, t.initZstdDictionaries, modules.UserInvisibleModule)
 	mm.RegisterModule(Distributor, t.initDistributor)
 	mm.RegisterModule(Store, t.initStore, modules.UserInvisibleModule)
 	mm.RegisterModule(Ingester, t.initIngester)
@@ -466,8 +517,10 @@ func (t *Loki) setupModuleManager() error {
 	mm.RegisterModule(Ruler, t.initRuler)
 	mm.RegisterModule(TableManager, t.initTableManager)
 	mm.RegisterModule(Compactor, t.initCompactor)
+	mm.RegisterModule(IndexGatewayRing, t.initIndexGatewayRing, modules.UserInvisibleModule)
 	mm.RegisterModule(IndexGateway, t.initIndexGateway)
 	mm.RegisterModule(QueryScheduler, t.initQueryScheduler)
+	mm.RegisterModule(Checker, t.initChecker)
 
 	mm.RegisterModule(All, nil)
 	mm.RegisterModule(Read, nil)
@@ -476,21 +529,23 @@ func (t *Loki) setupModuleManager() error {
 	// Add dependencies
 	deps := map[string][]string{
 		Ring:                     {RuntimeConfig, Server, MemberlistKV},
-		Overrides:                {RuntimeConfig},
+		Overrides:                {RuntimeConfig, Server},
 		OverridesExporter:        {Overrides, Server},
 		TenantConfigs:            {RuntimeConfig},
 		Distributor:              {Ring, Server, Overrides, TenantConfigs},
-		Store:                    {Overrides},
-		Ingester:                 {Store, Server, MemberlistKV, TenantConfigs},
-		Querier:                  {Store, Ring, Server, IngesterQuerier, TenantConfigs},
+		Store:                    {Overrides, ZstdDictionaries, IndexGatewayRing},
+		Ingester:                 {Store, Server, MemberlistKV, TenantConfigs, LookupTables},
+		Querier:                  {Store, Ring, Server, IngesterQuerier, TenantConfigs, LookupTables},
 		QueryFrontendTripperware: {Server, Overrides, TenantConfigs},
 		QueryFrontend:            {QueryFrontendTripperware},
 		QueryScheduler:           {Server, Overrides, MemberlistKV},
-		Ruler:                    {Ring, Server, Store, RulerStorage, IngesterQuerier, Overrides, TenantConfigs},
+		Ruler:                    {Ring, Server, Store, RulerStorage, IngesterQuerier, Overrides, TenantConfigs, LookupTables},
 		TableManager:             {Server},
 		Compactor:                {Server, Overrides, MemberlistKV},
-		IndexGateway:             {Server},
+		IndexGateway:             {Server, IndexGatewayRing},
+		IndexGatewayRing:         {Server, MemberlistKV},
 		IngesterQuerier:          {Ring},
+		Checker:                  {Store, Server},
 		All:                      {QueryScheduler, QueryFrontend, Querier, Ingester, Distributor, Ruler, Compactor},
 		Read:                     {QueryScheduler, QueryFrontend, Querier, Ruler, Compactor},
 		Write:                    {Ingester, Distributor},
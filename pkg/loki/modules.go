@@ -15,6 +15,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/frontend/v2/frontendv2pb"
 	cortex_ruler "github.com/cortexproject/cortex/pkg/ruler"
 	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
+	cortex_tenant "github.com/cortexproject/cortex/pkg/tenant"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/kv/codec"
@@ -29,12 +30,18 @@ import (
 	"github.com/weaveworks/common/server"
 	"github.com/weaveworks/common/user"
 
+	"github.com/grafana/loki/pkg/checker"
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/dictionaries"
 	"github.com/grafana/loki/pkg/distributor"
 	"github.com/grafana/loki/pkg/ingester"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logproto/otlp"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/lokifrontend/frontend"
 	"github.com/grafana/loki/pkg/lokifrontend/frontend/transport"
+	"github.com/grafana/loki/pkg/lookup"
+	"github.com/grafana/loki/pkg/overrides"
 	"github.com/grafana/loki/pkg/querier"
 	"github.com/grafana/loki/pkg/querier/queryrange"
 	"github.com/grafana/loki/pkg/ruler"
@@ -51,6 +58,8 @@ import (
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway/indexgatewaypb"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/uploads"
+	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/usage"
 	"github.com/grafana/loki/pkg/util/httpreq"
 	serverutil "github.com/grafana/loki/pkg/util/server"
 	"github.com/grafana/loki/pkg/validation"
@@ -65,6 +74,8 @@ const (
 	Overrides                string = "overrides"
 	OverridesExporter        string = "overrides-exporter"
 	TenantConfigs            string = "tenant-configs"
+	LookupTables             string = "lookup-tables"
+	ZstdDictionaries         string = "zstd-dictionaries"
 	Server                   string = "server"
 	Distributor              string = "distributor"
 	Ingester                 string = "ingester"
@@ -79,7 +90,9 @@ const (
 	MemberlistKV             string = "memberlist-kv"
 	Compactor                string = "compactor"
 	IndexGateway             string = "index-gateway"
+	IndexGatewayRing         string = "index-gateway-ring"
 	QueryScheduler           string = "query-scheduler"
+	Checker                  string = "checker"
 	All                      string = "all"
 	Read                     string = "read"
 	Write                    string = "write"
@@ -159,9 +172,33 @@ func (t *Loki) initRuntimeConfig() (services.Service, error) {
 }
 
 func (t *Loki) initOverrides() (_ services.Service, err error) {
+	if !t.Cfg.OverridesAPI.Enabled {
+		t.overrides, err = validation.NewOverrides(t.Cfg.LimitsConfig, t.TenantLimits)
+		// overrides are not a service, since they don't have any operational state.
+		return nil, err
+	}
+
+	var objectClient chunk.ObjectClient
+	if t.Cfg.OverridesAPI.Store != "" {
+		objectClient, err = storage.NewObjectClient(t.Cfg.OverridesAPI.Store, t.Cfg.StorageConfig.Config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// make sure to set default limits before limits are set through the API.
+	validation.SetDefaultLimitsForYAMLUnmarshalling(t.Cfg.LimitsConfig)
+
+	// The limits set through the API replace the ones of the runtime config file.
+	api := overrides.NewAPI(t.Cfg.OverridesAPI, t.Cfg.LimitsConfig, t.TenantLimits, objectClient, util_log.Logger, prometheus.DefaultRegisterer)
+	t.TenantLimits = api
 	t.overrides, err = validation.NewOverrides(t.Cfg.LimitsConfig, t.TenantLimits)
-	// overrides are not a service, since they don't have any operational state.
-	return nil, err
+	if err != nil {
+		return nil, err
+	}
+
+	t.Server.HTTP.Path("/config/overrides/{tenant}").Methods("GET", "PUT", "DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(api.Handler)))
+	return api, nil
 }
 
 func (t *Loki) initOverridesExporter() (services.Service, error) {
@@ -184,9 +221,37 @@ func (t *Loki) initTenantConfigs() (_ services.Service, err error) {
 	return nil, err
 }
 
+func (t *Loki) initLookupTables() (services.Service, error) {
+	if len(t.Cfg.LookupTables.Tables) == 0 {
+		return nil, nil
+	}
+
+	objectClient, err := storage.NewObjectClient(t.Cfg.LookupTables.Store, t.Cfg.StorageConfig.Config)
+	if err != nil {
+		return nil, err
+	}
+	t.lookupTables = lookup.NewTables(t.Cfg.LookupTables, objectClient, util_log.Logger, prometheus.DefaultRegisterer)
+	return t.lookupTables, nil
+}
+
+func (t *Loki) initZstdDictionaries() (services.Service, error) {
+	if t.Cfg.ZstdDictionaries.Store == "" {
+		return nil, nil
+	}
+
+	objectClient, err := storage.NewObjectClient(t.Cfg.ZstdDictionaries.Store, t.Cfg.StorageConfig.Config)
+	if err != nil {
+		return nil, err
+	}
+	t.zstdDictionaries = dictionaries.New(t.Cfg.ZstdDictionaries, objectClient, util_log.Logger, prometheus.DefaultRegisterer)
+	chunkenc.SetZstdDictionaryLoader(t.zstdDictionaries.Load)
+	return t.zstdDictionaries, nil
+}
+
 func (t *Loki) initDistributor() (services.Service, error) {
 	t.Cfg.Distributor.DistributorRing.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.runtimeConfig)
 	t.Cfg.Distributor.DistributorRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
+	t.Cfg.Distributor.UsageTracker.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.runtimeConfig)
 	var err error
 	t.distributor, err = distributor.New(t.Cfg.Distributor, t.Cfg.IngesterClient, t.tenantConfigs, t.ring, t.overrides, prometheus.DefaultRegisterer)
 	if err != nil {
@@ -198,6 +263,7 @@ func (t *Loki) initDistributor() (services.Service, error) {
 	if !t.Cfg.isModuleEnabled(All) && !t.Cfg.isModuleEnabled(Write) && !t.Cfg.isModuleEnabled(Ingester) {
 		logproto.RegisterPusherServer(t.Server.GRPC, t.distributor)
 	}
+	otlp.RegisterLogsServiceServer(t.Server.GRPC, t.distributor)
 
 	pushHandler := middleware.Merge(
 		serverutil.RecoveryHTTPMiddleware,
@@ -206,13 +272,31 @@ func (t *Loki) initDistributor() (services.Service, error) {
 
 	t.Server.HTTP.Path("/api/prom/push").Methods("POST").Handler(pushHandler)
 	t.Server.HTTP.Path("/loki/api/v1/push").Methods("POST").Handler(pushHandler)
+	t.Server.HTTP.Path("/otlp/v1/logs").Methods("POST").Handler(middleware.Merge(
+		serverutil.RecoveryHTTPMiddleware,
+		t.HTTPAuthMiddleware,
+	).Wrap(http.HandlerFunc(t.distributor.OTLPHandler)))
+	if t.Cfg.Distributor.SplunkHEC.Enabled {
+		hecHandler := middleware.Merge(
+			serverutil.RecoveryHTTPMiddleware,
+			t.HTTPAuthMiddleware,
+		).Wrap(http.HandlerFunc(t.distributor.SplunkHECHandler))
+		t.Server.HTTP.Path("/services/collector").Methods("POST").Handler(hecHandler)
+		t.Server.HTTP.Path("/services/collector/event").Methods("POST").Handler(hecHandler)
+		t.Server.HTTP.Path("/services/collector/event/1.0").Methods("POST").Handler(hecHandler)
+		t.Server.HTTP.Path("/services/collector/health").Methods("GET").Handler(serverutil.RecoveryHTTPMiddleware.Wrap(http.HandlerFunc(t.distributor.SplunkHECHealthHandler)))
+	}
+	t.Server.HTTP.Path("/distributor/push_tracing").Methods("GET", "POST", "DELETE").Handler(serverutil.RecoveryHTTPMiddleware.Wrap(http.HandlerFunc(t.distributor.PushTracingHandler)))
 	return t.distributor, nil
 }
 
 func (t *Loki) initQuerier() (services.Service, error) {
+	t.setupTenantResolver()
 	if t.Cfg.Ingester.QueryStoreMaxLookBackPeriod != 0 {
 		t.Cfg.Querier.IngesterQueryStoreMaxLookback = t.Cfg.Ingester.QueryStoreMaxLookBackPeriod
 	}
+	t.Cfg.Querier.PeriodConfigs = t.Cfg.SchemaConfig.Configs
+	t.Cfg.Querier.UsageTracker.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.runtimeConfig)
 	// Querier worker's max concurrent requests must be the same as the querier setting
 	t.Cfg.Worker.MaxConcurrentRequests = t.Cfg.Querier.MaxConcurrent
 
@@ -221,6 +305,16 @@ func (t *Loki) initQuerier() (services.Service, error) {
 	if err != nil {
 		return nil, err
 	}
+	if t.archiveStore != nil {
+		t.Querier.SetArchiveStore(t.archiveStore)
+	}
+	if t.lookupTables != nil {
+		t.Querier.SetLookupTables(t.lookupTables)
+		t.Store.SetLookupTables(t.lookupTables)
+		if t.archiveStore != nil {
+			t.archiveStore.SetLookupTables(t.lookupTables)
+		}
+	}
 
 	querierWorkerServiceConfig := querier.WorkerServiceConfig{
 		AllEnabled:            t.Cfg.isModuleEnabled(All),
@@ -233,6 +327,13 @@ func (t *Loki) initQuerier() (services.Service, error) {
 		SchedulerRing:         scheduler.SafeReadRing(t.queryScheduler),
 	}
 
+	// The query frontend proxies the tail requests picked by this querier to its advertised address rather than to
+	// the one it sees the querier connect from, which differs behind a NAT or a proxy.
+	tailTargetAddr, err := ring.GetInstanceAddr(t.Cfg.Querier.TailTargetAddr, t.Cfg.Querier.TailTargetInterfaceNames, util_log.Logger)
+	if err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to find the tail target address, the tail requests can't be proxied to this querier", "err", err)
+	}
+
 	queryHandlers := map[string]http.Handler{
 		"/loki/api/v1/query_range":         http.HandlerFunc(t.Querier.RangeQueryHandler),
 		"/loki/api/v1/query":               http.HandlerFunc(t.Querier.InstantQueryHandler),
@@ -240,6 +341,10 @@ func (t *Loki) initQuerier() (services.Service, error) {
 		"/loki/api/v1/labels":              http.HandlerFunc(t.Querier.LabelHandler),
 		"/loki/api/v1/label/{name}/values": http.HandlerFunc(t.Querier.LabelHandler),
 		"/loki/api/v1/series":              http.HandlerFunc(t.Querier.SeriesHandler),
+		"/loki/api/v1/explain":             http.HandlerFunc(t.Querier.ExplainHandler),
+		"/loki/api/v1/index/stats":         http.HandlerFunc(t.Querier.IndexStatsHandler),
+		"/loki/api/v1/metadata":            http.HandlerFunc(t.Querier.MetadataHandler),
+		httpreq.TailTargetPath:             httpreq.TailTargetHandler(tailTargetAddr, t.Cfg.Server.HTTPListenPort),
 
 		"/api/prom/query":               http.HandlerFunc(t.Querier.LogQueryHandler),
 		"/api/prom/label":               http.HandlerFunc(t.Querier.LabelHandler),
@@ -261,9 +366,39 @@ func (t *Loki) initQuerier() (services.Service, error) {
 		"/api/prom/tail":    http.HandlerFunc(t.Querier.TailHandler),
 	}
 
-	return querier.InitWorkerService(
+	// The inflight queries endpoint is admin-only, it covers the queries of all the tenants.
+	t.Server.HTTP.Path("/querier/inflight").Methods("GET", "DELETE").HandlerFunc(t.Querier.InflightQueries().Handler)
+
+	svc, err := querier.InitWorkerService(
 		querierWorkerServiceConfig, queryHandlers, alwaysExternalHandlers, t.Server.HTTP, t.Server.HTTPServer.Handler, t.HTTPAuthMiddleware,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := t.Querier.UsageTracker()
+	if tracker == nil {
+		return svc, nil
+	}
+
+	// Run the usage tracker along with the querier worker, if any, so that it flushes the usage accounted by the querier.
+	return services.NewIdleService(func(ctx context.Context) error {
+		if err := services.StartAndAwaitRunning(ctx, tracker); err != nil {
+			return err
+		}
+		if svc == nil {
+			return nil
+		}
+		return services.StartAndAwaitRunning(ctx, svc)
+	}, func(_ error) error {
+		// Log but not return in case of error, so that the usage tracker is stopped too.
+		if svc != nil {
+			if err := services.StopAndAwaitTerminated(context.Background(), svc); err != nil {
+				level.Warn(util_log.Logger).Log("msg", "failed to stop querier worker service", "err", err)
+			}
+		}
+		return services.StopAndAwaitTerminated(context.Background(), tracker)
+	}), nil
 }
 
 func (t *Loki) initIngester() (_ services.Service, err error) {
@@ -271,12 +406,20 @@ func (t *Loki) initIngester() (_ services.Service, err error) {
 	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
 	t.Cfg.Ingester.LifecyclerConfig.ListenPort = t.Cfg.Server.GRPCListenPort
 
+	if t.zstdDictionaries != nil {
+		t.Cfg.Ingester.ZstdDictionaries = t.zstdDictionaries
+	}
+	if t.lookupTables != nil {
+		t.Cfg.Ingester.LookupTables = t.lookupTables
+	}
+
 	t.Ingester, err = ingester.New(t.Cfg.Ingester, t.Cfg.IngesterClient, t.Store, t.overrides, t.tenantConfigs, prometheus.DefaultRegisterer)
 	if err != nil {
 		return
 	}
 	logproto.RegisterPusherServer(t.Server.GRPC, t.Ingester)
 	logproto.RegisterQuerierServer(t.Server.GRPC, t.Ingester)
+	logproto.RegisterRecentDataServer(t.Server.GRPC, t.Ingester)
 	logproto.RegisterIngesterServer(t.Server.GRPC, t.Ingester)
 
 	httpMiddleware := middleware.Merge(
@@ -284,6 +427,7 @@ func (t *Loki) initIngester() (_ services.Service, err error) {
 	)
 	t.Server.HTTP.Path("/flush").Methods("GET", "POST").Handler(httpMiddleware.Wrap(http.HandlerFunc(t.Ingester.FlushHandler)))
 	t.Server.HTTP.Methods("POST").Path("/ingester/flush_shutdown").Handler(httpMiddleware.Wrap(http.HandlerFunc(t.Ingester.ShutdownHandler)))
+	t.Server.HTTP.Methods("GET", "POST", "DELETE").Path("/ingester/prepare_shutdown").Handler(httpMiddleware.Wrap(http.HandlerFunc(t.Ingester.PrepareShutdownHandler)))
 
 	return t.Ingester, nil
 }
@@ -360,13 +504,18 @@ func (t *Loki) initStore() (_ services.Service, err error) {
 			// and queried as part of live data until the cache TTL expires on the index entry.
 			t.Cfg.Ingester.RetainPeriod = t.Cfg.StorageConfig.IndexCacheValidity + 1*time.Minute
 			t.Cfg.StorageConfig.BoltDBShipperConfig.IngesterDBRetainPeriod = boltdbShipperQuerierIndexUpdateDelay(t.Cfg) + 2*time.Minute
-		case t.Cfg.isModuleEnabled(Querier), t.Cfg.isModuleEnabled(Ruler), t.Cfg.isModuleEnabled(Read):
+		case t.Cfg.isModuleEnabled(Querier), t.Cfg.isModuleEnabled(Ruler), t.Cfg.isModuleEnabled(Read), t.Cfg.isModuleEnabled(Checker):
 			// We do not want query to do any updates to index
 			t.Cfg.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
+			// In ring mode, the index queries of each tenant are sent to the index gateways owning it.
+			if t.indexGatewayRing != nil {
+				t.Cfg.StorageConfig.BoltDBShipperConfig.IndexGatewayClientConfig.Ring = t.indexGatewayRing
+			}
 		default:
 			t.Cfg.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadWrite
 			t.Cfg.StorageConfig.BoltDBShipperConfig.IngesterDBRetainPeriod = boltdbShipperQuerierIndexUpdateDelay(t.Cfg) + 2*time.Minute
 		}
+		t.Cfg.StorageConfig.BoltDBShipperConfig.FailOnCorruptFiles = t.Cfg.ChunkStoreConfig.IndexFallback
 	}
 
 	chunkStore, err := chunk_storage.NewStore(t.Cfg.StorageConfig.Config, t.Cfg.ChunkStoreConfig.StoreConfig, t.Cfg.SchemaConfig.SchemaConfig, t.overrides, prometheus.DefaultRegisterer, nil, util_log.Logger)
@@ -410,8 +559,20 @@ func (t *Loki) initStore() (_ services.Service, err error) {
 		return
 	}
 
+	// Only the queriers read the archive.
+	if t.Cfg.StorageConfig.Archive.Enabled && (t.Cfg.isModuleEnabled(Querier) || t.Cfg.isModuleEnabled(Read) || t.Cfg.isModuleEnabled(All)) {
+		t.archiveStore, err = loki_storage.NewArchiveStore(t.Cfg.StorageConfig, t.Cfg.ChunkStoreConfig.StoreConfig, t.Cfg.SchemaConfig, t.overrides)
+		if err != nil {
+			t.Store.Stop()
+			return
+		}
+	}
+
 	return services.NewIdleService(nil, func(_ error) error {
 		t.Store.Stop()
+		if t.archiveStore != nil {
+			t.archiveStore.Stop()
+		}
 		return nil
 	}), nil
 }
@@ -425,14 +586,11 @@ func (t *Loki) initIngesterQuerier() (_ services.Service, err error) {
 	return services.NewIdleService(nil, nil), nil
 }
 
-// Placeholder limits type to pass to cortex frontend
-type disabledShuffleShardingLimits struct{}
-
-func (disabledShuffleShardingLimits) MaxQueriersPerUser(userID string) int { return 0 }
-
 func (t *Loki) initQueryFrontendTripperware() (_ services.Service, err error) {
 	level.Debug(util_log.Logger).Log("msg", "initializing query frontend tripperware")
 
+	t.Cfg.QueryRange.MaxChunkAge = t.Cfg.Ingester.MaxChunkAge
+
 	tripperware, stopper, err := queryrange.NewTripperware(
 		t.Cfg.QueryRange,
 		util_log.Logger,
@@ -450,8 +608,20 @@ func (t *Loki) initQueryFrontendTripperware() (_ services.Service, err error) {
 	return services.NewIdleService(nil, nil), nil
 }
 
+// setupTenantResolver makes the tenant IDs separated by a pipe in the X-Scope-OrgID header resolve to several tenants
+// when multi-tenant queries are enabled.
+func (t *Loki) setupTenantResolver() {
+	if !t.Cfg.Querier.MultiTenantQueriesEnabled {
+		return
+	}
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	// Used by the results cache of the query frontend.
+	cortex_tenant.WithDefaultResolver(cortex_tenant.NewMultiResolver())
+}
+
 func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	level.Debug(util_log.Logger).Log("msg", "initializing query frontend", "config", fmt.Sprintf("%+v", t.Cfg.Frontend))
+	t.setupTenantResolver()
 
 	combinedCfg := frontend.CombinedFrontendConfig{
 		Handler:       t.Cfg.Frontend.Handler,
@@ -462,7 +632,7 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	roundTripper, frontendV1, frontendV2, err := frontend.InitFrontend(
 		combinedCfg,
 		scheduler.SafeReadRing(t.queryScheduler),
-		disabledShuffleShardingLimits{},
+		t.overrides,
 		t.Cfg.Server.GRPCListenPort,
 		util_log.Logger,
 		prometheus.DefaultRegisterer)
@@ -482,7 +652,16 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		level.Debug(util_log.Logger).Log("msg", "no query frontend configured")
 	}
 
-	roundTripper = t.QueryFrontEndTripperware(roundTripper)
+	// Tail requests are not queries and skip the tripperware, the queue is only used to pick a querier.
+	queueRoundTripper := roundTripper
+	inflight := logql.NewInflightQueries()
+	roundTripper = queryrange.NewInflightRoundTripper(inflight, t.QueryFrontEndTripperware(roundTripper))
+	if t.Cfg.Frontend.Mirror.DownstreamURL != "" {
+		roundTripper, err = frontend.NewMirrorRoundTripper(t.Cfg.Frontend.Mirror, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	frontendHandler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
 	if t.Cfg.Frontend.CompressResponses {
@@ -491,6 +670,8 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 
 	frontendHandler = middleware.Merge(
 		httpreq.ExtractQueryTagsMiddleware(),
+		httpreq.ExtractQueryPriorityMiddleware(),
+		httpreq.ExtractAcceptNDJSONMiddleware(),
 		serverutil.RecoveryHTTPMiddleware,
 		t.HTTPAuthMiddleware,
 		queryrange.StatsHTTPMiddleware,
@@ -519,15 +700,28 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		}
 
 		defaultHandler = httpMiddleware.Wrap(tp)
+	} else if t.frontend != nil && !t.isModuleActive(Querier) {
+		// Without a tail proxy URL, tail websockets are proxied to a querier picked through the queue.
+		httpMiddleware := middleware.Merge(
+			httpreq.ExtractQueryTagsMiddleware(),
+			serverutil.RecoveryHTTPMiddleware,
+			t.HTTPAuthMiddleware,
+		)
+		defaultHandler = httpMiddleware.Wrap(frontend.NewTailProxy(queueRoundTripper, t.Cfg.Frontend.TailProxyScheme, util_log.Logger))
 	} else {
 		defaultHandler = frontendHandler
 	}
+	// The inflight queries endpoint is admin-only, it covers the queries of all the tenants.
+	t.Server.HTTP.Path("/frontend/inflight").Methods("GET", "DELETE").HandlerFunc(inflight.Handler)
 	t.Server.HTTP.Path("/loki/api/v1/query_range").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/query").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/label").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/labels").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/label/{name}/values").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/loki/api/v1/series").Methods("GET", "POST").Handler(frontendHandler)
+	t.Server.HTTP.Path("/loki/api/v1/explain").Methods("GET", "POST").Handler(frontendHandler)
+	t.Server.HTTP.Path("/loki/api/v1/index/stats").Methods("GET", "POST").Handler(frontendHandler)
+	t.Server.HTTP.Path("/loki/api/v1/metadata").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/query").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/label").Methods("GET", "POST").Handler(frontendHandler)
 	t.Server.HTTP.Path("/api/prom/label/{name}/values").Methods("GET", "POST").Handler(frontendHandler)
@@ -608,9 +802,21 @@ func (t *Loki) initRuler() (_ services.Service, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if t.lookupTables != nil {
+		q.SetLookupTables(t.lookupTables)
+		t.Store.SetLookupTables(t.lookupTables)
+	}
 
 	engine := logql.NewEngine(t.Cfg.Querier.Engine, q, t.overrides)
 
+	var alertStateClient chunk.ObjectClient
+	if t.Cfg.Ruler.AlertState.Enabled {
+		alertStateClient, err = storage.NewObjectClient(t.Cfg.Ruler.AlertState.SharedStoreType, t.Cfg.StorageConfig.Config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	t.ruler, err = ruler.NewRuler(
 		t.Cfg.Ruler,
 		engine,
@@ -618,6 +824,7 @@ func (t *Loki) initRuler() (_ services.Service, err error) {
 		util_log.Logger,
 		t.RulerStorage,
 		t.overrides,
+		alertStateClient,
 	)
 
 	if err != nil {
@@ -650,6 +857,7 @@ func (t *Loki) initRuler() (_ services.Service, err error) {
 		t.Server.HTTP.Path("/loki/api/v1/rules/{namespace}").Methods("POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.rulerAPI.CreateRuleGroup)))
 		t.Server.HTTP.Path("/loki/api/v1/rules/{namespace}").Methods("DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.rulerAPI.DeleteNamespace)))
 		t.Server.HTTP.Path("/loki/api/v1/rules/{namespace}/{groupName}").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.rulerAPI.GetRuleGroup)))
+		t.Server.HTTP.Path("/loki/api/v1/rules/{namespace}/{groupName}").Methods("PUT").Handler(t.HTTPAuthMiddleware.Wrap(ruler.SetRuleGroupHandler(t.rulerAPI.CreateRuleGroup)))
 		t.Server.HTTP.Path("/loki/api/v1/rules/{namespace}/{groupName}").Methods("DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.rulerAPI.DeleteRuleGroup)))
 	}
 
@@ -690,24 +898,67 @@ func (t *Loki) initCompactor() (services.Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, t.Cfg.StorageConfig.Config, t.Cfg.SchemaConfig, t.overrides, prometheus.DefaultRegisterer)
+	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, t.Cfg.StorageConfig.Config, t.Cfg.StorageConfig.BoltDBShipperConfig.SharedStoreConfig, t.Cfg.SchemaConfig, t.overrides, prometheus.DefaultRegisterer)
 	if err != nil {
 		return nil, err
 	}
 
 	t.Server.HTTP.Path("/compactor/ring").Methods("GET", "POST").Handler(t.compactor)
+	t.Server.HTTP.Path("/compactor/index/verify").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.VerifyIndexHandler)))
+	t.Server.HTTP.Path("/loki/api/admin/chunks").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.ListChunksHandler)))
+	if t.Cfg.CompactorConfig.VolumeAggregationEnabled {
+		t.Server.HTTP.Path("/loki/api/v1/index/volume").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.VolumeHandler)))
+	}
+	if t.Cfg.CompactorConfig.UsageStatsEnabled {
+		reporter, err := t.newUsageReporter()
+		if err != nil {
+			return nil, err
+		}
+		t.compactor.SetUsageReporter(reporter)
+		t.Server.HTTP.Path("/loki/api/v1/usage").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(reporter.Handler)))
+	}
 	if t.Cfg.CompactorConfig.RetentionEnabled {
 		t.Server.HTTP.Path("/loki/api/admin/delete").Methods("PUT", "POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.DeleteRequestsHandler.AddDeleteRequestHandler)))
 		t.Server.HTTP.Path("/loki/api/admin/delete").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.DeleteRequestsHandler.GetAllDeleteRequestsHandler)))
 		t.Server.HTTP.Path("/loki/api/admin/cancel_delete_request").Methods("PUT", "POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.DeleteRequestsHandler.CancelDeleteRequestHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/delete_tenant").Methods("PUT", "POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.TenantDeletionHandler.AddTenantDeletionHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/delete_tenant").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.TenantDeletionHandler.GetTenantDeletionsHandler)))
+		t.Server.HTTP.Path("/loki/api/admin/delete_tenant").Methods("DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.TenantDeletionHandler.CancelTenantDeletionHandler)))
 	}
 
 	return t.compactor, nil
 }
 
+// newUsageReporter creates a usage reporter reading the KV stores of the enabled usage trackers, and the volume
+// aggregates of the compactor when enabled.
+func (t *Loki) newUsageReporter() (*usage.Reporter, error) {
+	var ingestion, queries *usage.Reader
+	var err error
+	if t.Cfg.Distributor.UsageTracker.Enabled {
+		t.Cfg.Distributor.UsageTracker.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.runtimeConfig)
+		ingestion, err = usage.NewReader("distributor", t.Cfg.Distributor.UsageTracker.KVStore, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t.Cfg.Querier.UsageTracker.Enabled {
+		t.Cfg.Querier.UsageTracker.KVStore.Multi.ConfigProvider = multiClientRuntimeConfigChannel(t.runtimeConfig)
+		queries, err = usage.NewReader("querier", t.Cfg.Querier.UsageTracker.KVStore, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var stored usage.Storage
+	if t.Cfg.CompactorConfig.VolumeAggregationEnabled {
+		stored = t.compactor
+	}
+	return usage.NewReporter(ingestion, queries, stored), nil
+}
+
 func (t *Loki) initIndexGateway() (services.Service, error) {
 	t.Cfg.StorageConfig.BoltDBShipperConfig.Mode = shipper.ModeReadOnly
-	objectClient, err := storage.NewObjectClient(t.Cfg.StorageConfig.BoltDBShipperConfig.SharedStoreType, t.Cfg.StorageConfig.Config)
+	objectClient, err := storage.NewObjectClient(t.Cfg.StorageConfig.BoltDBShipperConfig.SharedStoreType, t.Cfg.StorageConfig.BoltDBShipperConfig.SharedStoreConfig.Apply(t.Cfg.StorageConfig.Config))
 	if err != nil {
 		return nil, err
 	}
@@ -717,11 +968,33 @@ func (t *Loki) initIndexGateway() (services.Service, error) {
 		return nil, err
 	}
 
-	gateway := indexgateway.NewIndexGateway(shipperIndexClient.(*shipper.Shipper))
+	gateway, err := indexgateway.NewIndexGateway(t.Cfg.IndexGateway, shipperIndexClient, t.indexGatewayRing, prometheus.DefaultRegisterer, util_log.Logger)
+	if err != nil {
+		return nil, err
+	}
 	indexgatewaypb.RegisterIndexGatewayServer(t.Server.GRPC, gateway)
 	return gateway, nil
 }
 
+func (t *Loki) initIndexGatewayRing() (_ services.Service, err error) {
+	// The ring is only needed by the index gateways and the components querying them.
+	if t.Cfg.IndexGateway.Mode != indexgateway.ModeRing ||
+		!(t.Cfg.isModuleEnabled(IndexGateway) || t.Cfg.isModuleEnabled(Querier) || t.Cfg.isModuleEnabled(Ruler) || t.Cfg.isModuleEnabled(Read) || t.Cfg.isModuleEnabled(Checker)) {
+		return nil, nil
+	}
+
+	// Set some config sections from other config sections in the config struct
+	t.Cfg.IndexGateway.Ring.ListenPort = t.Cfg.Server.GRPCListenPort
+	t.Cfg.IndexGateway.Ring.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
+
+	t.indexGatewayRing, err = indexgateway.NewRing(t.Cfg.IndexGateway, prometheus.DefaultRegisterer, util_log.Logger)
+	if err != nil {
+		return nil, err
+	}
+	t.Server.HTTP.Path("/indexgateway/ring").Methods("GET", "POST").Handler(t.indexGatewayRing)
+	return t.indexGatewayRing, nil
+}
+
 func (t *Loki) initQueryScheduler() (services.Service, error) {
 	// Set some config sections from other config sections in the config struct
 	t.Cfg.QueryScheduler.SchedulerRing.ListenPort = t.Cfg.Server.GRPCListenPort
@@ -739,6 +1012,14 @@ func (t *Loki) initQueryScheduler() (services.Service, error) {
 	return s, nil
 }
 
+func (t *Loki) initChecker() (services.Service, error) {
+	c, err := checker.New(t.Cfg.Checker, t.Store, util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 func calculateMaxLookBack(pc chunk.PeriodConfig, maxLookBackConfig, minDuration time.Duration) (time.Duration, error) {
 	if pc.ObjectType != shipper.FilesystemObjectStoreType && maxLookBackConfig.Nanoseconds() != 0 {
 		return 0, errors.New("it is an error to specify a non zero `query_store_max_look_back_period` value when using any object store other than `filesystem`")
@@ -2,7 +2,9 @@ package lokifrontend
 
 import (
 	"flag"
+	"fmt"
 
+	"github.com/grafana/loki/pkg/lokifrontend/frontend"
 	"github.com/grafana/loki/pkg/lokifrontend/frontend/transport"
 	v1 "github.com/grafana/loki/pkg/lokifrontend/frontend/v1"
 	v2 "github.com/grafana/loki/pkg/lokifrontend/frontend/v2"
@@ -16,7 +18,10 @@ type Config struct {
 	CompressResponses bool   `yaml:"compress_responses"`
 	DownstreamURL     string `yaml:"downstream_url"`
 
-	TailProxyURL string `yaml:"tail_proxy_url"`
+	TailProxyURL    string `yaml:"tail_proxy_url"`
+	TailProxyScheme string `yaml:"tail_proxy_scheme"`
+
+	Mirror frontend.MirrorConfig `yaml:"mirror"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -28,5 +33,15 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.CompressResponses, "querier.compress-http-responses", false, "Compress HTTP responses.")
 	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Prometheus.")
 
-	f.StringVar(&cfg.TailProxyURL, "frontend.tail-proxy-url", "", "URL of querier for tail proxy.")
+	f.StringVar(&cfg.TailProxyURL, "frontend.tail-proxy-url", "", "URL of querier for tail proxy. When empty, tail requests are proxied to a querier picked through the queue of the query frontend or query scheduler.")
+	f.StringVar(&cfg.TailProxyScheme, "frontend.tail-proxy-scheme", "http", "Scheme, http or https, of the queriers the tail requests are proxied to when no tail proxy URL is set.")
+	cfg.Mirror.RegisterFlags(f)
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if cfg.TailProxyScheme != "http" && cfg.TailProxyScheme != "https" {
+		return fmt.Errorf("invalid tail proxy scheme %q, must be http or https", cfg.TailProxyScheme)
+	}
+	return cfg.Mirror.Validate()
 }
@@ -0,0 +1,289 @@
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+// Results of the comparison of a mirrored request.
+const (
+	mirrorResultMatch    = "match"
+	mirrorResultMismatch = "mismatch"
+	mirrorResultError    = "error"
+	mirrorResultSkipped  = "skipped"
+)
+
+// MirrorConfig configures the mirroring of a sample of the queries to a second downstream, whose results are compared
+// to the results of the queries.
+type MirrorConfig struct {
+	DownstreamURL string        `yaml:"downstream_url"`
+	SampleRatio   float64       `yaml:"sample_ratio"`
+	Timeout       time.Duration `yaml:"timeout"`
+	MaxConcurrent int           `yaml:"max_concurrent"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *MirrorConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.DownstreamURL, "frontend.mirror.downstream-url", "", "URL of the Loki to which a sample of the queries is mirrored, and whose results are compared to the results of the queries. When empty, queries are not mirrored.")
+	f.Float64Var(&cfg.SampleRatio, "frontend.mirror.sample-ratio", 0.1, "Ratio of the queries mirrored, between 0 and 1.")
+	f.DurationVar(&cfg.Timeout, "frontend.mirror.timeout", 2*time.Minute, "Timeout of the mirrored queries.")
+	f.IntVar(&cfg.MaxConcurrent, "frontend.mirror.max-concurrent", 10, "Maximum number of mirrored queries in flight. Queries sampled beyond this limit are not mirrored.")
+}
+
+// Validate validates the config.
+func (cfg *MirrorConfig) Validate() error {
+	if cfg.DownstreamURL == "" {
+		return nil
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("mirror sample ratio must be between 0 and 1")
+	}
+	if cfg.MaxConcurrent <= 0 {
+		return fmt.Errorf("mirror max concurrent must be > 0")
+	}
+	return nil
+}
+
+type mirrorMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newMirrorMetrics(reg prometheus.Registerer) *mirrorMetrics {
+	return &mirrorMetrics{
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "frontend_mirrored_requests_total",
+			Help:      "Total number of requests sampled to be mirrored, by result of the comparison of their responses.",
+		}, []string{"result"}),
+		duration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "loki",
+			Name:      "frontend_mirrored_request_duration_seconds",
+			Help:      "Time taken by the mirrored requests, by downstream.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"downstream"}),
+	}
+}
+
+// mirroredResponse is the response of a request to a downstream.
+type mirroredResponse struct {
+	statusCode int
+	body       []byte
+	err        error
+}
+
+// mirrorRoundTripper sends a sample of the requests to a mirror downstream too, and records the mismatches of the
+// responses of both downstreams. The responses of the mirror are discarded.
+type mirrorRoundTripper struct {
+	cfg     MirrorConfig
+	next    http.RoundTripper
+	mirror  http.RoundTripper
+	logger  log.Logger
+	metrics *mirrorMetrics
+
+	// inflight limits the number of mirrored requests in flight.
+	inflight chan struct{}
+}
+
+// NewMirrorRoundTripper returns a RoundTripper sending the requests to next, and mirroring a sample of them to the
+// downstream of the config.
+func NewMirrorRoundTripper(cfg MirrorConfig, next http.RoundTripper, logger log.Logger, reg prometheus.Registerer) (http.RoundTripper, error) {
+	mirror, err := NewDownstreamRoundTripper(cfg.DownstreamURL, http.DefaultTransport)
+	if err != nil {
+		return nil, err
+	}
+	return &mirrorRoundTripper{
+		cfg:      cfg,
+		next:     next,
+		mirror:   mirror,
+		logger:   logger,
+		metrics:  newMirrorMetrics(reg),
+		inflight: make(chan struct{}, cfg.MaxConcurrent),
+	}, nil
+}
+
+func (m *mirrorRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	if rand.Float64() >= m.cfg.SampleRatio {
+		return m.next.RoundTrip(r)
+	}
+	select {
+	case m.inflight <- struct{}{}:
+	default:
+		m.metrics.requests.WithLabelValues(mirrorResultSkipped).Inc()
+		return m.next.RoundTrip(r)
+	}
+
+	mirrorReq, err := m.mirrorRequest(r)
+	if err != nil {
+		<-m.inflight
+		level.Warn(m.logger).Log("msg", "failed to mirror request", "err", err)
+		m.metrics.requests.WithLabelValues(mirrorResultSkipped).Inc()
+		return m.next.RoundTrip(r)
+	}
+
+	// The request is mirrored while it is processed, to compare results computed from the same data as much as possible.
+	primary := make(chan mirroredResponse, 1)
+	go m.compare(mirrorReq, primary)
+
+	start := time.Now()
+	resp, err := m.next.RoundTrip(r)
+	if err != nil {
+		primary <- mirroredResponse{err: err}
+		return nil, err
+	}
+	m.metrics.duration.WithLabelValues("primary").Observe(time.Since(start).Seconds())
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		primary <- mirroredResponse{err: err}
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		primary <- mirroredResponse{err: fmt.Errorf("unsupported content type %q", resp.Header.Get("Content-Type"))}
+		return resp, nil
+	}
+	primary <- mirroredResponse{statusCode: resp.StatusCode, body: body}
+	return resp, nil
+}
+
+// mirrorRequest returns a copy of the request, sent to the mirror with its own timeout since the request can complete
+// before its mirror.
+func (m *mirrorRoundTripper) mirrorRequest(r *http.Request) (*http.Request, error) {
+	orgID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	mirrorReq := r.Clone(user.InjectOrgID(context.Background(), orgID))
+	mirrorReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	mirrorReq.RequestURI = ""
+	if err := user.InjectOrgIDIntoHTTPRequest(mirrorReq.Context(), mirrorReq); err != nil {
+		return nil, err
+	}
+	return mirrorReq, nil
+}
+
+// compare sends the request to the mirror and compares its response to the response of the request.
+func (m *mirrorRoundTripper) compare(r *http.Request, primary <-chan mirroredResponse) {
+	defer func() { <-m.inflight }()
+
+	// The URL of the request is rewritten when it is sent.
+	logger := log.With(m.logger, "path", r.URL.Path, "query", r.URL.Query().Get("query"), "org_id", r.Header.Get(user.OrgIDHeaderName))
+	ctx, cancel := context.WithTimeout(r.Context(), m.cfg.Timeout)
+	defer cancel()
+	mirrored := m.do(r.WithContext(ctx))
+	expected := <-primary
+
+	switch {
+	case expected.err != nil:
+		m.metrics.requests.WithLabelValues(mirrorResultSkipped).Inc()
+	case mirrored.err != nil:
+		level.Warn(logger).Log("msg", "mirrored request failed", "err", mirrored.err)
+		m.metrics.requests.WithLabelValues(mirrorResultError).Inc()
+	case !responsesEqual(expected, mirrored):
+		level.Warn(logger).Log("msg", "mirrored request result mismatch", "status_code", expected.statusCode, "mirror_status_code", mirrored.statusCode)
+		m.metrics.requests.WithLabelValues(mirrorResultMismatch).Inc()
+	default:
+		m.metrics.requests.WithLabelValues(mirrorResultMatch).Inc()
+	}
+}
+
+func (m *mirrorRoundTripper) do(r *http.Request) mirroredResponse {
+	start := time.Now()
+	resp, err := m.mirror.RoundTrip(r)
+	if err != nil {
+		return mirroredResponse{err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return mirroredResponse{err: err}
+	}
+	m.metrics.duration.WithLabelValues("mirror").Observe(time.Since(start).Seconds())
+	return mirroredResponse{statusCode: resp.StatusCode, body: body}
+}
+
+// responsesEqual returns true if the responses have the same status code and the same results, ignoring the
+// statistics of the queries and the order of the streams and series.
+func responsesEqual(a, b mirroredResponse) bool {
+	if a.statusCode != b.statusCode {
+		return false
+	}
+	normalizedA, errA := normalizeResponse(a.body)
+	normalizedB, errB := normalizeResponse(b.body)
+	if errA != nil || errB != nil {
+		return bytes.Equal(a.body, b.body)
+	}
+	return reflect.DeepEqual(normalizedA, normalizedB)
+}
+
+func normalizeResponse(body []byte) (interface{}, error) {
+	var resp map[string]interface{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+
+	switch data := resp["data"].(type) {
+	case map[string]interface{}:
+		delete(data, "stats")
+		if result, ok := data["result"].([]interface{}); ok {
+			sortJSONValues(result)
+		}
+	case []interface{}:
+		sortJSONValues(data)
+	}
+	return resp, nil
+}
+
+// sortJSONValues sorts values by their JSON encoding.
+func sortJSONValues(values []interface{}) {
+	keys := make([]string, len(values))
+	for i, v := range values {
+		b, _ := json.Marshal(v)
+		keys[i] = string(b)
+	}
+	sort.Sort(jsonValues{values: values, keys: keys})
+}
+
+type jsonValues struct {
+	values []interface{}
+	keys   []string
+}
+
+func (v jsonValues) Len() int           { return len(v.values) }
+func (v jsonValues) Less(i, j int) bool { return v.keys[i] < v.keys[j] }
+func (v jsonValues) Swap(i, j int) {
+	v.values[i], v.values[j] = v.values[j], v.values[i]
+	v.keys[i], v.keys[j] = v.keys[j], v.keys[i]
+}
@@ -0,0 +1,85 @@
+package frontend
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json; charset=UTF-8"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func Test_MirrorRoundTripper(t *testing.T) {
+	const primaryBody = `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"foo"},"values":[["1","line"]]},{"stream":{"app":"bar"},"values":[["2","line"]]}],"stats":{"summary":{"execTime":1.5}}}}`
+
+	mirrorBody := make(chan string, 1)
+	mirrorPaths := make(chan string, 1)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorPaths <- r.URL.Path + " " + r.Header.Get(user.OrgIDHeaderName)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(<-mirrorBody))
+	}))
+	defer mirror.Close()
+
+	reg := prometheus.NewRegistry()
+	rt, err := NewMirrorRoundTripper(MirrorConfig{DownstreamURL: mirror.URL, SampleRatio: 1, Timeout: time.Minute, MaxConcurrent: 1}, roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(primaryBody), nil
+	}), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	m := rt.(*mirrorRoundTripper)
+
+	for _, tc := range []struct {
+		name       string
+		mirrorBody string
+		result     string
+	}{
+		{
+			name:       "same results in a different order and with different stats",
+			mirrorBody: `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"bar"},"values":[["2","line"]]},{"stream":{"app":"foo"},"values":[["1","line"]]}],"stats":{"summary":{"execTime":3}}}}`,
+			result:     mirrorResultMatch,
+		},
+		{
+			name:       "different results",
+			mirrorBody: `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"foo"},"values":[["1","line"]]}],"stats":{}}}`,
+			result:     mirrorResultMismatch,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mirrorBody <- tc.mirrorBody
+			req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range?query={app=~\".%2B\"}", nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "fake"))
+
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, primaryBody, string(body))
+			require.Equal(t, "/loki/api/v1/query_range fake", <-mirrorPaths)
+
+			// The comparison completes once the mirrored request is released.
+			m.inflight <- struct{}{}
+			<-m.inflight
+			require.Equal(t, float64(1), testutil.ToFloat64(m.metrics.requests.WithLabelValues(tc.result)))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package frontend
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/httpgrpc/server"
+
+	"github.com/grafana/loki/pkg/util/httpreq"
+)
+
+// tailProxy proxies tail websockets to the querier picked by round tripping a tail target request through the
+// queue of the frontend, so that tail requests go through the same path as every other query.
+type tailProxy struct {
+	roundTripper http.RoundTripper
+	scheme       string
+	logger       log.Logger
+}
+
+// NewTailProxy returns a handler proxying tail requests to a querier picked through roundTripper, which must
+// be the round tripper returned by InitFrontend for a v1 or v2 frontend. The querier is reached with scheme, http or
+// https.
+func NewTailProxy(roundTripper http.RoundTripper, scheme string, logger log.Logger) http.Handler {
+	return &tailProxy{roundTripper: roundTripper, scheme: scheme, logger: logger}
+}
+
+func (p *tailProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target, err := p.target(r)
+	if err != nil {
+		level.Error(p.logger).Log("msg", "failed to pick a querier for tail request", "err", err)
+		server.WriteError(w, err)
+		return
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// target round trips a tail target request and returns the URL of the querier which processed it.
+func (p *tailProxy) target(r *http.Request) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, httpreq.TailTargetPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = httpreq.TailTargetPath
+	req.Header = r.Header.Clone()
+
+	resp, err := p.roundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, httpgrpc.Errorf(resp.StatusCode, "tail target request failed: %s", body)
+	}
+
+	host, port := resp.Header.Get(httpreq.TailTargetHostHeader), resp.Header.Get(httpreq.TailTargetPortHeader)
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("querier did not return its tail target address")
+	}
+	return &url.URL{Scheme: p.scheme, Host: net.JoinHostPort(host, port)}, nil
+}
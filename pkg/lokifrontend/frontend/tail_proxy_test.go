@@ -0,0 +1,37 @@
+package frontend
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/util/httpreq"
+)
+
+func TestTailProxy_Target(t *testing.T) {
+	roundTripper := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		require.Equal(t, httpreq.TailTargetPath, r.URL.Path)
+		return &http.Response{
+			StatusCode: http.StatusNoContent,
+			Header: http.Header{
+				httpreq.TailTargetHostHeader: []string{"10.0.0.1"},
+				httpreq.TailTargetPortHeader: []string{"3100"},
+			},
+			Body: ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+
+	for _, scheme := range []string{"http", "https"} {
+		t.Run(scheme, func(t *testing.T) {
+			p := NewTailProxy(roundTripper, scheme, log.NewNopLogger()).(*tailProxy)
+			target, err := p.target(httptest.NewRequest(http.MethodGet, "/loki/api/v1/tail", nil))
+			require.NoError(t, err)
+			require.Equal(t, scheme+"://10.0.0.1:3100", target.String())
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/util/httpreq"
 
 	serverutil "github.com/grafana/loki/pkg/util/server"
 )
@@ -126,6 +127,9 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		writeError(w, err)
 		return
 	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
 
 	hs := w.Header()
 	for h, vs := range resp.Header {
@@ -137,8 +141,12 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.WriteHeader(resp.StatusCode)
+	var dst io.Writer = w
+	if f, ok := w.(http.Flusher); ok && resp.Header.Get("Content-Type") == httpreq.NDJSONContentType {
+		dst = flushWriter{Writer: w, flusher: f}
+	}
 	// we don't check for copy error as there is no much we can do at this point
-	_, _ = io.Copy(w, resp.Body)
+	_, _ = io.Copy(dst, resp.Body)
 
 	// Check whether we should parse the query string.
 	shouldReportSlowQuery := f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan
@@ -154,6 +162,19 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// flushWriter flushes the response after each write, so that the batches of the query responses streamed as newline
+// delimited JSON reach the client as soon as they are encoded.
+type flushWriter struct {
+	io.Writer
+	flusher http.Flusher
+}
+
+func (w flushWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.flusher.Flush()
+	return n, err
+}
+
 // reportSlowQuery reports slow queries.
 func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, queryResponseTime time.Duration) {
 	logMessage := append([]interface{}{
@@ -0,0 +1,64 @@
+package transport
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/util/httpreq"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// flushRecorder records the body written before each flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []string
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed = append(r.flushed, r.Body.String())
+}
+
+func TestHandler_FlushesNDJSON(t *testing.T) {
+	for _, tc := range []struct {
+		contentType string
+		expected    []string
+	}{
+		{contentType: httpreq.NDJSONContentType, expected: []string{"{\"a\":1}\n", "{\"a\":1}\n{\"a\":2}\n"}},
+		{contentType: "application/json", expected: nil},
+	} {
+		t.Run(tc.contentType, func(t *testing.T) {
+			h := NewHandler(HandlerConfig{}, roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				r, w := io.Pipe()
+				go func() {
+					_, _ = w.Write([]byte("{\"a\":1}\n"))
+					_, _ = w.Write([]byte("{\"a\":2}\n"))
+					_ = w.Close()
+				}()
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{tc.contentType}},
+					Body:       r,
+				}, nil
+			}), log.NewNopLogger(), nil)
+
+			rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range", nil))
+
+			body, err := ioutil.ReadAll(rec.Body)
+			require.NoError(t, err)
+			require.Equal(t, "{\"a\":1}\n{\"a\":2}\n", string(body))
+			require.Equal(t, tc.expected, rec.flushed)
+		})
+	}
+}
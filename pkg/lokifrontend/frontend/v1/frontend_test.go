@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cortexproject/cortex/pkg/scheduler/queue"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+)
+
+type mockLimits struct {
+	queriers int
+}
+
+func (l mockLimits) MaxQueriersPerUser(_ string) int {
+	return l.queriers
+}
+
+func TestFrontend_MaxQueriersPerUser(t *testing.T) {
+	for _, maxQueriers := range []int{1, 2} {
+		t.Run(fmt.Sprintf("max queriers %d", maxQueriers), func(t *testing.T) {
+			f, err := New(Config{MaxOutstandingPerTenant: 100}, mockLimits{queriers: maxQueriers}, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), f.requestQueue))
+
+			var (
+				mtx      sync.Mutex
+				queriers = map[string]int{}
+				wg       sync.WaitGroup
+			)
+			for i := 0; i < 5; i++ {
+				querierID := fmt.Sprintf("querier-%d", i)
+				f.requestQueue.RegisterQuerierConnection(querierID)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					last := queue.FirstUser()
+					for {
+						var err error
+						if _, last, err = f.requestQueue.GetNextRequestForQuerier(context.Background(), last, querierID); err != nil {
+							return
+						}
+						mtx.Lock()
+						queriers[querierID]++
+						mtx.Unlock()
+					}
+				}()
+			}
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			for i := 0; i < 50; i++ {
+				require.NoError(t, f.queueRequest(ctx, &request{request: &httpgrpc.HTTPRequest{}}))
+			}
+			// The queue stops once all the requests are dispatched, which releases the queriers.
+			require.NoError(t, services.StopAndAwaitTerminated(context.Background(), f.requestQueue))
+			wg.Wait()
+
+			// The requests of the tenant are only dispatched to the queriers of its shard.
+			require.LessOrEqual(t, len(queriers), maxQueriers)
+			var requests int
+			for _, n := range queriers {
+				requests += n
+			}
+			require.Equal(t, 50, requests)
+		})
+	}
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/flagext"
-	"github.com/grafana/dskit/grpcclient"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
 	"github.com/opentracing/opentracing-go"
@@ -25,6 +24,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/util/grpcclient"
 	lokigrpc "github.com/grafana/loki/pkg/util/httpgrpc"
 )
 
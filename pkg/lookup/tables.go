@@ -0,0 +1,272 @@
+// Package lookup loads the lookup tables used by the LogQL lookup stage from the object store and refreshes them
+// periodically.
+package lookup
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	logql_log "github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// Formats of the objects of the lookup tables.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Config configures the lookup tables.
+type Config struct {
+	Store         string        `yaml:"store"`
+	RefreshPeriod time.Duration `yaml:"refresh_period"`
+	Tables        []TableConfig `yaml:"tables"`
+}
+
+// TableConfig configures a lookup table.
+type TableConfig struct {
+	Name string `yaml:"name"`
+	// Key is the key of the object of the table in the store.
+	Key string `yaml:"key"`
+	// Format is the format of the object, defaulting to the extension of its key.
+	Format string `yaml:"format"`
+}
+
+// format returns the format of the object of the table.
+func (cfg TableConfig) format() string {
+	if cfg.Format != "" {
+		return cfg.Format
+	}
+	if ext := path.Ext(cfg.Key); ext != "" {
+		return ext[1:]
+	}
+	return ""
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Store, "lookup-tables.store", "", "Object store in which the objects of the lookup tables are read. Supported types: gcs, s3, azure, swift, filesystem.")
+	f.DurationVar(&cfg.RefreshPeriod, "lookup-tables.refresh-period", 5*time.Minute, "How often the lookup tables are reloaded from the object store.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if len(cfg.Tables) == 0 {
+		return nil
+	}
+	if cfg.Store == "" {
+		return fmt.Errorf("lookup tables store must be set when lookup tables are configured")
+	}
+	if cfg.RefreshPeriod <= 0 {
+		return fmt.Errorf("lookup tables refresh period must be > 0")
+	}
+	names := map[string]struct{}{}
+	for _, t := range cfg.Tables {
+		if !model.LabelName(t.Name).IsValid() {
+			return fmt.Errorf("invalid lookup table name %q", t.Name)
+		}
+		if _, ok := names[t.Name]; ok {
+			return fmt.Errorf("duplicate lookup table %s", t.Name)
+		}
+		names[t.Name] = struct{}{}
+		if t.Key == "" {
+			return fmt.Errorf("lookup table %s key must not be empty", t.Name)
+		}
+		if f := t.format(); f != FormatCSV && f != FormatJSON {
+			return fmt.Errorf("lookup table %s has an unsupported format %q, supported formats: %s, %s", t.Name, f, FormatCSV, FormatJSON)
+		}
+	}
+	return nil
+}
+
+// Table is a lookup table, whose rows are replaced each time it is reloaded.
+type Table struct {
+	mtx  sync.RWMutex
+	rows map[string]labels.Labels
+}
+
+// Lookup returns the labels of the row whose key is key.
+func (t *Table) Lookup(key string) (labels.Labels, bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	row, ok := t.rows[key]
+	return row, ok
+}
+
+func (t *Table) set(rows map[string]labels.Labels) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.rows = rows
+}
+
+// Tables loads the configured lookup tables from the object store. It implements log.LookupTables.
+type Tables struct {
+	services.Service
+
+	cfg          Config
+	objectClient chunk.ObjectClient
+	logger       log.Logger
+	tables       map[string]*Table
+
+	loads *prometheus.CounterVec
+	rows  *prometheus.GaugeVec
+}
+
+// NewTables creates the lookup tables, which are empty until the service is started.
+func NewTables(cfg Config, objectClient chunk.ObjectClient, logger log.Logger, registerer prometheus.Registerer) *Tables {
+	t := &Tables{
+		cfg:          cfg,
+		objectClient: objectClient,
+		logger:       logger,
+		tables:       make(map[string]*Table, len(cfg.Tables)),
+		loads: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "lookup_table_loads_total",
+			Help:      "Total number of loads of the lookup tables, by table and status.",
+		}, []string{"table", "status"}),
+		rows: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki",
+			Name:      "lookup_table_rows",
+			Help:      "Number of rows of the last loaded lookup tables.",
+		}, []string{"table"}),
+	}
+	for _, tc := range cfg.Tables {
+		t.tables[tc.Name] = &Table{rows: map[string]labels.Labels{}}
+	}
+	t.Service = services.NewTimerService(cfg.RefreshPeriod, t.starting, t.refresh, nil)
+	return t
+}
+
+// Table returns the table named name.
+func (t *Tables) Table(name string) (logql_log.LookupTable, bool) {
+	table, ok := t.tables[name]
+	return table, ok
+}
+
+// starting loads all the tables, failing when a table exists but cannot be loaded. A missing table stays empty until
+// it is found by a refresh.
+func (t *Tables) starting(ctx context.Context) error {
+	for _, tc := range t.cfg.Tables {
+		if err := t.load(ctx, tc); err != nil {
+			if t.objectClient.IsObjectNotFoundErr(err) {
+				level.Warn(t.logger).Log("msg", "lookup table not found", "table", tc.Name, "key", tc.Key)
+				continue
+			}
+			return fmt.Errorf("failed to load lookup table %s: %w", tc.Name, err)
+		}
+	}
+	return nil
+}
+
+// refresh reloads all the tables, keeping the last loaded rows of a table failing to load.
+func (t *Tables) refresh(ctx context.Context) error {
+	for _, tc := range t.cfg.Tables {
+		if err := t.load(ctx, tc); err != nil {
+			level.Warn(t.logger).Log("msg", "failed to reload lookup table", "table", tc.Name, "key", tc.Key, "err", err)
+		}
+	}
+	return nil
+}
+
+func (t *Tables) load(ctx context.Context, tc TableConfig) error {
+	rows, err := t.read(ctx, tc)
+	if err != nil {
+		t.loads.WithLabelValues(tc.Name, "failure").Inc()
+		return err
+	}
+	t.loads.WithLabelValues(tc.Name, "success").Inc()
+	t.rows.WithLabelValues(tc.Name).Set(float64(len(rows)))
+	t.tables[tc.Name].set(rows)
+	return nil
+}
+
+func (t *Tables) read(ctx context.Context, tc TableConfig) (map[string]labels.Labels, error) {
+	reader, err := t.objectClient.GetObject(ctx, tc.Key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if tc.format() == FormatJSON {
+		return parseJSON(reader)
+	}
+	return parseCSV(reader)
+}
+
+// parseCSV parses a CSV table whose first line is the header. The first column is the key of the rows, and the
+// other columns are the labels added to the lines matching it. Empty values are skipped.
+func parseCSV(r io.Reader) (map[string]labels.Labels, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return map[string]labels.Labels{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) < 2 {
+		return nil, fmt.Errorf("the header must have a key column and at least one label column")
+	}
+	for _, name := range header[1:] {
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid label name %q in the header", name)
+		}
+	}
+
+	rows := map[string]labels.Labels{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(labels.Labels, 0, len(record)-1)
+		for i, value := range record[1:] {
+			if value != "" {
+				row = append(row, labels.Label{Name: header[i+1], Value: value})
+			}
+		}
+		sort.Sort(row)
+		rows[record[0]] = row
+	}
+}
+
+// parseJSON parses a JSON table, which is an object whose keys are the keys of the rows and whose values are objects
+// of the labels added to the lines matching them.
+func parseJSON(r io.Reader) (map[string]labels.Labels, error) {
+	var table map[string]map[string]string
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return nil, err
+	}
+	rows := make(map[string]labels.Labels, len(table))
+	for key, values := range table {
+		row := make(labels.Labels, 0, len(values))
+		for name, value := range values {
+			if !model.LabelName(name).IsValid() {
+				return nil, fmt.Errorf("invalid label name %q in row %s", name, key)
+			}
+			row = append(row, labels.Label{Name: name, Value: value})
+		}
+		sort.Sort(row)
+		rows[key] = row
+	}
+	return rows, nil
+}
@@ -0,0 +1,108 @@
+package lookup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		err  bool
+	}{
+		{name: "no tables", cfg: Config{}},
+		{
+			name: "valid",
+			cfg: Config{Store: "filesystem", RefreshPeriod: time.Minute, Tables: []TableConfig{
+				{Name: "teams", Key: "lookup/teams.csv"},
+				{Name: "owners", Key: "lookup/owners", Format: "json"},
+			}},
+		},
+		{
+			name: "no store",
+			cfg:  Config{RefreshPeriod: time.Minute, Tables: []TableConfig{{Name: "teams", Key: "teams.csv"}}},
+			err:  true,
+		},
+		{
+			name: "duplicate table",
+			cfg: Config{Store: "filesystem", RefreshPeriod: time.Minute, Tables: []TableConfig{
+				{Name: "teams", Key: "teams.csv"},
+				{Name: "teams", Key: "teams.json"},
+			}},
+			err: true,
+		},
+		{
+			name: "unknown format",
+			cfg:  Config{Store: "filesystem", RefreshPeriod: time.Minute, Tables: []TableConfig{{Name: "teams", Key: "teams.txt"}}},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTables(t *testing.T) {
+	ctx := context.Background()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	put := func(key, content string) {
+		require.NoError(t, objectClient.PutObject(ctx, key, strings.NewReader(content)))
+	}
+
+	put("lookup/teams.csv", "instance_id,team,owner\ni-1,payments,alice\ni-2,search,\n")
+	cfg := Config{Store: "filesystem", RefreshPeriod: time.Minute, Tables: []TableConfig{
+		{Name: "teams", Key: "lookup/teams.csv"},
+		{Name: "regions", Key: "lookup/regions.json"},
+	}}
+	tables := NewTables(cfg, objectClient, log.NewNopLogger(), nil)
+
+	// A missing table is empty until it is created.
+	require.NoError(t, tables.starting(ctx))
+	teams, ok := tables.Table("teams")
+	require.True(t, ok)
+	row, ok := teams.Lookup("i-1")
+	require.True(t, ok)
+	require.Equal(t, labels.Labels{{Name: "owner", Value: "alice"}, {Name: "team", Value: "payments"}}, row)
+	row, ok = teams.Lookup("i-2")
+	require.True(t, ok)
+	require.Equal(t, labels.Labels{{Name: "team", Value: "search"}}, row)
+	_, ok = teams.Lookup("i-3")
+	require.False(t, ok)
+
+	regions, ok := tables.Table("regions")
+	require.True(t, ok)
+	_, ok = regions.Lookup("eu")
+	require.False(t, ok)
+	_, ok = tables.Table("unknown")
+	require.False(t, ok)
+
+	// A refresh picks up the updates, and keeps the last loaded rows of a table failing to load.
+	put("lookup/regions.json", `{"eu": {"continent": "europe"}}`)
+	put("lookup/teams.csv", "instance_id,bad label\ni-1,payments\n")
+	require.NoError(t, tables.refresh(ctx))
+	row, ok = regions.Lookup("eu")
+	require.True(t, ok)
+	require.Equal(t, labels.Labels{{Name: "continent", Value: "europe"}}, row)
+	row, ok = teams.Lookup("i-1")
+	require.True(t, ok)
+	require.Equal(t, labels.Labels{{Name: "owner", Value: "alice"}, {Name: "team", Value: "payments"}}, row)
+
+	// A table existing but failing to load fails the start.
+	require.Error(t, NewTables(cfg, objectClient, log.NewNopLogger(), nil).starting(ctx))
+}
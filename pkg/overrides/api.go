@@ -0,0 +1,354 @@
+// Package overrides implements an HTTP API to view and modify the limits of tenants at runtime, on top of the
+// overrides of the runtime config file.
+package overrides
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// Config configures the overrides API.
+type Config struct {
+	Enabled        bool          `yaml:"enabled"`
+	UpdatesEnabled bool          `yaml:"updates_enabled"`
+	Store          string        `yaml:"store"`
+	Key            string        `yaml:"key"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "overrides-api.enabled", false, "Enable the /config/overrides/{tenant} API to view and modify the limits of tenants at runtime. Limits set through the API take precedence over the overrides of the runtime config file.")
+	f.BoolVar(&cfg.UpdatesEnabled, "overrides-api.updates-enabled", false, "Allow the PUT and DELETE requests of the /config/overrides/{tenant} API modifying the limits of tenants. The API only views the limits otherwise.")
+	f.StringVar(&cfg.Store, "overrides-api.store", "", "Object store in which the limits set through the API are persisted, so that all the components converge on them. Supported types: gcs, s3, azure, swift, filesystem. Updates require a store supporting conditional writes, which swift does not. Empty keeps them in memory, only suitable for a single binary.")
+	f.StringVar(&cfg.Key, "overrides-api.key", "overrides/overrides.yaml", "Key of the object in which the limits set through the API are persisted.")
+	f.DurationVar(&cfg.PollInterval, "overrides-api.poll-interval", 10*time.Second, "How often the limits persisted in the object store are reloaded.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled || cfg.Store == "" {
+		return nil
+	}
+	if cfg.Key == "" {
+		return fmt.Errorf("overrides API key must not be empty")
+	}
+	if cfg.PollInterval <= 0 {
+		return fmt.Errorf("overrides API poll interval must be > 0")
+	}
+	if cfg.UpdatesEnabled && cfg.Store == "swift" {
+		return fmt.Errorf("overrides API updates require a store supporting conditional writes, which swift does not")
+	}
+	return nil
+}
+
+// maxSetAttempts is the number of times Set reads and writes the persisted object when it gets modified concurrently.
+const maxSetAttempts = 10
+
+// persisted is the format of the persisted object, which is the one of the overrides of the runtime config file.
+type persisted struct {
+	Overrides map[string]*validation.Limits `yaml:"overrides"`
+}
+
+// API serves and stores the limits of tenants set at runtime. It implements validation.TenantLimits, the limits set
+// through the API replacing the ones of the underlying tenant limits, which may be nil.
+type API struct {
+	services.Service
+
+	cfg          Config
+	defaults     validation.Limits
+	base         validation.TenantLimits
+	objectClient chunk.ObjectClient
+	logger       log.Logger
+
+	// writeMtx serializes the updates of the persisted object by this replica, the updates by different replicas
+	// being serialized by conditional writes.
+	writeMtx sync.Mutex
+	mtx      sync.RWMutex
+	limits   map[string]*validation.Limits
+
+	loads *prometheus.CounterVec
+}
+
+// NewAPI creates the overrides API. The limits are kept in memory when objectClient is nil.
+func NewAPI(cfg Config, defaults validation.Limits, base validation.TenantLimits, objectClient chunk.ObjectClient, logger log.Logger, registerer prometheus.Registerer) *API {
+	a := &API{
+		cfg:          cfg,
+		defaults:     defaults,
+		base:         base,
+		objectClient: objectClient,
+		logger:       logger,
+		limits:       map[string]*validation.Limits{},
+		loads: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "overrides_api_loads_total",
+			Help:      "Total number of loads of the limits persisted by the overrides API, by status.",
+		}, []string{"status"}),
+	}
+
+	if objectClient == nil {
+		a.Service = services.NewIdleService(nil, nil)
+	} else {
+		a.Service = services.NewTimerService(cfg.PollInterval, a.load, a.poll, nil)
+	}
+	return a
+}
+
+// TenantLimits returns the limits of the tenant set through the API, or otherwise the underlying ones.
+func (a *API) TenantLimits(userID string) *validation.Limits {
+	a.mtx.RLock()
+	l, ok := a.limits[userID]
+	a.mtx.RUnlock()
+	if ok {
+		return l
+	}
+	if a.base == nil {
+		return nil
+	}
+	return a.base.TenantLimits(userID)
+}
+
+// AllByUserID returns the limits of all the tenants, the ones set through the API replacing the underlying ones.
+func (a *API) AllByUserID() map[string]*validation.Limits {
+	all := map[string]*validation.Limits{}
+	if a.base != nil {
+		for userID, l := range a.base.AllByUserID() {
+			all[userID] = l
+		}
+	}
+
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+	for userID, l := range a.limits {
+		all[userID] = l
+	}
+	return all
+}
+
+// Set sets the limits of the tenant, or removes them when l is nil so that the underlying ones apply again.
+// The persisted object is only written if it was not modified since it was read, so that the updates done by the
+// other replicas are not lost, and is read again when it was.
+func (a *API) Set(ctx context.Context, userID string, l *validation.Limits) error {
+	a.writeMtx.Lock()
+	defer a.writeMtx.Unlock()
+
+	if a.objectClient == nil {
+		limits := a.copyLimits()
+		setTenantLimits(limits, userID, l)
+
+		a.mtx.Lock()
+		a.limits = limits
+		a.mtx.Unlock()
+		return nil
+	}
+
+	writer, ok := a.objectClient.(chunk.ConditionalObjectWriter)
+	if !ok {
+		return fmt.Errorf("the overrides API store does not support conditional writes")
+	}
+
+	for attempt := 1; ; attempt++ {
+		reader, version, err := writer.GetObjectVersion(ctx, a.cfg.Key)
+		if err != nil && !a.objectClient.IsObjectNotFoundErr(err) {
+			return err
+		}
+		limits := map[string]*validation.Limits{}
+		if err == nil {
+			if limits, err = decodePersisted(reader); err != nil {
+				return err
+			}
+		}
+
+		setTenantLimits(limits, userID, l)
+
+		b, err := yaml.Marshal(persisted{Overrides: limits})
+		if err != nil {
+			return err
+		}
+		_, err = writer.PutObjectIfVersion(ctx, a.cfg.Key, bytes.NewReader(b), version)
+		if err == chunk.ErrObjectModified && attempt < maxSetAttempts {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		a.mtx.Lock()
+		a.limits = limits
+		a.mtx.Unlock()
+		return nil
+	}
+}
+
+func setTenantLimits(limits map[string]*validation.Limits, userID string, l *validation.Limits) {
+	if l == nil {
+		delete(limits, userID)
+	} else {
+		limits[userID] = l
+	}
+}
+
+func (a *API) copyLimits() map[string]*validation.Limits {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+	limits := make(map[string]*validation.Limits, len(a.limits))
+	for userID, l := range a.limits {
+		limits[userID] = l
+	}
+	return limits
+}
+
+func (a *API) load(ctx context.Context) error {
+	limits, err := a.read(ctx)
+	if err != nil {
+		a.loads.WithLabelValues("failure").Inc()
+		return err
+	}
+	a.loads.WithLabelValues("success").Inc()
+
+	a.mtx.Lock()
+	a.limits = limits
+	a.mtx.Unlock()
+	return nil
+}
+
+// poll reloads the persisted limits, keeping the last loaded ones when the object store is unavailable.
+func (a *API) poll(ctx context.Context) error {
+	a.writeMtx.Lock()
+	defer a.writeMtx.Unlock()
+
+	if err := a.load(ctx); err != nil {
+		level.Warn(a.logger).Log("msg", "failed to load the limits persisted by the overrides API", "err", err)
+	}
+	return nil
+}
+
+// read returns the persisted limits, which are empty until the first tenant limits are set.
+func (a *API) read(ctx context.Context) (map[string]*validation.Limits, error) {
+	reader, err := a.objectClient.GetObject(ctx, a.cfg.Key)
+	if err != nil {
+		if a.objectClient.IsObjectNotFoundErr(err) {
+			return map[string]*validation.Limits{}, nil
+		}
+		return nil, err
+	}
+	return decodePersisted(reader)
+}
+
+func decodePersisted(reader io.ReadCloser) (map[string]*validation.Limits, error) {
+	defer reader.Close()
+
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var p persisted
+	if err := yaml.UnmarshalStrict(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode the persisted overrides: %w", err)
+	}
+	limits := make(map[string]*validation.Limits, len(p.Overrides))
+	for userID, l := range p.Overrides {
+		if l == nil {
+			continue
+		}
+		if err := l.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid persisted override for tenant %s: %w", userID, err)
+		}
+		limits[userID] = l
+	}
+	return limits, nil
+}
+
+// Handler serves the limits of the tenant of the request path, to be routed on /config/overrides/{tenant}. GET
+// responds with the effective limits of the tenant in YAML, PUT replaces them with the limits of the YAML request
+// body, whose missing fields take the default values, and DELETE removes the limits set through the API.
+func (a *API) Handler(w http.ResponseWriter, r *http.Request) {
+	userID, err := url.PathUnescape(mux.Vars(r)["tenant"])
+	if err != nil || userID == "" {
+		http.Error(w, "invalid tenant", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method != http.MethodGet && !a.cfg.UpdatesEnabled {
+		http.Error(w, "updates of the tenant limits are disabled", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		l := a.TenantLimits(userID)
+		if l == nil {
+			l = &a.defaults
+		}
+		b, err := yaml.Marshal(l)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(b)
+		return
+
+	case http.MethodPut:
+		l, err := a.decodeLimits(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = a.Set(r.Context(), userID, l)
+		if err != nil {
+			level.Error(a.logger).Log("msg", "failed to set tenant limits", "tenant", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+	case http.MethodDelete:
+		if err := a.Set(r.Context(), userID, nil); err != nil {
+			level.Error(a.logger).Log("msg", "failed to delete tenant limits", "tenant", userID, "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) decodeLimits(r io.Reader) (*validation.Limits, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing fields take the defaults set by validation.SetDefaultLimitsForYAMLUnmarshalling, as the overrides
+	// of the runtime config file do.
+	var l validation.Limits
+	if err := yaml.UnmarshalStrict(b, &l); err != nil {
+		return nil, fmt.Errorf("invalid limits: %w", err)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid limits: %w", err)
+	}
+	return &l, nil
+}
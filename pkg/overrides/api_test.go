@@ -0,0 +1,163 @@
+package overrides
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+type fixedTenantLimits map[string]*validation.Limits
+
+func (l fixedTenantLimits) TenantLimits(userID string) *validation.Limits {
+	return l[userID]
+}
+
+func (l fixedTenantLimits) AllByUserID() map[string]*validation.Limits {
+	return l
+}
+
+func TestAPI(t *testing.T) {
+	var defaults validation.Limits
+	flagext.DefaultValues(&defaults)
+	validation.SetDefaultLimitsForYAMLUnmarshalling(defaults)
+
+	fromFile := defaults
+	fromFile.MaxLineSize = 100
+	base := fixedTenantLimits{"file": &fromFile}
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	cfg := Config{Enabled: true, UpdatesEnabled: true, Store: "filesystem", Key: "overrides/overrides.yaml"}
+	a1 := NewAPI(cfg, defaults, base, objectClient, log.NewNopLogger(), nil)
+	a2 := NewAPI(cfg, defaults, base, objectClient, log.NewNopLogger(), nil)
+
+	router := mux.NewRouter()
+	router.Path("/config/overrides/{tenant}").HandlerFunc(a1.Handler)
+	do := func(method, tenant, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/config/overrides/"+tenant, strings.NewReader(body)))
+		return w
+	}
+	get := func(tenant string) validation.Limits {
+		w := do(http.MethodGet, tenant, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var l validation.Limits
+		require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &l))
+		return l
+	}
+
+	// Tenants without overrides get the limits of the runtime config file or the defaults.
+	require.Equal(t, 100, int(get("file").MaxLineSize))
+	require.Equal(t, defaults.MaxLineSize, get("fake").MaxLineSize)
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodPut, "file", "max_line_size: 200").Code)
+	require.Equal(t, http.StatusNoContent, do(http.MethodPut, "fake", "max_streams_per_user: 10").Code)
+	require.Equal(t, 200, int(get("file").MaxLineSize))
+	require.Equal(t, 10, get("fake").MaxLocalStreamsPerUser)
+	require.Equal(t, defaults.MaxLineSize, get("fake").MaxLineSize)
+
+	// The other replicas converge on the persisted limits.
+	require.Nil(t, a2.TenantLimits("fake"))
+	require.NoError(t, a2.load(context.Background()))
+	require.Equal(t, 10, a2.TenantLimits("fake").MaxLocalStreamsPerUser)
+	require.Len(t, a2.AllByUserID(), 2)
+
+	// Deleting the overrides restores the limits of the runtime config file.
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "file", "").Code)
+	require.Equal(t, 100, int(get("file").MaxLineSize))
+	require.NoError(t, a2.load(context.Background()))
+	require.Equal(t, 100, int(a2.TenantLimits("file").MaxLineSize))
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "fake", "unknown_limit: 1").Code)
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "fake", "debug_sample_ratio: 2").Code)
+}
+
+// racingObjectClient calls beforePut once before the first conditional write, to race with another replica.
+type racingObjectClient struct {
+	*local.FSObjectClient
+	beforePut func()
+}
+
+func (c *racingObjectClient) PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error) {
+	if c.beforePut != nil {
+		beforePut := c.beforePut
+		c.beforePut = nil
+		beforePut()
+	}
+	return c.FSObjectClient.PutObjectIfVersion(ctx, objectKey, object, version)
+}
+
+func TestAPI_ConcurrentUpdates(t *testing.T) {
+	var defaults validation.Limits
+	flagext.DefaultValues(&defaults)
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	cfg := Config{Enabled: true, UpdatesEnabled: true, Store: "filesystem", Key: "overrides/overrides.yaml"}
+	racingClient := &racingObjectClient{FSObjectClient: objectClient}
+	a1 := NewAPI(cfg, defaults, nil, racingClient, log.NewNopLogger(), nil)
+	a2 := NewAPI(cfg, defaults, nil, objectClient, log.NewNopLogger(), nil)
+
+	l1, l2 := defaults, defaults
+	l1.MaxLineSize = 10
+	l2.MaxLineSize = 20
+
+	// The update of the other replica between the read and the write of a1 must not be lost.
+	racingClient.beforePut = func() {
+		require.NoError(t, a2.Set(context.Background(), "tenant2", &l2))
+	}
+	require.NoError(t, a1.Set(context.Background(), "tenant1", &l1))
+
+	require.NoError(t, a2.load(context.Background()))
+	for _, a := range []*API{a1, a2} {
+		require.Len(t, a.AllByUserID(), 2)
+		require.Equal(t, l1.MaxLineSize, a.TenantLimits("tenant1").MaxLineSize)
+		require.Equal(t, l2.MaxLineSize, a.TenantLimits("tenant2").MaxLineSize)
+	}
+}
+
+func TestAPI_InMemory(t *testing.T) {
+	var defaults validation.Limits
+	flagext.DefaultValues(&defaults)
+
+	a := NewAPI(Config{Enabled: true}, defaults, nil, nil, log.NewNopLogger(), nil)
+	require.Nil(t, a.TenantLimits("fake"))
+
+	l := defaults
+	l.MaxLineSize = 10
+	require.NoError(t, a.Set(context.Background(), "fake", &l))
+	require.Equal(t, &l, a.TenantLimits("fake"))
+	require.Equal(t, map[string]*validation.Limits{"fake": &l}, a.AllByUserID())
+}
+
+func TestAPI_UpdatesDisabled(t *testing.T) {
+	var defaults validation.Limits
+	flagext.DefaultValues(&defaults)
+	validation.SetDefaultLimitsForYAMLUnmarshalling(defaults)
+
+	a := NewAPI(Config{Enabled: true}, defaults, nil, nil, log.NewNopLogger(), nil)
+	router := mux.NewRouter()
+	router.Path("/config/overrides/{tenant}").HandlerFunc(a.Handler)
+	do := func(method, body string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/config/overrides/tenant1", strings.NewReader(body)))
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusForbidden, do(http.MethodPut, "max_line_size: 10\n"))
+	require.Equal(t, http.StatusForbidden, do(http.MethodDelete, ""))
+	require.Nil(t, a.TenantLimits("tenant1"))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, ""))
+}
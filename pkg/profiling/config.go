@@ -0,0 +1,34 @@
+package profiling
+
+import (
+	"errors"
+	"flag"
+	"runtime"
+)
+
+// Config enables the mutex and block profiles served on /debug/pprof/mutex and /debug/pprof/block.
+type Config struct {
+	MutexProfileFraction int `yaml:"mutex_profile_fraction"`
+	BlockProfileRate     int `yaml:"block_profile_rate"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&cfg.MutexProfileFraction, "profiling.mutex-profile-fraction", 0, "On average 1 out of this many mutex contention events is reported in the mutex profile. 0 to disable mutex profiling.")
+	f.IntVar(&cfg.BlockProfileRate, "profiling.block-profile-rate", 0, "On average 1 blocking event is reported in the block profile per this many nanoseconds spent blocked. 0 to disable block profiling.")
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.MutexProfileFraction < 0 {
+		return errors.New("mutex profile fraction must not be negative")
+	}
+	if cfg.BlockProfileRate < 0 {
+		return errors.New("block profile rate must not be negative")
+	}
+	return nil
+}
+
+// Apply sets the profiling rates of the runtime.
+func (cfg *Config) Apply() {
+	runtime.SetMutexProfileFraction(cfg.MutexProfileFraction)
+	runtime.SetBlockProfileRate(cfg.BlockProfileRate)
+}
@@ -0,0 +1,133 @@
+package querier
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+// Explain parses the query and returns its AST, its execution plan and its estimated cost.
+func (q *Querier) Explain(ctx context.Context, query string, start, end time.Time) (*loghttp.ExplainResponseData, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := logql.ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cost is estimated by reading the index, so the range is limited as for the queries.
+	if start, end, err = validateQueryTimeRangeLimits(ctx, userID, q.limits, start, end); err != nil {
+		return nil, err
+	}
+
+	// Enforce the query timeout while querying the store
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
+	defer cancel()
+
+	plan, err := q.explainPlan(userID, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	cost, err := q.explainCost(ctx, userID, expr, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loghttp.ExplainResponseData{
+		AST:  logql.ExplainExpr(expr),
+		Plan: plan,
+		Cost: cost,
+	}, nil
+}
+
+func (q *Querier) explainPlan(userID, query string, start, end time.Time) (loghttp.ExplainPlan, error) {
+	plan := loghttp.ExplainPlan{
+		SplitInterval: model.Duration(q.limits.QuerySplitDuration(userID)),
+		Splits:        1,
+	}
+	if plan.SplitInterval > 0 {
+		plan.Splits = 0
+		for s := start; s.Before(end); s = s.Add(time.Duration(plan.SplitInterval)) {
+			plan.Splits++
+		}
+	}
+
+	conf, ok := periodConfigFor(q.cfg.PeriodConfigs, start, end)
+	if !ok || conf.RowShards < 2 {
+		return plan, nil
+	}
+	mapper, err := logql.NewShardMapper(int(conf.RowShards), q.shardingMetrics)
+	if err != nil {
+		return plan, err
+	}
+	noop, mapped, err := mapper.Parse(query)
+	if err != nil {
+		return plan, err
+	}
+	if !noop {
+		plan.Shards = int(conf.RowShards)
+		plan.ShardedQuery = mapped.String()
+	}
+	return plan, nil
+}
+
+// periodConfigFor returns the period config covering the whole time range, if any.
+func periodConfigFor(configs []chunk.PeriodConfig, start, end time.Time) (chunk.PeriodConfig, bool) {
+	from, through := model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(end.UnixNano())
+	for i, conf := range configs {
+		if from < conf.From.Time {
+			return chunk.PeriodConfig{}, false
+		}
+		if i == len(configs)-1 || through < configs[i+1].From.Time {
+			return conf, true
+		}
+	}
+	return chunk.PeriodConfig{}, false
+}
+
+func (q *Querier) explainCost(ctx context.Context, userID string, expr logql.Expr, start, end time.Time) (loghttp.ExplainCost, error) {
+	var (
+		selectors [][]*labels.Matcher
+		lookback  time.Duration
+	)
+	expr.Walk(func(e interface{}) {
+		switch e := e.(type) {
+		case *logql.LogRange:
+			if d := e.Interval + e.Offset; d > lookback {
+				lookback = d
+			}
+		case *logql.MatchersExpr:
+			selectors = append(selectors, e.Matchers())
+		}
+	})
+
+	var (
+		cost    loghttp.ExplainCost
+		streams = map[model.Fingerprint]struct{}{}
+		from    = model.TimeFromUnixNano(start.Add(-lookback).UnixNano())
+		through = model.TimeFromUnixNano(end.UnixNano())
+	)
+	for _, matchers := range selectors {
+		chunks, _, err := q.store.GetChunkRefs(ctx, userID, from, through, matchers...)
+		if err != nil {
+			return cost, err
+		}
+		for _, group := range chunks {
+			for _, c := range group {
+				streams[c.Fingerprint] = struct{}{}
+				cost.Chunks++
+			}
+		}
+	}
+	cost.Streams = len(streams)
+	return cost, nil
+}
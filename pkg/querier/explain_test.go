@@ -0,0 +1,68 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestQuerier_Explain(t *testing.T) {
+	var (
+		start = time.Unix(0, 0).Add(24 * time.Hour)
+		end   = start.Add(3 * time.Hour)
+	)
+
+	store := newStoreMock()
+	store.On("GetChunkRefs", mock.Anything, "test", model.TimeFromUnixNano(start.Add(-5*time.Minute).UnixNano()), model.TimeFromUnixNano(end.UnixNano()), mock.Anything).Return(
+		[][]chunk.Chunk{{{Fingerprint: 1}, {Fingerprint: 1}, {Fingerprint: 2}}},
+		[]*chunk.Fetcher{nil},
+		nil,
+	)
+
+	defaults := defaultLimitsTestConfig()
+	defaults.QuerySplitDuration = model.Duration(time.Hour)
+	limits, err := validation.NewOverrides(defaults, nil)
+	require.NoError(t, err)
+
+	cfg := mockQuerierConfig()
+	cfg.PeriodConfigs = []chunk.PeriodConfig{{RowShards: 2}}
+	q, err := newQuerier(cfg, mockIngesterClientConfig(), newIngesterClientMockFactory(newQuerierClientMock()), mockReadRingWithOneActiveIngester(), store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	res, err := q.Explain(ctx, `sum(rate({app="foo"} |= "bar" [5m]))`, start, end)
+	require.NoError(t, err)
+	store.AssertExpectations(t)
+
+	require.Equal(t, "vector_aggregation(sum)", res.AST.Type)
+	require.Equal(t, "range_aggregation(rate)", res.AST.Children[0].Type)
+	require.Equal(t, "log_range", res.AST.Children[0].Children[0].Type)
+	pipeline := res.AST.Children[0].Children[0].Children[0]
+	require.Equal(t, "pipeline", pipeline.Type)
+	require.Equal(t, "selector", pipeline.Children[0].Type)
+	require.Equal(t, "line_filter", pipeline.Children[1].Type)
+
+	require.Equal(t, model.Duration(time.Hour), res.Plan.SplitInterval)
+	require.Equal(t, 3, res.Plan.Splits)
+	require.Equal(t, 2, res.Plan.Shards)
+	require.Contains(t, res.Plan.ShardedQuery, "shard=0_of_2")
+
+	require.Equal(t, 2, res.Cost.Streams)
+	require.Equal(t, 3, res.Cost.Chunks)
+
+	_, err = q.Explain(ctx, `sum(rate({app="foo"}`, start, end)
+	require.Error(t, err)
+
+	// ranges longer than max_query_length should be rejected without reading the index.
+	_, err = q.Explain(ctx, `{app="foo"}`, start, start.Add(time.Duration(defaults.MaxQueryLength)+time.Hour))
+	require.Error(t, err)
+	store.AssertNumberOfCalls(t, "GetChunkRefs", 1)
+}
@@ -0,0 +1,567 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+	"github.com/cortexproject/cortex/pkg/util/spanlogger"
+	"github.com/go-kit/log/level"
+	json "github.com/json-iterator/go"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/pkg/ingester/index"
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/build"
+)
+
+const (
+	federationQueryRangePath  = "/loki/api/v1/query_range"
+	federationLabelsPath      = "/loki/api/v1/labels"
+	federationLabelValuesPath = "/loki/api/v1/label/%s/values"
+)
+
+var federationUserAgent = fmt.Sprintf("loki-querier-federation/%s", build.Version)
+
+// FederationConfig configures the remote Loki clusters queried along with the local one.
+type FederationConfig struct {
+	Remotes           []RemoteClusterConfig `yaml:"remotes"`
+	Timeout           time.Duration         `yaml:"timeout"`
+	BatchSize         int                   `yaml:"batch_size"`
+	MaxFetchedEntries int                   `yaml:"max_fetched_entries"`
+}
+
+// RemoteClusterConfig is a remote Loki cluster queried by the federation.
+type RemoteClusterConfig struct {
+	Name             string                  `yaml:"name"`
+	URL              string                  `yaml:"url"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:",inline"`
+}
+
+// RegisterFlags registers the flags of the federation, the remote clusters are configured in the YAML file only.
+func (cfg *FederationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.Timeout, "querier.federation.timeout", 30*time.Second, "Timeout of each request sent to a remote cluster.")
+	f.IntVar(&cfg.BatchSize, "querier.federation.batch-size", 5000, "Maximum number of entries requested at once from a remote cluster. It must not exceed the max_entries_limit_per_query of the remote clusters.")
+	f.IntVar(&cfg.MaxFetchedEntries, "querier.federation.max-fetched-entries", 1000000, "Maximum number of entries fetched from the remote clusters by a single query, before applying the rest of its pipeline. 0 to disable.")
+}
+
+// Validate validates the federation config.
+func (cfg *FederationConfig) Validate() error {
+	names := map[string]struct{}{}
+	for _, r := range cfg.Remotes {
+		if r.Name == "" {
+			return fmt.Errorf("federation remote cluster without a name")
+		}
+		if _, ok := names[r.Name]; ok {
+			return fmt.Errorf("duplicate federation remote cluster %q", r.Name)
+		}
+		names[r.Name] = struct{}{}
+		if _, err := url.Parse(r.URL); err != nil || r.URL == "" {
+			return fmt.Errorf("invalid url of the federation remote cluster %q", r.Name)
+		}
+		if err := r.HTTPClientConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid http client config of the federation remote cluster %q: %w", r.Name, err)
+		}
+	}
+	if len(cfg.Remotes) > 0 && cfg.BatchSize <= 0 {
+		return fmt.Errorf("federation batch size must be > 0")
+	}
+	if cfg.MaxFetchedEntries < 0 {
+		return fmt.Errorf("federation max fetched entries must be >= 0")
+	}
+	return nil
+}
+
+// federation queries the remote clusters over their HTTP API.
+//
+// The remote clusters are asked for the raw entries of the stream selector and the leading line filters of a
+// query, the rest of the pipeline is applied by this querier. This lets the entries of the remote clusters be
+// sharded like the local ones and their samples be extracted locally, so that they are merged with the local
+// entries and samples before any aggregation. Identical entries returned by several clusters are deduplicated.
+// The entries fetched by a query are bounded by the max fetched entries of the federation, summed over the remote
+// clusters, and their lines by the max fetched chunk bytes per query of the tenant.
+//
+// The summable range aggregations whose pipeline only has line filters, e.g. rate({app="foo"} |= "error" [5m]), are
+// instead evaluated by the remote clusters over the steps of the query, and their results summed with the local
+// ones by series, so that metric queries don't fetch the entries. Their series are the remote streams, sharded like
+// the local ones. The clusters are expected to hold distinct entries, as they are not deduplicated.
+type federation struct {
+	cfg          FederationConfig
+	remotes      []*remoteCluster
+	lookupTables log.LookupTables
+}
+
+type remoteCluster struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+func newFederation(cfg FederationConfig) (*federation, error) {
+	f := &federation{cfg: cfg}
+	for _, r := range cfg.Remotes {
+		client, err := config.NewClientFromConfig(r.HTTPClientConfig, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		f.remotes = append(f.remotes, &remoteCluster{
+			name:   r.Name,
+			url:    strings.TrimSuffix(r.URL, "/"),
+			client: client,
+		})
+	}
+	return f, nil
+}
+
+func (f *federation) enabled() bool {
+	return f != nil && len(f.remotes) > 0
+}
+
+// selectLogs returns the entries of the remote clusters for a log query.
+func (f *federation) selectLogs(ctx context.Context, params logql.SelectLogParams) ([]iter.EntryIterator, error) {
+	expr, err := params.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	logql.BindLookupTables(expr, f.lookupTables)
+	pipeline, err := expr.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	selector, pushed := remoteSelector(expr)
+	// The remote clusters can only apply the limit when they apply the whole pipeline and the query is not sharded.
+	limit := 0
+	if pushed && len(params.Shards) == 0 {
+		limit = int(params.Limit)
+	}
+
+	results, err := f.fetch(ctx, selector, params.Start, params.End, params.Direction, limit, params.Shards)
+	if err != nil {
+		return nil, err
+	}
+	iters := make([]iter.EntryIterator, 0, len(results))
+	for _, streams := range results {
+		iters = append(iters, iter.NewStreamsIterator(ctx, processRemoteStreams(streams, pipeline, params.Direction), params.Direction))
+	}
+	return iters, nil
+}
+
+// selectSamples returns the samples of the remote clusters for a metric query.
+func (f *federation) selectSamples(ctx context.Context, params logql.SelectSampleParams) ([]iter.SampleIterator, error) {
+	expr, err := params.Expr()
+	if err != nil {
+		return nil, err
+	}
+	logql.BindLookupTables(expr, f.lookupTables)
+	extractor, err := expr.Extractor()
+	if err != nil {
+		return nil, err
+	}
+	selector, _ := remoteSelector(expr.Selector())
+
+	results, err := f.fetch(ctx, selector, params.Start, params.End, logproto.FORWARD, 0, params.Shards)
+	if err != nil {
+		return nil, err
+	}
+	iters := make([]iter.SampleIterator, 0, len(results))
+	for _, streams := range results {
+		iters = append(iters, iter.NewMultiSeriesIterator(ctx, processRemoteSeries(streams, extractor)))
+	}
+	return iters, nil
+}
+
+// aggregates tells if the remote clusters evaluate the range aggregation themselves, which requires it to be summable
+// and its whole pipeline to be sent to them.
+func (f *federation) aggregates(expr *logql.RangeAggregationExpr) bool {
+	if !logql.SummableRangeAggregation(expr) {
+		return false
+	}
+	_, pushed := remoteSelector(expr.Left.Left)
+	return pushed
+}
+
+// selectRangeAggregation returns the results of the range aggregation evaluated by the remote clusters over the steps
+// of the query, filtered by shard.
+func (f *federation) selectRangeAggregation(ctx context.Context, expr *logql.RangeAggregationExpr, params logql.Params) (promql.Matrix, error) {
+	parsedShards, err := logql.ParseShards(params.Shards())
+	if err != nil {
+		return nil, err
+	}
+	step := params.Step()
+	if step == 0 {
+		// instant queries are evaluated at their single step.
+		step = time.Second
+	}
+	qsb := util.NewQueryStringBuilder()
+	qsb.SetString("query", expr.String())
+	qsb.SetString("start", params.Start().Format(time.RFC3339Nano))
+	qsb.SetString("end", params.End().Format(time.RFC3339Nano))
+	qsb.SetString("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	results := make([]promql.Matrix, len(f.remotes))
+	g, ctx := errgroup.WithContext(ctx)
+	for i, r := range f.remotes {
+		i, r := i, r
+		g.Go(func() error {
+			var resp loghttp.QueryResponse
+			if err := f.do(ctx, r, federationQueryRangePath, qsb.Encode(), &resp); err != nil {
+				return fmt.Errorf("querying the federation remote cluster %s: %w", r.name, err)
+			}
+			m, ok := resp.Data.Result.(loghttp.Matrix)
+			if !ok {
+				return fmt.Errorf("querying the federation remote cluster %s: unexpected result type %q", r.name, resp.Data.ResultType)
+			}
+			results[i] = remoteMatrix(m, parsedShards)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var result promql.Matrix
+	for _, m := range results {
+		result = append(result, m...)
+	}
+	return result, nil
+}
+
+// label returns the label names or values of the remote clusters.
+func (f *federation) label(ctx context.Context, req *logproto.LabelRequest) ([][]string, error) {
+	path := federationLabelsPath
+	if req.Values {
+		path = fmt.Sprintf(federationLabelValuesPath, url.PathEscape(req.Name))
+	}
+	qsb := util.NewQueryStringBuilder()
+	qsb.SetString("start", req.Start.Format(time.RFC3339Nano))
+	qsb.SetString("end", req.End.Format(time.RFC3339Nano))
+	if req.Query != "" {
+		qsb.SetString("query", req.Query)
+	}
+
+	results := make([][]string, len(f.remotes))
+	g, ctx := errgroup.WithContext(ctx)
+	for i, r := range f.remotes {
+		i, r := i, r
+		g.Go(func() error {
+			var resp loghttp.LabelResponse
+			if err := f.do(ctx, r, path, qsb.Encode(), &resp); err != nil {
+				return fmt.Errorf("querying the federation remote cluster %s: %w", r.name, err)
+			}
+			results[i] = resp.Data
+			return nil
+		})
+	}
+	return results, g.Wait()
+}
+
+// fetch returns the raw streams of each remote cluster for a selector, filtered by shard.
+// A limit of 0 fetches all the entries of the time range, in batches.
+func (f *federation) fetch(ctx context.Context, selector string, start, end time.Time, direction logproto.Direction, limit int, shards []string) ([][]logproto.Stream, error) {
+	parsedShards, err := logql.ParseShards(shards)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]logproto.Stream, len(f.remotes))
+	fetched := atomic.NewInt64(0)
+	g, ctx := errgroup.WithContext(ctx)
+	for i, r := range f.remotes {
+		i, r := i, r
+		g.Go(func() error {
+			streams, err := f.fetchRemote(ctx, r, selector, start, end, direction, limit, fetched)
+			if err != nil {
+				var limitErr *logqlmodel.LimitError
+				if errors.As(err, &limitErr) {
+					return err
+				}
+				return fmt.Errorf("querying the federation remote cluster %s: %w", r.name, err)
+			}
+			if len(parsedShards) > 0 {
+				streams, err = filterShard(streams, parsedShards[0].Shard, parsedShards[0].Of)
+				if err != nil {
+					return err
+				}
+			}
+			results[i] = streams
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// fetchRemote returns the raw streams of a remote cluster for a selector. fetched counts the entries fetched by the
+// query from all the remote clusters.
+func (f *federation) fetchRemote(ctx context.Context, r *remoteCluster, selector string, start, end time.Time, direction logproto.Direction, limit int, fetched *atomic.Int64) ([]logproto.Stream, error) {
+	log := spanlogger.FromContext(ctx)
+	queryLimiter := limiter.QueryLimiterFromContextWithFallback(ctx)
+	byLabels := map[string]*logproto.Stream{}
+	// seen are the entries of the previous batch at its boundary timestamp, which the next batch returns again.
+	var seen map[string]struct{}
+	total := 0
+
+	for {
+		batch := f.cfg.BatchSize
+		if limit > 0 && limit-total < batch {
+			batch = limit - total
+		}
+		qsb := util.NewQueryStringBuilder()
+		qsb.SetString("query", selector)
+		qsb.SetString("start", start.Format(time.RFC3339Nano))
+		qsb.SetString("end", end.Format(time.RFC3339Nano))
+		qsb.SetInt("limit", int64(batch))
+		qsb.SetString("direction", direction.String())
+
+		var resp loghttp.QueryResponse
+		if err := f.do(ctx, r, federationQueryRangePath, qsb.Encode(), &resp); err != nil {
+			return nil, err
+		}
+		streams, ok := resp.Data.Result.(loghttp.Streams)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %q", resp.Data.ResultType)
+		}
+
+		received, added, receivedBytes := 0, 0, 0
+		var boundary time.Time
+		for _, s := range streams {
+			for _, e := range s.Entries {
+				received++
+				receivedBytes += len(e.Line)
+				if boundary.IsZero() || (direction == logproto.FORWARD && e.Timestamp.After(boundary)) ||
+					(direction == logproto.BACKWARD && e.Timestamp.Before(boundary)) {
+					boundary = e.Timestamp
+				}
+			}
+		}
+		if err := queryLimiter.AddChunkBytes(receivedBytes); err != nil {
+			return nil, logqlmodel.NewQueryLimitError(err)
+		}
+		next := map[string]struct{}{}
+		for _, s := range streams {
+			ls := s.Labels.String()
+			for _, e := range s.Entries {
+				key := ls + e.Timestamp.String() + e.Line
+				if e.Timestamp.Equal(boundary) {
+					next[key] = struct{}{}
+				}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				stream, ok := byLabels[ls]
+				if !ok {
+					stream = &logproto.Stream{Labels: ls}
+					byLabels[ls] = stream
+				}
+				stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: e.Timestamp, Line: e.Line})
+				added++
+			}
+		}
+		total += added
+		if max := f.cfg.MaxFetchedEntries; max > 0 && fetched.Add(int64(added)) > int64(max) {
+			return nil, logqlmodel.NewQueryLimitError(fmt.Errorf("the query hit the max number of entries fetched from the federation remote clusters (limit: %d)", max))
+		}
+		level.Debug(log).Log("msg", "queried federation remote cluster", "remote", r.name, "entries", received, "new", added)
+
+		if received < batch || (limit > 0 && total >= limit) {
+			break
+		}
+		if added == 0 {
+			return nil, fmt.Errorf("at least %d entries at %s, increase the federation batch size", batch, boundary)
+		}
+		// The next batch starts at the boundary timestamp, since the batch may not contain all its entries.
+		seen = next
+		if direction == logproto.FORWARD {
+			start = boundary
+		} else {
+			end = boundary.Add(time.Nanosecond)
+		}
+	}
+
+	result := make([]logproto.Stream, 0, len(byLabels))
+	for _, s := range byLabels {
+		result = append(result, *s)
+	}
+	return result, nil
+}
+
+// do sends a request to a remote cluster on behalf of the tenant of the context and decodes the JSON response.
+func (f *federation) do(ctx context.Context, r *remoteCluster, path, query string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+path+"?"+query, nil)
+	if err != nil {
+		return err
+	}
+	orgID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(user.OrgIDHeaderName, orgID)
+	req.Header.Set("User-Agent", federationUserAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// remoteSelector returns the part of a log selector sent to the remote clusters: its stream selector and the line
+// filters preceding the other stages of its pipeline, which don't modify the lines and labels. It tells if this is
+// the whole selector.
+func remoteSelector(expr logql.LogSelectorExpr) (string, bool) {
+	pipeline, ok := expr.(*logql.PipelineExpr)
+	if !ok {
+		return expr.String(), true
+	}
+	var filters logql.MultiStageExpr
+	for _, stage := range pipeline.MultiStages {
+		if _, ok := stage.(*logql.LineFilterExpr); !ok {
+			break
+		}
+		filters = append(filters, stage)
+	}
+	if len(filters) == 0 {
+		return pipeline.Left.String(), false
+	}
+	return (&logql.PipelineExpr{Left: pipeline.Left, MultiStages: filters}).String(), len(filters) == len(pipeline.MultiStages)
+}
+
+// filterShard keeps the streams of a shard, sharded like the local streams by the series ID of their labels.
+func filterShard(streams []logproto.Stream, shard, of int) ([]logproto.Stream, error) {
+	result := streams[:0]
+	for _, s := range streams {
+		ls, err := logql.ParseLabels(s.Labels)
+		if err != nil {
+			return nil, err
+		}
+		if index.ShardOf(ls, of) == shard {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// remoteMatrix converts the series of a remote cluster, keeping the ones of the shard if any.
+func remoteMatrix(m loghttp.Matrix, shards logql.Shards) promql.Matrix {
+	result := make(promql.Matrix, 0, len(m))
+	for _, s := range m {
+		ls := make(labels.Labels, 0, len(s.Metric))
+		for name, value := range s.Metric {
+			ls = append(ls, labels.Label{Name: string(name), Value: string(value)})
+		}
+		sort.Sort(ls)
+		if len(shards) > 0 && index.ShardOf(ls, shards[0].Of) != shards[0].Shard {
+			continue
+		}
+		points := make([]promql.Point, 0, len(s.Values))
+		for _, v := range s.Values {
+			points = append(points, promql.Point{T: int64(v.Timestamp), V: float64(v.Value)})
+		}
+		result = append(result, promql.Series{Metric: ls, Points: points})
+	}
+	return result
+}
+
+// processRemoteStreams applies a pipeline to the raw streams of a remote cluster.
+func processRemoteStreams(streams []logproto.Stream, pipeline log.Pipeline, direction logproto.Direction) []logproto.Stream {
+	byLabels := map[string]*logproto.Stream{}
+	for _, s := range streams {
+		ls, err := logql.ParseLabels(s.Labels)
+		if err != nil {
+			continue
+		}
+		sp := pipeline.ForStream(ls)
+		for _, e := range s.Entries {
+			line, out, ok := sp.ProcessString(e.Timestamp.UnixNano(), e.Line)
+			if !ok {
+				continue
+			}
+			stream, found := byLabels[out.String()]
+			if !found {
+				stream = &logproto.Stream{Labels: out.String()}
+				byLabels[out.String()] = stream
+			}
+			stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: e.Timestamp, Line: line})
+		}
+	}
+
+	result := make([]logproto.Stream, 0, len(byLabels))
+	for _, s := range byLabels {
+		// The entries of several streams may have been merged into this one.
+		sort.SliceStable(s.Entries, func(i, j int) bool {
+			if direction == logproto.FORWARD {
+				return s.Entries[i].Timestamp.Before(s.Entries[j].Timestamp)
+			}
+			return s.Entries[i].Timestamp.After(s.Entries[j].Timestamp)
+		})
+		result = append(result, *s)
+	}
+	return result
+}
+
+// processRemoteSeries extracts the samples of the raw streams of a remote cluster.
+func processRemoteSeries(streams []logproto.Stream, extractor log.SampleExtractor) []logproto.Series {
+	bySeries := map[string]*logproto.Series{}
+	for _, s := range streams {
+		ls, err := logql.ParseLabels(s.Labels)
+		if err != nil {
+			continue
+		}
+		ex := extractor.ForStream(ls)
+		for _, e := range s.Entries {
+			v, out, ok := ex.ProcessString(e.Timestamp.UnixNano(), e.Line)
+			if !ok {
+				continue
+			}
+			series, found := bySeries[out.String()]
+			if !found {
+				series = &logproto.Series{Labels: out.String()}
+				bySeries[out.String()] = series
+			}
+			series.Samples = append(series.Samples, logproto.Sample{
+				Timestamp: e.Timestamp.UnixNano(),
+				Value:     v,
+				Hash:      xxhash.Sum64String(e.Line),
+			})
+		}
+	}
+
+	result := make([]logproto.Series, 0, len(bySeries))
+	for _, s := range bySeries {
+		sort.Sort(s)
+		result = append(result, *s)
+	}
+	return result
+}
@@ -0,0 +1,341 @@
+package querier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util/limiter"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/util/marshal"
+)
+
+// newFakeRemoteCluster serves the streams with the query and label APIs of a Loki cluster.
+func newFakeRemoteCluster(t *testing.T, streams []logproto.Stream) (*httptest.Server, *atomic.Int64) {
+	engine := logql.NewEngine(logql.EngineOpts{}, logql.NewMockQuerier(0, streams), logql.NoLimits)
+	requests := atomic.NewInt64(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		require.NoError(t, r.ParseForm())
+		ctx := user.InjectOrgID(r.Context(), r.Header.Get(user.OrgIDHeaderName))
+		switch r.URL.Path {
+		case federationQueryRangePath:
+			req, err := loghttp.ParseRangeQuery(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			params := logql.NewLiteralParams(req.Query, req.Start, req.End, req.Step, req.Interval, req.Direction, req.Limit, nil)
+			res, err := engine.Query(params).Exec(ctx)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			require.NoError(t, marshal.WriteQueryResponseJSON(res, w))
+		case federationLabelsPath:
+			require.NoError(t, marshal.WriteLabelResponseJSON(logproto.LabelResponse{Values: []string{"app", r.Header.Get(user.OrgIDHeaderName)}}, w))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func newTestFederation(t *testing.T, batchSize int, urls ...string) *federation {
+	cfg := FederationConfig{Timeout: time.Minute, BatchSize: batchSize}
+	for i, u := range urls {
+		cfg.Remotes = append(cfg.Remotes, RemoteClusterConfig{Name: fmt.Sprintf("remote-%d", i), URL: u})
+	}
+	require.NoError(t, cfg.Validate())
+	f, err := newFederation(cfg)
+	require.NoError(t, err)
+	return f
+}
+
+func remoteTestStreams() []logproto.Stream {
+	return []logproto.Stream{
+		{
+			Labels: `{app="foo", pod="a"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: `{"level":"info"}`},
+				{Timestamp: time.Unix(2, 0), Line: `{"level":"error"}`},
+				{Timestamp: time.Unix(3, 0), Line: `{"level":"error","n":1}`},
+				{Timestamp: time.Unix(4, 0), Line: `{"level":"info"}`},
+			},
+		},
+		{
+			Labels: `{app="foo", pod="b"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(3, 0), Line: `{"level":"error","n":3}`},
+				{Timestamp: time.Unix(5, 0), Line: `{"level":"error"}`},
+			},
+		},
+		{
+			Labels: `{app="bar", pod="c"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(3, 0), Line: `{"level":"error"}`},
+			},
+		},
+	}
+}
+
+func readEntries(t *testing.T, it iter.EntryIterator) []string {
+	defer it.Close()
+	var res []string
+	for it.Next() {
+		res = append(res, fmt.Sprintf("%s %d %s", it.Labels(), it.Entry().Timestamp.Unix(), it.Entry().Line))
+	}
+	require.NoError(t, it.Error())
+	return res
+}
+
+func TestFederation_SelectLogs(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "test")
+	srv, requests := newFakeRemoteCluster(t, remoteTestStreams())
+	// Both remotes are the same cluster, their entries are deduplicated.
+	f := newTestFederation(t, 3, srv.URL, srv.URL+"/")
+
+	selectLogs := func(selector string, direction logproto.Direction, limit uint32) []string {
+		iters, err := f.selectLogs(ctx, logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+			Selector:  selector,
+			Start:     time.Unix(0, 0),
+			End:       time.Unix(10, 0),
+			Direction: direction,
+			Limit:     limit,
+		}})
+		require.NoError(t, err)
+		require.Len(t, iters, 2)
+		return readEntries(t, iter.NewHeapIterator(ctx, iters, direction))
+	}
+
+	// The pipeline is applied locally on the entries fetched in batches.
+	requests.Store(0)
+	require.Equal(t, []string{
+		`{app="foo", level="error", pod="a"} 2 {"level":"error"}`,
+		`{app="foo", level="error", n="1", pod="a"} 3 {"level":"error","n":1}`,
+		`{app="foo", level="error", n="3", pod="b"} 3 {"level":"error","n":3}`,
+		`{app="foo", level="error", pod="b"} 5 {"level":"error"}`,
+	}, selectLogs(`{app="foo"} |= "level" | json | level="error"`, logproto.FORWARD, 1))
+	require.Greater(t, requests.Load(), int64(2))
+
+	// The limit is applied by the remotes when they apply the whole pipeline.
+	requests.Store(0)
+	require.ElementsMatch(t, []string{
+		`{app="foo", pod="b"} 5 {"level":"error"}`,
+		`{app="foo", pod="a"} 3 {"level":"error","n":1}`,
+		`{app="foo", pod="b"} 3 {"level":"error","n":3}`,
+	}, selectLogs(`{app="foo"} |= "error"`, logproto.BACKWARD, 3))
+	require.Equal(t, int64(2), requests.Load())
+
+	// All the entries are fetched when paging backward.
+	require.Len(t, selectLogs(`{app="foo"}`, logproto.BACKWARD, 100), 6)
+}
+
+func TestFederation_SelectLogsTooManyEntriesAtTimestamp(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "test")
+	srv, _ := newFakeRemoteCluster(t, remoteTestStreams())
+	f := newTestFederation(t, 2, srv.URL)
+
+	_, err := f.selectLogs(ctx, logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  `{app=~"foo|bar"} | json`,
+		Start:     time.Unix(3, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+		Limit:     100,
+	}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "remote-0")
+}
+
+func TestFederation_SelectLogsLimits(t *testing.T) {
+	srv, _ := newFakeRemoteCluster(t, remoteTestStreams())
+	f := newTestFederation(t, 3, srv.URL, srv.URL)
+	params := logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  `{app="foo"} | json`,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+		Limit:     100,
+	}}
+
+	// The 6 entries of each remote are fetched.
+	ctx := user.InjectOrgID(context.Background(), "test")
+	f.cfg.MaxFetchedEntries = 12
+	_, err := f.selectLogs(ctx, params)
+	require.NoError(t, err)
+
+	f.cfg.MaxFetchedEntries = 11
+	_, err = f.selectLogs(ctx, params)
+	require.Error(t, err)
+	require.IsType(t, &logqlmodel.LimitError{}, errors.Cause(err))
+
+	f.cfg.MaxFetchedEntries = 0
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 10, 0))
+	_, err = f.selectLogs(ctx, params)
+	require.Error(t, err)
+	require.IsType(t, &logqlmodel.LimitError{}, errors.Cause(err))
+}
+
+func TestFederation_SelectSamples(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "test")
+	srv, _ := newFakeRemoteCluster(t, remoteTestStreams())
+	f := newTestFederation(t, 3, srv.URL)
+
+	selectSamples := func(shards []string) map[string]int {
+		iters, err := f.selectSamples(ctx, logql.SelectSampleParams{SampleQueryRequest: &logproto.SampleQueryRequest{
+			Selector: `count_over_time({app="foo"} | json | level="error" [1m])`,
+			Start:    time.Unix(0, 0),
+			End:      time.Unix(10, 0),
+			Shards:   shards,
+		}})
+		require.NoError(t, err)
+		it := iter.NewHeapSampleIterator(ctx, iters)
+		defer it.Close()
+		counts := map[string]int{}
+		for it.Next() {
+			counts[it.Labels()]++
+		}
+		require.NoError(t, it.Error())
+		return counts
+	}
+
+	all := selectSamples(nil)
+	require.Equal(t, map[string]int{
+		`{app="foo", level="error", pod="a"}`:        1,
+		`{app="foo", level="error", n="1", pod="a"}`: 1,
+		`{app="foo", level="error", n="3", pod="b"}`: 1,
+		`{app="foo", level="error", pod="b"}`:        1,
+	}, all)
+
+	// The streams are split between the shards like the local ones.
+	sharded := selectSamples([]string{"0_of_2"})
+	for k, v := range selectSamples([]string{"1_of_2"}) {
+		require.NotContains(t, sharded, k)
+		sharded[k] = v
+	}
+	require.Equal(t, all, sharded)
+}
+
+func TestFederation_SelectRangeAggregation(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "test")
+	srv, _ := newFakeRemoteCluster(t, remoteTestStreams())
+	f := newTestFederation(t, 3, srv.URL)
+
+	for _, tc := range []struct {
+		query      string
+		aggregates bool
+	}{
+		{`count_over_time({app="foo"} |= "error" [10s])`, true},
+		{`bytes_rate({app="foo"}[10s])`, true},
+		{`count_over_time({app="foo"} | json [10s])`, false},
+		{`sum_over_time({app="foo"} | json | unwrap n [10s])`, false},
+		{`max_over_time({app="foo"} | json | unwrap n [10s]) by (pod)`, false},
+	} {
+		expr, err := logql.ParseSampleExpr(tc.query)
+		require.NoError(t, err)
+		require.Equal(t, tc.aggregates, f.aggregates(expr.(*logql.RangeAggregationExpr)), tc.query)
+	}
+
+	expr, err := logql.ParseSampleExpr(`count_over_time({app="foo"} |= "error" [10s])`)
+	require.NoError(t, err)
+	selectRangeAggregation := func(shards []string) map[string][]promql.Point {
+		params := logql.NewLiteralParams(expr.String(), time.Unix(10, 0), time.Unix(10, 0), 0, 0, logproto.FORWARD, 0, shards)
+		m, err := f.selectRangeAggregation(ctx, expr.(*logql.RangeAggregationExpr), params)
+		require.NoError(t, err)
+		res := map[string][]promql.Point{}
+		for _, s := range m {
+			res[s.Metric.String()] = s.Points
+		}
+		return res
+	}
+
+	all := selectRangeAggregation(nil)
+	require.Equal(t, map[string][]promql.Point{
+		`{app="foo", pod="a"}`: {{T: 10000, V: 2}},
+		`{app="foo", pod="b"}`: {{T: 10000, V: 2}},
+	}, all)
+
+	// The series are split between the shards like the local ones.
+	sharded := selectRangeAggregation([]string{"0_of_2"})
+	for k, v := range selectRangeAggregation([]string{"1_of_2"}) {
+		require.NotContains(t, sharded, k)
+		sharded[k] = v
+	}
+	require.Equal(t, all, sharded)
+}
+
+func TestFederation_Label(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "test")
+	srv, _ := newFakeRemoteCluster(t, nil)
+	f := newTestFederation(t, 10, srv.URL)
+
+	start, end := time.Unix(0, 0), time.Unix(10, 0)
+	values, err := f.label(ctx, &logproto.LabelRequest{Start: &start, End: &end})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"app", "test"}}, values)
+
+	_, err = f.label(ctx, &logproto.LabelRequest{Name: "app", Values: true, Start: &start, End: &end})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "status 404")
+}
+
+func TestRemoteSelector(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+		whole    bool
+	}{
+		{`{app="foo"}`, `{app="foo"}`, true},
+		{`{app="foo"} |= "a" != "b"`, `{app="foo"} |= "a" != "b"`, true},
+		{`{app="foo"} | json`, `{app="foo"}`, false},
+		{`{app="foo"} |= "a" | logfmt |= "b"`, `{app="foo"} |= "a"`, false},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := logql.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			selector, whole := remoteSelector(expr)
+			require.Equal(t, tc.expected, selector)
+			require.Equal(t, tc.whole, whole)
+		})
+	}
+}
+
+func TestFederationConfig_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg   FederationConfig
+		valid bool
+	}{
+		"disabled": {FederationConfig{}, true},
+		"valid":    {FederationConfig{BatchSize: 10, Remotes: []RemoteClusterConfig{{Name: "a", URL: "http://a"}, {Name: "b", URL: "http://b"}}}, true},
+		"no name":  {FederationConfig{BatchSize: 10, Remotes: []RemoteClusterConfig{{URL: "http://a"}}}, false},
+		"no url":   {FederationConfig{BatchSize: 10, Remotes: []RemoteClusterConfig{{Name: "a"}}}, false},
+		"duplicate name": {FederationConfig{BatchSize: 10, Remotes: []RemoteClusterConfig{
+			{Name: "a", URL: "http://a"}, {Name: "a", URL: "http://b"},
+		}}, false},
+		"no batch size":                {FederationConfig{Remotes: []RemoteClusterConfig{{Name: "a", URL: "http://a"}}}, false},
+		"negative max fetched entries": {FederationConfig{BatchSize: 10, MaxFetchedEntries: -1}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package querier
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	cortex_validation "github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/websocket"
 	"github.com/prometheus/prometheus/model/labels"
@@ -17,7 +21,9 @@ import (
 	"github.com/grafana/loki/pkg/loghttp"
 	loghttp_legacy "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/util/httpreq"
 	"github.com/grafana/loki/pkg/util/marshal"
 	marshal_legacy "github.com/grafana/loki/pkg/util/marshal/legacy"
 	serverutil "github.com/grafana/loki/pkg/util/server"
@@ -34,8 +40,8 @@ type QueryResponse struct {
 
 // RangeQueryHandler is a http.HandlerFunc for range queries.
 func (q *Querier) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
-	// Enforce the query timeout while querying backends
-	ctx, cancel := context.WithDeadline(r.Context(), time.Now().Add(q.cfg.QueryTimeout))
+	// Enforce the query timeout and the per-query limits while querying backends
+	ctx, cancel, maxExecutionTime := q.queryContext(r.Context())
 	defer cancel()
 
 	request, err := loghttp.ParseRangeQuery(r)
@@ -62,10 +68,12 @@ func (q *Querier) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
 	query := q.engine.Query(params)
 	result, err := query.Exec(ctx)
 	if err != nil {
-		serverutil.WriteError(err, w)
+		serverutil.WriteError(queryExecutionError(ctx, err, maxExecutionTime), w)
 		return
 	}
-	if err := marshal.WriteQueryResponseJSON(result, w); err != nil {
+	q.recordParsedLabels(ctx, request.Query)
+	q.recordUsage(ctx, result.Statistics)
+	if err := writeQueryResponse(r, w, result); err != nil {
 		serverutil.WriteError(err, w)
 		return
 	}
@@ -73,8 +81,8 @@ func (q *Querier) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
 
 // InstantQueryHandler is a http.HandlerFunc for instant queries.
 func (q *Querier) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
-	// Enforce the query timeout while querying backends
-	ctx, cancel := context.WithDeadline(r.Context(), time.Now().Add(q.cfg.QueryTimeout))
+	// Enforce the query timeout and the per-query limits while querying backends
+	ctx, cancel, maxExecutionTime := q.queryContext(r.Context())
 	defer cancel()
 
 	request, err := loghttp.ParseInstantQuery(r)
@@ -101,11 +109,13 @@ func (q *Querier) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
 	query := q.engine.Query(params)
 	result, err := query.Exec(ctx)
 	if err != nil {
-		serverutil.WriteError(err, w)
+		serverutil.WriteError(queryExecutionError(ctx, err, maxExecutionTime), w)
 		return
 	}
+	q.recordParsedLabels(ctx, request.Query)
+	q.recordUsage(ctx, result.Statistics)
 
-	if err := marshal.WriteQueryResponseJSON(result, w); err != nil {
+	if err := writeQueryResponse(r, w, result); err != nil {
 		serverutil.WriteError(err, w)
 		return
 	}
@@ -113,8 +123,8 @@ func (q *Querier) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
 
 // LogQueryHandler is a http.HandlerFunc for log only queries.
 func (q *Querier) LogQueryHandler(w http.ResponseWriter, r *http.Request) {
-	// Enforce the query timeout while querying backends
-	ctx, cancel := context.WithDeadline(r.Context(), time.Now().Add(q.cfg.QueryTimeout))
+	// Enforce the query timeout and the per-query limits while querying backends
+	ctx, cancel, maxExecutionTime := q.queryContext(r.Context())
 	defer cancel()
 
 	request, err := loghttp.ParseRangeQuery(r)
@@ -159,9 +169,10 @@ func (q *Querier) LogQueryHandler(w http.ResponseWriter, r *http.Request) {
 
 	result, err := query.Exec(ctx)
 	if err != nil {
-		serverutil.WriteError(err, w)
+		serverutil.WriteError(queryExecutionError(ctx, err, maxExecutionTime), w)
 		return
 	}
+	q.recordUsage(ctx, result.Statistics)
 
 	if err := marshal_legacy.WriteQueryResponseJSON(result, w); err != nil {
 		serverutil.WriteError(err, w)
@@ -197,7 +208,8 @@ func (q *Querier) LabelHandler(w http.ResponseWriter, r *http.Request) {
 // TailHandler is a http.HandlerFunc for handling tail queries.
 func (q *Querier) TailHandler(w http.ResponseWriter, r *http.Request) {
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin:       func(r *http.Request) bool { return true },
+		EnableCompression: q.cfg.TailCompression,
 	}
 	logger := util_log.WithContext(r.Context(), util_log.Logger)
 
@@ -213,6 +225,11 @@ func (q *Querier) TailHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateTailQuery(req.Query, q.lookupTables); err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		level.Error(logger).Log("msg", "Error in upgrading websocket", "err", err)
@@ -279,24 +296,62 @@ func (q *Querier) TailHandler(w http.ResponseWriter, r *http.Request) {
 		doneChan <- struct{}{}
 	}()
 
+	writeResponse := func(response *loghttp_legacy.TailResponse) error {
+		var err error
+		if loghttp.GetVersion(r.RequestURI) == loghttp.VersionV1 {
+			err = marshal.WriteTailResponseJSON(*response, conn)
+		} else {
+			err = marshal_legacy.WriteTailResponseJSON(*response, conn)
+		}
+		if err != nil {
+			level.Error(logger).Log("msg", "Error writing to websocket", "err", err)
+			if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error())); err != nil {
+				level.Error(logger).Log("msg", "Error writing close message to websocket", "err", err)
+			}
+		}
+		return err
+	}
+
+	// When a flush interval is configured, responses are batched and sent to the client
+	// once the interval elapses or the batch gets too big.
+	var flushChan <-chan time.Time
+	batch := newTailResponseBatch()
+	if q.cfg.TailFlushInterval > 0 {
+		flushTicker := time.NewTicker(q.cfg.TailFlushInterval)
+		defer flushTicker.Stop()
+		flushChan = flushTicker.C
+	}
+
 	for {
 		select {
 		case response = <-responseChan:
-			var err error
-			if loghttp.GetVersion(r.RequestURI) == loghttp.VersionV1 {
-				err = marshal.WriteTailResponseJSON(*response, conn)
-			} else {
-				err = marshal_legacy.WriteTailResponseJSON(*response, conn)
+			if flushChan == nil {
+				if err := writeResponse(response); err != nil {
+					return
+				}
+				continue
 			}
-			if err != nil {
-				level.Error(logger).Log("msg", "Error writing to websocket", "err", err)
-				if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error())); err != nil {
-					level.Error(logger).Log("msg", "Error writing close message to websocket", "err", err)
+
+			batch.add(response)
+			if q.cfg.TailMaxBatchEntries > 0 && batch.size() >= q.cfg.TailMaxBatchEntries {
+				if err := writeResponse(batch.flush()); err != nil {
+					return
+				}
+			}
+
+		case <-flushChan:
+			if !batch.empty() {
+				if err := writeResponse(batch.flush()); err != nil {
+					return
 				}
-				return
 			}
 
 		case err := <-closeErrChan:
+			if !batch.empty() {
+				if err := writeResponse(batch.flush()); err != nil {
+					return
+				}
+			}
 			level.Error(logger).Log("msg", "Error from iterator", "err", err)
 			if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error())); err != nil {
 				level.Error(logger).Log("msg", "Error writing close message to websocket", "err", err)
@@ -339,6 +394,70 @@ func (q *Querier) SeriesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ExplainHandler is a http.HandlerFunc returning the AST, the execution plan and the estimated cost of a query.
+func (q *Querier) ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithDeadline(r.Context(), time.Now().Add(q.cfg.QueryTimeout))
+	defer cancel()
+
+	request, err := loghttp.ParseRangeQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	explanation, err := q.Explain(ctx, request.Query, request.Start, request.End)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteExplainResponseJSON(*explanation, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
+// IndexStatsHandler is a http.HandlerFunc returning the index stats of the streams matching a selector.
+func (q *Querier) IndexStatsHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseIndexStatsQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	stats, err := q.IndexStats(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteIndexStatsResponseJSON(*stats, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
+// MetadataHandler is a http.HandlerFunc returning the metadata of the stream labels and of the labels
+// extracted by parsers in the recent queries of the tenant.
+func (q *Querier) MetadataHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseMetadataQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	metadata, err := q.Metadata(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteMetadataResponseJSON(metadata, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
 // parseRegexQuery parses regex and query querystring from httpRequest and returns the combined LogQL query.
 // This is used only to keep regexp query string support until it gets fully deprecated.
 func parseRegexQuery(httpRequest *http.Request) (string, error) {
@@ -358,8 +477,66 @@ func parseRegexQuery(httpRequest *http.Request) (string, error) {
 	return query, nil
 }
 
+// writeQueryResponse writes the result of a v1 query. Results of log queries are streamed
+// as newline delimited JSON when the client asks for it with the Accept header.
+func writeQueryResponse(r *http.Request, w http.ResponseWriter, result logqlmodel.Result) error {
+	if httpreq.AcceptsNDJSON(r) && result.Data.Type() == logqlmodel.ValueTypeStreams {
+		w.Header().Set("Content-Type", httpreq.NDJSONContentType)
+		return marshal.WriteQueryResponseNDJSON(result, w)
+	}
+	return marshal.WriteQueryResponseJSON(result, w)
+}
+
+// validateTailQuery makes sure the tail query is a log query whose pipeline can be built.
+// The pipeline is evaluated by the ingesters, validating it upfront rejects invalid parsers,
+// label filters or formatters before upgrading the connection.
+func validateTailQuery(query string, lookupTables log.LookupTables) error {
+	expr, err := logql.ParseLogSelector(query, true)
+	if err != nil {
+		return err
+	}
+	logql.BindLookupTables(expr, lookupTables)
+	_, err = expr.Pipeline()
+	return err
+}
+
+// queryContext returns the context a query is executed with. It is cancelled after the query timeout or
+// the tenant max execution time if lower, which is returned when it applies, and carries a query limiter
+// enforcing the tenant limits on the chunks fetched from the store.
+func (q *Querier) queryContext(ctx context.Context) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := q.cfg.QueryTimeout
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		// the request is rejected later on when validating the query limits.
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		return ctx, cancel, 0
+	}
+
+	var maxExecutionTime time.Duration
+	if limit := cortex_validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, q.limits.MaxQueryExecutionTime); limit > 0 && (timeout <= 0 || limit < timeout) {
+		maxExecutionTime = limit
+		timeout = limit
+	}
+
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(
+		0,
+		cortex_validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, q.limits.MaxFetchedChunkBytesPerQuery),
+		cortex_validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, q.limits.MaxChunksPerQuery),
+	))
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, maxExecutionTime
+}
+
+// queryExecutionError turns the error of a query cancelled by the tenant max execution time into a limit error.
+func queryExecutionError(ctx context.Context, err error, maxExecutionTime time.Duration) error {
+	if maxExecutionTime > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return logqlmodel.NewQueryLimitError(fmt.Errorf("the query hit the max execution time limit (limit: %s)", maxExecutionTime))
+	}
+	return err
+}
+
 func (q *Querier) validateEntriesLimits(ctx context.Context, query string, limit uint32) error {
-	userID, err := tenant.TenantID(ctx)
+	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
 		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 	}
@@ -374,7 +551,7 @@ func (q *Querier) validateEntriesLimits(ctx context.Context, query string, limit
 		return nil
 	}
 
-	maxEntriesLimit := q.limits.MaxEntriesLimitPerQuery(userID)
+	maxEntriesLimit := cortex_validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, q.limits.MaxEntriesLimitPerQuery)
 	if int(limit) > maxEntriesLimit && maxEntriesLimit != 0 {
 		return httpgrpc.Errorf(http.StatusBadRequest,
 			"max entries limit per query exceeded, limit > max_entries_limit (%d > %d)", limit, maxEntriesLimit)
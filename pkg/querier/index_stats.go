@@ -0,0 +1,153 @@
+package querier
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+// IndexStats returns the number of streams, chunks, bytes and entries of the streams matching the selector,
+// in total and for each label value. Only the index is read: the ingesters know the size of the chunks they
+// hold, but the bytes and entries of the chunks referenced by the store index are estimated from the average
+// chunk of the ingesters, and are 0 when the ingesters hold no chunk for the selector.
+func (q *Querier) IndexStats(ctx context.Context, req *logproto.IndexStatsRequest) (*loghttp.IndexStatsResponseData, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matchers, err := logql.ParseMatchers(req.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Start, req.End, err = validateQueryTimeRangeLimits(ctx, userID, q.limits, req.Start, req.End); err != nil {
+		return nil, err
+	}
+
+	// Enforce the query timeout while querying backends
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
+	defer cancel()
+
+	var (
+		ingesterStats []*logproto.IndexStatsResponse
+		storeSeries   []chunk.SeriesChunkRefs
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	if !q.cfg.QueryStoreOnly {
+		g.Go(func() error {
+			var err error
+			ingesterStats, err = q.ingesterQuerier.IndexStats(gctx, req)
+			return err
+		})
+	}
+	g.Go(func() error {
+		nameLabelMatcher, err := labels.NewMatcher(labels.MatchEqual, labels.MetricName, "logs")
+		if err != nil {
+			return err
+		}
+		from, through := model.TimeFromUnixNano(req.Start.UnixNano()), model.TimeFromUnixNano(req.End.UnixNano())
+		storeSeries, err = q.store.GetSeriesChunkRefs(gctx, userID, from, through, append([]*labels.Matcher{nameLabelMatcher}, matchers...)...)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return mergeIndexStats(ingesterStats, storeSeries)
+}
+
+// mergeIndexStats merges the streams of the ingesters and the store and aggregates them.
+func mergeIndexStats(ingesterStats []*logproto.IndexStatsResponse, storeSeries []chunk.SeriesChunkRefs) (*loghttp.IndexStatsResponseData, error) {
+	streams := map[string]*loghttp.IndexStats{}
+
+	// streams are replicated across ingesters, keep the replica holding the most entries.
+	var ingesterChunks, ingesterBytes, ingesterEntries uint64
+	for _, resp := range ingesterStats {
+		for _, s := range resp.Streams {
+			if existing, ok := streams[s.Labels]; ok && existing.Entries >= s.Entries {
+				continue
+			}
+			streams[s.Labels] = &loghttp.IndexStats{Streams: 1, Chunks: s.Chunks, Bytes: s.Bytes, Entries: s.Entries}
+		}
+	}
+	for _, s := range streams {
+		ingesterChunks += s.Chunks
+		ingesterBytes += s.Bytes
+		ingesterEntries += s.Entries
+	}
+
+	for _, series := range storeSeries {
+		key := series.Labels.String()
+		s, ok := streams[key]
+		if !ok {
+			s = &loghttp.IndexStats{Streams: 1}
+			streams[key] = s
+		}
+		chunks := uint64(len(series.Chunks))
+		s.Chunks += chunks
+		if ingesterChunks > 0 {
+			s.Bytes += chunks * ingesterBytes / ingesterChunks
+			s.Entries += chunks * ingesterEntries / ingesterChunks
+		}
+	}
+
+	var (
+		result   = &loghttp.IndexStatsResponseData{Labels: []loghttp.LabelIndexStats{}}
+		byLabels = map[string]map[string]*loghttp.IndexStats{}
+	)
+	for key, s := range streams {
+		ls, err := logql.ParseLabels(key)
+		if err != nil {
+			return nil, err
+		}
+		addIndexStats(&result.IndexStats, *s)
+		for _, l := range ls {
+			values, ok := byLabels[l.Name]
+			if !ok {
+				values = map[string]*loghttp.IndexStats{}
+				byLabels[l.Name] = values
+			}
+			v, ok := values[l.Value]
+			if !ok {
+				v = &loghttp.IndexStats{}
+				values[l.Value] = v
+			}
+			addIndexStats(v, *s)
+		}
+	}
+
+	for name, values := range byLabels {
+		stats := loghttp.LabelIndexStats{Name: name, Values: make([]loghttp.LabelValueIndexStats, 0, len(values))}
+		for value, s := range values {
+			stats.Values = append(stats.Values, loghttp.LabelValueIndexStats{Value: value, IndexStats: *s})
+		}
+		sort.Slice(stats.Values, func(i, j int) bool {
+			if stats.Values[i].Streams != stats.Values[j].Streams {
+				return stats.Values[i].Streams > stats.Values[j].Streams
+			}
+			return stats.Values[i].Value < stats.Values[j].Value
+		})
+		result.Labels = append(result.Labels, stats)
+	}
+	sort.Slice(result.Labels, func(i, j int) bool { return result.Labels[i].Name < result.Labels[j].Name })
+
+	return result, nil
+}
+
+func addIndexStats(dst *loghttp.IndexStats, s loghttp.IndexStats) {
+	dst.Streams += s.Streams
+	dst.Chunks += s.Chunks
+	dst.Bytes += s.Bytes
+	dst.Entries += s.Entries
+}
@@ -0,0 +1,79 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestQuerier_IndexStats(t *testing.T) {
+	var (
+		start = time.Unix(0, 0).Add(24 * time.Hour)
+		end   = start.Add(3 * time.Hour)
+	)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("GetIndexStats", mock.Anything, mock.Anything, mock.Anything).Return(&logproto.IndexStatsResponse{
+		Streams: []logproto.StreamIndexStats{
+			{Labels: `{app="foo", env="prod"}`, Chunks: 2, Bytes: 200, Entries: 20},
+		},
+	}, nil)
+
+	store := newStoreMock()
+	store.On("GetSeriesChunkRefs", mock.Anything, "test", mock.Anything, mock.Anything, mock.Anything).Return([]chunk.SeriesChunkRefs{
+		{Labels: labels.Labels{{Name: "app", Value: "foo"}, {Name: "env", Value: "prod"}}, Chunks: []chunk.Chunk{{}}},
+		{Labels: labels.Labels{{Name: "app", Value: "bar"}, {Name: "env", Value: "prod"}}, Chunks: []chunk.Chunk{{}, {}, {}}},
+	}, nil)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	q, err := newQuerier(mockQuerierConfig(), mockIngesterClientConfig(), newIngesterClientMockFactory(ingesterClient), mockReadRingWithOneActiveIngester(), store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	res, err := q.IndexStats(ctx, &logproto.IndexStatsRequest{Matchers: `{env="prod"}`, Start: start, End: end})
+	require.NoError(t, err)
+	store.AssertExpectations(t)
+
+	// store chunks are estimated from the average ingester chunk: 100 bytes and 10 entries.
+	require.Equal(t, loghttp.IndexStats{Streams: 2, Chunks: 6, Bytes: 600, Entries: 60}, res.IndexStats)
+	require.Equal(t, []loghttp.LabelIndexStats{
+		{Name: "app", Values: []loghttp.LabelValueIndexStats{
+			{Value: "bar", IndexStats: loghttp.IndexStats{Streams: 1, Chunks: 3, Bytes: 300, Entries: 30}},
+			{Value: "foo", IndexStats: loghttp.IndexStats{Streams: 1, Chunks: 3, Bytes: 300, Entries: 30}},
+		}},
+		{Name: "env", Values: []loghttp.LabelValueIndexStats{
+			{Value: "prod", IndexStats: loghttp.IndexStats{Streams: 2, Chunks: 6, Bytes: 600, Entries: 60}},
+		}},
+	}, res.Labels)
+
+	_, err = q.IndexStats(ctx, &logproto.IndexStatsRequest{Matchers: `{env="prod"`, Start: start, End: end})
+	require.Error(t, err)
+}
+
+func Test_mergeIndexStats_ReplicatedStreams(t *testing.T) {
+	res, err := mergeIndexStats([]*logproto.IndexStatsResponse{
+		{Streams: []logproto.StreamIndexStats{{Labels: `{app="foo"}`, Chunks: 1, Bytes: 10, Entries: 1}}},
+		{Streams: []logproto.StreamIndexStats{{Labels: `{app="foo"}`, Chunks: 1, Bytes: 20, Entries: 2}}},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, loghttp.IndexStats{Streams: 1, Chunks: 1, Bytes: 20, Entries: 2}, res.IndexStats)
+
+	// without ingester chunks, store chunks can't be estimated.
+	res, err = mergeIndexStats(nil, []chunk.SeriesChunkRefs{
+		{Labels: labels.Labels{{Name: "app", Value: "foo"}}, Chunks: []chunk.Chunk{{}, {}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, loghttp.IndexStats{Streams: 1, Chunks: 2}, res.IndexStats)
+}
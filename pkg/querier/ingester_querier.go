@@ -4,10 +4,12 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	cortex_distributor "github.com/cortexproject/cortex/pkg/distributor"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/services"
@@ -15,6 +17,8 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/iter"
@@ -74,7 +78,15 @@ func (q *IngesterQuerier) forAllIngesters(ctx context.Context, f func(logproto.Q
 // forGivenIngesters runs f, in parallel, for given ingesters
 // TODO taken from Cortex, see if we can refactor out an usable interface.
 func (q *IngesterQuerier) forGivenIngesters(ctx context.Context, replicationSet ring.ReplicationSet, f func(logproto.QuerierClient) (interface{}, error)) ([]responseFromIngesters, error) {
-	results, err := replicationSet.Do(ctx, q.extraQueryDelay, func(ctx context.Context, ingester *ring.InstanceDesc) (interface{}, error) {
+	do := replicationSet.Do
+	// With zone-aware replication, the extra query delay applies to the zones not required for the query to succeed.
+	if q.extraQueryDelay > 0 && replicationSet.MaxUnavailableZones > 0 {
+		do = func(ctx context.Context, delay time.Duration, f func(context.Context, *ring.InstanceDesc) (interface{}, error)) ([]interface{}, error) {
+			return doMinimizingZones(ctx, replicationSet, delay, f)
+		}
+	}
+
+	results, err := do(ctx, q.extraQueryDelay, func(ctx context.Context, ingester *ring.InstanceDesc) (interface{}, error) {
 		client, err := q.pool.GetClientFor(ingester.Addr)
 		if err != nil {
 			return nil, err
@@ -131,6 +143,113 @@ func (q *IngesterQuerier) SelectSample(ctx context.Context, params logql.SelectS
 	return iterators, nil
 }
 
+// SelectLogsRecent queries the ingesters only for the data not available in the store, identified by the chunks returned by the store.
+// The ingesters without the RecentData service, e.g. the ones of an older version during a rolling update, are queried
+// for all their data, which the iterators dedupe with the store's.
+func (q *IngesterQuerier) SelectLogsRecent(ctx context.Context, params logql.SelectLogParams, storeChunks []logproto.StoreChunk) ([]iter.EntryIterator, error) {
+	resps, err := q.forAllIngesters(ctx, func(client logproto.QuerierClient) (interface{}, error) {
+		stats.FromContext(ctx).AddIngesterReached(1)
+		if recentClient, ok := client.(logproto.RecentDataClient); ok {
+			stream, err := recentClient.QueryRecent(ctx, &logproto.RecentQueryRequest{
+				Request:     params.QueryRequest,
+				StoreChunks: storeChunks,
+			})
+			if err != nil && !isUnimplemented(err) {
+				return nil, err
+			}
+			if err == nil {
+				// The status of the stream is only known with its first response.
+				peeked := &peekedQueryClient{Querier_QueryClient: stream}
+				peeked.resp, peeked.err = stream.Recv()
+				if !isUnimplemented(peeked.err) {
+					return peeked, nil
+				}
+			}
+		}
+		return client.Query(ctx, params.QueryRequest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	iterators := make([]iter.EntryIterator, len(resps))
+	for i := range resps {
+		iterators[i] = iter.NewQueryClientIterator(resps[i].response.(logproto.Querier_QueryClient), params.Direction)
+	}
+	return iterators, nil
+}
+
+// SelectSampleRecent is the SelectLogsRecent counterpart for sample queries.
+func (q *IngesterQuerier) SelectSampleRecent(ctx context.Context, params logql.SelectSampleParams, storeChunks []logproto.StoreChunk) ([]iter.SampleIterator, error) {
+	resps, err := q.forAllIngesters(ctx, func(client logproto.QuerierClient) (interface{}, error) {
+		stats.FromContext(ctx).AddIngesterReached(1)
+		if recentClient, ok := client.(logproto.RecentDataClient); ok {
+			stream, err := recentClient.QuerySampleRecent(ctx, &logproto.RecentSampleQueryRequest{
+				Request:     params.SampleQueryRequest,
+				StoreChunks: storeChunks,
+			})
+			if err != nil && !isUnimplemented(err) {
+				return nil, err
+			}
+			if err == nil {
+				// The status of the stream is only known with its first response.
+				peeked := &peekedQuerySampleClient{Querier_QuerySampleClient: stream}
+				peeked.resp, peeked.err = stream.Recv()
+				if !isUnimplemented(peeked.err) {
+					return peeked, nil
+				}
+			}
+		}
+		return client.QuerySample(ctx, params.SampleQueryRequest)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	iterators := make([]iter.SampleIterator, len(resps))
+	for i := range resps {
+		iterators[i] = iter.NewSampleQueryClientIterator(resps[i].response.(logproto.Querier_QuerySampleClient))
+	}
+	return iterators, nil
+}
+
+// isUnimplemented returns true if err is returned by an ingester not serving the called service.
+func isUnimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
+// peekedQueryClient returns the first response of the stream, received beforehand, then the rest of the stream.
+type peekedQueryClient struct {
+	logproto.Querier_QueryClient
+	resp   *logproto.QueryResponse
+	err    error
+	peeked bool
+}
+
+func (c *peekedQueryClient) Recv() (*logproto.QueryResponse, error) {
+	if !c.peeked {
+		c.peeked = true
+		return c.resp, c.err
+	}
+	return c.Querier_QueryClient.Recv()
+}
+
+// peekedQuerySampleClient is the peekedQueryClient counterpart for sample queries.
+type peekedQuerySampleClient struct {
+	logproto.Querier_QuerySampleClient
+	resp   *logproto.SampleQueryResponse
+	err    error
+	peeked bool
+}
+
+func (c *peekedQuerySampleClient) Recv() (*logproto.SampleQueryResponse, error) {
+	if !c.peeked {
+		c.peeked = true
+		return c.resp, c.err
+	}
+	return c.Querier_QuerySampleClient.Recv()
+}
+
 func (q *IngesterQuerier) Label(ctx context.Context, req *logproto.LabelRequest) ([][]string, error) {
 	resps, err := q.forAllIngesters(ctx, func(client logproto.QuerierClient) (interface{}, error) {
 		return client.Label(ctx, req)
@@ -196,9 +315,23 @@ func (q *IngesterQuerier) TailDisconnectedIngesters(ctx context.Context, req *lo
 		return nil, nil
 	}
 
-	// Instance a tail client for each ingester to re(connect)
+	// Instance a tail client for each ingester to re(connect). The ingesters failing, e.g. the ones of an unavailable
+	// zone, are tried again on the next reconnection while tailing the others.
+	var (
+		mtx      sync.Mutex
+		firstErr error
+	)
 	reconnectClients, err := q.forGivenIngesters(ctx, ring.ReplicationSet{Instances: reconnectIngesters}, func(client logproto.QuerierClient) (interface{}, error) {
-		return client.Tail(ctx, req)
+		tailClient, err := client.Tail(ctx, req)
+		if err != nil {
+			mtx.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mtx.Unlock()
+			return nil, nil
+		}
+		return tailClient, nil
 	})
 	if err != nil {
 		return nil, err
@@ -206,8 +339,15 @@ func (q *IngesterQuerier) TailDisconnectedIngesters(ctx context.Context, req *lo
 
 	reconnectClientsMap := make(map[string]logproto.Querier_TailClient)
 	for _, client := range reconnectClients {
+		if client.response == nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to reconnect to ingester", "addr", client.addr)
+			continue
+		}
 		reconnectClientsMap[client.addr] = client.response.(logproto.Querier_TailClient)
 	}
+	if len(reconnectClientsMap) == 0 {
+		return nil, firstErr
+	}
 
 	return reconnectClientsMap, nil
 }
@@ -227,8 +367,24 @@ func (q *IngesterQuerier) Series(ctx context.Context, req *logproto.SeriesReques
 	return acc, nil
 }
 
+func (q *IngesterQuerier) IndexStats(ctx context.Context, req *logproto.IndexStatsRequest) ([]*logproto.IndexStatsResponse, error) {
+	resps, err := q.forAllIngesters(ctx, func(client logproto.QuerierClient) (interface{}, error) {
+		return client.GetIndexStats(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	acc := make([]*logproto.IndexStatsResponse, 0, len(resps))
+	for _, resp := range resps {
+		acc = append(acc, resp.response.(*logproto.IndexStatsResponse))
+	}
+
+	return acc, nil
+}
+
 func (q *IngesterQuerier) TailersCount(ctx context.Context) ([]uint32, error) {
-	replicationSet, err := q.ring.GetAllHealthy(ring.Read)
+	// The read replication set tolerates the failures of the ingesters as the queries do, e.g. an unavailable zone.
+	replicationSet, err := q.ring.GetReplicationSetForOperation(ring.Read)
 	if err != nil {
 		return nil, err
 	}
@@ -244,6 +400,10 @@ func (q *IngesterQuerier) TailersCount(ctx context.Context) ([]uint32, error) {
 	if len(ingesters) == 0 {
 		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "no active ingester found")
 	}
+	replicationSet.Instances = ingesters
+	if replicationSet.MaxErrors >= len(ingesters) {
+		replicationSet.MaxErrors = len(ingesters) - 1
+	}
 
 	responses, err := q.forGivenIngesters(ctx, replicationSet, func(querierClient logproto.QuerierClient) (interface{}, error) {
 		resp, err := querierClient.TailersCount(ctx, &logproto.TailersCountRequest{})
@@ -258,7 +418,7 @@ func (q *IngesterQuerier) TailersCount(ctx context.Context) ([]uint32, error) {
 		return nil, err
 	}
 
-	counts := make([]uint32, len(responses))
+	counts := make([]uint32, 0, len(responses))
 
 	for _, resp := range responses {
 		counts = append(counts, resp.response.(uint32))
@@ -2,16 +2,23 @@ package querier
 
 import (
 	"context"
+	"errors"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/grafana/dskit/ring"
+	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
 
+	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 )
 
 func TestQuerier_tailDisconnectedIngesters(t *testing.T) {
@@ -104,6 +111,209 @@ func TestQuerier_tailDisconnectedIngesters(t *testing.T) {
 	}
 }
 
+func TestIngesterQuerier_ZoneAwareReplication(t *testing.T) {
+	t.Parallel()
+
+	// 3 zones of 2 ingesters, the replicas of a stream being in distinct zones.
+	var ingesters []ring.InstanceDesc
+	for _, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+		for _, n := range []string{"1", "2"} {
+			instance := mockInstanceDesc(zone+"-"+n, ring.ACTIVE)
+			instance.Zone = zone
+			ingesters = append(ingesters, instance)
+		}
+	}
+
+	tests := map[string]struct {
+		extraQueryDelay time.Duration
+		failingZones    []string
+		expectedCalls   int // Only checked when the queries of the other zones are delayed.
+		expectedErr     bool
+	}{
+		"all the zones are queried without extra query delay": {},
+		"a zone outage is tolerated without extra query delay": {
+			failingZones: []string{"zone-b"},
+		},
+		"the minimum number of zones is queried with extra query delay": {
+			extraQueryDelay: time.Hour,
+			expectedCalls:   4,
+		},
+		"a zone outage is tolerated with extra query delay": {
+			extraQueryDelay: time.Hour,
+			failingZones:    []string{"zone-b"},
+		},
+		"the outage of 2 zones fails the queries": {
+			extraQueryDelay: time.Hour,
+			failingZones:    []string{"zone-a", "zone-b"},
+			expectedErr:     true,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			clients := map[string]*querierClientMock{}
+			for _, ingester := range ingesters {
+				c := newQuerierClientMock()
+				for _, zone := range testData.failingZones {
+					if zone == ingester.Zone {
+						c.On("Label", mock.Anything, mock.Anything, mock.Anything).Return((*logproto.LabelResponse)(nil), errors.New("zone unavailable"))
+						c.On("TailersCount", mock.Anything, mock.Anything, mock.Anything).Return((*logproto.TailersCountResponse)(nil), errors.New("zone unavailable"))
+					}
+				}
+				c.On("Label", mock.Anything, mock.Anything, mock.Anything).Return(&logproto.LabelResponse{Values: []string{ingester.Addr}}, nil)
+				c.On("TailersCount", mock.Anything, mock.Anything, mock.Anything).Return(&logproto.TailersCountResponse{Count: 1}, nil)
+				clients[ingester.Addr] = c
+			}
+
+			readRing := newReadRingMock(ingesters)
+			readRing.replicationSet.MaxUnavailableZones = 1
+			ingesterQuerier, err := newIngesterQuerier(
+				mockIngesterClientConfig(),
+				readRing,
+				testData.extraQueryDelay,
+				func(addr string) (ring_client.PoolClient, error) { return clients[addr], nil },
+			)
+			require.NoError(t, err)
+
+			values, err := ingesterQuerier.Label(context.Background(), &logproto.LabelRequest{})
+			if testData.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			// The responses are the ones of the successful zones, each holding a replica of every stream.
+			require.GreaterOrEqual(t, len(values), 4)
+
+			if testData.expectedCalls > 0 {
+				var calls int
+				for _, c := range clients {
+					for _, call := range c.Calls {
+						if call.Method == "Label" {
+							calls++
+						}
+					}
+				}
+				require.Equal(t, testData.expectedCalls, calls)
+			}
+
+			counts, err := ingesterQuerier.TailersCount(context.Background())
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, len(counts), 4)
+			for _, count := range counts {
+				require.Equal(t, uint32(1), count)
+			}
+		})
+	}
+}
+
+// queryServer serves only the Querier service, like the ingesters of the versions without the RecentData service.
+type queryServer struct {
+	logproto.UnimplementedQuerierServer
+}
+
+func (s *queryServer) Query(_ *logproto.QueryRequest, server logproto.Querier_QueryServer) error {
+	return server.Send(mockQueryResponse([]logproto.Stream{mockStream(1, 2)}))
+}
+
+func (s *queryServer) QuerySample(_ *logproto.SampleQueryRequest, server logproto.Querier_QuerySampleServer) error {
+	return server.Send(&logproto.SampleQueryResponse{Series: []logproto.Series{{
+		Labels:  `{type="test"}`,
+		Samples: []logproto.Sample{{Timestamp: 1, Value: 1, Hash: 1}, {Timestamp: 2, Value: 1, Hash: 2}},
+	}}})
+}
+
+func TestIngesterQuerier_SelectRecentWithoutRecentDataService(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	logproto.RegisterQuerierServer(server, &queryServer{})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	clientCfg := mockIngesterClientConfig()
+	clientCfg.GRPCClientConfig.MaxRecvMsgSize = 1024 * 1024
+	clientCfg.GRPCClientConfig.MaxSendMsgSize = 1024 * 1024
+	ingesterQuerier, err := newIngesterQuerier(
+		clientCfg,
+		newReadRingMock([]ring.InstanceDesc{mockInstanceDesc(listener.Addr().String(), ring.ACTIVE)}),
+		mockQuerierConfig().ExtraQueryDelay,
+		func(addr string) (ring_client.PoolClient, error) {
+			return client.New(clientCfg, addr)
+		},
+	)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	storeChunks := []logproto.StoreChunk{{Fingerprint: 1, Checksum: 1}}
+
+	// The ingester answers the RecentData queries with codes.Unimplemented and is queried for all its data.
+	iterators, err := ingesterQuerier.SelectLogsRecent(ctx, logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  `{type="test"}`,
+		Limit:     10,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+	}}, storeChunks)
+	require.NoError(t, err)
+	require.Len(t, iterators, 1)
+
+	var entries int
+	for iterators[0].Next() {
+		entries++
+	}
+	require.NoError(t, iterators[0].Error())
+	require.Equal(t, 2, entries)
+
+	sampleIterators, err := ingesterQuerier.SelectSampleRecent(ctx, logql.SelectSampleParams{SampleQueryRequest: &logproto.SampleQueryRequest{
+		Selector: `count_over_time({type="test"}[1m])`,
+		Start:    time.Unix(0, 0),
+		End:      time.Unix(10, 0),
+	}}, storeChunks)
+	require.NoError(t, err)
+	require.Len(t, sampleIterators, 1)
+
+	var samples int
+	for sampleIterators[0].Next() {
+		samples++
+	}
+	require.NoError(t, sampleIterators[0].Error())
+	require.Equal(t, 2, samples)
+}
+
+func TestIngesterQuerier_tailDisconnectedIngestersFailing(t *testing.T) {
+	req := logproto.TailRequest{Query: "{type=\"test\"}", Limit: 10, Start: time.Now()}
+
+	failing := newQuerierClientMock()
+	failing.On("Tail", mock.Anything, &req, mock.Anything).Return((*tailClientMock)(nil), errors.New("ingester unavailable"))
+	healthy := newQuerierClientMock()
+	healthy.On("Tail", mock.Anything, &req, mock.Anything).Return(newTailClientMock(), nil)
+	clients := map[string]*querierClientMock{"1.1.1.1": failing, "2.2.2.2": healthy}
+
+	ingesterQuerier, err := newIngesterQuerier(
+		mockIngesterClientConfig(),
+		newReadRingMock([]ring.InstanceDesc{mockInstanceDesc("1.1.1.1", ring.ACTIVE), mockInstanceDesc("2.2.2.2", ring.ACTIVE)}),
+		0,
+		func(addr string) (ring_client.PoolClient, error) { return clients[addr], nil },
+	)
+	require.NoError(t, err)
+
+	// The failing ingester doesn't prevent tailing the other ones, it is retried on the next reconnection.
+	tailClients, err := ingesterQuerier.TailDisconnectedIngesters(context.Background(), &req, nil)
+	require.NoError(t, err)
+	require.Len(t, tailClients, 1)
+	require.Contains(t, tailClients, "2.2.2.2")
+
+	// An error is returned when no ingester could be reconnected.
+	_, err = ingesterQuerier.TailDisconnectedIngesters(context.Background(), &req, []string{"2.2.2.2"})
+	require.Error(t, err)
+}
+
 func TestConvertMatchersToString(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -0,0 +1,108 @@
+package querier
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/dskit/ring"
+)
+
+// doMinimizingZones runs f, in parallel, for the instances of a replication set with zone-aware replication. Unlike
+// ring.ReplicationSet.Do, which queries all the zones at once, it first only queries the minimum number of zones
+// required for the set to succeed, each of these zones holding a replica of every stream. The other zones are queried
+// once delay elapsed or as soon as one of the queried zones fails, so that a zone outage is tolerated with no read
+// failure.
+func doMinimizingZones(ctx context.Context, replicationSet ring.ReplicationSet, delay time.Duration, f func(context.Context, *ring.InstanceDesc) (interface{}, error)) ([]interface{}, error) {
+	instancesByZone := make(map[string][]*ring.InstanceDesc)
+	var zones []string
+	for i := range replicationSet.Instances {
+		zone := replicationSet.Instances[i].Zone
+		if _, ok := instancesByZone[zone]; !ok {
+			zones = append(zones, zone)
+		}
+		instancesByZone[zone] = append(instancesByZone[zone], &replicationSet.Instances[i])
+	}
+
+	minSuccessfulZones := len(zones) - replicationSet.MaxUnavailableZones
+	if minSuccessfulZones <= 0 || minSuccessfulZones >= len(zones) {
+		return replicationSet.Do(ctx, delay, f)
+	}
+
+	// Shuffle the zones to spread the queries over all the zones.
+	rand.Shuffle(len(zones), func(i, j int) { zones[i], zones[j] = zones[j], zones[i] })
+
+	type instanceResult struct {
+		res  interface{}
+		err  error
+		zone string
+	}
+
+	ch := make(chan instanceResult, len(replicationSet.Instances))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startZone := func(zone string) {
+		for _, instance := range instancesByZone[zone] {
+			go func(instance *ring.InstanceDesc) {
+				res, err := f(ctx, instance)
+				ch <- instanceResult{res: res, err: err, zone: zone}
+			}(instance)
+		}
+	}
+	for _, zone := range zones[:minSuccessfulZones] {
+		startZone(zone)
+	}
+	delayedZones := zones[minSuccessfulZones:]
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var (
+		pending         = make(map[string]int, len(zones))
+		failedZones     = make(map[string]struct{})
+		successfulZones int
+		results         = make([]interface{}, 0, len(replicationSet.Instances))
+	)
+	for zone, instances := range instancesByZone {
+		pending[zone] = len(instances)
+	}
+
+	for successfulZones < minSuccessfulZones {
+		select {
+		case res := <-ch:
+			if res.err != nil {
+				if _, ok := failedZones[res.zone]; ok {
+					continue
+				}
+				failedZones[res.zone] = struct{}{}
+				if len(failedZones) > replicationSet.MaxUnavailableZones {
+					return nil, res.err
+				}
+				// Replace the failed zone with one of the delayed ones.
+				if len(delayedZones) > 0 {
+					startZone(delayedZones[0])
+					delayedZones = delayedZones[1:]
+				}
+				continue
+			}
+
+			results = append(results, res.res)
+			pending[res.zone]--
+			if _, ok := failedZones[res.zone]; !ok && pending[res.zone] == 0 {
+				successfulZones++
+			}
+
+		case <-timer.C:
+			for _, zone := range delayedZones {
+				startZone(zone)
+			}
+			delayedZones = nil
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
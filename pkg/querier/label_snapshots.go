@@ -0,0 +1,240 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+	"golang.org/x/sync/errgroup"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+var labelSnapshotRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "loki",
+	Name:      "querier_label_snapshot_requests_total",
+	Help:      "Total number of label requests which could be served from the label snapshots, by whether a snapshot was fresh enough (hit) or had to be computed (miss).",
+}, []string{"result"})
+
+// LabelSnapshotsConfig configures the snapshots of the label names and values the label requests are served from.
+type LabelSnapshotsConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Lookback        time.Duration `yaml:"lookback"`
+	Resolution      time.Duration `yaml:"resolution"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	MaxStaleness    time.Duration `yaml:"max_staleness"`
+	MaxSnapshots    int           `yaml:"max_snapshots"`
+}
+
+// RegisterFlags registers the flags of the label snapshots.
+func (cfg *LabelSnapshotsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "querier.label-snapshots.enabled", false, "Serve the label names and values requests without a query from snapshots of the labels of each tenant over the lookback, refreshed in the background, instead of looking the labels up in the ingesters and the index for each request. The responses include the labels seen since the start of the request, up to the resolution of the snapshots, and may miss the labels of the streams created within the max staleness.")
+	f.DurationVar(&cfg.Lookback, "querier.label-snapshots.lookback", 6*time.Hour, "Period before now covered by the label snapshots. The requests starting before it are not served from the snapshots.")
+	f.DurationVar(&cfg.Resolution, "querier.label-snapshots.resolution", time.Hour, "Precision of the times the labels of the snapshots were last seen at, which the responses are filtered with. The labels of the lookback are looked up over windows of this duration when a snapshot is computed, the refreshes only look up the labels since the previous one.")
+	f.DurationVar(&cfg.RefreshInterval, "querier.label-snapshots.refresh-interval", time.Minute, "Age after which a label snapshot is refreshed in the background when a request uses it.")
+	f.DurationVar(&cfg.MaxStaleness, "querier.label-snapshots.max-staleness", 5*time.Minute, "Maximum age of the label snapshots the requests are served from. An older snapshot is computed again before responding.")
+	f.IntVar(&cfg.MaxSnapshots, "querier.label-snapshots.max-snapshots", 10000, "Maximum number of label snapshots kept, one for the label names and one for the values of each label requested per tenant. The least recently used are dropped.")
+}
+
+// Validate validates the label snapshots config.
+func (cfg *LabelSnapshotsConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Lookback <= 0 {
+		return errors.New("the label snapshots lookback must be positive")
+	}
+	if cfg.Resolution <= 0 || cfg.Resolution > cfg.Lookback {
+		return errors.New("the label snapshots resolution must be positive and not exceed their lookback")
+	}
+	if cfg.RefreshInterval <= 0 || cfg.RefreshInterval > cfg.MaxStaleness {
+		return errors.New("the label snapshots refresh interval must be positive and not exceed their max staleness")
+	}
+	if cfg.MaxSnapshots <= 0 {
+		return errors.New("the label snapshots max snapshots must be positive")
+	}
+	return nil
+}
+
+// labelSnapshots serves the label requests from snapshots of the label names and values of each tenant, turning the
+// lookups of the labels in the ingesters and the index into a map lookup for the frequent requests, e.g. the ones
+// of the autocompletion in Grafana.
+type labelSnapshots struct {
+	cfg LabelSnapshotsConfig
+	// compute looks the labels of a request up in the ingesters and the store.
+	compute func(ctx context.Context, userID string, req *logproto.LabelRequest) ([]string, error)
+	// timeout is the timeout of the refreshes in the background.
+	timeout time.Duration
+	now     func() time.Time
+
+	snapshots *lru.Cache
+}
+
+// labelSnapshot is the result of a label request over [from, at], with the time each value was last seen at.
+type labelSnapshot struct {
+	values   []string
+	lastSeen map[string]time.Time
+	from     time.Time
+	at       time.Time
+
+	mtx        sync.Mutex
+	refreshing bool
+}
+
+func newLabelSnapshots(cfg LabelSnapshotsConfig, timeout time.Duration, compute func(ctx context.Context, userID string, req *logproto.LabelRequest) ([]string, error)) (*labelSnapshots, error) {
+	snapshots, err := lru.New(cfg.MaxSnapshots)
+	if err != nil {
+		return nil, err
+	}
+	return &labelSnapshots{
+		cfg:       cfg,
+		compute:   compute,
+		timeout:   timeout,
+		now:       time.Now,
+		snapshots: snapshots,
+	}, nil
+}
+
+// get returns the labels of a request seen since its start from its snapshot, computing it if missing or too stale,
+// and false if the request can't be served from a snapshot: a request with a query, or which starts before the
+// lookback or ends before the max staleness.
+func (s *labelSnapshots) get(ctx context.Context, userID string, req *logproto.LabelRequest) ([]string, bool, error) {
+	now := s.now()
+	if req.Query != "" || req.Start.Before(now.Add(-s.cfg.Lookback)) || req.End.Before(now.Add(-s.cfg.MaxStaleness)) {
+		return nil, false, nil
+	}
+
+	key := snapshotKey(userID, req)
+	var previous *labelSnapshot
+	if v, ok := s.snapshots.Get(key); ok {
+		previous = v.(*labelSnapshot)
+		if age := now.Sub(previous.at); age <= s.cfg.MaxStaleness {
+			labelSnapshotRequests.WithLabelValues("hit").Inc()
+			if age > s.cfg.RefreshInterval {
+				s.refreshInBackground(key, userID, req, previous)
+			}
+			return previous.valuesSince(*req.Start), true, nil
+		}
+	}
+
+	labelSnapshotRequests.WithLabelValues("miss").Inc()
+	snapshot, err := s.refresh(ctx, key, userID, req, previous)
+	if err != nil {
+		return nil, false, err
+	}
+	return snapshot.valuesSince(*req.Start), true, nil
+}
+
+// refresh computes the snapshot of a request over the lookback. The labels are looked up over windows of the
+// resolution, the values found in a window are last seen at its end. Only the labels since the previous snapshot
+// are looked up if it is still within the lookback.
+func (s *labelSnapshots) refresh(ctx context.Context, key, userID string, req *logproto.LabelRequest, previous *labelSnapshot) (*labelSnapshot, error) {
+	at := s.now()
+	from := at.Add(-s.cfg.Lookback)
+
+	lastSeen := map[string]time.Time{}
+	start := from
+	if previous != nil && !previous.at.Before(from) {
+		for v, t := range previous.lastSeen {
+			if !t.Before(from) {
+				lastSeen[v] = t
+			}
+		}
+		start = previous.at
+	}
+
+	var windows [][2]time.Time
+	for ; start.Before(at); start = start.Add(s.cfg.Resolution) {
+		end := start.Add(s.cfg.Resolution)
+		if end.After(at) {
+			end = at
+		}
+		windows = append(windows, [2]time.Time{start, end})
+	}
+	results := make([][]string, len(windows))
+	g, ctx := errgroup.WithContext(ctx)
+	for i, w := range windows {
+		i, start, end := i, w[0], w[1]
+		g.Go(func() error {
+			values, err := s.compute(ctx, userID, &logproto.LabelRequest{
+				Name:   req.Name,
+				Values: req.Values,
+				Start:  &start,
+				End:    &end,
+			})
+			results[i] = values
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	for i, values := range results {
+		for _, v := range values {
+			lastSeen[v] = windows[i][1]
+		}
+	}
+
+	values := make([]string, 0, len(lastSeen))
+	for v := range lastSeen {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	snapshot := &labelSnapshot{values: values, lastSeen: lastSeen, from: from, at: at}
+	s.snapshots.Add(key, snapshot)
+	return snapshot, nil
+}
+
+// valuesSince returns the values of the snapshot last seen at or after start.
+func (s *labelSnapshot) valuesSince(start time.Time) []string {
+	if !start.After(s.from) {
+		return s.values
+	}
+	values := make([]string, 0, len(s.values))
+	for _, v := range s.values {
+		if !s.lastSeen[v].Before(start) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// refreshInBackground refreshes a snapshot unless it is already being refreshed.
+func (s *labelSnapshots) refreshInBackground(key, userID string, req *logproto.LabelRequest, snapshot *labelSnapshot) {
+	snapshot.mtx.Lock()
+	defer snapshot.mtx.Unlock()
+	if snapshot.refreshing {
+		return
+	}
+	snapshot.refreshing = true
+
+	req = &logproto.LabelRequest{Name: req.Name, Values: req.Values}
+	go func() {
+		ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), userID), s.timeout)
+		defer cancel()
+		if _, err := s.refresh(ctx, key, userID, req, snapshot); err != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to refresh label snapshot", "user", userID, "label", req.Name, "err", err)
+			// Let the next request retry.
+			snapshot.mtx.Lock()
+			snapshot.refreshing = false
+			snapshot.mtx.Unlock()
+		}
+	}()
+}
+
+func snapshotKey(userID string, req *logproto.LabelRequest) string {
+	if req.Values {
+		return userID + "\xffvalues\xff" + req.Name
+	}
+	return userID + "\xffnames"
+}
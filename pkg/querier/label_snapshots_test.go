@@ -0,0 +1,162 @@
+package querier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// fakeLabels returns the values existing over the requested range, and records the requests.
+type fakeLabels struct {
+	mtx      sync.Mutex
+	values   map[string][2]time.Time
+	requests map[string][]*logproto.LabelRequest
+}
+
+func (f *fakeLabels) compute(_ context.Context, userID string, req *logproto.LabelRequest) ([]string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.requests[userID] = append(f.requests[userID], req)
+	values := []string{userID}
+	for v, r := range f.values {
+		if req.Start.Before(r[1]) && req.End.After(r[0]) {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+func (f *fakeLabels) count(userID string) int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return len(f.requests[userID])
+}
+
+func (f *fakeLabels) lastRequest(userID string) *logproto.LabelRequest {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.requests[userID][len(f.requests[userID])-1]
+}
+
+func TestLabelSnapshots(t *testing.T) {
+	cfg := LabelSnapshotsConfig{
+		Enabled:         true,
+		Lookback:        6 * time.Hour,
+		Resolution:      time.Hour,
+		RefreshInterval: time.Minute,
+		MaxStaleness:    5 * time.Minute,
+		MaxSnapshots:    10,
+	}
+	require.NoError(t, cfg.Validate())
+
+	now := time.Date(2021, 12, 1, 12, 0, 0, 0, time.UTC)
+	fake := &fakeLabels{
+		values: map[string][2]time.Time{
+			"old":    {now.Add(-6 * time.Hour), now.Add(-210 * time.Minute)},
+			"recent": {now.Add(-30 * time.Minute), now.Add(24 * time.Hour)},
+			"new":    {now.Add(time.Minute), now.Add(24 * time.Hour)},
+		},
+		requests: map[string][]*logproto.LabelRequest{},
+	}
+	s, err := newLabelSnapshots(cfg, time.Minute, fake.compute)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+
+	request := func(start, end time.Time, query string) *logproto.LabelRequest {
+		return &logproto.LabelRequest{Name: "app", Values: true, Start: &start, End: &end, Query: query}
+	}
+
+	// The first request computes the snapshot over the lookback, by windows of the resolution,
+	// and only returns the values seen since its start.
+	values, ok, err := s.get(context.Background(), "a", request(now.Add(-time.Hour), now, ""))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "recent"}, values)
+	require.Equal(t, 6, fake.count("a"))
+	for _, req := range fake.requests["a"] {
+		require.Equal(t, time.Hour, req.End.Sub(*req.Start))
+	}
+
+	// The next ones are served from it, separately for each tenant and label.
+	values, ok, err = s.get(context.Background(), "a", request(now.Add(-3*time.Hour), now, ""))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "old", "recent"}, values)
+	values, ok, err = s.get(context.Background(), "a", request(now.Add(-6*time.Hour), now, ""))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "old", "recent"}, values)
+	require.Equal(t, 6, fake.count("a"))
+
+	values, _, err = s.get(context.Background(), "b", request(now.Add(-time.Hour), now, ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "recent"}, values)
+	_, _, err = s.get(context.Background(), "a", &logproto.LabelRequest{Start: &now, End: &now})
+	require.NoError(t, err)
+	require.Equal(t, 12, fake.count("a"))
+
+	// The requests with a query, starting before the lookback or ending too long ago are not served from snapshots.
+	for _, req := range []*logproto.LabelRequest{
+		request(now.Add(-time.Hour), now, `{env="prod"}`),
+		request(now.Add(-7*time.Hour), now, ""),
+		request(now.Add(-2*time.Hour), now.Add(-time.Hour), ""),
+	} {
+		_, ok, err = s.get(context.Background(), "a", req)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+	require.Equal(t, 12, fake.count("a"))
+
+	// A snapshot older than the refresh interval is served while it is refreshed in the background,
+	// which only looks the labels up since the snapshot.
+	snapshotAt := now
+	now = now.Add(2 * time.Minute)
+	values, ok, err = s.get(context.Background(), "b", request(now.Add(-time.Hour), now, ""))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"b", "recent"}, values)
+	require.Eventually(t, func() bool {
+		values, _, _ := s.get(context.Background(), "b", request(now.Add(-time.Hour), now, ""))
+		return len(values) == 3
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 7, fake.count("b"))
+	require.Equal(t, snapshotAt, *fake.lastRequest("b").Start)
+	require.Equal(t, now, *fake.lastRequest("b").End)
+
+	values, _, err = s.get(context.Background(), "b", request(now.Add(-5*time.Hour), now, ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "new", "old", "recent"}, values)
+
+	// A snapshot older than the max staleness is refreshed before responding.
+	now = now.Add(10 * time.Minute)
+	_, ok, err = s.get(context.Background(), "b", request(now.Add(-time.Hour), now, ""))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 8, fake.count("b"))
+
+	// The values last seen before the lookback are dropped, and a snapshot older than the lookback is computed again.
+	now = now.Add(3 * time.Hour)
+	values, _, err = s.get(context.Background(), "b", request(now.Add(-6*time.Hour), now, ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "new", "recent"}, values)
+	require.Equal(t, 11, fake.count("b"))
+
+	now = now.Add(7 * time.Hour)
+	values, _, err = s.get(context.Background(), "b", request(now.Add(-6*time.Hour), now, ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "new", "recent"}, values)
+	require.Equal(t, 17, fake.count("b"))
+}
+
+func TestLabelSnapshotsConfig_Validate(t *testing.T) {
+	require.NoError(t, (&LabelSnapshotsConfig{}).Validate())
+	require.EqualError(t, (&LabelSnapshotsConfig{Enabled: true, Resolution: time.Hour, RefreshInterval: time.Minute, MaxStaleness: time.Minute, MaxSnapshots: 1}).Validate(), "the label snapshots lookback must be positive")
+	require.EqualError(t, (&LabelSnapshotsConfig{Enabled: true, Lookback: time.Hour, Resolution: 2 * time.Hour, RefreshInterval: time.Minute, MaxStaleness: time.Minute, MaxSnapshots: 1}).Validate(), "the label snapshots resolution must be positive and not exceed their lookback")
+	require.EqualError(t, (&LabelSnapshotsConfig{Enabled: true, Lookback: time.Hour, Resolution: time.Hour, RefreshInterval: 2 * time.Minute, MaxStaleness: time.Minute, MaxSnapshots: 1}).Validate(), "the label snapshots refresh interval must be positive and not exceed their max staleness")
+	require.EqualError(t, (&LabelSnapshotsConfig{Enabled: true, Lookback: time.Hour, Resolution: time.Hour, RefreshInterval: time.Minute, MaxStaleness: time.Minute}).Validate(), "the label snapshots max snapshots must be positive")
+}
@@ -0,0 +1,256 @@
+package querier
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log/pattern"
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+const (
+	// parsedLabelsRetention is how long a parsed label is reported after a query last used it.
+	parsedLabelsRetention = 24 * time.Hour
+	// maxParsedLabelsPerTenant bounds the parsed labels tracked per tenant, the least recently used are forgotten.
+	maxParsedLabelsPerTenant = 1000
+)
+
+// parsedLabelsUsage tracks the labels extracted by parsers which the queries of each tenant executed by this
+// querier used recently.
+type parsedLabelsUsage struct {
+	mtx     sync.Mutex
+	tenants map[string]map[string]*parsedLabel
+}
+
+type parsedLabel struct {
+	parsers  map[string]struct{}
+	lastUsed time.Time
+}
+
+func newParsedLabelsUsage() *parsedLabelsUsage {
+	return &parsedLabelsUsage{tenants: map[string]map[string]*parsedLabel{}}
+}
+
+// record records the parsed labels used by a query.
+func (u *parsedLabelsUsage) record(userID, query string, now time.Time) {
+	expr, err := logql.ParseExpr(query)
+	if err != nil {
+		return
+	}
+	used := parsedLabels(expr)
+	if len(used) == 0 {
+		return
+	}
+
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	tenantLabels, ok := u.tenants[userID]
+	if !ok {
+		tenantLabels = map[string]*parsedLabel{}
+		u.tenants[userID] = tenantLabels
+	}
+	for name, parsers := range used {
+		l, ok := tenantLabels[name]
+		if !ok {
+			l = &parsedLabel{parsers: map[string]struct{}{}}
+			tenantLabels[name] = l
+		}
+		for _, p := range parsers {
+			l.parsers[p] = struct{}{}
+		}
+		l.lastUsed = now
+	}
+
+	if excess := len(tenantLabels) - maxParsedLabelsPerTenant; excess > 0 {
+		names := make([]string, 0, len(tenantLabels))
+		for name := range tenantLabels {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return tenantLabels[names[i]].lastUsed.Before(tenantLabels[names[j]].lastUsed) })
+		for _, name := range names[:excess] {
+			delete(tenantLabels, name)
+		}
+	}
+}
+
+// labels returns the metadata of the parsed labels used by the queries of a tenant within the retention.
+func (u *parsedLabelsUsage) labels(userID string, now time.Time) map[string]loghttp.Metadata {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	result := map[string]loghttp.Metadata{}
+	for name, l := range u.tenants[userID] {
+		if now.Sub(l.lastUsed) > parsedLabelsRetention {
+			delete(u.tenants[userID], name)
+			continue
+		}
+		parsers := make([]string, 0, len(l.parsers))
+		for p := range l.parsers {
+			parsers = append(parsers, p)
+		}
+		sort.Strings(parsers)
+		lastUsed := l.lastUsed
+		result[name] = loghttp.Metadata{
+			Type:     loghttp.MetadataTypeParsed,
+			Help:     "Label extracted by the " + strings.Join(parsers, ", ") + " parser in recent queries.",
+			Parsers:  parsers,
+			LastUsed: &lastUsed,
+		}
+	}
+	if len(u.tenants[userID]) == 0 {
+		delete(u.tenants, userID)
+	}
+	return result
+}
+
+// parsedLabels returns the labels used by a query after a parser, with the parsers of the query. These are the
+// labels extracted explicitly by the parsers, and the labels the stages after a parser, the unwrap and the
+// groupings of the query refer to, except the labels of the stream selectors.
+func parsedLabels(expr logql.Expr) map[string][]string {
+	var parsers []string
+	addParser := func(p string) {
+		for _, existing := range parsers {
+			if existing == p {
+				return
+			}
+		}
+		parsers = append(parsers, p)
+	}
+	names := map[string]struct{}{}
+	selectorNames := map[string]struct{}{}
+	// The labels of the unwraps and groupings are only parsed labels if the query has a parser.
+	var referenced []string
+
+	expr.Walk(func(e interface{}) {
+		switch e := e.(type) {
+		case *logql.MatchersExpr:
+			for _, m := range e.Matchers() {
+				selectorNames[m.Name] = struct{}{}
+			}
+		case *logql.PipelineExpr:
+			parsed := false
+			for _, stage := range e.MultiStages {
+				switch s := stage.(type) {
+				case *logql.LabelParserExpr:
+					parsed = true
+					addParser(s.Op)
+					switch s.Op {
+					case logql.OpParserTypeRegexp:
+						if re, err := regexp.Compile(s.Param); err == nil {
+							for _, name := range re.SubexpNames() {
+								names[name] = struct{}{}
+							}
+						}
+					case logql.OpParserTypePattern:
+						if m, err := pattern.New(s.Param); err == nil {
+							for _, name := range m.Names() {
+								names[name] = struct{}{}
+							}
+						}
+					}
+					continue
+				case *logql.JSONExpressionParser:
+					parsed = true
+					addParser(logql.OpParserTypeJSON)
+					for _, exp := range s.Expressions {
+						names[exp.Identifier] = struct{}{}
+					}
+					continue
+				}
+				if !parsed {
+					continue
+				}
+				if st, err := stage.Stage(); err == nil {
+					for _, name := range st.RequiredLabelNames() {
+						names[name] = struct{}{}
+					}
+				}
+			}
+		case *logql.LogRange:
+			if e.Unwrap != nil {
+				referenced = append(referenced, e.Unwrap.Identifier)
+			}
+		case *logql.RangeAggregationExpr:
+			if e.Grouping != nil {
+				referenced = append(referenced, e.Grouping.Groups...)
+			}
+		case *logql.VectorAggregationExpr:
+			if e.Grouping != nil {
+				referenced = append(referenced, e.Grouping.Groups...)
+			}
+		}
+	})
+	if len(parsers) == 0 {
+		return nil
+	}
+	for _, name := range referenced {
+		names[name] = struct{}{}
+	}
+
+	result := map[string][]string{}
+	for name := range names {
+		if _, ok := selectorNames[name]; ok || name == "" || strings.HasPrefix(name, "__") {
+			continue
+		}
+		result[name] = parsers
+	}
+	return result
+}
+
+// recordParsedLabels records the parsed labels used by a query of the tenant of the context.
+func (q *Querier) recordParsedLabels(ctx context.Context, query string) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return
+	}
+	q.parsedLabelsUsage.record(userID, query, time.Now())
+}
+
+// Metadata returns the metadata of the stream labels of the index and of the labels extracted by parsers in the
+// recent queries of the tenant. The parsed labels which are also stream labels are not reported.
+func (q *Querier) Metadata(ctx context.Context, req *loghttp.MetadataQuery) (map[string][]loghttp.Metadata, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	streamLabels, err := q.Label(ctx, &logproto.LabelRequest{Start: &req.Start, End: &req.End})
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string][]loghttp.Metadata{}
+	for _, name := range streamLabels.Values {
+		result[name] = []loghttp.Metadata{{Type: loghttp.MetadataTypeStream, Help: "Stream label."}}
+	}
+	for name, m := range q.parsedLabelsUsage.labels(userID, time.Now()) {
+		if _, ok := result[name]; !ok {
+			result[name] = []loghttp.Metadata{m}
+		}
+	}
+
+	if req.Metric != "" {
+		m, ok := result[req.Metric]
+		result = map[string][]loghttp.Metadata{}
+		if ok {
+			result[req.Metric] = m
+		}
+	}
+	if req.Limit > 0 && len(result) > req.Limit {
+		names := make([]string, 0, len(result))
+		for name := range result {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names[req.Limit:] {
+			delete(result, name)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package querier
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestParsedLabels(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected map[string][]string
+	}{
+		{query: `{app="foo"} |= "bar"`},
+		{query: `sum by (app) (rate({app="foo"}[1m]))`},
+		{
+			query: `{app="foo"} | logfmt | level="error" | line_format "{{.msg}}"`,
+			expected: map[string][]string{
+				"level": {"logfmt"},
+				"msg":   {"logfmt"},
+			},
+		},
+		{
+			query: `{app="foo"} | json first_server="servers[0]", ua="request.headers[\"User-Agent\"]"`,
+			expected: map[string][]string{
+				"first_server": {"json"},
+				"ua":           {"json"},
+			},
+		},
+		{
+			query: `sum by (app, path) (rate({app="foo"} | regexp "(?P<method>\\w+) (?P<path>[\\w/]+)" [1m]))`,
+			expected: map[string][]string{
+				"method": {"regexp"},
+				"path":   {"regexp"},
+			},
+		},
+		{
+			query: `quantile_over_time(0.99, {app="foo"} | json | unwrap latency [1m]) by (status)`,
+			expected: map[string][]string{
+				"latency": {"json"},
+				"status":  {"json"},
+			},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := logql.ParseExpr(tc.query)
+			require.NoError(t, err)
+			actual := parsedLabels(expr)
+			if tc.expected == nil {
+				require.Empty(t, actual)
+				return
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParsedLabelsUsage(t *testing.T) {
+	u := newParsedLabelsUsage()
+	now := time.Now()
+
+	u.record("user", `{app="foo"} | logfmt | level="error"`, now.Add(-2*parsedLabelsRetention))
+	u.record("user", `{app="foo"} | json | status>=500`, now.Add(-time.Hour))
+	u.record("user", `{app="foo"} | logfmt | status>=500`, now)
+	u.record("user", `not a query`, now)
+	u.record("other", `{app="foo"} | pattern "<ip> <_>"`, now)
+
+	labels := u.labels("user", now)
+	require.Len(t, labels, 1)
+	require.Equal(t, loghttp.MetadataTypeParsed, labels["status"].Type)
+	require.Equal(t, []string{"json", "logfmt"}, labels["status"].Parsers)
+	require.Equal(t, now, *labels["status"].LastUsed)
+
+	// The least recently used labels are forgotten past the maximum.
+	for i := 0; i < maxParsedLabelsPerTenant; i++ {
+		u.record("user", fmt.Sprintf(`{app="foo"} | logfmt | label_%d="bar"`, i), now.Add(time.Duration(i+1)*time.Millisecond))
+	}
+	labels = u.labels("user", now)
+	require.Len(t, labels, maxParsedLabelsPerTenant)
+	require.NotContains(t, labels, "status")
+	require.Len(t, u.labels("other", now), 1)
+}
+
+func TestQuerier_Metadata(t *testing.T) {
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("Label", mock.Anything, mock.Anything, mock.Anything).Return(mockLabelResponse([]string{"app", "level"}), nil)
+	store := newStoreMock()
+	store.On("LabelNamesForMetricName", mock.Anything, "test", mock.Anything, mock.Anything, "logs").Return([]string{"app", "env"}, nil)
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	q, err := newQuerier(
+		mockQuerierConfig(),
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(ingesterClient),
+		mockReadRingWithOneActiveIngester(),
+		store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	q.recordParsedLabels(ctx, `{app="foo"} | logfmt | level="error" | duration > 1s`)
+
+	req := &loghttp.MetadataQuery{Start: time.Now().Add(-time.Hour), End: time.Now()}
+	metadata, err := q.Metadata(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, metadata, 4)
+	for _, name := range []string{"app", "env", "level"} {
+		require.Equal(t, []loghttp.Metadata{{Type: loghttp.MetadataTypeStream, Help: "Stream label."}}, metadata[name])
+	}
+	require.Len(t, metadata["duration"], 1)
+	require.Equal(t, loghttp.MetadataTypeParsed, metadata["duration"][0].Type)
+	require.Equal(t, []string{"logfmt"}, metadata["duration"][0].Parsers)
+
+	req.Metric = "duration"
+	metadata, err = q.Metadata(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, metadata, 1)
+	require.Contains(t, metadata, "duration")
+
+	req.Metric, req.Limit = "", 2
+	metadata, err = q.Metadata(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, metadata, 2)
+	require.Contains(t, metadata, "app")
+	require.Contains(t, metadata, "duration")
+}
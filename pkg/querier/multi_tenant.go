@@ -0,0 +1,177 @@
+package querier
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/tenant"
+	listutil "github.com/grafana/loki/pkg/util"
+)
+
+// TenantLabel is the label added to the streams and series returned by multi-tenant queries, set to the tenant they belong to.
+const TenantLabel = "__tenant_id__"
+
+// multiTenantIDs returns the tenants of a multi-tenant query, or nil when the context holds a single tenant.
+// Each of the tenants must allow the others in its federated tenants.
+func (q *Querier) multiTenantIDs(ctx context.Context) ([]string, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil || len(tenantIDs) < 2 {
+		return nil, err
+	}
+
+	for _, tenantID := range tenantIDs {
+		allowed := map[string]struct{}{}
+		for _, federated := range q.limits.FederatedTenants(tenantID) {
+			allowed[federated] = struct{}{}
+		}
+		if _, ok := allowed["*"]; ok {
+			continue
+		}
+		for _, other := range tenantIDs {
+			if _, ok := allowed[other]; !ok && other != tenantID {
+				return nil, httpgrpc.Errorf(http.StatusForbidden, "tenant %s cannot be queried along with tenant %s, it must be listed in the federated tenants of %s", other, tenantID, tenantID)
+			}
+		}
+	}
+	return tenantIDs, nil
+}
+
+// selectLogsMultiTenant queries the logs of each tenant and merges them, labelled with their tenant.
+func (q *Querier) selectLogsMultiTenant(ctx context.Context, tenantIDs []string, params logql.SelectLogParams) (iter.EntryIterator, error) {
+	iters := make([]iter.EntryIterator, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		// The request is modified by the query of each tenant.
+		req := *params.QueryRequest
+		it, err := q.SelectLogs(user.InjectOrgID(ctx, tenantID), logql.SelectLogParams{QueryRequest: &req})
+		if err != nil {
+			for _, it := range iters {
+				it.Close()
+			}
+			return nil, err
+		}
+		iters = append(iters, &tenantEntryIterator{EntryIterator: it, labels: newTenantLabels(tenantID)})
+	}
+	return iter.NewHeapIterator(ctx, iters, params.Direction), nil
+}
+
+// selectSamplesMultiTenant queries the samples of each tenant and merges them, labelled with their tenant.
+func (q *Querier) selectSamplesMultiTenant(ctx context.Context, tenantIDs []string, params logql.SelectSampleParams, expr *logql.RangeAggregationExpr, queryParams logql.Params) (iter.SampleIterator, promql.Matrix, error) {
+	var aggregated promql.Matrix
+	iters := make([]iter.SampleIterator, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		// The request is modified by the query of each tenant.
+		req := *params.SampleQueryRequest
+		it, m, err := q.selectSamples(user.InjectOrgID(ctx, tenantID), logql.SelectSampleParams{SampleQueryRequest: &req}, expr, queryParams)
+		if err != nil {
+			for _, it := range iters {
+				it.Close()
+			}
+			return nil, nil, err
+		}
+		iters = append(iters, &tenantSampleIterator{SampleIterator: it, labels: newTenantLabels(tenantID)})
+		for _, series := range m {
+			series.Metric = labels.NewBuilder(series.Metric).Set(TenantLabel, tenantID).Labels()
+			aggregated = append(aggregated, series)
+		}
+	}
+	return iter.NewHeapSampleIterator(ctx, iters), aggregated, nil
+}
+
+// labelMultiTenant merges the label names or values of each tenant. The tenant label is a label of all of them.
+func (q *Querier) labelMultiTenant(ctx context.Context, tenantIDs []string, req *logproto.LabelRequest) (*logproto.LabelResponse, error) {
+	if req.Values && req.Name == TenantLabel {
+		return &logproto.LabelResponse{Values: tenantIDs}, nil
+	}
+
+	var results [][]string
+	if !req.Values {
+		results = append(results, []string{TenantLabel})
+	}
+	for _, tenantID := range tenantIDs {
+		// The time range of the request is modified by the query of each tenant.
+		tenantReq := *req
+		start, end := *req.Start, *req.End
+		tenantReq.Start, tenantReq.End = &start, &end
+		resp, err := q.Label(user.InjectOrgID(ctx, tenantID), &tenantReq)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, resp.Values)
+	}
+	return &logproto.LabelResponse{Values: listutil.MergeStringLists(results...)}, nil
+}
+
+// seriesMultiTenant merges the series of each tenant, labelled with their tenant.
+func (q *Querier) seriesMultiTenant(ctx context.Context, tenantIDs []string, req *logproto.SeriesRequest) (*logproto.SeriesResponse, error) {
+	response := &logproto.SeriesResponse{}
+	for _, tenantID := range tenantIDs {
+		tenantReq := *req
+		resp, err := q.Series(user.InjectOrgID(ctx, tenantID), &tenantReq)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range resp.Series {
+			if req.Limit > 0 && len(response.Series) >= int(req.Limit) {
+				return response, nil
+			}
+			lbs := make(map[string]string, len(s.Labels)+1)
+			for name, value := range s.Labels {
+				lbs[name] = value
+			}
+			lbs[TenantLabel] = tenantID
+			response.Series = append(response.Series, logproto.SeriesIdentifier{Labels: lbs})
+		}
+	}
+	return response, nil
+}
+
+// tenantLabels adds the tenant label to the labels of the streams or series of a tenant.
+type tenantLabels struct {
+	tenantID string
+	// cache of the labels with the tenant label, by original labels.
+	cache map[string]string
+}
+
+func newTenantLabels(tenantID string) *tenantLabels {
+	return &tenantLabels{tenantID: tenantID, cache: map[string]string{}}
+}
+
+func (t *tenantLabels) add(lbs string) string {
+	if withTenant, ok := t.cache[lbs]; ok {
+		return withTenant
+	}
+	parsed, err := logql.ParseLabels(lbs)
+	if err != nil {
+		// The labels of the streams and series are always valid.
+		return lbs
+	}
+	withTenant := labels.NewBuilder(parsed).Set(TenantLabel, t.tenantID).Labels().String()
+	t.cache[lbs] = withTenant
+	return withTenant
+}
+
+type tenantEntryIterator struct {
+	iter.EntryIterator
+	labels *tenantLabels
+}
+
+func (i *tenantEntryIterator) Labels() string {
+	return i.labels.add(i.EntryIterator.Labels())
+}
+
+type tenantSampleIterator struct {
+	iter.SampleIterator
+	labels *tenantLabels
+}
+
+func (i *tenantSampleIterator) Labels() string {
+	return i.labels.add(i.SampleIterator.Labels())
+}
@@ -0,0 +1,163 @@
+package querier
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/tenant"
+	"github.com/grafana/loki/pkg/usage"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func newMultiTenantQuerier(t *testing.T, store *storeMock, federatedTenants []string) *Querier {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() {
+		tenant.WithDefaultResolver(tenant.NewSingleResolver())
+	})
+
+	limitsCfg := defaultLimitsTestConfig()
+	limitsCfg.FederatedTenants = federatedTenants
+	limits, err := validation.NewOverrides(limitsCfg, nil)
+	require.NoError(t, err)
+
+	conf := mockQuerierConfig()
+	conf.QueryStoreOnly = true
+	q, err := newQuerier(
+		conf,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		store, limits)
+	require.NoError(t, err)
+	return q
+}
+
+func TestQuerier_multiTenantIDs(t *testing.T) {
+	for _, tc := range []struct {
+		desc             string
+		orgID            string
+		federatedTenants []string
+		expected         []string
+		forbidden        bool
+	}{
+		{desc: "single tenant", orgID: "a"},
+		{desc: "federated tenants", orgID: "b|a", federatedTenants: []string{"a", "b"}, expected: []string{"a", "b"}},
+		{desc: "any tenant", orgID: "a|c", federatedTenants: []string{"*"}, expected: []string{"a", "c"}},
+		{desc: "not federated", orgID: "a|c", federatedTenants: []string{"a", "b"}, forbidden: true},
+		{desc: "no federated tenants", orgID: "a|b", forbidden: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			q := newMultiTenantQuerier(t, newStoreMock(), tc.federatedTenants)
+
+			tenantIDs, err := q.multiTenantIDs(user.InjectOrgID(context.Background(), tc.orgID))
+			if tc.forbidden {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				require.Equal(t, int32(http.StatusForbidden), resp.Code)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tenantIDs)
+		})
+	}
+}
+
+func TestQuerier_SelectLogsMultiTenant(t *testing.T) {
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(mockStreamIterator(1, 2), nil).Once()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(mockStreamIterator(1, 1), nil).Once()
+	q := newMultiTenantQuerier(t, store, []string{"*"})
+
+	ctx := user.InjectOrgID(context.Background(), "b|a")
+	it, err := q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  `{type="test"}`,
+		Limit:     10,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+	}})
+	require.NoError(t, err)
+	defer it.Close()
+
+	var actual []string
+	for it.Next() {
+		actual = append(actual, it.Labels()+" "+it.Entry().Line)
+	}
+	require.NoError(t, it.Error())
+	require.ElementsMatch(t, []string{
+		`{__tenant_id__="a", type="test"} line 1`,
+		`{__tenant_id__="a", type="test"} line 2`,
+		`{__tenant_id__="b", type="test"} line 1`,
+	}, actual)
+
+	// Each tenant is queried with its own ID.
+	for i, call := range store.Calls {
+		orgID, err := user.ExtractOrgID(call.Arguments.Get(0).(context.Context))
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}[i], orgID)
+	}
+}
+
+func TestQuerier_LabelMultiTenant(t *testing.T) {
+	store := newStoreMock()
+	store.On("LabelNamesForMetricName", mock.Anything, "a", mock.Anything, mock.Anything, "logs").Return([]string{"app", "env"}, nil)
+	store.On("LabelNamesForMetricName", mock.Anything, "b", mock.Anything, mock.Anything, "logs").Return([]string{"app", "pod"}, nil)
+	q := newMultiTenantQuerier(t, store, []string{"*"})
+
+	ctx := user.InjectOrgID(context.Background(), "a|b")
+	start, end := time.Now().Add(-time.Hour), time.Now()
+	resp, err := q.Label(ctx, &logproto.LabelRequest{Start: &start, End: &end})
+	require.NoError(t, err)
+	require.Equal(t, []string{TenantLabel, "app", "env", "pod"}, resp.Values)
+
+	resp, err = q.Label(ctx, &logproto.LabelRequest{Name: TenantLabel, Values: true, Start: &start, End: &end})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, resp.Values)
+}
+
+func TestQuerier_SeriesMultiTenant(t *testing.T) {
+	store := newStoreMock()
+	store.On("GetSeries", mock.Anything, mock.Anything).Return([]logproto.SeriesIdentifier{
+		{Labels: map[string]string{"app": "foo"}},
+	}, nil)
+	q := newMultiTenantQuerier(t, store, []string{"*"})
+
+	ctx := user.InjectOrgID(context.Background(), "a|b")
+	resp, err := q.Series(ctx, &logproto.SeriesRequest{Start: time.Unix(0, 0), End: time.Unix(10, 0), Groups: []string{`{app="foo"}`}})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []logproto.SeriesIdentifier{
+		{Labels: map[string]string{"app": "foo", TenantLabel: "a"}},
+		{Labels: map[string]string{"app": "foo", TenantLabel: "b"}},
+	}, resp.Series)
+}
+
+func TestQuerier_recordUsageMultiTenant(t *testing.T) {
+	q := newMultiTenantQuerier(t, newStoreMock(), []string{"*"})
+	kvStore, closer := consul.NewInMemoryClient(usage.Codec{}, log.NewNopLogger(), nil)
+	defer closer.Close()
+	cfg := usage.TrackerConfig{Enabled: true, FlushPeriod: time.Minute}
+	cfg.KVStore.Mock = kvStore
+	tracker, err := usage.NewTracker("test", cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	q.usageTracker = tracker
+
+	// The usage of a multi-tenant query is split between its tenants.
+	q.recordUsage(user.InjectOrgID(context.Background(), "a|b"), stats.Result{Summary: stats.Summary{TotalBytesProcessed: 101, TotalLinesProcessed: 11}})
+	require.Equal(t, usage.Usage{Bytes: 51, Lines: 6}, tracker.Usage("a"))
+	require.Equal(t, usage.Usage{Bytes: 50, Lines: 5}, tracker.Usage("b"))
+
+	q.recordUsage(user.InjectOrgID(context.Background(), "a"), stats.Result{Summary: stats.Summary{TotalBytesProcessed: 10, TotalLinesProcessed: 1}})
+	require.Equal(t, usage.Usage{Bytes: 61, Lines: 7}, tracker.Usage("a"))
+}
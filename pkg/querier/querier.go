@@ -6,13 +6,18 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/weaveworks/common/httpgrpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 	cortex_validation "github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
 
 	"github.com/grafana/loki/pkg/tenant"
 
@@ -20,7 +25,11 @@ import (
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/usage"
 	listutil "github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/validation"
 )
@@ -40,34 +49,76 @@ type interval struct {
 
 // Config for a querier.
 type Config struct {
-	QueryTimeout                  time.Duration    `yaml:"query_timeout"`
-	TailMaxDuration               time.Duration    `yaml:"tail_max_duration"`
-	ExtraQueryDelay               time.Duration    `yaml:"extra_query_delay,omitempty"`
-	QueryIngestersWithin          time.Duration    `yaml:"query_ingesters_within,omitempty"`
-	IngesterQueryStoreMaxLookback time.Duration    `yaml:"-"`
-	Engine                        logql.EngineOpts `yaml:"engine,omitempty"`
-	MaxConcurrent                 int              `yaml:"max_concurrent"`
-	QueryStoreOnly                bool             `yaml:"query_store_only"`
+	QueryTimeout                  time.Duration        `yaml:"query_timeout"`
+	TailMaxDuration               time.Duration        `yaml:"tail_max_duration"`
+	TailCompression               bool                 `yaml:"tail_compression"`
+	TailFlushInterval             time.Duration        `yaml:"tail_flush_interval"`
+	TailMaxBatchEntries           int                  `yaml:"tail_max_batch_entries"`
+	TailTargetAddr                string               `yaml:"tail_target_addr"`
+	TailTargetInterfaceNames      []string             `yaml:"tail_target_interface_names"`
+	ExtraQueryDelay               time.Duration        `yaml:"extra_query_delay,omitempty"`
+	QueryIngestersWithin          time.Duration        `yaml:"query_ingesters_within,omitempty"`
+	IngesterQueryStoreMaxLookback time.Duration        `yaml:"-"`
+	PeriodConfigs                 []chunk.PeriodConfig `yaml:"-"`
+	Engine                        logql.EngineOpts     `yaml:"engine,omitempty"`
+	MaxConcurrent                 int                  `yaml:"max_concurrent"`
+	QueryStoreOnly                bool                 `yaml:"query_store_only"`
+	QueryIngestersRecentDataOnly  bool                 `yaml:"query_ingesters_recent_data_only"`
+	MultiTenantQueriesEnabled     bool                 `yaml:"multi_tenant_queries_enabled"`
+	Federation                    FederationConfig     `yaml:"federation"`
+	UsageTracker                  usage.TrackerConfig  `yaml:"usage_tracker"`
+	LabelSnapshots                LabelSnapshotsConfig `yaml:"label_snapshots"`
 }
 
 // RegisterFlags register flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Engine.RegisterFlagsWithPrefix("querier", f)
+	cfg.Federation.RegisterFlags(f)
+	cfg.LabelSnapshots.RegisterFlags(f)
+	cfg.UsageTracker.RegisterFlagsWithPrefix("querier.usage-tracker.", "query-usage/", "Account the bytes and lines scanned by the queries of each tenant per calendar month in the KV store, reported by the usage stats of the compactor.", f)
 	f.DurationVar(&cfg.TailMaxDuration, "querier.tail-max-duration", 1*time.Hour, "Limit the duration for which live tailing request would be served")
+	f.BoolVar(&cfg.TailCompression, "querier.tail-compression", false, "Negotiate the permessage-deflate WebSocket extension with live tailing clients supporting it, to compress the tailed entries.")
+	f.DurationVar(&cfg.TailFlushInterval, "querier.tail-flush-interval", 0, "Batch the entries sent to live tailing clients and flush them at this interval. 0 sends the entries as soon as they are received.")
+	f.IntVar(&cfg.TailMaxBatchEntries, "querier.tail-max-batch-entries", 1000, "Maximum number of entries in a live tailing batch, the batch is flushed before the flush interval once reached. 0 means no limit. Applies only when the flush interval is set.")
+	f.StringVar(&cfg.TailTargetAddr, "querier.tail-target-addr", "", "Address the query frontend proxies the tail requests picked by this querier to, along with the HTTP listen port. Defaults to the address of the first of the tail target interface names which has one.")
+	cfg.TailTargetInterfaceNames = []string{"eth0", "en0"}
+	f.Var((*flagext.StringSlice)(&cfg.TailTargetInterfaceNames), "querier.tail-target-interface-names", "Name of the network interfaces to read the tail target address from when it is not set.")
 	f.DurationVar(&cfg.QueryTimeout, "querier.query-timeout", 1*time.Minute, "Timeout when querying backends (ingesters or storage) during the execution of a query request")
-	f.DurationVar(&cfg.ExtraQueryDelay, "querier.extra-query-delay", 0, "Time to wait before sending more than the minimum successful query requests.")
+	f.DurationVar(&cfg.ExtraQueryDelay, "querier.extra-query-delay", 0, "Time to wait before sending more than the minimum successful query requests. With zone-aware replication, the ingesters of the minimum number of zones holding a replica of every stream are queried first, the ingesters of the other zones after this delay or as soon as one of the queried zones fails.")
 	f.DurationVar(&cfg.QueryIngestersWithin, "querier.query-ingesters-within", 0, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
 	f.IntVar(&cfg.MaxConcurrent, "querier.max-concurrent", 20, "The maximum number of concurrent queries.")
 	f.BoolVar(&cfg.QueryStoreOnly, "querier.query-store-only", false, "Queriers should only query the store and not try to query any ingesters")
+	f.BoolVar(&cfg.MultiTenantQueriesEnabled, "querier.multi-tenant-queries-enabled", false, "Accept queries over several tenants whose IDs are separated by a pipe in the X-Scope-OrgID header, e.g. tenant-a|tenant-b. The results are labelled with the __tenant_id__ label. Each tenant must allow the others in its federated_tenants limit. It restricts the characters allowed in tenant IDs.")
+	f.BoolVar(&cfg.QueryIngestersRecentDataOnly, "querier.query-ingesters-recent-data-only", false, "Query ingesters only for the data which is not available in the store yet, to avoid reading the already flushed data from both ingesters and the store. It requires an additional lookup of chunks in the index and applies only when the store is queried for the whole ingester query interval.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if err := cfg.Federation.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.LabelSnapshots.Validate(); err != nil {
+		return err
+	}
+	return cfg.UsageTracker.Validate()
 }
 
 // Querier handlers queries.
 type Querier struct {
 	cfg             Config
 	store           storage.Store
+	archive         storage.Store
 	engine          *logql.Engine
 	limits          *validation.Overrides
 	ingesterQuerier *IngesterQuerier
+	shardingMetrics *logql.ShardingMetrics
+	federation      *federation
+	lookupTables    log.LookupTables
+
+	parsedLabelsUsage *parsedLabelsUsage
+	usageTracker      *usage.Tracker
+	labelSnapshots    *labelSnapshots
+	inflight          *logql.InflightQueries
 }
 
 // New makes a new Querier.
@@ -77,28 +128,110 @@ func New(cfg Config, store storage.Store, ingesterQuerier *IngesterQuerier, limi
 		store:           store,
 		ingesterQuerier: ingesterQuerier,
 		limits:          limits,
+		// only used to explain queries, the query frontend owns the sharding metrics.
+		shardingMetrics: logql.NewShardingMetrics(nil),
+
+		parsedLabelsUsage: newParsedLabelsUsage(),
+		inflight:          logql.NewInflightQueries(),
 	}
+	querier.cfg.Engine.Inflight = querier.inflight
 
-	querier.engine = logql.NewEngine(cfg.Engine, &querier, limits)
+	var err error
+	if querier.federation, err = newFederation(cfg.Federation); err != nil {
+		return nil, err
+	}
+
+	if cfg.LabelSnapshots.Enabled {
+		if querier.labelSnapshots, err = newLabelSnapshots(cfg.LabelSnapshots, cfg.QueryTimeout, querier.label); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.UsageTracker.Enabled {
+		if querier.usageTracker, err = usage.NewTracker("querier", cfg.UsageTracker, util_log.Logger, prometheus.DefaultRegisterer); err != nil {
+			return nil, err
+		}
+	}
+
+	querier.engine = logql.NewEngine(querier.cfg.Engine, &querier, limits)
 
 	return &querier, nil
 }
 
+// UsageTracker returns the tracker of the bytes scanned by the queries, nil if disabled. It is a service the caller must run.
+func (q *Querier) UsageTracker() *usage.Tracker {
+	return q.usageTracker
+}
+
+// InflightQueries returns the queries being executed by the querier.
+func (q *Querier) InflightQueries() *logql.InflightQueries {
+	return q.inflight
+}
+
+// recordUsage accounts the bytes and lines scanned by a query of the tenants of the context. The statistics of a
+// multi-tenant query are not broken down by tenant, its usage is split evenly between its tenants.
+func (q *Querier) recordUsage(ctx context.Context, result stats.Result) {
+	if q.usageTracker == nil {
+		return
+	}
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return
+	}
+	n := int64(len(tenantIDs))
+	for i, tenantID := range tenantIDs {
+		bytes, lines := result.Summary.TotalBytesProcessed/n, result.Summary.TotalLinesProcessed/n
+		// The first tenant is accounted the remainders, so that the total usage is the one of the query.
+		if i == 0 {
+			bytes += result.Summary.TotalBytesProcessed % n
+			lines += result.Summary.TotalLinesProcessed % n
+		}
+		q.usageTracker.Add(tenantID, int(bytes), int(lines))
+	}
+}
+
+// SetArchiveStore makes the querier read the data of the tenants enabling archive queries from the archive store when
+// queried past their retention period.
+func (q *Querier) SetArchiveStore(archive storage.Store) {
+	q.archive = archive
+}
+
+// SetLookupTables sets the tables of the lookup stages of the queries evaluated by the querier.
+func (q *Querier) SetLookupTables(tables log.LookupTables) {
+	q.lookupTables = tables
+	q.federation.lookupTables = tables
+}
+
 func (q *Querier) SetQueryable(queryable logql.Querier) {
 	q.engine = logql.NewEngine(q.cfg.Engine, queryable, q.limits)
 }
 
 // Select Implements logql.Querier which select logs via matchers and regex filters.
 func (q *Querier) SelectLogs(ctx context.Context, params logql.SelectLogParams) (iter.EntryIterator, error) {
-	var err error
+	tenantIDs, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs != nil {
+		return q.selectLogsMultiTenant(ctx, tenantIDs, params)
+	}
+
 	params.Start, params.End, err = q.validateQueryRequest(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
+	iters := []iter.EntryIterator{}
+	if q.federation.enabled() {
+		remoteIters, err := q.federation.selectLogs(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		iters = append(iters, remoteIters...)
+	}
+
 	ingesterQueryInterval, storeQueryInterval := q.buildQueryIntervals(params.Start, params.End)
 
-	iters := []iter.EntryIterator{}
 	if !q.cfg.QueryStoreOnly && ingesterQueryInterval != nil {
 		// Make a copy of the request before modifying
 		// because the initial request is used below to query stores
@@ -111,12 +244,54 @@ func (q *Querier) SelectLogs(ctx context.Context, params logql.SelectLogParams)
 		level.Debug(spanlogger.FromContext(ctx)).Log(
 			"msg", "querying ingester",
 			"params", newParams)
-		ingesterIters, err := q.ingesterQuerier.SelectLogs(ctx, newParams)
+
+		var ingesterIters []iter.EntryIterator
+		if q.queryRecentIngesterData(ingesterQueryInterval, storeQueryInterval) {
+			expr, err := params.LogSelector()
+			if err != nil {
+				return nil, err
+			}
+
+			storeChunks, err := q.storeChunks(ctx, newParams.Start, newParams.End, expr.Matchers())
+			if err != nil {
+				return nil, err
+			}
+
+			ingesterIters, err = q.ingesterQuerier.SelectLogsRecent(ctx, newParams, storeChunks)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			ingesterIters, err = q.ingesterQuerier.SelectLogs(ctx, newParams)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		iters = append(iters, ingesterIters...)
+	}
+
+	archiveQueryInterval, err := q.buildArchiveQueryInterval(ctx, storeQueryInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	if archiveQueryInterval != nil {
+		queryRequestCopy := *params.QueryRequest
+		archiveParams := logql.SelectLogParams{
+			QueryRequest: &queryRequestCopy,
+		}
+		archiveParams.Start = archiveQueryInterval.start
+		archiveParams.End = archiveQueryInterval.end
+		level.Debug(spanlogger.FromContext(ctx)).Log(
+			"msg", "querying archive",
+			"params", archiveParams)
+		archiveIter, err := q.archive.SelectLogs(ctx, archiveParams)
 		if err != nil {
 			return nil, err
 		}
 
-		iters = append(iters, ingesterIters...)
+		iters = append(iters, archiveIter)
 	}
 
 	if storeQueryInterval != nil {
@@ -137,15 +312,49 @@ func (q *Querier) SelectLogs(ctx context.Context, params logql.SelectLogParams)
 }
 
 func (q *Querier) SelectSamples(ctx context.Context, params logql.SelectSampleParams) (iter.SampleIterator, error) {
-	var err error
+	it, _, err := q.selectSamples(ctx, params, nil, nil)
+	return it, err
+}
+
+// SelectSamplesAggregated implements logql.RangeAggregationQuerier. The remote clusters of the federation evaluate
+// the range aggregations whose whole pipeline they can apply, rather than returning their entries.
+func (q *Querier) SelectSamplesAggregated(ctx context.Context, params logql.SelectSampleParams, expr *logql.RangeAggregationExpr, queryParams logql.Params) (iter.SampleIterator, promql.Matrix, error) {
+	return q.selectSamples(ctx, params, expr, queryParams)
+}
+
+// selectSamples selects the samples of the query, and the results of the range aggregation expr evaluated by the
+// remote clusters when expr is not nil and they can.
+func (q *Querier) selectSamples(ctx context.Context, params logql.SelectSampleParams, expr *logql.RangeAggregationExpr, queryParams logql.Params) (iter.SampleIterator, promql.Matrix, error) {
+	tenantIDs, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tenantIDs != nil {
+		return q.selectSamplesMultiTenant(ctx, tenantIDs, params, expr, queryParams)
+	}
+
 	params.Start, params.End, err = q.validateQueryRequest(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	var aggregated promql.Matrix
+	iters := []iter.SampleIterator{}
+	if q.federation.enabled() {
+		if expr != nil && q.federation.aggregates(expr) {
+			aggregated, err = q.federation.selectRangeAggregation(ctx, expr, queryParams)
+		} else {
+			var remoteIters []iter.SampleIterator
+			remoteIters, err = q.federation.selectSamples(ctx, params)
+			iters = append(iters, remoteIters...)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	ingesterQueryInterval, storeQueryInterval := q.buildQueryIntervals(params.Start, params.End)
 
-	iters := []iter.SampleIterator{}
 	if !q.cfg.QueryStoreOnly && ingesterQueryInterval != nil {
 		// Make a copy of the request before modifying
 		// because the initial request is used below to query stores
@@ -156,26 +365,128 @@ func (q *Querier) SelectSamples(ctx context.Context, params logql.SelectSamplePa
 		newParams.Start = ingesterQueryInterval.start
 		newParams.End = ingesterQueryInterval.end
 
-		ingesterIters, err := q.ingesterQuerier.SelectSample(ctx, newParams)
-		if err != nil {
-			return nil, err
+		var ingesterIters []iter.SampleIterator
+		if q.queryRecentIngesterData(ingesterQueryInterval, storeQueryInterval) {
+			expr, err := params.Expr()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			storeChunks, err := q.storeChunks(ctx, newParams.Start, newParams.End, expr.Selector().Matchers())
+			if err != nil {
+				return nil, nil, err
+			}
+
+			ingesterIters, err = q.ingesterQuerier.SelectSampleRecent(ctx, newParams, storeChunks)
+			if err != nil {
+				return nil, nil, err
+			}
+		} else {
+			ingesterIters, err = q.ingesterQuerier.SelectSample(ctx, newParams)
+			if err != nil {
+				return nil, nil, err
+			}
 		}
 
 		iters = append(iters, ingesterIters...)
 	}
 
+	archiveQueryInterval, err := q.buildArchiveQueryInterval(ctx, storeQueryInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if archiveQueryInterval != nil {
+		queryRequestCopy := *params.SampleQueryRequest
+		archiveParams := logql.SelectSampleParams{
+			SampleQueryRequest: &queryRequestCopy,
+		}
+		archiveParams.Start = archiveQueryInterval.start
+		archiveParams.End = archiveQueryInterval.end
+
+		archiveIter, err := q.archive.SelectSamples(ctx, archiveParams)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		iters = append(iters, archiveIter)
+	}
+
 	if storeQueryInterval != nil {
 		params.Start = storeQueryInterval.start
 		params.End = storeQueryInterval.end
 
 		storeIter, err := q.store.SelectSamples(ctx, params)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		iters = append(iters, storeIter)
 	}
-	return iter.NewHeapSampleIterator(ctx, iters), nil
+	return iter.NewHeapSampleIterator(ctx, iters), aggregated, nil
+}
+
+// buildArchiveQueryInterval returns the part of the store query interval past the retention period of the tenant,
+// which is read from the archive if the tenant enables archive queries. It is nil otherwise. The store is still
+// queried for the whole interval, since the streams retained longer by the stream retention rules are in it, the
+// entries found in both being deduplicated by the iterators.
+func (q *Querier) buildArchiveQueryInterval(ctx context.Context, storeQueryInterval *interval) (*interval, error) {
+	if q.archive == nil || storeQueryInterval == nil {
+		return nil, nil
+	}
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	retention := q.limits.RetentionPeriod(userID)
+	if retention <= 0 || !q.limits.FeatureEnabled(userID, validation.FeatureArchiveQueries) {
+		return nil, nil
+	}
+
+	retentionStart := nowFunc().Add(-retention)
+	if !storeQueryInterval.start.Before(retentionStart) {
+		return nil, nil
+	}
+	archiveQueryInterval := &interval{
+		start: storeQueryInterval.start,
+		end:   storeQueryInterval.end,
+	}
+	if archiveQueryInterval.end.After(retentionStart) {
+		archiveQueryInterval.end = retentionStart
+	}
+	if maxLength := q.limits.ArchiveMaxQueryLength(userID); maxLength > 0 && archiveQueryInterval.end.Sub(archiveQueryInterval.start) > maxLength {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "the query past the retention period is too long to be read from the archive (%s > %s)", archiveQueryInterval.end.Sub(archiveQueryInterval.start), maxLength)
+	}
+	return archiveQueryInterval, nil
+}
+
+// queryRecentIngesterData returns true if the ingesters should be queried only for the data not available in the store.
+// It is possible only when the store is queried for the whole ingester query interval.
+func (q *Querier) queryRecentIngesterData(ingesterQueryInterval, storeQueryInterval *interval) bool {
+	return q.cfg.QueryIngestersRecentDataOnly && storeQueryInterval != nil &&
+		!storeQueryInterval.start.After(ingesterQueryInterval.start) && !storeQueryInterval.end.Before(ingesterQueryInterval.end)
+}
+
+// storeChunks returns the fingerprints and checksums of chunks in the store matching the given matchers and time range.
+func (q *Querier) storeChunks(ctx context.Context, from, through time.Time, matchers []*labels.Matcher) ([]logproto.StoreChunk, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, _, err := q.store.GetChunkRefs(ctx, userID, model.TimeFromUnixNano(from.UnixNano()), model.TimeFromUnixNano(through.UnixNano()), matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	var storeChunks []logproto.StoreChunk
+	for _, group := range chunks {
+		for _, c := range group {
+			storeChunks = append(storeChunks, logproto.StoreChunk{Fingerprint: uint64(c.Fingerprint), Checksum: c.Checksum})
+		}
+	}
+
+	return storeChunks, nil
 }
 
 func (q *Querier) buildQueryIntervals(queryStart, queryEnd time.Time) (*interval, *interval) {
@@ -255,6 +566,14 @@ func (q *Querier) buildQueryIntervals(queryStart, queryEnd time.Time) (*interval
 
 // Label does the heavy lifting for a Label query.
 func (q *Querier) Label(ctx context.Context, req *logproto.LabelRequest) (*logproto.LabelResponse, error) {
+	tenantIDs, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs != nil {
+		return q.labelMultiTenant(ctx, tenantIDs, req)
+	}
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -268,6 +587,35 @@ func (q *Querier) Label(ctx context.Context, req *logproto.LabelRequest) (*logpr
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
 	defer cancel()
 
+	if q.labelSnapshots != nil {
+		values, ok, err := q.labelSnapshots.get(ctx, userID, req)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &logproto.LabelResponse{Values: values}, nil
+		}
+	}
+
+	values, err := q.label(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+	return &logproto.LabelResponse{Values: values}, nil
+}
+
+// label looks the label names or values of a request up in the ingesters, the store and the remote clusters.
+func (q *Querier) label(ctx context.Context, userID string, req *logproto.LabelRequest) ([]string, error) {
+	var (
+		matchers []*labels.Matcher
+		err      error
+	)
+	if req.Values && req.Query != "" {
+		if matchers, err = logql.ParseMatchers(req.Query); err != nil {
+			return nil, err
+		}
+	}
+
 	var ingesterValues [][]string
 	if !q.cfg.QueryStoreOnly {
 		ingesterValues, err = q.ingesterQuerier.Label(ctx, req)
@@ -279,7 +627,7 @@ func (q *Querier) Label(ctx context.Context, req *logproto.LabelRequest) (*logpr
 	from, through := model.TimeFromUnixNano(req.Start.UnixNano()), model.TimeFromUnixNano(req.End.UnixNano())
 	var storeValues []string
 	if req.Values {
-		storeValues, err = q.store.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name)
+		storeValues, err = q.store.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name, matchers...)
 		if err != nil {
 			return nil, err
 		}
@@ -291,9 +639,14 @@ func (q *Querier) Label(ctx context.Context, req *logproto.LabelRequest) (*logpr
 	}
 
 	results := append(ingesterValues, storeValues)
-	return &logproto.LabelResponse{
-		Values: listutil.MergeStringLists(results...),
-	}, nil
+	if q.federation.enabled() {
+		remoteValues, err := q.federation.label(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, remoteValues...)
+	}
+	return listutil.MergeStringLists(results...), nil
 }
 
 // Check implements the grpc healthcheck
@@ -323,6 +676,14 @@ func (q *Querier) Tail(ctx context.Context, req *logproto.TailRequest) (*Tailer,
 		return nil, err
 	}
 
+	expr, err := logql.ParseLogSelector(req.Query, true)
+	if err != nil {
+		return nil, err
+	}
+	if logql.HasDedup(expr) {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "the dedup stage is not supported when tailing")
+	}
+
 	// Enforce the query timeout except when tailing, otherwise the tailing
 	// will be terminated once the query timeout is reached
 	tailCtx := ctx
@@ -358,6 +719,14 @@ func (q *Querier) Tail(ctx context.Context, req *logproto.TailRequest) (*Tailer,
 
 // Series fetches any matching series for a list of matcher sets
 func (q *Querier) Series(ctx context.Context, req *logproto.SeriesRequest) (*logproto.SeriesResponse, error) {
+	tenantIDs, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tenantIDs != nil {
+		return q.seriesMultiTenant(ctx, tenantIDs, req)
+	}
+
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
 		return nil, err
@@ -396,7 +765,7 @@ func (q *Querier) awaitSeries(ctx context.Context, req *logproto.SeriesRequest)
 	}
 
 	go func() {
-		storeValues, err := q.seriesForMatchers(ctx, req.Start, req.End, req.GetGroups(), req.Shards)
+		storeValues, err := q.seriesForMatchers(ctx, req.Start, req.End, req.GetGroups(), req.Shards, req.Limit)
 		if err != nil {
 			errs <- err
 			return
@@ -429,6 +798,9 @@ func (q *Querier) awaitSeries(ctx context.Context, req *logproto.SeriesRequest)
 	}
 
 	for _, s := range deduped {
+		if req.Limit > 0 && len(response.Series) >= int(req.Limit) {
+			break
+		}
 		response.Series = append(response.Series, s)
 	}
 
@@ -442,6 +814,7 @@ func (q *Querier) seriesForMatchers(
 	from, through time.Time,
 	groups []string,
 	shards []string,
+	limit uint32,
 ) ([]logproto.SeriesIdentifier, error) {
 
 	var results []logproto.SeriesIdentifier
@@ -449,28 +822,32 @@ func (q *Querier) seriesForMatchers(
 	// we send a query with an empty matcher which will match every series.
 	if len(groups) == 0 {
 		var err error
-		results, err = q.seriesForMatcher(ctx, from, through, "", shards)
+		results, err = q.seriesForMatcher(ctx, from, through, "", shards, limit)
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		for _, group := range groups {
-			ids, err := q.seriesForMatcher(ctx, from, through, group, shards)
+			ids, err := q.seriesForMatcher(ctx, from, through, group, shards, limit)
 			if err != nil {
 				return nil, err
 			}
 			results = append(results, ids...)
+			// series of different groups can overlap, so only stop once the limit is reached.
+			if limit > 0 && len(results) >= int(limit) {
+				break
+			}
 		}
 	}
 	return results, nil
 }
 
-// seriesForMatcher fetches series from the store for a given matcher
-func (q *Querier) seriesForMatcher(ctx context.Context, from, through time.Time, matcher string, shards []string) ([]logproto.SeriesIdentifier, error) {
+// seriesForMatcher fetches series from the store for a given matcher, up to limit series if it is not 0.
+func (q *Querier) seriesForMatcher(ctx context.Context, from, through time.Time, matcher string, shards []string, limit uint32) ([]logproto.SeriesIdentifier, error) {
 	ids, err := q.store.GetSeries(ctx, logql.SelectLogParams{
 		QueryRequest: &logproto.QueryRequest{
 			Selector:  matcher,
-			Limit:     1,
+			Limit:     limit,
 			Start:     from,
 			End:       through,
 			Direction: logproto.FORWARD,
@@ -538,8 +915,8 @@ func (q *Querier) checkTailRequestLimit(ctx context.Context) error {
 	}
 
 	responses, err := q.ingesterQuerier.TailersCount(ctx)
-	// We are only checking active ingesters, and any error returned stops checking other ingesters
-	// so return that error here as well.
+	// We are only checking active ingesters, and more failures than tolerated by the read replication
+	// set stop checking other ingesters so return that error here as well.
 	if err != nil {
 		return err
 	}
@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/distributor"
-	"github.com/grafana/dskit/grpcclient"
+	dskit_grpcclient "github.com/grafana/dskit/grpcclient"
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,9 +21,11 @@ import (
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/storage"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/grpcclient"
 )
 
 // querierClientMock is a mockable version of QuerierClient, used in querier
@@ -67,6 +69,11 @@ func (c *querierClientMock) TailersCount(ctx context.Context, in *logproto.Taile
 	return args.Get(0).(*logproto.TailersCountResponse), args.Error(1)
 }
 
+func (c *querierClientMock) GetIndexStats(ctx context.Context, in *logproto.IndexStatsRequest, opts ...grpc.CallOption) (*logproto.IndexStatsResponse, error) {
+	args := c.Called(ctx, in, opts)
+	return args.Get(0).(*logproto.IndexStatsResponse), args.Error(1)
+}
+
 func (c *querierClientMock) Context() context.Context {
 	return context.Background()
 }
@@ -92,7 +99,9 @@ func mockIngesterClientConfig() client.Config {
 			RemoteTimeout:        1 * time.Second,
 		},
 		GRPCClientConfig: grpcclient.Config{
-			MaxRecvMsgSize: 1024,
+			Config: dskit_grpcclient.Config{
+				MaxRecvMsgSize: 1024,
+			},
 		},
 		RemoteTimeout: 1 * time.Second,
 	}
@@ -209,6 +218,8 @@ func newStoreMock() *storeMock {
 
 func (s *storeMock) SetChunkFilterer(storage.RequestChunkFilterer) {}
 
+func (s *storeMock) SetLookupTables(log.LookupTables) {}
+
 func (s *storeMock) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error) {
 	args := s.Called(ctx, req)
 	res := args.Get(0)
@@ -234,7 +245,12 @@ func (s *storeMock) Get(ctx context.Context, userID string, from, through model.
 
 func (s *storeMock) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([][]chunk.Chunk, []*chunk.Fetcher, error) {
 	args := s.Called(ctx, userID, from, through, matchers)
-	return args.Get(0).([][]chunk.Chunk), args.Get(0).([]*chunk.Fetcher), args.Error(2)
+	return args.Get(0).([][]chunk.Chunk), args.Get(1).([]*chunk.Fetcher), args.Error(2)
+}
+
+func (s *storeMock) GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]chunk.SeriesChunkRefs, error) {
+	args := s.Called(ctx, userID, from, through, matchers)
+	return args.Get(0).([]chunk.SeriesChunkRefs), args.Error(1)
 }
 
 func (s *storeMock) Put(ctx context.Context, chunks []chunk.Chunk) error {
@@ -245,8 +261,8 @@ func (s *storeMock) PutOne(ctx context.Context, from, through model.Time, chunk
 	return errors.New("storeMock.PutOne() has not been mocked")
 }
 
-func (s *storeMock) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error) {
-	args := s.Called(ctx, userID, from, through, metricName, labelName)
+func (s *storeMock) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
+	args := s.Called(ctx, userID, from, through, metricName, labelName, matchers)
 	return args.Get(0).([]string), args.Error(1)
 }
 
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cortexproject/cortex/pkg/util/limiter"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
@@ -21,6 +22,7 @@ import (
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/storage"
 	"github.com/grafana/loki/pkg/validation"
 )
@@ -53,7 +55,7 @@ func TestQuerier_Label_QueryTimeoutConfigFlag(t *testing.T) {
 	ingesterClient.On("Label", mock.Anything, &request, mock.Anything).Return(mockLabelResponse([]string{}), nil)
 
 	store := newStoreMock()
-	store.On("LabelValuesForMetricName", mock.Anything, "test", model.TimeFromUnixNano(startTime.UnixNano()), model.TimeFromUnixNano(endTime.UnixNano()), "logs", "test").Return([]string{"foo", "bar"}, nil)
+	store.On("LabelValuesForMetricName", mock.Anything, "test", model.TimeFromUnixNano(startTime.UnixNano()), model.TimeFromUnixNano(endTime.UnixNano()), "logs", "test", mock.Anything).Return([]string{"foo", "bar"}, nil)
 
 	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
@@ -142,6 +144,29 @@ func TestQuerier_Tail_QueryTimeoutConfigFlag(t *testing.T) {
 	store.AssertExpectations(t)
 }
 
+func TestQuerier_Tail_Dedup(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("TailersCount", mock.Anything, mock.Anything, mock.Anything).Return(&logproto.TailersCountResponse{}, nil)
+
+	q, err := newQuerier(
+		mockQuerierConfig(),
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(ingesterClient),
+		mockReadRingWithOneActiveIngester(),
+		newStoreMock(), limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	_, err = q.Tail(ctx, &logproto.TailRequest{Query: `{type="test"} | dedup 5s`, Limit: 10, Start: time.Now()})
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusBadRequest), resp.Code)
+	ingesterClient.AssertNotCalled(t, "Tail", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func mockQuerierConfig() Config {
 	return Config{
 		TailMaxDuration: 1 * time.Minute,
@@ -724,3 +749,158 @@ func Test_validateQueryTimeRangeLimits(t *testing.T) {
 		})
 	}
 }
+
+func TestQuerier_queryContext(t *testing.T) {
+	defaults := defaultLimitsTestConfig()
+	defaults.MaxChunksPerQuery = 1
+	defaults.MaxFetchedChunkBytesPerQuery = 10
+	defaults.MaxQueryExecutionTime = model.Duration(time.Millisecond)
+	limits, err := validation.NewOverrides(defaults, nil)
+	require.NoError(t, err)
+	q := &Querier{cfg: Config{QueryTimeout: time.Minute}, limits: limits}
+
+	// Without a tenant only the query timeout applies.
+	ctx, cancel, maxExecutionTime := q.queryContext(context.Background())
+	defer cancel()
+	require.Equal(t, time.Duration(0), maxExecutionTime)
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	ctx, cancel, maxExecutionTime = q.queryContext(user.InjectOrgID(context.Background(), "test"))
+	defer cancel()
+	require.Equal(t, time.Millisecond, maxExecutionTime)
+
+	queryLimiter := limiter.QueryLimiterFromContextWithFallback(ctx)
+	require.NoError(t, queryLimiter.AddChunks(1))
+	require.Error(t, queryLimiter.AddChunks(1))
+	require.NoError(t, queryLimiter.AddChunkBytes(10))
+	require.Error(t, queryLimiter.AddChunkBytes(1))
+
+	<-ctx.Done()
+	err = queryExecutionError(ctx, ctx.Err(), maxExecutionTime)
+	require.True(t, errors.Is(err, logqlmodel.ErrLimit))
+	require.EqualError(t, err, "the query hit the max execution time limit (limit: 1ms)")
+
+	// A deadline not caused by the tenant limit is returned as is.
+	require.Equal(t, ctx.Err(), queryExecutionError(ctx, ctx.Err(), 0))
+}
+
+func TestQuerier_buildArchiveQueryInterval(t *testing.T) {
+	now := time.Now()
+	nowFunc = func() time.Time { return now }
+	defer func() { nowFunc = time.Now }()
+
+	defaults := defaultLimitsTestConfig()
+	defaults.RetentionPeriod = model.Duration(24 * time.Hour)
+	defaults.ArchiveMaxQueryLength = model.Duration(7 * 24 * time.Hour)
+	tenantLimits := map[string]*validation.Limits{}
+	enabled := defaults
+	enabled.FeatureFlags = map[string]bool{string(validation.FeatureArchiveQueries): true}
+	tenantLimits["archive"] = &enabled
+	limits, err := validation.NewOverrides(defaults, mockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+	q := &Querier{limits: limits, archive: newStoreMock()}
+
+	retentionStart := now.Add(-24 * time.Hour)
+	for _, tc := range []struct {
+		name            string
+		tenant          string
+		store           *interval
+		expectedArchive *interval
+		expectedErr     bool
+	}{
+		{
+			name:   "archive queries disabled",
+			tenant: "other",
+			store:  &interval{start: now.Add(-48 * time.Hour), end: now},
+		},
+		{
+			name:   "within retention",
+			tenant: "archive",
+			store:  &interval{start: now.Add(-time.Hour), end: now},
+		},
+		{
+			name:            "across retention",
+			tenant:          "archive",
+			store:           &interval{start: now.Add(-48 * time.Hour), end: now},
+			expectedArchive: &interval{start: now.Add(-48 * time.Hour), end: retentionStart},
+		},
+		{
+			name:            "past retention",
+			tenant:          "archive",
+			store:           &interval{start: now.Add(-72 * time.Hour), end: now.Add(-48 * time.Hour)},
+			expectedArchive: &interval{start: now.Add(-72 * time.Hour), end: now.Add(-48 * time.Hour)},
+		},
+		{
+			name:        "archive query too long",
+			tenant:      "archive",
+			store:       &interval{start: now.Add(-10 * 24 * time.Hour), end: now},
+			expectedErr: true,
+		},
+		{
+			name:   "no store query",
+			tenant: "archive",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			archive, err := q.buildArchiveQueryInterval(user.InjectOrgID(context.Background(), tc.tenant), tc.store)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedArchive, archive)
+		})
+	}
+}
+
+func TestQuerier_SelectLogsFromArchive(t *testing.T) {
+	defaults := defaultLimitsTestConfig()
+	defaults.RetentionPeriod = model.Duration(24 * time.Hour)
+	defaults.FeatureFlags = map[string]bool{string(validation.FeatureArchiveQueries): true}
+	limits, err := validation.NewOverrides(defaults, nil)
+	require.NoError(t, err)
+
+	// The archive holds a copy of an entry of the store.
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, mock.Anything).Return(mockStreamIterator(1, 2), nil)
+	archive := newStoreMock()
+	archive.On("SelectLogs", mock.Anything, mock.Anything).Return(mockStreamIterator(2, 2), nil)
+
+	cfg := mockQuerierConfig()
+	cfg.QueryStoreOnly = true
+	q, err := newQuerier(cfg, mockIngesterClientConfig(), newIngesterClientMockFactory(newQuerierClientMock()), mockReadRingWithOneActiveIngester(), store, limits)
+	require.NoError(t, err)
+	q.SetArchiveStore(archive)
+
+	start, end := time.Now().Add(-48*time.Hour), time.Now()
+	it, err := q.SelectLogs(user.InjectOrgID(context.Background(), "test"), logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  `{type="test"}`,
+		Limit:     10,
+		Start:     start,
+		End:       end,
+		Direction: logproto.FORWARD,
+	}})
+	require.NoError(t, err)
+	var entries int
+	for it.Next() {
+		entries++
+	}
+	require.NoError(t, it.Close())
+	require.Equal(t, 3, entries)
+
+	// The archive is read up to the retention period, the store over the whole query.
+	archiveParams := archive.Calls[0].Arguments.Get(1).(logql.SelectLogParams)
+	storeParams := store.Calls[0].Arguments.Get(1).(logql.SelectLogParams)
+	require.Equal(t, start, archiveParams.Start)
+	require.WithinDuration(t, end.Add(-24*time.Hour), archiveParams.End, time.Minute)
+	require.Equal(t, start, storeParams.Start)
+	require.Equal(t, end, storeParams.End)
+}
+
+type mockTenantLimits map[string]*validation.Limits
+
+func (l mockTenantLimits) TenantLimits(userID string) *validation.Limits { return l[userID] }
+
+func (l mockTenantLimits) AllByUserID() map[string]*validation.Limits { return l }
@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -240,6 +241,7 @@ func (Codec) DecodeRequest(_ context.Context, r *http.Request, forwardHeaders []
 			EndTs:   req.End.UTC(),
 			Path:    r.URL.Path,
 			Shards:  req.Shards,
+			Limit:   req.Limit,
 		}, nil
 	case LabelNamesOp:
 		req, err := loghttp.ParseLabelQuery(r)
@@ -262,6 +264,10 @@ func (Codec) EncodeRequest(ctx context.Context, r queryrange.Request) (*http.Req
 	if queryTags != "" {
 		header.Set(string(httpreq.QueryTagsHTTPHeader), queryTags)
 	}
+	queryPriority := getQueryPriority(ctx)
+	if queryPriority != "" {
+		header.Set(string(httpreq.QueryPriorityHTTPHeader), queryPriority)
+	}
 
 	switch request := r.(type) {
 	case *LokiRequest:
@@ -301,6 +307,9 @@ func (Codec) EncodeRequest(ctx context.Context, r queryrange.Request) (*http.Req
 		if len(request.Shards) > 0 {
 			params["shards"] = request.Shards
 		}
+		if request.Limit > 0 {
+			params["limit"] = []string{fmt.Sprintf("%d", request.Limit)}
+		}
 		u := &url.URL{
 			Path:     "/loki/api/v1/series",
 			RawQuery: params.Encode(),
@@ -401,6 +410,7 @@ func (Codec) DecodeResponse(ctx context.Context, r *http.Response, req queryrang
 			Version: uint32(loghttp.GetVersion(req.Path)),
 			Data:    data,
 			Headers: httpResponseHeadersToPromResponseHeaders(r.Header),
+			Limit:   req.Limit,
 		}, nil
 	case *LokiLabelNamesRequest:
 		var resp loghttp.LabelResponse
@@ -418,6 +428,7 @@ func (Codec) DecodeResponse(ctx context.Context, r *http.Response, req queryrang
 		if err := resp.UnmarshalJSON(buf); err != nil {
 			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "error decoding response: %v", err)
 		}
+		addInflightBytes(ctx, resp.Data.Statistics)
 		switch string(resp.Data.ResultType) {
 		case loghttp.ResultTypeMatrix:
 			return &LokiPromResponse{
@@ -498,6 +509,9 @@ func (Codec) EncodeResponse(ctx context.Context, res queryrange.Response) (*http
 			Data:       logqlmodel.Streams(streams),
 			Statistics: response.Statistics,
 		}
+		if loghttp.Version(response.Version) != loghttp.VersionLegacy && httpreq.AcceptsNDJSONFromContext(ctx) {
+			return encodeNDJSONResponse(result), nil
+		}
 		if loghttp.Version(response.Version) == loghttp.VersionLegacy {
 			if err := marshal_legacy.WriteQueryResponseJSON(result, &buf); err != nil {
 				return nil, err
@@ -541,6 +555,23 @@ func (Codec) EncodeResponse(ctx context.Context, res queryrange.Response) (*http
 	return &resp, nil
 }
 
+// encodeNDJSONResponse streams the result as newline delimited JSON while the response body is read,
+// so that the whole response doesn't get buffered. The body must be closed if not fully read.
+func encodeNDJSONResponse(result logqlmodel.Result) *http.Response {
+	r, w := io.Pipe()
+	go func() {
+		_ = w.CloseWithError(marshal.WriteQueryResponseNDJSON(result, w))
+	}()
+
+	return &http.Response{
+		Header: http.Header{
+			"Content-Type": []string{httpreq.NDJSONContentType},
+		},
+		Body:       r,
+		StatusCode: http.StatusOK,
+	}
+}
+
 // NOTE: When we would start caching response from non-metric queries we would have to consider cache gen headers as well in
 // MergeResponse implementation for Loki codecs same as it is done in Cortex at https://github.com/cortexproject/cortex/blob/21bad57b346c730d684d6d0205efef133422ab28/pkg/querier/queryrange/query_range.go#L170
 func (Codec) MergeResponse(responses ...queryrange.Response) (queryrange.Response, error) {
@@ -594,9 +625,13 @@ func (Codec) MergeResponse(responses ...queryrange.Response) (queryrange.Respons
 		uniqueSeries := make(map[string]struct{})
 
 		// only unique series should be merged
+	outer:
 		for _, res := range responses {
 			lokiResult := res.(*LokiSeriesResponse)
 			for _, series := range lokiResult.Data {
+				if lokiSeriesRes.Limit > 0 && len(lokiSeriesData) >= int(lokiSeriesRes.Limit) {
+					break outer
+				}
 				if _, ok := uniqueSeries[series.String()]; !ok {
 					lokiSeriesData = append(lokiSeriesData, series)
 					uniqueSeries[series.String()] = struct{}{}
@@ -608,6 +643,7 @@ func (Codec) MergeResponse(responses ...queryrange.Response) (queryrange.Respons
 			Status:  lokiSeriesRes.Status,
 			Version: lokiSeriesRes.Version,
 			Data:    lokiSeriesData,
+			Limit:   lokiSeriesRes.Limit,
 		}, nil
 	case *LokiLabelNamesResponse:
 		labelNameRes := responses[0].(*LokiLabelNamesResponse)
@@ -864,12 +900,18 @@ func getQueryTags(ctx context.Context) string {
 	return v
 }
 
+func getQueryPriority(ctx context.Context) string {
+	v, _ := ctx.Value(httpreq.QueryPriorityHTTPHeader).(string) // it's ok to be empty
+	return v
+}
+
 func NewEmptyResponse(r queryrange.Request) (queryrange.Response, error) {
 	switch req := r.(type) {
 	case *LokiSeriesRequest:
 		return &LokiSeriesResponse{
 			Status:  loghttp.QueryStatusSuccess,
 			Version: uint32(loghttp.GetVersion(req.Path)),
+			Limit:   req.Limit,
 		}, nil
 	case *LokiLabelNamesRequest:
 		return &LokiLabelNamesResponse{
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	strings "strings"
 	"testing"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/util/httpreq"
 )
 
 func init() {
@@ -71,6 +73,16 @@ func Test_codec_DecodeRequest(t *testing.T) {
 			StartTs: start,
 			EndTs:   end,
 		}, false},
+		{"series with limit", func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet,
+				fmt.Sprintf(`/series?start=%d&end=%d&match={foo="bar"}&limit=10`, start.UnixNano(), end.UnixNano()), nil)
+		}, &LokiSeriesRequest{
+			Match:   []string{`{foo="bar"}`},
+			Path:    "/series",
+			StartTs: start,
+			EndTs:   end,
+			Limit:   10,
+		}, false},
 		{"labels", func() (*http.Request, error) {
 			return http.NewRequest(http.MethodGet,
 				fmt.Sprintf(`/label?start=%d&end=%d`, start.UnixNano(), end.UnixNano()), nil)
@@ -252,6 +264,26 @@ func Test_codec_EncodeRequest(t *testing.T) {
 	require.Equal(t, "/loki/api/v1/query_range", req.(*LokiRequest).Path)
 }
 
+func Test_codec_EncodeRequest_QueryPriority(t *testing.T) {
+	toEncode := &LokiRequest{
+		Query:     `{foo="bar"}`,
+		Limit:     200,
+		Direction: logproto.FORWARD,
+		Path:      "/query_range",
+		StartTs:   start,
+		EndTs:     end,
+	}
+
+	got, err := LokiCodec.EncodeRequest(context.Background(), toEncode)
+	require.NoError(t, err)
+	require.Empty(t, got.Header.Get(string(httpreq.QueryPriorityHTTPHeader)))
+
+	ctx := context.WithValue(context.Background(), httpreq.QueryPriorityHTTPHeader, "high")
+	got, err = LokiCodec.EncodeRequest(ctx, toEncode)
+	require.NoError(t, err)
+	require.Equal(t, "high", got.Header.Get(string(httpreq.QueryPriorityHTTPHeader)))
+}
+
 func Test_codec_series_EncodeRequest(t *testing.T) {
 	got, err := LokiCodec.EncodeRequest(context.TODO(), &queryrange.PrometheusRequest{})
 	require.Error(t, err)
@@ -263,6 +295,7 @@ func Test_codec_series_EncodeRequest(t *testing.T) {
 		Path:    "/series",
 		StartTs: start,
 		EndTs:   end,
+		Limit:   10,
 	}
 	got, err = LokiCodec.EncodeRequest(ctx, toEncode)
 	require.NoError(t, err)
@@ -278,6 +311,7 @@ func Test_codec_series_EncodeRequest(t *testing.T) {
 	require.Equal(t, toEncode.Match, req.(*LokiSeriesRequest).Match)
 	require.Equal(t, toEncode.StartTs, req.(*LokiSeriesRequest).StartTs)
 	require.Equal(t, toEncode.EndTs, req.(*LokiSeriesRequest).EndTs)
+	require.Equal(t, toEncode.Limit, req.(*LokiSeriesRequest).Limit)
 	require.Equal(t, "/loki/api/v1/series", req.(*LokiSeriesRequest).Path)
 }
 
@@ -303,6 +337,47 @@ func Test_codec_labels_EncodeRequest(t *testing.T) {
 	require.Equal(t, "/loki/api/v1/labels", req.(*LokiLabelNamesRequest).Path)
 }
 
+func Test_codec_EncodeResponse_NDJSON(t *testing.T) {
+	// capture a context asking for newline delimited JSON.
+	var ctx context.Context
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range", nil)
+	req.Header.Set("Accept", httpreq.NDJSONContentType)
+	httpreq.ExtractAcceptNDJSONMiddleware().Wrap(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	res := &LokiResponse{
+		Status:    loghttp.QueryStatusSuccess,
+		Direction: logproto.FORWARD,
+		Version:   uint32(loghttp.VersionV1),
+		Data: LokiData{
+			ResultType: loghttp.ResultTypeStream,
+			Result: []logproto.Stream{
+				{Labels: `{foo="bar"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1), Line: "1"}}},
+				{Labels: `{foo="buzz"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 2), Line: "2"}}},
+			},
+		},
+	}
+
+	got, err := LokiCodec.EncodeResponse(ctx, res)
+	require.NoError(t, err)
+	require.Equal(t, httpreq.NDJSONContentType, got.Header.Get("Content-Type"))
+	body, err := ioutil.ReadAll(got.Body)
+	require.NoError(t, err)
+	require.NoError(t, got.Body.Close())
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	require.Len(t, lines, 3)
+	require.JSONEq(t, `{"stream":{"foo":"bar"},"values":[["1","1"]]}`, lines[0])
+	require.JSONEq(t, `{"stream":{"foo":"buzz"},"values":[["2","2"]]}`, lines[1])
+	require.Contains(t, lines[2], `"status":"success"`)
+
+	// Without asking for it, the response is plain JSON.
+	got, err = LokiCodec.EncodeResponse(context.Background(), res)
+	require.NoError(t, err)
+	require.Equal(t, "application/json", got.Header.Get("Content-Type"))
+}
+
 func Test_codec_EncodeResponse(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -813,6 +888,51 @@ func Test_codec_MergeResponse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"loki series limited",
+			[]queryrange.Response{
+				&LokiSeriesResponse{
+					Status:  "success",
+					Version: 1,
+					Limit:   2,
+					Data: []logproto.SeriesIdentifier{
+						{
+							Labels: map[string]string{"filename": "/var/hostlog/apport.log", "job": "varlogs"},
+						},
+					},
+				},
+				&LokiSeriesResponse{
+					Status:  "success",
+					Version: 1,
+					Limit:   2,
+					Data: []logproto.SeriesIdentifier{
+						{
+							Labels: map[string]string{"filename": "/var/hostlog/apport.log", "job": "varlogs"},
+						},
+						{
+							Labels: map[string]string{"filename": "/var/hostlog/test.log", "job": "varlogs"},
+						},
+						{
+							Labels: map[string]string{"filename": "/var/hostlog/other.log", "job": "varlogs"},
+						},
+					},
+				},
+			},
+			&LokiSeriesResponse{
+				Status:  "success",
+				Version: 1,
+				Limit:   2,
+				Data: []logproto.SeriesIdentifier{
+					{
+						Labels: map[string]string{"filename": "/var/hostlog/apport.log", "job": "varlogs"},
+					},
+					{
+						Labels: map[string]string{"filename": "/var/hostlog/test.log", "job": "varlogs"},
+					},
+				},
+			},
+			false,
+		},
 		{
 			"loki labels",
 			[]queryrange.Response{
@@ -879,7 +999,8 @@ var (
 				},
 				"chunksDownloadTime": 0,
 				"totalChunksRef": 0,
-				"totalChunksDownloaded": 0
+				"totalChunksDownloaded": 0,
+				"totalIndexPagesRead": 0
 			},
 			"totalBatches": 6,
 			"totalChunksMatched": 7,
@@ -898,7 +1019,8 @@ var (
 				},
 				"chunksDownloadTime": 16,
 				"totalChunksRef": 17,
-				"totalChunksDownloaded": 18
+				"totalChunksDownloaded": 18,
+				"totalIndexPagesRead": 25
 			}
 		},
 		"summary": {
@@ -906,7 +1028,14 @@ var (
 			"execTime": 21,
 			"linesProcessedPerSecond": 22,
 			"totalBytesProcessed": 23,
-			"totalLinesProcessed": 24
+			"totalLinesProcessed": 24,
+			"chunkCacheHitRatio": 0.5,
+			"indexCacheHitRatio": 0.25,
+			"duplicateRatio": 0.75
+		},
+		"cache": {
+			"chunk": {"entriesRequested": 26, "entriesFound": 27},
+			"index": {"entriesRequested": 28, "entriesFound": 29}
 		}
 	},`
 	matrixString = `{
@@ -1056,6 +1185,9 @@ var (
 			LinesProcessedPerSecond: 22,
 			TotalBytesProcessed:     23,
 			TotalLinesProcessed:     24,
+			ChunkCacheHitRatio:      0.5,
+			IndexCacheHitRatio:      0.25,
+			DuplicateRatio:          0.75,
 		},
 		Querier: stats.Querier{
 			Store: stats.Store{
@@ -1070,6 +1202,7 @@ var (
 				ChunksDownloadTime:    16,
 				TotalChunksRef:        17,
 				TotalChunksDownloaded: 18,
+				TotalIndexPagesRead:   25,
 			},
 		},
 
@@ -1089,6 +1222,11 @@ var (
 			TotalLinesSent:     9,
 			TotalReached:       10,
 		},
+
+		Caches: stats.Caches{
+			Chunk: stats.Cache{EntriesRequested: 26, EntriesFound: 27},
+			Index: stats.Cache{EntriesRequested: 28, EntriesFound: 29},
+		},
 	}
 )
 
@@ -0,0 +1,43 @@
+package queryrange
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/weaveworks/common/httpgrpc"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+const inflightBytesKey ctxKeyType = "inflight_bytes"
+
+// NewInflightRoundTripper tracks the LogQL queries going through next in inflight, with the bytes processed by their
+// subqueries completed so far.
+func NewInflightRoundTripper(inflight *logql.InflightQueries, next http.RoundTripper) http.RoundTripper {
+	return queryrange.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		switch getOperation(req.URL.Path) {
+		case QueryRangeOp, InstantQueryOp:
+		default:
+			return next.RoundTrip(req)
+		}
+		if err := req.ParseForm(); err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+
+		bytes := atomic.NewInt64(0)
+		ctx, done := inflight.Track(context.WithValue(req.Context(), inflightBytesKey, bytes), req.Form.Get("query"), bytes.Load)
+		defer done()
+		return next.RoundTrip(req.WithContext(ctx))
+	})
+}
+
+// addInflightBytes accounts the bytes processed by a subquery to the query of the context tracked by the inflight
+// round tripper, if any.
+func addInflightBytes(ctx context.Context, statistics stats.Result) {
+	if bytes, ok := ctx.Value(inflightBytesKey).(*atomic.Int64); ok {
+		bytes.Add(statistics.Summary.TotalBytesProcessed)
+	}
+}
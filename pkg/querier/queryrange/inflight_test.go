@@ -0,0 +1,53 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+func TestInflightRoundTripper(t *testing.T) {
+	inflight := logql.NewInflightQueries()
+	var listed []logql.InflightQuery
+	rt := NewInflightRoundTripper(inflight, queryrange.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		// A subquery completes, then the query is listed.
+		_, err := LokiCodec.DecodeResponse(req.Context(), &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`{"status":"success","data":{"resultType":"streams","result":[],"stats":{"summary":{"totalBytesProcessed":42}}}}`)),
+		}, &LokiRequest{Query: `{app="foo"}`, Limit: 100, StartTs: time.Unix(0, 0), EndTs: time.Unix(1, 0), Direction: logproto.FORWARD, Path: "/loki/api/v1/query_range"})
+		require.NoError(t, err)
+		listed = inflight.List("")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, `/loki/api/v1/query_range?query={app="foo"}`, nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "fake"))
+	_, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, "fake", listed[0].Tenant)
+	require.Equal(t, `{app="foo"}`, listed[0].Query)
+	require.Equal(t, int64(42), listed[0].BytesProcessed)
+	require.Empty(t, inflight.List(""))
+
+	// Only the LogQL queries are tracked.
+	listed = nil
+	req = httptest.NewRequest(http.MethodGet, `/loki/api/v1/labels`, nil)
+	_, err = NewInflightRoundTripper(inflight, queryrange.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		listed = inflight.List("")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})).RoundTrip(req)
+	require.NoError(t, err)
+	require.Empty(t, listed)
+}
@@ -0,0 +1,139 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+// LogResultCacheMetrics is the metrics wrapper used in log result cache.
+type LogResultCacheMetrics struct {
+	CacheHit  prometheus.Counter
+	CacheMiss prometheus.Counter
+}
+
+// NewLogResultCacheMetrics creates metrics to be used in log result cache.
+func NewLogResultCacheMetrics(registerer prometheus.Registerer) *LogResultCacheMetrics {
+	return &LogResultCacheMetrics{
+		CacheHit: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_log_result_cache_hit_total",
+			Help:      "Total number of log queries served from the results cache.",
+		}),
+		CacheMiss: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_log_result_cache_miss_total",
+			Help:      "Total number of cacheable log queries not found in the results cache.",
+		}),
+	}
+}
+
+type logResultCache struct {
+	next        queryrange.Handler
+	limits      Limits
+	cache       cache.Cache
+	maxChunkAge time.Duration
+	metrics     *LogResultCacheMetrics
+	logger      log.Logger
+}
+
+// NewLogResultCache creates a middleware caching the responses of backward log queries once their time range
+// ended more than the max chunk age ago, since ingesters could still be holding entries of more recent ranges.
+// Responses are only cached for tenants having log results caching enabled.
+func NewLogResultCache(logger log.Logger, limits Limits, cache cache.Cache, maxChunkAge time.Duration, metrics *LogResultCacheMetrics) queryrange.Middleware {
+	if metrics == nil {
+		metrics = NewLogResultCacheMetrics(nil)
+	}
+	return queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
+		return &logResultCache{
+			next:        next,
+			limits:      limits,
+			cache:       cache,
+			maxChunkAge: maxChunkAge,
+			metrics:     metrics,
+			logger:      logger,
+		}
+	})
+}
+
+func (l *logResultCache) Do(ctx context.Context, req queryrange.Request) (queryrange.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	lokiReq, ok := req.(*LokiRequest)
+	if !ok || !l.cacheable(lokiReq, tenantIDs) {
+		return l.next.Do(ctx, req)
+	}
+
+	key := cache.HashKey(l.cacheKey(lokiReq, tenantIDs))
+	found, bufs, _ := l.cache.Fetch(ctx, []string{key})
+	if len(found) == 1 {
+		var cached LokiResponse
+		if err := cached.Unmarshal(bufs[0]); err == nil {
+			l.metrics.CacheHit.Inc()
+			return &cached, nil
+		}
+		level.Warn(util_log.WithContext(ctx, l.logger)).Log("msg", "error unmarshalling cached log result", "err", err)
+	}
+	l.metrics.CacheMiss.Inc()
+
+	resp, err := l.next.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	lokiResp, ok := resp.(*LokiResponse)
+	if !ok || lokiResp.Status != loghttp.QueryStatusSuccess {
+		return resp, nil
+	}
+
+	// statistics describe the work done to compute the response and must not be reported again when served from the cache.
+	toCache := *lokiResp
+	toCache.Statistics = stats.Result{}
+	buf, err := toCache.Marshal()
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, l.logger)).Log("msg", "error marshalling log result to cache", "err", err)
+		return resp, nil
+	}
+	l.cache.Store(ctx, []string{key}, [][]byte{buf})
+
+	return resp, nil
+}
+
+// cacheable tells if the response of the request can be cached: only backward queries have a stable result once
+// their time range is complete, forward queries are usually tailing the most recent entries.
+func (l *logResultCache) cacheable(req *LokiRequest, tenantIDs []string) bool {
+	if req.Direction != logproto.BACKWARD || req.GetCachingOptions().Disabled {
+		return false
+	}
+	if req.EndTs.After(time.Now().Add(-l.maxChunkAge)) {
+		return false
+	}
+	for _, tenantID := range tenantIDs {
+		if !l.limits.CacheLogResults(tenantID) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *logResultCache) cacheKey(req *LokiRequest, tenantIDs []string) string {
+	return fmt.Sprintf("log:%s:%s:%d:%d:%d:%v", tenant.JoinTenantIDs(tenantIDs), req.Query, req.Limit, req.StartTs.UnixNano(), req.EndTs.UnixNano(), req.Shards)
+}
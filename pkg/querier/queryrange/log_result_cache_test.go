@@ -0,0 +1,117 @@
+package queryrange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+func Test_LogResultCache(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	end := time.Now().Add(-3 * time.Hour)
+
+	for _, tc := range []struct {
+		name     string
+		limits   fakeLimits
+		req      *LokiRequest
+		expected int
+	}{
+		{
+			name:     "cached",
+			limits:   fakeLimits{cacheLogResults: true},
+			req:      &LokiRequest{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+			expected: 1,
+		},
+		{
+			name:     "disabled for tenant",
+			limits:   fakeLimits{},
+			req:      &LokiRequest{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+			expected: 2,
+		},
+		{
+			name:     "forward",
+			limits:   fakeLimits{cacheLogResults: true},
+			req:      &LokiRequest{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.FORWARD},
+			expected: 2,
+		},
+		{
+			name:     "more recent than max chunk age",
+			limits:   fakeLimits{cacheLogResults: true},
+			req:      &LokiRequest{Query: `{app="foo"}`, Limit: 100, StartTs: end, EndTs: time.Now().Add(-time.Hour), Direction: logproto.BACKWARD},
+			expected: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			h := NewLogResultCache(log.NewNopLogger(), tc.limits, cache.NewMockCache(), 2*time.Hour, nil).Wrap(queryrange.HandlerFunc(func(_ context.Context, r queryrange.Request) (queryrange.Response, error) {
+				calls++
+				return &LokiResponse{
+					Status:     loghttp.QueryStatusSuccess,
+					Direction:  r.(*LokiRequest).Direction,
+					Limit:      r.(*LokiRequest).Limit,
+					Version:    uint32(loghttp.VersionV1),
+					Statistics: stats.Result{Summary: stats.Summary{TotalBytesProcessed: 10}},
+					Data: LokiData{
+						ResultType: loghttp.ResultTypeStream,
+						Result: []logproto.Stream{
+							{
+								Labels:  `{app="foo"}`,
+								Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1), Line: "foo"}},
+							},
+						},
+					},
+				}, nil
+			}))
+
+			first, err := h.Do(ctx, tc.req)
+			require.NoError(t, err)
+			second, err := h.Do(ctx, tc.req)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, calls)
+			require.Equal(t, first.(*LokiResponse).Data, second.(*LokiResponse).Data)
+
+			if tc.expected == 1 {
+				// statistics are not reported again for cached responses.
+				require.Equal(t, stats.Result{}, second.(*LokiResponse).Statistics)
+			}
+		})
+	}
+}
+
+func Test_LogResultCache_KeyIncludesRequest(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "foo")
+	end := time.Now().Add(-3 * time.Hour)
+
+	calls := 0
+	h := NewLogResultCache(log.NewNopLogger(), fakeLimits{cacheLogResults: true}, cache.NewMockCache(), time.Hour, nil).Wrap(queryrange.HandlerFunc(func(_ context.Context, r queryrange.Request) (queryrange.Response, error) {
+		calls++
+		return &LokiResponse{Status: loghttp.QueryStatusSuccess}, nil
+	}))
+
+	for _, req := range []*LokiRequest{
+		{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+		{Query: `{app="bar"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+		{Query: `{app="foo"}`, Limit: 10, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+		{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-2 * time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+		{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD},
+	} {
+		_, err := h.Do(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 4, calls)
+
+	// tenants don't share cached results.
+	_, err := h.Do(user.InjectOrgID(context.Background(), "bar"), &LokiRequest{Query: `{app="foo"}`, Limit: 100, StartTs: end.Add(-time.Hour), EndTs: end, Direction: logproto.BACKWARD})
+	require.NoError(t, err)
+	require.Equal(t, 5, calls)
+}
@@ -18,6 +18,7 @@ var emptyStats = `"stats": {
 			"chunksDownloadTime": 0,
 			"totalChunksRef": 0,
 			"totalChunksDownloaded": 0,
+			"totalIndexPagesRead": 0,
 			"chunk" :{
 				"compressedBytes": 0,
 				"decompressedBytes": 0,
@@ -37,6 +38,7 @@ var emptyStats = `"stats": {
 			"chunksDownloadTime": 0,
 			"totalChunksRef": 0,
 			"totalChunksDownloaded": 0,
+			"totalIndexPagesRead": 0,
 			"chunk" :{
 				"compressedBytes": 0,
 				"decompressedBytes": 0,
@@ -52,7 +54,14 @@ var emptyStats = `"stats": {
 		"execTime": 0,
 		"linesProcessedPerSecond": 0,
 		"totalBytesProcessed":0,
-		"totalLinesProcessed":0
+		"totalLinesProcessed": 0,
+		"chunkCacheHitRatio": 0,
+		"indexCacheHitRatio": 0,
+		"duplicateRatio": 0
+	},
+	"cache": {
+		"chunk": {"entriesRequested": 0, "entriesFound": 0},
+		"index": {"entriesRequested": 0, "entriesFound": 0}
 	}
 }`
 
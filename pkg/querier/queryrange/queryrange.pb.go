@@ -322,6 +322,7 @@ type LokiSeriesRequest struct {
 	EndTs   time.Time `protobuf:"bytes,3,opt,name=endTs,proto3,stdtime" json:"endTs"`
 	Path    string    `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
 	Shards  []string  `protobuf:"bytes,5,rep,name=shards,proto3" json:"shards"`
+	Limit   uint32    `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
 }
 
 func (m *LokiSeriesRequest) Reset()      { *m = LokiSeriesRequest{} }
@@ -391,11 +392,19 @@ func (m *LokiSeriesRequest) GetShards() []string {
 	return nil
 }
 
+func (m *LokiSeriesRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
 type LokiSeriesResponse struct {
 	Status  string                                                                            `protobuf:"bytes,1,opt,name=Status,proto3" json:"status"`
 	Data    []logproto.SeriesIdentifier                                                       `protobuf:"bytes,2,rep,name=Data,proto3" json:"data,omitempty"`
 	Version uint32                                                                            `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
 	Headers []github_com_cortexproject_cortex_pkg_querier_queryrange.PrometheusResponseHeader `protobuf:"bytes,4,rep,name=Headers,proto3,customtype=github.com/cortexproject/cortex/pkg/querier/queryrange.PrometheusResponseHeader" json:"-"`
+	Limit   uint32                                                                            `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
 }
 
 func (m *LokiSeriesResponse) Reset()      { *m = LokiSeriesResponse{} }
@@ -451,6 +460,13 @@ func (m *LokiSeriesResponse) GetVersion() uint32 {
 	return 0
 }
 
+func (m *LokiSeriesResponse) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
 type LokiLabelNamesRequest struct {
 	StartTs time.Time `protobuf:"bytes,1,opt,name=startTs,proto3,stdtime" json:"startTs"`
 	EndTs   time.Time `protobuf:"bytes,2,opt,name=endTs,proto3,stdtime" json:"endTs"`
@@ -683,65 +699,65 @@ func init() {
 }
 
 var fileDescriptor_51b9d53b40d11902 = []byte{
-	// 913 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x56, 0x4f, 0x6f, 0x23, 0x35,
-	0x14, 0x8f, 0xf3, 0xb7, 0x71, 0xd9, 0x02, 0xee, 0xb2, 0x3b, 0x2a, 0xd2, 0x4c, 0x14, 0x21, 0x08,
-	0x82, 0x9d, 0x88, 0x2e, 0x5c, 0x10, 0xa0, 0xdd, 0xd1, 0xf2, 0x67, 0xa5, 0x15, 0x20, 0x6f, 0x0e,
-	0x5c, 0xdd, 0x8c, 0x3b, 0x19, 0x3a, 0x33, 0x9e, 0xda, 0x0e, 0xa2, 0x37, 0xae, 0xdc, 0xf6, 0x06,
-	0x7c, 0x02, 0x10, 0x67, 0xf8, 0x0e, 0x3d, 0xf6, 0xb8, 0x5a, 0x89, 0x81, 0xa6, 0x17, 0xc8, 0x69,
-	0x3f, 0x02, 0xb2, 0x3d, 0x93, 0xb8, 0xa8, 0x85, 0xa6, 0xbd, 0xa0, 0xbd, 0x24, 0x7e, 0xcf, 0xef,
-	0xd9, 0xef, 0xf7, 0x7b, 0x3f, 0x3f, 0x0d, 0x7c, 0x2d, 0xdf, 0x8b, 0x86, 0xfb, 0x53, 0xca, 0x63,
-	0xca, 0xf5, 0xff, 0x01, 0x27, 0x59, 0x44, 0xad, 0xa5, 0x9f, 0x73, 0x26, 0x19, 0x82, 0x4b, 0xcf,
-	0xd6, 0xad, 0x28, 0x96, 0x93, 0xe9, 0x8e, 0x3f, 0x66, 0xe9, 0x30, 0x62, 0x11, 0x1b, 0xea, 0x90,
-	0x9d, 0xe9, 0xae, 0xb6, 0xb4, 0xa1, 0x57, 0x26, 0x75, 0xeb, 0x65, 0x75, 0x47, 0xc2, 0x22, 0xb3,
-	0x51, 0x2d, 0xca, 0xcd, 0x5e, 0xb9, 0xb9, 0x9f, 0xa4, 0x2c, 0xa4, 0xc9, 0x50, 0x48, 0x22, 0x85,
-	0xf9, 0x2d, 0x23, 0x3e, 0xb6, 0x6e, 0x1b, 0x33, 0x2e, 0xe9, 0xd7, 0x39, 0x67, 0x5f, 0xd2, 0xb1,
-	0x2c, 0xad, 0xe1, 0x05, 0x21, 0x6c, 0x79, 0x11, 0x63, 0x51, 0x42, 0x97, 0xd5, 0xca, 0x38, 0xa5,
-	0x42, 0x92, 0x34, 0x37, 0x01, 0xfd, 0x5f, 0xea, 0x70, 0xfd, 0x01, 0xdb, 0x8b, 0x31, 0xdd, 0x9f,
-	0x52, 0x21, 0xd1, 0x75, 0xd8, 0xd2, 0x87, 0x38, 0xa0, 0x07, 0x06, 0x5d, 0x6c, 0x0c, 0xe5, 0x4d,
-	0xe2, 0x34, 0x96, 0x4e, 0xbd, 0x07, 0x06, 0xd7, 0xb0, 0x31, 0x10, 0x82, 0x4d, 0x21, 0x69, 0xee,
-	0x34, 0x7a, 0x60, 0xd0, 0xc0, 0x7a, 0x8d, 0x3e, 0x80, 0x1d, 0x21, 0x09, 0x97, 0x23, 0xe1, 0x34,
-	0x7b, 0x60, 0xb0, 0xbe, 0xbd, 0xe5, 0x9b, 0x12, 0xfc, 0xaa, 0x04, 0x7f, 0x54, 0x95, 0x10, 0xac,
-	0x1d, 0x16, 0x5e, 0xed, 0xd1, 0xef, 0x1e, 0xc0, 0x55, 0x12, 0x7a, 0x17, 0xb6, 0x68, 0x16, 0x8e,
-	0x84, 0xd3, 0x5a, 0x21, 0xdb, 0xa4, 0xa0, 0xb7, 0x60, 0x37, 0x8c, 0x39, 0x1d, 0xcb, 0x98, 0x65,
-	0x4e, 0xbb, 0x07, 0x06, 0x1b, 0xdb, 0x9b, 0xfe, 0x82, 0xfb, 0x7b, 0xd5, 0x16, 0x5e, 0x46, 0x29,
-	0x08, 0x39, 0x91, 0x13, 0xa7, 0xa3, 0xd1, 0xea, 0x35, 0xea, 0xc3, 0xb6, 0x98, 0x10, 0x1e, 0x0a,
-	0x67, 0xad, 0xd7, 0x18, 0x74, 0x03, 0x38, 0x2f, 0xbc, 0xd2, 0x83, 0xcb, 0xff, 0xfe, 0x5f, 0x00,
-	0x22, 0x45, 0xdb, 0xfd, 0x4c, 0x48, 0x92, 0xc9, 0xcb, 0xb0, 0xf7, 0x1e, 0x6c, 0xab, 0x66, 0x8c,
-	0x84, 0xe6, 0xef, 0xa2, 0x50, 0xcb, 0x9c, 0xd3, 0x58, 0x9b, 0x2b, 0x61, 0x6d, 0x9d, 0x89, 0xb5,
-	0x7d, 0x2e, 0xd6, 0xef, 0x9a, 0xf0, 0x39, 0x23, 0x11, 0x91, 0xb3, 0x4c, 0x50, 0x95, 0xf4, 0x50,
-	0x12, 0x39, 0x15, 0x06, 0x66, 0x99, 0xa4, 0x3d, 0xb8, 0xdc, 0x41, 0x77, 0x60, 0xf3, 0x1e, 0x91,
-	0x44, 0x43, 0x5e, 0xdf, 0xbe, 0xee, 0x5b, 0xca, 0x54, 0x67, 0xa9, 0xbd, 0xe0, 0x86, 0x42, 0x35,
-	0x2f, 0xbc, 0x8d, 0x90, 0x48, 0xf2, 0x26, 0x4b, 0x63, 0x49, 0xd3, 0x5c, 0x1e, 0x60, 0x9d, 0x89,
-	0xde, 0x81, 0xdd, 0x0f, 0x39, 0x67, 0x7c, 0x74, 0x90, 0x53, 0x4d, 0x51, 0x37, 0xb8, 0x39, 0x2f,
-	0xbc, 0x4d, 0x5a, 0x39, 0xad, 0x8c, 0x65, 0x24, 0x7a, 0x1d, 0xb6, 0xb4, 0xa1, 0x49, 0xe9, 0x06,
-	0x9b, 0xf3, 0xc2, 0x7b, 0x5e, 0xa7, 0x58, 0xe1, 0x26, 0xe2, 0x34, 0x87, 0xad, 0x0b, 0x71, 0xb8,
-	0x68, 0x65, 0xdb, 0x6e, 0xa5, 0x03, 0x3b, 0x5f, 0x51, 0x2e, 0xd4, 0x31, 0x1d, 0xed, 0xaf, 0x4c,
-	0x74, 0x17, 0x42, 0x45, 0x4c, 0x2c, 0x64, 0x3c, 0x56, 0x7a, 0x52, 0x64, 0x5c, 0xf3, 0xcd, 0x53,
-	0xc7, 0x54, 0x4c, 0x13, 0x19, 0xa0, 0x92, 0x05, 0x2b, 0x10, 0x5b, 0x6b, 0xf4, 0x3d, 0x80, 0x9d,
-	0x4f, 0x28, 0x09, 0x29, 0x17, 0x4e, 0xb7, 0xd7, 0x18, 0xac, 0x6f, 0xbf, 0x62, 0xb3, 0xf9, 0x39,
-	0x67, 0x29, 0x95, 0x13, 0x3a, 0x15, 0x55, 0x7f, 0x4c, 0x70, 0xf0, 0xc5, 0x93, 0xc2, 0xfb, 0xec,
-	0x72, 0x73, 0xe4, 0xdc, 0x43, 0xe7, 0x85, 0x07, 0x6e, 0xe1, 0xaa, 0x9c, 0xfe, 0x6f, 0x00, 0xbe,
-	0xa8, 0xba, 0xf9, 0x50, 0x1d, 0x20, 0xac, 0x47, 0x90, 0x12, 0x39, 0x9e, 0x38, 0x40, 0x49, 0x0a,
-	0x1b, 0xc3, 0x1e, 0x0c, 0xf5, 0x2b, 0x0d, 0x86, 0xc6, 0xea, 0x83, 0xa1, 0x52, 0x7e, 0xf3, 0x4c,
-	0xe5, 0xb7, 0xce, 0x55, 0xfe, 0xaf, 0x75, 0xf3, 0xca, 0x2b, 0x7c, 0x2b, 0xe8, 0xff, 0xa3, 0x85,
-	0xfe, 0x1b, 0xba, 0xda, 0x85, 0xac, 0xcc, 0x59, 0xf7, 0x43, 0x9a, 0xc9, 0x78, 0x37, 0xa6, 0xfc,
-	0x3f, 0x5e, 0x81, 0x25, 0xad, 0xc6, 0x69, 0x69, 0xd9, 0xba, 0x68, 0xfe, 0xbf, 0x74, 0xf1, 0x23,
-	0x80, 0x2f, 0x29, 0xde, 0x1e, 0x90, 0x1d, 0x9a, 0x7c, 0x4a, 0xd2, 0xa5, 0x36, 0x2c, 0x15, 0x80,
-	0x2b, 0xa9, 0xa0, 0x7e, 0x79, 0x15, 0x34, 0x96, 0x2a, 0xe8, 0xff, 0x50, 0x87, 0x37, 0xfe, 0x59,
-	0xe9, 0x0a, 0x5d, 0x7e, 0xd5, 0xea, 0x72, 0x37, 0x40, 0xcf, 0x56, 0x17, 0x7f, 0x06, 0x70, 0xad,
-	0x9a, 0xd5, 0xc8, 0x87, 0xd0, 0xcc, 0x2b, 0x3d, 0x8e, 0x0d, 0x23, 0x1b, 0x6a, 0x6a, 0xf1, 0x85,
-	0x17, 0x5b, 0x11, 0x28, 0x83, 0x6d, 0x63, 0x95, 0x2f, 0xe0, 0xa6, 0xf5, 0x02, 0x24, 0xa7, 0x24,
-	0xbd, 0x1b, 0x92, 0x5c, 0x52, 0x1e, 0xbc, 0xaf, 0xda, 0xf4, 0xa4, 0xf0, 0xde, 0xb0, 0x3f, 0xb0,
-	0x38, 0xd9, 0x25, 0x19, 0x19, 0x26, 0x6c, 0x2f, 0x1e, 0xda, 0x5f, 0x52, 0x65, 0xae, 0xea, 0x84,
-	0xb9, 0x17, 0x97, 0xb7, 0xf4, 0xbf, 0x05, 0xf0, 0x05, 0x55, 0xac, 0xc2, 0xb6, 0x68, 0xe1, 0x1d,
-	0xb8, 0xc6, 0xcb, 0x75, 0x29, 0x37, 0xf7, 0xdf, 0xc9, 0x0d, 0x9a, 0x87, 0x85, 0x07, 0xf0, 0x22,
-	0x0b, 0xdd, 0x3e, 0x35, 0xbf, 0xeb, 0x67, 0xcd, 0x6f, 0x95, 0x52, 0xb3, 0x27, 0x76, 0xf0, 0xf6,
-	0xd1, 0xb1, 0x5b, 0x7b, 0x7c, 0xec, 0xd6, 0x9e, 0x1e, 0xbb, 0xe0, 0x9b, 0x99, 0x0b, 0x7e, 0x9a,
-	0xb9, 0xe0, 0x70, 0xe6, 0x82, 0xa3, 0x99, 0x0b, 0xfe, 0x98, 0xb9, 0xe0, 0xcf, 0x99, 0x5b, 0x7b,
-	0x3a, 0x73, 0xc1, 0xa3, 0x13, 0xb7, 0x76, 0x74, 0xe2, 0xd6, 0x1e, 0x9f, 0xb8, 0xb5, 0x9d, 0xb6,
-	0x46, 0x78, 0xfb, 0xef, 0x00, 0x00, 0x00, 0xff, 0xff, 0x52, 0x92, 0xbf, 0x59, 0x9f, 0x0a, 0x00,
-	0x00,
+	// 923 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x56, 0x4f, 0x8f, 0x1b, 0x35,
+	0x14, 0x8f, 0x33, 0xf9, 0xb3, 0xf1, 0xd2, 0x05, 0xbc, 0xa5, 0x1d, 0x2d, 0xd2, 0x4c, 0x14, 0x21,
+	0x08, 0x82, 0x4e, 0xc4, 0x16, 0x2e, 0x08, 0x50, 0x3b, 0x2a, 0x7f, 0x2a, 0x55, 0x80, 0xdc, 0x1c,
+	0xb8, 0x7a, 0x13, 0xef, 0x64, 0xd8, 0x99, 0xf1, 0xac, 0xed, 0x20, 0xf6, 0xc6, 0x95, 0x5b, 0x6f,
+	0xc0, 0x27, 0x00, 0x71, 0xe6, 0x43, 0xac, 0xc4, 0x65, 0x8f, 0x55, 0x0f, 0x03, 0x9b, 0xbd, 0x40,
+	0x4e, 0x95, 0xf8, 0x02, 0xc8, 0xf6, 0x4c, 0xe2, 0x45, 0x59, 0x68, 0xb6, 0x17, 0xd4, 0x4b, 0xe2,
+	0x67, 0xbf, 0x67, 0xbf, 0xdf, 0xef, 0xfd, 0xde, 0xd3, 0xc0, 0xd7, 0xf2, 0x83, 0x68, 0x70, 0x38,
+	0xa5, 0x3c, 0xa6, 0x5c, 0xff, 0x1f, 0x71, 0x92, 0x45, 0xd4, 0x5a, 0x06, 0x39, 0x67, 0x92, 0x21,
+	0xb8, 0xdc, 0xd9, 0xb9, 0x11, 0xc5, 0x72, 0x32, 0xdd, 0x0b, 0x46, 0x2c, 0x1d, 0x44, 0x2c, 0x62,
+	0x03, 0xed, 0xb2, 0x37, 0xdd, 0xd7, 0x96, 0x36, 0xf4, 0xca, 0x84, 0xee, 0xbc, 0xac, 0xde, 0x48,
+	0x58, 0x64, 0x0e, 0xaa, 0x45, 0x79, 0xd8, 0x2d, 0x0f, 0x0f, 0x93, 0x94, 0x8d, 0x69, 0x32, 0x10,
+	0x92, 0x48, 0x61, 0x7e, 0x4b, 0x8f, 0x8f, 0xad, 0xd7, 0x46, 0x8c, 0x4b, 0xfa, 0x75, 0xce, 0xd9,
+	0x97, 0x74, 0x24, 0x4b, 0x6b, 0xf0, 0x84, 0x10, 0x76, 0xfc, 0x88, 0xb1, 0x28, 0xa1, 0xcb, 0x6c,
+	0x65, 0x9c, 0x52, 0x21, 0x49, 0x9a, 0x1b, 0x87, 0xde, 0x2f, 0x75, 0xb8, 0x79, 0x8f, 0x1d, 0xc4,
+	0x98, 0x1e, 0x4e, 0xa9, 0x90, 0xe8, 0x2a, 0x6c, 0xea, 0x4b, 0x5c, 0xd0, 0x05, 0xfd, 0x0e, 0x36,
+	0x86, 0xda, 0x4d, 0xe2, 0x34, 0x96, 0x6e, 0xbd, 0x0b, 0xfa, 0x57, 0xb0, 0x31, 0x10, 0x82, 0x0d,
+	0x21, 0x69, 0xee, 0x3a, 0x5d, 0xd0, 0x77, 0xb0, 0x5e, 0xa3, 0x0f, 0x60, 0x5b, 0x48, 0xc2, 0xe5,
+	0x50, 0xb8, 0x8d, 0x2e, 0xe8, 0x6f, 0xee, 0xee, 0x04, 0x26, 0x85, 0xa0, 0x4a, 0x21, 0x18, 0x56,
+	0x29, 0x84, 0x1b, 0xc7, 0x85, 0x5f, 0x7b, 0xf0, 0x9b, 0x0f, 0x70, 0x15, 0x84, 0xde, 0x85, 0x4d,
+	0x9a, 0x8d, 0x87, 0xc2, 0x6d, 0xae, 0x11, 0x6d, 0x42, 0xd0, 0x5b, 0xb0, 0x33, 0x8e, 0x39, 0x1d,
+	0xc9, 0x98, 0x65, 0x6e, 0xab, 0x0b, 0xfa, 0x5b, 0xbb, 0xdb, 0xc1, 0x82, 0xfb, 0x3b, 0xd5, 0x11,
+	0x5e, 0x7a, 0x29, 0x08, 0x39, 0x91, 0x13, 0xb7, 0xad, 0xd1, 0xea, 0x35, 0xea, 0xc1, 0x96, 0x98,
+	0x10, 0x3e, 0x16, 0xee, 0x46, 0xd7, 0xe9, 0x77, 0x42, 0x38, 0x2f, 0xfc, 0x72, 0x07, 0x97, 0xff,
+	0xbd, 0x3f, 0x01, 0x44, 0x8a, 0xb6, 0xbb, 0x99, 0x90, 0x24, 0x93, 0x97, 0x61, 0xef, 0x3d, 0xd8,
+	0x52, 0xc5, 0x18, 0x0a, 0xd7, 0x59, 0x03, 0x6a, 0x19, 0x73, 0x1e, 0x6b, 0x63, 0x2d, 0xac, 0xcd,
+	0x95, 0x58, 0x5b, 0x17, 0x62, 0xfd, 0xae, 0x01, 0x9f, 0x33, 0x12, 0x11, 0x39, 0xcb, 0x04, 0x55,
+	0x41, 0xf7, 0x25, 0x91, 0x53, 0x61, 0x60, 0x96, 0x41, 0x7a, 0x07, 0x97, 0x27, 0xe8, 0x16, 0x6c,
+	0xdc, 0x21, 0x92, 0x68, 0xc8, 0x9b, 0xbb, 0x57, 0x03, 0x4b, 0x99, 0xea, 0x2e, 0x75, 0x16, 0x5e,
+	0x53, 0xa8, 0xe6, 0x85, 0xbf, 0x35, 0x26, 0x92, 0xbc, 0xc9, 0xd2, 0x58, 0xd2, 0x34, 0x97, 0x47,
+	0x58, 0x47, 0xa2, 0x77, 0x60, 0xe7, 0x43, 0xce, 0x19, 0x1f, 0x1e, 0xe5, 0x54, 0x53, 0xd4, 0x09,
+	0xaf, 0xcf, 0x0b, 0x7f, 0x9b, 0x56, 0x9b, 0x56, 0xc4, 0xd2, 0x13, 0xbd, 0x0e, 0x9b, 0xda, 0xd0,
+	0xa4, 0x74, 0xc2, 0xed, 0x79, 0xe1, 0x3f, 0xaf, 0x43, 0x2c, 0x77, 0xe3, 0x71, 0x9e, 0xc3, 0xe6,
+	0x13, 0x71, 0xb8, 0x28, 0x65, 0xcb, 0x2e, 0xa5, 0x0b, 0xdb, 0x5f, 0x51, 0x2e, 0xd4, 0x35, 0x6d,
+	0xbd, 0x5f, 0x99, 0xe8, 0x36, 0x84, 0x8a, 0x98, 0x58, 0xc8, 0x78, 0xa4, 0xf4, 0xa4, 0xc8, 0xb8,
+	0x12, 0x98, 0x56, 0xc7, 0x54, 0x4c, 0x13, 0x19, 0xa2, 0x92, 0x05, 0xcb, 0x11, 0x5b, 0x6b, 0xf4,
+	0x3d, 0x80, 0xed, 0x4f, 0x28, 0x19, 0x53, 0x2e, 0xdc, 0x4e, 0xd7, 0xe9, 0x6f, 0xee, 0xbe, 0x62,
+	0xb3, 0xf9, 0x39, 0x67, 0x29, 0x95, 0x13, 0x3a, 0x15, 0x55, 0x7d, 0x8c, 0x73, 0xf8, 0xc5, 0xa3,
+	0xc2, 0xff, 0xec, 0x72, 0x73, 0xe4, 0xc2, 0x4b, 0xe7, 0x85, 0x0f, 0x6e, 0xe0, 0x2a, 0x9d, 0xde,
+	0x5f, 0x00, 0xbe, 0xa8, 0xaa, 0x79, 0x5f, 0x5d, 0x20, 0xac, 0x26, 0x48, 0x89, 0x1c, 0x4d, 0x5c,
+	0xa0, 0x24, 0x85, 0x8d, 0x61, 0x0f, 0x86, 0xfa, 0x53, 0x0d, 0x06, 0x67, 0xfd, 0xc1, 0x50, 0x29,
+	0xbf, 0xb1, 0x52, 0xf9, 0xcd, 0x8b, 0x94, 0xbf, 0xba, 0xda, 0xbd, 0x5f, 0xeb, 0x10, 0xd9, 0xa8,
+	0xd7, 0xe8, 0x8a, 0x8f, 0x16, 0x5d, 0xe1, 0x68, 0x0c, 0x0b, 0xb1, 0x99, 0xbb, 0xee, 0x8e, 0x69,
+	0x26, 0xe3, 0xfd, 0x98, 0xf2, 0xff, 0xe8, 0x0d, 0x4b, 0x70, 0xce, 0x79, 0xc1, 0xd9, 0x6a, 0x69,
+	0xfc, 0xaf, 0xd4, 0xb2, 0x64, 0xb3, 0x69, 0xb3, 0xf9, 0x23, 0x80, 0x2f, 0x29, 0x36, 0xef, 0x91,
+	0x3d, 0x9a, 0x7c, 0x4a, 0xd2, 0xa5, 0x8e, 0x2c, 0xc5, 0x80, 0xa7, 0x52, 0x4c, 0xfd, 0xf2, 0x8a,
+	0x71, 0x96, 0x8a, 0xe9, 0xfd, 0x50, 0x87, 0xd7, 0xfe, 0x99, 0xe9, 0x1a, 0xb5, 0x7f, 0xd5, 0xaa,
+	0x7d, 0x27, 0x44, 0xcf, 0x54, 0x6d, 0x7b, 0x3f, 0x03, 0xb8, 0x51, 0xcd, 0x75, 0x14, 0x40, 0x68,
+	0x66, 0x9b, 0x1e, 0xdd, 0x86, 0x91, 0x2d, 0x35, 0xe1, 0xf8, 0x62, 0x17, 0x5b, 0x1e, 0x28, 0x83,
+	0x2d, 0x63, 0x95, 0x7d, 0x71, 0xdd, 0xea, 0x0b, 0xc9, 0x29, 0x49, 0x6f, 0x8f, 0x49, 0x2e, 0x29,
+	0x0f, 0xdf, 0x57, 0x65, 0x7a, 0x54, 0xf8, 0x6f, 0xd8, 0x1f, 0x63, 0x9c, 0xec, 0x93, 0x8c, 0x0c,
+	0x12, 0x76, 0x10, 0x0f, 0xec, 0xaf, 0xae, 0x32, 0x56, 0x55, 0xc2, 0xbc, 0x8b, 0xcb, 0x57, 0x7a,
+	0xdf, 0x02, 0xf8, 0x82, 0x4a, 0x56, 0x61, 0x5b, 0x94, 0xf0, 0x16, 0xdc, 0xe0, 0xe5, 0xba, 0x94,
+	0x9b, 0xf7, 0xef, 0xe4, 0x86, 0x8d, 0xe3, 0xc2, 0x07, 0x78, 0x11, 0x85, 0x6e, 0x9e, 0x9b, 0xf5,
+	0xf5, 0x55, 0xb3, 0x5e, 0x85, 0xd4, 0xec, 0xe9, 0x1e, 0xbe, 0x7d, 0x72, 0xea, 0xd5, 0x1e, 0x9e,
+	0x7a, 0xb5, 0xc7, 0xa7, 0x1e, 0xf8, 0x66, 0xe6, 0x81, 0x9f, 0x66, 0x1e, 0x38, 0x9e, 0x79, 0xe0,
+	0x64, 0xe6, 0x81, 0xdf, 0x67, 0x1e, 0xf8, 0x63, 0xe6, 0xd5, 0x1e, 0xcf, 0x3c, 0xf0, 0xe0, 0xcc,
+	0xab, 0x9d, 0x9c, 0x79, 0xb5, 0x87, 0x67, 0x5e, 0x6d, 0xaf, 0xa5, 0x11, 0xde, 0xfc, 0x3b, 0x00,
+	0x00, 0xff, 0xff, 0x33, 0x12, 0x30, 0xb5, 0xcb, 0x0a, 0x00, 0x00,
 }
 
 func (this *LokiRequest) Equal(that interface{}) bool {
@@ -935,6 +951,9 @@ func (this *LokiSeriesRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.Limit != that1.Limit {
+		return false
+	}
 	return true
 }
 func (this *LokiSeriesResponse) Equal(that interface{}) bool {
@@ -978,6 +997,9 @@ func (this *LokiSeriesResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.Limit != that1.Limit {
+		return false
+	}
 	return true
 }
 func (this *LokiLabelNamesRequest) Equal(that interface{}) bool {
@@ -1166,13 +1188,14 @@ func (this *LokiSeriesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&queryrange.LokiSeriesRequest{")
 	s = append(s, "Match: "+fmt.Sprintf("%#v", this.Match)+",\n")
 	s = append(s, "StartTs: "+fmt.Sprintf("%#v", this.StartTs)+",\n")
 	s = append(s, "EndTs: "+fmt.Sprintf("%#v", this.EndTs)+",\n")
 	s = append(s, "Path: "+fmt.Sprintf("%#v", this.Path)+",\n")
 	s = append(s, "Shards: "+fmt.Sprintf("%#v", this.Shards)+",\n")
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1180,7 +1203,7 @@ func (this *LokiSeriesResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&queryrange.LokiSeriesResponse{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	if this.Data != nil {
@@ -1192,6 +1215,7 @@ func (this *LokiSeriesResponse) GoString() string {
 	}
 	s = append(s, "Version: "+fmt.Sprintf("%#v", this.Version)+",\n")
 	s = append(s, "Headers: "+fmt.Sprintf("%#v", this.Headers)+",\n")
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1506,6 +1530,11 @@ func (m *LokiSeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintQueryrange(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x30
+	}
 	if len(m.Shards) > 0 {
 		for iNdEx := len(m.Shards) - 1; iNdEx >= 0; iNdEx-- {
 			i -= len(m.Shards[iNdEx])
@@ -1570,6 +1599,11 @@ func (m *LokiSeriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Limit != 0 {
+		i = encodeVarintQueryrange(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x28
+	}
 	if len(m.Headers) > 0 {
 		for iNdEx := len(m.Headers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1950,6 +1984,9 @@ func (m *LokiSeriesRequest) Size() (n int) {
 			n += 1 + l + sovQueryrange(uint64(l))
 		}
 	}
+	if m.Limit != 0 {
+		n += 1 + sovQueryrange(uint64(m.Limit))
+	}
 	return n
 }
 
@@ -1978,6 +2015,9 @@ func (m *LokiSeriesResponse) Size() (n int) {
 			n += 1 + l + sovQueryrange(uint64(l))
 		}
 	}
+	if m.Limit != 0 {
+		n += 1 + sovQueryrange(uint64(m.Limit))
+	}
 	return n
 }
 
@@ -2126,6 +2166,7 @@ func (this *LokiSeriesRequest) String() string {
 		`EndTs:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EndTs), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Path:` + fmt.Sprintf("%v", this.Path) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2144,6 +2185,7 @@ func (this *LokiSeriesResponse) String() string {
 		`Data:` + repeatedStringForData + `,`,
 		`Version:` + fmt.Sprintf("%v", this.Version) + `,`,
 		`Headers:` + fmt.Sprintf("%v", this.Headers) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3192,6 +3234,25 @@ func (m *LokiSeriesRequest) Unmarshal(dAtA []byte) error {
 			}
 			m.Shards = append(m.Shards, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQueryrange(dAtA[iNdEx:])
@@ -3364,6 +3425,25 @@ func (m *LokiSeriesResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowQueryrange
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipQueryrange(dAtA[iNdEx:])
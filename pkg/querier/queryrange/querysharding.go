@@ -10,6 +10,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
 	"github.com/cortexproject/cortex/pkg/util"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	cortex_validation "github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
@@ -23,6 +24,7 @@ import (
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/util/marshal"
+	"github.com/grafana/loki/pkg/validation"
 )
 
 var errInvalidShardingRange = errors.New("Query does not fit in a single sharding configuration")
@@ -136,7 +138,11 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrange.Request) (queryra
 	if err != nil {
 		return nil, err
 	}
+	return responseFromResult(res, params, path)
+}
 
+// responseFromResult converts the result of a query executed by the frontend into a response.
+func responseFromResult(res logqlmodel.Result, params logql.Params, path string) (queryrange.Response, error) {
 	value, err := marshal.NewResultValue(res.Data)
 	if err != nil {
 		return nil, err
@@ -193,11 +199,14 @@ type shardSplitter struct {
 }
 
 func (splitter *shardSplitter) Do(ctx context.Context, r queryrange.Request) (queryrange.Response, error) {
-	userid, err := tenant.TenantID(ctx)
+	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
 		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 	}
-	minShardingLookback := splitter.limits.MinShardingLookback(userid)
+	if !featureEnabled(splitter.limits, tenantIDs, validation.FeatureQuerySharding) {
+		return splitter.next.Do(ctx, r)
+	}
+	minShardingLookback := cortex_validation.MaxDurationPerTenant(tenantIDs, splitter.limits.MinShardingLookback)
 	if minShardingLookback == 0 {
 		return splitter.shardingware.Do(ctx, r)
 	}
@@ -263,7 +272,7 @@ func NewSeriesQueryShardMiddleware(
 	confs ShardingConfigs,
 	middlewareMetrics *queryrange.InstrumentMiddlewareMetrics,
 	shardingMetrics *logql.ShardingMetrics,
-	limits queryrange.Limits,
+	limits Limits,
 	merger queryrange.Merger,
 ) queryrange.Middleware {
 
@@ -296,11 +305,19 @@ type seriesShardingHandler struct {
 	logger  log.Logger
 	next    queryrange.Handler
 	metrics *logql.ShardingMetrics
-	limits  queryrange.Limits
+	limits  Limits
 	merger  queryrange.Merger
 }
 
 func (ss *seriesShardingHandler) Do(ctx context.Context, r queryrange.Request) (queryrange.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	if !featureEnabled(ss.limits, tenantIDs, validation.FeatureQuerySharding) {
+		return ss.next.Do(ctx, r)
+	}
+
 	conf, err := ss.confs.GetConf(r)
 	// cannot shard with this timerange
 	if err != nil {
@@ -339,3 +356,13 @@ func (ss *seriesShardingHandler) Do(ctx context.Context, r queryrange.Request) (
 	}
 	return ss.merger.MergeResponse(responses...)
 }
+
+// featureEnabled returns true if the feature is enabled for all the tenants of a query.
+func featureEnabled(limits Limits, tenantIDs []string, feature validation.FeatureFlag) bool {
+	for _, tenantID := range tenantIDs {
+		if !limits.FeatureEnabled(tenantID, feature) {
+			return false
+		}
+	}
+	return len(tenantIDs) > 0
+}
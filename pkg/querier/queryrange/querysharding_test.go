@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/validation"
 )
 
 var (
@@ -83,6 +84,7 @@ func Test_shardSplitter(t *testing.T) {
 	for _, tc := range []struct {
 		desc        string
 		lookback    time.Duration
+		disabled    bool
 		shouldShard bool
 	}{
 		{
@@ -105,6 +107,12 @@ func Test_shardSplitter(t *testing.T) {
 			lookback:    0,
 			shouldShard: true,
 		},
+		{
+			desc:        "disabled for the tenant",
+			lookback:    -time.Minute,
+			disabled:    true,
+			shouldShard: false,
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			var didShard bool
@@ -117,6 +125,7 @@ func Test_shardSplitter(t *testing.T) {
 				now:  func() time.Time { return end },
 				limits: fakeLimits{
 					minShardingLookback: tc.lookback,
+					featureFlags:        map[validation.FeatureFlag]bool{validation.FeatureQuerySharding: !tc.disabled},
 				},
 			}
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	cortex_validation "github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
@@ -23,12 +24,18 @@ import (
 
 // Config is the configuration for the queryrange tripperware
 type Config struct {
-	queryrange.Config `yaml:",inline"`
+	queryrange.Config   `yaml:",inline"`
+	SplitInstantQueries bool `yaml:"split_instant_queries"`
+
+	// MaxChunkAge is the max chunk age of ingesters, results of log queries are only cached once their time range
+	// ended more than this duration ago.
+	MaxChunkAge time.Duration `yaml:"-"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Config.RegisterFlags(f)
+	f.BoolVar(&cfg.SplitInstantQueries, "querier.split-instant-queries", false, "Split instant metric queries with a range longer than the split interval into queries over sub-ranges.")
 }
 
 // Stopper gracefully shutdown resources created
@@ -54,15 +61,18 @@ func NewTripperware(
 	shardingMetrics := logql.NewShardingMetrics(registerer)
 	splitByMetrics := NewSplitByMetrics(registerer)
 
-	metricsTripperware, cache, err := NewMetricTripperware(cfg, log, limits, schema, minShardingLookback, LokiCodec,
+	metricsTripperware, metricsCache, err := NewMetricTripperware(cfg, log, limits, schema, minShardingLookback, LokiCodec,
 		PrometheusExtractor{}, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics, registerer)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// NOTE: When we would start caching response from non-metric queries we would have to consider cache gen headers as well in
-	// MergeResponse implementation for Loki codecs same as it is done in Cortex at https://github.com/cortexproject/cortex/blob/21bad57b346c730d684d6d0205efef133422ab28/pkg/querier/queryrange/query_range.go#L170
-	logFilterTripperware, err := NewLogFilterTripperware(cfg, log, limits, schema, minShardingLookback, LokiCodec, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics)
+	// the results cache is shared by metric and log queries.
+	resultsCache, _ := metricsCache.(cache.Cache)
+
+	// NOTE: Log results are cached per split request without cache gen headers, so responses cached before a delete request
+	// was processed could still be served until they expire. See how it is done in Cortex at https://github.com/cortexproject/cortex/blob/21bad57b346c730d684d6d0205efef133422ab28/pkg/querier/queryrange/query_range.go#L170
+	logFilterTripperware, err := NewLogFilterTripperware(cfg, log, limits, schema, minShardingLookback, LokiCodec, resultsCache, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics, NewLogResultCacheMetrics(registerer))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -88,7 +98,7 @@ func NewTripperware(
 		labelsRT := labelsTripperware(next)
 		instantRT := instantMetricTripperware(next)
 		return newRoundTripper(next, logFilterRT, metricRT, seriesRT, labelsRT, instantRT, limits)
-	}, cache, nil
+	}, metricsCache, nil
 }
 
 type roundTripper struct {
@@ -123,6 +133,9 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 		}
 		expr, err := logql.ParseExpr(rangeQuery.Query)
+		if err == nil {
+			err = logql.ValidateUserExpr(expr)
+		}
 		if err != nil {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 		}
@@ -163,6 +176,9 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 		}
 		expr, err := logql.ParseExpr(instantQuery.Query)
+		if err == nil {
+			err = logql.ValidateUserExpr(expr)
+		}
 		if err != nil {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 		}
@@ -198,12 +214,12 @@ func transformRegexQuery(req *http.Request, expr logql.LogSelectorExpr) (logql.L
 
 // validates log entries limits
 func validateLimits(req *http.Request, reqLimit uint32, limits Limits) error {
-	userID, err := tenant.TenantID(req.Context())
+	tenantIDs, err := tenant.TenantIDs(req.Context())
 	if err != nil {
 		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 	}
 
-	maxEntriesLimit := limits.MaxEntriesLimitPerQuery(userID)
+	maxEntriesLimit := cortex_validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, limits.MaxEntriesLimitPerQuery)
 	if int(reqLimit) > maxEntriesLimit && maxEntriesLimit != 0 {
 		return httpgrpc.Errorf(http.StatusBadRequest,
 			"max entries limit per query exceeded, limit > max_entries_limit (%d > %d)", reqLimit, maxEntriesLimit)
@@ -241,16 +257,26 @@ func NewLogFilterTripperware(
 	schema chunk.SchemaConfig,
 	minShardingLookback time.Duration,
 	codec queryrange.Codec,
+	c cache.Cache,
 	instrumentMetrics *queryrange.InstrumentMiddlewareMetrics,
 	retryMiddlewareMetrics *queryrange.RetryMiddlewareMetrics,
 	shardingMetrics *logql.ShardingMetrics,
 	splitByMetrics *SplitByMetrics,
+	logResultCacheMetrics *LogResultCacheMetrics,
 ) (queryrange.Tripperware, error) {
 	queryRangeMiddleware := []queryrange.Middleware{StatsCollectorMiddleware(), NewLimitsMiddleware(limits)}
 	if cfg.SplitQueriesByInterval != 0 {
 		queryRangeMiddleware = append(queryRangeMiddleware, queryrange.InstrumentMiddleware("split_by_interval", instrumentMetrics), SplitByIntervalMiddleware(limits, codec, splitByTime, splitByMetrics))
 	}
 
+	// c is only set when results caching is enabled.
+	if c != nil {
+		queryRangeMiddleware = append(queryRangeMiddleware,
+			queryrange.InstrumentMiddleware("log_results_cache", instrumentMetrics),
+			NewLogResultCache(log, limits, c, cfg.MaxChunkAge, logResultCacheMetrics),
+		)
+	}
+
 	if cfg.ShardedQueries {
 		if minShardingLookback == 0 {
 			return nil, errors.New("a non-zero value is required for querier.query-ingesters-within when -querier.parallelise-shardable-queries is enabled")
@@ -465,6 +491,13 @@ func NewInstantMetricTripperware(
 ) (queryrange.Tripperware, error) {
 	queryRangeMiddleware := []queryrange.Middleware{StatsCollectorMiddleware(), NewLimitsMiddleware(limits)}
 
+	if cfg.SplitInstantQueries {
+		queryRangeMiddleware = append(queryRangeMiddleware,
+			queryrange.InstrumentMiddleware("split_by_range", instrumentMetrics),
+			NewSplitByRangeMiddleware(log, limits, shardingMetrics),
+		)
+	}
+
 	if cfg.ShardedQueries {
 		queryRangeMiddleware = append(queryRangeMiddleware,
 			NewQueryShardMiddleware(
@@ -28,11 +28,12 @@ import (
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/util/marshal"
+	"github.com/grafana/loki/pkg/validation"
 )
 
 var (
 	testTime   = time.Date(2019, 12, 02, 11, 10, 10, 10, time.UTC)
-	testConfig = Config{queryrange.Config{
+	testConfig = Config{Config: queryrange.Config{
 		SplitQueriesByInterval: 4 * time.Hour,
 		AlignQueriesWithStep:   true,
 		MaxRetries:             3,
@@ -554,6 +555,8 @@ type fakeLimits struct {
 	maxSeries               int
 	splits                  map[string]time.Duration
 	minShardingLookback     time.Duration
+	cacheLogResults         bool
+	featureFlags            map[validation.FeatureFlag]bool
 }
 
 func (f fakeLimits) QuerySplitDuration(key string) time.Duration {
@@ -593,10 +596,21 @@ func (f fakeLimits) MaxQueryLookback(string) time.Duration {
 	return f.maxQueryLookback
 }
 
+func (f fakeLimits) CacheLogResults(string) bool {
+	return f.cacheLogResults
+}
+
 func (f fakeLimits) MinShardingLookback(string) time.Duration {
 	return f.minShardingLookback
 }
 
+func (f fakeLimits) FeatureEnabled(_ string, feature validation.FeatureFlag) bool {
+	if enabled, ok := f.featureFlags[feature]; ok {
+		return enabled
+	}
+	return validation.DefaultFeatureEnabled(feature)
+}
+
 func counter() (*int, http.Handler) {
 	count := 0
 	var lock sync.Mutex
@@ -691,3 +705,45 @@ func (s fakeRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 func toMs(t time.Time) int64 {
 	return t.UnixNano() / (int64(time.Millisecond) / int64(time.Nanosecond))
 }
+
+func TestInternalOperationTripperware(t *testing.T) {
+	tpw, stopper, err := NewTripperware(testConfig, util_log.Logger, fakeLimits{}, chunk.SchemaConfig{}, 0, nil)
+	if stopper != nil {
+		defer stopper.Stop()
+	}
+	require.NoError(t, err)
+	rt, err := newfakeRoundTripper()
+	require.NoError(t, err)
+	defer rt.Close()
+
+	query := `__quantile_sketch_over_time__({app="foo"} | unwrap latency [1m]) by (app)`
+	for _, lreq := range []queryrange.Request{
+		&LokiRequest{
+			Query:     query,
+			Limit:     1000,
+			Step:      30000,
+			StartTs:   testTime.Add(-6 * time.Hour),
+			EndTs:     testTime,
+			Direction: logproto.FORWARD,
+			Path:      "/loki/api/v1/query_range",
+		},
+		&LokiInstantRequest{
+			Query:     query,
+			Limit:     1000,
+			TimeTs:    testTime,
+			Direction: logproto.FORWARD,
+			Path:      "/loki/api/v1/query",
+		},
+	} {
+		ctx := user.InjectOrgID(context.Background(), "1")
+		req, err := LokiCodec.EncodeRequest(ctx, lreq)
+		require.NoError(t, err)
+
+		req = req.WithContext(ctx)
+		err = user.InjectOrgIDIntoHTTPRequest(ctx, req)
+		require.NoError(t, err)
+
+		_, err = tpw(rt).RoundTrip(req)
+		require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "parse error : __quantile_sketch_over_time__ is reserved to sharded queries"), err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	cortex_validation "github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/opentracing/opentracing-go"
 	otlog "github.com/opentracing/opentracing-go/log"
 	"github.com/prometheus/client_golang/prometheus"
@@ -88,7 +89,7 @@ func (h *splitByInterval) Process(
 	parallelism int,
 	threshold int64,
 	input []*lokiResult,
-	userID string,
+	maxSeries int,
 ) ([]queryrange.Response, error) {
 	var responses []queryrange.Response
 	ctx, cancel := context.WithCancel(ctx)
@@ -109,7 +110,7 @@ func (h *splitByInterval) Process(
 	}
 
 	// per request wrapped handler for limiting the amount of series.
-	next := newSeriesLimiter(h.limits.MaxQuerySeries(userID)).Wrap(h.next)
+	next := newSeriesLimiter(maxSeries).Wrap(h.next)
 	for i := 0; i < p; i++ {
 		go h.loop(ctx, ch, next)
 	}
@@ -160,12 +161,12 @@ func (h *splitByInterval) loop(ctx context.Context, ch <-chan *lokiResult, next
 }
 
 func (h *splitByInterval) Do(ctx context.Context, r queryrange.Request) (queryrange.Response, error) {
-	userid, err := tenant.TenantID(ctx)
+	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
 		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 	}
 
-	interval := h.limits.QuerySplitDuration(userid)
+	interval := cortex_validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, h.limits.QuerySplitDuration)
 	// skip split by if unset
 	if interval == 0 {
 		return h.next.Do(ctx, r)
@@ -207,7 +208,9 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrange.Request) (queryra
 		})
 	}
 
-	resps, err := h.Process(ctx, h.limits.MaxQueryParallelism(userid), limit, input, userid)
+	parallelism := cortex_validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, h.limits.MaxQueryParallelism)
+	maxSeries := cortex_validation.SmallestPositiveIntPerTenant(tenantIDs, h.limits.MaxQuerySeries)
+	resps, err := h.Process(ctx, parallelism, limit, input, maxSeries)
 	if err != nil {
 		return nil, err
 	}
@@ -238,6 +241,7 @@ func splitByTime(req queryrange.Request, interval time.Duration) []queryrange.Re
 				StartTs: start,
 				EndTs:   end,
 				Shards:  r.Shards,
+				Limit:   r.Limit,
 			})
 		})
 	case *LokiLabelNamesRequest:
@@ -0,0 +1,83 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	cortex_validation "github.com/cortexproject/cortex/pkg/util/validation"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/tenant"
+)
+
+type splitByRange struct {
+	logger log.Logger
+	next   queryrange.Handler
+	limits Limits
+	ng     *logql.ShardedEngine
+}
+
+// NewSplitByRangeMiddleware creates a middleware splitting instant metric queries whose range is longer
+// than the tenant split interval into queries over sub-ranges, and recombining their results.
+func NewSplitByRangeMiddleware(logger log.Logger, limits Limits, metrics *logql.ShardingMetrics) queryrange.Middleware {
+	return queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
+		return &splitByRange{
+			logger: log.With(logger, "middleware", "InstantQuery.splitByRange"),
+			next:   next,
+			limits: limits,
+			ng:     logql.NewShardedEngine(logql.EngineOpts{}, DownstreamHandler{next}, metrics, limits),
+		}
+	})
+}
+
+func (s *splitByRange) Do(ctx context.Context, r queryrange.Request) (queryrange.Response, error) {
+	req, ok := r.(*LokiInstantRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected *LokiInstantRequest, got (%T)", r)
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	interval := cortex_validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.QuerySplitDuration)
+	// skip split by if unset
+	if interval == 0 {
+		return s.next.Do(ctx, r)
+	}
+
+	mapper, err := logql.NewRangeMapper(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	noop, parsed, err := mapper.Parse(r.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+	logger := util_log.WithContext(ctx, s.logger)
+	level.Debug(logger).Log("no-op", noop, "mapped", parsed.String())
+
+	if noop {
+		// the query can't be split.
+		return s.next.Do(ctx, r)
+	}
+
+	params, err := paramsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.ng.Query(params, parsed).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return responseFromResult(res, params, req.GetPath())
+}
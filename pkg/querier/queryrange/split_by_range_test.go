@@ -0,0 +1,88 @@
+package queryrange
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+func Test_SplitByRange(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	for _, tc := range []struct {
+		query    string
+		expected []string
+		value    float64
+	}{
+		{
+			query:    `sum(count_over_time({app="foo"}[1m]))`,
+			expected: []string{`sum(count_over_time({app="foo"}[1m]))`},
+			value:    10,
+		},
+		{
+			query: `sum(count_over_time({app="foo"}[3m]))`,
+			expected: []string{
+				`sum(count_over_time({app="foo"}[1m]))`,
+				`sum(count_over_time({app="foo"}[1m] offset 1m0s))`,
+				`sum(count_over_time({app="foo"}[1m] offset 2m0s))`,
+			},
+			value: 30,
+		},
+		{
+			query: `sum(rate({app="foo"}[2m]))`,
+			expected: []string{
+				`sum(count_over_time({app="foo"}[1m]))`,
+				`sum(count_over_time({app="foo"}[1m] offset 1m0s))`,
+			},
+			value: 20. / 120,
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			var (
+				lock    sync.Mutex
+				queries []string
+			)
+			split := NewSplitByRangeMiddleware(log.NewNopLogger(), fakeLimits{
+				splits:              map[string]time.Duration{"1": time.Minute},
+				maxSeries:           math.MaxInt32,
+				maxQueryParallelism: 10,
+			}, nilShardingMetrics)
+			resp, err := split.Wrap(queryrange.HandlerFunc(func(c context.Context, r queryrange.Request) (queryrange.Response, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				queries = append(queries, r.GetQuery())
+				return &LokiPromResponse{Response: &queryrange.PrometheusResponse{
+					Data: queryrange.PrometheusData{
+						ResultType: loghttp.ResultTypeVector,
+						Result: []queryrange.SampleStream{
+							{
+								Labels:  []cortexpb.LabelAdapter{},
+								Samples: []cortexpb.Sample{{Value: 10, TimestampMs: 10}},
+							},
+						},
+					},
+				}}, nil
+			})).Do(ctx, &LokiInstantRequest{
+				Query:  tc.query,
+				TimeTs: util.TimeFromMillis(10),
+				Path:   "/v1/query",
+			})
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, queries)
+			result := resp.(*LokiPromResponse).Response.Data.Result
+			require.Len(t, result, 1)
+			require.InDelta(t, tc.value, result[0].Samples[0].Value, 1e-9)
+		})
+	}
+}
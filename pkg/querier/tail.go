@@ -304,3 +304,57 @@ func dropEntries(droppedEntries []loghttp.DroppedEntry, streams []logproto.Strea
 
 	return droppedEntries
 }
+
+// tailResponseBatch merges the tail responses added to it until it gets flushed,
+// so that high-volume tails send fewer, bigger messages to the client.
+type tailResponseBatch struct {
+	streams        []logproto.Stream
+	streamsIndex   map[string]int
+	droppedEntries []loghttp.DroppedEntry
+	entries        int
+}
+
+func newTailResponseBatch() *tailResponseBatch {
+	return &tailResponseBatch{
+		streamsIndex: map[string]int{},
+	}
+}
+
+// add merges the response into the batch, entries of streams with the same labels are appended together.
+func (b *tailResponseBatch) add(response *loghttp.TailResponse) {
+	for _, stream := range response.Streams {
+		idx, ok := b.streamsIndex[stream.Labels]
+		if !ok {
+			idx = len(b.streams)
+			b.streamsIndex[stream.Labels] = idx
+			b.streams = append(b.streams, logproto.Stream{Labels: stream.Labels})
+		}
+		b.streams[idx].Entries = append(b.streams[idx].Entries, stream.Entries...)
+		b.entries += len(stream.Entries)
+	}
+	for _, dropped := range response.DroppedEntries {
+		b.droppedEntries = dropEntry(b.droppedEntries, dropped.Timestamp, dropped.Labels)
+	}
+}
+
+// size returns the number of entries in the batch.
+func (b *tailResponseBatch) size() int {
+	return b.entries
+}
+
+func (b *tailResponseBatch) empty() bool {
+	return len(b.streams) == 0 && len(b.droppedEntries) == 0
+}
+
+// flush returns the merged response and resets the batch.
+func (b *tailResponseBatch) flush() *loghttp.TailResponse {
+	response := &loghttp.TailResponse{
+		Streams:        b.streams,
+		DroppedEntries: b.droppedEntries,
+	}
+	b.streams = nil
+	b.streamsIndex = map[string]int{}
+	b.droppedEntries = nil
+	b.entries = 0
+	return response
+}
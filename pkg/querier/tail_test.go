@@ -252,3 +252,68 @@ func flattenStreamsFromResponses(responses []*loghttp.TailResponse) []logproto.S
 
 	return result
 }
+
+func Test_validateTailQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query   string
+		wantErr bool
+	}{
+		{`{app="foo"}`, false},
+		{`{app="foo"} |= "bar"`, false},
+		{`{app="foo"} | json | level="error" | line_format "{{.msg}}"`, false},
+		{`{app="foo"} | logfmt | duration > 10s`, false},
+		{`{app="foo"} | json | line_format "{{.msg"`, true},
+		{`rate({app="foo"}[1m])`, true},
+		{`{app="foo"`, true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			err := validateTailQuery(tc.query, nil)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_tailResponseBatch(t *testing.T) {
+	batch := newTailResponseBatch()
+	require.True(t, batch.empty())
+
+	batch.add(&loghttp.TailResponse{
+		Streams: []logproto.Stream{
+			{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1), Line: "1"}}},
+			{Labels: `{app="bar"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 2), Line: "2"}}},
+		},
+	})
+	batch.add(&loghttp.TailResponse{
+		Streams: []logproto.Stream{
+			{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 3), Line: "3"}}},
+		},
+		DroppedEntries: []loghttp.DroppedEntry{{Timestamp: time.Unix(0, 4), Labels: `{app="baz"}`}},
+	})
+	require.False(t, batch.empty())
+	require.Equal(t, 3, batch.size())
+
+	require.Equal(t, &loghttp.TailResponse{
+		Streams: []logproto.Stream{
+			{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1), Line: "1"}, {Timestamp: time.Unix(0, 3), Line: "3"}}},
+			{Labels: `{app="bar"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 2), Line: "2"}}},
+		},
+		DroppedEntries: []loghttp.DroppedEntry{{Timestamp: time.Unix(0, 4), Labels: `{app="baz"}`}},
+	}, batch.flush())
+
+	require.True(t, batch.empty())
+	require.Equal(t, 0, batch.size())
+
+	// Streams of a flushed batch are not merged with the next ones.
+	batch.add(&loghttp.TailResponse{
+		Streams: []logproto.Stream{
+			{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 5), Line: "5"}}},
+		},
+	})
+	require.Equal(t, []logproto.Stream{
+		{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 5), Line: "5"}}},
+	}, batch.flush().Streams)
+}
@@ -0,0 +1,243 @@
+package ruler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/rules"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// alertStatePrefix is the prefix of the keys of the alert state snapshots in the object store.
+const alertStatePrefix = "ruler-alert-state/"
+
+// AlertStateConfig configures the persistence of the for state of the alerts in an object store.
+type AlertStateConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	SharedStoreType  string        `yaml:"shared_store"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+	RestoreTimeout   time.Duration `yaml:"restore_timeout"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (c *AlertStateConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&c.Enabled, "ruler.alert-state.enabled", false, "Persist snapshots of the for state of the active alerts of each tenant in an object store, and restore them when the rules of the tenant are loaded so that alerts don't restart pending after a ruler restart.")
+	f.StringVar(&c.SharedStoreType, "ruler.alert-state.shared-store", "", "Object store of the alert state snapshots. Supported types: gcs, s3, azure, swift, filesystem.")
+	f.DurationVar(&c.SnapshotInterval, "ruler.alert-state.snapshot-interval", time.Minute, "How often the alert state of each tenant is snapshotted. A last snapshot is taken when the rules of a tenant are unloaded.")
+	f.DurationVar(&c.RestoreTimeout, "ruler.alert-state.restore-timeout", 10*time.Second, "Timeout of the download of the alert state snapshot of a tenant when its rules are loaded.")
+}
+
+// Validate validates the alert state config.
+func (c *AlertStateConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.SharedStoreType == "" {
+		return fmt.Errorf("alert state persistence enabled but no shared store is configured")
+	}
+	if c.SnapshotInterval <= 0 {
+		return fmt.Errorf("alert state snapshot interval must be > 0")
+	}
+	return nil
+}
+
+// alertStateSnapshot is the for state of the active alerts of a tenant at a point in time.
+type alertStateSnapshot struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Alerts    []alertForState `json:"alerts"`
+}
+
+// alertForState is the ALERTS_FOR_STATE series of an alert and the time it became active.
+type alertForState struct {
+	Labels   labels.Labels `json:"labels"`
+	ActiveAt time.Time     `json:"active_at"`
+}
+
+// activeAt returns when the alert of an ALERTS_FOR_STATE series became active.
+func (s *alertStateSnapshot) activeAt(ls labels.Labels) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	for _, a := range s.Alerts {
+		if labels.Equal(a.Labels, ls) {
+			return a.ActiveAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+type alertStateMetrics struct {
+	snapshots      *prometheus.CounterVec
+	restoredAlerts prometheus.Counter
+}
+
+func newAlertStateMetrics(r prometheus.Registerer) *alertStateMetrics {
+	return &alertStateMetrics{
+		snapshots: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "ruler_alert_state_snapshots_total",
+			Help:      "Total number of alert state snapshots written to the object store.",
+		}, []string{"status"}),
+		restoredAlerts: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "ruler_alert_state_restored_alerts_total",
+			Help:      "Total number of alerts whose for state was restored from a snapshot.",
+		}),
+	}
+}
+
+// alertStateStore reads and writes the alert state snapshots of the tenants in an object store.
+type alertStateStore struct {
+	cfg     AlertStateConfig
+	client  chunk.ObjectClient
+	metrics *alertStateMetrics
+}
+
+func newAlertStateStore(cfg AlertStateConfig, client chunk.ObjectClient, reg prometheus.Registerer) *alertStateStore {
+	return &alertStateStore{
+		cfg:     cfg,
+		client:  client,
+		metrics: newAlertStateMetrics(reg),
+	}
+}
+
+func alertStateKey(userID string) string {
+	return alertStatePrefix + userID + ".json"
+}
+
+// load returns the last snapshot of a tenant, nil if there is none.
+func (s *alertStateStore) load(ctx context.Context, userID string) (*alertStateSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.RestoreTimeout)
+	defer cancel()
+
+	r, err := s.client.GetObject(ctx, alertStateKey(userID))
+	if err != nil {
+		if s.client.IsObjectNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer r.Close()
+
+	var snapshot alertStateSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("decoding the alert state snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *alertStateStore) save(ctx context.Context, userID string, snapshot *alertStateSnapshot) error {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	err = s.client.PutObject(ctx, alertStateKey(userID), bytes.NewReader(b))
+	if err != nil {
+		s.metrics.snapshots.WithLabelValues(statusFailure).Inc()
+		return err
+	}
+	s.metrics.snapshots.WithLabelValues(statusSuccess).Inc()
+	return nil
+}
+
+// takeSnapshot returns the for state of the active alerts of the rules. The alerts of the rules whose for state
+// was not restored yet are taken from the restored snapshot, so that they are not lost by snapshotting before the
+// rules are restored, which happens after the second evaluation of their group.
+func takeSnapshot(now time.Time, alertingRules []*rules.AlertingRule, restored *alertStateSnapshot) *alertStateSnapshot {
+	snapshot := &alertStateSnapshot{Timestamp: now, Alerts: []alertForState{}}
+	for _, rule := range alertingRules {
+		if !rule.Restored() {
+			if restored != nil {
+				for _, a := range restored.Alerts {
+					if a.Labels.Get(labels.AlertName) == rule.Name() {
+						snapshot.Alerts = append(snapshot.Alerts, a)
+					}
+				}
+			}
+			continue
+		}
+		for _, a := range rule.ActiveAlerts() {
+			snapshot.Alerts = append(snapshot.Alerts, alertForState{
+				Labels:   ForStateMetric(a.Labels, rule.Name()),
+				ActiveAt: a.ActiveAt,
+			})
+		}
+	}
+	return snapshot
+}
+
+// snapshottingManager is a rules manager snapshotting the alert state of its tenant periodically and when stopped.
+type snapshottingManager struct {
+	*rules.Manager
+
+	userID   string
+	store    *alertStateStore
+	restored *alertStateSnapshot
+	logger   log.Logger
+
+	// mtx serializes the snapshots, so that the last one written is the most recent.
+	mtx      sync.Mutex
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newSnapshottingManager(mgr *rules.Manager, userID string, store *alertStateStore, restored *alertStateSnapshot, logger log.Logger) *snapshottingManager {
+	return &snapshottingManager{
+		Manager:  mgr,
+		userID:   userID,
+		store:    store,
+		restored: restored,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+}
+
+// Run runs the rules manager and snapshots the alert state until Stop is called.
+func (m *snapshottingManager) Run() {
+	go m.snapshotLoop()
+	m.Manager.Run()
+}
+
+func (m *snapshottingManager) snapshotLoop() {
+	t := time.NewTicker(m.store.cfg.SnapshotInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-t.C:
+			m.snapshot()
+		}
+	}
+}
+
+// Stop stops the rules manager, then takes a last snapshot of the alert state.
+func (m *snapshottingManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+		m.Manager.Stop()
+		m.snapshot()
+	})
+}
+
+func (m *snapshottingManager) snapshot() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	snapshot := takeSnapshot(time.Now(), m.AlertingRules(), m.restored)
+	if err := m.store.save(context.Background(), m.userID, snapshot); err != nil {
+		level.Error(m.logger).Log("msg", "failed to save the alert state snapshot", "err", err)
+		return
+	}
+	level.Debug(m.logger).Log("msg", "alert state snapshot saved", "alerts", len(snapshot.Alerts))
+}
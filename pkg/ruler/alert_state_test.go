@@ -0,0 +1,180 @@
+package ruler
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+)
+
+func newTestAlertStateStore(t *testing.T) *alertStateStore {
+	client, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	return newAlertStateStore(AlertStateConfig{Enabled: true, SnapshotInterval: time.Hour, RestoreTimeout: time.Second}, client, nil)
+}
+
+func TestAlertStateStore(t *testing.T) {
+	store := newTestAlertStateStore(t)
+
+	snapshot, err := store.load(context.Background(), "user")
+	require.NoError(t, err)
+	require.Nil(t, snapshot)
+
+	ls := ForStateMetric(labels.FromStrings("foo", "bar"), ruleName)
+	expected := &alertStateSnapshot{
+		Timestamp: time.Unix(100, 0).UTC(),
+		Alerts:    []alertForState{{Labels: ls, ActiveAt: time.Unix(50, 0).UTC()}},
+	}
+	require.NoError(t, store.save(context.Background(), "user", expected))
+
+	snapshot, err = store.load(context.Background(), "user")
+	require.NoError(t, err)
+	require.Equal(t, expected, snapshot)
+
+	activeAt, ok := snapshot.activeAt(ls)
+	require.True(t, ok)
+	require.Equal(t, time.Unix(50, 0).UTC(), activeAt)
+	_, ok = snapshot.activeAt(ForStateMetric(labels.FromStrings("foo", "baz"), ruleName))
+	require.False(t, ok)
+}
+
+func TestSelectRestoresFromAlertState(t *testing.T) {
+	ars := []*rules.AlertingRule{
+		rules.NewAlertingRule(ruleName, &parser.StringLiteral{Val: "unused"}, time.Hour, nil, nil, nil, "", false, NilLogger),
+	}
+	evaluations := 0
+	fn := rules.QueryFunc(func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		evaluations++
+		return promql.Vector{{Metric: labels.FromStrings("foo", "baz"), Point: promql.Point{T: util.TimeToMillis(t), V: 1}}}, nil
+	})
+
+	now := time.Now()
+	active := ForStateMetric(labels.FromStrings("foo", "bar"), ruleName)
+	inactive := ForStateMetric(labels.FromStrings("foo", "baz"), ruleName)
+	metrics := newAlertStateMetrics(nil)
+
+	store := testStore(fn)
+	store.setAlertState(&alertStateSnapshot{
+		Timestamp: now.Add(-time.Minute),
+		Alerts:    []alertForState{{Labels: active, ActiveAt: now.Add(-30 * time.Minute)}},
+	}, metrics)
+	store.Start(MockRuleIter(ars))
+
+	q, err := store.Querier(context.Background(), util.TimeToMillis(now.Add(-time.Hour)), util.TimeToMillis(now))
+	require.NoError(t, err)
+
+	sset := q.Select(false, nil, labelsToMatchers(active)...)
+	require.True(t, sset.Next())
+	require.Equal(t, active, sset.At().Labels())
+	it := sset.At().Iterator()
+	require.True(t, it.Next())
+	ts, v := it.At()
+	require.Equal(t, util.TimeToMillis(now.Add(-time.Minute)), ts)
+	require.Equal(t, float64(now.Add(-30*time.Minute).Unix()), v)
+	require.False(t, sset.Next())
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.restoredAlerts))
+
+	// The alerts missing from the snapshot were not active.
+	require.False(t, q.Select(false, nil, labelsToMatchers(inactive)...).Next())
+	require.Equal(t, 0, evaluations)
+
+	// Snapshots older than the outage tolerance are ignored.
+	q, err = store.Querier(context.Background(), util.TimeToMillis(now.Add(-time.Second)), util.TimeToMillis(now))
+	require.NoError(t, err)
+	require.True(t, q.Select(false, nil, labelsToMatchers(inactive)...).Next())
+	require.Equal(t, 1, evaluations)
+}
+
+func TestSnapshottingManager(t *testing.T) {
+	store := newTestAlertStateStore(t)
+	ruleFile := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, ioutil.WriteFile(ruleFile, []byte(`
+groups:
+  - name: test
+    rules:
+      - alert: HighErrors
+        expr: sum(rate({app="foo"}[1m])) > 0
+        for: 1h
+`), 0o644))
+
+	var queries atomic.Int64
+	queryFunc := rules.QueryFunc(func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		queries.Inc()
+		return promql.Vector{{Metric: labels.Labels{}, Point: promql.Point{T: util.TimeToMillis(t), V: 1}}}, nil
+	})
+	run := func(restored *alertStateSnapshot) *snapshottingManager {
+		queries.Store(0)
+		memStore := NewMemStore("user", queryFunc, NilMetrics, time.Minute, NilLogger)
+		memStore.setAlertState(restored, store.metrics)
+		mgr := rules.NewManager(&rules.ManagerOptions{
+			Appendable:      nullRegistry{},
+			Queryable:       memStore,
+			QueryFunc:       queryFunc,
+			Context:         user.InjectOrgID(context.Background(), "user"),
+			NotifyFunc:      func(context.Context, string, ...*rules.Alert) {},
+			Logger:          NilLogger,
+			OutageTolerance: time.Hour,
+			GroupLoader:     GroupLoader{},
+		})
+		memStore.Start(mgr)
+		require.NoError(t, mgr.Update(10*time.Millisecond, []string{ruleFile}, nil, ""))
+
+		m := newSnapshottingManager(mgr, "user", store, restored, NilLogger)
+		go m.Run()
+		// The for state is restored after the second evaluation of the group, wait for a few more.
+		require.Eventually(t, func() bool { return queries.Load() >= 5 }, 5*time.Second, 10*time.Millisecond)
+		return m
+	}
+	alertLabels := ForStateMetric(labels.Labels{}, "HighErrors")
+
+	// The state of the alerts is snapshotted when the manager is stopped.
+	m := run(nil)
+	activeAt := m.AlertingRules()[0].ActiveAlerts()[0].ActiveAt
+	m.Stop()
+	snapshot, err := store.load(context.Background(), "user")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Alerts, 1)
+	require.Equal(t, alertLabels, snapshot.Alerts[0].Labels)
+	require.True(t, activeAt.Equal(snapshot.Alerts[0].ActiveAt))
+
+	// The alerts of a snapshot keep pending from the time they became active.
+	now := time.Now()
+	m = run(&alertStateSnapshot{
+		Timestamp: now,
+		Alerts:    []alertForState{{Labels: alertLabels, ActiveAt: now.Add(-30 * time.Minute)}},
+	})
+	defer m.Stop()
+	require.True(t, m.AlertingRules()[0].ActiveAlerts()[0].ActiveAt.Before(now.Add(-29*time.Minute)))
+}
+
+func TestTakeSnapshot_KeepsAlertsNotRestored(t *testing.T) {
+	ls := ForStateMetric(labels.FromStrings("foo", "bar"), ruleName)
+	restored := &alertStateSnapshot{
+		Timestamp: time.Unix(100, 0),
+		Alerts: []alertForState{
+			{Labels: ls, ActiveAt: time.Unix(50, 0)},
+			{Labels: ForStateMetric(nil, "deleted"), ActiveAt: time.Unix(50, 0)},
+		},
+	}
+
+	rule := rules.NewAlertingRule(ruleName, &parser.StringLiteral{Val: "unused"}, time.Hour, nil, nil, nil, "", false, NilLogger)
+	snapshot := takeSnapshot(time.Unix(200, 0), []*rules.AlertingRule{rule}, restored)
+	require.Equal(t, []alertForState{{Labels: ls, ActiveAt: time.Unix(50, 0)}}, snapshot.Alerts)
+
+	rule.SetRestored(true)
+	snapshot = takeSnapshot(time.Unix(200, 0), []*rules.AlertingRule{rule}, restored)
+	require.Empty(t, snapshot.Alerts)
+}
@@ -0,0 +1,53 @@
+package ruler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3"
+)
+
+// SetRuleGroupHandler returns a handler creating or updating the rule group of the request path, to be routed on
+// /loki/api/v1/rules/{namespace}/{groupName}. The rule group of the request body may omit its name, otherwise it must
+// be the one of the path. The rule group is then validated and stored by the create handler of the ruler API, which
+// takes the namespace from the path and the rule group from the body.
+func SetRuleGroupHandler(create http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		groupName := mux.Vars(r)["groupName"]
+		if groupName == "" {
+			http.Error(w, "invalid rule group name", http.StatusBadRequest)
+			return
+		}
+
+		payload, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var rg rulefmt.RuleGroup
+		if err := yaml.Unmarshal(payload, &rg); err != nil {
+			http.Error(w, fmt.Sprintf("invalid rule group: %s", err), http.StatusBadRequest)
+			return
+		}
+		if rg.Name == "" {
+			rg.Name = groupName
+		}
+		if rg.Name != groupName {
+			http.Error(w, fmt.Sprintf("rule group name %q does not match the name %q of the path", rg.Name, groupName), http.StatusBadRequest)
+			return
+		}
+
+		payload, err = yaml.Marshal(&rg)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		r.ContentLength = int64(len(payload))
+		create(w, r)
+	}
+}
@@ -0,0 +1,82 @@
+package ruler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetRuleGroupHandler(t *testing.T) {
+	var created *rulefmt.RuleGroup
+	router := mux.NewRouter()
+	router.Path("/loki/api/v1/rules/{namespace}/{groupName}").Methods("PUT").Handler(SetRuleGroupHandler(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "ns", mux.Vars(r)["namespace"])
+		payload, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		created = &rulefmt.RuleGroup{}
+		require.NoError(t, yaml.Unmarshal(payload, created))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	put := func(group, body string) *httptest.ResponseRecorder {
+		created = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/loki/api/v1/rules/ns/"+group, strings.NewReader(body)))
+		return w
+	}
+
+	for name, tc := range map[string]struct {
+		group, body string
+		code        int
+		name        string
+	}{
+		"name from the path": {
+			group: "my%20group",
+			body:  "rules:\n  - record: foo\n    expr: sum(rate({app=\"foo\"}[1m]))\n",
+			code:  http.StatusAccepted,
+			name:  "my group",
+		},
+		"name with an escaped percent sign": {
+			group: "100%25",
+			body:  "rules:\n  - record: foo\n    expr: sum(rate({app=\"foo\"}[1m]))\n",
+			code:  http.StatusAccepted,
+			name:  "100%",
+		},
+		"name in the body": {
+			group: "group",
+			body:  "name: group\nrules:\n  - record: foo\n    expr: sum(rate({app=\"foo\"}[1m]))\n",
+			code:  http.StatusAccepted,
+			name:  "group",
+		},
+		"mismatching name": {
+			group: "group",
+			body:  "name: other\nrules:\n  - record: foo\n    expr: sum(rate({app=\"foo\"}[1m]))\n",
+			code:  http.StatusBadRequest,
+		},
+		"invalid body": {
+			group: "group",
+			body:  "rules: foo",
+			code:  http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := put(tc.group, tc.body)
+			require.Equal(t, tc.code, w.Code, w.Body.String())
+			if tc.code != http.StatusAccepted {
+				require.Nil(t, created)
+				return
+			}
+			require.Equal(t, tc.name, created.Name)
+			require.Len(t, created.Rules, 1)
+			require.Equal(t, "foo", created.Rules[0].Record.Value)
+			require.Equal(t, `sum(rate({app="foo"}[1m]))`, created.Rules[0].Expr.Value)
+		})
+	}
+}
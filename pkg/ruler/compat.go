@@ -11,9 +11,11 @@ import (
 	"github.com/cortexproject/cortex/pkg/ruler"
 	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/prometheus/prometheus/model/timestamp"
@@ -28,6 +30,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/ruler/util"
+	"github.com/grafana/loki/pkg/storage/chunk"
 )
 
 // RulesLimits is the one function we need from limits.Overrides, and
@@ -48,6 +51,7 @@ type RulesLimits interface {
 	RulerRemoteWriteQueueMinBackoff(userID string) time.Duration
 	RulerRemoteWriteQueueMaxBackoff(userID string) time.Duration
 	RulerRemoteWriteQueueRetryOnRateLimit(userID string) bool
+	RulerRemoteWriteSigV4Config(userID string) *sigv4.SigV4Config
 }
 
 // engineQueryFunc returns a new query function using the rules.EngineQueryFunc function
@@ -92,16 +96,18 @@ func engineQueryFunc(engine *logql.Engine, overrides RulesLimits, checker readyC
 }
 
 // MultiTenantManagerAdapter will wrap a MultiTenantManager which validates loki rules
-func MultiTenantManagerAdapter(mgr ruler.MultiTenantManager) ruler.MultiTenantManager {
-	return &MultiTenantManager{inner: mgr}
+func MultiTenantManagerAdapter(mgr ruler.MultiTenantManager, reg prometheus.Registerer) ruler.MultiTenantManager {
+	return &MultiTenantManager{inner: mgr, ownership: newRuleGroupOwnership(reg)}
 }
 
 // MultiTenantManager wraps a cortex MultiTenantManager but validates loki rules
 type MultiTenantManager struct {
-	inner ruler.MultiTenantManager
+	inner     ruler.MultiTenantManager
+	ownership *ruleGroupOwnership
 }
 
 func (m *MultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGroups map[string]rulespb.RuleGroupList) {
+	m.ownership.sync(ruleGroups)
 	m.inner.SyncRuleGroups(ctx, ruleGroups)
 }
 
@@ -127,7 +133,12 @@ const MetricsPrefix = "loki_ruler_wal_"
 
 var registry storageRegistry
 
-func MultiTenantRuleManager(cfg Config, engine *logql.Engine, overrides RulesLimits, logger log.Logger, reg prometheus.Registerer) ruler.ManagerFactory {
+func MultiTenantRuleManager(cfg Config, engine *logql.Engine, overrides RulesLimits, alertStateClient chunk.ObjectClient, logger log.Logger, reg prometheus.Registerer) ruler.ManagerFactory {
+	var alertState *alertStateStore
+	if alertStateClient != nil {
+		alertState = newAlertStateStore(cfg.AlertState, alertStateClient, reg)
+	}
+
 	reg = prometheus.WrapRegistererWithPrefix(MetricsPrefix, reg)
 
 	registry = newWALRegistry(log.With(logger, "storage", "registry"), reg, cfg, overrides)
@@ -160,10 +171,20 @@ func MultiTenantRuleManager(cfg Config, engine *logql.Engine, overrides RulesLim
 			GroupLoader:     GroupLoader{},
 		})
 
-		// initialize memStore, bound to the manager's alerting rules
+		if alertState == nil {
+			// initialize memStore, bound to the manager's alerting rules
+			memStore.Start(mgr)
+			return mgr
+		}
+
+		snapshot, err := alertState.load(ctx, userID)
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to load the alert state snapshot, restoring the alert state by evaluating the rules", "err", err)
+		}
+		memStore.setAlertState(snapshot, alertState.metrics)
 		memStore.Start(mgr)
 
-		return mgr
+		return newSnapshottingManager(mgr, userID, alertState, snapshot, logger)
 	}
 }
 
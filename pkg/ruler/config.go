@@ -22,6 +22,8 @@ type Config struct {
 
 	WALCleaner  cleaner.Config    `yaml:"wal_cleaner,omitempty"`
 	RemoteWrite RemoteWriteConfig `yaml:"remote_write,omitempty"`
+
+	AlertState AlertStateConfig `yaml:"alert_state,omitempty"`
 }
 
 func (c *Config) RegisterFlags(f *flag.FlagSet) {
@@ -29,6 +31,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.RemoteWrite.RegisterFlags(f)
 	c.WAL.RegisterFlags(f)
 	c.WALCleaner.RegisterFlags(f)
+	c.AlertState.RegisterFlags(f)
 
 	// TODO(owen-d, 3.0.0): remove deprecated experimental prefix in Cortex if they'll accept it.
 	f.BoolVar(&c.Config.EnableAPI, "ruler.enable-api", true, "Enable the ruler api")
@@ -44,6 +47,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid ruler remote-write config: %w", err)
 	}
 
+	if err := c.AlertState.Validate(); err != nil {
+		return fmt.Errorf("invalid ruler alert state config: %w", err)
+	}
+
 	return nil
 }
 
@@ -68,6 +68,11 @@ type MemStore struct {
 	logger    log.Logger
 	rules     map[string]*RuleCache
 
+	// alertState is the snapshot of the alert state restored when the rules of the tenant were loaded, nil if
+	// there was none.
+	alertState        *alertStateSnapshot
+	alertStateMetrics *alertStateMetrics
+
 	initiated       chan struct{}
 	done            chan struct{}
 	cleanupInterval time.Duration
@@ -89,6 +94,13 @@ func NewMemStore(userID string, queryFunc rules.QueryFunc, metrics *memstoreMetr
 
 }
 
+// setAlertState makes the for state of the alerts restored from the snapshot rather than by evaluating their rules,
+// when the snapshot is within the outage tolerance. It must be called before Start.
+func (m *MemStore) setAlertState(snapshot *alertStateSnapshot, metrics *alertStateMetrics) {
+	m.alertState = snapshot
+	m.alertStateMetrics = metrics
+}
+
 // Calling Start will set the RuleIter, unblock the MemStore, and start the run() function in a separate goroutine.
 func (m *MemStore) Start(iter RuleIter) {
 	m.mgr = iter
@@ -166,6 +178,7 @@ func (m *MemStore) run() {
 func (m *MemStore) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
 	<-m.initiated
 	return &memStoreQuerier{
+		mint:     util.TimeFromMillis(mint),
 		ts:       util.TimeFromMillis(maxt),
 		MemStore: m,
 		ctx:      ctx,
@@ -174,8 +187,9 @@ func (m *MemStore) Querier(ctx context.Context, mint, maxt int64) (storage.Queri
 }
 
 type memStoreQuerier struct {
-	ts  time.Time
-	ctx context.Context
+	mint time.Time
+	ts   time.Time
+	ctx  context.Context
 	*MemStore
 }
 
@@ -199,6 +213,23 @@ func (m *memStoreQuerier) Select(sortSeries bool, params *storage.SelectHints, m
 		return storage.NoopSeriesSet()
 	}
 
+	// A snapshot within the outage tolerance is authoritative: the alerts missing from it were not active.
+	if m.alertState != nil && !m.alertState.Timestamp.Before(m.mint) {
+		activeAt, ok := m.alertState.activeAt(ls)
+		if !ok {
+			return storage.NoopSeriesSet()
+		}
+		level.Debug(m.logger).Log("msg", "restoring for state from snapshot", "rule", ruleKey, "active_at", activeAt)
+		m.alertStateMetrics.restoredAlerts.Inc()
+		return series.NewConcreteSeriesSet(
+			[]storage.Series{
+				series.NewConcreteSeries(ls, []model.SamplePair{
+					{Timestamp: model.Time(util.TimeToMillis(m.alertState.Timestamp)), Value: model.SampleValue(activeAt.Unix())},
+				}),
+			},
+		)
+	}
+
 	var rule *rules.AlertingRule
 
 	// go fetch the rule via the alertname
@@ -0,0 +1,83 @@
+package ruler
+
+import (
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ruleGroupOwnership tracks the rule groups owned by this ruler, which are the rule groups it syncs.
+// When sharding is enabled, the ruler syncs the rule groups the ring assigns to it each time the rule groups
+// or the ring change.
+type ruleGroupOwnership struct {
+	// owned are the keys of the rule groups owned by each tenant at the last sync.
+	owned map[string]map[string]struct{}
+
+	ownedRuleGroups *prometheus.GaugeVec
+	ownedRules      *prometheus.GaugeVec
+	changes         *prometheus.CounterVec
+}
+
+func newRuleGroupOwnership(reg prometheus.Registerer) *ruleGroupOwnership {
+	return &ruleGroupOwnership{
+		owned: map[string]map[string]struct{}{},
+		ownedRuleGroups: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki",
+			Name:      "ruler_owned_rule_groups",
+			Help:      "Number of rule groups of each tenant evaluated by this ruler.",
+		}, []string{"tenant"}),
+		ownedRules: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki",
+			Name:      "ruler_owned_rules",
+			Help:      "Number of rules of each tenant evaluated by this ruler.",
+		}, []string{"tenant"}),
+		changes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "ruler_rule_group_ownership_changes_total",
+			Help:      "Total number of rule groups this ruler started (gained) or stopped (lost) evaluating, because they were created or deleted or because the ring changed.",
+		}, []string{"change"}),
+	}
+}
+
+func ruleGroupKey(g *rulespb.RuleGroupDesc) string {
+	return g.Namespace + "/" + g.Name
+}
+
+// sync updates the owned rule groups with the rule groups of a sync.
+func (o *ruleGroupOwnership) sync(ruleGroups map[string]rulespb.RuleGroupList) {
+	gained, lost := 0, 0
+	for userID, groups := range ruleGroups {
+		previous := o.owned[userID]
+		current := make(map[string]struct{}, len(groups))
+		rules := 0
+		for _, g := range groups {
+			key := ruleGroupKey(g)
+			current[key] = struct{}{}
+			if _, ok := previous[key]; !ok {
+				gained++
+			}
+			rules += len(g.Rules)
+		}
+		for key := range previous {
+			if _, ok := current[key]; !ok {
+				lost++
+			}
+		}
+		o.owned[userID] = current
+		o.ownedRuleGroups.WithLabelValues(userID).Set(float64(len(groups)))
+		o.ownedRules.WithLabelValues(userID).Set(float64(rules))
+	}
+
+	for userID, previous := range o.owned {
+		if _, ok := ruleGroups[userID]; ok {
+			continue
+		}
+		lost += len(previous)
+		delete(o.owned, userID)
+		o.ownedRuleGroups.DeleteLabelValues(userID)
+		o.ownedRules.DeleteLabelValues(userID)
+	}
+
+	o.changes.WithLabelValues("gained").Add(float64(gained))
+	o.changes.WithLabelValues("lost").Add(float64(lost))
+}
@@ -0,0 +1,42 @@
+package ruler
+
+import (
+	"testing"
+
+	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleGroupOwnership(t *testing.T) {
+	o := newRuleGroupOwnership(prometheus.NewRegistry())
+	group := func(namespace, name string, rules int) *rulespb.RuleGroupDesc {
+		return &rulespb.RuleGroupDesc{Namespace: namespace, Name: name, Rules: make([]*rulespb.RuleDesc, rules)}
+	}
+	requireOwned := func(userID string, groups, rules int) {
+		require.Equal(t, float64(groups), testutil.ToFloat64(o.ownedRuleGroups.WithLabelValues(userID)))
+		require.Equal(t, float64(rules), testutil.ToFloat64(o.ownedRules.WithLabelValues(userID)))
+	}
+	requireChanges := func(gained, lost int) {
+		require.Equal(t, float64(gained), testutil.ToFloat64(o.changes.WithLabelValues("gained")))
+		require.Equal(t, float64(lost), testutil.ToFloat64(o.changes.WithLabelValues("lost")))
+	}
+
+	o.sync(map[string]rulespb.RuleGroupList{
+		"user-1": {group("ns", "a", 2), group("ns", "b", 3)},
+		"user-2": {group("ns", "a", 1)},
+	})
+	requireOwned("user-1", 2, 5)
+	requireOwned("user-2", 1, 1)
+	requireChanges(3, 0)
+
+	// The ring changed: a group moved to another ruler, another one moved to this one and user-2 left.
+	o.sync(map[string]rulespb.RuleGroupList{
+		"user-1": {group("ns", "b", 3), group("other", "a", 4)},
+	})
+	requireOwned("user-1", 2, 7)
+	requireChanges(4, 2)
+	require.Equal(t, 1, testutil.CollectAndCount(o.ownedRuleGroups))
+	require.Equal(t, 1, testutil.CollectAndCount(o.ownedRules))
+}
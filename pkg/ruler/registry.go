@@ -229,7 +229,12 @@ func (r *walRegistry) getTenantRemoteWriteConfig(tenant string, base RemoteWrite
 	// TODO(dannyk): configure HTTP client overrides
 	// metadata is only used by prometheus scrape configs
 	overrides.Client.MetadataConfig = config.MetadataConfig{Send: false}
+	// the clone redacts the secret key, so copy the sigv4 config over
 	overrides.Client.SigV4Config = nil
+	if base.Client.SigV4Config != nil {
+		sigV4Config := *base.Client.SigV4Config
+		overrides.Client.SigV4Config = &sigV4Config
+	}
 
 	if r.overrides.RulerRemoteWriteDisabled(tenant) {
 		overrides.Enabled = false
@@ -252,6 +257,14 @@ func (r *walRegistry) getTenantRemoteWriteConfig(tenant string, base RemoteWrite
 		overrides.Client.Headers = v
 	}
 
+	// sigv4 cannot be combined with any other authorization method
+	if v := r.overrides.RulerRemoteWriteSigV4Config(tenant); v != nil {
+		overrides.Client.SigV4Config = v
+		overrides.Client.HTTPClientConfig.BasicAuth = nil
+		overrides.Client.HTTPClientConfig.Authorization = nil
+		overrides.Client.HTTPClientConfig.OAuth2 = nil
+	}
+
 	relabelConfigs, err := r.createRelabelConfigs(tenant)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse relabel configs: %w", err)
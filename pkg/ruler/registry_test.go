@@ -14,6 +14,7 @@ import (
 	"github.com/go-kit/log"
 	promConfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,7 @@ const customRelabelsTenant = "custom-relabels"
 const badRelabelsTenant = "bad-relabels"
 const nilRelabelsTenant = "nil-relabels"
 const emptySliceRelabelsTenant = "empty-slice-relabels"
+const sigV4Tenant = "sigv4"
 
 const defaultCapacity = 1000
 
@@ -74,6 +76,13 @@ func newFakeLimits() fakeLimits {
 			emptySliceRelabelsTenant: {
 				RulerRemoteWriteRelabelConfigs: []*util.RelabelConfig{},
 			},
+			sigV4Tenant: {
+				RulerRemoteWriteSigV4Config: &sigv4.SigV4Config{
+					Region:    "us-east-1",
+					AccessKey: "tenant-access-key",
+					SecretKey: promConfig.Secret("tenant-secret-key"),
+				},
+			},
 			badRelabelsTenant: {
 				RulerRemoteWriteRelabelConfigs: []*util.RelabelConfig{
 					{
@@ -194,6 +203,50 @@ func TestTenantRemoteWriteHTTPConfigMaintained(t *testing.T) {
 	assert.Equal(t, tenantCfg.RemoteWrite[0].HTTPClientConfig.BasicAuth.Password, promConfig.Secret("<secret>"))
 }
 
+func TestTenantRemoteWriteSigV4Override(t *testing.T) {
+	walDir, err := createTempWALDir()
+	require.NoError(t, err)
+	reg := setupRegistry(t, walDir)
+	defer os.RemoveAll(walDir)
+
+	tenantCfg, err := reg.getTenantConfig(sigV4Tenant)
+	require.NoError(t, err)
+
+	// the tenant's sigv4 config replaces the base basic auth
+	assert.Nil(t, tenantCfg.RemoteWrite[0].HTTPClientConfig.BasicAuth)
+	assert.Equal(t, &sigv4.SigV4Config{
+		Region:    "us-east-1",
+		AccessKey: "tenant-access-key",
+		SecretKey: promConfig.Secret("tenant-secret-key"),
+	}, tenantCfg.RemoteWrite[0].SigV4Config)
+
+	// other tenants inherit the base config
+	tenantCfg, err = reg.getTenantConfig(enabledRWTenant)
+	require.NoError(t, err)
+	assert.Nil(t, tenantCfg.RemoteWrite[0].SigV4Config)
+}
+
+func TestTenantRemoteWriteSigV4Maintained(t *testing.T) {
+	walDir, err := createTempWALDir()
+	require.NoError(t, err)
+	reg := setupRegistry(t, walDir)
+	defer os.RemoveAll(walDir)
+
+	sigV4Config := &sigv4.SigV4Config{
+		Region:    "eu-west-1",
+		AccessKey: "access-key",
+		SecretKey: promConfig.Secret("secret-key"),
+	}
+	reg.config.RemoteWrite.Client.HTTPClientConfig.BasicAuth = nil
+	reg.config.RemoteWrite.Client.SigV4Config = sigV4Config
+
+	tenantCfg, err := reg.getTenantConfig(enabledRWTenant)
+	require.NoError(t, err)
+
+	// the secret key must not be redacted when the base config is cloned
+	assert.Equal(t, sigV4Config, tenantCfg.RemoteWrite[0].SigV4Config)
+}
+
 func TestTenantRemoteWriteHeaderOverride(t *testing.T) {
 	walDir, err := createTempWALDir()
 	require.NoError(t, err)
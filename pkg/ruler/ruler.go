@@ -7,12 +7,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/chunk"
 )
 
-func NewRuler(cfg Config, engine *logql.Engine, reg prometheus.Registerer, logger log.Logger, ruleStore rulestore.RuleStore, limits RulesLimits) (*ruler.Ruler, error) {
+// NewRuler creates a ruler. The alert state snapshots are stored with alertStateClient, which is nil
+// when the alert state persistence is disabled.
+func NewRuler(cfg Config, engine *logql.Engine, reg prometheus.Registerer, logger log.Logger, ruleStore rulestore.RuleStore, limits RulesLimits, alertStateClient chunk.ObjectClient) (*ruler.Ruler, error) {
 	mgr, err := ruler.NewDefaultMultiTenantManager(
 		cfg.Config,
-		MultiTenantRuleManager(cfg, engine, limits, logger, reg),
+		MultiTenantRuleManager(cfg, engine, limits, alertStateClient, logger, reg),
 		reg,
 		logger,
 	)
@@ -21,7 +24,7 @@ func NewRuler(cfg Config, engine *logql.Engine, reg prometheus.Registerer, logge
 	}
 	return ruler.NewRuler(
 		cfg.Config,
-		MultiTenantManagerAdapter(mgr),
+		MultiTenantManagerAdapter(mgr, reg),
 		reg,
 		logger,
 		ruleStore,
@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"strings"
+)
+
+// Priority is the class of a request in the queue. Requests of a tenant are dequeued
+// from its priority classes using weighted fair queuing, see PriorityWeights.
+type Priority int
+
+const (
+	// PriorityLow is meant for long-running requests like recording rules or async queries.
+	PriorityLow Priority = iota
+	// PriorityNormal is the priority of requests not asking for a specific priority.
+	PriorityNormal
+	// PriorityHigh is meant for interactive requests like the ones coming from Grafana Explore.
+	PriorityHigh
+
+	numPriorities = int(PriorityHigh) + 1
+)
+
+// ParsePriority returns the priority with the given name. Unknown or empty names
+// get the normal priority.
+func ParsePriority(name string) Priority {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low":
+		return PriorityLow
+	case "high":
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// PriorityWeights holds the weight of each priority class, indexed by Priority.
+// A class with a weight twice as big as another one gets twice as many of its requests
+// dequeued when both have pending requests. Weights lower than 1 are treated as 1.
+type PriorityWeights [numPriorities]int
+
+// NewPriorityWeights returns the weights for the given classes.
+func NewPriorityWeights(low, normal, high int) PriorityWeights {
+	var w PriorityWeights
+	w[PriorityLow] = low
+	w[PriorityNormal] = normal
+	w[PriorityHigh] = high
+	return w
+}
+
+func (w PriorityWeights) weight(p Priority) float64 {
+	if w[p] < 1 {
+		return 1
+	}
+	return float64(w[p])
+}
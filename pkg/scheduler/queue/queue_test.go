@@ -0,0 +1,122 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	priority Priority
+	id       int
+}
+
+func newTestQueue(maxOutstanding int) *RequestQueue {
+	return NewRequestQueue(maxOutstanding, 0,
+		prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		prometheus.NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+	)
+}
+
+func dequeueN(t *testing.T, q *RequestQueue, n int) []testRequest {
+	t.Helper()
+
+	last := FirstUser()
+	res := make([]testRequest, 0, n)
+	for i := 0; i < n; i++ {
+		req, idx, err := q.GetNextRequestForQuerier(context.Background(), last, "querier-1")
+		require.NoError(t, err)
+		last = idx
+		res = append(res, req.(testRequest))
+	}
+	return res
+}
+
+func TestRequestQueue_Priorities(t *testing.T) {
+	q := newTestQueue(100)
+	q.RegisterQuerierConnection("querier-1")
+	weights := NewPriorityWeights(1, 4, 8)
+
+	for i := 0; i < 8; i++ {
+		for _, p := range []Priority{PriorityLow, PriorityHigh} {
+			require.NoError(t, q.EnqueueRequest("user-1", testRequest{priority: p, id: i}, p, 0, weights, nil))
+		}
+	}
+
+	var high, low int
+	for _, r := range dequeueN(t, q, 9) {
+		switch r.priority {
+		case PriorityHigh:
+			high++
+		case PriorityLow:
+			low++
+		}
+	}
+	require.Equal(t, 8, high)
+	require.Equal(t, 1, low)
+
+	// Requests of the same class are dequeued in order.
+	for i, r := range dequeueN(t, q, 7) {
+		require.Equal(t, testRequest{priority: PriorityLow, id: i + 1}, r)
+	}
+}
+
+func TestRequestQueue_PrioritiesNoCredit(t *testing.T) {
+	q := newTestQueue(100)
+	q.RegisterQuerierConnection("querier-1")
+	weights := NewPriorityWeights(1, 1, 1)
+
+	// Only high priority requests are dequeued at first, advancing the pass of their class.
+	for i := 0; i < 20; i++ {
+		require.NoError(t, q.EnqueueRequest("user-1", testRequest{priority: PriorityHigh, id: i}, PriorityHigh, 0, weights, nil))
+	}
+	dequeueN(t, q, 10)
+
+	// The low priority class was empty, it must not have accumulated credit in the meantime.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.EnqueueRequest("user-1", testRequest{priority: PriorityLow, id: i}, PriorityLow, 0, weights, nil))
+	}
+	var high, low int
+	for _, r := range dequeueN(t, q, 10) {
+		switch r.priority {
+		case PriorityHigh:
+			high++
+		case PriorityLow:
+			low++
+		}
+	}
+	require.Equal(t, 5, high)
+	require.Equal(t, 5, low)
+}
+
+func TestRequestQueue_MaxOutstandingAcrossPriorities(t *testing.T) {
+	q := newTestQueue(3)
+	weights := NewPriorityWeights(1, 1, 1)
+
+	require.NoError(t, q.EnqueueRequest("user-1", testRequest{}, PriorityLow, 0, weights, nil))
+	require.NoError(t, q.EnqueueRequest("user-1", testRequest{}, PriorityNormal, 0, weights, nil))
+	require.NoError(t, q.EnqueueRequest("user-1", testRequest{}, PriorityHigh, 0, weights, nil))
+	require.Equal(t, ErrTooManyRequests, q.EnqueueRequest("user-1", testRequest{}, PriorityHigh, 0, weights, nil))
+	require.NoError(t, q.EnqueueRequest("user-2", testRequest{}, PriorityHigh, 0, weights, nil))
+}
+
+func TestParsePriority(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		exp Priority
+	}{
+		{"", PriorityNormal},
+		{"low", PriorityLow},
+		{"HIGH", PriorityHigh},
+		{" normal ", PriorityNormal},
+		{"unknown", PriorityNormal},
+	} {
+		t.Run(fmt.Sprintf("%q", tc.in), func(t *testing.T) {
+			require.Equal(t, tc.exp, ParsePriority(tc.in))
+			require.Equal(t, tc.exp, ParsePriority(tc.exp.String()))
+		})
+	}
+}
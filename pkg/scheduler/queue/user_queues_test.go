@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func queriersForUser(q *queues, userID string, queriers []string) []string {
+	var res []string
+	for _, querierID := range queriers {
+		if uq, u, _ := q.getNextQueueForQuerier(-1, querierID); uq != nil && u == userID {
+			res = append(res, querierID)
+		}
+	}
+	return res
+}
+
+func TestQueues_ShuffleSharding(t *testing.T) {
+	var queriers []string
+	q := newUserQueues(10, 0)
+	other := newUserQueues(10, 0)
+	for i := 0; i < 5; i++ {
+		querierID := fmt.Sprintf("querier-%d", i)
+		queriers = append(queriers, querierID)
+		q.addQuerierConnection(querierID)
+		other.addQuerierConnection(querierID)
+	}
+
+	// A tenant without limit uses all queriers.
+	require.NotNil(t, q.getOrAddQueue("user-1", 0, PriorityWeights{}))
+	require.Equal(t, queriers, queriersForUser(q, "user-1", queriers))
+	q.deleteQueue("user-1")
+
+	// A limited tenant only lands on a subset of queriers, the same one on every queue.
+	require.NotNil(t, q.getOrAddQueue("user-1", 2, PriorityWeights{}))
+	require.NotNil(t, other.getOrAddQueue("user-1", 2, PriorityWeights{}))
+	shard := queriersForUser(q, "user-1", queriers)
+	require.Len(t, shard, 2)
+	require.Equal(t, shard, queriersForUser(other, "user-1", queriers))
+
+	// Raising the limit above the number of queriers uses all of them.
+	require.NotNil(t, q.getOrAddQueue("user-1", 10, PriorityWeights{}))
+	require.Equal(t, queriers, queriersForUser(q, "user-1", queriers))
+}
+
+func TestQueues_ShuffleShardingForgetDelay(t *testing.T) {
+	var queriers []string
+	q := newUserQueues(10, time.Minute)
+	for i := 0; i < 5; i++ {
+		querierID := fmt.Sprintf("querier-%d", i)
+		queriers = append(queriers, querierID)
+		q.addQuerierConnection(querierID)
+	}
+
+	require.NotNil(t, q.getOrAddQueue("user-1", 2, PriorityWeights{}))
+	shard := queriersForUser(q, "user-1", queriers)
+	require.Len(t, shard, 2)
+
+	// A querier of the shard disconnecting without notifying a shutdown is kept until the forget delay passes.
+	now := time.Now()
+	q.removeQuerierConnection(shard[0], now)
+	require.Equal(t, 0, q.forgetDisconnectedQueriers(now.Add(30*time.Second)))
+	require.Equal(t, shard, queriersForUser(q, "user-1", queriers))
+
+	require.Equal(t, 1, q.forgetDisconnectedQueriers(now.Add(2*time.Minute)))
+	newShard := queriersForUser(q, "user-1", queriers)
+	require.Len(t, newShard, 2)
+	require.NotContains(t, newShard, shard[0])
+
+	// A querier notifying its shutdown is removed straight away once disconnected.
+	q.notifyQuerierShutdown(newShard[0])
+	q.removeQuerierConnection(newShard[0], now)
+	require.NotContains(t, queriersForUser(q, "user-1", queriers), newShard[0])
+}
@@ -5,19 +5,18 @@ import (
 	"flag"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 
 	"github.com/cortexproject/cortex/pkg/frontend/v2/frontendv2pb"
-	"github.com/cortexproject/cortex/pkg/scheduler/queue"
 	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
-	"github.com/grafana/dskit/grpcclient"
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
@@ -32,10 +31,13 @@ import (
 	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 
+	"github.com/grafana/loki/pkg/scheduler/queue"
 	"github.com/grafana/loki/pkg/tenant"
 
 	lokiutil "github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/grpcclient"
 	lokigrpc "github.com/grafana/loki/pkg/util/httpgrpc"
+	"github.com/grafana/loki/pkg/util/httpreq"
 )
 
 var (
@@ -120,7 +122,7 @@ type connectedFrontend struct {
 
 type Config struct {
 	MaxOutstandingPerTenant int               `yaml:"max_outstanding_requests_per_tenant"`
-	QuerierForgetDelay      time.Duration     `yaml:"-"`
+	QuerierForgetDelay      time.Duration     `yaml:"querier_forget_delay"`
 	GRPCClientConfig        grpcclient.Config `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
 	// Schedulers ring
 	UseSchedulerRing bool                `yaml:"use_scheduler_ring"`
@@ -129,9 +131,7 @@ type Config struct {
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxOutstandingPerTenant, "query-scheduler.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per query scheduler. In-flight requests above this limit will fail with HTTP response status code 429.")
-	// Loki doesn't have query shuffle sharding yet for which this config is intended
-	// use the default value of 0 until someday when this config may be needed.
-	cfg.QuerierForgetDelay = 0
+	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 	f.BoolVar(&cfg.UseSchedulerRing, "query-scheduler.use-scheduler-ring", false, "Set to true to have the query scheduler create a ring and the frontend and frontend_worker use this ring to get the addresses of the query schedulers. If frontend_address and scheduler_address are not present in the config this value will be toggle by Loki to true")
 	cfg.SchedulerRing.RegisterFlagsWithPrefix("query-scheduler.", "collectors/", f)
@@ -237,6 +237,12 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 type Limits interface {
 	// MaxQueriersPerUser returns max queriers to use per tenant, or 0 if shuffle sharding is disabled.
 	MaxQueriersPerUser(user string) int
+
+	// QueryPriorityWeightHigh, QueryPriorityWeightNormal and QueryPriorityWeightLow return the weights
+	// used to dequeue the requests of a tenant from its priority classes.
+	QueryPriorityWeightHigh(user string) int
+	QueryPriorityWeightNormal(user string) int
+	QueryPriorityWeightLow(user string) int
 }
 
 type schedulerRequest struct {
@@ -404,9 +410,16 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 		return err
 	}
 	maxQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxQueriersPerUser)
+	weights := queue.NewPriorityWeights(
+		validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.QueryPriorityWeightLow),
+		validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.QueryPriorityWeightNormal),
+		validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.QueryPriorityWeightHigh),
+	)
+	priority := requestPriority(msg.HttpRequest)
+	req.queueSpan.SetTag("priority", priority.String())
 
 	s.activeUsers.UpdateUserTimestamp(userID, now)
-	return s.requestQueue.EnqueueRequest(userID, req, maxQueriers, func() {
+	return s.requestQueue.EnqueueRequest(userID, req, priority, maxQueriers, weights, func() {
 		shouldCancel = false
 
 		s.pendingRequestsMu.Lock()
@@ -415,6 +428,19 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 	})
 }
 
+// requestPriority returns the priority requested with the X-Query-Priority header of the request.
+func requestPriority(r *httpgrpc.HTTPRequest) queue.Priority {
+	if r == nil {
+		return queue.PriorityNormal
+	}
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Key, string(httpreq.QueryPriorityHTTPHeader)) && len(h.Values) > 0 {
+			return queue.ParsePriority(h.Values[0])
+		}
+	}
+	return queue.PriorityNormal
+}
+
 // This method doesn't do removal from the queue.
 func (s *Scheduler) cancelRequestAndRemoveFromPending(frontendAddr string, queryID uint64) {
 	s.pendingRequestsMu.Lock()
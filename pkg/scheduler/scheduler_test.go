@@ -10,7 +10,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/weaveworks/common/httpgrpc"
 	"google.golang.org/grpc/metadata"
+
+	"github.com/grafana/loki/pkg/scheduler/queue"
 )
 
 func TestScheduler_setRunState(t *testing.T) {
@@ -98,3 +101,14 @@ func (m mockSchedulerForFrontendFrontendLoopServer) SendMsg(msg interface{}) err
 func (m mockSchedulerForFrontendFrontendLoopServer) RecvMsg(msg interface{}) error {
 	panic("implement me")
 }
+
+func Test_requestPriority(t *testing.T) {
+	assert.Equal(t, queue.PriorityNormal, requestPriority(nil))
+	assert.Equal(t, queue.PriorityNormal, requestPriority(&httpgrpc.HTTPRequest{}))
+	assert.Equal(t, queue.PriorityHigh, requestPriority(&httpgrpc.HTTPRequest{
+		Headers: []*httpgrpc.Header{{Key: "X-Query-Priority", Values: []string{"high"}}},
+	}))
+	assert.Equal(t, queue.PriorityLow, requestPriority(&httpgrpc.HTTPRequest{
+		Headers: []*httpgrpc.Header{{Key: "x-query-priority", Values: []string{"low"}}},
+	}))
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+)
+
+// ArchiveConfig configures the archive, a secondary read-only store holding the chunks and boltdb-shipper index of
+// the data past the retention period, e.g. copied to a colder bucket before their deletion. The archive has the
+// layout of the primary store: the chunks keyed as configured by the schema config and the index under its own
+// key prefix.
+type ArchiveConfig struct {
+	Enabled           bool                      `yaml:"enabled"`
+	StoreType         string                    `yaml:"store"`
+	ObjectStoreConfig storage.ObjectStoreConfig `yaml:"object_store_config"`
+	IndexKeyPrefix    string                    `yaml:"index_key_prefix"`
+	CacheLocation     string                    `yaml:"cache_location"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *ArchiveConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "store.archive.enabled", false, "Read the data of the tenants enabling the archive_queries feature flag from the archive when queried past their retention period.")
+	f.StringVar(&cfg.StoreType, "store.archive.store", "", "Object store holding the archive. Supported values are: aws, s3, azure, gcs, swift, filesystem.")
+	cfg.ObjectStoreConfig.RegisterFlagsWithPrefix("store.archive.object-store-config.", f)
+	f.StringVar(&cfg.IndexKeyPrefix, "store.archive.index-key-prefix", "index/", "Prefix of the keys of the boltdb-shipper index files in the archive. Prefix should never start with a separator but should always end with it.")
+	f.StringVar(&cfg.CacheLocation, "store.archive.cache-location", "", "Directory where the index files of the archive are downloaded to be queried.")
+}
+
+// Validate validates the config.
+func (cfg *ArchiveConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	switch cfg.StoreType {
+	case storage.StorageTypeAWS, storage.StorageTypeS3, storage.StorageTypeAzure, storage.StorageTypeGCS, storage.StorageTypeSwift, storage.StorageTypeFileSystem:
+	default:
+		return fmt.Errorf("unsupported archive store %q, the archive must be in an object store", cfg.StoreType)
+	}
+	if strings.HasPrefix(cfg.IndexKeyPrefix, "/") || !strings.HasSuffix(cfg.IndexKeyPrefix, "/") {
+		return errors.New("the index key prefix of the archive must end with a separator and not start with it")
+	}
+	if cfg.CacheLocation == "" {
+		return errors.New("the cache location of the archive must be set")
+	}
+	return nil
+}
+
+// ArchiveLimits are the limits of the queries of the archive.
+type ArchiveLimits interface {
+	chunk.StoreLimits
+	ArchiveMaxChunksPerQuery(userID string) int
+}
+
+// NewArchiveStore creates a Store reading the archive, for the periods of the schema config using the boltdb-shipper
+// index. The index files of the archive are downloaded to the cache location of the archive as they are queried.
+func NewArchiveStore(cfg Config, storeCfg chunk.StoreConfig, schemaCfg SchemaConfig, limits ArchiveLimits) (Store, error) {
+	var periods []chunk.PeriodConfig
+	for _, p := range schemaCfg.Configs {
+		if p.IndexType == shipper.BoltDBShipperType {
+			periods = append(periods, p)
+		}
+	}
+	if len(periods) == 0 {
+		return nil, errors.New("the archive requires a period of the schema config using the boltdb-shipper index")
+	}
+
+	archiveStorageCfg := cfg.Archive.ObjectStoreConfig.Apply(cfg.Config)
+	objectClient, err := storage.NewObjectClient(cfg.Archive.StoreType, archiveStorageCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	shipperCfg := cfg.BoltDBShipperConfig
+	shipperCfg.Mode = shipper.ModeReadOnly
+	shipperCfg.SharedStoreKeyPrefix = cfg.Archive.IndexKeyPrefix
+	shipperCfg.CacheLocation = cfg.Archive.CacheLocation
+	shipperCfg.ActiveIndexDirectory = ""
+	// The archive is rarely queried, its tables are only downloaded on demand.
+	shipperCfg.QueryReadyNumDays = 0
+	shipperCfg.PrefetchLookback = 0
+	// The archive metrics aren't registered, not to collide with the ones of the primary store.
+	indexClient, err := shipper.NewShipper(shipperCfg, objectClient, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkClient, err := storage.NewChunkClient(cfg.Archive.StoreType, archiveStorageCfg, schemaCfg.SchemaConfig, nil)
+	if err != nil {
+		indexClient.Stop()
+		return nil, err
+	}
+
+	stores := chunk.NewCompositeStore(nil)
+	for _, p := range periods {
+		if err := stores.AddPeriod(storeCfg, p, indexClient, chunkClient, limits, cache.NewNoopCache(), cache.NewNoopCache()); err != nil {
+			indexClient.Stop()
+			chunkClient.Stop()
+			return nil, err
+		}
+	}
+
+	return &store{
+		Store:         stores,
+		cfg:           cfg,
+		chunkMetrics:  NewChunkMetrics(nil, cfg.MaxChunkBatchSize),
+		schemaCfg:     schemaCfg,
+		archiveLimits: limits,
+	}, nil
+}
+
+// checkArchiveChunks returns an error if a query of the archive would fetch more chunks than the tenant is allowed to.
+func (s *store) checkArchiveChunks(userID string, chunks int) error {
+	if s.archiveLimits == nil {
+		return nil
+	}
+	if limit := s.archiveLimits.ArchiveMaxChunksPerQuery(userID); limit > 0 && chunks > limit {
+		return httpgrpc.Errorf(http.StatusBadRequest, "the query would fetch too many chunks from the archive (%d > %d), reduce the time range of the query past the retention period", chunks, limit)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_local "github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/chunk/storage"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestArchiveConfig_Validate(t *testing.T) {
+	valid := ArchiveConfig{Enabled: true, StoreType: "s3", IndexKeyPrefix: "index/", CacheLocation: "/loki/archive-cache"}
+	require.NoError(t, valid.Validate())
+	require.NoError(t, (&ArchiveConfig{}).Validate())
+
+	for name, update := range map[string]func(*ArchiveConfig){
+		"not an object store":     func(cfg *ArchiveConfig) { cfg.StoreType = "cassandra" },
+		"no key prefix separator": func(cfg *ArchiveConfig) { cfg.IndexKeyPrefix = "index" },
+		"no cache location":       func(cfg *ArchiveConfig) { cfg.CacheLocation = "" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := valid
+			update(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestArchiveStore(t *testing.T) {
+	tempDir := t.TempDir()
+	archiveDir := path.Join(tempDir, "archive")
+
+	defaults := validation.Limits{}
+	flagext.DefaultValues(&defaults)
+	limits, err := validation.NewOverrides(defaults, nil)
+	require.NoError(t, err)
+
+	boltdbShipperConfig := shipper.Config{}
+	flagext.DefaultValues(&boltdbShipperConfig)
+	boltdbShipperConfig.ActiveIndexDirectory = path.Join(tempDir, "index")
+	boltdbShipperConfig.SharedStoreType = "filesystem"
+	boltdbShipperConfig.CacheLocation = path.Join(tempDir, "boltdb-shipper-cache")
+
+	config := Config{
+		Config: storage.Config{
+			FSConfig: chunk_local.FSConfig{Directory: archiveDir},
+		},
+		MaxChunkBatchSize:   10,
+		BoltDBShipperConfig: boltdbShipperConfig,
+	}
+	schemaConfig := SchemaConfig{
+		chunk.SchemaConfig{
+			Configs: []chunk.PeriodConfig{
+				{
+					From:       chunk.DayTime{Time: timeToModelTime(parseDate("2019-01-01"))},
+					IndexType:  "boltdb-shipper",
+					ObjectType: "filesystem",
+					Schema:     "v11",
+					IndexTables: chunk.PeriodicTableConfig{
+						Prefix: "index_",
+						Period: time.Hour * 24,
+					},
+					RowShards: 2,
+				},
+			},
+		},
+	}
+
+	// Write chunks with the layout of the archive, as if copied there from the store before their deletion.
+	RegisterCustomIndexClients(&config, nil)
+	chunkStore, err := storage.NewStore(config.Config, chunk.StoreConfig{}, schemaConfig.SchemaConfig, limits, nil, nil, util_log.Logger)
+	require.NoError(t, err)
+	day := parseDate("2019-01-02")
+	for _, tr := range []timeRange{
+		{day.Add(time.Hour), day.Add(2 * time.Hour)},
+		{day.Add(3 * time.Hour), day.Add(4 * time.Hour)},
+	} {
+		chk := newChunk(buildTestStreams(fooLabelsWithName, tr))
+		require.NoError(t, chunkStore.PutOne(ctx, chk.From, chk.Through, chk))
+	}
+	chunkStore.Stop()
+
+	config.Archive = ArchiveConfig{
+		Enabled:           true,
+		StoreType:         "filesystem",
+		ObjectStoreConfig: storage.ObjectStoreConfig{Enabled: true, FSConfig: chunk_local.FSConfig{Directory: archiveDir}},
+		IndexKeyPrefix:    "index/",
+		CacheLocation:     path.Join(tempDir, "archive-cache"),
+	}
+	// The store itself no longer holds the archived data.
+	config.FSConfig.Directory = path.Join(tempDir, "chunks")
+	archive, err := NewArchiveStore(config, chunk.StoreConfig{}, schemaConfig, limits)
+	require.NoError(t, err)
+	defer archive.Stop()
+
+	it, err := archive.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: newQuery(fooLabels, day, day.Add(24*time.Hour), nil)})
+	require.NoError(t, err)
+	var entries int
+	for it.Next() {
+		entries++
+	}
+	require.NoError(t, it.Close())
+	require.Equal(t, 2*3600, entries)
+
+	// The archive has its own limit of chunks per query.
+	defaults.ArchiveMaxChunksPerQuery = 1
+	limits, err = validation.NewOverrides(defaults, nil)
+	require.NoError(t, err)
+	archive.(*store).archiveLimits = limits
+	_, err = archive.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: newQuery(fooLabels, day, day.Add(24*time.Hour), nil)})
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(400), resp.Code)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/astmapper"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
@@ -20,6 +21,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 )
@@ -679,6 +681,11 @@ func fetchLazyChunks(ctx context.Context, chunks []*LazyChunk) error {
 	}
 	level.Debug(logger).Log("msg", "loading lazy chunks", "chunks", totalChunks)
 
+	queryLimiter := limiter.QueryLimiterFromContextWithFallback(ctx)
+	if err := queryLimiter.AddChunks(int(totalChunks)); err != nil {
+		return logqlmodel.NewQueryLimitError(err)
+	}
+
 	errChan := make(chan error)
 	for fetcher, chunks := range chksByFetcher {
 		go func(fetcher *chunk.Fetcher, chunks []*LazyChunk) {
@@ -698,7 +705,7 @@ func fetchLazyChunks(ctx context.Context, chunks []*LazyChunk) error {
 			if err != nil {
 				level.Error(logger).Log("msg", "error fetching chunks", "err", err)
 				if isInvalidChunkError(err) {
-					level.Error(logger).Log("msg", "checksum of chunks does not match", "err", chunk.ErrInvalidChecksum)
+					level.Error(logger).Log("msg", "chunks failed their integrity checks", "err", err)
 					errChan <- nil
 					return
 				}
@@ -707,8 +714,16 @@ func fetchLazyChunks(ctx context.Context, chunks []*LazyChunk) error {
 
 			}
 			// assign fetched chunk by key as FetchChunks doesn't guarantee the order.
+			var fetchedBytes int
 			for _, chk := range chks {
 				index[chk.ExternalKey()].Chunk = chk
+				if encoded, err := chk.Encoded(); err == nil {
+					fetchedBytes += len(encoded)
+				}
+			}
+			if err := queryLimiter.AddChunkBytes(fetchedBytes); err != nil {
+				errChan <- logqlmodel.NewQueryLimitError(err)
+				return
 			}
 
 			errChan <- nil
@@ -737,7 +752,7 @@ func fetchLazyChunks(ctx context.Context, chunks []*LazyChunk) error {
 func isInvalidChunkError(err error) bool {
 	err = errors.Cause(err)
 	if err, ok := err.(promql.ErrStorage); ok {
-		return err.Err == chunk.ErrInvalidChecksum || err.Err == chunkenc.ErrInvalidChecksum
+		return chunk.IsCorruptChunkErr(err.Err) || err.Err == chunkenc.ErrInvalidChecksum
 	}
 	return false
 }
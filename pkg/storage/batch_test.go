@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/cespare/xxhash/v2"
+	"github.com/cortexproject/cortex/pkg/util/limiter"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
@@ -18,6 +19,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 )
@@ -1586,6 +1588,11 @@ func Test_IsInvalidChunkError(t *testing.T) {
 			promql.ErrStorage{Err: chunkenc.ErrInvalidChecksum},
 			true,
 		},
+		{
+			"wrong chunk metadata error",
+			promql.ErrStorage{Err: errors.WithStack(chunk.ErrWrongMetadata)},
+			true,
+		},
 		{
 			"cache error",
 			promql.ErrStorage{Err: errors.New("error fetching from cache")},
@@ -1632,6 +1639,47 @@ func TestBatchCancel(t *testing.T) {
 	require.Equal(t, context.Canceled, it.Error())
 }
 
+func Test_fetchLazyChunksQueryLimits(t *testing.T) {
+	store := newMockChunkStore(streamsFixture)
+	lazyChunks := func() []*LazyChunk {
+		refs, fetchers, err := store.GetChunkRefs(context.Background(), "fake", 0, 0)
+		require.NoError(t, err)
+		var chks []*LazyChunk
+		for _, ref := range refs[0] {
+			chks = append(chks, &LazyChunk{Chunk: ref, Fetcher: fetchers[0]})
+		}
+		return chks
+	}
+	var chunkBytes int
+	for _, c := range store.chunks {
+		encoded, err := c.Encoded()
+		require.NoError(t, err)
+		chunkBytes += len(encoded)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		limiter *limiter.QueryLimiter
+		err     bool
+	}{
+		{"no limits", limiter.NewQueryLimiter(0, 0, 0), false},
+		{"within limits", limiter.NewQueryLimiter(0, chunkBytes, len(store.chunks)), false},
+		{"too many chunks", limiter.NewQueryLimiter(0, 0, len(store.chunks)-1), true},
+		{"too many chunk bytes", limiter.NewQueryLimiter(0, chunkBytes-1, 0), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := limiter.AddQueryLimiterToContext(context.Background(), tc.limiter)
+			err := fetchLazyChunks(ctx, lazyChunks())
+			if !tc.err {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, errors.Is(err, logqlmodel.ErrLimit))
+		})
+	}
+}
+
 var entry logproto.Entry
 
 func Benchmark_store_OverlappingChunks(b *testing.B) {
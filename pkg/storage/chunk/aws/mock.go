@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"sort"
 	"strings"
 	"sync"
@@ -413,6 +414,17 @@ func (m *mockS3) PutObjectWithContext(_ aws.Context, req *s3.PutObjectInput, _ .
 	return &s3.PutObjectOutput{}, nil
 }
 
+func (m *mockS3) HeadObjectWithContext(_ aws.Context, req *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if _, ok := m.objects[*req.Key]; !ok {
+		return nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), http.StatusNotFound, "")
+	}
+
+	return &s3.HeadObjectOutput{}, nil
+}
+
 func (m *mockS3) GetObjectWithContext(_ aws.Context, req *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
 	m.RLock()
 	defer m.RUnlock()
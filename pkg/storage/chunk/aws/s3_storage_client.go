@@ -413,6 +413,41 @@ func (a *S3ObjectClient) GetObject(ctx context.Context, objectKey string) (io.Re
 	return nil, errors.Wrap(err, "failed to get s3 object")
 }
 
+// ObjectExists checks whether the object exists using a HEAD request.
+func (a *S3ObjectClient) ObjectExists(ctx context.Context, objectKey string) (bool, error) {
+	err := instrument.CollectedRequest(ctx, "S3.HeadObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+		_, requestErr := a.hedgedS3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(a.bucketFromKey(objectKey)),
+			Key:    aws.String(objectKey),
+		})
+		return requestErr
+	})
+	if err == nil {
+		return true, nil
+	}
+	if aerr, ok := errors.Cause(err).(awserr.RequestFailure); ok && aerr.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return false, errors.Wrap(err, "failed to head s3 object")
+}
+
+// ObjectSize returns the size of the object using a HEAD request.
+func (a *S3ObjectClient) ObjectSize(ctx context.Context, objectKey string) (int64, error) {
+	var resp *s3.HeadObjectOutput
+	err := instrument.CollectedRequest(ctx, "S3.HeadObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+		var requestErr error
+		resp, requestErr = a.hedgedS3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(a.bucketFromKey(objectKey)),
+			Key:    aws.String(objectKey),
+		})
+		return requestErr
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to head s3 object")
+	}
+	return aws.Int64Value(resp.ContentLength), nil
+}
+
 // PutObject into the store
 func (a *S3ObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
 	return instrument.CollectedRequest(ctx, "S3.PutObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
@@ -433,6 +468,60 @@ func (a *S3ObjectClient) PutObject(ctx context.Context, objectKey string, object
 	})
 }
 
+// GetObjectVersion returns a reader for the specified object key along with its ETag.
+func (a *S3ObjectClient) GetObjectVersion(ctx context.Context, objectKey string) (io.ReadCloser, string, error) {
+	var resp *s3.GetObjectOutput
+	err := instrument.CollectedRequest(ctx, "S3.GetObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+		var requestErr error
+		resp, requestErr = a.S3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(a.bucketFromKey(objectKey)),
+			Key:    aws.String(objectKey),
+		})
+		return requestErr
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Body, aws.StringValue(resp.ETag), nil
+}
+
+// PutObjectIfVersion puts the object only if its ETag matches the given version, using If-Match,
+// or only if it does not exist when the version is empty, using If-None-Match.
+func (a *S3ObjectClient) PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error) {
+	var resp *s3.PutObjectOutput
+	err := instrument.CollectedRequest(ctx, "S3.PutObject", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+		putObjectInput := &s3.PutObjectInput{
+			Body:   object,
+			Bucket: aws.String(a.bucketFromKey(objectKey)),
+			Key:    aws.String(objectKey),
+		}
+
+		if a.sseConfig != nil {
+			putObjectInput.ServerSideEncryption = aws.String(a.sseConfig.ServerSideEncryption)
+			putObjectInput.SSEKMSKeyId = a.sseConfig.KMSKeyID
+			putObjectInput.SSEKMSEncryptionContext = a.sseConfig.KMSEncryptionContext
+		}
+
+		precondition := map[string]string{"If-None-Match": "*"}
+		if version != "" {
+			precondition = map[string]string{"If-Match": version}
+		}
+
+		var requestErr error
+		resp, requestErr = a.S3.PutObjectWithContext(ctx, putObjectInput, request.WithSetRequestHeaders(precondition))
+		return requestErr
+	})
+	if err != nil {
+		// S3 answers 409 when a conflicting conditional write is in progress.
+		if aerr, ok := errors.Cause(err).(awserr.RequestFailure); ok &&
+			(aerr.StatusCode() == http.StatusPreconditionFailed || aerr.StatusCode() == http.StatusConflict) {
+			return "", chunk.ErrObjectModified
+		}
+		return "", err
+	}
+	return aws.StringValue(resp.ETag), nil
+}
+
 // List implements chunk.ObjectClient.
 func (a *S3ObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	var storageObjects []chunk.StorageObject
@@ -484,6 +573,45 @@ func (a *S3ObjectClient) List(ctx context.Context, prefix, delimiter string) ([]
 	return storageObjects, commonPrefixes, nil
 }
 
+// ListPages implements chunk.ObjectPagesLister, calling f with each page of the listing of each bucket.
+func (a *S3ObjectClient) ListPages(ctx context.Context, prefix string, f func([]chunk.StorageObject) error) error {
+	for i := range a.bucketNames {
+		input := s3.ListObjectsV2Input{
+			Bucket: aws.String(a.bucketNames[i]),
+			Prefix: aws.String(prefix),
+		}
+
+		for {
+			var output *s3.ListObjectsV2Output
+			err := instrument.CollectedRequest(ctx, "S3.List", s3RequestDuration, instrument.ErrorCode, func(ctx context.Context) error {
+				var err error
+				output, err = a.S3.ListObjectsV2WithContext(ctx, &input)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			storageObjects := make([]chunk.StorageObject, 0, len(output.Contents))
+			for _, content := range output.Contents {
+				storageObjects = append(storageObjects, chunk.StorageObject{
+					Key:        *content.Key,
+					ModifiedAt: *content.LastModified,
+				})
+			}
+			if err := f(storageObjects); err != nil {
+				return err
+			}
+
+			if output.IsTruncated == nil || !*output.IsTruncated || output.NextContinuationToken == nil {
+				break
+			}
+			input.SetContinuationToken(*output.NextContinuationToken)
+		}
+	}
+	return nil
+}
+
 // IsObjectNotFoundErr returns true if error means that object is not found. Relevant to GetObject and DeleteObject operations.
 func (a *S3ObjectClient) IsObjectNotFoundErr(err error) bool {
 	if aerr, ok := errors.Cause(err).(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
@@ -204,6 +204,47 @@ func (b *BlobStorage) getObject(ctx context.Context, objectKey string) (rc io.Re
 	return downloadResponse.Body(azblob.RetryReaderOptions{MaxRetryRequests: b.cfg.MaxRetries}), nil
 }
 
+// ObjectExists checks whether the blob exists by fetching its properties.
+func (b *BlobStorage) ObjectExists(ctx context.Context, objectKey string) (bool, error) {
+	if b.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.cfg.RequestTimeout)
+		defer cancel()
+	}
+	blockBlobURL, err := b.getBlobURL(objectKey, true)
+	if err != nil {
+		return false, err
+	}
+	_, err = blockBlobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, noClientKey)
+	if err == nil {
+		return true, nil
+	}
+	// HEAD responses have no body, so rely on the status code rather than the service code.
+	var e azblob.StorageError
+	if errors.As(err, &e) && e.Response() != nil && e.Response().StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// ObjectSize returns the size of the blob from its properties.
+func (b *BlobStorage) ObjectSize(ctx context.Context, objectKey string) (int64, error) {
+	if b.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.cfg.RequestTimeout)
+		defer cancel()
+	}
+	blockBlobURL, err := b.getBlobURL(objectKey, true)
+	if err != nil {
+		return 0, err
+	}
+	props, err := blockBlobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, noClientKey)
+	if err != nil {
+		return 0, err
+	}
+	return props.ContentLength(), nil
+}
+
 func (b *BlobStorage) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
 	blockBlobURL, err := b.getBlobURL(objectKey, false)
 	if err != nil {
@@ -218,6 +259,56 @@ func (b *BlobStorage) PutObject(ctx context.Context, objectKey string, object io
 	return err
 }
 
+// GetObjectVersion returns a reader for the specified blob along with its ETag.
+func (b *BlobStorage) GetObjectVersion(ctx context.Context, objectKey string) (io.ReadCloser, string, error) {
+	var cancel context.CancelFunc = func() {}
+	if b.cfg.RequestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, b.cfg.RequestTimeout)
+	}
+
+	blockBlobURL, err := b.getBlobURL(objectKey, false)
+	if err != nil {
+		cancel()
+		return nil, "", err
+	}
+
+	downloadResponse, err := blockBlobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, noClientKey)
+	if err != nil {
+		cancel()
+		return nil, "", err
+	}
+
+	rc := downloadResponse.Body(azblob.RetryReaderOptions{MaxRetryRequests: b.cfg.MaxRetries})
+	return chunk_util.NewReadCloserWithContextCancelFunc(rc, cancel), string(downloadResponse.ETag()), nil
+}
+
+// PutObjectIfVersion uploads the blob only if its ETag matches the given version, using If-Match,
+// or only if it does not exist when the version is empty, using If-None-Match.
+func (b *BlobStorage) PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error) {
+	blockBlobURL, err := b.getBlobURL(objectKey, false)
+	if err != nil {
+		return "", err
+	}
+
+	conditions := azblob.ModifiedAccessConditions{IfNoneMatch: azblob.ETagAny}
+	if version != "" {
+		conditions = azblob.ModifiedAccessConditions{IfMatch: azblob.ETag(version)}
+	}
+
+	resp, err := blockBlobURL.Upload(ctx, object, azblob.BlobHTTPHeaders{}, azblob.Metadata{},
+		azblob.BlobAccessConditions{ModifiedAccessConditions: conditions}, azblob.AccessTierNone, nil, noClientKey)
+	if err != nil {
+		// Azure answers 409 instead of 412 when If-None-Match fails because the blob exists.
+		var e azblob.StorageError
+		if errors.As(err, &e) && e.Response() != nil &&
+			(e.Response().StatusCode == http.StatusPreconditionFailed || e.Response().StatusCode == http.StatusConflict) {
+			return "", chunk.ErrObjectModified
+		}
+		return "", err
+	}
+	return string(resp.ETag()), nil
+}
+
 func (b *BlobStorage) getBlobURL(blobID string, hedging bool) (azblob.BlockBlobURL, error) {
 	blobID = strings.Replace(blobID, ":", "-", -1)
 
@@ -373,6 +464,34 @@ func (b *BlobStorage) List(ctx context.Context, prefix, delimiter string) ([]chu
 	return storageObjects, commonPrefixes, nil
 }
 
+// ListPages implements chunk.ObjectPagesLister, calling f with each segment of the listing.
+func (b *BlobStorage) ListPages(ctx context.Context, prefix string, f func([]chunk.StorageObject) error) error {
+	for marker := (azblob.Marker{}); marker.NotDone(); {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		listBlob, err := b.containerURL.ListBlobsFlatSegment(ctx, marker, azblob.ListBlobsSegmentOptions{Prefix: prefix})
+		if err != nil {
+			return err
+		}
+
+		marker = listBlob.NextMarker
+
+		storageObjects := make([]chunk.StorageObject, 0, len(listBlob.Segment.BlobItems))
+		for _, blobInfo := range listBlob.Segment.BlobItems {
+			storageObjects = append(storageObjects, chunk.StorageObject{
+				Key:        blobInfo.Name,
+				ModifiedAt: blobInfo.Properties.LastModified,
+			})
+		}
+		if err := f(storageObjects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *BlobStorage) DeleteObject(ctx context.Context, blobID string) error {
 	blockBlobURL, err := b.getBlobURL(blobID, false)
 	if err != nil {
@@ -56,6 +56,9 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, description string, f
 }
 
 func (cfg *Config) Validate() error {
+	if err := cfg.MemcacheClient.Validate(); err != nil {
+		return err
+	}
 	return cfg.Fifocache.Validate()
 }
 
@@ -101,7 +104,10 @@ func New(cfg Config, reg prometheus.Registerer, logger log.Logger) (Cache, error
 			cfg.Memcache.Expiration = cfg.DefaultValidity
 		}
 
-		client := NewMemcachedClient(cfg.MemcacheClient, cfg.Prefix, reg, logger)
+		client, err := NewMemcachedClient(cfg.MemcacheClient, cfg.Prefix, reg, logger)
+		if err != nil {
+			return nil, err
+		}
 		cache := NewMemcached(cfg.Memcache, client, cfg.Prefix, reg, logger)
 
 		cacheName := cfg.Prefix + "memcache"
@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net"
@@ -13,6 +15,8 @@ import (
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	dstls "github.com/grafana/dskit/crypto/tls"
+	"github.com/grafana/dskit/flagext"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -54,6 +58,11 @@ type memcachedClient struct {
 
 	maxItemSize int
 
+	connectTimeout time.Duration
+	tlsConfig      *tls.Config
+	username       string
+	password       string
+
 	quit chan struct{}
 	wait sync.WaitGroup
 
@@ -76,6 +85,12 @@ type MemcachedClientConfig struct {
 	CBFailures     uint          `yaml:"circuit_breaker_consecutive_failures"`
 	CBTimeout      time.Duration `yaml:"circuit_breaker_timeout"`  // reset error count after this long
 	CBInterval     time.Duration `yaml:"circuit_breaker_interval"` // remain closed for this long after CBFailures errors
+
+	ConnectTimeout   time.Duration      `yaml:"connect_timeout"`
+	TLSEnabled       bool               `yaml:"tls_enabled"`
+	TLS              dstls.ClientConfig `yaml:",inline"`
+	AuthFileUsername string             `yaml:"auth_file_username"`
+	AuthFilePassword flagext.Secret     `yaml:"auth_file_password"`
 }
 
 // RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet
@@ -91,11 +106,35 @@ func (cfg *MemcachedClientConfig) RegisterFlagsWithPrefix(prefix, description st
 	f.DurationVar(&cfg.CBTimeout, prefix+"memcached.circuit-breaker-timeout", 10*time.Second, description+"Duration circuit-breaker remains open after tripping (if zero then 60 seconds is used).")
 	f.DurationVar(&cfg.CBInterval, prefix+"memcached.circuit-breaker-interval", 10*time.Second, description+"Reset circuit-breaker counts after this long (if zero then never reset).")
 	f.IntVar(&cfg.MaxItemSize, prefix+"memcached.max-item-size", 0, description+"The maximum size of an item stored in memcached. Bigger items are not stored. If set to 0, no maximum size is enforced.")
+	f.DurationVar(&cfg.ConnectTimeout, prefix+"memcached.connect-timeout", 0, description+"Maximum time to wait before giving up on establishing a connection to memcached, including the TLS handshake and the authentication. If set to 0, the timeout of the requests is used.")
+	f.BoolVar(&cfg.TLSEnabled, prefix+"memcached.tls-enabled", false, description+"Enable connecting to memcached with TLS.")
+	cfg.TLS.RegisterFlagsWithPrefix(prefix+"memcached", f)
+	f.StringVar(&cfg.AuthFileUsername, prefix+"memcached.auth-file-username", "", description+"Username of the memcached auth file (memcached -Y) to authenticate with, in the text protocol. SASL authentication, which requires the binary protocol, is not supported. If empty, no authentication is done.")
+	f.Var(&cfg.AuthFilePassword, prefix+"memcached.auth-file-password", description+"Password of the memcached auth file to authenticate with.")
+}
+
+// Validate the memcached client config and returns an error if the validation doesn't pass.
+func (cfg *MemcachedClientConfig) Validate() error {
+	if cfg.AuthFilePassword.Value != "" && cfg.AuthFileUsername == "" {
+		return errors.New("the memcached auth file username is required when the password is set")
+	}
+	if cfg.ConnectTimeout < 0 {
+		return errors.New("the memcached connect timeout must not be negative")
+	}
+	return nil
 }
 
 // NewMemcachedClient creates a new MemcacheClient that gets its server list
 // from SRV and updates the server list on a regular basis.
-func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Registerer, logger log.Logger) MemcachedClient {
+func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Registerer, logger log.Logger) (MemcachedClient, error) {
+	var tlsConfig *tls.Config
+	if cfg.TLSEnabled {
+		var err error
+		if tlsConfig, err = cfg.TLS.GetTLSConfig(); err != nil {
+			return nil, errors.Wrap(err, "error creating memcached TLS config")
+		}
+	}
+
 	var selector serverSelector
 	if cfg.ConsistentHash {
 		selector = &MemcachedJumpHashSelector{}
@@ -126,6 +165,11 @@ func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Reg
 		maxItemSize: cfg.MaxItemSize,
 		quit:        make(chan struct{}),
 
+		connectTimeout: cfg.ConnectTimeout,
+		tlsConfig:      tlsConfig,
+		username:       cfg.AuthFileUsername,
+		password:       cfg.AuthFilePassword.Value,
+
 		numServers: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Namespace:   "loki",
 			Name:        "memcache_client_servers",
@@ -140,6 +184,7 @@ func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Reg
 			ConstLabels: prometheus.Labels{"name": name},
 		}),
 	}
+	newClient.Client.DialTimeout = newClient.dial
 	if cfg.CBFailures > 0 {
 		newClient.Client.DialTimeout = newClient.dialViaCircuitBreaker
 	}
@@ -156,7 +201,7 @@ func NewMemcachedClient(cfg MemcachedClientConfig, name string, r prometheus.Reg
 
 	newClient.wait.Add(1)
 	go newClient.updateLoop(cfg.UpdateInterval)
-	return newClient
+	return newClient, nil
 }
 
 func (c *memcachedClient) circuitBreakerStateChange(name string, from gobreaker.State, to gobreaker.State) {
@@ -181,7 +226,7 @@ func (c *memcachedClient) dialViaCircuitBreaker(network, address string, timeout
 	c.Unlock()
 
 	conn, err := cb.Execute(func() (interface{}, error) {
-		return net.DialTimeout(network, address, timeout)
+		return c.dial(network, address, timeout)
 	})
 	if err != nil {
 		return nil, err
@@ -189,6 +234,60 @@ func (c *memcachedClient) dialViaCircuitBreaker(network, address string, timeout
 	return conn.(net.Conn), nil
 }
 
+// dial connects to a memcached server, with TLS if enabled, and authenticates the connection if a username is
+// configured. The whole connection setup has to complete within the connect timeout, which defaults to the timeout
+// of the requests.
+func (c *memcachedClient) dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	if c.connectTimeout > 0 {
+		timeout = c.connectTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	dialer := &net.Dialer{Deadline: deadline}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, network, address, c.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(network, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.username != "" {
+		if err := c.authenticate(conn, deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// authenticate authenticates a connection with the authentication of the memcached text protocol, checked against
+// the auth file of memcached, a set command whose value is the username and the password separated by a space. The
+// SASL authentication isn't supported, memcached only accepting the binary protocol once SASL is enabled.
+func (c *memcachedClient) authenticate(conn net.Conn, deadline time.Time) error {
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	credentials := c.username + " " + c.password
+	if _, err := fmt.Fprintf(conn, "set auth 0 0 %d\r\n%s\r\n", len(credentials), credentials); err != nil {
+		return errors.Wrap(err, "memcached authentication")
+	}
+	// The server sends nothing else until the next command, so the buffered reader can't read past the response.
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return errors.Wrap(err, "memcached authentication")
+	}
+	if line != "STORED\r\n" {
+		return fmt.Errorf("memcached authentication failed: %s", strings.TrimSpace(line))
+	}
+	return conn.SetDeadline(time.Time{})
+}
+
 // Stop the memcache client.
 func (c *memcachedClient) Stop() {
 	close(c.quit)
@@ -1,9 +1,27 @@
 package cache_test
 
 import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
 	"sync"
+	"testing"
+	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
 )
 
 type mockMemcache struct {
@@ -37,3 +55,174 @@ func (m *mockMemcache) Set(item *memcache.Item) error {
 	m.contents[item.Key] = item.Value
 	return nil
 }
+
+// authMemcached is a memcached server requiring the authentication of the text protocol, serving TLS if enabled.
+type authMemcached struct {
+	listener net.Listener
+	username string
+	password string
+
+	mtx      sync.Mutex
+	contents map[string][]byte
+}
+
+func newAuthMemcached(t *testing.T, tlsConfig *tls.Config, username, password string) *authMemcached {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
+	m := &authMemcached{
+		listener: l,
+		username: username,
+		password: password,
+		contents: map[string][]byte{},
+	}
+	go m.serve()
+	t.Cleanup(func() { _ = l.Close() })
+	return m
+}
+
+func (m *authMemcached) serve() {
+	for {
+		conn, err := m.listener.Accept()
+		if err != nil {
+			return
+		}
+		go m.handle(conn)
+	}
+}
+
+func (m *authMemcached) handle(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	authenticated := false
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 5 || fields[0] != "set" {
+			_, _ = rw.WriteString("ERROR\r\n")
+			_ = rw.Flush()
+			return
+		}
+		size, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(rw, value); err != nil {
+			return
+		}
+		value = value[:size]
+
+		if !authenticated {
+			if string(value) != m.username+" "+m.password {
+				_, _ = rw.WriteString("CLIENT_ERROR authentication failure\r\n")
+				_ = rw.Flush()
+				return
+			}
+			authenticated = true
+		} else {
+			m.mtx.Lock()
+			m.contents[fields[1]] = value
+			m.mtx.Unlock()
+		}
+		_, _ = rw.WriteString("STORED\r\n")
+		_ = rw.Flush()
+	}
+}
+
+func (m *authMemcached) get(key string) []byte {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.contents[key]
+}
+
+// selfSignedCertificate returns a self-signed certificate for 127.0.0.1.
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "memcached"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestMemcachedClient_TLSAndAuthentication(t *testing.T) {
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}}
+
+	for _, tc := range []struct {
+		name        string
+		serverTLS   *tls.Config
+		tlsEnabled  bool
+		password    string
+		expectedErr string
+	}{
+		{
+			name:     "authentication",
+			password: "secret",
+		},
+		{
+			name:       "authentication over TLS",
+			serverTLS:  serverTLS,
+			tlsEnabled: true,
+			password:   "secret",
+		},
+		{
+			name:        "wrong password",
+			password:    "wrong",
+			expectedErr: "memcached authentication failed: CLIENT_ERROR authentication failure",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newAuthMemcached(t, tc.serverTLS, "loki", "secret")
+
+			cfg := cache.MemcachedClientConfig{
+				Addresses:      server.listener.Addr().String(),
+				Timeout:        time.Second,
+				MaxIdleConns:   1,
+				UpdateInterval: time.Minute,
+				TLSEnabled:     tc.tlsEnabled,
+			}
+			cfg.AuthFileUsername = "loki"
+			cfg.TLS.InsecureSkipVerify = true
+			require.NoError(t, cfg.AuthFilePassword.Set(tc.password))
+
+			client, err := cache.NewMemcachedClient(cfg, "test", nil, log.NewNopLogger())
+			require.NoError(t, err)
+			defer client.(interface{ Stop() }).Stop()
+
+			err = client.Set(&memcache.Item{Key: "foo", Value: []byte("bar")})
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []byte("bar"), server.get("foo"))
+		})
+	}
+}
+
+func TestMemcachedClientConfig_Validate(t *testing.T) {
+	cfg := cache.MemcachedClientConfig{}
+	require.NoError(t, cfg.Validate())
+
+	require.NoError(t, cfg.AuthFilePassword.Set("secret"))
+	require.EqualError(t, cfg.Validate(), "the memcached auth file username is required when the password is set")
+
+	cfg.AuthFileUsername = "loki"
+	require.NoError(t, cfg.Validate())
+
+	cfg.ConnectTimeout = -time.Second
+	require.EqualError(t, cfg.Validate(), "the memcached connect timeout must not be negative")
+}
@@ -30,6 +30,25 @@ const (
 	ErrSliceOutOfRange = errs.Error("chunk can't be sliced out of its data range")
 )
 
+// CorruptionReason returns the integrity check of a chunk failed by err, if any: checksum, metadata or data_length.
+func CorruptionReason(err error) (string, bool) {
+	switch errors.Cause(err) {
+	case ErrInvalidChecksum:
+		return "checksum", true
+	case ErrWrongMetadata, ErrMetadataLength:
+		return "metadata", true
+	case ErrDataLength:
+		return "data_length", true
+	}
+	return "", false
+}
+
+// IsCorruptChunkErr returns whether err is a chunk failing one of its integrity checks.
+func IsCorruptChunkErr(err error) bool {
+	_, ok := CorruptionReason(err)
+	return ok
+}
+
 var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
 
 func errInvalidChunkID(s string) error {
@@ -89,10 +108,17 @@ func NewChunk(userID string, fp model.Fingerprint, metric labels.Labels, c prom_
 // Post-checksums, externals keys become the same across DynamoDB, Memcache
 // and S3.  Numbers become hex encoded.  Keys look like:
 // `<user id>/<fingerprint>:<start time>:<end time>:<checksum>`.
+//
+// From schema v12, the keys in the object store are also prefixed by the
+// fingerprint and look like:
+// `<user id>/<fingerprint>/<start time>:<end time>:<checksum>`.
 func ParseExternalKey(userID, externalKey string) (Chunk, error) {
 	if !strings.Contains(externalKey, "/") {
 		return parseLegacyChunkID(userID, externalKey)
 	}
+	if strings.Count(externalKey, "/") == 2 {
+		return parsePrefixedExternalKey(userID, externalKey)
+	}
 	chunk, err := parseNewExternalKey(userID, externalKey)
 	if err != nil {
 		return Chunk{}, err
@@ -176,6 +202,15 @@ func parseNewExternalKey(userID, key string) (Chunk, error) {
 	}, nil
 }
 
+func parsePrefixedExternalKey(userID, key string) (Chunk, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[1] == "" {
+		return Chunk{}, errInvalidChunkID(key)
+	}
+	// This is the same layout as the newer external keys once the fingerprint prefix is turned back into a part.
+	return parseNewExternalKey(userID, parts[0]+"/"+parts[1]+":"+parts[2])
+}
+
 func readOneHexPart(hex []byte) (part []byte, i int) {
 	for i < len(hex) {
 		if hex[i] != ':' {
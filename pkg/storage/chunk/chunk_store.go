@@ -22,6 +22,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	"github.com/grafana/loki/pkg/storage/chunk/encoding"
 )
@@ -42,6 +43,26 @@ var (
 		Name:      "cache_corrupt_chunks_total",
 		Help:      "Total count of corrupt chunks found in cache.",
 	})
+	notFoundCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "chunk_store_not_found_cache_hits_total",
+		Help:      "Total count of chunk fetches failed without querying the storage, because the chunk was recently not found in it.",
+	})
+
+	// CancelledOperations counts the storage operations skipped because the query they were part of got cancelled,
+	// e.g. because the client disconnected.
+	CancelledOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "chunk_store_cancelled_operations_total",
+		Help:      "Total count of chunk decodes, chunk fetches and index queries skipped because their query was cancelled.",
+	}, []string{"operation"})
+)
+
+// Operations counted by CancelledOperations.
+const (
+	CancelledChunkDecode = "chunk_decode"
+	CancelledChunkFetch  = "chunk_fetch"
+	CancelledIndexQuery  = "index_query"
 )
 
 // Query errors are to be treated as user errors, rather than storage errors.
@@ -56,8 +77,19 @@ type StoreConfig struct {
 	ChunkCacheConfig       cache.Config `yaml:"chunk_cache_config"`
 	WriteDedupeCacheConfig cache.Config `yaml:"write_dedupe_cache_config"`
 
+	// When ChunkNotFoundCacheTTL is set, the chunks not found in the store are not fetched again for this long.
+	ChunkNotFoundCacheTTL time.Duration `yaml:"chunk_not_found_cache_ttl"`
+
 	CacheLookupsOlderThan model.Duration `yaml:"cache_lookups_older_than"`
 
+	// When ChunkUploadDeduplication is true, the object store is checked before uploading a chunk
+	// so replicas don't upload identical chunks multiple times.
+	ChunkUploadDeduplication bool `yaml:"chunk_upload_deduplication"`
+
+	// When IndexFallback is true, the chunks of a tenant are listed from the chunk store when the index is unavailable.
+	IndexFallback           bool `yaml:"index_fallback"`
+	IndexFallbackMaxObjects int  `yaml:"index_fallback_max_objects"`
+
 	// Not visible in yaml because the setting shouldn't be common between ingesters and queriers.
 	// This exists in case we don't want to cache all the chunks but still want to take advantage of
 	// ingester chunk write deduplication. But for the queriers we need the full value. So when this option
@@ -72,9 +104,13 @@ type StoreConfig struct {
 func (cfg *StoreConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.ChunkCacheConfig.RegisterFlagsWithPrefix("store.chunks-cache.", "Cache config for chunks. ", f)
 	f.BoolVar(&cfg.chunkCacheStubs, "store.chunks-cache.cache-stubs", false, "If true, don't write the full chunk to cache, just a stub entry.")
+	f.DurationVar(&cfg.ChunkNotFoundCacheTTL, "store.chunks-cache.not-found-ttl", 0, "How long the chunks not found in the store are remembered, failing the queries fetching them without requesting them from the store again. Use a short TTL to avoid hammering the store with requests for missing chunks. 0 to disable.")
 	cfg.WriteDedupeCacheConfig.RegisterFlagsWithPrefix("store.index-cache-write.", "Cache config for index entry writing.", f)
 
 	f.Var(&cfg.CacheLookupsOlderThan, "store.cache-lookups-older-than", "Cache index entries older than this period. 0 to disable.")
+	f.BoolVar(&cfg.ChunkUploadDeduplication, "store.chunk-upload-deduplication", false, "Check whether a chunk already exists in the object store before uploading it, so chunks already flushed by another replica are not uploaded again. This costs an extra request per chunk.")
+	f.BoolVar(&cfg.IndexFallback, "store.index-fallback", false, "When the index of a table is missing or corrupt, list the chunks of the tenant from the object store and filter them after fetching, instead of failing the query. This is slow and costly, and meant to keep queries working while the index is being repaired. When disabled, the boltdb-shipper index files failing to open are skipped by the queries, which miss the chunks indexed in them.")
+	f.IntVar(&cfg.IndexFallbackMaxObjects, "store.index-fallback.max-objects", 100000, "Maximum number of objects of the tenant listed when falling back to listing its chunks. Queries listing more objects fail. 0 to disable the limit.")
 }
 
 // Validate validates the store config.
@@ -96,7 +132,7 @@ type baseStore struct {
 }
 
 func newBaseStore(cfg StoreConfig, schema BaseSchema, index IndexClient, chunks Client, limits StoreLimits, chunksCache cache.Cache) (baseStore, error) {
-	fetcher, err := NewChunkFetcher(chunksCache, cfg.chunkCacheStubs, chunks)
+	fetcher, err := NewChunkFetcher(chunksCache, cfg.chunkCacheStubs, cfg.ChunkNotFoundCacheTTL, chunks)
 	if err != nil {
 		return baseStore{}, err
 	}
@@ -218,8 +254,37 @@ func (c *store) GetChunkRefs(ctx context.Context, userID string, from, through m
 	return nil, nil, errors.New("not implemented")
 }
 
+func (c *store) GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, allMatchers ...*labels.Matcher) ([]SeriesChunkRefs, error) {
+	return nil, errors.New("not implemented")
+}
+
 // LabelValuesForMetricName retrieves all label values for a single label name and metric name.
-func (c *baseStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName, labelName string) ([]string, error) {
+// The label values of the series matching the matchers are read from their chunks.
+func (c *store) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName, labelName string, matchers ...*labels.Matcher) ([]string, error) {
+	if len(matchers) == 0 {
+		return c.labelValuesForMetricName(ctx, userID, from, through, metricName, labelName)
+	}
+
+	metricNameMatcher, err := labels.NewMatcher(labels.MatchEqual, labels.MetricName, metricName)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := c.Get(ctx, userID, from, through, append([]*labels.Matcher{metricNameMatcher}, matchers...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result UniqueStrings
+	for _, chk := range chunks {
+		if value := chk.Metric.Get(labelName); value != "" {
+			result.Add(value)
+		}
+	}
+	return result.Strings(), nil
+}
+
+// labelValuesForMetricName retrieves all label values for a single label name and metric name.
+func (c *baseStore) labelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName, labelName string) ([]string, error) {
 	log, ctx := spanlogger.New(ctx, "ChunkStore.LabelValues")
 	defer log.Span.Finish()
 	level.Debug(log).Log("from", from, "through", through, "metricName", metricName, "labelName", labelName)
@@ -500,7 +565,9 @@ func (c *baseStore) lookupEntriesByQueries(ctx context.Context, queries []IndexQ
 
 	var lock sync.Mutex
 	var entries []IndexEntry
+	statsCtx := stats.FromContext(ctx)
 	err := c.index.QueryPages(ctx, queries, func(query IndexQuery, resp ReadBatch) bool {
+		statsCtx.AddIndexPagesRead(1)
 		iter := resp.Iterator()
 		lock.Lock()
 		for iter.Next() {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -353,6 +354,68 @@ func TestChunkStore_LabelValuesForMetricName(t *testing.T) {
 	}
 }
 
+func TestChunkStore_LabelValuesForMetricNameWithMatchers(t *testing.T) {
+	ctx := context.Background()
+	now := model.Now()
+
+	fooMetric1 := labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "baz"},
+		{Name: "flip", Value: "flop"},
+	}
+	fooMetric2 := labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "beep"},
+	}
+	fooMetric3 := labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "bop"},
+		{Name: "flip", Value: "flap"},
+	}
+
+	for _, tc := range []struct {
+		matchers []*labels.Matcher
+		expect   []string
+	}{
+		{
+			[]*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "flip", "fl.p")},
+			[]string{"baz", "bop"},
+		},
+		{
+			[]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "flip", "flop")},
+			[]string{"baz"},
+		},
+		{
+			// filters also match the series without the label.
+			[]*labels.Matcher{labels.MustNewMatcher(labels.MatchNotEqual, "flip", "flop")},
+			[]string{"beep", "bop"},
+		},
+		{
+			[]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "flip", "flip")},
+			nil,
+		},
+	} {
+		for _, schema := range schemas {
+			for _, storeCase := range stores {
+				t.Run(fmt.Sprintf("%s / %s / %s", tc.matchers, schema, storeCase.name), func(t *testing.T) {
+					store := newTestChunkStoreConfig(t, schema, storeCase.configFn())
+					defer store.Stop()
+
+					require.NoError(t, store.Put(ctx, []Chunk{
+						dummyChunkFor(now, fooMetric1),
+						dummyChunkFor(now, fooMetric2),
+						dummyChunkFor(now, fooMetric3),
+					}))
+
+					values, err := store.LabelValuesForMetricName(ctx, userID, now.Add(-time.Hour), now, "foo", "bar", tc.matchers...)
+					require.NoError(t, err)
+					require.Equal(t, tc.expect, values)
+				})
+			}
+		}
+	}
+}
+
 func TestChunkStore_LabelNamesForMetricName(t *testing.T) {
 	ctx := context.Background()
 	now := model.Now()
@@ -454,6 +517,56 @@ func TestChunkStore_LabelNamesForMetricName(t *testing.T) {
 	}
 }
 
+func TestSeriesStore_GetSeriesChunkRefs(t *testing.T) {
+	ctx := context.Background()
+	now := model.Now()
+
+	fooMetric1 := labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "baz"},
+		{Name: "flip", Value: "flop"},
+	}
+	fooMetric2 := labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "beep"},
+	}
+	fooMetric3 := labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "flip", Value: "flap"},
+	}
+
+	fooChunk1 := dummyChunkFor(now, fooMetric1)
+	fooChunk2 := dummyChunkFor(now.Add(-time.Hour), fooMetric1)
+	fooChunk3 := dummyChunkFor(now, fooMetric2)
+	fooChunk4 := dummyChunkFor(now, fooMetric3)
+	fooChunk5 := dummyChunkFor(now.Add(-48*time.Hour), fooMetric3) // outside of the queried time range
+
+	for _, schema := range seriesStoreSchemas {
+		for _, storeCase := range stores {
+			t.Run(fmt.Sprintf("%s / %s", schema, storeCase.name), func(t *testing.T) {
+				store := newTestChunkStoreConfig(t, schema, storeCase.configFn())
+				defer store.Stop()
+
+				require.NoError(t, store.Put(ctx, []Chunk{fooChunk1, fooChunk2, fooChunk3, fooChunk4, fooChunk5}))
+
+				series, err := store.GetSeriesChunkRefs(ctx, userID, now.Add(-2*time.Hour), now,
+					labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "foo"),
+					labels.MustNewMatcher(labels.MatchRegexp, "flip", "fl.p"),
+				)
+				require.NoError(t, err)
+				sort.Slice(series, func(i, j int) bool { return labels.Compare(series[i].Labels, series[j].Labels) < 0 })
+
+				require.Len(t, series, 2)
+				require.Equal(t, labels.Labels{{Name: "bar", Value: "baz"}, {Name: "flip", Value: "flop"}}, series[0].Labels)
+				require.Len(t, series[0].Chunks, 2)
+				require.Equal(t, labels.Labels{{Name: "flip", Value: "flap"}}, series[1].Labels)
+				require.Len(t, series[1].Chunks, 1)
+				require.Equal(t, fooChunk4.ExternalKey(), series[1].Chunks[0].ExternalKey())
+			})
+		}
+	}
+}
+
 // TestChunkStore_getMetricNameChunks tests if chunks are fetched correctly when we have the metric name
 func TestChunkStore_getMetricNameChunks(t *testing.T) {
 	ctx := context.Background()
@@ -1147,3 +1260,138 @@ func TestDisableIndexDeduplication(t *testing.T) {
 		})
 	}
 }
+
+func TestChunkUploadDeduplication(t *testing.T) {
+	for _, chunkUploadDeduplication := range []bool{
+		false, true,
+	} {
+		t.Run(fmt.Sprintf("%v", chunkUploadDeduplication), func(t *testing.T) {
+			ctx := context.Background()
+			metric := labels.Labels{
+				{Name: labels.MetricName, Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			}
+			storeMaker := stores[0]
+			storeCfg := storeMaker.configFn()
+			storeCfg.ChunkUploadDeduplication = chunkUploadDeduplication
+
+			store := newTestChunkStoreConfig(t, "v9", storeCfg)
+			defer store.Stop()
+
+			storage := store.(CompositeStore).stores[0].Store.(*seriesStore).fetcher.storage.(*MockStorage)
+
+			fooChunk1 := dummyChunkFor(model.Time(0).Add(15*time.Second), metric)
+			require.NoError(t, fooChunk1.Encode())
+
+			// Put the same chunk twice without any chunk cache, as two replicas would.
+			require.NoError(t, store.Put(ctx, []Chunk{fooChunk1}))
+			require.Equal(t, 1, storage.numChunkWrites)
+			require.NoError(t, store.Put(ctx, []Chunk{fooChunk1}))
+
+			expectedChunkWrites := 2
+			if chunkUploadDeduplication {
+				expectedChunkWrites = 1
+			}
+			require.Equal(t, expectedChunkWrites, storage.numChunkWrites)
+		})
+	}
+}
+
+type unavailableIndexClient struct {
+	IndexClient
+}
+
+func (unavailableIndexClient) QueryPages(context.Context, []IndexQuery, func(IndexQuery, ReadBatch) bool) error {
+	return fmt.Errorf("table index_1: %w", ErrIndexUnavailable)
+}
+
+type listingClient struct {
+	Client
+	chunks []Chunk
+}
+
+func (c listingClient) ListChunks(_ context.Context, _ string, from, through model.Time, _ int) ([]Chunk, error) {
+	var chunks []Chunk
+	for _, chk := range c.chunks {
+		if chk.Through >= from && chk.From <= through {
+			chunks = append(chunks, chk)
+		}
+	}
+	return chunks, nil
+}
+
+func TestSeriesStore_IndexFallback(t *testing.T) {
+	ctx := context.Background()
+	fooChunk := dummyChunkFor(model.Time(0).Add(15*time.Second), labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "baz"},
+	})
+	barChunk := dummyChunkFor(model.Time(0).Add(15*time.Second), labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "qux"},
+	})
+	oldChunk := dummyChunkFor(model.Time(0).Add(-time.Hour), labels.Labels{
+		{Name: labels.MetricName, Value: "foo"},
+		{Name: "bar", Value: "baz"},
+	})
+
+	for _, tc := range []struct {
+		name     string
+		fallback bool
+		matchers string
+		expected []Chunk
+		err      bool
+	}{
+		{
+			name:     "fallback disabled",
+			matchers: `foo{bar="baz"}`,
+			err:      true,
+		},
+		{
+			name:     "all the chunks in the time range",
+			fallback: true,
+			matchers: `foo{bar="baz"}`,
+			expected: []Chunk{fooChunk, barChunk},
+		},
+		{
+			name:     "first shard",
+			fallback: true,
+			matchers: `foo{bar="baz",__cortex_shard__="0_of_2"}`,
+			expected: []Chunk{fooChunk, barChunk},
+		},
+		{
+			name:     "other shards",
+			fallback: true,
+			matchers: `foo{bar="baz",__cortex_shard__="1_of_2"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var storeCfg StoreConfig
+			flagext.DefaultValues(&storeCfg)
+			storeCfg.IndexFallback = tc.fallback
+
+			store := newTestChunkStoreConfig(t, "v11", storeCfg)
+			defer store.Stop()
+
+			s := store.(CompositeStore).stores[0].Store.(*seriesStore)
+			s.index = unavailableIndexClient{s.index}
+			s.chunks = listingClient{Client: s.chunks, chunks: []Chunk{fooChunk, barChunk, oldChunk}}
+
+			matchers, err := parser.ParseMetricSelector(tc.matchers)
+			require.NoError(t, err)
+
+			chunks, fetchers, err := store.GetChunkRefs(ctx, userID, model.Time(0), model.Time(0).Add(time.Hour), matchers...)
+			if tc.err {
+				require.ErrorIs(t, err, ErrIndexUnavailable)
+				return
+			}
+			require.NoError(t, err)
+			if len(tc.expected) == 0 {
+				require.Empty(t, chunks)
+				return
+			}
+			require.Len(t, fetchers, 1)
+			require.Equal(t, [][]Chunk{tc.expected}, chunks)
+		})
+	}
+}
@@ -2,9 +2,13 @@ package chunk
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
@@ -12,10 +16,18 @@ import (
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/spanlogger"
 
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 )
 
-const chunkDecodeParallelism = 16
+const (
+	chunkDecodeParallelism = 16
+	notFoundCacheSize      = 100000
+)
+
+// ErrChunkRecentlyNotFound is returned when fetching a chunk not found in the storage within the TTL of the not
+// found cache.
+var ErrChunkRecentlyNotFound = errors.New("chunk recently not found in the storage")
 
 func filterChunksByTime(from, through model.Time, chunks []Chunk) []Chunk {
 	filtered := make([]Chunk, 0, len(chunks))
@@ -85,11 +97,16 @@ type Fetcher struct {
 	cache      cache.Cache
 	cacheStubs bool
 
+	// notFound caches the expiry of the keys of the chunks not found in the storage, nil if disabled.
+	notFound    *lru.Cache
+	notFoundTTL time.Duration
+
 	wait           sync.WaitGroup
 	decodeRequests chan decodeRequest
 }
 
 type decodeRequest struct {
+	ctx       context.Context
 	chunk     Chunk
 	buf       []byte
 	responses chan decodeResponse
@@ -100,14 +117,22 @@ type decodeResponse struct {
 	err   error
 }
 
-// NewChunkFetcher makes a new ChunkFetcher.
-func NewChunkFetcher(cacher cache.Cache, cacheStubs bool, storage Client) (*Fetcher, error) {
+// NewChunkFetcher makes a new ChunkFetcher. The chunks not found in the storage are not fetched again for
+// notFoundTTL, 0 to disable it.
+func NewChunkFetcher(cacher cache.Cache, cacheStubs bool, notFoundTTL time.Duration, storage Client) (*Fetcher, error) {
 	c := &Fetcher{
 		storage:        storage,
 		cache:          cacher,
 		cacheStubs:     cacheStubs,
+		notFoundTTL:    notFoundTTL,
 		decodeRequests: make(chan decodeRequest),
 	}
+	if notFoundTTL > 0 {
+		var err error
+		if c.notFound, err = lru.New(notFoundCacheSize); err != nil {
+			return nil, err
+		}
+	}
 
 	c.wait.Add(chunkDecodeParallelism)
 	for i := 0; i < chunkDecodeParallelism; i++ {
@@ -128,12 +153,21 @@ func (c *Fetcher) worker() {
 	defer c.wait.Done()
 	decodeContext := NewDecodeContext()
 	for req := range c.decodeRequests {
-		err := req.chunk.Decode(decodeContext, req.buf)
+		// Don't decode chunks nobody is waiting for anymore.
+		if err := req.ctx.Err(); err != nil {
+			CancelledOperations.WithLabelValues(CancelledChunkDecode).Inc()
+			req.responses <- decodeResponse{chunk: req.chunk, err: err}
+			continue
+		}
+		chunk := req.chunk
+		err := chunk.Decode(decodeContext, req.buf)
 		if err != nil {
 			cacheCorrupt.Inc()
+			// Respond with the chunk as it was before decoding, to fetch it from the store.
+			chunk = req.chunk
 		}
 		req.responses <- decodeResponse{
-			chunk: req.chunk,
+			chunk: chunk,
 			err:   err,
 		}
 	}
@@ -141,21 +175,65 @@ func (c *Fetcher) worker() {
 
 // FetchChunks fetches a set of chunks from cache and store. Note that the keys passed in must be
 // lexicographically sorted, while the returned chunks are not in the same order as the passed in chunks.
+// Chunks missing from the cache are fetched from the store while the ones found in the cache get decoded,
+// so that slow store fetches don't add up to the decoding time.
 func (c *Fetcher) FetchChunks(ctx context.Context, chunks []Chunk, keys []string) ([]Chunk, error) {
 	log, ctx := spanlogger.New(ctx, "ChunkStore.FetchChunks")
 	defer log.Span.Finish()
 
+	if err := ctx.Err(); err != nil {
+		CancelledOperations.WithLabelValues(CancelledChunkFetch).Add(float64(len(chunks)))
+		return nil, err
+	}
+
 	// Now fetch the actual chunk data from Memcache / S3
 	cacheHits, cacheBufs, _ := c.cache.Fetch(ctx, keys)
+	stats.FromContext(ctx).AddChunkCacheEntries(int64(len(keys)), int64(len(cacheHits)))
 
-	fromCache, missing, err := c.processCacheResponse(ctx, chunks, cacheHits, cacheBufs)
-	if err != nil {
-		level.Warn(log).Log("msg", "error fetching from cache", "err", err)
+	requests, missing := c.processCacheResponse(ctx, chunks, cacheHits, cacheBufs)
+	level.Debug(log).Log("chunks", len(chunks), "decodeRequests", len(requests), "missing", len(missing))
+
+	// The query might have been cancelled while fetching from the cache.
+	if err := ctx.Err(); err != nil {
+		CancelledOperations.WithLabelValues(CancelledChunkFetch).Add(float64(len(missing)))
+		missing = nil
 	}
 
-	var fromStorage []Chunk
+	// Don't hammer the storage with the chunks it recently didn't find, they would fail the query anyway.
+	if key, ok := c.recentlyNotFound(missing); ok {
+		return nil, promql.ErrStorage{Err: fmt.Errorf("%w: %s", ErrChunkRecentlyNotFound, key)}
+	}
+
+	var storeResult chan storeFetchResponse
 	if len(missing) > 0 {
-		fromStorage, err = c.storage.GetChunks(ctx, missing)
+		storeResult = make(chan storeFetchResponse, 1)
+		go func() {
+			fromStorage, err := c.storage.GetChunks(ctx, missing)
+			storeResult <- storeFetchResponse{chunks: fromStorage, err: err}
+		}()
+	}
+
+	fromCache, corrupt := c.decodeCacheResponse(requests)
+	if len(corrupt) > 0 {
+		level.Warn(log).Log("msg", "error decoding chunks from cache, fetching them from the store", "chunks", len(corrupt))
+	}
+
+	var (
+		fromStorage []Chunk
+		err         error
+	)
+	if storeResult != nil {
+		res := <-storeResult
+		fromStorage, err = res.chunks, res.err
+		if err != nil && c.notFound != nil && c.storage.IsChunkNotFoundErr(err) {
+			fromStorage, err = c.fetchNotReturned(ctx, missing, fromStorage)
+		}
+	}
+	// The chunks which failed to be decoded from the cache are cache misses.
+	if len(corrupt) > 0 && err == nil && ctx.Err() == nil {
+		var refetched []Chunk
+		refetched, err = c.storage.GetChunks(ctx, corrupt)
+		fromStorage = append(fromStorage, refetched...)
 	}
 
 	// Always cache any chunks we did get
@@ -163,6 +241,11 @@ func (c *Fetcher) FetchChunks(ctx context.Context, chunks []Chunk, keys []string
 		level.Warn(log).Log("msg", "could not store chunks in chunk cache", "err", cacheErr)
 	}
 
+	// A cancelled query is not a storage failure.
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
 	if err != nil {
 		// Don't rely on Cortex error translation here.
 		return nil, promql.ErrStorage{Err: err}
@@ -172,6 +255,66 @@ func (c *Fetcher) FetchChunks(ctx context.Context, chunks []Chunk, keys []string
 	return allChunks, nil
 }
 
+type storeFetchResponse struct {
+	chunks []Chunk
+	err    error
+}
+
+// recentlyNotFound returns the key of the first chunk not found in the storage within the TTL of the not found cache.
+func (c *Fetcher) recentlyNotFound(chunks []Chunk) (string, bool) {
+	if c.notFound == nil {
+		return "", false
+	}
+	now := time.Now()
+	for _, chk := range chunks {
+		key := chk.ExternalKey()
+		expiry, ok := c.notFound.Get(key)
+		if !ok {
+			continue
+		}
+		if now.Before(expiry.(time.Time)) {
+			notFoundCacheHits.Inc()
+			return key, true
+		}
+		c.notFound.Remove(key)
+	}
+	return "", false
+}
+
+// fetchNotReturned fetches again, in a single call, the chunks not returned by a fetch which failed because some
+// chunks were not found. The chunks still not returned when the retry fails because some chunks were not found are
+// cached as not found, the chunks which failed transiently being returned by the retry.
+func (c *Fetcher) fetchNotReturned(ctx context.Context, requested, returned []Chunk) ([]Chunk, error) {
+	returnedKeys := make(map[string]struct{}, len(returned))
+	for _, chk := range returned {
+		returnedKeys[chk.ExternalKey()] = struct{}{}
+	}
+	notReturned := make([]Chunk, 0, len(requested)-len(returned))
+	for _, chk := range requested {
+		if _, ok := returnedKeys[chk.ExternalKey()]; !ok {
+			notReturned = append(notReturned, chk)
+		}
+	}
+
+	fetched, err := c.storage.GetChunks(ctx, notReturned)
+	returned = append(returned, fetched...)
+	if err == nil || !c.storage.IsChunkNotFoundErr(err) {
+		return returned, err
+	}
+
+	fetchedKeys := make(map[string]struct{}, len(fetched))
+	for _, chk := range fetched {
+		fetchedKeys[chk.ExternalKey()] = struct{}{}
+	}
+	expiry := time.Now().Add(c.notFoundTTL)
+	for _, chk := range notReturned {
+		if _, ok := fetchedKeys[chk.ExternalKey()]; !ok {
+			c.notFound.Add(chk.ExternalKey(), expiry)
+		}
+	}
+	return returned, err
+}
+
 func (c *Fetcher) writeBackCache(ctx context.Context, chunks []Chunk) error {
 	keys := make([]string, 0, len(chunks))
 	bufs := make([][]byte, 0, len(chunks))
@@ -194,9 +337,9 @@ func (c *Fetcher) writeBackCache(ctx context.Context, chunks []Chunk) error {
 	return nil
 }
 
-// ProcessCacheResponse decodes the chunks coming back from the cache, separating
-// hits and misses.
-func (c *Fetcher) processCacheResponse(ctx context.Context, chunks []Chunk, keys []string, bufs [][]byte) ([]Chunk, []Chunk, error) {
+// processCacheResponse matches the chunks coming back from the cache with the requested ones,
+// separating the hits to decode and the misses.
+func (c *Fetcher) processCacheResponse(ctx context.Context, chunks []Chunk, keys []string, bufs [][]byte) ([]decodeRequest, []Chunk) {
 	var (
 		requests  = make([]decodeRequest, 0, len(keys))
 		responses = make(chan decodeResponse)
@@ -216,6 +359,7 @@ func (c *Fetcher) processCacheResponse(ctx context.Context, chunks []Chunk, keys
 			j++
 		} else {
 			requests = append(requests, decodeRequest{
+				ctx:       ctx,
 				chunk:     chunks[i],
 				buf:       bufs[j],
 				responses: responses,
@@ -227,31 +371,35 @@ func (c *Fetcher) processCacheResponse(ctx context.Context, chunks []Chunk, keys
 	for ; i < len(chunks); i++ {
 		missing = append(missing, chunks[i])
 	}
-	level.Debug(logger).Log("chunks", len(chunks), "decodeRequests", len(requests), "missing", len(missing))
+	return requests, missing
+}
 
+// decodeCacheResponse decodes the chunks coming back from the cache, in parallel, returning the decoded ones and the
+// ones which failed to be decoded.
+func (c *Fetcher) decodeCacheResponse(requests []decodeRequest) ([]Chunk, []Chunk) {
 	go func() {
 		for _, request := range requests {
 			c.decodeRequests <- request
 		}
 	}()
 
-	var (
-		err   error
-		found []Chunk
-	)
+	var found, corrupt []Chunk
 	for i := 0; i < len(requests); i++ {
-		response := <-responses
+		response := <-requests[i].responses
 
 		// Don't exit early, as we don't want to block the workers.
 		if response.err != nil {
-			err = response.err
+			corrupt = append(corrupt, response.chunk)
 		} else {
 			found = append(found, response.chunk)
 		}
 	}
-	return found, missing, err
+	return found, corrupt
 }
 
 func (c *Fetcher) IsChunkNotFoundErr(err error) bool {
-	return c.storage.IsChunkNotFoundErr(err)
+	if storageErr, ok := err.(promql.ErrStorage); ok {
+		err = storageErr.Err
+	}
+	return errors.Is(err, ErrChunkRecentlyNotFound) || c.storage.IsChunkNotFoundErr(err)
 }
@@ -0,0 +1,293 @@
+package chunk
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/cache"
+)
+
+// recordingClient records the chunks requested to the store and the number of calls. Like the object clients, it
+// returns the chunks found along with the last error.
+type recordingClient struct {
+	Client
+	requested []string
+	calls     int
+}
+
+func (c *recordingClient) GetChunks(ctx context.Context, chunks []Chunk) ([]Chunk, error) {
+	c.calls++
+	var (
+		fetched []Chunk
+		lastErr error
+	)
+	for _, chk := range chunks {
+		c.requested = append(c.requested, chk.ExternalKey())
+		f, err := c.Client.GetChunks(ctx, []Chunk{chk})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		fetched = append(fetched, f...)
+	}
+	return fetched, lastErr
+}
+
+func TestFetcher_FetchChunks(t *testing.T) {
+	now := model.Now()
+	var (
+		chunks []Chunk
+		keys   []string
+	)
+	for _, app := range []string{"a", "b", "c", "d"} {
+		chk := dummyChunkFor(now, labels.Labels{{Name: labels.MetricName, Value: "logs"}, {Name: "app", Value: app}})
+		chunks = append(chunks, chk)
+		keys = append(keys, chk.ExternalKey())
+	}
+	sort.Strings(keys)
+
+	storage := NewMockStorage()
+	require.NoError(t, storage.PutChunks(context.Background(), chunks))
+	client := &recordingClient{Client: storage}
+
+	// Only some of the chunks are in the cache.
+	c := cache.NewMockCache()
+	for _, chk := range chunks[:2] {
+		buf, err := chk.Encoded()
+		require.NoError(t, err)
+		c.Store(context.Background(), []string{chk.ExternalKey()}, [][]byte{buf})
+	}
+
+	fetcher, err := NewChunkFetcher(c, false, 0, client)
+	require.NoError(t, err)
+	defer fetcher.Stop()
+
+	toFetch := make([]Chunk, 0, len(keys))
+	for _, key := range keys {
+		chk, err := ParseExternalKey(userID, key)
+		require.NoError(t, err)
+		toFetch = append(toFetch, chk)
+	}
+
+	fetched, err := fetcher.FetchChunks(context.Background(), toFetch, keys)
+	require.NoError(t, err)
+	require.Equal(t, keys, sortedKeys(fetched))
+	for _, chk := range fetched {
+		require.NotNil(t, chk.Data)
+	}
+
+	// Only the chunks missing from the cache are fetched from the store, and get written back to the cache.
+	require.ElementsMatch(t, []string{chunks[2].ExternalKey(), chunks[3].ExternalKey()}, client.requested)
+	found, _, missing := c.Fetch(context.Background(), keys)
+	require.Equal(t, keys, found)
+	require.Empty(t, missing)
+
+	// Chunks missing from both the cache and the store fail the fetch.
+	unknown := dummyChunkFor(now, labels.Labels{{Name: labels.MetricName, Value: "logs"}, {Name: "app", Value: "unknown"}})
+	unknownChunk, err := ParseExternalKey(userID, unknown.ExternalKey())
+	require.NoError(t, err)
+	_, err = fetcher.FetchChunks(context.Background(), []Chunk{unknownChunk}, []string{unknown.ExternalKey()})
+	require.Error(t, err)
+	require.IsType(t, promql.ErrStorage{}, err)
+}
+
+func TestFetcher_FetchChunksCorruptCache(t *testing.T) {
+	now := model.Now()
+	var (
+		chunks []Chunk
+		keys   []string
+	)
+	for _, app := range []string{"a", "b", "c"} {
+		chk := dummyChunkFor(now, labels.Labels{{Name: labels.MetricName, Value: "logs"}, {Name: "app", Value: app}})
+		chunks = append(chunks, chk)
+		keys = append(keys, chk.ExternalKey())
+	}
+	sort.Strings(keys)
+
+	storage := NewMockStorage()
+	require.NoError(t, storage.PutChunks(context.Background(), chunks))
+	client := &recordingClient{Client: storage}
+
+	// All the chunks are in the cache, one of them is corrupt.
+	c := cache.NewMockCache()
+	for i, chk := range chunks {
+		buf, err := chk.Encoded()
+		require.NoError(t, err)
+		if i == 0 {
+			buf = []byte("corrupt")
+		}
+		c.Store(context.Background(), []string{chk.ExternalKey()}, [][]byte{buf})
+	}
+
+	fetcher, err := NewChunkFetcher(c, false, 0, client)
+	require.NoError(t, err)
+	defer fetcher.Stop()
+
+	toFetch := make([]Chunk, 0, len(keys))
+	for _, key := range keys {
+		chk, err := ParseExternalKey(userID, key)
+		require.NoError(t, err)
+		toFetch = append(toFetch, chk)
+	}
+
+	// The corrupt chunk is fetched from the store, and written back to the cache.
+	fetched, err := fetcher.FetchChunks(context.Background(), toFetch, keys)
+	require.NoError(t, err)
+	require.Equal(t, keys, sortedKeys(fetched))
+	for _, chk := range fetched {
+		require.NotNil(t, chk.Data)
+	}
+	require.Equal(t, []string{chunks[0].ExternalKey()}, client.requested)
+
+	client.requested = nil
+	_, err = fetcher.FetchChunks(context.Background(), toFetch, keys)
+	require.NoError(t, err)
+	require.Empty(t, client.requested)
+}
+
+func TestFetcher_FetchChunksNotFoundCache(t *testing.T) {
+	now := model.Now()
+	var chunks []Chunk
+	for _, app := range []string{"a", "b", "unknown"} {
+		chunks = append(chunks, dummyChunkFor(now, labels.Labels{{Name: labels.MetricName, Value: "logs"}, {Name: "app", Value: app}}))
+	}
+
+	// The last chunk is missing from the store.
+	storage := NewMockStorage()
+	require.NoError(t, storage.PutChunks(context.Background(), chunks[:2]))
+	client := &recordingClient{Client: storage}
+
+	fetcher, err := NewChunkFetcher(cache.NewMockCache(), false, 100*time.Millisecond, client)
+	require.NoError(t, err)
+	defer fetcher.Stop()
+
+	fetch := func(chunks ...Chunk) ([]Chunk, error) {
+		keys := keysFromChunks(chunks)
+		sort.Strings(keys)
+		toFetch := make([]Chunk, 0, len(keys))
+		for _, key := range keys {
+			chk, err := ParseExternalKey(userID, key)
+			require.NoError(t, err)
+			toFetch = append(toFetch, chk)
+		}
+		return fetcher.FetchChunks(context.Background(), toFetch, keys)
+	}
+	unknownKey := chunks[2].ExternalKey()
+
+	// Only the chunk not returned is fetched again, in a single call.
+	_, err = fetch(chunks...)
+	require.Error(t, err)
+	require.True(t, fetcher.IsChunkNotFoundErr(err))
+	require.Equal(t, 2, client.calls)
+	require.Len(t, client.requested, 4)
+	require.Equal(t, unknownKey, client.requested[3])
+
+	// The missing chunk is not requested from the store again, while the others can still be fetched.
+	client.requested = nil
+	_, err = fetch(chunks[2])
+	require.True(t, errors.Is(err.(promql.ErrStorage).Err, ErrChunkRecentlyNotFound))
+	require.True(t, fetcher.IsChunkNotFoundErr(err))
+	require.Empty(t, client.requested)
+
+	fetched, err := fetch(chunks[:2]...)
+	require.NoError(t, err)
+	require.Len(t, fetched, 2)
+
+	// Once the TTL expired, the missing chunk is requested from the store again.
+	time.Sleep(150 * time.Millisecond)
+	_, err = fetch(chunks[2])
+	require.Error(t, err)
+	require.Equal(t, []string{unknownKey, unknownKey}, client.requested)
+}
+
+// cancellingCache cancels the query while fetching from the cache, as a client disconnecting would.
+type cancellingCache struct {
+	cache.Cache
+	cancel context.CancelFunc
+}
+
+func (c *cancellingCache) Fetch(ctx context.Context, keys []string) ([]string, [][]byte, []string) {
+	c.cancel()
+	return c.Cache.Fetch(ctx, keys)
+}
+
+func TestFetcher_FetchChunksCancelled(t *testing.T) {
+	now := model.Now()
+	var (
+		chunks []Chunk
+		keys   []string
+	)
+	for _, app := range []string{"a", "b", "c", "d"} {
+		chk := dummyChunkFor(now, labels.Labels{{Name: labels.MetricName, Value: "logs"}, {Name: "app", Value: app}})
+		chunks = append(chunks, chk)
+		keys = append(keys, chk.ExternalKey())
+	}
+	sort.Strings(keys)
+
+	storage := NewMockStorage()
+	require.NoError(t, storage.PutChunks(context.Background(), chunks))
+	client := &recordingClient{Client: storage}
+
+	mockCache := cache.NewMockCache()
+	for _, chk := range chunks[:2] {
+		buf, err := chk.Encoded()
+		require.NoError(t, err)
+		mockCache.Store(context.Background(), []string{chk.ExternalKey()}, [][]byte{buf})
+	}
+
+	toFetch := make([]Chunk, 0, len(keys))
+	for _, key := range keys {
+		chk, err := ParseExternalKey(userID, key)
+		require.NoError(t, err)
+		toFetch = append(toFetch, chk)
+	}
+
+	decodes := CancelledOperations.WithLabelValues(CancelledChunkDecode)
+	fetches := CancelledOperations.WithLabelValues(CancelledChunkFetch)
+
+	t.Run("cancelled before the fetch", func(t *testing.T) {
+		fetcher, err := NewChunkFetcher(mockCache, false, 0, client)
+		require.NoError(t, err)
+		defer fetcher.Stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		before := testutil.ToFloat64(fetches)
+		_, err = fetcher.FetchChunks(ctx, toFetch, keys)
+		require.Equal(t, context.Canceled, err)
+		require.Empty(t, client.requested)
+		require.Equal(t, float64(len(toFetch)), testutil.ToFloat64(fetches)-before)
+	})
+
+	t.Run("cancelled while fetching from the cache", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		fetcher, err := NewChunkFetcher(&cancellingCache{Cache: mockCache, cancel: cancel}, false, 0, client)
+		require.NoError(t, err)
+		defer fetcher.Stop()
+
+		decodesBefore, fetchesBefore := testutil.ToFloat64(decodes), testutil.ToFloat64(fetches)
+		_, err = fetcher.FetchChunks(ctx, toFetch, keys)
+		require.Equal(t, context.Canceled, err)
+
+		// Neither the cache hits get decoded nor the misses fetched from the store.
+		require.Empty(t, client.requested)
+		require.Equal(t, float64(2), testutil.ToFloat64(decodes)-decodesBefore)
+		require.Equal(t, float64(2), testutil.ToFloat64(fetches)-fetchesBefore)
+	})
+}
+
+func sortedKeys(chunks []Chunk) []string {
+	keys := keysFromChunks(chunks)
+	sort.Strings(keys)
+	return keys
+}
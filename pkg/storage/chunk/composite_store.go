@@ -30,7 +30,11 @@ type Store interface {
 	// GetChunkRefs returns the un-loaded chunks and the fetchers to be used to load them. You can load each slice of chunks ([]Chunk),
 	// using the corresponding Fetcher (fetchers[i].FetchChunks(ctx, chunks[i], ...)
 	GetChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([][]Chunk, []*Fetcher, error)
-	LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error)
+	// GetSeriesChunkRefs returns the labels and the un-loaded chunks of every series matching the matchers,
+	// reading only the index.
+	GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]SeriesChunkRefs, error)
+	// LabelValuesForMetricName returns the values of a label name, only for the series matching the matchers if any.
+	LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error)
 	LabelNamesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string) ([]string, error)
 	GetChunkFetcher(tm model.Time) *Fetcher
 
@@ -42,6 +46,12 @@ type Store interface {
 	Stop()
 }
 
+// SeriesChunkRefs holds the labels of a series and the un-loaded chunks of that series.
+type SeriesChunkRefs struct {
+	Labels labels.Labels
+	Chunks []Chunk
+}
+
 // CompositeStore is a Store which delegates to various stores depending
 // on when they were activated.
 type CompositeStore struct {
@@ -127,10 +137,10 @@ func (c compositeStore) Get(ctx context.Context, userID string, from, through mo
 }
 
 // LabelValuesForMetricName retrieves all label values for a single label name and metric name.
-func (c compositeStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error) {
+func (c compositeStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
 	var result UniqueStrings
 	err := c.forStores(ctx, userID, from, through, func(innerCtx context.Context, from, through model.Time, store Store) error {
-		labelValues, err := store.LabelValuesForMetricName(innerCtx, userID, from, through, metricName, labelName)
+		labelValues, err := store.LabelValuesForMetricName(innerCtx, userID, from, through, metricName, labelName, matchers...)
 		if err != nil {
 			return err
 		}
@@ -175,6 +185,19 @@ func (c compositeStore) GetChunkRefs(ctx context.Context, userID string, from, t
 	return chunkIDs, fetchers, err
 }
 
+func (c compositeStore) GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]SeriesChunkRefs, error) {
+	var results []SeriesChunkRefs
+	err := c.forStores(ctx, userID, from, through, func(innerCtx context.Context, from, through model.Time, store Store) error {
+		series, err := store.GetSeriesChunkRefs(innerCtx, userID, from, through, matchers...)
+		if err != nil {
+			return err
+		}
+		results = append(results, series...)
+		return nil
+	})
+	return results, err
+}
+
 func (c compositeStore) GetChunkFetcher(tm model.Time) *Fetcher {
 	// find the schema with the lowest start _after_ tm
 	j := sort.Search(len(c.stores), func(j int) bool {
@@ -26,7 +26,7 @@ func (m mockStore) PutOne(ctx context.Context, from, through model.Time, chunk C
 func (m mockStore) Get(tx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]Chunk, error) {
 	return nil, nil
 }
-func (m mockStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error) {
+func (m mockStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
 	return nil, nil
 }
 
@@ -34,6 +34,10 @@ func (m mockStore) GetChunkRefs(tx context.Context, userID string, from, through
 	return nil, nil, nil
 }
 
+func (m mockStore) GetSeriesChunkRefs(tx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]SeriesChunkRefs, error) {
+	return nil, nil
+}
+
 func (m mockStore) LabelNamesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string) ([]string, error) {
 	return nil, nil
 }
@@ -184,7 +188,7 @@ type mockStoreLabel struct {
 	values []string
 }
 
-func (m mockStoreLabel) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error) {
+func (m mockStoreLabel) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
 	return m.values, nil
 }
 
@@ -4,12 +4,15 @@ import (
 	"context"
 	"flag"
 	"io"
+	"net/http"
+	"strconv"
 	"time"
 
 	"cloud.google.com/go/storage"
 	cortex_gcp "github.com/cortexproject/cortex/pkg/chunk/gcp"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
@@ -18,6 +21,9 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk/util"
 )
 
+// listPageSize is the number of objects of each page listed by ListPages.
+const listPageSize = 1000
+
 type ClientFactory func(ctx context.Context, opts ...option.ClientOption) (*storage.Client, error)
 
 type GCSObjectClient struct {
@@ -136,6 +142,37 @@ func (s *GCSObjectClient) getObject(ctx context.Context, objectKey string) (rc i
 	return reader, nil
 }
 
+// ObjectExists checks whether the object exists by fetching its attributes.
+func (s *GCSObjectClient) ObjectExists(ctx context.Context, objectKey string) (bool, error) {
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+		defer cancel()
+	}
+	_, err := s.hedgingBucket.Object(objectKey).Attrs(ctx)
+	if err == nil {
+		return true, nil
+	}
+	if s.IsObjectNotFoundErr(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ObjectSize returns the size of the object from its attributes.
+func (s *GCSObjectClient) ObjectSize(ctx context.Context, objectKey string) (int64, error) {
+	if s.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+		defer cancel()
+	}
+	attrs, err := s.hedgingBucket.Object(objectKey).Attrs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return attrs.Size, nil
+}
+
 // PutObject puts the specified bytes into the configured GCS bucket at the provided key
 func (s *GCSObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
 	writer := s.bucket.Object(objectKey).NewWriter(ctx)
@@ -151,6 +188,50 @@ func (s *GCSObjectClient) PutObject(ctx context.Context, objectKey string, objec
 	return writer.Close()
 }
 
+// GetObjectVersion returns a reader for the specified object key along with its generation.
+func (s *GCSObjectClient) GetObjectVersion(ctx context.Context, objectKey string) (io.ReadCloser, string, error) {
+	var cancel context.CancelFunc = func() {}
+	if s.cfg.RequestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+	}
+
+	reader, err := s.bucket.Object(objectKey).NewReader(ctx)
+	if err != nil {
+		cancel()
+		return nil, "", err
+	}
+	return util.NewReadCloserWithContextCancelFunc(reader, cancel), strconv.FormatInt(reader.Attrs.Generation, 10), nil
+}
+
+// PutObjectIfVersion puts the object only if its generation matches the given version,
+// or only if it does not exist when the version is empty.
+func (s *GCSObjectClient) PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error) {
+	conditions := storage.Conditions{DoesNotExist: true}
+	if version != "" {
+		generation, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid generation %s", version)
+		}
+		conditions = storage.Conditions{GenerationMatch: generation}
+	}
+
+	writer := s.bucket.Object(objectKey).If(conditions).NewWriter(ctx)
+	writer.ChunkSize = s.cfg.ChunkBufferSize
+
+	if _, err := io.Copy(writer, object); err != nil {
+		_ = writer.Close()
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		var gerr *googleapi.Error
+		if errors.As(err, &gerr) && gerr.Code == http.StatusPreconditionFailed {
+			return "", chunk.ErrObjectModified
+		}
+		return "", err
+	}
+	return strconv.FormatInt(writer.Attrs().Generation, 10), nil
+}
+
 // List implements chunk.ObjectClient.
 func (s *GCSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
 	var storageObjects []chunk.StorageObject
@@ -197,6 +278,38 @@ func (s *GCSObjectClient) List(ctx context.Context, prefix, delimiter string) ([
 	return storageObjects, commonPrefixes, nil
 }
 
+// ListPages implements chunk.ObjectPagesLister.
+func (s *GCSObjectClient) ListPages(ctx context.Context, prefix string, f func([]chunk.StorageObject) error) error {
+	q := &storage.Query{Prefix: prefix}
+	if err := q.SetAttrSelection([]string{"Name", "Updated"}); err != nil {
+		return err
+	}
+
+	pager := iterator.NewPager(s.bucket.Objects(ctx, q), listPageSize, "")
+	for {
+		var attrs []*storage.ObjectAttrs
+		nextPageToken, err := pager.NextPage(&attrs)
+		if err != nil {
+			return err
+		}
+
+		storageObjects := make([]chunk.StorageObject, 0, len(attrs))
+		for _, attr := range attrs {
+			storageObjects = append(storageObjects, chunk.StorageObject{
+				Key:        attr.Name,
+				ModifiedAt: attr.Updated,
+			})
+		}
+		if err := f(storageObjects); err != nil {
+			return err
+		}
+
+		if nextPageToken == "" {
+			return nil
+		}
+	}
+}
+
 // DeleteObject deletes the specified object key from the configured GCS bucket.
 func (s *GCSObjectClient) DeleteObject(ctx context.Context, objectKey string) error {
 	err := s.bucket.Object(objectKey).Delete(ctx)
@@ -0,0 +1,100 @@
+package hedging
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// adaptiveTimeoutRoundTripper cancels the GET and HEAD requests whose first byte takes longer than their timeout,
+// and retries them. The timeout of an attempt is the first byte timeout, shortened to share the time left before the
+// deadline of the request between the remaining attempts. Once the response is received, reading its body times out
+// after the time expected to read its size at the minimum throughput.
+type adaptiveTimeoutRoundTripper struct {
+	cfg  Config
+	next http.RoundTripper
+}
+
+func newAdaptiveTimeoutRoundTripper(cfg Config, next http.RoundTripper) *adaptiveTimeoutRoundTripper {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &adaptiveTimeoutRoundTripper{
+		cfg:  cfg,
+		next: next,
+	}
+}
+
+func (rt *adaptiveTimeoutRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests with a body are not retried.
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return rt.next.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithCancel(req.Context())
+		timedOut := atomic.NewBool(false)
+		timer := time.AfterFunc(rt.firstByteTimeout(req.Context(), attempt), func() {
+			totalTimedOutRequests.Inc()
+			timedOut.Store(true)
+			cancel()
+		})
+
+		resp, err := rt.next.RoundTrip(req.WithContext(ctx))
+		if err == nil && timer.Stop() {
+			if resp.ContentLength > 0 {
+				timer.Reset(rt.cfg.FirstByteTimeout + rt.readTimeout(resp.ContentLength))
+			}
+			resp.Body = &timeoutBody{ReadCloser: resp.Body, timer: timer, cancel: cancel}
+			return resp, nil
+		}
+		if err == nil {
+			// The attempt timed out while the response was received.
+			_ = resp.Body.Close()
+		}
+		cancel()
+
+		if !timedOut.Load() || req.Context().Err() != nil {
+			return nil, err
+		}
+		if attempt >= rt.cfg.MaxAttempts {
+			return nil, ErrRequestTimedOut
+		}
+	}
+}
+
+// firstByteTimeout returns the timeout of the first byte of an attempt.
+func (rt *adaptiveTimeoutRoundTripper) firstByteTimeout(ctx context.Context, attempt int) time.Duration {
+	timeout := rt.cfg.FirstByteTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		share := time.Until(deadline) / time.Duration(rt.cfg.MaxAttempts-attempt+1)
+		if share < timeout {
+			timeout = share
+		}
+	}
+	return timeout
+}
+
+// readTimeout returns the time expected to read size bytes at the minimum throughput.
+func (rt *adaptiveTimeoutRoundTripper) readTimeout(size int64) time.Duration {
+	if rt.cfg.MinThroughput <= 0 {
+		return 0
+	}
+	return time.Duration(float64(size) / float64(rt.cfg.MinThroughput) * float64(time.Second))
+}
+
+// timeoutBody is the body of a response, canceled when reading it times out.
+type timeoutBody struct {
+	io.ReadCloser
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	b.cancel()
+	return b.ReadCloser.Close()
+}
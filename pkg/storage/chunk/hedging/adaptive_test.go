@@ -0,0 +1,118 @@
+package hedging
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// blockingBody blocks reads until the context of its request is done.
+type blockingBody struct {
+	ctx context.Context
+}
+
+func (b blockingBody) Read([]byte) (int, error) {
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b blockingBody) Close() error { return nil }
+
+func TestAdaptiveTimeout_RetriesSlowRequests(t *testing.T) {
+	resetMetrics()
+	cfg := Config{FirstByteTimeout: 50 * time.Millisecond, MinThroughput: 1 << 20, MaxAttempts: 3}
+	count := atomic.NewInt32(0)
+	rt, err := cfg.RoundTripper(RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		// The first attempt hangs until it is canceled.
+		if count.Inc() == 1 {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, ContentLength: 5, Body: ioutil.NopCloser(strings.NewReader("hello"))}, nil
+	}))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "hello", string(body))
+	require.Equal(t, int32(2), count.Load())
+	require.Equal(t, float64(1), testutil.ToFloat64(totalTimedOutRequests))
+
+	// Requests with a body are not retried.
+	count.Store(0)
+	req, err = http.NewRequest(http.MethodPut, "http://example.com", strings.NewReader("hello"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = rt.RoundTrip(req.WithContext(ctx))
+	require.Error(t, err)
+	require.Equal(t, int32(1), count.Load())
+}
+
+func TestAdaptiveTimeout_GivesUpAfterMaxAttempts(t *testing.T) {
+	resetMetrics()
+	cfg := Config{FirstByteTimeout: 10 * time.Millisecond, MaxAttempts: 3}
+	count := atomic.NewInt32(0)
+	rt, err := cfg.RoundTripper(RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		count.Inc()
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	require.Equal(t, ErrRequestTimedOut, err)
+	require.Equal(t, int32(3), count.Load())
+}
+
+func TestAdaptiveTimeout_BodyTimeout(t *testing.T) {
+	resetMetrics()
+	cfg := Config{FirstByteTimeout: 20 * time.Millisecond, MinThroughput: 1000, MaxAttempts: 1}
+	rt, err := cfg.RoundTripper(RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, ContentLength: 30, Body: blockingBody{ctx: r.Context()}}, nil
+	}))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+
+	// Reading the 30 bytes of the body at 1000 bytes per second times out after 20ms + 30ms.
+	start := time.Now()
+	_, err = ioutil.ReadAll(resp.Body)
+	require.ErrorIs(t, err, context.Canceled)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	require.NoError(t, resp.Body.Close())
+}
+
+func TestAdaptiveTimeout_FirstByteTimeout(t *testing.T) {
+	rt := newAdaptiveTimeoutRoundTripper(Config{FirstByteTimeout: time.Minute, MaxAttempts: 3}, nil)
+
+	require.Equal(t, time.Minute, rt.firstByteTimeout(context.Background(), 1))
+
+	// The time left before the deadline is shared between the remaining attempts.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.InDelta(t, 10*time.Second, rt.firstByteTimeout(ctx, 1), float64(time.Second))
+	require.InDelta(t, 15*time.Second, rt.firstByteTimeout(ctx, 2), float64(time.Second))
+	require.InDelta(t, 30*time.Second, rt.firstByteTimeout(ctx, 3), float64(time.Second))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	require.Equal(t, time.Minute, rt.firstByteTimeout(ctx, 1))
+}
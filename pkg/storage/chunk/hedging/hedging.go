@@ -10,12 +10,16 @@ import (
 	"github.com/cristalhq/hedgedhttp"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/time/rate"
+
+	"github.com/grafana/loki/pkg/util/flagext"
 )
 
 var (
 	ErrTooManyHedgeRequests       = errors.New("too many hedge requests")
+	ErrRequestTimedOut            = errors.New("request timed out before its first byte")
 	totalHedgeRequests            prometheus.Counter
 	totalRateLimitedHedgeRequests prometheus.Counter
+	totalTimedOutRequests         prometheus.Counter
 	once                          sync.Once
 )
 
@@ -34,6 +38,11 @@ func initMetrics() {
 		Name: "hedged_requests_rate_limited_total",
 		Help: "The total number of hedged requests rejected via rate limiting.",
 	})
+
+	totalTimedOutRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hedged_requests_timed_out_total",
+		Help: "The total number of requests canceled by their adaptive timeout.",
+	})
 }
 
 // Config is the configuration for hedging requests.
@@ -44,6 +53,14 @@ type Config struct {
 	UpTo int `yaml:"up_to"`
 	// The maximun of hedge requests allowed per second.
 	MaxPerSecond int `yaml:"max_per_second"`
+
+	// FirstByteTimeout is the time after which a request without response is canceled and retried. It is shortened
+	// to share the time left before the deadline of the request between the remaining attempts.
+	FirstByteTimeout time.Duration `yaml:"first_byte_timeout"`
+	// MinThroughput is the throughput below which reading the body of a response times out.
+	MinThroughput flagext.ByteSize `yaml:"min_throughput"`
+	// MaxAttempts is the maximum number of attempts of a request timing out before its first byte.
+	MaxAttempts int `yaml:"max_attempts"`
 }
 
 // RegisterFlags registers flags.
@@ -56,6 +73,23 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.IntVar(&cfg.UpTo, prefix+"hedge-requests-up-to", 2, "The maximun of hedge requests allowed.")
 	f.DurationVar(&cfg.At, prefix+"hedge-requests-at", 0, "If set to a non-zero value a second request will be issued at the provided duration. Default is 0 (disabled)")
 	f.IntVar(&cfg.MaxPerSecond, prefix+"hedge-max-per-second", 5, "The maximun of hedge requests allowed per seconds.")
+	f.DurationVar(&cfg.FirstByteTimeout, prefix+"hedge-first-byte-timeout", 0, "If set to a non-zero value, requests without response after the provided duration are canceled and retried. The timeout is shortened to share the time left before the deadline of the query between the remaining attempts. Default is 0 (disabled)")
+	cfg.MinThroughput = flagext.ByteSize(1 << 20)
+	f.Var(&cfg.MinThroughput, prefix+"hedge-min-throughput", "Minimum throughput expected when reading the response of a request with a first byte timeout. Reading the response times out after the first byte timeout plus the time taken to read its size at this throughput.")
+	f.IntVar(&cfg.MaxAttempts, prefix+"hedge-max-attempts", 3, "The maximum number of attempts of a request timing out before its first byte.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if cfg.FirstByteTimeout < 0 {
+		return errors.New("the first byte timeout must not be negative")
+	}
+	return nil
+}
+
+// enabled returns true if requests are hedged or have adaptive timeouts.
+func (cfg *Config) enabled() bool {
+	return cfg.At != 0 || cfg.FirstByteTimeout != 0
 }
 
 // Client returns a hedged http client.
@@ -73,7 +107,7 @@ func (cfg *Config) ClientWithRegisterer(client *http.Client, reg prometheus.Regi
 	if client == nil {
 		client = http.DefaultClient
 	}
-	if cfg.At == 0 {
+	if !cfg.enabled() {
 		return client, nil
 	}
 	var err error
@@ -92,19 +126,30 @@ func (cfg *Config) RoundTripperWithRegisterer(next http.RoundTripper, reg promet
 	if next == nil {
 		next = http.DefaultTransport
 	}
-	if cfg.At == 0 {
+	if !cfg.enabled() {
 		return next, nil
 	}
 	// register metrics
 	once.Do(func() {
 		reg.MustRegister(totalHedgeRequests)
 		reg.MustRegister(totalRateLimitedHedgeRequests)
+		reg.MustRegister(totalTimedOutRequests)
 	})
-	return hedgedhttp.NewRoundTripper(
-		cfg.At,
-		cfg.UpTo,
-		newLimitedHedgingRoundTripper(cfg.MaxPerSecond, next),
-	)
+	if cfg.At != 0 {
+		var err error
+		next, err = hedgedhttp.NewRoundTripper(
+			cfg.At,
+			cfg.UpTo,
+			newLimitedHedgingRoundTripper(cfg.MaxPerSecond, next),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.FirstByteTimeout != 0 {
+		next = newAdaptiveTimeoutRoundTripper(*cfg, next)
+	}
+	return next, nil
 }
 
 // RoundTripper returns a hedged roundtripper.
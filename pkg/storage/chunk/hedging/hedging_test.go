@@ -94,3 +94,11 @@ hedged_requests_total 1
 `,
 		), "hedged_requests_total", "hedged_requests_rate_limited_total"))
 }
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Config{FirstByteTimeout: time.Second}
+	require.NoError(t, cfg.Validate())
+
+	cfg.FirstByteTimeout = -time.Second
+	require.EqualError(t, cfg.Validate(), "the first byte timeout must not be negative")
+}
@@ -415,6 +415,45 @@ func (m *MockStorage) GetObject(ctx context.Context, objectKey string) (io.ReadC
 	return ioutil.NopCloser(bytes.NewReader(buf)), nil
 }
 
+// ObjectExists implements ObjectExistsChecker.
+func (m *MockStorage) ObjectExists(ctx context.Context, objectKey string) (bool, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	if m.mode == MockStorageModeWriteOnly {
+		return false, errPermissionDenied
+	}
+
+	_, ok := m.objects[objectKey]
+	return ok, nil
+}
+
+// ObjectSize implements ObjectSizer.
+func (m *MockStorage) ObjectSize(ctx context.Context, objectKey string) (int64, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	if m.mode == MockStorageModeWriteOnly {
+		return 0, errPermissionDenied
+	}
+
+	buf, ok := m.objects[objectKey]
+	if !ok {
+		return 0, errStorageObjectNotFound
+	}
+	return int64(len(buf)), nil
+}
+
+// ChunkSize implements ChunkSizer.
+func (m *MockStorage) ChunkSize(ctx context.Context, c Chunk) (int64, error) {
+	return m.ObjectSize(ctx, c.ExternalKey())
+}
+
+// ChunkExists implements ChunkExistsChecker.
+func (m *MockStorage) ChunkExists(ctx context.Context, c Chunk) (bool, error) {
+	return m.ObjectExists(ctx, c.ExternalKey())
+}
+
 func (m *MockStorage) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
 	buf, err := ioutil.ReadAll(object)
 	if err != nil {
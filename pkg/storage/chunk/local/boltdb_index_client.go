@@ -34,6 +34,9 @@ const (
 	DBOperationWrite
 
 	openBoltDBFileTimeout = 5 * time.Second
+
+	// cancellationCheckInterval is the number of rows iterated between two checks of the query cancellation.
+	cancellationCheckInterval = 1000
 )
 
 // BoltDBConfig for a BoltDB index client.
@@ -249,7 +252,7 @@ func (b *BoltIndexClient) QueryDB(ctx context.Context, db *bbolt.DB, query chunk
 	})
 }
 
-func (b *BoltIndexClient) QueryWithCursor(_ context.Context, c *bbolt.Cursor, query chunk.IndexQuery, callback func(chunk.IndexQuery, chunk.ReadBatch) (shouldContinue bool)) error {
+func (b *BoltIndexClient) QueryWithCursor(ctx context.Context, c *bbolt.Cursor, query chunk.IndexQuery, callback func(chunk.IndexQuery, chunk.ReadBatch) (shouldContinue bool)) error {
 	var start []byte
 	if len(query.RangeValuePrefix) > 0 {
 		start = []byte(query.HashValue + separator + string(query.RangeValuePrefix))
@@ -261,19 +264,34 @@ func (b *BoltIndexClient) QueryWithCursor(_ context.Context, c *bbolt.Cursor, qu
 
 	rowPrefix := []byte(query.HashValue + separator)
 
-	var batch boltReadBatch
+	var (
+		batch boltReadBatch
+		rows  int
+	)
 
 	for k, v := c.Seek(start); k != nil; k, v = c.Next() {
 		if !bytes.HasPrefix(k, rowPrefix) {
 			break
 		}
 
+		// Stop iterating when the query got cancelled, checking it periodically to keep it cheap.
+		if rows%cancellationCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				chunk.CancelledOperations.WithLabelValues(chunk.CancelledIndexQuery).Inc()
+				return err
+			}
+		}
+		rows++
+
 		if len(query.RangeValuePrefix) > 0 && !bytes.HasPrefix(k, start) {
 			break
 		}
 		if len(query.ValueEqual) > 0 && !bytes.Equal(v, query.ValueEqual) {
 			continue
 		}
+		if query.ValueMatcher != nil && !query.ValueMatcher.Matches(string(v)) {
+			continue
+		}
 
 		// make a copy since k, v are only valid for the life of the transaction.
 		// See: https://godoc.org/github.com/boltdb/bolt#Cursor.Seek
@@ -364,3 +382,17 @@ func (b *boltReadBatchIterator) Value() []byte {
 func OpenBoltdbFile(path string) (*bbolt.DB, error) {
 	return bbolt.Open(path, 0666, &bbolt.Options{Timeout: 5 * time.Second})
 }
+
+// OpenBoltdbFileReadOnly opens the database in read-only mode, for files which never get written like the ones
+// downloaded from the object store. The file is only mmapped and the freelist, which can be big for compacted files,
+// is not loaded in memory. The kernel is also hinted to read the file in the page cache in the background.
+// The timeout bounds the wait for the file lock, 0 meaning no timeout.
+func OpenBoltdbFileReadOnly(path string, timeout time.Duration) (*bbolt.DB, error) {
+	db, err := bbolt.Open(path, 0444, &bbolt.Options{Timeout: timeout, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	adviseWillNeed(path)
+	return db, nil
+}
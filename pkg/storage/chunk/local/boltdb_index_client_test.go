@@ -7,7 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 	"go.etcd.io/bbolt"
 
@@ -271,3 +273,124 @@ func TestBoltDB_Writes(t *testing.T) {
 		})
 	}
 }
+
+func TestBoltDB_QueryValueMatcher(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "boltdb")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dirname))
+	}()
+
+	indexClient, err := NewBoltDBIndexClient(BoltDBConfig{
+		Directory: dirname,
+	})
+	require.NoError(t, err)
+	defer indexClient.Stop()
+
+	batch := indexClient.NewWriteBatch()
+	for i, value := range []string{"foo", "bar", "baz", "qux"} {
+		batch.Add("table", "hash", []byte(fmt.Sprint(i)), []byte(value))
+	}
+	require.NoError(t, indexClient.BatchWrite(context.Background(), batch))
+
+	var values []string
+	err = indexClient.query(context.Background(), chunk.IndexQuery{
+		TableName:    "table",
+		HashValue:    "hash",
+		ValueMatcher: labels.MustNewMatcher(labels.MatchRegexp, "name", "ba.*"),
+	}, func(_ chunk.IndexQuery, read chunk.ReadBatch) bool {
+		for iter := read.Iterator(); iter.Next(); {
+			values = append(values, string(iter.Value()))
+		}
+		return true
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar", "baz"}, values)
+}
+
+func TestBoltDB_QueryCancelled(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "boltdb")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(dirname))
+	}()
+
+	indexClient, err := NewBoltDBIndexClient(BoltDBConfig{
+		Directory: dirname,
+	})
+	require.NoError(t, err)
+	defer indexClient.Stop()
+
+	batch := indexClient.NewWriteBatch()
+	for i := 0; i < 3*cancellationCheckInterval; i++ {
+		batch.Add("table", "hash", []byte(fmt.Sprintf("%05d", i)), testValue)
+	}
+	require.NoError(t, indexClient.BatchWrite(context.Background(), batch))
+
+	// The iteration stops at the next check once the query is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var rows int
+	err = indexClient.query(ctx, chunk.IndexQuery{
+		TableName: "table",
+		HashValue: "hash",
+	}, func(_ chunk.IndexQuery, read chunk.ReadBatch) bool {
+		rows++
+		if rows == 10 {
+			cancel()
+		}
+		return true
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, cancellationCheckInterval, rows)
+}
+
+func BenchmarkOpenBoltdbFile(b *testing.B) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "boltdb")
+	require.NoError(b, err)
+
+	defer func() {
+		require.NoError(b, os.RemoveAll(dirname))
+	}()
+
+	indexClient, err := NewBoltDBIndexClient(BoltDBConfig{
+		Directory: dirname,
+	})
+	require.NoError(b, err)
+
+	// write and then delete a lot of rows to have a big freelist.
+	for _, add := range []bool{true, false} {
+		batch := indexClient.NewWriteBatch()
+		for i := 0; i < 20000; i++ {
+			if add {
+				batch.Add("table", "hash", []byte(fmt.Sprint(i)), testValue)
+			} else if i%2 == 0 {
+				batch.Delete("table", "hash", []byte(fmt.Sprint(i)))
+			}
+		}
+		require.NoError(b, indexClient.BatchWrite(context.Background(), batch))
+	}
+	indexClient.Stop()
+
+	for _, bm := range []struct {
+		name string
+		open func(path string) (*bbolt.DB, error)
+	}{
+		{"read-write", OpenBoltdbFile},
+		{"read-only", func(path string) (*bbolt.DB, error) {
+			return OpenBoltdbFileReadOnly(path, 5*time.Second)
+		}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				db, err := bm.open(filepath.Join(dirname, "table"))
+				require.NoError(b, err)
+				require.NoError(b, db.Close())
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package local
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseWillNeed hints the kernel to read the whole file in the page cache in the background.
+// bbolt advises its mmap for random access, which disables readahead and makes the first queries
+// on a freshly opened file fault in its pages one by one.
+func adviseWillNeed(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	// this is only a hint, the file can be queried just fine if it fails.
+	_ = unix.Fadvise(int(f.Fd()), 0, stat.Size(), unix.FADV_WILLNEED)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+// +build !linux
+
+package local
+
+// adviseWillNeed is a no-op on platforms without posix_fadvise.
+func adviseWillNeed(_ string) {}
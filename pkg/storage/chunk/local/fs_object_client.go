@@ -1,12 +1,17 @@
 package local
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
@@ -45,6 +50,9 @@ func (cfg *FSConfig) ToCortexLocalConfig() cortex_local.Config {
 type FSObjectClient struct {
 	cfg           FSConfig
 	pathSeparator string
+
+	// conditionalWriteMtx serialises the conditional writes, which are only atomic within this process.
+	conditionalWriteMtx *sync.Mutex
 }
 
 // NewFSObjectClient makes a chunk.Client which stores chunks as files in the local filesystem.
@@ -58,8 +66,9 @@ func NewFSObjectClient(cfg FSConfig) (*FSObjectClient, error) {
 	}
 
 	return &FSObjectClient{
-		cfg:           cfg,
-		pathSeparator: string(os.PathSeparator),
+		cfg:                 cfg,
+		pathSeparator:       string(os.PathSeparator),
+		conditionalWriteMtx: &sync.Mutex{},
 	}, nil
 }
 
@@ -76,6 +85,27 @@ func (f *FSObjectClient) GetObject(_ context.Context, objectKey string) (io.Read
 	return fl, nil
 }
 
+// ObjectExists checks whether the object exists on disk.
+func (f *FSObjectClient) ObjectExists(_ context.Context, objectKey string) (bool, error) {
+	_, err := os.Stat(filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey)))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// ObjectSize returns the size of the object on disk.
+func (f *FSObjectClient) ObjectSize(_ context.Context, objectKey string) (int64, error) {
+	info, err := os.Stat(filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey)))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
 // PutObject into the store
 func (f *FSObjectClient) PutObject(_ context.Context, objectKey string, object io.ReadSeeker) error {
 	fullPath := filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey))
@@ -104,6 +134,51 @@ func (f *FSObjectClient) PutObject(_ context.Context, objectKey string, object i
 	return fl.Close()
 }
 
+// GetObjectVersion returns the object along with a version derived from its content.
+func (f *FSObjectClient) GetObjectVersion(_ context.Context, objectKey string) (io.ReadCloser, string, error) {
+	f.conditionalWriteMtx.Lock()
+	defer f.conditionalWriteMtx.Unlock()
+
+	buf, err := ioutil.ReadFile(filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey)))
+	if err != nil {
+		return nil, "", err
+	}
+	return ioutil.NopCloser(bytes.NewReader(buf)), contentVersion(buf), nil
+}
+
+// PutObjectIfVersion writes the object only if its current content matches the given version,
+// or only if it does not exist when the version is empty.
+func (f *FSObjectClient) PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error) {
+	f.conditionalWriteMtx.Lock()
+	defer f.conditionalWriteMtx.Unlock()
+
+	current := ""
+	buf, err := ioutil.ReadFile(filepath.Join(f.cfg.Directory, filepath.FromSlash(objectKey)))
+	if err == nil {
+		current = contentVersion(buf)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	if current != version {
+		return "", chunk.ErrObjectModified
+	}
+
+	buf, err = ioutil.ReadAll(object)
+	if err != nil {
+		return "", err
+	}
+	if err := f.PutObject(ctx, objectKey, bytes.NewReader(buf)); err != nil {
+		return "", err
+	}
+	return contentVersion(buf), nil
+}
+
+func contentVersion(buf []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(buf)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
 // List implements chunk.ObjectClient.
 // FSObjectClient assumes that prefix is a directory, and only supports "" and "/" delimiters.
 func (f *FSObjectClient) List(ctx context.Context, prefix, delimiter string) ([]chunk.StorageObject, []chunk.StorageCommonPrefix, error) {
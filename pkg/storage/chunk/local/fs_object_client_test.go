@@ -215,3 +215,20 @@ func TestFSObjectClient_DeleteObject(t *testing.T) {
 	require.Len(t, commonPrefixes, 0)
 	require.Len(t, files, len(foldersWithFiles["folder2/"]))*/
 }
+
+func TestFSObjectClient_ObjectExists(t *testing.T) {
+	fsObjectClient, err := NewFSObjectClient(FSConfig{
+		Directory: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	exists, err := fsObjectClient.ObjectExists(context.Background(), "outer-file1")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, fsObjectClient.PutObject(context.Background(), "outer-file1", bytes.NewReader([]byte("foo"))))
+
+	exists, err = fsObjectClient.ObjectExists(context.Background(), "outer-file1")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
@@ -4,9 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"flag"
+	"fmt"
 	"io/ioutil"
+	"strings"
 
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/util"
@@ -22,17 +31,69 @@ var Base64Encoder = func(key string) string {
 	return base64.StdEncoding.EncodeToString([]byte(key))
 }
 
+var (
+	corruptChunks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "chunk_store_corrupt_chunks_total",
+		Help:      "Total number of chunks fetched from the object store failing their integrity checks, by failed check.",
+	}, []string{"reason"})
+	quarantinedChunks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "chunk_store_quarantined_chunks_total",
+		Help:      "Total number of corrupt chunks copied under the quarantine key prefix.",
+	})
+)
+
+// maxQuarantinedKeys is the number of keys of the quarantined chunks remembered to quarantine each of them once.
+const maxQuarantinedKeys = 10000
+
+// IntegrityConfig configures the handling of the chunks failing their integrity checks when fetched.
+type IntegrityConfig struct {
+	QuarantineKeyPrefix string `yaml:"quarantine_key_prefix"`
+	SkipCorruptChunks   bool   `yaml:"skip_corrupt_chunks"`
+}
+
+// RegisterFlagsWithPrefix registers flags with prefix.
+func (cfg *IntegrityConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.QuarantineKeyPrefix, prefix+"quarantine-key-prefix", "", "Prefix of the object keys under which the chunks failing their integrity checks when fetched are copied, for inspection. The corrupt chunks are left in place. Prefix should never start with a separator but should always end with it. Empty disables quarantining.")
+	f.BoolVar(&cfg.SkipCorruptChunks, prefix+"skip-corrupt-chunks", false, "Leave the chunks failing their integrity checks out of the fetched chunks instead of failing the queries fetching them.")
+}
+
+// Validate validates the config.
+func (cfg *IntegrityConfig) Validate() error {
+	if cfg.QuarantineKeyPrefix != "" && (strings.HasPrefix(cfg.QuarantineKeyPrefix, "/") || !strings.HasSuffix(cfg.QuarantineKeyPrefix, "/")) {
+		return errors.New("the quarantine key prefix of the corrupt chunks must end with a separator and not start with it")
+	}
+	return nil
+}
+
 // Client is used to store chunks in object store backends
 type Client struct {
 	store      chunk.ObjectClient
 	keyEncoder KeyEncoder
+	schemaCfg  chunk.SchemaConfig
+	integrity  IntegrityConfig
+
+	// quarantined holds the keys of the corrupt chunks last quarantined by this client.
+	quarantined *lru.Cache
 }
 
-// NewClient wraps the provided ObjectClient with a chunk.Client implementation
-func NewClient(store chunk.ObjectClient, encoder KeyEncoder) *Client {
+// NewClient wraps the provided ObjectClient with a chunk.Client implementation.
+// The schema config decides of the layout of the chunk keys in the object store.
+func NewClient(store chunk.ObjectClient, encoder KeyEncoder, schemaCfg chunk.SchemaConfig) *Client {
+	return NewClientWithIntegrity(store, encoder, schemaCfg, IntegrityConfig{})
+}
+
+// NewClientWithIntegrity is like NewClient, the chunks failing their integrity checks being handled as configured.
+func NewClientWithIntegrity(store chunk.ObjectClient, encoder KeyEncoder, schemaCfg chunk.SchemaConfig, integrity IntegrityConfig) *Client {
+	// The size is positive, so creating the cache can't fail.
+	quarantined, _ := lru.New(maxQuarantinedKeys)
 	return &Client{
-		store:      store,
-		keyEncoder: encoder,
+		store:       store,
+		keyEncoder:  encoder,
+		schemaCfg:   schemaCfg,
+		integrity:   integrity,
+		quarantined: quarantined,
 	}
 }
 
@@ -54,10 +115,7 @@ func (o *Client) PutChunks(ctx context.Context, chunks []chunk.Chunk) error {
 		if err != nil {
 			return err
 		}
-		key := chunks[i].ExternalKey()
-		if o.keyEncoder != nil {
-			key = o.keyEncoder(key)
-		}
+		key := o.objectKey(chunks[i])
 
 		chunkKeys = append(chunkKeys, key)
 		chunkBufs = append(chunkBufs, buf)
@@ -80,17 +138,34 @@ func (o *Client) PutChunks(ctx context.Context, chunks []chunk.Chunk) error {
 	return lastErr
 }
 
+// ChunkExists checks whether the chunk has already been stored. Since chunk keys include
+// a checksum of the chunk content, an existing object holds the exact same chunk.
+// It always returns false if the underlying ObjectClient can't check for existence.
+func (o *Client) ChunkExists(ctx context.Context, c chunk.Chunk) (bool, error) {
+	checker, ok := o.store.(chunk.ObjectExistsChecker)
+	if !ok || !c.ChecksumSet {
+		return false, nil
+	}
+	return checker.ObjectExists(ctx, o.objectKey(c))
+}
+
+// ChunkSize returns the size of the stored chunk, failing with chunk.ErrMethodNotImplemented if the underlying
+// ObjectClient can't get the size of an object without downloading it.
+func (o *Client) ChunkSize(ctx context.Context, c chunk.Chunk) (int64, error) {
+	sizer, ok := o.store.(chunk.ObjectSizer)
+	if !ok {
+		return 0, chunk.ErrMethodNotImplemented
+	}
+	return sizer.ObjectSize(ctx, o.objectKey(c))
+}
+
 // GetChunks retrieves the specified chunks from the configured backend
 func (o *Client) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
 	return util.GetParallelChunks(ctx, chunks, o.getChunk)
 }
 
 func (o *Client) getChunk(ctx context.Context, decodeContext *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
-	key := c.ExternalKey()
-	if o.keyEncoder != nil {
-		key = o.keyEncoder(key)
-	}
-
+	key := o.objectKey(c)
 	readCloser, err := o.store.GetObject(ctx, key)
 	if err != nil {
 		return chunk.Chunk{}, errors.WithStack(err)
@@ -104,18 +179,105 @@ func (o *Client) getChunk(ctx context.Context, decodeContext *chunk.DecodeContex
 	}
 
 	if err := c.Decode(decodeContext, buf); err != nil {
+		if reason, ok := chunk.CorruptionReason(err); ok {
+			return o.corruptChunk(ctx, key, buf, reason, err)
+		}
 		return chunk.Chunk{}, errors.WithStack(err)
 	}
 	return c, nil
 }
 
-// GetChunks retrieves the specified chunks from the configured backend
+// corruptChunk surfaces a chunk failing its integrity checks and copies it once under the quarantine prefix. The
+// chunk is then skipped if configured so, rather than failing the fetch.
+func (o *Client) corruptChunk(ctx context.Context, key string, buf []byte, reason string, err error) (chunk.Chunk, error) {
+	logger := util_log.WithContext(ctx, util_log.Logger)
+	corruptChunks.WithLabelValues(reason).Inc()
+	level.Error(logger).Log("msg", "chunk failed its integrity checks", "key", key, "reason", reason, "err", err)
+
+	if o.integrity.QuarantineKeyPrefix != "" {
+		if loaded, _ := o.quarantined.ContainsOrAdd(key, struct{}{}); !loaded {
+			if err := o.store.PutObject(ctx, o.integrity.QuarantineKeyPrefix+key, bytes.NewReader(buf)); err != nil {
+				level.Error(logger).Log("msg", "failed to quarantine corrupt chunk", "key", key, "err", err)
+				o.quarantined.Remove(key)
+			} else {
+				quarantinedChunks.Inc()
+			}
+		}
+	}
+
+	if o.integrity.SkipCorruptChunks {
+		return chunk.Chunk{}, util.ErrSkipChunk
+	}
+	return chunk.Chunk{}, errors.WithStack(err)
+}
+
+// DeleteChunk deletes the specified chunk from the configured backend
 func (o *Client) DeleteChunk(ctx context.Context, userID, chunkID string) error {
-	key := chunkID
+	c, err := chunk.ParseExternalKey(userID, chunkID)
+	if err != nil || !c.ChecksumSet {
+		// legacy chunks are always stored under their id.
+		key := chunkID
+		if o.keyEncoder != nil {
+			key = o.keyEncoder(key)
+		}
+		return o.store.DeleteObject(ctx, key)
+	}
+	return o.store.DeleteObject(ctx, o.objectKey(c))
+}
+
+// ListChunks implements chunk.ChunkLister by listing the objects stored under the tenant's prefix.
+// Encoded keys only share the encoding of the longest part of the tenant's prefix made of whole base64 groups, the
+// objects listed under it are decoded and filtered.
+func (o *Client) ListChunks(ctx context.Context, userID string, from, through model.Time, maxObjects int) ([]chunk.Chunk, error) {
+	prefix := userID + "/"
+	listPrefix := prefix
+	if o.keyEncoder != nil {
+		listPrefix = base64.StdEncoding.EncodeToString([]byte(prefix[:len(prefix)/3*3]))
+	}
+	objects, _, err := o.store.List(ctx, listPrefix, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		chunks []chunk.Chunk
+		listed int
+	)
+	for _, object := range objects {
+		key := object.Key
+		if o.keyEncoder != nil {
+			decoded, err := base64.StdEncoding.DecodeString(key)
+			if err != nil {
+				continue
+			}
+			key = string(decoded)
+		}
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if listed++; maxObjects > 0 && listed > maxObjects {
+			return nil, fmt.Errorf("listed more than %d objects for tenant %s", maxObjects, userID)
+		}
+		c, err := chunk.ParseExternalKey(userID, key)
+		if err != nil {
+			// not a chunk.
+			continue
+		}
+		if c.Through < from || c.From > through {
+			continue
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, nil
+}
+
+// objectKey returns the key of the chunk in the object store.
+func (o *Client) objectKey(c chunk.Chunk) string {
+	key := o.schemaCfg.ExternalKey(c)
 	if o.keyEncoder != nil {
 		key = o.keyEncoder(key)
 	}
-	return o.store.DeleteObject(ctx, key)
+	return key
 }
 
 func (o *Client) IsChunkNotFoundErr(err error) bool {
@@ -0,0 +1,195 @@
+package objectclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/testutils"
+)
+
+func TestClient_ChunkKeyLayout(t *testing.T) {
+	v12From := model.TimeFromUnix(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	schemaCfg := chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{
+			{From: chunk.DayTime{Time: 0}, Schema: "v11"},
+			{From: chunk.DayTime{Time: v12From}, Schema: "v12"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		from      model.Time
+		keyLayout func(c chunk.Chunk) string
+	}{
+		{
+			name:      "v11",
+			from:      v12From.Add(-48 * time.Hour),
+			keyLayout: func(c chunk.Chunk) string { return c.ExternalKey() },
+		},
+		{
+			name: "v12",
+			from: v12From,
+			keyLayout: func(c chunk.Chunk) string {
+				return fmt.Sprintf("%s/%x/%x:%x:%x", c.UserID, uint64(c.Fingerprint), int64(c.From), int64(c.Through), c.Checksum)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := chunk.NewMockStorage()
+			client := NewClient(store, nil, schemaCfg)
+
+			keys, chunks, err := testutils.CreateChunks(0, 5, tc.from, tc.from.Add(time.Hour))
+			require.NoError(t, err)
+			require.NoError(t, client.PutChunks(context.Background(), chunks))
+
+			expectedKeys := make([]string, 0, len(chunks))
+			for _, c := range chunks {
+				expectedKeys = append(expectedKeys, tc.keyLayout(c))
+			}
+			require.ElementsMatch(t, expectedKeys, store.GetSortedObjectKeys())
+
+			toFetch := make([]chunk.Chunk, 0, len(keys))
+			for _, key := range keys {
+				c, err := chunk.ParseExternalKey("userID", key)
+				require.NoError(t, err)
+				toFetch = append(toFetch, c)
+
+				exists, err := client.ChunkExists(context.Background(), c)
+				require.NoError(t, err)
+				require.True(t, exists)
+			}
+			fetched, err := client.GetChunks(context.Background(), toFetch)
+			require.NoError(t, err)
+			require.Len(t, fetched, len(chunks))
+
+			// chunks are deleted using their id from the index.
+			for _, key := range keys {
+				require.NoError(t, client.DeleteChunk(context.Background(), "userID", key))
+			}
+			require.Empty(t, store.GetSortedObjectKeys())
+		})
+	}
+}
+
+func TestClient_ListChunks(t *testing.T) {
+	from := model.TimeFromUnix(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	schemaCfg := chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{
+			{From: chunk.DayTime{Time: 0}, Schema: "v11"},
+			{From: chunk.DayTime{Time: from.Add(time.Hour)}, Schema: "v12"},
+		},
+	}
+
+	for _, encoder := range []KeyEncoder{nil, Base64Encoder} {
+		store := chunk.NewMockStorage()
+		client := NewClient(store, encoder, schemaCfg)
+
+		// chunks of both key layouts, and chunks of another tenant.
+		_, chunks, err := testutils.CreateChunks(0, 4, from, from.Add(time.Hour))
+		require.NoError(t, err)
+		_, v12Chunks, err := testutils.CreateChunks(4, 4, from.Add(time.Hour), from.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, client.PutChunks(context.Background(), append(chunks, v12Chunks...)))
+		require.NoError(t, store.PutObject(context.Background(), "other/index", bytes.NewReader([]byte("index"))))
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("other/%d", i)
+			if encoder != nil {
+				key = encoder(key)
+			}
+			require.NoError(t, store.PutObject(context.Background(), key, bytes.NewReader([]byte("chunk"))))
+		}
+
+		listed, err := client.ListChunks(context.Background(), "userID", from, from.Add(3*time.Hour), 0)
+		require.NoError(t, err)
+		require.Len(t, listed, 8)
+
+		listed, err = client.ListChunks(context.Background(), "userID", from.Add(90*time.Minute), from.Add(3*time.Hour), 0)
+		require.NoError(t, err)
+		require.Len(t, listed, 4)
+		for _, c := range listed {
+			require.Equal(t, from.Add(time.Hour), c.From)
+		}
+
+		// the objects of the other tenants don't count in the limit.
+		listed, err = client.ListChunks(context.Background(), "userID", from, from.Add(3*time.Hour), 8)
+		require.NoError(t, err)
+		require.Len(t, listed, 8)
+		_, err = client.ListChunks(context.Background(), "userID", from, from.Add(3*time.Hour), 5)
+		require.Error(t, err)
+	}
+}
+
+func TestClient_CorruptChunks(t *testing.T) {
+	schemaCfg := chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{{From: chunk.DayTime{Time: 0}, Schema: "v11"}},
+	}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name      string
+		integrity IntegrityConfig
+	}{
+		{name: "fail"},
+		{name: "skip and quarantine", integrity: IntegrityConfig{QuarantineKeyPrefix: "quarantine/", SkipCorruptChunks: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := chunk.NewMockStorage()
+			client := NewClientWithIntegrity(store, nil, schemaCfg, tc.integrity)
+
+			keys, chunks, err := testutils.CreateChunks(0, 2, model.Now().Add(-time.Hour), model.Now())
+			require.NoError(t, err)
+			require.NoError(t, client.PutChunks(ctx, chunks))
+
+			// Corrupt the data of the first chunk.
+			buf, err := chunks[0].Encoded()
+			require.NoError(t, err)
+			corrupted := append([]byte{}, buf...)
+			corrupted[len(corrupted)-1]++
+			require.NoError(t, store.PutObject(ctx, keys[0], bytes.NewReader(corrupted)))
+
+			toFetch := make([]chunk.Chunk, 0, len(keys))
+			for _, key := range keys {
+				c, err := chunk.ParseExternalKey("userID", key)
+				require.NoError(t, err)
+				toFetch = append(toFetch, c)
+			}
+
+			fetched, err := client.GetChunks(ctx, toFetch)
+			if !tc.integrity.SkipCorruptChunks {
+				require.Error(t, err)
+				require.True(t, chunk.IsCorruptChunkErr(err))
+				require.ElementsMatch(t, keys, store.GetSortedObjectKeys())
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, fetched, 1)
+			require.Equal(t, keys[1], fetched[0].ExternalKey())
+
+			// The corrupt chunk is copied once under the quarantine prefix and left in place.
+			_, err = client.GetChunks(ctx, toFetch[:1])
+			require.NoError(t, err)
+			require.ElementsMatch(t, append([]string{"quarantine/" + keys[0]}, keys...), store.GetSortedObjectKeys())
+			quarantined, err := store.GetObject(ctx, "quarantine/"+keys[0])
+			require.NoError(t, err)
+			defer quarantined.Close()
+			var got bytes.Buffer
+			_, err = got.ReadFrom(quarantined)
+			require.NoError(t, err)
+			require.Equal(t, corrupted, got.Bytes())
+		})
+	}
+}
+
+func TestIntegrityConfig_Validate(t *testing.T) {
+	require.NoError(t, (&IntegrityConfig{}).Validate())
+	require.NoError(t, (&IntegrityConfig{QuarantineKeyPrefix: "quarantine/"}).Validate())
+	require.Error(t, (&IntegrityConfig{QuarantineKeyPrefix: "quarantine"}).Validate())
+	require.Error(t, (&IntegrityConfig{QuarantineKeyPrefix: "/quarantine/"}).Validate())
+}
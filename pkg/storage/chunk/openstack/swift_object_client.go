@@ -8,11 +8,13 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/ncw/swift"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
 
 	cortex_openstack "github.com/cortexproject/cortex/pkg/chunk/openstack"
 	cortex_swift "github.com/cortexproject/cortex/pkg/storage/bucket/swift"
@@ -32,11 +34,31 @@ type SwiftObjectClient struct {
 	conn        *swift.Connection
 	hedgingConn *swift.Connection
 	cfg         SwiftConfig
+
+	// expiredTokens counts the requests of conn rejected because of an expired token.
+	expiredTokens *atomic.Int64
+}
+
+// tokenExpiryRoundTripper counts the requests rejected because of an expired token.
+type tokenExpiryRoundTripper struct {
+	next          http.RoundTripper
+	expiredTokens *atomic.Int64
+}
+
+func (rt tokenExpiryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.expiredTokens.Inc()
+	}
+	return resp, err
 }
 
 // SwiftConfig is config for the Swift Chunk Client.
 type SwiftConfig struct {
 	cortex_swift.Config `yaml:",inline"`
+
+	TrustID      string `yaml:"trust_id"`
+	EndpointType string `yaml:"endpoint_type"`
 }
 
 // RegisterFlags registers flags.
@@ -46,12 +68,28 @@ func (cfg *SwiftConfig) RegisterFlags(f *flag.FlagSet) {
 
 // Validate config and returns error on failure
 func (cfg *SwiftConfig) Validate() error {
+	switch swift.EndpointType(cfg.EndpointType) {
+	case "", swift.EndpointTypePublic, swift.EndpointTypeInternal, swift.EndpointTypeAdmin:
+	default:
+		return fmt.Errorf("invalid swift endpoint type %q, must be one of public, internal or admin", cfg.EndpointType)
+	}
+	if cfg.TrustID != "" {
+		if cfg.AuthVersion != 0 && cfg.AuthVersion != 3 {
+			return errors.New("swift trust ID requires the v3 auth version")
+		}
+		// A trust scoped token is scoped to the project of the trust.
+		if cfg.ProjectID != "" || cfg.ProjectName != "" {
+			return errors.New("swift trust ID cannot be used with a project ID or name")
+		}
+	}
 	return nil
 }
 
 // RegisterFlagsWithPrefix registers flags with prefix.
 func (cfg *SwiftConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	cfg.Config.RegisterFlagsWithPrefix(prefix, f)
+	f.StringVar(&cfg.TrustID, prefix+"swift.trust-id", "", "OpenStack Keystone trust ID to scope the token to (v3 auth only), instead of a project.")
+	f.StringVar(&cfg.EndpointType, prefix+"swift.endpoint-type", "", "Interface of the Swift endpoint to pick from the catalog of the region: public, internal or admin (v2,v3 auth only). Defaults to public.")
 }
 
 func (cfg *SwiftConfig) ToCortexSwiftConfig() cortex_openstack.SwiftConfig {
@@ -64,7 +102,8 @@ func (cfg *SwiftConfig) ToCortexSwiftConfig() cortex_openstack.SwiftConfig {
 func NewSwiftObjectClient(cfg SwiftConfig, hedgingCfg hedging.Config) (*SwiftObjectClient, error) {
 	log.WarnExperimentalUse("OpenStack Swift Storage")
 
-	c, err := createConnection(cfg, hedgingCfg, false)
+	expiredTokens := atomic.NewInt64(0)
+	c, err := createConnection(cfg, tokenExpiryRoundTripper{next: defaultTransport, expiredTokens: expiredTokens}, hedgingCfg, false)
 	if err != nil {
 		return nil, err
 	}
@@ -72,18 +111,19 @@ func NewSwiftObjectClient(cfg SwiftConfig, hedgingCfg hedging.Config) (*SwiftObj
 	if err := c.ContainerCreate(cfg.ContainerName, nil); err != nil {
 		return nil, err
 	}
-	hedging, err := createConnection(cfg, hedgingCfg, true)
+	hedging, err := createConnection(cfg, defaultTransport, hedgingCfg, true)
 	if err != nil {
 		return nil, err
 	}
 	return &SwiftObjectClient{
-		conn:        c,
-		hedgingConn: hedging,
-		cfg:         cfg,
+		conn:          c,
+		hedgingConn:   hedging,
+		cfg:           cfg,
+		expiredTokens: expiredTokens,
 	}, nil
 }
 
-func createConnection(cfg SwiftConfig, hedgingCfg hedging.Config, hedging bool) (*swift.Connection, error) {
+func createConnection(cfg SwiftConfig, transport http.RoundTripper, hedgingCfg hedging.Config, hedging bool) (*swift.Connection, error) {
 	// Create a connection
 	c := &swift.Connection{
 		AuthVersion:    cfg.AuthVersion,
@@ -101,7 +141,9 @@ func createConnection(cfg SwiftConfig, hedgingCfg hedging.Config, hedging bool)
 		Domain:         cfg.DomainName,
 		DomainId:       cfg.DomainID,
 		Region:         cfg.RegionName,
-		Transport:      defaultTransport,
+		TrustId:        cfg.TrustID,
+		EndpointType:   swift.EndpointType(cfg.EndpointType),
+		Transport:      transport,
 	}
 
 	switch {
@@ -144,8 +186,24 @@ func (s *SwiftObjectClient) GetObject(ctx context.Context, objectKey string) (io
 
 // PutObject puts the specified bytes into the configured Swift container at the provided key
 func (s *SwiftObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
-	_, err := s.conn.ObjectPut(s.cfg.ContainerName, objectKey, object, false, "", "", nil)
-	return err
+	size, err := object.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	// The client re-authenticates and resends the request when the token has expired, but the body has already
+	// been consumed. Setting the length fails that request instead of uploading an empty object, and the upload
+	// is retried from the start of the object.
+	headers := swift.Headers{"Content-Length": strconv.FormatInt(size, 10)}
+	for retries := s.cfg.MaxRetries; ; retries-- {
+		if _, err = object.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		expiredTokens := s.expiredTokens.Load()
+		_, err = s.conn.ObjectPut(s.cfg.ContainerName, objectKey, object, false, "", "", headers)
+		if err == nil || retries <= 0 || s.expiredTokens.Load() == expiredTokens {
+			return err
+		}
+	}
 }
 
 // List only objects from the store non-recursively
@@ -3,6 +3,8 @@ package openstack
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"testing"
 	"time"
@@ -109,3 +111,104 @@ func Test_Hedging(t *testing.T) {
 		})
 	}
 }
+
+func Test_PutObjectTokenExpiry(t *testing.T) {
+	var (
+		authentications int
+		uploaded        []string
+	)
+	defaultTransport = RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// fake auth, issuing a new token each time
+		if req.Header.Get("X-Auth-Key") == "passwd" {
+			authentications++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       http.NoBody,
+				Header: http.Header{
+					"X-Storage-Url": []string{"http://swift.example.com/v1/AUTH_test"},
+					"X-Auth-Token":  []string{fmt.Sprintf("token-%d", authentications)},
+				},
+			}, nil
+		}
+		// fake container creation
+		if req.Method == "PUT" && req.URL.Path == "/v1/AUTH_test/foo" {
+			return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody}, nil
+		}
+		var body []byte
+		if req.Body != nil {
+			var err error
+			if body, err = ioutil.ReadAll(req.Body); err != nil {
+				return nil, err
+			}
+		}
+		// the first token expires while uploading
+		if req.Header.Get("X-Auth-Token") == "token-1" {
+			return &http.Response{StatusCode: http.StatusUnauthorized, Body: http.NoBody}, nil
+		}
+		// like http.Transport, reject bodies shorter than the content length
+		if int64(len(body)) != req.ContentLength {
+			return nil, fmt.Errorf("http: ContentLength=%d with Body length %d", req.ContentLength, len(body))
+		}
+		uploaded = append(uploaded, string(body))
+		return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody}, nil
+	})
+
+	c, err := NewSwiftObjectClient(SwiftConfig{
+		Config: swift.Config{
+			MaxRetries:     1,
+			ContainerName:  "foo",
+			AuthVersion:    1,
+			Password:       "passwd",
+			ConnectTimeout: 10 * time.Second,
+			RequestTimeout: 10 * time.Second,
+		},
+	}, hedging.Config{})
+	require.NoError(t, err)
+
+	require.NoError(t, c.PutObject(context.Background(), "chunk", bytes.NewReader([]byte("data"))))
+	require.Equal(t, []string{"data"}, uploaded)
+}
+
+func TestSwiftConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  SwiftConfig
+		err  bool
+	}{
+		{
+			name: "default",
+		},
+		{
+			name: "internal endpoint",
+			cfg:  SwiftConfig{EndpointType: "internal"},
+		},
+		{
+			name: "unknown endpoint type",
+			cfg:  SwiftConfig{EndpointType: "private"},
+			err:  true,
+		},
+		{
+			name: "trust",
+			cfg:  SwiftConfig{Config: swift.Config{AuthVersion: 3}, TrustID: "trust"},
+		},
+		{
+			name: "trust with v2 auth",
+			cfg:  SwiftConfig{Config: swift.Config{AuthVersion: 2}, TrustID: "trust"},
+			err:  true,
+		},
+		{
+			name: "trust with project",
+			cfg:  SwiftConfig{Config: swift.Config{ProjectName: "project"}, TrustID: "trust"},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -97,6 +97,9 @@ type IndexQuery struct {
 
 	// Filters for querying
 	ValueEqual []byte
+	// ValueMatcher filters the rows by their value, which for label rows is the label value.
+	// Index clients not supporting it return all the rows, which are then filtered by the caller.
+	ValueMatcher *labels.Matcher
 
 	// If the result of this lookup is immutable or not (for caching).
 	Immutable bool
@@ -188,7 +188,7 @@ func (cfg PeriodConfig) CreateSchema() (BaseSchema, error) {
 		return newStoreSchema(buckets, v6Entries{}), nil
 	case "v9":
 		return newSeriesStoreSchema(buckets, v9Entries{}), nil
-	case "v10", "v11":
+	case "v10", "v11", "v12":
 		if cfg.RowShards == 0 {
 			return nil, fmt.Errorf("Must have row_shards > 0 (current: %d) for schema (%s)", cfg.RowShards, cfg.Schema)
 		}
@@ -426,12 +426,38 @@ func (cfg *PeriodicTableConfig) periodicTables(from, through model.Time, pCfg Pr
 
 // ChunkTableFor calculates the chunk table shard for a given point in time.
 func (cfg SchemaConfig) ChunkTableFor(t model.Time) (string, error) {
+	if p, ok := cfg.periodConfigFor(t); ok {
+		return p.ChunkTables.TableFor(t), nil
+	}
+	return "", fmt.Errorf("no chunk table found for time %v", t)
+}
+
+// ExternalKey returns the key of the chunk in the object store, using the chunk key layout of the period
+// the chunk starts in.
+func (cfg SchemaConfig) ExternalKey(c Chunk) string {
+	if c.ChecksumSet {
+		if p, ok := cfg.periodConfigFor(c.From); ok && p.prefixedChunkKeys() {
+			// This is the inverse of parsePrefixedExternalKey.
+			return fmt.Sprintf("%s/%x/%x:%x:%x", c.UserID, uint64(c.Fingerprint), int64(c.From), int64(c.Through), c.Checksum)
+		}
+	}
+	return c.ExternalKey()
+}
+
+func (cfg SchemaConfig) periodConfigFor(t model.Time) (PeriodConfig, bool) {
 	for i := range cfg.Configs {
 		if t >= cfg.Configs[i].From.Time && (i+1 == len(cfg.Configs) || t < cfg.Configs[i+1].From.Time) {
-			return cfg.Configs[i].ChunkTables.TableFor(t), nil
+			return cfg.Configs[i], true
 		}
 	}
-	return "", fmt.Errorf("no chunk table found for time %v", t)
+	return PeriodConfig{}, false
+}
+
+// prefixedChunkKeys returns whether the chunks of the period are stored under a tenant and
+// series prefix in the object store, which spreads the keys of a tenant over several prefixes
+// and keeps all the chunks of a series together.
+func (cfg PeriodConfig) prefixedChunkKeys() bool {
+	return cfg.Schema == "v12"
 }
 
 // TableFor calculates the table shard for a given point in time.
@@ -1,6 +1,7 @@
 package chunk
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -303,6 +304,50 @@ func TestChunkTableFor(t *testing.T) {
 	}
 }
 
+func TestSchemaConfig_ExternalKey(t *testing.T) {
+	schemaCfg := SchemaConfig{
+		Configs: []PeriodConfig{
+			{From: MustParseDayTime("1970-01-01"), Schema: "v11"},
+			{From: MustParseDayTime("2019-01-02"), Schema: "v12"},
+		},
+	}
+	v12From := schemaCfg.Configs[1].From.Time
+
+	for _, tc := range []struct {
+		name     string
+		chunk    Chunk
+		expected string
+	}{
+		{
+			name:     "v11 period",
+			chunk:    Chunk{UserID: "fake", Fingerprint: 2, From: 655200000, Through: 655200000, ChecksumSet: true, Checksum: 4165752645},
+			expected: "fake/2:270d8f00:270d8f00:f84c5745",
+		},
+		{
+			name:     "v12 period",
+			chunk:    Chunk{UserID: "fake", Fingerprint: 2, From: v12From, Through: v12From, ChecksumSet: true, Checksum: 4165752645},
+			expected: fmt.Sprintf("fake/2/%x:%x:f84c5745", int64(v12From), int64(v12From)),
+		},
+		{
+			name:     "v12 period without checksum",
+			chunk:    Chunk{UserID: "fake", Fingerprint: 2, From: v12From, Through: v12From},
+			expected: fmt.Sprintf("fake/2:%d:%d", int64(v12From), int64(v12From)),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			key := schemaCfg.ExternalKey(tc.chunk)
+			require.Equal(t, tc.expected, key)
+			if !tc.chunk.ChecksumSet {
+				return
+			}
+
+			parsed, err := ParseExternalKey("fake", key)
+			require.NoError(t, err)
+			require.Equal(t, tc.chunk, parsed)
+		})
+	}
+}
+
 func TestSchemaConfig_Validate(t *testing.T) {
 	t.Parallel()
 
@@ -66,6 +66,16 @@ var (
 		Name:      "chunk_store_deduped_chunks_total",
 		Help:      "Count of chunks which were not stored because they have already been stored by another replica.",
 	})
+	indexFallbacksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "chunk_store_index_fallbacks_total",
+		Help:      "Count of queries which found the index unavailable, by whether listing the chunks from the chunk store succeeded, failed or is disabled.",
+	}, []string{"status"})
+	indexFallbackChunksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "chunk_store_index_fallback_chunks_total",
+		Help:      "Count of chunks listed from the chunk store because the index was unavailable.",
+	})
 )
 
 // seriesStore implements Store
@@ -162,7 +172,7 @@ func (c *seriesStore) GetChunkRefs(ctx context.Context, userID string, from, thr
 	_, matchers = util.SplitFiltersAndMatchers(matchers)
 	seriesIDs, err := c.lookupSeriesByMetricNameMatchers(ctx, from, through, userID, metricName, matchers)
 	if err != nil {
-		return nil, nil, err
+		return c.indexFallback(ctx, userID, from, through, allMatchers, err)
 	}
 	level.Debug(log).Log("series-ids", len(seriesIDs))
 
@@ -170,7 +180,7 @@ func (c *seriesStore) GetChunkRefs(ctx context.Context, userID string, from, thr
 	chunkIDs, err := c.lookupChunksBySeries(ctx, from, through, userID, seriesIDs)
 	if err != nil {
 		level.Error(log).Log("msg", "lookupChunksBySeries", "err", err)
-		return nil, nil, err
+		return c.indexFallback(ctx, userID, from, through, allMatchers, err)
 	}
 	level.Debug(log).Log("chunk-ids", len(chunkIDs))
 
@@ -192,6 +202,251 @@ func (c *seriesStore) GetChunkRefs(ctx context.Context, userID string, from, thr
 	return [][]Chunk{chunks}, []*Fetcher{c.baseStore.fetcher}, nil
 }
 
+// indexFallback lists the chunks of the tenant from the chunk store when the index is unavailable, so that queries
+// degrade to a slow brute-force read instead of failing. The listed chunks are not filtered by the matchers, this is
+// left to the caller once the chunks are fetched, as Loki does for the matchers not used in the index.
+func (c *seriesStore) indexFallback(ctx context.Context, userID string, from, through model.Time, allMatchers []*labels.Matcher, indexErr error) ([][]Chunk, []*Fetcher, error) {
+	if !errors.Is(indexErr, ErrIndexUnavailable) {
+		return nil, nil, indexErr
+	}
+	lister, ok := c.chunks.(ChunkLister)
+	if !c.cfg.IndexFallback || !ok {
+		indexFallbacksTotal.WithLabelValues("disabled").Inc()
+		return nil, nil, indexErr
+	}
+
+	// The listed chunks can't be split by shard without their labels, so they all go to the first shard.
+	shard, _, err := astmapper.ShardFromMatchers(allMatchers)
+	if err != nil {
+		return nil, nil, err
+	}
+	if shard != nil && shard.Shard != 0 {
+		return [][]Chunk{}, []*Fetcher{}, nil
+	}
+
+	logger := util_log.WithContext(ctx, util_log.Logger)
+	level.Warn(logger).Log("msg", "index unavailable, listing the chunks from the chunk store", "user", userID, "from", from, "through", through, "err", indexErr)
+	chunks, err := lister.ListChunks(ctx, userID, from, through, c.cfg.IndexFallbackMaxObjects)
+	if err != nil {
+		indexFallbacksTotal.WithLabelValues("failure").Inc()
+		level.Error(logger).Log("msg", "failed to list the chunks from the chunk store", "user", userID, "err", err)
+		return nil, nil, errors.Wrapf(indexErr, "failed to list the chunks from the chunk store: %v", err)
+	}
+	indexFallbacksTotal.WithLabelValues("success").Inc()
+	indexFallbackChunksTotal.Add(float64(len(chunks)))
+
+	if len(chunks) == 0 {
+		return [][]Chunk{}, []*Fetcher{}, nil
+	}
+	return [][]Chunk{chunks}, []*Fetcher{c.baseStore.fetcher}, nil
+}
+
+// GetSeriesChunkRefs implements Store. Labels and chunk refs are rebuilt from the index entries only,
+// except for schemas older than v11 which don't index label names per series: those fall back
+// to reading the label names from one chunk per series.
+func (c *seriesStore) GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, allMatchers ...*labels.Matcher) ([]SeriesChunkRefs, error) {
+	log, ctx := spanlogger.New(ctx, "SeriesStore.GetSeriesChunkRefs")
+	defer log.Span.Finish()
+
+	metricName, matchers, shortcut, err := c.validateQuery(ctx, userID, &from, &through, allMatchers)
+	if err != nil {
+		return nil, err
+	} else if shortcut {
+		return nil, nil
+	}
+
+	_, matchers = util.SplitFiltersAndMatchers(matchers)
+	seriesIDs, err := c.lookupSeriesByMetricNameMatchers(ctx, from, through, userID, metricName, matchers)
+	if err != nil {
+		return nil, err
+	}
+	level.Debug(log).Log("series-ids", len(seriesIDs))
+	if len(seriesIDs) == 0 {
+		return nil, nil
+	}
+
+	chunksBySeries, err := c.lookupChunkRefsBySeries(ctx, from, through, userID, seriesIDs)
+	if err != nil {
+		level.Error(log).Log("msg", "lookupChunkRefsBySeries", "err", err)
+		return nil, err
+	}
+	labelsBySeries, err := c.lookupLabelsBySeries(ctx, from, through, userID, metricName, seriesIDs)
+	if err != nil {
+		level.Error(log).Log("msg", "lookupLabelsBySeries", "err", err)
+		return nil, err
+	}
+
+	result := make([]SeriesChunkRefs, 0, len(chunksBySeries))
+	for _, seriesID := range seriesIDs {
+		chunks := chunksBySeries[seriesID]
+		if len(chunks) == 0 {
+			continue
+		}
+		result = append(result, SeriesChunkRefs{
+			Labels: labels.New(labelsBySeries[seriesID]...),
+			Chunks: chunks,
+		})
+	}
+	level.Debug(log).Log("series-post-filtering", len(result))
+	return result, nil
+}
+
+// lookupChunkRefsBySeries returns the refs of the chunks overlapping the time range, grouped by series ID.
+func (c *seriesStore) lookupChunkRefsBySeries(ctx context.Context, from, through model.Time, userID string, seriesIDs []string) (map[string][]Chunk, error) {
+	var (
+		queries          = make([]IndexQuery, 0, len(seriesIDs))
+		seriesByHashKeys = make(map[string]string, len(seriesIDs))
+	)
+	for _, seriesID := range seriesIDs {
+		qs, err := c.schema.GetChunksForSeries(from, through, userID, []byte(seriesID))
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range qs {
+			seriesByHashKeys[q.HashValue] = seriesID
+		}
+		queries = append(queries, qs...)
+	}
+
+	entries, err := c.lookupEntriesByQueries(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkIDsBySeries := map[string][]IndexEntry{}
+	for _, entry := range entries {
+		seriesID := seriesByHashKeys[entry.HashValue]
+		chunkIDsBySeries[seriesID] = append(chunkIDsBySeries[seriesID], entry)
+	}
+
+	result := make(map[string][]Chunk, len(chunkIDsBySeries))
+	for seriesID, entries := range chunkIDsBySeries {
+		chunkIDs, err := c.parseIndexEntries(ctx, entries, nil)
+		if err != nil {
+			return nil, err
+		}
+		chunks, err := c.convertChunkIDsToChunks(ctx, userID, chunkIDs)
+		if err != nil {
+			return nil, err
+		}
+		result[seriesID] = filterChunksByTime(from, through, chunks)
+	}
+	return result, nil
+}
+
+// lookupLabelsBySeries rebuilds the labels of the given series from the label value rows of the index.
+func (c *seriesStore) lookupLabelsBySeries(ctx context.Context, from, through model.Time, userID, metricName string, seriesIDs []string) (map[string][]labels.Label, error) {
+	labelNames, err := c.lookupLabelNamesBySeries(ctx, from, through, userID, seriesIDs)
+	if err == ErrNotSupported {
+		labelNames, err = c.lookupLabelNamesByChunks(ctx, from, through, userID, seriesIDs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]labels.Label, len(seriesIDs))
+	for _, labelName := range labelNames {
+		if labelName == model.MetricNameLabel {
+			continue
+		}
+		values, err := c.lookupLabelValuesBySeries(ctx, from, through, userID, metricName, labelName, seriesIDs)
+		if err != nil {
+			return nil, err
+		}
+		for seriesID, value := range values {
+			result[seriesID] = append(result[seriesID], labels.Label{Name: labelName, Value: value})
+		}
+	}
+	return result, nil
+}
+
+// lookupLabelValuesBySeries returns the value of a label name for the given series having that label,
+// read from the label value rows of the index.
+func (c *seriesStore) lookupLabelValuesBySeries(ctx context.Context, from, through model.Time, userID, metricName, labelName string, seriesIDs []string) (map[string]string, error) {
+	result := make(map[string]string, len(seriesIDs))
+	if len(seriesIDs) == 0 {
+		return result, nil
+	}
+
+	wanted := make(map[string]struct{}, len(seriesIDs))
+	for _, seriesID := range seriesIDs {
+		wanted[seriesID] = struct{}{}
+	}
+
+	queries, err := c.schema.GetReadQueriesForMetricLabel(from, through, userID, metricName, labelName)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.lookupEntriesByQueries(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same series is indexed once per bucket, all with the same value.
+	for _, entry := range entries {
+		seriesID, labelValue, err := parseChunkTimeRangeValue(entry.RangeValue, entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := wanted[seriesID]; ok {
+			result[seriesID] = string(labelValue)
+		}
+	}
+	return result, nil
+}
+
+// LabelValuesForMetricName retrieves all label values for a single label name and metric name.
+// The label values of the series matching the matchers are read from the index only.
+func (c *seriesStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName, labelName string, matchers ...*labels.Matcher) ([]string, error) {
+	if len(matchers) == 0 {
+		return c.labelValuesForMetricName(ctx, userID, from, through, metricName, labelName)
+	}
+
+	log, ctx := spanlogger.New(ctx, "SeriesStore.LabelValuesForMetricName")
+	defer log.Span.Finish()
+
+	shortcut, err := c.validateQueryTimeRange(ctx, userID, &from, &through)
+	if err != nil {
+		return nil, err
+	} else if shortcut {
+		return nil, nil
+	}
+
+	filters, matchers := util.SplitFiltersAndMatchers(matchers)
+	seriesIDs, err := c.lookupSeriesByMetricNameMatchers(ctx, from, through, userID, metricName, matchers)
+	if err != nil {
+		return nil, err
+	}
+	level.Debug(log).Log("series-ids", len(seriesIDs))
+
+	// filters also match the series without their label, which are not in the index:
+	// apply them on the values of the series instead.
+	for _, filter := range filters {
+		values, err := c.lookupLabelValuesBySeries(ctx, from, through, userID, metricName, filter.Name, seriesIDs)
+		if err != nil {
+			return nil, err
+		}
+		filtered := seriesIDs[:0]
+		for _, seriesID := range seriesIDs {
+			if filter.Matches(values[seriesID]) {
+				filtered = append(filtered, seriesID)
+			}
+		}
+		seriesIDs = filtered
+	}
+	level.Debug(log).Log("series-post-filtering", len(seriesIDs))
+
+	values, err := c.lookupLabelValuesBySeries(ctx, from, through, userID, metricName, labelName, seriesIDs)
+	if err != nil {
+		return nil, err
+	}
+	var result UniqueStrings
+	for _, value := range values {
+		result.Add(value)
+	}
+	return result.Strings(), nil
+}
+
 // LabelNamesForMetricName retrieves all label names for a metric name.
 func (c *seriesStore) LabelNamesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string) ([]string, error) {
 	log, ctx := spanlogger.New(ctx, "SeriesStore.LabelNamesForMetricName")
@@ -346,7 +601,15 @@ func (c *seriesStore) lookupSeriesByMetricNameMatchers(ctx context.Context, from
 
 func (c *seriesStore) lookupSeriesByMetricNameMatcher(ctx context.Context, from, through model.Time, userID, metricName string, matcher *labels.Matcher, shard *astmapper.ShardAnnotation) ([]string, error) {
 	return c.lookupIdsByMetricNameMatcher(ctx, from, through, userID, metricName, matcher, func(queries []IndexQuery) []IndexQuery {
-		return c.schema.FilterReadQueries(queries, shard)
+		queries = c.schema.FilterReadQueries(queries, shard)
+		// label rows of the series store hold the label value, let the index skip the ones not matching
+		// instead of returning them all.
+		if matcher != nil && matcher.Type != labels.MatchEqual {
+			for i := range queries {
+				queries[i].ValueMatcher = matcher
+			}
+		}
+		return queries
 	})
 }
 
@@ -437,6 +700,21 @@ func (c *seriesStore) PutOne(ctx context.Context, from, through model.Time, chun
 		return nil
 	}
 
+	// Chunk keys contain a checksum of the chunk, so if the object already exists another replica
+	// has uploaded the very same chunk. The index is still written since it may not be there yet.
+	uploadChunk := writeChunk
+	if uploadChunk && c.cfg.ChunkUploadDeduplication {
+		if checker, ok := c.fetcher.storage.(ChunkExistsChecker); ok {
+			exists, err := checker.ChunkExists(ctx, chunk)
+			if err != nil {
+				level.Warn(log).Log("msg", "could not check if chunk exists, uploading it", "err", err)
+			} else if exists {
+				uploadChunk = false
+				dedupedChunksTotal.Inc()
+			}
+		}
+	}
+
 	chunks := []Chunk{chunk}
 
 	writeReqs, keysToCache, err := c.calculateIndexEntries(ctx, from, through, chunk)
@@ -446,7 +724,7 @@ func (c *seriesStore) PutOne(ctx context.Context, from, through model.Time, chun
 
 	if oic, ok := c.fetcher.storage.(ObjectAndIndexClient); ok {
 		chunks := chunks
-		if !writeChunk {
+		if !uploadChunk {
 			chunks = []Chunk{}
 		}
 		if err = oic.PutChunksAndIndex(ctx, chunks, writeReqs); err != nil {
@@ -454,7 +732,7 @@ func (c *seriesStore) PutOne(ctx context.Context, from, through model.Time, chun
 		}
 	} else {
 		// chunk not found, write it.
-		if writeChunk {
+		if uploadChunk {
 			err := c.fetcher.storage.PutChunks(ctx, chunks)
 			if err != nil {
 				return err
@@ -15,6 +15,7 @@ import (
 
 	"github.com/grafana/loki/pkg/tenant"
 
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
@@ -54,12 +55,13 @@ type cachingIndexClient struct {
 	chunk.IndexClient
 	cache               cache.Cache
 	validity            time.Duration
+	negativeValidity    time.Duration
 	limits              StoreLimits
 	logger              log.Logger
 	disableBroadQueries bool
 }
 
-func newCachingIndexClient(client chunk.IndexClient, c cache.Cache, validity time.Duration, limits StoreLimits, logger log.Logger, disableBroadQueries bool) chunk.IndexClient {
+func newCachingIndexClient(client chunk.IndexClient, c cache.Cache, validity, negativeValidity time.Duration, limits StoreLimits, logger log.Logger, disableBroadQueries bool) chunk.IndexClient {
 	if c == nil || cache.IsEmptyTieredCache(c) {
 		return client
 	}
@@ -68,6 +70,7 @@ func newCachingIndexClient(client chunk.IndexClient, c cache.Cache, validity tim
 		IndexClient:         client,
 		cache:               cache.NewSnappy(c, logger),
 		validity:            validity,
+		negativeValidity:    negativeValidity,
 		limits:              limits,
 		logger:              logger,
 		disableBroadQueries: disableBroadQueries,
@@ -189,6 +192,12 @@ func (s *cachingIndexClient) queryPages(ctx context.Context, queries []chunk.Ind
 				}
 			}
 
+			// The lookups finding nothing, e.g. while the index of a table is not available yet, are not cached
+			// for long, even when their query is immutable.
+			if cardinality == 0 && s.negativeValidity > 0 {
+				batch.Expiry = time.Now().Add(s.negativeValidity).UnixNano()
+			}
+
 			keys = append(keys, key)
 			batches = append(batches, batch)
 			if cardinalityErr != nil {
@@ -233,6 +242,10 @@ func (s *cachingIndexClient) doQueries(ctx context.Context, queries []chunk.Inde
 			ret += sep + yoloString(q.ValueEqual)
 		}
 
+		if q.ValueMatcher != nil {
+			ret += sep + q.ValueMatcher.String()
+		}
+
 		return ret
 	})
 }
@@ -358,5 +371,6 @@ func (s *cachingIndexClient) cacheFetch(ctx context.Context, keys []string) (bat
 	}
 
 	level.Debug(log).Log("hits", len(batches), "misses", len(misses))
+	stats.FromContext(ctx).AddIndexCacheEntries(int64(len(keys)), int64(len(batches)))
 	return batches, missed
 }
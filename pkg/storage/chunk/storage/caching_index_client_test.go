@@ -45,7 +45,7 @@ func TestCachingStorageClientBasic(t *testing.T) {
 	require.NoError(t, err)
 	logger := log.NewNopLogger()
 	cache := cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger)
-	client := newCachingIndexClient(store, cache, 1*time.Second, limits, logger, false)
+	client := newCachingIndexClient(store, cache, 1*time.Second, 0, limits, logger, false)
 	queries := []chunk.IndexQuery{{
 		TableName: "table",
 		HashValue: "baz",
@@ -77,7 +77,7 @@ func TestTempCachingStorageClient(t *testing.T) {
 	require.NoError(t, err)
 	logger := log.NewNopLogger()
 	cache := cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger)
-	client := newCachingIndexClient(store, cache, 100*time.Millisecond, limits, logger, false)
+	client := newCachingIndexClient(store, cache, 100*time.Millisecond, 0, limits, logger, false)
 	queries := []chunk.IndexQuery{
 		{TableName: "table", HashValue: "foo"},
 		{TableName: "table", HashValue: "bar"},
@@ -136,7 +136,7 @@ func TestPermCachingStorageClient(t *testing.T) {
 	require.NoError(t, err)
 	logger := log.NewNopLogger()
 	cache := cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger)
-	client := newCachingIndexClient(store, cache, 100*time.Millisecond, limits, logger, false)
+	client := newCachingIndexClient(store, cache, 100*time.Millisecond, 0, limits, logger, false)
 	queries := []chunk.IndexQuery{
 		{TableName: "table", HashValue: "foo", Immutable: true},
 		{TableName: "table", HashValue: "bar", Immutable: true},
@@ -188,7 +188,7 @@ func TestCachingStorageClientEmptyResponse(t *testing.T) {
 	require.NoError(t, err)
 	logger := log.NewNopLogger()
 	cache := cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger)
-	client := newCachingIndexClient(store, cache, 1*time.Second, limits, logger, false)
+	client := newCachingIndexClient(store, cache, 1*time.Second, 0, limits, logger, false)
 	queries := []chunk.IndexQuery{{TableName: "table", HashValue: "foo"}}
 	err = client.QueryPages(ctx, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
 		assert.False(t, batch.Iterator().Next())
@@ -206,6 +206,49 @@ func TestCachingStorageClientEmptyResponse(t *testing.T) {
 	assert.EqualValues(t, 1, len(store.queries))
 }
 
+func TestCachingStorageClientNegativeValidity(t *testing.T) {
+	store := &mockStore{}
+	limits, err := defaultLimits()
+	require.NoError(t, err)
+	logger := log.NewNopLogger()
+	cache := cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger)
+	client := newCachingIndexClient(store, cache, 1*time.Second, 100*time.Millisecond, limits, logger, false)
+	// The empty lookups of immutable queries are not cached forever.
+	queries := []chunk.IndexQuery{{TableName: "table", HashValue: "foo", Immutable: true}}
+	for i := 0; i < 2; i++ {
+		err = client.QueryPages(ctx, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+			assert.False(t, batch.Iterator().Next())
+			return true
+		})
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, len(store.queries))
+
+	// Once the empty lookup expired, the store is queried again.
+	time.Sleep(150 * time.Millisecond)
+	store.results = ReadBatch{
+		Entries: []Entry{{
+			Column: []byte("foo"),
+			Value:  []byte("bar"),
+		}},
+	}
+	err = client.QueryPages(ctx, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+		assert.True(t, batch.Iterator().Next())
+		return true
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, len(store.queries))
+
+	// The lookups finding entries are still cached with the validity of their query.
+	time.Sleep(150 * time.Millisecond)
+	err = client.QueryPages(ctx, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+		assert.True(t, batch.Iterator().Next())
+		return true
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, len(store.queries))
+}
+
 func TestCachingStorageClientCollision(t *testing.T) {
 	// These two queries should result in one query to the cache & index, but
 	// two results, as we cache entire rows.
@@ -227,7 +270,7 @@ func TestCachingStorageClientCollision(t *testing.T) {
 	require.NoError(t, err)
 	logger := log.NewNopLogger()
 	cache := cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger)
-	client := newCachingIndexClient(store, cache, 1*time.Second, limits, logger, false)
+	client := newCachingIndexClient(store, cache, 1*time.Second, 0, limits, logger, false)
 	queries := []chunk.IndexQuery{
 		{TableName: "table", HashValue: "foo", RangeValuePrefix: []byte("bar")},
 		{TableName: "table", HashValue: "foo", RangeValuePrefix: []byte("baz")},
@@ -408,7 +451,7 @@ func TestCachingStorageClientStoreQueries(t *testing.T) {
 				cache := &mockCache{
 					Cache: cache.NewFifoCache("test", cache.FifoCacheConfig{MaxSizeItems: 10, Validity: 10 * time.Second}, nil, logger),
 				}
-				client := newCachingIndexClient(store, cache, 1*time.Second, limits, logger, disableBroadQueries)
+				client := newCachingIndexClient(store, cache, 1*time.Second, 0, limits, logger, disableBroadQueries)
 				var callbackQueries []chunk.IndexQuery
 
 				err = client.QueryPages(ctx, tc.queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
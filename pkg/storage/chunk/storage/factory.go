@@ -90,7 +90,8 @@ type Config struct {
 	FSConfig               local.FSConfig          `yaml:"filesystem"`
 	Swift                  openstack.SwiftConfig   `yaml:"swift"`
 
-	IndexCacheValidity time.Duration `yaml:"index_cache_validity"`
+	IndexCacheValidity         time.Duration `yaml:"index_cache_validity"`
+	IndexCacheNegativeValidity time.Duration `yaml:"index_cache_negative_validity"`
 
 	IndexQueriesCacheConfig  cache.Config `yaml:"index_queries_cache_config"`
 	DisableBroadIndexQueries bool         `yaml:"disable_broad_index_queries"`
@@ -98,6 +99,8 @@ type Config struct {
 	GrpcConfig grpc.Config `yaml:"grpc_store"`
 
 	Hedging hedging.Config `yaml:"hedging"`
+
+	ChunkIntegrity objectclient.IntegrityConfig `yaml:"chunk_integrity"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
@@ -112,10 +115,12 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Swift.RegisterFlags(f)
 	cfg.GrpcConfig.RegisterFlags(f)
 	cfg.Hedging.RegisterFlagsWithPrefix("store.", f)
+	cfg.ChunkIntegrity.RegisterFlagsWithPrefix("store.chunk-integrity.", f)
 
 	f.StringVar(&cfg.Engine, "store.engine", "chunks", "The storage engine to use: chunks or blocks.")
 	cfg.IndexQueriesCacheConfig.RegisterFlagsWithPrefix("store.index-cache-read.", "Cache config for index entry reading.", f)
 	f.DurationVar(&cfg.IndexCacheValidity, "store.index-cache-validity", 5*time.Minute, "Cache validity for active index entries. Should be no higher than -ingester.max-chunk-idle.")
+	f.DurationVar(&cfg.IndexCacheNegativeValidity, "store.index-cache-negative-validity", 0, "Cache validity for the index lookups finding no entries, including the ones of immutable tables. Use a short validity to avoid hammering the index store with lookups for missing entries, without caching an index unavailable at the time for long. 0 to cache them like the other lookups.")
 	f.BoolVar(&cfg.DisableBroadIndexQueries, "store.disable-broad-index-queries", false, "Disable broad index queries which results in reduced cache usage and faster query performance at the expense of somewhat higher QPS on the index store.")
 }
 
@@ -136,12 +141,18 @@ func (cfg *Config) Validate() error {
 	if err := cfg.IndexQueriesCacheConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid Index Queries Cache config")
 	}
+	if err := cfg.ChunkIntegrity.Validate(); err != nil {
+		return errors.Wrap(err, "invalid chunk integrity config")
+	}
 	if err := cfg.AzureStorageConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid Azure Storage config")
 	}
 	if err := cfg.AWSStorageConfig.Validate(); err != nil {
 		return errors.Wrap(err, "invalid AWS Storage config")
 	}
+	if err := cfg.Hedging.Validate(); err != nil {
+		return errors.Wrap(err, "invalid hedging config")
+	}
 	return nil
 }
 
@@ -200,7 +211,7 @@ func NewStore(
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating index client")
 		}
-		index = newCachingIndexClient(index, indexReadCache, cfg.IndexCacheValidity, limits, logger, cfg.DisableBroadIndexQueries)
+		index = newCachingIndexClient(index, indexReadCache, cfg.IndexCacheValidity, cfg.IndexCacheNegativeValidity, limits, logger, cfg.DisableBroadIndexQueries)
 
 		objectStoreType := s.ObjectType
 		if objectStoreType == "" {
@@ -271,7 +282,11 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, regis
 	case StorageTypeInMemory:
 		return chunk.NewMockStorage(), nil
 	case StorageTypeAWS, StorageTypeS3:
-		return newChunkClientFromStore(aws.NewS3ObjectClient(cfg.AWSStorageConfig.S3Config, cfg.Hedging))
+		store, err := aws.NewS3ObjectClient(cfg.AWSStorageConfig.S3Config, cfg.Hedging)
+		if err != nil {
+			return nil, err
+		}
+		return objectclient.NewClientWithIntegrity(store, nil, schemaCfg, cfg.ChunkIntegrity), nil
 	case StorageTypeAWSDynamo:
 		if cfg.AWSStorageConfig.DynamoDB.URL == nil {
 			return nil, fmt.Errorf("Must set -dynamodb.url in aws mode")
@@ -282,15 +297,27 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, regis
 		}
 		return aws.NewDynamoDBChunkClient(cfg.AWSStorageConfig.DynamoDBConfig, schemaCfg, registerer)
 	case StorageTypeAzure:
-		return newChunkClientFromStore(azure.NewBlobStorage(&cfg.AzureStorageConfig, cfg.Hedging))
+		store, err := azure.NewBlobStorage(&cfg.AzureStorageConfig, cfg.Hedging)
+		if err != nil {
+			return nil, err
+		}
+		return objectclient.NewClientWithIntegrity(store, nil, schemaCfg, cfg.ChunkIntegrity), nil
 	case StorageTypeGCP:
 		return gcp.NewBigtableObjectClient(context.Background(), cfg.GCPStorageConfig, schemaCfg)
 	case StorageTypeGCPColumnKey, StorageTypeBigTable, StorageTypeBigTableHashed:
 		return gcp.NewBigtableObjectClient(context.Background(), cfg.GCPStorageConfig, schemaCfg)
 	case StorageTypeGCS:
-		return newChunkClientFromStore(gcp.NewGCSObjectClient(context.Background(), cfg.GCSConfig, cfg.Hedging))
+		store, err := gcp.NewGCSObjectClient(context.Background(), cfg.GCSConfig, cfg.Hedging)
+		if err != nil {
+			return nil, err
+		}
+		return objectclient.NewClientWithIntegrity(store, nil, schemaCfg, cfg.ChunkIntegrity), nil
 	case StorageTypeSwift:
-		return newChunkClientFromStore(openstack.NewSwiftObjectClient(cfg.Swift, cfg.Hedging))
+		store, err := openstack.NewSwiftObjectClient(cfg.Swift, cfg.Hedging)
+		if err != nil {
+			return nil, err
+		}
+		return objectclient.NewClientWithIntegrity(store, nil, schemaCfg, cfg.ChunkIntegrity), nil
 	case StorageTypeCassandra:
 		return cassandra.NewObjectClient(cfg.CassandraStorageConfig, schemaCfg, registerer)
 	case StorageTypeFileSystem:
@@ -298,7 +325,7 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, regis
 		if err != nil {
 			return nil, err
 		}
-		return objectclient.NewClient(store, objectclient.Base64Encoder), nil
+		return objectclient.NewClientWithIntegrity(store, objectclient.Base64Encoder, schemaCfg, cfg.ChunkIntegrity), nil
 	case StorageTypeGrpc:
 		return grpc.NewStorageClient(cfg.GrpcConfig, schemaCfg)
 	default:
@@ -306,13 +333,6 @@ func NewChunkClient(name string, cfg Config, schemaCfg chunk.SchemaConfig, regis
 	}
 }
 
-func newChunkClientFromStore(store chunk.ObjectClient, err error) (chunk.Client, error) {
-	if err != nil {
-		return nil, err
-	}
-	return objectclient.NewClient(store, nil), nil
-}
-
 // NewTableClient makes a new table client based on the configuration.
 func NewTableClient(name string, cfg Config, registerer prometheus.Registerer) (chunk.TableClient, error) {
 	if indexClientFactory, ok := customIndexStores[name]; ok {
@@ -355,6 +375,41 @@ func NewBucketClient(storageConfig Config) (chunk.BucketClient, error) {
 	return nil, nil
 }
 
+// ObjectStoreConfig overrides the object store clients of a Config, to keep some objects in other buckets or with other
+// credentials than the chunks, e.g. the boltdb-shipper index on faster storage.
+type ObjectStoreConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	AWSStorageConfig   aws.S3Config            `yaml:"aws"`
+	AzureStorageConfig azure.BlobStorageConfig `yaml:"azure"`
+	GCSConfig          gcp.GCSConfig           `yaml:"gcs"`
+	Swift              openstack.SwiftConfig   `yaml:"swift"`
+	FSConfig           local.FSConfig          `yaml:"filesystem"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to configure this flag set with the given prefix.
+func (cfg *ObjectStoreConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Use the object store clients of this block instead of the ones of the storage config.")
+	cfg.AWSStorageConfig.RegisterFlagsWithPrefix(prefix, f)
+	cfg.AzureStorageConfig.RegisterFlagsWithPrefix(prefix, f)
+	cfg.GCSConfig.RegisterFlagsWithPrefix(prefix, f)
+	cfg.Swift.RegisterFlagsWithPrefix(prefix, f)
+	cfg.FSConfig.RegisterFlagsWithPrefix(prefix, f)
+}
+
+// Apply returns the storage config with its object store clients replaced by the ones of cfg, if enabled.
+func (cfg ObjectStoreConfig) Apply(storageCfg Config) Config {
+	if !cfg.Enabled {
+		return storageCfg
+	}
+	storageCfg.AWSStorageConfig.S3Config = cfg.AWSStorageConfig
+	storageCfg.AzureStorageConfig = cfg.AzureStorageConfig
+	storageCfg.GCSConfig = cfg.GCSConfig
+	storageCfg.Swift = cfg.Swift
+	storageCfg.FSConfig = cfg.FSConfig
+	return storageCfg
+}
+
 // NewObjectClient makes a new StorageClient of the desired types.
 func NewObjectClient(name string, cfg Config) (chunk.ObjectClient, error) {
 	switch name {
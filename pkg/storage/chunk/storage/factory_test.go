@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -200,3 +203,26 @@ func TestCassandraInMultipleSchemas(t *testing.T) {
 func unregisterAllCustomIndexStores() {
 	customIndexStores = map[string]indexStoreFactories{}
 }
+
+func TestObjectStoreConfig_Apply(t *testing.T) {
+	chunksDir, indexDir := t.TempDir(), t.TempDir()
+	var cfg Config
+	flagext.DefaultValues(&cfg)
+	cfg.FSConfig.Directory = chunksDir
+	cfg.GCSConfig.BucketName = "chunks"
+
+	storeCfg := ObjectStoreConfig{FSConfig: local.FSConfig{Directory: indexDir}}
+	require.Equal(t, cfg, storeCfg.Apply(cfg), "a disabled config overrides nothing")
+
+	storeCfg.Enabled = true
+	applied := storeCfg.Apply(cfg)
+	require.Equal(t, indexDir, applied.FSConfig.Directory)
+	require.Empty(t, applied.GCSConfig.BucketName)
+	require.Equal(t, chunksDir, cfg.FSConfig.Directory)
+
+	client, err := NewObjectClient(StorageTypeFileSystem, applied)
+	require.NoError(t, err)
+	require.NoError(t, client.PutObject(context.Background(), "index/table/file", strings.NewReader("index")))
+	_, err = os.Stat(filepath.Join(indexDir, "index/table/file"))
+	require.NoError(t, err)
+}
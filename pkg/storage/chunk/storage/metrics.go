@@ -105,6 +105,13 @@ func (c metricsChunkClient) GetChunks(ctx context.Context, chunks []chunk.Chunk)
 	return chks, nil
 }
 
+func (c metricsChunkClient) ChunkExists(ctx context.Context, chk chunk.Chunk) (bool, error) {
+	if checker, ok := c.client.(chunk.ChunkExistsChecker); ok {
+		return checker.ChunkExists(ctx, chk)
+	}
+	return false, nil
+}
+
 func (c metricsChunkClient) DeleteChunk(ctx context.Context, userID, chunkID string) error {
 	return c.client.DeleteChunk(ctx, userID, chunkID)
 }
@@ -5,11 +5,21 @@ import (
 	"errors"
 	"io"
 	"time"
+
+	"github.com/prometheus/common/model"
 )
 
 var (
 	// ErrMethodNotImplemented when any of the storage clients do not implement a method
 	ErrMethodNotImplemented = errors.New("method is not implemented")
+
+	// ErrIndexUnavailable is returned by index clients when the index of a table is missing or corrupt,
+	// instead of returning partial or empty results.
+	ErrIndexUnavailable = errors.New("index unavailable")
+
+	// ErrObjectModified is returned by ConditionalObjectWriters when the object was created or modified
+	// by someone else since it was read.
+	ErrObjectModified = errors.New("object was modified concurrently")
 )
 
 // IndexClient is a client for the storage of the index (e.g. DynamoDB or Bigtable).
@@ -34,6 +44,26 @@ type Client interface {
 	IsChunkNotFoundErr(err error) bool
 }
 
+// ChunkExistsChecker is implemented by Clients which can check whether a chunk
+// has already been stored without fetching it.
+type ChunkExistsChecker interface {
+	ChunkExists(ctx context.Context, c Chunk) (bool, error)
+}
+
+// ChunkSizer is implemented by Clients which can get the size of a stored
+// chunk without fetching it.
+type ChunkSizer interface {
+	ChunkSize(ctx context.Context, c Chunk) (int64, error)
+}
+
+// ChunkLister is implemented by Clients which can list the chunks of a tenant
+// without the index, by listing the chunk objects.
+type ChunkLister interface {
+	// ListChunks returns the chunks of userID overlapping from and through, failing once more than
+	// maxObjects objects have been listed.
+	ListChunks(ctx context.Context, userID string, from, through model.Time, maxObjects int) ([]Chunk, error)
+}
+
 // ObjectAndIndexClient allows optimisations where the same client handles both
 type ObjectAndIndexClient interface {
 	PutChunksAndIndex(ctx context.Context, chunks []Chunk, index WriteBatch) error
@@ -79,6 +109,38 @@ type ObjectClient interface {
 	Stop()
 }
 
+// ObjectExistsChecker is implemented by ObjectClients which can check whether an
+// object exists without downloading it, i.e. with a HEAD request.
+type ObjectExistsChecker interface {
+	ObjectExists(ctx context.Context, objectKey string) (bool, error)
+}
+
+// ObjectPagesLister is implemented by ObjectClients which can list the objects
+// page by page, without holding all of them in memory.
+type ObjectPagesLister interface {
+	// ListPages calls f with the pages of the objects with given prefix, including the
+	// ones nested in "subdirectories". It stops at the first error returned by f.
+	ListPages(ctx context.Context, prefix string, f func([]StorageObject) error) error
+}
+
+// ObjectSizer is implemented by ObjectClients which can get the size of an
+// object without downloading it, i.e. with a HEAD request.
+type ObjectSizer interface {
+	ObjectSize(ctx context.Context, objectKey string) (int64, error)
+}
+
+// ConditionalObjectWriter is implemented by ObjectClients which can write an object only if it was not
+// modified since it was read, i.e. with If-Match and If-None-Match preconditions, which lets multiple
+// writers coordinate over an object without overwriting each other.
+type ConditionalObjectWriter interface {
+	// GetObjectVersion returns the object along with its current version, i.e. its ETag or generation.
+	GetObjectVersion(ctx context.Context, objectKey string) (io.ReadCloser, string, error)
+	// PutObjectIfVersion writes the object only if its current version is the given one, or only if it
+	// does not exist when the version is empty, and returns its new version.
+	// It returns ErrObjectModified when the precondition is not met.
+	PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error)
+}
+
 // StorageObject represents an object being stored in an Object Store
 type StorageObject struct {
 	Key        string
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +28,16 @@ const (
 	writeLabel = "write"
 
 	bucketRetentionEnforcementInterval = 12 * time.Hour
+
+	// TableValidationDisabled disables the validation of the existing tables against the schema config.
+	TableValidationDisabled = "disabled"
+	// TableValidationWarn logs the existing tables not matching the schema config.
+	TableValidationWarn = "warn"
+	// TableValidationFail fails the start of the table manager when existing tables don't match the schema config.
+	TableValidationFail = "fail"
+
+	// maxReportedDriftedTables is the maximum number of tables not matching the schema config which are logged.
+	maxReportedDriftedTables = 10
 )
 
 type tableManagerMetrics struct {
@@ -98,6 +109,9 @@ type TableManagerConfig struct {
 	// duration a table will be created before it is needed.
 	CreationGracePeriod time.Duration `yaml:"creation_grace_period"`
 
+	// What to do when existing tables don't match the schema config on startup.
+	TableValidation string `yaml:"table_validation"`
+
 	IndexTables ProvisionConfig `yaml:"index_tables_provisioning"`
 	ChunkTables ProvisionConfig `yaml:"chunk_tables_provisioning"`
 }
@@ -133,6 +147,12 @@ func (cfg *TableManagerConfig) Validate() error {
 		cfg.RetentionPeriod = time.Duration(cfg.RetentionPeriodModel)
 	}
 
+	switch cfg.TableValidation {
+	case "", TableValidationDisabled, TableValidationWarn, TableValidationFail:
+	default:
+		return fmt.Errorf("invalid table validation %q, must be %s, %s or %s", cfg.TableValidation, TableValidationDisabled, TableValidationWarn, TableValidationFail)
+	}
+
 	return nil
 }
 
@@ -143,6 +163,7 @@ func (cfg *TableManagerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&cfg.RetentionPeriodModel, "table-manager.retention-period", "Tables older than this retention period are deleted. Must be either 0 (disabled) or a multiple of 24h. When enabled, be aware this setting is destructive to data!")
 	f.DurationVar(&cfg.PollInterval, "table-manager.poll-interval", 2*time.Minute, "How frequently to poll backend to learn our capacity.")
 	f.DurationVar(&cfg.CreationGracePeriod, "table-manager.periodic-table.grace-period", 10*time.Minute, "Periodic tables grace period (duration which table will be created/deleted before/after it's needed).")
+	f.StringVar(&cfg.TableValidation, "table-manager.table-validation", TableValidationWarn, "What to do on startup when existing periodic tables don't match the prefix and period of any schema config, e.g. after changing the period of an existing schema config: log them (warn), fail the start (fail) or skip the validation (disabled).")
 
 	cfg.IndexTables.RegisterFlags("table-manager.index-table", f)
 	cfg.ChunkTables.RegisterFlags("table-manager.chunk-table", f)
@@ -191,6 +212,9 @@ func NewTableManager(cfg TableManagerConfig, schemaCfg SchemaConfig, maxChunkAge
 
 // Start the TableManager
 func (m *TableManager) starting(ctx context.Context) error {
+	if err := m.validateTables(ctx); err != nil {
+		return err
+	}
 	if m.bucketClient != nil && m.cfg.RetentionPeriod != 0 && m.cfg.RetentionDeletesEnabled {
 		m.bucketRetentionLoop = services.NewTimerService(bucketRetentionEnforcementInterval, nil, m.bucketRetentionIteration, nil)
 		return services.StartAndAwaitRunning(ctx, m.bucketRetentionLoop)
@@ -412,6 +436,83 @@ func (m *TableManager) calculateExpectedTables() []TableDesc {
 	return result
 }
 
+// validateTables checks that the existing periodic tables match the schema config, a table matches when its
+// name has the prefix of a periodic table config and its period overlaps the time range of that config.
+// Changing the prefix or the period of an existing schema config makes the existing tables unreachable, and
+// deleted once retention is enabled, instead of adding a new schema config.
+func (m *TableManager) validateTables(ctx context.Context) error {
+	if m.cfg.TableValidation == "" || m.cfg.TableValidation == TableValidationDisabled {
+		return nil
+	}
+
+	tables, err := m.client.ListTables(ctx)
+	if err != nil {
+		if m.cfg.TableValidation == TableValidationFail {
+			return fmt.Errorf("listing tables to validate them: %w", err)
+		}
+		level.Warn(util_log.Logger).Log("msg", "failed to list tables to validate them against the schema config", "err", err)
+		return nil
+	}
+
+	drifted := driftedTables(m.schemaCfg, tables, mtime.Now().Add(m.cfg.CreationGracePeriod))
+	if len(drifted) == 0 {
+		return nil
+	}
+	sort.Strings(drifted)
+	examples := drifted
+	if len(examples) > maxReportedDriftedTables {
+		examples = examples[:maxReportedDriftedTables]
+	}
+	if m.cfg.TableValidation == TableValidationFail {
+		return fmt.Errorf("%d existing tables don't match the schema config, add a new schema config instead of changing the prefix or period of an existing one: %s", len(drifted), strings.Join(examples, ", "))
+	}
+	level.Warn(util_log.Logger).Log("msg", "existing tables don't match the schema config, add a new schema config instead of changing the prefix or period of an existing one", "count", len(drifted), "tables", strings.Join(examples, ", "))
+	return nil
+}
+
+// driftedTables returns the tables which don't belong to any config: the tables without the prefix of any periodic
+// table config, such as the tables of a prefix changed in place, and the tables with the prefix of a periodic table
+// config outside of the time range of every config with that prefix, such as the tables of a period changed in place.
+func driftedTables(schemaCfg SchemaConfig, tables []string, through time.Time) []string {
+	var drifted []string
+	for _, table := range tables {
+		matched := false
+		for i, cfg := range schemaCfg.Configs {
+			var end time.Time
+			if i+1 < len(schemaCfg.Configs) {
+				end = schemaCfg.Configs[i+1].From.Time.Time()
+			} else {
+				end = through
+			}
+			for _, tableCfg := range []PeriodicTableConfig{cfg.IndexTables, cfg.ChunkTables} {
+				if tableCfg.Prefix == "" {
+					continue
+				}
+				// The tables of the configs without period are named after their prefix.
+				if tableCfg.Period == 0 {
+					matched = matched || table == tableCfg.Prefix
+					continue
+				}
+				if !strings.HasPrefix(table, tableCfg.Prefix) {
+					continue
+				}
+				n, err := strconv.ParseInt(strings.TrimPrefix(table, tableCfg.Prefix), 10, 64)
+				if err != nil {
+					continue
+				}
+				start := time.Unix(n*int64(tableCfg.Period/time.Second), 0)
+				if start.Add(tableCfg.Period).After(cfg.From.Time.Time()) && start.Before(end) {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			drifted = append(drifted, table)
+		}
+	}
+	return drifted
+}
+
 // partitionTables works out tables that need to be created vs tables that need to be updated
 func (m *TableManager) partitionTables(ctx context.Context, descriptions []TableDesc) ([]TableDesc, []TableDesc, []TableDesc, error) {
 	tables, err := m.client.ListTables(ctx)
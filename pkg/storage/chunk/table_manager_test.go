@@ -2,6 +2,7 @@ package chunk
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -768,3 +769,88 @@ func TestTableManagerRetentionOnly(t *testing.T) {
 	_, err = NewTableManager(tbmConfig, cfg, maxChunkAge, client, nil, nil, nil)
 	require.Error(t, err)
 }
+
+func TestTableManagerValidateTables(t *testing.T) {
+	day := 24 * time.Hour
+	from := time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)
+	now := from.Add(30*day + time.Hour)
+	weekly := func(t time.Time) string { return fmt.Sprintf("index_%d", t.Unix()/int64(tablePeriod/time.Second)) }
+	daily := func(t time.Time) string { return fmt.Sprintf("index_%d", t.Unix()/int64(day/time.Second)) }
+
+	weeklyCfg := PeriodConfig{
+		From:        DayTime{model.TimeFromUnix(from.Unix())},
+		IndexTables: PeriodicTableConfig{Prefix: "index_", Period: tablePeriod},
+	}
+	dailyCfg := PeriodConfig{
+		From:        DayTime{model.TimeFromUnix(from.Unix())},
+		IndexTables: PeriodicTableConfig{Prefix: "index_", Period: day},
+	}
+	renamedCfg := weeklyCfg
+	renamedCfg.IndexTables.Prefix = "loki_index_"
+	staticCfg := PeriodConfig{
+		From:        DayTime{model.TimeFromUnix(from.Add(2 * tablePeriod).Unix())},
+		IndexTables: PeriodicTableConfig{Prefix: "static_index"},
+	}
+	newDailyCfg := dailyCfg
+	newDailyCfg.From = DayTime{model.TimeFromUnix(from.Add(3 * tablePeriod).Unix())}
+
+	for _, tc := range []struct {
+		name     string
+		configs  []PeriodConfig
+		tables   []string
+		expected []string
+	}{
+		{
+			name:    "matching tables",
+			configs: []PeriodConfig{weeklyCfg, staticCfg},
+			tables:  []string{weekly(from), weekly(from.Add(tablePeriod)), "static_index"},
+		},
+		{
+			name:     "prefix changed without a new schema config",
+			configs:  []PeriodConfig{renamedCfg},
+			tables:   []string{weekly(from), "loki_" + weekly(from), "other_table"},
+			expected: []string{weekly(from), "other_table"},
+		},
+		{
+			name:     "tables with the prefix of a config but not its name",
+			configs:  []PeriodConfig{weeklyCfg},
+			tables:   []string{weekly(from), "index_foo"},
+			expected: []string{"index_foo"},
+		},
+		{
+			name:     "period changed without a new schema config",
+			configs:  []PeriodConfig{dailyCfg},
+			tables:   []string{weekly(from), weekly(from.Add(tablePeriod)), daily(now)},
+			expected: []string{weekly(from), weekly(from.Add(tablePeriod))},
+		},
+		{
+			name:    "period changed with a new schema config",
+			configs: []PeriodConfig{weeklyCfg, newDailyCfg},
+			tables:  []string{weekly(from), weekly(from.Add(2 * tablePeriod)), daily(from.Add(3 * tablePeriod)), daily(now)},
+		},
+		{
+			name:     "tables in the future",
+			configs:  []PeriodConfig{weeklyCfg},
+			tables:   []string{weekly(from), daily(from)},
+			expected: []string{daily(from)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			drifted := driftedTables(SchemaConfig{Configs: tc.configs}, tc.tables, now)
+			require.Equal(t, tc.expected, drifted)
+		})
+	}
+
+	client := newMockTableClient()
+	client.tables[weekly(from)] = TableDesc{Name: weekly(from)}
+	for mode, fails := range map[string]bool{TableValidationDisabled: false, TableValidationWarn: false, TableValidationFail: true} {
+		tableManager, err := NewTableManager(TableManagerConfig{TableValidation: mode}, SchemaConfig{Configs: []PeriodConfig{dailyCfg}}, maxChunkAge, client, nil, nil, nil)
+		require.NoError(t, err)
+		err = tableManager.validateTables(context.Background())
+		if fails {
+			require.Error(t, err, mode)
+		} else {
+			require.NoError(t, err, mode)
+		}
+	}
+}
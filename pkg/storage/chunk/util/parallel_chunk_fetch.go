@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	otlog "github.com/opentracing/opentracing-go/log"
@@ -13,6 +14,10 @@ import (
 
 const maxParallel = 1000
 
+// ErrSkipChunk is returned by the function fetching a chunk to leave it out of the fetched chunks without failing
+// the fetch.
+var ErrSkipChunk = errors.New("skip chunk")
+
 var decodeContextPool = sync.Pool{
 	New: func() interface{} {
 		return chunk.NewDecodeContext()
@@ -35,15 +40,23 @@ func GetParallelChunks(ctx context.Context, chunks []chunk.Chunk, f func(context
 	}()
 
 	processedChunks := make(chan chunk.Chunk)
-	errors := make(chan error)
+	errs := make(chan error)
 
 	for i := 0; i < min(maxParallel, len(chunks)); i++ {
 		go func() {
 			decodeContext := decodeContextPool.Get().(*chunk.DecodeContext)
 			for c := range queuedChunks {
+				// Keep draining the queue without fetching once the query is cancelled.
+				if err := ctx.Err(); err != nil {
+					chunk.CancelledOperations.WithLabelValues(chunk.CancelledChunkFetch).Inc()
+					errs <- err
+					continue
+				}
 				c, err := f(ctx, decodeContext, c)
-				if err != nil {
-					errors <- err
+				if err == ErrSkipChunk {
+					errs <- nil
+				} else if err != nil {
+					errs <- err
 				} else {
 					processedChunks <- c
 				}
@@ -58,8 +71,10 @@ func GetParallelChunks(ctx context.Context, chunks []chunk.Chunk, f func(context
 		select {
 		case chunk := <-processedChunks:
 			result = append(result, chunk)
-		case err := <-errors:
-			lastErr = err
+		case err := <-errs:
+			if err != nil {
+				lastErr = err
+			}
 		}
 	}
 
@@ -4,9 +4,44 @@ import (
 	"context"
 	"testing"
 
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
 	"github.com/grafana/loki/pkg/storage/chunk"
 )
 
+func TestGetParallelChunksCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var fetched atomic.Int32
+	res, err := GetParallelChunks(ctx, make([]chunk.Chunk, 100),
+		func(_ context.Context, d *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			fetched.Inc()
+			return c, nil
+		})
+	require.Equal(t, context.Canceled, err)
+	require.Empty(t, res)
+	require.Equal(t, int32(0), fetched.Load())
+}
+
+func TestGetParallelChunksSkipped(t *testing.T) {
+	chunks := make([]chunk.Chunk, 100)
+	for i := range chunks {
+		chunks[i].From = model.Time(i)
+	}
+	res, err := GetParallelChunks(context.Background(), chunks,
+		func(_ context.Context, d *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+			if c.From%10 == 0 {
+				return chunk.Chunk{}, ErrSkipChunk
+			}
+			return c, nil
+		})
+	require.NoError(t, err)
+	require.Len(t, res, 90)
+}
+
 func BenchmarkGetParallelChunks(b *testing.B) {
 	ctx := context.Background()
 	in := make([]chunk.Chunk, 1024)
@@ -100,6 +100,9 @@ func (f *filteringBatchIter) Next() bool {
 		if len(f.query.ValueEqual) != 0 && !bytes.Equal(value, f.query.ValueEqual) {
 			continue
 		}
+		if f.query.ValueMatcher != nil && !f.query.ValueMatcher.Matches(string(value)) {
+			continue
+		}
 
 		return true
 	}
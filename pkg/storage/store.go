@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	logql_log "github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	chunk_local "github.com/grafana/loki/pkg/storage/chunk/local"
@@ -39,15 +40,25 @@ type Config struct {
 	storage.Config      `yaml:",inline"`
 	MaxChunkBatchSize   int            `yaml:"max_chunk_batch_size"`
 	BoltDBShipperConfig shipper.Config `yaml:"boltdb_shipper"`
+	Archive             ArchiveConfig  `yaml:"archive"`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.Config.RegisterFlags(f)
 	cfg.BoltDBShipperConfig.RegisterFlags(f)
+	cfg.Archive.RegisterFlags(f)
 	f.IntVar(&cfg.MaxChunkBatchSize, "store.max-chunk-batch-size", 50, "The maximum number of chunks to fetch per batch.")
 }
 
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if err := cfg.Config.Validate(); err != nil {
+		return err
+	}
+	return cfg.Archive.Validate()
+}
+
 // SchemaConfig contains the config for our chunk index schemas
 type SchemaConfig struct {
 	chunk.SchemaConfig `yaml:",inline"`
@@ -103,6 +114,7 @@ type Store interface {
 	GetSeries(ctx context.Context, req logql.SelectLogParams) ([]logproto.SeriesIdentifier, error)
 	GetSchemaConfigs() []chunk.PeriodConfig
 	SetChunkFilterer(chunkFilter RequestChunkFilterer)
+	SetLookupTables(tables logql_log.LookupTables)
 }
 
 // RequestChunkFilterer creates ChunkFilterer for a given request context.
@@ -122,6 +134,9 @@ type store struct {
 	schemaCfg    SchemaConfig
 
 	chunkFilterer RequestChunkFilterer
+	lookupTables  logql_log.LookupTables
+	// archiveLimits are the limits of the queries of the archive, set only for the archive store.
+	archiveLimits ArchiveLimits
 }
 
 // NewStore creates a new Loki Store using configuration supplied.
@@ -196,6 +211,11 @@ func (s *store) SetChunkFilterer(chunkFilterer RequestChunkFilterer) {
 	s.chunkFilterer = chunkFilterer
 }
 
+// SetLookupTables sets the tables of the lookup stages of the queries.
+func (s *store) SetLookupTables(tables logql_log.LookupTables) {
+	s.lookupTables = tables
+}
+
 // lazyChunks is an internal function used to resolve a set of lazy chunks from the store without actually loading them. It's used internally by `LazyQuery` and `GetSeries`
 func (s *store) lazyChunks(ctx context.Context, matchers []*labels.Matcher, from, through model.Time) ([]*LazyChunk, error) {
 	userID, err := tenant.TenantID(ctx)
@@ -221,6 +241,9 @@ func (s *store) lazyChunks(ctx context.Context, matchers []*labels.Matcher, from
 
 	s.chunkMetrics.refs.WithLabelValues(statusDiscarded).Add(float64(prefiltered - filtered))
 	s.chunkMetrics.refs.WithLabelValues(statusMatched).Add(float64(filtered))
+	if err := s.checkArchiveChunks(userID, filtered); err != nil {
+		return nil, err
+	}
 
 	// creates lazychunks with chunks ref.
 	lazyChunks := make([]*LazyChunk, 0, filtered)
@@ -296,6 +319,11 @@ func (s *store) GetSeries(ctx context.Context, req logql.SelectLogParams) ([]log
 	}
 
 	for _, group := range groups {
+		// stop fetching chunks once we have enough series.
+		if req.Limit > 0 && len(results) >= int(req.Limit) {
+			break
+		}
+
 		err = fetchLazyChunks(ctx, group)
 		if err != nil {
 			return nil, err
@@ -324,6 +352,9 @@ func (s *store) GetSeries(ctx context.Context, req logql.SelectLogParams) ([]log
 		}
 	}
 	sort.Sort(results)
+	if req.Limit > 0 && len(results) > int(req.Limit) {
+		results = results[:req.Limit]
+	}
 	return results, nil
 }
 
@@ -344,6 +375,7 @@ func (s *store) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter
 	if err != nil {
 		return nil, err
 	}
+	logql.BindLookupTables(expr, s.lookupTables)
 
 	pipeline, err := expr.Pipeline()
 	if err != nil {
@@ -371,6 +403,7 @@ func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams)
 	if err != nil {
 		return nil, err
 	}
+	logql.BindLookupTables(expr, s.lookupTables)
 
 	extractor, err := expr.Extractor()
 	if err != nil {
@@ -419,7 +452,7 @@ func RegisterCustomIndexClients(cfg *Config, registerer prometheus.Registerer) {
 			return boltDBIndexClientWithShipper, nil
 		}
 
-		if cfg.BoltDBShipperConfig.Mode == shipper.ModeReadOnly && cfg.BoltDBShipperConfig.IndexGatewayClientConfig.Address != "" {
+		if cfg.BoltDBShipperConfig.Mode == shipper.ModeReadOnly && (cfg.BoltDBShipperConfig.IndexGatewayClientConfig.Address != "" || cfg.BoltDBShipperConfig.IndexGatewayClientConfig.Ring != nil) {
 			gateway, err := shipper.NewGatewayClient(cfg.BoltDBShipperConfig.IndexGatewayClientConfig, registerer)
 			if err != nil {
 				return nil, err
@@ -429,7 +462,7 @@ func RegisterCustomIndexClients(cfg *Config, registerer prometheus.Registerer) {
 			return gateway, nil
 		}
 
-		objectClient, err := storage.NewObjectClient(cfg.BoltDBShipperConfig.SharedStoreType, cfg.Config)
+		objectClient, err := storage.NewObjectClient(cfg.BoltDBShipperConfig.SharedStoreType, cfg.BoltDBShipperConfig.SharedStoreConfig.Apply(cfg.Config))
 		if err != nil {
 			return nil, err
 		}
@@ -438,7 +471,7 @@ func RegisterCustomIndexClients(cfg *Config, registerer prometheus.Registerer) {
 
 		return boltDBIndexClientWithShipper, err
 	}, func() (client chunk.TableClient, e error) {
-		objectClient, err := storage.NewObjectClient(cfg.BoltDBShipperConfig.SharedStoreType, cfg.Config)
+		objectClient, err := storage.NewObjectClient(cfg.BoltDBShipperConfig.SharedStoreType, cfg.BoltDBShipperConfig.SharedStoreConfig.Apply(cfg.Config))
 		if err != nil {
 			return nil, err
 		}
@@ -737,6 +737,23 @@ func Test_store_GetSeries(t *testing.T) {
 	}
 }
 
+func Test_store_GetSeriesLimit(t *testing.T) {
+	s := &store{
+		Store: storeFixture,
+		cfg: Config{
+			MaxChunkBatchSize: 1,
+		},
+		chunkMetrics: NilMetrics,
+	}
+	ctx := user.InjectOrgID(context.Background(), "test-user")
+
+	req := newQuery("{foo=~\"ba.*\"}", from, from.Add(6*time.Millisecond), nil)
+	req.Limit = 1
+	out, err := s.GetSeries(ctx, logql.SelectLogParams{QueryRequest: req})
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+}
+
 func Test_store_decodeReq_Matchers(t *testing.T) {
 	tests := []struct {
 		name     string
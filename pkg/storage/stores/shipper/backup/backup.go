@@ -0,0 +1,433 @@
+// Package backup snapshots the boltdb-shipper index tables along with a manifest of the chunk prefixes to a backup
+// bucket, and restores them into another bucket. The chunks are not part of the backups, they are restored from the
+// original bucket, so the chunks removed from it since a backup, e.g. by retention, are lost.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+)
+
+const (
+	manifestName = "manifest.json"
+	indexDirName = "index"
+
+	// idFormat is the format of the creation time prefixing the IDs of the backups, which sort chronologically. The
+	// IDs end with a random suffix, so that the backups created at the same time get distinct IDs.
+	idFormat = "20060102T150405.000Z"
+
+	// maxTableAttempts is the number of times the files of a table are copied before settling for the last copy when
+	// they keep changing, i.e. because the table is the active one or is being compacted.
+	maxTableAttempts = 5
+)
+
+// File is an index file of a table in a backup.
+type File struct {
+	Name       string    `json:"name"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Size       int64     `json:"size"`
+	// BackupID is the ID of the backup holding the copy of the file, which is an earlier backup when the file was
+	// unchanged since then.
+	BackupID string `json:"backup_id"`
+}
+
+// ChunkStore lists the prefixes under which chunks are stored in an object store. Chunks are immutable, so they are
+// not copied to the backup bucket but restored from the original object store.
+type ChunkStore struct {
+	ObjectType string   `json:"object_type"`
+	Prefixes   []string `json:"prefixes"`
+}
+
+// Manifest describes a backup.
+type Manifest struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Base is the ID of the backup an incremental backup builds upon, empty for a full backup.
+	Base        string            `json:"base,omitempty"`
+	Tables      map[string][]File `json:"tables"`
+	ChunkStores []ChunkStore      `json:"chunk_stores"`
+	// ChangingTables are the tables whose files kept changing during the backup, i.e. the active table. Their files
+	// are the ones of their last copy, which may miss the files uploaded meanwhile.
+	ChangingTables []string `json:"changing_tables,omitempty"`
+}
+
+// Backups manages the backups stored under a prefix of the backup bucket, each under <prefix><id>/.
+type Backups struct {
+	objectClient chunk.ObjectClient
+	prefix       string
+	logger       log.Logger
+}
+
+func New(objectClient chunk.ObjectClient, prefix string, logger log.Logger) *Backups {
+	return &Backups{
+		objectClient: objectClient,
+		prefix:       prefix,
+		logger:       logger,
+	}
+}
+
+// List returns the IDs of the complete backups from the oldest to the latest.
+func (b *Backups) List(ctx context.Context) ([]string, error) {
+	_, prefixes, err := b.objectClient.List(ctx, b.prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, p := range prefixes {
+		id := path.Base(string(p))
+		// The manifest is uploaded last, backups without one are incomplete.
+		if _, err := b.Manifest(ctx, id); err != nil {
+			if b.objectClient.IsObjectNotFoundErr(err) {
+				continue
+			}
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Manifest returns the manifest of the backup.
+func (b *Backups) Manifest(ctx context.Context, id string) (*Manifest, error) {
+	reader, err := b.objectClient.GetObject(ctx, b.key(id, manifestName))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(reader).Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of backup %s: %w", id, err)
+	}
+	return &m, nil
+}
+
+// Create backs up the index tables and lists the prefixes of the chunk stores, keyed by object type. When base is not
+// nil, the backup is incremental: the index files unchanged since the base backup are not copied again. The prefixes
+// of the chunk stores starting with any of excludePrefixes, i.e. the prefix of the index, are left out.
+func (b *Backups) Create(ctx context.Context, index shipper_storage.Client, chunkStores map[string]chunk.ObjectClient, excludePrefixes []string, base *Manifest, now time.Time) (*Manifest, error) {
+	id, err := newID(now)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		ID:        id,
+		CreatedAt: now.UTC(),
+		Tables:    map[string][]File{},
+	}
+	if base != nil {
+		if !base.CreatedAt.Before(m.CreatedAt) {
+			return nil, fmt.Errorf("base backup %s is not older than the new backup %s", base.ID, m.ID)
+		}
+		m.Base = base.ID
+	}
+
+	// Make sure the ID isn't taken, i.e. by a backup in progress, so that the files of two backups are never mixed.
+	objects, prefixes, err := b.objectClient.List(ctx, b.key(m.ID)+"/", "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) > 0 || len(prefixes) > 0 {
+		return nil, fmt.Errorf("backup %s already exists", m.ID)
+	}
+
+	tables, err := index.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		var baseFiles []File
+		if base != nil {
+			baseFiles = base.Tables[table]
+		}
+		files, changing, err := b.backupTable(ctx, index, m.ID, table, baseFiles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up table %s: %w", table, err)
+		}
+		if changing {
+			m.ChangingTables = append(m.ChangingTables, table)
+		}
+		if len(files) > 0 {
+			m.Tables[table] = files
+		}
+	}
+
+	objectTypes := make([]string, 0, len(chunkStores))
+	for objectType := range chunkStores {
+		objectTypes = append(objectTypes, objectType)
+	}
+	sort.Strings(objectTypes)
+	for _, objectType := range objectTypes {
+		prefixes, err := chunkPrefixes(ctx, chunkStores[objectType], excludePrefixes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the chunk prefixes of the %s store: %w", objectType, err)
+		}
+		m.ChunkStores = append(m.ChunkStores, ChunkStore{ObjectType: objectType, Prefixes: prefixes})
+	}
+
+	// Upload the manifest last, so that only complete backups are listed.
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.objectClient.PutObject(ctx, b.key(m.ID, manifestName), bytes.NewReader(buf)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// backupTable copies the files of the table to the backup. The files are listed again once copied and the copy is
+// retried if they changed meanwhile, so that the backup holds a consistent set of files, which is not guaranteed when
+// the table is compacted during the copy. When the files keep changing, i.e. because the ingesters keep uploading
+// the files of the active table, the last copy is kept as a snapshot and changing is true. The table is skipped when no
+// copy completed because its files kept being removed.
+func (b *Backups) backupTable(ctx context.Context, index shipper_storage.Client, id, table string, baseFiles []File) (_ []File, changing bool, _ error) {
+	unchanged := make(map[string]File, len(baseFiles))
+	for _, f := range baseFiles {
+		unchanged[f.Name] = f
+	}
+
+	var lastCopy []File
+	for attempt := 1; attempt <= maxTableAttempts; attempt++ {
+		files, err := index.ListFiles(ctx, table)
+		if err != nil {
+			return nil, false, err
+		}
+
+		backedUp, err := b.copyFiles(ctx, index, id, table, files, unchanged)
+		if err != nil {
+			return nil, false, err
+		}
+		if backedUp != nil {
+			after, err := index.ListFiles(ctx, table)
+			if err != nil {
+				return nil, false, err
+			}
+			if sameFiles(files, after) {
+				return backedUp, false, nil
+			}
+			lastCopy = backedUp
+		}
+		level.Info(b.logger).Log("msg", "files of the table changed while backing it up, retrying", "table", table, "attempt", attempt)
+	}
+
+	if lastCopy == nil {
+		level.Warn(b.logger).Log("msg", "skipping table whose files kept being removed while backing it up", "table", table, "attempts", maxTableAttempts)
+		return nil, false, nil
+	}
+	level.Warn(b.logger).Log("msg", "files of the table kept changing while backing it up, keeping the last copy", "table", table, "attempts", maxTableAttempts)
+	return lastCopy, true, nil
+}
+
+// copyFiles copies the files to the backup, except the ones unchanged since the base backup. It returns nil when a
+// file was removed before being copied.
+func (b *Backups) copyFiles(ctx context.Context, index shipper_storage.Client, id, table string, files []shipper_storage.IndexFile, unchanged map[string]File) ([]File, error) {
+	backedUp := make([]File, 0, len(files))
+	for _, f := range files {
+		if prev, ok := unchanged[f.Name]; ok && prev.ModifiedAt.Equal(f.ModifiedAt) {
+			backedUp = append(backedUp, prev)
+			continue
+		}
+
+		reader, err := index.GetFile(ctx, table, f.Name)
+		if err != nil {
+			if index.IsFileNotFoundErr(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		buf, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := b.objectClient.PutObject(ctx, b.key(id, indexDirName, table, f.Name), bytes.NewReader(buf)); err != nil {
+			return nil, err
+		}
+		backedUp = append(backedUp, File{Name: f.Name, ModifiedAt: f.ModifiedAt, Size: int64(len(buf)), BackupID: id})
+	}
+	return backedUp, nil
+}
+
+func sameFiles(a, b []shipper_storage.IndexFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	files := make(map[string]time.Time, len(a))
+	for _, f := range a {
+		files[f.Name] = f.ModifiedAt
+	}
+	for _, f := range b {
+		if modifiedAt, ok := files[f.Name]; !ok || !modifiedAt.Equal(f.ModifiedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// chunkPrefixes returns the top level prefixes of the object store, which are the tenants for the chunks.
+func chunkPrefixes(ctx context.Context, objectClient chunk.ObjectClient, excludePrefixes []string) ([]string, error) {
+	_, commonPrefixes, err := objectClient.List(ctx, "", "/")
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := make([]string, 0, len(commonPrefixes))
+Outer:
+	for _, p := range commonPrefixes {
+		for _, exclude := range excludePrefixes {
+			if exclude != "" && strings.HasPrefix(string(p), exclude) {
+				continue Outer
+			}
+		}
+		prefixes = append(prefixes, string(p))
+	}
+	sort.Strings(prefixes)
+	return prefixes, nil
+}
+
+// RestoreIndex copies the index files of the backup to the index of another bucket. Files already present in the
+// destination are skipped, so that an interrupted restore can be resumed.
+func (b *Backups) RestoreIndex(ctx context.Context, m *Manifest, index shipper_storage.Client) (int, error) {
+	restored := 0
+	for table, files := range m.Tables {
+		existing, err := index.ListFiles(ctx, table)
+		if err != nil {
+			return restored, err
+		}
+		present := make(map[string]struct{}, len(existing))
+		for _, f := range existing {
+			present[f.Name] = struct{}{}
+		}
+
+		for _, f := range files {
+			if _, ok := present[f.Name]; ok {
+				continue
+			}
+			buf, err := b.read(ctx, b.key(f.BackupID, indexDirName, table, f.Name))
+			if err != nil {
+				return restored, fmt.Errorf("failed to read file %s of table %s from backup %s: %w", f.Name, table, f.BackupID, err)
+			}
+			if err := index.PutFile(ctx, table, f.Name, bytes.NewReader(buf)); err != nil {
+				return restored, err
+			}
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+// RestoreChunks copies the chunks under the prefixes of the backup from the original object stores to the
+// destination ones, both keyed by object type. Chunks already present in the destination are skipped. The chunks are
+// listed page by page when the original object store supports it, so that the listing of a prefix holding many chunks
+// isn't held in memory.
+func RestoreChunks(ctx context.Context, m *Manifest, from, to map[string]chunk.ObjectClient) (int, error) {
+	restored := 0
+	for _, store := range m.ChunkStores {
+		src, dst := from[store.ObjectType], to[store.ObjectType]
+		if src == nil || dst == nil {
+			return restored, fmt.Errorf("no %s store to restore the chunks from and to", store.ObjectType)
+		}
+
+		for _, prefix := range store.Prefixes {
+			err := listPages(ctx, src, prefix, func(objects []chunk.StorageObject) error {
+				for _, o := range objects {
+					exists, err := objectExists(ctx, dst, o.Key)
+					if err != nil {
+						return err
+					}
+					if exists {
+						continue
+					}
+					reader, err := src.GetObject(ctx, o.Key)
+					if err != nil {
+						return err
+					}
+					buf, err := ioutil.ReadAll(reader)
+					reader.Close()
+					if err != nil {
+						return err
+					}
+					if err := dst.PutObject(ctx, o.Key, bytes.NewReader(buf)); err != nil {
+						return err
+					}
+					restored++
+				}
+				return nil
+			})
+			if err != nil {
+				return restored, err
+			}
+		}
+	}
+	return restored, nil
+}
+
+// listPages calls f with the pages of the objects under the prefix, or with all of them at once when the object client
+// can't list them page by page.
+func listPages(ctx context.Context, objectClient chunk.ObjectClient, prefix string, f func([]chunk.StorageObject) error) error {
+	if lister, ok := objectClient.(chunk.ObjectPagesLister); ok {
+		return lister.ListPages(ctx, prefix, f)
+	}
+	objects, _, err := objectClient.List(ctx, prefix, "")
+	if err != nil {
+		return err
+	}
+	return f(objects)
+}
+
+// objectExists checks whether the object exists, without downloading it when the object client supports it.
+func objectExists(ctx context.Context, objectClient chunk.ObjectClient, key string) (bool, error) {
+	if checker, ok := objectClient.(chunk.ObjectExistsChecker); ok {
+		return checker.ObjectExists(ctx, key)
+	}
+	reader, err := objectClient.GetObject(ctx, key)
+	if err != nil {
+		if objectClient.IsObjectNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	reader.Close()
+	return true, nil
+}
+
+func (b *Backups) read(ctx context.Context, key string) ([]byte, error) {
+	reader, err := b.objectClient.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// newID returns the ID of a backup created at now.
+func newID(now time.Time) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return now.UTC().Format(idFormat) + "-" + hex.EncodeToString(suffix), nil
+}
+
+func (b *Backups) key(id string, elem ...string) string {
+	return b.prefix + path.Join(append([]string{id}, elem...)...)
+}
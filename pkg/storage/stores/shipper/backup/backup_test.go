@@ -0,0 +1,208 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/chunk/util"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+)
+
+const indexPrefix = "index/"
+
+func newObjectClient(t *testing.T, dir string) chunk.ObjectClient {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: dir})
+	require.NoError(t, err)
+	return objectClient
+}
+
+func writeFile(t *testing.T, path, content string, modifiedAt time.Time) {
+	require.NoError(t, util.EnsureDirectory(filepath.Dir(path)))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0666))
+	require.NoError(t, os.Chtimes(path, modifiedAt, modifiedAt))
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestBackups(t *testing.T) {
+	ctx := context.Background()
+	sourceDir, backupDir, restoreDir := t.TempDir(), t.TempDir(), t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	writeFile(t, filepath.Join(sourceDir, indexPrefix, "table_1", "a"), "1a", now.Add(-time.Hour))
+	writeFile(t, filepath.Join(sourceDir, indexPrefix, "table_1", "b"), "1b", now.Add(-time.Hour))
+	writeFile(t, filepath.Join(sourceDir, "fake", "chunk_1"), "chunk_1", now.Add(-time.Hour))
+	writeFile(t, filepath.Join(sourceDir, "other", "chunk_2"), "chunk_2", now.Add(-time.Hour))
+
+	sourceClient := newObjectClient(t, sourceDir)
+	index := shipper_storage.NewIndexStorageClient(sourceClient, indexPrefix)
+	chunkStores := map[string]chunk.ObjectClient{"filesystem": sourceClient}
+	backups := New(newObjectClient(t, backupDir), "backups/", util_log.Logger)
+
+	full, err := backups.Create(ctx, index, chunkStores, []string{indexPrefix}, nil, now)
+	require.NoError(t, err)
+	require.Empty(t, full.Base)
+	require.Len(t, full.Tables["table_1"], 2)
+	require.Equal(t, []ChunkStore{{ObjectType: "filesystem", Prefixes: []string{"fake/", "other/"}}}, full.ChunkStores)
+
+	// Rewrite a file and add a table, only those are copied by the incremental backup.
+	writeFile(t, filepath.Join(sourceDir, indexPrefix, "table_1", "b"), "1b-compacted", now)
+	writeFile(t, filepath.Join(sourceDir, indexPrefix, "table_2", "c"), "2c", now)
+
+	incremental, err := backups.Create(ctx, index, chunkStores, []string{indexPrefix}, full, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, full.ID, incremental.Base)
+	for table, backupIDs := range map[string]map[string]string{
+		"table_1": {"a": full.ID, "b": incremental.ID},
+		"table_2": {"c": incremental.ID},
+	} {
+		files := incremental.Tables[table]
+		require.Len(t, files, len(backupIDs))
+		for _, f := range files {
+			require.Equal(t, backupIDs[f.Name], f.BackupID, f.Name)
+		}
+	}
+	require.NoFileExists(t, filepath.Join(backupDir, "backups", incremental.ID, indexDirName, "table_1", "a"))
+
+	// A backup must be newer than its base.
+	_, err = backups.Create(ctx, index, chunkStores, nil, incremental, now)
+	require.Error(t, err)
+
+	ids, err := backups.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{full.ID, incremental.ID}, ids)
+
+	// The backups created at the same time get distinct IDs.
+	other, err := backups.Create(ctx, index, chunkStores, []string{indexPrefix}, nil, now)
+	require.NoError(t, err)
+	require.NotEqual(t, full.ID, other.ID)
+	require.True(t, strings.HasPrefix(other.ID, now.UTC().Format(idFormat)+"-"), other.ID)
+	ids, err = backups.List(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{full.ID, other.ID, incremental.ID}, ids)
+
+	m, err := backups.Manifest(ctx, incremental.ID)
+	require.NoError(t, err)
+	expected, err := json.Marshal(incremental)
+	require.NoError(t, err)
+	actual, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+
+	// Restore into a fresh bucket, which is resumable.
+	restoreClient := newObjectClient(t, restoreDir)
+	restoreIndex := shipper_storage.NewIndexStorageClient(restoreClient, indexPrefix)
+	restored, err := backups.RestoreIndex(ctx, m, restoreIndex)
+	require.NoError(t, err)
+	require.Equal(t, 3, restored)
+	restored, err = backups.RestoreIndex(ctx, m, restoreIndex)
+	require.NoError(t, err)
+	require.Equal(t, 0, restored)
+	require.Equal(t, "1a", readFile(t, filepath.Join(restoreDir, indexPrefix, "table_1", "a")))
+	require.Equal(t, "1b-compacted", readFile(t, filepath.Join(restoreDir, indexPrefix, "table_1", "b")))
+	require.Equal(t, "2c", readFile(t, filepath.Join(restoreDir, indexPrefix, "table_2", "c")))
+
+	require.NoError(t, restoreClient.PutObject(ctx, "fake/chunk_1", bytes.NewReader([]byte("chunk_1"))))
+	restored, err = RestoreChunks(ctx, m, chunkStores, map[string]chunk.ObjectClient{"filesystem": restoreClient})
+	require.NoError(t, err)
+	require.Equal(t, 1, restored)
+	require.Equal(t, "chunk_2", readFile(t, filepath.Join(restoreDir, "other", "chunk_2")))
+
+	_, err = RestoreChunks(ctx, m, chunkStores, map[string]chunk.ObjectClient{})
+	require.Error(t, err)
+}
+
+// changingIndex is an index whose files of the "active" table appear modified each time they are listed.
+type changingIndex struct {
+	shipper_storage.Client
+	listed int
+}
+
+func (c *changingIndex) ListFiles(ctx context.Context, tableName string) ([]shipper_storage.IndexFile, error) {
+	files, err := c.Client.ListFiles(ctx, tableName)
+	if tableName == "active" {
+		c.listed++
+		for i := range files {
+			files[i].ModifiedAt = files[i].ModifiedAt.Add(time.Duration(c.listed) * time.Second)
+		}
+	}
+	return files, err
+}
+
+func TestBackups_ChangingTable(t *testing.T) {
+	ctx := context.Background()
+	sourceDir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+
+	writeFile(t, filepath.Join(sourceDir, indexPrefix, "active", "a"), "a", now)
+	writeFile(t, filepath.Join(sourceDir, indexPrefix, "table_1", "b"), "b", now.Add(-time.Hour))
+
+	index := &changingIndex{Client: shipper_storage.NewIndexStorageClient(newObjectClient(t, sourceDir), indexPrefix)}
+	backups := New(newObjectClient(t, t.TempDir()), "backups/", util_log.Logger)
+
+	// The last copy of the table whose files keep changing is kept.
+	m, err := backups.Create(ctx, index, nil, nil, nil, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"active"}, m.ChangingTables)
+	require.Len(t, m.Tables["active"], 1)
+	require.Len(t, m.Tables["table_1"], 1)
+	require.Equal(t, 2*maxTableAttempts, index.listed)
+}
+
+// pagedObjectClient lists the objects page by page, one object per page.
+type pagedObjectClient struct {
+	chunk.ObjectClient
+	pages int
+}
+
+func (c *pagedObjectClient) ListPages(ctx context.Context, prefix string, f func([]chunk.StorageObject) error) error {
+	objects, _, err := c.List(ctx, prefix, "")
+	if err != nil {
+		return err
+	}
+	for i := range objects {
+		c.pages++
+		if err := f(objects[i : i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRestoreChunks_Pages(t *testing.T) {
+	ctx := context.Background()
+	sourceDir, restoreDir := t.TempDir(), t.TempDir()
+	now := time.Now()
+
+	for _, name := range []string{"chunk_1", "chunk_2", "chunk_3"} {
+		writeFile(t, filepath.Join(sourceDir, "fake", name), name, now)
+	}
+	source := &pagedObjectClient{ObjectClient: newObjectClient(t, sourceDir)}
+	restoreClient := newObjectClient(t, restoreDir)
+	require.NoError(t, restoreClient.PutObject(ctx, "fake/chunk_2", bytes.NewReader([]byte("chunk_2"))))
+
+	m := &Manifest{ChunkStores: []ChunkStore{{ObjectType: "filesystem", Prefixes: []string{"fake/"}}}}
+	restored, err := RestoreChunks(ctx, m, map[string]chunk.ObjectClient{"filesystem": source}, map[string]chunk.ObjectClient{"filesystem": restoreClient})
+	require.NoError(t, err)
+	require.Equal(t, 2, restored)
+	require.Equal(t, 3, source.pages)
+	for _, name := range []string{"chunk_1", "chunk_2", "chunk_3"} {
+		require.Equal(t, name, readFile(t, filepath.Join(restoreDir, "fake", name)))
+	}
+}
@@ -0,0 +1,347 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/tenant"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+const (
+	listChunksWorkingDirName = "list-chunks"
+	defaultListChunksLimit   = 1000
+	maxListChunksLimit       = 10000
+	// maxListChunksRange bounds the time range of a listing, which downloads all the index files of the tables
+	// overlapping with it.
+	maxListChunksRange = 7 * 24 * time.Hour
+	// sizesConcurrency is the number of chunks whose size is requested in parallel.
+	sizesConcurrency = 16
+)
+
+// ChunkInfo describes a chunk stored in the object store.
+type ChunkInfo struct {
+	Key     string    `json:"key"`
+	From    time.Time `json:"from"`
+	Through time.Time `json:"through"`
+	// Size is the size in bytes of the chunk object. It is only populated when sizes are requested.
+	Size int `json:"size,omitempty"`
+}
+
+// ListChunksResponse is the response of the chunk listing endpoint.
+type ListChunksResponse struct {
+	Chunks []ChunkInfo `json:"chunks"`
+	// NextToken is set when more chunks are available, and is passed to the next request to get them.
+	NextToken string `json:"next_token,omitempty"`
+}
+
+// chunkLister lists chunks of a tenant by scanning the index files in the shared store.
+type chunkLister struct {
+	workingDirectory   string
+	indexStorageClient shipper_storage.Client
+	chunkClient        chunk.Client
+}
+
+func newChunkLister(workingDirectory string, indexStorageClient shipper_storage.Client, chunkClient chunk.Client) *chunkLister {
+	return &chunkLister{
+		workingDirectory:   workingDirectory,
+		indexStorageClient: indexStorageClient,
+		chunkClient:        chunkClient,
+	}
+}
+
+// list returns up to limit chunks of the user overlapping with the interval [from, through], ordered by their key
+// and starting after the key given in nextToken.
+func (l *chunkLister) list(ctx context.Context, userID string, from, through model.Time, nextToken string, limit int, withSizes bool) (*ListChunksResponse, error) {
+	tableNames, err := l.indexStorageClient.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each request has its own working directory, so that concurrent listings of a table don't share files.
+	listDirectory := filepath.Join(l.workingDirectory, listChunksWorkingDirName)
+	if err := chunk_util.EnsureDirectory(listDirectory); err != nil {
+		return nil, err
+	}
+	workingDirectory, err := os.MkdirTemp(listDirectory, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(workingDirectory); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove working directory of chunk listing", "path", workingDirectory, "err", err)
+		}
+	}()
+
+	// chunks spanning multiple tables are indexed in each of them, so they are deduped by their key.
+	chunks := map[string]ChunkInfo{}
+	for _, tableName := range tableNames {
+		if tableName == deletion.DeleteRequestsTableName {
+			continue
+		}
+
+		interval := retention.ExtractIntervalFromTableName(tableName)
+		if interval.End < from || interval.Start > through {
+			continue
+		}
+
+		if err := l.listTable(ctx, workingDirectory, tableName, userID, from, through, chunks); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := paginateChunks(chunks, nextToken, limit)
+	if withSizes {
+		if err := l.fillSizes(ctx, userID, resp.Chunks); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// paginateChunks returns up to limit chunks ordered by their key, starting after the key given in nextToken.
+func paginateChunks(chunks map[string]ChunkInfo, nextToken string, limit int) *ListChunksResponse {
+	keys := make([]string, 0, len(chunks))
+	for key := range chunks {
+		if key > nextToken {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	resp := &ListChunksResponse{Chunks: []ChunkInfo{}}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		resp.NextToken = keys[limit-1]
+	}
+	for _, key := range keys {
+		resp.Chunks = append(resp.Chunks, chunks[key])
+	}
+	return resp
+}
+
+func (l *chunkLister) listTable(ctx context.Context, workingDirectory, tableName, userID string, from, through model.Time, chunks map[string]ChunkInfo) error {
+	files, err := l.indexStorageClient.ListFiles(ctx, tableName)
+	if err != nil {
+		return err
+	}
+
+	workingDirectory = filepath.Join(workingDirectory, tableName)
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		refs, err := l.listFile(ctx, tableName, file.Name, filepath.Join(workingDirectory, file.Name), userID, from, through)
+		if err != nil {
+			if l.indexStorageClient.IsFileNotFoundErr(err) {
+				level.Info(util_log.Logger).Log("msg", "skipping missing file, possibly removed during compaction", "table-name", tableName, "file", file.Name)
+				continue
+			}
+			return err
+		}
+
+		for _, ref := range refs {
+			chunks[string(ref.ChunkID)] = ChunkInfo{
+				Key:     string(ref.ChunkID),
+				From:    ref.From.Time().UTC(),
+				Through: ref.Through.Time().UTC(),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *chunkLister) listFile(ctx context.Context, tableName, fileName, downloadAt, userID string, from, through model.Time) ([]retention.ChunkRef, error) {
+	err := shipper_util.GetFileFromStorage(ctx, l.indexStorageClient, tableName, fileName, downloadAt, false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := os.Remove(downloadAt); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove file", "path", downloadAt, "err", err)
+		}
+	}()
+
+	db, err := shipper_util.SafeOpenBoltdbFile(downloadAt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close db", "path", downloadAt, "err", err)
+		}
+	}()
+
+	return retention.ChunkRefsForUser(ctx, db, userID, from, through)
+}
+
+// fillSizes sets the size of the given chunks, from the attributes of their objects when the chunk client can get
+// them. The chunks are fetched otherwise.
+func (l *chunkLister) fillSizes(ctx context.Context, userID string, infos []ChunkInfo) error {
+	if len(infos) == 0 {
+		return nil
+	}
+
+	chunks := make([]chunk.Chunk, 0, len(infos))
+	for _, info := range infos {
+		c, err := chunk.ParseExternalKey(userID, info.Key)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, c)
+	}
+
+	sizer, ok := l.chunkClient.(chunk.ChunkSizer)
+	if ok {
+		err := l.fillSizesFromAttributes(ctx, sizer, chunks, infos)
+		if err != chunk.ErrMethodNotImplemented {
+			return err
+		}
+	}
+
+	fetched, err := l.chunkClient.GetChunks(ctx, chunks)
+	if err != nil {
+		return err
+	}
+
+	sizes := make(map[string]int, len(fetched))
+	for _, c := range fetched {
+		encoded, err := c.Encoded()
+		if err != nil {
+			return err
+		}
+		sizes[c.ExternalKey()] = len(encoded)
+	}
+
+	for i := range infos {
+		infos[i].Size = sizes[infos[i].Key]
+	}
+	return nil
+}
+
+func (l *chunkLister) fillSizesFromAttributes(ctx context.Context, sizer chunk.ChunkSizer, chunks []chunk.Chunk, infos []ChunkInfo) error {
+	g, ctx := errgroup.WithContext(ctx)
+	indexes := make(chan int)
+	g.Go(func() error {
+		defer close(indexes)
+		for i := range chunks {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	for w := 0; w < sizesConcurrency; w++ {
+		g.Go(func() error {
+			for i := range indexes {
+				size, err := sizer.ChunkSize(ctx, chunks[i])
+				if err != nil {
+					return err
+				}
+				infos[i].Size = int(size)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// ListChunksHandler lists the chunks of the tenant overlapping with the requested time range, using the index
+// in the shared store, to help with audits and manual recovery. It accepts start and end parameters defaulting to
+// the last hour, limit for the maximum number of chunks returned, next_token for getting the following page and
+// include_sizes to report the size of the chunks.
+func (c *Compactor) ListChunksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := r.URL.Query()
+
+	through := model.Now()
+	if endParam := params.Get("end"); endParam != "" {
+		end, err := util.ParseTime(endParam)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, "invalid value for end: %v", err)
+			return
+		}
+		through = model.Time(end)
+	}
+
+	from := through.Add(-time.Hour)
+	if startParam := params.Get("start"); startParam != "" {
+		start, err := util.ParseTime(startParam)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, "invalid value for start: %v", err)
+			return
+		}
+		from = model.Time(start)
+	}
+
+	if from > through {
+		serverutil.JSONError(w, http.StatusBadRequest, "start time can't be greater than end time")
+		return
+	}
+
+	if through.Sub(from) > maxListChunksRange {
+		serverutil.JSONError(w, http.StatusBadRequest, "the time range can't be longer than %s", maxListChunksRange)
+		return
+	}
+
+	limit := defaultListChunksLimit
+	if limitParam := params.Get("limit"); limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > maxListChunksLimit {
+			serverutil.JSONError(w, http.StatusBadRequest, "limit must be a positive integer up to %d", maxListChunksLimit)
+			return
+		}
+	}
+
+	withSizes := false
+	if includeSizesParam := params.Get("include_sizes"); includeSizesParam != "" {
+		withSizes, err = strconv.ParseBool(includeSizesParam)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, "invalid value for include_sizes: %v", err)
+			return
+		}
+	}
+
+	resp, err := c.chunkLister.list(r.Context(), userID, from, through, params.Get("next_token"), limit, withSizes)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error listing chunks", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
+	"github.com/grafana/loki/pkg/storage/chunk/testutils"
+)
+
+func Test_paginateChunks(t *testing.T) {
+	chunks := map[string]ChunkInfo{
+		"fake/1": {Key: "fake/1"},
+		"fake/2": {Key: "fake/2"},
+		"fake/3": {Key: "fake/3"},
+	}
+
+	resp := paginateChunks(chunks, "", 2)
+	require.Equal(t, []ChunkInfo{{Key: "fake/1"}, {Key: "fake/2"}}, resp.Chunks)
+	require.Equal(t, "fake/2", resp.NextToken)
+
+	resp = paginateChunks(chunks, resp.NextToken, 2)
+	require.Equal(t, []ChunkInfo{{Key: "fake/3"}}, resp.Chunks)
+	require.Empty(t, resp.NextToken)
+
+	resp = paginateChunks(chunks, "", 3)
+	require.Len(t, resp.Chunks, 3)
+	require.Empty(t, resp.NextToken)
+
+	resp = paginateChunks(map[string]ChunkInfo{}, "", 3)
+	require.Equal(t, []ChunkInfo{}, resp.Chunks)
+}
+
+func TestCompactor_ListChunksHandler_InvalidParams(t *testing.T) {
+	c := &Compactor{}
+	for _, tc := range []struct {
+		name   string
+		url    string
+		tenant string
+	}{
+		{name: "missing tenant", url: "/loki/api/admin/chunks"},
+		{name: "invalid start", url: "/loki/api/admin/chunks?start=foo", tenant: "fake"},
+		{name: "invalid end", url: "/loki/api/admin/chunks?end=foo", tenant: "fake"},
+		{name: "start after end", url: "/loki/api/admin/chunks?start=20&end=10", tenant: "fake"},
+		{name: "range too long", url: "/loki/api/admin/chunks?start=2021-12-01T00:00:00Z&end=2021-12-10T00:00:00Z", tenant: "fake"},
+		{name: "invalid limit", url: "/loki/api/admin/chunks?limit=-1", tenant: "fake"},
+		{name: "limit too high", url: "/loki/api/admin/chunks?limit=1000000", tenant: "fake"},
+		{name: "invalid include_sizes", url: "/loki/api/admin/chunks?include_sizes=foo", tenant: "fake"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.tenant != "" {
+				req = req.WithContext(user.InjectOrgID(req.Context(), tc.tenant))
+			}
+			w := httptest.NewRecorder()
+			c.ListChunksHandler(w, req)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func Test_chunkLister_fillSizes(t *testing.T) {
+	schemaCfg := chunk.SchemaConfig{Configs: []chunk.PeriodConfig{{From: chunk.DayTime{Time: 0}, Schema: "v11"}}}
+	keys, chunks, err := testutils.CreateChunks(0, 3, model.Now().Add(-time.Hour), model.Now())
+	require.NoError(t, err)
+
+	for name, client := range map[string]chunk.Client{
+		"object sizes":   objectclient.NewClient(chunk.NewMockStorage(), nil, schemaCfg),
+		"fetched chunks": chunkClientWithoutSizes{objectclient.NewClient(chunk.NewMockStorage(), nil, schemaCfg)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, client.PutChunks(context.Background(), chunks))
+			infos := make([]ChunkInfo, 0, len(keys))
+			for _, key := range keys {
+				infos = append(infos, ChunkInfo{Key: key})
+			}
+
+			l := newChunkLister(t.TempDir(), nil, client)
+			require.NoError(t, l.fillSizes(context.Background(), "userID", infos))
+			for i, c := range chunks {
+				encoded, err := c.Encoded()
+				require.NoError(t, err)
+				require.Equal(t, len(encoded), infos[i].Size)
+			}
+		})
+	}
+}
+
+// chunkClientWithoutSizes hides the ChunkSize method of a client.
+type chunkClientWithoutSizes struct {
+	chunk.Client
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/local"
 	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
 	"github.com/grafana/loki/pkg/storage/chunk/storage"
@@ -27,7 +29,10 @@ import (
 	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
 	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
 	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/volume"
+	"github.com/grafana/loki/pkg/usage"
 	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/flagext"
 )
 
 const (
@@ -50,20 +55,35 @@ const (
 	// ringNumTokens sets our single token in the ring,
 	// we only need to insert 1 token to be used for leader election purposes.
 	ringNumTokens = 1
+
+	// invalidationMarkerMaxAge is how long the invalidation markers of the tables are kept, the readers resyncing
+	// all their tables at their resync interval anyway.
+	invalidationMarkerMaxAge = time.Hour
 )
 
 type Config struct {
-	WorkingDirectory          string          `yaml:"working_directory"`
-	SharedStoreType           string          `yaml:"shared_store"`
-	SharedStoreKeyPrefix      string          `yaml:"shared_store_key_prefix"`
-	CompactionInterval        time.Duration   `yaml:"compaction_interval"`
-	ApplyRetentionInterval    time.Duration   `yaml:"apply_retention_interval"`
-	RetentionEnabled          bool            `yaml:"retention_enabled"`
-	RetentionDeleteDelay      time.Duration   `yaml:"retention_delete_delay"`
-	RetentionDeleteWorkCount  int             `yaml:"retention_delete_worker_count"`
-	DeleteRequestCancelPeriod time.Duration   `yaml:"delete_request_cancel_period"`
-	MaxCompactionParallelism  int             `yaml:"max_compaction_parallelism"`
-	CompactorRing             util.RingConfig `yaml:"compactor_ring,omitempty"`
+	WorkingDirectory          string           `yaml:"working_directory"`
+	SharedStoreType           string           `yaml:"shared_store"`
+	SharedStoreKeyPrefix      string           `yaml:"shared_store_key_prefix"`
+	CompactionInterval        time.Duration    `yaml:"compaction_interval"`
+	ApplyRetentionInterval    time.Duration    `yaml:"apply_retention_interval"`
+	RetentionEnabled          bool             `yaml:"retention_enabled"`
+	RetentionDeleteDelay      time.Duration    `yaml:"retention_delete_delay"`
+	RetentionDeleteWorkCount  int              `yaml:"retention_delete_worker_count"`
+	DeleteRequestCancelPeriod time.Duration    `yaml:"delete_request_cancel_period"`
+	MaxCompactionParallelism  int              `yaml:"max_compaction_parallelism"`
+	CompactionAllowedWindows  TimeWindows      `yaml:"compaction_allowed_windows"`
+	RetentionAllowedWindows   TimeWindows      `yaml:"retention_allowed_windows"`
+	UploadRateLimit           flagext.ByteSize `yaml:"upload_rate_limit"`
+	ReportsKeyPrefix          string           `yaml:"reports_key_prefix"`
+	TableLockTTL              time.Duration    `yaml:"table_lock_ttl"`
+	VolumeAggregationEnabled  bool             `yaml:"volume_aggregation_enabled"`
+	VolumeAggregationDelay    time.Duration    `yaml:"volume_aggregation_delay"`
+	VolumeKeyPrefix           string           `yaml:"volume_key_prefix"`
+	UsageStatsEnabled         bool             `yaml:"usage_stats_enabled"`
+	UsageExportInterval       time.Duration    `yaml:"usage_export_interval"`
+	UsageKeyPrefix            string           `yaml:"usage_key_prefix"`
+	CompactorRing             util.RingConfig  `yaml:"compactor_ring,omitempty"`
 }
 
 // RegisterFlags registers flags.
@@ -78,6 +98,17 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.RetentionDeleteWorkCount, "boltdb.shipper.compactor.retention-delete-worker-count", 150, "The total amount of worker to use to delete chunks.")
 	f.DurationVar(&cfg.DeleteRequestCancelPeriod, "boltdb.shipper.compactor.delete-request-cancel-period", 24*time.Hour, "Allow cancellation of delete request until duration after they are created. Data would be deleted only after delete requests have been older than this duration. Ideally this should be set to at least 24h.")
 	f.IntVar(&cfg.MaxCompactionParallelism, "boltdb.shipper.compactor.max-compaction-parallelism", 1, "Maximum number of tables to compact in parallel. While increasing this value, please make sure compactor has enough disk space allocated to be able to store and compact as many tables.")
+	f.Var(&cfg.CompactionAllowedWindows, "boltdb.shipper.compactor.compaction-allowed-windows", "Comma separated list of daily time windows in UTC, in HH:MM-HH:MM format, during which compaction is allowed to run, e.g. 01:00-05:00,22:00-23:30. Empty means compaction can run anytime.")
+	f.Var(&cfg.RetentionAllowedWindows, "boltdb.shipper.compactor.retention-allowed-windows", "Comma separated list of daily time windows in UTC, in HH:MM-HH:MM format, during which retention is allowed to be applied, e.g. 01:00-05:00. Empty means retention can be applied anytime.")
+	f.Var(&cfg.UploadRateLimit, "boltdb.shipper.compactor.upload-rate-limit", "Maximum rate in bytes per second at which compacted files are uploaded to the shared store, i.e. 10MB. 0 means no limit.")
+	f.StringVar(&cfg.ReportsKeyPrefix, "boltdb.shipper.compactor.reports-key-prefix", "", "Prefix of Object Keys in Shared store under which a JSON report comparing sizes of tables before and after each compaction run is uploaded. Empty disables uploading of reports. Prefix should never start with a separator but should always end with it and must not overlap with the index key prefix.")
+	f.DurationVar(&cfg.TableLockTTL, "boltdb.shipper.compactor.table-lock-ttl", 0, "TTL of the lock taken in the shared store on each table before compacting it, to avoid multiple compactors accidentally running at the same time from corrupting the index. The lock is taken with conditional writes on S3, GCS, Azure and the filesystem, and renewed periodically while the table is being compacted. The compaction of the table is aborted if the lock is lost. 0 disables locking of tables.")
+	f.BoolVar(&cfg.VolumeAggregationEnabled, "boltdb.shipper.compactor.volume-aggregation-enabled", false, "(Experimental) Aggregate the per-stream per-hour volume of tables to serve volume queries over long time ranges without scanning chunks.")
+	f.DurationVar(&cfg.VolumeAggregationDelay, "boltdb.shipper.compactor.volume-aggregation-delay", 6*time.Hour, "Delay after the end of a table before aggregating its volume, to let ingesters flush all the chunks of the table.")
+	f.StringVar(&cfg.VolumeKeyPrefix, "boltdb.shipper.compactor.volume-key-prefix", "volume/", "Prefix of Object Keys in Shared store under which the volume aggregates are stored. Prefix should never start with a separator but should always end with it and must not overlap with the index key prefix.")
+	f.BoolVar(&cfg.UsageStatsEnabled, "boltdb.shipper.compactor.usage-stats-enabled", false, "(Experimental) Serve the usage stats of each tenant, combining the usage accounted by the distributor and querier usage trackers with the stored bytes and active streams of the volume aggregates.")
+	f.DurationVar(&cfg.UsageExportInterval, "boltdb.shipper.compactor.usage-export-interval", 0, "Interval at which the usage records of all the tenants for the current month are exported to the shared store, for billing pipelines. Requires usage stats to be enabled. 0 disables exporting of usage records.")
+	f.StringVar(&cfg.UsageKeyPrefix, "boltdb.shipper.compactor.usage-key-prefix", "usage/", "Prefix of Object Keys in Shared store under which the usage records are exported. Prefix should never start with a separator but should always end with it and must not overlap with the index key prefix.")
 	cfg.CompactorRing.RegisterFlagsWithPrefix("boltdb.shipper.compactor.", "collectors/", f)
 }
 
@@ -90,6 +121,43 @@ func (cfg *Config) Validate() error {
 		return errors.New("interval for applying retention should either be set to a 0 or a multiple of compaction interval")
 	}
 
+	if cfg.TableLockTTL < 0 {
+		return errors.New("table lock ttl must be >= 0")
+	}
+
+	if cfg.ReportsKeyPrefix != "" {
+		if err := shipper_util.ValidateSharedStoreKeyPrefix(cfg.ReportsKeyPrefix); err != nil {
+			return errors.Wrap(err, "invalid reports key prefix")
+		}
+		if strings.HasPrefix(cfg.ReportsKeyPrefix, cfg.SharedStoreKeyPrefix) || strings.HasPrefix(cfg.SharedStoreKeyPrefix, cfg.ReportsKeyPrefix) {
+			return errors.New("reports key prefix must not overlap with the shared store key prefix")
+		}
+	}
+
+	if cfg.VolumeAggregationEnabled {
+		if err := shipper_util.ValidateSharedStoreKeyPrefix(cfg.VolumeKeyPrefix); err != nil {
+			return errors.Wrap(err, "invalid volume key prefix")
+		}
+		if strings.HasPrefix(cfg.VolumeKeyPrefix, cfg.SharedStoreKeyPrefix) || strings.HasPrefix(cfg.SharedStoreKeyPrefix, cfg.VolumeKeyPrefix) {
+			return errors.New("volume key prefix must not overlap with the shared store key prefix")
+		}
+	}
+
+	if cfg.UsageExportInterval < 0 {
+		return errors.New("usage export interval must be >= 0")
+	}
+	if cfg.UsageExportInterval > 0 {
+		if !cfg.UsageStatsEnabled {
+			return errors.New("usage stats must be enabled to export usage records")
+		}
+		if err := shipper_util.ValidateSharedStoreKeyPrefix(cfg.UsageKeyPrefix); err != nil {
+			return errors.Wrap(err, "invalid usage key prefix")
+		}
+		if strings.HasPrefix(cfg.UsageKeyPrefix, cfg.SharedStoreKeyPrefix) || strings.HasPrefix(cfg.SharedStoreKeyPrefix, cfg.UsageKeyPrefix) {
+			return errors.New("usage key prefix must not overlap with the shared store key prefix")
+		}
+	}
+
 	return shipper_util.ValidateSharedStoreKeyPrefix(cfg.SharedStoreKeyPrefix)
 }
 
@@ -102,8 +170,16 @@ type Compactor struct {
 	sweeper               *retention.Sweeper
 	deleteRequestsStore   deletion.DeleteRequestsStore
 	DeleteRequestsHandler *deletion.DeleteRequestHandler
+	TenantDeletionHandler *deletion.TenantDeletionHandler
 	deleteRequestsManager *deletion.DeleteRequestsManager
 	expirationChecker     retention.ExpirationChecker
+	reportUploader        *reportUploader
+	indexVerifier         *indexVerifier
+	chunkLister           *chunkLister
+	volumeAggregator      *volumeAggregator
+	usageExporter         *usageExporter
+	usageReporter         *usage.Reporter
+	tableLocker           *tableLocker
 	metrics               *metrics
 	running               bool
 	wg                    sync.WaitGroup
@@ -118,7 +194,9 @@ type Compactor struct {
 	subservicesWatcher *services.FailureWatcher
 }
 
-func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig, limits retention.Limits, r prometheus.Registerer) (*Compactor, error) {
+// NewCompactor creates the compactor of the index of the shared store. indexStoreConfig overrides the object store
+// config of the index, the chunks being in the object stores of storageConfig.
+func NewCompactor(cfg Config, storageConfig storage.Config, indexStoreConfig storage.ObjectStoreConfig, schemaConfig loki_storage.SchemaConfig, limits retention.Limits, r prometheus.Registerer) (*Compactor, error) {
 	if cfg.SharedStoreType == "" {
 		return nil, errors.New("compactor shared_store_type must be specified")
 	}
@@ -167,7 +245,7 @@ func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_st
 	compactor.subservicesWatcher = services.NewFailureWatcher()
 	compactor.subservicesWatcher.WatchManager(compactor.subservices)
 
-	if err := compactor.init(storageConfig, schemaConfig, limits, r); err != nil {
+	if err := compactor.init(storageConfig, indexStoreConfig, schemaConfig, limits, r); err != nil {
 		return nil, err
 	}
 
@@ -175,27 +253,57 @@ func NewCompactor(cfg Config, storageConfig storage.Config, schemaConfig loki_st
 	return compactor, nil
 }
 
-func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig, limits retention.Limits, r prometheus.Registerer) error {
-	objectClient, err := storage.NewObjectClient(c.cfg.SharedStoreType, storageConfig)
+func (c *Compactor) init(storageConfig storage.Config, indexStoreConfig storage.ObjectStoreConfig, schemaConfig loki_storage.SchemaConfig, limits retention.Limits, r prometheus.Registerer) error {
+	objectClient, err := storage.NewObjectClient(c.cfg.SharedStoreType, indexStoreConfig.Apply(storageConfig))
 	if err != nil {
 		return err
 	}
 
+	// The chunks are in the shared store too, unless the index has its own object store.
+	chunkObjectClient := objectClient
+	if indexStoreConfig.Enabled {
+		chunkObjectClient, err = newChunkObjectClient(storageConfig, schemaConfig)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = chunk_util.EnsureDirectory(c.cfg.WorkingDirectory)
 	if err != nil {
 		return err
 	}
-	c.indexStorageClient = shipper_storage.NewIndexStorageClient(objectClient, c.cfg.SharedStoreKeyPrefix)
+	c.indexStorageClient = newThrottledIndexStorageClient(shipper_storage.NewIndexStorageClient(objectClient, c.cfg.SharedStoreKeyPrefix), c.cfg.UploadRateLimit.Val())
 	c.metrics = newMetrics(r)
 
-	if c.cfg.RetentionEnabled {
-		var encoder objectclient.KeyEncoder
-		if _, ok := objectClient.(*local.FSObjectClient); ok {
-			encoder = objectclient.Base64Encoder
-		}
+	if c.cfg.ReportsKeyPrefix != "" {
+		c.reportUploader = newReportUploader(objectClient, c.cfg.ReportsKeyPrefix)
+	}
 
-		chunkClient := objectclient.NewClient(objectClient, encoder)
+	if c.cfg.TableLockTTL > 0 {
+		// suffix the instance id with the startup time to distinguish between multiple compactors accidentally running with the same id.
+		owner := fmt.Sprintf("%s-%d", c.ringLifecycler.GetInstanceID(), time.Now().UnixNano())
+		c.tableLocker = newTableLocker(objectClient, c.cfg.SharedStoreKeyPrefix, owner, c.cfg.TableLockTTL)
+	}
+
+	var encoder objectclient.KeyEncoder
+	if _, ok := chunkObjectClient.(*local.FSObjectClient); ok {
+		encoder = objectclient.Base64Encoder
+	}
+
+	chunkClient := objectclient.NewClient(chunkObjectClient, encoder, schemaConfig.SchemaConfig)
+	c.indexVerifier = newIndexVerifier(c.cfg.WorkingDirectory, c.indexStorageClient, schemaConfig, chunkClient)
+	c.chunkLister = newChunkLister(c.cfg.WorkingDirectory, c.indexStorageClient, chunkClient)
+
+	if c.cfg.VolumeAggregationEnabled {
+		volumeStore := volume.NewStore(objectClient, c.cfg.VolumeKeyPrefix)
+		c.volumeAggregator = newVolumeAggregator(c.cfg.WorkingDirectory, c.indexStorageClient, schemaConfig, chunkClient, volumeStore, c.cfg.VolumeAggregationDelay, c.metrics)
+	}
+
+	if c.cfg.UsageExportInterval > 0 {
+		c.usageExporter = newUsageExporter(objectClient, c.cfg.UsageKeyPrefix)
+	}
 
+	if c.cfg.RetentionEnabled {
 		retentionWorkDir := filepath.Join(c.cfg.WorkingDirectory, "retention")
 		c.sweeper, err = retention.NewSweeper(retentionWorkDir, chunkClient, c.cfg.RetentionDeleteWorkCount, c.cfg.RetentionDeleteDelay, r)
 		if err != nil {
@@ -211,6 +319,7 @@ func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage
 
 		c.DeleteRequestsHandler = deletion.NewDeleteRequestHandler(c.deleteRequestsStore, time.Hour, r)
 		c.deleteRequestsManager = deletion.NewDeleteRequestsManager(c.deleteRequestsStore, c.cfg.DeleteRequestCancelPeriod, r)
+		c.TenantDeletionHandler = deletion.NewTenantDeletionHandler(c.deleteRequestsStore, c.deleteRequestsManager, c.cfg.DeleteRequestCancelPeriod, r)
 
 		c.expirationChecker = newExpirationChecker(retention.NewExpirationChecker(limits), c.deleteRequestsManager)
 
@@ -223,6 +332,15 @@ func (c *Compactor) init(storageConfig storage.Config, schemaConfig loki_storage
 	return nil
 }
 
+// newChunkObjectClient returns the client of the object store of the chunks of the active period.
+func newChunkObjectClient(storageConfig storage.Config, schemaConfig loki_storage.SchemaConfig) (chunk.ObjectClient, error) {
+	if len(schemaConfig.Configs) == 0 {
+		return nil, errors.New("no period config to find the object store of the chunks in")
+	}
+	objectType := schemaConfig.Configs[loki_storage.ActivePeriodConfig(schemaConfig.Configs)].ObjectType
+	return storage.NewObjectClient(objectType, storageConfig)
+}
+
 func (c *Compactor) starting(ctx context.Context) (err error) {
 	// In case this function will return error we want to unregister the instance
 	// from the ring. We do it ensuring dependencies are gracefully stopped if they
@@ -346,10 +464,21 @@ func (c *Compactor) runCompactions(ctx context.Context) {
 
 	lastRetentionRunAt := time.Unix(0, 0)
 	runCompaction := func() {
+		now := time.Now()
+		if !c.cfg.CompactionAllowedWindows.Contains(now) {
+			level.Info(util_log.Logger).Log("msg", "skipping compaction since it is outside the allowed windows", "allowed_windows", c.cfg.CompactionAllowedWindows.String())
+			c.metrics.compactTablesOperationSkippedTotal.Inc()
+			return
+		}
+
 		applyRetention := false
 		if c.cfg.RetentionEnabled && time.Since(lastRetentionRunAt) >= c.cfg.ApplyRetentionInterval {
-			level.Info(util_log.Logger).Log("msg", "applying retention with compaction")
-			applyRetention = true
+			if c.cfg.RetentionAllowedWindows.Contains(now) {
+				level.Info(util_log.Logger).Log("msg", "applying retention with compaction")
+				applyRetention = true
+			} else {
+				level.Info(util_log.Logger).Log("msg", "skipping retention since it is outside the allowed windows", "allowed_windows", c.cfg.RetentionAllowedWindows.String())
+			}
 		}
 
 		err := c.RunCompaction(ctx, applyRetention)
@@ -379,6 +508,20 @@ func (c *Compactor) runCompactions(ctx context.Context) {
 			}
 		}
 	}()
+	if c.cfg.VolumeAggregationEnabled {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.runVolumeAggregation(ctx)
+		}()
+	}
+	if c.usageExporter != nil && c.usageReporter != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.runUsageExport(ctx)
+		}()
+	}
 	if c.cfg.RetentionEnabled {
 		c.wg.Add(1)
 		go func() {
@@ -398,11 +541,29 @@ func (c *Compactor) stopping(_ error) error {
 	return services.StopManagerAndAwaitStopped(context.Background(), c.subservices)
 }
 
-func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRetention bool) error {
+// CompactTable compacts the given table and returns its compaction stats, which would be nil if the table was not modified.
+// Tables locked by another compactor are skipped.
+func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRetention bool) (*TableCompactionStats, error) {
+	if c.tableLocker != nil {
+		lockCtx, unlock, err := c.tableLocker.lock(ctx, tableName)
+		if err != nil {
+			if err == errTableLocked {
+				level.Warn(util_log.Logger).Log("msg", "skipping compaction of table locked by another compactor, is more than one compactor running?", "table", tableName)
+				c.metrics.compactTablesLockedTotal.Inc()
+				return nil, nil
+			}
+			level.Error(util_log.Logger).Log("msg", "failed to lock table for compaction", "table", tableName, "err", err)
+			return nil, err
+		}
+		defer unlock()
+		// stop modifying the table as soon as we lose the lock on it.
+		ctx = lockCtx
+	}
+
 	table, err := newTable(ctx, filepath.Join(c.cfg.WorkingDirectory, tableName), c.indexStorageClient, c.cfg.RetentionEnabled, c.tableMarker)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "failed to initialize table for compaction", "table", tableName, "err", err)
-		return err
+		return nil, err
 	}
 
 	interval := retention.ExtractIntervalFromTableName(tableName)
@@ -414,16 +575,27 @@ func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRet
 	err = table.compact(intervalMayHaveExpiredChunks)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "failed to compact files", "table", tableName, "err", err)
-		return err
+		return nil, err
 	}
-	return nil
+
+	stats := table.stats()
+	if stats != nil {
+		// let the queriers and index gateways drop the files we replaced or deleted without waiting for their next resync.
+		if err := c.indexStorageClient.InvalidateTable(ctx, tableName); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to invalidate compacted table", "table", tableName, "err", err)
+		}
+	}
+	return stats, nil
 }
 
 func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) error {
 	status := statusSuccess
 	start := time.Now()
+	report := newCompactionReport(start, applyRetention)
 
-	if c.cfg.RetentionEnabled {
+	// Delete requests are only processed, and then marked as processed, when retention is applied.
+	processDeletes := c.cfg.RetentionEnabled && applyRetention
+	if processDeletes {
 		c.expirationChecker.MarkPhaseStarted()
 	}
 
@@ -438,7 +610,7 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) erro
 			}
 		}
 
-		if c.cfg.RetentionEnabled {
+		if processDeletes {
 			if status == statusSuccess {
 				c.expirationChecker.MarkPhaseFinished()
 			} else {
@@ -448,13 +620,27 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) erro
 		if runtime > c.cfg.CompactionInterval {
 			level.Warn(util_log.Logger).Log("msg", fmt.Sprintf("last compaction took %s which is longer than the compaction interval of %s, this can lead to duplicate compactors running if not running a standalone compactor instance.", runtime, c.cfg.CompactionInterval))
 		}
+
+		c.finishReport(ctx, report, status)
 	}()
 
+	// before compacting, so that the markers written by this run are kept until the next one at least.
+	c.deleteStaleInvalidationMarkers(ctx)
+
 	tables, err := c.indexStorageClient.ListTables(ctx)
 	if err != nil {
 		status = statusFailure
 		return err
 	}
+	if processDeletes {
+		tablesToProcess := 0
+		for _, tableName := range tables {
+			if tableName != deletion.DeleteRequestsTableName {
+				tablesToProcess++
+			}
+		}
+		c.deleteRequestsManager.SetTablesToProcess(tablesToProcess)
+	}
 
 	compactTablesChan := make(chan string)
 	errChan := make(chan error)
@@ -474,10 +660,15 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) erro
 					}
 
 					level.Info(util_log.Logger).Log("msg", "compacting table", "table-name", tableName)
-					err = c.CompactTable(ctx, tableName, applyRetention)
+					var stats *TableCompactionStats
+					stats, err = c.CompactTable(ctx, tableName, applyRetention)
 					if err != nil {
 						return
 					}
+					report.addTable(stats)
+					if processDeletes {
+						c.deleteRequestsManager.MarkTableProcessed()
+					}
 					level.Info(util_log.Logger).Log("msg", "finished compacting table", "table-name", tableName)
 				case <-ctx.Done():
 					return
@@ -516,6 +707,22 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) erro
 	return firstErr
 }
 
+// finishReport records the compaction report to metrics and uploads it to the storage if configured.
+func (c *Compactor) finishReport(ctx context.Context, report *CompactionReport, status string) {
+	report.finish(status)
+	report.observe(c.metrics)
+
+	level.Info(util_log.Logger).Log("msg", "compaction report", "status", status, "tables_modified", len(report.Tables), "total_input_bytes", report.TotalInputBytes, "total_output_bytes", report.TotalOutputBytes)
+
+	if c.reportUploader == nil {
+		return
+	}
+
+	if err := c.reportUploader.upload(ctx, report); err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to upload compaction report", "err", err)
+	}
+}
+
 type expirationChecker struct {
 	retentionExpiryChecker retention.ExpirationChecker
 	deletionExpiryChecker  retention.ExpirationChecker
@@ -582,3 +789,22 @@ func (c *Compactor) OnRingInstanceHeartbeat(_ *ring.BasicLifecycler, _ *ring.Des
 func (c *Compactor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c.ring.ServeHTTP(w, req)
 }
+
+// deleteStaleInvalidationMarkers deletes the invalidation markers of the tables the readers already synced. The markers
+// whose modification time isn't reported by the storage are considered stale.
+func (c *Compactor) deleteStaleInvalidationMarkers(ctx context.Context) {
+	invalidations, err := c.indexStorageClient.ListInvalidatedTables(ctx)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to list invalidated tables", "err", err)
+		return
+	}
+
+	for tableName, invalidatedAt := range invalidations {
+		if !invalidatedAt.IsZero() && time.Since(invalidatedAt) < invalidationMarkerMaxAge {
+			continue
+		}
+		if err := c.indexStorageClient.DeleteInvalidationMarker(ctx, tableName); err != nil && !c.indexStorageClient.IsFileNotFoundErr(err) {
+			level.Error(util_log.Logger).Log("msg", "failed to delete invalidation marker", "table", tableName, "err", err)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package compactor
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/dskit/flagext"
 	"github.com/stretchr/testify/require"
@@ -31,7 +33,7 @@ func setupTestCompactor(t *testing.T, tempDir string) *Compactor {
 
 	require.NoError(t, cfg.Validate())
 
-	c, err := NewCompactor(cfg, storage.Config{FSConfig: local.FSConfig{Directory: tempDir}}, loki_storage.SchemaConfig{}, nil, nil)
+	c, err := NewCompactor(cfg, storage.Config{FSConfig: local.FSConfig{Directory: tempDir}}, storage.ObjectStoreConfig{}, loki_storage.SchemaConfig{}, nil, nil)
 	require.NoError(t, err)
 
 	return c
@@ -108,4 +110,89 @@ func TestCompactor_RunCompaction(t *testing.T) {
 		// verify we have all the kvs in compacted db which were there in source dbs.
 		compareCompactedDB(t, filepath.Join(tablesPath, name, files[0].Name()), filepath.Join(tablesCopyPath, name))
 	}
+
+	// verify that the compacted tables were invalidated for the readers.
+	invalidations, err := compactor.indexStorageClient.ListInvalidatedTables(context.Background())
+	require.NoError(t, err)
+	require.Len(t, invalidations, len(tables))
+
+	// the markers older than their max age are deleted.
+	old := time.Now().Add(-invalidationMarkerMaxAge - time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(tablesPath, "table1.invalidated"), old, old))
+	compactor.deleteStaleInvalidationMarkers(context.Background())
+	invalidations, err = compactor.indexStorageClient.ListInvalidatedTables(context.Background())
+	require.NoError(t, err)
+	require.Len(t, invalidations, 1)
+	require.Contains(t, invalidations, "table2")
+}
+
+func TestCompactor_CompactionReport(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "compactor-compaction-report")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	tablesPath := filepath.Join(tempDir, "index")
+
+	tables := map[string]map[string]testutil.DBRecords{
+		// table with overlapping records across dbs which should get deduped.
+		"table1": {
+			"db1": {
+				Start:      0,
+				NumRecords: 10,
+			},
+			"db2": {
+				Start:      5,
+				NumRecords: 10,
+			},
+		},
+		// table with a single db which should not get modified.
+		"table2": {
+			"db1": {
+				Start:      0,
+				NumRecords: 10,
+			},
+		},
+	}
+
+	for name, dbs := range tables {
+		testutil.SetupDBTablesAtPath(t, name, tablesPath, dbs, false)
+	}
+
+	compactor := setupTestCompactor(t, tempDir)
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: tempDir})
+	require.NoError(t, err)
+	compactor.reportUploader = newReportUploader(objectClient, "compaction-reports/")
+
+	err = compactor.RunCompaction(context.Background(), false)
+	require.NoError(t, err)
+
+	reports, err := ioutil.ReadDir(filepath.Join(tempDir, "compaction-reports"))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+
+	reportJSON, err := ioutil.ReadFile(filepath.Join(tempDir, "compaction-reports", reports[0].Name()))
+	require.NoError(t, err)
+
+	var report CompactionReport
+	require.NoError(t, json.Unmarshal(reportJSON, &report))
+
+	require.Equal(t, statusSuccess, report.Status)
+	require.Len(t, report.Tables, 1)
+
+	stats := report.Tables[0]
+	require.Equal(t, "table1", stats.TableName)
+	require.Equal(t, 2, stats.InputFiles)
+	require.True(t, stats.Uploaded)
+	require.Equal(t, int64(20), stats.InputKeys)
+	require.Equal(t, int64(15), stats.OutputKeys)
+	require.Equal(t, int64(5), stats.DuplicateKeys)
+	require.Equal(t, int64(0), stats.RemovedKeys)
+	require.NotZero(t, stats.InputBytes)
+	require.NotZero(t, stats.OutputBytes)
+	require.NotZero(t, stats.OutputCompressedBytes)
+	require.Equal(t, stats.InputBytes, report.TotalInputBytes)
+	require.Equal(t, stats.OutputBytes, report.TotalOutputBytes)
 }
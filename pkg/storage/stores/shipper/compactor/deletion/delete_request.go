@@ -26,6 +26,10 @@ func (d *DeleteRequest) IsDeleted(entry retention.ChunkEntry) (bool, []model.Int
 		return false, nil
 	}
 
+	if d.IsTenantDeletion() {
+		return true, nil
+	}
+
 	if !intervalsOverlap(model.Interval{
 		Start: entry.From,
 		End:   entry.Through,
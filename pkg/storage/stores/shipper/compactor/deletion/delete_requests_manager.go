@@ -29,15 +29,18 @@ type DeleteRequestsManager struct {
 	// WARN: If by any chance we change deleteRequestsToProcessMtx to sync.RWMutex to be able to check multiple chunks at a time,
 	// please take care of chunkIntervalsToRetain which should be unique per chunk.
 	deleteRequestsToProcessMtx sync.Mutex
-	metrics                    *deleteRequestsManagerMetrics
-	wg                         sync.WaitGroup
-	done                       chan struct{}
+	// tenantDeletionsProgress tracks the tenant deletion requests being processed, by user and request ID.
+	tenantDeletionsProgress map[string]*TenantDeletionProgress
+	metrics                 *deleteRequestsManagerMetrics
+	wg                      sync.WaitGroup
+	done                    chan struct{}
 }
 
 func NewDeleteRequestsManager(store DeleteRequestsStore, deleteRequestCancelPeriod time.Duration, registerer prometheus.Registerer) *DeleteRequestsManager {
 	dm := &DeleteRequestsManager{
 		deleteRequestsStore:       store,
 		deleteRequestCancelPeriod: deleteRequestCancelPeriod,
+		tenantDeletionsProgress:   map[string]*TenantDeletionProgress{},
 		metrics:                   newDeleteRequestsManagerMetrics(registerer),
 		done:                      make(chan struct{}),
 	}
@@ -108,6 +111,7 @@ func (d *DeleteRequestsManager) loadDeleteRequestsToProcess() error {
 	defer d.deleteRequestsToProcessMtx.Unlock()
 
 	d.deleteRequestsToProcess = d.deleteRequestsToProcess[:0]
+	d.tenantDeletionsProgress = map[string]*TenantDeletionProgress{}
 	deleteRequests, err := d.deleteRequestsStore.GetDeleteRequestsByStatus(context.Background(), StatusReceived)
 	if err != nil {
 		return err
@@ -119,6 +123,9 @@ func (d *DeleteRequestsManager) loadDeleteRequestsToProcess() error {
 			continue
 		}
 		d.deleteRequestsToProcess = append(d.deleteRequestsToProcess, deleteRequest)
+		if deleteRequest.IsTenantDeletion() {
+			d.tenantDeletionsProgress[userIDAndRequestID(deleteRequest.UserID, deleteRequest.RequestID)] = &TenantDeletionProgress{}
+		}
 	}
 
 	return nil
@@ -149,6 +156,9 @@ func (d *DeleteRequestsManager) Expired(ref retention.ChunkEntry, _ model.Time)
 				rebuiltIntervals = append(rebuiltIntervals, interval)
 			} else {
 				rebuiltIntervals = append(rebuiltIntervals, newIntervalsToRetain...)
+				if deleteRequest.IsTenantDeletion() {
+					d.markTenantDeletionChunk(deleteRequest)
+				}
 			}
 		}
 
@@ -181,6 +191,7 @@ func (d *DeleteRequestsManager) MarkPhaseFailed() {
 	defer d.deleteRequestsToProcessMtx.Unlock()
 
 	d.deleteRequestsToProcess = d.deleteRequestsToProcess[:0]
+	d.tenantDeletionsProgress = map[string]*TenantDeletionProgress{}
 }
 
 func (d *DeleteRequestsManager) MarkPhaseFinished() {
@@ -193,6 +204,45 @@ func (d *DeleteRequestsManager) MarkPhaseFinished() {
 		}
 		d.metrics.deleteRequestsProcessedTotal.WithLabelValues(deleteRequest.UserID).Inc()
 	}
+	d.tenantDeletionsProgress = map[string]*TenantDeletionProgress{}
+}
+
+func (d *DeleteRequestsManager) markTenantDeletionChunk(deleteRequest DeleteRequest) {
+	if progress, ok := d.tenantDeletionsProgress[userIDAndRequestID(deleteRequest.UserID, deleteRequest.RequestID)]; ok {
+		progress.ChunksMarked++
+	}
+}
+
+// SetTablesToProcess sets the number of tables to process in the current phase, to report the progress of tenant deletions.
+func (d *DeleteRequestsManager) SetTablesToProcess(count int) {
+	d.deleteRequestsToProcessMtx.Lock()
+	defer d.deleteRequestsToProcessMtx.Unlock()
+
+	for _, progress := range d.tenantDeletionsProgress {
+		progress.TablesTotal = count
+	}
+}
+
+// MarkTableProcessed records that a table of the current phase was processed.
+func (d *DeleteRequestsManager) MarkTableProcessed() {
+	d.deleteRequestsToProcessMtx.Lock()
+	defer d.deleteRequestsToProcessMtx.Unlock()
+
+	for _, progress := range d.tenantDeletionsProgress {
+		progress.TablesProcessed++
+	}
+}
+
+// TenantDeletionProgress returns the progress of the tenant deletion request if it is being processed.
+func (d *DeleteRequestsManager) TenantDeletionProgress(userID, requestID string) (TenantDeletionProgress, bool) {
+	d.deleteRequestsToProcessMtx.Lock()
+	defer d.deleteRequestsToProcessMtx.Unlock()
+
+	progress, ok := d.tenantDeletionsProgress[userIDAndRequestID(userID, requestID)]
+	if !ok {
+		return TenantDeletionProgress{}, false
+	}
+	return *progress, true
 }
 
 func (d *DeleteRequestsManager) IntervalMayHaveExpiredChunks(_ model.Interval) bool {
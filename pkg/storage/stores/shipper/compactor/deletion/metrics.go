@@ -21,6 +21,22 @@ func newDeleteRequestHandlerMetrics(r prometheus.Registerer) *deleteRequestHandl
 	return &m
 }
 
+type tenantDeletionHandlerMetrics struct {
+	tenantDeleteRequestsReceivedTotal *prometheus.CounterVec
+}
+
+func newTenantDeletionHandlerMetrics(r prometheus.Registerer) *tenantDeletionHandlerMetrics {
+	m := tenantDeletionHandlerMetrics{}
+
+	m.tenantDeleteRequestsReceivedTotal = promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "compactor_tenant_delete_requests_received_total",
+		Help:      "Number of tenant delete requests received per user",
+	}, []string{"user"})
+
+	return &m
+}
+
 type deleteRequestsManagerMetrics struct {
 	deleteRequestsProcessedTotal         *prometheus.CounterVec
 	deleteRequestsChunksSelectedTotal    *prometheus.CounterVec
@@ -0,0 +1,186 @@
+package deletion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/tenant"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+// TenantDeletionSelector is the selector of the delete requests deleting all the data of a tenant.
+const TenantDeletionSelector = "{}"
+
+// IsTenantDeletion returns true if the request deletes all the data of the tenant.
+func (d *DeleteRequest) IsTenantDeletion() bool {
+	return len(d.Selectors) == 1 && d.Selectors[0] == TenantDeletionSelector
+}
+
+func userIDAndRequestID(userID, requestID string) string {
+	return fmt.Sprintf("%s:%s", userID, requestID)
+}
+
+// TenantDeletionProgress is the progress of a tenant deletion request during a compaction applying retention.
+type TenantDeletionProgress struct {
+	TablesTotal     int `json:"tables_total"`
+	TablesProcessed int `json:"tables_processed"`
+	// ChunksMarked is the number of chunks removed from the index and marked to be deleted by the sweeper.
+	ChunksMarked int `json:"chunks_marked"`
+}
+
+// TenantDeletion is the state of a tenant deletion request.
+type TenantDeletion struct {
+	RequestID        string              `json:"request_id"`
+	Status           DeleteRequestStatus `json:"status"`
+	CreatedAt        model.Time          `json:"created_at"`
+	CancellableUntil model.Time          `json:"cancellable_until"`
+	// Processing is true when the request is being processed by the current compaction.
+	Processing bool                    `json:"processing"`
+	Progress   *TenantDeletionProgress `json:"progress,omitempty"`
+}
+
+// TenantDeletionHandler provides handlers to schedule, follow and cancel the deletion of all the data of a tenant.
+type TenantDeletionHandler struct {
+	deleteRequestsStore       DeleteRequestsStore
+	deleteRequestsManager     *DeleteRequestsManager
+	deleteRequestCancelPeriod time.Duration
+	metrics                   *tenantDeletionHandlerMetrics
+}
+
+// NewTenantDeletionHandler creates a TenantDeletionHandler.
+func NewTenantDeletionHandler(store DeleteRequestsStore, manager *DeleteRequestsManager, deleteRequestCancelPeriod time.Duration, registerer prometheus.Registerer) *TenantDeletionHandler {
+	return &TenantDeletionHandler{
+		deleteRequestsStore:       store,
+		deleteRequestsManager:     manager,
+		deleteRequestCancelPeriod: deleteRequestCancelPeriod,
+		metrics:                   newTenantDeletionHandlerMetrics(registerer),
+	}
+}
+
+// tenantDeletions returns the tenant deletion requests of the user.
+func (h *TenantDeletionHandler) tenantDeletions(r *http.Request, userID string) ([]TenantDeletion, error) {
+	deleteRequests, err := h.deleteRequestsStore.GetAllDeleteRequestsForUser(r.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	deletions := []TenantDeletion{}
+	for _, deleteRequest := range deleteRequests {
+		// requests are listed by prefix of the user ID.
+		if deleteRequest.UserID != userID || !deleteRequest.IsTenantDeletion() {
+			continue
+		}
+		deletion := TenantDeletion{
+			RequestID:        deleteRequest.RequestID,
+			Status:           deleteRequest.Status,
+			CreatedAt:        deleteRequest.CreatedAt,
+			CancellableUntil: deleteRequest.CreatedAt.Add(h.deleteRequestCancelPeriod),
+		}
+		if progress, ok := h.deleteRequestsManager.TenantDeletionProgress(userID, deleteRequest.RequestID); ok {
+			deletion.Processing = true
+			deletion.Progress = &progress
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, nil
+}
+
+// AddTenantDeletionHandler schedules the deletion of all the data of the tenant, which starts once the cancel period
+// is over. Only one deletion can be pending per tenant.
+func (h *TenantDeletionHandler) AddTenantDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deletions, err := h.tenantDeletions(r, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting delete requests from the store", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, deletion := range deletions {
+		if deletion.Status == StatusReceived {
+			serverutil.JSONError(w, http.StatusConflict, "deletion of the tenant is already scheduled by request %s", deletion.RequestID)
+			return
+		}
+	}
+
+	// The deletion covers all the data of the tenant, whenever it was ingested.
+	if err := h.deleteRequestsStore.AddDeleteRequest(r.Context(), userID, 0, model.Latest, []string{TenantDeletionSelector}); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error adding tenant delete request to the store", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.metrics.tenantDeleteRequestsReceivedTotal.WithLabelValues(userID).Inc()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTenantDeletionsHandler responds with the tenant deletion requests of the tenant and the progress of the ones
+// being processed.
+func (h *TenantDeletionHandler) GetTenantDeletionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deletions, err := h.tenantDeletions(r, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting delete requests from the store", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(deletions); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
+
+// CancelTenantDeletionHandler cancels the pending tenant deletion request of the tenant, until its cancel period is over.
+func (h *TenantDeletionHandler) CancelTenantDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deleteRequests, err := h.deleteRequestsStore.GetAllDeleteRequestsForUser(r.Context(), userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting delete requests from the store", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, deleteRequest := range deleteRequests {
+		if deleteRequest.UserID != userID || !deleteRequest.IsTenantDeletion() || deleteRequest.Status != StatusReceived {
+			continue
+		}
+
+		if deleteRequest.CreatedAt.Add(h.deleteRequestCancelPeriod).Before(model.Now()) {
+			serverutil.JSONError(w, http.StatusBadRequest, "cancellation of the tenant deletion past the deadline of %s since its creation is not allowed", h.deleteRequestCancelPeriod.String())
+			return
+		}
+
+		if err := h.deleteRequestsStore.RemoveDeleteRequest(r.Context(), userID, deleteRequest.RequestID, deleteRequest.CreatedAt, deleteRequest.StartTime, deleteRequest.EndTime); err != nil {
+			level.Error(util_log.Logger).Log("msg", "error cancelling the tenant delete request", "err", err)
+			serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	serverutil.JSONError(w, http.StatusNotFound, "no pending deletion of the tenant")
+}
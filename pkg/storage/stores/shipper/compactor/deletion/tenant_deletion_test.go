@@ -0,0 +1,121 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+)
+
+func TestDeleteRequestsManager_TenantDeletion(t *testing.T) {
+	now := model.Now()
+	lblFoo, err := logql.ParseLabels(`{foo="bar"}`)
+	require.NoError(t, err)
+
+	mgr := NewDeleteRequestsManager(mockDeleteRequestsStore{deleteRequests: []DeleteRequest{
+		{
+			UserID:    testUserID,
+			RequestID: "1",
+			Selectors: []string{TenantDeletionSelector},
+			StartTime: 0,
+			EndTime:   model.Latest,
+			CreatedAt: now.Add(-2 * time.Hour),
+		},
+	}}, time.Hour, nil)
+	mgr.MarkPhaseStarted()
+	mgr.SetTablesToProcess(2)
+
+	chunkEntry := func(userID string) retention.ChunkEntry {
+		return retention.ChunkEntry{
+			ChunkRef: retention.ChunkRef{
+				UserID:  []byte(userID),
+				From:    now.Add(-12 * time.Hour),
+				Through: now.Add(time.Hour),
+			},
+			Labels: lblFoo,
+		}
+	}
+
+	// All the chunks of the tenant are deleted, whatever their labels and time range.
+	isExpired, nonDeletedIntervals := mgr.Expired(chunkEntry(testUserID), now)
+	require.True(t, isExpired)
+	require.Nil(t, nonDeletedIntervals)
+	isExpired, _ = mgr.Expired(chunkEntry("different-user"), now)
+	require.False(t, isExpired)
+	mgr.MarkTableProcessed()
+
+	progress, ok := mgr.TenantDeletionProgress(testUserID, "1")
+	require.True(t, ok)
+	require.Equal(t, TenantDeletionProgress{TablesTotal: 2, TablesProcessed: 1, ChunksMarked: 1}, progress)
+
+	mgr.MarkPhaseFinished()
+	_, ok = mgr.TenantDeletionProgress(testUserID, "1")
+	require.False(t, ok)
+}
+
+func TestTenantDeletionHandler(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	store, err := NewDeleteStore(filepath.Join(t.TempDir(), "working-dir"), storage.NewIndexStorageClient(objectClient, ""))
+	require.NoError(t, err)
+	defer store.Stop()
+
+	mgr := NewDeleteRequestsManager(store, time.Hour, nil)
+	defer mgr.Stop()
+	handler := NewTenantDeletionHandler(store, mgr, time.Hour, nil)
+
+	do := func(h http.HandlerFunc, method, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/loki/api/admin/delete_tenant", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), userID))
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+	get := func(userID string) []TenantDeletion {
+		w := do(handler.GetTenantDeletionsHandler, http.MethodGet, userID)
+		require.Equal(t, http.StatusOK, w.Code)
+		var deletions []TenantDeletion
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deletions))
+		return deletions
+	}
+
+	require.Empty(t, get(testUserID))
+	require.Equal(t, http.StatusNotFound, do(handler.CancelTenantDeletionHandler, http.MethodDelete, testUserID).Code)
+
+	// Only one deletion can be pending per tenant.
+	require.Equal(t, http.StatusNoContent, do(handler.AddTenantDeletionHandler, http.MethodPost, testUserID).Code)
+	require.Equal(t, http.StatusConflict, do(handler.AddTenantDeletionHandler, http.MethodPost, testUserID).Code)
+	require.Equal(t, http.StatusNoContent, do(handler.AddTenantDeletionHandler, http.MethodPost, "other-user").Code)
+
+	deletions := get(testUserID)
+	require.Len(t, deletions, 1)
+	require.Equal(t, StatusReceived, deletions[0].Status)
+	require.Equal(t, deletions[0].CreatedAt.Add(time.Hour), deletions[0].CancellableUntil)
+	require.False(t, deletions[0].Processing)
+
+	// Regular delete requests are not listed.
+	require.NoError(t, store.AddDeleteRequest(context.Background(), testUserID, 0, model.Now(), []string{`{foo="bar"}`}))
+	require.Len(t, get(testUserID), 1)
+
+	// The deletion can be cancelled during the cancel period, and scheduled again.
+	require.Equal(t, http.StatusNoContent, do(handler.CancelTenantDeletionHandler, http.MethodDelete, testUserID).Code)
+	require.Empty(t, get(testUserID))
+	require.Len(t, get("other-user"), 1)
+	require.Equal(t, http.StatusNoContent, do(handler.AddTenantDeletionHandler, http.MethodPost, testUserID).Code)
+
+	// Past the cancel period, the deletion is processed and cannot be cancelled anymore.
+	handler.deleteRequestCancelPeriod = -time.Minute
+	require.Equal(t, http.StatusBadRequest, do(handler.CancelTenantDeletionHandler, http.MethodDelete, testUserID).Code)
+}
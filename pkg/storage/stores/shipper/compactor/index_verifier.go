@@ -0,0 +1,227 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+
+	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+const verifyWorkingDirName = "verify"
+
+// TableVerificationReport holds verification reports of all the index files of a table.
+type TableVerificationReport struct {
+	TableName string                                   `json:"table_name"`
+	Healthy   bool                                     `json:"healthy"`
+	Files     []*retention.IndexFileVerificationReport `json:"files"`
+}
+
+// IndexVerificationReport is the machine readable report returned by the index verification endpoint.
+type IndexVerificationReport struct {
+	Healthy bool                       `json:"healthy"`
+	Tables  []*TableVerificationReport `json:"tables"`
+}
+
+// indexVerifier downloads index files from the shared store and verifies them.
+type indexVerifier struct {
+	workingDirectory   string
+	indexStorageClient shipper_storage.Client
+	schemaConfig       loki_storage.SchemaConfig
+	chunkClient        chunk.Client
+}
+
+func newIndexVerifier(workingDirectory string, indexStorageClient shipper_storage.Client, schemaConfig loki_storage.SchemaConfig, chunkClient chunk.Client) *indexVerifier {
+	return &indexVerifier{
+		workingDirectory:   workingDirectory,
+		indexStorageClient: indexStorageClient,
+		schemaConfig:       schemaConfig,
+		chunkClient:        chunkClient,
+	}
+}
+
+// errInvalidTable is returned when asked to verify a table which does not exist in the store.
+type errInvalidTable string
+
+func (e errInvalidTable) Error() string {
+	return fmt.Sprintf("invalid table %q", string(e))
+}
+
+// verify verifies all the files of given tables, or all the tables in the store if none are given. The given tables
+// must exist in the store.
+func (v *indexVerifier) verify(ctx context.Context, tableNames []string, checkChunks bool) (*IndexVerificationReport, error) {
+	storeTableNames, err := v.indexStorageClient.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tableNames) == 0 {
+		tableNames = storeTableNames
+	} else {
+		storeTables := make(map[string]struct{}, len(storeTableNames))
+		for _, tableName := range storeTableNames {
+			storeTables[tableName] = struct{}{}
+		}
+		for _, tableName := range tableNames {
+			// The table name is used as a directory of the working directory, which is removed after the verification.
+			if strings.ContainsAny(tableName, `/\`) || strings.Contains(tableName, "..") {
+				return nil, errInvalidTable(tableName)
+			}
+			if _, ok := storeTables[tableName]; !ok {
+				return nil, errInvalidTable(tableName)
+			}
+		}
+	}
+
+	report := &IndexVerificationReport{Healthy: true}
+	for _, tableName := range tableNames {
+		if tableName == deletion.DeleteRequestsTableName {
+			continue
+		}
+
+		tableReport, err := v.verifyTable(ctx, tableName, checkChunks)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Tables = append(report.Tables, tableReport)
+		report.Healthy = report.Healthy && tableReport.Healthy
+	}
+
+	return report, nil
+}
+
+func (v *indexVerifier) verifyTable(ctx context.Context, tableName string, checkChunks bool) (*TableVerificationReport, error) {
+	files, err := v.indexStorageClient.ListFiles(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	workingDirectory := filepath.Join(v.workingDirectory, verifyWorkingDirName, tableName)
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := os.RemoveAll(workingDirectory); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove working directory of index verification", "path", workingDirectory, "err", err)
+		}
+	}()
+
+	var chunkClient chunk.Client
+	if checkChunks {
+		chunkClient = v.chunkClient
+	}
+
+	report := &TableVerificationReport{
+		TableName: tableName,
+		Healthy:   true,
+	}
+
+	for _, file := range files {
+		fileReport, err := v.verifyFile(ctx, tableName, file.Name, filepath.Join(workingDirectory, file.Name), chunkClient)
+		if err != nil {
+			if v.indexStorageClient.IsFileNotFoundErr(err) {
+				level.Info(util_log.Logger).Log("msg", "skipping verification of missing file, possibly removed during compaction", "table-name", tableName, "file", file.Name)
+				continue
+			}
+			return nil, err
+		}
+
+		report.Files = append(report.Files, fileReport)
+		report.Healthy = report.Healthy && fileReport.Healthy()
+	}
+
+	return report, nil
+}
+
+func (v *indexVerifier) verifyFile(ctx context.Context, tableName, fileName, downloadAt string, chunkClient chunk.Client) (*retention.IndexFileVerificationReport, error) {
+	err := shipper_util.GetFileFromStorage(ctx, v.indexStorageClient, tableName, fileName, downloadAt, false)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := os.Remove(downloadAt); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove file", "path", downloadAt, "err", err)
+		}
+	}()
+
+	db, err := shipper_util.SafeOpenBoltdbFile(downloadAt)
+	if err != nil {
+		return &retention.IndexFileVerificationReport{
+			FileName:  fileName,
+			Corrupted: true,
+			Errors:    []string{err.Error()},
+		}, nil
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close db", "path", downloadAt, "err", err)
+		}
+	}()
+
+	report, err := retention.VerifyIndexFile(ctx, tableName, db, v.schemaConfig, chunkClient)
+	if err != nil {
+		return nil, err
+	}
+
+	report.FileName = fileName
+	return report, nil
+}
+
+// VerifyIndexHandler verifies the index files in the shared store and responds with a report of issues found in them.
+// It accepts optional table parameters to verify only specific tables and check_chunks parameter to
+// also verify existence of chunks referenced by the index, which could be really expensive and thus requires tables to
+// be given.
+func (c *Compactor) VerifyIndexHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	checkChunks := false
+	if checkChunksParam := params.Get("check_chunks"); checkChunksParam != "" {
+		var err error
+		checkChunks, err = strconv.ParseBool(checkChunksParam)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, "invalid value for check_chunks: %v", err)
+			return
+		}
+	}
+
+	if checkChunks && len(params["table"]) == 0 {
+		serverutil.JSONError(w, http.StatusBadRequest, "check_chunks requires at least one table")
+		return
+	}
+
+	report, err := c.indexVerifier.verify(r.Context(), params["table"], checkChunks)
+	if err != nil {
+		if invalidTable, ok := err.(errInvalidTable); ok {
+			serverutil.JSONError(w, http.StatusBadRequest, "%s", invalidTable)
+			return
+		}
+		level.Error(util_log.Logger).Log("msg", "error verifying index", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
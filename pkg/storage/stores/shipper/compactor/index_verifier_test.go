@@ -0,0 +1,45 @@
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+)
+
+func TestCompactor_VerifyIndexHandler(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, objectClient.PutObject(context.Background(), "index/index_10/file", strings.NewReader("not boltdb")))
+
+	workingDirectory := t.TempDir()
+	outside := filepath.Join(workingDirectory, "outside")
+	require.NoError(t, os.Mkdir(outside, 0o750))
+
+	c := &Compactor{indexVerifier: newIndexVerifier(filepath.Join(workingDirectory, "compactor"), shipper_storage.NewIndexStorageClient(objectClient, "index/"), volumeSchemaCfg, nil)}
+
+	for url, code := range map[string]int{
+		"/compactor/index/verify?table=index_10":                  http.StatusOK,
+		"/compactor/index/verify":                                 http.StatusOK,
+		"/compactor/index/verify?table=index_11":                  http.StatusBadRequest,
+		"/compactor/index/verify?table=../../outside":             http.StatusBadRequest,
+		"/compactor/index/verify?table=..":                        http.StatusBadRequest,
+		"/compactor/index/verify?check_chunks=true":               http.StatusBadRequest,
+		"/compactor/index/verify?check_chunks=foo&table=index_10": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		c.VerifyIndexHandler(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, code, w.Code, url)
+	}
+
+	// Directories outside of the working directory are left untouched.
+	require.DirExists(t, outside)
+}
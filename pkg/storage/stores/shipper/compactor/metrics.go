@@ -14,8 +14,23 @@ type metrics struct {
 	compactTablesOperationTotal           *prometheus.CounterVec
 	compactTablesOperationDurationSeconds prometheus.Gauge
 	compactTablesOperationLastSuccess     prometheus.Gauge
+	compactTablesOperationSkippedTotal    prometheus.Counter
+	compactTablesLockedTotal              prometheus.Counter
 	applyRetentionLastSuccess             prometheus.Gauge
 	compactorRunning                      prometheus.Gauge
+
+	compactionInputFilesTotal    prometheus.Counter
+	compactionInputBytesTotal    prometheus.Counter
+	compactionOutputBytesTotal   prometheus.Counter
+	compactionDuplicateKeysTotal prometheus.Counter
+	compactionRemovedKeysTotal   prometheus.Counter
+	compactionTablesModified     prometheus.Gauge
+
+	volumeAggregationTablesTotal *prometheus.CounterVec
+	volumeAggregationLastSuccess prometheus.Gauge
+
+	usageExportsTotal      *prometheus.CounterVec
+	usageExportLastSuccess prometheus.Gauge
 }
 
 func newMetrics(r prometheus.Registerer) *metrics {
@@ -35,6 +50,16 @@ func newMetrics(r prometheus.Registerer) *metrics {
 			Name:      "compact_tables_operation_last_successful_run_timestamp_seconds",
 			Help:      "Unix timestamp of the last successful compaction run",
 		}),
+		compactTablesOperationSkippedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compact_tables_operation_skipped_total",
+			Help:      "Total number of compaction runs skipped due to being outside the allowed windows",
+		}),
+		compactTablesLockedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compact_tables_locked_total",
+			Help:      "Total number of tables skipped from compaction due to being locked by another compactor",
+		}),
 		applyRetentionLastSuccess: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Namespace: "loki_boltdb_shipper",
 			Name:      "apply_retention_last_successful_run_timestamp_seconds",
@@ -45,6 +70,56 @@ func newMetrics(r prometheus.Registerer) *metrics {
 			Name:      "compactor_running",
 			Help:      "Value will be 1 if compactor is currently running on this instance",
 		}),
+		compactionInputFilesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compaction_input_files_total",
+			Help:      "Total number of index files processed by compaction in tables which got modified",
+		}),
+		compactionInputBytesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compaction_input_bytes_total",
+			Help:      "Total size of uncompressed index files processed by compaction in tables which got modified",
+		}),
+		compactionOutputBytesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compaction_output_bytes_total",
+			Help:      "Total size of uncompressed index files written by compaction",
+		}),
+		compactionDuplicateKeysTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compaction_duplicate_keys_total",
+			Help:      "Total number of duplicate index keys dropped by compaction",
+		}),
+		compactionRemovedKeysTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compaction_removed_keys_total",
+			Help:      "Total number of index keys removed by retention and delete requests during compaction",
+		}),
+		compactionTablesModified: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "compaction_tables_modified",
+			Help:      "Number of tables modified by the last compaction run",
+		}),
+		volumeAggregationTablesTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "volume_aggregation_tables_total",
+			Help:      "Total number of tables whose volume got aggregated by status",
+		}, []string{"status"}),
+		volumeAggregationLastSuccess: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "volume_aggregation_last_successful_run_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful volume aggregation run",
+		}),
+		usageExportsTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "usage_exports_total",
+			Help:      "Total number of usage records exports to the shared store by status",
+		}, []string{"status"}),
+		usageExportLastSuccess: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "usage_export_last_successful_run_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful usage records export",
+		}),
 	}
 
 	return &m
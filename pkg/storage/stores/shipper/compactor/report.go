@@ -0,0 +1,110 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// TableCompactionStats holds the stats of a single table processed by a compaction run.
+// Sizes are of uncompressed boltdb files unless mentioned otherwise. DuplicateKeys are the keys dropped by merging the
+// input files, RemovedKeys the ones removed afterwards by retention and delete requests.
+type TableCompactionStats struct {
+	TableName             string `json:"table_name"`
+	InputFiles            int    `json:"input_files"`
+	InputBytes            int64  `json:"input_bytes"`
+	InputKeys             int64  `json:"input_keys"`
+	Uploaded              bool   `json:"uploaded"`
+	OutputBytes           int64  `json:"output_bytes"`
+	OutputCompressedBytes int64  `json:"output_compressed_bytes"`
+	OutputKeys            int64  `json:"output_keys"`
+	DuplicateKeys         int64  `json:"duplicate_keys"`
+	RemovedKeys           int64  `json:"removed_keys"`
+}
+
+// CompactionReport summarizes the effectiveness of a compaction run for capacity planning.
+type CompactionReport struct {
+	StartedAt        time.Time               `json:"started_at"`
+	FinishedAt       time.Time               `json:"finished_at"`
+	RetentionRun     bool                    `json:"retention_run"`
+	Status           string                  `json:"status"`
+	Tables           []*TableCompactionStats `json:"tables"`
+	TotalInputBytes  int64                   `json:"total_input_bytes"`
+	TotalOutputBytes int64                   `json:"total_output_bytes"`
+
+	tablesMtx sync.Mutex
+}
+
+func newCompactionReport(startedAt time.Time, retentionRun bool) *CompactionReport {
+	return &CompactionReport{
+		StartedAt:    startedAt,
+		RetentionRun: retentionRun,
+	}
+}
+
+// addTable adds stats of a compacted table to the report. It is safe to call it concurrently.
+func (r *CompactionReport) addTable(stats *TableCompactionStats) {
+	if stats == nil {
+		return
+	}
+
+	r.tablesMtx.Lock()
+	defer r.tablesMtx.Unlock()
+
+	r.Tables = append(r.Tables, stats)
+	r.TotalInputBytes += stats.InputBytes
+	r.TotalOutputBytes += stats.OutputBytes
+}
+
+// finish marks the report as finished and sorts the tables by name.
+func (r *CompactionReport) finish(status string) {
+	r.tablesMtx.Lock()
+	defer r.tablesMtx.Unlock()
+
+	r.FinishedAt = time.Now()
+	r.Status = status
+	sort.Slice(r.Tables, func(i, j int) bool {
+		return r.Tables[i].TableName < r.Tables[j].TableName
+	})
+}
+
+// observe records the stats from the report to the metrics.
+func (r *CompactionReport) observe(m *metrics) {
+	for _, stats := range r.Tables {
+		m.compactionInputFilesTotal.Add(float64(stats.InputFiles))
+		m.compactionInputBytesTotal.Add(float64(stats.InputBytes))
+		m.compactionOutputBytesTotal.Add(float64(stats.OutputBytes))
+		m.compactionDuplicateKeysTotal.Add(float64(stats.DuplicateKeys))
+		m.compactionRemovedKeysTotal.Add(float64(stats.RemovedKeys))
+	}
+
+	m.compactionTablesModified.Set(float64(len(r.Tables)))
+}
+
+// reportUploader uploads the compaction reports in JSON format to the object storage.
+type reportUploader struct {
+	objectClient chunk.ObjectClient
+	keyPrefix    string
+}
+
+func newReportUploader(objectClient chunk.ObjectClient, keyPrefix string) *reportUploader {
+	return &reportUploader{
+		objectClient: objectClient,
+		keyPrefix:    keyPrefix,
+	}
+}
+
+func (u *reportUploader) upload(ctx context.Context, r *CompactionReport) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return u.objectClient.PutObject(ctx, fmt.Sprintf("%s%d.json", u.keyPrefix, r.StartedAt.Unix()), bytes.NewReader(buf))
+}
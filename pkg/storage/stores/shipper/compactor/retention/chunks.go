@@ -0,0 +1,84 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"go.etcd.io/bbolt"
+
+	"github.com/grafana/loki/pkg/storage"
+)
+
+// ChunkRefsForUser returns the references to the chunks of the given user overlapping with the interval [from, through]
+// found in a boltdb index file. References are copied so they can be used once the file is closed.
+func ChunkRefsForUser(ctx context.Context, db *bbolt.DB, userID string, from, through model.Time) ([]ChunkRef, error) {
+	var refs []ChunkRef
+
+	err := db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", bucketName)
+		}
+
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			ref, ok, err := parseChunkRef(decodeKey(k))
+			if err != nil {
+				return err
+			}
+			if !ok || string(ref.UserID) != userID || ref.Through < from || ref.From > through {
+				continue
+			}
+
+			refs = append(refs, ChunkRef{
+				UserID:   []byte(userID),
+				SeriesID: append([]byte(nil), ref.SeriesID...),
+				ChunkID:  append([]byte(nil), ref.ChunkID...),
+				From:     ref.From,
+				Through:  ref.Through,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// ForEachChunk calls fn for each chunk indexed in a boltdb index file of the given table along with the labels of its series.
+// The entry passed to fn is only valid until fn returns.
+func ForEachChunk(ctx context.Context, tableName string, db *bbolt.DB, config storage.SchemaConfig, fn func(ChunkEntry) error) error {
+	schemaCfg, ok := schemaPeriodForTable(config, tableName)
+	if !ok {
+		return fmt.Errorf("could not find schema for table: %s", tableName)
+	}
+
+	return db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		if bucket == nil {
+			return nil
+		}
+
+		chunkIt, err := newChunkIndexIterator(bucket, schemaCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create chunk index iterator: %w", err)
+		}
+
+		for chunkIt.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(chunkIt.Entry()); err != nil {
+				return err
+			}
+		}
+		return chunkIt.Err()
+	})
+}
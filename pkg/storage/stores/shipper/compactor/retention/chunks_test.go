@@ -0,0 +1,80 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+func Test_ChunkRefsForUser(t *testing.T) {
+	for _, tt := range allSchemas {
+		tt := tt
+		t.Run(tt.schema, func(t *testing.T) {
+			store := newTestStore(t)
+			c1 := createChunk(t, "1", labels.Labels{labels.Label{Name: "foo", Value: "bar"}}, tt.from, tt.from.Add(1*time.Hour))
+			c2 := createChunk(t, "1", labels.Labels{labels.Label{Name: "foo", Value: "buzz"}}, tt.from.Add(2*time.Hour), tt.from.Add(3*time.Hour))
+			c3 := createChunk(t, "2", labels.Labels{labels.Label{Name: "foo", Value: "bar"}}, tt.from, tt.from.Add(1*time.Hour))
+			require.NoError(t, store.Put(context.TODO(), []chunk.Chunk{c1, c2, c3}))
+			store.Stop()
+
+			tables := store.indexTables()
+			require.Len(t, tables, 1)
+			db := tables[0].DB
+
+			refs, err := ChunkRefsForUser(context.Background(), db, "1", tt.from, tt.from.Add(3*time.Hour))
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{c1.ExternalKey(), c2.ExternalKey()}, chunkIDs(refs))
+
+			refs, err = ChunkRefsForUser(context.Background(), db, "1", tt.from.Add(90*time.Minute), tt.from.Add(3*time.Hour))
+			require.NoError(t, err)
+			require.Len(t, refs, 1)
+			require.Equal(t, c2.ExternalKey(), string(refs[0].ChunkID))
+			require.Equal(t, c2.From, refs[0].From)
+			require.Equal(t, c2.Through, refs[0].Through)
+
+			refs, err = ChunkRefsForUser(context.Background(), db, "3", tt.from, tt.from.Add(3*time.Hour))
+			require.NoError(t, err)
+			require.Empty(t, refs)
+		})
+	}
+}
+
+func chunkIDs(refs []ChunkRef) []string {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, string(ref.ChunkID))
+	}
+	return ids
+}
+
+func Test_ForEachChunk(t *testing.T) {
+	for _, tt := range allSchemas {
+		tt := tt
+		t.Run(tt.schema, func(t *testing.T) {
+			store := newTestStore(t)
+			c1 := createChunk(t, "1", labels.Labels{labels.Label{Name: "foo", Value: "bar"}}, tt.from, tt.from.Add(1*time.Hour))
+			c2 := createChunk(t, "2", labels.Labels{labels.Label{Name: "foo", Value: "buzz"}}, tt.from, tt.from.Add(1*time.Hour))
+			require.NoError(t, store.Put(context.TODO(), []chunk.Chunk{c1, c2}))
+			store.Stop()
+
+			tables := store.indexTables()
+			require.Len(t, tables, 1)
+
+			seen := map[string]string{}
+			err := ForEachChunk(context.Background(), tables[0].name, tables[0].DB, store.schemaCfg, func(entry ChunkEntry) error {
+				seen[string(entry.ChunkID)] = string(entry.UserID) + entry.Labels.String()
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{
+				c1.ExternalKey(): "1" + `{foo="bar"}`,
+				c2.ExternalKey(): "2" + `{foo="buzz"}`,
+			}, seen)
+		})
+	}
+}
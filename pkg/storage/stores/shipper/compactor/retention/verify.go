@@ -0,0 +1,191 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+)
+
+// maxReportedErrors is the maximum number of errors kept in the verification report of a single file to keep its size sane.
+const maxReportedErrors = 10
+
+// IndexFileVerificationReport holds the result of verification of a single boltdb index file.
+type IndexFileVerificationReport struct {
+	FileName string `json:"file_name"`
+	// Corrupted is set when the file could not be opened or the consistency check of boltdb failed.
+	Corrupted bool `json:"corrupted"`
+	// SchemaMismatch is set when the table does not belong to any boltdb-shipper period config or the keys do not match the schema.
+	SchemaMismatch bool     `json:"schema_mismatch"`
+	Errors         []string `json:"errors,omitempty"`
+
+	ChunkRefs int64 `json:"chunk_refs"`
+	Series    int64 `json:"series"`
+	// InvalidKeys is the number of keys which could not be parsed as per the schema.
+	InvalidKeys int64 `json:"invalid_keys"`
+	// DanglingSeriesRefs is the number of chunk references to series which do not have their labels indexed.
+	DanglingSeriesRefs int64 `json:"dangling_series_refs"`
+	// DuplicateChunkRefs is the number of chunks referenced by more than one series.
+	DuplicateChunkRefs int64 `json:"duplicate_chunk_refs"`
+	// OutOfRangeChunkRefs is the number of chunk references which do not overlap with the interval of the table.
+	OutOfRangeChunkRefs int64 `json:"out_of_range_chunk_refs"`
+	// MissingChunks is the number of referenced chunks not found in the store. It is only populated when checking of chunks is requested.
+	MissingChunks int64 `json:"missing_chunks"`
+	ChunksChecked bool  `json:"chunks_checked"`
+}
+
+// Healthy returns true if no issues were found with the file.
+func (r *IndexFileVerificationReport) Healthy() bool {
+	return !r.Corrupted && !r.SchemaMismatch && len(r.Errors) == 0 && r.InvalidKeys == 0 && r.DanglingSeriesRefs == 0 &&
+		r.DuplicateChunkRefs == 0 && r.OutOfRangeChunkRefs == 0 && r.MissingChunks == 0
+}
+
+func (r *IndexFileVerificationReport) addError(err error) {
+	if len(r.Errors) < maxReportedErrors {
+		r.Errors = append(r.Errors, err.Error())
+	}
+}
+
+// VerifyIndexFile scans a boltdb index file of the given table for corruption, keys not matching the schema, chunks referencing series
+// without labels, chunks referenced by multiple series and chunks indexed in the wrong table.
+// When chunkClient is not nil, it also verifies that all the referenced chunks exist in the store.
+func VerifyIndexFile(ctx context.Context, tableName string, db *bbolt.DB, config storage.SchemaConfig, chunkClient chunk.Client) (*IndexFileVerificationReport, error) {
+	report := &IndexFileVerificationReport{
+		ChunksChecked: chunkClient != nil,
+	}
+
+	schemaCfg, ok := schemaPeriodForTable(config, tableName)
+	if !ok {
+		report.SchemaMismatch = true
+		report.addError(fmt.Errorf("could not find schema for table: %s", tableName))
+		return report, nil
+	}
+
+	if schemaCfg.IndexType != shipper.BoltDBShipperType {
+		report.SchemaMismatch = true
+		report.addError(fmt.Errorf("table %s belongs to period config with index type %s", tableName, schemaCfg.IndexType))
+		return report, nil
+	}
+
+	tableInterval := ExtractIntervalFromTableName(tableName)
+	// chunks by their ID which lets us detect duplicates and check their existence.
+	chunkSeries := map[string]userSeries{}
+	seriesWithLabels := map[string]struct{}{}
+
+	err := db.View(func(tx *bbolt.Tx) error {
+		for err := range tx.Check() {
+			report.Corrupted = true
+			report.addError(err)
+		}
+
+		if report.Corrupted {
+			return nil
+		}
+
+		bucket := tx.Bucket(bucketName)
+		if bucket == nil {
+			report.Corrupted = true
+			report.addError(fmt.Errorf("bucket %s not found", bucketName))
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			hashKey, rangeKey := decodeKey(k)
+
+			seriesRef, ok, err := parseLabelSeriesRangeKey(hashKey, rangeKey)
+			if err != nil {
+				report.InvalidKeys++
+				report.addError(err)
+				continue
+			}
+			if ok {
+				seriesWithLabels[newUserSeries(seriesRef.SeriesID, seriesRef.UserID).Key()] = struct{}{}
+				continue
+			}
+
+			chunkRef, ok, err := parseChunkRef(hashKey, rangeKey)
+			if err != nil {
+				report.InvalidKeys++
+				report.addError(err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			report.ChunkRefs++
+			if chunkRef.Through < tableInterval.Start || chunkRef.From > tableInterval.End {
+				report.OutOfRangeChunkRefs++
+			}
+
+			series := newUserSeries(chunkRef.SeriesID, chunkRef.UserID)
+			if existing, ok := chunkSeries[string(chunkRef.ChunkID)]; ok {
+				if existing.Key() != series.Key() {
+					report.DuplicateChunkRefs++
+				}
+				continue
+			}
+			chunkSeries[string(chunkRef.ChunkID)] = series
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Series = int64(len(seriesWithLabels))
+	for _, series := range chunkSeries {
+		if _, ok := seriesWithLabels[series.Key()]; !ok {
+			report.DanglingSeriesRefs++
+		}
+	}
+
+	if chunkClient == nil || report.Corrupted {
+		return report, nil
+	}
+
+	for chunkID, series := range chunkSeries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		exists, err := chunkExists(ctx, chunkClient, string(series.UserID()), chunkID)
+		if err != nil {
+			report.addError(err)
+			continue
+		}
+
+		if !exists {
+			report.MissingChunks++
+		}
+	}
+
+	return report, nil
+}
+
+func chunkExists(ctx context.Context, chunkClient chunk.Client, userID, chunkID string) (bool, error) {
+	c, err := chunk.ParseExternalKey(userID, chunkID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = chunkClient.GetChunks(ctx, []chunk.Chunk{c})
+	if err != nil {
+		if chunkClient.IsChunkNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	"github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+)
+
+func Test_VerifyIndexFile(t *testing.T) {
+	// verification only supports tables from boltdb-shipper periods.
+	shipperSchemaCfg := storage.SchemaConfig{SchemaConfig: chunk.SchemaConfig{}}
+	for _, cfg := range schemaCfg.Configs {
+		cfg.IndexType = shipper.BoltDBShipperType
+		shipperSchemaCfg.Configs = append(shipperSchemaCfg.Configs, cfg)
+	}
+
+	for _, tt := range allSchemas {
+		tt := tt
+		t.Run(tt.schema, func(t *testing.T) {
+			store := newTestStore(t)
+			c1 := createChunk(t, "1", labels.Labels{labels.Label{Name: "foo", Value: "bar"}}, tt.from, tt.from.Add(1*time.Hour))
+			c2 := createChunk(t, "2", labels.Labels{labels.Label{Name: "foo", Value: "buzz"}, labels.Label{Name: "bar", Value: "foo"}}, tt.from, tt.from.Add(1*time.Hour))
+
+			require.NoError(t, store.Put(context.TODO(), []chunk.Chunk{
+				c1, c2,
+			}))
+
+			store.Stop()
+
+			tables := store.indexTables()
+			require.Len(t, tables, 1)
+			tableName := tables[0].name
+			db := tables[0].DB
+			chunkClient := objectclient.NewClient(newTestObjectClient(store.chunkDir), objectclient.Base64Encoder, schemaCfg.SchemaConfig)
+
+			// healthy index.
+			report, err := VerifyIndexFile(context.Background(), tableName, db, shipperSchemaCfg, chunkClient)
+			require.NoError(t, err)
+			require.True(t, report.Healthy(), report.Errors)
+			require.Equal(t, int64(2), report.ChunkRefs)
+			require.Equal(t, int64(2), report.Series)
+			require.True(t, report.ChunksChecked)
+
+			// table not belonging to a boltdb-shipper period.
+			report, err = VerifyIndexFile(context.Background(), tableName, db, store.schemaCfg, nil)
+			require.NoError(t, err)
+			require.True(t, report.SchemaMismatch)
+			require.False(t, report.Healthy())
+
+			// delete one of the chunks from the store.
+			require.NoError(t, chunkClient.DeleteChunk(context.Background(), c1.UserID, c1.ExternalKey()))
+			report, err = VerifyIndexFile(context.Background(), tableName, db, shipperSchemaCfg, chunkClient)
+			require.NoError(t, err)
+			require.Equal(t, int64(1), report.MissingChunks)
+			require.False(t, report.Healthy())
+
+			// drop the series labels from the index to make the chunk references dangling.
+			require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+				bucket := tx.Bucket(bucketName)
+				cursor := bucket.Cursor()
+				for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+					_, ok, err := parseLabelSeriesRangeKey(decodeKey(k))
+					require.NoError(t, err)
+					if ok {
+						require.NoError(t, cursor.Delete())
+					}
+				}
+				return nil
+			}))
+
+			report, err = VerifyIndexFile(context.Background(), tableName, db, shipperSchemaCfg, nil)
+			require.NoError(t, err)
+			require.Equal(t, int64(2), report.DanglingSeriesRefs)
+			require.False(t, report.ChunksChecked)
+			require.False(t, report.Healthy())
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"go.etcd.io/bbolt"
+	"go.uber.org/atomic"
 
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
@@ -54,6 +55,14 @@ type table struct {
 	removeSourceFiles    bool
 	logger               log.Logger
 
+	// stats for building compaction report. input stats are updated concurrently while reading the files.
+	inputBytes            atomic.Int64
+	inputKeys             atomic.Int64
+	mergedKeys            int64
+	outputBytes           int64
+	outputCompressedBytes int64
+	outputKeys            int64
+
 	ctx  context.Context
 	quit chan struct{}
 }
@@ -106,6 +115,11 @@ func (t *table) compact(tableHasExpiredStreams bool) error {
 			return err
 		}
 
+		// count the keys before applying retention to tell the duplicate keys from the removed ones.
+		if t.mergedKeys, err = countKeys(t.compactedDB); err != nil {
+			return err
+		}
+
 		// we have compacted the files to a single file so let use upload the compacted db and remove the source files.
 		t.uploadCompactedDB = true
 		t.removeSourceFiles = true
@@ -122,6 +136,11 @@ func (t *table) compact(tableHasExpiredStreams bool) error {
 		if err != nil {
 			return err
 		}
+
+		if err := t.recordInputFile(downloadAt, t.compactedDB); err != nil {
+			return err
+		}
+		t.mergedKeys = t.inputKeys.Load()
 	}
 
 	if applyRetention {
@@ -257,6 +276,10 @@ func (t *table) compactFiles(files []storage.IndexFile) error {
 		return err
 	}
 
+	if err := t.recordInputFile(compactedDBName, t.compactedDB); err != nil {
+		return err
+	}
+
 	errChan := make(chan error)
 	readFileChan := make(chan string)
 	n := util_math.Min(len(files), readDBsParallelism)
@@ -393,6 +416,10 @@ func (t *table) readFile(path string) error {
 		}
 	}()
 
+	if err := t.recordInputFile(path, db); err != nil {
+		return err
+	}
+
 	writeBatch := make([]indexEntry, 0, batchSize)
 
 	return db.View(func(tx *bbolt.Tx) error {
@@ -439,8 +466,14 @@ func (t *table) readFile(path string) error {
 func (t *table) upload() error {
 	compactedDBPath := t.compactedDB.Path()
 
+	outputKeys, err := countKeys(t.compactedDB)
+	if err != nil {
+		return err
+	}
+	t.outputKeys = outputKeys
+
 	// close the compactedDB to make sure all the writes are processed.
-	err := t.compactedDB.Close()
+	err = t.compactedDB.Close()
 	if err != nil {
 		return err
 	}
@@ -454,6 +487,16 @@ func (t *table) upload() error {
 		return err
 	}
 
+	t.outputBytes, err = fileSize(compactedDBPath)
+	if err != nil {
+		return err
+	}
+
+	t.outputCompressedBytes, err = fileSize(compressedDBPath)
+	if err != nil {
+		return err
+	}
+
 	// open the file for reading.
 	compressedDB, err := os.Open(compressedDBPath)
 	if err != nil {
@@ -480,6 +523,50 @@ func (t *table) upload() error {
 	return t.indexStorageClient.PutFile(t.ctx, t.name, fileName, compressedDB)
 }
 
+// recordInputFile records size and number of keys of a source file for building compaction report.
+func (t *table) recordInputFile(path string, db *bbolt.DB) error {
+	size, err := fileSize(path)
+	if err != nil {
+		return err
+	}
+
+	keys, err := countKeys(db)
+	if err != nil {
+		return err
+	}
+
+	t.inputBytes.Add(size)
+	t.inputKeys.Add(keys)
+	return nil
+}
+
+// stats returns the stats of compaction of the table or nil if the table was not modified.
+// It must be called only after compaction of the table is done.
+func (t *table) stats() *TableCompactionStats {
+	if !t.uploadCompactedDB && !t.removeSourceFiles {
+		return nil
+	}
+
+	stats := TableCompactionStats{
+		TableName:     t.name,
+		InputFiles:    len(t.sourceFiles),
+		InputBytes:    t.inputBytes.Load(),
+		InputKeys:     t.inputKeys.Load(),
+		Uploaded:      t.uploadCompactedDB,
+		DuplicateKeys: t.inputKeys.Load() - t.mergedKeys,
+		RemovedKeys:   t.mergedKeys,
+	}
+
+	if t.uploadCompactedDB {
+		stats.OutputBytes = t.outputBytes
+		stats.OutputCompressedBytes = t.outputCompressedBytes
+		stats.OutputKeys = t.outputKeys
+		stats.RemovedKeys = t.mergedKeys - t.outputKeys
+	}
+
+	return &stats
+}
+
 // removeSourceFilesFromStorage deletes source db files from storage.
 func (t *table) removeSourceFilesFromStorage() error {
 	level.Info(t.logger).Log("msg", "removing source db files from storage", "count", len(t.sourceFiles))
@@ -508,6 +595,31 @@ func openBoltdbFileWithNoSync(path string) (*bbolt.DB, error) {
 	return boltdb, nil
 }
 
+// countKeys returns the number of keys in the index bucket of the db.
+func countKeys(db *bbolt.DB) (int64, error) {
+	var keys int64
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		if b == nil {
+			return nil
+		}
+
+		keys = int64(b.Stats().KeyN)
+		return nil
+	})
+
+	return keys, err
+}
+
+func fileSize(path string) (int64, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return fi.Size(), nil
+}
+
 // findSeedFileIdx returns index of file to use as seed which would then get index from all the files written to.
 // It tries to find previously compacted file(which has uploaderName) which would be the biggest file.
 // In a large cluster, using previously compacted file as seed would significantly reduce compaction time.
@@ -0,0 +1,340 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+const lockFileSuffix = ".lock"
+
+var (
+	errTableLocked = errors.New("table is locked by another compactor")
+
+	// lockVerifyDelay is the time to wait after writing the lock before reading it back to verify that we own it,
+	// with object clients which do not support conditional writes. It gives competing compactors a chance to
+	// overwrite the lock, which lets only the last writer proceed.
+	lockVerifyDelay = 2 * time.Second
+)
+
+type tableLock struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// tableLocker takes a lease on tables by writing lock objects to the storage, to avoid multiple compactors
+// accidentally running at the same time from operating on the same table and corrupting it.
+// Lock objects are stored as files next to the table directories so they are not considered as tables or index files.
+// The locks are taken and renewed with conditional writes when the object client supports them,
+// otherwise by writing the lock and reading it back after lockVerifyDelay.
+type tableLocker struct {
+	objectClient chunk.ObjectClient
+	keyPrefix    string
+	owner        string
+	ttl          time.Duration
+}
+
+func newTableLocker(objectClient chunk.ObjectClient, keyPrefix, owner string, ttl time.Duration) *tableLocker {
+	return &tableLocker{
+		objectClient: objectClient,
+		keyPrefix:    keyPrefix,
+		owner:        owner,
+		ttl:          ttl,
+	}
+}
+
+// lock acquires lock on the table and keeps renewing it until the returned unlock function is called.
+// It returns errTableLocked if some other compactor holds an unexpired lock on the table.
+// The returned context is derived from ctx and gets canceled when the lock is lost, either because some other
+// compactor took it over or because it could not be renewed before expiring, so that the table is not modified anymore.
+func (l *tableLocker) lock(ctx context.Context, tableName string) (context.Context, func(), error) {
+	key := l.lockKey(tableName)
+
+	var (
+		version   string
+		expiresAt time.Time
+		err       error
+	)
+	if writer, ok := l.objectClient.(chunk.ConditionalObjectWriter); ok {
+		version, expiresAt, err = l.lockConditionally(ctx, writer, key)
+	} else {
+		expiresAt, err = l.lockAndVerify(ctx, key)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lockCtx, cancelLock := context.WithCancel(ctx)
+	renewCtx, stopRenewing := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if l.renew(renewCtx, tableName, key, version, expiresAt) {
+			cancelLock()
+		}
+	}()
+
+	return lockCtx, func() {
+		stopRenewing()
+		wg.Wait()
+		cancelLock()
+		l.unlock(key)
+	}, nil
+}
+
+// lockConditionally takes the lock by creating the lock object only if it does not exist,
+// or by overwriting an expired lock only if it was not modified since it was read.
+func (l *tableLocker) lockConditionally(ctx context.Context, writer chunk.ConditionalObjectWriter, key string) (string, time.Time, error) {
+	existing, version, err := l.readLockVersion(ctx, writer, key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if existing != nil && existing.Owner != l.owner && time.Now().Before(existing.ExpiresAt) {
+		return "", time.Time{}, errTableLocked
+	}
+
+	version, expiresAt, err := l.writeLockIfVersion(ctx, writer, key, version)
+	if err == chunk.ErrObjectModified {
+		return "", time.Time{}, errTableLocked
+	}
+	return version, expiresAt, err
+}
+
+// lockAndVerify takes the lock by writing it and reading it back after lockVerifyDelay to check that
+// no other compactor overwrote it in the meantime.
+func (l *tableLocker) lockAndVerify(ctx context.Context, key string) (time.Time, error) {
+	existing, err := l.readLock(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if existing != nil && existing.Owner != l.owner && time.Now().Before(existing.ExpiresAt) {
+		return time.Time{}, errTableLocked
+	}
+
+	expiresAt, err := l.writeLock(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	select {
+	case <-time.After(lockVerifyDelay):
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	}
+
+	existing, err = l.readLock(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if existing == nil || existing.Owner != l.owner {
+		return time.Time{}, errTableLocked
+	}
+
+	return expiresAt, nil
+}
+
+// renew renews the lock every ttl/2 until ctx is canceled.
+// It returns true if the lock was lost, either because some other compactor took it over or because it expired
+// without getting renewed.
+func (l *tableLocker) renew(ctx context.Context, tableName, key, version string, expiresAt time.Time) bool {
+	ticker := time.NewTicker(l.ttl / 2)
+	defer ticker.Stop()
+
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			var (
+				newVersion   string
+				newExpiresAt time.Time
+				err          error
+			)
+			if writer, ok := l.objectClient.(chunk.ConditionalObjectWriter); ok {
+				newVersion, newExpiresAt, err = l.renewConditionally(ctx, writer, key, version)
+			} else {
+				newExpiresAt, err = l.verifyAndWriteLock(ctx, key)
+			}
+
+			if err == errTableLocked {
+				level.Error(util_log.Logger).Log("msg", "table lock was taken over by another compactor", "table-name", tableName)
+				return true
+			}
+			if err != nil {
+				level.Error(util_log.Logger).Log("msg", "failed to renew table lock", "table-name", tableName, "err", err)
+				continue
+			}
+
+			version = newVersion
+			if !expired.Stop() {
+				<-expired.C
+			}
+			expired.Reset(time.Until(newExpiresAt))
+		case <-expired.C:
+			level.Error(util_log.Logger).Log("msg", "table lock expired without getting renewed", "table-name", tableName)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// renewConditionally renews the lock only if the lock object was not modified since we last wrote it.
+// It rechecks the owner if it was modified since a write which failed on our side could still have been applied,
+// and returns errTableLocked if it is not owned by us anymore.
+func (l *tableLocker) renewConditionally(ctx context.Context, writer chunk.ConditionalObjectWriter, key, version string) (string, time.Time, error) {
+	newVersion, expiresAt, err := l.writeLockIfVersion(ctx, writer, key, version)
+	if err != chunk.ErrObjectModified {
+		return newVersion, expiresAt, err
+	}
+
+	existing, version, err := l.readLockVersion(ctx, writer, key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if existing == nil || existing.Owner != l.owner {
+		return "", time.Time{}, errTableLocked
+	}
+
+	return l.writeLockIfVersion(ctx, writer, key, version)
+}
+
+// verifyAndWriteLock renews the lock if it is still owned by us, with object clients which do not support
+// conditional writes. It returns errTableLocked if it is not owned by us anymore.
+func (l *tableLocker) verifyAndWriteLock(ctx context.Context, key string) (time.Time, error) {
+	existing, err := l.readLock(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if existing == nil || existing.Owner != l.owner {
+		return time.Time{}, errTableLocked
+	}
+
+	return l.writeLock(ctx, key)
+}
+
+// unlock removes the lock if it is still owned by us.
+func (l *tableLocker) unlock(key string) {
+	ctx := context.Background()
+
+	existing, err := l.readLock(ctx, key)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to read table lock for releasing it", "key", key, "err", err)
+		return
+	}
+
+	if existing == nil || existing.Owner != l.owner {
+		level.Warn(util_log.Logger).Log("msg", "table lock is not owned by us anymore, not releasing it", "key", key)
+		return
+	}
+
+	if err := l.objectClient.DeleteObject(ctx, key); err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to release table lock", "key", key, "err", err)
+	}
+}
+
+func (l *tableLocker) readLock(ctx context.Context, key string) (*tableLock, error) {
+	reader, err := l.objectClient.GetObject(ctx, key)
+	if err != nil {
+		if l.objectClient.IsObjectNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return decodeLock(reader, key)
+}
+
+// readLockVersion reads the lock along with the version of the lock object. Both are empty if there is no lock.
+func (l *tableLocker) readLockVersion(ctx context.Context, writer chunk.ConditionalObjectWriter, key string) (*tableLock, string, error) {
+	reader, version, err := writer.GetObjectVersion(ctx, key)
+	if err != nil {
+		if l.objectClient.IsObjectNotFoundErr(err) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+
+	lock, err := decodeLock(reader, key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return lock, version, nil
+}
+
+func (l *tableLocker) writeLock(ctx context.Context, key string) (time.Time, error) {
+	buf, expiresAt, err := l.encodeLock()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return expiresAt, l.objectClient.PutObject(ctx, key, bytes.NewReader(buf))
+}
+
+// writeLockIfVersion writes the lock only if the lock object was not modified since the given version was read.
+// It returns chunk.ErrObjectModified otherwise.
+func (l *tableLocker) writeLockIfVersion(ctx context.Context, writer chunk.ConditionalObjectWriter, key, version string) (string, time.Time, error) {
+	buf, expiresAt, err := l.encodeLock()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	version, err = writer.PutObjectIfVersion(ctx, key, bytes.NewReader(buf), version)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return version, expiresAt, nil
+}
+
+func decodeLock(reader io.ReadCloser, key string) (*tableLock, error) {
+	defer func() {
+		if err := reader.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close table lock reader", "key", key, "err", err)
+		}
+	}()
+
+	buf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock tableLock
+	if err := json.Unmarshal(buf, &lock); err != nil {
+		// the lock could be getting overwritten by its owner, so we can't consider it as released.
+		return nil, errors.Wrapf(err, "failed to decode table lock %s", key)
+	}
+
+	return &lock, nil
+}
+
+func (l *tableLocker) encodeLock() ([]byte, time.Time, error) {
+	expiresAt := time.Now().Add(l.ttl)
+	buf, err := json.Marshal(tableLock{
+		Owner:     l.owner,
+		ExpiresAt: expiresAt,
+	})
+	return buf, expiresAt, err
+}
+
+func (l *tableLocker) lockKey(tableName string) string {
+	return l.keyPrefix + tableName + lockFileSuffix
+}
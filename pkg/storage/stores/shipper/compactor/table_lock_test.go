@@ -0,0 +1,202 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+)
+
+// nonConditionalObjectClient hides the conditional writes of the object client to test locking without them.
+type nonConditionalObjectClient struct {
+	chunk.ObjectClient
+}
+
+// failingObjectClient fails all the writes once failing is set.
+type failingObjectClient struct {
+	*local.FSObjectClient
+	failing atomic.Bool
+}
+
+func (f *failingObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
+	if f.failing.Load() {
+		return errors.New("put failed")
+	}
+	return f.FSObjectClient.PutObject(ctx, objectKey, object)
+}
+
+func (f *failingObjectClient) PutObjectIfVersion(ctx context.Context, objectKey string, object io.ReadSeeker, version string) (string, error) {
+	if f.failing.Load() {
+		return "", errors.New("put failed")
+	}
+	return f.FSObjectClient.PutObjectIfVersion(ctx, objectKey, object, version)
+}
+
+var tableLockerObjectClients = map[string]func(client *local.FSObjectClient) chunk.ObjectClient{
+	"conditional writes": func(client *local.FSObjectClient) chunk.ObjectClient {
+		return client
+	},
+	"write and verify": func(client *local.FSObjectClient) chunk.ObjectClient {
+		return nonConditionalObjectClient{client}
+	},
+}
+
+func newTableLockerTestClient(t *testing.T) (*local.FSObjectClient, string) {
+	tempDir, err := ioutil.TempDir("", "table-lock")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	})
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: tempDir})
+	require.NoError(t, err)
+
+	return objectClient, tempDir
+}
+
+func TestTableLocker(t *testing.T) {
+	defer func(delay time.Duration) {
+		lockVerifyDelay = delay
+	}(lockVerifyDelay)
+	lockVerifyDelay = 10 * time.Millisecond
+
+	for name, newObjectClient := range tableLockerObjectClients {
+		t.Run(name, func(t *testing.T) {
+			fsObjectClient, tempDir := newTableLockerTestClient(t)
+			objectClient := newObjectClient(fsObjectClient)
+
+			tableName := "table_1"
+			lockPath := filepath.Join(tempDir, "index", tableName+lockFileSuffix)
+
+			locker1 := newTableLocker(objectClient, "index/", "compactor-1", time.Minute)
+			locker2 := newTableLocker(objectClient, "index/", "compactor-2", time.Minute)
+
+			_, unlock, err := locker1.lock(context.Background(), tableName)
+			require.NoError(t, err)
+			require.FileExists(t, lockPath)
+
+			// other compactor should not be able to take the lock while it is held.
+			_, _, err = locker2.lock(context.Background(), tableName)
+			require.Equal(t, errTableLocked, err)
+
+			// lock should not be listed as a table or a file within the table.
+			_, tables, err := objectClient.List(context.Background(), "index/", "/")
+			require.NoError(t, err)
+			require.Len(t, tables, 0)
+
+			unlock()
+			require.NoFileExists(t, lockPath)
+
+			// other compactor should be able to take the lock after it is released.
+			_, unlock, err = locker2.lock(context.Background(), tableName)
+			require.NoError(t, err)
+			unlock()
+
+			// expired lock should be taken over.
+			expiredLocker := newTableLocker(objectClient, "index/", "compactor-1", -time.Minute)
+			_, err = expiredLocker.writeLock(context.Background(), expiredLocker.lockKey(tableName))
+			require.NoError(t, err)
+
+			_, unlock, err = locker2.lock(context.Background(), tableName)
+			require.NoError(t, err)
+
+			// unlock by a compactor not owning the lock anymore should not remove the lock taken over by other compactor.
+			expiredLocker.unlock(expiredLocker.lockKey(tableName))
+			require.FileExists(t, lockPath)
+
+			unlock()
+			require.NoFileExists(t, lockPath)
+		})
+	}
+}
+
+func TestTableLocker_renew(t *testing.T) {
+	defer func(delay time.Duration) {
+		lockVerifyDelay = delay
+	}(lockVerifyDelay)
+	lockVerifyDelay = 10 * time.Millisecond
+
+	for name, newObjectClient := range tableLockerObjectClients {
+		t.Run(name, func(t *testing.T) {
+			fsObjectClient, _ := newTableLockerTestClient(t)
+
+			locker := newTableLocker(newObjectClient(fsObjectClient), "index/", "compactor-1", 100*time.Millisecond)
+			lockCtx, unlock, err := locker.lock(context.Background(), "table_1")
+			require.NoError(t, err)
+			defer unlock()
+
+			// lock should not expire while it is held since it keeps getting renewed.
+			time.Sleep(275 * time.Millisecond)
+			lock, err := locker.readLock(context.Background(), locker.lockKey("table_1"))
+			require.NoError(t, err)
+			require.NotNil(t, lock)
+			require.True(t, lock.ExpiresAt.After(time.Now()))
+			require.NoError(t, lockCtx.Err())
+		})
+	}
+}
+
+func TestTableLocker_lost(t *testing.T) {
+	defer func(delay time.Duration) {
+		lockVerifyDelay = delay
+	}(lockVerifyDelay)
+	lockVerifyDelay = 10 * time.Millisecond
+
+	for name, newObjectClient := range tableLockerObjectClients {
+		t.Run(name, func(t *testing.T) {
+			fsObjectClient, tempDir := newTableLockerTestClient(t)
+			objectClient := newObjectClient(fsObjectClient)
+			lockPath := filepath.Join(tempDir, "index", "table_1"+lockFileSuffix)
+
+			locker1 := newTableLocker(objectClient, "index/", "compactor-1", 100*time.Millisecond)
+			locker2 := newTableLocker(objectClient, "index/", "compactor-2", time.Minute)
+
+			lockCtx, unlock, err := locker1.lock(context.Background(), "table_1")
+			require.NoError(t, err)
+
+			// lock getting taken over by other compactor should cancel the context at the next renewal.
+			_, err = locker2.writeLock(context.Background(), locker2.lockKey("table_1"))
+			require.NoError(t, err)
+
+			select {
+			case <-lockCtx.Done():
+			case <-time.After(time.Second):
+				t.Fatal("context was not canceled after losing the lock")
+			}
+
+			// lock taken over by other compactor should not be removed.
+			unlock()
+			require.FileExists(t, lockPath)
+		})
+	}
+}
+
+func TestTableLocker_expired(t *testing.T) {
+	objectClient, _ := newTableLockerTestClient(t)
+	failingClient := &failingObjectClient{FSObjectClient: objectClient}
+
+	locker := newTableLocker(failingClient, "index/", "compactor-1", 100*time.Millisecond)
+	lockCtx, unlock, err := locker.lock(context.Background(), "table_1")
+	require.NoError(t, err)
+	defer unlock()
+
+	// lock expiring without getting renewed should cancel the context.
+	failingClient.failing.Store(true)
+
+	select {
+	case <-lockCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not canceled after the lock expired")
+	}
+}
@@ -196,6 +196,79 @@ func TestTable_CompactionRetention(t *testing.T) {
 	}
 }
 
+func TestTable_CompactionStats(t *testing.T) {
+	// removes the first n keys of the db, or all of them when n is negative.
+	removeKeys := func(n int) retention.TableMarker {
+		return TableMarkerFunc(func(ctx context.Context, tableName string, db *bbolt.DB) (bool, bool, error) {
+			err := db.Update(func(tx *bbolt.Tx) error {
+				b := tx.Bucket(bucketName)
+				var keys [][]byte
+				require.NoError(t, b.ForEach(func(k, _ []byte) error {
+					if n < 0 || len(keys) < n {
+						keys = append(keys, append([]byte{}, k...))
+					}
+					return nil
+				}))
+				for _, k := range keys {
+					if err := b.Delete(k); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			return n < 0, true, err
+		})
+	}
+
+	for name, tt := range map[string]struct {
+		tableMarker retention.TableMarker
+		expected    TableCompactionStats
+	}{
+		"deduped": {
+			tableMarker: removeKeys(0),
+			expected:    TableCompactionStats{InputKeys: 20, Uploaded: true, OutputKeys: 15, DuplicateKeys: 5},
+		},
+		"deduped and marked": {
+			tableMarker: removeKeys(3),
+			expected:    TableCompactionStats{InputKeys: 20, Uploaded: true, OutputKeys: 12, DuplicateKeys: 5, RemovedKeys: 3},
+		},
+		"emptied": {
+			tableMarker: removeKeys(-1),
+			expected:    TableCompactionStats{InputKeys: 20, DuplicateKeys: 5, RemovedKeys: 15},
+		},
+	} {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			objectStoragePath := filepath.Join(tempDir, objectsStorageDirName)
+			tableWorkingDirectory := filepath.Join(tempDir, workingDirName, tableName)
+
+			// setup dbs with overlapping records.
+			testutil.SetupDBTablesAtPath(t, tableName, objectStoragePath, map[string]testutil.DBRecords{
+				"0": {Start: 0, NumRecords: 10},
+				"1": {Start: 5, NumRecords: 10},
+			}, true)
+
+			objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: objectStoragePath})
+			require.NoError(t, err)
+
+			table, err := newTable(context.Background(), tableWorkingDirectory, storage.NewIndexStorageClient(objectClient, ""), true, tt.tableMarker)
+			require.NoError(t, err)
+
+			require.NoError(t, table.compact(true))
+
+			stats := table.stats()
+			require.NotNil(t, stats)
+			require.Equal(t, tt.expected.InputKeys, stats.InputKeys)
+			require.Equal(t, tt.expected.Uploaded, stats.Uploaded)
+			require.Equal(t, tt.expected.OutputKeys, stats.OutputKeys)
+			require.Equal(t, tt.expected.DuplicateKeys, stats.DuplicateKeys)
+			require.Equal(t, tt.expected.RemovedKeys, stats.RemovedKeys)
+		})
+	}
+}
+
 func TestTable_CompactionFailure(t *testing.T) {
 	tempDir, err := ioutil.TempDir("", "table-compaction-failure")
 	require.NoError(t, err)
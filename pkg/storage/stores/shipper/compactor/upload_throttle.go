@@ -0,0 +1,60 @@
+package compactor
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+)
+
+// throttledIndexStorageClient limits the rate at which files are uploaded to the storage to avoid
+// compactor competing with the queries for the network bandwidth.
+type throttledIndexStorageClient struct {
+	storage.Client
+	limiter *rate.Limiter
+}
+
+// newThrottledIndexStorageClient wraps the given client to limit the uploads to bytesPerSec.
+// It returns the given client as is if bytesPerSec is 0.
+func newThrottledIndexStorageClient(client storage.Client, bytesPerSec int) storage.Client {
+	if bytesPerSec <= 0 {
+		return client
+	}
+
+	return &throttledIndexStorageClient{
+		Client:  client,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSec), bytesPerSec),
+	}
+}
+
+func (c *throttledIndexStorageClient) PutFile(ctx context.Context, tableName, fileName string, file io.ReadSeeker) error {
+	return c.Client.PutFile(ctx, tableName, fileName, &throttledReadSeeker{
+		ctx:        ctx,
+		ReadSeeker: file,
+		limiter:    c.limiter,
+	})
+}
+
+type throttledReadSeeker struct {
+	io.ReadSeeker
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (r *throttledReadSeeker) Read(p []byte) (int, error) {
+	// do not read more than what the limiter allows in a single go.
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+
+	n, err := r.ReadSeeker.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
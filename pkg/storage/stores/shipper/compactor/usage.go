@@ -0,0 +1,130 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/usage"
+)
+
+// usageExporter uploads the usage records of all the tenants for a month as newline delimited JSON under
+// <prefix><month>/<timestamp>.json, so that billing pipelines can pick the latest export of each month.
+type usageExporter struct {
+	objectClient chunk.ObjectClient
+	keyPrefix    string
+}
+
+func newUsageExporter(objectClient chunk.ObjectClient, keyPrefix string) *usageExporter {
+	return &usageExporter{
+		objectClient: objectClient,
+		keyPrefix:    keyPrefix,
+	}
+}
+
+func (e *usageExporter) export(ctx context.Context, month string, records []*usage.Record, now time.Time) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return e.objectClient.PutObject(ctx, fmt.Sprintf("%s%s/%d.json", e.keyPrefix, month, now.Unix()), bytes.NewReader(buf.Bytes()))
+}
+
+// SetUsageReporter sets the reporter of the usage records exported by the compactor. It must be called before the
+// compactor is started.
+func (c *Compactor) SetUsageReporter(r *usage.Reporter) {
+	c.usageReporter = r
+}
+
+// runUsageExport exports the usage records of the current month at every usage export interval. The previous month
+// is exported one last time once it ended, so that its latest export accounts for the whole month.
+func (c *Compactor) runUsageExport(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.UsageExportInterval)
+	defer ticker.Stop()
+
+	var lastMonth string
+	for {
+		now := time.Now()
+		month := usage.Month(now)
+		months := []string{month}
+		if lastMonth != "" && lastMonth != month {
+			months = append(months, lastMonth)
+		}
+
+		success := true
+		for _, m := range months {
+			if err := c.exportUsage(ctx, m, now); err != nil {
+				level.Error(util_log.Logger).Log("msg", "failed to export usage records", "month", m, "err", err)
+				success = false
+			}
+		}
+		if success {
+			lastMonth = month
+			c.metrics.usageExportLastSuccess.SetToCurrentTime()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Compactor) exportUsage(ctx context.Context, month string, now time.Time) error {
+	records, err := c.usageReporter.ReportAll(ctx, month)
+	if err == nil {
+		err = c.usageExporter.export(ctx, month, records, now)
+	}
+	if err != nil {
+		c.metrics.usageExportsTotal.WithLabelValues(statusFailure).Inc()
+		return err
+	}
+	c.metrics.usageExportsTotal.WithLabelValues(statusSuccess).Inc()
+	return nil
+}
+
+// StoredUsage returns the bytes and the number of streams stored for the tenant over [from, through) from the
+// aggregated volume of the tables, which is complete once all the tables of the time range are aggregated.
+func (c *Compactor) StoredUsage(ctx context.Context, userID string, from, through time.Time) (uint64, int, bool, error) {
+	resp, err := c.volumeAggregator.volume(ctx, userID, nil, nil, model.TimeFromUnixNano(from.UnixNano()), model.TimeFromUnixNano(through.UnixNano())-1)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	var bytes uint64
+	for _, v := range resp.Volumes {
+		bytes += v.Bytes
+	}
+	return bytes, len(resp.Volumes), len(resp.MissingTables) == 0, nil
+}
+
+// StoredTenants returns the tenants with an aggregated volume in the tables overlapping with [from, through).
+func (c *Compactor) StoredTenants(ctx context.Context, from, through time.Time) ([]string, error) {
+	seen := map[string]struct{}{}
+	var tenants []string
+	for _, tableName := range c.volumeAggregator.tablesFor(model.TimeFromUnixNano(from.UnixNano()), model.TimeFromUnixNano(through.UnixNano())-1) {
+		ids, err := c.volumeAggregator.store.Tenants(ctx, tableName)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				tenants = append(tenants, id)
+			}
+		}
+	}
+	return tenants, nil
+}
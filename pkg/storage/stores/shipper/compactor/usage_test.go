@@ -0,0 +1,74 @@
+package compactor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/volume"
+	"github.com/grafana/loki/pkg/usage"
+)
+
+func TestCompactor_StoredUsage(t *testing.T) {
+	a, _ := newTestVolumeAggregator(t)
+	ctx := context.Background()
+
+	require.NoError(t, a.store.Put(ctx, "index_10", "fake", &volume.TableVolume{
+		Streams: []volume.StreamVolume{
+			{Labels: `{app="foo"}`, Hours: []volume.HourVolume{{Hour: 10 * 86400, Count: 2, Bytes: 20}}},
+			{Labels: `{app="bar"}`, Hours: []volume.HourVolume{{Hour: 10*86400 + 3600, Count: 3, Bytes: 30}}},
+		},
+	}))
+	require.NoError(t, a.store.Put(ctx, "index_11", "other", &volume.TableVolume{
+		Streams: []volume.StreamVolume{{Labels: `{app="foo"}`, Hours: []volume.HourVolume{{Hour: 11 * 86400, Count: 1, Bytes: 10}}}},
+	}))
+	require.NoError(t, a.store.MarkComplete(ctx, "index_10"))
+
+	c := &Compactor{volumeAggregator: a}
+
+	from, through := time.Unix(10*86400, 0), time.Unix(11*86400, 0)
+	bytes, streams, complete, err := c.StoredUsage(ctx, "fake", from, through)
+	require.NoError(t, err)
+	require.Equal(t, uint64(50), bytes)
+	require.Equal(t, 2, streams)
+	require.True(t, complete)
+
+	// The volume of the second table is not aggregated yet.
+	_, _, complete, err = c.StoredUsage(ctx, "fake", from, through.Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, complete)
+
+	tenants, err := c.StoredTenants(ctx, from, through)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fake"}, tenants)
+	tenants, err = c.StoredTenants(ctx, from, through.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{"fake", "other"}, tenants)
+}
+
+func Test_usageExporter(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	e := newUsageExporter(objectClient, "usage/")
+
+	records := []*usage.Record{
+		{Tenant: "bar", Month: "2021-12", IngestedBytes: 10},
+		{Tenant: "foo", Month: "2021-12", StoredBytes: 20, Incomplete: []string{usage.SourceQueries}},
+	}
+	require.NoError(t, e.export(context.Background(), "2021-12", records, time.Unix(1000, 0)))
+
+	reader, err := objectClient.GetObject(context.Background(), "usage/2021-12/1000.json")
+	require.NoError(t, err)
+	defer reader.Close()
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`{"tenant":"bar","month":"2021-12","ingested_bytes":10,"ingested_lines":0,"stored_bytes":0,"active_streams":0,"query_bytes_scanned":0,"query_lines_scanned":0}`,
+		`{"tenant":"foo","month":"2021-12","ingested_bytes":0,"ingested_lines":0,"stored_bytes":20,"active_streams":0,"query_bytes_scanned":0,"query_lines_scanned":0,"incomplete":["queries"]}`,
+	}, strings.Split(strings.TrimSpace(string(b)), "\n"))
+}
@@ -0,0 +1,429 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/deletion"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/compactor/retention"
+	shipper_storage "github.com/grafana/loki/pkg/storage/stores/shipper/storage"
+	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/volume"
+	"github.com/grafana/loki/pkg/tenant"
+	loki_util "github.com/grafana/loki/pkg/util"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+const (
+	volumeWorkingDirName = "volume"
+	// volumeFetchBatchSize is the number of chunks fetched at once while aggregating the volume of a table.
+	volumeFetchBatchSize = 100
+)
+
+// volumeAggregator computes the per-stream per-hour volume of tables once they can't receive new chunks anymore,
+// and stores it so that volume queries over long time ranges don't need to scan chunks.
+type volumeAggregator struct {
+	workingDirectory   string
+	indexStorageClient shipper_storage.Client
+	schemaConfig       loki_storage.SchemaConfig
+	chunkClient        chunk.Client
+	store              *volume.Store
+	delay              time.Duration
+	metrics            *metrics
+}
+
+func newVolumeAggregator(workingDirectory string, indexStorageClient shipper_storage.Client, schemaConfig loki_storage.SchemaConfig, chunkClient chunk.Client, store *volume.Store, delay time.Duration, metrics *metrics) *volumeAggregator {
+	return &volumeAggregator{
+		workingDirectory:   workingDirectory,
+		indexStorageClient: indexStorageClient,
+		schemaConfig:       schemaConfig,
+		chunkClient:        chunkClient,
+		store:              store,
+		delay:              delay,
+		metrics:            metrics,
+	}
+}
+
+// run aggregates the volume of all the tables which ended more than the delay ago and were not aggregated yet. A
+// table failing to be aggregated doesn't prevent the aggregation of the other tables, it is retried on the next run.
+func (a *volumeAggregator) run(ctx context.Context) error {
+	tableNames, err := a.indexStorageClient.ListTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs loki_util.MultiError
+	maxEnd := model.Now().Add(-a.delay)
+	for _, tableName := range tableNames {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if tableName == deletion.DeleteRequestsTableName {
+			continue
+		}
+
+		interval := retention.ExtractIntervalFromTableName(tableName)
+		if interval.End > maxEnd {
+			continue
+		}
+
+		complete, err := a.store.IsComplete(ctx, tableName)
+		if err != nil {
+			errs.Add(errors.Wrapf(err, "table %s", tableName))
+			continue
+		}
+		if complete {
+			continue
+		}
+
+		level.Info(util_log.Logger).Log("msg", "aggregating volume of table", "table-name", tableName)
+		if err := a.aggregateTable(ctx, tableName, interval); err != nil {
+			a.metrics.volumeAggregationTablesTotal.WithLabelValues(statusFailure).Inc()
+			level.Error(util_log.Logger).Log("msg", "failed to aggregate volume of table", "table-name", tableName, "err", err)
+			errs.Add(errors.Wrapf(err, "table %s", tableName))
+			continue
+		}
+		a.metrics.volumeAggregationTablesTotal.WithLabelValues(statusSuccess).Inc()
+		level.Info(util_log.Logger).Log("msg", "finished aggregating volume of table", "table-name", tableName)
+	}
+
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	a.metrics.volumeAggregationLastSuccess.SetToCurrentTime()
+	return nil
+}
+
+// aggregateTable computes and stores the volume of each tenant of the table.
+// Only entries within the interval of the table are accounted for since chunks are indexed in all the tables they overlap with.
+func (a *volumeAggregator) aggregateTable(ctx context.Context, tableName string, interval model.Interval) error {
+	chunksByUser, err := a.listChunks(ctx, tableName)
+	if err != nil {
+		return err
+	}
+
+	for userID, chunks := range chunksByUser {
+		builder := volume.NewBuilder()
+		for i := 0; i < len(chunks); i += volumeFetchBatchSize {
+			end := i + volumeFetchBatchSize
+			if end > len(chunks) {
+				end = len(chunks)
+			}
+			if err := a.aggregateChunks(ctx, userID, chunks[i:end], interval, builder); err != nil {
+				return err
+			}
+		}
+
+		if builder.Empty() {
+			continue
+		}
+		if err := a.store.Put(ctx, tableName, userID, builder.Build()); err != nil {
+			return err
+		}
+	}
+
+	return a.store.MarkComplete(ctx, tableName)
+}
+
+// volumeChunk is a chunk indexed in a table along with the labels of its stream.
+type volumeChunk struct {
+	key    string
+	labels string
+}
+
+// listChunks returns the chunks indexed in the table by tenant.
+func (a *volumeAggregator) listChunks(ctx context.Context, tableName string) (map[string][]volumeChunk, error) {
+	files, err := a.indexStorageClient.ListFiles(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	workingDirectory := filepath.Join(a.workingDirectory, volumeWorkingDirName, tableName)
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := os.RemoveAll(workingDirectory); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove working directory of volume aggregation", "path", workingDirectory, "err", err)
+		}
+	}()
+
+	// chunks could be indexed in multiple files of the table when it is not compacted yet.
+	seen := map[string]struct{}{}
+	chunksByUser := map[string][]volumeChunk{}
+	for _, file := range files {
+		err := a.listFileChunks(ctx, tableName, file.Name, filepath.Join(workingDirectory, file.Name), func(entry retention.ChunkEntry) error {
+			key := string(entry.ChunkID)
+			if _, ok := seen[key]; ok {
+				return nil
+			}
+			seen[key] = struct{}{}
+
+			userID := string(entry.UserID)
+			chunksByUser[userID] = append(chunksByUser[userID], volumeChunk{key: key, labels: entry.Labels.String()})
+			return nil
+		})
+		if err != nil {
+			if a.indexStorageClient.IsFileNotFoundErr(err) {
+				level.Info(util_log.Logger).Log("msg", "skipping missing file, possibly removed during compaction", "table-name", tableName, "file", file.Name)
+				continue
+			}
+			return nil, err
+		}
+	}
+
+	return chunksByUser, nil
+}
+
+func (a *volumeAggregator) listFileChunks(ctx context.Context, tableName, fileName, downloadAt string, fn func(retention.ChunkEntry) error) error {
+	err := shipper_util.GetFileFromStorage(ctx, a.indexStorageClient, tableName, fileName, downloadAt, false)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := os.Remove(downloadAt); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove file", "path", downloadAt, "err", err)
+		}
+	}()
+
+	db, err := shipper_util.SafeOpenBoltdbFile(downloadAt)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close db", "path", downloadAt, "err", err)
+		}
+	}()
+
+	return retention.ForEachChunk(ctx, tableName, db, a.schemaConfig, fn)
+}
+
+// aggregateChunks fetches the chunks and adds their entries within the interval to the builder.
+func (a *volumeAggregator) aggregateChunks(ctx context.Context, userID string, chunks []volumeChunk, interval model.Interval, builder *volume.Builder) error {
+	toFetch := make([]chunk.Chunk, 0, len(chunks))
+	labelsByKey := make(map[string]string, len(chunks))
+	for _, c := range chunks {
+		parsed, err := chunk.ParseExternalKey(userID, c.key)
+		if err != nil {
+			return err
+		}
+		toFetch = append(toFetch, parsed)
+		labelsByKey[c.key] = c.labels
+	}
+
+	fetched, err := a.chunkClient.GetChunks(ctx, toFetch)
+	if err != nil {
+		return err
+	}
+
+	from, through := interval.Start.Time(), interval.End.Time()
+	for _, c := range fetched {
+		lbs := labelsByKey[c.ExternalKey()]
+		lokiChunk := c.Data.(*chunkenc.Facade).LokiChunk()
+		it, err := lokiChunk.Iterator(ctx, from, through.Add(time.Millisecond), logproto.FORWARD, log.NewNoopPipeline().ForStream(nil))
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			entry := it.Entry()
+			builder.Add(lbs, entry.Timestamp, len(entry.Line))
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VolumeResponse is the response of the volume endpoint.
+type VolumeResponse struct {
+	Volumes []volume.Volume `json:"volumes"`
+	// MissingTables lists the tables overlapping with the requested time range whose volume is not aggregated yet.
+	MissingTables []string `json:"missing_tables,omitempty"`
+	// FailedTables lists the tables overlapping with the requested time range whose volume failed to be read, the
+	// volumes being partial.
+	FailedTables []string `json:"failed_tables,omitempty"`
+}
+
+// volume sums the aggregated volume of the streams of the user matching all the matchers over [from, through], grouped by the given labels.
+// The tables failing to be read are reported in the response with the volume of the other tables, it only fails when
+// all the tables failed.
+func (a *volumeAggregator) volume(ctx context.Context, userID string, matchers []*labels.Matcher, by []string, from, through model.Time) (*VolumeResponse, error) {
+	aggregator := volume.NewAggregator(matchers, by, from, through)
+	resp := &VolumeResponse{}
+
+	tableNames := a.tablesFor(from, through)
+	var errs loki_util.MultiError
+	for _, tableName := range tableNames {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		missing, err := a.addTableVolume(ctx, tableName, userID, aggregator)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to read volume of table", "table-name", tableName, "err", err)
+			errs.Add(errors.Wrapf(err, "table %s", tableName))
+			resp.FailedTables = append(resp.FailedTables, tableName)
+			continue
+		}
+		if missing {
+			resp.MissingTables = append(resp.MissingTables, tableName)
+		}
+	}
+	if len(errs) > 0 && len(errs) == len(tableNames) {
+		return nil, errs.Err()
+	}
+
+	resp.Volumes = aggregator.Volumes()
+	return resp, nil
+}
+
+// addTableVolume adds the aggregated volume of the user in the table to the aggregator, it returns true if the volume
+// of the table is not aggregated yet.
+func (a *volumeAggregator) addTableVolume(ctx context.Context, tableName, userID string, aggregator *volume.Aggregator) (bool, error) {
+	complete, err := a.store.IsComplete(ctx, tableName)
+	if err != nil {
+		return false, err
+	}
+	if !complete {
+		return true, nil
+	}
+
+	v, err := a.store.Get(ctx, tableName, userID)
+	if err != nil || v == nil {
+		return false, err
+	}
+	return false, aggregator.Add(v)
+}
+
+// tablesFor returns the names of the boltdb-shipper tables overlapping with [from, through].
+func (a *volumeAggregator) tablesFor(from, through model.Time) []string {
+	var tableNames []string
+	configs := a.schemaConfig.Configs
+	for i, cfg := range configs {
+		if cfg.IndexType != shipper.BoltDBShipperType || cfg.IndexTables.Period <= 0 {
+			continue
+		}
+
+		start, end := cfg.From.Time, through
+		if i+1 < len(configs) && configs[i+1].From.Time.Add(-1) < end {
+			end = configs[i+1].From.Time.Add(-1)
+		}
+		if start < from {
+			start = from
+		}
+
+		period := int64(cfg.IndexTables.Period / time.Millisecond)
+		for t := int64(start) - int64(start)%period; t <= int64(end); t += period {
+			tableNames = append(tableNames, cfg.IndexTables.TableFor(model.Time(t)))
+		}
+	}
+	return tableNames
+}
+
+// runVolumeAggregation aggregates the volume of tables at every compaction interval.
+func (c *Compactor) runVolumeAggregation(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.CompactionInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.volumeAggregator.run(ctx); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to aggregate volume", "err", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// VolumeHandler responds with the volume of the streams of the tenant matching a selector over a time range, grouped by
+// labels, computed from the aggregates of the tables. It accepts the query parameter for the stream selector, start and
+// end defaulting to the last 24 hours, and by for a comma separated list of labels to group by.
+func (c *Compactor) VolumeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := r.URL.Query()
+
+	matchers, err := parser.ParseMetricSelector(params.Get("query"))
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, "invalid query: %v", err)
+		return
+	}
+
+	through := model.Now()
+	if endParam := params.Get("end"); endParam != "" {
+		end, err := util.ParseTime(endParam)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, "invalid value for end: %v", err)
+			return
+		}
+		through = model.Time(end)
+	}
+
+	from := through.Add(-24 * time.Hour)
+	if startParam := params.Get("start"); startParam != "" {
+		start, err := util.ParseTime(startParam)
+		if err != nil {
+			serverutil.JSONError(w, http.StatusBadRequest, "invalid value for start: %v", err)
+			return
+		}
+		from = model.Time(start)
+	}
+
+	if from > through {
+		serverutil.JSONError(w, http.StatusBadRequest, "start time can't be greater than end time")
+		return
+	}
+
+	var by []string
+	if byParam := params.Get("by"); byParam != "" {
+		by = strings.Split(byParam, ",")
+	}
+
+	resp, err := c.volumeAggregator.volume(r.Context(), userID, matchers, by, from, through)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting volume", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	loki_storage "github.com/grafana/loki/pkg/storage"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+	"github.com/grafana/loki/pkg/storage/chunk/objectclient"
+	"github.com/grafana/loki/pkg/storage/stores/shipper"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/volume"
+)
+
+var volumeSchemaCfg = loki_storage.SchemaConfig{
+	SchemaConfig: chunk.SchemaConfig{
+		Configs: []chunk.PeriodConfig{
+			{
+				From:      chunk.DayTime{Time: 0},
+				IndexType: "boltdb",
+				Schema:    "v10",
+				IndexTables: chunk.PeriodicTableConfig{
+					Prefix: "index_",
+					Period: 24 * time.Hour,
+				},
+			},
+			{
+				From:      chunk.DayTime{Time: model.TimeFromUnix(10 * 86400)},
+				IndexType: shipper.BoltDBShipperType,
+				Schema:    "v11",
+				IndexTables: chunk.PeriodicTableConfig{
+					Prefix: "index_",
+					Period: 24 * time.Hour,
+				},
+			},
+		},
+	},
+}
+
+func newTestVolumeAggregator(t *testing.T) (*volumeAggregator, chunk.Client) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	chunkClient := objectclient.NewClient(objectClient, objectclient.Base64Encoder, chunk.SchemaConfig{})
+
+	return newVolumeAggregator(t.TempDir(), nil, volumeSchemaCfg, chunkClient, volume.NewStore(objectClient, "volume/"), time.Hour, newMetrics(nil)), chunkClient
+}
+
+func Test_volumeAggregator_aggregateChunks(t *testing.T) {
+	a, chunkClient := newTestVolumeAggregator(t)
+
+	// the chunk spans over two tables, only entries within the table are accounted for.
+	tableStart := model.TimeFromUnix(11 * 86400)
+	from, through := tableStart.Add(-30*time.Minute), tableStart.Add(90*time.Minute-time.Minute)
+	c := createTestChunk(t, "fake", labels.Labels{{Name: "app", Value: "foo"}}, from, through)
+	require.NoError(t, chunkClient.PutChunks(context.Background(), []chunk.Chunk{c}))
+
+	builder := volume.NewBuilder()
+	interval := model.Interval{Start: tableStart, End: tableStart.Add(24*time.Hour) - 1}
+	require.NoError(t, a.aggregateChunks(context.Background(), "fake", []volumeChunk{{key: c.ExternalKey(), labels: `{app="foo"}`}}, interval, builder))
+
+	require.Equal(t, &volume.TableVolume{
+		Streams: []volume.StreamVolume{
+			{
+				Labels: `{app="foo"}`,
+				Hours: []volume.HourVolume{
+					{Hour: tableStart.Unix(), Count: 60, Bytes: 60 * 5},
+					{Hour: tableStart.Unix() + 3600, Count: 30, Bytes: 30 * 5},
+				},
+			},
+		},
+	}, builder.Build())
+}
+
+func Test_volumeAggregator_tablesFor(t *testing.T) {
+	a, _ := newTestVolumeAggregator(t)
+
+	// only tables of boltdb-shipper periods are returned.
+	require.Equal(t, []string{"index_10", "index_11", "index_12"}, a.tablesFor(model.TimeFromUnix(8*86400), model.TimeFromUnix(12*86400+10)))
+	require.Equal(t, []string{"index_11"}, a.tablesFor(model.TimeFromUnix(11*86400+10), model.TimeFromUnix(11*86400+20)))
+	require.Empty(t, a.tablesFor(model.TimeFromUnix(86400), model.TimeFromUnix(2*86400)))
+}
+
+func TestCompactor_VolumeHandler(t *testing.T) {
+	a, _ := newTestVolumeAggregator(t)
+	ctx := context.Background()
+
+	require.NoError(t, a.store.Put(ctx, "index_10", "fake", &volume.TableVolume{
+		Streams: []volume.StreamVolume{
+			{Labels: `{app="foo", env="dev"}`, Hours: []volume.HourVolume{{Hour: 10 * 86400, Count: 2, Bytes: 20}}},
+			{Labels: `{app="foo", env="prod"}`, Hours: []volume.HourVolume{{Hour: 10 * 86400, Count: 3, Bytes: 30}}},
+			{Labels: `{app="bar", env="prod"}`, Hours: []volume.HourVolume{{Hour: 10 * 86400, Count: 1, Bytes: 10}}},
+		},
+	}))
+	require.NoError(t, a.store.MarkComplete(ctx, "index_10"))
+
+	c := &Compactor{volumeAggregator: a}
+
+	req := httptest.NewRequest(http.MethodGet, `/loki/api/v1/index/volume?query={app="foo"}&by=app&start=864000&end=950400`, nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "fake"))
+	w := httptest.NewRecorder()
+	c.VolumeHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp VolumeResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, VolumeResponse{
+		Volumes:       []volume.Volume{{Labels: map[string]string{"app": "foo"}, Count: 5, Bytes: 50}},
+		MissingTables: []string{"index_11"},
+	}, resp)
+
+	for _, url := range []string{
+		`/loki/api/v1/index/volume`,
+		`/loki/api/v1/index/volume?query={app="foo"}&start=foo`,
+		`/loki/api/v1/index/volume?query={app="foo"}&start=20&end=10`,
+	} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "fake"))
+		w := httptest.NewRecorder()
+		c.VolumeHandler(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func Test_volumeAggregator_volumePartial(t *testing.T) {
+	a, _ := newTestVolumeAggregator(t)
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	a.store = volume.NewStore(objectClient, "volume/")
+	ctx := context.Background()
+
+	require.NoError(t, a.store.Put(ctx, "index_10", "fake", &volume.TableVolume{
+		Streams: []volume.StreamVolume{
+			{Labels: `{app="foo"}`, Hours: []volume.HourVolume{{Hour: 10 * 86400, Count: 2, Bytes: 20}}},
+		},
+	}))
+	require.NoError(t, a.store.MarkComplete(ctx, "index_10"))
+	// The volume of index_11 is corrupted.
+	require.NoError(t, objectClient.PutObject(ctx, "volume/index_11/fake.json.gz", strings.NewReader("not gzip")))
+	require.NoError(t, a.store.MarkComplete(ctx, "index_11"))
+
+	// The volume of the other tables is returned along with the failed tables.
+	resp, err := a.volume(ctx, "fake", nil, nil, model.TimeFromUnix(10*86400), model.TimeFromUnix(12*86400))
+	require.NoError(t, err)
+	require.Equal(t, &VolumeResponse{
+		Volumes:       []volume.Volume{{Labels: map[string]string{"app": "foo"}, Count: 2, Bytes: 20}},
+		MissingTables: []string{"index_12"},
+		FailedTables:  []string{"index_11"},
+	}, resp)
+
+	// It fails when all the tables failed.
+	_, err = a.volume(ctx, "fake", nil, nil, model.TimeFromUnix(11*86400), model.TimeFromUnix(11*86400+10))
+	require.Error(t, err)
+}
+
+func createTestChunk(t *testing.T, userID string, lbs labels.Labels, from, through model.Time) chunk.Chunk {
+	t.Helper()
+	const (
+		targetSize = 1500 * 1024
+		blockSize  = 256 * 1024
+	)
+	labelsBuilder := labels.NewBuilder(lbs)
+	labelsBuilder.Set(labels.MetricName, "logs")
+	metric := labelsBuilder.Labels()
+	fp := client.Fingerprint(lbs)
+	chunkEnc := chunkenc.NewMemChunk(chunkenc.EncSnappy, chunkenc.UnorderedHeadBlockFmt, blockSize, targetSize)
+
+	for ts := from; !ts.After(through); ts = ts.Add(time.Minute) {
+		require.NoError(t, chunkEnc.Append(&logproto.Entry{
+			Timestamp: ts.Time(),
+			Line:      "hello",
+		}))
+	}
+
+	require.NoError(t, chunkEnc.Close())
+	c := chunk.NewChunk(userID, fp, metric, chunkenc.NewFacade(chunkEnc, blockSize, targetSize), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
@@ -0,0 +1,122 @@
+package compactor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a daily window of time in UTC defined by its start and end as offsets from midnight.
+// A window having its end before its start spans across midnight, e.g 22:00-02:00.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains returns true if time of the day of t in UTC falls within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if w.Start <= w.End {
+		return w.Start <= offset && offset < w.End
+	}
+
+	return offset >= w.Start || offset < w.End
+}
+
+func (w TimeWindow) String() string {
+	return fmt.Sprintf("%s-%s", formatTimeOfDay(w.Start), formatTimeOfDay(w.End))
+}
+
+// TimeWindows is a list of TimeWindow which can be configured as a comma separated list of windows in HH:MM-HH:MM format.
+// It implements flag.Value and yaml.Unmarshaler.
+type TimeWindows []TimeWindow
+
+// Contains returns true if t falls within any of the windows or if there are no windows configured.
+func (w TimeWindows) Contains(t time.Time) bool {
+	if len(w) == 0 {
+		return true
+	}
+
+	for _, window := range w {
+		if window.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w TimeWindows) String() string {
+	windows := make([]string, 0, len(w))
+	for _, window := range w {
+		windows = append(windows, window.String())
+	}
+
+	return strings.Join(windows, ",")
+}
+
+// Set implements flag.Value
+func (w *TimeWindows) Set(s string) error {
+	var windows TimeWindows
+
+	for _, window := range strings.Split(s, ",") {
+		window = strings.TrimSpace(window)
+		if window == "" {
+			continue
+		}
+
+		parts := strings.Split(window, "-")
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid time window %q, expected format is HH:MM-HH:MM", window)
+		}
+
+		start, err := parseTimeOfDay(parts[0])
+		if err != nil {
+			return fmt.Errorf("invalid start of time window %q: %w", window, err)
+		}
+
+		end, err := parseTimeOfDay(parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid end of time window %q: %w", window, err)
+		}
+
+		if start == end {
+			return fmt.Errorf("invalid time window %q, start and end can't be same", window)
+		}
+
+		windows = append(windows, TimeWindow{Start: start, End: end})
+	}
+
+	*w = windows
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (w *TimeWindows) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	return w.Set(s)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (w TimeWindows) MarshalYAML() (interface{}, error) {
+	return w.String(), nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
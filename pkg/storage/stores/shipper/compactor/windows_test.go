@@ -0,0 +1,77 @@
+package compactor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWindows(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2021, 12, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	for _, tc := range []struct {
+		name        string
+		windows     string
+		contains    []time.Time
+		notContains []time.Time
+	}{
+		{
+			name:     "no windows",
+			contains: []time.Time{at(0, 0), at(12, 30), at(23, 59)},
+		},
+		{
+			name:        "single window",
+			windows:     "01:00-05:00",
+			contains:    []time.Time{at(1, 0), at(3, 30), at(4, 59)},
+			notContains: []time.Time{at(0, 59), at(5, 0), at(12, 0)},
+		},
+		{
+			name:        "window spanning across midnight",
+			windows:     "22:00-02:00",
+			contains:    []time.Time{at(22, 0), at(23, 59), at(0, 0), at(1, 59)},
+			notContains: []time.Time{at(2, 0), at(12, 0), at(21, 59)},
+		},
+		{
+			name:        "multiple windows",
+			windows:     "01:00-05:00, 12:00-13:00",
+			contains:    []time.Time{at(1, 0), at(12, 30)},
+			notContains: []time.Time{at(6, 0), at(13, 0)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var windows TimeWindows
+			require.NoError(t, windows.Set(tc.windows))
+
+			for _, ts := range tc.contains {
+				require.True(t, windows.Contains(ts), ts)
+			}
+
+			for _, ts := range tc.notContains {
+				require.False(t, windows.Contains(ts), ts)
+			}
+
+			// windows should be same after a round trip through String.
+			var parsed TimeWindows
+			require.NoError(t, parsed.Set(windows.String()))
+			require.Equal(t, windows, parsed)
+		})
+	}
+}
+
+func TestTimeWindows_SetInvalid(t *testing.T) {
+	for _, windows := range []string{
+		"01:00",
+		"01:00-05:00-06:00",
+		"1am-5am",
+		"25:00-05:00",
+		"01:00-01:00",
+	} {
+		t.Run(windows, func(t *testing.T) {
+			var w TimeWindows
+			require.Error(t, w.Set(windows))
+		})
+	}
+}
@@ -56,6 +56,19 @@ type metrics struct {
 	tablesDownloadSizeBytes       *downloadTableBytesMetric
 
 	tablesSyncOperationTotal *prometheus.CounterVec
+	tablesInvalidatedTotal   prometheus.Counter
+
+	tablesPrefetchedTotal      prometheus.Counter
+	tablesPrefetchSkippedTotal prometheus.Counter
+
+	tablesEvictedTotal prometheus.Counter
+	cacheSizeBytes     prometheus.Gauge
+
+	queriesCoalescedTotal prometheus.Counter
+
+	filesQuarantinedTotal        prometheus.Counter
+	unavailableTableQueriesTotal prometheus.Counter
+	corruptFilesSkippedTotal     prometheus.Counter
 }
 
 func newMetrics(r prometheus.Registerer) *metrics {
@@ -79,6 +92,51 @@ func newMetrics(r prometheus.Registerer) *metrics {
 			Name:      "tables_sync_operation_total",
 			Help:      "Total number of tables sync operations done by status",
 		}, []string{"status"}),
+		tablesInvalidatedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_invalidated_total",
+			Help:      "Total number of downloaded tables synced ahead of the resync interval because the compactor invalidated them",
+		}),
+		tablesPrefetchedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_prefetched_total",
+			Help:      "Total number of tables downloaded in the background based on query patterns",
+		}),
+		tablesPrefetchSkippedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_prefetch_skipped_total",
+			Help:      "Total number of times prefetching of tables was skipped due to disk usage limit",
+		}),
+		tablesEvictedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "tables_evicted_total",
+			Help:      "Total number of downloaded tables evicted due to cache size limit",
+		}),
+		cacheSizeBytes: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "cache_size_bytes",
+			Help:      "Size of the downloaded tables in bytes",
+		}),
+		queriesCoalescedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "queries_coalesced_total",
+			Help:      "Total number of index queries served from an identical query running concurrently",
+		}),
+		filesQuarantinedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "files_quarantined_total",
+			Help:      "Total number of downloaded index files moved to quarantine because they failed to open",
+		}),
+		unavailableTableQueriesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "unavailable_table_queries_total",
+			Help:      "Total number of queries failed because some index files of the table failed to open",
+		}),
+		corruptFilesSkippedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "corrupt_files_skipped_total",
+			Help:      "Total number of index files which failed to open skipped by queries, which miss the chunks indexed in them",
+		}),
 	}
 
 	return m
@@ -0,0 +1,144 @@
+package downloads
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tableNumberLength is the length of the numeric suffix of daily tables i.e tables created with 24h period.
+const tableNumberLength = 5
+
+// queryPattern identifies tables relative to the currently active table.
+// We track the age of queried tables instead of their names so that the patterns remain useful as days roll over,
+// e.g. a dashboard querying last 7 days of logs would keep on querying the tables with age 0-7.
+type queryPattern struct {
+	tablePrefix string
+	age         int64
+}
+
+// queryPatternTracker tracks the tables being queried by each tenant to let us proactively download the tables which are likely going to be queried.
+// All the public methods are concurrency safe.
+type queryPatternTracker struct {
+	lookback time.Duration
+
+	patterns    map[string]map[queryPattern]time.Time
+	patternsMtx sync.Mutex
+}
+
+func newQueryPatternTracker(lookback time.Duration) *queryPatternTracker {
+	return &queryPatternTracker{
+		lookback: lookback,
+		patterns: map[string]map[queryPattern]time.Time{},
+	}
+}
+
+// Track records the query done by the tenant on the given table at the given time.
+// Tables not following the daily table naming convention are ignored.
+func (q *queryPatternTracker) Track(userID, tableName string, now time.Time) {
+	tablePrefix, tableNumber, ok := extractTableNumber(tableName)
+	if !ok {
+		return
+	}
+
+	pattern := queryPattern{
+		tablePrefix: tablePrefix,
+		age:         tableNumberAt(now) - tableNumber,
+	}
+
+	q.patternsMtx.Lock()
+	defer q.patternsMtx.Unlock()
+
+	userPatterns, ok := q.patterns[userID]
+	if !ok {
+		userPatterns = map[queryPattern]time.Time{}
+		q.patterns[userID] = userPatterns
+	}
+
+	userPatterns[pattern] = now
+}
+
+// TablesToPrefetch removes the patterns which were not seen within the lookback window and
+// returns names of tables covered by the remaining patterns, sorted by age to prioritize prefetching of the most recent tables.
+func (q *queryPatternTracker) TablesToPrefetch(now time.Time) []string {
+	q.patternsMtx.Lock()
+	defer q.patternsMtx.Unlock()
+
+	activeTableNumber := tableNumberAt(now)
+	uniquePatterns := map[queryPattern]struct{}{}
+
+	for userID, userPatterns := range q.patterns {
+		for pattern, lastSeenAt := range userPatterns {
+			if lastSeenAt.Add(q.lookback).Before(now) {
+				delete(userPatterns, pattern)
+				continue
+			}
+
+			uniquePatterns[pattern] = struct{}{}
+		}
+
+		if len(userPatterns) == 0 {
+			delete(q.patterns, userID)
+		}
+	}
+
+	patterns := make([]queryPattern, 0, len(uniquePatterns))
+	for pattern := range uniquePatterns {
+		// skip the tables for future periods which could have been queried due to clock skew.
+		if pattern.age < 0 {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].age != patterns[j].age {
+			return patterns[i].age < patterns[j].age
+		}
+		return patterns[i].tablePrefix < patterns[j].tablePrefix
+	})
+
+	tableNames := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		tableNames = append(tableNames, pattern.tablePrefix+strconv.FormatInt(activeTableNumber-pattern.age, 10))
+	}
+
+	return tableNames
+}
+
+// extractTableNumber splits the name of a daily table into its prefix and number.
+func extractTableNumber(tableName string) (string, int64, bool) {
+	if len(tableName) <= tableNumberLength {
+		return "", 0, false
+	}
+
+	tableNumber, err := strconv.ParseInt(tableName[len(tableName)-tableNumberLength:], 10, 64)
+	if err != nil || tableNumber < 0 {
+		return "", 0, false
+	}
+
+	return tableName[:len(tableName)-tableNumberLength], tableNumber, true
+}
+
+func tableNumberAt(t time.Time) int64 {
+	return t.Unix() / int64(durationDay/time.Second)
+}
+
+// diskUsage returns the total size of all the files in the given directory.
+func diskUsage(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
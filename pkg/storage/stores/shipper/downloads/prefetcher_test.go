@@ -0,0 +1,64 @@
+package downloads
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryPatternTracker(t *testing.T) {
+	now := time.Now()
+	activeTableNumber := tableNumberAt(now)
+	lookback := time.Hour
+
+	tracker := newQueryPatternTracker(lookback)
+
+	// tables not following the daily table naming convention should be ignored.
+	tracker.Track("user1", "foo", now)
+	require.Len(t, tracker.TablesToPrefetch(now), 0)
+
+	tracker.Track("user1", fmt.Sprintf("index_%d", activeTableNumber-2), now.Add(-2*time.Hour))
+	tracker.Track("user1", fmt.Sprintf("index_%d", activeTableNumber-1), now)
+	tracker.Track("user2", fmt.Sprintf("index_%d", activeTableNumber-1), now)
+	tracker.Track("user2", fmt.Sprintf("index_%d", activeTableNumber), now)
+
+	// pattern seen before the lookback window should be dropped and the rest should be sorted by age without duplicates.
+	require.Equal(t, []string{
+		fmt.Sprintf("index_%d", activeTableNumber),
+		fmt.Sprintf("index_%d", activeTableNumber-1),
+	}, tracker.TablesToPrefetch(now))
+
+	// patterns should be tracked relative to the active table so they should move along as the days roll over.
+	nextDay := now.Add(durationDay)
+	tracker.Track("user1", fmt.Sprintf("index_%d", activeTableNumber-1), nextDay.Add(-lookback/2))
+	require.Equal(t, []string{
+		fmt.Sprintf("index_%d", activeTableNumber-1),
+	}, tracker.TablesToPrefetch(nextDay))
+
+	// all the patterns are gone after lookback window so we should not have anything being tracked.
+	require.Len(t, tracker.TablesToPrefetch(nextDay.Add(lookback)), 0)
+	require.Len(t, tracker.patterns, 0)
+}
+
+func TestExtractTableNumber(t *testing.T) {
+	for _, tc := range []struct {
+		tableName      string
+		expectedPrefix string
+		expectedNumber int64
+		expectedOk     bool
+	}{
+		{tableName: "index_18500", expectedPrefix: "index_", expectedNumber: 18500, expectedOk: true},
+		{tableName: "18500"},
+		{tableName: "index_foo12"},
+		{tableName: "foo"},
+	} {
+		t.Run(tc.tableName, func(t *testing.T) {
+			prefix, number, ok := extractTableNumber(tc.tableName)
+			require.Equal(t, tc.expectedOk, ok)
+			require.Equal(t, tc.expectedPrefix, prefix)
+			require.Equal(t, tc.expectedNumber, number)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package downloads
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/util"
+)
+
+// queryCoalescer coalesces identical index queries running concurrently against the same table, like the ones
+// issued by the shards of a sharded query. Only the first of them scans the index files, the rows it reads are
+// then fanned out to all the callers waiting on it.
+type queryCoalescer struct {
+	mtx      sync.Mutex
+	inflight map[string]*inflightQuery
+
+	metrics *metrics
+}
+
+type inflightQuery struct {
+	done chan struct{}
+	rows []indexRow
+	err  error
+}
+
+type indexRow struct {
+	rangeValue, value []byte
+}
+
+func newQueryCoalescer(metrics *metrics) *queryCoalescer {
+	return &queryCoalescer{
+		inflight: map[string]*inflightQuery{},
+		metrics:  metrics,
+	}
+}
+
+// MultiQueries runs the queries against the table, waiting for the results of the identical queries already
+// in flight instead of running them again.
+func (c *queryCoalescer) MultiQueries(ctx context.Context, table util.TableQuerier, queries []chunk.IndexQuery, callback chunk_util.Callback) error {
+	var (
+		toRun   []chunk.IndexQuery
+		led     = map[string]*inflightQuery{}
+		pending = make([]*inflightQuery, 0, len(queries))
+	)
+
+	c.mtx.Lock()
+	for _, query := range queries {
+		key := queryKey(query)
+		inflight, ok := c.inflight[key]
+		if ok {
+			if _, own := led[key]; !own {
+				c.metrics.queriesCoalescedTotal.Inc()
+			}
+		} else {
+			inflight = &inflightQuery{done: make(chan struct{})}
+			c.inflight[key] = inflight
+			led[key] = inflight
+			toRun = append(toRun, query)
+		}
+		pending = append(pending, inflight)
+	}
+	c.mtx.Unlock()
+
+	if len(toRun) > 0 {
+		err := table.MultiQueries(ctx, toRun, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+			inflight := led[queryKey(query)]
+			for iter := batch.Iterator(); iter.Next(); {
+				inflight.rows = append(inflight.rows, indexRow{rangeValue: iter.RangeValue(), value: iter.Value()})
+			}
+			return true
+		})
+
+		c.mtx.Lock()
+		for key, inflight := range led {
+			inflight.err = err
+			delete(c.inflight, key)
+			close(inflight.done)
+		}
+		c.mtx.Unlock()
+	}
+
+	for i, inflight := range pending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-inflight.done:
+		}
+
+		if inflight.err != nil {
+			if _, own := led[queryKey(queries[i])]; own {
+				return inflight.err
+			}
+			// the query failed for the caller which ran it, e.g. because its context got canceled, run it again.
+			if err := table.MultiQueries(ctx, queries[i:i+1], callback); err != nil {
+				return err
+			}
+			continue
+		}
+
+		callback(queries[i], rowsBatch(inflight.rows))
+	}
+
+	return nil
+}
+
+// coalescingTable runs the queries of a table through a queryCoalescer.
+type coalescingTable struct {
+	table     util.TableQuerier
+	coalescer *queryCoalescer
+}
+
+func (t coalescingTable) MultiQueries(ctx context.Context, queries []chunk.IndexQuery, callback chunk_util.Callback) error {
+	return t.coalescer.MultiQueries(ctx, t.table, queries, callback)
+}
+
+func queryKey(q chunk.IndexQuery) string {
+	const sep = "\xff"
+	key := q.TableName + sep + q.HashValue + sep + string(q.RangeValuePrefix) + sep + string(q.RangeValueStart) + sep + string(q.ValueEqual)
+	if q.ValueMatcher != nil {
+		key += sep + q.ValueMatcher.String()
+	}
+	return key
+}
+
+type rowsBatch []indexRow
+
+func (b rowsBatch) Iterator() chunk.ReadBatchIterator {
+	return &rowsBatchIter{rows: b, i: -1}
+}
+
+type rowsBatchIter struct {
+	rows []indexRow
+	i    int
+}
+
+func (it *rowsBatchIter) Next() bool {
+	it.i++
+	return it.i < len(it.rows)
+}
+
+func (it *rowsBatchIter) RangeValue() []byte {
+	return it.rows[it.i].rangeValue
+}
+
+func (it *rowsBatchIter) Value() []byte {
+	return it.rows[it.i].value
+}
@@ -0,0 +1,122 @@
+package downloads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
+)
+
+type mockTableQuerier struct {
+	mtx     sync.Mutex
+	queried map[string]int
+
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newMockTableQuerier() *mockTableQuerier {
+	return &mockTableQuerier{
+		queried: map[string]int{},
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (m *mockTableQuerier) MultiQueries(ctx context.Context, queries []chunk.IndexQuery, callback chunk_util.Callback) error {
+	m.started <- struct{}{}
+	<-m.release
+
+	m.mtx.Lock()
+	for _, query := range queries {
+		m.queried[query.HashValue]++
+	}
+	m.mtx.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, query := range queries {
+		callback(query, rowsBatch{{rangeValue: []byte(query.HashValue), value: []byte("value")}})
+	}
+	return nil
+}
+
+func runCoalescedQueries(t *testing.T, coalescer *queryCoalescer, table *mockTableQuerier, queries []chunk.IndexQuery) (<-chan error, *[]string) {
+	var (
+		mtx  sync.Mutex
+		rows []string
+		errs = make(chan error, 1)
+	)
+
+	go func() {
+		errs <- coalescer.MultiQueries(context.Background(), table, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) bool {
+			mtx.Lock()
+			defer mtx.Unlock()
+			for iter := batch.Iterator(); iter.Next(); {
+				rows = append(rows, fmt.Sprintf("%s:%s", iter.RangeValue(), iter.Value()))
+			}
+			return true
+		})
+	}()
+
+	return errs, &rows
+}
+
+func TestQueryCoalescer_MultiQueries(t *testing.T) {
+	m := newMetrics(prometheus.NewRegistry())
+	coalescer := newQueryCoalescer(m)
+	table := newMockTableQuerier()
+
+	queries := []chunk.IndexQuery{{TableName: "table", HashValue: "a"}, {TableName: "table", HashValue: "b"}}
+
+	// the first caller starts scanning the index and blocks until released.
+	errs1, rows1 := runCoalescedQueries(t, coalescer, table, queries)
+	<-table.started
+
+	// the second caller has one of its queries in flight, only the other one gets run.
+	errs2, rows2 := runCoalescedQueries(t, coalescer, table, []chunk.IndexQuery{queries[0], {TableName: "table", HashValue: "c"}})
+	<-table.started
+
+	close(table.release)
+	require.NoError(t, <-errs1)
+	require.NoError(t, <-errs2)
+
+	require.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1}, table.queried)
+	require.ElementsMatch(t, []string{"a:value", "b:value"}, *rows1)
+	require.ElementsMatch(t, []string{"a:value", "c:value"}, *rows2)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.queriesCoalescedTotal))
+	require.Empty(t, coalescer.inflight)
+}
+
+func TestQueryCoalescer_MultiQueriesFailure(t *testing.T) {
+	coalescer := newQueryCoalescer(newMetrics(prometheus.NewRegistry()))
+	table := newMockTableQuerier()
+	table.err = errors.New("failed")
+
+	query := chunk.IndexQuery{TableName: "table", HashValue: "a"}
+
+	errs1, _ := runCoalescedQueries(t, coalescer, table, []chunk.IndexQuery{query})
+	<-table.started
+
+	errs2, _ := runCoalescedQueries(t, coalescer, table, []chunk.IndexQuery{query})
+
+	// the second caller has to wait for the first one before running the query itself.
+	close(table.release)
+	require.EqualError(t, <-errs1, "failed")
+	<-table.started
+	require.EqualError(t, <-errs2, "failed")
+
+	require.Equal(t, map[string]int{"a": 2}, table.queried)
+	require.Empty(t, coalescer.inflight)
+}
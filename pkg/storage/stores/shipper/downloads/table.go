@@ -2,6 +2,7 @@ package downloads
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -24,10 +25,16 @@ import (
 	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
 )
 
+// errCorruptFile is returned when an index file still fails to open after being downloaded again.
+var errCorruptFile = errors.New("index file failed to open")
+
 // timeout for downloading initial files for a table to avoid leaking resources by allowing it to take all the time.
 const (
 	downloadTimeout     = 5 * time.Minute
 	downloadParallelism = 50
+
+	// quarantineDirName is the directory of the cache location where index files failing to open are moved.
+	quarantineDirName = ".quarantine"
 )
 
 var bucketName = []byte("index")
@@ -41,6 +48,7 @@ type StorageClient interface {
 	ListFiles(ctx context.Context, tableName string) ([]storage.IndexFile, error)
 	GetFile(ctx context.Context, tableName, fileName string) (io.ReadCloser, error)
 	IsFileNotFoundErr(err error) bool
+	ListInvalidatedTables(ctx context.Context) (map[string]time.Time, error)
 }
 
 // Table is a collection of multiple files created for a same table by various ingesters.
@@ -51,17 +59,22 @@ type Table struct {
 	metrics           *metrics
 	storageClient     StorageClient
 	boltDBIndexClient BoltDBIndexClient
+	openTimeout       time.Duration
+	failOnCorrupt     bool
 
 	lastUsedAt time.Time
 	dbs        map[string]*bbolt.DB
 	dbsMtx     sync.RWMutex
 	err        error
+	// corruptFiles are the files of the table failing to open, with the time they were modified in the storage, which
+	// make the index of the table unavailable when failOnCorrupt is set, and are skipped otherwise.
+	corruptFiles map[string]time.Time
 
 	ready      chan struct{}      // helps with detecting initialization of table which downloads all the existing files.
 	cancelFunc context.CancelFunc // helps with cancellation of initialization if we are asked to stop.
 }
 
-func NewTable(spanCtx context.Context, name, cacheLocation string, storageClient StorageClient, boltDBIndexClient BoltDBIndexClient, metrics *metrics) *Table {
+func NewTable(spanCtx context.Context, name, cacheLocation string, storageClient StorageClient, boltDBIndexClient BoltDBIndexClient, openTimeout time.Duration, failOnCorruptFiles bool, metrics *metrics) *Table {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	table := Table{
@@ -70,8 +83,11 @@ func NewTable(spanCtx context.Context, name, cacheLocation string, storageClient
 		metrics:           metrics,
 		storageClient:     storageClient,
 		boltDBIndexClient: boltDBIndexClient,
+		openTimeout:       openTimeout,
+		failOnCorrupt:     failOnCorruptFiles,
 		lastUsedAt:        time.Now(),
 		dbs:               map[string]*bbolt.DB{},
+		corruptFiles:      map[string]time.Time{},
 		ready:             make(chan struct{}),
 		cancelFunc:        cancel,
 	}
@@ -99,7 +115,7 @@ func NewTable(spanCtx context.Context, name, cacheLocation string, storageClient
 }
 
 // LoadTable loads a table from local storage(syncs the table too if we have it locally) or downloads it from the shared store.
-func LoadTable(ctx context.Context, name, cacheLocation string, storageClient StorageClient, boltDBIndexClient BoltDBIndexClient, metrics *metrics) (*Table, error) {
+func LoadTable(ctx context.Context, name, cacheLocation string, storageClient StorageClient, boltDBIndexClient BoltDBIndexClient, openTimeout time.Duration, failOnCorruptFiles bool, metrics *metrics) (*Table, error) {
 	// see if folder for table already exists.
 	folderPath := path.Join(cacheLocation, name)
 	_, err := os.Stat(folderPath)
@@ -109,7 +125,7 @@ func LoadTable(ctx context.Context, name, cacheLocation string, storageClient St
 		}
 
 		// folder for table doesn't exist, this means we have to download it from the shared store.
-		table := NewTable(ctx, name, cacheLocation, storageClient, boltDBIndexClient, metrics)
+		table := NewTable(ctx, name, cacheLocation, storageClient, boltDBIndexClient, openTimeout, failOnCorruptFiles, metrics)
 		<-table.ready
 		if table.err != nil {
 			return nil, table.err
@@ -129,8 +145,11 @@ func LoadTable(ctx context.Context, name, cacheLocation string, storageClient St
 		metrics:           metrics,
 		storageClient:     storageClient,
 		boltDBIndexClient: boltDBIndexClient,
+		openTimeout:       openTimeout,
+		failOnCorrupt:     failOnCorruptFiles,
 		lastUsedAt:        time.Now(),
 		dbs:               map[string]*bbolt.DB{},
+		corruptFiles:      map[string]time.Time{},
 		ready:             make(chan struct{}),
 		cancelFunc:        func() {},
 	}
@@ -142,17 +161,17 @@ func LoadTable(ctx context.Context, name, cacheLocation string, storageClient St
 			continue
 		}
 
-		fullPath := filepath.Join(folderPath, fileInfo.Name())
-		// if we fail to open a boltdb file, lets skip it and let sync operation re-download the file from storage.
-		boltdb, err := shipper_util.SafeOpenBoltdbFile(fullPath)
+		// Sometimes files get corrupted when the process gets killed in the middle of a download operation,
+		// openDB takes care of getting a fresh copy of them from the storage.
+		// A file failing to open again is tried once more by the sync below, which keeps track of it.
+		boltdb, err := table.openDB(ctx, fileInfo.Name(), filepath.Join(folderPath, fileInfo.Name()))
+		if errors.Is(err, errCorruptFile) {
+			continue
+		}
 		if err != nil {
-			level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to open existing boltdb file %s, removing the file and continuing without it to let the sync operation catch up", fullPath), "err", err)
-			// Sometimes files get corrupted when the process gets killed in the middle of a download operation which causes boltdb client to panic.
-			// We already recover the panic but the lock on the file is not released by boltdb client which causes the reopening of the file to fail when the sync operation tries it.
-			// We want to remove the file failing to open to get rid of the lock.
-			if err := os.Remove(fullPath); err != nil {
-				level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to remove boltdb file %s which failed to open", fullPath))
-			}
+			return nil, err
+		}
+		if boltdb == nil {
 			continue
 		}
 
@@ -223,10 +242,17 @@ func (t *Table) init(ctx context.Context, spanLogger log.Logger) (err error) {
 			level.Info(util_log.Logger).Log("msg", fmt.Sprintf("skipping opening of non-existent file %s, possibly not downloaded due to it being removed during compaction.", filePath))
 			continue
 		}
-		boltdb, err := shipper_util.SafeOpenBoltdbFile(filePath)
+		boltdb, err := t.openDB(ctx, file.Name, filePath)
+		if errors.Is(err, errCorruptFile) {
+			t.corruptFiles[file.Name] = file.ModifiedAt
+			continue
+		}
 		if err != nil {
 			return err
 		}
+		if boltdb == nil {
+			continue
+		}
 
 		var stat os.FileInfo
 		stat, err = os.Stat(filePath)
@@ -280,6 +306,15 @@ func (t *Table) MultiQueries(ctx context.Context, queries []chunk.IndexQuery, ca
 		return t.err
 	}
 
+	// Partial results miss the chunks indexed in the corrupt files, which are listed by the store when it falls back.
+	if len(t.corruptFiles) > 0 {
+		if t.failOnCorrupt {
+			t.metrics.unavailableTableQueriesTotal.Inc()
+			return fmt.Errorf("%w: %d files of table %s failed to open", chunk.ErrIndexUnavailable, len(t.corruptFiles), t.name)
+		}
+		t.metrics.corruptFilesSkippedTotal.Add(float64(len(t.corruptFiles)))
+	}
+
 	t.lastUsedAt = time.Now()
 
 	logger := util_log.WithContext(ctx, util_log.Logger)
@@ -339,6 +374,30 @@ func (t *Table) LastUsedAt() time.Time {
 	return t.lastUsedAt
 }
 
+// Size returns the total size of the files of the table present locally.
+// It returns false if the table is not ready yet i.e the files are still being downloaded.
+func (t *Table) Size() (int64, bool, error) {
+	select {
+	case <-t.ready:
+	default:
+		return 0, false, nil
+	}
+
+	t.dbsMtx.RLock()
+	defer t.dbsMtx.RUnlock()
+
+	var size int64
+	for _, db := range t.dbs {
+		stat, err := os.Stat(db.Path())
+		if err != nil {
+			return 0, false, err
+		}
+		size += stat.Size()
+	}
+
+	return size, true, nil
+}
+
 func (t *Table) UpdateLastUsedAt() {
 	t.lastUsedAt = time.Now()
 }
@@ -382,6 +441,10 @@ func (t *Table) Sync(ctx context.Context) error {
 	defer t.dbsMtx.Unlock()
 
 	for _, db := range toDelete {
+		if _, ok := t.corruptFiles[db]; ok {
+			delete(t.corruptFiles, db)
+			continue
+		}
 		err := t.cleanupDB(db)
 		if err != nil {
 			return err
@@ -413,6 +476,10 @@ func (t *Table) checkStorageForUpdates(ctx context.Context) (toDownload []storag
 		// We do not ever upload files in the object store with the same name but different contents so we do not consider downloading modified files again.
 		_, ok := t.dbs[file.Name]
 		if !ok {
+			// A file failing to open is only downloaded again once replaced in the storage.
+			if modifiedAt, corrupt := t.corruptFiles[file.Name]; corrupt && modifiedAt.Equal(file.ModifiedAt) {
+				continue
+			}
 			toDownload = append(toDownload, file)
 		}
 	}
@@ -422,6 +489,11 @@ func (t *Table) checkStorageForUpdates(ctx context.Context) (toDownload []storag
 			toDelete = append(toDelete, db)
 		}
 	}
+	for db := range t.corruptFiles {
+		if _, isOK := listedDBs[db]; !isOK {
+			toDelete = append(toDelete, db)
+		}
+	}
 
 	return
 }
@@ -442,19 +514,82 @@ func (t *Table) downloadFile(ctx context.Context, file storage.IndexFile) error
 		return err
 	}
 
-	t.dbsMtx.Lock()
-	defer t.dbsMtx.Unlock()
+	boltdb, err := t.openDB(ctx, file.Name, filePath)
+	if errors.Is(err, errCorruptFile) {
+		t.dbsMtx.Lock()
+		defer t.dbsMtx.Unlock()
 
-	boltdb, err := shipper_util.SafeOpenBoltdbFile(filePath)
-	if err != nil {
+		t.corruptFiles[file.Name] = file.ModifiedAt
+		return nil
+	}
+	if err != nil || boltdb == nil {
 		return err
 	}
 
+	t.dbsMtx.Lock()
+	defer t.dbsMtx.Unlock()
+
+	delete(t.corruptFiles, file.Name)
 	t.dbs[file.Name] = boltdb
 
 	return nil
 }
 
+// openDB opens a db file downloaded from the storage. A file failing to open, most likely because it is corrupt,
+// is moved to the quarantine directory and downloaded again once. If the fresh copy fails to open too, errCorruptFile
+// is returned and the table keeps track of the file to report its index as unavailable, the syncs downloading the file
+// again once it is replaced in the storage.
+func (t *Table) openDB(ctx context.Context, fileName, filePath string) (*bbolt.DB, error) {
+	boltdb, err := shipper_util.SafeOpenBoltdbFileReadOnly(filePath, t.openTimeout)
+	if err == nil {
+		return boltdb, nil
+	}
+	t.quarantineFile(fileName, filePath, err)
+
+	err = shipper_util.GetFileFromStorage(ctx, t.storageClient, t.name, fileName, filePath, true)
+	if err != nil {
+		if t.storageClient.IsFileNotFoundErr(err) {
+			level.Info(util_log.Logger).Log("msg", fmt.Sprintf("ignoring missing object %s, possibly removed during compaction", fileName))
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	boltdb, err = shipper_util.SafeOpenBoltdbFileReadOnly(filePath, t.openTimeout)
+	if err != nil {
+		t.quarantineFile(fileName, filePath, err)
+		return nil, errCorruptFile
+	}
+
+	return boltdb, nil
+}
+
+// quarantineFile moves a db file failing to open out of the table folder, keeping it around for inspection.
+// Moving the file also gets rid of the lock boltdb does not release when it panics while opening a corrupt file.
+func (t *Table) quarantineFile(fileName, filePath string, openErr error) {
+	level.Error(util_log.Logger).Log("msg", "failed to open index file, moving it to quarantine", "table", t.name, "file", fileName, "err", openErr)
+	t.metrics.filesQuarantinedTotal.Inc()
+
+	quarantinePath := filepath.Join(t.cacheLocation, quarantineDirName, t.name)
+	err := chunk_util.EnsureDirectory(quarantinePath)
+	if err == nil {
+		err = os.Rename(filePath, filepath.Join(quarantinePath, fileName))
+	}
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to move index file to quarantine, removing it", "file", filePath, "err", err)
+		if err := os.Remove(filePath); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove index file failing to open", "file", filePath, "err", err)
+		}
+		return
+	}
+
+	// The quarantined files are removed once older than the cache TTL.
+	now := time.Now()
+	if err := os.Chtimes(filepath.Join(quarantinePath, fileName), now, now); err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to update the modification time of quarantined index file", "file", fileName, "err", err)
+	}
+}
+
 func (t *Table) folderPathForTable(ensureExists bool) (string, error) {
 	folderPath := path.Join(t.cacheLocation, t.name)
 
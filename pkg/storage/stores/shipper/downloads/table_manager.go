@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/tenant"
 )
 
 const (
@@ -30,6 +32,22 @@ type Config struct {
 	SyncInterval      time.Duration
 	CacheTTL          time.Duration
 	QueryReadyNumDays int
+	// PrefetchLookback is the duration for which queried tables are remembered to be prefetched in the background. 0 disables prefetching.
+	PrefetchLookback time.Duration
+	// PrefetchMaxDiskUsage is the size of cache directory in bytes beyond which no more tables are prefetched. 0 means no limit.
+	PrefetchMaxDiskUsage int64
+	// CacheSizeLimit is the size of downloaded tables in bytes beyond which least recently used tables are evicted. 0 means no limit.
+	CacheSizeLimit int64
+	// CoalesceQueries makes identical index queries running concurrently on a table scan the index only once.
+	CoalesceQueries bool
+	// OpenTimeout is the maximum time to wait for the lock of a downloaded file when opening it. 0 means no timeout.
+	OpenTimeout time.Duration
+	// InvalidationCheckInterval is how often the tables invalidated by the compactor are checked, to sync them ahead of the
+	// SyncInterval. 0 disables the checks.
+	InvalidationCheckInterval time.Duration
+	// FailOnCorruptFiles makes the queries of the tables with files failing to open fail with chunk.ErrIndexUnavailable,
+	// instead of querying the files which opened.
+	FailOnCorruptFiles bool
 }
 
 type TableManager struct {
@@ -37,9 +55,14 @@ type TableManager struct {
 	boltIndexClient    BoltDBIndexClient
 	indexStorageClient StorageClient
 
-	tables    map[string]*Table
-	tablesMtx sync.RWMutex
-	metrics   *metrics
+	tables       map[string]*Table
+	tablesMtx    sync.RWMutex
+	queryTracker *queryPatternTracker
+	coalescer    *queryCoalescer
+	metrics      *metrics
+
+	// invalidations are the invalidation times of the tables seen at the last check, only used by the loop.
+	invalidations map[string]time.Time
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -62,6 +85,23 @@ func NewTableManager(cfg Config, boltIndexClient BoltDBIndexClient, indexStorage
 		cancel:             cancel,
 	}
 
+	if cfg.PrefetchLookback > 0 {
+		tm.queryTracker = newQueryPatternTracker(cfg.PrefetchLookback)
+	}
+
+	if cfg.CoalesceQueries {
+		tm.coalescer = newQueryCoalescer(tm.metrics)
+	}
+
+	if cfg.InvalidationCheckInterval > 0 {
+		// the tables are up to date once loaded, only the invalidations happening after that need a sync.
+		invalidations, err := indexStorageClient.ListInvalidatedTables(ctx)
+		if err != nil {
+			level.Warn(util_log.Logger).Log("msg", "failed to list invalidated tables, the loaded tables will be synced at the first check", "err", err)
+		}
+		tm.invalidations = invalidations
+	}
+
 	// load the existing tables first.
 	err := tm.loadLocalTables()
 	if err != nil {
@@ -92,6 +132,13 @@ func (tm *TableManager) loop() {
 	cacheCleanupTicker := time.NewTicker(cacheCleanupInterval)
 	defer cacheCleanupTicker.Stop()
 
+	var invalidationCheckC <-chan time.Time
+	if tm.cfg.InvalidationCheckInterval > 0 {
+		invalidationCheckTicker := time.NewTicker(tm.cfg.InvalidationCheckInterval)
+		defer invalidationCheckTicker.Stop()
+		invalidationCheckC = invalidationCheckTicker.C
+	}
+
 	for {
 		select {
 		case <-syncTicker.C:
@@ -105,11 +152,31 @@ func (tm *TableManager) loop() {
 			if err != nil {
 				level.Error(util_log.Logger).Log("msg", "error ensuring query readiness of tables", "err", err)
 			}
+
+			err = tm.prefetchTables()
+			if err != nil {
+				level.Error(util_log.Logger).Log("msg", "error prefetching tables", "err", err)
+			}
+
+			err = tm.enforceCacheSizeLimit()
+			if err != nil {
+				level.Error(util_log.Logger).Log("msg", "error enforcing cache size limit", "err", err)
+			}
+		case <-invalidationCheckC:
+			err := tm.syncInvalidatedTables(tm.ctx)
+			if err != nil {
+				level.Error(util_log.Logger).Log("msg", "error syncing tables invalidated by the compactor", "err", err)
+			}
 		case <-cacheCleanupTicker.C:
 			err := tm.cleanupCache()
 			if err != nil {
 				level.Error(util_log.Logger).Log("msg", "error cleaning up expired tables", "err", err)
 			}
+
+			err = tm.enforceCacheSizeLimit()
+			if err != nil {
+				level.Error(util_log.Logger).Log("msg", "error enforcing cache size limit", "err", err)
+			}
 		case <-tm.ctx.Done():
 			return
 		}
@@ -144,9 +211,21 @@ func (tm *TableManager) query(ctx context.Context, tableName string, queries []c
 	logger := util_log.WithContext(ctx, util_log.Logger)
 	level.Debug(logger).Log("table-name", tableName)
 
+	if tm.queryTracker != nil {
+		userID, err := tenant.TenantID(ctx)
+		if err == nil {
+			tm.queryTracker.Track(userID, tableName, time.Now())
+		}
+	}
+
 	table := tm.getOrCreateTable(ctx, tableName)
 
-	err := util.DoParallelQueries(ctx, table, queries, callback)
+	var tableQuerier util.TableQuerier = table
+	if tm.coalescer != nil {
+		tableQuerier = coalescingTable{table: table, coalescer: tm.coalescer}
+	}
+
+	err := util.DoParallelQueries(ctx, tableQuerier, queries, callback)
 	if err != nil {
 		if table.Err() != nil {
 			// table is in invalid state, remove the table so that next queries re-create it.
@@ -177,7 +256,7 @@ func (tm *TableManager) getOrCreateTable(spanCtx context.Context, tableName stri
 			// table not found, creating one.
 			level.Info(util_log.Logger).Log("msg", fmt.Sprintf("downloading all files for table %s", tableName))
 
-			table = NewTable(spanCtx, tableName, tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.metrics)
+			table = NewTable(spanCtx, tableName, tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenTimeout, tm.cfg.FailOnCorruptFiles, tm.metrics)
 			tm.tables[tableName] = table
 		}
 		tm.tablesMtx.Unlock()
@@ -213,6 +292,38 @@ func (tm *TableManager) syncTables(ctx context.Context) error {
 	return nil
 }
 
+// syncInvalidatedTables syncs the downloaded tables invalidated by the compactor since the last check, so that the files
+// it replaced or deleted stop being queried without waiting for the next sync.
+func (tm *TableManager) syncInvalidatedTables(ctx context.Context) error {
+	invalidations, err := tm.indexStorageClient.ListInvalidatedTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	for name, invalidatedAt := range invalidations {
+		// without the modification time of the marker, the table can't be known to be synced since its invalidation.
+		if seenAt, ok := tm.invalidations[name]; ok && !invalidatedAt.IsZero() && !invalidatedAt.After(seenAt) {
+			continue
+		}
+
+		tm.tablesMtx.RLock()
+		table, ok := tm.tables[name]
+		tm.tablesMtx.RUnlock()
+		if !ok {
+			continue
+		}
+
+		level.Info(util_log.Logger).Log("msg", "syncing table invalidated by the compactor", "table-name", name, "invalidated-at", invalidatedAt)
+		if err := table.Sync(ctx); err != nil {
+			return err
+		}
+		tm.metrics.tablesInvalidatedTotal.Inc()
+	}
+
+	tm.invalidations = invalidations
+	return nil
+}
+
 func (tm *TableManager) cleanupCache() error {
 	tm.tablesMtx.Lock()
 	defer tm.tablesMtx.Unlock()
@@ -223,24 +334,179 @@ func (tm *TableManager) cleanupCache() error {
 		lastUsedAt := table.LastUsedAt()
 		if lastUsedAt.Add(tm.cfg.CacheTTL).Before(time.Now()) {
 			level.Info(util_log.Logger).Log("msg", fmt.Sprintf("cleaning up expired table %s", name))
-			err := table.CleanupAllDBs()
+			err := tm.removeTable(name, table)
 			if err != nil {
 				return err
 			}
+		}
+	}
 
-			delete(tm.tables, name)
+	tm.cleanupQuarantine(time.Now().Add(-tm.cfg.CacheTTL))
 
-			// remove the directory where files for the table were downloaded.
-			err = os.RemoveAll(path.Join(tm.cfg.CacheDir, name))
-			if err != nil {
-				level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to remove directory for table %s", name), "err", err)
+	return nil
+}
+
+// cleanupQuarantine removes the quarantined files moved to quarantine before the given time, along with the
+// directories of the tables left without quarantined files.
+func (tm *TableManager) cleanupQuarantine(before time.Time) {
+	quarantinePath := path.Join(tm.cfg.CacheDir, quarantineDirName)
+	tablesInfo, err := ioutil.ReadDir(quarantinePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Error(util_log.Logger).Log("msg", "failed to list the quarantined index files", "err", err)
+		}
+		return
+	}
+
+	for _, tableInfo := range tablesInfo {
+		if !tableInfo.IsDir() {
+			continue
+		}
+		tablePath := path.Join(quarantinePath, tableInfo.Name())
+		filesInfo, err := ioutil.ReadDir(tablePath)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to list the quarantined index files", "table", tableInfo.Name(), "err", err)
+			continue
+		}
+		remaining := len(filesInfo)
+		for _, fileInfo := range filesInfo {
+			if !fileInfo.ModTime().Before(before) {
+				continue
+			}
+			if err := os.Remove(path.Join(tablePath, fileInfo.Name())); err != nil {
+				level.Error(util_log.Logger).Log("msg", "failed to remove quarantined index file", "table", tableInfo.Name(), "file", fileInfo.Name(), "err", err)
+				continue
 			}
+			remaining--
 		}
+		if remaining == 0 {
+			if err := os.Remove(tablePath); err != nil {
+				level.Error(util_log.Logger).Log("msg", "failed to remove quarantine directory of table", "table", tableInfo.Name(), "err", err)
+			}
+		}
+	}
+}
+
+// removeTable removes all the files of the table and drops it from the list of tables.
+// It assumes the lock on tables is taken care of by the caller.
+func (tm *TableManager) removeTable(name string, table *Table) error {
+	err := table.CleanupAllDBs()
+	if err != nil {
+		return err
+	}
+
+	delete(tm.tables, name)
+
+	// remove the directory where files for the table were downloaded.
+	err = os.RemoveAll(path.Join(tm.cfg.CacheDir, name))
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", fmt.Sprintf("failed to remove directory for table %s", name), "err", err)
 	}
 
 	return nil
 }
 
+type evictionCandidate struct {
+	name           string
+	table          *Table
+	size           int64
+	lastUsedAt     time.Time
+	inActiveWindow bool
+}
+
+// enforceCacheSizeLimit evicts the least recently used tables until the size of downloaded tables goes below the configured limit.
+// Tables outside the active query window i.e not required for being query ready or being prefetched are evicted first.
+func (tm *TableManager) enforceCacheSizeLimit() error {
+	tm.tablesMtx.Lock()
+	defer tm.tablesMtx.Unlock()
+
+	var (
+		totalSize    int64
+		candidates   = make([]evictionCandidate, 0, len(tm.tables))
+		activeWindow = tm.activeQueryWindowTables()
+	)
+
+	for name, table := range tm.tables {
+		size, ready, err := table.Size()
+		if err != nil {
+			return err
+		}
+
+		// do not evict the tables which are still being downloaded.
+		if !ready {
+			continue
+		}
+
+		totalSize += size
+		_, inActiveWindow := activeWindow[name]
+		candidates = append(candidates, evictionCandidate{
+			name:           name,
+			table:          table,
+			size:           size,
+			lastUsedAt:     table.LastUsedAt(),
+			inActiveWindow: inActiveWindow,
+		})
+	}
+
+	tm.metrics.cacheSizeBytes.Set(float64(totalSize))
+
+	if tm.cfg.CacheSizeLimit <= 0 || totalSize <= tm.cfg.CacheSizeLimit {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].inActiveWindow != candidates[j].inActiveWindow {
+			return !candidates[i].inActiveWindow
+		}
+		return candidates[i].lastUsedAt.Before(candidates[j].lastUsedAt)
+	})
+
+	for _, candidate := range candidates {
+		if totalSize <= tm.cfg.CacheSizeLimit {
+			break
+		}
+
+		level.Info(util_log.Logger).Log("msg", "evicting table to honor cache size limit", "table-name", candidate.name,
+			"size", candidate.size, "cache-size", totalSize, "cache-size-limit", tm.cfg.CacheSizeLimit, "in-active-window", candidate.inActiveWindow)
+
+		err := tm.removeTable(candidate.name, candidate.table)
+		if err != nil {
+			return err
+		}
+
+		totalSize -= candidate.size
+		tm.metrics.tablesEvictedTotal.Inc()
+	}
+
+	tm.metrics.cacheSizeBytes.Set(float64(totalSize))
+
+	return nil
+}
+
+// activeQueryWindowTables returns names of the tables within active query window i.e
+// tables required for being query ready and the ones being prefetched based on query patterns.
+func (tm *TableManager) activeQueryWindowTables() map[string]struct{} {
+	activeTables := map[string]struct{}{}
+
+	if tm.cfg.QueryReadyNumDays > 0 {
+		minTableNumber, maxTableNumber := tm.queryReadyTableNumbersRange()
+		for name := range tm.tables {
+			_, tableNumber, ok := extractTableNumber(name)
+			if ok && minTableNumber <= tableNumber && tableNumber <= maxTableNumber {
+				activeTables[name] = struct{}{}
+			}
+		}
+	}
+
+	if tm.queryTracker != nil {
+		for _, name := range tm.queryTracker.TablesToPrefetch(time.Now()) {
+			activeTables[name] = struct{}{}
+		}
+	}
+
+	return activeTables
+}
+
 // ensureQueryReadiness compares tables required for being query ready with the tables we already have and downloads the missing ones.
 func (tm *TableManager) ensureQueryReadiness() error {
 	if tm.cfg.QueryReadyNumDays == 0 {
@@ -272,14 +538,85 @@ func (tm *TableManager) ensureQueryReadiness() error {
 
 		level.Info(util_log.Logger).Log("msg", "table required for query readiness does not exist locally, downloading it", "table-name", tableName)
 		// table doesn't exist, download it.
-		table, err := LoadTable(tm.ctx, tableName, tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.metrics)
+		table, err := LoadTable(tm.ctx, tableName, tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenTimeout, tm.cfg.FailOnCorruptFiles, tm.metrics)
+		if err != nil {
+			return err
+		}
+
+		tm.tablesMtx.Lock()
+		tm.tables[tableName] = table
+		tm.tablesMtx.Unlock()
+	}
+
+	return nil
+}
+
+// prefetchTables downloads the missing tables which were queried recently, as per the query patterns tracked within the lookback window.
+// It stops downloading more tables when disk usage of the cache directory goes beyond the configured limit.
+func (tm *TableManager) prefetchTables() error {
+	if tm.queryTracker == nil {
+		return nil
+	}
+
+	tableNames := tm.queryTracker.TablesToPrefetch(time.Now())
+	if len(tableNames) == 0 {
+		return nil
+	}
+
+	tablesInStorage, err := tm.indexStorageClient.ListTables(tm.ctx)
+	if err != nil {
+		return err
+	}
+
+	existingTables := make(map[string]struct{}, len(tablesInStorage))
+	for _, tableName := range tablesInStorage {
+		existingTables[tableName] = struct{}{}
+	}
+
+	level.Debug(util_log.Logger).Log("msg", fmt.Sprintf("list of tables to prefetch based on query patterns %s", tableNames))
+
+	for _, tableName := range tableNames {
+		if _, ok := existingTables[tableName]; !ok {
+			continue
+		}
+
+		tm.tablesMtx.RLock()
+		_, ok := tm.tables[tableName]
+		tm.tablesMtx.RUnlock()
+		if ok {
+			continue
+		}
+
+		if tm.cfg.PrefetchMaxDiskUsage > 0 {
+			usage, err := diskUsage(tm.cfg.CacheDir)
+			if err != nil {
+				return err
+			}
+
+			if usage >= tm.cfg.PrefetchMaxDiskUsage {
+				level.Info(util_log.Logger).Log("msg", "skipping prefetching of tables since disk usage has reached the limit", "usage", usage, "limit", tm.cfg.PrefetchMaxDiskUsage)
+				tm.metrics.tablesPrefetchSkippedTotal.Inc()
+				return nil
+			}
+		}
+
+		level.Info(util_log.Logger).Log("msg", "prefetching table based on query patterns", "table-name", tableName)
+		table, err := LoadTable(tm.ctx, tableName, tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenTimeout, tm.cfg.FailOnCorruptFiles, tm.metrics)
 		if err != nil {
 			return err
 		}
 
 		tm.tablesMtx.Lock()
+		if _, ok := tm.tables[tableName]; ok {
+			// a query created the table while we were prefetching it, keep the one already being used.
+			tm.tablesMtx.Unlock()
+			table.Close()
+			continue
+		}
 		tm.tables[tableName] = table
 		tm.tablesMtx.Unlock()
+
+		tm.metrics.tablesPrefetchedTotal.Inc()
 	}
 
 	return nil
@@ -330,13 +667,13 @@ func (tm *TableManager) loadLocalTables() error {
 	}
 
 	for _, fileInfo := range filesInfo {
-		if !fileInfo.IsDir() {
+		if !fileInfo.IsDir() || fileInfo.Name() == quarantineDirName {
 			continue
 		}
 
 		level.Info(util_log.Logger).Log("msg", fmt.Sprintf("loading local table %s", fileInfo.Name()))
 
-		table, err := LoadTable(tm.ctx, fileInfo.Name(), tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.metrics)
+		table, err := LoadTable(tm.ctx, fileInfo.Name(), tm.cfg.CacheDir, tm.indexStorageClient, tm.boltIndexClient, tm.cfg.OpenTimeout, tm.cfg.FailOnCorruptFiles, tm.metrics)
 		if err != nil {
 			return err
 		}
@@ -348,7 +685,5 @@ func (tm *TableManager) loadLocalTables() error {
 }
 
 func getActiveTableNumber() int64 {
-	periodSecs := int64(durationDay / time.Second)
-
-	return time.Now().Unix() / periodSecs
+	return tableNumberAt(time.Now())
 }
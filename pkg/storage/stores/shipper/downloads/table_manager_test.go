@@ -10,10 +10,13 @@ import (
 	"testing"
 	"time"
 
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/local"
 	"github.com/grafana/loki/pkg/storage/chunk/util"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/testutil"
 )
 
@@ -22,9 +25,10 @@ func buildTestTableManager(t *testing.T, path string) (*TableManager, stopFunc)
 	cachePath := filepath.Join(path, cacheDirName)
 
 	cfg := Config{
-		CacheDir:     cachePath,
-		SyncInterval: time.Hour,
-		CacheTTL:     time.Hour,
+		CacheDir:        cachePath,
+		SyncInterval:    time.Hour,
+		CacheTTL:        time.Hour,
+		CoalesceQueries: true,
 	}
 	tableManager, err := NewTableManager(cfg, boltDBIndexClient, indexStorageClient, nil)
 	require.NoError(t, err)
@@ -135,6 +139,34 @@ func TestTableManager_cleanupCache(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestTableManager_cleanupQuarantine(t *testing.T) {
+	tempDir := t.TempDir()
+
+	tableManager, stopFunc := buildTestTableManager(t, tempDir)
+	defer stopFunc()
+
+	quarantinePath := filepath.Join(tableManager.cfg.CacheDir, quarantineDirName)
+	for _, table := range []string{"table1", "table2"} {
+		require.NoError(t, util.EnsureDirectory(filepath.Join(quarantinePath, table)))
+		for _, file := range []string{"old", "new"} {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(quarantinePath, table, file), []byte("invalid boltdb file"), 0666))
+		}
+	}
+	old := time.Now().Add(-2 * tableManager.cfg.CacheTTL)
+	require.NoError(t, os.Chtimes(filepath.Join(quarantinePath, "table1", "old"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(quarantinePath, "table2", "old"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(quarantinePath, "table2", "new"), old, old))
+
+	// The files quarantined before the cache TTL are removed, along with the directories left empty.
+	require.NoError(t, tableManager.cleanupCache())
+	files, err := ioutil.ReadDir(filepath.Join(quarantinePath, "table1"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "new", files[0].Name())
+	_, err = os.Stat(filepath.Join(quarantinePath, "table2"))
+	require.True(t, os.IsNotExist(err))
+}
+
 func TestTableManager_ensureQueryReadiness(t *testing.T) {
 	for _, tc := range []struct {
 		name                 string
@@ -255,3 +287,234 @@ func TestTableManager_tablesRequiredForQueryReadiness(t *testing.T) {
 		})
 	}
 }
+
+func TestTableManager_prefetchTables(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		prefetchMaxDiskUsage int64
+		expectedTables       int
+	}{
+		{
+			name:           "no disk usage limit",
+			expectedTables: 2,
+		},
+		{
+			name:                 "disk usage limit reached",
+			prefetchMaxDiskUsage: 1,
+			expectedTables:       1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "table-manager-prefetch-tables")
+			require.NoError(t, err)
+
+			defer func() {
+				require.NoError(t, os.RemoveAll(tempDir))
+			}()
+
+			objectStoragePath := filepath.Join(tempDir, objectsStorageDirName)
+
+			activeTableNumber := getActiveTableNumber()
+			for i := 0; i < 5; i++ {
+				testutil.SetupDBTablesAtPath(t, fmt.Sprintf("table_%d", activeTableNumber-int64(i)), objectStoragePath, map[string]testutil.DBRecords{
+					"db": {
+						Start:      i * 10,
+						NumRecords: 10,
+					},
+				}, true)
+			}
+
+			boltDBIndexClient, indexStorageClient := buildTestClients(t, tempDir)
+			cachePath := filepath.Join(tempDir, cacheDirName)
+			require.NoError(t, util.EnsureDirectory(cachePath))
+
+			cfg := Config{
+				CacheDir:             cachePath,
+				SyncInterval:         time.Hour,
+				CacheTTL:             time.Hour,
+				PrefetchLookback:     time.Hour,
+				PrefetchMaxDiskUsage: tc.prefetchMaxDiskUsage,
+			}
+			tableManager := &TableManager{
+				cfg:                cfg,
+				boltIndexClient:    boltDBIndexClient,
+				indexStorageClient: indexStorageClient,
+				tables:             make(map[string]*Table),
+				queryTracker:       newQueryPatternTracker(cfg.PrefetchLookback),
+				metrics:            newMetrics(nil),
+				ctx:                context.Background(),
+				cancel:             func() {},
+			}
+
+			defer func() {
+				tableManager.Stop()
+				boltDBIndexClient.Stop()
+			}()
+
+			// nothing was queried yet so nothing should be prefetched.
+			require.NoError(t, tableManager.prefetchTables())
+			require.Len(t, tableManager.tables, 0)
+
+			now := time.Now()
+			tableManager.queryTracker.Track("user1", fmt.Sprintf("table_%d", activeTableNumber-1), now)
+			tableManager.queryTracker.Track("user2", fmt.Sprintf("table_%d", activeTableNumber-3), now)
+			// tables missing in the storage should be ignored.
+			tableManager.queryTracker.Track("user2", fmt.Sprintf("table_%d", activeTableNumber-10), now)
+
+			require.NoError(t, tableManager.prefetchTables())
+			require.Len(t, tableManager.tables, tc.expectedTables)
+
+			_, ok := tableManager.tables[fmt.Sprintf("table_%d", activeTableNumber-1)]
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestTableManager_enforceCacheSizeLimit(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "table-manager-enforce-cache-size-limit")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	objectStoragePath := filepath.Join(tempDir, objectsStorageDirName)
+
+	activeTableNumber := getActiveTableNumber()
+	tableNames := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		tableName := fmt.Sprintf("table_%d", activeTableNumber-int64(i))
+		tableNames = append(tableNames, tableName)
+		testutil.SetupDBTablesAtPath(t, tableName, objectStoragePath, map[string]testutil.DBRecords{
+			"db": {
+				Start:      i * 10,
+				NumRecords: 10,
+			},
+		}, true)
+	}
+
+	boltDBIndexClient, indexStorageClient := buildTestClients(t, tempDir)
+	cachePath := filepath.Join(tempDir, cacheDirName)
+	require.NoError(t, util.EnsureDirectory(cachePath))
+
+	tableManager := &TableManager{
+		cfg: Config{
+			CacheDir:          cachePath,
+			SyncInterval:      time.Hour,
+			CacheTTL:          time.Hour,
+			QueryReadyNumDays: 1,
+		},
+		boltIndexClient:    boltDBIndexClient,
+		indexStorageClient: indexStorageClient,
+		tables:             make(map[string]*Table),
+		metrics:            newMetrics(nil),
+		ctx:                context.Background(),
+		cancel:             func() {},
+	}
+
+	defer func() {
+		tableManager.Stop()
+		boltDBIndexClient.Stop()
+	}()
+
+	// download all the tables with the newest table being the least recently used one.
+	var tableSize int64
+	for i, tableName := range tableNames {
+		table, err := LoadTable(context.Background(), tableName, cachePath, indexStorageClient, boltDBIndexClient, 0, false, tableManager.metrics)
+		require.NoError(t, err)
+		table.lastUsedAt = time.Now().Add(time.Duration(i) * time.Minute)
+		tableManager.tables[tableName] = table
+
+		tableSize, _, err = table.Size()
+		require.NoError(t, err)
+		require.NotZero(t, tableSize)
+	}
+
+	// no limit set so nothing should be evicted.
+	require.NoError(t, tableManager.enforceCacheSizeLimit())
+	require.Len(t, tableManager.tables, 4)
+
+	// set limit to keep just 2 tables.
+	tableManager.cfg.CacheSizeLimit = 2 * tableSize
+	require.NoError(t, tableManager.enforceCacheSizeLimit())
+	require.Len(t, tableManager.tables, 2)
+
+	// tables within query ready window should be kept despite being used least recently.
+	for _, tableName := range tableNames[:2] {
+		_, ok := tableManager.tables[tableName]
+		require.True(t, ok)
+	}
+
+	// set limit to keep just 1 table, which should evict least recently used table from the query ready window.
+	tableManager.cfg.CacheSizeLimit = tableSize
+	require.NoError(t, tableManager.enforceCacheSizeLimit())
+	require.Len(t, tableManager.tables, 1)
+
+	_, ok := tableManager.tables[tableNames[1]]
+	require.True(t, ok)
+
+	// evicted tables should be removed from the disk.
+	_, err = os.Stat(filepath.Join(cachePath, tableNames[0]))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestTableManager_syncInvalidatedTables(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "table-manager-sync-invalidated-tables")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	objectStoragePath := filepath.Join(tempDir, objectsStorageDirName)
+	for _, tableName := range []string{"table1", "table2"} {
+		testutil.SetupDBTablesAtPath(t, tableName, objectStoragePath, map[string]testutil.DBRecords{
+			"db1": {Start: 0, NumRecords: 10},
+			"db2": {Start: 10, NumRecords: 10},
+		}, false)
+	}
+
+	tableManager, stopFunc := buildTestTableManager(t, tempDir)
+	defer stopFunc()
+
+	for _, tableName := range []string{"table1", "table2"} {
+		testutil.TestMultiTableQuery(t, []chunk.IndexQuery{{TableName: tableName}}, tableManager, 0, 20)
+	}
+
+	// compact the files of both tables but only invalidate table1.
+	for _, tableName := range []string{"table1", "table2"} {
+		require.NoError(t, os.Remove(filepath.Join(objectStoragePath, tableName, "db1")))
+		require.NoError(t, os.Remove(filepath.Join(objectStoragePath, tableName, "db2")))
+		testutil.AddRecordsToDB(t, filepath.Join(objectStoragePath, tableName, "compacted"), tableManager.boltIndexClient.(*local.BoltIndexClient), 0, 15)
+	}
+	require.NoError(t, tableManager.indexStorageClient.(storage.Client).InvalidateTable(context.Background(), "table1"))
+
+	require.NoError(t, tableManager.syncInvalidatedTables(context.Background()))
+	require.Equal(t, float64(1), promtestutil.ToFloat64(tableManager.metrics.tablesInvalidatedTotal))
+	testutil.TestMultiTableQuery(t, []chunk.IndexQuery{{TableName: "table1"}}, tableManager, 0, 15)
+	// table2 keeps the stale files until the next sync.
+	testutil.TestMultiTableQuery(t, []chunk.IndexQuery{{TableName: "table2"}}, tableManager, 0, 20)
+
+	// the invalidation is only handled once.
+	require.NoError(t, tableManager.syncInvalidatedTables(context.Background()))
+	require.Equal(t, float64(1), promtestutil.ToFloat64(tableManager.metrics.tablesInvalidatedTotal))
+
+	// the tables whose invalidation time isn't reported are synced at every check.
+	tableManager.indexStorageClient = zeroInvalidationTimeClient{tableManager.indexStorageClient}
+	require.NoError(t, tableManager.syncInvalidatedTables(context.Background()))
+	require.NoError(t, tableManager.syncInvalidatedTables(context.Background()))
+	require.Equal(t, float64(3), promtestutil.ToFloat64(tableManager.metrics.tablesInvalidatedTotal))
+}
+
+// zeroInvalidationTimeClient is a storage client not reporting the modification time of the invalidation markers.
+type zeroInvalidationTimeClient struct {
+	StorageClient
+}
+
+func (c zeroInvalidationTimeClient) ListInvalidatedTables(ctx context.Context) (map[string]time.Time, error) {
+	invalidations, err := c.StorageClient.ListInvalidatedTables(ctx)
+	for name := range invalidations {
+		invalidations[name] = time.Time{}
+	}
+	return invalidations, err
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/storage/chunk"
@@ -49,7 +50,7 @@ func (o storageClientWithFakeObjectsInList) ListFiles(ctx context.Context, table
 
 type stopFunc func()
 
-func buildTestClients(t *testing.T, path string) (*local.BoltIndexClient, StorageClient) {
+func buildTestClients(t testing.TB, path string) (*local.BoltIndexClient, StorageClient) {
 	cachePath := filepath.Join(path, cacheDirName)
 
 	boltDBIndexClient, err := local.NewBoltDBIndexClient(local.BoltDBConfig{Directory: cachePath})
@@ -66,7 +67,7 @@ func buildTestTable(t *testing.T, tableName, path string) (*Table, *local.BoltIn
 	boltDBIndexClient, storageClient := buildTestClients(t, path)
 	cachePath := filepath.Join(path, cacheDirName)
 
-	table := NewTable(context.Background(), tableName, cachePath, storageClient, boltDBIndexClient, newMetrics(nil))
+	table := NewTable(context.Background(), tableName, cachePath, storageClient, boltDBIndexClient, 0, false, newMetrics(nil))
 
 	// wait for either table to get ready or a timeout hits
 	select {
@@ -338,7 +339,7 @@ func TestLoadTable(t *testing.T) {
 	storageClient = newStorageClientWithFakeObjectsInList(storageClient)
 
 	// try loading the table.
-	table, err := LoadTable(context.Background(), tableName, cachePath, storageClient, boltDBIndexClient, newMetrics(nil))
+	table, err := LoadTable(context.Background(), tableName, cachePath, storageClient, boltDBIndexClient, 0, false, newMetrics(nil))
 	require.NoError(t, err)
 	require.NotNil(t, table)
 
@@ -367,8 +368,9 @@ func TestLoadTable(t *testing.T) {
 
 	testutil.SetupDBTablesAtPath(t, tableName, objectStoragePath, dbs, false)
 
-	// try loading the table, it should skip loading corrupt file and reload it from storage.
-	table, err = LoadTable(context.Background(), tableName, cachePath, storageClient, boltDBIndexClient, newMetrics(nil))
+	// try loading the table, it should quarantine the corrupt file and reload it from storage.
+	metrics := newMetrics(nil)
+	table, err = LoadTable(context.Background(), tableName, cachePath, storageClient, boltDBIndexClient, 0, false, metrics)
 	require.NoError(t, err)
 	require.NotNil(t, table)
 
@@ -376,4 +378,125 @@ func TestLoadTable(t *testing.T) {
 
 	// query the loaded table to see if it has right data.
 	testutil.TestSingleTableQuery(t, []chunk.IndexQuery{{}}, table, 0, 20)
+
+	require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.filesQuarantinedTotal))
+	quarantined, err := ioutil.ReadFile(filepath.Join(cachePath, quarantineDirName, tableName, "0"))
+	require.NoError(t, err)
+	require.Equal(t, "invalid boltdb file", string(quarantined))
+}
+
+func TestTable_CorruptFileInStorage(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "table-corrupt-file")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	objectStoragePath := filepath.Join(tempDir, objectsStorageDirName)
+	tablePath := testutil.SetupDBTablesAtPath(t, "test", objectStoragePath, map[string]testutil.DBRecords{
+		"db1": {
+			Start:      0,
+			NumRecords: 10,
+		},
+	}, false)
+
+	// the copy in the storage is corrupt too so the file keeps failing to open even after downloading it again.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tablePath, "corrupt"), []byte("invalid boltdb file"), 0666))
+
+	table, _, stopFunc := buildTestTable(t, "test", tempDir)
+	defer stopFunc()
+
+	// the table serves queries from the files which could be opened, counting the skipped ones.
+	testutil.TestSingleTableQuery(t, []chunk.IndexQuery{{}}, table, 0, 10)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(table.metrics.corruptFilesSkippedTotal))
+
+	// when the store falls back to listing the chunks, the index of the table is unavailable instead of missing the
+	// corrupt file.
+	table.failOnCorrupt = true
+	err = table.MultiQueries(context.Background(), []chunk.IndexQuery{{}}, func(chunk.IndexQuery, chunk.ReadBatch) bool { return true })
+	require.ErrorIs(t, err, chunk.ErrIndexUnavailable)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(table.metrics.unavailableTableQueriesTotal))
+
+	require.Equal(t, float64(2), promtestutil.ToFloat64(table.metrics.filesQuarantinedTotal))
+	_, err = os.Stat(filepath.Join(tempDir, cacheDirName, quarantineDirName, "test", "corrupt"))
+	require.NoError(t, err)
+
+	// a sync doesn't download the corrupt file again until it is replaced in the storage.
+	require.NoError(t, table.Sync(context.Background()))
+	require.Equal(t, float64(2), promtestutil.ToFloat64(table.metrics.filesQuarantinedTotal))
+
+	modifiedAt := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(tablePath, "corrupt"), modifiedAt, modifiedAt))
+	require.NoError(t, table.Sync(context.Background()))
+	require.Equal(t, float64(4), promtestutil.ToFloat64(table.metrics.filesQuarantinedTotal))
+
+	// once the corrupt file is removed from the storage, the table serves queries again.
+	require.NoError(t, os.Remove(filepath.Join(tablePath, "corrupt")))
+	require.NoError(t, table.Sync(context.Background()))
+	testutil.TestSingleTableQuery(t, []chunk.IndexQuery{{}}, table, 0, 10)
+}
+
+func BenchmarkTable_MultiQueries(b *testing.B) {
+	tempDir, err := ioutil.TempDir("", "table-downloads-benchmark")
+	require.NoError(b, err)
+
+	defer func() {
+		require.NoError(b, os.RemoveAll(tempDir))
+	}()
+
+	const (
+		numDBs          = 5
+		recordsPerDB    = 20000
+		numValueQueries = 20
+	)
+
+	testDBs := map[string]testutil.DBRecords{}
+	for i := 0; i < numDBs; i++ {
+		testDBs[fmt.Sprintf("db%d", i)] = testutil.DBRecords{
+			Start:      i * recordsPerDB,
+			NumRecords: recordsPerDB,
+		}
+	}
+
+	objectStoragePath := filepath.Join(tempDir, objectsStorageDirName)
+	testutil.SetupDBTablesAtPath(b, "test", objectStoragePath, testDBs, false)
+
+	boltDBIndexClient, storageClient := buildTestClients(b, tempDir)
+	defer boltDBIndexClient.Stop()
+
+	table := NewTable(context.Background(), "test", filepath.Join(tempDir, cacheDirName), storageClient, boltDBIndexClient, 0, false, newMetrics(nil))
+	defer table.Close()
+	<-table.ready
+	require.NoError(b, table.Err())
+
+	var valueQueries []chunk.IndexQuery
+	for i := 0; i < numValueQueries; i++ {
+		valueQueries = append(valueQueries, chunk.IndexQuery{ValueEqual: []byte(strconv.Itoa(i * numDBs * recordsPerDB / numValueQueries))})
+	}
+
+	for _, bm := range []struct {
+		name    string
+		queries []chunk.IndexQuery
+	}{
+		{"full scan", []chunk.IndexQuery{{}}},
+		{"value lookups", valueQueries},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				var rows int
+				err := table.MultiQueries(context.Background(), bm.queries, func(_ chunk.IndexQuery, batch chunk.ReadBatch) bool {
+					for iter := batch.Iterator(); iter.Next(); {
+						rows++
+					}
+					return true
+				})
+				require.NoError(b, err)
+				require.NotZero(b, rows)
+			}
+		})
+	}
 }
@@ -5,33 +5,65 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/ring"
+	ring_client "github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/weaveworks/common/instrument"
+	"go.uber.org/atomic"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/util"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway/indexgatewaypb"
 	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/tenant"
+	loki_util "github.com/grafana/loki/pkg/util"
 )
 
 const maxQueriesPerGoroutine = 100
 
+// IndexesRead is the operation run on the ring of the Index Gateways to find the ones serving the index of a tenant.
+var IndexesRead = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
+
+// Load balancing policies of the connections to the Index Gateways.
+const (
+	LoadBalancingPickFirst  = "pick_first"
+	LoadBalancingRoundRobin = "round_robin"
+)
+
 type IndexGatewayClientConfig struct {
 	Address          string            `yaml:"server_address,omitempty"`
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config"`
+
+	PoolSize            int           `yaml:"pool_size"`
+	LoadBalancingPolicy string        `yaml:"load_balancing_policy"`
+	KeepaliveTime       time.Duration `yaml:"keepalive_time"`
+	KeepaliveTimeout    time.Duration `yaml:"keepalive_timeout"`
+
+	CacheSize int           `yaml:"cache_size"`
+	CacheTTL  time.Duration `yaml:"cache_ttl"`
+
+	// Ring is the ring of the Index Gateways when they run in ring mode. The index queries of each tenant are then
+	// sent to the gateways owning the tenant in the ring instead of the server address.
+	Ring ring.ReadRing `yaml:"-"`
 }
 
 // RegisterFlags registers flags.
 func (cfg *IndexGatewayClientConfig) RegisterFlags(f *flag.FlagSet) {
-	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("", f)
+	cfg.RegisterFlagsWithPrefix("", f)
 }
 
 // RegisterFlagsWithPrefix registers flags with prefix.
@@ -39,14 +71,47 @@ func (cfg *IndexGatewayClientConfig) RegisterFlagsWithPrefix(prefix string, f *f
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix(prefix, f)
 
 	f.StringVar(&cfg.Address, prefix+".server-address", "", "Hostname or IP of the Index Gateway gRPC server.")
+	f.IntVar(&cfg.PoolSize, prefix+".pool-size", 1, "Number of gRPC connections opened to the Index Gateways. The queries are spread over the connections in turn.")
+	f.StringVar(&cfg.LoadBalancingPolicy, prefix+".load-balancing-policy", LoadBalancingPickFirst, "Load balancing policy of each connection over the addresses the server address resolves to. Supported values are: pick_first, round_robin. Use a dns:/// server address to balance the queries over all the Index Gateways.")
+	f.DurationVar(&cfg.KeepaliveTime, prefix+".keepalive-time", 20*time.Second, "Interval of the keepalive pings sent on the idle connections to the Index Gateways.")
+	f.DurationVar(&cfg.KeepaliveTimeout, prefix+".keepalive-timeout", 10*time.Second, "Time to wait for the answer of a keepalive ping before closing the connection.")
+	f.IntVar(&cfg.CacheSize, prefix+".cache-size", 0, "Number of index query results cached by the client, to answer the identical queries of the shards of a sharded query without calling the Index Gateways again. 0 disables the cache.")
+	f.DurationVar(&cfg.CacheTTL, prefix+".cache-ttl", time.Minute, "Time for which a cached index query result is used. Should be short, the index of the most recent tables changes as the ingesters upload it.")
+}
+
+// Validate validates the config.
+func (cfg *IndexGatewayClientConfig) Validate() error {
+	if cfg.PoolSize < 1 {
+		return errors.New("the pool size of the index gateway client must be at least 1")
+	}
+	switch cfg.LoadBalancingPolicy {
+	case LoadBalancingPickFirst, LoadBalancingRoundRobin:
+	default:
+		return fmt.Errorf("unsupported index gateway client load balancing policy %q, supported values are: %s", cfg.LoadBalancingPolicy, strings.Join([]string{LoadBalancingPickFirst, LoadBalancingRoundRobin}, ", "))
+	}
+	return nil
 }
 
 type GatewayClient struct {
 	cfg IndexGatewayClientConfig
 
 	storeGatewayClientRequestDuration *prometheus.HistogramVec
-	conn                              *grpc.ClientConn
-	grpcClient                        indexgatewaypb.IndexGatewayClient
+	conns                             []*grpc.ClientConn
+	grpcClients                       []indexgatewaypb.IndexGatewayClient
+	next                              atomic.Uint32
+
+	// pool holds the clients of the Index Gateways found in the ring, nil when not using the ring.
+	pool *ring_client.Pool
+
+	// cache holds the cachedResult of the index queries by their query key, nil when caching is disabled.
+	cache       *lru.Cache
+	cacheLookup *prometheus.CounterVec
+}
+
+// cachedResult is the rows of an index query received from the Index Gateways.
+type cachedResult struct {
+	rows    []*indexgatewaypb.Row
+	expires time.Time
 }
 
 func NewGatewayClient(cfg IndexGatewayClientConfig, r prometheus.Registerer) (*GatewayClient, error) {
@@ -60,22 +125,108 @@ func NewGatewayClient(cfg IndexGatewayClientConfig, r prometheus.Registerer) (*G
 		}, []string{"operation", "status_code"}),
 	}
 
+	if cfg.CacheSize > 0 {
+		var err error
+		sgClient.cache, err = lru.New(cfg.CacheSize)
+		if err != nil {
+			return nil, err
+		}
+		sgClient.cacheLookup = promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "index_gateway_client_cache_lookups_total",
+			Help:      "Total number of index queries looked up in the cache of the Index Gateway client.",
+		}, []string{"result"})
+	}
+
 	dialOpts, err := cfg.GRPCClientConfig.DialOption(grpcclient.Instrument(sgClient.storeGatewayClientRequestDuration))
 	if err != nil {
 		return nil, err
 	}
+	// The keepalive option overrides the one of the gRPC client config.
+	dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                cfg.KeepaliveTime,
+		Timeout:             cfg.KeepaliveTimeout,
+		PermitWithoutStream: true,
+	}))
+	// pick_first is the default policy of gRPC.
+	if cfg.LoadBalancingPolicy == LoadBalancingRoundRobin {
+		dialOpts = append(dialOpts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":%q}`, LoadBalancingRoundRobin)))
+	}
 
-	sgClient.conn, err = grpc.Dial(cfg.Address, dialOpts...)
-	if err != nil {
-		return nil, err
+	if cfg.Ring != nil {
+		sgClient.pool = ring_client.NewPool("index-gateway", ring_client.PoolConfig{
+			CheckInterval:      10 * time.Second,
+			HealthCheckEnabled: true,
+			HealthCheckTimeout: time.Second,
+		}, ring_client.NewRingServiceDiscovery(cfg.Ring), newGatewayPoolClientFactory(dialOpts), promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "loki_boltdb_shipper",
+			Name:      "index_gateway_clients",
+			Help:      "The current number of clients connected to the Index Gateways found in the ring.",
+		}), util_log.Logger)
+		if err := services.StartAndAwaitRunning(context.Background(), sgClient.pool); err != nil {
+			return nil, errors.Wrap(err, "start index gateway client pool")
+		}
+		return sgClient, nil
 	}
 
-	sgClient.grpcClient = indexgatewaypb.NewIndexGatewayClient(sgClient.conn)
+	poolSize := util_math.Max(cfg.PoolSize, 1)
+	for i := 0; i < poolSize; i++ {
+		conn, err := grpc.Dial(cfg.Address, dialOpts...)
+		if err != nil {
+			sgClient.Stop()
+			return nil, err
+		}
+		sgClient.conns = append(sgClient.conns, conn)
+		sgClient.grpcClients = append(sgClient.grpcClients, indexgatewaypb.NewIndexGatewayClient(conn))
+	}
 	return sgClient, nil
 }
 
 func (s *GatewayClient) Stop() {
-	s.conn.Close()
+	if s.pool != nil {
+		_ = services.StopAndAwaitTerminated(context.Background(), s.pool)
+	}
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// queryGateways runs query with the client of the next connection of the pool or, in ring mode, of the next Index
+// Gateway owning the tenant of the query in the ring. In ring mode, the other gateways of the replication set are tried
+// in turn as long as query fails before it got any result.
+func (s *GatewayClient) queryGateways(ctx context.Context, query func(indexgatewaypb.IndexGatewayClient) (gotResults bool, err error)) error {
+	if s.pool == nil {
+		_, err := query(s.grpcClients[int(s.next.Inc()-1)%len(s.grpcClients)])
+		return err
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return err
+	}
+	bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+	rs, err := s.cfg.Ring.Get(loki_util.TokenFor(userID, ""), IndexesRead, bufDescs, bufHosts, bufZones)
+	if err != nil {
+		return errors.Wrap(err, "index gateway get ring")
+	}
+	addrs := rs.GetAddresses()
+	start := int(s.next.Inc() - 1)
+	var lastErr error
+	for i := range addrs {
+		addr := addrs[(start+i)%len(addrs)]
+		client, err := s.pool.GetClientFor(addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		gotResults, err := query(client.(indexgatewaypb.IndexGatewayClient))
+		if err == nil || gotResults || ctx.Err() != nil {
+			return err
+		}
+		level.Warn(util_log.Logger).Log("msg", "failed to query index gateway, trying the next one", "addr", addr, "err", err)
+		lastErr = err
+	}
+	return lastErr
 }
 
 func (s *GatewayClient) QueryPages(ctx context.Context, queries []chunk.IndexQuery, callback func(chunk.IndexQuery, chunk.ReadBatch) (shouldContinue bool)) error {
@@ -104,7 +255,15 @@ func (s *GatewayClient) doQueries(ctx context.Context, queries []chunk.IndexQuer
 	gatewayQueries := make([]*indexgatewaypb.IndexQuery, 0, len(queries))
 
 	for _, query := range queries {
-		queryKeyQueryMap[shipper_util.QueryKey(query)] = query
+		queryKey := shipper_util.QueryKey(query)
+		if rows, ok := s.cachedRows(queryKey); ok {
+			if !callback(query, &readBatch{&indexgatewaypb.QueryIndexResponse{QueryKey: queryKey, Rows: rows}}) {
+				return nil
+			}
+			continue
+		}
+
+		queryKeyQueryMap[queryKey] = query
 		gatewayQueries = append(gatewayQueries, &indexgatewaypb.IndexQuery{
 			TableName:        query.TableName,
 			HashValue:        query.HashValue,
@@ -114,32 +273,98 @@ func (s *GatewayClient) doQueries(ctx context.Context, queries []chunk.IndexQuer
 		})
 	}
 
-	streamer, err := s.grpcClient.QueryIndex(ctx, &indexgatewaypb.QueryIndexRequest{Queries: gatewayQueries})
-	if err != nil {
-		return err
+	if len(gatewayQueries) == 0 {
+		return nil
 	}
 
-	for {
-		resp, err := streamer.Recv()
-		if err == io.EOF {
-			break
+	// The rows of a query can be split across several responses, they are cached once all of them are received.
+	var received map[string][]*indexgatewaypb.Row
+	done := false
+	err := s.queryGateways(ctx, func(client indexgatewaypb.IndexGatewayClient) (bool, error) {
+		if s.cache != nil {
+			received = make(map[string][]*indexgatewaypb.Row, len(gatewayQueries))
 		}
+		streamer, err := client.QueryIndex(ctx, &indexgatewaypb.QueryIndexRequest{Queries: gatewayQueries})
 		if err != nil {
-			return errors.WithStack(err)
+			return false, err
 		}
-		query, ok := queryKeyQueryMap[resp.QueryKey]
-		if !ok {
-			level.Error(util_log.Logger).Log("msg", fmt.Sprintf("unexpected %s QueryKey received, expected queries %s", resp.QueryKey, fmt.Sprint(queryKeyQueryMap)))
-			return fmt.Errorf("unexpected %s QueryKey received", resp.QueryKey)
+
+		gotResults := false
+		for {
+			resp, err := streamer.Recv()
+			if err == io.EOF {
+				return gotResults, nil
+			}
+			if err != nil {
+				return gotResults, errors.WithStack(err)
+			}
+			query, ok := queryKeyQueryMap[resp.QueryKey]
+			if !ok {
+				level.Error(util_log.Logger).Log("msg", fmt.Sprintf("unexpected %s QueryKey received, expected queries %s", resp.QueryKey, fmt.Sprint(queryKeyQueryMap)))
+				return gotResults, fmt.Errorf("unexpected %s QueryKey received", resp.QueryKey)
+			}
+			gotResults = true
+			if received != nil {
+				received[resp.QueryKey] = append(received[resp.QueryKey], resp.Rows...)
+			}
+			if !callback(query, &readBatch{resp}) {
+				done = true
+				return gotResults, nil
+			}
 		}
-		if !callback(query, &readBatch{resp}) {
-			return nil
+	})
+	if err != nil || done {
+		return err
+	}
+
+	if received != nil {
+		// The queries without any response have no rows.
+		expires := time.Now().Add(s.cfg.CacheTTL)
+		for queryKey := range queryKeyQueryMap {
+			s.cache.Add(queryKey, &cachedResult{rows: received[queryKey], expires: expires})
 		}
 	}
 
 	return nil
 }
 
+// cachedRows returns the cached rows of a query, if any.
+func (s *GatewayClient) cachedRows(queryKey string) ([]*indexgatewaypb.Row, bool) {
+	if s.cache == nil {
+		return nil, false
+	}
+	if v, ok := s.cache.Get(queryKey); ok {
+		result := v.(*cachedResult)
+		if time.Now().Before(result.expires) {
+			s.cacheLookup.WithLabelValues("hit").Inc()
+			return result.rows, true
+		}
+		s.cache.Remove(queryKey)
+	}
+	s.cacheLookup.WithLabelValues("miss").Inc()
+	return nil, false
+}
+
+type gatewayPoolClient struct {
+	indexgatewaypb.IndexGatewayClient
+	grpc_health_v1.HealthClient
+	io.Closer
+}
+
+func newGatewayPoolClientFactory(dialOpts []grpc.DialOption) ring_client.PoolFactory {
+	return func(addr string) (ring_client.PoolClient, error) {
+		conn, err := grpc.Dial(addr, dialOpts...)
+		if err != nil {
+			return nil, err
+		}
+		return &gatewayPoolClient{
+			IndexGatewayClient: indexgatewaypb.NewIndexGatewayClient(conn),
+			HealthClient:       grpc_health_v1.NewHealthClient(conn),
+			Closer:             conn,
+		}, nil
+	}
+}
+
 func (s *GatewayClient) NewWriteBatch() chunk.WriteBatch {
 	panic("unsupported")
 }
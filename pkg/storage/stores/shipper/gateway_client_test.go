@@ -4,19 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	golog "log"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
 
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway/indexgatewaypb"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	loki_util "github.com/grafana/loki/pkg/util"
 )
 
 const (
@@ -32,10 +39,22 @@ const (
 	valuePrefix      = "value"
 )
 
-type mockIndexGatewayServer struct{}
+type mockIndexGatewayServer struct {
+	numQueries atomic.Int32
+	failing    atomic.Bool
+}
+
+func (m *mockIndexGatewayServer) QueryIndex(request *indexgatewaypb.QueryIndexRequest, server indexgatewaypb.IndexGateway_QueryIndexServer) error {
+	if m.failing.Load() {
+		return errors.New("index gateway failing")
+	}
+	for _, query := range request.Queries {
+		m.numQueries.Inc()
+		var i int
+		if _, err := fmt.Sscanf(query.TableName, tableNamePrefix+"%d", &i); err != nil {
+			return err
+		}
 
-func (m mockIndexGatewayServer) QueryIndex(request *indexgatewaypb.QueryIndexRequest, server indexgatewaypb.IndexGateway_QueryIndexServer) error {
-	for i, query := range request.Queries {
 		resp := indexgatewaypb.QueryIndexResponse{
 			QueryKey: "",
 			Rows:     nil,
@@ -80,16 +99,15 @@ func (m mockIndexGatewayServer) QueryIndex(request *indexgatewaypb.QueryIndexReq
 	return nil
 }
 
-func createTestGrpcServer(t *testing.T) (func(), string) {
-	var server mockIndexGatewayServer
+func createTestGrpcServer(t *testing.T, server *mockIndexGatewayServer) (func(), string) {
 	lis, err := net.Listen("tcp", "localhost:0")
 	require.NoError(t, err)
 	s := grpc.NewServer()
 
-	indexgatewaypb.RegisterIndexGatewayServer(s, &server)
+	indexgatewaypb.RegisterIndexGatewayServer(s, server)
 	go func() {
 		if err := s.Serve(lis); err != nil {
-			log.Fatalf("Failed to serve: %v", err)
+			golog.Fatalf("Failed to serve: %v", err)
 		}
 	}()
 	cleanup := func() {
@@ -99,21 +117,9 @@ func createTestGrpcServer(t *testing.T) (func(), string) {
 	return cleanup, lis.Addr().String()
 }
 
-func TestGatewayClient(t *testing.T) {
-	cleanup, storeAddress := createTestGrpcServer(t)
-	defer cleanup()
-
-	var cfg IndexGatewayClientConfig
-	flagext.DefaultValues(&cfg)
-	cfg.Address = storeAddress
-
-	gatewayClient, err := NewGatewayClient(cfg, nil)
-	require.NoError(t, err)
-
-	ctx := user.InjectOrgID(context.Background(), "fake")
-
+func testIndexQueries(n int) []chunk.IndexQuery {
 	queries := []chunk.IndexQuery{}
-	for i := 0; i < 10; i++ {
+	for i := 0; i < n; i++ {
 		queries = append(queries, chunk.IndexQuery{
 			TableName:        fmt.Sprintf("%s%d", tableNamePrefix, i),
 			HashValue:        fmt.Sprintf("%s%d", hashValuePrefix, i),
@@ -122,12 +128,21 @@ func TestGatewayClient(t *testing.T) {
 			ValueEqual:       []byte(fmt.Sprintf("%s%d", valueEqualPrefix, i)),
 		})
 	}
+	return queries
+}
+
+// checkQueryPages checks the rows of the queries returned by testIndexQueries.
+func checkQueryPages(t *testing.T, gatewayClient *GatewayClient, queries []chunk.IndexQuery) {
+	ctx := user.InjectOrgID(context.Background(), "fake")
 
 	numCallbacks := 0
-	err = gatewayClient.QueryPages(ctx, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) (shouldContinue bool) {
+	err := gatewayClient.QueryPages(ctx, queries, func(query chunk.IndexQuery, batch chunk.ReadBatch) (shouldContinue bool) {
 		itr := batch.Iterator()
 
-		for j := 0; j <= numCallbacks; j++ {
+		var i int
+		_, err := fmt.Sscanf(query.TableName, tableNamePrefix+"%d", &i)
+		require.NoError(t, err)
+		for j := 0; j <= i; j++ {
 			require.True(t, itr.Next())
 			require.Equal(t, fmt.Sprintf("%s%d", rangeValuePrefix, j), string(itr.RangeValue()))
 			require.Equal(t, fmt.Sprintf("%s%d", valuePrefix, j), string(itr.Value()))
@@ -141,3 +156,133 @@ func TestGatewayClient(t *testing.T) {
 
 	require.Equal(t, len(queries), numCallbacks)
 }
+
+func TestGatewayClient(t *testing.T) {
+	var server mockIndexGatewayServer
+	cleanup, storeAddress := createTestGrpcServer(t, &server)
+	defer cleanup()
+
+	var cfg IndexGatewayClientConfig
+	flagext.DefaultValues(&cfg)
+	cfg.Address = storeAddress
+
+	gatewayClient, err := NewGatewayClient(cfg, nil)
+	require.NoError(t, err)
+	defer gatewayClient.Stop()
+
+	checkQueryPages(t, gatewayClient, testIndexQueries(10))
+}
+
+func TestGatewayClient_Cache(t *testing.T) {
+	var server mockIndexGatewayServer
+	cleanup, storeAddress := createTestGrpcServer(t, &server)
+	defer cleanup()
+
+	var cfg IndexGatewayClientConfig
+	flagext.DefaultValues(&cfg)
+	cfg.Address = storeAddress
+	cfg.PoolSize = 2
+	cfg.LoadBalancingPolicy = LoadBalancingRoundRobin
+	cfg.CacheSize = 100
+	require.NoError(t, cfg.Validate())
+
+	gatewayClient, err := NewGatewayClient(cfg, nil)
+	require.NoError(t, err)
+	defer gatewayClient.Stop()
+	require.Len(t, gatewayClient.conns, 2)
+
+	queries := testIndexQueries(10)
+	checkQueryPages(t, gatewayClient, queries[:5])
+	require.Equal(t, int32(5), server.numQueries.Load())
+
+	// Only the queries not cached yet are sent to the gateway.
+	checkQueryPages(t, gatewayClient, queries)
+	require.Equal(t, int32(10), server.numQueries.Load())
+	checkQueryPages(t, gatewayClient, queries)
+	require.Equal(t, int32(10), server.numQueries.Load())
+
+	// The expired results are queried again.
+	for _, k := range gatewayClient.cache.Keys() {
+		v, _ := gatewayClient.cache.Peek(k)
+		v.(*cachedResult).expires = time.Now()
+	}
+	checkQueryPages(t, gatewayClient, queries)
+	require.Equal(t, int32(20), server.numQueries.Load())
+}
+
+func TestIndexGatewayClientConfig_Validate(t *testing.T) {
+	var cfg IndexGatewayClientConfig
+	flagext.DefaultValues(&cfg)
+	require.NoError(t, cfg.Validate())
+
+	cfg.LoadBalancingPolicy = "random"
+	require.Error(t, cfg.Validate())
+
+	cfg.LoadBalancingPolicy = LoadBalancingRoundRobin
+	cfg.PoolSize = 0
+	require.Error(t, cfg.Validate())
+}
+
+func TestGatewayClient_Ring(t *testing.T) {
+	var servers [2]mockIndexGatewayServer
+	var addrs [2]string
+	for i := range servers {
+		var cleanup func()
+		cleanup, addrs[i] = createTestGrpcServer(t, &servers[i])
+		defer cleanup()
+	}
+
+	// The first gateway owns the tenant fake.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	defer closer.Close()
+	token := loki_util.TokenFor("fake", "")
+	require.NoError(t, ringStore.CAS(context.Background(), "index-gateway", func(_ interface{}) (interface{}, bool, error) {
+		desc := ring.NewDesc()
+		desc.AddIngester("index-gateway-1", addrs[0], "", []uint32{token + 1}, ring.ACTIVE, time.Now())
+		desc.AddIngester("index-gateway-2", addrs[1], "", []uint32{token + 1<<31}, ring.ACTIVE, time.Now())
+		return desc, true, nil
+	}))
+
+	var ringCfg ring.Config
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.KVStore.Mock = ringStore
+	ringCfg.ReplicationFactor = 1
+	indexGatewayRing, err := ring.New(ringCfg, "index-gateway", "index-gateway", log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), indexGatewayRing))
+	defer services.StopAndAwaitTerminated(context.Background(), indexGatewayRing) //nolint:errcheck
+	require.Eventually(t, func() bool {
+		return indexGatewayRing.InstancesCount() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	var cfg IndexGatewayClientConfig
+	flagext.DefaultValues(&cfg)
+	cfg.Ring = indexGatewayRing
+	gatewayClient, err := NewGatewayClient(cfg, nil)
+	require.NoError(t, err)
+	defer gatewayClient.Stop()
+
+	checkQueryPages(t, gatewayClient, testIndexQueries(10))
+	require.Equal(t, int32(10), servers[0].numQueries.Load())
+	require.Equal(t, int32(0), servers[1].numQueries.Load())
+
+	// The queries failing on the gateway owning the tenant are retried on the other gateways of the replication set.
+	ringCfg.ReplicationFactor = 2
+	replicatedRing, err := ring.New(ringCfg, "index-gateway", "index-gateway", log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), replicatedRing))
+	defer services.StopAndAwaitTerminated(context.Background(), replicatedRing) //nolint:errcheck
+	require.Eventually(t, func() bool {
+		return replicatedRing.InstancesCount() == 2
+	}, time.Second, 10*time.Millisecond)
+	gatewayClient.cfg.Ring = replicatedRing
+
+	servers[0].failing.Store(true)
+	checkQueryPages(t, gatewayClient, testIndexQueries(10))
+	require.Equal(t, int32(10), servers[0].numQueries.Load())
+	require.Equal(t, int32(10), servers[1].numQueries.Load())
+
+	// The queries without tenant can't be routed.
+	err = gatewayClient.QueryPages(context.Background(), testIndexQueries(1), func(chunk.IndexQuery, chunk.ReadBatch) bool { return true })
+	require.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package indexgateway
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/ring"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	lokiutil "github.com/grafana/loki/pkg/util"
+)
+
+// Modes of the Index Gateways.
+const (
+	// ModeSimple lets the queriers send their index queries to the server address of the index gateway client.
+	ModeSimple = "simple"
+	// ModeRing makes the Index Gateways join a ring, in which the queriers find the gateways owning each tenant.
+	ModeRing = "ring"
+)
+
+const (
+	// ringKey is the key under which we store the index gateways ring in the KVStore.
+	ringKey = "index-gateway"
+
+	// ringNameForServer is the name of the ring used by the index gateway server.
+	ringNameForServer = "index-gateway"
+
+	// ringNumTokens is the number of tokens of each index gateway in the ring, to spread the tenants evenly.
+	ringNumTokens = 128
+
+	// ringAutoForgetUnhealthyPeriods is how many consecutive timeout periods an unhealthy instance
+	// in the ring will be automatically removed.
+	ringAutoForgetUnhealthyPeriods = 10
+)
+
+type Config struct {
+	Mode              string              `yaml:"mode"`
+	ReplicationFactor int                 `yaml:"replication_factor"`
+	Ring              lokiutil.RingConfig `yaml:"ring,omitempty"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Mode, "index-gateway.mode", ModeSimple, "Mode of the Index Gateways. Supported values are: simple, ring. In simple mode the queriers send their index queries to the server address of the index gateway client. In ring mode the Index Gateways join a ring and the queriers send the index queries of each tenant to the gateways owning it in the ring.")
+	f.IntVar(&cfg.ReplicationFactor, "index-gateway.replication-factor", 3, "Number of Index Gateways serving the index queries of each tenant in ring mode.")
+	cfg.Ring.RegisterFlagsWithPrefix("index-gateway.", "collectors/", f)
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	switch cfg.Mode {
+	case ModeSimple:
+	case ModeRing:
+		if cfg.ReplicationFactor < 1 {
+			return errors.New("the replication factor of the index gateway ring must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unsupported index gateway mode %q, supported values are: %s", cfg.Mode, strings.Join([]string{ModeSimple, ModeRing}, ", "))
+	}
+	return nil
+}
+
+// NewRing returns the client of the ring of the Index Gateways, used by both the gateways and the queriers.
+func NewRing(cfg Config, r prometheus.Registerer, logger log.Logger) (*ring.Ring, error) {
+	ringStore, err := kv.NewClient(
+		cfg.Ring.KVStore,
+		ring.GetCodec(),
+		kv.RegistererWithKVName(prometheus.WrapRegistererWithPrefix("loki_", r), "index-gateway"),
+		logger,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create KV store client")
+	}
+
+	ringCfg := cfg.Ring.ToRingConfig(cfg.ReplicationFactor)
+	indexGatewayRing, err := ring.NewWithStoreClientAndStrategy(ringCfg, ringNameForServer, ringKey, ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), prometheus.WrapRegistererWithPrefix("cortex_", r), logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "create ring client")
+	}
+	return indexGatewayRing, nil
+}
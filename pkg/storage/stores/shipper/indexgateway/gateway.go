@@ -1,10 +1,17 @@
 package indexgateway
 
 import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/loki/pkg/storage/chunk"
-	"github.com/grafana/loki/pkg/storage/stores/shipper"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexgateway/indexgatewaypb"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/util"
 )
@@ -15,17 +22,143 @@ type gateway struct {
 	services.Service
 
 	shipper chunk.IndexClient
+	logger  log.Logger
+
+	// Ring of the index gateways in ring mode, nil otherwise.
+	ringLifecycler *ring.BasicLifecycler
+	ring           *ring.Ring
+
+	// Subservices manager.
+	subservices        *services.Manager
+	subservicesWatcher *services.FailureWatcher
 }
 
-func NewIndexGateway(shipperIndexClient *shipper.Shipper) *gateway {
+// NewIndexGateway creates the Index Gateway serving the index of the shipper. In ring mode, it registers itself in
+// indexGatewayRing, which is expected to be running.
+func NewIndexGateway(cfg Config, shipperIndexClient chunk.IndexClient, indexGatewayRing *ring.Ring, r prometheus.Registerer, logger log.Logger) (*gateway, error) {
 	g := &gateway{
 		shipper: shipperIndexClient,
+		logger:  logger,
+	}
+	if cfg.Mode != ModeRing {
+		g.Service = services.NewIdleService(nil, func(failureCase error) error {
+			g.shipper.Stop()
+			return nil
+		})
+		return g, nil
+	}
+
+	ringStore, err := kv.NewClient(
+		cfg.Ring.KVStore,
+		ring.GetCodec(),
+		kv.RegistererWithKVName(prometheus.WrapRegistererWithPrefix("loki_", r), "index-gateway-lifecycler"),
+		logger,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create KV store client")
+	}
+	lifecyclerCfg, err := cfg.Ring.ToLifecyclerConfig(ringNumTokens, logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ring lifecycler config")
+	}
+
+	// Define lifecycler delegates in reverse order (last to be called defined first because they're
+	// chained via "next delegate").
+	delegate := ring.BasicLifecyclerDelegate(g)
+	delegate = ring.NewLeaveOnStoppingDelegate(delegate, logger)
+	delegate = ring.NewTokensPersistencyDelegate(cfg.Ring.TokensFilePath, ring.JOINING, delegate, logger)
+	delegate = ring.NewAutoForgetDelegate(ringAutoForgetUnhealthyPeriods*cfg.Ring.HeartbeatTimeout, delegate, logger)
+
+	g.ringLifecycler, err = ring.NewBasicLifecycler(lifecyclerCfg, ringNameForServer, ringKey, ringStore, delegate, logger, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "create ring lifecycler")
 	}
-	g.Service = services.NewIdleService(nil, func(failureCase error) error {
-		g.shipper.Stop()
+	g.ring = indexGatewayRing
+
+	g.subservices, err = services.NewManager(g.ringLifecycler)
+	if err != nil {
+		return nil, err
+	}
+	g.subservicesWatcher = services.NewFailureWatcher()
+	g.subservicesWatcher.WatchManager(g.subservices)
+
+	g.Service = services.NewBasicService(g.starting, g.running, g.stopping)
+	return g, nil
+}
+
+func (g *gateway) starting(ctx context.Context) (err error) {
+	// In case this function will return error we want to unregister the instance
+	// from the ring. We do it ensuring dependencies are gracefully stopped if they
+	// were already started.
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if stopErr := services.StopManagerAndAwaitStopped(context.Background(), g.subservices); stopErr != nil {
+			level.Error(g.logger).Log("msg", "failed to gracefully stop index gateway dependencies", "err", stopErr)
+		}
+	}()
+
+	if err := services.StartManagerAndAwaitHealthy(ctx, g.subservices); err != nil {
+		return errors.Wrap(err, "unable to start index gateway subservices")
+	}
+
+	// The index gateway has nothing to prepare before serving queries, so it becomes ACTIVE right away.
+	level.Info(g.logger).Log("msg", "waiting until index gateway is JOINING in the ring")
+	if err := ring.WaitInstanceState(ctx, g.ring, g.ringLifecycler.GetInstanceID(), ring.JOINING); err != nil {
+		return err
+	}
+	level.Info(g.logger).Log("msg", "index gateway is JOINING in the ring")
+
+	if err = g.ringLifecycler.ChangeState(ctx, ring.ACTIVE); err != nil {
+		return errors.Wrapf(err, "switch instance to %s in the ring", ring.ACTIVE)
+	}
+
+	level.Info(g.logger).Log("msg", "waiting until index gateway is ACTIVE in the ring")
+	if err := ring.WaitInstanceState(ctx, g.ring, g.ringLifecycler.GetInstanceID(), ring.ACTIVE); err != nil {
+		return err
+	}
+	level.Info(g.logger).Log("msg", "index gateway is ACTIVE in the ring")
+
+	return nil
+}
+
+func (g *gateway) running(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
 		return nil
-	})
-	return g
+	case err := <-g.subservicesWatcher.Chan():
+		return errors.Wrap(err, "index gateway subservice failed")
+	}
+}
+
+func (g *gateway) stopping(_ error) error {
+	defer g.shipper.Stop()
+	return services.StopManagerAndAwaitStopped(context.Background(), g.subservices)
+}
+
+func (g *gateway) OnRingInstanceRegister(_ *ring.BasicLifecycler, ringDesc ring.Desc, instanceExists bool, instanceID string, instanceDesc ring.InstanceDesc) (ring.InstanceState, ring.Tokens) {
+	// When we initialize the index gateway instance in the ring we want to start from
+	// a clean situation, so whatever is the state we set it JOINING, while we keep existing
+	// tokens (if any) or the ones loaded from file.
+	var tokens []uint32
+	if instanceExists {
+		tokens = instanceDesc.GetTokens()
+	}
+
+	takenTokens := ringDesc.GetTokens()
+	newTokens := ring.GenerateTokens(ringNumTokens-len(tokens), takenTokens)
+
+	// Tokens sorting will be enforced by the parent caller.
+	tokens = append(tokens, newTokens...)
+
+	return ring.JOINING, tokens
+}
+
+func (g *gateway) OnRingInstanceTokens(_ *ring.BasicLifecycler, _ ring.Tokens) {}
+func (g *gateway) OnRingInstanceStopping(_ *ring.BasicLifecycler)              {}
+func (g *gateway) OnRingInstanceHeartbeat(_ *ring.BasicLifecycler, _ *ring.Desc, _ *ring.InstanceDesc) {
 }
 
 func (g gateway) QueryIndex(request *indexgatewaypb.QueryIndexRequest, server indexgatewaypb.IndexGateway_QueryIndexServer) error {
@@ -1,10 +1,17 @@
 package indexgateway
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
@@ -118,3 +125,52 @@ func TestGateway_sendBatch(t *testing.T) {
 		require.Len(t, expectedRanges, 0)
 	}
 }
+
+type mockIndexClient struct {
+	chunk.IndexClient
+	stopped atomic.Bool
+}
+
+func (m *mockIndexClient) Stop() {
+	m.stopped.Store(true)
+}
+
+func TestGateway_Ring(t *testing.T) {
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { closer.Close() })
+
+	var cfg Config
+	flagext.DefaultValues(&cfg)
+	require.Error(t, (&Config{Mode: "sharded"}).Validate())
+	cfg.Mode = ModeRing
+	cfg.ReplicationFactor = 1
+	require.NoError(t, cfg.Validate())
+	cfg.Ring.KVStore.Mock = ringStore
+	cfg.Ring.InstanceID = "index-gateway-1"
+	cfg.Ring.InstanceAddr = "127.0.0.1"
+	cfg.Ring.InstancePort = 9095
+
+	indexGatewayRing, err := NewRing(cfg, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), indexGatewayRing))
+	defer services.StopAndAwaitTerminated(context.Background(), indexGatewayRing) //nolint:errcheck
+
+	indexClient := &mockIndexClient{}
+	g, err := NewIndexGateway(cfg, indexClient, indexGatewayRing, nil, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), g))
+
+	// The gateway is ACTIVE in the ring and owns all the tenants.
+	require.Equal(t, 1, indexGatewayRing.InstancesCount())
+	bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+	rs, err := indexGatewayRing.Get(123, ring.Read, bufDescs, bufHosts, bufZones)
+	require.NoError(t, err)
+	require.Equal(t, []string{"127.0.0.1:9095"}, rs.GetAddresses())
+
+	// The gateway leaves the ring when stopping.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), g))
+	require.True(t, indexClient.stopped.Load())
+	desc, err := ringStore.Get(context.Background(), ringKey)
+	require.NoError(t, err)
+	require.Empty(t, desc.(*ring.Desc).Ingesters)
+}
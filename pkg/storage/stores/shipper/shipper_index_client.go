@@ -19,11 +19,13 @@ import (
 
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/local"
+	chunk_storage "github.com/grafana/loki/pkg/storage/chunk/storage"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/util"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/downloads"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/storage"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/uploads"
 	shipper_util "github.com/grafana/loki/pkg/storage/stores/shipper/util"
+	"github.com/grafana/loki/pkg/util/flagext"
 )
 
 const (
@@ -53,33 +55,53 @@ type boltDBIndexClient interface {
 }
 
 type Config struct {
-	ActiveIndexDirectory     string                   `yaml:"active_index_directory"`
-	SharedStoreType          string                   `yaml:"shared_store"`
-	SharedStoreKeyPrefix     string                   `yaml:"shared_store_key_prefix"`
-	CacheLocation            string                   `yaml:"cache_location"`
-	CacheTTL                 time.Duration            `yaml:"cache_ttl"`
-	ResyncInterval           time.Duration            `yaml:"resync_interval"`
-	QueryReadyNumDays        int                      `yaml:"query_ready_num_days"`
-	IndexGatewayClientConfig IndexGatewayClientConfig `yaml:"index_gateway_client"`
-	IngesterName             string                   `yaml:"-"`
-	Mode                     int                      `yaml:"-"`
-	IngesterDBRetainPeriod   time.Duration            `yaml:"-"`
+	ActiveIndexDirectory      string                          `yaml:"active_index_directory"`
+	SharedStoreType           string                          `yaml:"shared_store"`
+	SharedStoreKeyPrefix      string                          `yaml:"shared_store_key_prefix"`
+	SharedStoreConfig         chunk_storage.ObjectStoreConfig `yaml:"shared_store_config"`
+	CacheLocation             string                          `yaml:"cache_location"`
+	CacheTTL                  time.Duration                   `yaml:"cache_ttl"`
+	CacheSizeLimit            flagext.ByteSize                `yaml:"cache_size_limit"`
+	ResyncInterval            time.Duration                   `yaml:"resync_interval"`
+	InvalidationCheckInterval time.Duration                   `yaml:"invalidation_check_interval"`
+	QueryReadyNumDays         int                             `yaml:"query_ready_num_days"`
+	PrefetchLookback          time.Duration                   `yaml:"prefetch_lookback"`
+	PrefetchMaxDiskUsage      flagext.ByteSize                `yaml:"prefetch_max_disk_usage"`
+	CoalesceQueries           bool                            `yaml:"coalesce_queries"`
+	IndexFileOpenTimeout      time.Duration                   `yaml:"index_file_open_timeout"`
+	IndexGatewayClientConfig  IndexGatewayClientConfig        `yaml:"index_gateway_client"`
+	IngesterName              string                          `yaml:"-"`
+	Mode                      int                             `yaml:"-"`
+	IngesterDBRetainPeriod    time.Duration                   `yaml:"-"`
+	// FailOnCorruptFiles makes the queries of the tables with index files failing to open fail, for the store to
+	// fall back to listing the chunks.
+	FailOnCorruptFiles bool `yaml:"-"`
 }
 
 // RegisterFlags registers flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.IndexGatewayClientConfig.RegisterFlagsWithPrefix("boltdb.shipper.index-gateway-client", f)
+	cfg.SharedStoreConfig.RegisterFlagsWithPrefix("boltdb.shipper.shared-store-config.", f)
 
 	f.StringVar(&cfg.ActiveIndexDirectory, "boltdb.shipper.active-index-directory", "", "Directory where ingesters would write boltdb files which would then be uploaded by shipper to configured storage")
 	f.StringVar(&cfg.SharedStoreType, "boltdb.shipper.shared-store", "", "Shared store for keeping boltdb files. Supported types: gcs, s3, azure, filesystem")
 	f.StringVar(&cfg.SharedStoreKeyPrefix, "boltdb.shipper.shared-store.key-prefix", "index/", "Prefix to add to Object Keys in Shared store. Path separator(if any) should always be a '/'. Prefix should never start with a separator but should always end with it")
 	f.StringVar(&cfg.CacheLocation, "boltdb.shipper.cache-location", "", "Cache location for restoring boltDB files for queries")
 	f.DurationVar(&cfg.CacheTTL, "boltdb.shipper.cache-ttl", 24*time.Hour, "TTL for boltDB files restored in cache for queries")
+	f.Var(&cfg.CacheSizeLimit, "boltdb.shipper.cache-size-limit", "Maximum size of boltDB files restored in cache for queries, i.e. 10GB. Least recently used tables are evicted when the limit is reached, starting with the ones outside the query ready and prefetch windows. 0 means no limit.")
 	f.DurationVar(&cfg.ResyncInterval, "boltdb.shipper.resync-interval", 5*time.Minute, "Resync downloaded files with the storage")
+	f.DurationVar(&cfg.InvalidationCheckInterval, "boltdb.shipper.invalidation-check-interval", 0, "How often to check the tables invalidated by the compactor when it rewrites or deletes their files, to resync them ahead of the resync interval. 0 disables the checks.")
 	f.IntVar(&cfg.QueryReadyNumDays, "boltdb.shipper.query-ready-num-days", 0, "Number of days of index to be kept downloaded for queries. Works only with tables created with 24h period.")
+	f.DurationVar(&cfg.PrefetchLookback, "boltdb.shipper.prefetch-lookback", 0, "Duration for which the tables queried by tenants are remembered and kept downloaded in the background, relative to the active table. 0 disables prefetching. Works only with tables created with 24h period.")
+	f.Var(&cfg.PrefetchMaxDiskUsage, "boltdb.shipper.prefetch-max-disk-usage", "Maximum size of the cache location beyond which no more tables are prefetched, i.e. 10GB. 0 means no limit.")
+	f.BoolVar(&cfg.CoalesceQueries, "boltdb.shipper.coalesce-queries", true, "Coalesce identical index queries running concurrently on a table, like the ones of the shards of a sharded query, so that the index files are scanned only once and the results shared.")
+	f.DurationVar(&cfg.IndexFileOpenTimeout, "boltdb.shipper.index-file-open-timeout", 5*time.Second, "Maximum time to wait for the lock of a downloaded index file when opening it. Files failing to open are moved to the quarantine directory of the cache location and downloaded again once. 0 means no timeout.")
 }
 
 func (cfg *Config) Validate() error {
+	if err := cfg.IndexGatewayClientConfig.Validate(); err != nil {
+		return err
+	}
 	return shipper_util.ValidateSharedStoreKeyPrefix(cfg.SharedStoreKeyPrefix)
 }
 
@@ -148,10 +170,18 @@ func (s *Shipper) init(storageClient chunk.ObjectClient, registerer prometheus.R
 
 	if s.cfg.Mode != ModeWriteOnly {
 		cfg := downloads.Config{
-			CacheDir:          s.cfg.CacheLocation,
-			SyncInterval:      s.cfg.ResyncInterval,
-			CacheTTL:          s.cfg.CacheTTL,
-			QueryReadyNumDays: s.cfg.QueryReadyNumDays,
+			CacheDir:             s.cfg.CacheLocation,
+			SyncInterval:         s.cfg.ResyncInterval,
+			CacheTTL:             s.cfg.CacheTTL,
+			QueryReadyNumDays:    s.cfg.QueryReadyNumDays,
+			PrefetchLookback:     s.cfg.PrefetchLookback,
+			PrefetchMaxDiskUsage: int64(s.cfg.PrefetchMaxDiskUsage.Val()),
+			CacheSizeLimit:       int64(s.cfg.CacheSizeLimit.Val()),
+			CoalesceQueries:      s.cfg.CoalesceQueries,
+			OpenTimeout:          s.cfg.IndexFileOpenTimeout,
+
+			InvalidationCheckInterval: s.cfg.InvalidationCheckInterval,
+			FailOnCorruptFiles:        s.cfg.FailOnCorruptFiles,
 		}
 		downloadsManager, err := downloads.NewTableManager(cfg, s.boltDBIndexClient, indexStorageClient, registerer)
 		if err != nil {
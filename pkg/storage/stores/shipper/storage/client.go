@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"path"
@@ -10,7 +11,13 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk"
 )
 
-const delimiter = "/"
+const (
+	delimiter = "/"
+
+	// invalidationMarkerSuffix is the suffix of the invalidation markers, which are stored as files next to the table
+	// directories so they are not considered as tables or index files.
+	invalidationMarkerSuffix = ".invalidated"
+)
 
 // Client is used to manage boltdb index files in object storage, when using boltdb-shipper.
 type Client interface {
@@ -20,6 +27,13 @@ type Client interface {
 	PutFile(ctx context.Context, tableName, fileName string, file io.ReadSeeker) error
 	DeleteFile(ctx context.Context, tableName, fileName string) error
 	IsFileNotFoundErr(err error) bool
+	// InvalidateTable signals the readers of a table that its files were rewritten or deleted.
+	InvalidateTable(ctx context.Context, tableName string) error
+	// ListInvalidatedTables returns when each invalidated table was last invalidated, as per the storage clock. The
+	// time is zero when the storage doesn't report the modification time of the objects.
+	ListInvalidatedTables(ctx context.Context) (map[string]time.Time, error)
+	// DeleteInvalidationMarker removes the invalidation of a table, once the readers synced it.
+	DeleteInvalidationMarker(ctx context.Context, tableName string) error
 	Stop()
 }
 
@@ -87,6 +101,33 @@ func (s *indexStorageClient) DeleteFile(ctx context.Context, tableName, fileName
 	return s.objectClient.DeleteObject(ctx, s.storagePrefix+path.Join(tableName, fileName))
 }
 
+func (s *indexStorageClient) InvalidateTable(ctx context.Context, tableName string) error {
+	marker := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	return s.objectClient.PutObject(ctx, s.storagePrefix+tableName+invalidationMarkerSuffix, bytes.NewReader(marker))
+}
+
+func (s *indexStorageClient) ListInvalidatedTables(ctx context.Context) (map[string]time.Time, error) {
+	objects, _, err := s.objectClient.List(ctx, s.storagePrefix, delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidations := map[string]time.Time{}
+	for _, object := range objects {
+		name := path.Base(object.Key)
+		if !strings.HasSuffix(name, invalidationMarkerSuffix) {
+			continue
+		}
+		invalidations[strings.TrimSuffix(name, invalidationMarkerSuffix)] = object.ModifiedAt
+	}
+
+	return invalidations, nil
+}
+
+func (s *indexStorageClient) DeleteInvalidationMarker(ctx context.Context, tableName string) error {
+	return s.objectClient.DeleteObject(ctx, s.storagePrefix+tableName+invalidationMarkerSuffix)
+}
+
 func (s *indexStorageClient) IsFileNotFoundErr(err error) bool {
 	return s.objectClient.IsObjectNotFoundErr(err)
 }
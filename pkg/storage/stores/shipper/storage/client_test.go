@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -79,3 +80,49 @@ func TestIndexStorageClient(t *testing.T) {
 	tablesToSetup["table2"] = append(tablesToSetup["table2"], "e")
 	verifyFiles()
 }
+
+func TestIndexStorageClient_InvalidateTable(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "test-index-storage-client-invalidate")
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	}()
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: tempDir})
+	require.NoError(t, err)
+	indexStorageClient := NewIndexStorageClient(objectClient, "prefix/")
+
+	require.NoError(t, indexStorageClient.PutFile(context.Background(), "table1", "a", bytes.NewReader([]byte("a"))))
+
+	invalidations, err := indexStorageClient.ListInvalidatedTables(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, invalidations)
+
+	require.NoError(t, indexStorageClient.InvalidateTable(context.Background(), "table1"))
+	invalidations, err = indexStorageClient.ListInvalidatedTables(context.Background())
+	require.NoError(t, err)
+	require.Len(t, invalidations, 1)
+	invalidatedAt := invalidations["table1"]
+	require.False(t, invalidatedAt.IsZero())
+
+	// invalidating the table again moves its invalidation time forward.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, indexStorageClient.InvalidateTable(context.Background(), "table1"))
+	invalidations, err = indexStorageClient.ListInvalidatedTables(context.Background())
+	require.NoError(t, err)
+	require.True(t, invalidations["table1"].After(invalidatedAt))
+
+	// the markers are neither tables nor index files.
+	tables, err := indexStorageClient.ListTables(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"table1"}, tables)
+	files, err := indexStorageClient.ListFiles(context.Background(), "table1")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	require.NoError(t, indexStorageClient.DeleteInvalidationMarker(context.Background(), "table1"))
+	invalidations, err = indexStorageClient.ListInvalidatedTables(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, invalidations)
+}
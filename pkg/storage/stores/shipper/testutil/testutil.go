@@ -21,7 +21,7 @@ import (
 
 var boltBucketName = []byte("index")
 
-func AddRecordsToDB(t *testing.T, path string, dbClient *local.BoltIndexClient, start, numRecords int) {
+func AddRecordsToDB(t testing.TB, path string, dbClient *local.BoltIndexClient, start, numRecords int) {
 	t.Helper()
 	db, err := local.OpenBoltdbFile(path)
 	require.NoError(t, err)
@@ -141,7 +141,7 @@ type DBRecords struct {
 	Start, NumRecords int
 }
 
-func SetupDBTablesAtPath(t *testing.T, tableName, path string, dbs map[string]DBRecords, compressRandomFiles bool) string {
+func SetupDBTablesAtPath(t testing.TB, tableName, path string, dbs map[string]DBRecords, compressRandomFiles bool) string {
 	t.Helper()
 	boltIndexClient, err := local.NewBoltDBIndexClient(local.BoltDBConfig{Directory: path})
 	require.NoError(t, err)
@@ -163,7 +163,7 @@ func SetupDBTablesAtPath(t *testing.T, tableName, path string, dbs map[string]DB
 	return tablePath
 }
 
-func compressFile(t *testing.T, filepath string) {
+func compressFile(t testing.TB, filepath string) {
 	t.Helper()
 	uncompressedFile, err := os.Open(filepath)
 	require.NoError(t, err)
@@ -9,6 +9,7 @@ import (
 	"runtime/debug"
 	"strings"
 	"sync"
+	"time"
 
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/go-kit/log/level"
@@ -174,16 +175,28 @@ type result struct {
 
 // SafeOpenBoltdbFile will recover from a panic opening a DB file, and return the panic message in the err return object.
 func SafeOpenBoltdbFile(path string) (*bbolt.DB, error) {
+	return safeOpenBoltdbFile(path, local.OpenBoltdbFile)
+}
+
+// SafeOpenBoltdbFileReadOnly is like SafeOpenBoltdbFile but opens the DB file in read-only mode,
+// waiting at most timeout for the file lock.
+func SafeOpenBoltdbFileReadOnly(path string, timeout time.Duration) (*bbolt.DB, error) {
+	return safeOpenBoltdbFile(path, func(path string) (*bbolt.DB, error) {
+		return local.OpenBoltdbFileReadOnly(path, timeout)
+	})
+}
+
+func safeOpenBoltdbFile(path string, open func(path string) (*bbolt.DB, error)) (*bbolt.DB, error) {
 	result := make(chan *result)
 	// Open the file in a separate goroutine because we want to change
 	// the behavior of a Fault for just this operation and not for the
 	// calling goroutine
-	go safeOpenBoltDbFile(path, result)
+	go doSafeOpenBoltdbFile(path, open, result)
 	res := <-result
 	return res.boltdb, res.err
 }
 
-func safeOpenBoltDbFile(path string, ret chan *result) {
+func doSafeOpenBoltdbFile(path string, open func(path string) (*bbolt.DB, error), ret chan *result) {
 	// boltdb can throw faults which are not caught by recover unless we turn them into panics
 	debug.SetPanicOnFault(true)
 	res := &result{}
@@ -197,7 +210,7 @@ func safeOpenBoltDbFile(path string, ret chan *result) {
 		ret <- res
 	}()
 
-	b, err := local.OpenBoltdbFile(path)
+	b, err := open(path)
 	res.boltdb = b
 	res.err = err
 }
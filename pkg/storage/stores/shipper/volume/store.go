@@ -0,0 +1,113 @@
+package volume
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// completeMarkerName is the name of the object uploaded once all the volumes of a table are stored.
+const completeMarkerName = "complete"
+
+// tenantKeySuffix is the suffix of the objects storing the volume of a tenant.
+const tenantKeySuffix = ".json.gz"
+
+// Store stores the volumes of tables per tenant as gzipped JSON objects under <prefix><table>/<tenant>.json.gz.
+type Store struct {
+	objectClient chunk.ObjectClient
+	keyPrefix    string
+}
+
+func NewStore(objectClient chunk.ObjectClient, keyPrefix string) *Store {
+	return &Store{
+		objectClient: objectClient,
+		keyPrefix:    keyPrefix,
+	}
+}
+
+// Put stores the volume of the tenant for the table.
+func (s *Store) Put(ctx context.Context, tableName, userID string, v *TableVolume) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(v); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return s.objectClient.PutObject(ctx, s.tenantKey(tableName, userID), bytes.NewReader(buf.Bytes()))
+}
+
+// Get returns the volume of the tenant for the table, or nil if the tenant has no data in the table.
+func (s *Store) Get(ctx context.Context, tableName, userID string) (*TableVolume, error) {
+	reader, err := s.objectClient.GetObject(ctx, s.tenantKey(tableName, userID))
+	if err != nil {
+		if s.objectClient.IsObjectNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var v TableVolume
+	if err := json.NewDecoder(gz).Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode volume of table %s for tenant %s: %w", tableName, userID, err)
+	}
+	return &v, nil
+}
+
+// MarkComplete records that the volumes of all the tenants of the table are stored.
+func (s *Store) MarkComplete(ctx context.Context, tableName string) error {
+	return s.objectClient.PutObject(ctx, s.keyPrefix+path.Join(tableName, completeMarkerName), bytes.NewReader(nil))
+}
+
+// IsComplete returns true if the volumes of all the tenants of the table are stored.
+func (s *Store) IsComplete(ctx context.Context, tableName string) (bool, error) {
+	reader, err := s.objectClient.GetObject(ctx, s.keyPrefix+path.Join(tableName, completeMarkerName))
+	if err != nil {
+		if s.objectClient.IsObjectNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	_, err = io.Copy(io.Discard, reader)
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
+	return err == nil, err
+}
+
+// Tenants returns the tenants whose volume is stored for the table.
+func (s *Store) Tenants(ctx context.Context, tableName string) ([]string, error) {
+	objects, _, err := s.objectClient.List(ctx, s.keyPrefix+tableName+"/", "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var tenants []string
+	for _, object := range objects {
+		name := path.Base(object.Key)
+		if strings.HasSuffix(name, tenantKeySuffix) {
+			tenants = append(tenants, strings.TrimSuffix(name, tenantKeySuffix))
+		}
+	}
+	return tenants, nil
+}
+
+func (s *Store) tenantKey(tableName, userID string) string {
+	return s.keyPrefix + path.Join(tableName, userID+tenantKeySuffix)
+}
@@ -0,0 +1,169 @@
+package volume
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// TableVolume holds the per-hour volume of the streams of a tenant indexed in a table.
+type TableVolume struct {
+	Streams []StreamVolume `json:"streams"`
+}
+
+// StreamVolume holds the per-hour volume of a stream.
+type StreamVolume struct {
+	Labels string       `json:"labels"`
+	Hours  []HourVolume `json:"hours"`
+}
+
+// HourVolume holds the number of entries and their bytes of a stream in an hour.
+type HourVolume struct {
+	// Hour is the Unix timestamp in seconds of the start of the hour.
+	Hour  int64  `json:"hour"`
+	Count uint64 `json:"count"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// Builder accumulates entries of streams to build a TableVolume.
+type Builder struct {
+	streams map[string]map[int64]*HourVolume
+}
+
+func NewBuilder() *Builder {
+	return &Builder{streams: map[string]map[int64]*HourVolume{}}
+}
+
+// Add accounts for an entry of the stream with the given labels.
+func (b *Builder) Add(lbs string, ts time.Time, bytes int) {
+	hours, ok := b.streams[lbs]
+	if !ok {
+		hours = map[int64]*HourVolume{}
+		b.streams[lbs] = hours
+	}
+
+	hour := ts.Truncate(time.Hour).Unix()
+	v, ok := hours[hour]
+	if !ok {
+		v = &HourVolume{Hour: hour}
+		hours[hour] = v
+	}
+	v.Count++
+	v.Bytes += uint64(bytes)
+}
+
+// Empty returns true if no entries were added.
+func (b *Builder) Empty() bool {
+	return len(b.streams) == 0
+}
+
+// Build returns the TableVolume with streams sorted by labels and hours sorted by time.
+func (b *Builder) Build() *TableVolume {
+	v := &TableVolume{Streams: make([]StreamVolume, 0, len(b.streams))}
+	for lbs, hours := range b.streams {
+		stream := StreamVolume{Labels: lbs, Hours: make([]HourVolume, 0, len(hours))}
+		for _, h := range hours {
+			stream.Hours = append(stream.Hours, *h)
+		}
+		sort.Slice(stream.Hours, func(i, j int) bool { return stream.Hours[i].Hour < stream.Hours[j].Hour })
+		v.Streams = append(v.Streams, stream)
+	}
+	sort.Slice(v.Streams, func(i, j int) bool { return v.Streams[i].Labels < v.Streams[j].Labels })
+	return v
+}
+
+// Volume is the volume of a group of streams.
+type Volume struct {
+	Labels map[string]string `json:"labels"`
+	Count  uint64            `json:"count"`
+	Bytes  uint64            `json:"bytes"`
+}
+
+// Aggregator sums the volume of the streams matching a selector over a time range, grouped by labels.
+type Aggregator struct {
+	matchers      []*labels.Matcher
+	by            []string
+	from, through model.Time
+
+	volumes map[string]*Volume
+}
+
+// NewAggregator creates an Aggregator for the streams matching all the matchers. Volumes are grouped by the
+// given labels or by stream when none are given. Hours are accounted for when they overlap with [from, through].
+func NewAggregator(matchers []*labels.Matcher, by []string, from, through model.Time) *Aggregator {
+	return &Aggregator{
+		matchers: matchers,
+		by:       by,
+		from:     from,
+		through:  through,
+		volumes:  map[string]*Volume{},
+	}
+}
+
+// Add accounts for the volume of the streams of the table.
+func (a *Aggregator) Add(v *TableVolume) error {
+Outer:
+	for _, stream := range v.Streams {
+		lbs, err := parser.ParseMetric(stream.Labels)
+		if err != nil {
+			return err
+		}
+		for _, m := range a.matchers {
+			if !m.Matches(lbs.Get(m.Name)) {
+				continue Outer
+			}
+		}
+
+		if len(a.by) > 0 {
+			grouped := make(labels.Labels, 0, len(a.by))
+			for _, name := range a.by {
+				if value := lbs.Get(name); value != "" {
+					grouped = append(grouped, labels.Label{Name: name, Value: value})
+				}
+			}
+			sort.Sort(grouped)
+			lbs = grouped
+		}
+
+		var count, bytes uint64
+		for _, h := range stream.Hours {
+			start := model.TimeFromUnix(h.Hour)
+			if start > a.through || start.Add(time.Hour) <= a.from {
+				continue
+			}
+			count += h.Count
+			bytes += h.Bytes
+		}
+		if count == 0 {
+			continue
+		}
+
+		key := lbs.String()
+		volume, ok := a.volumes[key]
+		if !ok {
+			volume = &Volume{Labels: lbs.Map()}
+			a.volumes[key] = volume
+		}
+		volume.Count += count
+		volume.Bytes += bytes
+	}
+	return nil
+}
+
+// Volumes returns the volumes sorted by decreasing bytes.
+func (a *Aggregator) Volumes() []Volume {
+	volumes := make([]Volume, 0, len(a.volumes))
+	for _, v := range a.volumes {
+		volumes = append(volumes, *v)
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].Bytes == volumes[j].Bytes {
+			return labels.FromMap(volumes[i].Labels).String() < labels.FromMap(volumes[j].Labels).String()
+		}
+		return volumes[i].Bytes > volumes[j].Bytes
+	})
+	return volumes
+}
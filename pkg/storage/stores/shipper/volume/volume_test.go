@@ -0,0 +1,119 @@
+package volume
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/storage/chunk/local"
+)
+
+func Test_Builder(t *testing.T) {
+	b := NewBuilder()
+	require.True(t, b.Empty())
+
+	start := time.Unix(3600*10, 0)
+	b.Add(`{app="foo"}`, start.Add(time.Minute), 10)
+	b.Add(`{app="foo"}`, start.Add(2*time.Minute), 5)
+	b.Add(`{app="foo"}`, start.Add(time.Hour), 1)
+	b.Add(`{app="bar"}`, start, 3)
+
+	require.False(t, b.Empty())
+	require.Equal(t, &TableVolume{
+		Streams: []StreamVolume{
+			{Labels: `{app="bar"}`, Hours: []HourVolume{{Hour: 36000, Count: 1, Bytes: 3}}},
+			{Labels: `{app="foo"}`, Hours: []HourVolume{{Hour: 36000, Count: 2, Bytes: 15}, {Hour: 39600, Count: 1, Bytes: 1}}},
+		},
+	}, b.Build())
+}
+
+func Test_Aggregator(t *testing.T) {
+	v := &TableVolume{
+		Streams: []StreamVolume{
+			{Labels: `{app="bar", env="dev"}`, Hours: []HourVolume{{Hour: 0, Count: 1, Bytes: 3}}},
+			{Labels: `{app="foo", env="dev"}`, Hours: []HourVolume{{Hour: 0, Count: 2, Bytes: 15}, {Hour: 3600, Count: 1, Bytes: 1}}},
+			{Labels: `{app="foo", env="prod"}`, Hours: []HourVolume{{Hour: 0, Count: 4, Bytes: 20}, {Hour: 7200, Count: 1, Bytes: 1}}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		matchers      []*labels.Matcher
+		by            []string
+		from, through model.Time
+		expected      []Volume
+	}{
+		{
+			name:     "by stream",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "app", "foo")},
+			from:     0,
+			through:  model.TimeFromUnix(7200),
+			expected: []Volume{
+				{Labels: map[string]string{"app": "foo", "env": "prod"}, Count: 5, Bytes: 21},
+				{Labels: map[string]string{"app": "foo", "env": "dev"}, Count: 3, Bytes: 16},
+			},
+		},
+		{
+			name:     "by label",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "app", ".+")},
+			by:       []string{"env"},
+			from:     0,
+			through:  model.TimeFromUnix(7200),
+			expected: []Volume{
+				{Labels: map[string]string{"env": "prod"}, Count: 5, Bytes: 21},
+				{Labels: map[string]string{"env": "dev"}, Count: 4, Bytes: 19},
+			},
+		},
+		{
+			name:     "partial range",
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "env", "dev")},
+			by:       []string{"app"},
+			from:     model.TimeFromUnix(3601),
+			through:  model.TimeFromUnix(7199),
+			expected: []Volume{
+				{Labels: map[string]string{"app": "foo"}, Count: 1, Bytes: 1},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAggregator(tc.matchers, tc.by, tc.from, tc.through)
+			require.NoError(t, a.Add(v))
+			require.Equal(t, tc.expected, a.Volumes())
+		})
+	}
+}
+
+func Test_Store(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	s := NewStore(objectClient, "volume/")
+	ctx := context.Background()
+
+	v, err := s.Get(ctx, "index_1", "fake")
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	complete, err := s.IsComplete(ctx, "index_1")
+	require.NoError(t, err)
+	require.False(t, complete)
+
+	expected := &TableVolume{Streams: []StreamVolume{{Labels: `{app="foo"}`, Hours: []HourVolume{{Hour: 3600, Count: 2, Bytes: 10}}}}}
+	require.NoError(t, s.Put(ctx, "index_1", "fake", expected))
+	require.NoError(t, s.MarkComplete(ctx, "index_1"))
+
+	v, err = s.Get(ctx, "index_1", "fake")
+	require.NoError(t, err)
+	require.Equal(t, expected, v)
+
+	complete, err = s.IsComplete(ctx, "index_1")
+	require.NoError(t, err)
+	require.True(t, complete)
+
+	tenants, err := s.Tenants(ctx, "index_1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"fake"}, tenants)
+}
@@ -169,7 +169,7 @@ func (m *mockChunkStore) PutOne(ctx context.Context, from, through model.Time, c
 	return nil
 }
 
-func (m *mockChunkStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string) ([]string, error) {
+func (m *mockChunkStore) LabelValuesForMetricName(ctx context.Context, userID string, from, through model.Time, metricName string, labelName string, matchers ...*labels.Matcher) ([]string, error) {
 	return nil, nil
 }
 
@@ -193,6 +193,10 @@ func (m *mockChunkStore) GetChunkFetcher(_ model.Time) *chunk.Fetcher {
 	return nil
 }
 
+func (m *mockChunkStore) GetSeriesChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([]chunk.SeriesChunkRefs, error) {
+	return nil, nil
+}
+
 func (m *mockChunkStore) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, matchers ...*labels.Matcher) ([][]chunk.Chunk, []*chunk.Fetcher, error) {
 	refs := make([]chunk.Chunk, 0, len(m.chunks))
 	// transform real chunks into ref chunks.
@@ -209,7 +213,7 @@ func (m *mockChunkStore) GetChunkRefs(ctx context.Context, userID string, from,
 		panic(err)
 	}
 
-	f, err := chunk.NewChunkFetcher(cache, false, m.client)
+	f, err := chunk.NewChunkFetcher(cache, false, 0, m.client)
 	if err != nil {
 		panic(err)
 	}
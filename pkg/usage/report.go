@@ -0,0 +1,203 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/pkg/tenant"
+	serverutil "github.com/grafana/loki/pkg/util/server"
+)
+
+// Names of the sources of a usage record.
+const (
+	SourceIngestion = "ingestion"
+	SourceStorage   = "storage"
+	SourceQueries   = "queries"
+)
+
+// Record is the usage of a tenant during a month.
+type Record struct {
+	Tenant            string `json:"tenant"`
+	Month             string `json:"month"`
+	IngestedBytes     int64  `json:"ingested_bytes"`
+	IngestedLines     int64  `json:"ingested_lines"`
+	StoredBytes       uint64 `json:"stored_bytes"`
+	ActiveStreams     int    `json:"active_streams"`
+	QueryBytesScanned int64  `json:"query_bytes_scanned"`
+	QueryLinesScanned int64  `json:"query_lines_scanned"`
+	// Incomplete lists the sources which are not configured or did not account for the whole month yet.
+	Incomplete []string `json:"incomplete,omitempty"`
+}
+
+// Storage returns the usage of the data stored by the tenants.
+type Storage interface {
+	// StoredUsage returns the bytes and the number of streams stored for the tenant over [from, through), and
+	// whether all the data of the time range is accounted for.
+	StoredUsage(ctx context.Context, userID string, from, through time.Time) (bytes uint64, streams int, complete bool, err error)
+	// StoredTenants returns the tenants with data stored over [from, through).
+	StoredTenants(ctx context.Context, from, through time.Time) ([]string, error)
+}
+
+// Reporter builds the usage records of tenants from the KV stores of the distributor and querier trackers, and from
+// the storage. Any of them can be nil when not configured, in which case it is reported as incomplete.
+type Reporter struct {
+	ingestion *Reader
+	queries   *Reader
+	storage   Storage
+	now       func() time.Time
+}
+
+func NewReporter(ingestion, queries *Reader, storage Storage) *Reporter {
+	return &Reporter{
+		ingestion: ingestion,
+		queries:   queries,
+		storage:   storage,
+		now:       time.Now,
+	}
+}
+
+// Report returns the usage of the tenant during the month, formatted as YYYY-MM.
+func (r *Reporter) Report(ctx context.Context, tenant, month string) (*Record, error) {
+	from, through, err := r.monthRange(month)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{Tenant: tenant, Month: month}
+	if r.ingestion != nil {
+		u, err := r.ingestion.Get(ctx, month, tenant)
+		if err != nil {
+			return nil, err
+		}
+		record.IngestedBytes, record.IngestedLines = u.Bytes, u.Lines
+	} else {
+		record.Incomplete = append(record.Incomplete, SourceIngestion)
+	}
+
+	if r.storage != nil {
+		bytes, streams, complete, err := r.storage.StoredUsage(ctx, tenant, from, through)
+		if err != nil {
+			return nil, err
+		}
+		record.StoredBytes, record.ActiveStreams = bytes, streams
+		if !complete {
+			record.Incomplete = append(record.Incomplete, SourceStorage)
+		}
+	} else {
+		record.Incomplete = append(record.Incomplete, SourceStorage)
+	}
+
+	if r.queries != nil {
+		u, err := r.queries.Get(ctx, month, tenant)
+		if err != nil {
+			return nil, err
+		}
+		record.QueryBytesScanned, record.QueryLinesScanned = u.Bytes, u.Lines
+	} else {
+		record.Incomplete = append(record.Incomplete, SourceQueries)
+	}
+
+	return record, nil
+}
+
+// ReportAll returns the usage during the month of all the tenants known to any of the sources, sorted by tenant.
+func (r *Reporter) ReportAll(ctx context.Context, month string) ([]*Record, error) {
+	from, through, err := r.monthRange(month)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := map[string]struct{}{}
+	for _, reader := range []*Reader{r.ingestion, r.queries} {
+		if reader == nil {
+			continue
+		}
+		ids, err := reader.Tenants(ctx, month)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			tenants[id] = struct{}{}
+		}
+	}
+	if r.storage != nil {
+		ids, err := r.storage.StoredTenants(ctx, from, through)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			tenants[id] = struct{}{}
+		}
+	}
+
+	ids := make([]string, 0, len(tenants))
+	for id := range tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	records := make([]*Record, 0, len(ids))
+	for _, id := range ids {
+		record, err := r.Report(ctx, id, month)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// monthRange returns the time range of the month, truncated to now for the current month.
+func (r *Reporter) monthRange(month string) (time.Time, time.Time, error) {
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid month %q, expected format YYYY-MM", month)
+	}
+	through := from.AddDate(0, 1, 0)
+	if now := r.now(); now.Before(through) {
+		through = now
+	}
+	if !from.Before(through) {
+		return time.Time{}, time.Time{}, fmt.Errorf("month %s has not started yet", month)
+	}
+	return from, through, nil
+}
+
+// Handler responds with the usage record of the tenant for the month given by the month query parameter, formatted
+// as YYYY-MM and defaulting to the current month.
+func (r *Reporter) Handler(w http.ResponseWriter, req *http.Request) {
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	month := req.URL.Query().Get("month")
+	if month == "" {
+		month = Month(r.now())
+	}
+	if _, _, err := r.monthRange(month); err != nil {
+		serverutil.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	record, err := r.Report(req.Context(), userID, month)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting usage", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(record); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		serverutil.JSONError(w, http.StatusInternalServerError, "error marshalling response: %v", err)
+	}
+}
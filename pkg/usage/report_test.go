@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+type fakeStorage struct {
+	bytes    map[string]uint64
+	complete bool
+}
+
+func (s fakeStorage) StoredUsage(_ context.Context, userID string, _, _ time.Time) (uint64, int, bool, error) {
+	return s.bytes[userID], len(s.bytes), s.complete, nil
+}
+
+func (s fakeStorage) StoredTenants(_ context.Context, _, _ time.Time) ([]string, error) {
+	var tenants []string
+	for tenant := range s.bytes {
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+func TestReporter(t *testing.T) {
+	ctx := context.Background()
+	ingestionKV, closer := consul.NewInMemoryClient(Codec{}, log.NewNopLogger(), nil)
+	defer closer.Close()
+	queriesKV, closer := consul.NewInMemoryClient(Codec{}, log.NewNopLogger(), nil)
+	defer closer.Close()
+
+	require.NoError(t, ingestionKV.CAS(ctx, "2021-12/foo", func(interface{}) (interface{}, bool, error) {
+		return &Usage{Bytes: 100, Lines: 10}, false, nil
+	}))
+	require.NoError(t, queriesKV.CAS(ctx, "2021-12/bar", func(interface{}) (interface{}, bool, error) {
+		return &Usage{Bytes: 1000, Lines: 100}, false, nil
+	}))
+
+	r := NewReporter(&Reader{kv: ingestionKV}, &Reader{kv: queriesKV}, fakeStorage{bytes: map[string]uint64{"baz": 50}})
+	r.now = func() time.Time { return time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC) }
+
+	records, err := r.ReportAll(ctx, "2021-12")
+	require.NoError(t, err)
+	require.Equal(t, []*Record{
+		{Tenant: "bar", Month: "2021-12", ActiveStreams: 1, QueryBytesScanned: 1000, QueryLinesScanned: 100, Incomplete: []string{SourceStorage}},
+		{Tenant: "baz", Month: "2021-12", StoredBytes: 50, ActiveStreams: 1, Incomplete: []string{SourceStorage}},
+		{Tenant: "foo", Month: "2021-12", IngestedBytes: 100, IngestedLines: 10, ActiveStreams: 1, Incomplete: []string{SourceStorage}},
+	}, records)
+
+	_, err = r.Report(ctx, "foo", "2022-01")
+	require.Error(t, err)
+	_, err = r.Report(ctx, "foo", "foo")
+	require.Error(t, err)
+
+	// Sources which are not configured are reported as incomplete.
+	r = NewReporter(&Reader{kv: ingestionKV}, nil, nil)
+	r.now = func() time.Time { return time.Date(2021, 12, 15, 0, 0, 0, 0, time.UTC) }
+	record, err := r.Report(ctx, "foo", "2021-12")
+	require.NoError(t, err)
+	require.Equal(t, &Record{Tenant: "foo", Month: "2021-12", IngestedBytes: 100, IngestedLines: 10, Incomplete: []string{SourceStorage, SourceQueries}}, record)
+
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/usage", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "foo"))
+	w := httptest.NewRecorder()
+	r.Handler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp Record
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, *record, resp)
+
+	for _, url := range []string{
+		"/loki/api/v1/usage?month=foo",
+		"/loki/api/v1/usage?month=2022-01",
+	} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "foo"))
+		w := httptest.NewRecorder()
+		r.Handler(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
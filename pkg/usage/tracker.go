@@ -0,0 +1,282 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// TrackerConfig configures the accounting of a usage of each tenant in a KV store.
+type TrackerConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	FlushPeriod time.Duration `yaml:"flush_period"`
+	KVStore     kv.Config     `yaml:"kvstore"`
+}
+
+// RegisterFlagsWithPrefix registers the tracker flags, the keys of the KV store are prefixed with kvPrefix.
+func (cfg *TrackerConfig) RegisterFlagsWithPrefix(prefix, kvPrefix, description string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, description)
+	f.DurationVar(&cfg.FlushPeriod, prefix+"flush-period", 15*time.Second, "How often the usage accounted by a replica is added to the KV store, and the usage of the other replicas is read back.")
+	cfg.KVStore.RegisterFlagsWithPrefix(prefix, kvPrefix, f)
+}
+
+// Validate validates the tracker config.
+func (cfg *TrackerConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FlushPeriod <= 0 {
+		return fmt.Errorf("usage tracker flush period must be > 0")
+	}
+	// Memberlist merges concurrent updates instead of compare-and-swapping them, which would lose increments.
+	if cfg.KVStore.Store == "memberlist" {
+		return fmt.Errorf("usage tracker does not support the memberlist KV store")
+	}
+	return nil
+}
+
+// Usage is the number of bytes and lines accounted for a tenant during a month.
+type Usage struct {
+	Bytes int64 `json:"bytes"`
+	Lines int64 `json:"lines"`
+}
+
+func (u Usage) Add(o Usage) Usage {
+	return Usage{Bytes: u.Bytes + o.Bytes, Lines: u.Lines + o.Lines}
+}
+
+// Codec stores usages as JSON so that they can be inspected and corrected by operators.
+type Codec struct{}
+
+func (Codec) CodecID() string {
+	return "usage"
+}
+
+func (Codec) Decode(b []byte) (interface{}, error) {
+	var u Usage
+	if err := json.Unmarshal(b, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (Codec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+type usageKey struct {
+	month  string
+	tenant string
+}
+
+func (k usageKey) String() string {
+	return k.month + "/" + k.tenant
+}
+
+// Month returns the month of t, as used in the keys of the KV store.
+func Month(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// Reader reads the usages stored in a KV store by the trackers.
+type Reader struct {
+	kv kv.Client
+}
+
+// NewReader creates a reader of the KV store of the trackers named name.
+func NewReader(name string, kvCfg kv.Config, logger log.Logger, registerer prometheus.Registerer) (*Reader, error) {
+	client, err := kv.NewClient(kvCfg, Codec{}, kv.RegistererWithKVName(registerer, name+"-usage-reader"), logger)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{kv: client}, nil
+}
+
+// Get returns the usage of the tenant during the month.
+func (r *Reader) Get(ctx context.Context, month, tenant string) (Usage, error) {
+	v, err := r.kv.Get(ctx, usageKey{month: month, tenant: tenant}.String())
+	if err != nil || v == nil {
+		return Usage{}, err
+	}
+	return *v.(*Usage), nil
+}
+
+// Tenants returns the tenants with a usage during the month.
+func (r *Reader) Tenants(ctx context.Context, month string) ([]string, error) {
+	keys, err := r.kv.List(ctx, month+"/")
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]string, 0, len(keys))
+	for _, key := range keys {
+		tenants = append(tenants, strings.TrimPrefix(key, month+"/"))
+	}
+	return tenants, nil
+}
+
+// Tracker accounts the usage of tenants in memory and periodically adds it to a key per tenant and month
+// of the KV store, which is shared by all the replicas of a component.
+type Tracker struct {
+	services.Service
+
+	kv     kv.Client
+	logger log.Logger
+	now    func() time.Time
+
+	mtx sync.Mutex
+	// stored is the last usage read from the KV store during the current month.
+	month  string
+	stored map[string]Usage
+	// pending is the usage not added to the KV store yet.
+	pending map[usageKey]Usage
+
+	usageBytes *prometheus.GaugeVec
+	usageLines *prometheus.GaugeVec
+	flushes    *prometheus.CounterVec
+}
+
+// NewTracker creates a tracker for the component named name, which is used in the names of its metrics.
+func NewTracker(name string, cfg TrackerConfig, logger log.Logger, registerer prometheus.Registerer) (*Tracker, error) {
+	client, err := kv.NewClient(cfg.KVStore, Codec{}, kv.RegistererWithKVName(registerer, name+"-usage"), logger)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tracker{
+		kv:      client,
+		logger:  logger,
+		now:     time.Now,
+		stored:  map[string]Usage{},
+		pending: map[usageKey]Usage{},
+		usageBytes: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki",
+			Name:      name + "_monthly_usage_bytes",
+			Help:      fmt.Sprintf("The number of bytes accounted for the tenant by the %ss during the current month, as last read from the KV store.", name),
+		}, []string{"tenant"}),
+		usageLines: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki",
+			Name:      name + "_monthly_usage_lines",
+			Help:      fmt.Sprintf("The number of lines accounted for the tenant by the %ss during the current month, as last read from the KV store.", name),
+		}, []string{"tenant"}),
+		flushes: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      name + "_usage_flushes_total",
+			Help:      "The total number of tenant usages added to the KV store, by status.",
+		}, []string{"status"}),
+	}
+	t.Service = services.NewTimerService(cfg.FlushPeriod, nil, t.flush, func(_ error) error {
+		// Best effort, the usage accounted since the last flush is lost if the KV store is unavailable.
+		return t.flush(context.Background())
+	})
+	return t, nil
+}
+
+// Add accounts bytes and lines for tenant.
+func (t *Tracker) Add(tenant string, bytes, lines int) {
+	k := usageKey{month: Month(t.now()), tenant: tenant}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.pending[k] = t.pending[k].Add(Usage{Bytes: int64(bytes), Lines: int64(lines)})
+}
+
+// Usage returns the usage of tenant during the current month, including the usage not flushed yet.
+func (t *Tracker) Usage(tenant string) Usage {
+	month := Month(t.now())
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if month != t.month {
+		return t.pending[usageKey{month: month, tenant: tenant}]
+	}
+	return t.stored[tenant].Add(t.pending[usageKey{month: month, tenant: tenant}])
+}
+
+// flush adds the pending usages to the KV store and reads back the usage of the tenants of the current month.
+func (t *Tracker) flush(ctx context.Context) error {
+	month := Month(t.now())
+
+	t.mtx.Lock()
+	pending := t.pending
+	t.pending = map[usageKey]Usage{}
+	tenants := make(map[string]struct{}, len(t.stored))
+	if month == t.month {
+		for tenant := range t.stored {
+			tenants[tenant] = struct{}{}
+		}
+	}
+	t.mtx.Unlock()
+
+	stored := map[string]Usage{}
+	for k, u := range pending {
+		total, err := t.addUsage(ctx, k, u)
+		if err != nil {
+			t.flushes.WithLabelValues("failure").Inc()
+			level.Warn(t.logger).Log("msg", "failed to add tenant usage to the KV store", "tenant", k.tenant, "month", k.month, "err", err)
+			// Keep it pending to retry at the next flush.
+			t.mtx.Lock()
+			t.pending[k] = t.pending[k].Add(u)
+			t.mtx.Unlock()
+			continue
+		}
+		t.flushes.WithLabelValues("success").Inc()
+		if k.month == month {
+			stored[k.tenant] = total
+		}
+	}
+
+	// Read back the usage added by the other replicas.
+	for tenant := range tenants {
+		if _, ok := stored[tenant]; ok {
+			continue
+		}
+		v, err := t.kv.Get(ctx, usageKey{month: month, tenant: tenant}.String())
+		if err != nil {
+			level.Warn(t.logger).Log("msg", "failed to read tenant usage from the KV store", "tenant", tenant, "month", month, "err", err)
+			continue
+		}
+		if v != nil {
+			stored[tenant] = *v.(*Usage)
+		}
+	}
+
+	t.mtx.Lock()
+	if month != t.month {
+		t.month = month
+		t.stored = map[string]Usage{}
+		t.usageBytes.Reset()
+		t.usageLines.Reset()
+	}
+	for tenant, u := range stored {
+		t.stored[tenant] = u
+		t.usageBytes.WithLabelValues(tenant).Set(float64(u.Bytes))
+		t.usageLines.WithLabelValues(tenant).Set(float64(u.Lines))
+	}
+	t.mtx.Unlock()
+
+	// Never fail the service, the KV store might be temporarily unavailable.
+	return nil
+}
+
+// addUsage adds u to the usage stored under k and returns the new total.
+func (t *Tracker) addUsage(ctx context.Context, k usageKey, u Usage) (Usage, error) {
+	var total Usage
+	err := t.kv.CAS(ctx, k.String(), func(in interface{}) (out interface{}, retry bool, err error) {
+		total = u
+		if in != nil {
+			total = in.(*Usage).Add(u)
+		}
+		return &total, true, nil
+	})
+	return total, err
+}
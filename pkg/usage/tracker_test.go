@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracker(t *testing.T, kvStore *consul.Client, now *time.Time) *Tracker {
+	cfg := TrackerConfig{Enabled: true, FlushPeriod: time.Minute}
+	cfg.KVStore.Mock = kvStore
+
+	tracker, err := NewTracker("test", cfg, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func TestTracker(t *testing.T) {
+	kvStore, closer := consul.NewInMemoryClient(Codec{}, log.NewNopLogger(), nil)
+	defer closer.Close()
+
+	now := time.Date(2021, 12, 31, 23, 0, 0, 0, time.UTC)
+	t1 := newTestTracker(t, kvStore, &now)
+	t2 := newTestTracker(t, kvStore, &now)
+
+	t1.Add("foo", 100, 10)
+	t2.Add("foo", 50, 5)
+	t2.Add("bar", 1, 1)
+	require.Equal(t, Usage{Bytes: 100, Lines: 10}, t1.Usage("foo"))
+
+	require.NoError(t, t1.flush(context.Background()))
+	require.NoError(t, t2.flush(context.Background()))
+	require.Equal(t, Usage{Bytes: 150, Lines: 15}, t2.Usage("foo"))
+	require.Equal(t, Usage{Bytes: 100, Lines: 10}, t1.Usage("foo"))
+
+	// Usage added by the other replicas is read back.
+	require.NoError(t, t1.flush(context.Background()))
+	require.Equal(t, Usage{Bytes: 150, Lines: 15}, t1.Usage("foo"))
+	require.Equal(t, Usage{}, t1.Usage("bar"))
+
+	v, err := kvStore.Get(context.Background(), "2021-12/bar")
+	require.NoError(t, err)
+	require.Equal(t, &Usage{Bytes: 1, Lines: 1}, v)
+
+	// Usage is reset at the start of a month.
+	now = now.Add(2 * time.Hour)
+	t1.Add("foo", 1, 1)
+	require.Equal(t, Usage{Bytes: 1, Lines: 1}, t1.Usage("foo"))
+	require.NoError(t, t1.flush(context.Background()))
+	require.Equal(t, Usage{Bytes: 1, Lines: 1}, t1.Usage("foo"))
+
+	v, err = kvStore.Get(context.Background(), "2021-12/foo")
+	require.NoError(t, err)
+	require.Equal(t, &Usage{Bytes: 150, Lines: 15}, v)
+
+	// The reader sees the usages of all the replicas.
+	reader := &Reader{kv: kvStore}
+	tenants, err := reader.Tenants(context.Background(), "2021-12")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"foo", "bar"}, tenants)
+	u, err := reader.Get(context.Background(), "2021-12", "foo")
+	require.NoError(t, err)
+	require.Equal(t, Usage{Bytes: 150, Lines: 15}, u)
+	u, err = reader.Get(context.Background(), "2021-11", "foo")
+	require.NoError(t, err)
+	require.Equal(t, Usage{}, u)
+}
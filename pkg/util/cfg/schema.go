@@ -0,0 +1,249 @@
+package cfg
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+// SchemaField describes a configuration field, as found in the YAML configuration file.
+type SchemaField struct {
+	Name        string         `json:"name"`
+	Type        string         `json:"type"`
+	Flag        string         `json:"flag,omitempty"`
+	Default     string         `json:"default,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Fields      []*SchemaField `json:"fields,omitempty"`
+}
+
+var (
+	flagValueType     = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	yamlMarshalerType = reflect.TypeOf((*yaml.Marshaler)(nil)).Elem()
+
+	// leafTypeNames names the types which are configured from a single value.
+	leafTypeNames = map[reflect.Type]string{
+		reflect.TypeOf(time.Duration(0)):         "duration",
+		reflect.TypeOf(model.Duration(0)):        "duration",
+		reflect.TypeOf(flagext.Secret{}):         "string",
+		reflect.TypeOf(flagext.URLValue{}):       "url",
+		reflect.TypeOf(flagext.StringSlice{}):    "list of strings",
+		reflect.TypeOf(flagext.StringSliceCSV{}): "list of strings",
+		reflect.TypeOf(flagext.Time{}):           "time",
+		reflect.TypeOf(flagext.DayValue{}):       "time",
+	}
+)
+
+// PrintSchema writes the schema of the configuration struct pointed by cfg as JSON to the provided writer.
+func PrintSchema(w io.Writer, cfg interface{}, fs *flag.FlagSet) error {
+	fields, err := Schema(cfg, fs)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fields)
+}
+
+// Schema returns the schema of the configuration struct pointed by cfg. Descriptions and default
+// values are taken from the flags registered on fs for cfg, which must be the registered instance.
+func Schema(cfg interface{}, fs *flag.FlagSet) ([]*SchemaField, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr {
+		return nil, ErrNotPointer
+	}
+	if v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a pointer to a struct, got %s", v.Type())
+	}
+
+	b := &schemaBuilder{
+		flags:    map[uintptr]*flag.Flag{},
+		visiting: map[reflect.Type]bool{},
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		fv := reflect.ValueOf(f.Value)
+		if fv.Kind() != reflect.Ptr {
+			return
+		}
+		b.flags[fv.Pointer()] = f
+	})
+
+	return b.structFields(v.Elem()), nil
+}
+
+type schemaBuilder struct {
+	// flags by address of the value they set.
+	flags map[uintptr]*flag.Flag
+	// types being described, to stop on recursive types.
+	visiting map[reflect.Type]bool
+}
+
+func (b *schemaBuilder) structFields(v reflect.Value) []*SchemaField {
+	var fields []*SchemaField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name, inline, skip := yamlName(field)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if inline {
+			if fv.Kind() == reflect.Struct {
+				fields = append(fields, b.structFields(fv)...)
+			}
+			continue
+		}
+		fields = append(fields, b.schemaField(name, fv))
+	}
+	return fields
+}
+
+func (b *schemaBuilder) schemaField(name string, v reflect.Value) *SchemaField {
+	f := &SchemaField{
+		Name: name,
+		Type: typeName(v.Type()),
+	}
+	if v.CanAddr() && isLeaf(v.Type()) {
+		if fl, ok := b.flags[v.Addr().Pointer()]; ok {
+			f.Flag = fl.Name
+			f.Default = fl.DefValue
+			f.Description = fl.Usage
+		}
+	}
+
+	if isLeaf(v.Type()) {
+		return f
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		f.Fields = b.structFields(v)
+	case reflect.Ptr:
+		if v.IsNil() {
+			f.Fields = b.typeFields(v.Type().Elem())
+			break
+		}
+		f.Fields = b.structFields(v.Elem())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		// elements are not registered instances, describe their type only.
+		f.Fields = b.typeFields(v.Type().Elem())
+	}
+	return f
+}
+
+func (b *schemaBuilder) typeFields(t reflect.Type) []*SchemaField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isLeaf(t) || b.visiting[t] {
+		return nil
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+	return b.structFields(reflect.New(t).Elem())
+}
+
+// yamlName returns the YAML name of a field, whether it is inlined and whether it should be skipped.
+func yamlName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, p := range parts[1:] {
+		if p == "inline" {
+			return "", true, false
+		}
+	}
+	if parts[0] != "" {
+		return parts[0], false, false
+	}
+	return strings.ToLower(field.Name), false, false
+}
+
+// isLeaf tells whether values of the given type are configured from a single value.
+func isLeaf(t reflect.Type) bool {
+	if _, ok := leafTypeNames[t]; ok {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return reflect.PtrTo(t).Implements(flagValueType) || isCustomYAMLValue(t)
+	case reflect.Ptr:
+		return isLeaf(t.Elem())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return typeFieldsLeaf(t.Elem())
+	}
+	return true
+}
+
+// isCustomYAMLValue tells whether the struct is marshalled to YAML as a single value,
+// that is it implements yaml.Marshaler and has no YAML fields.
+func isCustomYAMLValue(t reflect.Type) bool {
+	if !reflect.PtrTo(t).Implements(yamlMarshalerType) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func typeFieldsLeaf(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.Struct || isLeaf(t)
+}
+
+func typeName(t reflect.Type) string {
+	if name, ok := leafTypeNames[t]; ok {
+		return name
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list of " + pluralTypeName(t.Elem())
+	case reflect.Map:
+		return fmt.Sprintf("map of %s to %s", typeName(t.Key()), typeName(t.Elem()))
+	case reflect.Ptr:
+		return typeName(t.Elem())
+	case reflect.Struct:
+		if isLeaf(t) {
+			return "string"
+		}
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+func pluralTypeName(t reflect.Type) string {
+	name := typeName(t)
+	switch name {
+	case "boolean", "int", "float", "string", "duration", "url", "time", "object":
+		return name + "s"
+	}
+	return name
+}
@@ -0,0 +1,66 @@
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaData struct {
+	Data     `yaml:",inline"`
+	Password flagext.Secret    `yaml:"password"`
+	Backends []schemaBackend   `yaml:"backends"`
+	Headers  map[string]string `yaml:"headers"`
+	Ignored  string            `yaml:"-"`
+	Parent   *schemaBackend    `yaml:"parent"`
+}
+
+type schemaBackend struct {
+	Address  string           `yaml:"address"`
+	Fallback []*schemaBackend `yaml:"fallback"`
+}
+
+func TestSchema(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.PanicOnError)
+	var data schemaData
+	data.RegisterFlags(fs)
+	fs.Var(&data.Password, "password", "The password.")
+
+	fields, err := Schema(&data, fs)
+	require.NoError(t, err)
+	require.Equal(t, []*SchemaField{
+		{Name: "verbose", Type: "boolean", Flag: "verbose", Default: "false"},
+		{Name: "server", Type: "object", Fields: []*SchemaField{
+			{Name: "port", Type: "int", Flag: "server.port", Default: "80"},
+			{Name: "timeout", Type: "duration", Flag: "server.timeout", Default: "1m0s"},
+		}},
+		{Name: "tls", Type: "object", Fields: []*SchemaField{
+			{Name: "cert", Type: "string", Flag: "tls.cert", Default: "DEFAULTCERT"},
+			{Name: "key", Type: "string", Flag: "tls.key", Default: "DEFAULTKEY"},
+		}},
+		{Name: "password", Type: "string", Flag: "password", Description: "The password."},
+		{Name: "backends", Type: "list of objects", Fields: []*SchemaField{
+			{Name: "address", Type: "string"},
+			// recursive types are only described once.
+			{Name: "fallback", Type: "list of objects"},
+		}},
+		{Name: "headers", Type: "map of string to string"},
+		{Name: "parent", Type: "object", Fields: []*SchemaField{
+			{Name: "address", Type: "string"},
+			{Name: "fallback", Type: "list of objects"},
+		}},
+	}, fields)
+
+	_, err = Schema(data, fs)
+	require.Equal(t, ErrNotPointer, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintSchema(&buf, &data, fs))
+	var printed []*SchemaField
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &printed))
+	require.Equal(t, fields, printed)
+}
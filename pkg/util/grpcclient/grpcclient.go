@@ -0,0 +1,87 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	dskit_grpcclient "github.com/grafana/dskit/grpcclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// Config configures a gRPC client connection between Loki components. It adds the keepalive and the retries of the
+// RPCs failing with Unavailable to the dskit client config, e.g. for the connections going through a load balancer
+// or to the instances of a rolling update.
+type Config struct {
+	dskit_grpcclient.Config `yaml:",inline"`
+
+	KeepaliveTime                time.Duration `yaml:"keepalive_time"`
+	KeepaliveTimeout             time.Duration `yaml:"keepalive_timeout"`
+	KeepalivePermitWithoutStream bool          `yaml:"keepalive_permit_without_stream"`
+
+	MaxUnavailableRetries int `yaml:"max_unavailable_retries"`
+}
+
+// RegisterFlagsWithPrefix registers the flags of the client prefixed with prefix.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	cfg.Config.RegisterFlagsWithPrefix(prefix, f)
+
+	f.DurationVar(&cfg.KeepaliveTime, prefix+".keepalive-time", 20*time.Second, "Duration after which a keepalive ping is sent on the connection when there is no activity, at least 10s. Must not be shorter than the min time between pings enforced by the server. 0 to disable keepalive.")
+	f.DurationVar(&cfg.KeepaliveTimeout, prefix+".keepalive-timeout", 10*time.Second, "Duration the client waits for the response of a keepalive ping before closing the connection.")
+	f.BoolVar(&cfg.KeepalivePermitWithoutStream, prefix+".keepalive-permit-without-stream", true, "Send keepalive pings even when there are no active RPCs. The server must allow pings without stream.")
+	f.IntVar(&cfg.MaxUnavailableRetries, prefix+".max-unavailable-retries", 0, "Number of times an RPC failing with Unavailable, e.g. because the server is shutting down or a load balancer closed the connection, is retried with the backoff of backoff_config. 0 to disable.")
+}
+
+// Validate validates the client config.
+func (cfg *Config) Validate(log log.Logger) error {
+	if err := cfg.Config.Validate(log); err != nil {
+		return err
+	}
+	if cfg.KeepaliveTime < 0 || cfg.KeepaliveTimeout < 0 {
+		return errors.New("gRPC client keepalive time and timeout must not be negative")
+	}
+	if cfg.MaxUnavailableRetries < 0 {
+		return errors.New("gRPC client max unavailable retries must not be negative")
+	}
+	return nil
+}
+
+// DialOption returns the config as grpc.DialOptions.
+func (cfg *Config) DialOption(unaryClientInterceptors []grpc.UnaryClientInterceptor, streamClientInterceptors []grpc.StreamClientInterceptor) ([]grpc.DialOption, error) {
+	if cfg.MaxUnavailableRetries > 0 {
+		backoffCfg := cfg.BackoffConfig
+		backoffCfg.MaxRetries = cfg.MaxUnavailableRetries
+		unaryClientInterceptors = append([]grpc.UnaryClientInterceptor{NewUnavailableRetry(backoffCfg)}, unaryClientInterceptors...)
+	}
+
+	opts, err := cfg.Config.DialOption(unaryClientInterceptors, streamClientInterceptors)
+	if err != nil {
+		return nil, err
+	}
+	// Overrides the keepalive parameters of the dskit client, the last option wins.
+	return append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+		Time:                cfg.KeepaliveTime,
+		Timeout:             cfg.KeepaliveTimeout,
+		PermitWithoutStream: cfg.KeepalivePermitWithoutStream,
+	})), nil
+}
+
+// NewUnavailableRetry returns a gRPC middleware retrying the RPCs failing with Unavailable up to cfg.MaxRetries times.
+func NewUnavailableRetry(cfg backoff.Config) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := backoff.New(ctx, cfg)
+		for {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if status.Code(err) != codes.Unavailable || !backoff.Ongoing() {
+				return err
+			}
+			backoff.Wait()
+		}
+	}
+}
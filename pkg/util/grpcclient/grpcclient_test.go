@@ -0,0 +1,64 @@
+package grpcclient
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnavailableRetry(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		failures int
+		code     codes.Code
+		calls    int
+		err      bool
+	}{
+		{name: "success", failures: 0, code: codes.Unavailable, calls: 1},
+		{name: "retried until success", failures: 2, code: codes.Unavailable, calls: 3},
+		{name: "retries exhausted", failures: 5, code: codes.Unavailable, calls: 4, err: true},
+		{name: "other errors are not retried", failures: 5, code: codes.Internal, calls: 1, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			invoker := func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				calls++
+				if calls <= tc.failures {
+					return status.Error(tc.code, "failed")
+				}
+				return nil
+			}
+			retry := NewUnavailableRetry(backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 3})
+			err := retry(context.Background(), "/test", nil, nil, nil, invoker)
+			if tc.err {
+				require.Equal(t, tc.code, status.Code(err))
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.calls, calls)
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var cfg Config
+	cfg.RegisterFlagsWithPrefix("test", flag.NewFlagSet("test", flag.PanicOnError))
+	require.NoError(t, cfg.Validate(log.NewNopLogger()))
+	require.Equal(t, 20*time.Second, cfg.KeepaliveTime)
+
+	cfg.MaxUnavailableRetries = 2
+	opts, err := cfg.DialOption(nil, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, opts)
+
+	cfg.MaxUnavailableRetries = -1
+	require.EqualError(t, cfg.Validate(log.NewNopLogger()), "gRPC client max unavailable retries must not be negative")
+}
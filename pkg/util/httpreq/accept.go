@@ -0,0 +1,48 @@
+package httpreq
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/weaveworks/common/middleware"
+)
+
+// NDJSONContentType is the content type of query responses streamed as newline delimited JSON.
+const NDJSONContentType = "application/x-ndjson"
+
+var acceptNDJSONKey ctxKey = "accept-ndjson"
+
+// AcceptsNDJSON returns true if the Accept header of the request asks for newline delimited JSON.
+func AcceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && mediaType == NDJSONContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AcceptsNDJSONFromContext returns true if the request whose context is given asked for newline delimited JSON,
+// see ExtractAcceptNDJSONMiddleware.
+func AcceptsNDJSONFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(acceptNDJSONKey).(bool)
+	return v
+}
+
+// ExtractAcceptNDJSONMiddleware stores in the request context whether the request asks for newline delimited JSON,
+// so that the response can be encoded accordingly once the request got split and its results merged.
+func ExtractAcceptNDJSONMiddleware() middleware.Interface {
+	return middleware.Func(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if AcceptsNDJSON(req) {
+				req = req.WithContext(context.WithValue(req.Context(), acceptNDJSONKey, true))
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+}
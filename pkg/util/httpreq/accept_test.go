@@ -0,0 +1,58 @@
+package httpreq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsNDJSON(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		accept []string
+		exp    bool
+	}{
+		{
+			desc: "no-header",
+			exp:  false,
+		},
+		{
+			desc:   "json",
+			accept: []string{"application/json"},
+			exp:    false,
+		},
+		{
+			desc:   "ndjson",
+			accept: []string{"application/x-ndjson"},
+			exp:    true,
+		},
+		{
+			desc:   "ndjson-among-others",
+			accept: []string{"application/json;q=0.9, application/x-ndjson;q=1"},
+			exp:    true,
+		},
+		{
+			desc:   "multiple-headers",
+			accept: []string{"text/plain", "application/x-ndjson"},
+			exp:    true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://testing.com", nil)
+			for _, accept := range tc.accept {
+				req.Header.Add("Accept", accept)
+			}
+			require.Equal(t, tc.exp, AcceptsNDJSON(req))
+
+			checked := false
+			mware := ExtractAcceptNDJSONMiddleware().Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				require.Equal(t, tc.exp, AcceptsNDJSONFromContext(req.Context()))
+				checked = true
+			}))
+			mware.ServeHTTP(httptest.NewRecorder(), req)
+			require.True(t, checked)
+		})
+	}
+}
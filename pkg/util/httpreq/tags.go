@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"regexp"
+	"strings"
 
 	"github.com/weaveworks/common/middleware"
 )
@@ -13,8 +14,10 @@ import (
 type ctxKey string
 
 var (
-	QueryTagsHTTPHeader ctxKey = "X-Query-Tags"
-	safeQueryTags              = regexp.MustCompile("[^a-zA-Z0-9-=, ]+") // only alpha-numeric, ' ', ',', '=' and `-`
+	QueryTagsHTTPHeader     ctxKey = "X-Query-Tags"
+	QueryPriorityHTTPHeader ctxKey = "X-Query-Priority"
+	safeQueryTags                  = regexp.MustCompile("[^a-zA-Z0-9-=, ]+") // only alpha-numeric, ' ', ',', '=' and `-`
+	safeQueryPriority              = regexp.MustCompile("[^a-zA-Z]+")        // only alphabetic
 
 )
 
@@ -33,3 +36,21 @@ func ExtractQueryTagsMiddleware() middleware.Interface {
 		})
 	})
 }
+
+// ExtractQueryPriorityMiddleware stores the priority requested in the X-Query-Priority header in the request context,
+// so that it gets propagated with the queries sent to the query scheduler.
+func ExtractQueryPriorityMiddleware() middleware.Interface {
+	return middleware.Func(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			priority := req.Header.Get(string(QueryPriorityHTTPHeader))
+			priority = strings.ToLower(safeQueryPriority.ReplaceAllString(priority, ""))
+
+			if priority != "" {
+				ctx = context.WithValue(ctx, QueryPriorityHTTPHeader, priority)
+				req = req.WithContext(ctx)
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+}
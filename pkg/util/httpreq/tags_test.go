@@ -49,3 +49,43 @@ func TestQueryTags(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryPriority(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		in   string
+		exp  interface{}
+	}{
+		{
+			desc: "empty",
+			in:   ``,
+			exp:  nil,
+		},
+		{
+			desc: "lowercase",
+			in:   `high`,
+			exp:  `high`,
+		},
+		{
+			desc: "remove-invalid-chars",
+			in:   ` Lo-w1 `,
+			exp:  `low`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://testing.com", nil)
+			req.Header.Set(string(QueryPriorityHTTPHeader), tc.in)
+
+			w := httptest.NewRecorder()
+			checked := false
+			mware := ExtractQueryPriorityMiddleware().Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				require.Equal(t, tc.exp, req.Context().Value(QueryPriorityHTTPHeader))
+				checked = true
+			}))
+
+			mware.ServeHTTP(w, req)
+
+			require.True(t, checked)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package httpreq
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	// TailTargetPath is the path of the querier endpoint the query frontend round trips through the
+	// queue to pick the querier a tail websocket is proxied to.
+	TailTargetPath = "/loki/api/v1/tail/target"
+	// TailTargetHostHeader is the header the querier sets on the tail target responses with its advertised address.
+	TailTargetHostHeader = "X-Loki-Tail-Target-Host"
+	// TailTargetPortHeader is the header the querier sets on the tail target responses with its HTTP port.
+	TailTargetPortHeader = "X-Loki-Tail-Target-Port"
+)
+
+// TailTargetHandler returns the querier handler answering tail target requests with the address and the HTTP port
+// the tail websockets must be proxied to.
+func TailTargetHandler(addr string, httpPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(TailTargetHostHeader, addr)
+		w.Header().Set(TailTargetPortHeader, strconv.Itoa(httpPort))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
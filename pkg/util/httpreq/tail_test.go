@@ -0,0 +1,17 @@
+package httpreq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailTargetHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	TailTargetHandler("10.0.0.1", 3100).ServeHTTP(rec, httptest.NewRequest("GET", TailTargetPath, nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, "10.0.0.1", rec.Header().Get(TailTargetHostHeader))
+	require.Equal(t, "3100", rec.Header().Get(TailTargetPortHeader))
+}
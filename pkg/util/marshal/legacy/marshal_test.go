@@ -49,6 +49,7 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
+						"totalIndexPagesRead": 0,
 						"chunk" :{
 							"compressedBytes": 0,
 							"decompressedBytes": 0,
@@ -68,6 +69,7 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
+						"totalIndexPagesRead": 0,
 						"chunk" :{
 							"compressedBytes": 0,
 							"decompressedBytes": 0,
@@ -83,7 +85,14 @@ var queryTests = []struct {
 					"execTime": 0,
 					"linesProcessedPerSecond": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed": 0,
+					"chunkCacheHitRatio": 0,
+					"indexCacheHitRatio": 0,
+					"duplicateRatio": 0
+				},
+				"cache": {
+					"chunk": {"entriesRequested": 0, "entriesFound": 0},
+					"index": {"entriesRequested": 0, "entriesFound": 0}
 				}
 			}
 		}`,
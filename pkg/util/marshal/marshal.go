@@ -3,12 +3,16 @@
 package marshal
 
 import (
+	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 
 	"github.com/gorilla/websocket"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
 
 	"github.com/grafana/loki/pkg/loghttp"
 	legacy "github.com/grafana/loki/pkg/loghttp/legacy"
@@ -35,6 +39,53 @@ func WriteQueryResponseJSON(v logqlmodel.Result, w io.Writer) error {
 	return jsoniter.NewEncoder(w).Encode(q)
 }
 
+// NDJSONBatchSize is the maximum number of entries of a line of a query response streamed as newline delimited JSON.
+const NDJSONBatchSize = 1000
+
+// ndjsonTrailer is the last line of a query response streamed as newline delimited JSON.
+type ndjsonTrailer struct {
+	Status     string       `json:"status"`
+	Statistics stats.Result `json:"stats"`
+}
+
+// WriteQueryResponseNDJSON writes the streams of the result to the provided io.Writer as newline
+// delimited JSON: one v1 loghttp JSON stream per line, the streams of more than NDJSONBatchSize entries
+// being split in several lines, followed by a last line holding the status and the statistics of the
+// query. Writers implementing http.Flusher are flushed after each line.
+func WriteQueryResponseNDJSON(v logqlmodel.Result, w io.Writer) error {
+	streams, ok := v.Data.(logqlmodel.Streams)
+	if !ok {
+		return fmt.Errorf("newline delimited JSON responses only support %s result type", logqlmodel.ValueTypeStreams)
+	}
+
+	enc := jsoniter.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for _, s := range streams {
+		labels, err := NewLabelSet(s.Labels)
+		if err != nil {
+			return errors.Wrapf(err, "err while creating labelset for %s", s.Labels)
+		}
+		for i := 0; i < len(s.Entries); i += NDJSONBatchSize {
+			batch := s.Entries[i:]
+			if len(batch) > NDJSONBatchSize {
+				batch = batch[:NDJSONBatchSize]
+			}
+			stream := loghttp.Stream{Labels: labels, Entries: make([]loghttp.Entry, len(batch))}
+			for j, e := range batch {
+				stream.Entries[j] = NewEntry(e)
+			}
+			if err := enc.Encode(stream); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+
+	return enc.Encode(ndjsonTrailer{Status: "success", Statistics: v.Statistics})
+}
+
 // WriteLabelResponseJSON marshals a logproto.LabelResponse to v1 loghttp JSON
 // and then writes it to the provided io.Writer.
 func WriteLabelResponseJSON(l logproto.LabelResponse, w io.Writer) error {
@@ -80,6 +131,33 @@ func WriteSeriesResponseJSON(r logproto.SeriesResponse, w io.Writer) error {
 	return jsoniter.NewEncoder(w).Encode(adapter)
 }
 
+// WriteExplainResponseJSON marshals a loghttp.ExplainResponseData to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteExplainResponseJSON(d loghttp.ExplainResponseData, w io.Writer) error {
+	return jsoniter.NewEncoder(w).Encode(loghttp.ExplainResponse{
+		Status: "success",
+		Data:   d,
+	})
+}
+
+// WriteIndexStatsResponseJSON marshals a loghttp.IndexStatsResponseData to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteIndexStatsResponseJSON(d loghttp.IndexStatsResponseData, w io.Writer) error {
+	return jsoniter.NewEncoder(w).Encode(loghttp.IndexStatsResponse{
+		Status: "success",
+		Data:   d,
+	})
+}
+
+// WriteMetadataResponseJSON marshals the label metadata to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteMetadataResponseJSON(d map[string][]loghttp.Metadata, w io.Writer) error {
+	return jsoniter.NewEncoder(w).Encode(loghttp.MetadataResponse{
+		Status: "success",
+		Data:   d,
+	})
+}
+
 // This struct exists primarily because we can't specify a repeated map in proto v3.
 // Otherwise, we'd use that + gogoproto.jsontag to avoid this layer of indirection
 type seriesResponseAdapter struct {
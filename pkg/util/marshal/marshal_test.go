@@ -16,6 +16,7 @@ import (
 	legacy "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logqlmodel"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
 )
 
 // covers responses from /loki/api/v1/query_range and /loki/api/v1/query
@@ -55,6 +56,7 @@ var queryTests = []struct {
 							"chunksDownloadTime": 0,
 							"totalChunksRef": 0,
 							"totalChunksDownloaded": 0,
+							"totalIndexPagesRead": 0,
 							"chunk" :{
 								"compressedBytes": 0,
 								"decompressedBytes": 0,
@@ -74,6 +76,7 @@ var queryTests = []struct {
 							"chunksDownloadTime": 0,
 							"totalChunksRef": 0,
 							"totalChunksDownloaded": 0,
+							"totalIndexPagesRead": 0,
 							"chunk" :{
 								"compressedBytes": 0,
 								"decompressedBytes": 0,
@@ -89,7 +92,14 @@ var queryTests = []struct {
 						"execTime": 0,
 						"linesProcessedPerSecond": 0,
 						"totalBytesProcessed":0,
-						"totalLinesProcessed":0
+						"totalLinesProcessed": 0,
+						"chunkCacheHitRatio": 0,
+						"indexCacheHitRatio": 0,
+						"duplicateRatio": 0
+					},
+					"cache": {
+						"chunk": {"entriesRequested": 0, "entriesFound": 0},
+						"index": {"entriesRequested": 0, "entriesFound": 0}
 					}
 				}
 			}
@@ -162,6 +172,7 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
+						"totalIndexPagesRead": 0,
 						"chunk" :{
 							"compressedBytes": 0,
 							"decompressedBytes": 0,
@@ -181,6 +192,7 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
+						"totalIndexPagesRead": 0,
 						"chunk" :{
 							"compressedBytes": 0,
 							"decompressedBytes": 0,
@@ -196,7 +208,14 @@ var queryTests = []struct {
 					"execTime": 0,
 					"linesProcessedPerSecond": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed": 0,
+					"chunkCacheHitRatio": 0,
+					"indexCacheHitRatio": 0,
+					"duplicateRatio": 0
+				},
+				"cache": {
+					"chunk": {"entriesRequested": 0, "entriesFound": 0},
+					"index": {"entriesRequested": 0, "entriesFound": 0}
 				}
 			  }
 			},
@@ -286,6 +305,7 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
+						"totalIndexPagesRead": 0,
 						"chunk" :{
 							"compressedBytes": 0,
 							"decompressedBytes": 0,
@@ -305,6 +325,7 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
+						"totalIndexPagesRead": 0,
 						"chunk" :{
 							"compressedBytes": 0,
 							"decompressedBytes": 0,
@@ -320,7 +341,14 @@ var queryTests = []struct {
 					"execTime": 0,
 					"linesProcessedPerSecond": 0,
 					"totalBytesProcessed":0,
-					"totalLinesProcessed":0
+					"totalLinesProcessed": 0,
+					"chunkCacheHitRatio": 0,
+					"indexCacheHitRatio": 0,
+					"duplicateRatio": 0
+				},
+				"cache": {
+					"chunk": {"entriesRequested": 0, "entriesFound": 0},
+					"index": {"entriesRequested": 0, "entriesFound": 0}
 				}
 			  }
 			},
@@ -404,6 +432,57 @@ func Test_WriteQueryResponseJSON(t *testing.T) {
 	}
 }
 
+func Test_WriteQueryResponseNDJSON(t *testing.T) {
+	streams := logqlmodel.Streams{
+		{
+			Labels:  `{test="a"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1), Line: "line 1"}, {Timestamp: time.Unix(0, 2), Line: "line 2"}},
+		},
+		{
+			Labels:  `{test="b"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(0, 3), Line: "line 3"}},
+		},
+	}
+
+	var b bytes.Buffer
+	err := WriteQueryResponseNDJSON(logqlmodel.Result{Data: streams, Statistics: stats.Result{Summary: stats.Summary{TotalLinesProcessed: 3}}}, &b)
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSuffix(b.Bytes(), []byte("\n")), []byte("\n"))
+	require.Len(t, lines, 3)
+	testJSONBytesEqual(t, []byte(`{"stream":{"test":"a"},"values":[["1","line 1"],["2","line 2"]]}`), lines[0], "first stream")
+	testJSONBytesEqual(t, []byte(`{"stream":{"test":"b"},"values":[["3","line 3"]]}`), lines[1], "second stream")
+
+	var trailer struct {
+		Status     string       `json:"status"`
+		Statistics stats.Result `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal(lines[2], &trailer))
+	require.Equal(t, "success", trailer.Status)
+	require.Equal(t, int64(3), trailer.Statistics.Summary.TotalLinesProcessed)
+
+	// The streams are split in batches of NDJSONBatchSize entries.
+	entries := make([]logproto.Entry, NDJSONBatchSize+1)
+	for i := range entries {
+		entries[i] = logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: "line"}
+	}
+	b.Reset()
+	err = WriteQueryResponseNDJSON(logqlmodel.Result{Data: logqlmodel.Streams{{Labels: `{test="a"}`, Entries: entries}}}, &b)
+	require.NoError(t, err)
+	lines = bytes.Split(bytes.TrimSuffix(b.Bytes(), []byte("\n")), []byte("\n"))
+	require.Len(t, lines, 3)
+	var batches [2]loghttp.Stream
+	require.NoError(t, json.Unmarshal(lines[0], &batches[0]))
+	require.NoError(t, json.Unmarshal(lines[1], &batches[1]))
+	require.Len(t, batches[0].Entries, NDJSONBatchSize)
+	require.Equal(t, []loghttp.Entry{{Timestamp: time.Unix(0, NDJSONBatchSize), Line: "line"}}, batches[1].Entries)
+	require.Equal(t, loghttp.LabelSet{"test": "a"}, batches[1].Labels)
+
+	// Only streams can be streamed.
+	err = WriteQueryResponseNDJSON(logqlmodel.Result{Data: promql.Vector{}}, &b)
+	require.Error(t, err)
+}
+
 func Test_WriteLabelResponseJSON(t *testing.T) {
 	for i, labelTest := range labelTests {
 		var b bytes.Buffer
@@ -0,0 +1,69 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FeatureFlag is a feature which can be rolled out tenant by tenant through the overrides.
+type FeatureFlag string
+
+const (
+	// FeatureQuerySharding shards the queries of the tenant in the query frontend, when sharding is configured.
+	FeatureQuerySharding FeatureFlag = "query_sharding"
+	// FeatureChunkBloomFilters writes the chunks of the tenant with per-block bloom filters, as if
+	// -ingester.chunk-bloom-filters was set.
+	FeatureChunkBloomFilters FeatureFlag = "chunk_bloom_filters"
+	// FeatureEntrySequence requires a sequence number on the entries pushed by the tenant. The ingesters append the
+	// entries of a stream in the order of their sequence numbers and drop the entries whose sequence number was
+	// already accepted.
+	FeatureEntrySequence FeatureFlag = "entry_sequence"
+	// FeatureZstdDictionaries compresses the zstd chunks of the tenant with a dictionary trained from its lines, when
+	// the zstd dictionaries are configured.
+	FeatureZstdDictionaries FeatureFlag = "zstd_dictionaries"
+	// FeatureArchiveQueries reads the data of the tenant past its retention period from the archive, when the
+	// archive is configured.
+	FeatureArchiveQueries FeatureFlag = "archive_queries"
+)
+
+// featureFlagDefaults are the states of the features for the tenants which don't set them, keeping the behaviour
+// Loki had before the features were flagged.
+var featureFlagDefaults = map[FeatureFlag]bool{
+	FeatureQuerySharding:     true,
+	FeatureChunkBloomFilters: false,
+	FeatureEntrySequence:     false,
+	FeatureZstdDictionaries:  false,
+	FeatureArchiveQueries:    false,
+}
+
+// FeatureFlags returns the names of the supported feature flags.
+func FeatureFlags() []string {
+	names := make([]string, 0, len(featureFlagDefaults))
+	for f := range featureFlagDefaults {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateFeatureFlags(flags map[string]bool) error {
+	for name := range flags {
+		if _, ok := featureFlagDefaults[FeatureFlag(name)]; !ok {
+			return fmt.Errorf("unknown feature flag %q, supported flags are %v", name, FeatureFlags())
+		}
+	}
+	return nil
+}
+
+// DefaultFeatureEnabled returns whether a feature is enabled for the tenants which don't set its flag.
+func DefaultFeatureEnabled(feature FeatureFlag) bool {
+	return featureFlagDefaults[feature]
+}
+
+// FeatureEnabled returns whether a feature is enabled for a given user.
+func (o *Overrides) FeatureEnabled(userID string, feature FeatureFlag) bool {
+	if enabled, ok := o.getOverridesForUser(userID).FeatureFlags[string(feature)]; ok {
+		return enabled
+	}
+	return DefaultFeatureEnabled(feature)
+}
@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"regexp"
 	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
 	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v2"
 
@@ -25,6 +28,12 @@ const (
 	// Global ingestion rate strategy
 	GlobalIngestionRateStrategy = "global"
 
+	// DropStreamRateLimitPolicy drops the entries of a stream exceeding its distributor rate limit.
+	DropStreamRateLimitPolicy = "drop"
+
+	// RejectStreamRateLimitPolicy rejects pushes with a stream exceeding its distributor rate limit.
+	RejectStreamRateLimitPolicy = "reject"
+
 	bytesInMB = 1048576
 
 	defaultPerStreamRateLimit  = 3 << 20 // 3MB
@@ -50,25 +59,55 @@ type Limits struct {
 	MaxLineSize            flagext.ByteSize `yaml:"max_line_size" json:"max_line_size"`
 	MaxLineSizeTruncate    bool             `yaml:"max_line_size_truncate" json:"max_line_size_truncate"`
 
+	HashLabelValuesLongerThan    int `yaml:"hash_label_values_longer_than" json:"hash_label_values_longer_than"`
+	HashedLabelValuePrefixLength int `yaml:"hashed_label_value_prefix_length" json:"hashed_label_value_prefix_length"`
+
+	DebugTenantID    string  `yaml:"debug_tenant_id" json:"debug_tenant_id"`
+	DebugSampleRatio float64 `yaml:"debug_sample_ratio" json:"debug_sample_ratio"`
+
+	DeadLetterTenantID string `yaml:"dead_letter_tenant_id" json:"dead_letter_tenant_id"`
+
+	IngestionMonthlyBytesCap flagext.ByteSize `yaml:"ingestion_monthly_bytes_cap" json:"ingestion_monthly_bytes_cap"`
+	IngestionMonthlyLinesCap int              `yaml:"ingestion_monthly_lines_cap" json:"ingestion_monthly_lines_cap"`
+
+	DistributorStreamRateLimit       flagext.ByteSize `yaml:"distributor_stream_rate_limit" json:"distributor_stream_rate_limit"`
+	DistributorStreamRateLimitBurst  flagext.ByteSize `yaml:"distributor_stream_rate_limit_burst" json:"distributor_stream_rate_limit_burst"`
+	DistributorStreamRateLimitPolicy string           `yaml:"distributor_stream_rate_limit_policy" json:"distributor_stream_rate_limit_policy"`
+
 	// Ingester enforced limits.
 	MaxLocalStreamsPerUser  int              `yaml:"max_streams_per_user" json:"max_streams_per_user"`
 	MaxGlobalStreamsPerUser int              `yaml:"max_global_streams_per_user" json:"max_global_streams_per_user"`
 	UnorderedWrites         bool             `yaml:"unordered_writes" json:"unordered_writes"`
+	MaxOutOfOrderTimeWindow model.Duration   `yaml:"max_out_of_order_time_window" json:"max_out_of_order_time_window"`
 	PerStreamRateLimit      flagext.ByteSize `yaml:"per_stream_rate_limit" json:"per_stream_rate_limit"`
 	PerStreamRateLimitBurst flagext.ByteSize `yaml:"per_stream_rate_limit_burst" json:"per_stream_rate_limit_burst"`
+	MaxWALBytesPerUser      flagext.ByteSize `yaml:"max_wal_bytes_per_user" json:"max_wal_bytes_per_user"`
 
 	// Querier enforced limits.
-	MaxChunksPerQuery          int            `yaml:"max_chunks_per_query" json:"max_chunks_per_query"`
-	MaxQuerySeries             int            `yaml:"max_query_series" json:"max_query_series"`
-	MaxQueryLookback           model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
-	MaxQueryLength             model.Duration `yaml:"max_query_length" json:"max_query_length"`
-	MaxQueryParallelism        int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
-	CardinalityLimit           int            `yaml:"cardinality_limit" json:"cardinality_limit"`
-	MaxStreamsMatchersPerQuery int            `yaml:"max_streams_matchers_per_query" json:"max_streams_matchers_per_query"`
-	MaxConcurrentTailRequests  int            `yaml:"max_concurrent_tail_requests" json:"max_concurrent_tail_requests"`
-	MaxEntriesLimitPerQuery    int            `yaml:"max_entries_limit_per_query" json:"max_entries_limit_per_query"`
-	MaxCacheFreshness          model.Duration `yaml:"max_cache_freshness_per_query" json:"max_cache_freshness_per_query"`
-	MaxQueriersPerTenant       int            `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
+	MaxChunksPerQuery            int            `yaml:"max_chunks_per_query" json:"max_chunks_per_query"`
+	MaxFetchedChunkBytesPerQuery int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
+	MaxQueryExecutionTime        model.Duration `yaml:"max_query_execution_time" json:"max_query_execution_time"`
+	MaxQuerySeries               int            `yaml:"max_query_series" json:"max_query_series"`
+	MaxQueryLookback             model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
+	MaxQueryLength               model.Duration `yaml:"max_query_length" json:"max_query_length"`
+	MaxQueryParallelism          int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
+	CardinalityLimit             int            `yaml:"cardinality_limit" json:"cardinality_limit"`
+	MaxStreamsMatchersPerQuery   int            `yaml:"max_streams_matchers_per_query" json:"max_streams_matchers_per_query"`
+	MaxConcurrentTailRequests    int            `yaml:"max_concurrent_tail_requests" json:"max_concurrent_tail_requests"`
+	MaxEntriesLimitPerQuery      int            `yaml:"max_entries_limit_per_query" json:"max_entries_limit_per_query"`
+	MaxCacheFreshness            model.Duration `yaml:"max_cache_freshness_per_query" json:"max_cache_freshness_per_query"`
+	CacheLogResults              bool           `yaml:"cache_log_results" json:"cache_log_results"`
+	MaxQueriersPerTenant         int            `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
+	QueryPriorityWeightHigh      int            `yaml:"query_priority_weight_high" json:"query_priority_weight_high"`
+	QueryPriorityWeightNormal    int            `yaml:"query_priority_weight_normal" json:"query_priority_weight_normal"`
+	QueryPriorityWeightLow       int            `yaml:"query_priority_weight_low" json:"query_priority_weight_low"`
+
+	// Limits of the queries of the archive, for the tenants enabling the archive_queries feature flag.
+	ArchiveMaxQueryLength    model.Duration `yaml:"archive_max_query_length" json:"archive_max_query_length"`
+	ArchiveMaxChunksPerQuery int            `yaml:"archive_max_chunks_per_query" json:"archive_max_chunks_per_query"`
+
+	// Tenants whose data can be queried along with the data of the tenant by multi-tenant queries.
+	FederatedTenants []string `yaml:"federated_tenants,omitempty" json:"federated_tenants,omitempty"`
 
 	// Query frontend enforced limits. The default is actually parameterized by the queryrange config.
 	QuerySplitDuration  model.Duration `yaml:"split_queries_by_interval" json:"split_queries_by_interval"`
@@ -97,11 +136,22 @@ type Limits struct {
 	RulerRemoteWriteQueueMinBackoff        time.Duration                `yaml:"ruler_remote_write_queue_min_backoff" json:"ruler_remote_write_queue_min_backoff"`
 	RulerRemoteWriteQueueMaxBackoff        time.Duration                `yaml:"ruler_remote_write_queue_max_backoff" json:"ruler_remote_write_queue_max_backoff"`
 	RulerRemoteWriteQueueRetryOnRateLimit  bool                         `yaml:"ruler_remote_write_queue_retry_on_ratelimit" json:"ruler_remote_write_queue_retry_on_ratelimit"`
+	RulerRemoteWriteSigV4Config            *sigv4.SigV4Config           `yaml:"ruler_remote_write_sigv4_config" json:"ruler_remote_write_sigv4_config"`
 
 	// Global and per tenant retention
 	RetentionPeriod model.Duration    `yaml:"retention_period" json:"retention_period"`
 	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty"`
 
+	// Ingest time exclusion filters
+	DropRules []DropRule `yaml:"drop_rules,omitempty" json:"drop_rules,omitempty"`
+
+	// Ingest time relabeling of the stream labels
+	StreamRelabelConfigs []*util.RelabelConfig `yaml:"stream_relabel_configs,omitempty" json:"stream_relabel_configs,omitempty"`
+	StreamRelabeling     []*relabel.Config     `yaml:"-" json:"-"` // populated during validation.
+
+	// Per tenant rollout of features, by feature name.
+	FeatureFlags map[string]bool `yaml:"feature_flags,omitempty" json:"feature_flags,omitempty"`
+
 	// Config for overrides, convenient if it goes here.
 	PerTenantOverrideConfig string         `yaml:"per_tenant_override_config" json:"per_tenant_override_config"`
 	PerTenantOverridePeriod model.Duration `yaml:"per_tenant_override_period" json:"per_tenant_override_period"`
@@ -114,6 +164,16 @@ type StreamRetention struct {
 	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
 }
 
+// DropRule drops log lines of the streams matching the selector at ingestion time.
+// If LineRegex is set only matching lines are dropped.
+type DropRule struct {
+	Name      string            `yaml:"name" json:"name"`
+	Selector  string            `yaml:"selector" json:"selector"`
+	LineRegex string            `yaml:"line_regex,omitempty" json:"line_regex,omitempty"`
+	Matchers  []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+	Regex     *regexp.Regexp    `yaml:"-" json:"-"` // populated during validation.
+}
+
 // RegisterFlags adds the flags required to config this to the given FlagSet
 func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&l.IngestionRateStrategy, "distributor.ingestion-rate-limit-strategy", "global", "Whether the ingestion rate limit should be applied individually to each distributor instance (local), or evenly shared across the cluster (global).")
@@ -121,6 +181,16 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Float64Var(&l.IngestionBurstSizeMB, "distributor.ingestion-burst-size-mb", 6, "Per-user allowed ingestion burst size (in sample size). Units in MB.")
 	f.Var(&l.MaxLineSize, "distributor.max-line-size", "maximum line length allowed, i.e. 100mb. Default (0) means unlimited.")
 	f.BoolVar(&l.MaxLineSizeTruncate, "distributor.max-line-size-truncate", false, "Whether to truncate lines that exceed max_line_size")
+	f.IntVar(&l.HashLabelValuesLongerThan, "distributor.hash-label-values-longer-than", 0, "Label values longer than this are replaced by a prefix of the value followed by a dash and the 16 hex digits of its hash. Must be at least the prefix length plus 17. 0 to disable.")
+	f.IntVar(&l.HashedLabelValuePrefixLength, "distributor.hashed-label-value-prefix-length", 16, "Number of characters of a hashed label value kept in front of the hash. Values are truncated on character boundaries.")
+	f.StringVar(&l.DebugTenantID, "distributor.debug-tenant-id", "", "Tenant receiving a copy of the sampled streams of this tenant.")
+	f.Float64Var(&l.DebugSampleRatio, "distributor.debug-sample-ratio", 0, "Ratio of this tenant streams copied to the debug tenant, between 0 and 1. 0 to disable.")
+	f.StringVar(&l.DeadLetterTenantID, "distributor.dead-letter-tenant-id", "", "Tenant receiving the entries of this tenant rejected by the distributors, labeled with the tenant and the reason of the rejection, instead of dropping them. Empty to disable.")
+	f.Var(&l.IngestionMonthlyBytesCap, "distributor.ingestion-monthly-bytes-cap", "Maximum number of bytes ingested by a tenant during a calendar month (UTC), pushes are rejected once it is exceeded. Requires the distributor usage tracker. 0 to disable.")
+	f.IntVar(&l.IngestionMonthlyLinesCap, "distributor.ingestion-monthly-lines-cap", 0, "Maximum number of lines ingested by a tenant during a calendar month (UTC), pushes are rejected once it is exceeded. Requires the distributor usage tracker. 0 to disable.")
+	f.Var(&l.DistributorStreamRateLimit, "distributor.stream-rate-limit", "Maximum byte rate per second per stream enforced by the distributors, shared across them like the ingestion rate limit when the global strategy is used. 0 to disable.")
+	f.Var(&l.DistributorStreamRateLimitBurst, "distributor.stream-rate-limit-burst", "Maximum burst bytes per stream enforced by the distributors. 0 to use the stream rate limit.")
+	f.StringVar(&l.DistributorStreamRateLimitPolicy, "distributor.stream-rate-limit-policy", DropStreamRateLimitPolicy, "What to do with a stream exceeding its distributor rate limit: drop its entries beyond the limit (drop) or reject the push with a 429 (reject).")
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, "validation.max-length-label-value", 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
 	f.IntVar(&l.MaxLabelNamesPerSeries, "validation.max-label-names-per-series", 30, "Maximum number of label names per series.")
@@ -136,13 +206,18 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxLocalStreamsPerUser, "ingester.max-streams-per-user", 0, "Maximum number of active streams per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxGlobalStreamsPerUser, "ingester.max-global-streams-per-user", 5000, "Maximum number of active streams per user, across the cluster. 0 to disable.")
 	f.BoolVar(&l.UnorderedWrites, "ingester.unordered-writes", true, "Allow out of order writes.")
+	f.Var(&l.MaxOutOfOrderTimeWindow, "ingester.max-out-of-order-time-window", "How far behind the most recent entry of a stream out of order entries are accepted when out of order writes are allowed. 0 to use half of the ingester max chunk age.")
 
 	_ = l.PerStreamRateLimit.Set(strconv.Itoa(defaultPerStreamRateLimit))
 	f.Var(&l.PerStreamRateLimit, "ingester.per-stream-rate-limit", "Maximum byte rate per second per stream, also expressible in human readable forms (1MB, 256KB, etc).")
 	_ = l.PerStreamRateLimitBurst.Set(strconv.Itoa(defaultPerStreamBurstLimit))
 	f.Var(&l.PerStreamRateLimitBurst, "ingester.per-stream-rate-limit-burst", "Maximum burst bytes per stream, also expressible in human readable forms (1MB, 256KB, etc).")
+	f.Var(&l.MaxWALBytesPerUser, "ingester.max-wal-bytes-per-user", "Maximum bytes of WAL records per user, per ingester, in the WAL segments not yet truncated by a checkpoint. Pushes are rejected once it is reached. 0 to disable.")
 
 	f.IntVar(&l.MaxChunksPerQuery, "store.query-chunk-limit", 2e6, "Maximum number of chunks that can be fetched in a single query.")
+	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, "querier.max-fetched-chunk-bytes-per-query", 0, "Maximum size in bytes of the chunks that can be fetched from the store by a single query. 0 to disable.")
+	_ = l.MaxQueryExecutionTime.Set("0s")
+	f.Var(&l.MaxQueryExecutionTime, "querier.max-query-execution-time", "Maximum time a single query can run in a querier, lower than the querier query timeout. 0 to use the querier query timeout.")
 
 	_ = l.MaxQueryLength.Set("721h")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "Limit to length of chunk store queries, 0 to disable.")
@@ -160,8 +235,16 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
+	f.BoolVar(&l.CacheLogResults, "frontend.cache-log-results", false, "Cache results of backward log queries whose time range ended more than the max chunk age ago, when results caching is enabled.")
 
 	f.IntVar(&l.MaxQueriersPerTenant, "frontend.max-queriers-per-tenant", 0, "Maximum number of queriers that can handle requests for a single tenant. If set to 0 or value higher than number of available queriers, *all* queriers will handle requests for the tenant. Each frontend (or query-scheduler, if used) will select the same set of queriers for the same tenant (given that all queriers are connected to all frontends / query-schedulers). This option only works with queriers connecting to the query-frontend / query-scheduler, not when using downstream URL.")
+	f.IntVar(&l.QueryPriorityWeightHigh, "query-scheduler.priority-weight-high", 8, "Weight of the high priority queries of a tenant in the query scheduler. Queries of a tenant are dequeued from its priority classes proportionally to their weights.")
+	f.IntVar(&l.QueryPriorityWeightNormal, "query-scheduler.priority-weight-normal", 4, "Weight of the normal priority queries of a tenant in the query scheduler.")
+	f.IntVar(&l.QueryPriorityWeightLow, "query-scheduler.priority-weight-low", 1, "Weight of the low priority queries of a tenant in the query scheduler.")
+
+	_ = l.ArchiveMaxQueryLength.Set("0s")
+	f.Var(&l.ArchiveMaxQueryLength, "querier.archive-max-query-length", "Limit to the length of the part of a query past the retention period, read from the archive. 0 to disable.")
+	f.IntVar(&l.ArchiveMaxChunksPerQuery, "querier.archive-max-chunks-per-query", 1e5, "Maximum number of chunks that can be fetched from the archive by a single query. 0 to disable.")
 
 	_ = l.RulerEvaluationDelay.Set("0s")
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed to Cortex.")
@@ -212,6 +295,55 @@ func (l *Limits) Validate() error {
 			l.StreamRetention[i].Matchers = matchers
 		}
 	}
+	switch l.DistributorStreamRateLimitPolicy {
+	case "", DropStreamRateLimitPolicy, RejectStreamRateLimitPolicy:
+	default:
+		return fmt.Errorf("invalid distributor stream rate limit policy %q, must be %s or %s", l.DistributorStreamRateLimitPolicy, DropStreamRateLimitPolicy, RejectStreamRateLimitPolicy)
+	}
+	if l.DebugSampleRatio < 0 || l.DebugSampleRatio > 1 {
+		return fmt.Errorf("debug sample ratio must be between 0 and 1 was %v", l.DebugSampleRatio)
+	}
+	if l.HashedLabelValuePrefixLength < 0 {
+		return fmt.Errorf("hashed label value prefix length must be >= 0 was %d", l.HashedLabelValuePrefixLength)
+	}
+	// hashed values are the prefix followed by a dash and the 16 hex digits of the hash, they must not be longer than the threshold.
+	if l.HashLabelValuesLongerThan > 0 && l.HashedLabelValuePrefixLength+17 > l.HashLabelValuesLongerThan {
+		return fmt.Errorf("hashed label value prefix length plus 17 must be <= hash label values longer than, was %d with a threshold of %d", l.HashedLabelValuePrefixLength, l.HashLabelValuesLongerThan)
+	}
+	for i, rule := range l.DropRules {
+		if rule.Name == "" {
+			return fmt.Errorf("drop rule with selector %s must have a name", rule.Selector)
+		}
+		matchers, err := logql.ParseMatchers(rule.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid labels matchers for drop rule %s: %w", rule.Name, err)
+		}
+		// populate matchers and regex during validation
+		l.DropRules[i].Matchers = matchers
+		if rule.LineRegex != "" {
+			re, err := regexp.Compile(rule.LineRegex)
+			if err != nil {
+				return fmt.Errorf("invalid line regex for drop rule %s: %w", rule.Name, err)
+			}
+			l.DropRules[i].Regex = re
+		}
+	}
+	if err := validateFeatureFlags(l.FeatureFlags); err != nil {
+		return err
+	}
+	l.StreamRelabeling = nil
+	for _, config := range l.StreamRelabelConfigs {
+		// Round trip through YAML to apply the defaults and validation of the Prometheus relabel configs.
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			return err
+		}
+		var rc relabel.Config
+		if err := yaml.UnmarshalStrict(out, &rc); err != nil {
+			return fmt.Errorf("invalid stream relabel config: %w", err)
+		}
+		l.StreamRelabeling = append(l.StreamRelabeling, &rc)
+	}
 	return nil
 }
 
@@ -287,6 +419,41 @@ func (o *Overrides) MaxLabelValueLength(userID string) int {
 	return o.getOverridesForUser(userID).MaxLabelValueLength
 }
 
+// HashLabelValuesLongerThan returns the length above which label values are hashed.
+func (o *Overrides) HashLabelValuesLongerThan(userID string) int {
+	return o.getOverridesForUser(userID).HashLabelValuesLongerThan
+}
+
+// HashedLabelValuePrefixLength returns the length of the prefix kept when hashing label values.
+func (o *Overrides) HashedLabelValuePrefixLength(userID string) int {
+	return o.getOverridesForUser(userID).HashedLabelValuePrefixLength
+}
+
+// DebugTenantID returns the tenant receiving a copy of the sampled streams.
+func (o *Overrides) DebugTenantID(userID string) string {
+	return o.getOverridesForUser(userID).DebugTenantID
+}
+
+// DebugSampleRatio returns the ratio of streams copied to the debug tenant.
+func (o *Overrides) DebugSampleRatio(userID string) float64 {
+	return o.getOverridesForUser(userID).DebugSampleRatio
+}
+
+// DeadLetterTenantID returns the tenant receiving the rejected entries.
+func (o *Overrides) DeadLetterTenantID(userID string) string {
+	return o.getOverridesForUser(userID).DeadLetterTenantID
+}
+
+// IngestionMonthlyBytesCap returns the maximum number of bytes ingested during a calendar month.
+func (o *Overrides) IngestionMonthlyBytesCap(userID string) int {
+	return o.getOverridesForUser(userID).IngestionMonthlyBytesCap.Val()
+}
+
+// IngestionMonthlyLinesCap returns the maximum number of lines ingested during a calendar month.
+func (o *Overrides) IngestionMonthlyLinesCap(userID string) int {
+	return o.getOverridesForUser(userID).IngestionMonthlyLinesCap
+}
+
 // MaxLabelNamesPerSeries returns maximum number of label/value pairs timeseries.
 func (o *Overrides) MaxLabelNamesPerSeries(userID string) int {
 	return o.getOverridesForUser(userID).MaxLabelNamesPerSeries
@@ -321,6 +488,12 @@ func (o *Overrides) MaxGlobalStreamsPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalStreamsPerUser
 }
 
+// MaxWALBytesPerUser returns the maximum bytes of WAL records a user is allowed in the WAL segments of
+// a single ingester which were not truncated by a checkpoint yet.
+func (o *Overrides) MaxWALBytesPerUser(userID string) int {
+	return o.getOverridesForUser(userID).MaxWALBytesPerUser.Val()
+}
+
 // MaxChunksPerQuery returns the maximum number of chunks allowed per query.
 func (o *Overrides) MaxChunksPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxChunksPerQuery
@@ -340,11 +513,42 @@ func (o *Overrides) MaxQuerySeries(userID string) int {
 	return o.getOverridesForUser(userID).MaxQuerySeries
 }
 
+// MaxFetchedChunkBytesPerQuery returns the maximum size of the chunks fetched from the store by a single query.
+func (o *Overrides) MaxFetchedChunkBytesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQuery
+}
+
+// MaxQueryExecutionTime returns the maximum time a single query can run in a querier.
+func (o *Overrides) MaxQueryExecutionTime(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MaxQueryExecutionTime)
+}
+
 // MaxQueriersPerUser returns the maximum number of queriers that can handle requests for this user.
 func (o *Overrides) MaxQueriersPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxQueriersPerTenant
 }
 
+// QueryPriorityWeightHigh returns the weight of the high priority queries of this user in the query scheduler.
+func (o *Overrides) QueryPriorityWeightHigh(userID string) int {
+	return o.getOverridesForUser(userID).QueryPriorityWeightHigh
+}
+
+// QueryPriorityWeightNormal returns the weight of the normal priority queries of this user in the query scheduler.
+func (o *Overrides) QueryPriorityWeightNormal(userID string) int {
+	return o.getOverridesForUser(userID).QueryPriorityWeightNormal
+}
+
+// QueryPriorityWeightLow returns the weight of the low priority queries of this user in the query scheduler.
+func (o *Overrides) QueryPriorityWeightLow(userID string) int {
+	return o.getOverridesForUser(userID).QueryPriorityWeightLow
+}
+
+// FederatedTenants returns the tenants whose data can be queried along with the data of the user by
+// multi-tenant queries, "*" allowing any tenant.
+func (o *Overrides) FederatedTenants(userID string) []string {
+	return o.getOverridesForUser(userID).FederatedTenants
+}
+
 // MaxQueryParallelism returns the limit to the number of sub-queries the
 // frontend will process in parallel.
 func (o *Overrides) MaxQueryParallelism(userID string) int {
@@ -400,11 +604,26 @@ func (o *Overrides) MaxCacheFreshness(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxCacheFreshness)
 }
 
+// CacheLogResults returns whether results of log queries should be cached for the tenant.
+func (o *Overrides) CacheLogResults(userID string) bool {
+	return o.getOverridesForUser(userID).CacheLogResults
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryLookback)
 }
 
+// ArchiveMaxQueryLength returns the limit of the length of the part of a query read from the archive.
+func (o *Overrides) ArchiveMaxQueryLength(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ArchiveMaxQueryLength)
+}
+
+// ArchiveMaxChunksPerQuery returns the maximum number of chunks fetched from the archive by a query.
+func (o *Overrides) ArchiveMaxChunksPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).ArchiveMaxChunksPerQuery
+}
+
 // EvaluationDelay returns the rules evaluation delay for a given user.
 func (o *Overrides) EvaluationDelay(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).RulerEvaluationDelay)
@@ -491,6 +710,11 @@ func (o *Overrides) RulerRemoteWriteQueueRetryOnRateLimit(userID string) bool {
 	return o.getOverridesForUser(userID).RulerRemoteWriteQueueRetryOnRateLimit
 }
 
+// RulerRemoteWriteSigV4Config returns the AWS Signature Version 4 config to use in a remote-write for a given user.
+func (o *Overrides) RulerRemoteWriteSigV4Config(userID string) *sigv4.SigV4Config {
+	return o.getOverridesForUser(userID).RulerRemoteWriteSigV4Config
+}
+
 // RetentionPeriod returns the retention period for a given user.
 func (o *Overrides) RetentionPeriod(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).RetentionPeriod)
@@ -501,10 +725,25 @@ func (o *Overrides) StreamRetention(userID string) []StreamRetention {
 	return o.getOverridesForUser(userID).StreamRetention
 }
 
+// DropRules returns the ingest time drop rules for a given user.
+func (o *Overrides) DropRules(userID string) []DropRule {
+	return o.getOverridesForUser(userID).DropRules
+}
+
+// StreamRelabelConfigs returns the relabel configs applied to the labels of the streams pushed by a given user.
+func (o *Overrides) StreamRelabelConfigs(userID string) []*relabel.Config {
+	return o.getOverridesForUser(userID).StreamRelabeling
+}
+
 func (o *Overrides) UnorderedWrites(userID string) bool {
 	return o.getOverridesForUser(userID).UnorderedWrites
 }
 
+// MaxOutOfOrderTimeWindow returns how far behind the most recent entry of a stream out of order entries are accepted.
+func (o *Overrides) MaxOutOfOrderTimeWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MaxOutOfOrderTimeWindow)
+}
+
 func (o *Overrides) DefaultLimits() *Limits {
 	return o.defaultLimits
 }
@@ -518,6 +757,25 @@ func (o *Overrides) PerStreamRateLimit(userID string) RateLimit {
 	}
 }
 
+// DistributorStreamRateLimit returns the per stream rate limit enforced by the distributors, the limit is 0 when disabled.
+func (o *Overrides) DistributorStreamRateLimit(userID string) RateLimit {
+	user := o.getOverridesForUser(userID)
+
+	burst := user.DistributorStreamRateLimitBurst.Val()
+	if burst <= 0 {
+		burst = user.DistributorStreamRateLimit.Val()
+	}
+	return RateLimit{
+		Limit: rate.Limit(float64(user.DistributorStreamRateLimit.Val())),
+		Burst: burst,
+	}
+}
+
+// DistributorStreamRateLimitPolicy returns whether the distributors drop the entries of a stream exceeding its rate limit or reject the push.
+func (o *Overrides) DistributorStreamRateLimitPolicy(userID string) string {
+	return o.getOverridesForUser(userID).DistributorStreamRateLimitPolicy
+}
+
 func (o *Overrides) getOverridesForUser(userID string) *Limits {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits.TenantLimits(userID)
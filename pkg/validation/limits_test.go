@@ -7,9 +7,12 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/pkg/ruler/util"
 )
 
 func TestLimitsTagsYamlMatchJson(t *testing.T) {
@@ -239,3 +242,80 @@ reject_old_samples: true
 		})
 	}
 }
+
+func TestLimitsValidateDropRules(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		rule  DropRule
+		valid bool
+	}{
+		{"valid", DropRule{Name: "health", Selector: `{app="nginx"}`, LineRegex: `GET /healthz`}, true},
+		{"valid without regex", DropRule{Name: "debug", Selector: `{level="debug"}`}, true},
+		{"missing name", DropRule{Selector: `{app="nginx"}`}, false},
+		{"invalid selector", DropRule{Name: "health", Selector: `{app=}`}, false},
+		{"invalid regex", DropRule{Name: "health", Selector: `{app="nginx"}`, LineRegex: `(`}, false},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			l := Limits{DropRules: []DropRule{tc.rule}}
+			err := l.Validate()
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, l.DropRules[0].Matchers)
+			require.Equal(t, tc.rule.LineRegex != "", l.DropRules[0].Regex != nil)
+		})
+	}
+}
+
+func TestLimitsValidateStreamRelabelConfigs(t *testing.T) {
+	l := Limits{StreamRelabelConfigs: []*util.RelabelConfig{{SourceLabels: []string{"pod"}, TargetLabel: "instance"}}}
+	require.NoError(t, l.Validate())
+	require.Len(t, l.StreamRelabeling, 1)
+	require.Equal(t, relabel.Replace, l.StreamRelabeling[0].Action)
+
+	l = Limits{StreamRelabelConfigs: []*util.RelabelConfig{{Action: "unknown"}}}
+	require.Error(t, l.Validate())
+}
+
+func TestLimitsValidateFeatureFlags(t *testing.T) {
+	l := Limits{FeatureFlags: map[string]bool{string(FeatureQuerySharding): false}}
+	require.NoError(t, l.Validate())
+
+	l = Limits{FeatureFlags: map[string]bool{"unknown": true}}
+	require.Error(t, l.Validate())
+}
+
+func TestLimitsValidateHashedLabelValuePrefixLength(t *testing.T) {
+	l := Limits{HashedLabelValuePrefixLength: 0}
+	require.NoError(t, l.Validate())
+
+	l = Limits{HashedLabelValuePrefixLength: -1}
+	require.Error(t, l.Validate())
+
+	l = Limits{HashLabelValuesLongerThan: 33, HashedLabelValuePrefixLength: 16}
+	require.NoError(t, l.Validate())
+
+	// hashed values would be longer than the threshold.
+	l = Limits{HashLabelValuesLongerThan: 32, HashedLabelValuePrefixLength: 16}
+	require.Error(t, l.Validate())
+}
+
+func TestOverridesFeatureEnabled(t *testing.T) {
+	defaults := Limits{FeatureFlags: map[string]bool{string(FeatureChunkBloomFilters): true}}
+	overrides, err := NewOverrides(defaults, newMockTenantLimits(map[string]*Limits{
+		"disabled": {FeatureFlags: map[string]bool{string(FeatureQuerySharding): false, string(FeatureChunkBloomFilters): false}},
+	}))
+	require.NoError(t, err)
+
+	require.True(t, overrides.FeatureEnabled("other", FeatureQuerySharding))
+	require.True(t, overrides.FeatureEnabled("other", FeatureChunkBloomFilters))
+	require.False(t, overrides.FeatureEnabled("disabled", FeatureQuerySharding))
+	require.False(t, overrides.FeatureEnabled("disabled", FeatureChunkBloomFilters))
+
+	overrides, err = NewOverrides(Limits{}, nil)
+	require.NoError(t, err)
+	require.True(t, overrides.FeatureEnabled("other", FeatureQuerySharding))
+	require.False(t, overrides.FeatureEnabled("other", FeatureChunkBloomFilters))
+}
@@ -20,6 +20,9 @@ const (
 	// Declared here to avoid duplication in ingester and distributor.
 	RateLimited         = "rate_limited"
 	RateLimitedErrorMsg = "Ingestion rate limit exceeded for user %s (limit: %d bytes/sec) while attempting to ingest '%d' lines totaling '%d' bytes, reduce log volume or contact your Loki administrator to see if the limit can be increased"
+	// MonthlyCapExceeded is a reason for discarding lines when the tenant exceeded its monthly ingestion caps.
+	MonthlyCapExceeded         = "monthly_cap_exceeded"
+	MonthlyCapExceededErrorMsg = "Monthly ingestion cap exceeded for user %s (usage: %d bytes, %d lines; caps: %d bytes, %d lines) while attempting to ingest '%d' lines totaling '%d' bytes, contact your Loki administrator to see if the caps can be increased"
 	// LineTooLong is a reason for discarding too long log lines.
 	LineTooLong         = "line_too_long"
 	LineTooLongErrorMsg = "Max entry size '%d' bytes exceeded for stream '%s' while adding an entry with length '%d' bytes"
@@ -27,6 +30,9 @@ const (
 	// because the limit of active streams has been reached.
 	StreamLimit         = "stream_limit"
 	StreamLimitErrorMsg = "Maximum active stream limit exceeded, reduce the number of active streams (reduce labels or reduce label values), or contact your Loki administrator to see if the limit can be increased"
+	// WALLimit is a reason for discarding lines when the tenant has too many bytes in the WAL of an ingester.
+	WALLimit         = "wal_limit"
+	WALLimitErrorMsg = "Maximum WAL bytes per user exceeded for user %s (limit: %d bytes, WAL: %d bytes), the WAL is truncated at the next checkpoint, reduce log volume or contact your Loki administrator to see if the limit can be increased"
 	// StreamRateLimit is a reason for discarding lines when the streams own rate limit is hit
 	// rather than the overall ingestion rate limit.
 	StreamRateLimit = "per_stream_rate_limit"
@@ -50,6 +56,12 @@ const (
 	// DuplicateLabelNames is a reason for discarding a log line which has duplicate label names
 	DuplicateLabelNames         = "duplicate_label_names"
 	DuplicateLabelNamesErrorMsg = "stream '%s' has duplicate label name: '%s'"
+	// MissingSequence is a reason for discarding a log line without sequence number, when the tenant enforces them.
+	MissingSequence         = "missing_sequence"
+	MissingSequenceErrorMsg = "entry for stream '%s' has no sequence number, sequence numbers are required for this tenant"
+	// DuplicateSequence is a reason for discarding a log line whose sequence number was already accepted in its stream,
+	// usually pushed again by the retry of a push.
+	DuplicateSequence = "duplicate_sequence"
 )
 
 type ErrStreamRateLimit struct {